@@ -1,120 +0,0 @@
-package middleware
-
-import (
-	"net/http"
-	"net/http/httptest"
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-)
-
-func TestCORS_SetsHeaders(t *testing.T) {
-	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	rec := httptest.NewRecorder()
-
-	CORS(inner).ServeHTTP(rec, req)
-
-	assert.Equal(t, http.StatusOK, rec.Code)
-	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
-	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "GET")
-	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "POST")
-	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Content-Type")
-	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Authorization")
-	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "X-API-Key")
-}
-
-func TestCORS_OptionsPreflight_Returns204(t *testing.T) {
-	called := false
-	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		called = true
-		w.WriteHeader(http.StatusOK)
-	})
-
-	req := httptest.NewRequest(http.MethodOptions, "/test", nil)
-	rec := httptest.NewRecorder()
-
-	CORS(inner).ServeHTTP(rec, req)
-
-	assert.Equal(t, http.StatusNoContent, rec.Code)
-	assert.False(t, called, "inner handler should not be called for OPTIONS")
-	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
-}
-
-func TestLogging_CallsInnerHandler(t *testing.T) {
-	called := false
-	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		called = true
-		w.WriteHeader(http.StatusCreated)
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	rec := httptest.NewRecorder()
-
-	Logging(inner).ServeHTTP(rec, req)
-
-	assert.True(t, called, "inner handler should be called")
-	assert.Equal(t, http.StatusCreated, rec.Code)
-}
-
-func TestLogging_CapturesStatusCode(t *testing.T) {
-	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusNotFound)
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
-	rec := httptest.NewRecorder()
-
-	// Logging should not alter the status code written by the handler.
-	Logging(inner).ServeHTTP(rec, req)
-
-	assert.Equal(t, http.StatusNotFound, rec.Code)
-}
-
-func TestRecovery_CatchesPanic(t *testing.T) {
-	inner := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
-		panic("test panic")
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
-	rec := httptest.NewRecorder()
-
-	Recovery(inner).ServeHTTP(rec, req)
-
-	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	assert.Contains(t, rec.Body.String(), "internal server error")
-}
-
-func TestRecovery_NoPanic_PassesThrough(t *testing.T) {
-	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/safe", nil)
-	rec := httptest.NewRecorder()
-
-	Recovery(inner).ServeHTTP(rec, req)
-
-	assert.Equal(t, http.StatusOK, rec.Code)
-	assert.Equal(t, "ok", rec.Body.String())
-}
-
-func TestMiddlewareChain_CORSThenLoggingThenRecovery(t *testing.T) {
-	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	handler := CORS(Logging(Recovery(inner)))
-
-	req := httptest.NewRequest(http.MethodGet, "/chain", nil)
-	rec := httptest.NewRecorder()
-
-	handler.ServeHTTP(rec, req)
-
-	assert.Equal(t, http.StatusOK, rec.Code)
-	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
-}
@@ -1,57 +1,54 @@
+// Command api is the standalone glens API: glens serve configured from the
+// environment, as containers are, listening on every interface and logging
+// JSON.
 package main
 
 import (
-	"fmt"
-	"net/http"
 	"os"
-	"time"
+	"strings"
 
-	"github.com/rs/zerolog/log"
-	"glens/pkg/logging"
-	"glens/tools/api/internal/handler"
-	"glens/tools/api/internal/middleware"
+	glenscmd "glens/tools/glens/cmd"
 )
 
 // version is set at build time via -ldflags="-X main.version=<tag>".
 var version = "dev"
 
 func main() {
-	level := logging.LevelInfo
-	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
-		level = logging.Level(envLevel)
-	}
-
-	logging.Setup(logging.Config{
-		Level:  level,
-		Format: logging.FormatJSON,
-	})
-
-	mux := http.NewServeMux()
-	registerRoutes(mux)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	glenscmd.ExecuteServe(version, serveArgs(os.Getenv))
+}
 
-	wrapped := middleware.Recovery(middleware.Logging(middleware.CORS(mux)))
+// envDefaults are the settings the API applies where the environment sets
+// none, suited to running behind a load balancer:
+//
+//	WRITE_TIMEOUT     time to write a response, event streams excepted (60s)
+//	SHUTDOWN_DELAY    time /readyz reports draining before connections are
+//	                  drained, for load balancers to notice (5s)
+//	SHUTDOWN_TIMEOUT  time open requests get to finish on SIGTERM (25s)
+//
+// glens serve reads every other setting (PORT, AI_MODELS, API_KEYS,
+// REQUIRED_PROVIDERS, ...) from the environment itself, see glens serve --help.
+var envDefaults = []struct{ env, flag, value string }{
+	{"WRITE_TIMEOUT", "--write-timeout", "60s"},
+	{"SHUTDOWN_DELAY", "--shutdown-delay", "5s"},
+	{"SHUTDOWN_TIMEOUT", "--shutdown-timeout", "25s"},
+}
 
-	srv := &http.Server{
-		Addr:              fmt.Sprintf(":%s", port),
-		Handler:           wrapped,
-		ReadHeaderTimeout: 10 * time.Second,
+// serveArgs returns the glens serve flags of the environment read by getenv.
+// Flags take precedence over the environment in glens serve, so defaults
+// are passed only for settings the environment leaves unset. LOG_LEVEL
+// debug or error maps to --debug or --quiet.
+func serveArgs(getenv func(string) string) []string {
+	args := []string{"--host=", "--log-format=json"}
+	for _, d := range envDefaults {
+		if getenv(d.env) == "" {
+			args = append(args, d.flag+"="+d.value)
+		}
 	}
-
-	log.Info().Str("port", port).Str("version", version).Msg("starting API server")
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal().Err(err).Msg("server failed")
+	switch strings.ToLower(getenv("LOG_LEVEL")) {
+	case "debug":
+		args = append(args, "--debug")
+	case "error":
+		args = append(args, "--quiet")
 	}
-}
-
-func registerRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /healthz", handler.Health(version))
-	mux.HandleFunc("POST /api/v1/analyze", handler.Analyze)
-	mux.HandleFunc("POST /api/v1/analyze/preview", handler.AnalyzePreview)
-	mux.HandleFunc("GET /api/v1/models", handler.Models)
-	mux.HandleFunc("POST /api/v1/mcp", handler.MCP)
+	return args
 }
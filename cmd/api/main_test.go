@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"--host=", "--log-format=json", "--write-timeout=60s", "--shutdown-delay=5s", "--shutdown-timeout=25s"},
+		},
+		{
+			name: "environment overrides defaults",
+			env:  map[string]string{"WRITE_TIMEOUT": "0", "SHUTDOWN_DELAY": "1s", "SHUTDOWN_TIMEOUT": "10s"},
+			want: []string{"--host=", "--log-format=json"},
+		},
+		{
+			name: "debug log level",
+			env:  map[string]string{"LOG_LEVEL": "DEBUG", "SHUTDOWN_DELAY": "1s"},
+			want: []string{"--host=", "--log-format=json", "--write-timeout=60s", "--shutdown-timeout=25s", "--debug"},
+		},
+		{
+			name: "error log level",
+			env:  map[string]string{"LOG_LEVEL": "error"},
+			want: []string{"--host=", "--log-format=json", "--write-timeout=60s", "--shutdown-delay=5s", "--shutdown-timeout=25s", "--quiet"},
+		},
+		{
+			name: "info log level is the default",
+			env:  map[string]string{"LOG_LEVEL": "info"},
+			want: []string{"--host=", "--log-format=json", "--write-timeout=60s", "--shutdown-delay=5s", "--shutdown-timeout=25s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			assert.Equal(t, tt.want, serveArgs(getenv))
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/exitcode"
+)
+
+// loadAIConfig builds the AI provider configuration from the ai_models and
+// provider_limits config sections. ${VAR} references in credentials are expanded, and missing API
+// keys fall back to the providers' standard environment variables.
+func loadAIConfig() (ai.Config, error) {
+	var cfg ai.Config
+	providers := map[string]any{
+		"openai":    &cfg.OpenAI,
+		"anthropic": &cfg.Anthropic,
+		"google":    &cfg.Google,
+		"mistral":   &cfg.Mistral,
+	}
+	for name, target := range providers {
+		if err := unmarshalSecretKey("ai_models."+name, target); err != nil {
+			return ai.Config{}, err
+		}
+	}
+
+	cfg.Ollama = make(map[string]ai.OllamaConfig)
+	for name := range viper.GetStringMap("ai_models") {
+		if !strings.HasPrefix(name, ai.DefaultOllamaConfig) {
+			continue
+		}
+		var ollama ai.OllamaConfig
+		if err := unmarshalSecretKey("ai_models."+name, &ollama); err != nil {
+			return ai.Config{}, err
+		}
+		cfg.Ollama[name] = ollama
+	}
+
+	if err := viper.UnmarshalKey("generation", &cfg.Generation); err != nil {
+		return ai.Config{}, fmt.Errorf("failed to read generation: %w", err)
+	}
+	cfg.Override(samplingOverrides())
+
+	fallbacks, err := parseFallbacks(append(viper.GetStringSlice("fallbacks"), viper.GetStringSlice("run.fallbacks")...))
+	if err != nil {
+		return ai.Config{}, err
+	}
+	cfg.Fallbacks = fallbacks
+
+	if err := viper.UnmarshalKey("provider_limits", &cfg.Limits); err != nil {
+		return ai.Config{}, fmt.Errorf("failed to read provider_limits: %w", err)
+	}
+	if err := ai.ValidateLimits(cfg.Limits); err != nil {
+		return ai.Config{}, err
+	}
+
+	env := ai.ConfigFromEnv()
+	cfg.OpenAI.APIKey = credential(cfg.OpenAI.APIKey, env.OpenAI.APIKey)
+	cfg.Anthropic.APIKey = credential(cfg.Anthropic.APIKey, env.Anthropic.APIKey)
+	cfg.Google.APIKey = credential(cfg.Google.APIKey, env.Google.APIKey)
+	cfg.Google.ProjectID = credential(cfg.Google.ProjectID, env.Google.ProjectID)
+	cfg.Google.Location = credential(cfg.Google.Location, env.Google.Location)
+	cfg.Google.Credentials = os.ExpandEnv(cfg.Google.Credentials)
+	cfg.Google.Vertex = cfg.Google.Vertex || env.Google.Vertex
+	cfg.Mistral.APIKey = credential(cfg.Mistral.APIKey, env.Mistral.APIKey)
+
+	return cfg, nil
+}
+
+// samplingOverrides reads the generation parameters set for this run
+// (--temperature, --top-p, --seed, --max-output-tokens), which apply to
+// every model
+func samplingOverrides() ai.Sampling {
+	var over ai.Sampling
+	if viper.IsSet("run.temperature") {
+		temperature := viper.GetFloat64("run.temperature")
+		over.Temperature = &temperature
+	}
+	if viper.IsSet("run.top_p") {
+		topP := viper.GetFloat64("run.top_p")
+		over.TopP = &topP
+	}
+	if viper.IsSet("run.seed") {
+		seed := viper.GetInt("run.seed")
+		over.Seed = &seed
+	}
+	over.MaxTokens = viper.GetInt("run.max_output_tokens")
+	return over
+}
+
+// parseFallbacks parses fallback chains like "gpt-4o>gpt-4o-mini>enhanced-mock"
+// into the fallbacks of each chain's first model. A later chain for the same
+// model replaces an earlier one, so --fallback overrides the config.
+func parseFallbacks(chains []string) (map[string][]string, error) {
+	fallbacks := make(map[string][]string)
+	for _, chain := range chains {
+		models := strings.Split(chain, ">")
+		for i := range models {
+			models[i] = strings.TrimSpace(models[i])
+		}
+		if len(models) < 2 || slices.Contains(models, "") {
+			return nil, fmt.Errorf("fallbacks: %q is not a chain like model>fallback>fallback", chain)
+		}
+		fallbacks[models[0]] = models[1:]
+	}
+	return fallbacks, nil
+}
+
+// credential expands ${VAR} references in a configured value, falling back
+// to the environment value when the result is empty
+func credential(configured, fallback string) string {
+	if v := os.ExpandEnv(configured); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// newAIManager creates AI clients for models from the CLI configuration
+func newAIManager(models []string) (*ai.Manager, error) {
+	cfg, err := loadAIConfig()
+	if err != nil {
+		return nil, err
+	}
+	router, err := routerFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	manager, err := ai.NewManager(router.Models(models), cfg)
+	if err != nil {
+		err = fmt.Errorf("failed to initialize AI clients: %w", err)
+		if errors.As(err, new(ai.ErrAPIKeyMissing)) {
+			err = exitcode.New(exitcode.ProviderAuth, err)
+		}
+		return nil, err
+	}
+
+	prompts, err := loadPrompts()
+	if err != nil {
+		return nil, err
+	}
+	manager.SetPrompts(prompts)
+
+	if viper.GetBool("local_only") {
+		if err := manager.RequireLocal(); err != nil {
+			return nil, err
+		}
+	}
+	policy, err := promptPolicyFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	manager.SetPromptPolicy(policy)
+	manager.SetRouter(router)
+	return manager, nil
+}
+
+// routerFromConfig reads the routing rules of the routing config section;
+// it is nil when none are configured
+func routerFromConfig() (*ai.Router, error) {
+	var cfg ai.RoutingConfig
+	if err := viper.UnmarshalKey("routing", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read routing: %w", err)
+	}
+	return ai.NewRouter(cfg)
+}
+
+// embeddingsFromConfig reads the embeddings config section used by search
+func embeddingsFromConfig() (ai.EmbeddingConfig, error) {
+	var cfg ai.EmbeddingConfig
+	if err := unmarshalSecretKey("embeddings", &cfg); err != nil {
+		return ai.EmbeddingConfig{}, err
+	}
+	return cfg, nil
+}
+
+// promptPolicyFromConfig reads the prompt_policy config section; it is nil
+// unless the policy is enabled
+func promptPolicyFromConfig() (*ai.PromptPolicy, error) {
+	var cfg ai.PromptPolicyConfig
+	if err := viper.UnmarshalKey("prompt_policy", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read prompt_policy: %w", err)
+	}
+	return ai.NewPromptPolicy(cfg)
+}
+
+// loadPrompts loads the prompt templates and few-shot examples configured
+// under prompts.*
+func loadPrompts() (*ai.Prompts, error) {
+	prompts, err := ai.NewPrompts(viper.GetString("prompts.dir"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	if dir := viper.GetString("prompts.examples_dir"); dir != "" {
+		examples, err := ai.LoadExamples(dir)
+		if err != nil {
+			return nil, err
+		}
+		limit := ai.DefaultMaxExamples
+		if viper.IsSet("prompts.max_examples") {
+			limit = viper.GetInt("prompts.max_examples")
+		}
+		prompts.SetExamples(examples, limit)
+	}
+	return prompts, nil
+}
+
+// newOllamaClient creates a client for the default Ollama server
+func newOllamaClient() (*ai.OllamaClient, error) {
+	cfg, err := loadAIConfig()
+	if err != nil {
+		return nil, err
+	}
+	return ai.NewOllamaClient(cfg.Ollama[ai.DefaultOllamaConfig])
+}
+
+// prepareOllamaModels checks that the models of the Ollama clients of
+// aiManager are installed. Missing models are pulled when pull is set;
+// otherwise a warning suggests pulling them or a model suited to this
+// machine. Generation reports its own errors, so none are returned.
+func prepareOllamaModels(ctx context.Context, aiManager *ai.Manager, pull bool) {
+	for name, client := range aiManager.OllamaClients() {
+		if pull {
+			if _, err := client.EnsureModel(ctx, os.Stderr); err != nil {
+				log.Warn().Err(err).Str("ai_model", name).Msg("Failed to pull Ollama model")
+			}
+			continue
+		}
+
+		installed, err := client.ModelInstalled(ctx)
+		if err != nil {
+			log.Warn().Err(err).Str("ai_model", name).Msg("Failed to check Ollama models")
+			continue
+		}
+		if !installed {
+			recommendation := ai.RecommendOllamaModel(ai.DetectHardware(ctx))
+			log.Warn().
+				Str("ai_model", name).
+				Str("model", client.Model()).
+				Str("recommended", recommendation.Model).
+				Str("reason", recommendation.Reason).
+				Msg("Ollama model is not installed; use --auto-pull or glens models ollama pull")
+		}
+	}
+}
+
+// preflightTimeout bounds the health checks of all models before a run
+const preflightTimeout = 30 * time.Second
+
+// preflight health-checks every model of aiManager before any endpoint is
+// processed, failing with all the problems found at once
+func preflight(ctx context.Context, aiManager *ai.Manager) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	log.Info().Msg("Checking AI models before analysis")
+	if err := aiManager.Preflight(ctx); err != nil {
+		return exitcode.New(exitcode.ProviderAuth, fmt.Errorf("preflight failed, fix these models or rerun with --preflight=false:\n%w", err))
+	}
+	return nil
+}
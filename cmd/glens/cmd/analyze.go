@@ -2,22 +2,40 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"glens/pkg/logging"
+	"glens/pkg/safety"
 
 	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/events"
+	"glens/tools/glens/internal/exitcode"
 	"glens/tools/glens/internal/generator"
 	"glens/tools/glens/internal/github"
+	"glens/tools/glens/internal/notify"
 	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
 	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/storage"
+	"glens/tools/glens/internal/telemetry"
 )
 
 var analyzeCmd = &cobra.Command{
-	Use:   "analyze [openapi-url]",
+	Use:   "analyze [openapi-url...]",
 	Short: "Analyze OpenAPI specification and generate integration tests",
 	Long: `Analyzes an OpenAPI specification from a URL or file path and:
 1. Parses the OpenAPI spec to extract endpoints
@@ -27,8 +45,22 @@ var analyzeCmd = &cobra.Command{
 5. Generates comparison reports
 
 GitHub issues are created only when tests fail, indicating a mismatch
-between the OpenAPI specification and the actual implementation.`,
-	Args: cobra.ExactArgs(1),
+between the OpenAPI specification and the actual implementation.
+
+Several specs (as arguments or listed in --specs-file) are analyzed
+concurrently, each reporting to a directory named after its service, and
+a portfolio report ranks the services by health score.
+
+--focus narrows a run to the endpoints a description such as "payment
+endpoints" matches, ranked by the embeddings of the embeddings config
+section (local by default); the selection is listed for confirmation.
+
+--remote queues the analysis on a glens server (glens serve) instead of
+running it locally: a spec file is uploaded, a URL fetched by the server,
+the run's progress is streamed and its report downloaded to --output.
+Models and endpoint selection flags apply; test generation, execution
+and issues follow the server's configuration.`,
+	Args: cobra.ArbitraryArgs,
 	RunE: runAnalyze,
 }
 
@@ -37,13 +69,58 @@ func init() {
 
 	analyzeCmd.Flags().StringSlice("ai-models", []string{"gpt4"}, "AI models to use for test generation (gpt4, ollama, ollama:model-name, etc.)")
 	analyzeCmd.Flags().String("github-repo", "", "GitHub repository in owner/repo format (can also use GITHUB_REPOSITORY env var)")
-	analyzeCmd.Flags().String("test-framework", "testify", "Test framework to use (testify, ginkgo)")
+	analyzeCmd.Flags().String("test-framework", "testify", "Test framework to use (testify, ginkgo, grpc)")
 	analyzeCmd.Flags().Bool("create-issues", true, "Create GitHub issues when tests fail (requires github-repo and GITHUB_TOKEN)")
+	analyzeCmd.Flags().Bool("sync-issues", true, "Reconcile earlier issues with this run: reopen or update those of failing endpoints instead of opening duplicates and close those of endpoints that pass")
+	analyzeCmd.Flags().Int("max-issues", 0, "Open at most this many failure issues in a run, leaving the other failures to the report (0 for no cap)")
+	analyzeCmd.Flags().String("issue-attachments", github.AttachNone, "Attach the failing test code and log to issues: none, inline (collapsed blocks) or gist (secret gists)")
 	analyzeCmd.Flags().Bool("run-tests", true, "Execute generated tests")
 	analyzeCmd.Flags().String("output", "reports/report.md", "Output file for the final report")
+	analyzeCmd.Flags().String("format", "", "Report format (markdown, json, html or lsp-diagnostics for editor diagnostics keyed to spec lines); inferred from --output's extension when not set")
+	analyzeCmd.Flags().String("tests-output-dir", "", "Write the generated tests to this directory as a Go module (tests/<tag>/<operationId>_<model>_test.go)")
+	analyzeCmd.Flags().Bool("merge-models", false, "With --tests-output-dir, write the tests of all models of an endpoint as one file without near-duplicate cases (<operationId>_merged_test.go)")
+	analyzeCmd.Flags().String("env", "", "Target environment from the environments config section (base URL, headers, auth)")
+	analyzeCmd.Flags().String("server", "", "Run tests against this server of the spec, by index or description (overrides the environment's base URL)")
+	analyzeCmd.Flags().StringSlice("server-var", nil, "Value of a server URL variable as name=value (default: the variable's default)")
+	analyzeCmd.Flags().Duration("test-timeout", generator.DefaultTestTimeout, "Timeout for each test run attempt")
+	analyzeCmd.Flags().Duration("endpoint-timeout", 0, "Skip an endpoint whose test generation and execution take longer than this (0 for no limit)")
+	analyzeCmd.Flags().Duration("total-timeout", 0, "Skip the endpoints left once the analysis has run this long (0 for no limit)")
+	analyzeCmd.Flags().Int("test-retries", 1, "Re-run failed tests up to N times; tests passing on retry are reported as flaky")
+	analyzeCmd.Flags().String("allow-risk", "safe", "Highest endpoint risk whose tests are executed (safe, medium, high); riskier tests are generated only")
+	analyzeCmd.Flags().Float64("temperature", 0, "Sampling temperature for every model, overriding the config (0 for the most deterministic output)")
+	analyzeCmd.Flags().Float64("top-p", 0, "Nucleus sampling top_p for every model, overriding the config")
+	analyzeCmd.Flags().Int("seed", 0, "Sampling seed for every model that supports one, for reproducible runs")
+	analyzeCmd.Flags().Int("max-output-tokens", 0, "Maximum tokens each model may generate per test, overriding the config")
+	analyzeCmd.Flags().Bool("lint", false, "Run static analysis (go vet, staticcheck, gosec) on generated tests before executing them")
+	analyzeCmd.Flags().String("lint-fail-on", "high", "Lowest finding severity that blocks a test from running (low, medium, high, none)")
+	analyzeCmd.Flags().Int("repair-attempts", 0, "Send tests that fail to compile or run back to their model with the failure up to N times")
+	analyzeCmd.Flags().Bool("preflight", true, "Check every selected model (API key, reachability, model availability) before analysis and fail fast")
+	analyzeCmd.Flags().StringSlice("fallback", nil, "Models that generate in place of a failing model, in order (e.g. gpt-4o>gpt-4o-mini>enhanced-mock), overriding the config")
+	analyzeCmd.Flags().Bool("auto-pull", false, "Pull Ollama models that are not installed before generating tests")
+	analyzeCmd.Flags().String("ensemble", "", "Combine the tests of all models per endpoint: best (pick the best test) or merge (merge unique test functions)")
 
 	// Endpoint filtering options
 	analyzeCmd.Flags().String("op-id", "", "Target specific endpoint by operation ID (e.g., getPetById, addPet)")
+	analyzeCmd.Flags().StringSlice("tags", nil, "Only endpoints with one of these tags")
+	analyzeCmd.Flags().StringSlice("methods", nil, "Only endpoints with one of these HTTP methods (e.g. GET,HEAD)")
+	analyzeCmd.Flags().String("path", "", "Only paths matching this glob (* within a segment, ** across segments) or re:<regexp>")
+	analyzeCmd.Flags().StringSlice("exclude", nil, "Skip endpoints by operation ID, endpoint ID or \"METHOD /path\"")
+	analyzeCmd.Flags().String("focus", "", "Only the endpoints this natural language description matches (e.g. \"payment endpoints\"), by the similarity of their embeddings; the selection is confirmed unless --yes")
+	analyzeCmd.Flags().BoolP("yes", "y", false, "Analyze the --focus selection without asking for confirmation")
+	analyzeCmd.Flags().String("min-priority", "", "Only endpoints of at least this x-glens-priority (high, normal, low)")
+	analyzeCmd.Flags().Bool("include-deprecated", false, "Also test operations marked deprecated in the spec, which are skipped by default")
+	analyzeCmd.Flags().Bool("scenarios", false, "Also generate multi-step tests of resource lifecycles inferred from paths and operation IDs (create, read, update, delete)")
+	analyzeCmd.Flags().String("scenarios-file", "", "YAML file of explicit multi-step scenarios to generate tests for")
+	analyzeCmd.Flags().String("artifacts-dir", "", "Save the prompt, raw response, extracted code and compiler and test output of every generation to this directory (<endpoint>/<model>/)")
+	analyzeCmd.Flags().String("events-file", "", "Write run lifecycle events (spec_parsed, endpoint_started, generation_finished, test_executed, issue_created, issue_reopened, issue_closed) to this file as NDJSON")
+	analyzeCmd.Flags().String("specs-file", "", "YAML manifest of the specs to analyze (specs: [{name, spec}]), in addition to the arguments")
+	analyzeCmd.Flags().Int("parallel", defaultParallelSpecs, "How many specs of a multi-spec run are analyzed at once")
+	analyzeCmd.Flags().String("upload", "", "Upload the report, generated tests, generation artifacts and events file to s3://bucket/prefix or gs://bucket/prefix under the run ID")
+	analyzeCmd.Flags().Bool("watch", false, "Keep running and re-analyze changed endpoints whenever the spec file is saved")
+	analyzeCmd.Flags().StringSlice("fail-on", nil, "Exit non-zero when the run has failing tests (tests, exit 6), tests regressed from --baseline (regression, exit 7) or hit --total-timeout (budget, exit 5)")
+	analyzeCmd.Flags().String("baseline", "", "JSON report of an earlier run; tests that passed there and fail now are regressions")
+	analyzeCmd.Flags().String("remote", "", "Run the analysis on the glens server at this URL (e.g. https://glens.internal), following its progress and downloading the report")
+	analyzeCmd.Flags().String("remote-api-key", "", "API key of the --remote server (can also use GLENS_API_KEY env var)")
 
 	// Bind flag to a dedicated key so it does not shadow the ai_models config
 	// section (which is a YAML map of per-model settings like base URLs and API
@@ -52,14 +129,178 @@ func init() {
 	_ = viper.BindPFlag("github.repository", analyzeCmd.Flags().Lookup("github-repo"))
 	_ = viper.BindPFlag("test_framework", analyzeCmd.Flags().Lookup("test-framework"))
 	_ = viper.BindPFlag("create_issues", analyzeCmd.Flags().Lookup("create-issues"))
+	_ = viper.BindPFlag("github.sync_issues", analyzeCmd.Flags().Lookup("sync-issues"))
+	_ = viper.BindPFlag("github.max_issues", analyzeCmd.Flags().Lookup("max-issues"))
+	_ = viper.BindPFlag("github.attachments", analyzeCmd.Flags().Lookup("issue-attachments"))
 	_ = viper.BindPFlag("run_tests", analyzeCmd.Flags().Lookup("run-tests"))
 	_ = viper.BindPFlag("output", analyzeCmd.Flags().Lookup("output"))
+	_ = viper.BindPFlag("output_format", analyzeCmd.Flags().Lookup("format"))
+	_ = viper.BindPFlag("tests_output.dir", analyzeCmd.Flags().Lookup("tests-output-dir"))
+	_ = viper.BindPFlag("tests_output.merge_models", analyzeCmd.Flags().Lookup("merge-models"))
+	_ = viper.BindPFlag("run.environment", analyzeCmd.Flags().Lookup("env"))
+	_ = viper.BindPFlag("run.server", analyzeCmd.Flags().Lookup("server"))
+	_ = viper.BindPFlag("run.server_variables", analyzeCmd.Flags().Lookup("server-var"))
+	_ = viper.BindPFlag("test_execution.timeout", analyzeCmd.Flags().Lookup("test-timeout"))
+	_ = viper.BindPFlag("run.endpoint_timeout", analyzeCmd.Flags().Lookup("endpoint-timeout"))
+	_ = viper.BindPFlag("run.total_timeout", analyzeCmd.Flags().Lookup("total-timeout"))
+	_ = viper.BindPFlag("test_execution.retries", analyzeCmd.Flags().Lookup("test-retries"))
+	_ = viper.BindPFlag("run.allow_risk", analyzeCmd.Flags().Lookup("allow-risk"))
+	_ = viper.BindPFlag("run.temperature", analyzeCmd.Flags().Lookup("temperature"))
+	_ = viper.BindPFlag("run.top_p", analyzeCmd.Flags().Lookup("top-p"))
+	_ = viper.BindPFlag("run.seed", analyzeCmd.Flags().Lookup("seed"))
+	_ = viper.BindPFlag("run.max_output_tokens", analyzeCmd.Flags().Lookup("max-output-tokens"))
+	_ = viper.BindPFlag("test_execution.lint.enabled", analyzeCmd.Flags().Lookup("lint"))
+	_ = viper.BindPFlag("test_execution.lint.fail_on", analyzeCmd.Flags().Lookup("lint-fail-on"))
+	_ = viper.BindPFlag("test_execution.repair_attempts", analyzeCmd.Flags().Lookup("repair-attempts"))
+	_ = viper.BindPFlag("run.preflight", analyzeCmd.Flags().Lookup("preflight"))
+	_ = viper.BindPFlag("run.fallbacks", analyzeCmd.Flags().Lookup("fallback"))
+	_ = viper.BindPFlag("run.auto_pull", analyzeCmd.Flags().Lookup("auto-pull"))
+	_ = viper.BindPFlag("run.ensemble", analyzeCmd.Flags().Lookup("ensemble"))
+	_ = viper.BindPFlag("run.fail_on", analyzeCmd.Flags().Lookup("fail-on"))
+	_ = viper.BindPFlag("run.baseline", analyzeCmd.Flags().Lookup("baseline"))
 	_ = viper.BindPFlag("op_id", analyzeCmd.Flags().Lookup("op-id"))
+	_ = viper.BindPFlag("run.tags", analyzeCmd.Flags().Lookup("tags"))
+	_ = viper.BindPFlag("run.methods", analyzeCmd.Flags().Lookup("methods"))
+	_ = viper.BindPFlag("run.path", analyzeCmd.Flags().Lookup("path"))
+	_ = viper.BindPFlag("run.exclude", analyzeCmd.Flags().Lookup("exclude"))
+	_ = viper.BindPFlag("run.focus", analyzeCmd.Flags().Lookup("focus"))
+	_ = viper.BindPFlag("run.min_priority", analyzeCmd.Flags().Lookup("min-priority"))
+	_ = viper.BindPFlag("run.include_deprecated", analyzeCmd.Flags().Lookup("include-deprecated"))
+	_ = viper.BindPFlag("run.scenarios", analyzeCmd.Flags().Lookup("scenarios"))
+	_ = viper.BindPFlag("run.scenarios_file", analyzeCmd.Flags().Lookup("scenarios-file"))
+	_ = viper.BindPFlag("run.specs_file", analyzeCmd.Flags().Lookup("specs-file"))
+	_ = viper.BindPFlag("run.parallel", analyzeCmd.Flags().Lookup("parallel"))
+	_ = viper.BindPFlag("run.events_file", analyzeCmd.Flags().Lookup("events-file"))
+	_ = viper.BindPFlag("run.artifacts_dir", analyzeCmd.Flags().Lookup("artifacts-dir"))
+	_ = viper.BindPFlag("upload.target", analyzeCmd.Flags().Lookup("upload"))
+	_ = viper.BindPFlag("remote.url", analyzeCmd.Flags().Lookup("remote"))
+	_ = viper.BindPFlag("remote.api_key", analyzeCmd.Flags().Lookup("remote-api-key"))
+	_ = viper.BindEnv("remote.api_key", "GLENS_API_KEY")
+}
+
+// analysisOptions adds the CLI concerns of a run (issues, report file) to
+// the pipeline options
+type analysisOptions struct {
+	analysis.Options
+	CreateIssues bool
+	// SyncIssues reconciles the issues of earlier runs with the results
+	SyncIssues bool
+	// IssueLimits paces the GitHub requests of the run and caps its
+	// issues; nil reads them from the config for each spec
+	IssueLimits *github.Limits
+	Repository  string
+	Output      string
+	// OutputFormat is the format of Output; empty infers it from its
+	// extension
+	OutputFormat reporter.ReportFormat
+	// TestsOutputDir, when set, receives the generated tests as a Go module
+	// of TestsModule
+	TestsOutputDir string
+	TestsModule    string
+	// MergeModels writes the tests of all models of an endpoint as one
+	// deduplicated file
+	MergeModels bool
+	// Server selects a server of the specification as the base URL, with
+	// ServerVariables ("name=value") overriding its variable defaults
+	Server          string
+	ServerVariables []string
+	// Notifications, when set, announce each finished analysis
+	Notifications *notify.Config
+	// Storage keeps the reports, under their Output path; nil writes files
+	Storage storage.Store
+}
+
+// reports is the store of the run's reports
+func (o analysisOptions) reports() storage.Store {
+	if o.Storage == nil {
+		return storage.NewFS("")
+	}
+	return o.Storage
+}
+
+// analysisOptionsFromConfig reads the analysis settings bound to viper
+func analysisOptionsFromConfig() analysisOptions {
+	opts := analysisOptions{
+		Options: analysis.Options{
+			Models:            viper.GetStringSlice("run.ai_models"),
+			Framework:         viper.GetString("test_framework"),
+			OperationID:       viper.GetString("op_id"),
+			RunTests:          viper.GetBool("run_tests"),
+			TestTimeout:       viper.GetDuration("test_execution.timeout"),
+			TestRetries:       viper.GetInt("test_execution.retries"),
+			EndpointTimeout:   viper.GetDuration("run.endpoint_timeout"),
+			TotalTimeout:      viper.GetDuration("run.total_timeout"),
+			RepairAttempts:    viper.GetInt("test_execution.repair_attempts"),
+			ArtifactsDir:      viper.GetString("run.artifacts_dir"),
+			AllowRisk:         safety.Risk(viper.GetString("run.allow_risk")),
+			Ensemble:          viper.GetString("run.ensemble"),
+			IncludeDeprecated: viper.GetBool("run.include_deprecated"),
+			InferScenarios:    viper.GetBool("run.scenarios"),
+			ScenariosFile:     viper.GetString("run.scenarios_file"),
+			Selection: parser.Selection{
+				Tags:        viper.GetStringSlice("run.tags"),
+				Methods:     viper.GetStringSlice("run.methods"),
+				Path:        viper.GetString("run.path"),
+				Exclude:     viper.GetStringSlice("run.exclude"),
+				MinPriority: viper.GetString("run.min_priority"),
+			},
+			Scoring: scoringFromConfig(),
+		},
+		CreateIssues:    viper.GetBool("create_issues"),
+		SyncIssues:      viper.GetBool("github.sync_issues"),
+		Repository:      viper.GetString("github.repository"),
+		Output:          viper.GetString("output"),
+		TestsOutputDir:  viper.GetString("tests_output.dir"),
+		TestsModule:     viper.GetString("tests_output.module"),
+		MergeModels:     viper.GetBool("tests_output.merge_models"),
+		Server:          viper.GetString("run.server"),
+		ServerVariables: viper.GetStringSlice("run.server_variables"),
+	}
+	opts.Lint = lintFromConfig()
+	return opts
+}
+
+// lintFromConfig reads the static analysis gate of the config; it is nil
+// when the gate is disabled
+func lintFromConfig() *generator.LintOptions {
+	if !viper.GetBool("test_execution.lint.enabled") {
+		return nil
+	}
+	return &generator.LintOptions{
+		Tools:  viper.GetStringSlice("test_execution.lint.tools"),
+		FailOn: generator.Severity(viper.GetString("test_execution.lint.fail_on")),
+	}
+}
+
+// scoringFromConfig reads the scoring section of the config; a group of
+// weights it does not set keeps its defaults. It is nil without a scoring
+// section.
+func scoringFromConfig() *reporter.Scoring {
+	if !viper.IsSet("scoring") {
+		return nil
+	}
+	scoring := reporter.DefaultScoring()
+	if viper.IsSet("scoring.health") {
+		scoring.Health = reporter.HealthWeights{
+			SuccessRate:      viper.GetFloat64("scoring.health.success_rate"),
+			EndpointCoverage: viper.GetFloat64("scoring.health.endpoint_coverage"),
+			TestCoverage:     viper.GetFloat64("scoring.health.test_coverage"),
+		}
+	}
+	if viper.IsSet("scoring.model") {
+		scoring.Model = reporter.ModelWeights{
+			Quality:     viper.GetFloat64("scoring.model.quality"),
+			Coverage:    viper.GetFloat64("scoring.model.coverage"),
+			Reliability: viper.GetFloat64("scoring.model.reliability"),
+			Performance: viper.GetFloat64("scoring.model.performance"),
+		}
+	}
+	scoring.Webhook = viper.GetString("scoring.webhook")
+	return &scoring
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	openapiURL := args[0]
 
 	// Handle github repository with proper precedence: CLI flag > env var > config file
 	// If CLI flag is explicitly set, it should override config file values
@@ -68,228 +309,550 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		viper.Set("github.repository", flagValue)
 	}
 
+	opts := analysisOptionsFromConfig()
+	// The specs of the run share one pace and issue cap
+	opts.IssueLimits = issueLimitsFromConfig()
+	services, err := serviceSpecs(args, viper.GetString("run.specs_file"))
+	if err != nil {
+		return err
+	}
+	if opts.Storage, err = openStorage(); err != nil {
+		return err
+	}
+	if format := viper.GetString("output_format"); format != "" {
+		if opts.OutputFormat, err = reporter.ParseFormat(format); err != nil {
+			return exitcode.New(exitcode.Usage, err)
+		}
+	}
+	if opts.Notifications, err = notificationsFromConfig(); err != nil {
+		return err
+	}
+	watch, _ := cmd.Flags().GetBool("watch")
+	outcome, err := newOutcomeCheck(ctx, opts.Storage, viper.GetStringSlice("run.fail_on"), viper.GetString("run.baseline"))
+	if err != nil {
+		return err
+	}
+	if len(services) > 1 {
+		switch {
+		case watch:
+			return fmt.Errorf("--watch analyzes a single spec, got %d", len(services))
+		case opts.Server != "":
+			return fmt.Errorf("--server selects a server of a single spec, got %d specs", len(services))
+		case outcome != nil:
+			return exitcode.Errorf(exitcode.Usage, "--fail-on checks the run of a single spec, got %d specs", len(services))
+		}
+	}
+	if watch && outcome != nil {
+		return exitcode.New(exitcode.Usage, errors.New("--fail-on checks a finished run and can't be used with --watch"))
+	}
+	if focus := viper.GetString("run.focus"); focus != "" {
+		switch {
+		case len(services) > 1:
+			return exitcode.Errorf(exitcode.Usage, "--focus selects endpoints of a single spec, got %d specs", len(services))
+		case watch:
+			return exitcode.New(exitcode.Usage, errors.New("--focus can't be used with --watch, which analyzes the changed endpoints"))
+		case opts.OperationID != "":
+			return exitcode.New(exitcode.Usage, errors.New("--focus and --op-id both select the endpoints; use one"))
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		if opts.Approved, err = resolveFocus(ctx, services[0].Spec, focus, opts.Options, yes, cmd.InOrStdin(), cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+	}
+	if remote := viper.GetString("remote.url"); remote != "" {
+		switch {
+		case len(services) > 1:
+			return exitcode.Errorf(exitcode.Usage, "--remote analyzes a single spec, got %d specs", len(services))
+		case watch:
+			return exitcode.New(exitcode.Usage, errors.New("--remote can't be used with --watch, which analyzes locally"))
+		}
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		report, err := runRemote(ctx, remote, services[0].Spec, opts)
+		if err != nil {
+			return err
+		}
+		return outcome.check(report)
+	}
+	upload, err := openUpload(viper.GetString("upload.target"))
+	if err != nil {
+		return err
+	}
+	if upload != nil && watch {
+		return errors.New("--upload uploads the artifacts of a finished run and can't be used with --watch")
+	}
+
+	// Resolve the target environment tests run against
+	env, err := loadEnvironment(viper.GetString("run.environment"))
+	if err != nil {
+		return err
+	}
+	opts.Env = env
+
+	// Initialize AI clients
+	log.Info().Msg("Initializing AI model clients")
+	aiManager, err := newAIManager(opts.Models)
+	if err != nil {
+		return err
+	}
+	aiManager.SetEnvironment(env)
+	prepareOllamaModels(ctx, aiManager, viper.GetBool("run.auto_pull"))
+	if viper.GetBool("run.preflight") {
+		if err := preflight(ctx, aiManager); err != nil {
+			return err
+		}
+	}
+
+	eventsFile := viper.GetString("run.events_file")
+	if eventsFile != "" {
+		recorder, err := events.Create(eventsFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = recorder.Close() }()
+		opts.Events = recorder
+		log.Info().
+			Str("events_file", eventsFile).
+			Str("run_id", recorder.RunID()).
+			Msg("Recording run events")
+	}
+
+	if watch {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return watchAnalysis(ctx, services[0].Spec, opts, aiManager, cmd.OutOrStdout())
+	}
+
+	if len(services) > 1 {
+		portfolio, err := runPortfolio(ctx, services, opts, aiManager, viper.GetInt("run.parallel"))
+		if portfolio != nil && upload != nil {
+			// Keep the artifacts of the specs that were analyzed
+			err = errors.Join(err, uploadArtifacts(ctx, upload, opts, services, eventsFile))
+		}
+		return err
+	}
+
+	// Draw progress on interactive terminals, with logs printed above it
+	if !viper.GetBool("quiet") && term.IsTerminal(int(os.Stderr.Fd())) {
+		display := newProgressDisplay(os.Stderr, aiManager.RoutedModels(opts.Models), viper.GetBool("verbose"))
+		logs := loggingConfig()
+		logs.Output = redact.Writer(display)
+		logging.Setup(logs)
+		defer setupLogging()
+		display.start()
+		defer display.stop()
+		opts.Progress = display.update
+	}
+
+	report, err := runAnalysis(ctx, services[0].Spec, opts, aiManager)
+	if err != nil {
+		return err
+	}
+	if upload != nil {
+		if err := uploadArtifacts(ctx, upload, opts, services, eventsFile); err != nil {
+			return err
+		}
+	}
+	return outcome.check(report)
+}
+
+// runAnalysis parses the specification, generates and executes tests for
+// each selected endpoint, opens issues for real failures, and writes the report
+func runAnalysis(ctx context.Context, openapiURL string, opts analysisOptions, aiManager *ai.Manager) (*reporter.Report, error) {
 	log.Info().
 		Str("openapi_url", openapiURL).
-		Strs("ai_models", viper.GetStringSlice("run.ai_models")).
-		Str("github_repo", viper.GetString("github.repository")).
+		Strs("ai_models", opts.Models).
+		Str("github_repo", opts.Repository).
 		Msg("Starting OpenAPI analysis")
 
 	// Parse OpenAPI specification
 	log.Info().Msg("Parsing OpenAPI specification")
-	spec, err := parser.ParseOpenAPISpec(openapiURL)
+	spec, err := parser.ParseOpenAPISpecContext(ctx, openapiURL)
 	if err != nil {
-		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+		return nil, exitcode.New(exitcode.SpecParse, fmt.Errorf("failed to parse OpenAPI spec: %w", err))
 	}
 
 	log.Info().
 		Int("endpoints_count", len(spec.Endpoints)).
 		Msg("OpenAPI specification parsed successfully")
+	for _, warning := range spec.Warnings {
+		log.Warn().
+			Str("location", spec.Location(warning.Position)).
+			Str("code", warning.Code).
+			Msg(warning.Message)
+	}
+	opts.Events.Emit(specParsedEvent(openapiURL, spec))
 
-	// Initialize GitHub client
-	var githubClient *github.Client
-	if viper.GetBool("create_issues") {
-		log.Info().Msg("Initializing GitHub client")
-		githubClient, err = github.NewClient(viper.GetString("github.token"))
+	if opts.Server != "" {
+		env, err := serverEnvironment(spec, openapiURL, opts.Server, opts.ServerVariables, opts.Env)
 		if err != nil {
-			return fmt.Errorf("failed to initialize GitHub client: %w", err)
+			return nil, err
 		}
+		opts.Env = env
+		aiManager.SetEnvironment(env)
+	}
+
+	report, err := analyzeSpec(ctx, spec, opts, aiManager)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeReport(ctx, opts.reports(), report, opts.Output, opts.OutputFormat); err != nil {
+		return nil, err
+	}
+	if err := writeTestSuite(report, opts); err != nil {
+		return nil, err
+	}
+	if err := writeArtifactsReport(report, opts); err != nil {
+		return nil, err
+	}
+	notifyRun(ctx, opts.Notifications, report, opts.Output)
+
+	log.Info().
+		Str("output_file", opts.Output).
+		Int("endpoints_processed", len(report.EndpointResults)).
+		Msg("Analysis completed successfully")
+
+	return report, nil
+}
 
-		// Set the target repository
-		repo := viper.GetString("github.repository")
-		if repo == "" {
-			return fmt.Errorf("github repository is required when create-issues is enabled (use --github-repo flag or GITHUB_REPOSITORY env var)")
+// analyzeSpec runs the pipeline over a parsed spec and opens issues for
+// real failures before handing each result to opts.OnEndpoint, then closes
+// the issues of endpoints that pass again
+func analyzeSpec(ctx context.Context, spec *parser.OpenAPISpec, opts analysisOptions, aiManager *ai.Manager) (*reporter.Report, error) {
+	githubClient, err := newIssueClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	tracker := trackIssues(ctx, githubClient, opts)
+	openBoard(ctx, githubClient)
+
+	pipeline := opts.Options
+	pipeline.OnEndpoint = func(ctx context.Context, result *reporter.EndpointResult) {
+		// Issues still open from earlier runs are updated without an event
+		if eventType, ok := issueEvents[createFailureIssue(ctx, githubClient, tracker, result)]; ok {
+			event := analysis.EndpointEvent(eventType, &result.Endpoint)
+			event.IssueNumber = result.IssueNumber
+			opts.Events.Emit(event)
 		}
-		if err := githubClient.SetRepository(repo); err != nil {
-			return fmt.Errorf("failed to set github repository: %w", err)
+		if opts.OnEndpoint != nil {
+			opts.OnEndpoint(ctx, result)
 		}
+	}
+	report, err := analysis.Run(ctx, spec, aiManager, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	reconcileIssues(ctx, githubClient, tracker, report, opts.Events)
+	return report, nil
+}
 
-		log.Info().
-			Str("repository", repo).
-			Msg("GitHub client configured")
+// specParsedEvent returns the spec_parsed event of the spec read from source
+func specParsedEvent(source string, spec *parser.OpenAPISpec) events.Event {
+	return events.Event{Type: events.SpecParsed, Spec: source, Endpoints: len(spec.Endpoints)}
+}
+
+// writeReport writes report to the key output of store in format, or the
+// format of its extension when format is empty; an empty output leaves the
+// report to the caller
+func writeReport(ctx context.Context, store storage.Store, report *reporter.Report, output string, format reporter.ReportFormat) error {
+	if output == "" {
+		return nil
+	}
+	if format == "" {
+		format = reporter.FormatOf(output)
+	}
+	store, key := storage.UserPath(store, output)
+	if err := reporter.StoreReport(ctx, store, key, report, format); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
 	}
+	return nil
+}
 
-	// Initialize AI clients
-	log.Info().Msg("Initializing AI model clients")
-	aiManager, err := ai.NewManager(viper.GetStringSlice("run.ai_models"))
+// writeArtifactsReport saves report as the JSON report of the generation
+// artifacts, which glens replay re-runs, when opts.ArtifactsDir is set
+func writeArtifactsReport(report *reporter.Report, opts analysisOptions) error {
+	if opts.ArtifactsDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(opts.ArtifactsDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	if err := reporter.WriteReport(report, filepath.Join(opts.ArtifactsDir, artifacts.ReportFile)); err != nil {
+		return fmt.Errorf("failed to write artifacts report: %w", err)
+	}
+	return nil
+}
+
+// writeTestSuite writes the generated tests of report to
+// opts.TestsOutputDir, if set
+func writeTestSuite(report *reporter.Report, opts analysisOptions) error {
+	if opts.TestsOutputDir == "" {
+		return nil
+	}
+	suite := analysis.Suite(report, opts.Framework, opts.MergeModels)
+	if opts.Env != nil {
+		suite.BaseURL = opts.Env.BaseURL
+	}
+	index, err := generator.WriteSuite(opts.TestsOutputDir, opts.TestsModule, suite)
 	if err != nil {
-		return fmt.Errorf("failed to initialize AI clients: %w", err)
+		return fmt.Errorf("failed to write generated tests: %w", err)
 	}
+	log.Info().
+		Str("tests_output_dir", opts.TestsOutputDir).
+		Int("files", len(index.Files)).
+		Int("tests", index.TotalTests).
+		Msg("Generated tests written")
+	return nil
+}
 
-	// Initialize test generator
-	testGen := generator.NewTestGenerator(viper.GetString("test_framework"))
+// newIssueClient creates the GitHub client used for failure issues, or nil
+// when issue creation is disabled
+func newIssueClient(opts analysisOptions) (*github.Client, error) {
+	if !opts.CreateIssues {
+		return nil, nil //nolint:nilnil // issue creation disabled is not an error
+	}
 
-	// Filter endpoints if operation ID is specified
-	var endpointsToProcess []parser.Endpoint
-	opID := viper.GetString("op_id")
+	log.Info().Msg("Initializing GitHub client")
+	token, err := secretString("github.token")
+	if err != nil {
+		return nil, err
+	}
+	githubClient, err := github.NewClient(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
+	}
 
-	if opID != "" {
-		log.Info().
-			Str("operation_id", opID).
-			Msg("Filtering endpoints by operation ID")
-
-		// Find endpoint with matching operation ID
-		found := false
-		for i := range spec.Endpoints {
-			endpoint := &spec.Endpoints[i]
-			if endpoint.OperationID == opID {
-				endpointsToProcess = append(endpointsToProcess, *endpoint)
-				found = true
-				break
-			}
-		}
+	// Set the target repository
+	if opts.Repository == "" {
+		return nil, fmt.Errorf("github repository is required when create-issues is enabled (use --github-repo flag or GITHUB_REPOSITORY env var)")
+	}
+	if err := githubClient.SetRepository(opts.Repository); err != nil {
+		return nil, fmt.Errorf("failed to set github repository: %w", err)
+	}
+	templates, err := issueTemplatesFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	githubClient.SetIssueTemplates(templates)
+	limits := opts.IssueLimits
+	if limits == nil {
+		limits = issueLimitsFromConfig()
+	}
+	githubClient.SetLimits(limits)
 
-		if !found {
-			// List available operation IDs to help user
-			var availableOps []string
-			for i := range spec.Endpoints {
-				endpoint := &spec.Endpoints[i]
-				if endpoint.OperationID != "" {
-					availableOps = append(availableOps, endpoint.OperationID)
-				}
-			}
-			return fmt.Errorf("operation ID '%s' not found. Available operation IDs: %v", opID, availableOps)
-		}
+	log.Info().
+		Str("repository", opts.Repository).
+		Msg("GitHub client configured")
 
-		log.Info().
-			Str("operation_id", opID).
-			Int("matching_endpoints", len(endpointsToProcess)).
-			Msg("Found matching endpoint")
-	} else {
-		// Process all endpoints
-		endpointsToProcess = spec.Endpoints
+	return githubClient, nil
+}
+
+// issueLimitsFromConfig reads the pacing of GitHub requests and the issue
+// cap of a run from the github config section
+func issueLimitsFromConfig() *github.Limits {
+	limits := github.DefaultLimits()
+	if viper.IsSet("github.pacing") {
+		limits.Pacing = viper.GetDuration("github.pacing")
+	}
+	if viper.IsSet("github.max_wait") {
+		limits.MaxWait = viper.GetDuration("github.max_wait")
 	}
+	limits.MaxIssues = viper.GetInt("github.max_issues")
+	return limits
+}
 
-	// Process each endpoint
-	var results []reporter.EndpointResult
+// issueTemplatesFromConfig reads how failure issues are written from the
+// github config section
+func issueTemplatesFromConfig() (*github.IssueTemplates, error) {
+	var cfg github.IssueConfig
+	if err := viper.UnmarshalKey("github", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read github: %w", err)
+	}
+	// Flags bound to keys of the section are not part of it
+	cfg.Attachments = viper.GetString("github.attachments")
+	return github.NewIssueTemplates(cfg)
+}
 
-	for i := range endpointsToProcess {
-		endpoint := &endpointsToProcess[i]
-		log.Info().
-			Str("method", endpoint.Method).
-			Str("path", endpoint.Path).
-			Msg("Processing endpoint")
+// issueEvents are the events of the actions taken on failure issues
+var issueEvents = map[string]events.Type{
+	github.IssueOpened:   events.IssueCreated,
+	github.IssueReopened: events.IssueReopened,
+}
 
-		result := reporter.EndpointResult{
-			Endpoint: *endpoint,
-			Tests:    make(map[string]reporter.TestResult),
-		}
+// trackIssues finds the issues of earlier runs when they are reconciled
+// with this one. The run goes on without them, opening issues as before,
+// when they cannot be listed.
+func trackIssues(ctx context.Context, githubClient *github.Client, opts analysisOptions) *github.IssueTracker {
+	if githubClient == nil || !opts.SyncIssues {
+		return nil
+	}
+	tracker, err := githubClient.TrackIssues(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list earlier issues - issues will not be reconciled")
+		return nil
+	}
+	log.Info().Int("tracked_issues", tracker.Len()).Msg("Reconciling issues of earlier runs")
+	return tracker
+}
 
-		// Track if we should create an issue (only if tests fail)
-		hasFailedTests := false
-		failedModels := []string{}
-
-		// Generate and run tests for each AI model
-		for _, modelName := range viper.GetStringSlice("run.ai_models") {
-			log.Info().
-				Str("ai_model", modelName).
-				Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
-				Msg("Generating tests with AI model")
-
-			testCode, prompt, err := aiManager.GenerateTest(ctx, modelName, endpoint)
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("ai_model", modelName).
-					Msg("Failed to generate test")
-				continue
-			}
+// projectFromConfig reads the project board of the github config section
+func projectFromConfig() (github.ProjectConfig, error) {
+	var cfg github.ProjectConfig
+	if err := viper.UnmarshalKey("github.project", &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to read github.project: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
 
-			testResult := reporter.TestResult{
-				AIModel:   modelName,
-				Prompt:    prompt,
-				TestCode:  testCode,
-				Framework: viper.GetString("test_framework"),
-			}
+// openBoard sets the project board issues are placed on, when one is
+// configured. Issues are still opened, off the board, when it cannot be
+// opened.
+func openBoard(ctx context.Context, githubClient *github.Client) {
+	if githubClient == nil {
+		return
+	}
+	cfg, err := projectFromConfig()
+	if err != nil || !cfg.Enabled() {
+		return
+	}
+	board, err := githubClient.OpenBoard(ctx, cfg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to open project board - issues will not be placed on it")
+		return
+	}
+	githubClient.SetBoard(board)
+}
 
-			// Execute test if enabled
-			if viper.GetBool("run_tests") {
-				log.Info().
-					Str("ai_model", modelName).
-					Msg("Executing generated test")
-
-				execResult, err := testGen.ExecuteTest(ctx, testCode, endpoint)
-				if err != nil {
-					log.Error().
-						Err(err).
-						Str("ai_model", modelName).
-						Msg("Test execution failed")
-					testResult.ExecutionError = err.Error()
-					// Check if this is a real test failure, not just connection/setup issues
-					if isRealTestFailure(err, execResult) {
-						hasFailedTests = true
-						failedModels = append(failedModels, modelName)
-					}
-				} else {
-					testResult.ExecutionResult = execResult
-					log.Info().
-						Str("ai_model", modelName).
-						Bool("passed", execResult.Passed).
-						Dur("duration", execResult.Duration).
-						Msg("Test execution completed")
-
-					// Check if tests failed (not passed and has actual test failures)
-					if execResult.Failed && (execResult.FailureCount > 0 || execResult.ErrorCount > 0) {
-						hasFailedTests = true
-						failedModels = append(failedModels, modelName)
-					}
-				}
-			}
+// reconcileIssues closes the tracked issues of the endpoints whose tests
+// all pass in report; the issues of failing endpoints were already
+// reopened or updated as their results came in
+func reconcileIssues(ctx context.Context, githubClient *github.Client, tracker *github.IssueTracker, report *reporter.Report, recorder *events.Recorder) {
+	if tracker == nil {
+		return
+	}
+	for i := range report.EndpointResults {
+		result := &report.EndpointResults[i]
+		passed := passedModels(result)
+		if result.Status == reporter.StatusSkipped || len(passed) == 0 || len(failedModels(result)) > 0 {
+			continue
+		}
+		number, err := githubClient.ResolveEndpoint(ctx, tracker, &result.Endpoint, passed)
+		if err != nil {
+			log.Error().Err(err).Str("endpoint", fmt.Sprintf("%s %s", result.Endpoint.Method, result.Endpoint.Path)).Msg("Failed to close GitHub issue")
+			continue
+		}
+		if number > 0 {
+			event := analysis.EndpointEvent(events.IssueClosed, &result.Endpoint)
+			event.IssueNumber = number
+			recorder.Emit(event)
+		}
+	}
+}
 
-			result.Tests[modelName] = testResult
+// passedModels returns the models whose tests ran and passed
+func passedModels(result *reporter.EndpointResult) []string {
+	var passed []string
+	for modelName := range result.Tests {
+		if execResult := result.Tests[modelName].ExecutionResult; execResult != nil && execResult.Passed {
+			passed = append(passed, modelName)
 		}
+	}
+	sort.Strings(passed)
+	return passed
+}
 
-		// Create GitHub issue ONLY if tests failed
-		if githubClient != nil && hasFailedTests {
-			log.Info().
-				Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
-				Strs("failed_models", failedModels).
-				Msg("Creating GitHub issue for failed tests")
-
-			issueNumber, err := githubClient.CreateEndpointIssue(ctx, endpoint, failedModels)
-			if err != nil {
-				log.Error().Err(err).Msg("Failed to create GitHub issue")
-			} else {
-				result.IssueNumber = issueNumber
-				log.Info().
-					Int("issue_number", issueNumber).
-					Msg("GitHub issue created for test failures")
-
-				// Update issue with test results
-				resultsComment := formatTestFailureResults(result, failedModels)
-				if err := githubClient.UpdateIssueWithResults(ctx, issueNumber, resultsComment); err != nil {
-					log.Error().Err(err).Msg("Failed to update issue with results")
-				}
+// failedModels returns the models whose tests failed against the spec
+func failedModels(result *reporter.EndpointResult) []string {
+	var failed []string
+	for modelName := range result.Tests {
+		testResult := result.Tests[modelName]
+		execResult := testResult.ExecutionResult
+
+		if testResult.ExecutionError != "" {
+			// Check if this is a real test failure, not just connection/setup issues
+			if isRealTestFailure(errors.New(testResult.ExecutionError), execResult) {
+				failed = append(failed, modelName)
 			}
-		} else if githubClient != nil && !hasFailedTests {
-			log.Info().
-				Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
-				Msg("All tests passed - no issue created")
+			continue
 		}
 
-		results = append(results, result)
+		// Check if tests failed (not passed and has actual test failures)
+		if execResult != nil && execResult.Failed && (execResult.FailureCount > 0 || execResult.ErrorCount > 0) {
+			failed = append(failed, modelName)
+		}
 	}
+	sort.Strings(failed)
+	return failed
+}
 
-	// Generate final report
-	log.Info().Msg("Generating final report")
-	report := reporter.GenerateReport(spec, results)
-
-	outputFile := viper.GetString("output")
+// createFailureIssue opens a GitHub issue ONLY if tests failed, or
+// reopens or updates the issue tracker has for the endpoint. It returns the
+// action taken on the issue (see github.ReportFailure), empty when none.
+func createFailureIssue(ctx context.Context, githubClient *github.Client, tracker *github.IssueTracker, result *reporter.EndpointResult) string {
+	// Tests cut short by a timeout are not evidence of a spec violation
+	if githubClient == nil || result.Status == reporter.StatusSkipped {
+		return ""
+	}
 
-	// Ensure the reports directory exists
-	if err := reporter.EnsureReportDirectory(outputFile); err != nil {
-		return fmt.Errorf("failed to create report directory: %w", err)
+	endpoint := &result.Endpoint
+	failed := failedModels(result)
+	if len(failed) == 0 {
+		log.Info().
+			Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+			Msg("All tests passed - no issue created")
+		return ""
 	}
 
-	if err := reporter.WriteReport(report, outputFile); err != nil {
-		return fmt.Errorf("failed to write report: %w", err)
+	log.Info().
+		Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+		Strs("failed_models", failed).
+		Msg("Creating GitHub issue for failed tests")
+
+	resultsComment := formatTestFailureResults(*result, failed)
+	failure := &github.Failure{Endpoint: endpoint, Models: failed, Results: resultsComment}
+	for _, modelName := range failed {
+		testResult := result.Tests[modelName]
+		testLog := testResult.ExecutionError
+		if testResult.ExecutionResult != nil && testResult.ExecutionResult.Output != "" {
+			testLog = testResult.ExecutionResult.Output
+		}
+		failure.Attachments = append(failure.Attachments, github.NewAttachment(endpoint, modelName, testResult.TestCode, testLog))
+	}
+	issueNumber, action, err := githubClient.ReportFailure(ctx, tracker, failure)
+	if errors.Is(err, github.ErrIssueLimit) {
+		log.Warn().
+			Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+			Msg("Issue limit reached - failure left to the report")
+		return ""
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create GitHub issue")
+		telemetry.IssueCreationErrors.Inc()
+		return ""
+	}
+	// The report links the gists of the failing tests
+	for _, attachment := range failure.Attachments {
+		if testResult, ok := result.Tests[attachment.Model]; ok && attachment.GistURL != "" {
+			testResult.GistURL = attachment.GistURL
+			result.Tests[attachment.Model] = testResult
+		}
 	}
 
+	result.IssueNumber = issueNumber
+	if action != github.IssueOpened {
+		// The status comment of the tracked issue holds the results
+		return action
+	}
 	log.Info().
-		Str("output_file", outputFile).
-		Int("endpoints_processed", len(results)).
-		Msg("Analysis completed successfully")
+		Int("issue_number", issueNumber).
+		Msg("GitHub issue created for test failures")
 
-	return nil
+	// Update issue with test results
+	if err := githubClient.UpdateIssueWithResults(ctx, issueNumber, resultsComment); err != nil {
+		log.Error().Err(err).Msg("Failed to update issue with results")
+		telemetry.IssueCreationErrors.Inc()
+	}
+	return action
 }
 
 // isRealTestFailure determines if an error represents a real test failure
@@ -319,6 +882,7 @@ func isRealTestFailure(err error, result *generator.ExecutionResult) bool {
 		"failed to create test module",
 		"go mod tidy failed",
 		"compilation",
+		"blocked by static analysis",
 	}
 
 	for _, issue := range setupIssues {
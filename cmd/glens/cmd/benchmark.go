@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/benchmark"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "Compare AI models on a fixed set of endpoints",
+	Long: `Repeatedly generates tests for the same endpoints with every model and
+reports latency, token use, compile-success rate and (with --run-tests) pass
+rate, each with a 95% confidence interval.
+
+Generated tests are compiled without being run unless --run-tests is set;
+tests of endpoints riskier than --allow-risk are never executed. Use the
+sampling flags so that every model is compared under the same settings.
+
+Examples:
+  glens benchmark --spec=spec.json --ai-models=gpt4,claude,ollama --iterations=5
+  glens benchmark --spec=spec.json --ai-models=gpt4,gemini --tags=users --temperature=0 --seed=42
+  glens benchmark --spec=spec.json --ai-models=gpt4 --run-tests --env=staging --output=bench.json`,
+	Args: cobra.NoArgs,
+	RunE: runBenchmark,
+}
+
+func init() {
+	rootCmd.AddCommand(benchmarkCmd)
+
+	benchmarkCmd.Flags().String("spec", "", "OpenAPI specification file or URL (required)")
+	benchmarkCmd.Flags().StringSlice("ai-models", []string{"gpt4"}, "AI models to compare")
+	benchmarkCmd.Flags().Int("iterations", benchmark.DefaultIterations, "Times each endpoint is generated per model")
+	benchmarkCmd.Flags().Bool("run-tests", false, "Execute generated tests to measure pass rate (otherwise they are only compiled)")
+	benchmarkCmd.Flags().String("allow-risk", "safe", "Highest endpoint risk whose tests are executed (safe, medium, high)")
+	benchmarkCmd.Flags().String("env", "", "Target environment from the environments config section")
+	benchmarkCmd.Flags().String("test-framework", "testify", "Test framework to use (testify, ginkgo, grpc)")
+	benchmarkCmd.Flags().Duration("test-timeout", generator.DefaultTestTimeout, "Timeout for compiling or running each test")
+	benchmarkCmd.Flags().String("output", "reports/benchmark.md", "Benchmark report file (.md for Markdown, otherwise JSON)")
+	benchmarkCmd.Flags().StringSlice("tags", nil, "Only endpoints with one of these tags")
+	benchmarkCmd.Flags().StringSlice("methods", nil, "Only endpoints with one of these HTTP methods")
+	benchmarkCmd.Flags().String("path", "", "Only paths matching this glob (* within a segment, ** across segments) or re:<regexp>")
+	benchmarkCmd.Flags().StringSlice("exclude", nil, "Skip endpoints by operation ID, endpoint ID or \"METHOD /path\"")
+	benchmarkCmd.Flags().Float64("temperature", 0, "Sampling temperature for every model, overriding the config")
+	benchmarkCmd.Flags().Float64("top-p", 0, "Nucleus sampling top_p for every model, overriding the config")
+	benchmarkCmd.Flags().Int("seed", 0, "Sampling seed for every model that supports one")
+	benchmarkCmd.Flags().Int("max-output-tokens", 0, "Maximum tokens each model may generate per test")
+	_ = benchmarkCmd.MarkFlagRequired("spec")
+
+	_ = viper.BindPFlag("benchmark.ai_models", benchmarkCmd.Flags().Lookup("ai-models"))
+	_ = viper.BindPFlag("benchmark.iterations", benchmarkCmd.Flags().Lookup("iterations"))
+	_ = viper.BindPFlag("benchmark.run_tests", benchmarkCmd.Flags().Lookup("run-tests"))
+	_ = viper.BindPFlag("benchmark.allow_risk", benchmarkCmd.Flags().Lookup("allow-risk"))
+	_ = viper.BindPFlag("benchmark.environment", benchmarkCmd.Flags().Lookup("env"))
+	_ = viper.BindPFlag("benchmark.output", benchmarkCmd.Flags().Lookup("output"))
+}
+
+func runBenchmark(cmd *cobra.Command, _ []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The sampling flags share the run.* keys of analyze, which the AI
+	// configuration reads as overrides for every model
+	for flag, key := range map[string]string{
+		"temperature":       "run.temperature",
+		"top-p":             "run.top_p",
+		"seed":              "run.seed",
+		"max-output-tokens": "run.max_output_tokens",
+	} {
+		if cmd.Flags().Changed(flag) {
+			viper.Set(key, cmd.Flags().Lookup(flag).Value.String())
+		}
+	}
+
+	specPath, _ := cmd.Flags().GetString("spec")
+	spec, err := parser.ParseOpenAPISpec(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	tags, _ := cmd.Flags().GetStringSlice("tags")
+	methods, _ := cmd.Flags().GetStringSlice("methods")
+	path, _ := cmd.Flags().GetString("path")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	filter, err := parser.NewFilter(parser.Selection{Tags: tags, Methods: methods, Path: path, Exclude: exclude})
+	if err != nil {
+		return err
+	}
+	endpoints := filter.Apply(spec)
+
+	env, err := loadEnvironment(viper.GetString("benchmark.environment"))
+	if err != nil {
+		return err
+	}
+	models := viper.GetStringSlice("benchmark.ai_models")
+	aiManager, err := newAIManager(models)
+	if err != nil {
+		return err
+	}
+	aiManager.SetEnvironment(env)
+
+	framework, _ := cmd.Flags().GetString("test-framework")
+	timeout, _ := cmd.Flags().GetDuration("test-timeout")
+	testGen := generator.NewTestGenerator(framework)
+	testGen.SetTimeout(timeout)
+	testGen.SetEnvironment(env)
+
+	log.Info().
+		Str("spec", specPath).
+		Strs("ai_models", models).
+		Int("endpoints", len(endpoints)).
+		Int("iterations", viper.GetInt("benchmark.iterations")).
+		Msg("Starting benchmark")
+
+	report, err := benchmark.Run(ctx, endpoints, aiManager, testGen, benchmark.Options{
+		Models:     models,
+		Iterations: viper.GetInt("benchmark.iterations"),
+		RunTests:   viper.GetBool("benchmark.run_tests"),
+		AllowRisk:  safety.Risk(viper.GetString("benchmark.allow_risk")),
+		OnSample: func(s benchmark.Sample) {
+			log.Info().
+				Str("ai_model", s.Model).
+				Str("endpoint", s.Endpoint).
+				Int("iteration", s.Iteration).
+				Dur("latency", s.Latency).
+				Bool("compiled", s.Compiled).
+				Msg("Benchmark sample")
+		},
+	})
+	if err != nil {
+		return err
+	}
+	report.Spec = specPath
+
+	output := viper.GetString("benchmark.output")
+	if err := reporter.EnsureReportDirectory(output); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := benchmark.WriteReport(report, output); err != nil {
+		return err
+	}
+	log.Info().
+		Str("output_file", output).
+		Dur("duration", report.Duration).
+		Msg("Benchmark completed")
+	return nil
+}
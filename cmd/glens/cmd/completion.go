@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"glens/pkg/modelcatalog"
+
+	"glens/tools/glens/internal/config"
+	"glens/tools/glens/internal/scaffold"
+)
+
+// flagCompletions complete the values of the flags of every command
+// defining a flag of that name. --ai-models offers the current models of the
+// model catalog; any other model name (e.g. ollama:<model>) is still accepted.
+var flagCompletions = map[string]cobra.CompletionFunc{
+	"ai-models":  completeList(func() []string { return modelcatalog.Default().Names() }),
+	"env":        completeConfig(availableEnvironments),
+	"profile":    completeConfig(func() []string { return config.ProfileNames(viper.AllSettings()) }),
+	"ci":         completeList(func() []string { return scaffold.CISystems }),
+	"by":         completeList(func() []string { return usageGroups }),
+	"log-format": cobra.FixedCompletions([]string{"console", "json"}, cobra.ShellCompDirectiveNoFileComp),
+}
+
+// registerFlagCompletions registers flagCompletions on cmd and its
+// subcommands. It runs once every command has defined its flags.
+func registerFlagCompletions(cmd *cobra.Command) {
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if complete, ok := flagCompletions[flag.Name]; ok {
+			_ = cmd.RegisterFlagCompletionFunc(flag.Name, complete)
+		}
+	})
+	for _, sub := range cmd.Commands() {
+		registerFlagCompletions(sub)
+	}
+}
+
+// completeConfig completes the names returned by names, which read the
+// config file. The config was loaded before the completed command line set
+// --config, so a file given there is read first.
+func completeConfig(names func() []string) cobra.CompletionFunc {
+	return func(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if cfgFile != "" && viper.ConfigFileUsed() != cfgFile {
+			viper.SetConfigFile(cfgFile)
+			_ = viper.ReadInConfig()
+		}
+		return names(), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeList completes the last item of a comma-separated list flag
+func completeList(choices func() []string) cobra.CompletionFunc {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		done := toComplete[:strings.LastIndex(toComplete, ",")+1]
+		var completions []cobra.Completion
+		for _, choice := range choices() {
+			if !strings.Contains(","+done, ","+choice+",") {
+				completions = append(completions, done+choice)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/config"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/secrets"
+)
+
+// unsetConfigVars lists ${VAR} references in the config file whose variable
+// is not set; `glens config validate` reports them
+var unsetConfigVars []string
+
+// sensitiveConfig holds the paths of config values interpolated from
+// variables or resolved from secret references, which `glens config show`
+// never displays
+var sensitiveConfig map[string]bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate the effective configuration",
+	Long: `Inspect the effective configuration: the config file with ${VAR}
+references expanded, the selected profile (--profile or GLENS_PROFILE)
+applied over it, and environment variables and flags on top.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration with secrets redacted",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigShow,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the effective configuration for errors",
+	Long: `Check the effective configuration: AI model settings, target
+environments, durations and enumerated values. Unset ${VAR} references are
+reported as warnings.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runConfigValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
+
+// secretsTimeout bounds resolving the secret references of one read of
+// the config
+const secretsTimeout = 30 * time.Second
+
+// configSecrets resolves the secret references of config values when a
+// command reads them, so commands that need no credentials never contact a
+// secret store
+var configSecrets = secrets.FromEnv()
+
+// layerConfig expands ${VAR} and ${VAR:-default} references in the config
+// file and merges the selected profile over it. Profiles live under the
+// profiles section and may override any other key. Secret references
+// (secretref://gcp/..., vault://...) are left for the reads that resolve
+// them: unmarshalSecretKey and the spec_fetch headers.
+func layerConfig(configLoaded bool) error {
+	profile := viper.GetString("profile")
+	if !configLoaded {
+		if profile != "" {
+			return fmt.Errorf("profile '%s' selected but no config file was found", profile)
+		}
+		return nil
+	}
+
+	file := viper.New()
+	file.SetConfigFile(viper.ConfigFileUsed())
+	if err := file.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := file.AllSettings()
+	settings, unset := config.Interpolate(raw, os.LookupEnv)
+	unsetConfigVars = unset
+	sensitiveConfig = config.Sensitive(raw, secrets.IsRef)
+
+	base := maps.Clone(settings)
+	delete(base, config.ProfilesKey)
+	if err := viper.MergeConfigMap(base); err != nil {
+		return fmt.Errorf("failed to apply config file: %w", err)
+	}
+
+	if profile == "" {
+		return nil
+	}
+	overlay, err := config.Profile(settings, profile)
+	if err != nil {
+		return err
+	}
+	rawOverlay, _ := config.Profile(raw, profile)
+	maps.Copy(sensitiveConfig, config.Sensitive(rawOverlay, secrets.IsRef))
+	if err := viper.MergeConfigMap(overlay); err != nil {
+		return fmt.Errorf("failed to apply profile '%s': %w", profile, err)
+	}
+	fmt.Fprintln(os.Stderr, "Using profile:", profile)
+	return nil
+}
+
+// unmarshalSecretKey decodes the config at key like viper.UnmarshalKey,
+// resolving the secret references of its values. Provider keys and
+// environment headers are read with it.
+func unmarshalSecretKey(key string, target any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	resolveSecrets := func(_, _ reflect.Type, data any) (any, error) {
+		if value, ok := data.(string); ok {
+			return resolveSecret(ctx, value)
+		}
+		return data, nil
+	}
+	err := viper.UnmarshalKey(key, target, func(c *mapstructure.DecoderConfig) {
+		c.DecodeHook = mapstructure.ComposeDecodeHookFunc(resolveSecrets, c.DecodeHook)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return nil
+}
+
+// secretString returns the config string at key, resolving a secret
+// reference
+func secretString(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	value, err := resolveSecret(ctx, viper.GetString(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// resolveSecret returns the secret a secret reference names, which is
+// redacted from everything glens writes, or value unchanged when it is
+// not a reference
+func resolveSecret(ctx context.Context, value string) (string, error) {
+	if !secrets.IsRef(value) {
+		return value, nil
+	}
+	secret, err := configSecrets.Resolve(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	redact.Default().AddValues(secret)
+	return secret, nil
+}
+
+// effectiveSettings returns the merged configuration without the profile
+// definitions, which have already been applied
+func effectiveSettings() map[string]any {
+	settings := viper.AllSettings()
+	delete(settings, config.ProfilesKey)
+	return settings
+}
+
+func runConfigShow(cmd *cobra.Command, _ []string) error {
+	out, err := yaml.Marshal(config.Redact(effectiveSettings(), sensitiveConfig))
+	if err != nil {
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+	_, err = cmd.OutOrStdout().Write(out)
+	return err
+}
+
+func runConfigValidate(cmd *cobra.Command, _ []string) error {
+	out := cmd.OutOrStdout()
+	problems := validateConfig()
+
+	for _, name := range unsetConfigVars {
+		_, _ = fmt.Fprintf(out, "⚠️  ${%s} is referenced in the config file but not set\n", name)
+	}
+	for _, problem := range problems {
+		_, _ = fmt.Fprintf(out, "❌ %s\n", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("configuration has %d error(s)", len(problems))
+	}
+
+	source := viper.ConfigFileUsed()
+	if source == "" {
+		source = "defaults"
+	}
+	if profile := viper.GetString("profile"); profile != "" {
+		source += ", profile " + profile
+	}
+	_, _ = fmt.Fprintf(out, "✅ Configuration is valid (%s)\n", source)
+	return nil
+}
+
+// validateConfig returns a description of every problem in the effective
+// configuration
+func validateConfig() []string {
+	var problems []string
+
+	if _, err := loadAIConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := loadPrompts(); err != nil {
+		problems = append(problems, "prompts: "+err.Error())
+	}
+
+	for _, name := range availableEnvironments() {
+		if _, err := decodeEnvironment(name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, key := range []string{"test_execution.timeout", "test_generation.timeout", "http.timeout", "serve.job_ttl", "run.endpoint_timeout", "run.total_timeout", "github.pacing", "github.max_wait"} {
+		if !viper.IsSet(key) {
+			continue
+		}
+		if _, err := time.ParseDuration(viper.GetString(key)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q is not a duration like 30s or 2m", key, viper.GetString(key)))
+		}
+	}
+	if _, err := routerFromConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := promptPolicyFromConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if cfg, err := embeddingsFromConfig(); err != nil {
+		problems = append(problems, err.Error())
+	} else if !slices.Contains([]string{"", ai.EmbedLocal, ai.EmbedOpenAI, ai.EmbedOllama}, cfg.Provider) {
+		problems = append(problems, fmt.Sprintf("embeddings.provider: unknown provider %q (use local, openai or ollama)", cfg.Provider))
+	}
+	if _, err := issueTemplatesFromConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if viper.GetInt("github.max_issues") < 0 {
+		problems = append(problems, fmt.Sprintf("github.max_issues: %d is negative (0 is no cap)", viper.GetInt("github.max_issues")))
+	}
+	if _, err := projectFromConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := notificationsFromConfig(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if scoring := scoringFromConfig(); scoring != nil {
+		if err := scoring.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	var prices []reporter.Price
+	if err := viper.UnmarshalKey("pricing", &prices); err != nil {
+		problems = append(problems, fmt.Sprintf("pricing: %v", err))
+	}
+	for i, price := range prices {
+		if price.Model == "" || price.USDPerMillionTokens < 0 {
+			problems = append(problems, fmt.Sprintf("pricing[%d]: needs a model and a non-negative usd_per_million_tokens", i))
+		}
+	}
+	if viper.GetInt("test_execution.retries") < 0 {
+		problems = append(problems, "test_execution.retries: must not be negative")
+	}
+	if viper.GetInt("test_execution.repair_attempts") < 0 {
+		problems = append(problems, "test_execution.repair_attempts: must not be negative")
+	}
+
+	ranges := map[string][2]float64{
+		"generation.temperature": {0, 2},
+		"run.temperature":        {0, 2},
+		"generation.top_p":       {0, 1},
+		"run.top_p":              {0, 1},
+	}
+	for key, bounds := range ranges {
+		if value := viper.GetFloat64(key); viper.IsSet(key) && (value < bounds[0] || value > bounds[1]) {
+			problems = append(problems, fmt.Sprintf("%s: %v must be between %v and %v", key, value, bounds[0], bounds[1]))
+		}
+	}
+
+	oneOf := map[string][]string{
+		"log_format":                   {"console", "json"},
+		"test_generation.framework":    {"testify", "ginkgo", "standard", "grpc"},
+		"reporting.output_format":      {"markdown", "json", "html"},
+		"test_execution.output_format": {"json", "text"},
+		"run.allow_risk":               {"safe", "medium", "high"},
+		"test_execution.lint.fail_on":  {"low", "medium", "high", "none"},
+		"run.ensemble":                 {analysis.EnsembleBest, analysis.EnsembleMerge},
+		"run.min_priority":             {parser.PriorityHigh, parser.PriorityNormal, parser.PriorityLow},
+	}
+	for name := range viper.GetStringMap("ai_models") {
+		oneOf["ai_models."+name+".response_format"] = []string{ai.ResponseFormatStructured, ai.ResponseFormatText}
+		if strings.HasPrefix(name, ai.DefaultOllamaConfig) {
+			oneOf["ai_models."+name+".api"] = []string{ai.OllamaGenerateAPI, ai.OllamaChatAPI}
+		}
+	}
+	for key, allowed := range oneOf {
+		if value := viper.GetString(key); value != "" && !slices.Contains(allowed, value) {
+			problems = append(problems, fmt.Sprintf("%s: %q must be one of %v", key, value, allowed))
+		}
+	}
+
+	for category, threshold := range viper.GetStringMapString("ai_models.google.safety_settings") {
+		if !slices.Contains(ai.GoogleSafetyThresholds, strings.ToUpper(threshold)) {
+			problems = append(problems, fmt.Sprintf("ai_models.google.safety_settings.%s: %q must be one of %v", category, threshold, ai.GoogleSafetyThresholds))
+		}
+	}
+
+	for _, tool := range viper.GetStringSlice("test_execution.lint.tools") {
+		if !slices.Contains(generator.DefaultLintTools, tool) {
+			problems = append(problems, fmt.Sprintf("test_execution.lint.tools: %q must be one of %v", tool, generator.DefaultLintTools))
+		}
+	}
+
+	if _, err := parseServerVariables(viper.GetStringSlice("run.server_variables")); err != nil {
+		problems = append(problems, "run.server_variables: "+err.Error())
+	}
+
+	if addr := viper.GetString("metrics_listen"); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			problems = append(problems, fmt.Sprintf("metrics_listen: %q is not a host:port address", addr))
+		}
+	}
+
+	slices.Sort(problems)
+	return problems
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"glens/tools/glens/internal/corpus"
+)
+
+var corpusCmd = &cobra.Command{
+	Use:   "corpus",
+	Short: "Check the spec parser against its built-in spec corpus",
+	Long: `Developer commands for the corpus of specs built into glens: OpenAPI 3.0
+and 3.1, Swagger 2, and excerpts of the GitHub and Stripe APIs, each with
+the golden summary of what the parser extracts from it.`,
+	Hidden: true,
+}
+
+var corpusRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Report the parser's extraction accuracy against the golden summaries",
+	Long: `Parses every spec of the corpus and compares the endpoints, parameters,
+media types, responses, schema references, security schemes, pagination and
+warnings the parser extracts with the golden summaries: precision, recall
+and the facts missing from or added to the extraction.
+
+Refresh the golden summaries of an intended change with
+  go test ./internal/corpus -update
+
+Examples:
+  glens corpus run
+  glens corpus run --strict --output=json`,
+	Args: cobra.NoArgs,
+	RunE: runCorpus,
+}
+
+func init() {
+	rootCmd.AddCommand(corpusCmd)
+	corpusCmd.AddCommand(corpusRunCmd)
+
+	corpusRunCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+	corpusRunCmd.Flags().Bool("strict", false, "Fail when the extraction of any spec differs from its golden summary")
+}
+
+func runCorpus(cmd *cobra.Command, _ []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	strict, _ := cmd.Flags().GetBool("strict")
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (use table or json)", output)
+	}
+
+	results, err := corpus.Run()
+	if err != nil {
+		return fmt.Errorf("failed to read the spec corpus: %w", err)
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else if err := writeCorpusTable(cmd.OutOrStdout(), results); err != nil {
+		return err
+	}
+
+	if strict {
+		changed := 0
+		for i := range results {
+			if results[i].Changed() {
+				changed++
+			}
+		}
+		if changed > 0 {
+			return fmt.Errorf("extraction of %d of %d corpus specs differs from the golden summaries", changed, len(results))
+		}
+	}
+	return nil
+}
+
+func writeCorpusTable(out io.Writer, results []corpus.Result) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SPEC\tENDPOINTS\tFACTS\tPRECISION\tRECALL\tDELTA")
+	for i := range results {
+		r := &results[i]
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(w, "%s\t-\t-\t-\t-\terror: %s\n", r.File, r.Error)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d/%d\t%.1f%%\t%.1f%%\t-%d +%d\n",
+			r.File, r.Endpoints, r.Matched, r.Expected, r.Precision*100, r.Recall*100, len(r.Missing), len(r.Unexpected))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for i := range results {
+		r := &results[i]
+		if len(r.Missing) == 0 && len(r.Unexpected) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "\n%s:\n", r.File)
+		for _, fact := range r.Missing {
+			_, _ = fmt.Fprintf(out, "  - %s\n", fact)
+		}
+		for _, fact := range r.Unexpected {
+			_, _ = fmt.Fprintf(out, "  + %s\n", fact)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"glens/tools/glens/internal/reporter"
+)
+
+var coverageCmd = &cobra.Command{
+	Use:   "coverage <report.json>",
+	Short: "Show which endpoints each model generated, compiled, executed and passed",
+	Long: `Renders the coverage map of a JSON report written by analyze: a matrix of
+every endpoint of the spec against every model, with how far each test got
+(missing, generated, compile_failed, skipped, failed or passed), followed by
+totals per HTTP method and per tag. Endpoints the run did not analyze show
+as missing, so gaps on large specs stand out.
+
+The CSV format has one row per endpoint and model, for spreadsheets.
+
+Examples:
+  glens coverage reports/report.json
+  glens coverage reports/report.json --format=markdown
+  glens coverage reports/report.json --output=coverage.csv`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCoverage,
+}
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+
+	coverageCmd.Flags().String("format", "table", "Output format (table, markdown or csv); inferred from --output's extension when not set")
+	coverageCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+}
+
+func runCoverage(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	if !cmd.Flags().Changed("format") {
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".csv":
+			format = "csv"
+		case ".md":
+			format = "markdown"
+		}
+	}
+	if !slices.Contains([]string{"table", "markdown", "csv"}, format) {
+		return fmt.Errorf("unsupported format %q (use table, markdown or csv)", format)
+	}
+
+	report, err := reporter.ReadReport(args[0])
+	if err != nil {
+		return err
+	}
+	// Reports written before the coverage map was added are mapped from
+	// their results
+	coverage := report.Coverage
+	if coverage == nil {
+		models := slices.Sorted(slices.Values(report.Summary.AIModelsUsed))
+		coverage = reporter.BuildCoverage(&report.Specification, report.EndpointResults, models)
+	}
+
+	out := cmd.OutOrStdout()
+	if output != "" {
+		if err := reporter.EnsureReportDirectory(output); err != nil {
+			return err
+		}
+		f, err := os.Create(output) // #nosec G304 -- the path is chosen by the user
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer f.Close() //nolint:errcheck
+		out = f
+	}
+
+	switch format {
+	case "csv":
+		err = reporter.WriteCoverageCSV(out, coverage)
+	case "markdown":
+		_, err = io.WriteString(out, reporter.RenderCoverageMarkdown(coverage))
+	default:
+		err = printCoverage(out, coverage)
+	}
+	if err != nil {
+		return err
+	}
+	if f, ok := out.(*os.File); ok && output != "" {
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Coverage written to %s\n", output)
+	}
+	return nil
+}
+
+// printCoverage writes coverage as aligned text tables
+func printCoverage(out io.Writer, coverage *reporter.Coverage) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "ENDPOINT\t%s\n", strings.Join(coverage.Models, "\t"))
+	for _, row := range coverage.Endpoints {
+		cells := make([]string, len(coverage.Models))
+		for i, model := range coverage.Models {
+			cells[i] = string(row.Status[model])
+		}
+		_, _ = fmt.Fprintf(tw, "%s %s\t%s\n", row.Method, row.Path, strings.Join(cells, "\t"))
+	}
+	_, _ = fmt.Fprintln(tw)
+
+	for _, section := range []struct {
+		column string
+		totals []reporter.CoverageTotals
+	}{
+		{"METHOD", coverage.ByMethod},
+		{"TAG", coverage.ByTag},
+	} {
+		_, _ = fmt.Fprintf(tw, "%s\tENDPOINTS\tGENERATED\tCOMPILED\tEXECUTED\tPASSED\n", section.column)
+		for _, t := range section.totals {
+			printCoverageTotals(tw, t.Name, t)
+		}
+		printCoverageTotals(tw, "total", coverage.Total)
+		_, _ = fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// printCoverageTotals writes a row of totals named name
+func printCoverageTotals(out io.Writer, name string, t reporter.CoverageTotals) {
+	_, _ = fmt.Fprintf(out, "%s\t%d\t%d/%d\t%d/%d\t%d/%d\t%d/%d\n", name, t.Endpoints,
+		t.Generated, t.Tests, t.Compiled, t.Tests, t.Executed, t.Tests, t.Passed, t.Tests)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"glens/tools/glens/internal/parser"
+)
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover [base-url] [-- analyze flags]",
+	Short: "Find the OpenAPI specs a service publishes",
+	Long: `Probes the common spec locations of a base URL (/openapi.json,
+/swagger.json, /v3/api-docs, /.well-known/openapi, ...) and the service
+index pages listing specs (the /.well-known/api-catalog API catalog,
+apis.json, springdoc's swagger-config and springfox's swagger-resources),
+and lists the specs found. Spec downloads use the spec_fetch settings and
+--spec-header.
+
+With --analyze the specs found are analyzed straight away; flags after --
+are passed to analyze.
+
+Examples:
+  glens discover https://api.example.com
+  glens discover https://api.example.com/orders --output=json
+  glens discover https://api.example.com --analyze -- --ai-models=gpt-4o --run-tests=false`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runDiscover,
+}
+
+func init() {
+	rootCmd.AddCommand(discoverCmd)
+
+	discoverCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+	discoverCmd.Flags().Bool("analyze", false, "Analyze the specs found (flags after -- are passed to analyze)")
+}
+
+func runDiscover(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	analyze, _ := cmd.Flags().GetBool("analyze")
+
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (use table or json)", output)
+	}
+	var analyzeArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		analyzeArgs = args[dash:]
+		args = args[:dash]
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("discover takes one base URL, got %d", len(args))
+	}
+	if len(analyzeArgs) > 0 && !analyze {
+		return errors.New("flags after -- are passed to analyze and need --analyze")
+	}
+
+	specs, err := parser.Discover(args[0])
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(specs); err != nil {
+			return err
+		}
+	} else if err := writeDiscoveredTable(cmd.OutOrStdout(), specs); err != nil {
+		return err
+	}
+
+	if len(specs) == 0 {
+		return fmt.Errorf("no OpenAPI spec found at %s", args[0])
+	}
+	if !analyze {
+		return nil
+	}
+	return analyzeDiscovered(specs, analyzeArgs)
+}
+
+func writeDiscoveredTable(out io.Writer, specs []parser.DiscoveredSpec) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "URL\tTITLE\tVERSION\tOPENAPI\tENDPOINTS\tLISTED BY")
+	for _, s := range specs {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			s.URL, orDash(s.Title), orDash(s.Version), s.OpenAPI, s.Endpoints, orDash(s.Via))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%d specs found\n", len(specs))
+	return err
+}
+
+// analyzeDiscovered runs analyze over the discovered specs; several specs
+// are analyzed as a portfolio
+func analyzeDiscovered(specs []parser.DiscoveredSpec, analyzeArgs []string) error {
+	if err := analyzeCmd.ParseFlags(analyzeArgs); err != nil {
+		return fmt.Errorf("analyze flags: %w", err)
+	}
+	if extra := analyzeCmd.Flags().Args(); len(extra) > 0 {
+		return fmt.Errorf("analyze flags: unexpected arguments %v", extra)
+	}
+	urls := make([]string, len(specs))
+	for i, s := range specs {
+		urls[i] = s.URL
+	}
+	log.Info().Strs("specs", urls).Msg("Analyzing discovered specs")
+	return runAnalyze(analyzeCmd, urls)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/parser"
+)
+
+var endpointsCmd = &cobra.Command{
+	Use:   "endpoints [openapi-url]",
+	Short: "List the endpoints of an OpenAPI specification",
+	Long: `Lists every endpoint of a spec with its method, path, operation ID, tags
+and risk, sorted by path. Use the operation IDs as --op-id targets. Parts
+of the spec that could not be understood are listed on stderr.
+
+Examples:
+  glens endpoints spec.json --tag=users
+  glens endpoints spec.json --method=post --method=put
+  glens endpoints spec.json --path-glob='/admin/**' --output=json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEndpoints,
+}
+
+func init() {
+	rootCmd.AddCommand(endpointsCmd)
+
+	endpointsCmd.Flags().StringSlice("tag", nil, "Only endpoints with one of these tags (repeatable)")
+	endpointsCmd.Flags().StringSlice("method", nil, "Only endpoints with one of these HTTP methods (repeatable)")
+	endpointsCmd.Flags().String("path-glob", "", "Only paths matching this glob (* within a segment, ** across segments) or re:<regexp>")
+	endpointsCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+}
+
+// endpointRow is one listed endpoint
+type endpointRow struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind,omitempty"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	OperationID string   `json:"operation_id,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	Category    string   `json:"category"`
+	RiskLevel   string   `json:"risk_level"`
+}
+
+func runEndpoints(cmd *cobra.Command, args []string) error {
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	methods, _ := cmd.Flags().GetStringSlice("method")
+	pathGlob, _ := cmd.Flags().GetString("path-glob")
+	output, _ := cmd.Flags().GetString("output")
+
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (use table or json)", output)
+	}
+	filter, err := parser.NewFilter(parser.Selection{Tags: tags, Methods: methods, Path: pathGlob})
+	if err != nil {
+		return err
+	}
+
+	spec, err := parser.ParseOpenAPISpec(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	writeSpecWarnings(cmd.ErrOrStderr(), spec)
+
+	matched := filter.Apply(spec)
+	rows := make([]endpointRow, len(matched))
+	for i := range matched {
+		e := &matched[i]
+		category := safety.Categorise(e.Method, e.Path, e.XSafe).WithRisk(e.DeclaredRisk())
+		rows[i] = endpointRow{
+			ID:          e.ID,
+			Kind:        e.Kind,
+			Method:      e.Method,
+			Path:        e.Path,
+			OperationID: e.OperationID,
+			Summary:     e.Summary,
+			Tags:        e.Tags,
+			Deprecated:  e.Deprecated,
+			Category:    string(category.Category),
+			RiskLevel:   string(category.Risk),
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Path != rows[j].Path {
+			return rows[i].Path < rows[j].Path
+		}
+		return rows[i].Method < rows[j].Method
+	})
+
+	if output == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	return writeEndpointsTable(cmd.OutOrStdout(), rows, len(spec.Endpoints))
+}
+
+func writeEndpointsTable(out io.Writer, rows []endpointRow, total int) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METHOD\tPATH\tOPERATION ID\tTAGS\tCATEGORY\tRISK")
+	for _, r := range rows {
+		path := r.Path
+		if r.Kind != "" {
+			path += " [" + r.Kind + "]"
+		}
+		if r.Deprecated {
+			path += " [deprecated]"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Method, path, orDash(r.OperationID), orDash(strings.Join(r.Tags, ",")), r.Category, r.RiskLevel)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%d of %d endpoints\n", len(rows), total)
+	return err
+}
+
+// writeSpecWarnings lists the parts of spec the parser skipped, with
+// where they are in the spec file
+func writeSpecWarnings(out io.Writer, spec *parser.OpenAPISpec) {
+	if len(spec.Warnings) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(out, "%d spec warning(s), skipped parts may make tests incomplete:\n", len(spec.Warnings))
+	for _, warning := range spec.Warnings {
+		_, _ = fmt.Fprintf(out, "  %s: %s [%s]\n", spec.Location(warning.Position), warning.Message, warning.Code)
+	}
+	_, _ = fmt.Fprintln(out)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+)
+
+// loadEnvironment resolves the named entry of the environments config section.
+// An empty name selects no environment and returns nil.
+func loadEnvironment(name string) (*environment.Environment, error) {
+	if name == "" {
+		return nil, nil //nolint:nilnil // no environment selected is not an error
+	}
+
+	key := "environments." + name
+	if !viper.IsSet(key) {
+		return nil, fmt.Errorf("environment '%s' not found in config. Available environments: %v", name, availableEnvironments())
+	}
+
+	env, err := decodeEnvironment(name)
+	if err != nil {
+		return nil, err
+	}
+
+	redact.Default().AddValues(env.Secrets()...)
+
+	log.Info().
+		Str("environment", env.Name).
+		Str("base_url", env.BaseURL).
+		Str("auth", string(env.Auth.Type)).
+		Bool("insecure_skip_verify", env.InsecureSkipVerify).
+		Msg("Target environment selected")
+
+	return env, nil
+}
+
+// decodeEnvironment reads and resolves the named environment without
+// logging it
+func decodeEnvironment(name string) (*environment.Environment, error) {
+	var env environment.Environment
+	if err := unmarshalSecretKey("environments."+name, &env); err != nil {
+		return nil, err
+	}
+	env.Name = name
+
+	if err := env.Resolve(); err != nil {
+		return nil, fmt.Errorf("invalid environment '%s': %w", name, err)
+	}
+	return &env, nil
+}
+
+// availableEnvironments lists the environment names defined in config
+func availableEnvironments() []string {
+	names := make([]string, 0)
+	for name := range viper.GetStringMap("environments") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// serverEnvironment points env at the spec server selected by ref, with its
+// URL variables resolved. Without an environment the server becomes one
+// without headers or auth. A relative server URL is resolved against the
+// spec's own URL.
+func serverEnvironment(spec *parser.OpenAPISpec, source, ref string, variables []string, env *environment.Environment) (*environment.Environment, error) {
+	server, err := spec.Server(ref)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseServerVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := server.ResolveURL(values)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL %q: %w", baseURL, err)
+	}
+	if !u.IsAbs() {
+		base, err := url.Parse(source)
+		if err != nil || !base.IsAbs() {
+			return nil, fmt.Errorf("server URL %q is relative to the spec, which is not loaded from a URL: select an environment with --env instead", baseURL)
+		}
+		u = base.ResolveReference(u)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q: must be an absolute URL", baseURL)
+	}
+
+	if env == nil {
+		env = &environment.Environment{Name: "server " + ref}
+	} else {
+		copied := *env
+		env = &copied
+	}
+	env.BaseURL = strings.TrimSuffix(u.String(), "/")
+
+	log.Info().
+		Str("server", ref).
+		Str("base_url", env.BaseURL).
+		Msg("Target server selected")
+	return env, nil
+}
+
+// parseServerVariables parses "name=value" server variable assignments
+func parseServerVariables(assignments []string) (map[string]string, error) {
+	values := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("server variable %q is not name=value", assignment)
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/term"
+
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/search"
+)
+
+// resolveFocus returns the IDs of the endpoints of the spec at source that
+// focus describes in natural language, ranked by the embeddings of the
+// embeddings config section. Only endpoints the run would analyze anyway
+// are candidates: those of the selection, not marked x-glens-skip and, unless
+// included, not deprecated. The selection is listed on out and, unless yes,
+// must be confirmed on in.
+func resolveFocus(ctx context.Context, source, focus string, opts analysis.Options, yes bool, in io.Reader, out io.Writer) ([]string, error) {
+	spec, err := parser.ParseOpenAPISpec(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	filter, err := parser.NewFilter(opts.Selection)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []parser.Endpoint
+	for _, e := range filter.Select(spec.Endpoints) {
+		if _, skipped := e.Skipped(); skipped || (e.Deprecated && !opts.IncludeDeprecated) {
+			continue
+		}
+		candidates = append(candidates, e)
+	}
+	spec.Endpoints = candidates
+
+	embedder, err := newEmbedder()
+	if err != nil {
+		return nil, err
+	}
+	index, _, err := search.Build(ctx, embedder, spec, embeddingsCacheDir())
+	if err != nil {
+		return nil, err
+	}
+	matches, err := index.Focus(ctx, embedder, spec, focus)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no endpoints match the focus %q; list them with glens search", focus)
+	}
+
+	log.Info().
+		Str("focus", focus).
+		Str("embedder", embedder.Name()).
+		Int("selected", len(matches)).
+		Int("total", len(candidates)).
+		Msg("Resolved analysis focus")
+	if err := writeFocus(out, focus, matches, len(candidates)); err != nil {
+		return nil, err
+	}
+	if !yes {
+		f, ok := in.(*os.File)
+		if !ok || !term.IsTerminal(int(f.Fd())) {
+			return nil, fmt.Errorf("--focus asks to confirm its selection on a terminal; pass --yes to analyze it unattended")
+		}
+		p := &prompter{in: bufio.NewReader(f), out: out}
+		answer := strings.ToLower(p.ask(fmt.Sprintf("Analyze these %d endpoints? [y/N]", len(matches)), ""))
+		if p.err != nil {
+			return nil, fmt.Errorf("failed to read answer: %w", p.err)
+		}
+		if answer != "y" && answer != "yes" {
+			return nil, fmt.Errorf("analysis cancelled")
+		}
+	}
+
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.Endpoint.ID
+	}
+	return ids, nil
+}
+
+// writeFocus lists the endpoints selected by focus
+func writeFocus(out io.Writer, focus string, matches []search.Match, total int) error {
+	_, _ = fmt.Fprintf(out, "Focus %q selects %d of %d endpoints:\n", focus, len(matches), total)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, m := range matches {
+		_, _ = fmt.Fprintf(w, "  %.3f\t%s\t%s\t%s\n", m.Score, m.Endpoint.Method, m.Endpoint.Path, orDash(m.Endpoint.OperationID))
+	}
+	return w.Flush()
+}
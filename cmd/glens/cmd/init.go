@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"glens/tools/glens/internal/scaffold"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Scaffold the glens config and CI pipelines of a project",
+	Long: `Writes the starting point of a project using glens:
+
+  .glens/config.yaml            AI providers, the environment tests run against
+                                and the GitHub repository receiving issues
+  .glens/.gitignore             keeps the spec cache and reports out of git
+  .github/workflows/glens.yml   with --ci=github
+  .gitlab-ci.yml                with --ci=gitlab
+
+The pipelines install the released glens binary, verify its checksum and run
+glens analyze when the spec or the config changes (weekly for a remote spec).
+glens reads .glens/config.yaml when run from the project directory.
+
+Values not given as flags are asked for when stdin is a terminal; --yes
+takes the defaults instead. Existing files are left alone unless --force.
+
+Examples:
+  glens init
+  glens init --spec api/openapi.yaml --ai-models gpt4 --ci github --yes
+  glens init --spec https://api.example.com/openapi.json --env staging \
+    --base-url https://staging.example.com --repository acme/pets --ci gitlab`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().String("dir", ".", "Project directory to scaffold")
+	initCmd.Flags().String("spec", "", "Path or URL of the OpenAPI spec the pipelines analyze")
+	initCmd.Flags().StringSlice("ai-models", []string{"gpt4"}, "AI models generating the tests")
+	initCmd.Flags().String("env", "", "Name of the environment tests run against")
+	initCmd.Flags().String("base-url", "", "Base URL of the environment")
+	initCmd.Flags().String("repository", "", "GitHub repository (owner/repo) receiving issues; empty disables them")
+	initCmd.Flags().StringSlice("ci", nil, "CI pipelines to write (github, gitlab)")
+	initCmd.Flags().Bool("force", false, "Overwrite existing files")
+	initCmd.Flags().BoolP("yes", "y", false, "Take the defaults instead of asking for unset values")
+}
+
+func runInit(cmd *cobra.Command, _ []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	force, _ := cmd.Flags().GetBool("force")
+	yes, _ := cmd.Flags().GetBool("yes")
+	opts := scaffold.Options{Version: cmd.Root().Version}
+	opts.Spec, _ = cmd.Flags().GetString("spec")
+	opts.Models, _ = cmd.Flags().GetStringSlice("ai-models")
+	opts.Environment, _ = cmd.Flags().GetString("env")
+	opts.BaseURL, _ = cmd.Flags().GetString("base-url")
+	opts.Repository, _ = cmd.Flags().GetString("repository")
+	opts.CI, _ = cmd.Flags().GetStringSlice("ci")
+
+	if f, ok := cmd.InOrStdin().(*os.File); ok && !yes && term.IsTerminal(int(f.Fd())) {
+		p := &prompter{in: bufio.NewReader(f), out: cmd.OutOrStdout()}
+		ask := func(flag, question string, value *string) {
+			if !cmd.Flags().Changed(flag) {
+				*value = p.ask(question, *value)
+			}
+		}
+		ask("spec", "OpenAPI spec (path or URL)", &opts.Spec)
+		if !cmd.Flags().Changed("ai-models") {
+			opts.Models = splitList(p.ask("AI models (comma-separated)", strings.Join(opts.Models, ",")))
+		}
+		ask("env", "Environment name (empty for none)", &opts.Environment)
+		if opts.Environment != "" {
+			ask("base-url", "Base URL of "+opts.Environment, &opts.BaseURL)
+		}
+		ask("repository", "GitHub repository for issues, owner/repo (empty for none)", &opts.Repository)
+		if !cmd.Flags().Changed("ci") {
+			opts.CI = splitList(p.ask("CI pipelines: github, gitlab (empty for none)", strings.Join(opts.CI, ",")))
+		}
+		if p.err != nil {
+			return fmt.Errorf("failed to read answers: %w", p.err)
+		}
+	}
+
+	files, err := scaffold.Render(opts)
+	if err != nil {
+		return err
+	}
+	if err := scaffold.Write(dir, files, force); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	for _, f := range files {
+		_, _ = fmt.Fprintf(out, "Wrote %s\n", filepath.Join(dir, f.Path))
+	}
+	_, _ = fmt.Fprintln(out, "Set the API keys of your AI providers, then run: glens analyze", opts.Spec)
+	return nil
+}
+
+// prompter asks questions on a terminal, keeping the first read error
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	err error
+}
+
+// ask prints question and returns the answer, or def when it is empty
+func (p *prompter) ask(question, def string) string {
+	if p.err != nil {
+		return def
+	}
+	if def != "" {
+		_, _ = fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		_, _ = fmt.Fprintf(p.out, "%s: ", question)
+	}
+	answer, err := p.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		p.err = err
+	}
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// splitList splits a comma-separated answer, dropping empty items
+func splitList(answer string) []string {
+	var items []string
+	for _, item := range strings.Split(answer, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/mcp"
+	"glens/tools/glens/internal/reporter"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Model Context Protocol server for AI agents",
+	Long:  `Commands for exposing glens to AI agents over the Model Context Protocol.`,
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve glens tools over MCP (stdio or HTTP+SSE)",
+	Long: `Serves glens as a Model Context Protocol server so agents such as
+Claude Desktop or IDE assistants can orchestrate test generation:
+
+  parse_spec      summarise an OpenAPI spec
+  list_endpoints  list endpoints with operation IDs and risk levels
+  generate_test   generate a test for one endpoint with an AI model
+  run_test        run test code against the --env target environment
+  create_report   analyze a whole spec and return the report
+
+With --transport stdio (the default) messages are read from stdin and
+written to stdout; logs go to stderr. With --transport sse agents connect
+to GET /sse and post messages to the endpoint it announces, sending
+"Authorization: Bearer <token>" with the --token (or GLENS_MCP_TOKEN) the
+server requires. Requests must address the server by a loopback name and
+come from loopback origins, which keeps web pages from reaching it through
+DNS rebinding; --allowed-hosts adds the names of a server listening beyond
+loopback.
+
+Claude Desktop configuration:
+  {"mcpServers": {"glens": {"command": "glens",
+    "args": ["mcp", "serve", "--ai-models=gpt4"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: runMCPServe,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+	mcpCmd.AddCommand(mcpServeCmd)
+
+	mcpServeCmd.Flags().String("transport", "stdio", "MCP transport (stdio, sse)")
+	mcpServeCmd.Flags().String("host", "127.0.0.1", "Interface to listen on with --transport sse")
+	mcpServeCmd.Flags().Int("port", 8090, "Port to listen on with --transport sse")
+	mcpServeCmd.Flags().StringSlice("ai-models", []string{"gpt4"}, "AI models offered to agents")
+	mcpServeCmd.Flags().String("env", "", "Target environment run_test and create_report execute against")
+	mcpServeCmd.Flags().String("token", "", "Bearer token agents must send with --transport sse (or GLENS_MCP_TOKEN)")
+	mcpServeCmd.Flags().StringSlice("allowed-hosts", nil, "Host names besides loopback that --transport sse requests may address and come from")
+
+	// Dedicated keys so mcp flags do not shadow the analyze bindings
+	_ = viper.BindPFlag("mcp.transport", mcpServeCmd.Flags().Lookup("transport"))
+	_ = viper.BindPFlag("mcp.host", mcpServeCmd.Flags().Lookup("host"))
+	_ = viper.BindPFlag("mcp.port", mcpServeCmd.Flags().Lookup("port"))
+	_ = viper.BindPFlag("mcp.ai_models", mcpServeCmd.Flags().Lookup("ai-models"))
+	_ = viper.BindPFlag("mcp.environment", mcpServeCmd.Flags().Lookup("env"))
+	_ = viper.BindPFlag("mcp.token", mcpServeCmd.Flags().Lookup("token"))
+	_ = viper.BindPFlag("mcp.allowed_hosts", mcpServeCmd.Flags().Lookup("allowed-hosts"))
+	_ = viper.BindEnv("mcp.token", "GLENS_MCP_TOKEN")
+}
+
+func runMCPServe(cmd *cobra.Command, _ []string) error {
+	models := viper.GetStringSlice("mcp.ai_models")
+	aiManager, err := newAIManager(models)
+	if err != nil {
+		return err
+	}
+
+	env, err := loadEnvironment(viper.GetString("mcp.environment"))
+	if err != nil {
+		return err
+	}
+	aiManager.SetEnvironment(env)
+
+	base := analysisOptionsFromConfig()
+	base.Models = models
+	base.CreateIssues = false
+	base.Output = ""
+	base.Env = env
+
+	testGen := generator.NewTestGenerator(base.Framework)
+	testGen.SetTimeout(viper.GetDuration("test_execution.timeout"))
+	testGen.SetRetries(viper.GetInt("test_execution.retries"))
+	testGen.SetEnvironment(env)
+
+	srv := mcp.New(mcp.Info{Name: "glens", Version: cmd.Root().Version}, mcp.Tools(mcp.Backend{
+		AI:        aiManager,
+		Models:    models,
+		Generator: testGen,
+		Report:    mcpReport(aiManager, base),
+	})...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch transport := viper.GetString("mcp.transport"); transport {
+	case "stdio":
+		log.Info().Strs("ai_models", models).Msg("Serving MCP on stdio")
+		return srv.ServeStdio(ctx, os.Stdin, os.Stdout)
+	case "sse":
+		return serveMCPSSE(ctx, srv)
+	default:
+		return fmt.Errorf("unsupported MCP transport: %s (supported: stdio, sse)", transport)
+	}
+}
+
+// mcpReport runs create_report through the analysis pipeline
+func mcpReport(aiManager *ai.Manager, base analysisOptions) func(context.Context, mcp.ReportRequest) (*reporter.Report, error) {
+	return func(ctx context.Context, req mcp.ReportRequest) (*reporter.Report, error) {
+		opts := base
+		if len(req.Models) > 0 {
+			opts.Models = req.Models
+		}
+		opts.Approved = req.Endpoints
+		opts.RunTests = req.RunTests
+		return runAnalysis(ctx, req.SpecURL, opts, aiManager)
+	}
+}
+
+func serveMCPSSE(ctx context.Context, srv *mcp.Server) error {
+	token := viper.GetString("mcp.token")
+	if token == "" {
+		return errors.New("MCP over SSE runs generated code on this host and requires a token: set --token or GLENS_MCP_TOKEN")
+	}
+	httpServer := &http.Server{
+		Addr: net.JoinHostPort(viper.GetString("mcp.host"), strconv.Itoa(viper.GetInt("mcp.port"))),
+		Handler: srv.SSEHandler(mcp.SSEOptions{
+			Token:        token,
+			AllowedHosts: viper.GetStringSlice("mcp.allowed_hosts"),
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Event streams end with ctx so Shutdown does not wait on them
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	log.Info().Str("addr", httpServer.Addr).Msg("Serving MCP over SSE on /sse")
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("MCP server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down MCP server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/telemetry"
+)
+
+// startMetricsServer serves /metrics on --metrics-listen for the lifetime of
+// the process. Binding happens up front so a busy port fails the command.
+func startMetricsServer(_ *cobra.Command, _ []string) error {
+	addr := viper.GetString("metrics_listen")
+	if addr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", telemetry.Registry.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("metrics server failed")
+		}
+	}()
+
+	log.Info().Str("addr", listener.Addr().String()).Msg("serving Prometheus metrics on /metrics")
+	return nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"glens/tools/glens/internal/mockserver"
+	"glens/tools/glens/internal/parser"
+)
+
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Mock API server derived from an OpenAPI specification",
+	Long:  `Commands for running a mock API server that answers from an OpenAPI specification.`,
+}
+
+var mockServeCmd = &cobra.Command{
+	Use:   "serve [openapi-url]",
+	Short: "Serve example responses for every documented endpoint",
+	Long: `Starts an HTTP server that answers every documented path and method with
+the example (or a schema-generated value) of its lowest 2xx response.
+
+Paths are served under the base path of the spec's first server (such as
+/v1) and at the root. Recursive schemas are generated two levels deep, the
+second with its required properties only.
+
+Choose another documented response with the Prefer header:
+  Prefer: code=404          # respond with the documented 404
+  Prefer: example=notFound  # use a named example of the response
+
+The default port matches the base URL generated tests use, so
+  glens mock serve spec.json &
+  glens analyze spec.json --ai-models=mock
+executes tests without a real backend; with another --port, point the tests
+at it with GLENS_BASE_URL=http://127.0.0.1:<port>.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMockServe,
+}
+
+func init() {
+	rootCmd.AddCommand(mockCmd)
+	mockCmd.AddCommand(mockServeCmd)
+
+	mockServeCmd.Flags().String("host", "127.0.0.1", "Interface to listen on")
+	mockServeCmd.Flags().Int("port", 8080, "Port to listen on")
+}
+
+func runMockServe(cmd *cobra.Command, args []string) error {
+	host, _ := cmd.Flags().GetString("host")
+	port, _ := cmd.Flags().GetInt("port")
+
+	spec, err := parser.ParseOpenAPISpec(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(host, strconv.Itoa(port)),
+		Handler:           mockserver.New(spec),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	log.Info().
+		Str("addr", srv.Addr).
+		Str("spec", spec.Info.Title).
+		Int("endpoints", len(spec.Endpoints)).
+		Msg("Mock server listening")
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("mock server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("Shutting down mock server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down mock server: %w", err)
+	}
+	return nil
+}
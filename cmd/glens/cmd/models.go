@@ -2,13 +2,18 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"glens/pkg/modelcatalog"
+
 	"glens/tools/glens/internal/ai"
 )
 
@@ -22,8 +27,14 @@ Supports listing available models, checking status, and downloading models.`,
 var modelsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available AI models",
-	Long:  `List all available AI models including local Ollama models and cloud providers.`,
-	RunE:  runModelsList,
+	Long: `List the models of the model catalog with their aliases, provider, context
+window, whether prompts stay on this machine, their input and output price in
+USD per million tokens (list prices, or those of the pricing config section)
+and deprecations, followed by the models installed in Ollama.
+
+--output=json lists the models the way GET /api/v1/models of glens serve
+describes them.`,
+	RunE: runModelsList,
 }
 
 var modelsStatusCmd = &cobra.Command{
@@ -78,46 +89,60 @@ func init() {
 	modelsCmd.AddCommand(modelsStatusCmd)
 	modelsCmd.AddCommand(modelsOllamaCmd)
 
+	modelsListCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+
 	// Add Ollama subcommands
 	modelsOllamaCmd.AddCommand(modelsOllamaListCmd)
 	modelsOllamaCmd.AddCommand(modelsOllamaStatusCmd)
 	modelsOllamaCmd.AddCommand(modelsOllamaPullCmd)
 }
 
-func runModelsList(_ *cobra.Command, _ []string) error {
+// modelRow is one model of glens models list, described like the models of
+// GET /api/v1/models
+type modelRow struct {
+	ai.ModelInfo
+	Aliases     []string `json:"aliases,omitempty"`
+	Description string   `json:"description"`
+	// Price is the list price, or that of the pricing config section
+	Price *modelcatalog.Price `json:"price,omitempty"`
+}
+
+func runModelsList(cmd *cobra.Command, _ []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (use table or json)", output)
+	}
+	prices, err := pricesFromConfig()
+	if err != nil {
+		return err
+	}
+	var rows []modelRow
+	for _, m := range modelcatalog.Default().Models() {
+		row := modelRow{ModelInfo: ai.DescribeModel(m), Aliases: m.Aliases, Description: m.Description}
+		if price, ok := prices[m.ID]; ok {
+			row.Price = &price
+		}
+		rows = append(rows, row)
+	}
+	if output == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
 	fmt.Println("📋 Available AI Models")
 	fmt.Println("=====================")
-
-	// Cloud providers
-	fmt.Println("\n🌐 Cloud Providers (require API keys):")
-	fmt.Println("  • gpt4         - OpenAI GPT-4 Turbo")
-	fmt.Println("  • sonnet4      - Anthropic Claude 3.5 Sonnet")
-	fmt.Println("  • flash-pro    - Google Gemini 1.5 Flash Pro")
-	fmt.Println("  • mistral      - Mistral AI (cloud)")
-
-	// Local open-source model shortcuts
-	fmt.Println("\n🔓 Local Open-Source Models (no cloud/API-key required):")
-	fmt.Println("  Mistral:")
-	fmt.Println("    • mistral-local, mistral7b    → mistral (7B)")
-	fmt.Println("    • mistral-nemo-local          → mistral-nemo (12B)")
-	fmt.Println("    • mistral-small-local         → mistral-small")
-	fmt.Println("  Meta Llama:")
-	fmt.Println("    • llama3, llama3-local        → llama3")
-	fmt.Println("    • llama3.1, llama3.1-local    → llama3.1")
-	fmt.Println("    • llama3.2, llama3.2-local    → llama3.2")
-	fmt.Println("  Microsoft Phi:")
-	fmt.Println("    • phi3, phi3-local            → phi3")
-	fmt.Println("    • phi4, phi4-local            → phi4")
-	fmt.Println("  Google Gemma (open-weights):")
-	fmt.Println("    • gemma2, gemma2-local        → gemma2")
-	fmt.Println("    • gemma3, gemma3-local        → gemma3")
+	fmt.Println()
+	if err := writeModelsTable(os.Stdout, rows); err != nil {
+		return err
+	}
 	fmt.Println("  Custom: ollama:<model>          e.g. ollama:mistral:7b-instruct")
-	fmt.Println("\n💡 Pull a model first:  glens models ollama pull <model-name>")
+	fmt.Println("\n💡 Cloud providers require API keys; local models run on Ollama, pull one first:  glens models ollama pull <model-name>")
 
 	// Check Ollama models
 	fmt.Println("\n🏠 Installed Ollama Models:")
 
-	ollamaClient, err := ai.NewOllamaClient("")
+	ollamaClient, err := newOllamaClient()
 	if err != nil {
 		fmt.Printf("  ❌ Ollama not configured: %v\n", err)
 		return nil
@@ -179,7 +204,7 @@ func runModelsStatus(_ *cobra.Command, _ []string) error {
 
 	// Check Ollama
 	fmt.Print("\n🏠 Ollama: ")
-	ollamaClient, err := ai.NewOllamaClient("")
+	ollamaClient, err := newOllamaClient()
 	if err != nil {
 		fmt.Printf("❌ Not configured (%v)\n", err)
 	} else {
@@ -195,6 +220,17 @@ func runModelsStatus(_ *cobra.Command, _ []string) error {
 		}
 	}
 
+	// Suggest a local model this machine can run
+	hw := ai.DetectHardware(ctx)
+	recommendation := ai.RecommendOllamaModel(hw)
+	gpu := "no NVIDIA GPU detected"
+	if hw.GPUMemoryBytes > 0 {
+		gpu = formatSize(hw.GPUMemoryBytes) + " GPU memory"
+	}
+	fmt.Printf("\n🖥️  Hardware: %s RAM, %s\n", sizeOrUnknown(hw.MemoryBytes), gpu)
+	fmt.Printf("   🎯 Recommended local model: %s (%s)\n", recommendation.Model, recommendation.Reason)
+	fmt.Printf("   Pull it with: glens models ollama pull %s\n", recommendation.Model)
+
 	// Check cloud providers (would need API keys to test)
 	fmt.Println("\n🌐 Cloud Providers:")
 	fmt.Println("   🤖 OpenAI: API key required")
@@ -213,7 +249,7 @@ func runOllamaList(_ *cobra.Command, _ []string) error {
 	// digestDisplayLength is the number of hex characters shown from a model
 	// digest before truncating with "..." for readability.
 	const digestDisplayLength = 12
-	ollamaClient, err := ai.NewOllamaClient("")
+	ollamaClient, err := newOllamaClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Ollama client: %w", err)
 	}
@@ -264,7 +300,7 @@ func runOllamaList(_ *cobra.Command, _ []string) error {
 }
 
 func runOllamaStatus(_ *cobra.Command, _ []string) error {
-	ollamaClient, err := ai.NewOllamaClient("")
+	ollamaClient, err := newOllamaClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Ollama client: %w", err)
 	}
@@ -302,6 +338,27 @@ func runOllamaStatus(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+// writeModelsTable lists the models of the catalog
+func writeModelsTable(out io.Writer, rows []modelRow) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "  MODEL\tALIASES\tPROVIDER\tCONTEXT\tLOCAL\tUSD/1M IN/OUT\tDESCRIPTION")
+	for _, r := range rows {
+		window, price := "-", "-"
+		if r.ContextWindow > 0 {
+			window = fmt.Sprintf("%dk", r.ContextWindow/1000)
+		}
+		if r.Price != nil {
+			price = fmt.Sprintf("%.2f/%.2f", r.Price.Input, r.Price.Output)
+		}
+		description := r.Description
+		if r.Deprecated != "" {
+			description = "DEPRECATED: " + r.Deprecated
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%t\t%s\t%s\n", r.ID, orDash(strings.Join(r.Aliases, ", ")), r.Provider, window, r.Local, price, description)
+	}
+	return w.Flush()
+}
+
 // formatSize converts bytes to human readable format
 func formatSize(bytes int64) string {
 	const unit = 1024
@@ -316,6 +373,14 @@ func formatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// sizeOrUnknown formats bytes, or "unknown" when they are 0
+func sizeOrUnknown(bytes int64) string {
+	if bytes == 0 {
+		return "unknown"
+	}
+	return formatSize(bytes)
+}
+
 // isCodeModel checks if a model is recommended for code generation
 func isCodeModel(name string) bool {
 	codeModels := []string{
@@ -334,7 +399,7 @@ func isCodeModel(name string) bool {
 func runOllamaPull(_ *cobra.Command, args []string) error {
 	modelName := args[0]
 
-	ollamaClient, err := ai.NewOllamaClient("")
+	ollamaClient, err := newOllamaClient()
 	if err != nil {
 		return fmt.Errorf("failed to create Ollama client: %w", err)
 	}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/notify"
+	"glens/tools/glens/internal/reporter"
+)
+
+// notificationsFromConfig reads the notifications config section; it is
+// nil when no webhook or email is configured
+func notificationsFromConfig() (*notify.Config, error) {
+	if !viper.IsSet("notifications") {
+		return nil, nil //nolint:nilnil // no notifications configured is not an error
+	}
+	var cfg notify.Config
+	if err := viper.UnmarshalKey("notifications", &cfg); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	if cfg.IsZero() {
+		return nil, nil //nolint:nilnil // no notifications configured is not an error
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// notifyRun sends the run notifications of report, linking the report
+// file written to output. A failed notification is logged, not fatal.
+func notifyRun(ctx context.Context, cfg *notify.Config, report *reporter.Report, output string) {
+	if cfg == nil {
+		return
+	}
+	if err := notify.Send(ctx, cfg, report, output); err != nil {
+		log.Warn().Err(err).Msg("Failed to send run notification")
+	}
+}
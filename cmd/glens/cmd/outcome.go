@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/exitcode"
+	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/storage"
+)
+
+// Run outcomes --fail-on turns into exit codes
+const (
+	failOnTests      = "tests"
+	failOnRegression = "regression"
+	failOnBudget     = "budget"
+)
+
+var failOnOutcomes = []string{failOnTests, failOnRegression, failOnBudget}
+
+// outcomeCheck fails a finished run on the outcomes of --fail-on; a nil
+// check passes every run
+type outcomeCheck struct {
+	failOn   []string
+	baseline *reporter.Report
+}
+
+// newOutcomeCheck validates failOn and reads the baseline report the
+// regression outcome compares with from store; it returns nil when failOn
+// is empty
+func newOutcomeCheck(ctx context.Context, store storage.Store, failOn []string, baselinePath string) (*outcomeCheck, error) {
+	for _, outcome := range failOn {
+		if !slices.Contains(failOnOutcomes, outcome) {
+			return nil, exitcode.Errorf(exitcode.Usage, "unsupported --fail-on %q (use %s)", outcome, strings.Join(failOnOutcomes, ", "))
+		}
+	}
+	regression := slices.Contains(failOn, failOnRegression)
+	switch {
+	case regression && baselinePath == "":
+		return nil, exitcode.Errorf(exitcode.Usage, "--fail-on=regression needs the --baseline report to compare with")
+	case !regression && baselinePath != "":
+		return nil, exitcode.Errorf(exitcode.Usage, "--baseline is only read with --fail-on=regression")
+	case len(failOn) == 0:
+		return nil, nil //nolint:nilnil // no outcome fails the run
+	}
+
+	check := &outcomeCheck{failOn: failOn}
+	if regression {
+		store, key := storage.UserPath(store, baselinePath)
+		baseline, err := reporter.LoadReport(ctx, store, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the baseline report: %w", err)
+		}
+		check.baseline = baseline
+	}
+	return check, nil
+}
+
+// check returns the error of the most specific outcome of report that
+// fails the run: a regression, then failing tests, then the total timeout
+func (c *outcomeCheck) check(report *reporter.Report) error {
+	if c == nil {
+		return nil
+	}
+	if c.baseline != nil {
+		if regressions := reporter.Regressions(c.baseline, report); len(regressions) > 0 {
+			tests := make([]string, len(regressions))
+			for i, r := range regressions {
+				tests[i] = fmt.Sprintf("%s (%s, %s)", r.Endpoint, r.AIModel, r.Now)
+			}
+			return exitcode.Errorf(exitcode.Regression, "%d tests passing in the baseline regressed: %s",
+				len(regressions), strings.Join(tests, "; "))
+		}
+	}
+	if slices.Contains(c.failOn, failOnTests) && report.Summary.FailedTests > 0 {
+		return exitcode.Errorf(exitcode.TestsFailed, "%d of %d tests failed", report.Summary.FailedTests, report.Summary.TotalTests)
+	}
+	if slices.Contains(c.failOn, failOnBudget) && report.Metadata[analysis.TotalTimeoutExceeded] == true {
+		return exitcode.Errorf(exitcode.BudgetExceeded, "total timeout of %v exceeded; the remaining endpoints were skipped",
+			report.Metadata["total_timeout"])
+	}
+	return nil
+}
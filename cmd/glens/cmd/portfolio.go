@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/storage"
+)
+
+// defaultParallelSpecs is how many specs a multi-spec run analyzes at once
+const defaultParallelSpecs = 4
+
+// serviceSpec is one service of a multi-spec run
+type serviceSpec struct {
+	// Name names the service in the portfolio and its report directory
+	Name string `yaml:"name"`
+	// Spec is the URL or file path of the service's specification
+	Spec string `yaml:"spec"`
+}
+
+// unsafeNameChars are replaced in service names used as directory names
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// serviceSpecs returns the services of the spec arguments followed by those
+// of the specs file, a YAML manifest such as
+//
+//	specs:
+//	  - name: payments
+//	    spec: https://payments.example.com/openapi.json
+//	  - spec: ./users/openapi.yaml # named "openapi" after the file
+//
+// Names are made unique by numbering repeats.
+func serviceSpecs(args []string, specsFile string) ([]serviceSpec, error) {
+	services := make([]serviceSpec, 0, len(args))
+	for _, arg := range args {
+		services = append(services, serviceSpec{Spec: arg})
+	}
+	if specsFile != "" {
+		data, err := os.ReadFile(specsFile) // #nosec G304 -- the path is chosen by the user
+		if err != nil {
+			return nil, fmt.Errorf("failed to read specs file: %w", err)
+		}
+		var manifest struct {
+			Specs []serviceSpec `yaml:"specs"`
+		}
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse specs file %s: %w", specsFile, err)
+		}
+		for i, service := range manifest.Specs {
+			if service.Spec == "" {
+				return nil, fmt.Errorf("specs file %s: entry %d has no spec", specsFile, i+1)
+			}
+			// Relative spec paths are relative to the manifest
+			if !isURL(service.Spec) && !filepath.IsAbs(service.Spec) {
+				service.Spec = filepath.Join(filepath.Dir(specsFile), service.Spec)
+			}
+			services = append(services, service)
+		}
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no specification given: pass spec URLs or paths, or --specs-file")
+	}
+
+	seen := make(map[string]int, len(services))
+	for i := range services {
+		name := services[i].Name
+		if name == "" {
+			name = specName(services[i].Spec)
+		}
+		name = strings.Trim(unsafeNameChars.ReplaceAllString(name, "-"), "-")
+		if name == "" {
+			name = "spec"
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+		services[i].Name = name
+	}
+	return services, nil
+}
+
+// isURL reports whether spec is an http(s) URL
+func isURL(spec string) bool {
+	return strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://")
+}
+
+// specName derives a service name from a spec's file name
+func specName(spec string) string {
+	base := filepath.Base(spec)
+	if isURL(spec) {
+		if u, err := url.Parse(spec); err == nil {
+			base = path.Base(u.Path)
+		}
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// portfolioOutput returns where the portfolio of a run reporting to output
+// is written: portfolio.<ext> next to it, JSON for HTML reports
+func portfolioOutput(output string) string {
+	ext := filepath.Ext(output)
+	if ext != ".md" {
+		ext = ".json"
+	}
+	return filepath.Join(filepath.Dir(output), "portfolio"+ext)
+}
+
+// runPortfolio analyzes services concurrently, parallel at a time, writing
+// each report to a directory named after the service next to opts.Output,
+// then writes the portfolio ranking them. It fails when any service does,
+// after the portfolio is written.
+func runPortfolio(ctx context.Context, services []serviceSpec, opts analysisOptions, aiManager *ai.Manager, parallel int) (*reporter.Portfolio, error) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	log.Info().
+		Int("specs", len(services)).
+		Int("parallel", parallel).
+		Msg("Starting multi-spec analysis")
+
+	results := make([]reporter.PortfolioService, len(services))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, service := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			serviceOpts := opts
+			if opts.Output != "" {
+				serviceOpts.Output = filepath.Join(filepath.Dir(opts.Output), service.Name, filepath.Base(opts.Output))
+			}
+			if opts.TestsOutputDir != "" {
+				serviceOpts.TestsOutputDir = filepath.Join(opts.TestsOutputDir, service.Name)
+			}
+			if opts.ArtifactsDir != "" {
+				serviceOpts.ArtifactsDir = filepath.Join(opts.ArtifactsDir, service.Name)
+			}
+
+			report, err := runAnalysis(ctx, service.Spec, serviceOpts, aiManager)
+			if err != nil {
+				log.Error().Err(err).Str("service", service.Name).Msg("Spec analysis failed")
+				results[i] = reporter.PortfolioService{Name: service.Name, Spec: service.Spec, Error: err.Error()}
+				return
+			}
+			// Link reports relative to the portfolio next to them
+			reportPath := serviceOpts.Output
+			if rel, err := filepath.Rel(filepath.Dir(opts.Output), reportPath); err == nil && reportPath != "" {
+				reportPath = filepath.ToSlash(rel)
+			}
+			results[i] = reporter.NewPortfolioService(service.Name, service.Spec, reportPath, report)
+		}()
+	}
+	wg.Wait()
+
+	portfolio := reporter.BuildPortfolio(results)
+	if opts.Output != "" {
+		output := portfolioOutput(opts.Output)
+		store, key := storage.UserPath(opts.reports(), output)
+		if err := reporter.StorePortfolio(ctx, store, key, portfolio); err != nil {
+			return nil, err
+		}
+		log.Info().
+			Str("output_file", output).
+			Float64("average_health_score", portfolio.AverageHealthScore).
+			Msg("Portfolio report written")
+	}
+
+	failed := 0
+	for _, service := range portfolio.Services {
+		if service.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return portfolio, fmt.Errorf("%d of %d specs failed to analyze", failed, len(services))
+	}
+	return portfolio, nil
+}
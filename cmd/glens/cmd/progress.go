@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"glens/tools/glens/internal/jobs"
+)
+
+const (
+	// progressInterval is how often the spinners advance
+	progressInterval = 100 * time.Millisecond
+	// progressWindow is how many recent endpoints the ETA averages
+	progressWindow = 5
+	// progressBarWidth is the number of cells of the bar
+	progressBarWidth = 20
+	// progressEndpointWidth truncates long endpoint names
+	progressEndpointWidth = 40
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progressDisplay draws a status line of an analysis run on a terminal:
+// the endpoints done, a spinner per model of the current endpoint and the
+// time left, estimated from the average duration of recent endpoints. Log
+// lines written through it are printed above the status line.
+type progressDisplay struct {
+	mu      sync.Mutex
+	out     io.Writer
+	models  []string
+	verbose bool
+
+	progress jobs.Progress
+	// modelTimes are the finished models of the current endpoint
+	modelTimes    map[string]time.Duration
+	modelStart    time.Time
+	endpointStart time.Time
+	started       time.Time
+	recent        []time.Duration
+	frame         int
+	drawn         bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newProgressDisplay returns a display of a run of models on out; verbose
+// also lists each finished endpoint with its models' durations
+func newProgressDisplay(out io.Writer, models []string, verbose bool) *progressDisplay {
+	return &progressDisplay{
+		out:        out,
+		models:     models,
+		verbose:    verbose,
+		modelTimes: make(map[string]time.Duration),
+		done:       make(chan struct{}),
+	}
+}
+
+// start draws the status line and animates its spinners until stop
+func (d *progressDisplay) start() {
+	d.mu.Lock()
+	d.started = time.Now()
+	d.endpointStart = d.started
+	d.draw()
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-ticker.C:
+				d.mu.Lock()
+				d.frame++
+				d.draw()
+				d.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// stop replaces the status line with a summary of the run, if it reached
+// the endpoints
+func (d *progressDisplay) stop() {
+	close(d.done)
+	d.wg.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	if d.progress.EndpointsTotal == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(d.out, "✔ Analyzed %d/%d endpoints in %s\n",
+		d.progress.EndpointsProcessed, d.progress.EndpointsTotal, time.Since(d.started).Round(time.Second))
+}
+
+// update records the progress of the run; it is the pipeline's Progress
+// callback
+func (d *progressDisplay) update(p jobs.Progress) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	previous := d.progress
+	switch {
+	case p.EndpointsProcessed > previous.EndpointsProcessed:
+		if previous.CurrentModel != "" {
+			d.modelTimes[previous.CurrentModel] = now.Sub(d.modelStart)
+		}
+		elapsed := now.Sub(d.endpointStart)
+		d.recent = append(d.recent, elapsed)
+		if len(d.recent) > progressWindow {
+			d.recent = d.recent[1:]
+		}
+		if d.verbose {
+			d.clear()
+			_, _ = fmt.Fprintf(d.out, "✓ %s in %s%s\n", p.CurrentEndpoint, elapsed.Round(time.Millisecond), d.modelSummary())
+		}
+		clear(d.modelTimes)
+		d.endpointStart = now
+	case p.CurrentModel != previous.CurrentModel:
+		if previous.CurrentModel != "" {
+			d.modelTimes[previous.CurrentModel] = now.Sub(d.modelStart)
+		}
+		d.modelStart = now
+	}
+	d.progress = p
+	d.draw()
+}
+
+// Write prints log output above the status line
+func (d *progressDisplay) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	n, err := d.out.Write(p)
+	d.draw()
+	return n, err
+}
+
+// clear erases the status line
+func (d *progressDisplay) clear() {
+	if d.drawn {
+		_, _ = io.WriteString(d.out, "\r\033[K")
+		d.drawn = false
+	}
+}
+
+// draw redraws the status line
+func (d *progressDisplay) draw() {
+	select {
+	case <-d.done:
+		return
+	default:
+	}
+	_, _ = io.WriteString(d.out, "\r\033[K"+d.line())
+	d.drawn = true
+}
+
+// line renders the status line, e.g.
+// "[██████░░░░] 3/10 endpoints · GET /pets · ✓ gpt4 ⠹ claude · ETA 42s"
+func (d *progressDisplay) line() string {
+	p := d.progress
+	parts := []string{fmt.Sprintf("%s %d/%d endpoints", progressBar(p.EndpointsProcessed, p.EndpointsTotal), p.EndpointsProcessed, p.EndpointsTotal)}
+	if p.CurrentModel != "" {
+		parts = append(parts, truncate(p.CurrentEndpoint, progressEndpointWidth))
+		models := make([]string, len(d.models))
+		for i, model := range d.models {
+			_, finished := d.modelTimes[model]
+			switch {
+			case model == p.CurrentModel:
+				models[i] = spinnerFrames[d.frame%len(spinnerFrames)] + " " + model
+			case finished:
+				models[i] = "✓ " + model
+			default:
+				models[i] = "· " + model
+			}
+		}
+		parts = append(parts, strings.Join(models, " "))
+	}
+	parts = append(parts, "ETA "+d.eta())
+	return strings.Join(parts, " · ")
+}
+
+// eta estimates the time left from the average duration of the recent
+// endpoints, "--" until the first endpoint is done
+func (d *progressDisplay) eta() string {
+	if len(d.recent) == 0 {
+		return "--"
+	}
+	var sum time.Duration
+	for _, elapsed := range d.recent {
+		sum += elapsed
+	}
+	remaining := d.progress.EndpointsTotal - d.progress.EndpointsProcessed
+	left := sum / time.Duration(len(d.recent)) * time.Duration(remaining)
+	// The current endpoint has been running for part of its share
+	left -= time.Since(d.endpointStart)
+	if left < 0 || remaining == 0 {
+		left = 0
+	}
+	return left.Round(time.Second).String()
+}
+
+// modelSummary lists the durations of the models of the finished endpoint
+func (d *progressDisplay) modelSummary() string {
+	var models []string
+	for _, model := range d.models {
+		if elapsed, ok := d.modelTimes[model]; ok {
+			models = append(models, fmt.Sprintf("%s %s", model, elapsed.Round(time.Millisecond)))
+		}
+	}
+	if len(models) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(models, ", ") + ")"
+}
+
+// progressBar renders done out of total as a bar of progressBarWidth cells
+func progressBar(done, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * progressBarWidth / total
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled) + "]"
+}
+
+// truncate shortens s to at most width runes
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+var regenerateCmd = &cobra.Command{
+	Use:   "regenerate",
+	Short: "Regenerate persisted tests of endpoints that changed in the spec",
+	Long: `Reads the index of tests written with --tests-output-dir, finds the files
+whose endpoint changed in the spec since they were generated and rewrites
+only those, in place, with a new test from the model that generated them.
+
+Hand-written code between "// glens:keep-begin" and "// glens:keep-end"
+lines is carried over into the regenerated file; a kept declaration replaces
+a generated one of the same name. Files of endpoints removed from the spec
+are reported and left in place, as are new endpoints, which need analyze.
+
+Examples:
+  glens regenerate --spec=spec.json --dir=generated-tests
+  glens regenerate --spec=spec.json --dir=generated-tests --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runRegenerate,
+}
+
+func init() {
+	rootCmd.AddCommand(regenerateCmd)
+
+	regenerateCmd.Flags().String("spec", "", "OpenAPI specification file or URL (required)")
+	regenerateCmd.Flags().String("dir", "", "Directory of the persisted tests (defaults to tests_output.dir)")
+	regenerateCmd.Flags().Bool("dry-run", false, "Only list the files that would be regenerated")
+	regenerateCmd.Flags().String("env", "", "Target environment from the environments config section")
+	_ = regenerateCmd.MarkFlagRequired("spec")
+
+	_ = viper.BindPFlag("regenerate.environment", regenerateCmd.Flags().Lookup("env"))
+}
+
+func runRegenerate(cmd *cobra.Command, _ []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = viper.GetString("tests_output.dir")
+	}
+	if dir == "" {
+		return fmt.Errorf("no tests directory: set --dir or tests_output.dir")
+	}
+
+	specPath, _ := cmd.Flags().GetString("spec")
+	spec, err := parser.ParseOpenAPISpec(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	index, err := generator.ReadSuiteIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	plan := analysis.PlanRegeneration(index, spec)
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun || len(plan.Changed) == 0 {
+		printRegeneration(out, plan, "Would regenerate")
+		return nil
+	}
+
+	env, err := loadEnvironment(viper.GetString("regenerate.environment"))
+	if err != nil {
+		return err
+	}
+	aiManager, err := newAIManager(plan.Models)
+	if err != nil {
+		return err
+	}
+	aiManager.SetEnvironment(env)
+
+	log.Info().
+		Str("spec", specPath).
+		Str("dir", dir).
+		Int("changed", len(plan.Changed)).
+		Strs("ai_models", plan.Models).
+		Msg("Regenerating tests of changed endpoints")
+	result, err := analysis.Regenerate(ctx, dir, spec, aiManager)
+	if err != nil {
+		return err
+	}
+	printRegeneration(out, result, "Regenerated")
+	return nil
+}
+
+// printRegeneration lists the files of a regeneration, the changed ones
+// under verb
+func printRegeneration(out io.Writer, result *analysis.Regeneration, verb string) {
+	for _, file := range result.Changed {
+		_, _ = fmt.Fprintf(out, "%s: %s\n", verb, file)
+	}
+	for _, file := range result.Skipped {
+		_, _ = fmt.Fprintf(out, "Skipped: %s\n", file)
+	}
+	for _, file := range result.Removed {
+		_, _ = fmt.Fprintf(out, "Endpoint removed: %s\n", file)
+	}
+	for _, endpoint := range result.Added {
+		_, _ = fmt.Fprintf(out, "Not generated: %s\n", endpoint)
+	}
+	_, _ = fmt.Fprintf(out, "%d changed, %d unchanged, %d skipped, %d removed, %d new\n",
+		len(result.Changed), result.Unchanged, len(result.Skipped), len(result.Removed), len(result.Added))
+}
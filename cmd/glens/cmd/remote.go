@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"glens/pkg/apiclient"
+	"glens/pkg/logging"
+
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/exitcode"
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+	"glens/tools/glens/internal/reporter"
+)
+
+// runRemote analyzes spec on the glens server at serverURL: the spec, or
+// its URL, and the models and endpoints selected by opts are queued on the
+// server, whose progress is followed until the job finishes, and the
+// report it downloads is written like that of a local run. Test generation,
+// execution and issues follow the server's configuration.
+func runRemote(ctx context.Context, serverURL, spec string, opts analysisOptions) (*reporter.Report, error) {
+	client, err := apiclient.New(serverURL, apiclient.WithAPIKey(viper.GetString("remote.api_key")))
+	if err != nil {
+		return nil, exitcode.New(exitcode.Usage, err)
+	}
+
+	var req apiclient.AnalyzeRequest
+	// The server analyzes with its default models unless told otherwise
+	if viper.IsSet("run.ai_models") {
+		req.Models = opts.Models
+	}
+	if selectsEndpoints(opts.Options) {
+		if req.ApprovedEndpoints, err = remoteEndpoints(spec, opts.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	run, err := submitRemote(ctx, client, spec, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue the analysis on %s: %w", serverURL, err)
+	}
+	log.Info().
+		Str("server", serverURL).
+		Str("job_id", run.JobID).
+		Strs("ai_models", req.Models).
+		Int("endpoints", len(req.ApprovedEndpoints)).
+		Msg("Analysis queued on the remote server")
+
+	progress := logRemoteProgress()
+	if !viper.GetBool("quiet") && term.IsTerminal(int(os.Stderr.Fd())) {
+		display := newProgressDisplay(os.Stderr, req.Models, viper.GetBool("verbose"))
+		logs := loggingConfig()
+		logs.Output = redact.Writer(display)
+		logging.Setup(logs)
+		defer setupLogging()
+		display.start()
+		defer display.stop()
+		progress = display.update
+	}
+
+	job, err := client.WatchJob(ctx, run.JobID, func(job *apiclient.Job) {
+		progress(jobs.Progress(job.Progress))
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Warn().
+				Str("job_id", run.JobID).
+				Str("status_url", run.StatusURL).
+				Msg("Stopped following the remote analysis, which keeps running on the server")
+		}
+		return nil, fmt.Errorf("failed to follow remote analysis %s: %w", run.JobID, err)
+	}
+	if job.Status == apiclient.JobFailed {
+		return nil, fmt.Errorf("remote analysis %s failed: %s", job.ID, job.Error)
+	}
+
+	data, err := client.Report(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the report of remote analysis %s: %w", job.ID, err)
+	}
+	var report reporter.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode the report of remote analysis %s: %w", job.ID, err)
+	}
+	if err := writeReport(ctx, opts.reports(), &report, opts.Output, opts.OutputFormat); err != nil {
+		return nil, err
+	}
+	if err := writeTestSuite(&report, opts); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("output_file", opts.Output).
+		Int("endpoints_processed", len(report.EndpointResults)).
+		Msg("Remote analysis completed successfully")
+	return &report, nil
+}
+
+// selectsEndpoints reports whether opts narrows the endpoints a run tests
+// beyond the defaults the server applies itself
+func selectsEndpoints(opts analysis.Options) bool {
+	return opts.OperationID != "" || len(opts.Approved) > 0 || !opts.Selection.IsZero() || opts.IncludeDeprecated
+}
+
+// remoteEndpoints resolves the endpoint selection of opts against spec, as
+// the server's jobs only take the IDs of the endpoints to test
+func remoteEndpoints(spec string, opts analysis.Options) ([]string, error) {
+	parsed, err := parser.ParseOpenAPISpec(spec)
+	if err != nil {
+		return nil, exitcode.New(exitcode.SpecParse, fmt.Errorf("failed to parse OpenAPI spec: %w", err))
+	}
+	endpoints, err := analysis.Select(parsed, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return nil, exitcode.New(exitcode.Usage, errors.New("no endpoints of the spec are selected"))
+	}
+	ids := make([]string, len(endpoints))
+	for i, e := range endpoints {
+		ids[i] = e.ID
+	}
+	return ids, nil
+}
+
+// submitRemote queues the analysis of spec: a URL is fetched by the server,
+// a file is uploaded
+func submitRemote(ctx context.Context, client *apiclient.Client, spec string, req apiclient.AnalyzeRequest) (*apiclient.AnalyzeResponse, error) {
+	if isURL(spec) {
+		req.SpecURL = spec
+		return client.Analyze(ctx, req)
+	}
+	f, err := os.Open(filepath.Clean(spec))
+	if err != nil {
+		return nil, exitcode.New(exitcode.SpecParse, fmt.Errorf("failed to open OpenAPI spec: %w", err))
+	}
+	defer func() { _ = f.Close() }()
+	return client.AnalyzeUpload(ctx, filepath.Base(spec), f, req)
+}
+
+// logRemoteProgress returns a progress callback logging each endpoint the
+// server starts, for runs without a progress display
+func logRemoteProgress() func(jobs.Progress) {
+	var last string
+	return func(p jobs.Progress) {
+		current := strings.TrimSpace(p.CurrentEndpoint + " " + p.CurrentModel)
+		if current == "" || current == last {
+			return
+		}
+		last = current
+		log.Info().
+			Int("processed", p.EndpointsProcessed).
+			Int("total", p.EndpointsTotal).
+			Str("endpoint", p.CurrentEndpoint).
+			Str("model", p.CurrentModel).
+			Msg("Remote analysis progress")
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/reporter"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Re-run the tests of an earlier analysis from its artifacts, without AI",
+	Long: `Re-executes the tests an analysis saved with --artifacts-dir and writes a
+new report, without calling any AI provider: after fixing the backend (or
+the spec) an issue was opened for, replay verifies the fix with the very
+tests that failed.
+
+The endpoints, models and spec come from the report.json of the artifacts
+directory; each test runs as the last version saved in test.go.txt (that of
+its last repair), so a test fixed by hand is replayed as fixed. Endpoints
+the analysis skipped stay skipped. Tests run against --env, with the
+test_execution settings of the config, and only up to --allow-risk.
+
+Examples:
+  glens replay --artifacts-dir=artifacts
+  glens replay --artifacts-dir=artifacts --env=staging --fail-on=tests`,
+	Args: cobra.NoArgs,
+	RunE: runReplay,
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().String("artifacts-dir", "", "Artifacts directory of the analysis to replay (defaults to run.artifacts_dir)")
+	replayCmd.Flags().String("output", "reports/replay.md", "Output file for the report of the replay")
+	replayCmd.Flags().String("env", "", "Target environment from the environments config section")
+	replayCmd.Flags().String("allow-risk", "", "Highest endpoint risk whose tests are executed (safe, medium, high; defaults to run.allow_risk)")
+	replayCmd.Flags().StringSlice("fail-on", nil, "Exit non-zero when tests still fail (tests, exit 6) or regressed from --baseline (regression, exit 7)")
+	replayCmd.Flags().String("baseline", "", "JSON report of an earlier run; tests that passed there and fail now are regressions")
+
+	_ = viper.BindPFlag("replay.environment", replayCmd.Flags().Lookup("env"))
+}
+
+func runReplay(cmd *cobra.Command, _ []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dir, _ := cmd.Flags().GetString("artifacts-dir")
+	if dir == "" {
+		dir = viper.GetString("run.artifacts_dir")
+	}
+	if dir == "" {
+		return fmt.Errorf("no artifacts directory: set --artifacts-dir or run.artifacts_dir")
+	}
+	failOn, _ := cmd.Flags().GetStringSlice("fail-on")
+	baseline, _ := cmd.Flags().GetString("baseline")
+	store, err := openStorage()
+	if err != nil {
+		return err
+	}
+	outcome, err := newOutcomeCheck(ctx, store, failOn, baseline)
+	if err != nil {
+		return err
+	}
+	previous, err := reporter.ReadReport(filepath.Join(dir, artifacts.ReportFile))
+	if err != nil {
+		return fmt.Errorf("%w (was the analysis run with --artifacts-dir?)", err)
+	}
+
+	env, err := loadEnvironment(viper.GetString("replay.environment"))
+	if err != nil {
+		return err
+	}
+	allowRisk, _ := cmd.Flags().GetString("allow-risk")
+	if allowRisk == "" {
+		allowRisk = viper.GetString("run.allow_risk")
+	}
+	opts := analysis.Options{
+		RunTests:     true,
+		AllowRisk:    safety.Risk(allowRisk),
+		TestTimeout:  viper.GetDuration("test_execution.timeout"),
+		TestRetries:  viper.GetInt("test_execution.retries"),
+		Lint:         lintFromConfig(),
+		ArtifactsDir: dir,
+		Env:          env,
+		Scoring:      scoringFromConfig(),
+	}
+
+	log.Info().
+		Str("artifacts_dir", dir).
+		Int("endpoints", len(previous.EndpointResults)).
+		Msg("Replaying saved tests")
+	report, err := analysis.Replay(ctx, previous, opts)
+	if err != nil {
+		return err
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if err := writeReport(ctx, store, report, output, ""); err != nil {
+		return err
+	}
+
+	summary := report.Summary
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Replayed %d tests of %d endpoints: %d passed, %d failed, %d flaky\n",
+		summary.TotalTests, summary.EndpointsProcessed, summary.PassedTests, summary.FailedTests, summary.FlakyTests)
+	if output != "" {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Report written to %s\n", output)
+	}
+	return outcome.check(report)
+}
@@ -3,11 +3,18 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"glens/pkg/logging"
+
+	"glens/tools/glens/internal/exitcode"
+	"glens/tools/glens/internal/httpclient"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+	"glens/tools/glens/internal/scaffold"
 )
 
 var cfgFile string
@@ -18,41 +25,113 @@ var rootCmd = &cobra.Command{
 	Long: `A powerful tool that analyzes OpenAPI specifications and generates
 integration tests using multiple AI models (OpenAI GPT, Anthropic Sonnet, Google Flash).
 Creates GitHub issues for each endpoint and generates comprehensive test reports.`,
+	PersistentPreRunE: startMetricsServer,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(version string) {
 	rootCmd.Version = version
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+	registerFlagCompletions(rootCmd)
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return exitcode.New(exitcode.Usage, err)
+	})
+	checkErr(rootCmd.Execute())
+}
+
+// checkErr prints a non-nil err in the --error-format and exits with the
+// code of its kind
+func checkErr(err error) {
+	if err == nil {
+		return
+	}
+	if viper.GetString("error_format") == "json" {
+		_ = exitcode.WriteJSON(os.Stderr, err)
+	} else {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 	}
+	os.Exit(int(exitcode.Of(err)))
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.glens.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .glens/config.yaml, else .glens.yaml in $HOME, . or ./configs)")
 	rootCmd.PersistentFlags().Bool("debug", false, "enable debug logging")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "only log errors and hide the progress display")
+	rootCmd.PersistentFlags().Bool("verbose", false, "log debug details and list each finished endpoint above the progress display (implies --debug)")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
 	rootCmd.PersistentFlags().String("log-format", "console", "log format (console or json)")
+	rootCmd.PersistentFlags().String("error-format", "text", "format of the error printed on failure (text or json, a single line with code, kind and message)")
+	rootCmd.PersistentFlags().String("profile", "", "config profile to apply over the base configuration (env GLENS_PROFILE)")
+	rootCmd.PersistentFlags().String("metrics-listen", "", "serve Prometheus metrics on this address (e.g. :9090) while the command runs")
+	rootCmd.PersistentFlags().String("prompt-dir", "", "directory of prompt templates (<model|provider>[.<category>].tmpl) overriding the built-in prompts")
+	rootCmd.PersistentFlags().String("examples-dir", "", "directory of exemplar Go tests; the most relevant are added to prompts as few-shot examples")
+	rootCmd.PersistentFlags().Bool("local-only", false, "fail if any selected model (fallbacks included) would send spec content off this machine")
+	rootCmd.PersistentFlags().StringArray("spec-header", nil, "header sent when fetching specs from URLs, as 'Name: value' (repeatable, e.g. 'Authorization: Bearer $TOKEN')")
+	rootCmd.PersistentFlags().Bool("low-memory", false, "parse OpenAPI specs one path item at a time, without positions or warnings (automatic above spec_parse.low_memory_above_mb)")
+	rootCmd.PersistentFlags().String("storage", "", "where reports, and serve's jobs with --job-store=storage, are kept: a directory, sqlite://path/to/glens.db, s3://bucket/prefix or gs://bucket/prefix (default the working directory; env GLENS_STORAGE)")
 
 	if err := viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug")); err != nil {
 		fmt.Fprintln(os.Stderr, "failed to bind debug flag:", err)
 		os.Exit(1)
 	}
+	if err := viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind quiet flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind verbose flag:", err)
+		os.Exit(1)
+	}
 	if err := viper.BindPFlag("log_format", rootCmd.PersistentFlags().Lookup("log-format")); err != nil {
 		fmt.Fprintln(os.Stderr, "failed to bind log-format flag:", err)
 		os.Exit(1)
 	}
+	if err := viper.BindPFlag("error_format", rootCmd.PersistentFlags().Lookup("error-format")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind error-format flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind profile flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("metrics_listen", rootCmd.PersistentFlags().Lookup("metrics-listen")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind metrics-listen flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("prompts.dir", rootCmd.PersistentFlags().Lookup("prompt-dir")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind prompt-dir flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("prompts.examples_dir", rootCmd.PersistentFlags().Lookup("examples-dir")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind examples-dir flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("local_only", rootCmd.PersistentFlags().Lookup("local-only")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind local-only flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("spec_parse.low_memory", rootCmd.PersistentFlags().Lookup("low-memory")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind low-memory flag:", err)
+		os.Exit(1)
+	}
+	if err := viper.BindPFlag("storage.url", rootCmd.PersistentFlags().Lookup("storage")); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to bind storage flag:", err)
+		os.Exit(1)
+	}
 }
 
 func initConfig() {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
+	} else if _, err := os.Stat(scaffold.ConfigPath); err == nil {
+		// The project config written by glens init
+		viper.SetConfigFile(scaffold.ConfigPath)
 	} else {
 		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
+		checkErr(err)
 
 		viper.AddConfigPath(home)
 		viper.AddConfigPath(".")
@@ -67,27 +146,127 @@ func initConfig() {
 	// Bind environment variables explicitly for GitHub
 	_ = viper.BindEnv("github.token", "GITHUB_TOKEN")
 	_ = viper.BindEnv("github.repository", "GITHUB_REPOSITORY")
+	_ = viper.BindEnv("profile", "GLENS_PROFILE")
+	_ = viper.BindEnv("storage.url", "GLENS_STORAGE")
 
+	configLoaded := false
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		configLoaded = true
+		if !viper.GetBool("quiet") {
+			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		}
 	}
+	checkErr(layerConfig(configLoaded))
+	// A JSON error is the only output of a failure scripts have to parse
+	switch viper.GetString("error_format") {
+	case "json":
+		rootCmd.SilenceUsage = true
+	case "text":
+	default:
+		checkErr(exitcode.Errorf(exitcode.Usage, "unsupported error_format %q (use text or json)", viper.GetString("error_format")))
+	}
+	checkErr(setupRedaction())
+	checkErr(setupHTTP())
+	checkErr(setupSpecFetch())
+	checkErr(setupSpecParse())
 
 	setupLogging()
 }
 
+// setupRedaction masks the secrets of the redaction config section and
+// the environment in everything glens writes
+func setupRedaction() error {
+	var cfg redact.Config
+	if err := viper.UnmarshalKey("redaction", &cfg); err != nil {
+		return fmt.Errorf("failed to read redaction: %w", err)
+	}
+	r, err := redact.New(cfg, os.Environ())
+	if err != nil {
+		return err
+	}
+	redact.SetDefault(r)
+	return nil
+}
+
+// setupHTTP makes every outbound HTTP client trust the CA bundle of the
+// http config section, or skip verification. Proxies come from
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+func setupHTTP() error {
+	var tls httpclient.TLS
+	if err := viper.UnmarshalKey("http", &tls); err != nil {
+		return fmt.Errorf("failed to read http: %w", err)
+	}
+	tls.CAFile = os.ExpandEnv(tls.CAFile)
+	if err := httpclient.Configure(tls); err != nil {
+		return fmt.Errorf("http: %w", err)
+	}
+	return nil
+}
+
+// setupSpecFetch applies the spec_fetch config section, and the
+// --spec-header flags over its headers, to spec downloads
+func setupSpecFetch() error {
+	var cfg parser.FetchConfig
+	if err := viper.UnmarshalKey("spec_fetch", &cfg); err != nil {
+		return fmt.Errorf("failed to read spec_fetch: %w", err)
+	}
+	cfg.CacheDir = os.ExpandEnv(cfg.CacheDir)
+	flags, err := rootCmd.PersistentFlags().GetStringArray("spec-header")
+	if err != nil {
+		return err
+	}
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("--spec-header %q is not of the form 'Name: value'", flag)
+		}
+		name = strings.TrimSpace(name)
+		if cfg.Headers == nil {
+			cfg.Headers = map[string]string{}
+		}
+		for configured := range cfg.Headers {
+			if strings.EqualFold(configured, name) {
+				delete(cfg.Headers, configured)
+			}
+		}
+		cfg.Headers[name] = strings.TrimSpace(value)
+	}
+	cfg.ResolveHeader = resolveSecret
+	redact.Default().AddValues(cfg.Secrets()...)
+	parser.SetFetchConfig(cfg)
+	return nil
+}
+
+// setupSpecParse applies the spec_parse config section, and the
+// --low-memory flag, to spec parsing
+func setupSpecParse() error {
+	var cfg parser.ParseConfig
+	if err := viper.UnmarshalKey("spec_parse", &cfg); err != nil {
+		return fmt.Errorf("failed to read spec_parse: %w", err)
+	}
+	parser.SetParseConfig(cfg)
+	return nil
+}
+
 func setupLogging() {
-	logFormat := viper.GetString("log_format")
-	debug := viper.GetBool("debug")
+	logging.Setup(loggingConfig())
+}
 
+// loggingConfig returns the log level and format of the flags and config:
+// --quiet logs errors only, --verbose (or --debug) adds debug details
+func loggingConfig() logging.Config {
 	level := logging.LevelInfo
-	if debug {
+	switch {
+	case viper.GetBool("quiet"):
+		level = logging.LevelError
+	case viper.GetBool("verbose"), viper.GetBool("debug"):
 		level = logging.LevelDebug
 	}
 
 	format := logging.FormatJSON
-	if logFormat == "console" {
+	if viper.GetString("log_format") == "console" {
 		format = logging.FormatConsole
 	}
 
-	logging.Setup(logging.Config{Level: level, Format: format})
+	return logging.Config{Level: level, Format: format, Output: redact.Writer(os.Stderr)}
 }
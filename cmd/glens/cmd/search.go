@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/search"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Find the operations of a spec matching a natural language query",
+	Long: `Ranks the operations of a spec by how similar their method, path, operation
+ID, summary, description, tags and parameters are to a natural language
+query, to find the --op-id of an operation in specs with hundreds of them.
+
+Similarity is that of embeddings from the provider of the embeddings config
+section: local (default) hashes words on this machine, openai and ollama
+use their embedding models. Embeddings of the spec are cached under
+embeddings.cache_dir, keyed by a hash of its operations.
+
+Examples:
+  glens search "upload a profile picture" --spec spec.json
+  glens search "cancel an order" --spec spec.json --provider=openai -n 5
+  glens search "delete user" --spec spec.json --output=json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSearch,
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().String("spec", "", "OpenAPI specification file or URL (required)")
+	searchCmd.Flags().IntP("limit", "n", 10, "Number of operations to list")
+	searchCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+	searchCmd.Flags().String("provider", "", "Embedding provider: local, openai or ollama (default from embeddings.provider)")
+	searchCmd.Flags().Bool("no-cache", false, "Embed the spec again instead of reading cached embeddings")
+	_ = searchCmd.MarkFlagRequired("spec")
+
+	_ = viper.BindPFlag("embeddings.provider", searchCmd.Flags().Lookup("provider"))
+}
+
+// searchRow is one matching operation
+type searchRow struct {
+	Score       float64 `json:"score"`
+	ID          string  `json:"id"`
+	Method      string  `json:"method"`
+	Path        string  `json:"path"`
+	OperationID string  `json:"operation_id,omitempty"`
+	Summary     string  `json:"summary,omitempty"`
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	specPath, _ := cmd.Flags().GetString("spec")
+	limit, _ := cmd.Flags().GetInt("limit")
+	output, _ := cmd.Flags().GetString("output")
+	noCache, _ := cmd.Flags().GetBool("no-cache")
+
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (use table or json)", output)
+	}
+	if limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+
+	embedder, err := newEmbedder()
+	if err != nil {
+		return err
+	}
+
+	spec, err := parser.ParseOpenAPISpec(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	cacheDir := embeddingsCacheDir()
+	if noCache {
+		cacheDir = ""
+	}
+	index, _, err := search.Build(cmd.Context(), embedder, spec, cacheDir)
+	if err != nil {
+		return err
+	}
+	matches, err := index.Search(cmd.Context(), embedder, spec, args[0], limit)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]searchRow, len(matches))
+	for i, m := range matches {
+		rows[i] = searchRow{
+			Score:       m.Score,
+			ID:          m.Endpoint.ID,
+			Method:      m.Endpoint.Method,
+			Path:        m.Endpoint.Path,
+			OperationID: m.Endpoint.OperationID,
+			Summary:     m.Endpoint.Summary,
+		}
+	}
+	if output == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	return writeSearchTable(cmd.OutOrStdout(), rows)
+}
+
+// newEmbedder returns the embedder of the embeddings config section
+func newEmbedder() (ai.Embedder, error) {
+	cfg, err := embeddingsFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	providers, err := loadAIConfig()
+	if err != nil {
+		return nil, err
+	}
+	embedder, err := ai.NewEmbedder(cfg, providers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	return embedder, nil
+}
+
+// embeddingsCacheDir is embeddings.cache_dir, or the user cache directory
+func embeddingsCacheDir() string {
+	if viper.IsSet("embeddings.cache_dir") {
+		return viper.GetString("embeddings.cache_dir")
+	}
+	return search.DefaultCacheDir()
+}
+
+func writeSearchTable(out io.Writer, rows []searchRow) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SCORE\tMETHOD\tPATH\tOPERATION ID\tSUMMARY")
+	for _, r := range rows {
+		_, _ = fmt.Fprintf(w, "%.3f\t%s\t%s\t%s\t%s\n", r.Score, r.Method, r.Path, orDash(r.OperationID), orDash(r.Summary))
+	}
+	return w.Flush()
+}
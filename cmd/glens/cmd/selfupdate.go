@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"glens/tools/glens/internal/selfupdate"
+)
+
+// selfUpdateTimeout bounds listing releases and downloading the binary
+const selfUpdateTimeout = 5 * time.Minute
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update glens to the latest release",
+	Long: `Checks the glens releases on GitHub and replaces the running binary with
+the newest one of the channel: stable releases only, or prereleases too.
+The binary of the platform is verified against the SHA-256 checksums
+published with the release before the running one is replaced, atomically,
+so an interrupted update leaves the current version in place.
+
+The checksums come from the same release as the binary, so they prove it
+arrived intact but not who published it. --verify-signature also checks the
+GPG signature of the checksums, which requires gpg and the glens release key
+in your keyring; updates without it print a warning. GITHUB_TOKEN, when set,
+raises the GitHub API rate limit.
+
+Development builds are only replaced with --force, which also reinstalls
+the current release. Installs managed by a package manager should be
+updated with it instead.
+
+Examples:
+  glens self-update
+  glens self-update --check
+  glens self-update --channel prerelease
+  glens self-update --version 1.4.0 --force`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().String("channel", selfupdate.ChannelStable, "Release channel (stable or prerelease)")
+	selfUpdateCmd.Flags().String("version", "", "Install this release (e.g. 1.4.0) instead of the latest")
+	selfUpdateCmd.Flags().Bool("check", false, "Only report whether an update is available")
+	selfUpdateCmd.Flags().Bool("force", false, "Replace development builds and reinstall or downgrade releases")
+	selfUpdateCmd.Flags().Bool("verify-signature", false, "Also verify the GPG signature of the release checksums (requires gpg)")
+	_ = selfUpdateCmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(
+		[]string{selfupdate.ChannelStable, selfupdate.ChannelPrerelease}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runSelfUpdate(cmd *cobra.Command, _ []string) error {
+	channel, _ := cmd.Flags().GetString("channel")
+	version, _ := cmd.Flags().GetString("version")
+	check, _ := cmd.Flags().GetBool("check")
+	force, _ := cmd.Flags().GetBool("force")
+	verifySignature, _ := cmd.Flags().GetBool("verify-signature")
+	if !selfupdate.ValidChannel(channel) {
+		return fmt.Errorf("unsupported channel %q (use %s or %s)", channel, selfupdate.ChannelStable, selfupdate.ChannelPrerelease)
+	}
+	current := cmd.Root().Version
+	released := current != "" && current != "dev"
+	out := cmd.OutOrStdout()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), selfUpdateTimeout)
+	defer cancel()
+	token, err := secretString("github.token")
+	if err != nil {
+		return err
+	}
+	updater := &selfupdate.Updater{
+		Token:  token,
+		Client: &http.Client{},
+	}
+	release, err := updater.Latest(ctx, channel, version)
+	if errors.Is(err, selfupdate.ErrNoRelease) && version != "" {
+		return fmt.Errorf("glens release %s not found", version)
+	}
+	if err != nil {
+		return err
+	}
+
+	newer := !released || selfupdate.Compare(release.Version, current) > 0
+	switch {
+	case released && !newer && (version == "" || release.Version == current) && !force:
+		_, _ = fmt.Fprintf(out, "glens %s is up to date (latest %s release: %s)\n", current, channel, release.Version)
+		return nil
+	case check:
+		_, _ = fmt.Fprintf(out, "glens %s is available (current: %s): %s\n", release.Version, current, release.URL)
+		return nil
+	case !released && !force:
+		return fmt.Errorf("this is a development build of glens; use --force to replace it with release %s", release.Version)
+	case released && !newer && version != "" && !force:
+		return fmt.Errorf("release %s is older than glens %s; use --force to downgrade", release.Version, current)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to locate the running binary: %w", err)
+	}
+	asset := selfupdate.AssetName(runtime.GOOS, runtime.GOARCH)
+	if !verifySignature {
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Warning: the release checksums only prove the binary arrived intact, not who published it; use --verify-signature to check their GPG signature")
+	}
+	_, _ = fmt.Fprintf(out, "Downloading glens %s (%s)...\n", release.Version, asset)
+	binary, err := updater.Download(ctx, release, asset, verifySignature)
+	if err != nil {
+		return err
+	}
+	if err := selfupdate.Replace(exe, binary); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "Updated %s from %s to %s\n", exe, current, release.Version)
+	return nil
+}
@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/pkg/apiauth"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/cluster"
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/readiness"
+	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/server"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the glens REST API server",
+	Long: `Runs the REST API inside the glens binary, backed by the real analysis
+pipeline and a shared AI model manager. The standalone API (cmd/api) is
+this server configured from the environment.
+
+  GET  /healthz, /livez         liveness
+  GET  /readyz                  readiness: provider preflights, draining
+  POST /api/v1/analyze          queue an analysis job (202 + job_id)
+  GET  /api/v1/jobs/{id}        job status and progress
+  GET  /api/v1/jobs/{id}/report JSON report of a succeeded job
+  GET  /api/v1/jobs/{id}/events server-sent progress events of a job
+  POST /api/v1/analyze/preview  parse a spec and categorise endpoint risk
+  GET  /api/v1/models           models served by this instance
+  POST /api/v1/mcp              JSON-RPC 2.0 tool calls
+  GET  /metrics                 Prometheus metrics
+  GET  /api/v1/openapi.json     OpenAPI document of these routes
+
+Both analyze routes take a JSON request naming the spec by spec_url, or
+the spec itself: as the "spec" file of a multipart form (with models,
+approved_endpoints and skipped_endpoints as form fields) or as the request
+body (with them as query parameters). Uploads up to --max-spec-mb are kept
+in --upload-dir until their job has run.
+
+Jobs and reports are kept in memory by default; use --job-store redis to
+share them between instances and keep them across restarts, or
+--job-store storage to keep them in the --storage of reports: files, a
+SQLite database or S3-compatible object storage.
+
+With --distributed the server coordinates a cluster instead of generating
+tests itself: the endpoints of each job are queued and leased by machines
+running "glens worker --join <url>", each serving the models it has (e.g.
+a GPU box running ollama). Workers send heartbeats; the endpoints of a
+worker that stops are handed to another one. The cluster routes need an
+API key like every other route, and the --worker-token (GLENS_WORKER_TOKEN)
+when set.
+
+The server listens on 127.0.0.1 unless --host says otherwise. It requires
+an API key, sent as X-API-Key or a Bearer token, once keys are set with
+API_KEYS (comma-separated) or --api-keys-file (one per line); the probes,
+/metrics and the OpenAPI document stay open. Requests can be limited per
+key with --rate-limit-rps and --quota. Spec URLs must be http(s) ones:
+local specs are uploaded, never read from the server's disk. Generated
+tests run with only the environment variables the go command needs, so
+code written for a submitted spec cannot read the server's provider keys.
+
+/readyz reports not ready while a provider of --required-providers has no
+API key or does not answer, and once the server is stopping: on SIGTERM it
+reports draining for --shutdown-delay, for load balancers to notice, then
+gives open requests --shutdown-timeout to finish.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("host", "127.0.0.1", "Interface to listen on (\"\" or 0.0.0.0 for all interfaces)")
+	serveCmd.Flags().Int("port", 8080, "Port to listen on (PORT env var also honoured)")
+	serveCmd.Flags().StringSlice("ai-models", []string{"gpt4"}, "AI models served to analysis runs")
+	serveCmd.Flags().String("job-store", "memory", "Job store backend (memory, redis or storage, the --storage of reports; JOB_STORE env var also honoured)")
+	serveCmd.Flags().String("redis-url", "redis://localhost:6379/0", "Redis URL for the redis job store (REDIS_URL env var also honoured)")
+	serveCmd.Flags().Int("job-workers", 1, "Number of analysis jobs run concurrently")
+	serveCmd.Flags().Int("job-queue-size", 100, "Number of jobs that may wait for a worker")
+	serveCmd.Flags().Duration("job-ttl", 24*time.Hour, "How long finished jobs and their reports are kept")
+	serveCmd.Flags().Bool("distributed", false, "Hand the endpoints of jobs to remote workers (glens worker)")
+	serveCmd.Flags().String("worker-token", "", "Bearer token workers must present (GLENS_WORKER_TOKEN env var also honoured)")
+	serveCmd.Flags().String("upload-dir", "", "Directory keeping uploaded specs until their job has run (default the system temporary directory)")
+	serveCmd.Flags().Int("max-spec-mb", server.DefaultMaxSpecSize>>20, "Largest spec upload, and request body, accepted in MiB")
+	serveCmd.Flags().Duration("lease-timeout", cluster.DefaultLeaseTimeout, "How long a worker may go without a heartbeat before its endpoint is reassigned")
+	serveCmd.Flags().String("api-keys-file", "", "File of API keys clients must present, one per line (API_KEYS_FILE env var also honoured)")
+	serveCmd.Flags().Float64("rate-limit-rps", 0, "Requests per second allowed per API key (0 disables)")
+	serveCmd.Flags().Int("rate-limit-burst", 0, "Requests an API key may burst above --rate-limit-rps (default --rate-limit-rps)")
+	serveCmd.Flags().Int("quota", 0, "Requests allowed per API key per --quota-window (0 disables)")
+	serveCmd.Flags().Duration("quota-window", apiauth.DefaultQuotaWindow, "Period --quota is counted over")
+	serveCmd.Flags().Duration("read-header-timeout", 10*time.Second, "Time to read request headers")
+	serveCmd.Flags().Duration("read-timeout", 30*time.Second, "Time to read a whole request")
+	serveCmd.Flags().Duration("write-timeout", 0, "Time to write a response, event streams excepted (0 disables)")
+	serveCmd.Flags().Duration("idle-timeout", 120*time.Second, "Keep-alive idle time")
+	serveCmd.Flags().Duration("shutdown-delay", 0, "Time /readyz reports draining on SIGTERM before connections are drained")
+	serveCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "Time open requests get to finish on SIGTERM")
+	serveCmd.Flags().StringSlice("required-providers", nil, "Providers (openai, anthropic) whose missing or failing API key makes /readyz not ready")
+	serveCmd.Flags().Duration("readiness-interval", readiness.DefaultInterval, "How often the provider preflights of /readyz re-run")
+
+	// Dedicated keys so serve flags do not shadow the analyze bindings
+	_ = viper.BindPFlag("serve.host", serveCmd.Flags().Lookup("host"))
+	_ = viper.BindPFlag("serve.port", serveCmd.Flags().Lookup("port"))
+	_ = viper.BindPFlag("serve.ai_models", serveCmd.Flags().Lookup("ai-models"))
+	_ = viper.BindPFlag("serve.job_store", serveCmd.Flags().Lookup("job-store"))
+	_ = viper.BindPFlag("serve.redis_url", serveCmd.Flags().Lookup("redis-url"))
+	_ = viper.BindPFlag("serve.job_workers", serveCmd.Flags().Lookup("job-workers"))
+	_ = viper.BindPFlag("serve.job_queue_size", serveCmd.Flags().Lookup("job-queue-size"))
+	_ = viper.BindPFlag("serve.job_ttl", serveCmd.Flags().Lookup("job-ttl"))
+	_ = viper.BindPFlag("serve.distributed", serveCmd.Flags().Lookup("distributed"))
+	_ = viper.BindPFlag("serve.worker_token", serveCmd.Flags().Lookup("worker-token"))
+	_ = viper.BindPFlag("serve.lease_timeout", serveCmd.Flags().Lookup("lease-timeout"))
+	_ = viper.BindPFlag("serve.upload_dir", serveCmd.Flags().Lookup("upload-dir"))
+	_ = viper.BindPFlag("serve.max_spec_mb", serveCmd.Flags().Lookup("max-spec-mb"))
+	_ = viper.BindPFlag("serve.api_keys_file", serveCmd.Flags().Lookup("api-keys-file"))
+	_ = viper.BindPFlag("serve.rate_limit_rps", serveCmd.Flags().Lookup("rate-limit-rps"))
+	_ = viper.BindPFlag("serve.rate_limit_burst", serveCmd.Flags().Lookup("rate-limit-burst"))
+	_ = viper.BindPFlag("serve.quota", serveCmd.Flags().Lookup("quota"))
+	_ = viper.BindPFlag("serve.quota_window", serveCmd.Flags().Lookup("quota-window"))
+	_ = viper.BindPFlag("serve.read_header_timeout", serveCmd.Flags().Lookup("read-header-timeout"))
+	_ = viper.BindPFlag("serve.read_timeout", serveCmd.Flags().Lookup("read-timeout"))
+	_ = viper.BindPFlag("serve.write_timeout", serveCmd.Flags().Lookup("write-timeout"))
+	_ = viper.BindPFlag("serve.idle_timeout", serveCmd.Flags().Lookup("idle-timeout"))
+	_ = viper.BindPFlag("serve.shutdown_delay", serveCmd.Flags().Lookup("shutdown-delay"))
+	_ = viper.BindPFlag("serve.shutdown_timeout", serveCmd.Flags().Lookup("shutdown-timeout"))
+	_ = viper.BindPFlag("serve.required_providers", serveCmd.Flags().Lookup("required-providers"))
+	_ = viper.BindPFlag("serve.readiness_interval", serveCmd.Flags().Lookup("readiness-interval"))
+	_ = viper.BindEnv("serve.ai_models", "AI_MODELS")
+	_ = viper.BindEnv("serve.port", "PORT")
+	_ = viper.BindEnv("serve.job_store", "JOB_STORE")
+	_ = viper.BindEnv("serve.redis_url", "REDIS_URL")
+	_ = viper.BindEnv("serve.worker_token", "GLENS_WORKER_TOKEN")
+	// Keys are read from the environment only, keeping them out of process
+	// listings; the names are those of the standalone API
+	_ = viper.BindEnv("serve.api_keys", "API_KEYS")
+	_ = viper.BindEnv("serve.api_keys_file", "API_KEYS_FILE")
+	_ = viper.BindEnv("serve.rate_limit_rps", "RATE_LIMIT_RPS")
+	_ = viper.BindEnv("serve.rate_limit_burst", "RATE_LIMIT_BURST")
+	_ = viper.BindEnv("serve.quota", "API_QUOTA")
+	_ = viper.BindEnv("serve.quota_window", "API_QUOTA_WINDOW")
+	_ = viper.BindEnv("serve.read_header_timeout", "READ_HEADER_TIMEOUT")
+	_ = viper.BindEnv("serve.read_timeout", "READ_TIMEOUT")
+	_ = viper.BindEnv("serve.write_timeout", "WRITE_TIMEOUT")
+	_ = viper.BindEnv("serve.idle_timeout", "IDLE_TIMEOUT")
+	_ = viper.BindEnv("serve.shutdown_delay", "SHUTDOWN_DELAY")
+	_ = viper.BindEnv("serve.shutdown_timeout", "SHUTDOWN_TIMEOUT")
+	_ = viper.BindEnv("serve.required_providers", "REQUIRED_PROVIDERS")
+	_ = viper.BindEnv("serve.readiness_interval", "READINESS_INTERVAL")
+}
+
+// ExecuteServe runs glens serve with args, for binaries that are the
+// server only, such as the standalone API.
+func ExecuteServe(version string, args []string) {
+	rootCmd.SetArgs(append([]string{"serve"}, args...))
+	Execute(version)
+}
+
+func runServe(cmd *cobra.Command, _ []string) error {
+	models := viper.GetStringSlice("serve.ai_models")
+	aiManager, err := newAIManager(models)
+	if err != nil {
+		return err
+	}
+
+	base := analysisOptionsFromConfig()
+	base.Models = models
+	base.CreateIssues = false
+	base.Output = ""
+	// The tests of submitted specs are written by models prompted with
+	// them, so they run without the provider keys of the server
+	base.ScrubEnv = true
+
+	store, err := newJobStore(viper.GetString("serve.job_store"), viper.GetDuration("serve.job_ttl"))
+	if err != nil {
+		return err
+	}
+
+	cfg := server.Config{
+		Version:     cmd.Root().Version,
+		Models:      serverModels(aiManager),
+		Store:       store,
+		Workers:     viper.GetInt("serve.job_workers"),
+		QueueSize:   viper.GetInt("serve.job_queue_size"),
+		UploadDir:   viper.GetString("serve.upload_dir"),
+		MaxSpecSize: int64(viper.GetInt("serve.max_spec_mb")) << 20,
+	}
+	cfg.DefaultModels = models
+	cfg.Estimate = serveEstimator(aiManager)
+	cfg.Health = aiManager.Health
+	if cfg.Prices, err = pricesFromConfig(); err != nil {
+		return err
+	}
+	if cfg.APIKeys, err = serveAPIKeys(); err != nil {
+		return err
+	}
+	if len(cfg.APIKeys) == 0 {
+		log.Warn().Msg("No API keys set (API_KEYS, --api-keys-file): anyone reaching the server can run analyses")
+	}
+	cfg.RateLimit = apiauth.RateLimitConfig{
+		RequestsPerSecond: viper.GetFloat64("serve.rate_limit_rps"),
+		Burst:             viper.GetInt("serve.rate_limit_burst"),
+		Quota:             viper.GetInt("serve.quota"),
+		QuotaWindow:       viper.GetDuration("serve.quota_window"),
+	}
+	var coordinator *cluster.Coordinator
+	if viper.GetBool("serve.distributed") {
+		token := viper.GetString("serve.worker_token")
+		if token == "" {
+			log.Warn().Msg("No worker token set: anyone reaching the server can lease endpoints")
+		}
+		coordinator = cluster.NewCoordinator(cluster.CoordinatorConfig{
+			Token:        token,
+			LeaseTimeout: viper.GetDuration("serve.lease_timeout"),
+		})
+		cfg.Cluster = coordinator.Handler()
+	}
+	cfg.Runner = serveRunner(aiManager, base, coordinator)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	checks := readiness.ProviderChecks(os.Getenv, &http.Client{Timeout: readiness.DefaultCheckTimeout},
+		viper.GetStringSlice("serve.required_providers"))
+	cfg.Readiness = readiness.New(checks, viper.GetDuration("serve.readiness_interval"))
+	cfg.Readiness.Start(ctx)
+	srv := server.New(cfg)
+
+	httpServer := &http.Server{
+		Addr:              net.JoinHostPort(viper.GetString("serve.host"), strconv.Itoa(viper.GetInt("serve.port"))),
+		Handler:           srv,
+		ReadHeaderTimeout: viper.GetDuration("serve.read_header_timeout"),
+		ReadTimeout:       viper.GetDuration("serve.read_timeout"),
+		WriteTimeout:      viper.GetDuration("serve.write_timeout"),
+		IdleTimeout:       viper.GetDuration("serve.idle_timeout"),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	log.Info().Str("addr", httpServer.Addr).Str("version", cmd.Root().Version).Strs("ai_models", models).Msg("starting API server")
+
+	var serveErr error
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr = fmt.Errorf("server failed: %w", err)
+		}
+	case <-ctx.Done():
+		// Report not ready first so load balancers stop routing new
+		// requests here, then drain the open connections
+		cfg.Readiness.Drain()
+		delay := viper.GetDuration("serve.shutdown_delay")
+		log.Info().Dur("delay", delay).Msg("shutting down API server")
+		time.Sleep(delay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("serve.shutdown_timeout"))
+	defer cancel()
+	// Running jobs are cancelled and event streams end while the HTTP
+	// server drains its connections, which would otherwise wait on them
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Shutdown(shutdownCtx) }()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		serveErr = errors.Join(serveErr, fmt.Errorf("failed to shut down server: %w", err))
+	}
+	if err := <-stopped; err != nil {
+		serveErr = errors.Join(serveErr, fmt.Errorf("failed to stop analysis jobs: %w", err))
+	}
+	return serveErr
+}
+
+// serveAPIKeys returns the API keys of API_KEYS and --api-keys-file
+func serveAPIKeys() ([]string, error) {
+	keys := apiauth.ParseKeys(strings.Join(viper.GetStringSlice("serve.api_keys"), ","))
+	if path := viper.GetString("serve.api_keys_file"); path != "" {
+		fileKeys, err := apiauth.LoadKeysFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	return keys, nil
+}
+
+// serverModels converts the manager's models to the API representation
+func serverModels(aiManager *ai.Manager) []server.Model {
+	var models []server.Model
+	for _, m := range aiManager.Models() {
+		models = append(models, server.Model(m))
+	}
+	return models
+}
+
+// serveEstimator estimates the token use of previews with the prompts of
+// the served models
+func serveEstimator(aiManager *ai.Manager) server.Estimator {
+	return func(endpoints []parser.Endpoint, models []string) ([]server.TokenEstimate, error) {
+		estimates, err := aiManager.EstimateTokens(endpoints, models)
+		if err != nil {
+			return nil, err
+		}
+		converted := make([]server.TokenEstimate, len(estimates))
+		for i, e := range estimates {
+			converted[i] = server.TokenEstimate(e)
+		}
+		return converted, nil
+	}
+}
+
+// newJobStore creates the job store selected by --job-store
+func newJobStore(kind string, ttl time.Duration) (jobs.Store, error) {
+	switch kind {
+	case "", "memory":
+		return jobs.NewMemoryStore(ttl), nil
+	case "redis":
+		store, err := jobs.NewRedisStore(viper.GetString("serve.redis_url"), ttl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize redis job store: %w", err)
+		}
+		return store, nil
+	case "storage":
+		store, err := openStorage()
+		if err != nil {
+			return nil, err
+		}
+		return jobs.NewObjectStore(store, ttl), nil
+	default:
+		return nil, fmt.Errorf("unsupported job store: %s (supported: memory, redis, storage)", kind)
+	}
+}
+
+// serveRunner runs queued jobs through the analysis pipeline. Jobs hold the
+// lock for their whole run even with several workers: clients in the shared
+// manager are not safe for concurrent use. With a coordinator the endpoints
+// are dispatched to remote workers instead and jobs run concurrently.
+func serveRunner(aiManager *ai.Manager, base analysisOptions, coordinator *cluster.Coordinator) jobs.Runner {
+	var mu sync.Mutex
+	return func(ctx context.Context, job *jobs.Job, progress func(jobs.Progress)) ([]byte, error) {
+		opts := base
+		if coordinator != nil {
+			opts.Dispatch = func(ctx context.Context, endpoint *parser.Endpoint, run *analysis.Options) (reporter.EndpointResult, error) {
+				return coordinator.Dispatch(ctx, cluster.Task{JobID: job.ID, Endpoint: *endpoint, Options: taskOptions(run)})
+			}
+		} else {
+			mu.Lock()
+			defer mu.Unlock()
+		}
+
+		if len(job.Models) > 0 {
+			opts.Models = job.Models
+		}
+		opts.Approved = job.ApprovedEndpoints
+		opts.Skipped = job.SkippedEndpoints
+		opts.Progress = progress
+
+		report, err := runAnalysis(ctx, job.SpecURL, opts, aiManager)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode report: %w", err)
+		}
+		return data, nil
+	}
+}
+
+// taskOptions carries the settings a worker needs to process an endpoint
+// the way the run would
+func taskOptions(opts *analysis.Options) cluster.TaskOptions {
+	return cluster.TaskOptions{
+		Models:          opts.Models,
+		Framework:       opts.Framework,
+		RunTests:        opts.RunTests,
+		AllowRisk:       opts.AllowRisk,
+		TestTimeout:     opts.TestTimeout,
+		TestRetries:     opts.TestRetries,
+		EndpointTimeout: opts.EndpointTimeout,
+		RepairAttempts:  opts.RepairAttempts,
+		Ensemble:        opts.Ensemble,
+		Lint:            opts.Lint,
+		Env:             opts.Env,
+	}
+}
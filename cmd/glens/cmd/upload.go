@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/events"
+	"glens/tools/glens/internal/storage"
+)
+
+// openStorage opens the storage keeping reports and jobs (--storage): a
+// directory, sqlite://path, s3://bucket/prefix or gs://bucket/prefix; the
+// working directory by default
+func openStorage() (storage.Store, error) {
+	store, err := storage.New(viper.GetString("storage.url"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+	return store, nil
+}
+
+// uploadTarget is where a run's artifacts are uploaded (--upload)
+type uploadTarget struct {
+	store  storage.Store
+	prefix string
+}
+
+// openUpload opens the object storage of target, s3://bucket/prefix or
+// gs://bucket/prefix; it is nil without a target
+func openUpload(target string) (*uploadTarget, error) {
+	if target == "" {
+		return nil, nil //nolint:nilnil // no upload configured is not an error
+	}
+	store, prefix, err := storage.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	return &uploadTarget{store: store, prefix: prefix}, nil
+}
+
+// runArtifacts lists what a run wrote: the report (and, for several specs,
+// the portfolio and each service's report directory) under reports/, the
+// generated tests under tests/, the generation artifacts under artifacts/
+// and the events file
+func runArtifacts(opts analysisOptions, services []serviceSpec, eventsFile string) ([]storage.Artifact, error) {
+	var artifacts []storage.Artifact
+	if output, local := reportFile(opts); output != "" && !local {
+		log.Info().
+			Str("location", opts.Storage.Location(filepath.ToSlash(output))).
+			Msg("Reports are kept by --storage and not uploaded")
+	} else if output != "" {
+		if len(services) > 1 {
+			portfolio := portfolioOutput(output)
+			artifacts = append(artifacts, storage.Artifact{Key: "reports/" + filepath.Base(portfolio), Path: portfolio})
+			for _, service := range services {
+				dir, err := storage.Dir(filepath.Join(filepath.Dir(output), service.Name), "reports/"+service.Name)
+				if err != nil {
+					return nil, err
+				}
+				artifacts = append(artifacts, dir...)
+			}
+		} else {
+			artifacts = append(artifacts, storage.Artifact{Key: "reports/" + filepath.Base(output), Path: output})
+		}
+	}
+	if opts.TestsOutputDir != "" {
+		tests, err := storage.Dir(opts.TestsOutputDir, "tests")
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, tests...)
+	}
+	if opts.ArtifactsDir != "" {
+		generations, err := storage.Dir(opts.ArtifactsDir, "artifacts")
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, generations...)
+	}
+	if eventsFile != "" {
+		artifacts = append(artifacts, storage.Artifact{Key: filepath.Base(eventsFile), Path: eventsFile})
+	}
+	return artifacts, nil
+}
+
+// reportFile is the file the report of opts is written to, and whether it
+// is a local file rather than an object of a database or object storage
+func reportFile(opts analysisOptions) (string, bool) {
+	if opts.Output == "" {
+		return "", true
+	}
+	fsys, ok := opts.reports().(*storage.FS)
+	if !ok {
+		return opts.Output, false
+	}
+	return fsys.Location(filepath.ToSlash(opts.Output)), true
+}
+
+// uploadArtifacts uploads the artifacts of a run under
+// <prefix>/<run ID>, the run ID of the events file when one is recorded
+func uploadArtifacts(ctx context.Context, target *uploadTarget, opts analysisOptions, services []serviceSpec, eventsFile string) error {
+	artifacts, err := runArtifacts(opts, services, eventsFile)
+	if err != nil {
+		return err
+	}
+	runID := opts.Events.RunID()
+	if runID == "" {
+		if runID, err = events.NewRunID(); err != nil {
+			return err
+		}
+	}
+	location, err := storage.Upload(ctx, target.store, target.prefix, runID, artifacts)
+	if err != nil {
+		return fmt.Errorf("failed to upload run artifacts: %w", err)
+	}
+	log.Info().
+		Str("location", location).
+		Str("run_id", runID).
+		Int("files", len(artifacts)).
+		Msg("Run artifacts uploaded")
+	return nil
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/pkg/modelcatalog"
+
+	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/storage"
+)
+
+// usageGroups are the groupings glens usage prints, in order
+var usageGroups = []string{"model", "spec", "month"}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage [report.json|directory]...",
+	Short: "Summarize the AI tokens and cost of past runs",
+	Long: `Reads the JSON reports of past runs and prints the AI tokens they used
+and their cost, grouped by model, by spec and by month, so AI spend can be
+charged back to the teams owning the specs. Directories are searched for
+JSON reports recursively (default reports/); other JSON files are skipped.
+Only reports written as JSON (--output=report.json) are read, from the
+--storage the runs kept them in.
+
+Costs use the prices of the pricing config section, in USD per million
+tokens per model, or else the list prices of the model catalog (glens
+models list) at the mean of their input and output price, since reports
+record their sum only; models without a price are listed and cost nothing.
+Tests generated by a fallback model count for the model that wrote them.
+
+Examples:
+  glens usage
+  glens usage reports/ archive/2026 --by=spec,month
+  glens usage --output=usage.csv`,
+	RunE: runUsage,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().String("format", "table", "Output format (table, csv or json); inferred from --output's extension when not set")
+	usageCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	usageCmd.Flags().StringSlice("by", usageGroups, "Groupings to show (model, spec, month)")
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	groups, _ := cmd.Flags().GetStringSlice("by")
+	if !cmd.Flags().Changed("format") {
+		switch strings.ToLower(filepath.Ext(output)) {
+		case ".csv":
+			format = "csv"
+		case ".json":
+			format = "json"
+		}
+	}
+	if !slices.Contains([]string{"table", "csv", "json"}, format) {
+		return fmt.Errorf("unsupported format %q (use table, csv or json)", format)
+	}
+	for _, group := range groups {
+		if !slices.Contains(usageGroups, group) {
+			return fmt.Errorf("unsupported grouping %q (use model, spec or month)", group)
+		}
+	}
+	if len(args) == 0 {
+		args = []string{"reports"}
+	}
+
+	prices, err := pricesFromConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openStorage()
+	if err != nil {
+		return err
+	}
+	reports, err := readUsageReports(cmd.Context(), store, args)
+	if err != nil {
+		return err
+	}
+	usage := reporter.BuildUsage(reports, reportPrices(prices))
+
+	out := cmd.OutOrStdout()
+	if output != "" {
+		if err := reporter.EnsureReportDirectory(output); err != nil {
+			return err
+		}
+		f, err := os.Create(output) // #nosec G304 -- the path is chosen by the user
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer f.Close() //nolint:errcheck
+		out = f
+	}
+
+	switch format {
+	case "csv":
+		err = reporter.WriteUsageCSV(out, usage, groups)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(usage)
+	default:
+		err = printUsage(out, usage, groups)
+	}
+	if err != nil {
+		return err
+	}
+	if f, ok := out.(*os.File); ok && output != "" {
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Usage written to %s\n", output)
+	}
+	if len(usage.Unpriced) > 0 {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "No price known for %s; add them to the pricing config section\n",
+			strings.Join(usage.Unpriced, ", "))
+	}
+	return nil
+}
+
+// readUsageReports reads the JSON reports at paths of store, searching
+// directories (key prefixes) recursively; objects that are not glens
+// reports are skipped
+func readUsageReports(ctx context.Context, store storage.Store, paths []string) ([]*reporter.Report, error) {
+	var reports []*reporter.Report
+	for _, name := range paths {
+		store, root := storage.UserPath(store, name)
+		keys, err := store.List(ctx, strings.TrimSuffix(root, "/")+"/")
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			report, err := reporter.LoadReport(ctx, store, root)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, report)
+			continue
+		}
+		for _, key := range keys {
+			if !strings.EqualFold(path.Ext(key), ".json") {
+				continue
+			}
+			report, err := reporter.LoadReport(ctx, store, key)
+			if err != nil || report.GeneratedAt.IsZero() {
+				log.Debug().Err(err).Str("file", store.Location(key)).Msg("Skipping file that is not a report")
+				continue
+			}
+			reports = append(reports, report)
+		}
+	}
+	if len(reports) == 0 {
+		return nil, errors.New("no JSON reports found; write them with analyze --output=reports/<name>.json")
+	}
+	return reports, nil
+}
+
+// printUsage writes the groups of usage as aligned text tables
+func printUsage(out io.Writer, usage *reporter.Usage, groups []string) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	all := usage.Groups()
+	for _, group := range groups {
+		_, _ = fmt.Fprintf(tw, "%s\tRUNS\tTESTS\tTOKENS\tCOST (USD)\n", strings.ToUpper(group))
+		for _, row := range all[group] {
+			printUsageRow(tw, row)
+		}
+		printUsageRow(tw, usage.Total)
+		_, _ = fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// printUsageRow writes a row of usage
+func printUsageRow(out io.Writer, row reporter.UsageRow) {
+	_, _ = fmt.Fprintf(out, "%s\t%d\t%d\t%d\t%.2f\n", row.Name, row.Runs, row.Tests, row.Tokens, row.CostUSD)
+}
+
+// pricesFromConfig returns the prices of models in USD per million tokens:
+// the list prices of the model catalog, by ID and alias, overridden by the
+// pricing config section, which prices input and output tokens alike
+func pricesFromConfig() (map[string]modelcatalog.Price, error) {
+	var configured []reporter.Price
+	if err := viper.UnmarshalKey("pricing", &configured); err != nil {
+		return nil, fmt.Errorf("failed to read pricing: %w", err)
+	}
+	prices := make(map[string]modelcatalog.Price)
+	for _, m := range modelcatalog.Default().Models() {
+		if m.Price == nil {
+			continue
+		}
+		for _, name := range append([]string{m.ID}, m.Aliases...) {
+			prices[name] = *m.Price
+		}
+	}
+	for _, p := range configured {
+		prices[p.Model] = modelcatalog.Price{Input: p.USDPerMillionTokens, Output: p.USDPerMillionTokens}
+	}
+	return prices, nil
+}
+
+// reportPrices are the prices of reports, which count tokens without
+// splitting input from output, at the blended price of each model
+func reportPrices(prices map[string]modelcatalog.Price) []reporter.Price {
+	blended := make([]reporter.Price, 0, len(prices))
+	for model, price := range prices {
+		blended = append(blended, reporter.Price{Model: model, USDPerMillionTokens: price.Blended()})
+	}
+	return blended
+}
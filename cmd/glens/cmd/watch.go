@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// watchDebounce coalesces the burst of events editors emit on one save
+const watchDebounce = 300 * time.Millisecond
+
+// specWatch holds the latest spec and per-endpoint results of a watch
+// session so each re-run only analyzes what changed
+type specWatch struct {
+	opts      analysisOptions
+	aiManager *ai.Manager
+	out       io.Writer
+	filter    *parser.Filter
+	spec      *parser.OpenAPISpec
+	results   map[string]reporter.EndpointResult // key: endpoint ID
+}
+
+// watchAnalysis analyzes the spec file at specPath, then re-analyzes the
+// endpoints that changed each time the file is saved, until ctx is done.
+// Results stream to out; the report always covers the whole current spec.
+func watchAnalysis(ctx context.Context, specPath string, opts analysisOptions, aiManager *ai.Manager, out io.Writer) error {
+	if strings.HasPrefix(specPath, "http://") || strings.HasPrefix(specPath, "https://") {
+		return fmt.Errorf("--watch needs a local spec file, got %s", specPath)
+	}
+	path, err := filepath.Abs(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve spec path: %w", err)
+	}
+
+	filter, err := parser.NewFilter(opts.Selection)
+	if err != nil {
+		return err
+	}
+	spec, err := parser.ParseOpenAPISpec(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	opts.Events.Emit(specParsedEvent(specPath, spec))
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+	// Editors often save by replacing the file, so watch its directory
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", filepath.Dir(path), err)
+	}
+
+	w := &specWatch{
+		opts:      opts,
+		aiManager: aiManager,
+		out:       out,
+		filter:    filter,
+		spec:      &parser.OpenAPISpec{},
+		results:   make(map[string]reporter.EndpointResult),
+	}
+	w.opts.OnEndpoint = func(_ context.Context, result *reporter.EndpointResult) {
+		printEndpointResult(out, result)
+	}
+
+	_, _ = fmt.Fprintf(out, "🔍 Analyzing %s\n", specPath)
+	if err := w.update(ctx, spec); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(out, "👀 Watching %s for changes (Ctrl+C to stop)\n", specPath)
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create) {
+				debounce = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warn().Err(err).Msg("File watcher error")
+		case <-debounce:
+			debounce = nil
+			w.reload(ctx, path, specPath)
+		}
+	}
+}
+
+// reload re-parses the spec after a save; parse and analysis errors are
+// reported and the session keeps watching
+func (w *specWatch) reload(ctx context.Context, path, name string) {
+	spec, err := parser.ParseOpenAPISpec(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(w.out, "❌ %s: %v\n", name, err)
+		return
+	}
+	w.opts.Events.Emit(specParsedEvent(name, spec))
+
+	_, _ = fmt.Fprintf(w.out, "\n🔄 %s changed\n", name)
+	if err := w.update(ctx, spec); err != nil && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(w.out, "❌ %v\n", err)
+	}
+}
+
+// update analyzes the endpoints of spec that are new or changed since the
+// previous version and rewrites the report
+func (w *specWatch) update(ctx context.Context, spec *parser.OpenAPISpec) error {
+	changed, removed := parser.ChangedEndpoints(w.spec, spec)
+	changed = w.selected(changed)
+	w.spec = spec
+
+	for _, id := range removed {
+		if _, ok := w.results[id]; ok {
+			delete(w.results, id)
+			_, _ = fmt.Fprintf(w.out, "➖ %s removed\n", id)
+		}
+	}
+	if len(changed) == 0 {
+		if len(removed) == 0 {
+			_, _ = fmt.Fprintln(w.out, "No selected endpoint changed")
+			return nil
+		}
+		return w.writeReport(ctx, nil)
+	}
+
+	opts := w.opts
+	opts.Approved = make([]string, len(changed))
+	for i := range changed {
+		opts.Approved[i] = changed[i].ID
+	}
+	report, err := analyzeSpec(ctx, spec, opts, w.aiManager)
+	if err != nil {
+		return err
+	}
+	for i := range report.EndpointResults {
+		w.results[report.EndpointResults[i].Endpoint.ID] = report.EndpointResults[i]
+	}
+	return w.writeReport(ctx, report.Metadata)
+}
+
+// selected drops changed endpoints outside the run's --op-id and selection
+func (w *specWatch) selected(endpoints []parser.Endpoint) []parser.Endpoint {
+	endpoints = w.filter.Select(endpoints)
+	if w.opts.OperationID == "" {
+		return endpoints
+	}
+	kept := endpoints[:0]
+	for i := range endpoints {
+		if endpoints[i].OperationID == w.opts.OperationID {
+			kept = append(kept, endpoints[i])
+		}
+	}
+	return kept
+}
+
+// writeReport rebuilds the report from the latest result of every endpoint,
+// keeping the run settings recorded in metadata
+func (w *specWatch) writeReport(ctx context.Context, metadata map[string]interface{}) error {
+	results := make([]reporter.EndpointResult, 0, len(w.results))
+	for i := range w.spec.Endpoints {
+		if result, ok := w.results[w.spec.Endpoints[i].ID]; ok {
+			results = append(results, result)
+		}
+	}
+
+	report := analysis.BuildReport(ctx, w.spec, results, &w.opts.Options)
+	for key, value := range metadata {
+		if _, ok := report.Metadata[key]; !ok {
+			report.Metadata[key] = value
+		}
+	}
+	if err := writeReport(ctx, w.opts.reports(), report, w.opts.Output, w.opts.OutputFormat); err != nil {
+		return err
+	}
+	if err := writeTestSuite(report, w.opts); err != nil {
+		return err
+	}
+	if err := writeArtifactsReport(report, w.opts); err != nil {
+		return err
+	}
+	if w.opts.Output != "" {
+		_, _ = fmt.Fprintf(w.out, "📄 Report updated: %s (%d endpoints)\n", w.opts.Output, len(results))
+	}
+	return nil
+}
+
+// printEndpointResult writes one line per endpoint with each model's outcome
+func printEndpointResult(out io.Writer, result *reporter.EndpointResult) {
+	models := make([]string, 0, len(result.Tests))
+	for model := range result.Tests {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	icon := "📝"
+	outcomes := make([]string, len(models))
+	for i, model := range models {
+		outcome := testOutcomeLabel(result.Tests[model])
+		switch {
+		case outcome == "failed" || strings.HasPrefix(outcome, "error"):
+			icon = "❌"
+		case strings.HasPrefix(outcome, "flaky") && icon != "❌":
+			icon = "⚠️"
+		case outcome == "passed" && icon == "📝":
+			icon = "✅"
+		}
+		outcomes[i] = model + ": " + outcome
+	}
+	if len(models) == 0 {
+		icon = "❌"
+		outcomes = []string{"no test generated"}
+	}
+
+	_, _ = fmt.Fprintf(out, "%s %s %s  %s\n", icon, result.Endpoint.Method, result.Endpoint.Path, strings.Join(outcomes, ", "))
+	for _, warning := range result.Warnings {
+		_, _ = fmt.Fprintf(out, "   ⚠️  %s\n", warning)
+	}
+}
+
+// testOutcomeLabel summarises one model's test for the console
+func testOutcomeLabel(test reporter.TestResult) string {
+	switch exec := test.ExecutionResult; {
+	case test.ExecutionError != "":
+		return "error (" + test.ExecutionError + ")"
+	case exec == nil:
+		return "generated"
+	case exec.Flaky:
+		return fmt.Sprintf("flaky (passed on attempt %d)", exec.Attempts)
+	case exec.Passed:
+		return "passed"
+	case exec.Skipped:
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
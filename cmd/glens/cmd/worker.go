@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/cluster"
+	"glens/tools/glens/internal/reporter"
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Process endpoints of a distributed glens serve",
+	Long: `Joins a "glens serve --distributed" coordinator and processes the
+endpoints it hands out: the worker leases one endpoint at a time, generates
+(and, when the job asks for it, executes) its tests with the models given by
+--ai-models, and reports the result back. Only endpoints whose job uses a
+subset of the worker's models are leased, so a GPU box can serve ollama
+models while a laptop serves hosted ones.
+
+AI provider settings (keys, endpoints, ai_models config) are read from the
+worker's own configuration. When the coordinator requires API keys, pass
+one with --api-key (GLENS_API_KEY) besides the worker token. Stop the worker with Ctrl-C; an endpoint in
+progress is handed back to the coordinator for another worker.
+
+Examples:
+  glens worker --join http://glens.internal:8080 --ai-models ollama:llama3
+  GLENS_WORKER_TOKEN=s3cret GLENS_API_KEY=k1 glens worker --join https://glens.example.com --ai-models gpt-4o,sonnet4`,
+	Args: cobra.NoArgs,
+	RunE: runWorker,
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+
+	workerCmd.Flags().String("join", "", "Base URL of the coordinating glens serve")
+	workerCmd.Flags().StringSlice("ai-models", nil, "AI models this worker serves")
+	workerCmd.Flags().String("token", "", "Worker token of the coordinator (GLENS_WORKER_TOKEN env var also honoured)")
+	workerCmd.Flags().String("api-key", "", "API key of the coordinator (GLENS_API_KEY env var also honoured)")
+	workerCmd.Flags().String("id", "", "Worker ID reported to the coordinator (default <hostname>-<pid>)")
+	_ = workerCmd.MarkFlagRequired("join")
+
+	_ = viper.BindPFlag("worker.join", workerCmd.Flags().Lookup("join"))
+	_ = viper.BindPFlag("worker.ai_models", workerCmd.Flags().Lookup("ai-models"))
+	_ = viper.BindPFlag("worker.token", workerCmd.Flags().Lookup("token"))
+	_ = viper.BindPFlag("worker.api_key", workerCmd.Flags().Lookup("api-key"))
+	_ = viper.BindPFlag("worker.id", workerCmd.Flags().Lookup("id"))
+	_ = viper.BindEnv("worker.token", "GLENS_WORKER_TOKEN")
+	_ = viper.BindEnv("worker.api_key", "GLENS_API_KEY")
+}
+
+func runWorker(_ *cobra.Command, _ []string) error {
+	models := viper.GetStringSlice("worker.ai_models")
+	if len(models) == 0 {
+		return errors.New("--ai-models is required: name the models this worker serves")
+	}
+	aiManager, err := newAIManager(models)
+	if err != nil {
+		return err
+	}
+
+	id := viper.GetString("worker.id")
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	worker := &cluster.Worker{
+		Coordinator: viper.GetString("worker.join"),
+		Token:       viper.GetString("worker.token"),
+		APIKey:      viper.GetString("worker.api_key"),
+		ID:          id,
+		Models:      models,
+		Process: func(ctx context.Context, task cluster.Task) (reporter.EndpointResult, error) {
+			aiManager.SetEnvironment(task.Options.Env)
+			return analysis.ProcessEndpoint(ctx, &task.Endpoint, aiManager, analysis.Options{
+				Models:          task.Options.Models,
+				Framework:       task.Options.Framework,
+				RunTests:        task.Options.RunTests,
+				AllowRisk:       task.Options.AllowRisk,
+				TestTimeout:     task.Options.TestTimeout,
+				TestRetries:     task.Options.TestRetries,
+				EndpointTimeout: task.Options.EndpointTimeout,
+				RepairAttempts:  task.Options.RepairAttempts,
+				Ensemble:        task.Options.Ensemble,
+				Lint:            task.Options.Lint,
+				Env:             task.Options.Env,
+				// Tasks come from specs submitted to the coordinator
+				ScrubEnv: true,
+			})
+		},
+	}
+	return worker.Run(ctx)
+}
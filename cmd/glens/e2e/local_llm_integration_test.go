@@ -292,23 +292,35 @@ func TestLocalLLM_AnalyzeSpec_CustomOllamaModel(t *testing.T) {
 }
 
 // TestLocalLLM_AnalyzeSpec_OllamaServerDown verifies that when the Ollama server
-// is unreachable, glens still exits cleanly (exit 0) without panicking.
-// The analyze command treats AI generation failures as non-fatal per-endpoint
-// errors (it logs them and continues), so the process must not crash.
+// is unreachable, the preflight check fails analyze up front with a clear
+// error, and that with --preflight=false generation failures are logged per
+// endpoint instead; the process must never panic.
 func TestLocalLLM_AnalyzeSpec_OllamaServerDown(t *testing.T) {
 	// Write a config pointing at a port where nothing is listening.
 	cfgFile := writeConfig(t, "http://127.0.0.1:1")
 	specPath := sampleSpecPath(t)
 	reportPath := filepath.Join(t.TempDir(), "report.md")
 
-	// Discard error: we expect analyze to exit 0 even when Ollama is down,
-	// because generation failures are logged and skipped per endpoint.
-	out, _ := runGlens(t,
+	out, err := runGlens(t,
+		"analyze", specPath,
+		"--config", cfgFile,
+		"--ai-models", "ollama",
+		"--create-issues=false",
+		"--run-tests=false",
+		"--output", reportPath,
+	)
+	require.Error(t, err, "preflight must fail when Ollama is unreachable; output:\n%s", out)
+	assert.Contains(t, out, "AI model 'ollama' failed preflight")
+
+	// Discard error: without preflight, generation failures are logged and
+	// skipped per endpoint.
+	out, _ = runGlens(t,
 		"analyze", specPath,
 		"--config", cfgFile,
 		"--ai-models", "ollama",
 		"--create-issues=false",
 		"--run-tests=false",
+		"--preflight=false",
 		"--output", reportPath,
 	)
 
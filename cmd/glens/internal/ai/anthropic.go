@@ -1,13 +1,16 @@
 package ai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
-	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -17,18 +20,42 @@ import (
 
 // AnthropicClient implements the Client interface for Anthropic Claude models
 type AnthropicClient struct {
-	apiKey    string
-	baseURL   string
-	model     string
-	maxTokens int
-	client    *http.Client
+	apiKey   string
+	baseURL  string
+	model    string
+	sampling Sampling
+	stream   bool
+	beta     []string
+	client   *http.Client
+	promptEnvironment
+	promptTemplates
 }
 
 // AnthropicRequest represents the request structure for Anthropic API
 type AnthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	Messages  []AnthropicMessage `json:"messages"`
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	TopP        *float64             `json:"top_p,omitempty"`
+	System      string               `json:"system,omitempty"`
+	Messages    []AnthropicMessage   `json:"messages"`
+	Tools       []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *AnthropicToolChoice `json:"tool_choice,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+}
+
+// AnthropicTool is a tool the model may call; structured output forces a
+// call whose input is the generated test
+type AnthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// AnthropicToolChoice selects the tool the model must call
+type AnthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 // AnthropicMessage represents a message in Anthropic format
@@ -44,13 +71,18 @@ type AnthropicResponse struct {
 	Role    string             `json:"role"`
 	Content []AnthropicContent `json:"content"`
 	Model   string             `json:"model"`
-	Usage   AnthropicUsage     `json:"usage"`
+	// StopReason is "max_tokens" when the answer was cut off
+	StopReason string         `json:"stop_reason"`
+	Usage      AnthropicUsage `json:"usage"`
 }
 
 // AnthropicContent represents content in the response
 type AnthropicContent struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
+	// Name and Input are set on tool_use blocks
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
 }
 
 // AnthropicUsage represents token usage
@@ -59,38 +91,79 @@ type AnthropicUsage struct {
 	OutputTokens int `json:"output_tokens"`
 }
 
-// NewAnthropicClient creates a new Anthropic client
-func NewAnthropicClient() (*AnthropicClient, error) {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
+// NewAnthropicClient creates a new Anthropic client from cfg; opts override
+// the configured model, base URL and timeout
+func NewAnthropicClient(cfg AnthropicConfig, opts ...Option) (*AnthropicClient, error) {
+	if cfg.APIKey == "" {
 		return nil, ErrAPIKeyMissing{Model: "Anthropic"}
 	}
+	o := resolve(opts,
+		orDefault(cfg.BaseURL, DefaultAnthropicBaseURL),
+		orDefault(cfg.Model, "claude-sonnet-4-5"),
+		orDefault(cfg.Timeout, defaultCloudTimeout))
+
+	// Without a configured temperature the API default applies
+	sampling := cfg.Sampling
+	sampling.MaxTokens = orDefault(sampling.MaxTokens, defaultAnthropicMaxTokens)
+	sampling.Seed = nil
+	o.tls = cfg.TLS
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
+	}
 
 	return &AnthropicClient{
-		apiKey:    apiKey,
-		baseURL:   "https://api.anthropic.com",
-		model:     "claude-3-sonnet-20240229",
-		maxTokens: 4000,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		apiKey:          cfg.APIKey,
+		baseURL:         o.baseURL,
+		model:           o.model,
+		sampling:        sampling,
+		stream:          cfg.Stream,
+		beta:            cfg.Beta,
+		client:          client,
+		promptTemplates: promptTemplates{structured: structuredOutput(cfg.ResponseFormat)},
 	}, nil
 }
 
 // GenerateTest generates integration test code using Anthropic Claude
 func (c *AnthropicClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
+	prompt, err := c.buildPrompt(endpoint)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+	return c.complete(ctx, endpoint, prompt)
+}
+
+// RepairTest asks Anthropic Claude to fix testCode given the failure it produced
+func (c *AnthropicClient) RepairTest(ctx context.Context, endpoint *parser.Endpoint, testCode, failure string) (*TestGenerationResult, error) {
+	prompt, err := c.renderRepairPrompt(endpoint, c.environmentPrompt(), testCode, failure)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+	return c.complete(ctx, endpoint, prompt)
+}
+
+// complete sends prompt to Anthropic Claude and returns the test it answers with
+func (c *AnthropicClient) complete(ctx context.Context, endpoint *parser.Endpoint, prompt string) (*TestGenerationResult, error) {
 	startTime := time.Now()
 
-	prompt := c.buildPrompt(endpoint)
+	system, err := c.systemPrompt(endpoint)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
 
 	log.Debug().
 		Str("model", c.model).
 		Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+		Bool("stream", c.stream).
 		Msg("Generating test with Anthropic Claude")
 
 	request := AnthropicRequest{
-		Model:     c.model,
-		MaxTokens: c.maxTokens,
+		Model:       c.model,
+		MaxTokens:   c.sampling.MaxTokens,
+		Temperature: c.sampling.Temperature,
+		TopP:        c.sampling.TopP,
+		System:      system,
+		Stream:      c.stream,
 		Messages: []AnthropicMessage{
 			{
 				Role:    "user",
@@ -98,12 +171,21 @@ func (c *AnthropicClient) GenerateTest(ctx context.Context, endpoint *parser.End
 			},
 		},
 	}
+	if c.structured {
+		request.Tools = []AnthropicTool{{
+			Name:        generatedTestTool,
+			Description: "Submit the generated Go integration test",
+			InputSchema: generatedTestSchema,
+		}}
+		request.ToolChoice = &AnthropicToolChoice{Type: "tool", Name: generatedTestTool}
+	}
 
 	response, err := c.makeRequest(ctx, request)
 	if err != nil {
 		return nil, ErrGenerationFailed{
 			Model:  c.GetModelName(),
 			Reason: err.Error(),
+			Err:    err,
 		}
 	}
 
@@ -114,12 +196,18 @@ func (c *AnthropicClient) GenerateTest(ctx context.Context, endpoint *parser.End
 		}
 	}
 
-	testCode := response.Content[0].Text
+	if response.StopReason == "max_tokens" {
+		log.Warn().
+			Str("model", c.model).
+			Int("max_tokens", c.sampling.MaxTokens).
+			Msg("Anthropic answer was truncated; raise max_tokens")
+	}
+
 	generationTime := time.Since(startTime)
 
 	result := &TestGenerationResult{
-		TestCode:       testCode,
 		Prompt:         prompt,
+		SystemPrompt:   system,
 		ModelUsed:      c.model,
 		Framework:      "testify",
 		TestCategories: []string{"happy-path", "error-handling", "boundary", "security"},
@@ -132,6 +220,11 @@ func (c *AnthropicClient) GenerateTest(ctx context.Context, endpoint *parser.End
 			"output_tokens": fmt.Sprintf("%d", response.Usage.OutputTokens),
 		},
 	}
+	if response.StopReason != "" {
+		result.Metadata["stop_reason"] = response.StopReason
+	}
+	maps.Copy(result.Metadata, c.sampling.Params())
+	result.applyAnswer(response.answer())
 
 	log.Info().
 		Str("model", c.model).
@@ -152,94 +245,63 @@ func (c *AnthropicClient) GetCapabilities() ModelCapabilities {
 	return ModelCapabilities{
 		SupportsGoTests:      true,
 		SupportsSecurityTest: true,
-		SupportedFrameworks:  []string{"testify", "ginkgo", "standard"},
-		MaxTokens:            c.maxTokens,
+		SupportedFrameworks:  []string{"testify", "ginkgo", "standard", "grpc"},
+		MaxTokens:            c.sampling.MaxTokens,
 		Languages:            []string{"go", "python", "javascript", "java", "rust"},
 	}
 }
 
-// buildPrompt creates the detailed prompt for test generation
-func (c *AnthropicClient) buildPrompt(endpoint *parser.Endpoint) string {
-	var prompt bytes.Buffer
-
-	prompt.WriteString("You are an expert software testing engineer specializing in API integration testing with Go.\n\n")
-	prompt.WriteString("Generate comprehensive integration tests for the following OpenAPI endpoint using Go and the testify framework:\n\n")
-
-	prompt.WriteString("**Endpoint Details:**\n")
-	fmt.Fprintf(&prompt, "- Method: %s\n", endpoint.Method)
-	fmt.Fprintf(&prompt, "- Path: %s\n", endpoint.Path)
-
-	if endpoint.OperationID != "" {
-		fmt.Fprintf(&prompt, "- Operation ID: %s\n", endpoint.OperationID)
+// HealthCheck verifies that the API accepts the key and serves the model
+func (c *AnthropicClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/models/"+c.model, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	return checkModel(c.client, req, c.model)
+}
 
-	if endpoint.Summary != "" {
-		fmt.Fprintf(&prompt, "- Summary: %s\n", endpoint.Summary)
-	}
+// systemPrompt renders the anthropic-system prompt template for endpoint
+func (c *AnthropicClient) systemPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("anthropic-system", endpoint, c.environmentPrompt())
+}
 
-	if endpoint.Description != "" {
-		fmt.Fprintf(&prompt, "- Description: %s\n", endpoint.Description)
+// betas returns the anthropic-beta features of a request: the configured
+// ones and those older models need to generate MaxTokens
+func (c *AnthropicClient) betas() []string {
+	betas := slices.Clone(c.beta)
+	var output string
+	switch {
+	case strings.HasPrefix(c.model, "claude-3-5-sonnet") && c.sampling.MaxTokens > 4096:
+		output = "max-tokens-3-5-sonnet-2024-07-15"
+	case strings.HasPrefix(c.model, "claude-3-7-sonnet") && c.sampling.MaxTokens > 64000:
+		output = "output-128k-2025-02-19"
 	}
-
-	// Parameters
-	if len(endpoint.Parameters) > 0 {
-		prompt.WriteString("\n**Parameters:**\n")
-		for i := range endpoint.Parameters {
-			param := &endpoint.Parameters[i]
-			required := "optional"
-			if param.Required {
-				required = "required"
-			}
-			fmt.Fprintf(&prompt, "- %s (%s, %s): %s [Type: %s]\n",
-				param.Name, param.In, required, param.Description, param.Schema.Type)
-		}
+	if output != "" && !slices.Contains(betas, output) {
+		betas = append(betas, output)
 	}
+	return betas
+}
 
-	// Request Body
-	if endpoint.RequestBody != nil {
-		prompt.WriteString("\n**Request Body:**\n")
-		if endpoint.RequestBody.Description != "" {
-			fmt.Fprintf(&prompt, "- Description: %s\n", endpoint.RequestBody.Description)
-		}
-		prompt.WriteString("- Content Types:\n")
-		for contentType := range endpoint.RequestBody.Content {
-			mediaType := endpoint.RequestBody.Content[contentType]
-			fmt.Fprintf(&prompt, "  - %s: %s\n", contentType, mediaType.Schema.Type)
-		}
-	}
+// buildPrompt renders the anthropic prompt template for endpoint
+func (c *AnthropicClient) buildPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("anthropic", endpoint, c.environmentPrompt())
+}
 
-	// Responses
-	if len(endpoint.Responses) > 0 {
-		prompt.WriteString("\n**Expected Responses:**\n")
-		for code, response := range endpoint.Responses {
-			fmt.Fprintf(&prompt, "- %s: %s\n", code, response.Description)
+// answer returns the input of the submitted test tool call, or the text
+// blocks when the model answered without calling it
+func (r *AnthropicResponse) answer() string {
+	var text strings.Builder
+	for _, content := range r.Content {
+		switch {
+		case content.Type == "tool_use" && content.Name == generatedTestTool:
+			return string(content.Input)
+		case content.Type == "text":
+			text.WriteString(content.Text)
 		}
 	}
-
-	prompt.WriteString("\n**Requirements:**\n")
-	prompt.WriteString("1. Use Go programming language with testify framework\n")
-	prompt.WriteString("2. Include proper imports and package declaration\n")
-	prompt.WriteString("3. Generate realistic test data and scenarios\n")
-	prompt.WriteString("4. Cover all response status codes\n")
-	prompt.WriteString("5. Test parameter validation (required vs optional)\n")
-	prompt.WriteString("6. Include error handling scenarios\n")
-	prompt.WriteString("7. Add boundary testing for limits and edge cases\n")
-	prompt.WriteString("8. Consider security aspects (auth, validation)\n")
-	prompt.WriteString("9. Add performance considerations where applicable\n")
-	prompt.WriteString("10. Use descriptive test names and add comments\n")
-	prompt.WriteString("11. Include setup and cleanup if necessary\n")
-	prompt.WriteString("12. Make tests independent and idempotent\n\n")
-
-	prompt.WriteString("**Test Categories to Include:**\n")
-	prompt.WriteString("- Happy path tests with valid inputs\n")
-	prompt.WriteString("- Error scenarios with invalid inputs\n")
-	prompt.WriteString("- Boundary value testing\n")
-	prompt.WriteString("- Security validation tests\n")
-	prompt.WriteString("- Schema validation tests\n\n")
-
-	prompt.WriteString("Generate complete, executable Go test code that follows best practices and can be run immediately.")
-
-	return prompt.String()
+	return text.String()
 }
 
 // makeRequest makes an HTTP request to Anthropic API
@@ -257,6 +319,9 @@ func (c *AnthropicClient) makeRequest(ctx context.Context, request AnthropicRequ
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	if betas := c.betas(); len(betas) > 0 {
+		req.Header.Set("anthropic-beta", strings.Join(betas, ","))
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -268,15 +333,18 @@ func (c *AnthropicClient) makeRequest(ctx context.Context, request AnthropicRequ
 		}
 	}()
 
+	if err := responseError(c.model, resp); err != nil {
+		return nil, err
+	}
+	if request.Stream {
+		return readAnthropicStream(resp.Body)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
 	var response AnthropicResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -285,20 +353,89 @@ func (c *AnthropicClient) makeRequest(ctx context.Context, request AnthropicRequ
 	return &response, nil
 }
 
-// NewAnthropicClientWithModel creates a new Anthropic client with a specific model
-func NewAnthropicClientWithModel(modelName string) (*AnthropicClient, error) {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return nil, ErrAPIKeyMissing{Model: "Anthropic"}
+// anthropicStreamEvent is a server-sent event of a streamed message
+type anthropicStreamEvent struct {
+	Type         string             `json:"type"`
+	Index        int                `json:"index"`
+	Message      *AnthropicResponse `json:"message"`
+	ContentBlock *AnthropicContent  `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *AnthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readAnthropicStream assembles the response of a streamed message from
+// its events: text deltas are appended to their block and tool inputs are
+// collected from their JSON fragments
+func readAnthropicStream(r io.Reader) (*AnthropicResponse, error) {
+	var (
+		response AnthropicResponse
+		inputs   = make(map[int]*strings.Builder)
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				response = *event.Message
+			}
+		case "content_block_start":
+			if event.ContentBlock != nil {
+				for len(response.Content) <= event.Index {
+					response.Content = append(response.Content, AnthropicContent{})
+				}
+				response.Content[event.Index] = *event.ContentBlock
+			}
+		case "content_block_delta":
+			if event.Index >= len(response.Content) {
+				continue
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				response.Content[event.Index].Text += event.Delta.Text
+			case "input_json_delta":
+				if inputs[event.Index] == nil {
+					inputs[event.Index] = &strings.Builder{}
+				}
+				inputs[event.Index].WriteString(event.Delta.PartialJSON)
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				response.StopReason = event.Delta.StopReason
+			}
+			if event.Usage != nil {
+				response.Usage.OutputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			if event.Error != nil {
+				return nil, fmt.Errorf("stream error (%s): %s", event.Error.Type, event.Error.Message)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
 	}
 
-	return &AnthropicClient{
-		apiKey:    apiKey,
-		baseURL:   "https://api.anthropic.com",
-		model:     modelName,
-		maxTokens: 4000,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-	}, nil
+	for index, input := range inputs {
+		response.Content[index].Input = json.RawMessage(input.String())
+	}
+	return &response, nil
 }
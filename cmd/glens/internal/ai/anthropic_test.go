@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicClient_SystemPromptAndBetas(t *testing.T) {
+	var (
+		request AnthropicRequest
+		beta    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("anthropic-beta")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_ = json.NewEncoder(w).Encode(AnthropicResponse{
+			Content:    []AnthropicContent{{Type: "text", Text: sampleTest}},
+			StopReason: "end_turn",
+		})
+	}))
+	defer server.Close()
+
+	c, err := NewAnthropicClient(AnthropicConfig{APIKey: "key", ResponseFormat: ResponseFormatText}, WithBaseURL(server.URL))
+	require.NoError(t, err)
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "claude-sonnet-4-5", request.Model)
+	assert.Equal(t, defaultAnthropicMaxTokens, request.MaxTokens)
+	assert.Contains(t, request.System, "expert software testing engineer")
+	assert.Contains(t, request.System, "Go test code only")
+	assert.NotContains(t, request.Messages[0].Content, "You are", "the persona is in the system prompt")
+	assert.False(t, request.Stream)
+	assert.Empty(t, beta, "current models need no beta for 8192 tokens")
+	assert.Equal(t, "end_turn", result.Metadata["stop_reason"])
+
+	c, err = NewAnthropicClient(AnthropicConfig{
+		APIKey:   "key",
+		Sampling: Sampling{MaxTokens: 100000},
+		Beta:     []string{"token-efficient-tools-2025-02-19"},
+	}, WithBaseURL(server.URL), WithModel("claude-3-7-sonnet-20250219"))
+	require.NoError(t, err)
+	_, err = c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+	assert.Equal(t, "token-efficient-tools-2025-02-19,output-128k-2025-02-19", beta)
+	assert.Equal(t, 100000, request.MaxTokens)
+}
+
+func TestAnthropicClient_Stream(t *testing.T) {
+	input := `{"test_code":"` + jsonEscape(t, sampleTest) + `"}`
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":120,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Submitting "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"the test."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","name":"` + generatedTestTool + `","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":` + jsonString(t, input[:20]) + `}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":` + jsonString(t, input[20:]) + `}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":345}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	var request AnthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var typed struct{ Type string }
+			assert.NoError(t, json.Unmarshal([]byte(event), &typed))
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewAnthropicClient(AnthropicConfig{APIKey: "key", Stream: true}, WithBaseURL(server.URL))
+	require.NoError(t, err)
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	assert.True(t, request.Stream)
+	assert.Equal(t, sampleTest, result.TestCode)
+	assert.Equal(t, 465, result.TokensUsed)
+	assert.Equal(t, "tool_use", result.Metadata["stop_reason"])
+}
+
+func TestReadAnthropicStream_Error(t *testing.T) {
+	stream := "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"
+	_, err := readAnthropicStream(strings.NewReader(stream))
+	assert.EqualError(t, err, "stream error (overloaded_error): Overloaded")
+}
+
+// jsonString quotes s as a JSON string
+func jsonString(t *testing.T, s string) string {
+	t.Helper()
+	quoted, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(quoted)
+}
@@ -0,0 +1,38 @@
+package ai
+
+import "glens/pkg/modelcatalog"
+
+// DescribeModel describes a model of the catalog before any configuration
+func DescribeModel(m modelcatalog.Model) ModelInfo {
+	return ModelInfo{
+		ID:                m.ID,
+		Name:              m.Name,
+		Provider:          m.Provider,
+		ContextWindow:     m.ContextWindow,
+		SupportsStreaming: supportsStreaming(m.Provider),
+		Local:             m.Local(),
+		Deprecated:        m.Deprecated,
+	}
+}
+
+// supportsStreaming reports whether the client of provider can stream its
+// responses (ai_models.<provider>.stream)
+func supportsStreaming(provider string) bool {
+	return provider == "anthropic"
+}
+
+// modelOf returns the provider's name of the model of client
+func modelOf(client Client) string {
+	switch c := client.(type) {
+	case *OpenAIClient:
+		return c.model
+	case *AnthropicClient:
+		return c.model
+	case *GoogleClient:
+		return c.model
+	case *OllamaClient:
+		return c.model
+	default:
+		return client.GetModelName()
+	}
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/pkg/modelcatalog"
+)
+
+func TestCatalog_NamesCreatableModels(t *testing.T) {
+	for _, m := range modelcatalog.Default().Models() {
+		for _, name := range append([]string{m.ID}, m.Aliases...) {
+			_, err := createClient(name, Config{})
+			assert.False(t, errors.As(err, new(ErrUnsupportedModel)), "%s: %v", name, err)
+		}
+	}
+}
+
+func TestDescribeModel(t *testing.T) {
+	m, ok := modelcatalog.Default().Lookup("claude-3.5-sonnet")
+	require.True(t, ok)
+	info := DescribeModel(m)
+	assert.Equal(t, "anthropic", info.Provider)
+	assert.True(t, info.SupportsStreaming)
+	assert.False(t, info.Local)
+	assert.NotEmpty(t, info.Deprecated)
+}
+
+func TestManager_ModelsAndHealth(t *testing.T) {
+	m, err := NewManager([]string{"mock", "mistral-local"}, Config{})
+	require.NoError(t, err)
+
+	models := m.Models()
+	require.Len(t, models, 2)
+	assert.Equal(t, ModelInfo{ID: "mistral-local", Name: "ollama:mistral", Provider: "ollama", ContextWindow: 32768, MaxOutputTokens: defaultMaxTokens, Local: true}, models[0])
+	assert.Equal(t, "mock", models[1].ID)
+	assert.True(t, models[1].Local)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	health := m.Health(ctx)
+	assert.Len(t, health, 1, "mocks cannot be checked")
+	assert.Error(t, health["mistral-local"])
+}
@@ -0,0 +1,299 @@
+package ai
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"glens/tools/glens/internal/httpclient"
+)
+
+// Default provider settings, applied when a config field is left empty
+const (
+	DefaultOpenAIBaseURL    = "https://api.openai.com/v1"
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	DefaultGoogleBaseURL    = "https://generativelanguage.googleapis.com/v1beta"
+	DefaultMistralBaseURL   = "https://api.mistral.ai/v1"
+	DefaultOllamaBaseURL    = "http://localhost:11434"
+
+	// DefaultOllamaConfig is the Config.Ollama entry used for local model
+	// shortcuts and custom ollama:<model> names
+	DefaultOllamaConfig = "ollama"
+
+	defaultVertexLocation = "us-central1"
+
+	defaultCloudTimeout  = 60 * time.Second
+	defaultOllamaTimeout = 300 * time.Second
+	defaultMaxTokens     = 4000
+	// Claude 3.5 and later models generate up to 8192 tokens without betas
+	defaultAnthropicMaxTokens = 8192
+
+	defaultCloudTemperature  = 0.7
+	defaultOllamaTemperature = 0.1
+)
+
+// Config holds explicit settings for every AI provider so clients can be
+// built without global configuration. The CLI fills it from the ai_models
+// section of the config file; library callers build it directly or start
+// from ConfigFromEnv.
+type Config struct {
+	OpenAI    OpenAIConfig
+	Anthropic AnthropicConfig
+	Google    GoogleConfig
+	Mistral   OpenAIConfig
+	// Ollama holds named Ollama servers/models keyed by their ai_models
+	// entry ("ollama", "ollama_qwen", ...)
+	Ollama map[string]OllamaConfig
+	// Generation holds sampling defaults for every model; settings of the
+	// provider config take precedence
+	Generation Sampling
+	// Fallbacks lists, per model, the models that generate in its place, in
+	// order, when it errors (e.g. "gpt-4o": ["gpt-4o-mini", "enhanced-mock"])
+	Fallbacks map[string][]string
+	// Limits bounds the calls of each provider (openai, anthropic, google,
+	// mistral, ollama) across its models; providers without an entry use
+	// DefaultLimits
+	Limits map[string]Limits
+}
+
+// Sampling holds the generation parameters of a model. Unset fields keep
+// the provider default, so a temperature of 0 can be requested explicitly.
+type Sampling struct {
+	Temperature *float64 `mapstructure:"temperature"`
+	TopP        *float64 `mapstructure:"top_p"`
+	Seed        *int     `mapstructure:"seed"`
+	MaxTokens   int      `mapstructure:"max_tokens"`
+}
+
+// Merge returns s with the fields set in over replacing its own
+func (s Sampling) Merge(over Sampling) Sampling {
+	if over.Temperature != nil {
+		s.Temperature = over.Temperature
+	}
+	if over.TopP != nil {
+		s.TopP = over.TopP
+	}
+	if over.Seed != nil {
+		s.Seed = over.Seed
+	}
+	if over.MaxTokens != 0 {
+		s.MaxTokens = over.MaxTokens
+	}
+	return s
+}
+
+// withDefaults fills the temperature and token limit a provider always sends
+func (s Sampling) withDefaults(temperature float64) Sampling {
+	if s.Temperature == nil {
+		s.Temperature = &temperature
+	}
+	s.MaxTokens = orDefault(s.MaxTokens, defaultMaxTokens)
+	return s
+}
+
+// Params describes the set parameters for result metadata, so runs
+// comparing models can be reproduced
+func (s Sampling) Params() map[string]string {
+	params := map[string]string{
+		"max_output_tokens": strconv.Itoa(s.MaxTokens),
+	}
+	if s.Temperature != nil {
+		params["temperature"] = strconv.FormatFloat(*s.Temperature, 'g', -1, 64)
+	}
+	if s.TopP != nil {
+		params["top_p"] = strconv.FormatFloat(*s.TopP, 'g', -1, 64)
+	}
+	if s.Seed != nil {
+		params["seed"] = strconv.Itoa(*s.Seed)
+	}
+	return params
+}
+
+// Override applies over to the sampling of every provider, taking
+// precedence over their configured values
+func (c *Config) Override(over Sampling) {
+	c.OpenAI.Sampling = c.OpenAI.Sampling.Merge(over)
+	c.Anthropic.Sampling = c.Anthropic.Sampling.Merge(over)
+	c.Google.Sampling = c.Google.Sampling.Merge(over)
+	c.Mistral.Sampling = c.Mistral.Sampling.Merge(over)
+	for name, ollama := range c.Ollama {
+		ollama.Sampling = ollama.Sampling.Merge(over)
+		c.Ollama[name] = ollama
+	}
+	c.Generation = c.Generation.Merge(over)
+}
+
+// withGeneration returns c with the Generation defaults under the sampling
+// of every provider
+func (c Config) withGeneration() Config {
+	c.OpenAI.Sampling = c.Generation.Merge(c.OpenAI.Sampling)
+	c.Anthropic.Sampling = c.Generation.Merge(c.Anthropic.Sampling)
+	c.Google.Sampling = c.Generation.Merge(c.Google.Sampling)
+	c.Mistral.Sampling = c.Generation.Merge(c.Mistral.Sampling)
+	ollama := make(map[string]OllamaConfig, len(c.Ollama))
+	for name, cfg := range c.Ollama {
+		cfg.Sampling = c.Generation.Merge(cfg.Sampling)
+		ollama[name] = cfg
+	}
+	c.Ollama = ollama
+	return c
+}
+
+// OpenAIConfig holds configuration for OpenAI and OpenAI-compatible
+// (Mistral) clients
+type OpenAIConfig struct {
+	APIKey   string        `mapstructure:"api_key"`
+	BaseURL  string        `mapstructure:"base_url"`
+	Model    string        `mapstructure:"model"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+	Sampling `mapstructure:",squash"`
+	// ResponseFormat is "structured" (default) or "text" for servers
+	// without JSON output support
+	ResponseFormat string `mapstructure:"response_format"`
+	// TLS trusts a CA bundle (ca_file) or skips verification for the API
+	TLS httpclient.TLS `mapstructure:",squash"`
+}
+
+// AnthropicConfig holds configuration for the Anthropic client
+type AnthropicConfig struct {
+	APIKey  string        `mapstructure:"api_key"`
+	BaseURL string        `mapstructure:"base_url"`
+	Model   string        `mapstructure:"model"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Sampling.Seed is ignored; Anthropic does not support seeds
+	Sampling `mapstructure:",squash"`
+	// ResponseFormat is "structured" (default, tool use) or "text"
+	ResponseFormat string `mapstructure:"response_format"`
+	// Stream receives the answer as server-sent events, which keeps long
+	// generations from hitting idle timeouts of proxies
+	Stream bool `mapstructure:"stream"`
+	// Beta lists anthropic-beta features to enable; the extended output
+	// betas are added when MaxTokens needs them
+	Beta []string `mapstructure:"beta"`
+	// TLS trusts a CA bundle (ca_file) or skips verification for the API
+	TLS httpclient.TLS `mapstructure:",squash"`
+}
+
+// GoogleConfig holds configuration for the Google Gemini client
+type GoogleConfig struct {
+	APIKey    string        `mapstructure:"api_key"`
+	ProjectID string        `mapstructure:"project_id"`
+	BaseURL   string        `mapstructure:"base_url"`
+	Model     string        `mapstructure:"model"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+	Sampling  `mapstructure:",squash"`
+	// ResponseFormat is "structured" (default, JSON mode) or "text"
+	ResponseFormat string `mapstructure:"response_format"`
+	// Vertex sends requests to Vertex AI in ProjectID and Location,
+	// authenticated with Application Default Credentials instead of APIKey
+	Vertex bool `mapstructure:"vertex"`
+	// Location is the Vertex AI region (default us-central1, or global)
+	Location string `mapstructure:"location"`
+	// Credentials is a service account or gcloud user credentials file;
+	// empty uses GOOGLE_APPLICATION_CREDENTIALS, then gcloud's default
+	// credentials, then the metadata server
+	Credentials string `mapstructure:"credentials"`
+	// SafetySettings maps harm categories to block thresholds, e.g.
+	// HARM_CATEGORY_DANGEROUS_CONTENT: BLOCK_ONLY_HIGH
+	SafetySettings map[string]string `mapstructure:"safety_settings"`
+	// TLS trusts a CA bundle (ca_file) or skips verification for the API
+	TLS httpclient.TLS `mapstructure:",squash"`
+}
+
+// ConfigFromEnv returns a Config whose API keys come from the providers'
+// standard environment variables; all other settings use defaults.
+// GOOGLE_GENAI_USE_VERTEXAI=true selects Vertex AI in GOOGLE_CLOUD_PROJECT
+// (or GOOGLE_PROJECT_ID) and GOOGLE_CLOUD_LOCATION.
+func ConfigFromEnv() Config {
+	vertex, _ := strconv.ParseBool(os.Getenv("GOOGLE_GENAI_USE_VERTEXAI"))
+	return Config{
+		OpenAI:    OpenAIConfig{APIKey: os.Getenv("OPENAI_API_KEY")},
+		Anthropic: AnthropicConfig{APIKey: os.Getenv("ANTHROPIC_API_KEY")},
+		Google: GoogleConfig{
+			APIKey:    os.Getenv("GOOGLE_API_KEY"),
+			ProjectID: orDefault(os.Getenv("GOOGLE_PROJECT_ID"), os.Getenv("GOOGLE_CLOUD_PROJECT")),
+			Vertex:    vertex,
+			Location:  os.Getenv("GOOGLE_CLOUD_LOCATION"),
+		},
+		Mistral: OpenAIConfig{APIKey: os.Getenv("MISTRAL_API_KEY")},
+	}
+}
+
+// ollama returns the named Ollama configuration, or the Generation
+// defaults when it is not configured
+func (c Config) ollama(name string) OllamaConfig {
+	if cfg, ok := c.Ollama[name]; ok {
+		return cfg
+	}
+	return OllamaConfig{Sampling: c.Generation}
+}
+
+// Option overrides a client setting at construction time, taking
+// precedence over the provider config
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	timeout time.Duration
+	baseURL string
+	model   string
+	tls     httpclient.TLS
+}
+
+// WithTimeout sets the HTTP timeout of each API call
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) { o.timeout = timeout }
+}
+
+// WithBaseURL points the client at a different API server, e.g. a proxy or
+// a self-hosted OpenAI-compatible endpoint
+func WithBaseURL(baseURL string) Option {
+	return func(o *clientOptions) { o.baseURL = baseURL }
+}
+
+// WithModel selects the provider model, e.g. "gpt-4o" or "mistral"
+func WithModel(model string) Option {
+	return func(o *clientOptions) { o.model = model }
+}
+
+// resolve merges options over configured values and defaults
+func resolve(opts []Option, baseURL, model string, timeout time.Duration) clientOptions {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.baseURL = firstNonEmpty(o.baseURL, baseURL)
+	o.model = firstNonEmpty(o.model, model)
+	if o.timeout == 0 {
+		o.timeout = timeout
+	}
+	return o
+}
+
+// httpClient returns the client of the provider API, trusting the
+// provider's CA bundle if it has one
+func (o clientOptions) httpClient() (*http.Client, error) {
+	client, err := httpclient.Client(o.timeout, o.tls)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", o.baseURL, err)
+	}
+	return client, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func orDefault[T comparable](value, def T) T {
+	var zero T
+	if value == zero {
+		return def
+	}
+	return value
+}
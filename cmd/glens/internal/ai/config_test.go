@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/httpclient"
+)
+
+func TestNewOpenAIClient_Defaults(t *testing.T) {
+	c, err := NewOpenAIClient(OpenAIConfig{APIKey: "k"})
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultOpenAIBaseURL, c.baseURL)
+	assert.Equal(t, "gpt-4-turbo", c.model)
+	assert.Equal(t, defaultMaxTokens, c.sampling.MaxTokens)
+	assert.Equal(t, defaultCloudTimeout, c.client.Timeout)
+}
+
+func TestNewOpenAIClient_ConfigAndOptions(t *testing.T) {
+	cfg := OpenAIConfig{
+		APIKey:  "k",
+		BaseURL: "https://proxy.internal/v1",
+		Model:   "gpt-4o",
+		Timeout: 30 * time.Second,
+	}
+
+	c, err := NewOpenAIClient(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "https://proxy.internal/v1", c.baseURL)
+	assert.Equal(t, "gpt-4o", c.model)
+	assert.Equal(t, 30*time.Second, c.client.Timeout)
+
+	// Options take precedence over the config
+	c, err = NewOpenAIClient(cfg,
+		WithBaseURL("http://127.0.0.1:9000"),
+		WithModel("gpt-4.1"),
+		WithTimeout(5*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:9000", c.baseURL)
+	assert.Equal(t, "gpt-4.1", c.model)
+	assert.Equal(t, 5*time.Second, c.client.Timeout)
+}
+
+func TestNewClients_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"models":[]}`))
+	}))
+	defer server.Close()
+
+	c, err := NewOllamaClient(OllamaConfig{BaseURL: server.URL, TLS: httpclient.TLS{InsecureSkipVerify: true}})
+	require.NoError(t, err)
+	resp, err := c.httpClient.Get(server.URL + "/api/tags")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	_, err = NewOpenAIClient(OpenAIConfig{APIKey: "k", TLS: httpclient.TLS{CAFile: "missing.pem"}})
+	assert.ErrorContains(t, err, "failed to read CA bundle")
+	_, err = NewAnthropicClient(AnthropicConfig{APIKey: "k", TLS: httpclient.TLS{CAFile: "missing.pem"}})
+	assert.Error(t, err)
+}
+
+func TestNewOllamaClient_Defaults(t *testing.T) {
+	c, err := NewOllamaClient(OllamaConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultOllamaBaseURL, c.baseURL)
+	assert.Equal(t, "codellama:7b-instruct", c.model)
+	assert.Equal(t, defaultOllamaTimeout, c.httpClient.Timeout)
+	assert.InDelta(t, 0.1, *c.config.Temperature, 1e-9)
+}
+
+func TestCreateClient_NamedOllamaConfig(t *testing.T) {
+	cfg := Config{Ollama: map[string]OllamaConfig{
+		"ollama":      {BaseURL: "http://gpu-box:11434"},
+		"ollama_qwen": {BaseURL: "http://qwen-box:11434", Model: "qwen2.5-coder"},
+	}}
+
+	c, err := createClient("qwen-coder", cfg)
+	require.NoError(t, err)
+	qwen := c.(*OllamaClient)
+	assert.Equal(t, "http://qwen-box:11434", qwen.baseURL)
+	assert.Equal(t, "qwen2.5-coder", qwen.model)
+
+	// Local shortcuts and custom models run on the default server
+	c, err = createClient("ollama:mistral:7b-instruct", cfg)
+	require.NoError(t, err)
+	custom := c.(*OllamaClient)
+	assert.Equal(t, "http://gpu-box:11434", custom.baseURL)
+	assert.Equal(t, "ollama:mistral:7b-instruct", custom.GetModelName())
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "google-key")
+	t.Setenv("GOOGLE_PROJECT_ID", "proj")
+	t.Setenv("MISTRAL_API_KEY", "mistral-key")
+
+	cfg := ConfigFromEnv()
+
+	assert.Equal(t, "openai-key", cfg.OpenAI.APIKey)
+	assert.Empty(t, cfg.Anthropic.APIKey)
+	assert.Equal(t, "google-key", cfg.Google.APIKey)
+	assert.Equal(t, "proj", cfg.Google.ProjectID)
+	assert.Equal(t, "mistral-key", cfg.Mistral.APIKey)
+}
+
+func TestSampling_Precedence(t *testing.T) {
+	zero, half, topP, seed := 0.0, 0.5, 0.9, 42
+	cfg := Config{
+		OpenAI:     OpenAIConfig{APIKey: "k", Sampling: Sampling{Temperature: &half, MaxTokens: 1000}},
+		Generation: Sampling{TopP: &topP, MaxTokens: 2000},
+	}
+	cfg.Override(Sampling{Temperature: &zero, Seed: &seed})
+
+	manager, err := NewManager([]string{"gpt4", "ollama"}, cfg)
+	require.NoError(t, err)
+
+	// Overrides beat the provider config, which beats the Generation defaults
+	openai := manager.clients["gpt4"].(*OpenAIClient)
+	assert.Equal(t, map[string]string{
+		"temperature": "0", "top_p": "0.9", "seed": "42", "max_output_tokens": "1000",
+	}, openai.sampling.Params())
+
+	// Unconfigured models get the Generation defaults and overrides
+	ollama := manager.clients["ollama"].(*OllamaClient)
+	assert.Equal(t, map[string]string{
+		"temperature": "0", "top_p": "0.9", "seed": "42", "max_output_tokens": "2000",
+	}, ollama.config.Params())
+	assert.Equal(t, 2000, ollama.config.NumPredict)
+}
+
+func TestOpenAIClient_SamplingRequest(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_ = json.NewEncoder(w).Encode(OpenAIResponse{Choices: []Choice{{Message: Message{Content: "x"}}}})
+	}))
+	defer server.Close()
+
+	zero, seed := 0.0, 7
+	c, err := NewOpenAIClient(OpenAIConfig{APIKey: "k", Sampling: Sampling{Temperature: &zero, Seed: &seed}},
+		WithBaseURL(server.URL))
+	require.NoError(t, err)
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	// An explicit temperature of 0 is sent, an unset top_p is not
+	assert.Equal(t, 0.0, request["temperature"])
+	assert.Equal(t, 7.0, request["seed"])
+	assert.NotContains(t, request, "top_p")
+	assert.Equal(t, "0", result.Metadata["temperature"])
+	assert.Equal(t, "7", result.Metadata["seed"])
+}
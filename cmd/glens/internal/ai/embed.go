@@ -0,0 +1,266 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Embedding providers
+const (
+	// EmbedLocal hashes words and word pairs into vectors on this machine;
+	// it needs no model and sends nothing off the machine
+	EmbedLocal = "local"
+	// EmbedOpenAI uses the embeddings API of the openai provider config
+	EmbedOpenAI = "openai"
+	// EmbedOllama uses the embed API of the default Ollama server
+	EmbedOllama = "ollama"
+)
+
+// Default embedding models of the providers
+const (
+	DefaultOpenAIEmbedModel = "text-embedding-3-small"
+	DefaultOllamaEmbedModel = "nomic-embed-text"
+	localEmbedModel         = "hashing-512"
+	localEmbedDimensions    = 512
+)
+
+// EmbeddingConfig is the embeddings config section
+type EmbeddingConfig struct {
+	// Provider is EmbedLocal (default), EmbedOpenAI or EmbedOllama
+	Provider string `mapstructure:"provider"`
+	// Model is the provider's embedding model
+	Model string `mapstructure:"model"`
+}
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related the texts are
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+	// Name identifies the provider and model, e.g. openai/text-embedding-3-small;
+	// vectors of different names are not comparable
+	Name() string
+}
+
+// NewEmbedder returns the embedder of cfg, with the provider settings of
+// providers
+func NewEmbedder(cfg EmbeddingConfig, providers Config) (Embedder, error) {
+	switch cfg.Provider {
+	case "", EmbedLocal:
+		return localEmbedder{}, nil
+	case EmbedOpenAI:
+		if providers.OpenAI.APIKey == "" {
+			return nil, ErrAPIKeyMissing{Model: "OpenAI"}
+		}
+		o := resolve(nil, orDefault(providers.OpenAI.BaseURL, DefaultOpenAIBaseURL),
+			orDefault(cfg.Model, DefaultOpenAIEmbedModel), orDefault(providers.OpenAI.Timeout, defaultCloudTimeout))
+		o.tls = providers.OpenAI.TLS
+		client, err := o.httpClient()
+		if err != nil {
+			return nil, err
+		}
+		return &httpEmbedder{provider: EmbedOpenAI, url: o.baseURL + "/embeddings", apiKey: providers.OpenAI.APIKey, model: o.model, client: client}, nil
+	case EmbedOllama:
+		server := providers.Ollama[DefaultOllamaConfig]
+		o := resolve(nil, orDefault(server.BaseURL, DefaultOllamaBaseURL),
+			orDefault(cfg.Model, DefaultOllamaEmbedModel), orDefault(server.Timeout, defaultOllamaTimeout))
+		o.tls = server.TLS
+		client, err := o.httpClient()
+		if err != nil {
+			return nil, err
+		}
+		return &httpEmbedder{provider: EmbedOllama, url: o.baseURL + "/api/embed", model: o.model, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q (use local, openai or ollama)", cfg.Provider)
+	}
+}
+
+// httpEmbedder calls the embeddings API of OpenAI or Ollama; both take a
+// model and a list of inputs
+type httpEmbedder struct {
+	provider string
+	url      string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+func (e *httpEmbedder) Name() string {
+	return e.provider + "/" + e.model
+}
+
+func (e *httpEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	data, err := json.Marshal(map[string]any{"model": e.model, "input": texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Debug().Err(closeErr).Msg("failed to close response body")
+		}
+	}()
+	if err := responseError(e.model, resp); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// OpenAI answers {"data": [{"index", "embedding"}]}, Ollama {"embeddings"}
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	vectors := response.Embeddings
+	if len(response.Data) > 0 {
+		vectors = make([][]float64, len(response.Data))
+		for _, d := range response.Data {
+			if d.Index < 0 || d.Index >= len(vectors) {
+				return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// localEmbedder hashes the lowercased words of a text, their crude stems
+// and adjacent word pairs into a fixed number of dimensions. It matches
+// shared vocabulary rather than meaning, which suits the terse names and
+// summaries of API operations.
+type localEmbedder struct{}
+
+func (localEmbedder) Name() string {
+	return EmbedLocal + "/" + localEmbedModel
+}
+
+func (localEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = hashEmbed(text)
+	}
+	return vectors, nil
+}
+
+func hashEmbed(text string) []float64 {
+	vector := make([]float64, localEmbedDimensions)
+	add := func(feature string, weight float64) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(feature))
+		vector[h.Sum32()%localEmbedDimensions] += weight
+	}
+
+	words := splitWords(text)
+	for i, word := range words {
+		add(word, 1)
+		if stem := stemWord(word); stem != word {
+			add(stem, 1)
+		}
+		if i > 0 {
+			add(words[i-1]+" "+word, 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
+
+// splitWords splits text into lowercased words, also at camelCase and
+// snake_case boundaries, e.g. "listPetOwners" is list, pet, owners
+func splitWords(text string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// stemWord strips common English plural and verb endings, so "pets" and
+// "pet", "listing" and "list" share a feature
+func stemWord(word string) string {
+	for _, suffix := range []string{"ies", "ing", "es", "ed", "s"} {
+		if stem, ok := strings.CutSuffix(word, suffix); ok && len(stem) >= 3 {
+			if suffix == "ies" {
+				return stem + "y"
+			}
+			return stem
+		}
+	}
+	return word
+}
+
+// Cosine returns the cosine similarity of two vectors, 0 when their
+// lengths differ or either is zero
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
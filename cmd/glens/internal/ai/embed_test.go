@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWords(t *testing.T) {
+	assert.Equal(t, []string{"list", "pet", "owners", "by", "id"}, splitWords("listPetOwners by_id"))
+	assert.Equal(t, []string{"get", "users", "id", "avatar"}, splitWords("GET /users/{id}/avatar"))
+	assert.Equal(t, "category", stemWord("categories"))
+	assert.Equal(t, "list", stemWord("listing"))
+	assert.Equal(t, "bus", stemWord("bus"))
+}
+
+func TestLocalEmbedder(t *testing.T) {
+	embedder, err := NewEmbedder(EmbeddingConfig{Provider: EmbedLocal}, Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "local/hashing-512", embedder.Name())
+
+	vectors, err := embedder.Embed(context.Background(), []string{"list pets", "listPets", "delete an order", ""})
+	require.NoError(t, err)
+	require.Len(t, vectors, 4)
+	assert.InDelta(t, 1, Cosine(vectors[0], vectors[1]), 1e-9)
+	assert.Less(t, Cosine(vectors[0], vectors[2]), 0.5)
+	assert.Zero(t, Cosine(vectors[0], vectors[3]))
+}
+
+func TestHTTPEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+			assert.Equal(t, DefaultOpenAIEmbedModel, req.Model)
+			_, _ = w.Write([]byte(`{"data": [{"index": 1, "embedding": [0, 1]}, {"index": 0, "embedding": [1, 0]}]}`))
+		case "/api/embed":
+			assert.Equal(t, "all-minilm", req.Model)
+			_, _ = w.Write([]byte(`{"embeddings": [[1, 0], [0, 1]]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	openai, err := NewEmbedder(EmbeddingConfig{Provider: EmbedOpenAI}, Config{OpenAI: OpenAIConfig{APIKey: "sk-test", BaseURL: server.URL + "/v1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "openai/"+DefaultOpenAIEmbedModel, openai.Name())
+	vectors, err := openai.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0}, {0, 1}}, vectors, "vectors are ordered by index")
+
+	ollama, err := NewEmbedder(EmbeddingConfig{Provider: EmbedOllama, Model: "all-minilm"},
+		Config{Ollama: map[string]OllamaConfig{DefaultOllamaConfig: {BaseURL: server.URL}}})
+	require.NoError(t, err)
+	vectors, err = ollama.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0}, {0, 1}}, vectors)
+
+	_, err = ollama.Embed(context.Background(), []string{"a"})
+	assert.ErrorContains(t, err, "got 2 embeddings for 1 texts")
+
+	_, err = NewEmbedder(EmbeddingConfig{Provider: EmbedOpenAI}, Config{})
+	assert.Error(t, err)
+	_, err = NewEmbedder(EmbeddingConfig{Provider: "cohere"}, Config{})
+	assert.ErrorContains(t, err, "unknown embedding provider")
+}
@@ -3,9 +3,12 @@ package ai
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"glens/tools/glens/internal/environment"
 	"glens/tools/glens/internal/parser"
 )
 
@@ -92,7 +95,7 @@ func (c *EnhancedMockClient) GetCapabilities() ModelCapabilities {
 	return ModelCapabilities{
 		SupportsGoTests:      true,
 		SupportsSecurityTest: true,
-		SupportedFrameworks:  []string{"testify", "ginkgo", "standard"},
+		SupportedFrameworks:  []string{"testify", "ginkgo", "standard", "grpc"},
 		MaxTokens:            8000,
 		Languages:            []string{"go"},
 	}
@@ -124,14 +127,60 @@ func (c *EnhancedMockClient) initializePatterns() {
 		Description: "Tests for DELETE endpoints",
 		Scenarios:   []string{"success", "not_found", "cascade", "auth"},
 	}
+
+	c.patterns["pagination"] = TestPattern{
+		Name:        "Paginated List",
+		Description: "Tests for list endpoints that page their results",
+		Scenarios:   []string{"first_page", "custom_page_size", "out_of_range_page", "cursor_traversal"},
+	}
+
+	c.patterns["scenario"] = TestPattern{
+		Name:        "Scenario",
+		Description: "Tests for workflows across dependent endpoints",
+		Scenarios:   []string{"steps_in_order", "value_passing", "cleanup"},
+	}
+
+	c.patterns["graphql"] = TestPattern{
+		Name:        "GraphQL Operation",
+		Description: "Tests for queries and mutations of a GraphQL schema",
+		Scenarios:   []string{"success", "missing_arguments", "invalid_document"},
+	}
+
+	c.patterns["grpc"] = TestPattern{
+		Name:        "gRPC Method",
+		Description: "Tests for methods of a gRPC service called with grpcurl",
+		Scenarios:   []string{"success", "unknown_field"},
+	}
+
+	// Webhooks and callbacks are received, not called
+	c.patterns["receiver"] = TestPattern{
+		Name:        "Webhook Receiver",
+		Description: "Tests for webhooks and callbacks the API sends",
+		Scenarios:   []string{"delivered", "payload_schema"},
+	}
 }
 
 // selectPattern chooses the appropriate test pattern
 func (c *EnhancedMockClient) selectPattern(endpoint *parser.Endpoint) TestPattern {
+	if endpoint.Incoming() {
+		return c.patterns["receiver"]
+	}
+	if endpoint.Kind == parser.KindScenario {
+		return c.patterns["scenario"]
+	}
+	if endpoint.GraphQL != nil {
+		return c.patterns["graphql"]
+	}
+	if endpoint.GRPC != nil {
+		return c.patterns["grpc"]
+	}
 	method := strings.ToUpper(endpoint.Method)
 
 	switch method {
 	case "GET":
+		if endpoint.Pagination != nil {
+			return c.patterns["pagination"]
+		}
 		return c.patterns["crud_read"]
 	case "POST":
 		return c.patterns["crud_create"]
@@ -147,6 +196,21 @@ func (c *EnhancedMockClient) selectPattern(endpoint *parser.Endpoint) TestPatter
 // identifyCategories identifies test categories for the endpoint
 func (c *EnhancedMockClient) identifyCategories(endpoint *parser.Endpoint) []string {
 	categories := []string{"integration", "api"}
+	if endpoint.Incoming() {
+		return append(categories, endpoint.Kind, "payload-schema")
+	}
+	if endpoint.Kind == parser.KindScenario {
+		return append(categories, "scenario", "workflow")
+	}
+	if endpoint.GraphQL != nil {
+		return append(categories, "graphql", endpoint.GraphQL.Type)
+	}
+	if endpoint.GRPC != nil {
+		if endpoint.GRPC.ClientStreaming || endpoint.GRPC.ServerStreaming {
+			return append(categories, "grpc", "streaming")
+		}
+		return append(categories, "grpc", "unary")
+	}
 
 	// Add method-specific categories
 	method := strings.ToUpper(endpoint.Method)
@@ -160,9 +224,15 @@ func (c *EnhancedMockClient) identifyCategories(endpoint *parser.Endpoint) []str
 	case "DELETE":
 		categories = append(categories, "delete", "mutation")
 	}
+	if endpoint.Pagination != nil {
+		categories = append(categories, "pagination")
+	}
+	if endpoint.RateLimit() != nil {
+		categories = append(categories, "rate-limit")
+	}
 
 	// Add security category if enabled
-	if c.enableSecurity {
+	if c.enableSecurity && !endpoint.Public() {
 		categories = append(categories, "security", "auth")
 	}
 
@@ -176,18 +246,50 @@ func (c *EnhancedMockClient) identifyCategories(endpoint *parser.Endpoint) []str
 
 // generateEnhancedTestCode creates comprehensive test code
 func (c *EnhancedMockClient) generateEnhancedTestCode(endpoint *parser.Endpoint, pattern TestPattern) string {
+	if endpoint.Incoming() {
+		return c.generateReceiverTestCode(endpoint, pattern)
+	}
+	if endpoint.Kind == parser.KindScenario {
+		return c.generateScenarioTestCode(endpoint, pattern)
+	}
+	if endpoint.GraphQL != nil {
+		return c.generateGraphQLTestCode(endpoint, pattern)
+	}
+	if endpoint.GRPC != nil {
+		return c.generateGRPCTestCode(endpoint, pattern)
+	}
 	testName := fmt.Sprintf("Test%s%s", capitalize(endpoint.Method), sanitizePath(endpoint.Path))
 
 	var testCases strings.Builder
 
 	// Add header
+	imports := []string{"net/http", "testing", "time"}
+	if p := endpoint.Pagination; p != nil {
+		imports = append(imports, "encoding/json", "net/url")
+		if p.Style == parser.PaginationOffset && p.SizeParam == "" {
+			imports = append(imports, "strconv")
+		}
+	}
+	rateLimit := endpoint.RateLimit()
+	if rateLimit != nil && rateLimit.Throttled {
+		imports = append(imports, "os", "strconv")
+	}
+	_, validated := endpoint.JSONResponseSchemas()[successCode(endpoint)]
+	if validated {
+		imports = append(imports, "io")
+	}
+	slices.Sort(imports)
 	testCases.WriteString("package main\n\n")
 	testCases.WriteString("import (\n")
-	testCases.WriteString("\t\"net/http\"\n")
-	testCases.WriteString("\t\"testing\"\n")
-	testCases.WriteString("\t\"time\"\n\n")
+	for _, path := range slices.Compact(imports) {
+		fmt.Fprintf(&testCases, "\t%q\n", path)
+	}
+	testCases.WriteString("\n")
 	testCases.WriteString("\t\"github.com/stretchr/testify/assert\"\n")
 	testCases.WriteString("\t\"github.com/stretchr/testify/require\"\n")
+	if validated {
+		testCases.WriteString("\t\"glens/assertspec\"\n")
+	}
 	testCases.WriteString(")\n\n")
 
 	// Add main test function
@@ -200,13 +302,22 @@ func (c *EnhancedMockClient) generateEnhancedTestCode(endpoint *parser.Endpoint,
 	// Add test scenarios
 	c.addSuccessTest(&testCases, endpoint)
 
+	if endpoint.Pagination != nil {
+		c.addPaginationTests(&testCases, endpoint.Pagination)
+	}
+
+	if rateLimit != nil {
+		c.addRateLimitTests(&testCases, endpoint, rateLimit)
+	}
+
 	if c.enableEdgeCases {
 		c.addEdgeCaseTests(&testCases, endpoint)
 	}
 
 	c.addErrorTests(&testCases, endpoint)
 
-	if c.enableSecurity {
+	// Public endpoints (x-glens-auth: none) have no unauthorized case
+	if c.enableSecurity && !endpoint.Public() {
 		c.addSecurityTests(&testCases, endpoint)
 	}
 
@@ -229,11 +340,104 @@ func (c *EnhancedMockClient) addSuccessTest(sb *strings.Builder, endpoint *parse
 	sb.WriteString("\t\tdefer resp.Body.Close()\n\n")
 	sb.WriteString("\t\t// Verify status code\n")
 
-	expectedStatus := "http.StatusOK"
-	if strings.ToUpper(endpoint.Method) == "POST" {
+	expectedStatus, code := "http.StatusOK", successCode(endpoint)
+	if code == "201" {
 		expectedStatus = "http.StatusCreated"
 	}
 	fmt.Fprintf(sb, "\t\tassert.Equal(t, %s, resp.StatusCode)\n", expectedStatus)
+
+	response := endpoint.Responses[code]
+	contentType := responseContentType(response)
+	headers := assertedHeaders(endpoint.Method, code, response)
+	if contentType != "" || len(headers) > 0 {
+		sb.WriteString("\n\t\t// Verify documented headers\n")
+	}
+	if contentType != "" {
+		fmt.Fprintf(sb, "\t\tassert.Contains(t, resp.Header.Get(\"Content-Type\"), %q)\n", contentType)
+	}
+	for _, header := range headers {
+		fmt.Fprintf(sb, "\t\tassert.NotEmpty(t, resp.Header.Get(%q), \"missing %s header\")\n", header, header)
+	}
+	if _, ok := endpoint.JSONResponseSchemas()[code]; ok {
+		sb.WriteString("\n\t\t// Verify the body against the documented schema\n")
+		sb.WriteString("\t\tbody, err := io.ReadAll(resp.Body)\n")
+		sb.WriteString("\t\trequire.NoError(t, err)\n")
+		fmt.Fprintf(sb, "\t\tassertspec.Body(t, %q, resp.StatusCode, body)\n", strings.ToUpper(endpoint.Method)+" "+endpoint.Path)
+	}
+	sb.WriteString("\t})\n\n")
+
+	if strings.ToUpper(endpoint.Method) == "GET" && containsFold(headers, "ETag") {
+		if _, ok := endpoint.Responses["304"]; ok {
+			c.addConditionalRequestTest(sb)
+		}
+	}
+}
+
+// successCode is the status the success test expects: 201 for POST and 200
+// otherwise
+func successCode(endpoint *parser.Endpoint) string {
+	if strings.ToUpper(endpoint.Method) == "POST" {
+		return "201"
+	}
+	return "200"
+}
+
+// cachingHeaders are the response headers that make a GET cacheable
+var cachingHeaders = []string{"Cache-Control", "ETag", "Last-Modified", "Expires"}
+
+// assertedHeaders returns the documented headers of the code response the
+// success test asserts: Location on 201, caching headers on GET and every
+// required header. Rate-limit headers are left to the rate-limit tests.
+func assertedHeaders(method, code string, response parser.Response) []string {
+	var headers []string
+	for _, name := range response.HeaderNames() {
+		switch {
+		case parser.IsRateLimitHeader(name):
+			// Asserted by the rate-limit tests
+		case response.Headers[name].Required,
+			code == "201" && strings.EqualFold(name, "Location"),
+			strings.EqualFold(method, "GET") && containsFold(cachingHeaders, name):
+			headers = append(headers, name)
+		}
+	}
+	return headers
+}
+
+// responseContentType returns the media type the response is documented
+// with, preferring JSON; empty for responses without content
+func responseContentType(response parser.Response) string {
+	types := make([]string, 0, len(response.Content))
+	for contentType := range response.Content {
+		types = append(types, contentType)
+	}
+	slices.Sort(types)
+	if i := slices.IndexFunc(types, func(t string) bool { return strings.Contains(t, "json") }); i >= 0 {
+		return types[i]
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// addConditionalRequestTest adds a test that revalidating a GET with its
+// ETag answers 304 Not Modified
+func (c *EnhancedMockClient) addConditionalRequestTest(sb *strings.Builder) {
+	sb.WriteString("\t// Test: Conditional request\n")
+	sb.WriteString("\tt.Run(\"ConditionalRequest\", func(t *testing.T) {\n")
+	sb.WriteString("\t\tclient := &http.Client{Timeout: 10 * time.Second}\n")
+	sb.WriteString("\t\tresp, err := client.Get(baseURL + endpoint)\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\tresp.Body.Close()\n")
+	sb.WriteString("\t\tetag := resp.Header.Get(\"ETag\")\n")
+	sb.WriteString("\t\trequire.NotEmpty(t, etag)\n\n")
+	sb.WriteString("\t\treq, err := http.NewRequest(\"GET\", baseURL+endpoint, nil)\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\treq.Header.Set(\"If-None-Match\", etag)\n")
+	sb.WriteString("\t\tresp, err = client.Do(req)\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\tdefer resp.Body.Close()\n\n")
+	sb.WriteString("\t\tassert.Equal(t, http.StatusNotModified, resp.StatusCode)\n")
 	sb.WriteString("\t})\n\n")
 }
 
@@ -304,6 +508,685 @@ func (c *EnhancedMockClient) addPerformanceTest(sb *strings.Builder, endpoint *p
 	sb.WriteString("\t})\n\n")
 }
 
+// addPaginationTests adds the first page, custom page size, out of range
+// and traversal tests of a paginated list endpoint
+func (c *EnhancedMockClient) addPaginationTests(sb *strings.Builder, p *parser.Pagination) {
+	fmt.Fprintf(sb, "\t// Test: Pagination by %s\n", p.Style)
+	sb.WriteString("\tt.Run(\"Pagination\", func(t *testing.T) {\n")
+	sb.WriteString("\t\tclient := &http.Client{Timeout: 10 * time.Second}\n")
+	sb.WriteString("\t\t// getPage returns the status, items and decoded body of a page\n")
+	sb.WriteString("\t\tgetPage := func(t *testing.T, query url.Values) (int, []any, map[string]any) {\n")
+	sb.WriteString("\t\t\tt.Helper()\n")
+	sb.WriteString("\t\t\treq, err := http.NewRequest(\"GET\", baseURL+endpoint+\"?\"+query.Encode(), nil)\n")
+	sb.WriteString("\t\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\t\tresp, err := client.Do(req)\n")
+	sb.WriteString("\t\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\t\tdefer resp.Body.Close()\n\n")
+	sb.WriteString("\t\t\tvar body any\n")
+	sb.WriteString("\t\t\t_ = json.NewDecoder(resp.Body).Decode(&body)\n")
+	sb.WriteString("\t\t\tswitch v := body.(type) {\n")
+	sb.WriteString("\t\t\tcase []any:\n")
+	sb.WriteString("\t\t\t\treturn resp.StatusCode, v, nil\n")
+	sb.WriteString("\t\t\tcase map[string]any:\n")
+	if p.Items != "" {
+		fmt.Fprintf(sb, "\t\t\t\titems, _ := v[%q].([]any)\n", p.Items)
+		sb.WriteString("\t\t\t\treturn resp.StatusCode, items, v\n")
+	} else {
+		sb.WriteString("\t\t\t\tfor _, field := range v {\n")
+		sb.WriteString("\t\t\t\t\tif items, ok := field.([]any); ok {\n")
+		sb.WriteString("\t\t\t\t\t\treturn resp.StatusCode, items, v\n")
+		sb.WriteString("\t\t\t\t\t}\n")
+		sb.WriteString("\t\t\t\t}\n")
+		sb.WriteString("\t\t\t\treturn resp.StatusCode, nil, v\n")
+	}
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\treturn resp.StatusCode, nil, nil\n")
+	sb.WriteString("\t\t}\n\n")
+
+	firstPage := "url.Values{}"
+	if p.Style == parser.PaginationPage {
+		firstPage = fmt.Sprintf("url.Values{%q: {\"%d\"}}", p.PageParam, p.FirstPage)
+	}
+	sb.WriteString("\t\tt.Run(\"FirstPage\", func(t *testing.T) {\n")
+	fmt.Fprintf(sb, "\t\t\tstatus, items, _ := getPage(t, %s)\n", firstPage)
+	sb.WriteString("\t\t\tassert.Equal(t, http.StatusOK, status)\n")
+	sb.WriteString("\t\t\tassert.NotNil(t, items, \"the page should hold a list of items\")\n")
+	sb.WriteString("\t\t})\n\n")
+
+	if p.SizeParam != "" {
+		sb.WriteString("\t\tt.Run(\"CustomPageSize\", func(t *testing.T) {\n")
+		fmt.Fprintf(sb, "\t\t\tstatus, items, _ := getPage(t, url.Values{%q: {\"2\"}})\n", p.SizeParam)
+		sb.WriteString("\t\t\tassert.Equal(t, http.StatusOK, status)\n")
+		sb.WriteString("\t\t\tassert.LessOrEqual(t, len(items), 2)\n")
+		sb.WriteString("\t\t})\n\n")
+		if p.MaxSize > 0 {
+			sb.WriteString("\t\tt.Run(\"OversizedPage\", func(t *testing.T) {\n")
+			fmt.Fprintf(sb, "\t\t\tstatus, items, _ := getPage(t, url.Values{%q: {\"%d\"}})\n", p.SizeParam, p.MaxSize+1)
+			sb.WriteString("\t\t\tif status == http.StatusOK {\n")
+			fmt.Fprintf(sb, "\t\t\t\tassert.LessOrEqual(t, len(items), %d, \"the page should be clamped\")\n", p.MaxSize)
+			sb.WriteString("\t\t\t} else {\n")
+			sb.WriteString("\t\t\t\tassert.Equal(t, http.StatusBadRequest, status)\n")
+			sb.WriteString("\t\t\t}\n")
+			sb.WriteString("\t\t})\n\n")
+		}
+	}
+
+	if p.Style == parser.PaginationCursor {
+		c.addCursorTests(sb, p)
+	} else {
+		c.addPageTests(sb, p)
+	}
+	sb.WriteString("\t})\n\n")
+}
+
+// addPageTests adds the out of range and next page tests of page number
+// and offset pagination
+func (c *EnhancedMockClient) addPageTests(sb *strings.Builder, p *parser.Pagination) {
+	param, first, next := p.OffsetParam, 0, "2"
+	if p.Style == parser.PaginationPage {
+		param, first, next = p.PageParam, p.FirstPage, fmt.Sprint(p.FirstPage+1)
+	}
+	sb.WriteString("\t\tt.Run(\"OutOfRangePage\", func(t *testing.T) {\n")
+	fmt.Fprintf(sb, "\t\t\tstatus, items, _ := getPage(t, url.Values{%q: {\"1000000\"}})\n", param)
+	sb.WriteString("\t\t\tassert.Less(t, status, http.StatusInternalServerError)\n")
+	sb.WriteString("\t\t\tif status == http.StatusOK {\n")
+	sb.WriteString("\t\t\t\tassert.Empty(t, items, \"a page past the end should be empty\")\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t})\n\n")
+
+	sb.WriteString("\t\tt.Run(\"NextPage\", func(t *testing.T) {\n")
+	query := func(page string) string {
+		if p.SizeParam == "" {
+			return fmt.Sprintf("url.Values{%q: {%s}}", param, page)
+		}
+		return fmt.Sprintf("url.Values{%q: {%s}, %q: {\"2\"}}", param, page, p.SizeParam)
+	}
+	fmt.Fprintf(sb, "\t\t\t_, first, _ := getPage(t, %s)\n", query(fmt.Sprintf("\"%d\"", first)))
+	if p.Style == parser.PaginationOffset && p.SizeParam == "" {
+		// Without a page size the second page starts after the first
+		sb.WriteString("\t\t\tnext := strconv.Itoa(len(first))\n")
+		next = "next"
+	} else {
+		next = fmt.Sprintf("%q", next)
+	}
+	fmt.Fprintf(sb, "\t\t\tstatus, second, _ := getPage(t, %s)\n", query(next))
+	sb.WriteString("\t\t\tassert.Equal(t, http.StatusOK, status)\n")
+	sb.WriteString("\t\t\tseen := make(map[string]bool, len(first))\n")
+	sb.WriteString("\t\t\tfor _, item := range first {\n")
+	sb.WriteString("\t\t\t\tkey, _ := json.Marshal(item)\n")
+	sb.WriteString("\t\t\t\tseen[string(key)] = true\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tfor _, item := range second {\n")
+	sb.WriteString("\t\t\t\tkey, _ := json.Marshal(item)\n")
+	sb.WriteString("\t\t\t\tassert.False(t, seen[string(key)], \"item %s is on both pages\", key)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t})\n")
+}
+
+// addCursorTests adds the invalid cursor and cursor traversal tests of
+// cursor pagination
+func (c *EnhancedMockClient) addCursorTests(sb *strings.Builder, p *parser.Pagination) {
+	sb.WriteString("\t\tt.Run(\"InvalidCursor\", func(t *testing.T) {\n")
+	fmt.Fprintf(sb, "\t\t\tstatus, _, _ := getPage(t, url.Values{%q: {\"not-a-cursor\"}})\n", p.CursorParam)
+	sb.WriteString("\t\t\tassert.Equal(t, http.StatusBadRequest, status)\n")
+	sb.WriteString("\t\t})\n\n")
+
+	sb.WriteString("\t\tt.Run(\"CursorTraversal\", func(t *testing.T) {\n")
+	if p.NextCursor == "" {
+		sb.WriteString("\t\t\tt.Skip(\"the response field of the next cursor is unknown; declare it as x-pagination nextCursor\")\n")
+		sb.WriteString("\t\t})\n")
+		return
+	}
+	if p.SizeParam != "" {
+		fmt.Fprintf(sb, "\t\t\tquery := url.Values{%q: {\"2\"}}\n", p.SizeParam)
+	} else {
+		sb.WriteString("\t\t\tquery := url.Values{}\n")
+	}
+	sb.WriteString("\t\t\tcursors := map[string]bool{}\n")
+	sb.WriteString("\t\t\titems := map[string]bool{}\n")
+	sb.WriteString("\t\t\tfor range 5 {\n")
+	sb.WriteString("\t\t\t\tstatus, page, body := getPage(t, query)\n")
+	sb.WriteString("\t\t\t\trequire.Equal(t, http.StatusOK, status)\n")
+	sb.WriteString("\t\t\t\tfor _, item := range page {\n")
+	sb.WriteString("\t\t\t\t\tkey, _ := json.Marshal(item)\n")
+	sb.WriteString("\t\t\t\t\tassert.False(t, items[string(key)], \"item %s repeats\", key)\n")
+	sb.WriteString("\t\t\t\t\titems[string(key)] = true\n")
+	sb.WriteString("\t\t\t\t}\n\n")
+	sb.WriteString("\t\t\t\tvar next any = body\n")
+	fmt.Fprintf(sb, "\t\t\t\tfor _, key := range %#v {\n", strings.Split(p.NextCursor, "."))
+	sb.WriteString("\t\t\t\t\tfield, _ := next.(map[string]any)\n")
+	sb.WriteString("\t\t\t\t\tnext = field[key]\n")
+	sb.WriteString("\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\tcursor, _ := next.(string)\n")
+	sb.WriteString("\t\t\t\tif cursor == \"\" {\n")
+	sb.WriteString("\t\t\t\t\treturn\n")
+	sb.WriteString("\t\t\t\t}\n")
+	sb.WriteString("\t\t\t\trequire.False(t, cursors[cursor], \"cursor %q repeats\", cursor)\n")
+	sb.WriteString("\t\t\t\tcursors[cursor] = true\n")
+	fmt.Fprintf(sb, "\t\t\t\tquery.Set(%q, cursor)\n", p.CursorParam)
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t})\n")
+}
+
+// addRateLimitTests adds tests of the documented rate-limit headers and,
+// when a 429 response is documented, of throttling. Throttling tests send up
+// to EnvRateLimitBurst requests and are skipped when it is not set.
+func (c *EnhancedMockClient) addRateLimitTests(sb *strings.Builder, endpoint *parser.Endpoint, limit *parser.RateLimit) {
+	method := strings.ToUpper(endpoint.Method)
+	sb.WriteString("\t// Test: Rate limiting\n")
+	sb.WriteString("\tt.Run(\"RateLimit\", func(t *testing.T) {\n")
+	sb.WriteString("\t\tclient := &http.Client{Timeout: 10 * time.Second}\n")
+	if len(limit.Headers) > 0 {
+		sb.WriteString("\t\tt.Run(\"Headers\", func(t *testing.T) {\n")
+		fmt.Fprintf(sb, "\t\t\treq, err := http.NewRequest(%q, baseURL+endpoint, nil)\n", method)
+		sb.WriteString("\t\t\trequire.NoError(t, err)\n")
+		sb.WriteString("\t\t\tresp, err := client.Do(req)\n")
+		sb.WriteString("\t\t\trequire.NoError(t, err)\n")
+		sb.WriteString("\t\t\tdefer resp.Body.Close()\n\n")
+		fmt.Fprintf(sb, "\t\t\tfor _, header := range %#v {\n", limit.Headers)
+		sb.WriteString("\t\t\t\tassert.NotEmpty(t, resp.Header.Get(header), \"missing rate-limit header %s\", header)\n")
+		sb.WriteString("\t\t\t}\n")
+		sb.WriteString("\t\t})\n")
+	}
+	if limit.Throttled {
+		if len(limit.Headers) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\t\tt.Run(\"Throttling\", func(t *testing.T) {\n")
+		fmt.Fprintf(sb, "\t\t\tburst, _ := strconv.Atoi(os.Getenv(%q))\n", environment.EnvRateLimitBurst)
+		sb.WriteString("\t\t\tif burst <= 0 {\n")
+		fmt.Fprintf(sb, "\t\t\t\tt.Skip(\"set %s to the number of requests that may be sent to reach the rate limit\")\n", environment.EnvRateLimitBurst)
+		sb.WriteString("\t\t\t}\n")
+		sb.WriteString("\t\t\tfor range burst {\n")
+		fmt.Fprintf(sb, "\t\t\t\treq, err := http.NewRequest(%q, baseURL+endpoint, nil)\n", method)
+		sb.WriteString("\t\t\t\trequire.NoError(t, err)\n")
+		sb.WriteString("\t\t\t\tresp, err := client.Do(req)\n")
+		sb.WriteString("\t\t\t\trequire.NoError(t, err)\n")
+		sb.WriteString("\t\t\t\tresp.Body.Close()\n")
+		sb.WriteString("\t\t\t\tif resp.StatusCode != http.StatusTooManyRequests {\n")
+		sb.WriteString("\t\t\t\t\tcontinue\n")
+		sb.WriteString("\t\t\t\t}\n\n")
+		for _, header := range limit.ThrottledHeaders {
+			fmt.Fprintf(sb, "\t\t\t\tassert.NotEmpty(t, resp.Header.Get(%q), \"missing %s header\")\n", header, header)
+		}
+		if containsFold(limit.ThrottledHeaders, "Retry-After") {
+			sb.WriteString("\t\t\t\tif retryAfter := resp.Header.Get(\"Retry-After\"); retryAfter != \"\" {\n")
+			sb.WriteString("\t\t\t\t\t_, errSeconds := strconv.Atoi(retryAfter)\n")
+			sb.WriteString("\t\t\t\t\t_, errDate := http.ParseTime(retryAfter)\n")
+			sb.WriteString("\t\t\t\t\tassert.True(t, errSeconds == nil || errDate == nil, \"Retry-After %q is neither seconds nor an HTTP date\", retryAfter)\n")
+			sb.WriteString("\t\t\t\t}\n")
+		}
+		sb.WriteString("\t\t\t\treturn\n")
+		sb.WriteString("\t\t\t}\n")
+		sb.WriteString("\t\t\tt.Skipf(\"rate limit not reached within %d requests\", burst)\n")
+		sb.WriteString("\t\t})\n")
+	}
+	sb.WriteString("\t})\n\n")
+}
+
+// containsFold reports whether names contains name, ignoring case
+func containsFold(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) })
+}
+
+// generateReceiverTestCode creates a test that captures the webhook or
+// callback the API sends and checks its payload. Callbacks are triggered by
+// calling their operation with the capture server's URL; webhooks are
+// received on EnvWebhookAddr.
+func (c *EnhancedMockClient) generateReceiverTestCode(endpoint *parser.Endpoint, pattern TestPattern) string {
+	testName := fmt.Sprintf("Test%s%s", capitalize(endpoint.Kind), sanitizePath(endpoint.Method+"_"+endpoint.Path))
+	in, field := callbackParameter(endpoint.Path)
+	isCallback := endpoint.Kind == parser.KindCallback
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\n")
+	sb.WriteString("import (\n")
+	if isCallback && in == "body" {
+		sb.WriteString("\t\"bytes\"\n")
+	}
+	sb.WriteString("\t\"encoding/json\"\n")
+	if endpoint.Kind == parser.KindWebhook {
+		sb.WriteString("\t\"net\"\n")
+	}
+	sb.WriteString("\t\"net/http\"\n")
+	sb.WriteString("\t\"net/http/httptest\"\n")
+	if isCallback && in == "query" {
+		sb.WriteString("\t\"net/url\"\n")
+	}
+	sb.WriteString("\t\"os\"\n")
+	sb.WriteString("\t\"testing\"\n")
+	sb.WriteString("\t\"time\"\n\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/assert\"\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/require\"\n")
+	sb.WriteString(")\n\n")
+
+	fmt.Fprintf(&sb, "// %s receives the %s %s %s\n", testName, endpoint.Method, endpoint.Path, endpoint.Kind)
+	fmt.Fprintf(&sb, "// Pattern: %s\n", pattern.Name)
+	fmt.Fprintf(&sb, "func %s(t *testing.T) {\n", testName)
+	sb.WriteString("\ttype delivery struct {\n\t\tmethod, contentType string\n\t\tpayload     map[string]any\n\t}\n")
+	sb.WriteString("\treceived := make(chan delivery, 1)\n")
+	sb.WriteString("\tserver := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {\n")
+	sb.WriteString("\t\td := delivery{method: r.Method, contentType: r.Header.Get(\"Content-Type\")}\n")
+	sb.WriteString("\t\t_ = json.NewDecoder(r.Body).Decode(&d.payload)\n")
+	sb.WriteString("\t\tw.WriteHeader(http.StatusOK)\n")
+	sb.WriteString("\t\tselect {\n\t\tcase received <- d:\n\t\tdefault:\n\t\t}\n")
+	sb.WriteString("\t}))\n")
+
+	if endpoint.Kind == parser.KindWebhook {
+		fmt.Fprintf(&sb, "\taddr := os.Getenv(%q)\n", environment.EnvWebhookAddr)
+		sb.WriteString("\tif addr == \"\" {\n")
+		fmt.Fprintf(&sb, "\t\tt.Skip(\"set %s to the address the API delivers the webhook to\")\n", environment.EnvWebhookAddr)
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tlistener, err := net.Listen(\"tcp\", addr)\n")
+		sb.WriteString("\trequire.NoError(t, err)\n")
+		sb.WriteString("\tserver.Listener = listener\n")
+		sb.WriteString("\tserver.Start()\n")
+		sb.WriteString("\tdefer server.Close()\n\n")
+	} else {
+		sb.WriteString("\tserver.Start()\n")
+		sb.WriteString("\tdefer server.Close()\n\n")
+		method, path, _ := strings.Cut(endpoint.Trigger, " ")
+		fmt.Fprintf(&sb, "\t// Trigger: %s registers the callback\n", endpoint.Trigger)
+		fmt.Fprintf(&sb, "\tbaseURL := os.Getenv(%q)\n", environment.EnvBaseURL)
+		sb.WriteString("\tif baseURL == \"\" {\n")
+		fmt.Fprintf(&sb, "\t\tbaseURL = %q\n", environment.DefaultBaseURL)
+		sb.WriteString("\t}\n")
+		if in == "query" {
+			fmt.Fprintf(&sb, "\treq, err := http.NewRequest(%q, baseURL+%q+\"?%s=\"+url.QueryEscape(server.URL), nil)\n", method, path, field)
+		} else {
+			fmt.Fprintf(&sb, "\tbody, err := json.Marshal(map[string]any{%q: server.URL})\n", field)
+			sb.WriteString("\trequire.NoError(t, err)\n")
+			fmt.Fprintf(&sb, "\treq, err := http.NewRequest(%q, baseURL+%q, bytes.NewReader(body))\n", method, path)
+		}
+		sb.WriteString("\trequire.NoError(t, err)\n")
+		sb.WriteString("\treq.Header.Set(\"Content-Type\", \"application/json\")\n")
+		sb.WriteString("\tresp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)\n")
+		sb.WriteString("\trequire.NoError(t, err)\n")
+		sb.WriteString("\tresp.Body.Close()\n")
+		sb.WriteString("\trequire.Less(t, resp.StatusCode, 300, \"registering the callback should succeed\")\n\n")
+	}
+
+	sb.WriteString("\tselect {\n")
+	sb.WriteString("\tcase d := <-received:\n")
+	fmt.Fprintf(&sb, "\t\tassert.Equal(t, %q, d.method)\n", strings.ToUpper(endpoint.Method))
+	sb.WriteString("\t\tassert.Contains(t, d.contentType, \"application/json\")\n")
+	if endpoint.RequestBody != nil {
+		if media, ok := endpoint.RequestBody.Content["application/json"]; ok {
+			for _, field := range media.Schema.Required {
+				fmt.Fprintf(&sb, "\t\tassert.Contains(t, d.payload, %q)\n", field)
+			}
+		}
+	}
+	sb.WriteString("\tcase <-time.After(30 * time.Second):\n")
+	fmt.Fprintf(&sb, "\t\tt.Fatal(\"no %s received within 30s\")\n", endpoint.Kind)
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// callbackParameter returns where a callback URL expression such as
+// {$request.body#/callbackUrl} or {$request.query.url} reads the URL from:
+// "body" or "query" and the field or parameter name
+func callbackParameter(expression string) (in, name string) {
+	expression = strings.Trim(expression, "{}")
+	if field, ok := strings.CutPrefix(expression, "$request.query."); ok {
+		return "query", field
+	}
+	if pointer, ok := strings.CutPrefix(expression, "$request.body#/"); ok {
+		return "body", strings.ReplaceAll(pointer, "/", ".")
+	}
+	return "body", "callbackUrl"
+}
+
+// generateScenarioTestCode creates a test that runs the scenario's steps in
+// order as subtests, saving response fields for the path parameters of
+// later steps and deleting what it created when a step fails
+func (c *EnhancedMockClient) generateScenarioTestCode(endpoint *parser.Endpoint, pattern TestPattern) string {
+	testName := "TestScenario" + sanitizePath(endpoint.Path)
+	saves := slices.ContainsFunc(endpoint.Steps, func(step parser.ScenarioStep) bool { return len(step.Save) > 0 })
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\n")
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"bytes\"\n")
+	sb.WriteString("\t\"encoding/json\"\n")
+	if saves {
+		sb.WriteString("\t\"fmt\"\n")
+	}
+	sb.WriteString("\t\"net/http\"\n")
+	sb.WriteString("\t\"net/url\"\n")
+	sb.WriteString("\t\"strings\"\n")
+	sb.WriteString("\t\"testing\"\n")
+	sb.WriteString("\t\"time\"\n\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/require\"\n")
+	sb.WriteString(")\n\n")
+
+	fmt.Fprintf(&sb, "// %s runs the %s scenario\n", testName, endpoint.Path)
+	fmt.Fprintf(&sb, "// Pattern: %s\n", pattern.Name)
+	fmt.Fprintf(&sb, "func %s(t *testing.T) {\n", testName)
+	sb.WriteString("\tbaseURL := \"http://localhost:8080\"\n")
+	sb.WriteString("\tclient := &http.Client{Timeout: 10 * time.Second}\n")
+	sb.WriteString("\t// vars holds the values steps save for the path parameters of later steps\n")
+	sb.WriteString("\tvars := map[string]string{}\n\n")
+	sb.WriteString("\tcall := func(t *testing.T, method, path string, body map[string]any) (int, map[string]any) {\n")
+	sb.WriteString("\t\tt.Helper()\n")
+	sb.WriteString("\t\tfor name, value := range vars {\n")
+	sb.WriteString("\t\t\tpath = strings.ReplaceAll(path, \"{\"+name+\"}\", url.PathEscape(value))\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tvar payload []byte\n")
+	sb.WriteString("\t\tif body != nil {\n")
+	sb.WriteString("\t\t\tvar err error\n")
+	sb.WriteString("\t\t\tpayload, err = json.Marshal(body)\n")
+	sb.WriteString("\t\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treq, err := http.NewRequest(method, baseURL+path, bytes.NewReader(payload))\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\treq.Header.Set(\"Content-Type\", \"application/json\")\n")
+	sb.WriteString("\t\tresp, err := client.Do(req)\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\tdefer resp.Body.Close()\n")
+	sb.WriteString("\t\tvar decoded map[string]any\n")
+	sb.WriteString("\t\t_ = json.NewDecoder(resp.Body).Decode(&decoded)\n")
+	sb.WriteString("\t\treturn resp.StatusCode, decoded\n")
+	sb.WriteString("\t}\n")
+	if saves {
+		sb.WriteString("\n\tfield := func(response map[string]any, path string) (any, bool) {\n")
+		sb.WriteString("\t\tvar value any = response\n")
+		sb.WriteString("\t\tfor _, key := range strings.Split(path, \".\") {\n")
+		sb.WriteString("\t\t\tobject, ok := value.(map[string]any)\n")
+		sb.WriteString("\t\t\tif !ok {\n\t\t\t\treturn nil, false\n\t\t\t}\n")
+		sb.WriteString("\t\t\tif value, ok = object[key]; !ok {\n\t\t\t\treturn nil, false\n\t\t\t}\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t\treturn value, true\n")
+		sb.WriteString("\t}\n")
+	}
+
+	// A created resource is deleted even when a step before the delete fails
+	cleanup := slices.IndexFunc(endpoint.Steps, func(step parser.ScenarioStep) bool { return step.Endpoint.Method == "DELETE" })
+	if cleanup >= 0 && saves {
+		params := pathParams(endpoint.Steps[cleanup].Endpoint.Path)
+		conditions := []string{"!deleted"}
+		for _, param := range params {
+			conditions = append(conditions, fmt.Sprintf("vars[%q] != \"\"", param))
+		}
+		sb.WriteString("\n\tdeleted := false\n")
+		sb.WriteString("\tt.Cleanup(func() {\n")
+		fmt.Fprintf(&sb, "\t\tif %s {\n", strings.Join(conditions, " && "))
+		fmt.Fprintf(&sb, "\t\t\tcall(t, \"DELETE\", %q, nil)\n", endpoint.Steps[cleanup].Endpoint.Path)
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t})\n")
+	} else {
+		cleanup = -1
+	}
+
+	for i, step := range endpoint.Steps {
+		fmt.Fprintf(&sb, "\n\t// Step %d: %s %s\n", i+1, step.Endpoint.Method, step.Endpoint.Path)
+		fmt.Fprintf(&sb, "\tif !t.Run(%q, func(t *testing.T) {\n", step.Name)
+		response := "_"
+		if len(step.Save) > 0 {
+			response = "response"
+		}
+		fmt.Fprintf(&sb, "\t\tstatus, %s := call(t, %q, %q, %s)\n", response, step.Endpoint.Method, step.Endpoint.Path, sampleBody(step.Endpoint.RequestBody))
+		fmt.Fprintf(&sb, "\t\trequire.Equal(t, %d, status)\n", step.Expect)
+		variables := make([]string, 0, len(step.Save))
+		for variable := range step.Save {
+			variables = append(variables, variable)
+		}
+		slices.Sort(variables)
+		for j, variable := range variables {
+			assign := "="
+			if j == 0 {
+				assign = ":="
+			}
+			fmt.Fprintf(&sb, "\t\tvalue, ok %s field(response, %q)\n", assign, step.Save[variable])
+			fmt.Fprintf(&sb, "\t\trequire.True(t, ok, \"response has no %s field\")\n", step.Save[variable])
+			fmt.Fprintf(&sb, "\t\tvars[%q] = fmt.Sprint(value)\n", variable)
+		}
+		if i == cleanup {
+			sb.WriteString("\t\tdeleted = true\n")
+		}
+		sb.WriteString("\t}) {\n")
+		sb.WriteString("\t\treturn\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// generateGraphQLTestCode creates a test posting the operation's document
+// with sample variables, asserting its data and that missing arguments and
+// invalid documents are reported as GraphQL errors
+func (c *EnhancedMockClient) generateGraphQLTestCode(endpoint *parser.Endpoint, pattern TestPattern) string {
+	op := endpoint.GraphQL
+	testName := "Test" + capitalize(op.Type) + capitalize(op.Field)
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\n")
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"bytes\"\n")
+	sb.WriteString("\t\"encoding/json\"\n")
+	sb.WriteString("\t\"net/http\"\n")
+	sb.WriteString("\t\"testing\"\n")
+	sb.WriteString("\t\"time\"\n\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/assert\"\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/require\"\n")
+	sb.WriteString(")\n\n")
+
+	fmt.Fprintf(&sb, "// %s tests the %s %s of the GraphQL API\n", testName, op.Field, op.Type)
+	fmt.Fprintf(&sb, "// Pattern: %s\n", pattern.Name)
+	fmt.Fprintf(&sb, "func %s(t *testing.T) {\n", testName)
+	sb.WriteString("\tbaseURL := \"http://localhost:8080\"\n")
+	sb.WriteString("\tclient := &http.Client{Timeout: 10 * time.Second}\n")
+	fmt.Fprintf(&sb, "\tdocument := %s\n\n", goRawString(op.Document))
+	sb.WriteString("\tpost := func(t *testing.T, query string, variables map[string]any) (int, map[string]any) {\n")
+	sb.WriteString("\t\tt.Helper()\n")
+	sb.WriteString("\t\tpayload, err := json.Marshal(map[string]any{\"query\": query, \"variables\": variables})\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	fmt.Fprintf(&sb, "\t\tresp, err := client.Post(baseURL+%q, \"application/json\", bytes.NewReader(payload))\n", endpoint.Path)
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\tdefer resp.Body.Close()\n")
+	sb.WriteString("\t\tvar decoded map[string]any\n")
+	sb.WriteString("\t\trequire.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))\n")
+	sb.WriteString("\t\treturn resp.StatusCode, decoded\n")
+	sb.WriteString("\t}\n\n")
+
+	variables := make([]string, 0, len(endpoint.Parameters))
+	required := false
+	for _, param := range endpoint.Parameters {
+		if param.Required {
+			variables = append(variables, fmt.Sprintf("%q: %s", param.Name, sampleValue(param.Schema)))
+			required = true
+		}
+	}
+
+	sb.WriteString("\t// Test: Success scenario\n")
+	sb.WriteString("\tt.Run(\"Success\", func(t *testing.T) {\n")
+	fmt.Fprintf(&sb, "\t\tstatus, response := post(t, document, map[string]any{%s})\n", strings.Join(variables, ", "))
+	sb.WriteString("\t\trequire.Equal(t, http.StatusOK, status)\n")
+	sb.WriteString("\t\tassert.Empty(t, response[\"errors\"])\n")
+	sb.WriteString("\t\tdata, ok := response[\"data\"].(map[string]any)\n")
+	sb.WriteString("\t\trequire.True(t, ok, \"response has no data\")\n")
+	fmt.Fprintf(&sb, "\t\tassert.Contains(t, data, %q)\n", op.Field)
+	sb.WriteString("\t})\n")
+
+	if required {
+		sb.WriteString("\n\t// Test: Required arguments are validated\n")
+		sb.WriteString("\tt.Run(\"MissingArguments\", func(t *testing.T) {\n")
+		sb.WriteString("\t\t_, response := post(t, document, map[string]any{})\n")
+		sb.WriteString("\t\tassert.NotEmpty(t, response[\"errors\"], \"missing arguments must be reported as errors\")\n")
+		sb.WriteString("\t})\n")
+	}
+
+	sb.WriteString("\n\t// Test: Documents are validated against the schema\n")
+	sb.WriteString("\tt.Run(\"InvalidDocument\", func(t *testing.T) {\n")
+	fmt.Fprintf(&sb, "\t\t_, response := post(t, %q, nil)\n", "{ __glensUnknownField }")
+	sb.WriteString("\t\tassert.NotEmpty(t, response[\"errors\"], \"unknown fields must be reported as errors\")\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// generateGRPCTestCode creates a test calling the method with grpcurl,
+// which reads the schema from the method's .proto file or descriptor set,
+// asserting a response on success and an InvalidArgument status for a
+// request with an unknown field
+func (c *EnhancedMockClient) generateGRPCTestCode(endpoint *parser.Endpoint, pattern TestPattern) string {
+	method := endpoint.GRPC
+	service := method.Service[strings.LastIndex(method.Service, ".")+1:]
+	testName := "Test" + capitalize(service) + capitalize(method.Method)
+
+	schemaArgs := make([]string, 0, 4)
+	for _, arg := range method.GrpcurlArgs() {
+		schemaArgs = append(schemaArgs, strconv.Quote(arg))
+	}
+	request := "map[string]any{}"
+	if endpoint.RequestBody != nil {
+		request = sampleBody(endpoint.RequestBody)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("package main\n\n")
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"bytes\"\n")
+	sb.WriteString("\t\"context\"\n")
+	sb.WriteString("\t\"encoding/json\"\n")
+	sb.WriteString("\t\"net/url\"\n")
+	sb.WriteString("\t\"os/exec\"\n")
+	sb.WriteString("\t\"testing\"\n")
+	sb.WriteString("\t\"time\"\n\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/assert\"\n")
+	sb.WriteString("\t\"github.com/stretchr/testify/require\"\n")
+	sb.WriteString(")\n\n")
+
+	fmt.Fprintf(&sb, "// %s tests the %s method of the gRPC API\n", testName, method.Symbol())
+	fmt.Fprintf(&sb, "// Pattern: %s\n", pattern.Name)
+	fmt.Fprintf(&sb, "func %s(t *testing.T) {\n", testName)
+	sb.WriteString("\tgrpcurl, err := exec.LookPath(\"grpcurl\")\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\tt.Skip(\"grpcurl is not installed\")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tbaseURL := \"http://localhost:8080\"\n")
+	sb.WriteString("\ttarget, err := url.Parse(baseURL)\n")
+	sb.WriteString("\trequire.NoError(t, err)\n\n")
+	sb.WriteString("\tcall := func(t *testing.T, request any) (string, error) {\n")
+	sb.WriteString("\t\tt.Helper()\n")
+	sb.WriteString("\t\tpayload, err := json.Marshal(request)\n")
+	sb.WriteString("\t\trequire.NoError(t, err)\n")
+	sb.WriteString("\t\tctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)\n")
+	sb.WriteString("\t\tdefer cancel()\n")
+	fmt.Fprintf(&sb, "\t\targs := []string{%s}\n", strings.Join(schemaArgs, ", "))
+	sb.WriteString("\t\tif target.Scheme != \"https\" {\n")
+	sb.WriteString("\t\t\targs = append(args, \"-plaintext\")\n")
+	sb.WriteString("\t\t}\n")
+	fmt.Fprintf(&sb, "\t\targs = append(args, \"-format-error\", \"-d\", \"@\", target.Host, %q)\n", method.Symbol())
+	sb.WriteString("\t\tcmd := exec.CommandContext(ctx, grpcurl, args...)\n")
+	sb.WriteString("\t\tcmd.Stdin = bytes.NewReader(payload)\n")
+	sb.WriteString("\t\toutput, err := cmd.CombinedOutput()\n")
+	sb.WriteString("\t\treturn string(output), err\n")
+	sb.WriteString("\t}\n\n")
+
+	sb.WriteString("\t// Test: Success scenario\n")
+	sb.WriteString("\tt.Run(\"Success\", func(t *testing.T) {\n")
+	fmt.Fprintf(&sb, "\t\toutput, err := call(t, %s)\n", request)
+	sb.WriteString("\t\trequire.NoError(t, err, output)\n")
+	if method.ServerStreaming {
+		sb.WriteString("\t\t// Streamed responses are printed one JSON object after another\n")
+		sb.WriteString("\t\tdecoder := json.NewDecoder(bytes.NewReader([]byte(output)))\n")
+		sb.WriteString("\t\tfor decoder.More() {\n")
+		sb.WriteString("\t\t\tvar response map[string]any\n")
+		sb.WriteString("\t\t\trequire.NoError(t, decoder.Decode(&response), output)\n")
+		sb.WriteString("\t\t}\n")
+	} else {
+		sb.WriteString("\t\tvar response map[string]any\n")
+		sb.WriteString("\t\tassert.NoError(t, json.Unmarshal([]byte(output), &response), output)\n")
+	}
+	sb.WriteString("\t})\n")
+
+	sb.WriteString("\n\t// Test: Requests are checked against the schema\n")
+	sb.WriteString("\tt.Run(\"UnknownField\", func(t *testing.T) {\n")
+	sb.WriteString("\t\toutput, err := call(t, map[string]any{\"glensUnknownField\": true})\n")
+	sb.WriteString("\t\tassert.Error(t, err, \"unknown fields must be rejected\")\n")
+	sb.WriteString("\t\tassert.NotEmpty(t, output)\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// goRawString returns s as a Go raw string literal, or a quoted one when it
+// contains a backquote
+func goRawString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// pathParams returns the names of the path parameters of path, in order
+func pathParams(path string) []string {
+	var params []string
+	for {
+		_, rest, ok := strings.Cut(path, "{")
+		if !ok {
+			return params
+		}
+		name, rest, ok := strings.Cut(rest, "}")
+		if !ok {
+			return params
+		}
+		params = append(params, name)
+		path = rest
+	}
+}
+
+// sampleBody returns a Go map literal of the required properties of a JSON
+// request body with sample values, or nil without a JSON body
+func sampleBody(body *parser.RequestBody) string {
+	if body == nil {
+		return "nil"
+	}
+	media, ok := body.Content["application/json"]
+	if !ok {
+		return "nil"
+	}
+	fields := make([]string, 0, len(media.Schema.Required))
+	for _, name := range media.Schema.Required {
+		fields = append(fields, fmt.Sprintf("%q: %s", name, sampleValue(media.Schema.Properties[name])))
+	}
+	return "map[string]any{" + strings.Join(fields, ", ") + "}"
+}
+
+// sampleValue returns a Go literal of a value valid for schema: its const,
+// example or first enum value, or else a value of its type, objects with
+// their required properties
+func sampleValue(schema parser.Schema) string {
+	for _, value := range []any{schema.Const, schema.Example} {
+		if value != nil {
+			return fmt.Sprintf("%#v", value)
+		}
+	}
+	if len(schema.Enum) > 0 && schema.Enum[0] != nil {
+		return fmt.Sprintf("%#v", schema.Enum[0])
+	}
+	switch schema.Type {
+	case "integer":
+		return "1"
+	case "number":
+		return "1.5"
+	case "boolean":
+		return "true"
+	case "array":
+		return "[]any{}"
+	case "object":
+		fields := make([]string, 0, len(schema.Required))
+		for _, name := range schema.Required {
+			fields = append(fields, fmt.Sprintf("%q: %s", name, sampleValue(schema.Properties[name])))
+		}
+		return "map[string]any{" + strings.Join(fields, ", ") + "}"
+	default:
+		return `"test"`
+	}
+}
+
 // buildPrompt creates a comprehensive prompt
 func (c *EnhancedMockClient) buildPrompt(endpoint *parser.Endpoint) string {
 	return fmt.Sprintf("Generate comprehensive integration test for %s %s with security and edge cases",
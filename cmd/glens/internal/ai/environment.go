@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"glens/tools/glens/internal/environment"
+)
+
+// EnvironmentAware is implemented by clients whose prompts describe the
+// target environment generated tests will run against
+type EnvironmentAware interface {
+	SetEnvironment(env *environment.Environment)
+}
+
+// promptEnvironment is embedded by prompt-building clients to implement
+// EnvironmentAware
+type promptEnvironment struct {
+	env *environment.Environment
+}
+
+// SetEnvironment selects the target environment described in prompts
+func (p *promptEnvironment) SetEnvironment(env *environment.Environment) {
+	p.env = env
+}
+
+// environmentPrompt describes the target environment for the model. Header
+// values and secrets are never included; tests must read them from the
+// process environment.
+func (p *promptEnvironment) environmentPrompt() string {
+	env := p.env
+	if env == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Target Environment:** %s\n", env.Name)
+	fmt.Fprintf(&sb, "- Read the base URL with os.Getenv(%q), falling back to %q\n", environment.EnvBaseURL, env.BaseURL)
+
+	if names := env.HeaderNames(); len(names) > 0 {
+		sb.WriteString("- Send these headers on every request, each set to the value of its environment variable:\n")
+		for _, name := range names {
+			fmt.Fprintf(&sb, "  - %s: os.Getenv(%q)\n", name, environment.HeaderEnv(name))
+		}
+	}
+
+	switch env.Auth.Type {
+	case environment.AuthBearer:
+		fmt.Fprintf(&sb, "- Authenticate every request with header \"Authorization: Bearer <token>\" where the token is os.Getenv(%q)\n", environment.EnvToken)
+	case environment.AuthAPIKey:
+		fmt.Fprintf(&sb, "- Authenticate every request with header %q set to os.Getenv(%q)\n", env.AuthHeader(), environment.EnvToken)
+	}
+	if env.Auth.Type != environment.AuthNone || len(env.Headers) > 0 {
+		sb.WriteString("- Never hardcode credentials in the test code\n")
+	}
+
+	if env.InsecureSkipVerify {
+		sb.WriteString("- The server uses a self-signed certificate: use an http.Client whose Transport sets TLSClientConfig: &tls.Config{InsecureSkipVerify: true}\n")
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
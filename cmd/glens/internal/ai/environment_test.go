@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/environment"
+)
+
+func TestEnvironmentPrompt_NoEnvironment(t *testing.T) {
+	var p promptEnvironment
+	assert.Empty(t, p.environmentPrompt())
+}
+
+func TestEnvironmentPrompt_DescribesTargetWithoutSecrets(t *testing.T) {
+	env := &environment.Environment{
+		Name:               "staging",
+		BaseURL:            "https://staging.example.com",
+		Headers:            map[string]string{"X-Tenant": "acme", "X-Session-Key": "${GLENS_TEST_SESSION}"},
+		Auth:               environment.Auth{Type: environment.AuthBearer, Token: "s3cret"},
+		InsecureSkipVerify: true,
+	}
+
+	c, err := NewOllamaClient(OllamaConfig{})
+	require.NoError(t, err)
+	c.SetEnvironment(env)
+	prompt, err := c.buildPrompt(testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "**Target Environment:** staging")
+	assert.Contains(t, prompt, `os.Getenv("GLENS_BASE_URL")`)
+	assert.Contains(t, prompt, `X-Tenant: os.Getenv("GLENS_HEADER_X_TENANT")`)
+	assert.Contains(t, prompt, `X-Session-Key: os.Getenv("GLENS_HEADER_X_SESSION_KEY")`)
+	assert.NotContains(t, prompt, "acme")
+	assert.Contains(t, prompt, `os.Getenv("GLENS_TOKEN")`)
+	assert.Contains(t, prompt, "InsecureSkipVerify")
+	assert.NotContains(t, prompt, "s3cret")
+}
+
+func TestManager_SetEnvironment(t *testing.T) {
+	m, err := NewManager([]string{"mock", "mistral-local"}, Config{})
+	require.NoError(t, err)
+
+	env := &environment.Environment{Name: "dev", BaseURL: "http://dev.internal"}
+	m.SetEnvironment(env)
+
+	local, ok := m.clients["mistral-local"].(*OllamaClient)
+	require.True(t, ok)
+	assert.Same(t, env, local.env)
+}
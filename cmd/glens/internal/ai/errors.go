@@ -1,6 +1,10 @@
 package ai
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
 
 // ErrModelNotFound is returned when a requested AI model is not available
 type ErrModelNotFound struct {
@@ -33,12 +37,27 @@ func (e ErrAPIKeyMissing) Error() string {
 type ErrGenerationFailed struct {
 	Model  string
 	Reason string
+	// Err is the provider error behind Reason, if any
+	Err error
 }
 
 func (e ErrGenerationFailed) Error() string {
 	return fmt.Sprintf("test generation failed for model '%s': %s", e.Model, e.Reason)
 }
 
+func (e ErrGenerationFailed) Unwrap() error {
+	return e.Err
+}
+
+// ErrRepairUnsupported is returned when a model cannot repair failing tests
+type ErrRepairUnsupported struct {
+	Model string
+}
+
+func (e ErrRepairUnsupported) Error() string {
+	return fmt.Sprintf("AI model '%s' does not support test repair", e.Model)
+}
+
 // ErrRateLimited is returned when API rate limits are exceeded
 type ErrRateLimited struct {
 	Model      string
@@ -46,5 +65,23 @@ type ErrRateLimited struct {
 }
 
 func (e ErrRateLimited) Error() string {
+	if e.RetryAfter == "" {
+		return fmt.Sprintf("rate limited for model '%s'", e.Model)
+	}
 	return fmt.Sprintf("rate limited for model '%s', retry after: %s", e.Model, e.RetryAfter)
 }
+
+// responseError classifies a provider API response by its status: nil for
+// 200 OK, ErrRateLimited for rate and quota limits, which take the model out
+// of rotation (see Manager.Generate), and otherwise an error with the
+// response body
+func responseError(model string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusTooManyRequests:
+		return ErrRateLimited{Model: model, RetryAfter: resp.Header.Get("Retry-After")}
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+}
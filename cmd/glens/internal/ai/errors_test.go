@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		wantErr string
+	}{
+		{"ok", http.StatusOK, nil, ""},
+		{"rate limited", http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}, "rate limited for model 'gpt-4o', retry after: 30"},
+		{"api error", http.StatusBadGateway, nil, "API error (status 502): upstream down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header, Body: io.NopCloser(strings.NewReader("upstream down"))}
+
+			err := responseError("gpt-4o", resp)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+			if tt.status == http.StatusTooManyRequests {
+				assert.ErrorAs(t, err, &ErrRateLimited{}, "rate limits take the model out of rotation")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"fmt"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// estimatedTestTokens is the length of a typical generated test file, the
+// output an estimate expects per endpoint unless the model's limit is lower
+const estimatedTestTokens = 2000
+
+// charsPerToken approximates the tokenizers of the providers for English
+// prose and Go code
+const charsPerToken = 4
+
+// TokenEstimate is the expected token use of a model generating the tests
+// of some endpoints
+type TokenEstimate struct {
+	Model        string `json:"model"`
+	Endpoints    int    `json:"endpoints"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+}
+
+// promptBuilder is implemented by clients rendering their prompts from
+// templates; systemPromptBuilder by those also sending a system prompt
+type (
+	promptBuilder interface {
+		buildPrompt(endpoint *parser.Endpoint) (string, error)
+	}
+	systemPromptBuilder interface {
+		systemPrompt(endpoint *parser.Endpoint) (string, error)
+	}
+)
+
+// EstimateTokens estimates the tokens each model of a run of models uses
+// to generate the tests of endpoints, in the order the models are first
+// used: the prompts the model's client renders, at about four characters
+// per token, and a typical test per endpoint capped by the model's output
+// limit. Routing picks the models of each endpoint and the prompt policy
+// applies; fallbacks, retries and repairs are not counted.
+func (m *Manager) EstimateTokens(endpoints []parser.Endpoint, models []string) ([]TokenEstimate, error) {
+	var estimates []TokenEstimate
+	index := map[string]int{}
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		routed, _ := m.ModelsFor(endpoint, models)
+		for _, model := range routed {
+			client, ok := m.clients[model]
+			if !ok {
+				return nil, ErrModelNotFound{Model: model}
+			}
+			input, err := promptTokens(client, model, m.applyPolicy(client, model, endpoint))
+			if err != nil {
+				return nil, fmt.Errorf("failed to render the prompt of %s for %s %s: %w", model, endpoint.Method, endpoint.Path, err)
+			}
+			output := estimatedTestTokens
+			if limit := client.GetCapabilities().MaxTokens; limit > 0 {
+				output = min(output, limit)
+			}
+
+			n, ok := index[model]
+			if !ok {
+				n = len(estimates)
+				index[model] = n
+				estimates = append(estimates, TokenEstimate{Model: model})
+			}
+			estimates[n].Endpoints++
+			estimates[n].InputTokens += input
+			estimates[n].OutputTokens += output
+		}
+	}
+	return estimates, nil
+}
+
+// promptTokens approximates the tokens of the prompts client sends for
+// endpoint; clients without templates are estimated with the openai ones
+func promptTokens(client Client, model string, endpoint *parser.Endpoint) (int, error) {
+	builder, ok := client.(promptBuilder)
+	if !ok {
+		builder = &templateClient{promptTemplates{name: model}}
+	}
+	prompt, err := builder.buildPrompt(endpoint)
+	if err != nil {
+		return 0, err
+	}
+	chars := len(prompt)
+	if system, ok := client.(systemPromptBuilder); ok {
+		prompt, err := system.systemPrompt(endpoint)
+		if err != nil {
+			return 0, err
+		}
+		chars += len(prompt)
+	}
+	return (chars + charsPerToken - 1) / charsPerToken, nil
+}
+
+// templateClient renders the openai prompts, for estimates of clients
+// without templates
+type templateClient struct {
+	promptTemplates
+}
+
+func (c *templateClient) buildPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("openai", endpoint, "")
+}
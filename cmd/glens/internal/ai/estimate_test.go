@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	m, err := NewManager([]string{"mock", "mistral-local"}, Config{})
+	require.NoError(t, err)
+	router, err := NewRouter(RoutingConfig{Rules: []RouteRule{{Methods: []string{"DELETE"}, Models: []string{"mistral-local"}}}})
+	require.NoError(t, err)
+	m.SetRouter(router)
+
+	endpoints := []parser.Endpoint{
+		{Method: "GET", Path: "/pets", OperationID: "listPets"},
+		{Method: "POST", Path: "/pets", OperationID: "createPet", Summary: "Create a pet"},
+		{Method: "DELETE", Path: "/pets/{id}", OperationID: "deletePet"},
+	}
+	estimates, err := m.EstimateTokens(endpoints, []string{"mock"})
+	require.NoError(t, err)
+	require.Len(t, estimates, 2)
+
+	assert.Equal(t, "mock", estimates[0].Model)
+	assert.Equal(t, 2, estimates[0].Endpoints)
+	assert.Equal(t, 2*estimatedTestTokens, estimates[0].OutputTokens)
+	assert.Greater(t, estimates[0].InputTokens, 100, "the prompt is rendered from the templates")
+
+	assert.Equal(t, "mistral-local", estimates[1].Model, "routing sends DELETE to another model")
+	assert.Equal(t, 1, estimates[1].Endpoints)
+	assert.Greater(t, estimates[1].InputTokens, 0)
+
+	estimates, err = m.EstimateTokens(endpoints, []string{"gpt4"})
+	assert.ErrorAs(t, err, &ErrModelNotFound{})
+	assert.Nil(t, estimates)
+}
@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// DefaultMaxExamples is how many examples a prompt includes by default
+const DefaultMaxExamples = 2
+
+// exampleDirective is an optional first-line comment declaring what an
+// example covers, e.g. "// glens: methods=POST,PUT tags=users,auth"
+const exampleDirective = "// glens:"
+
+// methodPattern finds the HTTP methods an example exercises when it
+// declares none
+var methodPattern = regexp.MustCompile(`http\.Method(Get|Head|Post|Put|Patch|Delete|Options)\b|"(GET|HEAD|POST|PUT|PATCH|DELETE|OPTIONS)"`)
+
+// Example is an exemplar test injected into prompts as few-shot context
+type Example struct {
+	// Name is the file path relative to the examples directory
+	Name string
+	// Code is the file content without its glens directive
+	Code string
+	// Methods and Tags describe which endpoints the example suits; an
+	// example with neither suits every endpoint
+	Methods []string
+	Tags    []string
+}
+
+// LoadExamples reads every .go file under dir as an example
+func LoadExamples(dir string) ([]Example, error) {
+	var examples []Example
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		examples = append(examples, parseExample(filepath.ToSlash(name), string(content)))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load examples: %w", err)
+	}
+	return examples, nil
+}
+
+// parseExample reads the directive of an example or infers its methods
+func parseExample(name, content string) Example {
+	ex := Example{Name: name, Code: strings.TrimSpace(content)}
+
+	first, rest, _ := strings.Cut(content, "\n")
+	if directive, ok := strings.CutPrefix(strings.TrimSpace(first), exampleDirective); ok {
+		ex.Code = strings.TrimSpace(rest)
+		for _, field := range strings.Fields(directive) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "methods":
+				ex.Methods = strings.Split(strings.ToUpper(value), ",")
+			case "tags":
+				ex.Tags = strings.Split(value, ",")
+			}
+		}
+		return ex
+	}
+
+	for _, match := range methodPattern.FindAllStringSubmatch(content, -1) {
+		method := strings.ToUpper(match[1] + match[2])
+		if !slices.Contains(ex.Methods, method) {
+			ex.Methods = append(ex.Methods, method)
+		}
+	}
+	return ex
+}
+
+// relevance scores how well ex suits endpoint: a matching method counts
+// twice as much as each shared tag. Examples describing nothing score 1 so
+// they fill remaining slots; unrelated examples score 0.
+func (ex *Example) relevance(endpoint *parser.Endpoint) int {
+	if len(ex.Methods) == 0 && len(ex.Tags) == 0 {
+		return 1
+	}
+	score := 0
+	if slices.ContainsFunc(ex.Methods, func(m string) bool { return strings.EqualFold(m, endpoint.Method) }) {
+		score += 2
+	}
+	for _, tag := range endpoint.Tags {
+		if slices.Contains(ex.Tags, tag) {
+			score++
+		}
+	}
+	return score
+}
+
+// SelectExamples returns up to limit examples relevant to endpoint, most
+// relevant first
+func SelectExamples(examples []Example, endpoint *parser.Endpoint, limit int) []Example {
+	type scored struct {
+		example Example
+		score   int
+	}
+	var candidates []scored
+	for i := range examples {
+		if score := examples[i].relevance(endpoint); score > 0 {
+			candidates = append(candidates, scored{examples[i], score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].example.Name < candidates[j].example.Name
+	})
+
+	selected := make([]Example, 0, min(limit, len(candidates)))
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		selected = append(selected, candidates[i].example)
+	}
+	return selected
+}
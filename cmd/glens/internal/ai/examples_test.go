@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func writeExample(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestLoadExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeExample(t, dir, "create_user_test.go", "// glens: methods=post,put tags=users\npackage api\n")
+	writeExample(t, dir, "auth/login_test.go", "package auth\n\nreq, _ := http.NewRequest(http.MethodPost, url, body)\nreq2, _ := http.NewRequest(\"GET\", url, nil)\n")
+	writeExample(t, dir, "helpers.go", "package api\n\nfunc newClient() {}\n")
+	writeExample(t, dir, "README.md", "not an example")
+
+	examples, err := LoadExamples(dir)
+	require.NoError(t, err)
+	require.Len(t, examples, 3)
+
+	assert.Equal(t, "auth/login_test.go", examples[0].Name)
+	assert.Equal(t, []string{"POST", "GET"}, examples[0].Methods)
+	assert.Equal(t, Example{Name: "create_user_test.go", Code: "package api", Methods: []string{"POST", "PUT"}, Tags: []string{"users"}}, examples[1])
+	assert.Empty(t, examples[2].Methods)
+
+	_, err = LoadExamples(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "failed to load examples")
+}
+
+func TestSelectExamples(t *testing.T) {
+	examples := []Example{
+		{Name: "delete_test.go", Methods: []string{"DELETE"}},
+		{Name: "helpers.go"},
+		{Name: "users_post_test.go", Methods: []string{"POST"}, Tags: []string{"users"}},
+		{Name: "users_get_test.go", Methods: []string{"GET"}, Tags: []string{"users"}},
+		{Name: "orders_post_test.go", Methods: []string{"POST"}, Tags: []string{"orders"}},
+	}
+	names := func(examples []Example) []string {
+		out := make([]string, len(examples))
+		for i := range examples {
+			out[i] = examples[i].Name
+		}
+		return out
+	}
+
+	endpoint := &parser.Endpoint{Method: "post", Path: "/users", Tags: []string{"users"}}
+	assert.Equal(t, []string{"users_post_test.go", "orders_post_test.go"}, names(SelectExamples(examples, endpoint, 2)))
+	assert.Equal(t, []string{"users_post_test.go", "orders_post_test.go", "helpers.go", "users_get_test.go"},
+		names(SelectExamples(examples, endpoint, 10)), "unrelated examples are never selected")
+
+	assert.Empty(t, SelectExamples(examples, endpoint, 0))
+	assert.Empty(t, SelectExamples(nil, endpoint, 2))
+}
+
+func TestPrompts_Examples(t *testing.T) {
+	p, err := NewPrompts("")
+	require.NoError(t, err)
+	p.SetExamples([]Example{{Name: "users_test.go", Code: "func TestUsers(t *testing.T) {}", Methods: []string{"DELETE"}}}, DefaultMaxExamples)
+
+	var c promptTemplates
+	c.SetPrompts(p, "gpt4")
+	prompt, err := c.renderPrompt("openai", promptEndpoint(), "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "**Example Tests:**")
+	assert.Contains(t, prompt, "users_test.go:\n```go\nfunc TestUsers(t *testing.T) {}\n```")
+
+	prompt, err = c.renderPrompt("openai", testEndpoint("GET", "/users"), "")
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "**Example Tests:**")
+}
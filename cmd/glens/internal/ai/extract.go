@@ -0,0 +1,312 @@
+package ai
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// fileHintPattern matches the file name a model gives a code block, in the
+// fence info string ("```go title=helpers_test.go"), a heading or sentence
+// right before the fence ("**helpers_test.go**") or a leading comment
+// ("// file: helpers_test.go")
+var fileHintPattern = regexp.MustCompile(`[\w./-]*\w\.go\b|\bgo\.mod\b`)
+
+// goBlock is a fenced block of a model answer that may hold Go code
+type goBlock struct {
+	code string
+	// file is the name hinted for the block, if any
+	file  string
+	tests int
+}
+
+// ExtractGoCode returns the Go code of a model answer: the fenced Go block
+// holding the tests (the one with most test functions, then the longest)
+// with the declarations and imports of helper blocks of the same package
+// merged in, an unterminated block cut off at the end, or the text from
+// the package clause to the last closing brace. Blocks hinted as go.mod or
+// non-Go files and blocks of another package (such as a package main
+// example) are left out. Answers without any code are returned trimmed.
+func ExtractGoCode(answer string) string {
+	if blocks := goBlocks(answer); len(blocks) > 0 {
+		return mergeGoBlocks(blocks)
+	}
+
+	start := packagePattern.FindStringIndex(answer)
+	if start == nil {
+		return strings.TrimSpace(answer)
+	}
+	code := answer[start[0]:]
+	if end := strings.LastIndex(code, "\n}"); end != -1 {
+		code = code[:end+2]
+	}
+	return strings.TrimSpace(code)
+}
+
+// goBlocks returns the fenced blocks of answer that may hold Go code, the
+// test file first
+func goBlocks(answer string) []goBlock {
+	fences := fencePattern.FindAllStringSubmatchIndex(answer, -1)
+	var blocks []goBlock
+	for i := 0; i < len(fences); i += 2 {
+		lang := strings.ToLower(answer[fences[i][2]:fences[i][3]])
+		end := len(answer)
+		if i+1 < len(fences) {
+			end = fences[i+1][0]
+		}
+		code := strings.TrimSpace(answer[fences[i][1]:end])
+		if code == "" || !slices.Contains([]string{"go", "golang", ""}, lang) {
+			continue
+		}
+
+		info := ""
+		if fences[i][4] != -1 {
+			info = answer[fences[i][4]:fences[i][5]]
+		}
+		block := goBlock{
+			code:  code,
+			file:  fileHint(info, answer[:fences[i][0]], code),
+			tests: strings.Count(code, "func Test"),
+		}
+		if block.file != "" && !strings.HasSuffix(block.file, ".go") ||
+			strings.HasPrefix(code, "module ") {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	best := -1
+	for i, block := range blocks {
+		if best == -1 || block.tests > blocks[best].tests ||
+			block.tests == blocks[best].tests && isTestFile(block.file) && !isTestFile(blocks[best].file) ||
+			block.tests == blocks[best].tests && isTestFile(block.file) == isTestFile(blocks[best].file) &&
+				len(block.code) > len(blocks[best].code) {
+			best = i
+		}
+	}
+	if best > 0 {
+		blocks[0], blocks[best] = blocks[best], blocks[0]
+	}
+	return blocks
+}
+
+// fileHint returns the file name hinted for a block by its fence info, the
+// last line before it or its first line
+func fileHint(info, before, code string) string {
+	if hint := fileHintPattern.FindString(info); hint != "" {
+		return hint
+	}
+	before = strings.TrimRight(before, " \t\n")
+	if line := before[strings.LastIndex(before, "\n")+1:]; len(line) <= 120 {
+		if hint := fileHintPattern.FindString(line); hint != "" {
+			return hint
+		}
+	}
+	if first, _, _ := strings.Cut(code, "\n"); strings.HasPrefix(first, "//") {
+		return fileHintPattern.FindString(first)
+	}
+	return ""
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go")
+}
+
+// mergeGoBlocks appends the declarations of the helper blocks[1:] missing
+// from the test file blocks[0] to it, with their imports. The test file is
+// returned as is when nothing is merged or the merged code does not parse.
+func mergeGoBlocks(blocks []goBlock) string {
+	testFile := blocks[0].code
+	if len(blocks) == 1 {
+		return testFile
+	}
+	parsed := make([]*goSource, len(blocks))
+	for i := range blocks {
+		parsed[i] = parseGoSource(blocks[i].code)
+	}
+	if parsed[0] == nil {
+		return testFile
+	}
+	if !parsed[0].hasPackage {
+		for _, helper := range parsed[1:] {
+			if helper != nil && helper.hasPackage {
+				testFile = "package " + helper.file.Name.Name + "\n\n" + testFile
+				parsed[0] = parseGoSource(testFile)
+				break
+			}
+		}
+		if parsed[0] == nil || !parsed[0].hasPackage {
+			return blocks[0].code
+		}
+	}
+
+	declared := parsed[0].declared()
+	imported := make(map[string]bool)
+	for _, spec := range parsed[0].file.Imports {
+		imported[spec.Path.Value] = true
+	}
+	var imports, decls []string
+	for _, helper := range parsed[1:] {
+		if helper == nil || helper.hasPackage && !samePackage(helper.file.Name.Name, parsed[0].file.Name.Name) {
+			continue
+		}
+		merged := false
+		for _, decl := range helper.file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+			names := declNames(decl)
+			if len(names) == 0 || slices.ContainsFunc(names, func(name string) bool { return declared[name] }) {
+				continue
+			}
+			for _, name := range names {
+				declared[name] = true
+			}
+			decls = append(decls, helper.text(decl))
+			merged = true
+		}
+		if !merged {
+			continue
+		}
+		for _, spec := range helper.file.Imports {
+			if imported[spec.Path.Value] {
+				continue
+			}
+			imported[spec.Path.Value] = true
+			imports = append(imports, helper.src[helper.offset(spec.Pos()):helper.offset(spec.End())])
+		}
+	}
+	if len(decls) == 0 {
+		return blocks[0].code
+	}
+
+	code := addImportSpecs(parsed[0], imports) + "\n\n" + strings.Join(decls, "\n\n") + "\n"
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return blocks[0].code
+	}
+	return strings.TrimSpace(string(formatted))
+}
+
+// samePackage reports whether files of packages a and b can be merged,
+// treating an external test package like the package it tests
+func samePackage(a, b string) bool {
+	return a != "main" && strings.TrimSuffix(a, "_test") == strings.TrimSuffix(b, "_test")
+}
+
+// goSource is a parsed block, given a package clause when it has none
+type goSource struct {
+	src        string
+	file       *ast.File
+	fset       *token.FileSet
+	hasPackage bool
+}
+
+func parseGoSource(code string) *goSource {
+	source := &goSource{src: code, fset: token.NewFileSet(), hasPackage: packagePattern.MatchString(code)}
+	if !source.hasPackage {
+		source.src = "package helpers\n\n" + code
+	}
+	file, err := parser.ParseFile(source.fset, "", source.src, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+	source.file = file
+	return source
+}
+
+func (s *goSource) offset(pos token.Pos) int {
+	return s.fset.Position(pos).Offset
+}
+
+// text returns the source of decl with its doc comment
+func (s *goSource) text(decl ast.Decl) string {
+	start := decl.Pos()
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Doc != nil {
+			start = decl.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if decl.Doc != nil {
+			start = decl.Doc.Pos()
+		}
+	}
+	return s.src[s.offset(start):s.offset(decl.End())]
+}
+
+// declared returns the names declared at package level by the source
+func (s *goSource) declared() map[string]bool {
+	declared := make(map[string]bool)
+	for _, decl := range s.file.Decls {
+		for _, name := range declNames(decl) {
+			declared[name] = true
+		}
+	}
+	return declared
+}
+
+// declNames returns the package-level names decl declares, methods as
+// Type.Method
+func declNames(decl ast.Decl) []string {
+	var names []string
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		name := decl.Name.Name
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			name = receiverType(decl.Recv.List[0].Type) + "." + name
+		}
+		if name != "init" && name != "_" {
+			names = append(names, name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					if name.Name != "_" {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func receiverType(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(expr.X)
+	case *ast.IndexExpr:
+		return receiverType(expr.X)
+	case *ast.IndexListExpr:
+		return receiverType(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// addImportSpecs returns the source of s with imports added to its first
+// parenthesized import declaration, or a new one after the package clause
+func addImportSpecs(s *goSource, imports []string) string {
+	if len(imports) == 0 {
+		return s.src
+	}
+	specs := "\n\t" + strings.Join(imports, "\n\t")
+	for _, decl := range s.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+			at := s.offset(gen.Lparen) + 1
+			return s.src[:at] + specs + s.src[at:]
+		}
+	}
+	at := s.offset(s.file.Name.End())
+	return s.src[:at] + "\n\nimport (" + specs + "\n)" + s.src[at:]
+}
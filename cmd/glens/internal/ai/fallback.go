@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A model whose last fallbackThreshold generations failed, or that was rate
+// limited, is skipped in favour of its fallbacks for fallbackCooldown (or
+// the rate limit's Retry-After) before it is tried again
+const (
+	fallbackThreshold = 3
+	fallbackCooldown  = 5 * time.Minute
+)
+
+// modelHealth tracks the recent errors of a model
+type modelHealth struct {
+	failures  int
+	downUntil time.Time
+}
+
+// withFallbacks returns models followed by their fallbacks, each once
+func withFallbacks(models []string, fallbacks map[string][]string) []string {
+	all := slices.Clone(models)
+	for _, model := range models {
+		for _, fallback := range fallbacks[model] {
+			if !slices.Contains(all, fallback) {
+				all = append(all, fallback)
+			}
+		}
+	}
+	return all
+}
+
+// available reports whether the model is in rotation
+func (m *Manager) available(model string) bool {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	h, ok := m.health[model]
+	return !ok || time.Now().After(h.downUntil)
+}
+
+// record updates the health of model after a generation that returned err
+func (m *Manager) record(model string, err error) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	if m.health == nil {
+		m.health = make(map[string]*modelHealth)
+	}
+	h, ok := m.health[model]
+	if !ok {
+		h = &modelHealth{}
+		m.health[model] = h
+	}
+	if err == nil {
+		h.failures = 0
+		return
+	}
+
+	h.failures++
+	var limited ErrRateLimited
+	switch {
+	case errors.As(err, &limited):
+		h.downUntil = time.Now().Add(retryAfter(limited.RetryAfter))
+	case h.failures >= fallbackThreshold:
+		h.downUntil = time.Now().Add(fallbackCooldown)
+	}
+}
+
+// fallbackReason describes the errors of the models a fallback stands in
+// for on one line
+func fallbackReason(errs []error) string {
+	reasons := make([]string, len(errs))
+	for i, err := range errs {
+		reasons[i] = strings.ReplaceAll(err.Error(), "\n", " ")
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// retryAfter parses a Retry-After header given in seconds, defaulting to
+// fallbackCooldown
+func retryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallbackCooldown
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func TestWithFallbacks(t *testing.T) {
+	fallbacks := map[string][]string{
+		"gpt-4o": {"gpt-4o-mini", "enhanced-mock"},
+		"claude": {"enhanced-mock"},
+	}
+	assert.Equal(t, []string{"gpt-4o", "claude", "gpt-4o-mini", "enhanced-mock"},
+		withFallbacks([]string{"gpt-4o", "claude"}, fallbacks))
+	assert.Equal(t, []string{"mock"}, withFallbacks([]string{"mock"}, fallbacks))
+}
+
+// fallbackManager returns a manager whose gpt-4o is served by handler and
+// falls back to a mock, and a count of the requests gpt-4o received
+func fallbackManager(t *testing.T, handler http.HandlerFunc) (*Manager, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewOpenAIClient(OpenAIConfig{APIKey: "key", BaseURL: srv.URL}, WithModel("gpt-4o"))
+	require.NoError(t, err)
+	return &Manager{
+		clients:   map[string]Client{"gpt-4o": client, "mock": NewMockClient("mock")},
+		fallbacks: map[string][]string{"gpt-4o": {"mock"}},
+	}, &requests
+}
+
+func TestManager_Generate_RateLimitFallsBack(t *testing.T) {
+	manager, requests := fallbackManager(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	endpoint := &parser.Endpoint{Method: "GET", Path: "/pets"}
+
+	result, err := manager.Generate(context.Background(), "gpt-4o", endpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", result.Metadata["generated_by"])
+	assert.Equal(t, "gpt-4o", result.Metadata["fallback_from"])
+	assert.Equal(t, "test generation failed for model 'OpenAI GPT-4': rate limited for model 'gpt-4o', retry after: 60", result.Metadata["fallback_reason"])
+
+	// Until Retry-After has passed the rate limited model is not asked again
+	result, err = manager.Generate(context.Background(), "gpt-4o", endpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", result.Metadata["generated_by"])
+	assert.Equal(t, "gpt-4o: skipped after recent errors", result.Metadata["fallback_reason"])
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestManager_Generate_RepeatedErrorsTakeModelOutOfRotation(t *testing.T) {
+	manager, requests := fallbackManager(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	endpoint := &parser.Endpoint{Method: "GET", Path: "/pets"}
+
+	for range fallbackThreshold + 2 {
+		result, err := manager.Generate(context.Background(), "gpt-4o", endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, "mock", result.Metadata["generated_by"])
+	}
+	assert.Equal(t, int32(fallbackThreshold), requests.Load())
+}
+
+func TestManager_Generate_WithoutFallbacks(t *testing.T) {
+	manager, _ := fallbackManager(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	manager.fallbacks = nil
+
+	_, err := manager.Generate(context.Background(), "gpt-4o", &parser.Endpoint{Method: "GET", Path: "/pets"})
+	assert.ErrorAs(t, err, &ErrRateLimited{})
+
+	result, err := manager.Generate(context.Background(), "mock", &parser.Endpoint{Method: "GET", Path: "/pets"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Metadata["generated_by"])
+}
@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+	gcpMetadataToken   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// googleCredentials is a credentials file of a service account or of a
+// gcloud user (gcloud auth application-default login)
+type googleCredentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// googleTokenSource returns the Application Default Credentials for Google
+// Cloud, looked up in order: GOOGLE_OAUTH_ACCESS_TOKEN, the credentials
+// file, GOOGLE_APPLICATION_CREDENTIALS, gcloud's default credentials and
+// finally the metadata server of GCE, GKE and Cloud Run
+func googleTokenSource(credentialsFile string) (oauth2.TokenSource, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+	}
+
+	file := orDefault(credentialsFile, os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+	if file == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			gcloud := filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+			if _, err := os.Stat(gcloud); err == nil {
+				file = gcloud
+			}
+		}
+	}
+	if file == "" {
+		return metadataTokenSource{client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var creds googleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse Google credentials %s: %w", file, err)
+	}
+
+	ctx := context.Background()
+	switch creds.Type {
+	case "service_account":
+		cfg := &jwt.Config{
+			Email:        creds.ClientEmail,
+			PrivateKey:   []byte(creds.PrivateKey),
+			PrivateKeyID: creds.PrivateKeyID,
+			Scopes:       []string{cloudPlatformScope},
+			TokenURL:     orDefault(creds.TokenURI, googleTokenURL),
+		}
+		return cfg.TokenSource(ctx), nil
+	case "authorized_user":
+		cfg := &oauth2.Config{
+			ClientID:     creds.ClientID,
+			ClientSecret: creds.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: orDefault(creds.TokenURI, googleTokenURL)},
+			Scopes:       []string{cloudPlatformScope},
+		}
+		return cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: creds.RefreshToken}), nil
+	default:
+		return nil, fmt.Errorf("unsupported Google credentials type %q in %s", creds.Type, file)
+	}
+}
+
+// metadataTokenSource fetches access tokens of the default service account
+// from the metadata server
+type metadataTokenSource struct {
+	client *http.Client
+}
+
+// Token implements oauth2.TokenSource
+func (s metadataTokenSource) Token() (*oauth2.Token, error) {
+	req, err := http.NewRequest(http.MethodGet, gcpMetadataToken, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("no Google credentials: set GOOGLE_APPLICATION_CREDENTIALS or run gcloud auth application-default login (metadata server: %w)", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("metadata server returned no access token")
+	}
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		Expiry:      time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
-	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
 
 	"glens/tools/glens/internal/parser"
 )
@@ -20,19 +23,34 @@ type GoogleClient struct {
 	apiKey    string
 	baseURL   string
 	model     string
-	maxTokens int
+	sampling  Sampling
 	client    *http.Client
 	projectID string
+	// tokens authenticates Vertex AI requests; nil sends apiKey
+	tokens oauth2.TokenSource
+	safety []GoogleSafetySetting
+	promptEnvironment
+	promptTemplates
 }
 
 // GoogleRequest represents the request structure for Google Gemini API
 type GoogleRequest struct {
 	Contents         []GoogleContent        `json:"contents"`
 	GenerationConfig GoogleGenerationConfig `json:"generationConfig"`
+	SafetySettings   []GoogleSafetySetting  `json:"safetySettings,omitempty"`
 }
 
-// GoogleContent represents content in Google format
+// GoogleSafetySetting sets the threshold at which a harm category blocks
+// an answer
+type GoogleSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// GoogleContent represents content in Google format; Vertex AI requires
+// the role of request contents
 type GoogleContent struct {
+	Role  string       `json:"role,omitempty"`
 	Parts []GooglePart `json:"parts"`
 }
 
@@ -43,10 +61,13 @@ type GooglePart struct {
 
 // GoogleGenerationConfig represents generation configuration
 type GoogleGenerationConfig struct {
-	Temperature     float64 `json:"temperature"`
-	TopP            float64 `json:"topP"`
-	TopK            int     `json:"topK"`
-	MaxOutputTokens int     `json:"maxOutputTokens"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	TopK            int      `json:"topK"`
+	Seed            *int     `json:"seed,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens"`
+	// ResponseMimeType "application/json" enables JSON mode
+	ResponseMimeType string `json:"responseMimeType,omitempty"`
 }
 
 // GoogleResponse represents the response from Google Gemini API
@@ -69,35 +90,108 @@ type GoogleUsageMetadata struct {
 	TotalTokenCount      int `json:"totalTokenCount"`
 }
 
-// NewGoogleClient creates a new Google Gemini client
-func NewGoogleClient() (*GoogleClient, error) {
-	apiKey := os.Getenv("GOOGLE_API_KEY")
-	if apiKey == "" {
+// NewGoogleClient creates a new Google Gemini client from cfg; opts override
+// the configured model, base URL and timeout. With cfg.Vertex set it uses
+// Vertex AI in cfg.ProjectID with Application Default Credentials.
+func NewGoogleClient(cfg GoogleConfig, opts ...Option) (*GoogleClient, error) {
+	baseURL := DefaultGoogleBaseURL
+	var tokens oauth2.TokenSource
+	switch {
+	case cfg.Vertex && cfg.ProjectID == "":
+		return nil, fmt.Errorf("vertex AI requires a Google Cloud project ID")
+	case cfg.Vertex:
+		baseURL = vertexBaseURL(cfg.ProjectID, orDefault(cfg.Location, defaultVertexLocation))
+		var err error
+		if tokens, err = googleTokenSource(cfg.Credentials); err != nil {
+			return nil, err
+		}
+		tokens = oauth2.ReuseTokenSource(nil, tokens)
+	case cfg.APIKey == "":
 		return nil, ErrAPIKeyMissing{Model: "Google"}
 	}
-
-	projectID := os.Getenv("GOOGLE_PROJECT_ID")
-	if projectID == "" {
-		projectID = "default-project" // Use a default if not specified
+	o := resolve(opts,
+		orDefault(cfg.BaseURL, baseURL),
+		orDefault(cfg.Model, "gemini-2.0-flash"),
+		orDefault(cfg.Timeout, defaultCloudTimeout))
+	o.tls = cfg.TLS
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
 	}
 
 	return &GoogleClient{
-		apiKey:    apiKey,
-		baseURL:   "https://generativelanguage.googleapis.com/v1beta",
-		model:     "gemini-1.5-flash",
-		maxTokens: 4000,
-		projectID: projectID,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		apiKey:          cfg.APIKey,
+		baseURL:         o.baseURL,
+		model:           o.model,
+		sampling:        googleSampling(cfg.Sampling),
+		projectID:       orDefault(cfg.ProjectID, "default-project"),
+		tokens:          tokens,
+		safety:          safetySettings(cfg.SafetySettings),
+		client:          client,
+		promptTemplates: promptTemplates{structured: structuredOutput(cfg.ResponseFormat)},
 	}, nil
 }
 
+// GoogleSafetyThresholds are the block thresholds of safety settings
+var GoogleSafetyThresholds = []string{
+	"BLOCK_NONE", "BLOCK_ONLY_HIGH", "BLOCK_MEDIUM_AND_ABOVE", "BLOCK_LOW_AND_ABOVE", "OFF",
+}
+
+// vertexBaseURL returns the Vertex AI endpoint of Google's models in a
+// project and location
+func vertexBaseURL(project, location string) string {
+	host := location + "-aiplatform.googleapis.com"
+	if location == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/google", host, project, location)
+}
+
+// safetySettings returns the configured thresholds sorted by category.
+// Categories are upper-cased because config keys are read in lower case.
+func safetySettings(thresholds map[string]string) []GoogleSafetySetting {
+	settings := make([]GoogleSafetySetting, 0, len(thresholds))
+	for category, threshold := range thresholds {
+		settings = append(settings, GoogleSafetySetting{
+			Category:  strings.ToUpper(category),
+			Threshold: strings.ToUpper(threshold),
+		})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Category < settings[j].Category })
+	return settings
+}
+
+// googleSampling applies the Gemini defaults, including a top_p of 0.8
+func googleSampling(s Sampling) Sampling {
+	s = s.withDefaults(defaultCloudTemperature)
+	if s.TopP == nil {
+		topP := 0.8
+		s.TopP = &topP
+	}
+	return s
+}
+
 // GenerateTest generates integration test code using Google Gemini
 func (c *GoogleClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
-	startTime := time.Now()
+	prompt, err := c.buildPrompt(endpoint)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+	return c.complete(ctx, endpoint, prompt)
+}
+
+// RepairTest asks Google Gemini to fix testCode given the failure it produced
+func (c *GoogleClient) RepairTest(ctx context.Context, endpoint *parser.Endpoint, testCode, failure string) (*TestGenerationResult, error) {
+	prompt, err := c.renderRepairPrompt(endpoint, c.environmentPrompt(), testCode, failure)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+	return c.complete(ctx, endpoint, prompt)
+}
 
-	prompt := c.buildPrompt(endpoint)
+// complete sends prompt to Google Gemini and returns the test it answers with
+func (c *GoogleClient) complete(ctx context.Context, endpoint *parser.Endpoint, prompt string) (*TestGenerationResult, error) {
+	startTime := time.Now()
 
 	log.Debug().
 		Str("model", c.model).
@@ -107,6 +201,7 @@ func (c *GoogleClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 	request := GoogleRequest{
 		Contents: []GoogleContent{
 			{
+				Role: "user",
 				Parts: []GooglePart{
 					{
 						Text: prompt,
@@ -115,11 +210,16 @@ func (c *GoogleClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 			},
 		},
 		GenerationConfig: GoogleGenerationConfig{
-			Temperature:     0.7,
-			TopP:            0.8,
+			Temperature:     c.sampling.Temperature,
+			TopP:            c.sampling.TopP,
 			TopK:            40,
-			MaxOutputTokens: c.maxTokens,
+			Seed:            c.sampling.Seed,
+			MaxOutputTokens: c.sampling.MaxTokens,
 		},
+		SafetySettings: c.safety,
+	}
+	if c.structured {
+		request.GenerationConfig.ResponseMimeType = "application/json"
 	}
 
 	response, err := c.makeRequest(ctx, request)
@@ -127,21 +227,24 @@ func (c *GoogleClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 		return nil, ErrGenerationFailed{
 			Model:  c.GetModelName(),
 			Reason: err.Error(),
+			Err:    err,
 		}
 	}
 
 	if len(response.Candidates) == 0 || len(response.Candidates[0].Content.Parts) == 0 {
+		reason := "no content in response candidates"
+		if len(response.Candidates) > 0 && response.Candidates[0].FinishReason == "SAFETY" {
+			reason = "answer blocked by safety settings (finish reason SAFETY)"
+		}
 		return nil, ErrGenerationFailed{
 			Model:  c.GetModelName(),
-			Reason: "no content in response candidates",
+			Reason: reason,
 		}
 	}
 
-	testCode := response.Candidates[0].Content.Parts[0].Text
 	generationTime := time.Since(startTime)
 
 	result := &TestGenerationResult{
-		TestCode:       testCode,
 		Prompt:         prompt,
 		ModelUsed:      c.model,
 		Framework:      "testify",
@@ -150,12 +253,14 @@ func (c *GoogleClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 		TokensUsed:     response.UsageMetadata.TotalTokenCount,
 		GenerationTime: generationTime.String(),
 		Metadata: map[string]string{
-			"api_provider":          "google",
+			"api_provider":          c.provider(),
 			"finish_reason":         response.Candidates[0].FinishReason,
 			"prompt_token_count":    fmt.Sprintf("%d", response.UsageMetadata.PromptTokenCount),
 			"candidate_token_count": fmt.Sprintf("%d", response.UsageMetadata.CandidatesTokenCount),
 		},
 	}
+	maps.Copy(result.Metadata, c.sampling.Params())
+	result.applyAnswer(response.Candidates[0].Content.Parts[0].Text)
 
 	log.Info().
 		Str("model", c.model).
@@ -176,94 +281,58 @@ func (c *GoogleClient) GetCapabilities() ModelCapabilities {
 	return ModelCapabilities{
 		SupportsGoTests:      true,
 		SupportsSecurityTest: true,
-		SupportedFrameworks:  []string{"testify", "ginkgo", "standard"},
-		MaxTokens:            c.maxTokens,
+		SupportedFrameworks:  []string{"testify", "ginkgo", "standard", "grpc"},
+		MaxTokens:            c.sampling.MaxTokens,
 		Languages:            []string{"go", "python", "javascript", "java", "cpp", "rust"},
 	}
 }
 
-// buildPrompt creates the detailed prompt for test generation
-func (c *GoogleClient) buildPrompt(endpoint *parser.Endpoint) string {
-	var prompt bytes.Buffer
-
-	prompt.WriteString("As an expert software testing engineer, generate comprehensive integration tests for this OpenAPI endpoint using Go and testify.\n\n")
-
-	prompt.WriteString("**ENDPOINT SPECIFICATION:**\n")
-	fmt.Fprintf(&prompt, "Method: %s\n", endpoint.Method)
-	fmt.Fprintf(&prompt, "Path: %s\n", endpoint.Path)
-
-	if endpoint.OperationID != "" {
-		fmt.Fprintf(&prompt, "Operation ID: %s\n", endpoint.OperationID)
+// HealthCheck verifies that the API accepts the key and serves the model.
+// The key is sent in a header so it never appears in errors. On Vertex AI
+// it verifies that the credentials yield an access token.
+func (c *GoogleClient) HealthCheck(ctx context.Context) error {
+	if c.tokens != nil {
+		if _, err := c.tokens.Token(); err != nil {
+			return fmt.Errorf("google credentials rejected: %w", err)
+		}
+		return nil
 	}
-
-	if endpoint.Summary != "" {
-		fmt.Fprintf(&prompt, "Summary: %s\n", endpoint.Summary)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models/"+c.model, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
-
-	if endpoint.Description != "" {
-		fmt.Fprintf(&prompt, "Description: %s\n", endpoint.Description)
+	if err := c.authorize(req); err != nil {
+		return err
 	}
+	return checkModel(c.client, req, c.model)
+}
 
-	// Parameters
-	if len(endpoint.Parameters) > 0 {
-		prompt.WriteString("\n**PARAMETERS:**\n")
-		for i := range endpoint.Parameters {
-			param := &endpoint.Parameters[i]
-			required := "Optional"
-			if param.Required {
-				required = "Required"
-			}
-			fmt.Fprintf(&prompt, "• %s (%s, %s): %s [Type: %s]\n",
-				param.Name, param.In, required, param.Description, param.Schema.Type)
-		}
+// authorize authenticates req with an access token on Vertex AI and the
+// API key otherwise
+func (c *GoogleClient) authorize(req *http.Request) error {
+	if c.tokens == nil {
+		req.Header.Set("x-goog-api-key", c.apiKey)
+		return nil
 	}
-
-	// Request Body
-	if endpoint.RequestBody != nil {
-		prompt.WriteString("\n**REQUEST BODY:**\n")
-		if endpoint.RequestBody.Description != "" {
-			fmt.Fprintf(&prompt, "Description: %s\n", endpoint.RequestBody.Description)
-		}
-		prompt.WriteString("Supported Content Types:\n")
-		for contentType := range endpoint.RequestBody.Content {
-			mediaType := endpoint.RequestBody.Content[contentType]
-			fmt.Fprintf(&prompt, "• %s: %s\n", contentType, mediaType.Schema.Type)
-		}
+	token, err := c.tokens.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get Google access token: %w", err)
 	}
+	token.SetAuthHeader(req)
+	return nil
+}
 
-	// Responses
-	if len(endpoint.Responses) > 0 {
-		prompt.WriteString("\n**EXPECTED RESPONSES:**\n")
-		for code, response := range endpoint.Responses {
-			fmt.Fprintf(&prompt, "• HTTP %s: %s\n", code, response.Description)
-		}
+// provider names the API the client uses
+func (c *GoogleClient) provider() string {
+	if c.tokens != nil {
+		return "vertex-ai"
 	}
+	return "google"
+}
 
-	prompt.WriteString("\n**REQUIREMENTS:**\n")
-	prompt.WriteString("Generate Go integration tests that include:\n\n")
-	prompt.WriteString("1. **Package and Imports**: Proper Go package declaration with necessary imports\n")
-	prompt.WriteString("2. **Test Structure**: Use testify/assert and testify/suite if needed\n")
-	prompt.WriteString("3. **Happy Path Tests**: Valid requests with expected successful responses\n")
-	prompt.WriteString("4. **Error Handling**: Invalid inputs, missing required fields, wrong types\n")
-	prompt.WriteString("5. **Boundary Testing**: Edge cases, limits, empty values, max values\n")
-	prompt.WriteString("6. **Security Tests**: Authentication validation, authorization checks\n")
-	prompt.WriteString("7. **Schema Validation**: Response structure and data type validation\n")
-	prompt.WriteString("8. **HTTP Method Specific**: Appropriate tests for the HTTP method\n")
-	prompt.WriteString("9. **Parameter Testing**: All parameter types (path, query, header)\n")
-	prompt.WriteString("10. **Performance Checks**: Response time assertions where relevant\n\n")
-
-	prompt.WriteString("**CODE STANDARDS:**\n")
-	prompt.WriteString("• Use descriptive test names (TestEndpoint_Scenario_ExpectedResult)\n")
-	prompt.WriteString("• Include setup and teardown functions if needed\n")
-	prompt.WriteString("• Add comments explaining complex test scenarios\n")
-	prompt.WriteString("• Use table-driven tests for multiple scenarios\n")
-	prompt.WriteString("• Generate realistic test data\n")
-	prompt.WriteString("• Include proper error checking and assertions\n")
-	prompt.WriteString("• Make tests independent and idempotent\n\n")
-
-	prompt.WriteString("Generate complete, executable Go test code that can be run immediately without modifications.")
-
-	return prompt.String()
+// buildPrompt renders the google prompt template for endpoint
+func (c *GoogleClient) buildPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("google", endpoint, c.environmentPrompt())
 }
 
 // makeRequest makes an HTTP request to Google Gemini API
@@ -273,7 +342,7 @@ func (c *GoogleClient) makeRequest(ctx context.Context, request GoogleRequest) (
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", c.baseURL, c.model, c.apiKey)
+	url := fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, c.model)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
@@ -281,6 +350,9 @@ func (c *GoogleClient) makeRequest(ctx context.Context, request GoogleRequest) (
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -292,15 +364,14 @@ func (c *GoogleClient) makeRequest(ctx context.Context, request GoogleRequest) (
 		}
 	}()
 
+	if err := responseError(c.model, resp); err != nil {
+		return nil, err
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
 	var response GoogleResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -308,27 +379,3 @@ func (c *GoogleClient) makeRequest(ctx context.Context, request GoogleRequest) (
 
 	return &response, nil
 }
-
-// NewGoogleClientWithModel creates a new Google client with a specific model
-func NewGoogleClientWithModel(modelName string) (*GoogleClient, error) {
-	apiKey := os.Getenv("GOOGLE_API_KEY")
-	if apiKey == "" {
-		return nil, ErrAPIKeyMissing{Model: "Google"}
-	}
-
-	projectID := os.Getenv("GOOGLE_PROJECT_ID")
-	if projectID == "" {
-		projectID = "default-project" // Use a default if not specified
-	}
-
-	return &GoogleClient{
-		apiKey:    apiKey,
-		baseURL:   "https://generativelanguage.googleapis.com/v1beta",
-		model:     modelName,
-		maxTokens: 4000,
-		projectID: projectID,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-	}, nil
-}
@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeServiceAccount writes a service account credentials file whose
+// tokens are issued by tokenURL
+func writeServiceAccount(t *testing.T, tokenURL string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	creds, err := json.Marshal(googleCredentials{
+		Type:        "service_account",
+		ClientEmail: "glens@project.iam.gserviceaccount.com",
+		PrivateKey:  string(pemKey),
+		TokenURI:    tokenURL,
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "sa.json")
+	require.NoError(t, os.WriteFile(path, creds, 0o600))
+	return path
+}
+
+func TestGoogleClient_Vertex(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	var (
+		request GoogleRequest
+		path    string
+		auth    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"sa-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		assert.Empty(t, r.URL.RawQuery, "no API key on Vertex AI")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_ = json.NewEncoder(w).Encode(GoogleResponse{Candidates: []GoogleCandidate{{
+			Content: GoogleContent{Parts: []GooglePart{{Text: sampleTest}}},
+		}}})
+	}))
+	defer server.Close()
+
+	c, err := NewGoogleClient(GoogleConfig{
+		Vertex:         true,
+		ProjectID:      "my-project",
+		Location:       "europe-west4",
+		Credentials:    writeServiceAccount(t, server.URL+"/token"),
+		ResponseFormat: ResponseFormatText,
+		SafetySettings: map[string]string{
+			"harm_category_harassment":        "block_only_high",
+			"harm_category_dangerous_content": "BLOCK_NONE",
+		},
+	}, WithModel("gemini-2.5-flash"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://europe-west4-aiplatform.googleapis.com/v1/projects/my-project/locations/europe-west4/publishers/google", c.baseURL)
+	require.NoError(t, c.HealthCheck(context.Background()))
+
+	c.baseURL = server.URL + "/v1/projects/my-project/locations/europe-west4/publishers/google"
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/v1/projects/my-project/locations/europe-west4/publishers/google/models/gemini-2.5-flash:generateContent", path)
+	assert.Equal(t, "Bearer sa-token", auth)
+	assert.Equal(t, "user", request.Contents[0].Role)
+	assert.Equal(t, []GoogleSafetySetting{
+		{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Threshold: "BLOCK_NONE"},
+		{Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"},
+	}, request.SafetySettings)
+	assert.Equal(t, sampleTest, result.TestCode)
+	assert.Equal(t, "vertex-ai", result.Metadata["api_provider"])
+}
+
+func TestNewGoogleClient_Auth(t *testing.T) {
+	_, err := NewGoogleClient(GoogleConfig{})
+	assert.ErrorAs(t, err, &ErrAPIKeyMissing{})
+
+	_, err = NewGoogleClient(GoogleConfig{Vertex: true})
+	assert.EqualError(t, err, "vertex AI requires a Google Cloud project ID")
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	_, err = NewGoogleClient(GoogleConfig{Vertex: true, ProjectID: "p", Credentials: filepath.Join(t.TempDir(), "missing.json")})
+	assert.ErrorContains(t, err, "failed to read Google credentials")
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "static")
+	c, err := NewGoogleClient(GoogleConfig{Vertex: true, ProjectID: "p", Location: "global"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://aiplatform.googleapis.com/v1/projects/p/locations/global/publishers/google", c.baseURL)
+	token, err := c.tokens.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "static", token.AccessToken)
+}
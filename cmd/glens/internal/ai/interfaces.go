@@ -2,8 +2,20 @@ package ai
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/modelcatalog"
+
+	"glens/tools/glens/internal/environment"
 	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/telemetry"
 )
 
 // Client defines the interface for AI model clients
@@ -18,9 +30,16 @@ type Client interface {
 	GetCapabilities() ModelCapabilities
 }
 
+// Repairer is implemented by clients that can fix a test that failed to
+// compile or run, given the compiler or test output
+type Repairer interface {
+	RepairTest(ctx context.Context, endpoint *parser.Endpoint, testCode, failure string) (*TestGenerationResult, error)
+}
+
 // TestGenerationResult contains the result of test generation
 type TestGenerationResult struct {
 	TestCode       string            `json:"test_code"`
+	Notes          string            `json:"notes,omitempty"`
 	Prompt         string            `json:"prompt"`
 	ModelUsed      string            `json:"model_used"`
 	Framework      string            `json:"framework"`
@@ -29,6 +48,10 @@ type TestGenerationResult struct {
 	TokensUsed     int               `json:"tokens_used,omitempty"`
 	GenerationTime string            `json:"generation_time"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
+	// SystemPrompt is the system message sent along with Prompt, if any
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// RawResponse is the model's answer the test code was extracted from
+	RawResponse string `json:"raw_response,omitempty"`
 }
 
 // ModelCapabilities describes what the AI model can do
@@ -43,38 +66,174 @@ type ModelCapabilities struct {
 // Manager manages multiple AI model clients
 type Manager struct {
 	clients map[string]Client
+	// fallbacks and health implement fallback chains, see Generate
+	fallbacks map[string][]string
+	healthMu  sync.Mutex
+	health    map[string]*modelHealth
+	// policy strips sensitive spec content from prompts, see SetPromptPolicy
+	policy *PromptPolicy
+	// limiters enforce the Limits of each provider, keyed by provider
+	limiters map[string]*limiter
+	// router assigns endpoints to models, see SetRouter
+	router *Router
 }
 
-// NewManager creates a new AI manager with specified models
-func NewManager(modelNames []string) (*Manager, error) {
+// NewManager creates a new AI manager with specified models, building each
+// client from the matching provider settings in cfg. The errors of every
+// model that cannot be created are joined.
+func NewManager(modelNames []string, cfg Config) (*Manager, error) {
 	manager := &Manager{
 		clients: make(map[string]Client),
 	}
+	cfg = cfg.withGeneration()
 
-	for _, modelName := range modelNames {
-		client, err := createClient(modelName)
+	// Every model, including fallbacks, is created so all configuration
+	// errors surface at once
+	var errs []error
+	for _, modelName := range withFallbacks(modelNames, cfg.Fallbacks) {
+		client, err := createClient(modelName, cfg)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 		manager.clients[modelName] = client
+		if reason := deprecation(modelName); reason != "" {
+			log.Warn().Str("ai_model", modelName).Str("reason", reason).Msg("AI model is deprecated")
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	manager.fallbacks = cfg.Fallbacks
+	manager.limiters = make(map[string]*limiter)
+	for _, client := range manager.clients {
+		provider := providerOf(client)
+		if _, ok := manager.limiters[provider]; ok {
+			continue
+		}
+		limits, ok := cfg.Limits[provider]
+		if !ok {
+			limits = DefaultLimits[provider]
+		}
+		manager.limiters[provider] = newLimiter(provider, limits)
 	}
+	manager.SetPrompts(DefaultPrompts)
 
 	return manager, nil
 }
 
 // GenerateTest generates a test using the specified AI model
 func (m *Manager) GenerateTest(ctx context.Context, modelName string, endpoint *parser.Endpoint) (testCode, modelUsed string, err error) {
+	result, err := m.Generate(ctx, modelName, endpoint)
+	if err != nil {
+		return "", "", err
+	}
+	return result.TestCode, result.Prompt, nil
+}
+
+// Generate generates a test using the specified AI model and returns the
+// full result, including the generation parameters in its metadata. When
+// the model errors, or has been taken out of rotation after repeated errors
+// or a rate limit, its fallbacks are tried in order; the metadata of a test
+// from a fallback records the model that generated it (generated_by), the
+// model it stands in for (fallback_from) and why (fallback_reason).
+func (m *Manager) Generate(ctx context.Context, modelName string, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
+	chain := append([]string{modelName}, m.fallbacks[modelName]...)
+	var errs []error
+	for i, name := range chain {
+		// The last model of the chain is always tried
+		if i < len(chain)-1 && !m.available(name) {
+			errs = append(errs, fmt.Errorf("%s: skipped after recent errors", name))
+			continue
+		}
+
+		result, err := m.generate(ctx, name, endpoint)
+		m.record(name, err)
+		if err == nil {
+			if name != modelName {
+				if result.Metadata == nil {
+					result.Metadata = make(map[string]string)
+				}
+				result.Metadata["generated_by"] = name
+				result.Metadata["fallback_from"] = modelName
+				result.Metadata["fallback_reason"] = fallbackReason(errs)
+				log.Warn().
+					Str("ai_model", modelName).
+					Str("fallback", name).
+					Str("endpoint", endpoint.Method+" "+endpoint.Path).
+					Msg("Test generated by fallback model")
+			}
+			return result, nil
+		}
+		if len(chain) == 1 || ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// generate generates a test with the named model only
+func (m *Manager) generate(ctx context.Context, modelName string, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
 	client, exists := m.clients[modelName]
 	if !exists {
-		return "", "", ErrModelNotFound{Model: modelName}
+		return nil, ErrModelNotFound{Model: modelName}
 	}
 
-	result, err := client.GenerateTest(ctx, endpoint)
+	provider := providerOf(client)
+	release, err := m.limiters[provider].acquire(ctx)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
+	start := time.Now()
+	result, err := client.GenerateTest(ctx, m.applyPolicy(client, modelName, endpoint))
+	release(tokensOf(result))
+	if err != nil {
+		telemetry.AIGenerationDuration.ObserveDuration(start, provider, modelName, "error")
+		return nil, err
+	}
+	telemetry.AIGenerationDuration.ObserveDuration(start, provider, modelName, "success")
+	telemetry.AITokens.Add(float64(result.TokensUsed), provider, modelName)
 
-	return result.TestCode, result.Prompt, nil
+	return result, nil
+}
+
+// Repair asks the model that generated testCode to fix it given failure,
+// the compiler or test output it produced
+func (m *Manager) Repair(ctx context.Context, modelName string, endpoint *parser.Endpoint, testCode, failure string) (*TestGenerationResult, error) {
+	client, exists := m.clients[modelName]
+	if !exists {
+		return nil, ErrModelNotFound{Model: modelName}
+	}
+	repairer, ok := client.(Repairer)
+	if !ok {
+		return nil, ErrRepairUnsupported{Model: modelName}
+	}
+
+	provider := providerOf(client)
+	release, err := m.limiters[provider].acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := repairer.RepairTest(ctx, m.applyPolicy(client, modelName, endpoint), testCode, failure)
+	release(tokensOf(result))
+	if err != nil {
+		telemetry.AIGenerationDuration.ObserveDuration(start, provider, modelName, "error")
+		return nil, err
+	}
+	telemetry.AIGenerationDuration.ObserveDuration(start, provider, modelName, "success")
+	telemetry.AITokens.Add(float64(result.TokensUsed), provider, modelName)
+
+	return result, nil
+}
+
+// tokensOf returns the tokens used by a call, 0 when it failed
+func tokensOf(result *TestGenerationResult) int {
+	if result == nil {
+		return 0
+	}
+	return result.TokensUsed
 }
 
 // GetAvailableModels returns the names of all available AI models
@@ -86,6 +245,92 @@ func (m *Manager) GetAvailableModels() []string {
 	return models
 }
 
+// SetEnvironment describes the target environment in the prompts of every
+// client that supports it
+func (m *Manager) SetEnvironment(env *environment.Environment) {
+	for _, client := range m.clients {
+		if aware, ok := client.(EnvironmentAware); ok {
+			aware.SetEnvironment(env)
+		}
+	}
+}
+
+// SetPrompts renders the prompts of every client that supports templates
+// from prompts; per-model templates are named after the manager's model names
+func (m *Manager) SetPrompts(prompts *Prompts) {
+	for name, client := range m.clients {
+		if aware, ok := client.(PromptAware); ok {
+			aware.SetPrompts(prompts, name)
+		}
+	}
+}
+
+// ModelInfo describes a model configured in the manager
+type ModelInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	// ContextWindow is the tokens the model reads, 0 when unknown;
+	// MaxOutputTokens those glens lets it write
+	ContextWindow     int  `json:"context_window,omitempty"`
+	MaxOutputTokens   int  `json:"max_output_tokens,omitempty"`
+	SupportsStreaming bool `json:"supports_streaming"`
+	// Local models keep prompts on this machine, see RemoteModels
+	Local bool `json:"local"`
+	// Deprecated says why the catalog deprecates the model
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// Models describes the configured models, sorted by ID
+func (m *Manager) Models() []ModelInfo {
+	models := make([]ModelInfo, 0, len(m.clients))
+	for id, client := range m.clients {
+		provider := providerOf(client)
+		models = append(models, ModelInfo{
+			ID:                id,
+			Name:              client.GetModelName(),
+			Provider:          provider,
+			ContextWindow:     modelcatalog.Default().ContextWindow(modelOf(client)),
+			MaxOutputTokens:   client.GetCapabilities().MaxTokens,
+			SupportsStreaming: supportsStreaming(provider),
+			Local:             isLocal(client),
+			Deprecated:        deprecation(id),
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models
+}
+
+// OllamaClients returns the manager's Ollama clients by model name
+func (m *Manager) OllamaClients() map[string]*OllamaClient {
+	clients := make(map[string]*OllamaClient)
+	for name, client := range m.clients {
+		if ollama, ok := client.(*OllamaClient); ok {
+			clients[name] = ollama
+		}
+	}
+	return clients
+}
+
+// providerOf returns the provider name of a client
+func providerOf(client Client) string {
+	switch c := client.(type) {
+	case *OpenAIClient:
+		if strings.Contains(c.baseURL, "mistral") {
+			return "mistral"
+		}
+		return "openai"
+	case *AnthropicClient:
+		return "anthropic"
+	case *GoogleClient:
+		return "google"
+	case *OllamaClient:
+		return "ollama"
+	default:
+		return "mock"
+	}
+}
+
 // GetModelCapabilities returns capabilities for a specific model
 func (m *Manager) GetModelCapabilities(modelName string) (ModelCapabilities, error) {
 	client, exists := m.clients[modelName]
@@ -96,146 +341,51 @@ func (m *Manager) GetModelCapabilities(modelName string) (ModelCapabilities, err
 	return client.GetCapabilities(), nil
 }
 
-// createClient creates an AI client based on model name
-func createClient(modelName string) (Client, error) {
-	switch modelName {
-	case "mock":
-		return NewMockClient("mock"), nil
-	case "enhanced-mock", "mock-enhanced":
-		return NewEnhancedMockClient("enhanced-mock"), nil
-
-	// --- OpenAI ---
-	case "gpt4", "openai", "gpt-4-turbo":
-		return NewOpenAIClient()
-	case "gpt-4o", "gpt4o":
-		return NewOpenAIClientWithModel("gpt-4o")
-	case "gpt-4o-mini", "gpt4o-mini":
-		return NewOpenAIClientWithModel("gpt-4o-mini")
-	// OpenAI GPT-4.1 family (2025)
-	case "gpt-4.1":
-		return NewOpenAIClientWithModel("gpt-4.1")
-	case "gpt-4.1-mini":
-		return NewOpenAIClientWithModel("gpt-4.1-mini")
-	case "gpt-4.1-nano":
-		return NewOpenAIClientWithModel("gpt-4.1-nano")
-	// OpenAI reasoning models (o-series)
-	case "o3", "openai-o3":
-		return NewOpenAIClientWithModel("o3")
-	case "o3-mini", "openai-o3-mini":
-		return NewOpenAIClientWithModel("o3-mini")
-	case "o4-mini", "openai-o4-mini":
-		return NewOpenAIClientWithModel("o4-mini")
-	// OpenAI Codex (code-focused)
-	case "codex", "codex-mini":
-		return NewOpenAIClientWithModel("codex-mini-latest")
-
-	// --- Anthropic ---
-	case "sonnet4", "anthropic", "claude-3-sonnet":
-		return NewAnthropicClient()
-	case "claude-3.5-sonnet", "claude-3-5-sonnet":
-		return NewAnthropicClientWithModel("claude-3-5-sonnet-20241022")
-	// Claude 3.7 / 4.x family (2025)
-	case "claude-3.7-sonnet", "claude-3-7-sonnet":
-		return NewAnthropicClientWithModel("claude-3-7-sonnet-20250219")
-	case "claude-sonnet-4", "claude-sonnet-4-5":
-		return NewAnthropicClientWithModel("claude-sonnet-4-5")
-	case "claude-opus-4", "claude-4-opus", "claude-opus-4-5":
-		return NewAnthropicClientWithModel("claude-opus-4-5")
-	case "claude-haiku-4", "claude-haiku-4-5":
-		return NewAnthropicClientWithModel("claude-haiku-4-5")
-
-	// --- Google ---
-	case "flash-pro", "google", "gemini-1.5-flash":
-		return NewGoogleClient()
-	case "gemini-2.0-flash", "gemini-2-flash":
-		return NewGoogleClientWithModel("gemini-2.0-flash")
-	case "gemini-2.0-pro", "gemini-2-pro":
-		return NewGoogleClientWithModel("gemini-2.0-pro")
-	// Gemini 2.5 family (2025)
-	case "gemini-2.5-pro", "gemini-2-5-pro":
-		return NewGoogleClientWithModel("gemini-2.5-pro-preview-03-25")
-	case "gemini-2.5-flash", "gemini-2-5-flash":
-		return NewGoogleClientWithModel("gemini-2.5-flash")
-
-	// --- Mistral (OpenAI-compatible API, requires MISTRAL_API_KEY) ---
-	case "mistral", "mistral-large":
-		return NewMistralClient("mistral-large-latest")
-	case "mistral-medium":
-		return NewMistralClient("mistral-medium-latest")
-	case "mistral-small":
-		return NewMistralClient("mistral-small-latest")
-	case "codestral", "mistral-code":
-		return NewMistralClient("codestral-latest")
-	case "mistral-nemo":
-		return NewMistralClient("open-mistral-nemo")
-
-	// --- Ollama (local / self-hosted) ---
-	case "ollama":
-		return NewOllamaClient("")
-	case "ollama_codellama":
-		return NewOllamaClient("ollama")
-	case "ollama_deepseekcoder", "deepseek-coder":
-		return NewOllamaClient("ollama_deepseekcoder")
-	case "ollama_qwen", "qwen-coder":
-		return NewOllamaClient("ollama_qwen")
-	case "ollama_deepseek-r2", "deepseek-r2":
-		return NewOllamaClient("ollama_deepseek-r2")
-	case "ollama_qwen3", "qwen3":
-		return NewOllamaClient("ollama_qwen3")
-	case "ollama_llama4", "llama4":
-		return NewOllamaClient("ollama_llama4")
-
-	// --- Local open-source models via Ollama (no cloud/API-key dependency) ---
-	// Mistral (local)
-	case "mistral-local", "mistral7b":
-		return newOllamaLocal("mistral")
-	case "mistral-nemo-local":
-		return newOllamaLocal("mistral-nemo")
-	case "mistral-small-local":
-		return newOllamaLocal("mistral-small")
-	// Meta Llama (local)
-	case "llama3-local", "llama3":
-		return newOllamaLocal("llama3")
-	case "llama3.1-local", "llama3.1":
-		return newOllamaLocal("llama3.1")
-	case "llama3.2-local", "llama3.2":
-		return newOllamaLocal("llama3.2")
-	// Microsoft Phi (local)
-	case "phi3-local", "phi3":
-		return newOllamaLocal("phi3")
-	case "phi4-local", "phi4":
-		return newOllamaLocal("phi4")
-	// Google Gemma (local, open-weights)
-	case "gemma2-local", "gemma2":
-		return newOllamaLocal("gemma2")
-	case "gemma3-local", "gemma3":
-		return newOllamaLocal("gemma3")
-
-	default:
-		// Check if it's a custom Ollama model (format: ollama:model-name)
-		if len(modelName) > 7 && modelName[:7] == "ollama:" {
-			// For custom models, use default ollama config but override model name
-			client, err := NewOllamaClient("")
-			if err != nil {
-				return nil, err
-			}
-			// Override the model name - need to modify the client struct
-			return &OllamaClientWithModel{
-				client: client,
-				model:  modelName[7:], // Remove "ollama:" prefix
-			}, nil
+// createClient creates an AI client for a model of the catalog, or a
+// custom Ollama model named ollama:<model>
+func createClient(modelName string, cfg Config) (Client, error) {
+	entry, ok := modelcatalog.Default().Lookup(modelName)
+	if !ok {
+		// Custom Ollama model (format: ollama:model-name) on the default server
+		if model, ok := strings.CutPrefix(modelName, "ollama:"); ok && model != "" {
+			return newOllamaLocal(cfg, model)
 		}
 		return nil, ErrUnsupportedModel{Model: modelName}
 	}
-}
 
-// newOllamaLocal creates an OllamaClient using default server config but with
-// a specific model name, enabling local open-source model usage without any
-// cloud or API-key dependency.
-func newOllamaLocal(ollamaModelName string) (Client, error) {
-	base, err := NewOllamaClient("")
-	if err != nil {
-		return nil, err
+	var opts []Option
+	if !entry.Configured && entry.Model != "" {
+		opts = append(opts, WithModel(entry.Model))
 	}
-	return &OllamaClientWithModel{client: base, model: ollamaModelName}, nil
+	switch entry.Provider {
+	case "openai":
+		return NewOpenAIClient(cfg.OpenAI, opts...)
+	case "anthropic":
+		return NewAnthropicClient(cfg.Anthropic, opts...)
+	case "google":
+		return NewGoogleClient(cfg.Google, opts...)
+	case "mistral":
+		return NewMistralClient(cfg.Mistral, opts...)
+	case "ollama":
+		return NewOllamaClient(cfg.ollama(orDefault(entry.Server, DefaultOllamaConfig)), opts...)
+	case "mock":
+		if entry.Model == "enhanced-mock" {
+			return NewEnhancedMockClient(entry.Model), nil
+		}
+		return NewMockClient(entry.Model), nil
+	}
+	return nil, ErrUnsupportedModel{Model: modelName}
+}
+
+// deprecation returns why the catalog deprecates the model named name
+func deprecation(name string) string {
+	entry, _ := modelcatalog.Default().Lookup(name)
+	return entry.Deprecated
+}
+
+// newOllamaLocal creates an OllamaClient using the default server config but
+// with a specific model name, enabling local open-source model usage without
+// any cloud or API-key dependency.
+func newOllamaLocal(cfg Config, ollamaModelName string) (Client, error) {
+	return NewOllamaClient(cfg.ollama(DefaultOllamaConfig), WithModel(ollamaModelName))
 }
@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Limits bounds the calls of every model of a provider. Zero fields are
+// unlimited.
+type Limits struct {
+	// Concurrency is the number of calls in flight at once
+	Concurrency int `mapstructure:"concurrency"`
+	// RequestsPerMinute is the number of calls started in any minute
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	// TokensPerMinute is the number of tokens used in any minute; a call
+	// waits while the tokens of the last minute reach the limit, since its
+	// own usage is only known once it returns
+	TokensPerMinute int `mapstructure:"tokens_per_minute"`
+}
+
+// DefaultLimits apply to providers without configured limits: a local
+// Ollama server generates one test at a time
+var DefaultLimits = map[string]Limits{
+	"ollama": {Concurrency: 1},
+}
+
+// LimitedProviders are the providers Config.Limits may name
+var LimitedProviders = []string{"openai", "anthropic", "google", "mistral", "ollama"}
+
+// ValidateLimits reports limits of unknown providers and negative limits
+func ValidateLimits(limits map[string]Limits) error {
+	var errs []error
+	for _, provider := range slices.Sorted(maps.Keys(limits)) {
+		l := limits[provider]
+		if !slices.Contains(LimitedProviders, provider) {
+			errs = append(errs, fmt.Errorf("provider_limits.%s: unknown provider (known: %v)", provider, LimitedProviders))
+		}
+		if l.Concurrency < 0 || l.RequestsPerMinute < 0 || l.TokensPerMinute < 0 {
+			errs = append(errs, fmt.Errorf("provider_limits.%s: limits must not be negative", provider))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// limitWindow is the period of the per-minute limits
+const limitWindow = time.Minute
+
+// tokenUse is the token usage of a call that returned at
+type tokenUse struct {
+	at     time.Time
+	tokens int
+}
+
+// limiter enforces the Limits of a provider across its models
+type limiter struct {
+	provider string
+	limits   Limits
+	slots    chan struct{}
+	now      func() time.Time
+
+	mu       sync.Mutex
+	requests []time.Time
+	usage    []tokenUse
+}
+
+// newLimiter returns the limiter of limits, or nil when they are unlimited
+func newLimiter(provider string, limits Limits) *limiter {
+	if limits == (Limits{}) {
+		return nil
+	}
+	l := &limiter{provider: provider, limits: limits, now: time.Now}
+	if limits.Concurrency > 0 {
+		l.slots = make(chan struct{}, limits.Concurrency)
+	}
+	return l
+}
+
+// acquire waits until a call may start and returns the function recording
+// its token usage once it returned. A nil limiter never waits.
+func (l *limiter) acquire(ctx context.Context) (release func(tokens int), err error) {
+	if l == nil {
+		return func(int) {}, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	for {
+		wait := l.reserve()
+		if wait <= 0 {
+			break
+		}
+		log.Debug().Str("provider", l.provider).Dur("wait", wait).Msg("Provider rate limit reached, waiting")
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.free()
+			return nil, ctx.Err()
+		}
+	}
+	return func(tokens int) {
+		if tokens > 0 && l.limits.TokensPerMinute > 0 {
+			l.mu.Lock()
+			l.usage = append(l.usage, tokenUse{at: l.now(), tokens: tokens})
+			l.mu.Unlock()
+		}
+		l.free()
+	}, nil
+}
+
+// reserve records the start of a call when the per-minute limits allow it
+// and otherwise returns how long to wait before trying again
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	since := now.Add(-limitWindow)
+	for len(l.requests) > 0 && !l.requests[0].After(since) {
+		l.requests = l.requests[1:]
+	}
+	tokens := 0
+	for len(l.usage) > 0 && !l.usage[0].at.After(since) {
+		l.usage = l.usage[1:]
+	}
+	for _, use := range l.usage {
+		tokens += use.tokens
+	}
+
+	if rpm := l.limits.RequestsPerMinute; rpm > 0 && len(l.requests) >= rpm {
+		return l.requests[len(l.requests)-rpm].Add(limitWindow).Sub(now)
+	}
+	if tpm := l.limits.TokensPerMinute; tpm > 0 && tokens >= tpm {
+		// Wait until enough of the window's usage expires
+		for _, use := range l.usage {
+			tokens -= use.tokens
+			if tokens < tpm {
+				return use.at.Add(limitWindow).Sub(now)
+			}
+		}
+	}
+	if l.limits.RequestsPerMinute > 0 {
+		l.requests = append(l.requests, now)
+	}
+	return 0
+}
+
+// free releases the concurrency slot of a call
+func (l *limiter) free() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func TestLimiter_Concurrency(t *testing.T) {
+	l := newLimiter("ollama", Limits{Concurrency: 1})
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the only slot is taken")
+
+	release(0)
+	release, err = l.acquire(context.Background())
+	require.NoError(t, err)
+	release(0)
+
+	assert.Nil(t, newLimiter("openai", Limits{}), "no limits, no limiter")
+	release, err = (*limiter)(nil).acquire(context.Background())
+	require.NoError(t, err)
+	release(100)
+}
+
+func TestLimiter_PerMinute(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newLimiter("openai", Limits{RequestsPerMinute: 2, TokensPerMinute: 1000})
+	l.now = func() time.Time { return now }
+
+	assert.Zero(t, l.reserve())
+	now = now.Add(10 * time.Second)
+	assert.Zero(t, l.reserve())
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 40*time.Second, l.reserve(), "the first request leaves the window in 40s")
+	now = now.Add(40 * time.Second)
+	assert.Zero(t, l.reserve())
+
+	l.requests = nil
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+	release(600)
+	now = now.Add(30 * time.Second)
+	l.usage = append(l.usage, tokenUse{at: now, tokens: 500})
+	assert.Equal(t, 30*time.Second, l.reserve(), "1100 tokens used; waiting for the first 600 to expire")
+	now = now.Add(30 * time.Second)
+	assert.Zero(t, l.reserve())
+}
+
+func TestValidateLimits(t *testing.T) {
+	assert.NoError(t, ValidateLimits(map[string]Limits{"openai": {RequestsPerMinute: 60}, "ollama": {}}))
+	err := ValidateLimits(map[string]Limits{"azure": {}, "ollama": {Concurrency: -1}})
+	assert.ErrorContains(t, err, "provider_limits.azure: unknown provider")
+	assert.ErrorContains(t, err, "provider_limits.ollama: limits must not be negative")
+}
+
+func TestNewManager_Limits(t *testing.T) {
+	m, err := NewManager([]string{"mistral-local", "mock"}, Config{})
+	require.NoError(t, err)
+	require.NotNil(t, m.limiters["ollama"])
+	assert.Equal(t, DefaultLimits["ollama"], m.limiters["ollama"].limits)
+	assert.Nil(t, m.limiters["mock"])
+
+	m, err = NewManager([]string{"mistral-local"}, Config{Limits: map[string]Limits{"ollama": {}}})
+	require.NoError(t, err)
+	assert.Nil(t, m.limiters["ollama"], "an empty entry lifts the default limits")
+}
+
+// slowClient records the most calls it served at once
+type slowClient struct {
+	MockClient
+	running, peak atomic.Int32
+}
+
+func (c *slowClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
+	n := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return c.MockClient.GenerateTest(ctx, endpoint)
+}
+
+func TestManager_Generate_Concurrency(t *testing.T) {
+	client := &slowClient{MockClient: *NewMockClient("mock")}
+	m := &Manager{
+		clients:  map[string]Client{"a": client, "b": client},
+		limiters: map[string]*limiter{"mock": newLimiter("mock", Limits{Concurrency: 2})},
+	}
+
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Generate(context.Background(), []string{"a", "b"}[i%2], &parser.Endpoint{Method: "GET", Path: "/pets"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), client.peak.Load())
+}
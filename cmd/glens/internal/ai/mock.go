@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"time"
+	"unicode"
 
 	"glens/tools/glens/internal/parser"
 )
@@ -43,6 +44,19 @@ func (c *MockClient) GenerateTest(_ context.Context, endpoint *parser.Endpoint)
 	return result, nil
 }
 
+// RepairTest returns testCode unchanged: the mock cannot fix tests, so a
+// failing test is sent back until the repair attempts run out
+func (c *MockClient) RepairTest(_ context.Context, _ *parser.Endpoint, testCode, _ string) (*TestGenerationResult, error) {
+	return &TestGenerationResult{
+		TestCode:    testCode,
+		ModelUsed:   c.modelName,
+		Framework:   "testify",
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		TokensUsed:  1,
+		Metadata:    map[string]string{"mock_repair": "true"},
+	}, nil
+}
+
 // GetModelName returns the mock model name
 func (c *MockClient) GetModelName() string {
 	return c.modelName
@@ -53,7 +67,7 @@ func (c *MockClient) GetCapabilities() ModelCapabilities {
 	return ModelCapabilities{
 		SupportsGoTests:      true,
 		SupportsSecurityTest: true,
-		SupportedFrameworks:  []string{"testify", "ginkgo", "standard"},
+		SupportedFrameworks:  []string{"testify", "ginkgo", "standard", "grpc"},
 		MaxTokens:            4000,
 		Languages:            []string{"go"},
 	}
@@ -137,7 +151,9 @@ func sanitizePath(path string) string {
 	nextUpper := true
 
 	for _, r := range path {
-		if r == '/' || r == '{' || r == '}' || r == '-' {
+		// Anything but letters, digits and underscores separates words, so
+		// callback expressions such as {$request.body#/url} stay identifiers
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
 			nextUpper = true
 			continue
 		}
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"go/format"
 	"strings"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/telemetry"
 )
 
 // testEndpoint creates a simple endpoint for tests.
@@ -187,10 +189,294 @@ func TestEnhancedMockClient_Categories(t *testing.T) {
 	assert.Contains(t, result.TestCategories, "security")
 }
 
+func TestEnhancedMockClient_Receiver(t *testing.T) {
+	c := NewEnhancedMockClient("enhanced-mock")
+	body := &parser.RequestBody{Content: map[string]parser.MediaType{
+		"application/json": {Schema: parser.Schema{Type: "object", Required: []string{"id"}}},
+	}}
+	webhook := &parser.Endpoint{Kind: parser.KindWebhook, Method: "POST", Path: "newPet", RequestBody: body}
+	callback := &parser.Endpoint{
+		Kind: parser.KindCallback, Method: "POST", Path: "{$request.query.url}",
+		Trigger: "POST /subscriptions", RequestBody: body,
+	}
+
+	for _, ep := range []*parser.Endpoint{webhook, callback} {
+		result, err := c.GenerateTest(context.Background(), ep)
+		require.NoError(t, err)
+		_, err = format.Source([]byte(result.TestCode))
+		require.NoError(t, err, "receiver test must be valid Go:\n%s", result.TestCode)
+		assert.Contains(t, result.TestCode, "httptest.NewUnstartedServer")
+		assert.Contains(t, result.TestCode, `assert.Contains(t, d.payload, "id")`)
+		assert.Contains(t, result.TestCategories, ep.Kind)
+		assert.Equal(t, "Webhook Receiver", result.Metadata["pattern"])
+	}
+
+	result, err := c.GenerateTest(context.Background(), webhook)
+	require.NoError(t, err)
+	assert.Contains(t, result.TestCode, `os.Getenv("GLENS_WEBHOOK_ADDR")`)
+
+	result, err = c.GenerateTest(context.Background(), callback)
+	require.NoError(t, err)
+	assert.Contains(t, result.TestCode, `"/subscriptions"+"?url="+url.QueryEscape(server.URL)`)
+}
+
+// scenarioEndpoint returns a scenario creating, reading and deleting a pet
+func scenarioEndpoint() *parser.Endpoint {
+	body := &parser.RequestBody{Content: map[string]parser.MediaType{"application/json": {Schema: parser.Schema{
+		Type:     "object",
+		Required: []string{"name", "age"},
+		Properties: map[string]parser.Schema{
+			"name": {Type: "string", Example: "Rex"},
+			"age":  {Type: "integer"},
+		},
+	}}}}
+	return &parser.Endpoint{
+		ID: "SCENARIO_pets_lifecycle", Kind: parser.KindScenario, Method: "DELETE", Path: "pets_lifecycle",
+		Summary: "Lifecycle of a resource of /pets: create, read, delete",
+		Steps: []parser.ScenarioStep{
+			{Name: "create", Endpoint: parser.Endpoint{Method: "POST", Path: "/pets", OperationID: "createPet", RequestBody: body}, Save: map[string]string{"petId": "data.id"}, Expect: 201},
+			{Name: "read", Endpoint: parser.Endpoint{Method: "GET", Path: "/pets/{petId}", OperationID: "getPet"}, Expect: 200},
+			{Name: "delete", Endpoint: parser.Endpoint{Method: "DELETE", Path: "/pets/{petId}"}, Expect: 204},
+		},
+	}
+}
+
+func TestEnhancedMockClient_PublicEndpoint(t *testing.T) {
+	c := NewEnhancedMockClient("enhanced-mock")
+	endpoint := &parser.Endpoint{Method: "GET", Path: "/health", Extensions: map[string]interface{}{"x-glens-auth": "none"}}
+	result, err := c.GenerateTest(context.Background(), endpoint)
+	require.NoError(t, err)
+	assert.NotContains(t, result.TestCode, `t.Run("Unauthorized"`)
+	assert.NotContains(t, result.TestCategories, "auth")
+
+	endpoint.Extensions = nil
+	result, err = c.GenerateTest(context.Background(), endpoint)
+	require.NoError(t, err)
+	assert.Contains(t, result.TestCode, `t.Run("Unauthorized"`)
+}
+
+func TestEnhancedMockClient_Scenario(t *testing.T) {
+	result, err := NewEnhancedMockClient("enhanced-mock").GenerateTest(context.Background(), scenarioEndpoint())
+	require.NoError(t, err)
+	_, err = format.Source([]byte(result.TestCode))
+	require.NoError(t, err, "scenario test must be valid Go:\n%s", result.TestCode)
+	assert.Equal(t, "Scenario", result.Metadata["pattern"])
+	assert.Contains(t, result.TestCategories, "workflow")
+	for _, want := range []string{
+		"func TestScenarioPets_lifecycle(t *testing.T) {",
+		`status, response := call(t, "POST", "/pets", map[string]any{"name": "Rex", "age": 1})`,
+		`value, ok := field(response, "data.id")`,
+		`vars["petId"] = fmt.Sprint(value)`,
+		`status, _ := call(t, "GET", "/pets/{petId}", nil)`,
+		`if !deleted && vars["petId"] != "" {`,
+		"require.Equal(t, 204, status)\n\t\tdeleted = true",
+	} {
+		assert.Contains(t, result.TestCode, want)
+	}
+}
+
+// graphQLEndpoint returns the addPet mutation of a GraphQL schema
+func graphQLEndpoint() *parser.Endpoint {
+	return &parser.Endpoint{
+		ID: "MUTATION_addPet", Kind: parser.KindMutation, Method: "POST", Path: parser.GraphQLPath, OperationID: "addPet",
+		Parameters: []parser.Parameter{{Name: "input", In: "argument", Required: true, Schema: parser.Schema{
+			Type:       "object",
+			Required:   []string{"name"},
+			Properties: map[string]parser.Schema{"name": {Type: "string", Example: "Rex"}, "age": {Type: "integer"}},
+		}}},
+		GraphQL: &parser.GraphQLOperation{
+			Type:       parser.KindMutation,
+			Field:      "addPet",
+			Arguments:  []parser.GraphQLArgument{{Name: "input", Type: "NewPet!"}},
+			ReturnType: "Pet!",
+			Document:   "mutation AddPet($input: NewPet!) {\n  addPet(input: $input) {\n    id\n  }\n}",
+		},
+	}
+}
+
+func TestEnhancedMockClient_GraphQL(t *testing.T) {
+	result, err := NewEnhancedMockClient("enhanced-mock").GenerateTest(context.Background(), graphQLEndpoint())
+	require.NoError(t, err)
+	_, err = format.Source([]byte(result.TestCode))
+	require.NoError(t, err, "GraphQL test must be valid Go:\n%s", result.TestCode)
+	assert.Equal(t, "GraphQL Operation", result.Metadata["pattern"])
+	assert.Equal(t, []string{"integration", "api", "graphql", "mutation"}, result.TestCategories)
+	for _, want := range []string{
+		"func TestMutationAddPet(t *testing.T) {",
+		"document := `mutation AddPet($input: NewPet!) {\n  addPet(input: $input) {",
+		`resp, err := client.Post(baseURL+"/graphql", "application/json", bytes.NewReader(payload))`,
+		`status, response := post(t, document, map[string]any{"input": map[string]any{"name": "Rex"}})`,
+		`assert.Contains(t, data, "addPet")`,
+		`t.Run("MissingArguments"`,
+		`t.Run("InvalidDocument"`,
+	} {
+		assert.Contains(t, result.TestCode, want)
+	}
+}
+
+// grpcEndpoint returns the server streaming WatchPets method of a gRPC
+// service read from a descriptor set
+func grpcEndpoint() *parser.Endpoint {
+	return &parser.Endpoint{
+		ID: "RPC_PetService_WatchPets", Kind: parser.KindRPC, Method: "POST", Path: "/petstore.v1.PetService/WatchPets",
+		RequestBody: &parser.RequestBody{Required: true, Content: map[string]parser.MediaType{
+			"application/json": {Schema: parser.Schema{Type: "object", Properties: map[string]parser.Schema{"status": {Type: "string"}}}},
+		}},
+		GRPC: &parser.GRPCMethod{
+			Service:         "petstore.v1.PetService",
+			Method:          "WatchPets",
+			InputType:       "petstore.v1.ListPetsRequest",
+			OutputType:      "petstore.v1.Pet",
+			ServerStreaming: true,
+			Protoset:        "/specs/petstore.protoset",
+		},
+	}
+}
+
+func TestEnhancedMockClient_GRPC(t *testing.T) {
+	result, err := NewEnhancedMockClient("enhanced-mock").GenerateTest(context.Background(), grpcEndpoint())
+	require.NoError(t, err)
+	_, err = format.Source([]byte(result.TestCode))
+	require.NoError(t, err, "gRPC test must be valid Go:\n%s", result.TestCode)
+	assert.Equal(t, "gRPC Method", result.Metadata["pattern"])
+	assert.Equal(t, []string{"integration", "api", "grpc", "streaming"}, result.TestCategories)
+	for _, want := range []string{
+		"func TestPetServiceWatchPets(t *testing.T) {",
+		`t.Skip("grpcurl is not installed")`,
+		`args := []string{"-protoset", "/specs/petstore.protoset"}`,
+		`args = append(args, "-format-error", "-d", "@", target.Host, "petstore.v1.PetService/WatchPets")`,
+		"for decoder.More() {",
+		`t.Run("UnknownField"`,
+	} {
+		assert.Contains(t, result.TestCode, want)
+	}
+}
+
+func TestEnhancedMockClient_Pagination(t *testing.T) {
+	c := NewEnhancedMockClient("enhanced-mock")
+	tests := []struct {
+		name       string
+		pagination parser.Pagination
+		contains   []string
+	}{
+		{"page", parser.Pagination{Style: parser.PaginationPage, PageParam: "page", SizeParam: "per_page", FirstPage: 1, MaxSize: 50}, []string{
+			`getPage(t, url.Values{"page": {"1"}})`,
+			`getPage(t, url.Values{"per_page": {"2"}})`,
+			`t.Run("OversizedPage"`,
+			`getPage(t, url.Values{"page": {"1000000"}})`,
+			`getPage(t, url.Values{"page": {"2"}, "per_page": {"2"}})`,
+		}},
+		{"offset", parser.Pagination{Style: parser.PaginationOffset, OffsetParam: "offset"}, []string{
+			`t.Run("OutOfRangePage"`,
+			`next := strconv.Itoa(len(first))`,
+		}},
+		{"cursor", parser.Pagination{Style: parser.PaginationCursor, CursorParam: "cursor", SizeParam: "limit", NextCursor: "meta.next_cursor", Items: "data"}, []string{
+			`items, _ := v["data"].([]any)`,
+			`t.Run("InvalidCursor"`,
+			`for _, key := range []string{"meta", "next_cursor"}`,
+			`query.Set("cursor", cursor)`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &parser.Endpoint{Method: "GET", Path: "/pets", Pagination: &tt.pagination}
+			result, err := c.GenerateTest(context.Background(), ep)
+			require.NoError(t, err)
+			_, err = format.Source([]byte(result.TestCode))
+			require.NoError(t, err, "pagination test must be valid Go:\n%s", result.TestCode)
+			assert.Equal(t, "Paginated List", result.Metadata["pattern"])
+			assert.Contains(t, result.TestCategories, "pagination")
+			for _, want := range tt.contains {
+				assert.Contains(t, result.TestCode, want)
+			}
+		})
+	}
+}
+
+func TestEnhancedMockClient_RateLimit(t *testing.T) {
+	c := NewEnhancedMockClient("enhanced-mock")
+	ep := &parser.Endpoint{Method: "GET", Path: "/pets", Responses: map[string]parser.Response{
+		"200": {Headers: map[string]parser.Header{"X-RateLimit-Remaining": {}, "ETag": {}}},
+		"429": {Headers: map[string]parser.Header{"Retry-After": {}}},
+	}}
+
+	result, err := c.GenerateTest(context.Background(), ep)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(result.TestCode))
+	require.NoError(t, err, "rate-limit test must be valid Go:\n%s", result.TestCode)
+	assert.Contains(t, result.TestCategories, "rate-limit")
+	assert.Contains(t, result.TestCode, `for _, header := range []string{"X-RateLimit-Remaining"}`)
+	assert.Contains(t, result.TestCode, `os.Getenv("GLENS_RATE_LIMIT_BURST")`)
+	assert.Contains(t, result.TestCode, `http.ParseTime(retryAfter)`)
+
+	delete(ep.Responses, "429")
+	result, err = c.GenerateTest(context.Background(), ep)
+	require.NoError(t, err)
+	assert.NotContains(t, result.TestCode, "Throttling")
+	assert.NotContains(t, result.TestCode, `"os"`)
+}
+
+func TestEnhancedMockClient_ResponseHeaders(t *testing.T) {
+	c := NewEnhancedMockClient("enhanced-mock")
+	created := &parser.Endpoint{Method: "POST", Path: "/pets", Responses: map[string]parser.Response{
+		"201": {
+			Headers: map[string]parser.Header{"Location": {}, "X-Trace": {}, "X-Request-ID": {Required: true}},
+			Content: map[string]parser.MediaType{"application/xml": {}, "application/json": {}},
+		},
+	}}
+	result, err := c.GenerateTest(context.Background(), created)
+	require.NoError(t, err)
+	assert.Contains(t, result.TestCode, `assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")`)
+	assert.Contains(t, result.TestCode, `assert.NotEmpty(t, resp.Header.Get("Location"), "missing Location header")`)
+	assert.Contains(t, result.TestCode, `resp.Header.Get("X-Request-ID")`, "required headers are asserted")
+	assert.NotContains(t, result.TestCode, "X-Trace", "optional custom headers are not")
+
+	cached := &parser.Endpoint{Method: "GET", Path: "/pets/{id}", Responses: map[string]parser.Response{
+		"200": {Headers: map[string]parser.Header{"ETag": {}, "Cache-Control": {}, "X-RateLimit-Limit": {}}},
+		"304": {Description: "Not modified"},
+	}}
+	result, err = c.GenerateTest(context.Background(), cached)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(result.TestCode))
+	require.NoError(t, err, "test must be valid Go:\n%s", result.TestCode)
+	assert.Contains(t, result.TestCode, `resp.Header.Get("Cache-Control")`)
+	assert.Contains(t, result.TestCode, `req.Header.Set("If-None-Match", etag)`)
+	assert.Contains(t, result.TestCode, "http.StatusNotModified")
+	assert.NotContains(t, result.TestCode, `"missing X-RateLimit-Limit header"`, "rate-limit headers have their own test")
+}
+
+func TestEnhancedMockClient_SchemaValidation(t *testing.T) {
+	c := NewEnhancedMockClient("enhanced-mock")
+	ep := &parser.Endpoint{Method: "GET", Path: "/pets/{id}", Responses: map[string]parser.Response{
+		"200": {Content: map[string]parser.MediaType{"application/json": {Schema: parser.Schema{Type: "object"}}}},
+	}}
+	result, err := c.GenerateTest(context.Background(), ep)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(result.TestCode))
+	require.NoError(t, err, "test must be valid Go:\n%s", result.TestCode)
+	assert.Contains(t, result.TestCode, "\t\"io\"\n")
+	assert.Contains(t, result.TestCode, "\t\"glens/assertspec\"\n")
+	assert.Contains(t, result.TestCode, `assertspec.Body(t, "GET /pets/{id}", resp.StatusCode, body)`)
+
+	ep.Responses["200"] = parser.Response{Content: map[string]parser.MediaType{"text/plain": {Schema: parser.Schema{Type: "string"}}}}
+	result, err = c.GenerateTest(context.Background(), ep)
+	require.NoError(t, err)
+	assert.NotContains(t, result.TestCode, "assertspec")
+}
+
+func TestCallbackParameter(t *testing.T) {
+	in, name := callbackParameter("{$request.body#/hooks/url}")
+	assert.Equal(t, "body", in)
+	assert.Equal(t, "hooks.url", name)
+	in, name = callbackParameter("{$request.query.callback}")
+	assert.Equal(t, "query", in)
+	assert.Equal(t, "callback", name)
+}
+
 // --- Manager ---
 
 func TestManager_MockModel(t *testing.T) {
-	m, err := NewManager([]string{"mock"})
+	m, err := NewManager([]string{"mock"}, Config{})
 	require.NoError(t, err)
 
 	models := m.GetAvailableModels()
@@ -198,7 +484,7 @@ func TestManager_MockModel(t *testing.T) {
 }
 
 func TestManager_EnhancedMockModel(t *testing.T) {
-	m, err := NewManager([]string{"enhanced-mock"})
+	m, err := NewManager([]string{"enhanced-mock"}, Config{})
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -210,12 +496,12 @@ func TestManager_EnhancedMockModel(t *testing.T) {
 }
 
 func TestManager_UnknownModel(t *testing.T) {
-	_, err := NewManager([]string{"unknown-model-xyz"})
+	_, err := NewManager([]string{"unknown-model-xyz"}, Config{})
 	assert.Error(t, err)
 }
 
 func TestManager_ModelNotFound(t *testing.T) {
-	m, err := NewManager([]string{"mock"})
+	m, err := NewManager([]string{"mock"}, Config{})
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -226,16 +512,8 @@ func TestManager_ModelNotFound(t *testing.T) {
 }
 
 // TestCreateClient_RequiresAPIKey verifies that cloud models return an error
-// when the required environment variable is not set.
-// Env vars are process-global, so subtests must not run in parallel.
+// when their provider config has no API key.
 func TestCreateClient_RequiresAPIKey(t *testing.T) {
-	// Clear all API keys for the duration of the test so the result is
-	// deterministic regardless of the developer's local environment.
-	t.Setenv("OPENAI_API_KEY", "")
-	t.Setenv("ANTHROPIC_API_KEY", "")
-	t.Setenv("GOOGLE_API_KEY", "")
-	t.Setenv("MISTRAL_API_KEY", "")
-
 	cloudModels := []string{
 		// OpenAI
 		"gpt-4o", "gpt-4.1", "gpt-4.1-mini", "gpt-4.1-nano",
@@ -254,8 +532,9 @@ func TestCreateClient_RequiresAPIKey(t *testing.T) {
 	for _, name := range cloudModels {
 		name := name
 		t.Run(name, func(t *testing.T) {
-			_, err := createClient(name)
-			// Without an API key set the client must return an error.
+			t.Parallel()
+			_, err := createClient(name, Config{})
+			// Without an API key the client must return an error.
 			assert.Error(t, err, "model %q should require an API key", name)
 		})
 	}
@@ -271,7 +550,7 @@ func TestCreateClient_LocalModels(t *testing.T) {
 		name := name
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			c, err := createClient(name)
+			c, err := createClient(name, Config{})
 			assert.NoError(t, err, "model %q should not need an API key", name)
 			assert.NotNil(t, c)
 		})
@@ -311,3 +590,16 @@ func TestSanitizePath(t *testing.T) {
 		assert.Equal(t, tt.want, sanitizePath(tt.in), "sanitizePath(%q)", tt.in)
 	}
 }
+
+// --- Manager metrics ---
+
+func TestManager_GenerateTest_RecordsMetrics(t *testing.T) {
+	m, err := NewManager([]string{"mock"}, Config{})
+	require.NoError(t, err)
+	before := telemetry.AIGenerationDuration.Count("mock", "mock", "success")
+
+	_, _, err = m.GenerateTest(context.Background(), "mock", testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	assert.Equal(t, before+1, telemetry.AIGenerationDuration.Count("mock", "mock", "success"))
+}
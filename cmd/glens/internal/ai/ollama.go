@@ -7,45 +7,86 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
-	"github.com/spf13/viper"
 
+	"glens/tools/glens/internal/httpclient"
 	"glens/tools/glens/internal/parser"
 )
 
+// Ollama APIs a client can generate with
+const (
+	OllamaGenerateAPI = "generate"
+	OllamaChatAPI     = "chat"
+)
+
+// defaultOllamaContext is Ollama's context window when context_length is
+// not configured
+const defaultOllamaContext = 2048
+
 // OllamaClient implements Client interface for Ollama local LLM
 type OllamaClient struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
 	config     OllamaConfig
+	promptEnvironment
+	promptTemplates
+
+	mu sync.Mutex // guards sessions
+	// sessions hold the conversation of each tag when ReuseContext is set
+	sessions map[string]*ollamaSession
+}
+
+// ollamaSession is the conversation reused across endpoints of a tag: the
+// generate API's context tokens or the chat API's messages
+type ollamaSession struct {
+	mu       sync.Mutex
+	context  []int
+	messages []OllamaMessage
 }
 
 // OllamaConfig holds configuration for Ollama client
 type OllamaConfig struct {
-	BaseURL       string  `mapstructure:"base_url"`
-	Model         string  `mapstructure:"model"`
-	Timeout       string  `mapstructure:"timeout"`
-	Temperature   float64 `mapstructure:"temperature"`
-	MaxTokens     int     `mapstructure:"max_tokens"`
-	ContextLength int     `mapstructure:"context_length"`
-	NumPredict    int     `mapstructure:"num_predict"`
-	TopK          int     `mapstructure:"top_k"`
-	TopP          float64 `mapstructure:"top_p"`
-	RepeatPenalty float64 `mapstructure:"repeat_penalty"`
-	Seed          int     `mapstructure:"seed"`
+	BaseURL       string        `mapstructure:"base_url"`
+	Model         string        `mapstructure:"model"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	ContextLength int           `mapstructure:"context_length"`
+	TopK          int           `mapstructure:"top_k"`
+	RepeatPenalty float64       `mapstructure:"repeat_penalty"`
+	Sampling      `mapstructure:",squash"`
+	// NumPredict limits generated tokens; it defaults to MaxTokens
+	NumPredict int `mapstructure:"num_predict"`
+	// ResponseFormat is "structured" (default, JSON mode) or "text"
+	ResponseFormat string `mapstructure:"response_format"`
+	// API is OllamaGenerateAPI (default) or OllamaChatAPI, which sends the
+	// ollama-system template as a system message
+	API string `mapstructure:"api"`
+	// KeepAlive is how long Ollama keeps the model loaded after a request
+	// (e.g. "30m", "-1" for ever); empty keeps the server default
+	KeepAlive string `mapstructure:"keep_alive"`
+	// ReuseContext continues one conversation across the endpoints of a tag
+	// so Ollama can reuse the evaluated prompt; it restarts when half the
+	// context window is used
+	ReuseContext bool `mapstructure:"reuse_context"`
+	// TLS trusts a CA bundle (ca_file) or skips verification for a remote
+	// Ollama server behind HTTPS
+	TLS httpclient.TLS `mapstructure:",squash"`
 }
 
 // OllamaGenerateRequest represents the request structure for Ollama API
 type OllamaGenerateRequest struct {
-	Model   string                 `json:"model"`
-	Prompt  string                 `json:"prompt"`
-	Stream  bool                   `json:"stream"`
-	Format  string                 `json:"format,omitempty"`
-	Options map[string]interface{} `json:"options,omitempty"`
+	Model     string                 `json:"model"`
+	Prompt    string                 `json:"prompt"`
+	Stream    bool                   `json:"stream"`
+	Format    string                 `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Context   []int                  `json:"context,omitempty"`
 }
 
 // OllamaGenerateResponse represents the response structure from Ollama API
@@ -60,6 +101,35 @@ type OllamaGenerateResponse struct {
 	EvalTime       int64  `json:"eval_duration,omitempty"`
 }
 
+// OllamaMessage is a message of an Ollama chat
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OllamaChatRequest represents the request structure for /api/chat
+type OllamaChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []OllamaMessage        `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	Format    string                 `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+}
+
+// OllamaChatResponse represents the response structure from /api/chat
+type OllamaChatResponse struct {
+	Model          string        `json:"model"`
+	Message        OllamaMessage `json:"message"`
+	Done           bool          `json:"done"`
+	TotalTime      int64         `json:"total_duration,omitempty"`
+	LoadTime       int64         `json:"load_duration,omitempty"`
+	PromptEvalTime int64         `json:"prompt_eval_duration,omitempty"`
+	PromptEvalTok  int           `json:"prompt_eval_count,omitempty"`
+	EvalTime       int64         `json:"eval_duration,omitempty"`
+	EvalTok        int           `json:"eval_count,omitempty"`
+}
+
 // OllamaModel represents a model in Ollama
 type OllamaModel struct {
 	Name       string    `json:"name"`
@@ -87,95 +157,92 @@ type OllamaPullResponse struct {
 	Completed int64  `json:"completed,omitempty"`
 }
 
-// NewOllamaClient creates a new Ollama client
-func NewOllamaClient(configKey string) (*OllamaClient, error) {
-	var config OllamaConfig
-
-	// Use provided config key or default to "ollama"
-	if configKey == "" {
-		configKey = "ollama"
+// NewOllamaClient creates a new Ollama client from cfg; opts override the
+// configured model, base URL and timeout
+func NewOllamaClient(cfg OllamaConfig, opts ...Option) (*OllamaClient, error) {
+	o := resolve(opts,
+		orDefault(cfg.BaseURL, DefaultOllamaBaseURL),
+		orDefault(cfg.Model, "codellama:7b-instruct"),
+		orDefault(cfg.Timeout, defaultOllamaTimeout))
+
+	cfg.BaseURL = o.baseURL
+	cfg.Model = o.model
+	cfg.Timeout = o.timeout
+	cfg.Sampling = cfg.Sampling.withDefaults(defaultOllamaTemperature)
+	cfg.NumPredict = orDefault(cfg.NumPredict, cfg.MaxTokens)
+	o.tls = cfg.TLS
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
 	}
 
-	if err := viper.UnmarshalKey(fmt.Sprintf("ai_models.%s", configKey), &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal Ollama config: %w", err)
-	}
+	return &OllamaClient{
+		baseURL:         cfg.BaseURL,
+		model:           cfg.Model,
+		config:          cfg,
+		httpClient:      client,
+		promptTemplates: promptTemplates{structured: structuredOutput(cfg.ResponseFormat)},
+		sessions:        make(map[string]*ollamaSession),
+	}, nil
+}
 
-	// Set defaults
-	if config.BaseURL == "" {
-		config.BaseURL = "http://localhost:11434"
-	}
-	if config.Model == "" {
-		config.Model = "codellama:7b-instruct"
-	}
-	if config.Temperature == 0 {
-		config.Temperature = 0.1
-	}
-	if config.MaxTokens == 0 {
-		config.MaxTokens = 4000
+// GenerateTest generates integration test code using Ollama
+func (c *OllamaClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
+	prompt, err := c.buildPrompt(endpoint)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
 	}
+	return c.complete(ctx, endpoint, prompt, c.sessionKey(endpoint))
+}
 
-	// Parse timeout
-	timeout := 300 * time.Second
-	if config.Timeout != "" {
-		if parsedTimeout, err := time.ParseDuration(config.Timeout); err == nil {
-			timeout = parsedTimeout
-		}
+// RepairTest asks Ollama to fix testCode given the failure it produced
+func (c *OllamaClient) RepairTest(ctx context.Context, endpoint *parser.Endpoint, testCode, failure string) (*TestGenerationResult, error) {
+	prompt, err := c.renderRepairPrompt(endpoint, c.environmentPrompt(), testCode, failure)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
 	}
+	return c.complete(ctx, endpoint, prompt, "")
+}
 
-	client := &OllamaClient{
-		baseURL: config.BaseURL,
-		model:   config.Model,
-		config:  config,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
+// sessionKey is the conversation endpoint's test is generated in: its first
+// tag when ReuseContext is set, otherwise none
+func (c *OllamaClient) sessionKey(endpoint *parser.Endpoint) string {
+	if !c.config.ReuseContext {
+		return ""
 	}
-
-	return client, nil
+	if len(endpoint.Tags) == 0 {
+		return "untagged"
+	}
+	return endpoint.Tags[0]
 }
 
-// GenerateTest generates integration test code using Ollama
-func (c *OllamaClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
+// complete sends prompt to Ollama and returns the test it answers with.
+// A non-empty session continues that conversation.
+func (c *OllamaClient) complete(ctx context.Context, endpoint *parser.Endpoint, prompt, session string) (*TestGenerationResult, error) {
 	startTime := time.Now()
 
-	prompt := c.buildPrompt(endpoint)
-
 	log.Info().
 		Str("model", c.model).
+		Str("api", c.api()).
 		Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
 		Msg("Generating test with Ollama")
 
-	// Create request
-	req := OllamaGenerateRequest{
-		Model:  c.model,
-		Prompt: prompt,
-		Stream: false, // Use non-streaming for simplicity
-		Options: map[string]interface{}{
-			"temperature":    c.config.Temperature,
-			"num_predict":    c.config.NumPredict,
-			"top_k":          c.config.TopK,
-			"top_p":          c.config.TopP,
-			"repeat_penalty": c.config.RepeatPenalty,
-		},
-	}
-
-	if c.config.Seed >= 0 {
-		req.Options["seed"] = c.config.Seed
+	var (
+		response *OllamaGenerateResponse
+		err      error
+	)
+	if c.api() == OllamaChatAPI {
+		response, err = c.chat(ctx, endpoint, prompt, session)
+	} else {
+		response, err = c.generateIn(ctx, prompt, session)
 	}
-
-	// Make API call
-	response, err := c.generate(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate with Ollama: %w", err)
 	}
 
 	generationTime := time.Since(startTime)
 
-	// Extract test code from response
-	testCode := c.extractTestCode(response.Response)
-
 	result := &TestGenerationResult{
-		TestCode:       testCode,
 		Prompt:         prompt,
 		ModelUsed:      c.model,
 		Framework:      "testify", // Default framework
@@ -184,11 +251,18 @@ func (c *OllamaClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 		GenerationTime: generationTime.String(),
 		Metadata: map[string]string{
 			"ollama_version":          "latest",
+			"ollama_api":              c.api(),
 			"total_duration_ms":       fmt.Sprintf("%d", response.TotalTime/1000000),
+			"load_duration_ms":        fmt.Sprintf("%d", response.LoadTime/1000000),
 			"eval_duration_ms":        fmt.Sprintf("%d", response.EvalTime/1000000),
 			"prompt_eval_duration_ms": fmt.Sprintf("%d", response.PromptEvalTime/1000000),
 		},
 	}
+	if session != "" {
+		result.Metadata["ollama_session"] = session
+	}
+	maps.Copy(result.Metadata, c.config.Params())
+	result.applyAnswer(response.Response)
 
 	log.Info().
 		Str("model", c.model).
@@ -199,6 +273,143 @@ func (c *OllamaClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 	return result, nil
 }
 
+// api returns the Ollama API the client generates with
+func (c *OllamaClient) api() string {
+	if c.config.API == OllamaChatAPI {
+		return OllamaChatAPI
+	}
+	return OllamaGenerateAPI
+}
+
+// options returns the model options of every request
+func (c *OllamaClient) options() map[string]interface{} {
+	options := map[string]interface{}{
+		"temperature":    *c.config.Temperature,
+		"num_predict":    c.config.NumPredict,
+		"top_k":          c.config.TopK,
+		"repeat_penalty": c.config.RepeatPenalty,
+	}
+	if c.config.TopP != nil {
+		options["top_p"] = *c.config.TopP
+	}
+	if c.config.Seed != nil {
+		options["seed"] = *c.config.Seed
+	}
+	if c.config.ContextLength > 0 {
+		options["num_ctx"] = c.config.ContextLength
+	}
+	return options
+}
+
+// format returns the response format requested from Ollama
+func (c *OllamaClient) format() string {
+	if c.structured {
+		return "json"
+	}
+	return ""
+}
+
+// reuseLimit is the context size, in tokens, after which a session restarts
+func (c *OllamaClient) reuseLimit() int {
+	return orDefault(c.config.ContextLength, defaultOllamaContext) / 2
+}
+
+// session returns the conversation named key locked, or nil for no key.
+// Sessions of different tags run independently; requests within one are
+// serialised until unlock is called.
+func (c *OllamaClient) session(key string) (session *ollamaSession, unlock func()) {
+	if key == "" {
+		return nil, func() {}
+	}
+	c.mu.Lock()
+	session, ok := c.sessions[key]
+	if !ok {
+		session = &ollamaSession{}
+		c.sessions[key] = session
+	}
+	c.mu.Unlock()
+
+	session.mu.Lock()
+	return session, session.mu.Unlock
+}
+
+// generateIn sends prompt to /api/generate, continuing the context of the
+// named session
+func (c *OllamaClient) generateIn(ctx context.Context, prompt, key string) (*OllamaGenerateResponse, error) {
+	session, unlock := c.session(key)
+	defer unlock()
+
+	req := OllamaGenerateRequest{
+		Model:     c.model,
+		Prompt:    prompt,
+		Stream:    false, // Use non-streaming for simplicity
+		Format:    c.format(),
+		Options:   c.options(),
+		KeepAlive: c.config.KeepAlive,
+	}
+	if session != nil {
+		req.Context = session.context
+	}
+
+	response, err := c.generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		session.context = response.Context
+		if len(session.context) > c.reuseLimit() {
+			session.context = nil
+		}
+	}
+	return response, nil
+}
+
+// chat sends prompt to /api/chat after the ollama-system message,
+// continuing the messages of the named session
+func (c *OllamaClient) chat(ctx context.Context, endpoint *parser.Endpoint, prompt, key string) (*OllamaGenerateResponse, error) {
+	system, err := c.renderPrompt("ollama-system", endpoint, c.environmentPrompt())
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+
+	session, unlock := c.session(key)
+	defer unlock()
+
+	messages := []OllamaMessage{{Role: "system", Content: system}}
+	if session != nil {
+		messages = append(messages, session.messages...)
+	}
+	messages = append(messages, OllamaMessage{Role: "user", Content: prompt})
+
+	var response OllamaChatResponse
+	req := OllamaChatRequest{
+		Model:     c.model,
+		Messages:  messages,
+		Format:    c.format(),
+		Options:   c.options(),
+		KeepAlive: c.config.KeepAlive,
+	}
+	if err := c.post(ctx, "/api/chat", req, &response); err != nil {
+		return nil, err
+	}
+
+	if session != nil {
+		session.messages = append(messages[1:], response.Message)
+		if response.PromptEvalTok+response.EvalTok > c.reuseLimit() {
+			session.messages = nil
+		}
+	}
+	return &OllamaGenerateResponse{
+		Model:          response.Model,
+		Response:       response.Message.Content,
+		Done:           response.Done,
+		TotalTime:      response.TotalTime,
+		LoadTime:       response.LoadTime,
+		PromptEvalTime: response.PromptEvalTime,
+		EvalTime:       response.EvalTime,
+	}, nil
+}
+
 // GetModelName returns the Ollama model name
 func (c *OllamaClient) GetModelName() string {
 	return fmt.Sprintf("ollama:%s", c.model)
@@ -209,7 +420,7 @@ func (c *OllamaClient) GetCapabilities() ModelCapabilities {
 	return ModelCapabilities{
 		SupportsGoTests:      true,
 		SupportsSecurityTest: true,
-		SupportedFrameworks:  []string{"testify", "ginkgo", "standard"},
+		SupportedFrameworks:  []string{"testify", "ginkgo", "standard", "grpc"},
 		MaxTokens:            c.config.MaxTokens,
 		Languages:            []string{"go", "json", "yaml"},
 	}
@@ -218,7 +429,11 @@ func (c *OllamaClient) GetCapabilities() ModelCapabilities {
 // HealthCheck verifies if Ollama is running and the model is available
 func (c *OllamaClient) HealthCheck(ctx context.Context) error {
 	// Check if Ollama is running
-	resp, err := c.httpClient.Get(c.baseURL + "/api/version")
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/version", http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("ollama server not accessible: %w", err)
 	}
@@ -232,12 +447,9 @@ func (c *OllamaClient) HealthCheck(ctx context.Context) error {
 		return fmt.Errorf("failed to list models: %w", err)
 	}
 
-	for _, model := range models {
-		if model.Name == c.model {
-			return nil // Model found
-		}
+	if c.hasModel(models) {
+		return nil
 	}
-
 	return fmt.Errorf("model %s not found in Ollama. Available models: %v", c.model, c.getModelNames(models))
 }
 
@@ -270,6 +482,45 @@ func (c *OllamaClient) ListModels(ctx context.Context) ([]OllamaModel, error) {
 	return modelsResp.Models, nil
 }
 
+// Model returns the Ollama model the client generates with
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// ModelInstalled reports whether the client's model is installed on the
+// server; a model without a tag matches its ":latest" tag
+func (c *OllamaClient) ModelInstalled(ctx context.Context) (bool, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return false, err
+	}
+	return c.hasModel(models), nil
+}
+
+// hasModel reports whether models include the client's model
+func (c *OllamaClient) hasModel(models []OllamaModel) bool {
+	for _, model := range models {
+		if model.Name == c.model || model.Name == c.model+":latest" {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureModel pulls the client's model when the server does not have it,
+// streaming progress to progress, and reports whether it pulled
+func (c *OllamaClient) EnsureModel(ctx context.Context, progress io.Writer) (pulled bool, err error) {
+	installed, err := c.ModelInstalled(ctx)
+	if err != nil || installed {
+		return false, err
+	}
+	log.Info().Str("model", c.model).Msg("Pulling missing Ollama model")
+	if err := c.PullModel(ctx, c.model, progress); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PullModel pulls (downloads) a model from the Ollama registry.
 // It streams progress status lines to the provided writer (pass os.Stdout for
 // live terminal feedback, or io.Discard to suppress output).
@@ -334,21 +585,31 @@ func (c *OllamaClient) PullModel(ctx context.Context, modelName string, progress
 
 // generate makes a generation request to Ollama
 func (c *OllamaClient) generate(ctx context.Context, req OllamaGenerateRequest) (*OllamaGenerateResponse, error) {
+	var response OllamaGenerateResponse
+	if err := c.post(ctx, "/api/generate", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// post sends req as JSON to the Ollama API at path and decodes the answer
+// into response
+func (c *OllamaClient) post(ctx context.Context, path string, req, response any) error {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request to Ollama: %w", err)
+		return fmt.Errorf("failed to make request to Ollama: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -358,84 +619,18 @@ func (c *OllamaClient) generate(ctx context.Context, req OllamaGenerateRequest)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var response OllamaGenerateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return &response, nil
-}
-
-// buildPrompt creates a prompt optimized for local LLMs to generate Go integration tests
-func (c *OllamaClient) buildPrompt(endpoint *parser.Endpoint) string {
-	prompt := fmt.Sprintf(`You are a Go developer writing integration tests. Generate a complete Go test function for this OpenAPI endpoint:
-
-Endpoint: %s %s
-Summary: %s
-Description: %s
-
-Requirements:
-1. Use the testify framework
-2. Create a test function that covers:
-   - Valid request with expected response
-   - Invalid request handling
-   - Status code validation
-   - Response structure validation
-3. Include proper imports
-4. Use realistic test data
-5. Handle authentication if required
-6. Test error cases
-
-Generate ONLY the Go test code, no explanations:
-
-`, endpoint.Method, endpoint.Path, endpoint.Summary, endpoint.Description)
-
-	// Add parameters information if available
-	if len(endpoint.Parameters) > 0 {
-		prompt += "Parameters:\n"
-		for i := range endpoint.Parameters {
-			param := &endpoint.Parameters[i]
-			prompt += fmt.Sprintf("- %s (%s): %s\n", param.Name, param.In, param.Description)
-		}
-		prompt += "\n"
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	// Add response information if available
-	if len(endpoint.Responses) > 0 {
-		prompt += "Expected Responses:\n"
-		for code, response := range endpoint.Responses {
-			prompt += fmt.Sprintf("- %s: %s\n", code, response.Description)
-		}
-		prompt += "\n"
-	}
-
-	prompt += "```go\n"
-
-	return prompt
+	return nil
 }
 
-// extractTestCode extracts Go test code from the Ollama response
-func (c *OllamaClient) extractTestCode(response string) string {
-	// Ollama responses often include the code block markers
-	// Extract code between ```go and ``` markers
-	startMarker := "```go"
-	endMarker := "```"
-
-	startIdx := bytes.Index([]byte(response), []byte(startMarker))
-	if startIdx != -1 {
-		startIdx += len(startMarker)
-		endIdx := bytes.Index([]byte(response[startIdx:]), []byte(endMarker))
-		if endIdx != -1 {
-			return response[startIdx : startIdx+endIdx]
-		}
-	}
-
-	// If no code blocks found, return the response as-is
-	// (some models might not use markdown formatting)
-	return response
+// buildPrompt renders the ollama prompt template for endpoint
+func (c *OllamaClient) buildPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("ollama", endpoint, c.environmentPrompt())
 }
 
 // getModelNames extracts model names from OllamaModel slice
@@ -446,31 +641,3 @@ func (c *OllamaClient) getModelNames(models []OllamaModel) []string {
 	}
 	return names
 }
-
-// OllamaClientWithModel wraps OllamaClient to override the model name
-type OllamaClientWithModel struct {
-	client *OllamaClient
-	model  string
-}
-
-// GenerateTest delegates to the wrapped client but uses custom model name
-func (c *OllamaClientWithModel) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
-	// Temporarily override the model name
-	originalModel := c.client.model
-	c.client.model = c.model
-	defer func() {
-		c.client.model = originalModel
-	}()
-
-	return c.client.GenerateTest(ctx, endpoint)
-}
-
-// GetModelName returns the custom model name
-func (c *OllamaClientWithModel) GetModelName() string {
-	return fmt.Sprintf("ollama:%s", c.model)
-}
-
-// GetCapabilities delegates to the wrapped client
-func (c *OllamaClientWithModel) GetCapabilities() ModelCapabilities {
-	return c.client.GetCapabilities()
-}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
 )
 
 // --- newOllamaLocal / local model shortcuts ---
@@ -29,7 +31,7 @@ func TestNewOllamaLocal_CreatesClient(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.modelName, func(t *testing.T) {
 			t.Parallel()
-			c, err := newOllamaLocal(tt.modelName)
+			c, err := newOllamaLocal(Config{}, tt.modelName)
 			require.NoError(t, err)
 			assert.NotNil(t, c)
 			assert.Equal(t, "ollama:"+tt.modelName, c.GetModelName())
@@ -69,7 +71,7 @@ func TestCreateClient_LocalModelShortcuts(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.shortcut, func(t *testing.T) {
 			t.Parallel()
-			c, err := createClient(tt.shortcut)
+			c, err := createClient(tt.shortcut, Config{})
 			require.NoError(t, err, "shortcut %q should not require an API key", tt.shortcut)
 			assert.NotNil(t, c)
 			assert.Equal(t, tt.wantModelName, c.GetModelName(),
@@ -79,7 +81,7 @@ func TestCreateClient_LocalModelShortcuts(t *testing.T) {
 }
 
 func TestCreateClient_LocalModelShortcuts_Capabilities(t *testing.T) {
-	c, err := createClient("mistral-local")
+	c, err := createClient("mistral-local", Config{})
 	require.NoError(t, err)
 
 	caps := c.GetCapabilities()
@@ -139,11 +141,143 @@ func TestOllamaClient_PullModel_ConnectionRefused(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// --- OllamaClient.EnsureModel ---
+
+func TestOllamaClient_EnsureModel(t *testing.T) {
+	var pulls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			_ = json.NewEncoder(w).Encode(OllamaModelsResponse{Models: []OllamaModel{{Name: "mistral:latest"}}})
+		case "/api/pull":
+			var req OllamaPullRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			pulls = append(pulls, req.Name)
+			_ = json.NewEncoder(w).Encode(OllamaPullResponse{Status: "success"})
+		}
+	}))
+	defer srv.Close()
+
+	installed, err := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Model: "mistral"})
+	require.NoError(t, err)
+	pulled, err := installed.EnsureModel(context.Background(), io.Discard)
+	require.NoError(t, err)
+	assert.False(t, pulled, "a model without a tag matches :latest")
+
+	missing, err := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, Model: "qwen2.5-coder:7b"})
+	require.NoError(t, err)
+	pulled, err = missing.EnsureModel(context.Background(), io.Discard)
+	require.NoError(t, err)
+	assert.True(t, pulled)
+	assert.Equal(t, []string{"qwen2.5-coder:7b"}, pulls)
+}
+
+// --- chat API, keep_alive and context reuse ---
+
+func TestOllamaClient_ChatReusesSessionPerTag(t *testing.T) {
+	var requests []OllamaChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		var req OllamaChatRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		_ = json.NewEncoder(w).Encode(OllamaChatResponse{
+			Message: OllamaMessage{Role: "assistant", Content: "package api_test\n"},
+			Done:    true,
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewOllamaClient(OllamaConfig{
+		BaseURL:        srv.URL,
+		API:            OllamaChatAPI,
+		KeepAlive:      "30m",
+		ReuseContext:   true,
+		ResponseFormat: "text",
+	})
+	require.NoError(t, err)
+
+	users := &parser.Endpoint{Method: "GET", Path: "/users", Tags: []string{"users"}}
+	pets := &parser.Endpoint{Method: "GET", Path: "/pets", Tags: []string{"pets"}}
+	for _, endpoint := range []*parser.Endpoint{users, users, pets} {
+		result, err := client.GenerateTest(context.Background(), endpoint)
+		require.NoError(t, err)
+		assert.Equal(t, OllamaChatAPI, result.Metadata["ollama_api"])
+	}
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, "30m", requests[0].KeepAlive)
+	assert.Equal(t, "system", requests[0].Messages[0].Role)
+	assert.Len(t, requests[0].Messages, 2)
+	assert.Len(t, requests[1].Messages, 4, "the second users endpoint continues the users conversation")
+	assert.Equal(t, "assistant", requests[1].Messages[2].Role)
+	assert.Len(t, requests[2].Messages, 2, "other tags start their own conversation")
+}
+
+func TestOllamaClient_GenerateReusesContext(t *testing.T) {
+	var contexts [][]int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OllamaGenerateRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		contexts = append(contexts, req.Context)
+		_ = json.NewEncoder(w).Encode(OllamaGenerateResponse{Response: "package api_test\n", Done: true, Context: []int{1, 2, 3}})
+	}))
+	defer srv.Close()
+
+	client, err := NewOllamaClient(OllamaConfig{BaseURL: srv.URL, ReuseContext: true, ContextLength: 4})
+	require.NoError(t, err)
+	endpoint := &parser.Endpoint{Method: "GET", Path: "/users"}
+	for range 3 {
+		_, err := client.GenerateTest(context.Background(), endpoint)
+		require.NoError(t, err)
+	}
+	_, err = client.RepairTest(context.Background(), endpoint, "", "")
+	require.NoError(t, err)
+
+	// 3 tokens exceed half of the 4-token window, so every session restarts
+	assert.Equal(t, [][]int{nil, nil, nil, nil}, contexts)
+
+	client, err = NewOllamaClient(OllamaConfig{BaseURL: srv.URL, ReuseContext: true})
+	require.NoError(t, err)
+	contexts = nil
+	for range 2 {
+		_, err := client.GenerateTest(context.Background(), endpoint)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, [][]int{nil, {1, 2, 3}}, contexts)
+}
+
+func TestOllamaClient_RepairTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+		var req OllamaGenerateRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Contains(t, req.Prompt, "func TestBroken(t *testing.T) { undefined() }")
+		assert.Contains(t, req.Prompt, "undefined: undefined")
+
+		_ = json.NewEncoder(w).Encode(OllamaGenerateResponse{
+			Response: "```go\npackage api_test\n\nfunc TestFixed(t *testing.T) {}\n```",
+			Done:     true,
+		})
+	}))
+	defer srv.Close()
+
+	manager := &Manager{clients: map[string]Client{"ollama": newTestOllamaClient(t, srv.URL)}}
+	endpoint := &parser.Endpoint{Method: "GET", Path: "/users"}
+	result, err := manager.Repair(context.Background(), "ollama", endpoint,
+		"func TestBroken(t *testing.T) { undefined() }", "./api_test.go:3:34: undefined: undefined")
+	require.NoError(t, err)
+	assert.Contains(t, result.TestCode, "func TestFixed")
+
+	manager.clients["enhanced-mock"] = NewEnhancedMockClient("enhanced-mock")
+	_, err = manager.Repair(context.Background(), "enhanced-mock", endpoint, "", "")
+	assert.ErrorAs(t, err, &ErrRepairUnsupported{})
+}
+
 // newTestOllamaClient builds an OllamaClient pointed at the given base URL.
 func newTestOllamaClient(t *testing.T, baseURL string) *OllamaClient {
 	t.Helper()
-	c, err := NewOllamaClient("")
+	c, err := NewOllamaClient(OllamaConfig{BaseURL: baseURL})
 	require.NoError(t, err)
-	c.baseURL = baseURL
 	return c
 }
@@ -6,8 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -17,19 +17,38 @@ import (
 
 // OpenAIClient implements the Client interface for OpenAI GPT models
 type OpenAIClient struct {
-	apiKey    string
-	baseURL   string
-	model     string
-	maxTokens int
-	client    *http.Client
+	apiKey   string
+	baseURL  string
+	model    string
+	sampling Sampling
+	client   *http.Client
+	promptEnvironment
+	promptTemplates
 }
 
 // OpenAIRequest represents the request structure for OpenAI API
 type OpenAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens"`
-	Temperature float64   `json:"temperature"`
+	Model          string                `json:"model"`
+	Messages       []Message             `json:"messages"`
+	MaxTokens      int                   `json:"max_tokens"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	TopP           *float64              `json:"top_p,omitempty"`
+	Seed           *int                  `json:"seed,omitempty"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat asks for a JSON answer: "json_schema" with a schema
+// (OpenAI) or "json_object" (Mistral and other compatible servers)
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema is the schema of a json_schema response format
+type OpenAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
 }
 
 // Message represents a chat message
@@ -62,29 +81,44 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
-// NewOpenAIClient creates a new OpenAI client
-func NewOpenAIClient() (*OpenAIClient, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
+// NewOpenAIClient creates a new OpenAI client from cfg; opts override the
+// configured model, base URL and timeout
+func NewOpenAIClient(cfg OpenAIConfig, opts ...Option) (*OpenAIClient, error) {
+	if cfg.APIKey == "" {
 		return nil, ErrAPIKeyMissing{Model: "OpenAI"}
 	}
-
-	return &OpenAIClient{
-		apiKey:    apiKey,
-		baseURL:   "https://api.openai.com/v1",
-		model:     "gpt-4-turbo",
-		maxTokens: 4000,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-	}, nil
+	return newOpenAICompatible(cfg, resolve(opts,
+		orDefault(cfg.BaseURL, DefaultOpenAIBaseURL),
+		orDefault(cfg.Model, "gpt-4-turbo"),
+		orDefault(cfg.Timeout, defaultCloudTimeout)))
 }
 
 // GenerateTest generates integration test code using OpenAI GPT
 func (c *OpenAIClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoint) (*TestGenerationResult, error) {
+	prompt, err := c.buildPrompt(endpoint)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+	return c.complete(ctx, endpoint, prompt)
+}
+
+// RepairTest asks OpenAI to fix testCode given the failure it produced
+func (c *OpenAIClient) RepairTest(ctx context.Context, endpoint *parser.Endpoint, testCode, failure string) (*TestGenerationResult, error) {
+	prompt, err := c.renderRepairPrompt(endpoint, c.environmentPrompt(), testCode, failure)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
+	return c.complete(ctx, endpoint, prompt)
+}
+
+// complete sends prompt to OpenAI and returns the test it answers with
+func (c *OpenAIClient) complete(ctx context.Context, endpoint *parser.Endpoint, prompt string) (*TestGenerationResult, error) {
 	startTime := time.Now()
 
-	prompt := c.buildPrompt(endpoint)
+	systemPrompt, err := c.systemPrompt(endpoint)
+	if err != nil {
+		return nil, ErrGenerationFailed{Model: c.GetModelName(), Reason: err.Error()}
+	}
 
 	log.Debug().
 		Str("model", c.model).
@@ -96,15 +130,18 @@ func (c *OpenAIClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 		Messages: []Message{
 			{
 				Role:    "system",
-				Content: c.getSystemPrompt(),
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
 				Content: prompt,
 			},
 		},
-		MaxTokens:   c.maxTokens,
-		Temperature: 0.7,
+		MaxTokens:      c.sampling.MaxTokens,
+		Temperature:    c.sampling.Temperature,
+		TopP:           c.sampling.TopP,
+		Seed:           c.sampling.Seed,
+		ResponseFormat: c.responseFormat(),
 	}
 
 	response, err := c.makeRequest(ctx, request)
@@ -112,6 +149,7 @@ func (c *OpenAIClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 		return nil, ErrGenerationFailed{
 			Model:  c.GetModelName(),
 			Reason: err.Error(),
+			Err:    err,
 		}
 	}
 
@@ -122,12 +160,11 @@ func (c *OpenAIClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 		}
 	}
 
-	testCode := response.Choices[0].Message.Content
 	generationTime := time.Since(startTime)
 
 	result := &TestGenerationResult{
-		TestCode:       testCode,
 		Prompt:         prompt,
+		SystemPrompt:   systemPrompt,
 		ModelUsed:      c.model,
 		Framework:      "testify",
 		TestCategories: []string{"happy-path", "error-handling", "boundary", "security"},
@@ -141,6 +178,8 @@ func (c *OpenAIClient) GenerateTest(ctx context.Context, endpoint *parser.Endpoi
 			"completion_tokens": fmt.Sprintf("%d", response.Usage.CompletionTokens),
 		},
 	}
+	maps.Copy(result.Metadata, c.sampling.Params())
+	result.applyAnswer(response.Choices[0].Message.Content)
 
 	log.Info().
 		Str("model", c.model).
@@ -161,107 +200,49 @@ func (c *OpenAIClient) GetCapabilities() ModelCapabilities {
 	return ModelCapabilities{
 		SupportsGoTests:      true,
 		SupportsSecurityTest: true,
-		SupportedFrameworks:  []string{"testify", "ginkgo", "standard"},
-		MaxTokens:            c.maxTokens,
+		SupportedFrameworks:  []string{"testify", "ginkgo", "standard", "grpc"},
+		MaxTokens:            c.sampling.MaxTokens,
 		Languages:            []string{"go", "python", "javascript", "java"},
 	}
 }
 
-// getSystemPrompt returns the system prompt for test generation
-func (c *OpenAIClient) getSystemPrompt() string {
-	return `You are an expert software testing engineer specializing in API integration testing with Go.
-
-Your task is to generate comprehensive integration tests for OpenAPI endpoints using the Go programming language and the testify framework.
-
-Generate tests that cover:
-1. Happy path scenarios with valid inputs
-2. Error handling with invalid inputs
-3. Boundary testing with edge cases
-4. Security testing for authentication/authorization
-5. Performance considerations
-
-Requirements:
-- Use Go with testify framework
-- Include proper imports and package declaration
-- Add comprehensive assertions
-- Generate realistic test data
-- Include setup and teardown if needed
-- Add clear test names and descriptions
-- Handle different HTTP methods appropriately
-- Validate response schemas and status codes
-- Test both positive and negative scenarios
-
-Provide clean, production-ready Go test code that can be executed immediately.`
-}
-
-// buildPrompt creates the detailed prompt for test generation
-func (c *OpenAIClient) buildPrompt(endpoint *parser.Endpoint) string {
-	var prompt bytes.Buffer
-
-	prompt.WriteString("Generate comprehensive integration tests for this OpenAPI endpoint:\n\n")
-	fmt.Fprintf(&prompt, "**Method:** %s\n", endpoint.Method)
-	fmt.Fprintf(&prompt, "**Path:** %s\n", endpoint.Path)
-
-	if endpoint.OperationID != "" {
-		fmt.Fprintf(&prompt, "**Operation ID:** %s\n", endpoint.OperationID)
+// HealthCheck verifies that the API accepts the key and serves the model
+func (c *OpenAIClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models/"+c.model, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return checkModel(c.client, req, c.model)
+}
 
-	if endpoint.Summary != "" {
-		fmt.Fprintf(&prompt, "**Summary:** %s\n", endpoint.Summary)
-	}
+// systemPrompt renders the openai-system prompt template for endpoint
+func (c *OpenAIClient) systemPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("openai-system", endpoint, c.environmentPrompt())
+}
 
-	if endpoint.Description != "" {
-		fmt.Fprintf(&prompt, "**Description:** %s\n\n", endpoint.Description)
-	}
+// buildPrompt renders the openai prompt template for endpoint
+func (c *OpenAIClient) buildPrompt(endpoint *parser.Endpoint) (string, error) {
+	return c.renderPrompt("openai", endpoint, c.environmentPrompt())
+}
 
-	// Parameters
-	if len(endpoint.Parameters) > 0 {
-		prompt.WriteString("**Parameters:**\n")
-		for i := range endpoint.Parameters {
-			param := &endpoint.Parameters[i]
-			required := "optional"
-			if param.Required {
-				required = "required"
-			}
-			fmt.Fprintf(&prompt, "- %s (%s, %s): %s - Type: %s\n",
-				param.Name, param.In, required, param.Description, param.Schema.Type)
-		}
-		prompt.WriteString("\n")
+// responseFormat returns the structured output request of the provider, or
+// nil for free text
+func (c *OpenAIClient) responseFormat() *OpenAIResponseFormat {
+	if !c.structured {
+		return nil
 	}
-
-	// Request Body
-	if endpoint.RequestBody != nil {
-		prompt.WriteString("**Request Body:**\n")
-		if endpoint.RequestBody.Description != "" {
-			fmt.Fprintf(&prompt, "Description: %s\n", endpoint.RequestBody.Description)
-		}
-		prompt.WriteString("Content Types:\n")
-		for contentType := range endpoint.RequestBody.Content {
-			mediaType := endpoint.RequestBody.Content[contentType]
-			fmt.Fprintf(&prompt, "- %s: %s\n", contentType, mediaType.Schema.Type)
-		}
-		prompt.WriteString("\n")
+	if providerOf(c) == "mistral" {
+		return &OpenAIResponseFormat{Type: "json_object"}
 	}
-
-	// Responses
-	if len(endpoint.Responses) > 0 {
-		prompt.WriteString("**Expected Responses:**\n")
-		for code, response := range endpoint.Responses {
-			fmt.Fprintf(&prompt, "- %s: %s\n", code, response.Description)
-		}
-		prompt.WriteString("\n")
+	return &OpenAIResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &OpenAIJSONSchema{
+			Name:   "generated_test",
+			Strict: true,
+			Schema: generatedTestSchema,
+		},
 	}
-
-	prompt.WriteString("Generate Go integration tests using testify that:\n")
-	prompt.WriteString("1. Test all documented response codes\n")
-	prompt.WriteString("2. Validate request/response schemas\n")
-	prompt.WriteString("3. Include error scenarios\n")
-	prompt.WriteString("4. Test parameter validation\n")
-	prompt.WriteString("5. Include performance assertions\n")
-	prompt.WriteString("6. Add security considerations\n")
-	prompt.WriteString("\nProvide complete, executable Go test code.")
-
-	return prompt.String()
 }
 
 // makeRequest makes an HTTP request to OpenAI API
@@ -289,15 +270,14 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, request OpenAIRequest) (
 		}
 	}()
 
+	if err := responseError(c.model, resp); err != nil {
+		return nil, err
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
 	var response OpenAIResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -306,39 +286,30 @@ func (c *OpenAIClient) makeRequest(ctx context.Context, request OpenAIRequest) (
 	return &response, nil
 }
 
-// NewOpenAIClientWithModel creates a new OpenAI client with a specific model
-func NewOpenAIClientWithModel(modelName string) (*OpenAIClient, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return nil, ErrAPIKeyMissing{Model: "OpenAI"}
+// NewMistralClient creates a client for Mistral AI (OpenAI-compatible API)
+func NewMistralClient(cfg OpenAIConfig, opts ...Option) (*OpenAIClient, error) {
+	if cfg.APIKey == "" {
+		return nil, ErrAPIKeyMissing{Model: "Mistral"}
 	}
-
-	return &OpenAIClient{
-		apiKey:    apiKey,
-		baseURL:   "https://api.openai.com/v1",
-		model:     modelName,
-		maxTokens: 4000,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-	}, nil
+	return newOpenAICompatible(cfg, resolve(opts,
+		orDefault(cfg.BaseURL, DefaultMistralBaseURL),
+		orDefault(cfg.Model, "mistral-large-latest"),
+		orDefault(cfg.Timeout, defaultCloudTimeout)))
 }
 
-// NewMistralClient creates a client for Mistral AI (OpenAI-compatible API).
-// Requires MISTRAL_API_KEY env var.
-func NewMistralClient(modelName string) (*OpenAIClient, error) {
-	apiKey := os.Getenv("MISTRAL_API_KEY")
-	if apiKey == "" {
-		return nil, ErrAPIKeyMissing{Model: "Mistral"}
+// newOpenAICompatible builds a client for any OpenAI-compatible chat API
+func newOpenAICompatible(cfg OpenAIConfig, o clientOptions) (*OpenAIClient, error) {
+	o.tls = cfg.TLS
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
 	}
-
 	return &OpenAIClient{
-		apiKey:    apiKey,
-		baseURL:   "https://api.mistral.ai/v1",
-		model:     modelName,
-		maxTokens: 4000,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		apiKey:          cfg.APIKey,
+		baseURL:         o.baseURL,
+		model:           o.model,
+		sampling:        cfg.Sampling.withDefaults(defaultCloudTemperature),
+		client:          client,
+		promptTemplates: promptTemplates{structured: structuredOutput(cfg.ResponseFormat)},
 	}, nil
 }
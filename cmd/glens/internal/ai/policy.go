@@ -0,0 +1,316 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+)
+
+// sensitivePatterns match personal data a prompt policy keeps out of
+// cloud prompts: email addresses, US social security numbers, payment card
+// numbers, international phone numbers and IBANs
+var sensitivePatterns = []string{
+	`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	`\b\d{3}-\d{2}-\d{4}\b`,
+	`\b(?:\d[ -]?){12,18}\d\b`,
+	`\+\d{1,3}[ .\-]?\(?\d{1,4}\)?(?:[ .\-]?\d{2,4}){2,4}`,
+	`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){3,7}(?: ?[A-Z0-9]{1,4})?\b`,
+}
+
+// PromptPolicyConfig is the prompt_policy section of the config
+type PromptPolicyConfig struct {
+	// Enabled strips spec descriptions and examples holding sensitive data
+	// from the prompts of cloud models
+	Enabled bool `mapstructure:"enabled"`
+	// Patterns are regular expressions of sensitive data, in addition to
+	// the built-in personal data and secret patterns
+	Patterns []string `mapstructure:"patterns"`
+	// Local also applies the policy to models running on this machine
+	Local bool `mapstructure:"local"`
+}
+
+// PromptPolicy strips the summaries, descriptions and examples of an
+// endpoint that match sensitive patterns before the endpoint is described
+// to a model. The contract (paths, parameters, schemas) is kept, so tests
+// can still be generated.
+type PromptPolicy struct {
+	patterns []*regexp.Regexp
+	local    bool
+}
+
+// NewPromptPolicy returns the policy of cfg; it is nil when the policy is
+// disabled
+func NewPromptPolicy(cfg PromptPolicyConfig) (*PromptPolicy, error) {
+	if !cfg.Enabled {
+		return nil, nil //nolint:nilnil // a disabled policy is not an error
+	}
+	policy := &PromptPolicy{local: cfg.Local}
+	for _, pattern := range sensitivePatterns {
+		policy.patterns = append(policy.patterns, regexp.MustCompile(pattern))
+	}
+	for i, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("prompt_policy.patterns[%d]: %w", i, err)
+		}
+		policy.patterns = append(policy.patterns, re)
+	}
+	return policy, nil
+}
+
+// sensitive reports whether s holds data matching the policy or a secret
+func (p *PromptPolicy) sensitive(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return redact.String(s) != s
+}
+
+// sensitiveValue reports whether an example value is sensitive
+func (p *PromptPolicy) sensitiveValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return p.sensitive(v)
+	default:
+		data, err := json.Marshal(v)
+		return err != nil || p.sensitive(string(data))
+	}
+}
+
+// Apply returns a copy of endpoint without its sensitive summaries,
+// descriptions and examples, and how many it stripped; endpoint itself is
+// left unchanged
+func (p *PromptPolicy) Apply(endpoint *parser.Endpoint) (*parser.Endpoint, int) {
+	out := *endpoint
+	stripped := 0
+	text := func(s string) string {
+		if p.sensitive(s) {
+			stripped++
+			return ""
+		}
+		return s
+	}
+	value := func(v interface{}) interface{} {
+		if p.sensitiveValue(v) {
+			stripped++
+			return nil
+		}
+		return v
+	}
+	var schema func(s parser.Schema) parser.Schema
+	schemas := func(list []parser.Schema) []parser.Schema {
+		if list == nil {
+			return nil
+		}
+		copied := make([]parser.Schema, len(list))
+		for i := range list {
+			copied[i] = schema(list[i])
+		}
+		return copied
+	}
+	schema = func(s parser.Schema) parser.Schema {
+		s.Description = text(s.Description)
+		s.Example = value(s.Example)
+		if s.Properties != nil {
+			properties := make(map[string]parser.Schema, len(s.Properties))
+			for name, property := range s.Properties {
+				properties[name] = schema(property)
+			}
+			s.Properties = properties
+		}
+		if s.Items != nil {
+			items := schema(*s.Items)
+			s.Items = &items
+		}
+		s.PrefixItems = schemas(s.PrefixItems)
+		s.OneOf = schemas(s.OneOf)
+		s.AnyOf = schemas(s.AnyOf)
+		s.AllOf = schemas(s.AllOf)
+		return s
+	}
+	content := func(media map[string]parser.MediaType) map[string]parser.MediaType {
+		if media == nil {
+			return nil
+		}
+		copied := make(map[string]parser.MediaType, len(media))
+		for contentType, m := range media {
+			m.Schema = schema(m.Schema)
+			m.Example = value(m.Example)
+			if m.Examples != nil {
+				examples := make(map[string]parser.Example, len(m.Examples))
+				for name, example := range m.Examples {
+					if p.sensitive(example.Summary) || p.sensitive(example.Description) || p.sensitiveValue(example.Value) {
+						stripped++
+						continue
+					}
+					examples[name] = example
+				}
+				m.Examples = examples
+			}
+			copied[contentType] = m
+		}
+		return copied
+	}
+
+	out.Summary = text(out.Summary)
+	out.Description = text(out.Description)
+	if out.Parameters != nil {
+		out.Parameters = make([]parser.Parameter, len(endpoint.Parameters))
+		for i, param := range endpoint.Parameters {
+			param.Description = text(param.Description)
+			param.Example = value(param.Example)
+			param.Schema = schema(param.Schema)
+			out.Parameters[i] = param
+		}
+	}
+	if endpoint.RequestBody != nil {
+		body := *endpoint.RequestBody
+		body.Description = text(body.Description)
+		body.Content = content(body.Content)
+		out.RequestBody = &body
+	}
+	if out.Responses != nil {
+		out.Responses = make(map[string]parser.Response, len(endpoint.Responses))
+		for code, response := range endpoint.Responses {
+			response.Description = text(response.Description)
+			response.Content = content(response.Content)
+			if response.Headers != nil {
+				headers := make(map[string]parser.Header, len(response.Headers))
+				for name, header := range response.Headers {
+					header.Description = text(header.Description)
+					header.Example = value(header.Example)
+					header.Schema = schema(header.Schema)
+					headers[name] = header
+				}
+				response.Headers = headers
+			}
+			out.Responses[code] = response
+		}
+	}
+	if out.Steps != nil {
+		out.Steps = make([]parser.ScenarioStep, len(endpoint.Steps))
+		for i, step := range endpoint.Steps {
+			stepEndpoint, n := p.Apply(&step.Endpoint)
+			step.Endpoint = *stepEndpoint
+			stripped += n
+			out.Steps[i] = step
+		}
+	}
+	return &out, stripped
+}
+
+// SetPromptPolicy applies policy to the endpoints described to cloud
+// models, and to local models when the policy says so; nil disables it
+func (m *Manager) SetPromptPolicy(policy *PromptPolicy) {
+	m.policy = policy
+}
+
+// applyPolicy returns the endpoint described to client under the manager's
+// prompt policy
+func (m *Manager) applyPolicy(client Client, modelName string, endpoint *parser.Endpoint) *parser.Endpoint {
+	if m.policy == nil || (!m.policy.local && isLocal(client)) {
+		return endpoint
+	}
+	sanitized, stripped := m.policy.Apply(endpoint)
+	if stripped > 0 {
+		log.Debug().
+			Str("ai_model", modelName).
+			Str("endpoint", endpoint.Method+" "+endpoint.Path).
+			Int("stripped", stripped).
+			Msg("Prompt policy stripped sensitive descriptions and examples")
+	}
+	return sanitized
+}
+
+// RemoteModel is a model whose prompts leave this machine
+type RemoteModel struct {
+	Model    string
+	Provider string
+	Host     string
+}
+
+// RemoteModels lists the models, fallbacks included, that send prompts
+// (and so spec content) to another machine, sorted by model
+func (m *Manager) RemoteModels() []RemoteModel {
+	var remote []RemoteModel
+	for name, client := range m.clients {
+		if isLocal(client) {
+			continue
+		}
+		remote = append(remote, RemoteModel{Model: name, Provider: providerOf(client), Host: hostOf(baseURLOf(client))})
+	}
+	sort.Slice(remote, func(i, j int) bool { return remote[i].Model < remote[j].Model })
+	return remote
+}
+
+// RequireLocal fails when any model would send spec content off this
+// machine (--local-only)
+func (m *Manager) RequireLocal() error {
+	remote := m.RemoteModels()
+	if len(remote) == 0 {
+		return nil
+	}
+	models := make([]string, len(remote))
+	for i, r := range remote {
+		models[i] = fmt.Sprintf("%s (%s at %s)", r.Model, r.Provider, r.Host)
+	}
+	return fmt.Errorf("local-only mode: %s would send spec content off this machine; use mock models, Ollama or an OpenAI-compatible server on localhost",
+		strings.Join(models, ", "))
+}
+
+// baseURLOf returns the API base URL of a client; empty for in-process
+// clients
+func baseURLOf(client Client) string {
+	switch c := client.(type) {
+	case *OpenAIClient:
+		return c.baseURL
+	case *AnthropicClient:
+		return c.baseURL
+	case *GoogleClient:
+		return c.baseURL
+	case *OllamaClient:
+		return c.baseURL
+	default:
+		return ""
+	}
+}
+
+// isLocal reports whether client runs in-process or calls a server on
+// the loopback interface
+func isLocal(client Client) bool {
+	base := baseURLOf(client)
+	if base == "" {
+		return true
+	}
+	host := hostOf(base)
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// hostOf returns the host name of a base URL
+func hostOf(base string) string {
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return base
+	}
+	return u.Hostname()
+}
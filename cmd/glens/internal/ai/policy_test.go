@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func sensitiveEndpoint() *parser.Endpoint {
+	return &parser.Endpoint{
+		Method:      "GET",
+		Path:        "/patients/{id}",
+		Summary:     "Get a patient",
+		Description: "Returns the record, e.g. for jane.doe@example.com",
+		Parameters: []parser.Parameter{{
+			Name: "id", In: "path", Required: true,
+			Description: "Medical record number",
+			Example:     "MRN-0042",
+			Schema:      parser.Schema{Type: "string"},
+		}},
+		Responses: map[string]parser.Response{
+			"200": {
+				Description: "The patient",
+				Content: map[string]parser.MediaType{
+					"application/json": {
+						Schema: parser.Schema{Type: "object", Properties: map[string]parser.Schema{
+							"ssn": {Type: "string", Example: "123-45-6789"},
+						}},
+						Example: map[string]any{"name": "Jane", "phone": "+1 555 010 0199"},
+						Examples: map[string]parser.Example{
+							"plain": {Value: map[string]any{"name": "Jane"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPromptPolicy_Apply(t *testing.T) {
+	policy, err := NewPromptPolicy(PromptPolicyConfig{Enabled: true, Patterns: []string{`MRN-\d+`}})
+	require.NoError(t, err)
+
+	endpoint := sensitiveEndpoint()
+	sanitized, stripped := policy.Apply(endpoint)
+
+	assert.Equal(t, 4, stripped)
+	assert.Equal(t, "Get a patient", sanitized.Summary)
+	assert.Empty(t, sanitized.Description)
+	assert.Equal(t, "Medical record number", sanitized.Parameters[0].Description)
+	assert.Nil(t, sanitized.Parameters[0].Example)
+	media := sanitized.Responses["200"].Content["application/json"]
+	assert.Nil(t, media.Example)
+	assert.Nil(t, media.Schema.Properties["ssn"].Example)
+	assert.Equal(t, "string", media.Schema.Properties["ssn"].Type)
+	assert.Contains(t, media.Examples, "plain")
+
+	// The endpoint itself is left unchanged
+	assert.Equal(t, sensitiveEndpoint(), endpoint)
+}
+
+func TestNewPromptPolicy(t *testing.T) {
+	policy, err := NewPromptPolicy(PromptPolicyConfig{Patterns: []string{"("}})
+	require.NoError(t, err)
+	assert.Nil(t, policy, "a disabled policy is nil")
+
+	_, err = NewPromptPolicy(PromptPolicyConfig{Enabled: true, Patterns: []string{"("}})
+	assert.ErrorContains(t, err, "prompt_policy.patterns[0]")
+}
+
+func TestManager_RequireLocal(t *testing.T) {
+	local, err := NewOllamaClient(OllamaConfig{BaseURL: "http://127.0.0.1:11434"})
+	require.NoError(t, err)
+	lan, err := NewOllamaClient(OllamaConfig{BaseURL: "http://gpu-box.internal:11434"})
+	require.NoError(t, err)
+	localOpenAI, err := NewOpenAIClient(OpenAIConfig{APIKey: "key", BaseURL: "http://localhost:8000/v1"})
+	require.NoError(t, err)
+	cloud, err := NewOpenAIClient(OpenAIConfig{APIKey: "key"}, WithModel("gpt-4o"))
+	require.NoError(t, err)
+
+	manager := &Manager{clients: map[string]Client{
+		"ollama":   local,
+		"vllm":     localOpenAI,
+		"mock":     NewMockClient("mock"),
+		"gpt-4o":   cloud,
+		"ollama_x": lan,
+	}}
+	assert.Equal(t, []RemoteModel{
+		{Model: "gpt-4o", Provider: "openai", Host: "api.openai.com"},
+		{Model: "ollama_x", Provider: "ollama", Host: "gpu-box.internal"},
+	}, manager.RemoteModels())
+	err = manager.RequireLocal()
+	assert.ErrorContains(t, err, "gpt-4o (openai at api.openai.com), ollama_x (ollama at gpu-box.internal) would send spec content off this machine")
+
+	delete(manager.clients, "gpt-4o")
+	delete(manager.clients, "ollama_x")
+	assert.NoError(t, manager.RequireLocal())
+}
+
+func TestManager_PromptPolicy(t *testing.T) {
+	policy, err := NewPromptPolicy(PromptPolicyConfig{Enabled: true})
+	require.NoError(t, err)
+	manager := &Manager{clients: map[string]Client{"mock": NewMockClient("mock")}}
+	manager.SetPromptPolicy(policy)
+
+	// Local models see the spec unless the policy covers them
+	endpoint := sensitiveEndpoint()
+	assert.Same(t, endpoint, manager.applyPolicy(manager.clients["mock"], "mock", endpoint))
+
+	policy.local = true
+	sanitized := manager.applyPolicy(manager.clients["mock"], "mock", endpoint)
+	assert.Empty(t, sanitized.Description)
+
+	result, err := manager.Generate(context.Background(), "mock", endpoint)
+	require.NoError(t, err)
+	assert.NotContains(t, result.Prompt, "jane.doe@example.com")
+}
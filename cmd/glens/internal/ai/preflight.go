@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HealthChecker is implemented by clients that can verify, without
+// generating anything, that their provider is reachable, accepts the
+// credentials and serves the configured model
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ErrPreflightFailed is returned when a model fails its health check
+type ErrPreflightFailed struct {
+	Model  string
+	Reason string
+}
+
+func (e ErrPreflightFailed) Error() string {
+	return fmt.Sprintf("AI model '%s' failed preflight: %s", e.Model, e.Reason)
+}
+
+// Preflight health-checks every model of the manager concurrently and
+// returns the failures, sorted by model, joined into one error. Models
+// whose client cannot be checked (mocks) pass.
+func (m *Manager) Preflight(ctx context.Context) error {
+	var failures []ErrPreflightFailed
+	for name, err := range m.Health(ctx) {
+		if err != nil {
+			failures = append(failures, ErrPreflightFailed{Model: name, Reason: err.Error()})
+		}
+	}
+
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Model < failures[j].Model })
+	errs := make([]error, len(failures))
+	for i, failure := range failures {
+		errs[i] = failure
+	}
+	return errors.Join(errs...)
+}
+
+// Health health-checks every model of the manager concurrently and returns
+// the error of each, nil when healthy. Models whose client cannot be
+// checked (mocks) are left out.
+func (m *Manager) Health(ctx context.Context) map[string]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error)
+	)
+	for name, client := range m.clients {
+		checker, ok := client.(HealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := checker.HealthCheck(ctx)
+			log.Debug().Err(err).Str("ai_model", name).Msg("Preflight check finished")
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// checkModel sends req, a lookup of a provider's model, and describes a
+// failed lookup by what its status means for generation
+func checkModel(client *http.Client, req *http.Request, model string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("provider not reachable: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Debug().Err(closeErr).Msg("failed to close response body")
+		}
+	}()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	status := fmt.Sprintf("status %d", resp.StatusCode)
+	if detail := strings.TrimSpace(string(body)); detail != "" {
+		status += ": " + detail
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("API key rejected (status %d)", resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("model %q not found", model)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("rate limit or quota exceeded (%s)", status)
+	default:
+		return fmt.Errorf("API error (%s)", status)
+	}
+}
@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Preflight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/models/gpt-4o" && r.Header.Get("Authorization") == "Bearer good":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/models/gpt-4o":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v1/models/claude-haiku-4-5":
+			assert.Equal(t, "good", r.Header.Get("x-api-key"))
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/models/gemini-2.0-flash":
+			assert.Equal(t, "good", r.Header.Get("x-goog-api-key"))
+			assert.Empty(t, r.URL.RawQuery, "the key is not sent in the URL")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	ok, err := NewOpenAIClient(OpenAIConfig{APIKey: "good", BaseURL: srv.URL}, WithModel("gpt-4o"))
+	require.NoError(t, err)
+	badKey, err := NewOpenAIClient(OpenAIConfig{APIKey: "bad", BaseURL: srv.URL}, WithModel("gpt-4o"))
+	require.NoError(t, err)
+	missing, err := NewAnthropicClient(AnthropicConfig{APIKey: "good", BaseURL: srv.URL}, WithModel("claude-haiku-4-5"))
+	require.NoError(t, err)
+	quota, err := NewGoogleClient(GoogleConfig{APIKey: "good", BaseURL: srv.URL}, WithModel("gemini-2.0-flash"))
+	require.NoError(t, err)
+
+	manager := &Manager{clients: map[string]Client{
+		"gpt-4o":           ok,
+		"gpt-bad":          badKey,
+		"claude-haiku-4":   missing,
+		"gemini-2.0-flash": quota,
+		"mock":             NewMockClient("mock"),
+	}}
+	err = manager.Preflight(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "AI model 'claude-haiku-4' failed preflight: model \"claude-haiku-4-5\" not found\n"+
+		"AI model 'gemini-2.0-flash' failed preflight: rate limit or quota exceeded (status 429)\n"+
+		"AI model 'gpt-bad' failed preflight: API key rejected (status 401)", err.Error())
+	assert.ErrorAs(t, err, &ErrPreflightFailed{})
+
+	delete(manager.clients, "gpt-bad")
+	delete(manager.clients, "claude-haiku-4")
+	delete(manager.clients, "gemini-2.0-flash")
+	assert.NoError(t, manager.Preflight(context.Background()))
+}
+
+func TestNewManager_JoinsErrors(t *testing.T) {
+	_, err := NewManager([]string{"gpt4", "mock", "gemini-2.0-flash"}, Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "'OpenAI'")
+	assert.Contains(t, err.Error(), "'Google'")
+	assert.ErrorAs(t, err, &ErrAPIKeyMissing{})
+}
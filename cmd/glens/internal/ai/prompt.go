@@ -0,0 +1,384 @@
+package ai
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/parser"
+)
+
+// PromptExt is the file extension of prompt templates
+const PromptExt = ".tmpl"
+
+// RepairPrompt is the kind of the template asking a model to fix a test
+// that failed to compile or run
+const RepairPrompt = "repair"
+
+// ReceiverPrompt is the kind of the template describing how to test a
+// webhook or callback, which the API sends instead of receiving
+const ReceiverPrompt = "receiver"
+
+// PaginationPrompt is the kind of the template describing how to test the
+// pages of a paginated list endpoint
+const PaginationPrompt = "pagination"
+
+// RateLimitPrompt is the kind of the template describing how to test the
+// documented rate limiting of an endpoint
+const RateLimitPrompt = "ratelimit"
+
+// SchemaPrompt is the kind of the template describing how to validate
+// response bodies against the spec with glens/assertspec
+const SchemaPrompt = "assertspec"
+
+// HintsPrompt is the kind of the template describing the guidance of an
+// endpoint's x-glens extensions: authentication, test data and priority
+const HintsPrompt = "hints"
+
+// ScenarioPrompt is the kind of the template describing how to test a
+// scenario, a sequence of requests across dependent endpoints
+const ScenarioPrompt = "scenario"
+
+// GraphQLPrompt is the kind of the template describing how to call and
+// test a query or mutation of a GraphQL schema
+const GraphQLPrompt = "graphql"
+
+// GRPCPrompt is the kind of the template describing how to call and test a
+// method of a gRPC service
+const GRPCPrompt = "grpc"
+
+//go:embed prompts/*.tmpl
+var embeddedPrompts embed.FS
+
+// PromptData is the data prompt templates are executed with. The endpoint's
+// fields are promoted, so templates use {{.Method}}, {{.Path}},
+// {{.OperationID}}, {{.Summary}}, {{.Description}}, {{.Tags}},
+// {{.Parameters}}, {{.RequestBody}} and {{.Responses}} directly.
+type PromptData struct {
+	*parser.Endpoint
+	// Model is the glens model name the prompt is for, e.g. "gpt4"
+	Model string
+	// Category is the endpoint's safety category: read, write, mutate or destroy
+	Category string
+	// Risk is the endpoint's risk level: safe, medium or high
+	Risk string
+	// Environment describes the target environment, ending in a blank
+	// line; it is empty when no environment is configured
+	Environment string
+	// Examples are the user's exemplar tests most relevant to the endpoint
+	Examples []Example
+	// Structured is set when the provider is asked for a JSON answer with
+	// test_code, imports, notes and categories instead of free text
+	Structured bool
+	// Hints is the guidance of the endpoint's x-glens extensions, ending in
+	// a blank line; it is empty for endpoints without them
+	Hints string
+	// Receiver describes how to test a webhook or callback endpoint,
+	// ending in a blank line; it is empty for path operations
+	Receiver string
+	// Paginated describes how to test the pages of a paginated list
+	// endpoint, ending in a blank line; it is empty for other endpoints
+	Paginated string
+	// RateLimited describes how to test the documented rate-limit headers
+	// and 429 responses, ending in a blank line; it is empty for endpoints
+	// without them
+	RateLimited string
+	// SchemaValidation describes how to validate response bodies with
+	// glens/assertspec, ending in a blank line; it is empty for endpoints
+	// without JSON response schemas
+	SchemaValidation string
+	// Scenario describes the steps of a scenario and how values pass
+	// between them, ending in a blank line; it is empty for other endpoints
+	Scenario string
+	// GraphQLRequest describes how to post a GraphQL query or mutation and
+	// check its errors, ending in a blank line; it is empty for other
+	// endpoints
+	GraphQLRequest string
+	// GRPCCall describes how to call a gRPC method and check its status,
+	// ending in a blank line; it is empty for other endpoints
+	GRPCCall string
+	// WebhookAddr is the environment variable webhook tests receive on
+	WebhookAddr string
+	// RateLimitBurst is the environment variable holding the number of
+	// requests throttling tests may send
+	RateLimitBurst string
+	// TestCode and Failure are the failing test and the compiler or test
+	// output it produced; they are set for repair prompts only
+	TestCode string
+	Failure  string
+}
+
+// Prompts renders test generation prompts from text/template files. A
+// template is looked up as <model>.<category>, <model>, <kind>.<category>
+// and finally <kind> (e.g. "sonnet4.destroy", "sonnet4", "anthropic.destroy",
+// "anthropic"), first in the override directory and then among the built-in
+// templates. Kinds are the providers (openai, anthropic, google, ollama),
+// "openai-system", "anthropic-system" and "ollama-system" for the system
+// prompts of OpenAI, Anthropic and Ollama chat, and "repair" for every
+// provider's repair prompt, overridden per model as <model>-repair.
+type Prompts struct {
+	examples    []Example
+	maxExamples int
+
+	mu    sync.Mutex
+	cache map[string]*template.Template // key: template name
+}
+
+// DefaultPrompts renders the built-in templates without examples
+var DefaultPrompts = &Prompts{}
+
+// NewPrompts returns prompts that prefer the templates in dir over the
+// built-in ones; an empty dir uses the built-ins only. Every template in dir
+// is parsed up front so syntax errors surface before any generation.
+func NewPrompts(dir string) (*Prompts, error) {
+	p := &Prompts{cache: make(map[string]*template.Template)}
+	if dir == "" {
+		return p, nil
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to read prompt directory: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+PromptExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), PromptExt)
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		tmpl, err := parsePrompt(name, string(content))
+		if err != nil {
+			return nil, err
+		}
+		p.cache[name] = tmpl
+	}
+	return p, nil
+}
+
+// SetExamples makes prompts include up to limit of the examples most
+// relevant to each endpoint as few-shot context
+func (p *Prompts) SetExamples(examples []Example, limit int) {
+	p.examples = examples
+	p.maxExamples = limit
+}
+
+// Render executes the most specific template for kind, model and the
+// category of data. For endpoints with x-glens extensions the hints template
+// is rendered into data.Hints first, for webhooks and callbacks the receiver
+// template into data.Receiver, for paginated endpoints the pagination
+// template into data.Paginated, for rate-limited endpoints the ratelimit
+// template into data.RateLimited, for endpoints with JSON response schemas
+// the assertspec template into data.SchemaValidation, for scenarios the
+// scenario template into data.Scenario, for GraphQL operations the graphql
+// template into data.GraphQLRequest and for gRPC methods the grpc template
+// into data.GRPCCall.
+func (p *Prompts) Render(kind string, data *PromptData) (string, error) {
+	if kind != HintsPrompt && data.Endpoint != nil && data.Hints == "" && data.HasHints() {
+		hints, err := p.Render(HintsPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.Hints = hints
+	}
+	if kind != ReceiverPrompt && data.Endpoint != nil && data.Incoming() && data.Receiver == "" {
+		data.WebhookAddr = environment.EnvWebhookAddr
+		receiver, err := p.Render(ReceiverPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.Receiver = receiver
+	}
+	if kind != PaginationPrompt && data.Endpoint != nil && data.Pagination != nil && data.Paginated == "" {
+		paginated, err := p.Render(PaginationPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.Paginated = paginated
+	}
+	if kind != RateLimitPrompt && data.Endpoint != nil && data.RateLimited == "" && data.RateLimit() != nil {
+		data.RateLimitBurst = environment.EnvRateLimitBurst
+		rateLimited, err := p.Render(RateLimitPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.RateLimited = rateLimited
+	}
+	if kind != SchemaPrompt && data.Endpoint != nil && data.SchemaValidation == "" && len(data.JSONResponseSchemas()) > 0 {
+		validation, err := p.Render(SchemaPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.SchemaValidation = validation
+	}
+	if kind != ScenarioPrompt && data.Endpoint != nil && len(data.Steps) > 0 && data.Scenario == "" {
+		scenario, err := p.Render(ScenarioPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.Scenario = scenario
+	}
+	if kind != GraphQLPrompt && data.Endpoint != nil && data.GraphQL != nil && data.GraphQLRequest == "" {
+		request, err := p.Render(GraphQLPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.GraphQLRequest = request
+	}
+	if kind != GRPCPrompt && data.Endpoint != nil && data.GRPC != nil && data.GRPCCall == "" {
+		call, err := p.Render(GRPCPrompt, data)
+		if err != nil {
+			return "", err
+		}
+		data.GRPCCall = call
+	}
+
+	tmpl, err := p.lookup(promptCandidates(kind, data.Model, data.Category))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %q: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// lookup returns the first candidate template that exists
+func (p *Prompts) lookup(candidates []string) (*template.Template, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cache == nil {
+		p.cache = make(map[string]*template.Template)
+	}
+
+	// Templates of the override directory were all cached by NewPrompts
+	for _, name := range candidates {
+		if tmpl, ok := p.cache[name]; ok {
+			return tmpl, nil
+		}
+	}
+	for _, name := range candidates {
+		content, err := embeddedPrompts.ReadFile("prompts/" + name + PromptExt)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		tmpl, err := parsePrompt(name, string(content))
+		if err != nil {
+			return nil, err
+		}
+		p.cache[name] = tmpl
+		return tmpl, nil
+	}
+	return nil, fmt.Errorf("no prompt template found (tried %s)", strings.Join(candidates, ", "))
+}
+
+// promptCandidates lists template names from most to least specific
+func promptCandidates(kind, model, category string) []string {
+	bases := []string{kind}
+	if model != "" {
+		// Model names such as "ollama:codellama" are not portable file names
+		model = strings.NewReplacer(":", "_", "/", "_").Replace(model)
+		if _, message, ok := strings.Cut(kind, "-"); ok {
+			model += "-" + message
+		} else if kind == RepairPrompt || kind == ReceiverPrompt || kind == PaginationPrompt || kind == RateLimitPrompt || kind == SchemaPrompt || kind == ScenarioPrompt || kind == HintsPrompt || kind == GraphQLPrompt || kind == GRPCPrompt {
+			model += "-" + kind
+		}
+		bases = []string{model, kind}
+	}
+
+	var names []string
+	for _, base := range bases {
+		if category != "" {
+			names = append(names, base+"."+category)
+		}
+		names = append(names, base)
+	}
+	return names
+}
+
+func parsePrompt(name, content string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"join":  strings.Join,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template %q: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// promptTemplates is embedded by prompt-building clients so their prompts
+// can be customised with templates
+type promptTemplates struct {
+	prompts    *Prompts
+	name       string
+	structured bool
+}
+
+// PromptAware is implemented by clients whose prompts are rendered from
+// templates
+type PromptAware interface {
+	SetPrompts(prompts *Prompts, model string)
+}
+
+// SetPrompts selects the templates prompts are rendered from and the model
+// name used to find per-model templates
+func (p *promptTemplates) SetPrompts(prompts *Prompts, model string) {
+	p.prompts = prompts
+	p.name = model
+}
+
+// renderPrompt renders the kind template for endpoint
+func (p *promptTemplates) renderPrompt(kind string, endpoint *parser.Endpoint, env string) (string, error) {
+	prompts := p.prompts
+	if prompts == nil {
+		prompts = DefaultPrompts
+	}
+	return prompts.Render(kind, p.promptData(prompts, endpoint, env))
+}
+
+// renderRepairPrompt renders the repair template asking the model to fix
+// testCode, which failed with failure
+func (p *promptTemplates) renderRepairPrompt(endpoint *parser.Endpoint, env, testCode, failure string) (string, error) {
+	prompts := p.prompts
+	if prompts == nil {
+		prompts = DefaultPrompts
+	}
+	data := p.promptData(prompts, endpoint, env)
+	data.TestCode = testCode
+	data.Failure = failure
+	return prompts.Render(RepairPrompt, data)
+}
+
+// promptData is the data templates are executed with for endpoint
+func (p *promptTemplates) promptData(prompts *Prompts, endpoint *parser.Endpoint, env string) *PromptData {
+	category := safety.Categorise(endpoint.Method, endpoint.Path, endpoint.XSafe).WithRisk(endpoint.DeclaredRisk())
+	return &PromptData{
+		Endpoint:    endpoint,
+		Model:       p.name,
+		Category:    string(category.Category),
+		Risk:        string(category.Risk),
+		Environment: env,
+		Examples:    SelectExamples(prompts.examples, endpoint, prompts.maxExamples),
+		Structured:  p.structured,
+	}
+}
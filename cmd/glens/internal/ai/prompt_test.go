@@ -0,0 +1,314 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func promptEndpoint() *parser.Endpoint {
+	return &parser.Endpoint{
+		Method:      "DELETE",
+		Path:        "/users/{id}",
+		OperationID: "deleteUser",
+		Summary:     "Delete a user",
+		Parameters: []parser.Parameter{
+			{Name: "id", In: "path", Required: true, Description: "User ID", Schema: parser.Schema{Type: "string"}},
+		},
+		Responses: map[string]parser.Response{
+			"404": {Description: "Not found"},
+			"204": {Description: "Deleted"},
+		},
+	}
+}
+
+func writePrompt(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+PromptExt), []byte(content), 0o600))
+}
+
+func TestDefaultPrompts_BuiltinTemplates(t *testing.T) {
+	for _, kind := range []string{"openai", "openai-system", "anthropic", "anthropic-system", "google", "ollama", "ollama-system", RepairPrompt} {
+		t.Run(kind, func(t *testing.T) {
+			prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: promptEndpoint(), Model: "gpt4", Category: "destroy"})
+			require.NoError(t, err)
+			assert.NotEmpty(t, prompt)
+			assert.NotContains(t, prompt, "<no value>")
+		})
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "**Operation ID:** deleteUser")
+	assert.Contains(t, prompt, "- id (path, required): User ID - Type: string")
+	assert.Contains(t, prompt, "- 204: Deleted\n- 404: Not found", "responses are sorted by code")
+
+	maxLength := 20
+	endpoint := promptEndpoint()
+	endpoint.Parameters[0].Schema = parser.Schema{Type: "string", Nullable: true, MaxLength: &maxLength, Format: "uuid"}
+	prompt, err = DefaultPrompts.Render("anthropic", &PromptData{Endpoint: endpoint})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "[Type: string or null; max length 20, format uuid]")
+
+	endpoint.Servers = []parser.Server{{URL: "https://upload.example.com"}}
+	prompt, err = DefaultPrompts.Render("openai", &PromptData{Endpoint: endpoint})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "**Server:** https://upload.example.com (this operation is served here")
+}
+
+func TestDefaultPrompts_Receiver(t *testing.T) {
+	webhook := &parser.Endpoint{Kind: parser.KindWebhook, Method: "POST", Path: "newPet"}
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: webhook})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "**Webhook:** the API sends this POST request to subscribers of the `newPet` webhook")
+	assert.Contains(t, prompt, `os.Getenv("GLENS_WEBHOOK_ADDR")`)
+
+	callback := &parser.Endpoint{Kind: parser.KindCallback, Method: "POST", Path: "{$request.body#/url}", Trigger: "POST /subscriptions"}
+	prompt, err = DefaultPrompts.Render("anthropic", &PromptData{Endpoint: callback})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "in the request of POST /subscriptions")
+	assert.Contains(t, prompt, "Call POST /subscriptions on the API")
+
+	prompt, err = DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "receiver test")
+}
+
+func TestDefaultPrompts_Pagination(t *testing.T) {
+	endpoint := &parser.Endpoint{Method: "GET", Path: "/events", Pagination: &parser.Pagination{
+		Style: parser.PaginationCursor, CursorParam: "cursor", SizeParam: "limit", NextCursor: "meta.next_cursor", MaxSize: 100,
+	}}
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama"} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: endpoint})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "**Pagination:** this list endpoint pages its results by cursor with the `cursor` query parameter and the page size in `limit` (at most 100).", kind)
+		assert.Contains(t, prompt, "4. Cursor traversal: follow the `meta.next_cursor` response field", kind)
+		assert.Contains(t, prompt, "5. Oversized page: set `limit` above 100", kind)
+	}
+
+	endpoint.Pagination = &parser.Pagination{Style: parser.PaginationPage, PageParam: "page", FirstPage: 1, Items: "results"}
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: endpoint})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "by page with the `page` query parameter; the items are in the `results` response field.")
+	assert.Contains(t, prompt, "1. First page: call with `page` set to 1")
+	assert.Contains(t, prompt, "3. Out of range: set `page` far beyond the last page")
+
+	prompt, err = DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "Pagination")
+}
+
+func TestDefaultPrompts_Hints(t *testing.T) {
+	endpoint := promptEndpoint()
+	endpoint.Extensions = map[string]interface{}{
+		"x-glens-auth":      "none",
+		"x-glens-priority":  "high",
+		"x-glens-test-data": map[string]interface{}{"id": "user-42"},
+	}
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama", RepairPrompt} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: endpoint})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "**Authentication:** this endpoint is public: send no credentials", kind)
+		assert.Contains(t, prompt, "instead of invented ones:\n```json\n{\n  \"id\": \"user-42\"\n}\n```\n", kind)
+		assert.Contains(t, prompt, "**Priority:** high: cover this endpoint thoroughly", kind)
+	}
+
+	endpoint.Extensions = map[string]interface{}{"x-glens-priority": "low"}
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: endpoint})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "**Priority:** low: keep the test short, covering the success case and the main documented error.\n\n")
+	assert.NotContains(t, prompt, "**Authentication:**")
+
+	prompt, err = DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "**Priority:**")
+}
+
+func TestDefaultPrompts_Scenario(t *testing.T) {
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama", RepairPrompt} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: scenarioEndpoint()})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "**Scenario:** instead of a single request, test this workflow across dependent endpoints (Lifecycle of a resource of /pets: create, read, delete).", kind)
+		assert.Contains(t, prompt, "- create: POST /pets (createPet), expect 201; application/json body object (required: name, age); save the response field `data.id` as petId", kind)
+		assert.Contains(t, prompt, "- read: GET /pets/{petId} (getPet), expect 200\n- delete: DELETE /pets/{petId}, expect 204\n", kind)
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "Scenario")
+}
+
+func TestDefaultPrompts_GraphQL(t *testing.T) {
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama", RepairPrompt} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: graphQLEndpoint()})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "**GraphQL:** this endpoint is the `addPet` field of the mutation type of a GraphQL API, returning Pet!;", kind)
+		assert.Contains(t, prompt, "```graphql\nmutation AddPet($input: NewPet!) {\n  addPet(input: $input) {\n    id\n  }\n}\n```\n", kind)
+		assert.Contains(t, prompt, "that `data.addPet` has the documented shape", kind)
+		assert.Contains(t, prompt, "Clean up what the mutation creates with t.Cleanup.\n\n", kind)
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "GraphQL")
+}
+
+func TestDefaultPrompts_GRPC(t *testing.T) {
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama", RepairPrompt} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: grpcEndpoint()})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "**gRPC:** this endpoint is the server streaming method `petstore.v1.PetService/WatchPets` of a gRPC service, taking a petstore.v1.ListPetsRequest and returning a stream of petstore.v1.Pet;", kind)
+		assert.Contains(t, prompt, "(loaded from the descriptor set /specs/petstore.protoset) and invoke it with conn.NewStream", kind)
+		assert.Contains(t, prompt, "run `grpcurl -protoset /specs/petstore.protoset -d @ <host:port> petstore.v1.PetService/WatchPets`", kind)
+		assert.Contains(t, prompt, "via status.Code) instead of an HTTP status.\n\n", kind)
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "gRPC")
+}
+
+func TestDefaultPrompts_RateLimit(t *testing.T) {
+	endpoint := promptEndpoint()
+	endpoint.Responses["204"] = parser.Response{Description: "Deleted", Headers: map[string]parser.Header{
+		"X-RateLimit-Remaining": {}, "X-RateLimit-Limit": {},
+	}}
+	endpoint.Responses["429"] = parser.Response{Description: "Too many requests", Headers: map[string]parser.Header{"Retry-After": {}}}
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama", RepairPrompt} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: endpoint})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "Deleted (headers: X-RateLimit-Limit, X-RateLimit-Remaining)", kind)
+		assert.Contains(t, prompt, "**Rate limiting:** responses carry the rate-limit headers X-RateLimit-Limit, X-RateLimit-Remaining; the API answers 429 Too Many Requests when the limit is exceeded, with Retry-After.", kind)
+		assert.Contains(t, prompt, `2. Throttling: skip with t.Skip unless os.Getenv("GLENS_RATE_LIMIT_BURST") is set`, kind)
+		if kind != RepairPrompt {
+			assert.Contains(t, prompt, "Assert the documented response headers: a Location on 201", kind)
+		}
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "Rate limiting")
+	assert.NotContains(t, prompt, "documented response headers")
+}
+
+func TestDefaultPrompts_SchemaValidation(t *testing.T) {
+	endpoint := promptEndpoint()
+	endpoint.Responses["404"] = parser.Response{Description: "Not found", Content: map[string]parser.MediaType{
+		"application/json": {Schema: parser.Schema{Type: "object", Required: []string{"message"}}},
+	}}
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama", RepairPrompt} {
+		prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: endpoint})
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "**Schema validation:** the documented JSON response bodies are validated by the `glens/assertspec` package", kind)
+		assert.Contains(t, prompt, `assertspec.Body(t, "DELETE /users/{id}", resp.StatusCode, body)`, kind)
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "assertspec", "endpoints without JSON response schemas")
+}
+
+func TestNewPrompts_LookupOrder(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "anthropic", "provider {{.Method}}")
+	writePrompt(t, dir, "anthropic.destroy", "provider destroy {{.Risk}}")
+	writePrompt(t, dir, "sonnet4", "model {{.Model}}")
+	writePrompt(t, dir, "ollama_codellama.read", "ollama model read")
+	writePrompt(t, dir, "gpt4-system", "system for {{.Model}}")
+	writePrompt(t, dir, "sonnet4-repair", "repair for {{.Model}}")
+
+	p, err := NewPrompts(dir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		kind, model, category string
+		want                  string
+	}{
+		{"anthropic", "sonnet4", "destroy", "model sonnet4"},
+		{"anthropic", "claude-3.5-sonnet", "destroy", "provider destroy high"},
+		{"anthropic", "claude-3.5-sonnet", "read", "provider DELETE"},
+		{"ollama", "ollama:codellama", "read", "ollama model read"},
+		{"openai-system", "gpt4", "read", "system for gpt4"},
+		{RepairPrompt, "sonnet4", "read", "repair for sonnet4"},
+	}
+	for _, tt := range tests {
+		data := &PromptData{Endpoint: promptEndpoint(), Model: tt.model, Category: tt.category, Risk: "high"}
+		got, err := p.Render(tt.kind, data)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s/%s/%s", tt.kind, tt.model, tt.category)
+	}
+
+	// Kinds without an override fall back to the built-in template
+	got, err := p.Render("google", &PromptData{Endpoint: promptEndpoint(), Model: "flash-pro"})
+	require.NoError(t, err)
+	assert.Contains(t, got, "**ENDPOINT SPECIFICATION:**")
+}
+
+func TestNewPrompts_Errors(t *testing.T) {
+	_, err := NewPrompts(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "failed to read prompt directory")
+
+	dir := t.TempDir()
+	writePrompt(t, dir, "openai", "{{.Method")
+	_, err = NewPrompts(dir)
+	assert.ErrorContains(t, err, `failed to parse prompt template "openai"`)
+
+	dir = t.TempDir()
+	writePrompt(t, dir, "openai", "{{.Nope}}")
+	p, err := NewPrompts(dir)
+	require.NoError(t, err)
+	_, err = p.Render("openai", &PromptData{Endpoint: promptEndpoint()})
+	assert.ErrorContains(t, err, `failed to render prompt template "openai"`)
+
+	_, err = DefaultPrompts.Render("unknown", &PromptData{Endpoint: promptEndpoint()})
+	assert.ErrorContains(t, err, "no prompt template found (tried unknown)")
+}
+
+func TestManager_SetPrompts(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "mistral-local", "custom prompt for {{.Model}} {{.Method}} {{.Path}}")
+	p, err := NewPrompts(dir)
+	require.NoError(t, err)
+
+	m, err := NewManager([]string{"mistral-local"}, Config{})
+	require.NoError(t, err)
+	m.SetPrompts(p)
+
+	local, ok := m.clients["mistral-local"].(*OllamaClient)
+	require.True(t, ok)
+	prompt, err := local.buildPrompt(testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+	assert.Equal(t, "custom prompt for mistral-local GET /users", prompt)
+}
+
+func TestDefaultPrompts_Variants(t *testing.T) {
+	endpoint := &parser.Endpoint{Method: "POST", Path: "/pets", RequestBody: &parser.RequestBody{
+		Content: map[string]parser.MediaType{"application/json": {Schema: parser.Schema{
+			OneOf: []parser.Schema{
+				{Ref: "#/components/schemas/Cat", Type: "object", Required: []string{"petType", "hunts"}},
+				{Ref: "#/components/schemas/Dog", Type: "object", Required: []string{"petType", "bark"}},
+			},
+			Discriminator: &parser.Discriminator{PropertyName: "petType", Mapping: map[string]string{"dog": "#/components/schemas/Dog"}},
+		}}},
+	}}
+
+	for _, kind := range []string{"openai", "anthropic", "google", "ollama"} {
+		t.Run(kind, func(t *testing.T) {
+			prompt, err := DefaultPrompts.Render(kind, &PromptData{Endpoint: endpoint})
+			require.NoError(t, err)
+			assert.Contains(t, prompt, "one test per variant")
+			assert.Contains(t, prompt, "Cat (petType: Cat)")
+			assert.Contains(t, prompt, "Dog (petType: dog)")
+		})
+	}
+
+	prompt, err := DefaultPrompts.Render("openai", &PromptData{Endpoint: endpoint})
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "- application/json: oneOf(Cat, Dog)\n")
+	assert.Contains(t, prompt, "- Dog (petType: dog), required: petType, bark")
+}
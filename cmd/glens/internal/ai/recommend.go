@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const gib = 1 << 30
+
+// Hardware is the memory local models can use
+type Hardware struct {
+	// MemoryBytes is the total system memory, 0 when unknown
+	MemoryBytes int64
+	// GPUMemoryBytes is the memory of the largest NVIDIA GPU, 0 when there
+	// is none or nvidia-smi is not installed
+	GPUMemoryBytes int64
+}
+
+// OllamaRecommendation is a local code model suited to some hardware
+type OllamaRecommendation struct {
+	Model  string
+	Reason string
+}
+
+// ollamaSizes are the local code models recommended by size, largest first,
+// with the memory each needs to run on a GPU or, slower, on the CPU
+var ollamaSizes = []struct {
+	model     string
+	gpuMemory int64
+	memory    int64
+}{
+	{"qwen2.5-coder:32b", 24 * gib, 48 * gib},
+	{"qwen2.5-coder:14b", 12 * gib, 24 * gib},
+	{"qwen2.5-coder:7b", 6 * gib, 12 * gib},
+	{"qwen2.5-coder:3b", 3 * gib, 6 * gib},
+	{"qwen2.5-coder:1.5b", 0, 0},
+}
+
+// DetectHardware reads the system memory from /proc/meminfo and the GPU
+// memory from nvidia-smi; what cannot be read is left 0
+func DetectHardware(ctx context.Context) Hardware {
+	var hw Hardware
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		hw.MemoryBytes = parseMeminfo(f)
+		_ = f.Close()
+	}
+	if _, err := exec.LookPath("nvidia-smi"); err == nil {
+		out, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=memory.total", "--format=csv,noheader,nounits").Output()
+		if err == nil {
+			hw.GPUMemoryBytes = parseGPUMemory(string(out))
+		}
+	}
+	return hw
+}
+
+// RecommendOllamaModel suggests the largest local code model that fits hw,
+// preferring to run on the GPU
+func RecommendOllamaModel(hw Hardware) OllamaRecommendation {
+	if hw.GPUMemoryBytes > 0 {
+		for _, size := range ollamaSizes {
+			if hw.GPUMemoryBytes >= size.gpuMemory {
+				return OllamaRecommendation{
+					Model:  size.model,
+					Reason: "fits in " + formatGiB(hw.GPUMemoryBytes) + " of GPU memory",
+				}
+			}
+		}
+	}
+	if hw.MemoryBytes > 0 {
+		for _, size := range ollamaSizes {
+			if hw.MemoryBytes >= size.memory {
+				return OllamaRecommendation{
+					Model:  size.model,
+					Reason: "fits in " + formatGiB(hw.MemoryBytes) + " of RAM without a GPU",
+				}
+			}
+		}
+	}
+	return OllamaRecommendation{
+		Model:  ollamaSizes[len(ollamaSizes)-1].model,
+		Reason: "available memory is unknown",
+	}
+}
+
+// parseMeminfo returns the MemTotal of /proc/meminfo in bytes
+func parseMeminfo(r io.Reader) int64 {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// parseGPUMemory returns the largest of the per-GPU MiB totals nvidia-smi
+// prints, one per line, in bytes
+func parseGPUMemory(out string) int64 {
+	var largest int64
+	for _, line := range strings.Split(out, "\n") {
+		mib, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+		if err == nil && mib<<20 > largest {
+			largest = mib << 20
+		}
+	}
+	return largest
+}
+
+// formatGiB formats bytes in whole GiB
+func formatGiB(bytes int64) string {
+	return strconv.FormatInt((bytes+gib/2)/gib, 10) + " GiB"
+}
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendOllamaModel(t *testing.T) {
+	tests := []struct {
+		name string
+		hw   Hardware
+		want string
+	}{
+		{"unknown", Hardware{}, "qwen2.5-coder:1.5b"},
+		{"small laptop", Hardware{MemoryBytes: 8 * gib}, "qwen2.5-coder:3b"},
+		{"workstation", Hardware{MemoryBytes: 32 * gib}, "qwen2.5-coder:14b"},
+		{"GPU preferred over RAM", Hardware{MemoryBytes: 64 * gib, GPUMemoryBytes: 8 * gib}, "qwen2.5-coder:7b"},
+		{"large GPU", Hardware{MemoryBytes: 16 * gib, GPUMemoryBytes: 24 * gib}, "qwen2.5-coder:32b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RecommendOllamaModel(tt.hw)
+			assert.Equal(t, tt.want, got.Model)
+			assert.NotEmpty(t, got.Reason)
+		})
+	}
+}
+
+func TestParseHardware(t *testing.T) {
+	meminfo := "MemTotal:       16314388 kB\nMemFree:         1017004 kB\n"
+	assert.Equal(t, int64(16314388*1024), parseMeminfo(strings.NewReader(meminfo)))
+	assert.Zero(t, parseMeminfo(strings.NewReader("garbage")))
+
+	assert.Equal(t, int64(24576)<<20, parseGPUMemory("8192\n24576\n"))
+	assert.Zero(t, parseGPUMemory(""))
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"fmt"
+	"slices"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// RouteDefault is the route of endpoints no routing rule matches
+const RouteDefault = "default"
+
+// RoutingConfig is the routing config section: rules assigning classes of
+// endpoints to models, so one run uses different models for, say, admin
+// and destructive endpoints
+type RoutingConfig struct {
+	// Rules are tried in order; the first matching rule picks the models
+	Rules []RouteRule `mapstructure:"rules"`
+	// Default are the models of endpoints no rule matches; empty keeps the
+	// models of the run (--ai-models)
+	Default []string `mapstructure:"default"`
+}
+
+// RouteRule assigns the endpoints matching every set criterion to Models.
+// Lists match when any of their entries does.
+type RouteRule struct {
+	Tags []string `mapstructure:"tags"`
+	// Methods match case-insensitively, e.g. DELETE
+	Methods []string `mapstructure:"methods"`
+	// Path is a glob ("*" within a segment, "**" across segments) or, with
+	// the re: prefix, a regular expression
+	Path   string   `mapstructure:"path"`
+	Models []string `mapstructure:"models"`
+}
+
+// Router assigns each endpoint the models of the first rule it matches
+type Router struct {
+	rules    []route
+	fallback []string
+}
+
+type route struct {
+	name   string
+	filter *parser.Filter
+	models []string
+}
+
+// NewRouter compiles the rules of cfg; it is nil when cfg has no rules and
+// no default, so every endpoint gets the models of the run
+func NewRouter(cfg RoutingConfig) (*Router, error) {
+	if len(cfg.Rules) == 0 && len(cfg.Default) == 0 {
+		return nil, nil //nolint:nilnil // no routing is not an error
+	}
+	router := &Router{fallback: cfg.Default}
+	for i, rule := range cfg.Rules {
+		name := fmt.Sprintf("rules[%d]", i)
+		if len(rule.Models) == 0 {
+			return nil, fmt.Errorf("routing.%s: no models", name)
+		}
+		selection := parser.Selection{Tags: rule.Tags, Methods: rule.Methods, Path: rule.Path}
+		if selection.IsZero() {
+			return nil, fmt.Errorf("routing.%s: matches every endpoint, set routing.default instead", name)
+		}
+		filter, err := parser.NewFilter(selection)
+		if err != nil {
+			return nil, fmt.Errorf("routing.%s: %w", name, err)
+		}
+		router.rules = append(router.rules, route{name: name, filter: filter, models: rule.Models})
+	}
+	return router, nil
+}
+
+// Route returns the models of endpoint and the rule that picked them
+// (e.g. "rules[0]"), or RouteDefault; models are those of the run when no
+// rule matches and the router has no default
+func (r *Router) Route(endpoint *parser.Endpoint, models []string) ([]string, string) {
+	if r == nil {
+		return models, ""
+	}
+	for i := range r.rules {
+		if r.rules[i].filter.Match(endpoint) {
+			return r.rules[i].models, r.rules[i].name
+		}
+	}
+	if len(r.fallback) > 0 {
+		return r.fallback, RouteDefault
+	}
+	return models, RouteDefault
+}
+
+// Models returns every model a run of models may route endpoints to: the
+// models of the rules, and the default or, without one, the run's models
+func (r *Router) Models(models []string) []string {
+	if r == nil {
+		return models
+	}
+	var all []string
+	add := func(names []string) {
+		for _, name := range names {
+			if !slices.Contains(all, name) {
+				all = append(all, name)
+			}
+		}
+	}
+	if len(r.fallback) > 0 {
+		add(r.fallback)
+	} else {
+		add(models)
+	}
+	for i := range r.rules {
+		add(r.rules[i].models)
+	}
+	return all
+}
+
+// SetRouter sets the routing rules of the manager; nil gives every endpoint
+// the models of the run. Create the manager with Router.Models, so every
+// routed model has a client.
+func (m *Manager) SetRouter(router *Router) {
+	m.router = router
+}
+
+// ModelsFor returns the models that generate the tests of endpoint in a run
+// of models, and the routing rule that picked them; the route is empty
+// without routing
+func (m *Manager) ModelsFor(endpoint *parser.Endpoint, models []string) ([]string, string) {
+	return m.router.Route(endpoint, models)
+}
+
+// RoutedModels returns every model a run of models may use, see
+// Router.Models
+func (m *Manager) RoutedModels(models []string) []string {
+	return m.router.Models(models)
+}
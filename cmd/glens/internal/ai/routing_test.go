@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func TestRouter(t *testing.T) {
+	router, err := NewRouter(RoutingConfig{Rules: []RouteRule{
+		{Tags: []string{"admin"}, Models: []string{"anthropic"}},
+		{Methods: []string{"delete"}, Models: []string{"gpt-4o"}},
+		{Path: "/reports/**", Methods: []string{"GET"}, Models: []string{"gpt-4o", "mock"}},
+	}})
+	require.NoError(t, err)
+
+	run := []string{"mock"}
+	cases := []struct {
+		endpoint parser.Endpoint
+		models   []string
+		route    string
+	}{
+		{parser.Endpoint{Method: "DELETE", Path: "/users/{id}", Tags: []string{"admin"}}, []string{"anthropic"}, "rules[0]"},
+		{parser.Endpoint{Method: "DELETE", Path: "/pets/{id}"}, []string{"gpt-4o"}, "rules[1]"},
+		{parser.Endpoint{Method: "GET", Path: "/reports/2024/q1"}, []string{"gpt-4o", "mock"}, "rules[2]"},
+		{parser.Endpoint{Method: "GET", Path: "/pets"}, []string{"mock"}, RouteDefault},
+	}
+	for _, tc := range cases {
+		models, route := router.Route(&tc.endpoint, run)
+		assert.Equal(t, tc.models, models, tc.endpoint.Method+" "+tc.endpoint.Path)
+		assert.Equal(t, tc.route, route, tc.endpoint.Method+" "+tc.endpoint.Path)
+	}
+	assert.Equal(t, []string{"mock", "anthropic", "gpt-4o"}, router.Models(run))
+
+	router, err = NewRouter(RoutingConfig{Rules: []RouteRule{{Methods: []string{"DELETE"}, Models: []string{"gpt-4o"}}}, Default: []string{"ollama"}})
+	require.NoError(t, err)
+	models, route := router.Route(&parser.Endpoint{Method: "GET", Path: "/pets"}, run)
+	assert.Equal(t, []string{"ollama"}, models)
+	assert.Equal(t, RouteDefault, route)
+	assert.Equal(t, []string{"ollama", "gpt-4o"}, router.Models(run), "a default replaces the run's models")
+}
+
+func TestRouter_Disabled(t *testing.T) {
+	router, err := NewRouter(RoutingConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, router)
+
+	m, err := NewManager([]string{"mock"}, Config{})
+	require.NoError(t, err)
+	m.SetRouter(router)
+	models, route := m.ModelsFor(&parser.Endpoint{Method: "GET", Path: "/pets"}, []string{"mock"})
+	assert.Equal(t, []string{"mock"}, models)
+	assert.Empty(t, route)
+	assert.Equal(t, []string{"mock"}, m.RoutedModels([]string{"mock"}))
+}
+
+func TestNewRouter_Invalid(t *testing.T) {
+	_, err := NewRouter(RoutingConfig{Rules: []RouteRule{{Tags: []string{"admin"}}}})
+	assert.ErrorContains(t, err, "routing.rules[0]: no models")
+
+	_, err = NewRouter(RoutingConfig{Rules: []RouteRule{{Models: []string{"mock"}}}})
+	assert.ErrorContains(t, err, "set routing.default instead")
+
+	_, err = NewRouter(RoutingConfig{Rules: []RouteRule{{Path: "re:([", Models: []string{"mock"}}}})
+	assert.ErrorContains(t, err, "routing.rules[0]: invalid path pattern")
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Response formats selectable per provider with the response_format setting
+const (
+	// ResponseFormatStructured (the default) requests a JSON object matching
+	// GeneratedTest: OpenAI json_schema, Anthropic tool use, Gemini and
+	// Ollama JSON mode
+	ResponseFormatStructured = "structured"
+	// ResponseFormatText requests free text, for models or OpenAI-compatible
+	// servers without structured output
+	ResponseFormatText = "text"
+)
+
+// generatedTestTool is the Anthropic tool the model is made to call
+const generatedTestTool = "submit_test"
+
+// GeneratedTest is the structured answer requested from models
+type GeneratedTest struct {
+	TestCode   string   `json:"test_code"`
+	Imports    []string `json:"imports"`
+	Notes      string   `json:"notes"`
+	Categories []string `json:"categories"`
+}
+
+// generatedTestSchema is the JSON schema of GeneratedTest
+var generatedTestSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"test_code": map[string]any{
+			"type":        "string",
+			"description": "The complete Go test file: package clause, imports and test functions, without Markdown fences",
+		},
+		"imports": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Import paths used by test_code",
+		},
+		"notes": map[string]any{
+			"type":        "string",
+			"description": "Brief assumptions or caveats about the tests",
+		},
+		"categories": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Test categories covered, e.g. happy-path, error-handling, boundary, security",
+		},
+	},
+	"required":             []string{"test_code", "imports", "notes", "categories"},
+	"additionalProperties": false,
+}
+
+// structuredOutput reports whether format selects structured output
+func structuredOutput(format string) bool {
+	return format != ResponseFormatText
+}
+
+// Patterns locating Go code in model answers
+var (
+	// fencePattern matches a Markdown code fence, its language and the rest
+	// of its info string
+	fencePattern   = regexp.MustCompile("(?m)^[ \t]*```[ \t]*([A-Za-z0-9_+-]*)(?:[ \t:]+([^`\n]*?))?[ \t]*$")
+	packagePattern = regexp.MustCompile(`(?m)^package \w+`)
+	importPattern  = regexp.MustCompile(`(?m)^import\b`)
+)
+
+// ParseGeneratedTest reads a model answer. A JSON answer (bare or fenced)
+// is decoded as GeneratedTest and ok is true; anything else is treated as
+// prose around Go code. TestCode is always passed through ExtractGoCode.
+func ParseGeneratedTest(answer string) (test GeneratedTest, ok bool) {
+	if decodeGeneratedTest(answer, &test) {
+		test.TestCode = addImports(ExtractGoCode(test.TestCode), test.Imports)
+		return test, true
+	}
+	return GeneratedTest{TestCode: ExtractGoCode(answer)}, false
+}
+
+// applyAnswer fills the test code, notes and categories of result from a
+// model answer, keeping the answer itself, and records in its metadata
+// whether the answer was structured
+func (r *TestGenerationResult) applyAnswer(answer string) {
+	r.RawResponse = answer
+	test, ok := ParseGeneratedTest(answer)
+	r.TestCode = test.TestCode
+	r.Notes = test.Notes
+	if len(test.Categories) > 0 {
+		r.TestCategories = test.Categories
+	}
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]string)
+	}
+	r.Metadata["output_format"] = ResponseFormatText
+	if ok {
+		r.Metadata["output_format"] = ResponseFormatStructured
+	}
+}
+
+// decodeGeneratedTest decodes the JSON object in answer, if any
+func decodeGeneratedTest(answer string, test *GeneratedTest) bool {
+	answer = strings.TrimSpace(answer)
+	if block, ok := fencedBlock(answer, "json"); ok {
+		answer = block
+	}
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start == -1 || end < start {
+		return false
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), test); err != nil {
+		return false
+	}
+	return strings.TrimSpace(test.TestCode) != ""
+}
+
+// fencedBlock returns the best fenced block whose info string is one of
+// langs: the longest one declaring tests, else the longest. A fence left
+// open runs to the end of the text.
+func fencedBlock(text string, langs ...string) (string, bool) {
+	fences := fencePattern.FindAllStringSubmatchIndex(text, -1)
+	best, bestTests := "", false
+	for i := 0; i < len(fences); i += 2 {
+		lang := strings.ToLower(text[fences[i][2]:fences[i][3]])
+		end := len(text)
+		if i+1 < len(fences) {
+			end = fences[i+1][0]
+		}
+		block := strings.TrimSpace(text[fences[i][1]:end])
+		if !slices.Contains(langs, lang) || block == "" {
+			continue
+		}
+		tests := strings.Contains(block, "func Test")
+		if (tests && !bestTests) || (tests == bestTests && len(block) > len(best)) {
+			best, bestTests = block, tests
+		}
+	}
+	return best, best != ""
+}
+
+// addImports adds an import block for imports when code has none
+func addImports(code string, imports []string) string {
+	if len(imports) == 0 || importPattern.MatchString(code) {
+		return code
+	}
+	pkg := packagePattern.FindStringIndex(code)
+	if pkg == nil {
+		return code
+	}
+
+	var block strings.Builder
+	block.WriteString("\n\nimport (\n")
+	for _, path := range imports {
+		block.WriteString("\t" + strconv.Quote(strings.Trim(path, `"`)) + "\n")
+	}
+	block.WriteString(")")
+	return code[:pkg[1]] + block.String() + code[pkg[1]:]
+}
@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleTest = "package api_test\n\nfunc TestGetUsers(t *testing.T) {\n\tassert.True(t, true)\n}"
+
+func TestExtractGoCode(t *testing.T) {
+	tests := []struct {
+		name, answer string
+	}{
+		{"bare code", sampleTest},
+		{"fenced", "Here are the tests:\n```go\n" + sampleTest + "\n```\nThey cover the happy path."},
+		{"golang fence", "```golang\n" + sampleTest + "\n```"},
+		{"prefers tests", "```go\npackage main\n\nfunc main() {\n\n\n\n\n}\n```\n\n```go\n" + sampleTest + "\n```"},
+		{"skips go.mod", "```\nmodule example.com/api\n```\n\n```go\n" + sampleTest + "\n```"},
+		{"unterminated fence", "Sure!\n```go\n" + sampleTest + "\n"},
+		{"prose around package", "Here you go:\n\n" + sampleTest + "\n\nLet me know if you need more."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, sampleTest, ExtractGoCode(tt.answer))
+		})
+	}
+
+	assert.Equal(t, "no code here", ExtractGoCode("  no code here\n"))
+}
+
+func TestExtractGoCode_MultipleBlocks(t *testing.T) {
+	answer := "The helpers go in their own file:\n\n" +
+		"**helpers_test.go**\n```go\npackage api_test\n\nimport (\n\t\"encoding/json\"\n\t\"net/http\"\n)\n\n" +
+		"// user is a user of the API\ntype user struct {\n\tID int `json:\"id\"`\n}\n\n" +
+		"func decodeUser(resp *http.Response) (u user, err error) {\n\terr = json.NewDecoder(resp.Body).Decode(&u)\n\treturn u, err\n}\n```\n\n" +
+		"```go title=\"users_test.go\"\npackage api_test\n\nimport (\n\t\"net/http\"\n\t\"testing\"\n)\n\n" +
+		"func TestGetUser(t *testing.T) {\n\tresp, _ := http.Get(\"http://localhost:8080/users/1\")\n\tu, err := decodeUser(resp)\n\tif err != nil || u.ID != 1 {\n\t\tt.Fatal(err)\n\t}\n}\n```\n\n" +
+		"And the model type again, for reference:\n```go\ntype user struct {\n\tID int\n}\n```\n\n" +
+		"Run it:\n```go\npackage main\n\nfunc main() {}\n```\n\n```go\n// go.mod\nmodule example.com/api\n```"
+
+	assert.Equal(t, `package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestGetUser(t *testing.T) {
+	resp, _ := http.Get("http://localhost:8080/users/1")
+	u, err := decodeUser(resp)
+	if err != nil || u.ID != 1 {
+		t.Fatal(err)
+	}
+}
+
+// user is a user of the API
+type user struct {
+	ID int `+"`json:\"id\"`"+`
+}
+
+func decodeUser(resp *http.Response) (u user, err error) {
+	err = json.NewDecoder(resp.Body).Decode(&u)
+	return u, err
+}`, ExtractGoCode(answer))
+
+	assert.Equal(t, sampleTest+"\n\ntype helper struct{}",
+		ExtractGoCode("```go\n"+sampleTest[len("package api_test\n\n"):]+"\n```\n```go\npackage api_test\n\ntype helper struct{}\n```"),
+		"tests without a package clause take the one of their helpers")
+}
+
+func TestParseGeneratedTest(t *testing.T) {
+	answer, err := json.Marshal(GeneratedTest{
+		TestCode:   sampleTest,
+		Imports:    []string{"testing", "github.com/stretchr/testify/assert"},
+		Notes:      "assumes seeded users",
+		Categories: []string{"happy-path"},
+	})
+	require.NoError(t, err)
+
+	test, ok := ParseGeneratedTest(string(answer))
+	require.True(t, ok)
+	assert.Equal(t, "assumes seeded users", test.Notes)
+	assert.Equal(t, []string{"happy-path"}, test.Categories)
+	assert.Equal(t, "package api_test\n\nimport (\n\t\"testing\"\n\t\"github.com/stretchr/testify/assert\"\n)"+
+		sampleTest[len("package api_test"):], test.TestCode, "imports are added when the code has none")
+
+	fenced, ok := ParseGeneratedTest("```json\n" + string(answer) + "\n```")
+	require.True(t, ok)
+	assert.Equal(t, test, fenced)
+
+	test, ok = ParseGeneratedTest("```go\n" + sampleTest + "\n```")
+	assert.False(t, ok)
+	assert.Equal(t, sampleTest, test.TestCode)
+}
+
+func TestOpenAIClient_StructuredOutput(t *testing.T) {
+	var request OpenAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		answer := `{"test_code":"` + jsonEscape(t, sampleTest) + `","imports":[],"notes":"n","categories":["security"]}`
+		_ = json.NewEncoder(w).Encode(OpenAIResponse{Choices: []Choice{{Message: Message{Content: answer}}}})
+	}))
+	defer server.Close()
+
+	c, err := NewOpenAIClient(OpenAIConfig{APIKey: "key"}, WithBaseURL(server.URL))
+	require.NoError(t, err)
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	require.NotNil(t, request.ResponseFormat)
+	assert.Equal(t, "json_schema", request.ResponseFormat.Type)
+	assert.Equal(t, sampleTest, result.TestCode)
+	assert.Equal(t, "n", result.Notes)
+	assert.Equal(t, []string{"security"}, result.TestCategories)
+	assert.Equal(t, ResponseFormatStructured, result.Metadata["output_format"])
+
+	// Text mode neither requests nor asks for JSON
+	text, err := NewOpenAIClient(OpenAIConfig{APIKey: "key", ResponseFormat: ResponseFormatText})
+	require.NoError(t, err)
+	assert.Nil(t, text.responseFormat())
+	prompt, err := text.buildPrompt(testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, `"test_code"`)
+
+	mistral, err := NewMistralClient(OpenAIConfig{APIKey: "key"})
+	require.NoError(t, err)
+	assert.Equal(t, &OpenAIResponseFormat{Type: "json_object"}, mistral.responseFormat())
+}
+
+func TestAnthropicClient_StructuredOutput(t *testing.T) {
+	var request AnthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		input := json.RawMessage(`{"test_code":"` + jsonEscape(t, sampleTest) + `","imports":[],"notes":"","categories":[]}`)
+		_ = json.NewEncoder(w).Encode(AnthropicResponse{Content: []AnthropicContent{
+			{Type: "text", Text: "Submitting the test."},
+			{Type: "tool_use", Name: generatedTestTool, Input: input},
+		}})
+	}))
+	defer server.Close()
+
+	c, err := NewAnthropicClient(AnthropicConfig{APIKey: "key"}, WithBaseURL(server.URL))
+	require.NoError(t, err)
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	require.Len(t, request.Tools, 1)
+	assert.Equal(t, &AnthropicToolChoice{Type: "tool", Name: generatedTestTool}, request.ToolChoice)
+	assert.Equal(t, sampleTest, result.TestCode)
+	assert.Equal(t, []string{"happy-path", "error-handling", "boundary", "security"}, result.TestCategories,
+		"empty categories keep the defaults")
+}
+
+func TestOllamaClient_StructuredOutput(t *testing.T) {
+	var request OllamaGenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		// Models in JSON mode still occasionally fence their code
+		answer := `{"test_code":"` + jsonEscape(t, "```go\n"+sampleTest+"\n```") + `"}`
+		_ = json.NewEncoder(w).Encode(OllamaGenerateResponse{Response: answer, Done: true})
+	}))
+	defer server.Close()
+
+	c, err := NewOllamaClient(OllamaConfig{BaseURL: server.URL})
+	require.NoError(t, err)
+	result, err := c.GenerateTest(context.Background(), testEndpoint("GET", "/users"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "json", request.Format)
+	assert.NotContains(t, request.Prompt, "```go\n", "structured prompts do not open a code fence")
+	assert.Equal(t, sampleTest, result.TestCode)
+}
+
+func jsonEscape(t *testing.T, s string) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(b[1 : len(b)-1])
+}
@@ -0,0 +1,794 @@
+// Package analysis runs the glens pipeline: it generates a test per
+// selected endpoint and AI model, optionally executes it, and builds the
+// report. Every setting is passed explicitly through Options.
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/events"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+	"glens/tools/glens/internal/telemetry"
+)
+
+// TotalTimeoutExceeded is the report metadata key set when the run was cut
+// short by Options.TotalTimeout
+const TotalTimeoutExceeded = "total_timeout_exceeded"
+
+// Options configure one analysis run
+type Options struct {
+	// Models are the AI models that generate a test for every endpoint
+	Models []string
+	// Framework is the test framework generated tests use (testify, ginkgo)
+	Framework string
+	// OperationID restricts the run to a single endpoint
+	OperationID string
+	// Approved and Skipped narrow the endpoints by operation ID, endpoint ID,
+	// or "METHOD /path"; an empty Approved list keeps every endpoint
+	Approved []string
+	Skipped  []string
+	// Selection narrows the endpoints by tag, method, path pattern,
+	// exclusions and deprecation; it is recorded in the report metadata
+	Selection parser.Selection
+	// IncludeDeprecated also tests operations marked deprecated, which are
+	// otherwise skipped unless named by OperationID or Approved
+	IncludeDeprecated bool
+	// InferScenarios adds a multi-step test of each resource lifecycle
+	// inferred from the spec's paths and operation IDs (create, read,
+	// update, delete); ScenariosFile adds the scenarios of a YAML file.
+	// A scenario is tested when all of its steps are selected.
+	InferScenarios bool
+	ScenariosFile  string
+	// RunTests executes generated tests against Env
+	RunTests bool
+	// ScrubEnv runs generated tests without the secrets of this process'
+	// environment, see generator.TestGenerator.SetScrubEnv
+	ScrubEnv bool
+	// AllowRisk is the highest endpoint risk whose tests are executed; tests
+	// of riskier (mutating, destructive) endpoints are generated but not
+	// run. Empty allows safe, read-only endpoints only.
+	AllowRisk   safety.Risk
+	TestTimeout time.Duration
+	TestRetries int
+	// EndpointTimeout bounds the generation and execution of each endpoint's
+	// tests and TotalTimeout the whole run; endpoints cut short are
+	// reported as skipped with the reason. 0 disables either.
+	EndpointTimeout time.Duration
+	TotalTimeout    time.Duration
+	// Lint runs go vet, staticcheck and gosec over generated tests before
+	// execution; nil disables the gate
+	Lint *generator.LintOptions
+	// Ensemble combines the tests of all models per endpoint: EnsembleBest
+	// picks the best test, EnsembleMerge merges their unique test functions.
+	// Empty disables it.
+	Ensemble string
+	// RepairAttempts bounds how often a test that fails to compile or run
+	// is sent back to its model with the failure for a fix; 0 disables it
+	RepairAttempts int
+	// ArtifactsDir, when set, receives the prompt, raw response, code and
+	// compiler and test output of every generation and repair, per endpoint
+	// and model; the report's tests refer to their directory
+	ArtifactsDir string
+	Env          *environment.Environment
+	// Progress, when set, is called as endpoints and models are processed
+	Progress func(jobs.Progress)
+	// OnEndpoint, when set, is called with each endpoint's results before
+	// they are added to the report (e.g. to open issues for failures)
+	OnEndpoint func(ctx context.Context, result *reporter.EndpointResult)
+	// Events, when set, receives the endpoint_started, generation_finished
+	// and test_executed events of the run
+	Events *events.Recorder
+	// Dispatch, when set, processes each endpoint in place of this process,
+	// e.g. on a worker of a distributed analysis, with the validated options
+	// of the run; every endpoint is dispatched at once and the results are
+	// collected in order
+	Dispatch func(ctx context.Context, endpoint *parser.Endpoint, opts *Options) (reporter.EndpointResult, error)
+	// Scoring weighs the report's health and model scores and may name a
+	// scoring webhook; nil uses the default weights
+	Scoring *reporter.Scoring
+}
+
+// reportProgress forwards p to the progress callback, if any
+func (o *Options) reportProgress(p jobs.Progress) {
+	if o.Progress != nil {
+		o.Progress(p)
+	}
+}
+
+// prepare validates opts, normalizing its risk and lint settings, and
+// returns the test generator of the run
+func prepare(opts *Options) (*generator.TestGenerator, error) {
+	allowRisk, err := safety.ParseRisk(string(opts.AllowRisk))
+	if err != nil {
+		return nil, err
+	}
+	opts.AllowRisk = allowRisk
+	if opts.Ensemble != "" && opts.Ensemble != EnsembleBest && opts.Ensemble != EnsembleMerge {
+		return nil, fmt.Errorf("invalid ensemble mode %q: must be %s or %s", opts.Ensemble, EnsembleBest, EnsembleMerge)
+	}
+	if opts.Scoring != nil {
+		if err := opts.Scoring.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Lint != nil {
+		failOn, err := generator.ParseSeverity(string(opts.Lint.FailOn))
+		if err != nil {
+			return nil, err
+		}
+		opts.Lint.FailOn = failOn
+	}
+
+	testGen := generator.NewTestGenerator(opts.Framework)
+	testGen.SetTimeout(opts.TestTimeout)
+	testGen.SetRetries(opts.TestRetries)
+	testGen.SetEnvironment(opts.Env)
+	testGen.SetScrubEnv(opts.ScrubEnv)
+	testGen.SetLint(opts.Lint)
+	return testGen, nil
+}
+
+// ProcessEndpoint generates, and optionally executes, the tests of a single
+// endpoint as Run does for each selected endpoint. Workers of a distributed
+// analysis run the endpoints they are handed with it.
+func ProcessEndpoint(ctx context.Context, endpoint *parser.Endpoint, aiManager *ai.Manager, opts Options) (reporter.EndpointResult, error) {
+	testGen, err := prepare(&opts)
+	if err != nil {
+		return reporter.EndpointResult{}, err
+	}
+	return processEndpointWithin(ctx, endpoint, &opts, aiManager, testGen, func(string) {}), nil
+}
+
+// Run analyzes spec with the models of aiManager and returns the report
+func Run(ctx context.Context, spec *parser.OpenAPISpec, aiManager *ai.Manager, opts Options) (*reporter.Report, error) {
+	testGen, err := prepare(&opts)
+	if err != nil {
+		return nil, err
+	}
+	testGen.SetServers(spec.Servers)
+
+	endpointsToProcess, err := Select(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+	scenarios, err := loadScenarios(spec, &opts)
+	if err != nil {
+		return nil, err
+	}
+	endpointsToProcess = withScenarios(endpointsToProcess, scenarios)
+	// High-priority endpoints (x-glens-priority) are analysed first
+	endpointsToProcess = slices.Clone(endpointsToProcess)
+	slices.SortStableFunc(endpointsToProcess, parser.ComparePriority)
+
+	// Process each endpoint; ctx stays the caller's so cancellation is told
+	// apart from the total timeout
+	runCtx := ctx
+	if opts.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.TotalTimeout)
+		defer cancel()
+	}
+	var results []reporter.EndpointResult
+	progress := jobs.Progress{EndpointsTotal: len(endpointsToProcess)}
+	onModel := func(model string) {
+		progress.CurrentModel = model
+		opts.reportProgress(progress)
+	}
+	opts.reportProgress(progress)
+	if opts.Dispatch != nil {
+		// Distributed runs hand every endpoint out at once
+		results, err = dispatchEndpoints(ctx, runCtx, endpointsToProcess, &opts, &progress)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range endpointsToProcess {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("analysis cancelled: %w", err)
+			}
+
+			endpoint := &endpointsToProcess[i]
+			progress.CurrentEndpoint = fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)
+			var result reporter.EndpointResult
+			if runCtx.Err() != nil {
+				result = skippedEndpoint(endpoint, fmt.Sprintf("total timeout of %s reached before the endpoint started", opts.TotalTimeout))
+			} else {
+				opts.Events.Emit(EndpointEvent(events.EndpointStarted, endpoint))
+				result = processEndpointWithin(runCtx, endpoint, &opts, aiManager, testGen, onModel)
+			}
+			if opts.OnEndpoint != nil {
+				opts.OnEndpoint(ctx, &result)
+			}
+			results = append(results, result)
+
+			progress.EndpointsProcessed = i + 1
+			progress.CurrentModel = ""
+			opts.reportProgress(progress)
+		}
+	}
+
+	// Generate final report
+	log.Info().Msg("Generating final report")
+	report := BuildReport(ctx, spec, results, &opts)
+	if opts.RunTests {
+		timeout := opts.TestTimeout
+		if timeout <= 0 {
+			timeout = generator.DefaultTestTimeout
+		}
+		report.Metadata["test_timeout"] = timeout.String()
+		report.Metadata["test_retries"] = max(opts.TestRetries, 0)
+		report.Metadata["allow_risk"] = string(opts.AllowRisk)
+	}
+	if opts.EndpointTimeout > 0 {
+		report.Metadata["endpoint_timeout"] = opts.EndpointTimeout.String()
+	}
+	if opts.TotalTimeout > 0 {
+		report.Metadata["total_timeout"] = opts.TotalTimeout.String()
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			report.Metadata[TotalTimeoutExceeded] = true
+		}
+	}
+	if opts.Lint != nil {
+		report.Metadata["lint_fail_on"] = string(opts.Lint.FailOn)
+	}
+	if opts.Ensemble != "" {
+		report.Metadata["ensemble"] = opts.Ensemble
+	}
+	if opts.RepairAttempts > 0 {
+		report.Metadata["repair_attempts"] = opts.RepairAttempts
+	}
+	if opts.ArtifactsDir != "" {
+		report.Metadata["artifacts_dir"] = opts.ArtifactsDir
+	}
+	if !opts.Selection.IsZero() {
+		report.Metadata["selection"] = opts.Selection.String()
+	}
+	if opts.IncludeDeprecated {
+		report.Metadata["include_deprecated"] = true
+	}
+	if len(scenarios) > 0 {
+		report.Metadata["scenarios"] = len(scenarios)
+	}
+	if opts.Env != nil {
+		report.Metadata["environment"] = opts.Env.Name
+		report.Metadata["base_url"] = opts.Env.BaseURL
+	}
+
+	return report, nil
+}
+
+// BuildReport builds the report of results with the scoring of opts,
+// calling its webhook if any, and maps every model of opts for coverage.
+// A failing webhook keeps the weighted scores and is noted in metadata.
+func BuildReport(ctx context.Context, spec *parser.OpenAPISpec, results []reporter.EndpointResult, opts *Options) *reporter.Report {
+	scoring := reporter.DefaultScoring()
+	if opts.Scoring != nil {
+		scoring = *opts.Scoring
+	}
+	report := reporter.GenerateReportWithScoring(spec, results, scoring)
+	// Map the models that generated nothing too
+	report.Coverage = reporter.BuildCoverage(spec, results, coverageModels(results, opts.Models))
+
+	if scoring.Webhook != "" {
+		if err := reporter.ScoreWithWebhook(ctx, report, scoring.Webhook); err != nil {
+			log.Warn().Err(err).Msg("Scoring webhook failed, keeping weighted scores")
+			report.Metadata["scoring_webhook_error"] = err.Error()
+		} else {
+			report.Metadata["scoring_webhook"] = true
+		}
+	}
+	return report
+}
+
+// Select returns the endpoints of spec a run with opts tests: those named
+// by OperationID or Approved and not Skipped, matching Selection and, unless
+// named explicitly, neither marked x-glens-skip nor deprecated (without
+// IncludeDeprecated). Scenarios are not included.
+func Select(spec *parser.OpenAPISpec, opts Options) ([]parser.Endpoint, error) {
+	endpoints, err := selectEndpoints(spec, opts.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	endpoints = filterApproved(endpoints, opts.Approved, opts.Skipped)
+	endpoints, err = applySelection(endpoints, opts.Selection)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OperationID != "" || len(opts.Approved) > 0 {
+		return endpoints, nil
+	}
+	// Endpoints named explicitly are tested even when marked x-glens-skip
+	// or deprecated
+	if endpoints, err = skipMarked(endpoints); err != nil {
+		return nil, err
+	}
+	if !opts.IncludeDeprecated {
+		return skipDeprecated(endpoints)
+	}
+	return endpoints, nil
+}
+
+// applySelection keeps the endpoints matching selection. A selection that
+// matches nothing is an error rather than an empty report.
+func applySelection(endpoints []parser.Endpoint, selection parser.Selection) ([]parser.Endpoint, error) {
+	if selection.IsZero() {
+		return endpoints, nil
+	}
+
+	filter, err := parser.NewFilter(selection)
+	if err != nil {
+		return nil, err
+	}
+	selected := filter.Select(endpoints)
+
+	log.Info().
+		Str("selection", selection.String()).
+		Int("selected", len(selected)).
+		Int("total", len(endpoints)).
+		Msg("Applied endpoint selection")
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no endpoints match the selection (%s)", selection)
+	}
+	return selected, nil
+}
+
+// skipMarked drops the endpoints marked x-glens-skip. Skipping every
+// endpoint is an error rather than an empty report.
+func skipMarked(endpoints []parser.Endpoint) ([]parser.Endpoint, error) {
+	kept := make([]parser.Endpoint, 0, len(endpoints))
+	for i := range endpoints {
+		if reason, skipped := endpoints[i].Skipped(); skipped {
+			log.Info().
+				Str("endpoint", fmt.Sprintf("%s %s", endpoints[i].Method, endpoints[i].Path)).
+				Str("reason", reason).
+				Msg("Skipping endpoint marked " + parser.ExtSkip)
+			continue
+		}
+		kept = append(kept, endpoints[i])
+	}
+	if len(kept) == 0 && len(endpoints) > 0 {
+		return nil, fmt.Errorf("all %d selected endpoints are marked %s", len(endpoints), parser.ExtSkip)
+	}
+	return kept, nil
+}
+
+// skipDeprecated drops the endpoints marked deprecated. Skipping every
+// endpoint is an error rather than an empty report.
+func skipDeprecated(endpoints []parser.Endpoint) ([]parser.Endpoint, error) {
+	kept := slices.DeleteFunc(slices.Clone(endpoints), func(e parser.Endpoint) bool { return e.Deprecated })
+	if skipped := len(endpoints) - len(kept); skipped > 0 {
+		log.Info().
+			Int("skipped", skipped).
+			Msg("Skipping deprecated operations (--include-deprecated tests them)")
+	}
+	if len(kept) == 0 && len(endpoints) > 0 {
+		return nil, fmt.Errorf("all %d selected endpoints are deprecated; use --include-deprecated to test them", len(endpoints))
+	}
+	return kept, nil
+}
+
+// loadScenarios returns the inferred scenarios and those of the scenarios
+// file, as configured
+func loadScenarios(spec *parser.OpenAPISpec, opts *Options) ([]parser.Endpoint, error) {
+	var scenarios []parser.Endpoint
+	if opts.InferScenarios {
+		scenarios = parser.InferScenarios(spec)
+	}
+	if opts.ScenariosFile != "" {
+		explicit, err := parser.LoadScenarios(opts.ScenariosFile, spec)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, explicit...)
+	}
+	return scenarios, nil
+}
+
+// withScenarios appends the scenarios whose steps are all among the
+// selected endpoints
+func withScenarios(endpoints, scenarios []parser.Endpoint) []parser.Endpoint {
+	if len(scenarios) == 0 {
+		return endpoints
+	}
+	selected := make(map[string]bool, len(endpoints))
+	for i := range endpoints {
+		selected[endpoints[i].ID] = true
+	}
+	endpoints = slices.Clip(endpoints)
+	for i := range scenarios {
+		if slices.ContainsFunc(scenarios[i].Steps, func(step parser.ScenarioStep) bool { return !selected[step.Endpoint.ID] }) {
+			log.Info().
+				Str("scenario", scenarios[i].Path).
+				Msg("Skipping scenario with unselected steps")
+			continue
+		}
+		endpoints = append(endpoints, scenarios[i])
+	}
+	return endpoints
+}
+
+// selectEndpoints returns all endpoints, or only the one matching opID
+func selectEndpoints(spec *parser.OpenAPISpec, opID string) ([]parser.Endpoint, error) {
+	if opID == "" {
+		return spec.Endpoints, nil
+	}
+
+	log.Info().
+		Str("operation_id", opID).
+		Msg("Filtering endpoints by operation ID")
+
+	for i := range spec.Endpoints {
+		endpoint := &spec.Endpoints[i]
+		if endpoint.OperationID == opID {
+			log.Info().
+				Str("operation_id", opID).
+				Int("matching_endpoints", 1).
+				Msg("Found matching endpoint")
+			return []parser.Endpoint{*endpoint}, nil
+		}
+	}
+
+	// List available operation IDs to help user
+	var availableOps []string
+	for i := range spec.Endpoints {
+		endpoint := &spec.Endpoints[i]
+		if endpoint.OperationID != "" {
+			availableOps = append(availableOps, endpoint.OperationID)
+		}
+	}
+	return nil, fmt.Errorf("operation ID '%s' not found. Available operation IDs: %v", opID, availableOps)
+}
+
+// filterApproved keeps approved endpoints and drops skipped ones
+func filterApproved(endpoints []parser.Endpoint, approved, skipped []string) []parser.Endpoint {
+	if len(approved) == 0 && len(skipped) == 0 {
+		return endpoints
+	}
+
+	var selected []parser.Endpoint
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if len(approved) > 0 && !matchesAnyRef(endpoint, approved) {
+			continue
+		}
+		if matchesAnyRef(endpoint, skipped) {
+			continue
+		}
+		selected = append(selected, *endpoint)
+	}
+
+	log.Info().
+		Int("approved", len(selected)).
+		Int("total", len(endpoints)).
+		Msg("Applied endpoint approvals")
+
+	return selected
+}
+
+// matchesAnyRef reports whether an endpoint is named by any reference
+func matchesAnyRef(endpoint *parser.Endpoint, refs []string) bool {
+	for _, ref := range refs {
+		if endpoint.Matches(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// processEndpointWithin processes an endpoint under opts.EndpointTimeout and
+// reports endpoints whose endpoint or total timeout passed as skipped,
+// keeping the tests that finished in time
+func processEndpointWithin(ctx context.Context, endpoint *parser.Endpoint, opts *Options, aiManager *ai.Manager, testGen *generator.TestGenerator, onModel func(string)) reporter.EndpointResult {
+	endpointCtx := ctx
+	if opts.EndpointTimeout > 0 {
+		var cancel context.CancelFunc
+		endpointCtx, cancel = context.WithTimeout(ctx, opts.EndpointTimeout)
+		defer cancel()
+	}
+
+	result := processEndpoint(endpointCtx, endpoint, opts, aiManager, testGen, onModel)
+	if !errors.Is(endpointCtx.Err(), context.DeadlineExceeded) {
+		return result
+	}
+	reason := fmt.Sprintf("endpoint timeout of %s exceeded", opts.EndpointTimeout)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = fmt.Sprintf("total timeout of %s exceeded", opts.TotalTimeout)
+	}
+	log.Warn().
+		Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+		Str("reason", reason).
+		Msg("Skipping endpoint")
+	result.Status = reporter.StatusSkipped
+	result.SkipReason = reason
+	return result
+}
+
+// skippedEndpoint returns the result of an endpoint skipped for reason
+func skippedEndpoint(endpoint *parser.Endpoint, reason string) reporter.EndpointResult {
+	return reporter.EndpointResult{
+		Endpoint:   *endpoint,
+		Tests:      make(map[string]reporter.TestResult),
+		Status:     reporter.StatusSkipped,
+		SkipReason: reason,
+	}
+}
+
+// dispatchEndpoints hands every endpoint to opts.Dispatch at once and
+// collects the results in order as they come back. Endpoints whose dispatch
+// fails are reported as skipped with the reason.
+func dispatchEndpoints(ctx, runCtx context.Context, endpoints []parser.Endpoint, opts *Options, progress *jobs.Progress) ([]reporter.EndpointResult, error) {
+	results := make([]reporter.EndpointResult, len(endpoints))
+	done := make(chan int, len(endpoints))
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		opts.Events.Emit(EndpointEvent(events.EndpointStarted, endpoint))
+		go func() {
+			result, err := opts.Dispatch(runCtx, endpoint, opts)
+			if err != nil {
+				reason := "dispatch failed: " + err.Error()
+				if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+					reason = fmt.Sprintf("total timeout of %s exceeded", opts.TotalTimeout)
+				}
+				result = skippedEndpoint(endpoint, reason)
+			}
+			results[i] = result
+			done <- i
+		}()
+	}
+
+	for n := 1; n <= len(endpoints); n++ {
+		var i int
+		select {
+		case i = <-done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("analysis cancelled: %w", ctx.Err())
+		}
+		if opts.OnEndpoint != nil {
+			opts.OnEndpoint(ctx, &results[i])
+		}
+		progress.EndpointsProcessed = n
+		progress.CurrentEndpoint = fmt.Sprintf("%s %s", endpoints[i].Method, endpoints[i].Path)
+		opts.reportProgress(*progress)
+	}
+	return results, nil
+}
+
+// processEndpoint generates and optionally executes a test per AI model
+func processEndpoint(ctx context.Context, endpoint *parser.Endpoint, opts *Options, aiManager *ai.Manager, testGen *generator.TestGenerator, onModel func(string)) reporter.EndpointResult {
+	log.Info().
+		Str("method", endpoint.Method).
+		Str("path", endpoint.Path).
+		Msg("Processing endpoint")
+
+	result, runTests := newEndpointResult(endpoint, opts)
+	models, route := aiManager.ModelsFor(endpoint, opts.Models)
+	if route != "" {
+		result.Models, result.Route = models, route
+	}
+
+	// Models generate concurrently, bounded by their providers' limits;
+	// their tests are then assessed one at a time
+	generations := generateTests(ctx, endpoint, models, opts, aiManager)
+	for i, modelName := range models {
+		onModel(modelName)
+		generated := generations[i]
+		if generated == nil {
+			continue
+		}
+
+		testResult := reporter.TestResult{
+			AIModel:   modelName,
+			Prompt:    generated.Prompt,
+			TestCode:  testGen.Parameterize(generated.TestCode, endpoint),
+			Framework: opts.Framework,
+			Metadata:  generated.Metadata,
+		}
+		testResult.Metrics.Performance.TokensUsed = generated.TokensUsed
+		testResult.Metrics.Performance.APICallsCount = 1
+		blocked := assessTest(ctx, endpoint, &testResult, testGen, runTests)
+		if opts.ArtifactsDir != "" {
+			testResult.ArtifactsDir = artifacts.Dir(opts.ArtifactsDir, endpoint, modelName)
+			saveArtifacts(ctx, testResult.ArtifactsDir, generated, &testResult, endpoint, testGen)
+		}
+		if opts.RepairAttempts > 0 {
+			blocked = repairTest(ctx, endpoint, &testResult, blocked, opts, aiManager, testGen, runTests)
+		}
+		if blocked != "" {
+			result.Status = reporter.StatusFailed
+			result.Warnings = append(result.Warnings, modelName+": "+blocked)
+		}
+		if runTests {
+			opts.Events.Emit(testEvent(endpoint, &testResult))
+		}
+		result.Tests[modelName] = testResult
+	}
+
+	if opts.Ensemble != "" && len(result.Tests) > 1 {
+		result.Ensemble = ensemble(ctx, endpoint, &result, opts, testGen, runTests)
+	}
+
+	return result
+}
+
+// coverageModels returns the models of the run and those routing rules
+// assigned to endpoints of results
+func coverageModels(results []reporter.EndpointResult, models []string) []string {
+	all := slices.Clone(models)
+	for i := range results {
+		for _, model := range results[i].Models {
+			if !slices.Contains(all, model) {
+				all = append(all, model)
+			}
+		}
+	}
+	return all
+}
+
+// newEndpointResult returns the empty result of endpoint, classified by
+// its side effects, and whether its tests are executed: mutating and
+// destructive endpoints only run when opts allows their risk
+func newEndpointResult(endpoint *parser.Endpoint, opts *Options) (result reporter.EndpointResult, runTests bool) {
+	category := safety.Categorise(endpoint.Method, endpoint.Path, endpoint.XSafe).WithRisk(endpoint.DeclaredRisk())
+	result = reporter.EndpointResult{
+		Endpoint:  *endpoint,
+		Tests:     make(map[string]reporter.TestResult),
+		Category:  category.Category,
+		RiskLevel: category.Risk,
+		Warnings:  safety.Warnings([]safety.EndpointCategory{category}),
+	}
+
+	runTests = opts.RunTests
+	if runTests && !opts.AllowRisk.Allows(category.Risk) {
+		runTests = false
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"tests not executed: %s risk exceeds allowed risk %s", category.Risk, opts.AllowRisk))
+		log.Warn().
+			Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+			Str("risk", string(category.Risk)).
+			Str("allow_risk", string(opts.AllowRisk)).
+			Msg("Skipping test execution for risky endpoint")
+	}
+	return result, runTests
+}
+
+// generateTests generates a test of endpoint with every model at once and
+// returns them in model order, nil where generation failed
+func generateTests(ctx context.Context, endpoint *parser.Endpoint, models []string, opts *Options, aiManager *ai.Manager) []*ai.TestGenerationResult {
+	results := make([]*ai.TestGenerationResult, len(models))
+	var wg sync.WaitGroup
+	for i, modelName := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info().
+				Str("ai_model", modelName).
+				Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+				Msg("Generating tests with AI model")
+
+			started := time.Now()
+			generated, err := aiManager.Generate(ctx, modelName, endpoint)
+			generation := EndpointEvent(events.GenerationFinished, endpoint)
+			generation.Model = modelName
+			generation.DurationMS = time.Since(started).Milliseconds()
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("ai_model", modelName).
+					Msg("Failed to generate test")
+				generation.Outcome = "error"
+				generation.Error = err.Error()
+				opts.Events.Emit(generation)
+				return
+			}
+			generation.Outcome = "success"
+			opts.Events.Emit(generation)
+			results[i] = generated
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// assessTest measures a generated test, runs the static analysis gate and
+// executes the test when runTests is set and the gate allows it. It returns
+// why the gate blocked the test, if it did.
+func assessTest(ctx context.Context, endpoint *parser.Endpoint, testResult *reporter.TestResult, testGen *generator.TestGenerator, runTests bool) (blocked string) {
+	measureTest(endpoint, testResult)
+
+	// Statically analyse the test; blocked tests are never executed
+	if testGen.LintEnabled() {
+		if reason, isBlocked := lintTest(ctx, testGen, endpoint, testResult); isBlocked {
+			if runTests {
+				testResult.ExecutionError = reason
+			}
+			return reason
+		}
+	}
+
+	if runTests {
+		executeTest(ctx, testGen, endpoint, testResult)
+	}
+	return ""
+}
+
+// executeTest runs a generated test and records the outcome on testResult
+func executeTest(ctx context.Context, testGen *generator.TestGenerator, endpoint *parser.Endpoint, testResult *reporter.TestResult) {
+	log.Info().
+		Str("ai_model", testResult.AIModel).
+		Msg("Executing generated test")
+
+	execResult, err := testGen.ExecuteTest(ctx, testResult.TestCode, endpoint)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("ai_model", testResult.AIModel).
+			Msg("Test execution failed")
+		testResult.ExecutionError = err.Error()
+		telemetry.TestResults.Inc(testResult.AIModel, "error")
+		return
+	}
+
+	testResult.ExecutionResult = execResult
+	telemetry.TestResults.Inc(testResult.AIModel, testOutcome(execResult))
+	log.Info().
+		Str("ai_model", testResult.AIModel).
+		Bool("passed", execResult.Passed).
+		Dur("duration", execResult.Duration).
+		Msg("Test execution completed")
+
+	// Flaky tests passed on retry and must not open failure issues
+	if execResult.Flaky {
+		log.Warn().
+			Str("ai_model", testResult.AIModel).
+			Int("attempts", execResult.Attempts).
+			Msg("Test passed on retry - marked as flaky, no issue will be created")
+	}
+}
+
+// EndpointEvent returns an event of type t about endpoint
+func EndpointEvent(t events.Type, endpoint *parser.Endpoint) events.Event {
+	return events.Event{Type: t, Endpoint: endpoint.ID, Method: endpoint.Method, Path: endpoint.Path}
+}
+
+// testEvent returns the test_executed event of a test's final run; tests
+// blocked by static analysis are reported with an error outcome
+func testEvent(endpoint *parser.Endpoint, testResult *reporter.TestResult) events.Event {
+	event := EndpointEvent(events.TestExecuted, endpoint)
+	event.Model = testResult.AIModel
+	if execResult := testResult.ExecutionResult; execResult != nil {
+		event.Outcome = testOutcome(execResult)
+		event.DurationMS = execResult.Duration.Milliseconds()
+	} else {
+		event.Outcome = "error"
+	}
+	event.Error = testResult.ExecutionError
+	return event
+}
+
+// testOutcome classifies an execution for the glens_tests_total metric
+func testOutcome(result *generator.ExecutionResult) string {
+	switch {
+	case result.Flaky:
+		return "flaky"
+	case result.Passed:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
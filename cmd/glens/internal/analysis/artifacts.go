@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// saveArtifacts writes the artifacts of the generation (or repair) of
+// testResult to dir. A test that was not executed is compiled for its
+// compiler output. Write errors are logged and never interrupt a run.
+func saveArtifacts(ctx context.Context, dir string, generated *ai.TestGenerationResult, testResult *reporter.TestResult, endpoint *parser.Endpoint, testGen *generator.TestGenerator) {
+	attempt := artifacts.Attempt{
+		SystemPrompt: generated.SystemPrompt,
+		Prompt:       generated.Prompt,
+		RawResponse:  generated.RawResponse,
+		TestCode:     testResult.TestCode,
+		Findings:     testResult.Metrics.CodeQuality.StaticFindings,
+		TestOutput:   testResult.ExecutionError,
+	}
+	switch exec := testResult.ExecutionResult; {
+	case exec != nil:
+		attempt.TestOutput = exec.Output
+		var compile []string
+		for _, e := range exec.Errors {
+			if e.TestName == "compilation" {
+				compile = append(compile, e.Message)
+			}
+		}
+		attempt.CompileOutput = strings.Join(compile, "\n")
+	case testResult.ExecutionError == "":
+		if err := testGen.Compile(ctx, testResult.TestCode, endpoint); err != nil {
+			attempt.CompileOutput = err.Error()
+		}
+	}
+
+	if err := artifacts.Write(dir, &attempt); err != nil {
+		log.Warn().
+			Err(err).
+			Str("artifacts_dir", dir).
+			Msg("Failed to save generation artifacts")
+	}
+}
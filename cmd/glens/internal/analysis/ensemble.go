@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// Ensemble modes
+const (
+	// EnsembleBest picks the test that passes, or else compiles, with the
+	// highest quality score
+	EnsembleBest = "best"
+	// EnsembleMerge merges the unique test functions of every model into
+	// one suite
+	EnsembleMerge = "merge"
+	// EnsembleModel is the AI model name of merged tests
+	EnsembleModel = "ensemble"
+)
+
+// candidate is a model's test ranked for the ensemble
+type candidate struct {
+	model    string
+	test     *reporter.TestResult
+	passed   bool
+	compiles bool
+}
+
+// ensemble combines the tests generated for endpoint by every model
+func ensemble(ctx context.Context, endpoint *parser.Endpoint, result *reporter.EndpointResult, opts *Options, testGen *generator.TestGenerator, runTests bool) *reporter.EnsembleResult {
+	candidates := rankCandidates(ctx, endpoint, result.Tests, testGen)
+	if opts.Ensemble == EnsembleBest {
+		return selectBest(endpoint, candidates[0])
+	}
+
+	sources := make([]generator.TestSource, len(candidates))
+	for i, c := range candidates {
+		sources[i] = generator.TestSource{Model: c.model, Code: c.test.TestCode}
+	}
+	merged, err := generator.MergeTests(sources)
+	if err != nil {
+		// Unparseable tests cannot be merged; fall back to the best one
+		log.Warn().
+			Err(err).
+			Str("endpoint", endpoint.Method+" "+endpoint.Path).
+			Msg("Failed to merge tests, selecting the best instead")
+		return selectBest(endpoint, candidates[0])
+	}
+
+	testResult := reporter.TestResult{
+		AIModel:   EnsembleModel,
+		TestCode:  merged.Code,
+		Framework: opts.Framework,
+	}
+	if blocked := assessTest(ctx, endpoint, &testResult, testGen, runTests); blocked != "" {
+		result.Status = reporter.StatusFailed
+		result.Warnings = append(result.Warnings, EnsembleModel+": "+blocked)
+	}
+	log.Info().
+		Str("endpoint", endpoint.Method+" "+endpoint.Path).
+		Int("models", len(merged.Contributions)).
+		Float64("quality_score", testResult.QualityScore).
+		Msg("Ensemble merged tests")
+	return &reporter.EnsembleResult{
+		Mode:          EnsembleMerge,
+		Contributions: merged.Contributions,
+		Test:          testResult,
+	}
+}
+
+// selectBest makes the ensemble result of the top ranked test
+func selectBest(endpoint *parser.Endpoint, best candidate) *reporter.EnsembleResult {
+	log.Info().
+		Str("endpoint", endpoint.Method+" "+endpoint.Path).
+		Str("selected", best.model).
+		Msg("Ensemble selected best test")
+	return &reporter.EnsembleResult{
+		Mode:          EnsembleBest,
+		Selected:      best.model,
+		Contributions: map[string][]string{best.model: generator.TestFunctions(best.test.TestCode)},
+		Test:          *best.test,
+	}
+}
+
+// rankCandidates orders the tests best first: passing tests, then tests
+// that compile, each by quality score. Tests that were not executed are
+// compiled to find out.
+func rankCandidates(ctx context.Context, endpoint *parser.Endpoint, tests map[string]reporter.TestResult, testGen *generator.TestGenerator) []candidate {
+	candidates := make([]candidate, 0, len(tests))
+	for model := range tests {
+		test := tests[model]
+		c := candidate{model: model, test: &test}
+		if exec := test.ExecutionResult; exec != nil {
+			c.passed = exec.Passed
+			c.compiles = !hasCompilationError(exec)
+		} else if test.ExecutionError == "" {
+			c.compiles = testGen.Compile(ctx, test.TestCode, endpoint) == nil
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.passed != b.passed {
+			return a.passed
+		}
+		if a.compiles != b.compiles {
+			return a.compiles
+		}
+		if a.test.QualityScore != b.test.QualityScore {
+			return a.test.QualityScore > b.test.QualityScore
+		}
+		return a.model < b.model
+	})
+	return candidates
+}
+
+func hasCompilationError(exec *generator.ExecutionResult) bool {
+	for _, e := range exec.Errors {
+		if e.TestName == "compilation" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+const (
+	testCodeA = "package main\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) { t.Log(\"a\") }\n"
+	testCodeB = "package main\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) { t.Log(\"b\") }\n"
+	// unparseableCode cannot be merged
+	unparseableCode = "package main\n\nfunc TestC(t *testing.T) {"
+)
+
+var (
+	passedRun       = &generator.ExecutionResult{Passed: true}
+	failedRun       = &generator.ExecutionResult{Failed: true, Errors: []generator.TestError{{TestName: "TestA", Message: "status 500"}}}
+	notCompilingRun = &generator.ExecutionResult{Failed: true, Errors: []generator.TestError{{TestName: "compilation", Message: "undefined: x"}}}
+	endpointOfTest  = &parser.Endpoint{ID: "GET__users", Method: "GET", Path: "/users"}
+)
+
+func TestRankCandidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		tests map[string]reporter.TestResult
+		want  []string
+	}{
+		{
+			name: "passing tests first",
+			tests: map[string]reporter.TestResult{
+				"a": {QualityScore: 90, ExecutionResult: failedRun},
+				"b": {QualityScore: 10, ExecutionResult: passedRun},
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "then tests that compile",
+			tests: map[string]reporter.TestResult{
+				"a": {QualityScore: 90, ExecutionResult: notCompilingRun},
+				"b": {QualityScore: 10, ExecutionResult: failedRun},
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "tests that could not be run do not compile",
+			tests: map[string]reporter.TestResult{
+				"a": {QualityScore: 90, ExecutionError: "go not found"},
+				"b": {QualityScore: 10, ExecutionResult: failedRun},
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "then by quality score",
+			tests: map[string]reporter.TestResult{
+				"a": {QualityScore: 50, ExecutionResult: passedRun},
+				"b": {QualityScore: 80, ExecutionResult: passedRun},
+				"c": {QualityScore: 20, ExecutionResult: passedRun},
+			},
+			want: []string{"b", "a", "c"},
+		},
+		{
+			name: "ties by model name",
+			tests: map[string]reporter.TestResult{
+				"b": {QualityScore: 50, ExecutionResult: passedRun},
+				"a": {QualityScore: 50, ExecutionResult: passedRun},
+			},
+			want: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := rankCandidates(context.Background(), endpointOfTest, tt.tests, generator.NewTestGenerator("testify"))
+
+			var got []string
+			for _, c := range candidates {
+				got = append(got, c.model)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnsemble(t *testing.T) {
+	tests := []struct {
+		name              string
+		mode              string
+		codeB             string
+		wantMode          string
+		wantSelected      string
+		wantModel         string
+		wantContributions map[string][]string
+	}{
+		{
+			name:              "best picks the top ranked test",
+			mode:              EnsembleBest,
+			codeB:             testCodeB,
+			wantMode:          EnsembleBest,
+			wantSelected:      "b",
+			wantModel:         "b",
+			wantContributions: map[string][]string{"b": {"TestB"}},
+		},
+		{
+			name:              "merge combines the tests of every model",
+			mode:              EnsembleMerge,
+			codeB:             testCodeB,
+			wantMode:          EnsembleMerge,
+			wantModel:         EnsembleModel,
+			wantContributions: map[string][]string{"a": {"TestA"}, "b": {"TestB"}},
+		},
+		{
+			name:              "merge falls back to the best test when a test cannot be parsed",
+			mode:              EnsembleMerge,
+			codeB:             unparseableCode,
+			wantMode:          EnsembleBest,
+			wantSelected:      "b",
+			wantModel:         "b",
+			wantContributions: map[string][]string{"b": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &reporter.EndpointResult{
+				Status: reporter.StatusCompleted,
+				Tests: map[string]reporter.TestResult{
+					"a": {AIModel: "a", TestCode: testCodeA, ExecutionResult: failedRun},
+					"b": {AIModel: "b", TestCode: tt.codeB, ExecutionResult: passedRun},
+				},
+			}
+			opts := &Options{Ensemble: tt.mode, Framework: "testify"}
+
+			got := ensemble(context.Background(), endpointOfTest, result, opts, generator.NewTestGenerator("testify"), false)
+
+			require.NotNil(t, got)
+			assert.Equal(t, tt.wantMode, got.Mode)
+			assert.Equal(t, tt.wantSelected, got.Selected)
+			assert.Equal(t, tt.wantModel, got.Test.AIModel)
+			assert.Equal(t, tt.wantContributions, got.Contributions)
+			assert.Equal(t, reporter.StatusCompleted, result.Status)
+		})
+	}
+}
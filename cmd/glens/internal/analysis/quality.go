@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/metrics"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// measureTest fills the metrics and quality score of testResult from a
+// static analysis of its code against endpoint. Code that does not parse
+// scores zero.
+func measureTest(endpoint *parser.Endpoint, testResult *reporter.TestResult) {
+	quality, err := metrics.AnalyzeTest(testResult.TestCode, endpointFacts(endpoint))
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("ai_model", testResult.AIModel).
+			Msg("Generated test is not valid Go, quality score is 0")
+		return
+	}
+
+	testResult.QualityScore = quality.Score
+	testResult.Metrics.CodeQuality = reporter.CodeQuality{
+		LinesOfCode:       quality.LinesOfCode,
+		TestFunctionCount: quality.TestFunctions,
+		AssertionCount:    quality.Assertions,
+		CommentLines:      quality.CommentLines,
+		ComplexityScore:   quality.Complexity,
+		ReadabilityScore:  quality.Readability,
+		CategoriesCovered: quality.Categories,
+		AssertionStrength: quality.Strength.Score,
+		WeakTests:         quality.Strength.WeakTests,
+	}
+	testResult.Metrics.TestCoverage = reporter.TestCoverage{
+		HTTPMethodsCovered:   quality.Methods,
+		StatusCodesCovered:   quality.StatusCodes,
+		ParametersCovered:    quality.ParametersCovered,
+		ParametersTotal:      quality.ParametersTotal,
+		ResponseTypesCovered: quality.DocumentedCovered,
+		EdgeCasesCovered:     quality.EdgeCases,
+		CoveragePercentage:   quality.Coverage,
+	}
+	testResult.Metrics.SecurityCoverage = reporter.SecurityCoverage{
+		AuthenticationTests:  quality.Security.Authentication,
+		AuthorizationTests:   quality.Security.Authorization,
+		InputValidationTests: quality.Security.InputValidation,
+		SQLInjectionTests:    quality.Security.SQLInjection,
+		XSSTests:             quality.Security.XSS,
+		SecurityScore:        quality.Security.Score,
+	}
+}
+
+// endpointFacts describes endpoint for the quality analyzer
+func endpointFacts(endpoint *parser.Endpoint) metrics.Endpoint {
+	facts := metrics.Endpoint{
+		Method:      endpoint.Method,
+		Path:        endpoint.Path,
+		StatusCodes: make([]string, 0, len(endpoint.Responses)),
+		Parameters:  make([]string, 0, len(endpoint.Parameters)),
+	}
+	for code := range endpoint.Responses {
+		facts.StatusCodes = append(facts.StatusCodes, code)
+	}
+	sort.Strings(facts.StatusCodes)
+	for i := range endpoint.Parameters {
+		facts.Parameters = append(facts.Parameters, endpoint.Parameters[i].Name)
+	}
+	return facts
+}
+
+// lintTest runs the static analysis gate over a generated test, records its
+// findings and deducts them from the quality score. It returns why the test
+// is blocked from execution, if it is; a gate that cannot run blocks nothing.
+func lintTest(ctx context.Context, testGen *generator.TestGenerator, endpoint *parser.Endpoint, testResult *reporter.TestResult) (reason string, blocked bool) {
+	findings, blocked, err := testGen.Lint(ctx, testResult.TestCode, endpoint)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("ai_model", testResult.AIModel).
+			Msg("Static analysis failed")
+		return "", false
+	}
+
+	testResult.Metrics.CodeQuality.StaticFindings = findings
+	testResult.QualityScore = max(0, testResult.QualityScore-generator.LintPenalty(findings))
+	if !blocked {
+		return "", false
+	}
+
+	worst := findings[0]
+	for _, f := range findings[1:] {
+		if f.Severity.AtLeast(worst.Severity) && f.Severity != worst.Severity {
+			worst = f
+		}
+	}
+	log.Warn().
+		Str("ai_model", testResult.AIModel).
+		Str("tool", worst.Tool).
+		Str("rule", worst.Rule).
+		Str("severity", string(worst.Severity)).
+		Msg("Generated test blocked by static analysis")
+	return fmt.Sprintf("blocked by static analysis: %s %s (%s) at line %d: %s",
+		worst.Tool, worst.Rule, worst.Severity, worst.Line, worst.Message), true
+}
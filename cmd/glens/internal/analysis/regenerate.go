@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// Regeneration compares a suite written by generator.WriteSuite with the
+// current spec, by the endpoint fingerprints in its index
+type Regeneration struct {
+	// Changed are the files whose endpoint changed since they were generated
+	Changed []string
+	// Unchanged counts the files whose endpoint is as generated
+	Unchanged int
+	// Removed are the files whose endpoint is no longer in the spec; they
+	// are left in place for the team to delete
+	Removed []string
+	// Skipped are changed files glens cannot regenerate: ensemble and
+	// merged files, which combine several models, and files whose model
+	// failed
+	Skipped []string
+	// Added are the endpoints of the spec without tests in the suite
+	Added []string
+	// Models are the models that generated the changed files
+	Models []string
+}
+
+// PlanRegeneration finds the files of index whose endpoint changed in spec,
+// without touching the suite
+func PlanRegeneration(index *generator.SuiteIndex, spec *parser.OpenAPISpec) *Regeneration {
+	plan := &Regeneration{}
+	covered := make(map[string]bool)
+	models := make(map[string]bool)
+	for i := range index.Files {
+		entry := &index.Files[i]
+		endpoint := suiteEndpoint(entry, spec)
+		switch {
+		case endpoint == nil:
+			plan.Removed = append(plan.Removed, entry.Path)
+			continue
+		case endpoint.Fingerprint() == entry.Fingerprint:
+			plan.Unchanged++
+		case entry.Metadata["ai_model"] == "" || entry.Metadata["ai_model"] == EnsembleModel || entry.Metadata["ai_model"] == MergedModel:
+			plan.Skipped = append(plan.Skipped, entry.Path)
+		default:
+			plan.Changed = append(plan.Changed, entry.Path)
+			models[entry.Metadata["ai_model"]] = true
+		}
+		covered[endpoint.ID] = true
+	}
+
+	for i := range spec.Endpoints {
+		if endpoint := &spec.Endpoints[i]; !covered[endpoint.ID] {
+			plan.Added = append(plan.Added, endpoint.Method+" "+endpoint.Path)
+		}
+	}
+	for model := range models {
+		plan.Models = append(plan.Models, model)
+	}
+	sort.Strings(plan.Models)
+	return plan
+}
+
+// Regenerate rewrites the files of the suite in dir whose endpoint changed
+// in spec with a test from the model that generated them, keeping their
+// keep regions, and updates the index. Files whose model fails are skipped.
+func Regenerate(ctx context.Context, dir string, spec *parser.OpenAPISpec, aiManager *ai.Manager) (*Regeneration, error) {
+	index, err := generator.ReadSuiteIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	plan := PlanRegeneration(index, spec)
+
+	changed := make(map[string]bool, len(plan.Changed))
+	for _, file := range plan.Changed {
+		changed[file] = true
+	}
+	plan.Changed = nil
+	testGen := generator.NewTestGenerator(index.Framework)
+	testGen.SetServers(spec.Servers)
+	for i := range index.Files {
+		entry := &index.Files[i]
+		if !changed[entry.Path] {
+			continue
+		}
+		if err := regenerateFile(ctx, dir, entry, suiteEndpoint(entry, spec), aiManager, testGen); err != nil {
+			log.Warn().
+				Err(err).
+				Str("file", entry.Path).
+				Msg("Failed to regenerate test")
+			plan.Skipped = append(plan.Skipped, entry.Path)
+			continue
+		}
+		plan.Changed = append(plan.Changed, entry.Path)
+	}
+	sort.Strings(plan.Skipped)
+
+	index.TotalTests = 0
+	for i := range index.Files {
+		index.TotalTests += len(index.Files[i].Tests)
+	}
+	if err := generator.WriteSuiteIndex(dir, index); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// regenerateFile rewrites the file of entry with a new test for endpoint
+// from the entry's model, parameterized by testGen. The test is measured
+// but not run.
+func regenerateFile(ctx context.Context, dir string, entry *generator.SuiteEntry, endpoint *parser.Endpoint, aiManager *ai.Manager, testGen *generator.TestGenerator) error {
+	model := entry.Metadata["ai_model"]
+	log.Info().
+		Str("ai_model", model).
+		Str("endpoint", endpoint.Method+" "+endpoint.Path).
+		Str("file", entry.Path).
+		Msg("Regenerating test for changed endpoint")
+
+	generated, err := aiManager.Generate(ctx, model, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to generate test: %w", err)
+	}
+	testCode := testGen.Parameterize(generated.TestCode, endpoint)
+	if err := generator.RewriteSuiteFile(dir, entry, endpoint, testCode); err != nil {
+		return err
+	}
+
+	testResult := reporter.TestResult{AIModel: model, TestCode: testCode}
+	measureTest(endpoint, &testResult)
+	metadata := maps.Clone(entry.Metadata)
+	maps.Copy(metadata, generated.Metadata)
+	metadata["status"] = testStatus(&testResult)
+	metadata["quality_score"] = fmt.Sprintf("%.1f", testResult.QualityScore)
+	entry.Metadata = metadata
+	return nil
+}
+
+// suiteEndpoint returns the endpoint of spec a suite file tests, or nil
+// when the spec no longer has it. Entries are matched by endpoint ID, then
+// by method and path.
+func suiteEndpoint(entry *generator.SuiteEntry, spec *parser.OpenAPISpec) *parser.Endpoint {
+	for i := range spec.Endpoints {
+		endpoint := &spec.Endpoints[i]
+		if entry.EndpointID != "" && endpoint.ID == entry.EndpointID {
+			return endpoint
+		}
+		if entry.EndpointID == "" && endpoint.Method+" "+endpoint.Path == entry.Endpoint {
+			return endpoint
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+func TestPlanRegeneration(t *testing.T) {
+	users := parser.Endpoint{ID: "GET__users", Method: "GET", Path: "/users"}
+	posts := parser.Endpoint{ID: "GET__posts", Method: "GET", Path: "/posts"}
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{users, posts}}
+	current := users.Fingerprint()
+	entry := func(path, endpointID, model, fingerprint string) generator.SuiteEntry {
+		return generator.SuiteEntry{
+			Path:        path,
+			Endpoint:    "GET /users",
+			EndpointID:  endpointID,
+			Fingerprint: fingerprint,
+			Metadata:    map[string]string{"ai_model": model},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		entries []generator.SuiteEntry
+		want    Regeneration
+	}{
+		{
+			name:    "unchanged",
+			entries: []generator.SuiteEntry{entry("users_gpt4_test.go", users.ID, "gpt4", current)},
+			want:    Regeneration{Unchanged: 1, Added: []string{"GET /posts"}},
+		},
+		{
+			name:    "changed",
+			entries: []generator.SuiteEntry{entry("users_gpt4_test.go", users.ID, "gpt4", "stale")},
+			want:    Regeneration{Changed: []string{"users_gpt4_test.go"}, Added: []string{"GET /posts"}, Models: []string{"gpt4"}},
+		},
+		{
+			name:    "matched by method and path without an endpoint ID",
+			entries: []generator.SuiteEntry{entry("users_gpt4_test.go", "", "gpt4", "stale")},
+			want:    Regeneration{Changed: []string{"users_gpt4_test.go"}, Added: []string{"GET /posts"}, Models: []string{"gpt4"}},
+		},
+		{
+			name:    "removed from the spec",
+			entries: []generator.SuiteEntry{entry("legacy_gpt4_test.go", "GET__legacy", "gpt4", "stale")},
+			want:    Regeneration{Removed: []string{"legacy_gpt4_test.go"}, Added: []string{"GET /users", "GET /posts"}},
+		},
+		{
+			name: "changed tests of several models cannot be regenerated",
+			entries: []generator.SuiteEntry{
+				entry("users_ensemble_test.go", users.ID, EnsembleModel, "stale"),
+				entry("users_merged_test.go", users.ID, MergedModel, "stale"),
+				entry("users_test.go", users.ID, "", "stale"),
+			},
+			want: Regeneration{
+				Skipped: []string{"users_ensemble_test.go", "users_merged_test.go", "users_test.go"},
+				Added:   []string{"GET /posts"},
+			},
+		},
+		{
+			name:    "unchanged ensemble tests",
+			entries: []generator.SuiteEntry{entry("users_ensemble_test.go", users.ID, EnsembleModel, current)},
+			want:    Regeneration{Unchanged: 1, Added: []string{"GET /posts"}},
+		},
+		{
+			name: "models of the changed files, once and sorted",
+			entries: []generator.SuiteEntry{
+				entry("users_llama_test.go", users.ID, "llama3", "stale"),
+				entry("users_gpt4_test.go", users.ID, "gpt4", "stale"),
+				entry("users_gpt4_2_test.go", users.ID, "gpt4", "stale"),
+			},
+			want: Regeneration{
+				Changed: []string{"users_llama_test.go", "users_gpt4_test.go", "users_gpt4_2_test.go"},
+				Added:   []string{"GET /posts"},
+				Models:  []string{"gpt4", "llama3"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PlanRegeneration(&generator.SuiteIndex{Files: tt.entries}, spec)
+
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// maxFailureBytes bounds the compiler or test output sent back to a model;
+// the end of the output, where go test summarises failures, is kept
+const maxFailureBytes = 8000
+
+// repairTest sends a test that failed to compile, was blocked by static
+// analysis or failed when run back to its model with the failure, up to
+// opts.RepairAttempts times, and assesses each fix like a generated test.
+// testResult ends up holding the last version and the attempts made in its
+// metadata. It returns why the gate blocked the last version, if it did.
+func repairTest(ctx context.Context, endpoint *parser.Endpoint, testResult *reporter.TestResult, blocked string, opts *Options, aiManager *ai.Manager, testGen *generator.TestGenerator, runTests bool) string {
+	// A test from a fallback model is repaired by the model that wrote it
+	model := testResult.AIModel
+	if generatedBy := testResult.Metadata["generated_by"]; generatedBy != "" {
+		model = generatedBy
+	}
+	attempts := 0
+	for attempts < opts.RepairAttempts {
+		failure := repairFailure(ctx, endpoint, testResult, blocked, testGen)
+		if failure == "" {
+			break
+		}
+
+		log.Info().
+			Str("ai_model", model).
+			Str("endpoint", endpoint.Method+" "+endpoint.Path).
+			Int("attempt", attempts+1).
+			Msg("Asking model to repair failing test")
+		repaired, err := aiManager.Repair(ctx, model, endpoint, testResult.TestCode, failure)
+		if err != nil {
+			if errors.As(err, &ai.ErrRepairUnsupported{}) {
+				log.Debug().Str("ai_model", model).Msg("Model cannot repair tests")
+			} else {
+				log.Warn().Err(err).Str("ai_model", model).Msg("Failed to repair test")
+			}
+			break
+		}
+		attempts++
+
+		metadata := maps.Clone(testResult.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		maps.Copy(metadata, repaired.Metadata)
+		performance := testResult.Metrics.Performance
+		*testResult = reporter.TestResult{
+			AIModel:      testResult.AIModel,
+			Prompt:       testResult.Prompt,
+			TestCode:     testGen.Parameterize(repaired.TestCode, endpoint),
+			Framework:    testResult.Framework,
+			Metadata:     metadata,
+			ArtifactsDir: testResult.ArtifactsDir,
+		}
+		// The tokens of every repair count towards the test's usage
+		testResult.Metrics.Performance.TokensUsed = performance.TokensUsed + repaired.TokensUsed
+		testResult.Metrics.Performance.APICallsCount = performance.APICallsCount + 1
+		blocked = assessTest(ctx, endpoint, testResult, testGen, runTests)
+		if testResult.ArtifactsDir != "" {
+			saveArtifacts(ctx, artifacts.RepairDir(testResult.ArtifactsDir, attempts), repaired, testResult, endpoint, testGen)
+		}
+	}
+
+	if attempts > 0 {
+		repairedOK := repairFailure(ctx, endpoint, testResult, blocked, testGen) == ""
+		testResult.Metadata["repair_attempts"] = strconv.Itoa(attempts)
+		testResult.Metadata["repaired"] = strconv.FormatBool(repairedOK)
+		log.Info().
+			Str("ai_model", model).
+			Int("attempts", attempts).
+			Bool("repaired", repairedOK).
+			Msg("Test repair finished")
+	}
+	return blocked
+}
+
+// repairFailure describes why testResult needs repair: the static analysis
+// findings that blocked it, its failing test output or, when it was not
+// executed, its compiler errors. It is empty when the test needs no repair;
+// infrastructure errors are not the model's to fix.
+func repairFailure(ctx context.Context, endpoint *parser.Endpoint, testResult *reporter.TestResult, blocked string, testGen *generator.TestGenerator) string {
+	if blocked != "" {
+		var failure strings.Builder
+		failure.WriteString(blocked + "\n")
+		for _, f := range testResult.Metrics.CodeQuality.StaticFindings {
+			fmt.Fprintf(&failure, "%s %s (%s) line %d: %s\n", f.Tool, f.Rule, f.Severity, f.Line, f.Message)
+		}
+		return failure.String()
+	}
+
+	if exec := testResult.ExecutionResult; exec != nil {
+		if exec.Passed || exec.Skipped {
+			return ""
+		}
+		output := exec.Output
+		if output == "" {
+			for _, e := range exec.Errors {
+				output += e.TestName + ": " + e.Message + "\n"
+			}
+		}
+		return tail(output, maxFailureBytes)
+	}
+	if testResult.ExecutionError != "" {
+		return ""
+	}
+
+	if err := testGen.Compile(ctx, testResult.TestCode, endpoint); err != nil {
+		return tail(err.Error(), maxFailureBytes)
+	}
+	return ""
+}
+
+// tail returns the last n bytes of s
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
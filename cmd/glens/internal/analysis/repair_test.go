@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/reporter"
+)
+
+func TestRepairFailure(t *testing.T) {
+	long := strings.Repeat("x", maxFailureBytes) + "--- FAIL: TestA"
+	blockedTest := reporter.TestResult{}
+	blockedTest.Metrics.CodeQuality.StaticFindings = []generator.Finding{
+		{Tool: "gosec", Rule: "G402", Severity: "high", Line: 12, Message: "TLS InsecureSkipVerify set true"},
+	}
+
+	tests := []struct {
+		name    string
+		test    reporter.TestResult
+		blocked string
+		want    string
+	}{
+		{"blocked by static analysis", blockedTest, "blocked by gosec", "blocked by gosec\ngosec G402 (high) line 12: TLS InsecureSkipVerify set true\n"},
+		{"passed", reporter.TestResult{ExecutionResult: passedRun}, "", ""},
+		{"skipped", reporter.TestResult{ExecutionResult: &generator.ExecutionResult{Skipped: true}}, "", ""},
+		{"failed with output", reporter.TestResult{ExecutionResult: &generator.ExecutionResult{Failed: true, Output: "--- FAIL: TestA"}}, "", "--- FAIL: TestA"},
+		{"failed without output", reporter.TestResult{ExecutionResult: failedRun}, "", "TestA: status 500\n"},
+		{"long output keeps its end", reporter.TestResult{ExecutionResult: &generator.ExecutionResult{Failed: true, Output: long}}, "", long[len(long)-maxFailureBytes:]},
+		{"infrastructure error", reporter.TestResult{ExecutionError: "go not found"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repairFailure(context.Background(), endpointOfTest, &tt.test, tt.blocked, generator.NewTestGenerator("testify"))
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRepairTest_AttemptBound(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles generated tests")
+	}
+	const (
+		compiling = "package main\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) { t.Log(\"a\") }\n"
+		broken    = "package main\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) { undefined() }\n"
+	)
+	// The mock model returns tests unchanged, so broken tests stay broken;
+	// the enhanced mock cannot repair tests
+	aiManager, err := ai.NewManager([]string{"mock", "enhanced-mock"}, ai.Config{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		model        string
+		generatedBy  string
+		code         string
+		attempts     int
+		wantAttempts string
+		wantCalls    int
+	}{
+		{"disabled", "mock", "", broken, 0, "", 0},
+		{"stops at the bound", "mock", "", broken, 2, "2", 2},
+		{"compiling tests need no repair", "mock", "", compiling, 3, "", 0},
+		{"models that cannot repair", "enhanced-mock", "", broken, 3, "", 0},
+		{"fallback tests are repaired by their model", "enhanced-mock", "mock", broken, 1, "1", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := reporter.TestResult{AIModel: tt.model, TestCode: tt.code, Metadata: map[string]string{}}
+			if tt.generatedBy != "" {
+				test.Metadata["generated_by"] = tt.generatedBy
+			}
+			opts := &Options{RepairAttempts: tt.attempts}
+
+			blocked := repairTest(context.Background(), endpointOfTest, &test, "", opts, aiManager, generator.NewTestGenerator("testify"), false)
+
+			assert.Empty(t, blocked)
+			assert.Equal(t, tt.wantAttempts, test.Metadata["repair_attempts"])
+			assert.Equal(t, tt.wantCalls, test.Metrics.Performance.APICallsCount)
+			if tt.wantAttempts != "" {
+				assert.Equal(t, "false", test.Metadata["repaired"], "the mock does not fix tests")
+				assert.Equal(t, tt.model, test.AIModel)
+			}
+		})
+	}
+}
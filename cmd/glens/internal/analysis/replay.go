@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/reporter"
+)
+
+// Replay re-executes the tests of a previous report, e.g. once the backend
+// has been fixed, and returns the new report without calling any model.
+// Test code is read from the artifacts saved under opts.ArtifactsDir, the
+// last repair of each test and any edit to it included, falling back to the
+// code in the report. Endpoints the previous run skipped stay skipped.
+// Models and Framework default to those of the previous report; the
+// environment, risk and execution settings are taken from opts.
+func Replay(ctx context.Context, previous *reporter.Report, opts Options) (*reporter.Report, error) {
+	if len(opts.Models) == 0 {
+		opts.Models = previous.Summary.AIModelsUsed
+	}
+	if opts.Framework == "" && len(previous.Summary.Frameworks) > 0 {
+		opts.Framework = previous.Summary.Frameworks[0]
+	}
+	testGen, err := prepare(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]reporter.EndpointResult, 0, len(previous.EndpointResults))
+	progress := jobs.Progress{EndpointsTotal: len(previous.EndpointResults)}
+	opts.reportProgress(progress)
+	for i := range previous.EndpointResults {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("replay cancelled: %w", err)
+		}
+
+		result := previous.EndpointResults[i]
+		progress.CurrentEndpoint = fmt.Sprintf("%s %s", result.Endpoint.Method, result.Endpoint.Path)
+		if result.Status != reporter.StatusSkipped {
+			result = replayEndpoint(ctx, &previous.EndpointResults[i], &opts, testGen)
+		}
+		if opts.OnEndpoint != nil {
+			opts.OnEndpoint(ctx, &result)
+		}
+		results = append(results, result)
+
+		progress.EndpointsProcessed = i + 1
+		opts.reportProgress(progress)
+	}
+
+	report := BuildReport(ctx, &previous.Specification, results, &opts)
+	report.Metadata["replay"] = true
+	report.Metadata["replayed_report_generated_at"] = previous.GeneratedAt
+	if opts.RunTests {
+		report.Metadata["allow_risk"] = string(opts.AllowRisk)
+	}
+	if opts.Env != nil {
+		report.Metadata["environment"] = opts.Env.Name
+		report.Metadata["base_url"] = opts.Env.BaseURL
+	}
+	return report, nil
+}
+
+// replayEndpoint re-executes the tests of an endpoint's previous result;
+// the issue it opened is kept so that the report links the fix to it
+func replayEndpoint(ctx context.Context, previous *reporter.EndpointResult, opts *Options, testGen *generator.TestGenerator) reporter.EndpointResult {
+	endpoint := &previous.Endpoint
+	log.Info().
+		Str("method", endpoint.Method).
+		Str("path", endpoint.Path).
+		Msg("Replaying endpoint")
+
+	result, runTests := newEndpointResult(endpoint, opts)
+	result.IssueNumber = previous.IssueNumber
+	for _, model := range slices.Sorted(maps.Keys(previous.Tests)) {
+		test := previous.Tests[model]
+		replayed := reporter.TestResult{
+			AIModel:     test.AIModel,
+			Prompt:      test.Prompt,
+			TestCode:    test.TestCode,
+			Framework:   test.Framework,
+			GeneratedAt: test.GeneratedAt,
+			Metadata:    test.Metadata,
+		}
+		if opts.ArtifactsDir != "" {
+			dir := artifacts.Dir(opts.ArtifactsDir, endpoint, model)
+			if code, err := artifacts.LatestCode(dir); err != nil {
+				log.Warn().
+					Err(err).
+					Str("ai_model", model).
+					Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+					Msg("No saved test code, replaying the code of the report")
+			} else {
+				replayed.TestCode = code
+				replayed.ArtifactsDir = dir
+			}
+		}
+
+		if blocked := assessTest(ctx, endpoint, &replayed, testGen, runTests); blocked != "" {
+			result.Status = reporter.StatusFailed
+			result.Warnings = append(result.Warnings, model+": "+blocked)
+		}
+		if runTests {
+			opts.Events.Emit(testEvent(endpoint, &replayed))
+		}
+		result.Tests[model] = replayed
+	}
+
+	// The best test is the replayed test of the selected model; merged
+	// tests are re-executed as they were merged
+	if previous.Ensemble != nil {
+		ensemble := *previous.Ensemble
+		if selected, ok := result.Tests[ensemble.Selected]; ok {
+			ensemble.Test = selected
+		} else {
+			ensemble.Test = reporter.TestResult{
+				AIModel:     ensemble.Test.AIModel,
+				TestCode:    ensemble.Test.TestCode,
+				Framework:   ensemble.Test.Framework,
+				GeneratedAt: ensemble.Test.GeneratedAt,
+				Metadata:    ensemble.Test.Metadata,
+			}
+			if blocked := assessTest(ctx, endpoint, &ensemble.Test, testGen, runTests); blocked != "" {
+				result.Status = reporter.StatusFailed
+				result.Warnings = append(result.Warnings, ensemble.Test.AIModel+": "+blocked)
+			}
+		}
+		result.Ensemble = &ensemble
+	}
+	return result
+}
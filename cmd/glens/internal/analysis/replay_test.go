@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/artifacts"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// writeArtifact saves code as the test of model for endpoint, or of its
+// repair when repair is positive
+func writeArtifact(t *testing.T, root string, endpoint *parser.Endpoint, model string, repair int, code string) {
+	t.Helper()
+	dir := artifacts.Dir(root, endpoint, model)
+	if repair > 0 {
+		dir = artifacts.RepairDir(dir, repair)
+	}
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, artifacts.CodeFile), []byte(code), 0o600))
+}
+
+func TestReplay(t *testing.T) {
+	const (
+		repairedCode = "package main\n\nimport \"testing\"\n\nfunc TestRepaired(t *testing.T) { t.Log(\"r\") }\n"
+		mergedCode   = "package main\n\nimport \"testing\"\n\nfunc TestMerged(t *testing.T) { t.Log(\"m\") }\n"
+	)
+	users := parser.Endpoint{ID: "GET__users", Method: "GET", Path: "/users"}
+	posts := parser.Endpoint{ID: "GET__posts", Method: "GET", Path: "/posts"}
+	deleteUser := parser.Endpoint{ID: "DELETE__users_id", Method: "DELETE", Path: "/users/{id}"}
+	previous := &reporter.Report{
+		Summary:       reporter.Summary{AIModelsUsed: []string{"a", "b"}, Frameworks: []string{"testify"}},
+		Specification: parser.OpenAPISpec{Endpoints: []parser.Endpoint{users, posts, deleteUser}},
+		EndpointResults: []reporter.EndpointResult{
+			{
+				Endpoint:    users,
+				Status:      reporter.StatusFailed,
+				IssueNumber: 7,
+				Tests: map[string]reporter.TestResult{
+					"a": {AIModel: "a", TestCode: testCodeA, ExecutionResult: failedRun},
+					"b": {AIModel: "b", TestCode: testCodeB, ExecutionResult: failedRun},
+				},
+				Ensemble: &reporter.EnsembleResult{Mode: EnsembleBest, Selected: "a", Test: reporter.TestResult{AIModel: "a", TestCode: testCodeA}},
+			},
+			{
+				Endpoint: posts,
+				Status:   reporter.StatusCompleted,
+				Tests: map[string]reporter.TestResult{
+					"a": {AIModel: "a", TestCode: testCodeA},
+				},
+				Ensemble: &reporter.EnsembleResult{Mode: EnsembleMerge, Test: reporter.TestResult{AIModel: EnsembleModel, TestCode: mergedCode, ExecutionResult: failedRun}},
+			},
+			{Endpoint: deleteUser, Status: reporter.StatusSkipped, SkipReason: "endpoint timeout"},
+		},
+	}
+	root := t.TempDir()
+	writeArtifact(t, root, &users, "a", 0, testCodeA)
+	writeArtifact(t, root, &users, "a", 1, repairedCode)
+
+	report, err := Replay(context.Background(), previous, Options{ArtifactsDir: root})
+
+	require.NoError(t, err)
+	assert.Equal(t, true, report.Metadata["replay"])
+	require.Len(t, report.EndpointResults, 3)
+
+	replayedUsers := report.EndpointResults[0]
+	assert.Equal(t, 7, replayedUsers.IssueNumber, "the issue of the previous run is kept")
+	assert.Equal(t, repairedCode, replayedUsers.Tests["a"].TestCode, "the last repair saved is replayed")
+	assert.Equal(t, artifacts.Dir(root, &users, "a"), replayedUsers.Tests["a"].ArtifactsDir)
+	assert.Equal(t, testCodeB, replayedUsers.Tests["b"].TestCode, "tests without artifacts replay the code of the report")
+	assert.Empty(t, replayedUsers.Tests["b"].ArtifactsDir)
+	assert.Nil(t, replayedUsers.Tests["b"].ExecutionResult, "tests are not run without RunTests")
+	require.NotNil(t, replayedUsers.Ensemble)
+	assert.Equal(t, replayedUsers.Tests["a"], replayedUsers.Ensemble.Test, "the ensemble is the replayed test of its selected model")
+
+	replayedPosts := report.EndpointResults[1]
+	require.NotNil(t, replayedPosts.Ensemble)
+	assert.Equal(t, EnsembleModel, replayedPosts.Ensemble.Test.AIModel)
+	assert.Equal(t, mergedCode, replayedPosts.Ensemble.Test.TestCode, "merged tests are replayed as merged")
+	assert.Nil(t, replayedPosts.Ensemble.Test.ExecutionResult)
+
+	assert.Equal(t, previous.EndpointResults[2], report.EndpointResults[2], "skipped endpoints stay skipped")
+}
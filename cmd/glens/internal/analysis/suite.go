@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/reporter"
+)
+
+// MergedModel is the AI model name of the deduplicated tests of all models
+// of an endpoint (--merge-models)
+const MergedModel = "merged"
+
+// Suite collects the tests of report, including ensemble tests, into a
+// suite laid out by generator.SuitePath for generator.WriteSuite. Each
+// file's metadata records its model, outcome and quality score. With
+// mergeModels, the tests of an endpoint with several models are written as
+// one file without near-duplicate cases (see generator.DedupeTests) in
+// place of their per-model and ensemble files.
+func Suite(report *reporter.Report, framework string, mergeModels bool) *generator.TestSuite {
+	testGen := generator.NewTestGenerator(framework)
+	var files []generator.TestFile
+	for i := range report.EndpointResults {
+		result := &report.EndpointResults[i]
+		if mergeModels {
+			if file := mergedFile(testGen, result); file != nil {
+				files = append(files, *file)
+				continue
+			}
+		}
+
+		models := make([]string, 0, len(result.Tests))
+		for model := range result.Tests {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+
+		tests := make([]reporter.TestResult, 0, len(models)+1)
+		for _, model := range models {
+			tests = append(tests, result.Tests[model])
+		}
+		if result.Ensemble != nil && result.Ensemble.Mode == EnsembleMerge {
+			tests = append(tests, result.Ensemble.Test)
+		}
+
+		for j := range tests {
+			test := &tests[j]
+			if test.TestCode == "" {
+				continue
+			}
+			file := testGen.GenerateTestFile(&result.Endpoint, test.TestCode)
+			file.Path = generator.SuitePath(&result.Endpoint, test.AIModel)
+			file.Name = path.Base(file.Path)
+			file.Metadata["ai_model"] = test.AIModel
+			file.Metadata["status"] = testStatus(test)
+			file.Metadata["quality_score"] = fmt.Sprintf("%.1f", test.QualityScore)
+			files = append(files, *file)
+		}
+	}
+
+	name := report.Specification.Info.Title
+	if name == "" {
+		name = "glens"
+	}
+	return testGen.CreateTestSuite(name, files)
+}
+
+// testStatus summarises the outcome of a test for the suite index
+func testStatus(test *reporter.TestResult) string {
+	exec := test.ExecutionResult
+	switch {
+	case test.ExecutionError != "":
+		return "error"
+	case exec == nil:
+		return "not_run"
+	case exec.Flaky:
+		return "flaky"
+	case exec.Passed:
+		return "passed"
+	case exec.Skipped:
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
+
+// mergedFile deduplicates the tests of result's models, best first:
+// passing tests, then by quality score. Tests that failed to compile are
+// left out. It returns nil, for the tests to be written per model, when
+// fewer than two models have tests or they cannot be parsed.
+func mergedFile(testGen *generator.TestGenerator, result *reporter.EndpointResult) *generator.TestFile {
+	tests := make([]*reporter.TestResult, 0, len(result.Tests))
+	for model := range result.Tests {
+		test := result.Tests[model]
+		if test.TestCode == "" || (test.ExecutionResult != nil && hasCompilationError(test.ExecutionResult)) {
+			continue
+		}
+		tests = append(tests, &test)
+	}
+	if len(tests) < 2 {
+		return nil
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		a, b := tests[i], tests[j]
+		if passed(a) != passed(b) {
+			return passed(a)
+		}
+		if a.QualityScore != b.QualityScore {
+			return a.QualityScore > b.QualityScore
+		}
+		return a.AIModel < b.AIModel
+	})
+
+	sources := make([]generator.TestSource, len(tests))
+	for i, test := range tests {
+		sources[i] = generator.TestSource{Model: test.AIModel, Code: test.TestCode}
+	}
+	merged, err := generator.DedupeTests(sources)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("endpoint", result.Endpoint.Method+" "+result.Endpoint.Path).
+			Msg("Failed to merge tests, writing them per model")
+		return nil
+	}
+
+	duplicates := 0
+	for _, scenario := range merged.Scenarios {
+		duplicates += len(scenario.Duplicates)
+	}
+	models := make([]string, 0, len(merged.Contributions))
+	for model := range merged.Contributions {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	log.Debug().
+		Str("endpoint", result.Endpoint.Method+" "+result.Endpoint.Path).
+		Int("scenarios", len(merged.Scenarios)).
+		Int("duplicates", duplicates).
+		Msg("Merged tests of all models")
+
+	file := testGen.GenerateTestFile(&result.Endpoint, merged.Code)
+	file.Path = generator.SuitePath(&result.Endpoint, MergedModel)
+	file.Name = path.Base(file.Path)
+	file.Metadata["ai_model"] = MergedModel
+	file.Metadata["models"] = strings.Join(models, ",")
+	file.Metadata["status"] = "not_run"
+	file.Metadata["scenarios"] = strconv.Itoa(len(merged.Scenarios))
+	file.Metadata["duplicates"] = strconv.Itoa(duplicates)
+	return file
+}
+
+func passed(test *reporter.TestResult) bool {
+	return test.ExecutionResult != nil && test.ExecutionResult.Passed
+}
@@ -0,0 +1,157 @@
+// Package artifacts saves what went into and came out of each test
+// generation (the prompt, the raw model answer, the extracted code and the
+// compiler and test output) for debugging bad generations (--artifacts-dir).
+//
+// Artifacts of an endpoint and model live in
+// <root>/<endpoint ID>/<model>/, with each repair of the test in a
+// repair-<n> directory below it:
+//
+//	prompt.txt    system prompt, if any, and prompt sent to the model
+//	response.txt  the model's raw answer
+//	test.go.txt   the test code extracted from the answer
+//	compile.log   compiler output of a test that failed to compile
+//	lint.log      static analysis findings
+//	test.log      go test output, or the error that kept the test from running
+//
+// Files without content are not written. The test code is saved as .txt so
+// that a root inside a Go module does not add broken packages to it. The
+// JSON report of the run is saved as report.json in the root, so that glens
+// replay can re-run the tests without calling a model.
+package artifacts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+)
+
+// File names of an attempt's artifacts
+const (
+	PromptFile   = "prompt.txt"
+	ResponseFile = "response.txt"
+	CodeFile     = "test.go.txt"
+	CompileFile  = "compile.log"
+	LintFile     = "lint.log"
+	TestLogFile  = "test.log"
+	// ReportFile is the JSON report of the run, in the root
+	ReportFile = "report.json"
+)
+
+// Attempt is one generation or repair of a test
+type Attempt struct {
+	SystemPrompt string
+	Prompt       string
+	RawResponse  string
+	TestCode     string
+	// CompileOutput is the compiler output of a test that failed to compile
+	CompileOutput string
+	Findings      []generator.Finding
+	// TestOutput is the go test output of an executed test, or the error
+	// that kept it from running
+	TestOutput string
+}
+
+// Dir returns the directory of the artifacts of endpoint and model under root
+func Dir(root string, endpoint *parser.Endpoint, model string) string {
+	id := endpoint.ID
+	if id == "" {
+		id = endpoint.Method + "_" + endpoint.Path
+	}
+	return filepath.Join(root, segment(id), segment(model))
+}
+
+// RepairDir returns the directory of the n-th repair of the test whose
+// artifacts are in dir
+func RepairDir(dir string, n int) string {
+	return filepath.Join(dir, "repair-"+strconv.Itoa(n))
+}
+
+// LatestCode returns the test code of the last attempt saved in dir: that
+// of its last repair, if any, else its own. Edits to the file are kept, so a
+// replay runs the test as fixed by hand.
+func LatestCode(dir string) (string, error) {
+	code, err := os.ReadFile(filepath.Join(dir, CodeFile)) // #nosec G304 -- the directory is chosen by the user
+	if err != nil {
+		return "", fmt.Errorf("failed to read test code: %w", err)
+	}
+	for n := 1; ; n++ {
+		repaired, err := os.ReadFile(filepath.Join(RepairDir(dir, n), CodeFile)) // #nosec G304 -- the directory is chosen by the user
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return string(code), nil
+		case err != nil:
+			return "", fmt.Errorf("failed to read repaired test code: %w", err)
+		}
+		code = repaired
+	}
+}
+
+// Write saves attempt to dir, replacing what an earlier run left there,
+// repairs included. Secrets are masked with the process-wide redactor.
+func Write(dir string, attempt *Attempt) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear artifacts directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+
+	prompt := attempt.Prompt
+	if attempt.SystemPrompt != "" {
+		prompt = "# System\n\n" + attempt.SystemPrompt + "\n\n# Prompt\n\n" + attempt.Prompt
+	}
+	files := map[string]string{
+		PromptFile:   prompt,
+		ResponseFile: attempt.RawResponse,
+		CodeFile:     attempt.TestCode,
+		CompileFile:  attempt.CompileOutput,
+		LintFile:     findings(attempt.Findings),
+		TestLogFile:  attempt.TestOutput,
+	}
+	for name, content := range files {
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(redact.String(content)), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// findings lists static analysis findings one per line
+func findings(list []generator.Finding) string {
+	var b strings.Builder
+	for _, f := range list {
+		fmt.Fprintf(&b, "%s %s (%s) line %d: %s\n", f.Tool, f.Rule, f.Severity, f.Line, f.Message)
+	}
+	return b.String()
+}
+
+// segment turns an endpoint ID or model name into a directory name of
+// letters, digits, dots, dashes and underscores
+func segment(name string) string {
+	segment := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if strings.Trim(segment, ".") == "" {
+		return "_"
+	}
+	return segment
+}
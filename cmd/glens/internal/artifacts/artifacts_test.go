@@ -0,0 +1,70 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+func TestDir(t *testing.T) {
+	endpoint := &parser.Endpoint{ID: "GET__pets_{id}", Method: "GET", Path: "/pets/{id}"}
+	assert.Equal(t, filepath.Join("out", "GET__pets__id_", "ollama_llama3.1_8b"), Dir("out", endpoint, "ollama:llama3.1/8b"))
+	assert.Equal(t, filepath.Join("out", "POST__pets", "_"), Dir("out", &parser.Endpoint{Method: "POST", Path: "/pets"}, ".."))
+	assert.Equal(t, filepath.Join("out", "x", "repair-2"), RepairDir(filepath.Join("out", "x"), 2))
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "GET__pets", "gpt4")
+	require.NoError(t, os.MkdirAll(RepairDir(dir, 1), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, CompileFile), []byte("stale"), 0o600))
+
+	require.NoError(t, Write(dir, &Attempt{
+		SystemPrompt: "You write Go tests.",
+		Prompt:       "Test GET /pets",
+		RawResponse:  "```go\npackage api_test\n```",
+		TestCode:     "package api_test",
+		Findings:     []generator.Finding{{Tool: "vet", Rule: "printf", Severity: generator.SeverityHigh, Line: 3, Message: "bad verb"}},
+		TestOutput:   "--- FAIL: TestPets",
+	}))
+
+	read := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+	assert.Equal(t, "# System\n\nYou write Go tests.\n\n# Prompt\n\nTest GET /pets\n", read(PromptFile))
+	assert.Equal(t, "```go\npackage api_test\n```\n", read(ResponseFile))
+	assert.Equal(t, "package api_test\n", read(CodeFile))
+	assert.Equal(t, "vet printf (high) line 3: bad verb\n", read(LintFile))
+	assert.Equal(t, "--- FAIL: TestPets\n", read(TestLogFile))
+	assert.NoFileExists(t, filepath.Join(dir, CompileFile), "files of an earlier run are removed")
+	assert.NoDirExists(t, RepairDir(dir, 1), "repairs of an earlier run are removed")
+
+	require.NoError(t, Write(dir, &Attempt{Prompt: "Test GET /pets", CompileOutput: "undefined: x"}))
+	assert.Equal(t, "Test GET /pets\n", read(PromptFile))
+	assert.Equal(t, "undefined: x\n", read(CompileFile))
+}
+
+func TestLatestCode(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LatestCode(dir)
+	require.Error(t, err)
+
+	require.NoError(t, Write(dir, &Attempt{TestCode: "package generated"}))
+	code, err := LatestCode(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "package generated\n", code)
+
+	require.NoError(t, Write(RepairDir(dir, 1), &Attempt{TestCode: "package repaired"}))
+	require.NoError(t, Write(RepairDir(dir, 2), &Attempt{TestCode: "package repaired_again"}))
+	code, err = LatestCode(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "package repaired_again\n", code, "the last repair is replayed")
+}
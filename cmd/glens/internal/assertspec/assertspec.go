@@ -0,0 +1,333 @@
+// Package assertspec validates JSON response bodies against the response
+// schemas of the API's spec, so that generated tests check the whole
+// documented structure instead of a few hand-picked fields:
+//
+//	body, err := io.ReadAll(resp.Body)
+//	require.NoError(t, err)
+//	assertspec.Body(t, "GET /pets/{id}", resp.StatusCode, body)
+//
+// glens writes this package next to the tests it generates as the module
+// glens/assertspec, with the schemas of the spec in schemas.json; it only
+// depends on the standard library.
+package assertspec
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Spec holds the JSON response schemas of an API by operation, e.g.
+// "GET /pets/{id}", and status: a code such as "200", a range such as "4XX"
+// or "default".
+type Spec map[string]map[string]*Schema
+
+// Schema is the subset of JSON Schema used by OpenAPI response bodies.
+type Schema struct {
+	Type             []string           `json:"type,omitempty"`
+	Nullable         bool               `json:"nullable,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	PrefixItems      []*Schema          `json:"prefixItems,omitempty"`
+	Enum             []any              `json:"enum,omitempty"`
+	Const            any                `json:"const,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int               `json:"minLength,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	Pattern          string             `json:"pattern,omitempty"`
+	OneOf            []*Schema          `json:"oneOf,omitempty"`
+	AnyOf            []*Schema          `json:"anyOf,omitempty"`
+	AllOf            []*Schema          `json:"allOf,omitempty"`
+}
+
+// TestingT is the part of *testing.T that Body reports through; Ginkgo's
+// GinkgoT() satisfies it too.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+//go:embed schemas.json
+var schemasJSON []byte
+
+var loadSpec = sync.OnceValues(func() (Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(schemasJSON, &spec); err != nil {
+		return nil, fmt.Errorf("assertspec: invalid schemas.json: %w", err)
+	}
+	return spec, nil
+})
+
+// Body reports an error through t unless body, the response with status to
+// operation (e.g. "GET /pets/{id}"), matches the schema the spec documents
+// for it, and returns whether it does.
+func Body(t TestingT, operation string, status int, body []byte) bool {
+	t.Helper()
+	if err := Validate(operation, status, body); err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	return true
+}
+
+// Validate checks body, the response with status to operation, against the
+// schema the spec documents for it. Responses without a JSON schema match.
+func Validate(operation string, status int, body []byte) error {
+	spec, err := loadSpec()
+	if err != nil {
+		return err
+	}
+	return spec.Validate(operation, status, body)
+}
+
+// Validate checks body, the response with status to operation, against the
+// schema of s. The operation may name its path as documented
+// ("/pets/{id}") or as requested ("/pets/42").
+func (s Spec) Validate(operation string, status int, body []byte) error {
+	name, responses, ok := s.operation(operation)
+	if !ok {
+		return fmt.Errorf("assertspec: %s is not an operation of the spec", operation)
+	}
+	code := strconv.Itoa(status)
+	schema, ok := responses[code]
+	if !ok {
+		schema, ok = responses[code[:1]+"XX"]
+	}
+	if !ok {
+		schema = responses["default"]
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("assertspec: %s %d: body is not JSON: %w", name, status, err)
+	}
+	var problems []string
+	schema.validate(value, "$", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("assertspec: %s %d: body does not match the spec:\n  %s", name, status, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// operation finds the responses of operation, matching its path against
+// the documented path templates when it is not one of them
+func (s Spec) operation(operation string) (string, map[string]*Schema, bool) {
+	method, path, _ := strings.Cut(strings.TrimSpace(operation), " ")
+	name := strings.ToUpper(method) + " " + strings.TrimSpace(path)
+	if responses, ok := s[name]; ok {
+		return name, responses, true
+	}
+
+	path, _, _ = strings.Cut(strings.TrimSpace(path), "?")
+	segments := strings.Split(path, "/")
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		keyMethod, template, _ := strings.Cut(key, " ")
+		if keyMethod == strings.ToUpper(method) && matchesTemplate(strings.Split(template, "/"), segments) {
+			return key, s[key], true
+		}
+	}
+	return "", nil, false
+}
+
+// matchesTemplate reports whether the segments of a path match those of a
+// path template, whose {parameters} match any segment
+func matchesTemplate(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, part := range template {
+		if part != segments[i] && !(strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && segments[i] != "") {
+			return false
+		}
+	}
+	return true
+}
+
+// uuidPattern matches the textual form of a UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validate appends to problems how value, at path, does not match s
+func (s *Schema) validate(value any, path string, problems *[]string) {
+	if s == nil {
+		return
+	}
+	report := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if value == nil {
+		if len(s.Type) > 0 && !s.Nullable && !slices.Contains(s.Type, "null") {
+			report("null, want %s", strings.Join(s.Type, " or "))
+		}
+		return
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		report("%s, want %s", typeOf(value), strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return reflect.DeepEqual(e, value) }) {
+		report("%v is not one of %v", value, s.Enum)
+	}
+	if s.Const != nil && !reflect.DeepEqual(s.Const, value) {
+		report("%v, want %v", value, s.Const)
+	}
+
+	switch v := value.(type) {
+	case string:
+		s.validateString(v, report)
+	case float64:
+		s.validateNumber(v, report)
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if property, ok := v[name]; ok {
+				s.Properties[name].validate(property, path+"."+name, problems)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			itemPath := path + "[" + strconv.Itoa(i) + "]"
+			if i < len(s.PrefixItems) {
+				s.PrefixItems[i].validate(item, itemPath, problems)
+			} else {
+				s.Items.validate(item, itemPath, problems)
+			}
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		sub.validate(value, path, problems)
+	}
+	if len(s.AnyOf) > 0 && s.matching(s.AnyOf, value) == 0 {
+		report("matches none of the anyOf schemas")
+	}
+	if len(s.OneOf) > 0 {
+		if n := s.matching(s.OneOf, value); n != 1 {
+			report("matches %d of the oneOf schemas, want exactly 1", n)
+		}
+	}
+}
+
+// matching counts the schemas value matches
+func (s *Schema) matching(schemas []*Schema, value any) int {
+	n := 0
+	for _, schema := range schemas {
+		var problems []string
+		schema.validate(value, "$", &problems)
+		if len(problems) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Schema) validateString(v string, report func(string, ...any)) {
+	length := utf8.RuneCountInString(v)
+	if s.MinLength != nil && length < *s.MinLength {
+		report("length %d, want at least %d", length, *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		report("length %d, want at most %d", length, *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		// Patterns Go cannot compile (e.g. lookarounds) are not checked
+		if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+			report("%q does not match pattern %s", v, s.Pattern)
+		}
+	}
+	var err error
+	switch s.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, v)
+	case "date":
+		_, err = time.Parse(time.DateOnly, v)
+	case "uuid":
+		if !uuidPattern.MatchString(v) {
+			err = errors.New("not a UUID")
+		}
+	}
+	if err != nil {
+		report("%q is not a valid %s", v, s.Format)
+	}
+}
+
+func (s *Schema) validateNumber(v float64, report func(string, ...any)) {
+	if s.Minimum != nil && v < *s.Minimum {
+		report("%v, want at least %v", v, *s.Minimum)
+	}
+	if s.Maximum != nil && v > *s.Maximum {
+		report("%v, want at most %v", v, *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+		report("%v, want more than %v", v, *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum {
+		report("%v, want less than %v", v, *s.ExclusiveMaximum)
+	}
+}
+
+// hasType reports whether a decoded JSON value is of a JSON Schema type
+func hasType(value any, typ string) bool {
+	switch v := value.(type) {
+	case string:
+		return typ == "string"
+	case float64:
+		return typ == "number" || (typ == "integer" && v == math.Trunc(v))
+	case bool:
+		return typ == "boolean"
+	case map[string]any:
+		return typ == "object"
+	case []any:
+		return typ == "array"
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value
+func typeOf(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return "null"
+}
@@ -0,0 +1,122 @@
+package assertspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petSpec = `{
+  "GET /pets/{id}": {
+    "200": {
+      "type": ["object"],
+      "required": ["id", "name"],
+      "properties": {
+        "id": {"type": ["integer"], "minimum": 1},
+        "name": {"type": ["string"], "minLength": 1},
+        "status": {"type": ["string"], "enum": ["available", "sold"]},
+        "born": {"type": ["string"], "format": "date", "nullable": true},
+        "tags": {"type": ["array"], "items": {"type": ["string"]}}
+      }
+    },
+    "4XX": {
+      "type": ["object"],
+      "required": ["message"],
+      "properties": {"message": {"type": ["string"]}}
+    }
+  },
+  "POST /pets": {
+    "201": {"oneOf": [
+      {"type": ["object"], "required": ["cat"]},
+      {"type": ["object"], "required": ["dog"]}
+    ]},
+    "204": null
+  }
+}`
+
+func loadPetSpec(t *testing.T) Spec {
+	t.Helper()
+	var spec Spec
+	require.NoError(t, json.Unmarshal([]byte(petSpec), &spec))
+	return spec
+}
+
+func TestSpec_Validate(t *testing.T) {
+	spec := loadPetSpec(t)
+	tests := []struct {
+		name      string
+		operation string
+		status    int
+		body      string
+		problems  []string
+	}{
+		{"valid", "GET /pets/{id}", 200, `{"id": 1, "name": "Rex", "status": "sold", "born": null, "tags": ["dog"]}`, nil},
+		{"requested path", "get /pets/42", 200, `{"id": 1, "name": "Rex"}`, nil},
+		{"missing and mistyped", "GET /pets/{id}", 200, `{"id": 1.5, "status": "lost", "born": "yesterday", "tags": [1]}`, []string{
+			`$: missing required property "name"`,
+			`$.born: "yesterday" is not a valid date`,
+			`$.id: number, want integer`,
+			`$.status: lost is not one of [available sold]`,
+			`$.tags[0]: number, want string`,
+		}},
+		{"constraints", "GET /pets/{id}", 200, `{"id": 0, "name": ""}`, []string{
+			`$.id: 0, want at least 1`,
+			`$.name: length 0, want at least 1`,
+		}},
+		{"status range", "GET /pets/{id}", 404, `{}`, []string{`$: missing required property "message"`}},
+		{"undocumented status", "GET /pets/{id}", 500, `not json`, nil},
+		{"no schema", "POST /pets", 204, ``, nil},
+		{"one of", "POST /pets", 201, `{"cat": {}}`, nil},
+		{"none of", "POST /pets", 201, `{"bird": {}}`, []string{`$: matches 0 of the oneOf schemas, want exactly 1`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := spec.Validate(tt.operation, tt.status, []byte(tt.body))
+			if tt.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, problem := range tt.problems {
+				assert.Contains(t, err.Error(), "\n  "+problem)
+			}
+		})
+	}
+}
+
+func TestSpec_ValidateErrors(t *testing.T) {
+	spec := loadPetSpec(t)
+	assert.ErrorContains(t, spec.Validate("DELETE /pets/{id}", 200, nil), "DELETE /pets/{id} is not an operation of the spec")
+	assert.ErrorContains(t, spec.Validate("GET /pets/1", 200, []byte("<html>")), "GET /pets/{id} 200: body is not JSON")
+}
+
+// recorder records the errors Body reports
+type recorder struct{ errors []string }
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestBody(t *testing.T) {
+	// The schemas.json of this package is empty
+	r := &recorder{}
+	assert.False(t, Body(r, "GET /pets/{id}", 200, []byte(`{}`)))
+	assert.Equal(t, []string{"assertspec: GET /pets/{id} is not an operation of the spec"}, r.errors)
+}
+
+func TestModuleFiles(t *testing.T) {
+	files, err := ModuleFiles(loadPetSpec(t))
+	require.NoError(t, err)
+	assert.Equal(t, "module glens/assertspec\n\ngo 1.25\n", files["go.mod"])
+	assert.Contains(t, files["assertspec.go"], "package assertspec")
+	assert.NotContains(t, files["assertspec.go"], "ModuleFiles", "only the runtime is written")
+
+	var spec Spec
+	require.NoError(t, json.Unmarshal([]byte(files[SchemasFile]), &spec))
+	assert.Equal(t, []string{"id", "name"}, spec["GET /pets/{id}"]["200"].Required)
+}
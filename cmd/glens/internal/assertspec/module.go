@@ -0,0 +1,37 @@
+package assertspec
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+// ModulePath is the module path generated tests import the package by
+const ModulePath = "glens/assertspec"
+
+// SchemasFile is the file of a module holding the schemas it validates
+// against
+const SchemasFile = "schemas.json"
+
+// source is the code of the package, written into the modules of
+// generated tests; this file stays behind
+//
+//go:embed assertspec.go
+var source string
+
+// ModuleFiles returns the files, by name, of the module ModulePath
+// validating against spec
+func ModuleFiles(spec Spec) (map[string]string, error) {
+	if spec == nil {
+		spec = Spec{}
+	}
+	schemas, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response schemas: %w", err)
+	}
+	return map[string]string{
+		"go.mod":        "module " + ModulePath + "\n\ngo 1.25\n",
+		"assertspec.go": source,
+		SchemasFile:     string(schemas) + "\n",
+	}, nil
+}
@@ -0,0 +1,245 @@
+// Package benchmark compares AI models on a fixed set of endpoints: it
+// repeatedly generates (and optionally executes) tests for the same
+// endpoints and aggregates latency, token use, compile success and pass
+// rate with 95% confidence intervals.
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/metrics"
+	"glens/pkg/safety"
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+// DefaultIterations is how often each endpoint is generated per model when
+// Options.Iterations is not set
+const DefaultIterations = 3
+
+// Runner compiles and executes generated tests; *generator.TestGenerator
+// implements it
+type Runner interface {
+	Compile(ctx context.Context, testCode string, endpoint *parser.Endpoint) error
+	ExecuteTest(ctx context.Context, testCode string, endpoint *parser.Endpoint) (*generator.ExecutionResult, error)
+}
+
+// Options configure a benchmark run
+type Options struct {
+	// Models are the AI models compared
+	Models []string
+	// Iterations is how often each endpoint is generated per model
+	Iterations int
+	// RunTests executes generated tests; otherwise they are only compiled
+	RunTests bool
+	// AllowRisk is the highest endpoint risk whose tests are executed;
+	// riskier tests are only compiled. Empty allows safe endpoints only.
+	AllowRisk safety.Risk
+	// OnSample, when set, is called after every generated test
+	OnSample func(Sample)
+}
+
+// Sample is one generation of one endpoint by one model
+type Sample struct {
+	Model     string        `json:"model"`
+	Endpoint  string        `json:"endpoint"`
+	Iteration int           `json:"iteration"`
+	Latency   time.Duration `json:"latency"`
+	Tokens    int           `json:"tokens"`
+	// Error is set when generation failed; the sample then only counts
+	// against the generation rate
+	Error    string `json:"error,omitempty"`
+	Compiled bool   `json:"compiled"`
+	Executed bool   `json:"executed"`
+	Passed   bool   `json:"passed"`
+}
+
+// ModelResult aggregates the samples of one model
+type ModelResult struct {
+	Model   string `json:"model"`
+	Samples int    `json:"samples"`
+	// Latency is the generation time in seconds
+	Latency        metrics.Stats `json:"latency_seconds"`
+	Tokens         metrics.Stats `json:"tokens"`
+	GenerationRate metrics.Rate  `json:"generation_rate"`
+	// CompileRate is the share of generated tests that compile
+	CompileRate metrics.Rate `json:"compile_rate"`
+	// PassRate is the share of executed tests that pass
+	PassRate metrics.Rate `json:"pass_rate"`
+	// Sampling are the generation parameters the model reported
+	Sampling map[string]string `json:"sampling,omitempty"`
+}
+
+// Report is the outcome of a benchmark run
+type Report struct {
+	Spec       string        `json:"spec"`
+	Endpoints  []string      `json:"endpoints"`
+	Iterations int           `json:"iterations"`
+	RunTests   bool          `json:"run_tests"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Models     []ModelResult `json:"models"`
+	Samples    []Sample      `json:"samples"`
+}
+
+// Run benchmarks the models of aiManager on endpoints. Iterations run the
+// models in turn on each endpoint, so drift in API latency over the run
+// affects every model alike.
+func Run(ctx context.Context, endpoints []parser.Endpoint, aiManager *ai.Manager, runner Runner, opts Options) (*Report, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints to benchmark")
+	}
+	if len(opts.Models) == 0 {
+		return nil, fmt.Errorf("no AI models to benchmark")
+	}
+	allowRisk, err := safety.ParseRisk(string(opts.AllowRisk))
+	if err != nil {
+		return nil, err
+	}
+	iterations := opts.Iterations
+	if iterations <= 0 {
+		iterations = DefaultIterations
+	}
+
+	report := &Report{
+		Iterations: iterations,
+		RunTests:   opts.RunTests,
+		StartedAt:  time.Now(),
+	}
+	for i := range endpoints {
+		report.Endpoints = append(report.Endpoints, endpointName(&endpoints[i]))
+	}
+
+	sampling := make(map[string]map[string]string)
+	for iteration := 1; iteration <= iterations; iteration++ {
+		for i := range endpoints {
+			endpoint := &endpoints[i]
+			execute := opts.RunTests && allowRisk.Allows(safety.Categorise(endpoint.Method, endpoint.Path, endpoint.XSafe).WithRisk(endpoint.DeclaredRisk()).Risk)
+			for _, model := range opts.Models {
+				if err := ctx.Err(); err != nil {
+					return nil, fmt.Errorf("benchmark cancelled: %w", err)
+				}
+				sample, params := measure(ctx, aiManager, runner, model, endpoint, execute)
+				sample.Iteration = iteration
+				if params != nil {
+					sampling[model] = params
+				}
+				report.Samples = append(report.Samples, sample)
+				if opts.OnSample != nil {
+					opts.OnSample(sample)
+				}
+			}
+		}
+	}
+
+	for _, model := range opts.Models {
+		result := aggregate(model, report.Samples)
+		result.Sampling = sampling[model]
+		report.Models = append(report.Models, result)
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// measure generates, compiles and optionally executes one test. It returns
+// the generation parameters the model reported, if any.
+func measure(ctx context.Context, aiManager *ai.Manager, runner Runner, model string, endpoint *parser.Endpoint, execute bool) (Sample, map[string]string) {
+	sample := Sample{Model: model, Endpoint: endpointName(endpoint)}
+
+	start := time.Now()
+	result, err := aiManager.Generate(ctx, model, endpoint)
+	sample.Latency = time.Since(start)
+	if err != nil {
+		log.Warn().Err(err).Str("ai_model", model).Str("endpoint", sample.Endpoint).Msg("Benchmark generation failed")
+		sample.Error = err.Error()
+		return sample, nil
+	}
+	sample.Tokens = result.TokensUsed
+
+	if !execute {
+		if err := runner.Compile(ctx, result.TestCode, endpoint); err != nil {
+			log.Debug().Err(err).Str("ai_model", model).Str("endpoint", sample.Endpoint).Msg("Generated test does not compile")
+		} else {
+			sample.Compiled = true
+		}
+		return sample, samplingParams(result.Metadata)
+	}
+
+	execution, err := runner.ExecuteTest(ctx, result.TestCode, endpoint)
+	if err != nil {
+		log.Warn().Err(err).Str("ai_model", model).Str("endpoint", sample.Endpoint).Msg("Benchmark execution failed")
+		return sample, samplingParams(result.Metadata)
+	}
+	sample.Compiled = compiled(execution)
+	sample.Executed = sample.Compiled
+	sample.Passed = execution.Passed
+	return sample, samplingParams(result.Metadata)
+}
+
+// aggregate summarises the samples of model
+func aggregate(model string, samples []Sample) ModelResult {
+	var latencies, tokens []float64
+	var total, generated, compiledCount, executed, passed int
+	for i := range samples {
+		s := &samples[i]
+		if s.Model != model {
+			continue
+		}
+		total++
+		latencies = append(latencies, s.Latency.Seconds())
+		if s.Error != "" {
+			continue
+		}
+		generated++
+		tokens = append(tokens, float64(s.Tokens))
+		if s.Compiled {
+			compiledCount++
+		}
+		if s.Executed {
+			executed++
+			if s.Passed {
+				passed++
+			}
+		}
+	}
+	return ModelResult{
+		Model:          model,
+		Samples:        total,
+		Latency:        metrics.Summarize(latencies),
+		Tokens:         metrics.Summarize(tokens),
+		GenerationRate: metrics.Proportion(generated, total),
+		CompileRate:    metrics.Proportion(compiledCount, generated),
+		PassRate:       metrics.Proportion(passed, executed),
+	}
+}
+
+// compiled reports whether an executed test got past compilation
+func compiled(result *generator.ExecutionResult) bool {
+	return !slices.ContainsFunc(result.Errors, func(e generator.TestError) bool {
+		return e.TestName == "compilation"
+	})
+}
+
+// samplingParams picks the generation parameters from result metadata
+func samplingParams(metadata map[string]string) map[string]string {
+	params := make(map[string]string)
+	for _, key := range []string{"temperature", "top_p", "seed", "max_output_tokens"} {
+		if value, ok := metadata[key]; ok {
+			params[key] = value
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+func endpointName(endpoint *parser.Endpoint) string {
+	return endpoint.Method + " " + endpoint.Path
+}
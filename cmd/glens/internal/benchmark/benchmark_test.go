@@ -0,0 +1,117 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+// fakeRunner compiles every other test and passes every executed one
+type fakeRunner struct {
+	compiles int
+	executed []string
+}
+
+func (f *fakeRunner) Compile(context.Context, string, *parser.Endpoint) error {
+	f.compiles++
+	if f.compiles%2 == 0 {
+		return errors.New("compilation failed")
+	}
+	return nil
+}
+
+func (f *fakeRunner) ExecuteTest(_ context.Context, _ string, endpoint *parser.Endpoint) (*generator.ExecutionResult, error) {
+	f.executed = append(f.executed, endpoint.Method)
+	return &generator.ExecutionResult{Passed: true, TestCount: 1}, nil
+}
+
+func newManager(t *testing.T) *ai.Manager {
+	t.Helper()
+	manager, err := ai.NewManager([]string{"mock"}, ai.Config{})
+	require.NoError(t, err)
+	return manager
+}
+
+func TestRun_CompileOnly(t *testing.T) {
+	endpoints := []parser.Endpoint{{Method: "GET", Path: "/users"}}
+	runner := &fakeRunner{}
+
+	var samples int
+	report, err := Run(context.Background(), endpoints, newManager(t), runner, Options{
+		Models:     []string{"mock", "missing"},
+		Iterations: 4,
+		OnSample:   func(Sample) { samples++ },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 8, samples)
+	assert.Len(t, report.Samples, 8)
+	assert.Equal(t, []string{"GET /users"}, report.Endpoints)
+	require.Len(t, report.Models, 2)
+
+	mock := report.Models[0]
+	assert.Equal(t, "mock", mock.Model)
+	assert.Equal(t, 4, mock.Samples)
+	assert.Equal(t, 4, mock.Latency.N)
+	assert.Equal(t, 1.0, mock.GenerationRate.Value)
+	assert.Equal(t, 0.5, mock.CompileRate.Value)
+	assert.Zero(t, mock.PassRate.Total, "nothing executed")
+	assert.Empty(t, runner.executed)
+
+	// Models that fail to generate count against the generation rate only
+	missing := report.Models[1]
+	assert.Equal(t, 4, missing.Samples)
+	assert.Equal(t, 0.0, missing.GenerationRate.Value)
+	assert.Zero(t, missing.CompileRate.Total)
+	assert.NotEmpty(t, report.Samples[1].Error)
+}
+
+func TestRun_ExecutesAllowedRiskOnly(t *testing.T) {
+	endpoints := []parser.Endpoint{{Method: "GET", Path: "/users"}, {Method: "DELETE", Path: "/users/{id}"}}
+	runner := &fakeRunner{}
+
+	report, err := Run(context.Background(), endpoints, newManager(t), runner, Options{
+		Models:     []string{"mock"},
+		Iterations: 2,
+		RunTests:   true,
+		AllowRisk:  safety.RiskSafe,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"GET", "GET"}, runner.executed, "DELETE tests are only compiled")
+	assert.Equal(t, 2, runner.compiles)
+	assert.Equal(t, 2, report.Models[0].PassRate.Total)
+	assert.Equal(t, 1.0, report.Models[0].PassRate.Value)
+}
+
+func TestRun_Validation(t *testing.T) {
+	_, err := Run(context.Background(), nil, newManager(t), &fakeRunner{}, Options{Models: []string{"mock"}})
+	assert.Error(t, err)
+
+	_, err = Run(context.Background(), []parser.Endpoint{{Method: "GET", Path: "/"}}, newManager(t), &fakeRunner{}, Options{})
+	assert.Error(t, err)
+}
+
+func TestReport_Markdown(t *testing.T) {
+	report, err := Run(context.Background(), []parser.Endpoint{{Method: "GET", Path: "/users"}}, newManager(t), &fakeRunner{}, Options{
+		Models:     []string{"mock"},
+		Iterations: 2,
+	})
+	require.NoError(t, err)
+	report.Spec = "spec.json"
+
+	md := report.Markdown()
+	assert.Contains(t, md, "| mock | 2 |")
+	assert.Contains(t, md, "50% [")
+	assert.NotContains(t, md, "Passes", "no pass rate without execution")
+	assert.Contains(t, md, "- `GET /users`")
+}
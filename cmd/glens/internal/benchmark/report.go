@@ -0,0 +1,109 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"glens/pkg/metrics"
+)
+
+// WriteReport writes report to filePath as Markdown (.md) or JSON
+func WriteReport(report *Report, filePath string) error {
+	var content []byte
+	if strings.HasSuffix(strings.ToLower(filePath), ".md") {
+		content = []byte(report.Markdown())
+	} else {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal benchmark report: %w", err)
+		}
+		content = data
+	}
+	if err := os.WriteFile(filePath, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write benchmark report: %w", err)
+	}
+	return nil
+}
+
+// Markdown renders the report as a comparison table with 95% confidence
+// intervals, followed by the run settings
+func (r *Report) Markdown() string {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# Model Benchmark\n\n")
+	fmt.Fprintf(&md, "- **Spec:** %s\n", r.Spec)
+	fmt.Fprintf(&md, "- **Endpoints:** %d\n", len(r.Endpoints))
+	fmt.Fprintf(&md, "- **Iterations:** %d\n", r.Iterations)
+	fmt.Fprintf(&md, "- **Tests Executed:** %t\n", r.RunTests)
+	fmt.Fprintf(&md, "- **Started At:** %s\n", r.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&md, "- **Duration:** %s\n\n", r.Duration.Round(time.Millisecond))
+
+	fmt.Fprintf(&md, "## Results\n\n")
+	fmt.Fprintf(&md, "Values are means or rates with their 95%% confidence interval.\n\n")
+	fmt.Fprintf(&md, "| Model | Samples | Latency (s) | p95 Latency (s) | Tokens | Generated | Compiles |")
+	if r.RunTests {
+		fmt.Fprintf(&md, " Passes |")
+	}
+	fmt.Fprintf(&md, "\n|-------|---------|-------------|-----------------|--------|-----------|----------|")
+	if r.RunTests {
+		fmt.Fprintf(&md, "--------|")
+	}
+	fmt.Fprintf(&md, "\n")
+	for i := range r.Models {
+		m := &r.Models[i]
+		fmt.Fprintf(&md, "| %s | %d | %s | %.2f | %s | %s | %s |",
+			m.Model, m.Samples, formatStats(m.Latency, 2), m.Latency.P95,
+			formatStats(m.Tokens, 0), formatRate(m.GenerationRate), formatRate(m.CompileRate))
+		if r.RunTests {
+			fmt.Fprintf(&md, " %s |", formatRate(m.PassRate))
+		}
+		fmt.Fprintf(&md, "\n")
+	}
+
+	if slices.ContainsFunc(r.Models, func(m ModelResult) bool { return len(m.Sampling) > 0 }) {
+		fmt.Fprintf(&md, "\n## Sampling\n\n")
+		for i := range r.Models {
+			m := &r.Models[i]
+			if len(m.Sampling) == 0 {
+				continue
+			}
+			keys := make([]string, 0, len(m.Sampling))
+			for key := range m.Sampling {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			params := make([]string, len(keys))
+			for j, key := range keys {
+				params[j] = key + "=" + m.Sampling[key]
+			}
+			fmt.Fprintf(&md, "- **%s:** %s\n", m.Model, strings.Join(params, ", "))
+		}
+	}
+
+	fmt.Fprintf(&md, "\n## Endpoints\n\n")
+	for _, endpoint := range r.Endpoints {
+		fmt.Fprintf(&md, "- `%s`\n", endpoint)
+	}
+	return md.String()
+}
+
+// formatStats renders a mean with its confidence interval
+func formatStats(s metrics.Stats, decimals int) string {
+	if s.N == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.*f [%.*f, %.*f]", decimals, s.Mean, decimals, s.CILow, decimals, s.CIHigh)
+}
+
+// formatRate renders a rate as a percentage with its confidence interval
+func formatRate(r metrics.Rate) string {
+	if r.Total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%% [%.0f, %.0f]", r.Value*100, r.CILow*100, r.CIHigh*100)
+}
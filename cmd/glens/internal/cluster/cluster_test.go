@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+func endpointTask(path string, models ...string) Task {
+	return Task{
+		JobID:    "job-1",
+		Endpoint: parser.Endpoint{Method: "GET", Path: path},
+		Options:  TaskOptions{Models: models, RunTests: true},
+	}
+}
+
+func TestWorker_ProcessesDispatchedTasks(t *testing.T) {
+	coordinator := NewCoordinator(CoordinatorConfig{Token: "secret", LeaseWait: 100 * time.Millisecond})
+	server := httptest.NewServer(coordinator.Handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	processed := map[string]string{}
+	worker := func(id string, models ...string) *Worker {
+		return &Worker{
+			Coordinator: server.URL, Token: "secret", ID: id, Models: models,
+			Process: func(_ context.Context, task Task) (reporter.EndpointResult, error) {
+				mu.Lock()
+				processed[task.Endpoint.Path] = id
+				mu.Unlock()
+				return reporter.EndpointResult{
+					Endpoint: task.Endpoint,
+					Status:   reporter.StatusCompleted,
+					Tests:    map[string]reporter.TestResult{task.Options.Models[0]: {AIModel: task.Options.Models[0]}},
+				}, nil
+			},
+		}
+	}
+	go func() { _ = worker("laptop", "mock").Run(ctx) }()
+	go func() { _ = worker("gpu", "ollama").Run(ctx) }()
+
+	results := make([]reporter.EndpointResult, 2)
+	var wg sync.WaitGroup
+	for i, task := range []Task{endpointTask("/mock", "mock"), endpointTask("/ollama", "ollama")} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := coordinator.Dispatch(ctx, task)
+			assert.NoError(t, err)
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "/mock", results[0].Endpoint.Path)
+	assert.Equal(t, reporter.StatusCompleted, results[1].Status)
+	assert.Contains(t, results[1].Tests, "ollama")
+	assert.Equal(t, map[string]string{"/mock": "laptop", "/ollama": "gpu"}, processed, "tasks go to workers serving their models")
+
+	status := coordinator.Status()
+	assert.Zero(t, status.Pending)
+	require.Len(t, status.Workers, 2)
+	assert.Equal(t, "gpu", status.Workers[0].ID)
+
+	rejected := worker("intruder")
+	rejected.Token = "wrong"
+	assert.ErrorContains(t, rejected.Run(ctx), "rejected the worker token")
+}
+
+func TestWorker_SendsAPIKey(t *testing.T) {
+	coordinator := NewCoordinator(CoordinatorConfig{Token: "secret", LeaseWait: 10 * time.Millisecond})
+	handler := coordinator.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	worker := &Worker{Coordinator: server.URL, Token: "secret", APIKey: "key", ID: "w", Models: []string{"mock"}}
+	assert.NoError(t, worker.Run(ctx), "the worker leases until stopped")
+
+	worker.APIKey = ""
+	assert.ErrorContains(t, worker.Run(context.Background()), "rejected the worker token or API key")
+}
+
+func TestCoordinator_RetriesFailedAttempts(t *testing.T) {
+	coordinator := NewCoordinator(CoordinatorConfig{MaxAttempts: 2, LeaseTimeout: time.Minute})
+	now := time.Now()
+	coordinator.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	type outcome struct {
+		result reporter.EndpointResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := coordinator.Dispatch(ctx, endpointTask("/flaky", "mock"))
+		done <- outcome{result, err}
+	}()
+
+	// The first worker fails, the second lets its lease expire
+	task, ok := coordinator.Lease(ctx, "w1", nil, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, 1, task.Attempt)
+	require.NoError(t, coordinator.Complete(task.ID, Result{WorkerID: "w1", Error: "model not pulled"}))
+	assert.ErrorIs(t, coordinator.Complete(task.ID, Result{WorkerID: "w1"}), ErrUnknownTask)
+
+	task, ok = coordinator.Lease(ctx, "w2", nil, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, 2, task.Attempt)
+	require.NoError(t, coordinator.Heartbeat("w2", task.ID))
+	assert.ErrorIs(t, coordinator.Heartbeat("w1", task.ID), ErrUnknownTask)
+	now = now.Add(2 * time.Minute)
+	_, ok = coordinator.Lease(ctx, "w3", nil, 10*time.Millisecond)
+	assert.False(t, ok, "the expired task has no attempts left")
+
+	got := <-done
+	assert.True(t, errors.Is(got.err, ErrAttemptsExhausted))
+	assert.ErrorContains(t, got.err, "w1: model not pulled; w2: lease expired")
+}
+
+func TestCoordinator_DispatchCancelled(t *testing.T) {
+	coordinator := NewCoordinator(CoordinatorConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := coordinator.Dispatch(ctx, endpointTask("/slow", "mock"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, coordinator.Status().Pending, "cancelled tasks leave the queue")
+}
@@ -0,0 +1,445 @@
+// Package cluster distributes analyses across machines. A coordinator
+// (glens serve --distributed) queues one task per endpoint of a job;
+// stateless workers (glens worker --join) lease tasks over HTTP, generate and
+// execute the tests locally, e.g. next to the GPU running Ollama, and report
+// the results back for the coordinator's report.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// Defaults of a coordinator
+const (
+	// DefaultLeaseTimeout is how long a worker may hold a task without a
+	// heartbeat before it is handed to another worker
+	DefaultLeaseTimeout = 2 * time.Minute
+	// DefaultMaxAttempts is how often a task is handed out before its
+	// endpoint is reported as failed
+	DefaultMaxAttempts = 3
+	// DefaultLeaseWait is how long a lease request waits for a task
+	DefaultLeaseWait = 20 * time.Second
+)
+
+// Errors returned by the coordinator
+var (
+	// ErrUnknownTask is returned for tasks that are not leased by the worker
+	ErrUnknownTask = errors.New("task not leased by this worker")
+	// ErrAttemptsExhausted is returned when every attempt of a task failed
+	ErrAttemptsExhausted = errors.New("task failed on every attempt")
+)
+
+// TaskOptions are the analysis settings a worker applies to a task
+type TaskOptions struct {
+	Models          []string                 `json:"models"`
+	Framework       string                   `json:"framework,omitempty"`
+	RunTests        bool                     `json:"run_tests"`
+	AllowRisk       safety.Risk              `json:"allow_risk,omitempty"`
+	TestTimeout     time.Duration            `json:"test_timeout,omitempty"`
+	TestRetries     int                      `json:"test_retries,omitempty"`
+	EndpointTimeout time.Duration            `json:"endpoint_timeout,omitempty"`
+	RepairAttempts  int                      `json:"repair_attempts,omitempty"`
+	Ensemble        string                   `json:"ensemble,omitempty"`
+	Lint            *generator.LintOptions   `json:"lint,omitempty"`
+	Env             *environment.Environment `json:"environment,omitempty"`
+}
+
+// Task is the work of one endpoint of a job
+type Task struct {
+	ID       string          `json:"id"`
+	JobID    string          `json:"job_id,omitempty"`
+	Endpoint parser.Endpoint `json:"endpoint"`
+	Options  TaskOptions     `json:"options"`
+	Attempt  int             `json:"attempt"`
+	// LeaseTimeout tells the worker how often to send heartbeats
+	LeaseTimeout time.Duration `json:"lease_timeout"`
+}
+
+// Result is what a worker reports for a task
+type Result struct {
+	WorkerID string                   `json:"worker_id"`
+	Result   *reporter.EndpointResult `json:"result,omitempty"`
+	// Error is set when the worker could not process the task; the task is
+	// handed to another worker until its attempts are exhausted
+	Error string `json:"error,omitempty"`
+}
+
+// leaseRequest is the body of a lease request
+type leaseRequest struct {
+	WorkerID string `json:"worker_id"`
+	// Models are the models the worker serves; empty serves any
+	Models []string `json:"models,omitempty"`
+}
+
+// WorkerStatus describes a worker the coordinator has heard from
+type WorkerStatus struct {
+	ID       string    `json:"id"`
+	Models   []string  `json:"models,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+	Tasks    []string  `json:"tasks,omitempty"`
+}
+
+// Status summarizes the coordinator's queue and workers
+type Status struct {
+	Pending int            `json:"pending"`
+	Leased  int            `json:"leased"`
+	Workers []WorkerStatus `json:"workers"`
+}
+
+// entry is a queued or leased task and the dispatcher waiting for it
+type entry struct {
+	task     Task
+	done     chan Result
+	worker   string
+	deadline time.Time
+	errors   []string
+}
+
+// CoordinatorConfig configures a coordinator
+type CoordinatorConfig struct {
+	// Token, when set, must be sent by workers as a bearer token
+	Token string
+	// LeaseTimeout defaults to DefaultLeaseTimeout
+	LeaseTimeout time.Duration
+	// MaxAttempts defaults to DefaultMaxAttempts
+	MaxAttempts int
+	// LeaseWait defaults to DefaultLeaseWait
+	LeaseWait time.Duration
+}
+
+// Coordinator queues tasks for workers and hands their results back to the
+// analyses waiting for them
+type Coordinator struct {
+	cfg CoordinatorConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending []*entry
+	leased  map[string]*entry
+	workers map[string]*WorkerStatus
+	// wake is closed and replaced whenever a task becomes available
+	wake chan struct{}
+}
+
+// NewCoordinator creates a coordinator with cfg, filling in defaults
+func NewCoordinator(cfg CoordinatorConfig) *Coordinator {
+	if cfg.LeaseTimeout <= 0 {
+		cfg.LeaseTimeout = DefaultLeaseTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.LeaseWait <= 0 {
+		cfg.LeaseWait = DefaultLeaseWait
+	}
+	return &Coordinator{
+		cfg:     cfg,
+		now:     time.Now,
+		leased:  map[string]*entry{},
+		workers: map[string]*WorkerStatus{},
+		wake:    make(chan struct{}),
+	}
+}
+
+// Dispatch queues task and waits until a worker reports its result, every
+// attempt failed, or ctx is done
+func (c *Coordinator) Dispatch(ctx context.Context, task Task) (reporter.EndpointResult, error) {
+	id, err := newTaskID()
+	if err != nil {
+		return reporter.EndpointResult{}, err
+	}
+	task.ID = id
+	task.LeaseTimeout = c.cfg.LeaseTimeout
+	e := &entry{task: task, done: make(chan Result, 1)}
+
+	c.mu.Lock()
+	c.pending = append(c.pending, e)
+	c.signal()
+	c.mu.Unlock()
+
+	select {
+	case result := <-e.done:
+		if result.Result == nil {
+			return reporter.EndpointResult{}, fmt.Errorf("%w: %s", ErrAttemptsExhausted, result.Error)
+		}
+		return *result.Result, nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.pending = slices.DeleteFunc(c.pending, func(p *entry) bool { return p == e })
+		delete(c.leased, id)
+		c.mu.Unlock()
+		return reporter.EndpointResult{}, ctx.Err()
+	}
+}
+
+// signal wakes the waiting lease requests; c.mu must be held
+func (c *Coordinator) signal() {
+	close(c.wake)
+	c.wake = make(chan struct{})
+}
+
+// Lease hands the oldest task whose models the worker serves to workerID,
+// waiting up to wait for one; ok is false when none became available
+func (c *Coordinator) Lease(ctx context.Context, workerID string, models []string, wait time.Duration) (task Task, ok bool) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		c.seen(workerID, models)
+		c.expireLeases()
+		for i, e := range c.pending {
+			if !serves(models, e.task.Options.Models) {
+				continue
+			}
+			c.pending = slices.Delete(c.pending, i, i+1)
+			e.task.Attempt++
+			e.worker = workerID
+			e.deadline = c.now().Add(c.cfg.LeaseTimeout)
+			c.leased[e.task.ID] = e
+			task = e.task
+			c.mu.Unlock()
+			log.Debug().Str("worker", workerID).Str("task", task.ID).
+				Str("endpoint", task.Endpoint.Method+" "+task.Endpoint.Path).Int("attempt", task.Attempt).Msg("Task leased")
+			return task, true
+		}
+		wake := c.wake
+		c.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			return Task{}, false
+		case <-ctx.Done():
+			return Task{}, false
+		}
+	}
+}
+
+// Heartbeat extends the lease of a task held by workerID
+func (c *Coordinator) Heartbeat(workerID, taskID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen(workerID, nil)
+	e, ok := c.leased[taskID]
+	if !ok || e.worker != workerID {
+		return ErrUnknownTask
+	}
+	e.deadline = c.now().Add(c.cfg.LeaseTimeout)
+	return nil
+}
+
+// Complete records the result a worker reports for a task. A failed
+// attempt is queued again until the task's attempts are exhausted.
+func (c *Coordinator) Complete(taskID string, result Result) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen(result.WorkerID, nil)
+	e, ok := c.leased[taskID]
+	if !ok || e.worker != result.WorkerID {
+		return ErrUnknownTask
+	}
+	delete(c.leased, taskID)
+	if result.Result == nil {
+		if result.Error == "" {
+			result.Error = "worker reported no result"
+		}
+		c.retry(e, fmt.Sprintf("%s: %s", result.WorkerID, result.Error))
+		return nil
+	}
+	e.done <- result
+	return nil
+}
+
+// expireLeases queues the tasks of workers whose leases ran out again;
+// c.mu must be held
+func (c *Coordinator) expireLeases() {
+	now := c.now()
+	for id, e := range c.leased {
+		if now.After(e.deadline) {
+			delete(c.leased, id)
+			log.Warn().Str("worker", e.worker).Str("task", id).Msg("Task lease expired")
+			c.retry(e, fmt.Sprintf("%s: lease expired", e.worker))
+		}
+	}
+}
+
+// retry queues e again, or fails it once its attempts are exhausted;
+// c.mu must be held
+func (c *Coordinator) retry(e *entry, reason string) {
+	e.errors = append(e.errors, reason)
+	e.worker = ""
+	if e.task.Attempt >= c.cfg.MaxAttempts {
+		e.done <- Result{Error: strings.Join(e.errors, "; ")}
+		return
+	}
+	c.pending = append(c.pending, e)
+	c.signal()
+}
+
+// seen records that a worker contacted the coordinator; c.mu must be held
+func (c *Coordinator) seen(workerID string, models []string) {
+	w, ok := c.workers[workerID]
+	if !ok {
+		w = &WorkerStatus{ID: workerID}
+		c.workers[workerID] = w
+		log.Info().Str("worker", workerID).Strs("models", models).Msg("Worker joined")
+	}
+	if models != nil {
+		w.Models = models
+	}
+	w.LastSeen = c.now().UTC()
+}
+
+// Status returns the number of pending and leased tasks and the workers
+// with the tasks they hold, sorted by ID
+func (c *Coordinator) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := Status{Pending: len(c.pending), Leased: len(c.leased), Workers: []WorkerStatus{}}
+	for _, w := range c.workers {
+		worker := *w
+		worker.Tasks = nil
+		for id, e := range c.leased {
+			if e.worker == w.ID {
+				worker.Tasks = append(worker.Tasks, id)
+			}
+		}
+		sort.Strings(worker.Tasks)
+		status.Workers = append(status.Workers, worker)
+	}
+	sort.Slice(status.Workers, func(i, j int) bool { return status.Workers[i].ID < status.Workers[j].ID })
+	return status
+}
+
+// serves reports whether a worker serving models can run a task needing
+// required; a worker without models serves any
+func serves(models, required []string) bool {
+	if len(models) == 0 {
+		return true
+	}
+	for _, model := range required {
+		if !slices.Contains(models, model) {
+			return false
+		}
+	}
+	return true
+}
+
+// newTaskID returns a random task ID
+func newTaskID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate task id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Handler serves the worker API under /api/v1/cluster/:
+//
+//	POST /api/v1/cluster/lease                 lease a task (200) or none (204)
+//	POST /api/v1/cluster/tasks/{id}/heartbeat  extend a lease
+//	POST /api/v1/cluster/tasks/{id}/result     report a task's result
+//	GET  /api/v1/cluster/status                queue and workers
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/cluster/lease", c.handleLease)
+	mux.HandleFunc("POST /api/v1/cluster/tasks/{id}/heartbeat", c.handleHeartbeat)
+	mux.HandleFunc("POST /api/v1/cluster/tasks/{id}/result", c.handleResult)
+	mux.HandleFunc("GET /api/v1/cluster/status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, c.Status())
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.cfg.Token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(c.cfg.Token)) != 1 {
+				http.Error(w, "invalid worker token", http.StatusUnauthorized)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (c *Coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	var req leaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.WorkerID == "" {
+		http.Error(w, "worker_id is required", http.StatusBadRequest)
+		return
+	}
+	wait := c.cfg.LeaseWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 && d < wait {
+			wait = d
+		}
+	}
+	task, ok := c.Lease(r.Context(), req.WorkerID, req.Models, wait)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Context().Err() != nil {
+		// The worker is gone; hand the task to the next one
+		_ = c.Complete(task.ID, Result{WorkerID: req.WorkerID, Error: "worker disconnected while leasing"})
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (c *Coordinator) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var req leaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := c.Heartbeat(req.WorkerID, r.PathValue("id")); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Coordinator) handleResult(w http.ResponseWriter, r *http.Request) {
+	var result Result
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := c.Complete(r.PathValue("id"), result); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes v as a JSON response with status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "failed to encode JSON response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,200 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/reporter"
+)
+
+// retryDelay is how long a worker waits after the coordinator could not be
+// reached
+const retryDelay = 5 * time.Second
+
+// ProcessFunc runs a task on the worker
+type ProcessFunc func(ctx context.Context, task Task) (reporter.EndpointResult, error)
+
+// Worker leases tasks from a coordinator one at a time and reports their
+// results back
+type Worker struct {
+	// Coordinator is the base URL of the coordinating glens serve
+	Coordinator string
+	// Token is sent as a bearer token when set
+	Token string
+	// APIKey is sent in the X-API-Key header when set, for coordinators
+	// requiring API keys of every client
+	APIKey string
+	// ID identifies the worker to the coordinator
+	ID string
+	// Models are the models the worker serves; empty serves any task
+	Models []string
+	// Process runs a leased task
+	Process ProcessFunc
+	// Client defaults to a client without timeout; lease requests wait for
+	// tasks
+	Client *http.Client
+}
+
+// Run leases and processes tasks until ctx is done; it fails only when the
+// coordinator rejects the worker's token or API key
+func (w *Worker) Run(ctx context.Context) error {
+	if w.Client == nil {
+		w.Client = &http.Client{}
+	}
+	log.Info().Str("coordinator", w.Coordinator).Str("worker", w.ID).Strs("models", w.Models).Msg("Worker joining")
+	for ctx.Err() == nil {
+		task, ok, err := w.lease(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			var status *statusError
+			if errors.As(err, &status) && status.code == http.StatusUnauthorized {
+				return fmt.Errorf("coordinator rejected the worker token or API key: %w", err)
+			}
+			log.Warn().Err(err).Msg("Failed to lease a task, retrying")
+			sleep(ctx, retryDelay)
+			continue
+		}
+		if ok {
+			w.runTask(ctx, task)
+		}
+	}
+	return nil
+}
+
+// runTask processes task while sending heartbeats, then reports the result
+func (w *Worker) runTask(ctx context.Context, task Task) {
+	log.Info().Str("task", task.ID).Str("endpoint", task.Endpoint.Method+" "+task.Endpoint.Path).
+		Int("attempt", task.Attempt).Msg("Processing task")
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	go w.heartbeats(taskCtx, cancel, task)
+	result, err := w.Process(taskCtx, task)
+	lost := taskCtx.Err() != nil && ctx.Err() == nil
+	cancel()
+	if lost {
+		log.Warn().Str("task", task.ID).Msg("Lease lost, dropping the task")
+		return
+	}
+
+	report := Result{WorkerID: w.ID}
+	switch {
+	case ctx.Err() != nil:
+		// A cut-short result is not kept; another worker retries the task
+		report.Error = "worker stopped"
+	case err != nil:
+		report.Error = err.Error()
+	default:
+		report.Result = &result
+	}
+	// Report even when the worker is stopping so the task is handed on
+	reportCtx, done := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer done()
+	if err := w.post(reportCtx, "/api/v1/cluster/tasks/"+task.ID+"/result", report, nil); err != nil {
+		log.Error().Err(err).Str("task", task.ID).Msg("Failed to report the task result")
+	}
+}
+
+// heartbeats extends the lease of task until ctx is done, cancelling the
+// task when the coordinator no longer knows it
+func (w *Worker) heartbeats(ctx context.Context, cancel context.CancelFunc, task Task) {
+	interval := task.LeaseTimeout / 3
+	if interval <= 0 {
+		interval = DefaultLeaseTimeout / 3
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.post(ctx, "/api/v1/cluster/tasks/"+task.ID+"/heartbeat", leaseRequest{WorkerID: w.ID}, nil)
+			var status *statusError
+			if errors.As(err, &status) && status.code == http.StatusConflict {
+				cancel()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Str("task", task.ID).Msg("Heartbeat failed")
+			}
+		}
+	}
+}
+
+// lease asks the coordinator for a task
+func (w *Worker) lease(ctx context.Context) (Task, bool, error) {
+	var task Task
+	ok := false
+	err := w.post(ctx, "/api/v1/cluster/lease", leaseRequest{WorkerID: w.ID, Models: w.Models}, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		ok = true
+		return json.NewDecoder(resp.Body).Decode(&task)
+	})
+	return task, ok, err
+}
+
+// statusError is an unexpected response of the coordinator
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("coordinator returned HTTP %d: %s", e.code, e.body)
+}
+
+// post sends body as JSON to the coordinator path and hands successful
+// responses to decode
+func (w *Worker) post(ctx context.Context, path string, body any, decode func(*http.Response) error) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(w.Coordinator, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.Token)
+	}
+	if w.APIKey != "" {
+		req.Header.Set("X-API-Key", w.APIKey)
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+	if decode != nil {
+		return decode(resp)
+	}
+	return nil
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
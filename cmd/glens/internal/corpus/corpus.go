@@ -0,0 +1,245 @@
+// Package corpus holds the specs glens's parser is checked against:
+// OpenAPI 3.0 and 3.1, Swagger 2 and excerpts of public APIs (GitHub,
+// Stripe), embedded with the golden summary of what the parser extracts
+// from each. Golden tests keep the summaries current, and glens corpus run
+// reports how far the parser's extraction drifted from them.
+package corpus
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"glens/tools/glens/internal/parser"
+)
+
+//go:embed specs
+var specs embed.FS
+
+//go:embed golden
+var golden embed.FS
+
+// Directories of the specs and their golden summaries, relative to this
+// package
+const (
+	SpecsDir  = "specs"
+	GoldenDir = "golden"
+)
+
+// Spec is a spec of the corpus
+type Spec struct {
+	// Name is the file name without extension, e.g. petstore_31
+	Name string
+	// File is the file name, e.g. petstore_31.yaml
+	File string
+	Data []byte
+}
+
+// Specs returns the specs of the corpus, sorted by name
+func Specs() ([]Spec, error) {
+	entries, err := fs.ReadDir(specs, SpecsDir)
+	if err != nil {
+		return nil, err
+	}
+	corpus := make([]Spec, 0, len(entries))
+	for _, entry := range entries {
+		data, err := specs.ReadFile(path.Join(SpecsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		corpus = append(corpus, Spec{
+			Name: strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())),
+			File: entry.Name(),
+			Data: data,
+		})
+	}
+	return corpus, nil
+}
+
+// Parse parses the spec with the glens parser
+func (s Spec) Parse() (*parser.OpenAPISpec, error) {
+	return parser.ParseSpecData(s.File, s.Data)
+}
+
+// GoldenFile returns the file of the golden summary of the spec named
+// name, relative to this package
+func GoldenFile(name string) string {
+	return path.Join(GoldenDir, name+".json")
+}
+
+// Golden returns the golden summary of the spec named name
+func Golden(name string) (*Summary, error) {
+	data, err := golden.ReadFile(GoldenFile(name))
+	if err != nil {
+		return nil, err
+	}
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse golden summary of %s: %w", name, err)
+	}
+	return &summary, nil
+}
+
+// Summary is what the parser extracted from a spec, in a stable order:
+// the facts the golden files record
+type Summary struct {
+	Title     string            `json:"title"`
+	Version   string            `json:"version"`
+	Endpoints []EndpointSummary `json:"endpoints"`
+	// Warnings are the spec warnings as "code pointer"
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// EndpointSummary is what the parser extracted of an endpoint
+type EndpointSummary struct {
+	Kind        string `json:"kind,omitempty"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operation_id,omitempty"`
+	// Parameters are in.name, followed by " required" for required ones
+	Parameters []string `json:"parameters,omitempty"`
+	// Request are the media types of the request body
+	Request []string `json:"request,omitempty"`
+	// Responses are the status codes of the responses
+	Responses []string `json:"responses,omitempty"`
+	// Refs are the resolved references of the parameter, request and
+	// response schemas
+	Refs []string `json:"refs,omitempty"`
+	// Security are the security schemes the endpoint accepts
+	Security   []string `json:"security,omitempty"`
+	Pagination string   `json:"pagination,omitempty"`
+}
+
+// Key identifies the endpoint, e.g. "GET /pets" or "webhook POST newPet"
+func (e EndpointSummary) Key() string {
+	key := e.Method + " " + e.Path
+	if e.Kind != "" {
+		key = e.Kind + " " + key
+	}
+	return key
+}
+
+// Summarize returns the summary of a parsed spec
+func Summarize(spec *parser.OpenAPISpec) *Summary {
+	summary := &Summary{Title: spec.Info.Title, Version: spec.Version, Endpoints: []EndpointSummary{}}
+	for i := range spec.Endpoints {
+		summary.Endpoints = append(summary.Endpoints, summarizeEndpoint(&spec.Endpoints[i]))
+	}
+	sort.Slice(summary.Endpoints, func(i, j int) bool {
+		return summary.Endpoints[i].Key() < summary.Endpoints[j].Key()
+	})
+	for _, warning := range spec.Warnings {
+		summary.Warnings = append(summary.Warnings, warning.Code+" "+warning.Path)
+	}
+	return summary
+}
+
+func summarizeEndpoint(e *parser.Endpoint) EndpointSummary {
+	summary := EndpointSummary{Kind: e.Kind, Method: e.Method, Path: e.Path, OperationID: e.OperationID}
+	var refs []string
+	for i := range e.Parameters {
+		p := &e.Parameters[i]
+		parameter := p.In + "." + p.Name
+		if p.Required {
+			parameter += " required"
+		}
+		summary.Parameters = append(summary.Parameters, parameter)
+		refs = schemaRefs(refs, &p.Schema)
+	}
+	if e.RequestBody != nil {
+		for _, mediaType := range sortedKeys(e.RequestBody.Content) {
+			summary.Request = append(summary.Request, mediaType)
+			schema := e.RequestBody.Content[mediaType].Schema
+			refs = schemaRefs(refs, &schema)
+		}
+	}
+	for _, code := range sortedKeys(e.Responses) {
+		summary.Responses = append(summary.Responses, code)
+		content := e.Responses[code].Content
+		for _, mediaType := range sortedKeys(content) {
+			schema := content[mediaType].Schema
+			refs = schemaRefs(refs, &schema)
+		}
+	}
+	summary.Refs = unique(refs)
+	var security []string
+	for _, requirement := range e.Security {
+		security = append(security, sortedKeys(requirement)...)
+	}
+	summary.Security = unique(security)
+	if e.Pagination != nil {
+		summary.Pagination = e.Pagination.Style
+	}
+	return summary
+}
+
+// schemaRefs appends the references of schema and its subschemas to refs
+func schemaRefs(refs []string, schema *parser.Schema) []string {
+	if schema.Ref != "" {
+		refs = append(refs, schema.Ref)
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		property := schema.Properties[name]
+		refs = schemaRefs(refs, &property)
+	}
+	if schema.Items != nil {
+		refs = schemaRefs(refs, schema.Items)
+	}
+	for _, group := range [][]parser.Schema{schema.PrefixItems, schema.OneOf, schema.AnyOf, schema.AllOf} {
+		for i := range group {
+			refs = schemaRefs(refs, &group[i])
+		}
+	}
+	return refs
+}
+
+// Facts returns the facts of the summary, such as "parameter GET /pets
+// query.limit", sorted
+func (s *Summary) Facts() []string {
+	facts := []string{"title " + s.Title, "version " + s.Version}
+	for _, e := range s.Endpoints {
+		key := e.Key()
+		facts = append(facts, "endpoint "+key)
+		if e.OperationID != "" {
+			facts = append(facts, fmt.Sprintf("operation_id %s %s", key, e.OperationID))
+		}
+		for kind, values := range map[string][]string{
+			"parameter": e.Parameters,
+			"request":   e.Request,
+			"response":  e.Responses,
+			"ref":       e.Refs,
+			"security":  e.Security,
+		} {
+			for _, value := range values {
+				facts = append(facts, fmt.Sprintf("%s %s %s", kind, key, value))
+			}
+		}
+		if e.Pagination != "" {
+			facts = append(facts, fmt.Sprintf("pagination %s %s", key, e.Pagination))
+		}
+	}
+	for _, warning := range s.Warnings {
+		facts = append(facts, "warning "+warning)
+	}
+	return unique(facts)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func unique(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
@@ -0,0 +1,75 @@
+package corpus
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden summaries from the parser output")
+
+func TestGolden(t *testing.T) {
+	corpus, err := Specs()
+	require.NoError(t, err)
+	require.Len(t, corpus, 5)
+
+	for _, spec := range corpus {
+		t.Run(spec.Name, func(t *testing.T) {
+			parsed, err := spec.Parse()
+			require.NoError(t, err)
+			got, err := json.MarshalIndent(Summarize(parsed), "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			if *update {
+				require.NoError(t, os.WriteFile(GoldenFile(spec.Name), got, 0o600))
+				return
+			}
+			want, err := os.ReadFile(GoldenFile(spec.Name))
+			require.NoError(t, err, "run go test ./internal/corpus -update to write it")
+			assert.JSONEq(t, string(want), string(got), "run go test ./internal/corpus -update if the change is intended")
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	if *update {
+		t.Skip("golden summaries are being rewritten")
+	}
+	results, err := Run()
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.False(t, result.Changed(), "%s: %+v", result.Name, result)
+		assert.Equal(t, 1.0, result.Recall)
+		assert.Positive(t, result.Endpoints)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := &Summary{Title: "Pets", Version: "3.0.3", Endpoints: []EndpointSummary{
+		{Method: "GET", Path: "/pets", Parameters: []string{"query.limit"}, Responses: []string{"200"}},
+	}}
+	got := &Summary{Title: "Pets", Version: "3.0.3", Endpoints: []EndpointSummary{
+		{Method: "GET", Path: "/pets", Responses: []string{"200", "default"}},
+		{Kind: "webhook", Method: "POST", Path: "newPet"},
+	}}
+
+	var result Result
+	compare(&result, want.Facts(), got.Facts())
+	assert.Equal(t, []string{"parameter GET /pets query.limit"}, result.Missing)
+	assert.Equal(t, []string{"endpoint webhook POST newPet", "response GET /pets default"}, result.Unexpected)
+	assert.Equal(t, 5, result.Expected)
+	assert.Equal(t, 6, result.Extracted)
+	assert.Equal(t, 4, result.Matched)
+	assert.InDelta(t, 0.8, result.Recall, 1e-9)
+	assert.True(t, result.Changed())
+
+	result = Result{}
+	compare(&result, nil, nil)
+	assert.Equal(t, 1.0, result.Precision)
+	assert.Equal(t, 1.0, result.Recall)
+}
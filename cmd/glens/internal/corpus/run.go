@@ -0,0 +1,92 @@
+package corpus
+
+import (
+	"slices"
+)
+
+// Result is the extraction accuracy of the parser on a spec of the corpus,
+// measured against the spec's golden summary
+type Result struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	// Error is why the spec could not be parsed or has no golden summary
+	Error     string `json:"error,omitempty"`
+	Endpoints int    `json:"endpoints"`
+	// Expected counts the golden facts, Extracted the facts of the parser
+	// output and Matched those in both
+	Expected  int     `json:"expected"`
+	Extracted int     `json:"extracted"`
+	Matched   int     `json:"matched"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	// Missing are the golden facts the parser no longer extracts,
+	// Unexpected the extracted facts the golden summary lacks
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+}
+
+// Changed reports whether the parser's extraction differs from the golden
+// summary or failed
+func (r *Result) Changed() bool {
+	return r.Error != "" || len(r.Missing) > 0 || len(r.Unexpected) > 0
+}
+
+// Run parses every spec of the corpus and compares what the parser
+// extracts against the golden summaries
+func Run() ([]Result, error) {
+	corpus, err := Specs()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(corpus))
+	for _, spec := range corpus {
+		results = append(results, run(spec))
+	}
+	return results, nil
+}
+
+// run compares the parser's extraction of spec against its golden summary
+func run(spec Spec) Result {
+	result := Result{Name: spec.Name, File: spec.File}
+	want, err := Golden(spec.Name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	parsed, err := spec.Parse()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	got := Summarize(parsed)
+	result.Endpoints = len(got.Endpoints)
+	compare(&result, want.Facts(), got.Facts())
+	return result
+}
+
+// compare sets the counts and deltas of result from the expected and the
+// extracted facts, both sorted; with nothing to find or nothing found
+// precision and recall are 1, as nothing was missed or wrongly extracted
+func compare(result *Result, expected, extracted []string) {
+	result.Expected = len(expected)
+	result.Extracted = len(extracted)
+	for _, fact := range expected {
+		if _, found := slices.BinarySearch(extracted, fact); found {
+			result.Matched++
+		} else {
+			result.Missing = append(result.Missing, fact)
+		}
+	}
+	for _, fact := range extracted {
+		if _, found := slices.BinarySearch(expected, fact); !found {
+			result.Unexpected = append(result.Unexpected, fact)
+		}
+	}
+	result.Precision, result.Recall = 1, 1
+	if result.Extracted > 0 {
+		result.Precision = float64(result.Matched) / float64(result.Extracted)
+	}
+	if result.Expected > 0 {
+		result.Recall = float64(result.Matched) / float64(result.Expected)
+	}
+}
@@ -0,0 +1,214 @@
+// Package environment describes the target deployment (base URL, default
+// headers, authentication, TLS) that generated tests run against.
+package environment
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Process environment variables exported to generated tests at run time
+const (
+	// DefaultBaseURL is the host generated tests use when no environment is selected
+	DefaultBaseURL = "http://localhost:8080"
+	// EnvBaseURL holds the base URL of the target environment
+	EnvBaseURL = "GLENS_BASE_URL"
+	// EnvToken holds the resolved bearer token or API key
+	EnvToken = "GLENS_TOKEN"
+	// EnvInsecureSkipVerify is "true" when TLS verification must be skipped
+	EnvInsecureSkipVerify = "GLENS_INSECURE_SKIP_VERIFY"
+	// EnvWebhookAddr is the address webhook tests receive on; the API must
+	// be subscribed to it out of band
+	EnvWebhookAddr = "GLENS_WEBHOOK_ADDR"
+	// EnvRateLimitBurst is the number of requests throttling tests may send
+	// to reach a rate limit; they are skipped when it is not set
+	EnvRateLimitBurst = "GLENS_RATE_LIMIT_BURST"
+	// EnvHeaderPrefix prefixes the variables holding the values of the
+	// environment's headers, see HeaderEnv
+	EnvHeaderPrefix = "GLENS_HEADER_"
+)
+
+// Descriptions describe the process environment variables generated tests
+// read, for the reports that document them
+var Descriptions = map[string]string{
+	EnvBaseURL:            "Base URL of the API under test (default " + DefaultBaseURL + ")",
+	EnvToken:              "Bearer token or API key of the API under test",
+	EnvInsecureSkipVerify: `"true" to skip TLS certificate verification`,
+	EnvWebhookAddr:        "Address webhook tests receive on, subscribed to the API out of band",
+	EnvRateLimitBurst:     "Requests throttling tests may send to reach the rate limit",
+}
+
+// Description describes a process environment variable generated tests
+// read, including the header variables of HeaderEnv
+func Description(name string) string {
+	if description, ok := Descriptions[name]; ok {
+		return description
+	}
+	if strings.HasPrefix(name, EnvHeaderPrefix) {
+		return "Value of a header sent on every request to the API under test"
+	}
+	return ""
+}
+
+// HeaderEnv returns the variable holding the value of header name, e.g.
+// GLENS_HEADER_X_TENANT for X-Tenant
+func HeaderEnv(name string) string {
+	return EnvHeaderPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// AuthType identifies how requests are authenticated
+type AuthType string
+
+// Supported authentication types
+const (
+	// AuthNone sends no credentials
+	AuthNone AuthType = ""
+	// AuthBearer sends "Authorization: Bearer <token>"
+	AuthBearer AuthType = "bearer"
+	// AuthAPIKey sends the token in a custom header
+	AuthAPIKey AuthType = "api_key"
+)
+
+// defaultAPIKeyHeader is used for api_key auth when no header is configured
+const defaultAPIKeyHeader = "X-API-Key"
+
+// Auth configures credentials injected into generated tests
+type Auth struct {
+	Type AuthType `mapstructure:"type"`
+	// Token is a template such as "${STAGING_TOKEN}" expanded from the process environment
+	Token string `mapstructure:"token"`
+	// Header is the header name used for api_key auth (default X-API-Key)
+	Header string `mapstructure:"header"`
+}
+
+// Environment is one entry of the "environments" config section
+type Environment struct {
+	Name               string            `mapstructure:"-"`
+	BaseURL            string            `mapstructure:"base_url"`
+	Headers            map[string]string `mapstructure:"headers"`
+	Auth               Auth              `mapstructure:"auth"`
+	InsecureSkipVerify bool              `mapstructure:"insecure_skip_verify"`
+}
+
+// Resolve expands ${VAR} references in the base URL, headers, and token and
+// validates the result
+func (e *Environment) Resolve() error {
+	e.BaseURL = strings.TrimSuffix(os.ExpandEnv(e.BaseURL), "/")
+	if e.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	u, err := url.Parse(e.BaseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid base_url %q: must be an absolute URL", e.BaseURL)
+	}
+
+	for name, value := range e.Headers {
+		e.Headers[name] = os.ExpandEnv(value)
+	}
+
+	e.Auth.Token = os.ExpandEnv(e.Auth.Token)
+	switch e.Auth.Type {
+	case AuthNone:
+	case AuthBearer, AuthAPIKey:
+		if e.Auth.Token == "" {
+			return fmt.Errorf("auth type %q requires a token", e.Auth.Type)
+		}
+	default:
+		return fmt.Errorf("unsupported auth type %q (use bearer or api_key)", e.Auth.Type)
+	}
+
+	return nil
+}
+
+// AuthHeader returns the header carrying credentials, or "" when auth is disabled
+func (e *Environment) AuthHeader() string {
+	switch e.Auth.Type {
+	case AuthBearer:
+		return "Authorization"
+	case AuthAPIKey:
+		if e.Auth.Header != "" {
+			return e.Auth.Header
+		}
+		return defaultAPIKeyHeader
+	default:
+		return ""
+	}
+}
+
+// HeaderNames returns the names of the environment's headers, sorted
+func (e *Environment) HeaderNames() []string {
+	if e == nil {
+		return nil
+	}
+	names := make([]string, 0, len(e.Headers))
+	for name := range e.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProcessEnv returns the KEY=value pairs exported to the test process: the
+// base URL, the token and the value of every header under its HeaderEnv
+func (e *Environment) ProcessEnv() []string {
+	if e == nil {
+		return nil
+	}
+
+	vars := []string{EnvBaseURL + "=" + e.BaseURL}
+	if e.Auth.Token != "" {
+		vars = append(vars, EnvToken+"="+e.Auth.Token)
+	}
+	if e.InsecureSkipVerify {
+		vars = append(vars, EnvInsecureSkipVerify+"=true")
+	}
+	for _, name := range e.HeaderNames() {
+		vars = append(vars, HeaderEnv(name)+"="+e.Headers[name])
+	}
+	return vars
+}
+
+// secretHeaderWords mark headers whose values are credentials
+var secretHeaderWords = []string{"auth", "cookie", "token", "secret", "key", "password"}
+
+// Secrets returns the credentials of the environment: the auth token and
+// the values of headers such as Authorization, Cookie or X-API-Key
+func (e *Environment) Secrets() []string {
+	if e == nil {
+		return nil
+	}
+	var secrets []string
+	if e.Auth.Token != "" {
+		secrets = append(secrets, e.Auth.Token)
+	}
+	for name, value := range e.Headers {
+		name = strings.ToLower(name)
+		for _, word := range secretHeaderWords {
+			if strings.Contains(name, word) {
+				secrets = append(secrets, value)
+				break
+			}
+		}
+	}
+	return secrets
+}
+
+// Rewrite points test code that still targets DefaultBaseURL at this environment
+func (e *Environment) Rewrite(testCode string) string {
+	if e == nil || e.BaseURL == DefaultBaseURL {
+		return testCode
+	}
+	return strings.ReplaceAll(testCode, DefaultBaseURL, e.BaseURL)
+}
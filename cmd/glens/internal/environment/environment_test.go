@@ -0,0 +1,80 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironment_Resolve(t *testing.T) {
+	t.Setenv("STAGING_HOST", "staging.example.com")
+	t.Setenv("STAGING_TOKEN", "s3cret")
+
+	env := &Environment{
+		BaseURL: "https://${STAGING_HOST}/",
+		Headers: map[string]string{"X-Tenant": "acme"},
+		Auth:    Auth{Type: AuthBearer, Token: "${STAGING_TOKEN}"},
+	}
+	require.NoError(t, env.Resolve())
+
+	assert.Equal(t, "https://staging.example.com", env.BaseURL)
+	assert.Equal(t, "s3cret", env.Auth.Token)
+	assert.Equal(t, "Authorization", env.AuthHeader())
+}
+
+func TestEnvironment_Resolve_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  Environment
+	}{
+		{"missing base url", Environment{}},
+		{"relative base url", Environment{BaseURL: "/api"}},
+		{"unknown auth type", Environment{BaseURL: "https://x.test", Auth: Auth{Type: "oauth"}}},
+		{"bearer without token", Environment{BaseURL: "https://x.test", Auth: Auth{Type: AuthBearer, Token: "${GLENS_UNSET_TOKEN}"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.env.Resolve())
+		})
+	}
+}
+
+func TestEnvironment_ProcessEnvAndRewrite(t *testing.T) {
+	env := &Environment{
+		BaseURL:            "https://staging.example.com",
+		Headers:            map[string]string{"X-Tenant": "acme", "cookie": "session=abc"},
+		Auth:               Auth{Type: AuthAPIKey, Token: "k"},
+		InsecureSkipVerify: true,
+	}
+
+	assert.Equal(t, []string{
+		"GLENS_BASE_URL=https://staging.example.com",
+		"GLENS_TOKEN=k",
+		"GLENS_INSECURE_SKIP_VERIFY=true",
+		"GLENS_HEADER_X_TENANT=acme",
+		"GLENS_HEADER_COOKIE=session=abc",
+	}, env.ProcessEnv())
+	assert.Equal(t, "GLENS_HEADER_X_API_KEY_2", HeaderEnv("x-api.key 2"))
+	assert.Equal(t, "Value of a header sent on every request to the API under test", Description("GLENS_HEADER_X_TENANT"))
+	assert.Equal(t, "X-API-Key", env.AuthHeader())
+	assert.Equal(t, `u := "https://staging.example.com/users"`, env.Rewrite(`u := "http://localhost:8080/users"`))
+
+	var none *Environment
+	assert.Nil(t, none.ProcessEnv())
+	assert.Equal(t, "code", none.Rewrite("code"))
+}
+
+func TestEnvironment_Secrets(t *testing.T) {
+	env := &Environment{
+		BaseURL: "https://staging.example.com",
+		Headers: map[string]string{"X-Tenant": "acme", "Cookie": "session=abc123"},
+		Auth:    Auth{Type: AuthBearer, Token: "staging-token"},
+	}
+
+	assert.ElementsMatch(t, []string{"staging-token", "session=abc123"}, env.Secrets())
+
+	var none *Environment
+	assert.Nil(t, none.Secrets())
+}
@@ -0,0 +1,150 @@
+// Package events writes a machine-readable log of an analysis run: one JSON
+// object per line (NDJSON) for each lifecycle event, so orchestrators and
+// dashboards can follow a run without parsing console logs.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/redact"
+)
+
+// Type names a lifecycle event
+type Type string
+
+// Lifecycle events, in the order a run emits them
+const (
+	// SpecParsed is emitted once the specification is parsed
+	SpecParsed Type = "spec_parsed"
+	// EndpointStarted is emitted before an endpoint's tests are generated
+	EndpointStarted Type = "endpoint_started"
+	// GenerationFinished is emitted when a model has generated, or failed
+	// to generate, a test for an endpoint
+	GenerationFinished Type = "generation_finished"
+	// TestExecuted is emitted when a generated test has run
+	TestExecuted Type = "test_executed"
+	// IssueCreated is emitted when an issue is opened for failing tests
+	IssueCreated Type = "issue_created"
+	// IssueReopened is emitted when the closed issue of an endpoint that
+	// fails again is reopened
+	IssueReopened Type = "issue_reopened"
+	// IssueClosed is emitted when the issue of an endpoint whose tests pass
+	// again is closed
+	IssueClosed Type = "issue_closed"
+)
+
+// Event is one line of the stream. Seq orders the events of a run; the
+// fields that do not apply to an event are omitted.
+type Event struct {
+	Type     Type      `json:"type"`
+	Time     time.Time `json:"time"`
+	RunID    string    `json:"run_id"`
+	Seq      int       `json:"seq"`
+	Endpoint string    `json:"endpoint_id,omitempty"`
+	Method   string    `json:"method,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	// Outcome is passed, failed, flaky or error for tests, success or
+	// error for generations
+	Outcome     string `json:"outcome,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DurationMS  int64  `json:"duration_ms,omitempty"`
+	IssueNumber int    `json:"issue_number,omitempty"`
+	// Spec and Endpoints describe the parsed specification
+	Spec      string `json:"spec,omitempty"`
+	Endpoints int    `json:"endpoints,omitempty"`
+}
+
+// Recorder writes events to a stream. A nil Recorder discards them, so
+// callers need not check whether a stream was requested.
+type Recorder struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	runID  string
+	seq    int
+	now    func() time.Time
+}
+
+// NewRecorder returns a Recorder writing the events of run runID to w
+func NewRecorder(w io.Writer, runID string) *Recorder {
+	return &Recorder{w: w, runID: runID, now: time.Now}
+}
+
+// Create creates (or truncates) the file at path and returns a Recorder
+// writing to it under a new run ID. Close the Recorder to close the file.
+func Create(path string) (*Recorder, error) {
+	runID, err := NewRunID()
+	if err != nil {
+		return nil, err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create events directory: %w", err)
+		}
+	}
+	f, err := os.Create(path) // #nosec G304 -- the path is chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to create events file: %w", err)
+	}
+	r := NewRecorder(redact.Writer(f), runID)
+	r.closer = f
+	return r, nil
+}
+
+// NewRunID returns a random run ID
+func NewRunID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RunID returns the ID stamped on every event, empty for a nil Recorder
+func (r *Recorder) RunID() string {
+	if r == nil {
+		return ""
+	}
+	return r.runID
+}
+
+// Emit stamps e with the time, run ID and sequence number and writes it as
+// one line. Write errors are logged and never interrupt a run.
+func (r *Recorder) Emit(e Event) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	e.Time = r.now().UTC()
+	e.RunID = r.runID
+	e.Seq = r.seq
+	line, err := json.Marshal(e)
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("event", string(e.Type)).Msg("Failed to write run event")
+	}
+}
+
+// Close closes the file of a Recorder returned by Create
+func (r *Recorder) Close() error {
+	if r == nil || r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
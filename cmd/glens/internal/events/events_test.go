@@ -0,0 +1,64 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Emit(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf, "run-1")
+	r.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)) }
+
+	r.Emit(Event{Type: SpecParsed, Spec: "petstore.yaml", Endpoints: 3})
+	r.Emit(Event{Type: TestExecuted, Endpoint: "GET_pets", Method: "GET", Path: "/pets", Model: "gpt4", Outcome: "passed"})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"type":"spec_parsed","time":"2026-01-02T02:04:05Z","run_id":"run-1","seq":1,"spec":"petstore.yaml","endpoints":3}`, string(lines[0]))
+
+	var event Event
+	require.NoError(t, json.Unmarshal(lines[1], &event))
+	assert.Equal(t, TestExecuted, event.Type)
+	assert.Equal(t, 2, event.Seq)
+	assert.Equal(t, "GET_pets", event.Endpoint)
+	assert.Equal(t, "passed", event.Outcome)
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var r *Recorder
+	r.Emit(Event{Type: SpecParsed})
+	assert.Empty(t, r.RunID())
+	assert.NoError(t, r.Close())
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs", "events.ndjson")
+	r, err := Create(path)
+	require.NoError(t, err)
+	assert.Len(t, r.RunID(), 16)
+	r.Emit(Event{Type: EndpointStarted, Endpoint: "GET_pets"})
+	r.Emit(Event{Type: IssueCreated, Endpoint: "GET_pets", IssueNumber: 7})
+	require.NoError(t, r.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	var types []Type
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		assert.Equal(t, r.RunID(), event.RunID)
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []Type{EndpointStarted, IssueCreated}, types)
+}
@@ -0,0 +1,103 @@
+// Package exitcode classifies the errors glens exits with, so CI pipelines
+// can branch on the kind of failure by exit code or by the JSON error glens
+// writes with --error-format=json.
+package exitcode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Code is a process exit code
+type Code int
+
+// Exit codes of glens; they are part of its interface and never renumbered
+const (
+	OK Code = 0
+	// Failure is any error without a more specific code
+	Failure Code = 1
+	// Usage is an invalid flag or argument
+	Usage Code = 2
+	// SpecParse is a spec that could not be read or parsed
+	SpecParse Code = 3
+	// ProviderAuth is an AI provider that is missing credentials, rejects
+	// them or otherwise fails preflight
+	ProviderAuth Code = 4
+	// BudgetExceeded is a run cut short by its total timeout
+	BudgetExceeded Code = 5
+	// TestsFailed is a run with failing generated tests
+	TestsFailed Code = 6
+	// Regression is a run where tests that passed in the baseline fail
+	Regression Code = 7
+)
+
+var kinds = map[Code]string{
+	OK:             "ok",
+	Failure:        "error",
+	Usage:          "usage",
+	SpecParse:      "spec_parse",
+	ProviderAuth:   "provider_auth",
+	BudgetExceeded: "budget_exceeded",
+	TestsFailed:    "tests_failed",
+	Regression:     "regression",
+}
+
+// Kind names the code in JSON errors, e.g. spec_parse
+func (c Code) Kind() string {
+	if kind, ok := kinds[c]; ok {
+		return kind
+	}
+	return kinds[Failure]
+}
+
+// Error is an error with the code glens exits with
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// New returns err with code; a nil err stays nil
+func New(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf formats an error with code
+func Errorf(code Code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Of returns the code of err: OK for nil, the code of the outermost Error
+// in its chain, or Failure
+func Of(err error) Code {
+	if err == nil {
+		return OK
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return Failure
+}
+
+// jsonError is the JSON form of an error
+type jsonError struct {
+	Code    Code   `json:"code"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// WriteJSON writes err as a single line of JSON with its code, kind and
+// message
+func WriteJSON(w io.Writer, err error) error {
+	code := Of(err)
+	return json.NewEncoder(w).Encode(jsonError{Code: code, Kind: code.Kind(), Message: err.Error()})
+}
@@ -0,0 +1,33 @@
+package exitcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	assert.Equal(t, OK, Of(nil))
+	assert.Equal(t, Failure, Of(errors.New("boom")))
+	assert.Nil(t, New(SpecParse, nil))
+
+	err := fmt.Errorf("analysis failed: %w", New(SpecParse, errors.New("bad yaml")))
+	assert.Equal(t, SpecParse, Of(err), "the code survives wrapping")
+	assert.Equal(t, "analysis failed: bad yaml", err.Error())
+
+	err = Errorf(TestsFailed, "%d tests failed", 3)
+	assert.Equal(t, TestsFailed, Of(err))
+	assert.Equal(t, "tests_failed", Of(err).Kind())
+	assert.Equal(t, "error", Code(42).Kind())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, New(ProviderAuth, errors.New(`API key missing for AI model "gpt4"`))))
+	assert.JSONEq(t, `{"code": 4, "kind": "provider_auth", "message": "API key missing for AI model \"gpt4\""}`, buf.String())
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")), "one line per error")
+}
@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/assertspec"
+	"glens/tools/glens/internal/parser"
+)
+
+// AssertspecDir is the directory of a test module holding the
+// glens/assertspec module its tests validate responses with
+const AssertspecDir = "assertspec"
+
+// ResponseSchemas returns the JSON response schemas of endpoints for
+// glens/assertspec (see parser.Endpoint.JSONResponseSchemas)
+func ResponseSchemas(endpoints ...*parser.Endpoint) assertspec.Spec {
+	spec := make(assertspec.Spec)
+	for _, endpoint := range endpoints {
+		schemas := endpoint.JSONResponseSchemas()
+		if len(schemas) == 0 {
+			continue
+		}
+		responses := make(map[string]*assertspec.Schema, len(schemas))
+		for code, schema := range schemas {
+			responses[strings.ToUpper(code)] = specSchema(schema)
+		}
+		spec[strings.ToUpper(endpoint.Method)+" "+endpoint.Path] = responses
+	}
+	return spec
+}
+
+// specSchema converts a schema of the spec for glens/assertspec
+func specSchema(s *parser.Schema) *assertspec.Schema {
+	schema := &assertspec.Schema{
+		Nullable:         s.Nullable,
+		Format:           s.Format,
+		Required:         s.Required,
+		Enum:             s.Enum,
+		Const:            s.Const,
+		Minimum:          s.Minimum,
+		Maximum:          s.Maximum,
+		ExclusiveMinimum: s.ExclusiveMinimum,
+		ExclusiveMaximum: s.ExclusiveMaximum,
+		MinLength:        s.MinLength,
+		MaxLength:        s.MaxLength,
+		Pattern:          s.Pattern,
+		OneOf:            specSchemas(s.OneOf),
+		AnyOf:            specSchemas(s.AnyOf),
+		AllOf:            specSchemas(s.AllOf),
+		PrefixItems:      specSchemas(s.PrefixItems),
+	}
+	switch {
+	case len(s.Types) > 0:
+		schema.Type = s.Types
+	case s.Type != "":
+		schema.Type = []string{s.Type}
+	}
+	if len(s.Properties) > 0 {
+		schema.Properties = make(map[string]*assertspec.Schema, len(s.Properties))
+		for name := range s.Properties {
+			property := s.Properties[name]
+			schema.Properties[name] = specSchema(&property)
+		}
+	}
+	if s.Items != nil {
+		schema.Items = specSchema(s.Items)
+	}
+	return schema
+}
+
+func specSchemas(schemas []parser.Schema) []*assertspec.Schema {
+	if len(schemas) == 0 {
+		return nil
+	}
+	converted := make([]*assertspec.Schema, len(schemas))
+	for i := range schemas {
+		converted[i] = specSchema(&schemas[i])
+	}
+	return converted
+}
+
+// writeAssertspec writes the glens/assertspec module validating against
+// spec into the AssertspecDir of the test module in dir
+func writeAssertspec(dir string, spec assertspec.Spec) error {
+	files, err := assertspec.ModuleFiles(spec)
+	if err != nil {
+		return err
+	}
+	moduleDir := filepath.Join(dir, AssertspecDir)
+	if err := os.MkdirAll(moduleDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create assertspec directory: %w", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(moduleDir, name), []byte(content), 0o600); err != nil {
+			return fmt.Errorf("failed to write assertspec module: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateSuiteAssertspec adds the schemas of spec to the glens/assertspec
+// module of the suite in dir, keeping those of operations not regenerated,
+// and requires the module from suite go.mod files written before it existed
+func updateSuiteAssertspec(dir string, spec assertspec.Spec) error {
+	current, err := os.ReadFile(filepath.Join(dir, AssertspecDir, assertspec.SchemasFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read suite schemas: %w", err)
+	}
+	merged := make(assertspec.Spec)
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &merged); err != nil {
+			log.Warn().Err(err).Msg("Replacing unreadable suite schemas")
+		}
+	}
+	maps.Copy(merged, spec)
+	if err := writeAssertspec(dir, merged); err != nil {
+		return err
+	}
+
+	goModPath := filepath.Join(dir, "go.mod")
+	goMod, err := os.ReadFile(goModPath)
+	if err != nil {
+		return fmt.Errorf("failed to read suite go.mod: %w", err)
+	}
+	if strings.Contains(string(goMod), assertspec.ModulePath+" ") {
+		return nil
+	}
+	if err := os.WriteFile(goModPath, append(goMod, assertspecRequire...), 0o600); err != nil {
+		return fmt.Errorf("failed to write suite go.mod: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/assertspec"
+	glensparser "glens/tools/glens/internal/parser"
+)
+
+func petEndpoint() *glensparser.Endpoint {
+	minID := 1.0
+	return &glensparser.Endpoint{
+		Method: "get",
+		Path:   "/pets/{id}",
+		Responses: map[string]glensparser.Response{
+			"200": {Content: map[string]glensparser.MediaType{"application/json": {Schema: glensparser.Schema{
+				Type:     "object",
+				Required: []string{"id"},
+				Properties: map[string]glensparser.Schema{
+					"id":   {Type: "integer", Minimum: &minID},
+					"tags": {Type: "array", Items: &glensparser.Schema{Types: []string{"string", "null"}}},
+				},
+			}}}},
+			"4xx": {Content: map[string]glensparser.MediaType{"application/problem+json": {Schema: glensparser.Schema{Type: "object"}}}},
+			"204": {},
+			"201": {Content: map[string]glensparser.MediaType{"text/plain": {Schema: glensparser.Schema{Type: "string"}}}},
+		},
+	}
+}
+
+func TestResponseSchemas(t *testing.T) {
+	webhook := petEndpoint()
+	webhook.Kind = glensparser.KindWebhook
+	spec := ResponseSchemas(petEndpoint(), webhook, &glensparser.Endpoint{Method: "DELETE", Path: "/pets/{id}"})
+
+	require.Len(t, spec, 1, "webhooks and endpoints without JSON responses are left out")
+	responses := spec["GET /pets/{id}"]
+	assert.Len(t, responses, 2, "only JSON responses have schemas")
+	assert.Equal(t, []string{"object"}, responses["4XX"].Type)
+
+	pet := responses["200"]
+	assert.Equal(t, []string{"id"}, pet.Required)
+	assert.Equal(t, 1.0, *pet.Properties["id"].Minimum)
+	assert.Equal(t, []string{"string", "null"}, pet.Properties["tags"].Items.Type)
+
+	assert.NoError(t, spec.Validate("GET /pets/7", 200, []byte(`{"id": 7, "tags": ["a", null]}`)))
+	assert.Error(t, spec.Validate("GET /pets/7", 200, []byte(`{"id": 0}`)))
+}
+
+func TestUpdateSuiteAssertspec(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/mine\n"), 0o600))
+	require.NoError(t, writeAssertspec(dir, assertspec.Spec{"GET /users": {"200": {Type: []string{"array"}}}}))
+
+	require.NoError(t, updateSuiteAssertspec(dir, ResponseSchemas(petEndpoint())))
+	require.NoError(t, updateSuiteAssertspec(dir, ResponseSchemas(petEndpoint())))
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/mine\n"+assertspecRequire, string(goMod), "the requirement is added once")
+
+	for _, name := range []string{"go.mod", "assertspec.go", assertspec.SchemasFile} {
+		assert.FileExists(t, filepath.Join(dir, AssertspecDir, name))
+	}
+	schemas, err := os.ReadFile(filepath.Join(dir, AssertspecDir, assertspec.SchemasFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(schemas), `"GET /users"`, "schemas of other operations are kept")
+	assert.Contains(t, string(schemas), `"GET /pets/{id}"`)
+}
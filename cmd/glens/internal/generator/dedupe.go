@@ -0,0 +1,522 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Scenario is a test case of a deduplicated suite
+type Scenario struct {
+	// Test is the test function, or "TestFunc/subtest" for a t.Run case
+	Test  string `json:"test"`
+	Model string `json:"model"`
+	// Signature is the scenario the case exercises: method, path, asserted
+	// status codes and request payload keys; empty when the case asserts
+	// no status and was compared by its code instead
+	Signature string `json:"signature,omitempty"`
+	// Duplicates are the models whose equivalent case was dropped
+	Duplicates []string `json:"duplicates,omitempty"`
+}
+
+// httpMethods are the methods a scenario signature recognises
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// statusConstants maps the http.Status* constant names to their codes
+var statusConstants = func() map[string]int {
+	names := map[string]int{
+		"StatusNonAuthoritativeInfo":         http.StatusNonAuthoritativeInfo,
+		"StatusRequestEntityTooLarge":        http.StatusRequestEntityTooLarge,
+		"StatusRequestURITooLong":            http.StatusRequestURITooLong,
+		"StatusRequestedRangeNotSatisfiable": http.StatusRequestedRangeNotSatisfiable,
+		"StatusTeapot":                       http.StatusTeapot,
+		"StatusHTTPVersionNotSupported":      http.StatusHTTPVersionNotSupported,
+	}
+	for code := 100; code < 600; code++ {
+		text := http.StatusText(code)
+		if text == "" {
+			continue
+		}
+		name := "Status" + strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' || r == '\'' {
+				return -1
+			}
+			return r
+		}, text)
+		if _, ok := names[name]; !ok {
+			names[name] = code
+		}
+	}
+	return names
+}()
+
+// idSegment matches path segments that hold an identifier value
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F-]{32,36}|%[a-z]|\{[^}]*\})$`)
+
+// DedupeTests combines sources, ordered best first, into one test file
+// without near-duplicate cases. Each test function, or each t.Run subtest
+// at the top of one, is a case; cases are clustered by scenario signature
+// (method, path, asserted status codes and request payload keys) and only
+// the first of a cluster is kept. Cases asserting no status are compared
+// by their code. A test function whose cases were all dropped is dropped.
+// Kept test functions carry a comment naming the model that wrote them
+// and the models whose equivalent cases were dropped. Helpers, name
+// clashes and imports are handled as by MergeTests.
+func DedupeTests(sources []TestSource) (*MergedTest, error) {
+	merged := &MergedTest{Contributions: make(map[string][]string)}
+	var (
+		pkgName  string
+		imports  = make(map[string]*ast.ImportSpec)
+		declared = make(map[string]bool)
+		clusters = make(map[string]int)
+		decls    []*dedupedDecl
+	)
+
+	for _, source := range sources {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", source.Code, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse test code of %s: %w", source.Model, err)
+		}
+		if pkgName == "" {
+			pkgName = file.Name.Name
+		}
+		for _, spec := range file.Imports {
+			key := importKey(spec)
+			if _, ok := imports[key]; !ok {
+				imports[key] = spec
+			}
+		}
+		comments := ast.NewCommentMap(fset, file, file.Comments)
+
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+			kept := &dedupedDecl{decl: decl, fset: fset, comments: comments, model: source.Model}
+
+			fn, isFunc := decl.(*ast.FuncDecl)
+			if !isFunc || !isTestFunc(fn) {
+				names := declNames(decl)
+				if anyDeclared(declared, names) {
+					continue
+				}
+				for _, name := range names {
+					declared[name] = true
+				}
+				decls = append(decls, kept)
+				continue
+			}
+
+			cases, err := testCases(fset, fn)
+			if err != nil {
+				return nil, err
+			}
+			var dropped []ast.Stmt
+			for _, c := range cases {
+				key := c.signature
+				if key == "" {
+					key = "code:" + c.code
+				}
+				if i, ok := clusters[key]; ok {
+					scenario := &merged.Scenarios[i]
+					if scenario.Model != source.Model && !slices.Contains(scenario.Duplicates, source.Model) {
+						scenario.Duplicates = append(scenario.Duplicates, source.Model)
+					}
+					dropped = append(dropped, c.stmt)
+					continue
+				}
+				clusters[key] = len(merged.Scenarios)
+				kept.scenarios = append(kept.scenarios, len(merged.Scenarios))
+				merged.Scenarios = append(merged.Scenarios, Scenario{Test: c.name, Model: source.Model, Signature: c.signature})
+			}
+			if len(kept.scenarios) == 0 {
+				continue
+			}
+			if len(dropped) > 0 {
+				first := fn.Body.List[0]
+				fn.Body.List = slices.DeleteFunc(fn.Body.List, func(stmt ast.Stmt) bool {
+					return slices.Contains(dropped, stmt)
+				})
+				if fn.Body.List[0] != first {
+					// no blank line where the dropped cases began the body
+					fn.Body.Lbrace = leadingPos(comments, fn.Body.List[0]) - 1
+				}
+			}
+
+			name := fn.Name.Name
+			if declared[name] {
+				fn.Name.Name += "_" + identSuffix(source.Model)
+				for _, i := range kept.scenarios {
+					merged.Scenarios[i].Test = fn.Name.Name + strings.TrimPrefix(merged.Scenarios[i].Test, name)
+				}
+			}
+			declared[fn.Name.Name] = true
+			merged.Contributions[source.Model] = append(merged.Contributions[source.Model], fn.Name.Name)
+			decls = append(decls, kept)
+		}
+	}
+	if pkgName == "" {
+		return nil, fmt.Errorf("no test code to merge")
+	}
+
+	printed := make([]string, 0, len(decls))
+	for _, decl := range decls {
+		code, err := decl.print(merged.Scenarios)
+		if err != nil {
+			return nil, err
+		}
+		printed = append(printed, code)
+	}
+	code, err := assembleFile(pkgName, imports, strings.Join(printed, "\n\n"))
+	if err != nil {
+		return nil, err
+	}
+	merged.Code = code
+	return merged, nil
+}
+
+// dedupedDecl is a declaration kept in a deduplicated file
+type dedupedDecl struct {
+	decl     ast.Decl
+	fset     *token.FileSet
+	comments ast.CommentMap
+	model    string
+	// scenarios index the cases of a test function kept in the file
+	scenarios []int
+}
+
+// print formats the declaration with its remaining comments, test
+// functions preceded by their provenance
+func (d *dedupedDecl) print(scenarios []Scenario) (string, error) {
+	code, err := printNode(d.fset, &printer.CommentedNode{Node: d.decl, Comments: d.comments.Filter(d.decl).Comments()})
+	if err != nil || len(d.scenarios) == 0 {
+		return code, err
+	}
+
+	var provenance strings.Builder
+	fmt.Fprintf(&provenance, "// Generated by %s", d.model)
+	var duplicated []Scenario
+	for _, i := range d.scenarios {
+		if len(scenarios[i].Duplicates) > 0 {
+			duplicated = append(duplicated, scenarios[i])
+		}
+	}
+	if len(duplicated) > 0 {
+		provenance.WriteString("; equivalent cases of other models were dropped:")
+	}
+	provenance.WriteString("\n")
+	for _, s := range duplicated {
+		description := s.Signature
+		if description == "" {
+			description = "same code"
+		}
+		fmt.Fprintf(&provenance, "//   - %s (%s): %s\n", s.Test, description, strings.Join(s.Duplicates, ", "))
+	}
+	return provenance.String() + code, nil
+}
+
+// leadingPos returns the position of stmt's leading comment, if any, else
+// that of stmt
+func leadingPos(comments ast.CommentMap, stmt ast.Stmt) token.Pos {
+	pos := stmt.Pos()
+	for _, group := range comments[stmt] {
+		pos = min(pos, group.Pos())
+	}
+	return pos
+}
+
+// testCase is a test function, or a t.Run subtest of one
+type testCase struct {
+	name      string
+	signature string
+	code      string
+	// stmt is the t.Run statement of a subtest, nil for a whole function
+	stmt ast.Stmt
+}
+
+// testCases splits fn into its top-level t.Run subtests, or returns fn
+// itself as the only case. Subtests inherit the method and path of the
+// statements around them.
+func testCases(fset *token.FileSet, fn *ast.FuncDecl) ([]testCase, error) {
+	tParam := testingParam(fn)
+	var subtests []*ast.ExprStmt
+	for _, stmt := range fn.Body.List {
+		if expr, ok := stmt.(*ast.ExprStmt); ok && isSubtest(expr.X, tParam) {
+			subtests = append(subtests, expr)
+		}
+	}
+
+	if len(subtests) == 0 {
+		code, err := printNode(fset, fn.Body)
+		if err != nil {
+			return nil, err
+		}
+		s := scenarioOf(fn.Body)
+		return []testCase{{name: fn.Name.Name, signature: s.String(), code: code}}, nil
+	}
+
+	outer := scenario{}
+	for _, stmt := range fn.Body.List {
+		if expr, ok := stmt.(*ast.ExprStmt); !ok || !slices.Contains(subtests, expr) {
+			outer.add(stmt)
+		}
+	}
+	cases := make([]testCase, 0, len(subtests))
+	for _, stmt := range subtests {
+		call := stmt.X.(*ast.CallExpr)
+		s := scenarioOf(call.Args[1])
+		if s.method == "" {
+			s.method = outer.method
+		}
+		if s.path == "" {
+			s.path = outer.path
+		}
+		code, err := printNode(fset, call.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		name := fn.Name.Name + "/" + subtestName(call.Args[0])
+		cases = append(cases, testCase{name: name, signature: s.String(), code: code, stmt: stmt})
+	}
+	return cases, nil
+}
+
+// testingParam names the *testing.T parameter of fn
+func testingParam(fn *ast.FuncDecl) string {
+	for _, field := range fn.Type.Params.List {
+		star, ok := field.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if sel, ok := star.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "T" && len(field.Names) > 0 {
+			return field.Names[0].Name
+		}
+	}
+	return "t"
+}
+
+// isSubtest reports whether expr is t.Run(name, func(t *testing.T) {...})
+func isSubtest(expr ast.Expr, tParam string) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Run" {
+		return false
+	}
+	if ident, ok := sel.X.(*ast.Ident); !ok || ident.Name != tParam {
+		return false
+	}
+	_, ok = call.Args[1].(*ast.FuncLit)
+	return ok
+}
+
+// subtestName returns the name a subtest is run under
+func subtestName(expr ast.Expr) string {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if name, err := strconv.Unquote(lit.Value); err == nil {
+			return strings.ReplaceAll(name, " ", "_")
+		}
+	}
+	return "?"
+}
+
+// scenario is the signature of a test case
+type scenario struct {
+	method   string
+	path     string
+	statuses []int
+	payload  []string
+}
+
+// scenarioOf reads the scenario of the code in node
+func scenarioOf(node ast.Node) scenario {
+	s := scenario{}
+	s.add(node)
+	return s
+}
+
+// String formats the signature, e.g. "POST /pets -> 201 {name,tag}"; it is
+// empty when no status is asserted
+func (s *scenario) String() string {
+	if len(s.statuses) == 0 {
+		return ""
+	}
+	slices.Sort(s.statuses)
+	statuses := make([]string, len(s.statuses))
+	for i, code := range s.statuses {
+		statuses[i] = strconv.Itoa(code)
+	}
+	signature := strings.TrimSpace(s.method+" "+s.path) + " -> " + strings.Join(statuses, ",")
+	if len(s.payload) > 0 {
+		slices.Sort(s.payload)
+		signature += " {" + strings.Join(s.payload, ",") + "}"
+	}
+	return strings.TrimSpace(signature)
+}
+
+// add records the method, path, asserted statuses and payload keys of the
+// code in node; the first method and path found are kept
+func (s *scenario) add(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			s.addSelector(n)
+		case *ast.BasicLit:
+			s.addLiteral(n)
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && s.method == "" {
+				if ident, ok := sel.X.(*ast.Ident); ok && (ident.Name == "http" || strings.Contains(strings.ToLower(ident.Name), "client")) {
+					switch sel.Sel.Name {
+					case "Get", "Head", "Post", "PostForm":
+						s.method = strings.ToUpper(strings.TrimSuffix(sel.Sel.Name, "Form"))
+					}
+				}
+			}
+			if refersToStatus(n) {
+				s.addStatusLiterals(n.Args)
+			}
+		case *ast.BinaryExpr:
+			if refersToStatus(n) {
+				s.addStatusLiterals([]ast.Expr{n.X, n.Y})
+			}
+		case *ast.CompositeLit:
+			s.addPayload(n)
+		}
+		return true
+	})
+}
+
+// addSelector records http.Method* and http.Status* constants
+func (s *scenario) addSelector(sel *ast.SelectorExpr) {
+	if ident, ok := sel.X.(*ast.Ident); !ok || ident.Name != "http" {
+		return
+	}
+	name := sel.Sel.Name
+	if method, ok := strings.CutPrefix(name, "Method"); ok && s.method == "" {
+		s.method = strings.ToUpper(method)
+	}
+	if code, ok := statusConstants[name]; ok && !slices.Contains(s.statuses, code) {
+		s.statuses = append(s.statuses, code)
+	}
+}
+
+// addLiteral records a method name or the first URL path of a string
+func (s *scenario) addLiteral(lit *ast.BasicLit) {
+	if lit.Kind != token.STRING {
+		return
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	if s.method == "" && slices.Contains(httpMethods, value) {
+		s.method = value
+		return
+	}
+	if s.path == "" {
+		s.path = normalizePath(value)
+	}
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var object map[string]any
+		if json.Unmarshal([]byte(value), &object) == nil {
+			for key := range object {
+				s.addPayloadKey(key)
+			}
+		}
+	}
+}
+
+// addStatusLiterals records the integer status codes among exprs
+func (s *scenario) addStatusLiterals(exprs []ast.Expr) {
+	for _, expr := range exprs {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			continue
+		}
+		code, err := strconv.Atoi(lit.Value)
+		if err == nil && http.StatusText(code) != "" && !slices.Contains(s.statuses, code) {
+			s.statuses = append(s.statuses, code)
+		}
+	}
+}
+
+// addPayload records the keys of a map or struct literal with keyed
+// elements
+func (s *scenario) addPayload(lit *ast.CompositeLit) {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		switch key := kv.Key.(type) {
+		case *ast.BasicLit:
+			if name, err := strconv.Unquote(key.Value); err == nil && key.Kind == token.STRING {
+				s.addPayloadKey(name)
+			}
+		case *ast.Ident:
+			s.addPayloadKey(key.Name)
+		}
+	}
+}
+
+func (s *scenario) addPayloadKey(key string) {
+	key = strings.ToLower(key)
+	if !slices.Contains(s.payload, key) {
+		s.payload = append(s.payload, key)
+	}
+}
+
+// refersToStatus reports whether node reads a status code, e.g.
+// resp.StatusCode or rec.Code
+func refersToStatus(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && (sel.Sel.Name == "StatusCode" || sel.Sel.Name == "Code") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// normalizePath returns the path of a URL or path literal with identifier
+// values replaced by {}, or "" when value is neither
+func normalizePath(value string) string {
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil || u.Path == "" {
+			return ""
+		}
+		value = u.Path
+	}
+	if !strings.HasPrefix(value, "/") || strings.ContainsAny(value, " \n\t") {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, "?")
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{}"
+		}
+	}
+	if len(segments) > 2 && segments[len(segments)-1] == "" {
+		// "/pets/" + id
+		segments[len(segments)-1] = "{}"
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gptPetsTest = `package api_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePet(t *testing.T) {
+	url := "http://localhost:8080/pets"
+
+	t.Run("valid pet", func(t *testing.T) {
+		resp, _ := http.Post(url, "application/json", strings.NewReader(` + "`" + `{"name":"rex","tag":"dog"}` + "`" + `))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	// missing name is rejected
+	t.Run("missing name", func(t *testing.T) {
+		resp, _ := http.Post(url, "application/json", strings.NewReader(` + "`" + `{"tag":"dog"}` + "`" + `))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+`
+
+const sonnetPetsTest = `package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePet(t *testing.T) {
+	t.Run("creates a pet", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]any{"tag": "cat", "name": "tom"})
+		req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:9999/pets", bytes.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, 201, resp.StatusCode)
+	})
+
+	t.Run("rejects a conflicting pet", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]any{"name": "rex", "tag": "dog"})
+		req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:9999/pets", bytes.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}
+`
+
+const mistralPetsTest = `package api_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCreatePetWithoutName(t *testing.T) {
+	resp, _ := http.Post("http://localhost:8080/pets", "application/json", strings.NewReader(` + "`" + `{"tag":"fish"}` + "`" + `))
+	if resp.StatusCode != 400 {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+}
+`
+
+func TestDedupeTests(t *testing.T) {
+	merged, err := DedupeTests([]TestSource{
+		{Model: "gpt4", Code: gptPetsTest},
+		{Model: "sonnet", Code: sonnetPetsTest},
+		{Model: "mistral", Code: mistralPetsTest},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Scenario{
+		{Test: "TestCreatePet/valid_pet", Model: "gpt4", Signature: "POST /pets -> 201 {name,tag}", Duplicates: []string{"sonnet"}},
+		{Test: "TestCreatePet/missing_name", Model: "gpt4", Signature: "POST /pets -> 400 {tag}", Duplicates: []string{"mistral"}},
+		{Test: "TestCreatePet_sonnet/rejects_a_conflicting_pet", Model: "sonnet", Signature: "POST /pets -> 409 {name,tag}"},
+	}, merged.Scenarios)
+	assert.Equal(t, map[string][]string{
+		"gpt4":   {"TestCreatePet"},
+		"sonnet": {"TestCreatePet_sonnet"},
+	}, merged.Contributions, "a test whose cases all duplicate kept ones is dropped")
+
+	assert.Equal(t, []string{"TestCreatePet", "TestCreatePet_sonnet"}, TestFunctions(merged.Code))
+	assert.Contains(t, merged.Code, "// Generated by gpt4; equivalent cases of other models were dropped:\n"+
+		"//   - TestCreatePet/valid_pet (POST /pets -> 201 {name,tag}): sonnet\n")
+	assert.Contains(t, merged.Code, "// Generated by sonnet\nfunc TestCreatePet_sonnet")
+	assert.Contains(t, merged.Code, "// missing name is rejected")
+	assert.NotContains(t, merged.Code, "creates a pet")
+	assert.NotContains(t, merged.Code, "TestCreatePetWithoutName")
+	assert.Contains(t, merged.Code, "func TestCreatePet_sonnet(t *testing.T) {\n\tt.Run(")
+
+	_, err = parser.ParseFile(token.NewFileSet(), "", merged.Code, parser.ParseComments)
+	require.NoError(t, err, merged.Code)
+}
+
+func TestDedupeTests_ComparesCodeWithoutStatus(t *testing.T) {
+	code := `package api_test
+
+import "testing"
+
+func TestPing(t *testing.T) {
+	t.Log("ping")
+}
+`
+	merged, err := DedupeTests([]TestSource{{Model: "gpt4", Code: code}, {Model: "sonnet", Code: code}})
+	require.NoError(t, err)
+	assert.Equal(t, []Scenario{{Test: "TestPing", Model: "gpt4", Duplicates: []string{"sonnet"}}}, merged.Scenarios)
+	assert.Contains(t, merged.Code, "//   - TestPing (same code): sonnet\n")
+
+	_, err = DedupeTests([]TestSource{{Model: "gpt4", Code: "not go"}})
+	require.Error(t, err)
+}
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, "/pets/{}", normalizePath("http://localhost:8080/pets/42?verbose=1"))
+	assert.Equal(t, "/pets/{}", normalizePath("/pets/"))
+	assert.Equal(t, "/pets/{}/toys", normalizePath("/pets/%d/toys"))
+	assert.Equal(t, "/users/missing", normalizePath("/users/missing"))
+	assert.Empty(t, normalizePath("application/json"))
+}
@@ -7,23 +7,75 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"glens/tools/glens/internal/assertspec"
+	"glens/tools/glens/internal/environment"
 	"glens/tools/glens/internal/parser"
 )
 
+// DefaultTestTimeout is the per-attempt timeout applied to a test run
+const DefaultTestTimeout = 2 * time.Minute
+
 // NewTestGenerator creates a new test generator
 func NewTestGenerator(framework string) *TestGenerator {
 	return &TestGenerator{
 		framework: framework,
-		timeout:   2 * time.Minute,
+		timeout:   DefaultTestTimeout,
+	}
+}
+
+// SetTimeout sets the timeout applied to each test run attempt.
+// Non-positive values keep the current timeout.
+func (g *TestGenerator) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		g.timeout = timeout
 	}
 }
 
+// SetRetries sets how many times a failing test is re-run before the
+// failure is accepted. Negative values are treated as zero.
+func (g *TestGenerator) SetRetries(retries int) {
+	g.retries = max(retries, 0)
+}
+
+// SetEnvironment selects the target environment tests are executed against.
+// A nil environment keeps the generated code unchanged.
+func (g *TestGenerator) SetEnvironment(env *environment.Environment) {
+	g.env = env
+}
+
+// SetScrubEnv, when scrub is set, runs tests with only the variables the go
+// command needs and those of the target environment rather than the whole
+// environment of the process, whose provider API keys and other secrets
+// generated code could otherwise read. Servers running the tests of
+// submitted specs set it.
+func (g *TestGenerator) SetScrubEnv(scrub bool) {
+	g.scrubEnv = scrub
+}
+
+// SetServers sets the servers of the spec, whose hosts Parameterize
+// replaces in generated tests besides those of the endpoint
+func (g *TestGenerator) SetServers(servers []parser.Server) {
+	g.servers = servers
+}
+
+// Parameterize rewrites the hardcoded hosts and credentials of testCode,
+// generated for endpoint, to the GLENS_* placeholders (see Parameterize).
+// Tests of webhooks and callbacks, whose local hosts are their own capture
+// servers, are left unchanged.
+func (g *TestGenerator) Parameterize(testCode string, endpoint *parser.Endpoint) string {
+	if endpoint.Incoming() {
+		return testCode
+	}
+	return Parameterize(testCode, g.env, append(slices.Clone(endpoint.Servers), g.servers...))
+}
+
 // ExecuteTest executes the generated test code and returns results
 func (g *TestGenerator) ExecuteTest(ctx context.Context, testCode string, endpoint *parser.Endpoint) (*ExecutionResult, error) {
 	startTime := time.Now()
@@ -33,32 +85,14 @@ func (g *TestGenerator) ExecuteTest(ctx context.Context, testCode string, endpoi
 		Str("framework", g.framework).
 		Msg("Executing generated test")
 
-	// Create temporary directory for test execution
-	tmpDir, err := os.MkdirTemp("", "glens-*")
+	tmpDir, _, err := g.writeTestModule(testCode, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer func() {
-		if removeErr := os.RemoveAll(tmpDir); removeErr != nil {
-			log.Debug().Err(removeErr).Msg("failed to remove temporary directory")
-		}
-	}()
-
-	// Write test code to file
-	testFileName := g.generateTestFileName(endpoint)
-	testFilePath := filepath.Join(tmpDir, testFileName)
-
-	if err := os.WriteFile(testFilePath, []byte(testCode), 0o600); err != nil {
-		return nil, fmt.Errorf("failed to write test file: %w", err)
+		return nil, err
 	}
+	defer removeTestModule(tmpDir)
 
-	// Create go.mod for the test
-	if err := g.createTestModule(tmpDir); err != nil {
-		return nil, fmt.Errorf("failed to create test module: %w", err)
-	}
-
-	// Run the test
-	result, err := g.runTest(ctx, tmpDir, testFileName)
+	// Run the test, re-running genuine failures to detect flakes
+	result, err := g.runWithRetries(ctx, tmpDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to run test: %w", err)
 	}
@@ -68,6 +102,8 @@ func (g *TestGenerator) ExecuteTest(ctx context.Context, testCode string, endpoi
 	log.Info().
 		Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
 		Bool("passed", result.Passed).
+		Bool("flaky", result.Flaky).
+		Int("attempts", result.Attempts).
 		Dur("duration", result.Duration).
 		Int("test_count", result.TestCount).
 		Msg("Test execution completed")
@@ -75,8 +111,119 @@ func (g *TestGenerator) ExecuteTest(ctx context.Context, testCode string, endpoi
 	return result, nil
 }
 
+// Compile builds testCode without running it, reporting whether generated
+// code compiles without sending requests to the target API
+func (g *TestGenerator) Compile(ctx context.Context, testCode string, endpoint *parser.Endpoint) error {
+	dir, _, err := g.writeTestModule(testCode, endpoint)
+	if err != nil {
+		return err
+	}
+	defer removeTestModule(dir)
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	tidyModule(ctx, dir)
+
+	cmd := exec.CommandContext(ctx, "go", "test", "-count=1", "-run", "^$", "./...")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("compilation failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeTestModule writes testCode, rewritten for the target environment,
+// into a temporary Go module and returns the directory and file name
+func (g *TestGenerator) writeTestModule(testCode string, endpoint *parser.Endpoint) (dir, fileName string, err error) {
+	dir, err = os.MkdirTemp("", "glens-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	fileName = g.generateTestFileName(endpoint)
+	testCode = g.env.Rewrite(testCode)
+	if err := os.WriteFile(filepath.Join(dir, fileName), []byte(testCode), 0o600); err != nil {
+		removeTestModule(dir)
+		return "", "", fmt.Errorf("failed to write test file: %w", err)
+	}
+
+	if err := g.createTestModule(dir); err != nil {
+		removeTestModule(dir)
+		return "", "", fmt.Errorf("failed to create test module: %w", err)
+	}
+	if err := writeAssertspec(dir, ResponseSchemas(endpoint)); err != nil {
+		removeTestModule(dir)
+		return "", "", fmt.Errorf("failed to create test module: %w", err)
+	}
+	return dir, fileName, nil
+}
+
+func removeTestModule(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		log.Debug().Err(err).Msg("failed to remove temporary directory")
+	}
+}
+
+// runWithRetries runs the test and re-runs it up to g.retries times while it
+// keeps failing. A test that fails and then passes on a later attempt is
+// classified as flaky; the errors of the first failed attempt are kept.
+func (g *TestGenerator) runWithRetries(ctx context.Context, dir string) (*ExecutionResult, error) {
+	var firstFailure *ExecutionResult
+
+	for attempt := 1; ; attempt++ {
+		result, err := g.runTest(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		result.Attempts = attempt
+
+		if !shouldRetry(result) || attempt > g.retries || ctx.Err() != nil {
+			if result.Passed && firstFailure != nil {
+				result.Flaky = true
+				result.FlakyErrors = firstFailure.Errors
+			}
+			return result, nil
+		}
+
+		if firstFailure == nil {
+			firstFailure = result
+		}
+
+		log.Warn().
+			Int("attempt", attempt).
+			Int("max_retries", g.retries).
+			Int("failures", result.FailureCount).
+			Msg("Test failed, retrying to rule out flakiness")
+	}
+}
+
+// shouldRetry reports whether a result is worth re-running. Compilation
+// errors are deterministic, so only assertion failures are retried.
+func shouldRetry(result *ExecutionResult) bool {
+	if !result.Failed {
+		return false
+	}
+	for _, e := range result.Errors {
+		if e.TestName == "compilation" {
+			return false
+		}
+	}
+	return true
+}
+
 // generateTestFileName creates a standardized test file name
 func (g *TestGenerator) generateTestFileName(endpoint *parser.Endpoint) string {
+	// Webhook names, callback expressions and scenario names are not paths,
+	// GraphQL operations share one and gRPC paths are dotted; their IDs,
+	// e.g. WEBHOOK_POST_newPet, QUERY_pet or RPC_PetService_GetPet, name
+	// them instead
+	if endpoint.Incoming() || endpoint.Kind == parser.KindScenario || endpoint.GraphQL != nil || endpoint.GRPC != nil {
+		words := strings.FieldsFunc(strings.ToLower(endpoint.ID), func(r rune) bool {
+			return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+		})
+		return strings.Join(words, "_") + "_test.go"
+	}
+
 	// Clean path for filename
 	path := strings.ReplaceAll(endpoint.Path, "/", "_")
 	path = strings.ReplaceAll(path, "{", "")
@@ -93,7 +240,19 @@ func (g *TestGenerator) generateTestFileName(endpoint *parser.Endpoint) string {
 
 // createTestModule creates a go.mod file for the test
 func (g *TestGenerator) createTestModule(dir string) error {
-	goModContent := `module glens-temp
+	goModPath := filepath.Join(dir, "go.mod")
+	return os.WriteFile(goModPath, []byte(goModFile("glens-temp", g.framework)), 0o600)
+}
+
+// goModFile is the go.mod of a module of generated tests; tests of the grpc
+// framework require grpc-go too. The glens/assertspec module is replaced by
+// its copy in the AssertspecDir of the module.
+func goModFile(module, framework string) string {
+	grpc := ""
+	if framework == string(FrameworkGRPC) {
+		grpc = "\tgoogle.golang.org/grpc v1.75.0\n\tgoogle.golang.org/protobuf v1.36.8\n"
+	}
+	return `module ` + module + `
 
 go 1.25
 
@@ -101,31 +260,28 @@ require (
 	github.com/stretchr/testify v1.11.1
 	github.com/onsi/ginkgo/v2 v2.13.0
 	github.com/onsi/gomega v1.29.0
-)
-`
-
-	goModPath := filepath.Join(dir, "go.mod")
-	return os.WriteFile(goModPath, []byte(goModContent), 0o600)
+` + grpc + `)
+` + assertspecRequire
 }
 
+// assertspecRequire requires the glens/assertspec module from the
+// AssertspecDir of a module of generated tests
+const assertspecRequire = `
+require ` + assertspec.ModulePath + ` v0.0.0
+
+replace ` + assertspec.ModulePath + ` => ./` + AssertspecDir + `
+`
+
 // runTest executes the test using go test command
-func (g *TestGenerator) runTest(ctx context.Context, dir, fileName string) (*ExecutionResult, error) {
+func (g *TestGenerator) runTest(ctx context.Context, dir string) (*ExecutionResult, error) {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(ctx, g.timeout)
 	defer cancel()
 
-	// Run go mod tidy first
-	tidyCmd := exec.CommandContext(ctx, "go", "mod", "tidy")
-	tidyCmd.Dir = dir
-	if output, err := tidyCmd.CombinedOutput(); err != nil {
-		log.Debug().
-			Str("output", string(output)).
-			Err(err).
-			Msg("go mod tidy failed, continuing anyway")
-	}
+	tidyModule(ctx, dir)
 
 	// Build test command based on framework
-	args := g.buildTestCommand(fileName)
+	args := g.buildTestCommand()
 
 	// Validate args to ensure they're safe (gosec G204 mitigation)
 	if len(args) == 0 {
@@ -142,6 +298,7 @@ func (g *TestGenerator) runTest(ctx context.Context, dir, fileName string) (*Exe
 
 	cmd := exec.CommandContext(ctx, "go", args...) //nolint:gosec // args are validated and come from controlled buildTestCommand function
 	cmd.Dir = dir
+	cmd.Env = g.processEnv(os.Environ())
 
 	output, err := cmd.CombinedOutput()
 	outputStr := string(output)
@@ -152,7 +309,7 @@ func (g *TestGenerator) runTest(ctx context.Context, dir, fileName string) (*Exe
 
 	// Parse test results based on framework
 	switch g.framework {
-	case "testify", "standard":
+	case "testify", "standard", "grpc":
 		g.parseGoTestOutput(result, outputStr, err)
 	case "ginkgo":
 		g.parseGinkgoOutput(result, outputStr, err)
@@ -163,13 +320,55 @@ func (g *TestGenerator) runTest(ctx context.Context, dir, fileName string) (*Exe
 	return result, nil
 }
 
+// toolchainEnv are the variables kept by SetScrubEnv: those the go command
+// needs to build and run tests, and those tests need to reach the target
+// API through a proxy or trust its certificates
+var toolchainEnv = []string{
+	"PATH", "HOME", "TMPDIR", "USERPROFILE", "SYSTEMROOT", "LOCALAPPDATA",
+	"GOROOT", "GOPATH", "GOCACHE", "GOMODCACHE", "GOFLAGS", "GOTOOLCHAIN",
+	"GOPROXY", "GOSUMDB", "GONOSUMDB", "GOPRIVATE", "GONOPROXY", "GOINSECURE",
+	"CGO_ENABLED", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+	"SSL_CERT_FILE", "SSL_CERT_DIR",
+}
+
+// processEnv returns the environment of test runs given that of the
+// process, environ: nil, inheriting it, unless a target environment or
+// SetScrubEnv asks for another
+func (g *TestGenerator) processEnv(environ []string) []string {
+	if g.env == nil && !g.scrubEnv {
+		return nil
+	}
+	if g.scrubEnv {
+		environ = slices.DeleteFunc(slices.Clone(environ), func(kv string) bool {
+			name, _, _ := strings.Cut(kv, "=")
+			return !slices.Contains(toolchainEnv, name)
+		})
+	}
+	return append(environ, g.env.ProcessEnv()...)
+}
+
+// tidyModule resolves the dependencies of the test module in dir
+func tidyModule(ctx context.Context, dir string) {
+	tidyCmd := exec.CommandContext(ctx, "go", "mod", "tidy")
+	tidyCmd.Dir = dir
+	if output, err := tidyCmd.CombinedOutput(); err != nil {
+		log.Debug().
+			Str("output", string(output)).
+			Err(err).
+			Msg("go mod tidy failed, continuing anyway")
+	}
+}
+
 // buildTestCommand builds the appropriate test command for the framework
-func (g *TestGenerator) buildTestCommand(fileName string) []string {
+func (g *TestGenerator) buildTestCommand() []string {
 	switch g.framework {
 	case "ginkgo":
 		return []string{"run", "github.com/onsi/ginkgo/v2/ginkgo", "-v", "--json-report=results.json"}
 	default:
-		return []string{"test", "-v", "-json", "./" + strings.TrimSuffix(fileName, ".go")}
+		// The test file is part of the module's root package, built as
+		// Compile builds it. -count=1 disables the test cache so retries
+		// really re-run; -v prints the lines parseGoTestOutput reads.
+		return []string{"test", "-v", "-count=1", "."}
 	}
 }
 
@@ -320,6 +519,10 @@ func (g *TestGenerator) ValidateTestCode(testCode string) error {
 		if !strings.Contains(testCode, "github.com/onsi/ginkgo") {
 			log.Warn().Msg("ginkgo framework specified but imports not found")
 		}
+	case "grpc":
+		if !strings.Contains(testCode, "google.golang.org/grpc") && !strings.Contains(testCode, "grpcurl") {
+			log.Warn().Msg("grpc framework specified but neither grpc-go imports nor grpcurl calls found")
+		}
 	}
 
 	return nil
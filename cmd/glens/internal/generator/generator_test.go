@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/parser"
+)
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *ExecutionResult
+		want   bool
+	}{
+		{"passed", &ExecutionResult{Passed: true}, false},
+		{"assertion failure", &ExecutionResult{
+			Failed: true,
+			Errors: []TestError{{TestName: "TestGetUser", Type: "failure"}},
+		}, true},
+		{"compilation error", &ExecutionResult{
+			Failed: true,
+			Errors: []TestError{{TestName: "compilation", Type: "error"}},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shouldRetry(tt.result))
+		})
+	}
+}
+
+func TestTestGenerator_SetTimeoutAndRetries(t *testing.T) {
+	g := NewTestGenerator("testify")
+	assert.Equal(t, DefaultTestTimeout, g.timeout)
+
+	g.SetTimeout(0)
+	assert.Equal(t, DefaultTestTimeout, g.timeout, "non-positive timeout keeps the default")
+
+	g.SetTimeout(30 * time.Second)
+	assert.Equal(t, 30*time.Second, g.timeout)
+
+	g.SetRetries(-1)
+	assert.Equal(t, 0, g.retries)
+
+	g.SetRetries(3)
+	assert.Equal(t, 3, g.retries)
+}
+
+func TestTestGenerator_GRPC(t *testing.T) {
+	g := NewTestGenerator(string(FrameworkGRPC))
+	endpoint := &parser.Endpoint{
+		ID:     "RPC_PetService_GetPet",
+		Kind:   parser.KindRPC,
+		Method: "POST",
+		Path:   "/petstore.v1.PetService/GetPet",
+		GRPC:   &parser.GRPCMethod{Service: "petstore.v1.PetService", Method: "GetPet"},
+	}
+	assert.Equal(t, "rpc_petservice_getpet_test.go", g.generateTestFileName(endpoint))
+
+	assert.Contains(t, goModFile("suite", "grpc"), "\tgoogle.golang.org/grpc ")
+	assert.NotContains(t, goModFile("suite", "testify"), "google.golang.org/grpc")
+	assert.Contains(t, goModFile("suite", "testify"), "replace glens/assertspec => ./assertspec\n")
+}
+
+func TestTestGenerator_ExecuteTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test on generated modules")
+	}
+
+	// The flaky test fails until its marker file exists
+	marker := filepath.Join(t.TempDir(), "attempted")
+	tests := []struct {
+		name       string
+		body       string
+		wantPassed bool
+		wantFlaky  bool
+		wantError  string
+	}{
+		{"passing", `if 1+1 != 2 { t.Fatal("math") }`, true, false, ""},
+		{"failing", `t.Fatal("status 500")`, false, false, "TestEndpoint"},
+		{"flaky", fmt.Sprintf(`if _, err := os.Stat(%q); err != nil {
+		_ = os.WriteFile(%q, nil, 0o600)
+		t.Fatal("connection reset")
+	}`, marker, marker), true, true, ""},
+		{"not compiling", `undefined()`, false, false, "compilation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewTestGenerator("standard")
+			g.SetRetries(1)
+			code := "package main\n\nimport (\n\t\"os\"\n\t\"testing\"\n)\n\nvar _ = os.Stat\n\n" +
+				"func TestEndpoint(t *testing.T) {\n\t" + tt.body + "\n}\n"
+
+			result, err := g.ExecuteTest(context.Background(), code, &parser.Endpoint{Method: "GET", Path: "/users"})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantPassed, result.Passed, result.Output)
+			assert.Equal(t, !tt.wantPassed, result.Failed, result.Output)
+			assert.Equal(t, tt.wantFlaky, result.Flaky)
+			if tt.wantError != "" {
+				require.NotEmpty(t, result.Errors)
+				assert.Equal(t, tt.wantError, result.Errors[0].TestName)
+			}
+			switch tt.name {
+			case "failing", "flaky":
+				assert.Equal(t, 2, result.Attempts, "failures are retried")
+			default:
+				assert.Equal(t, 1, result.Attempts, "passes and compilation errors are not")
+			}
+		})
+	}
+}
+
+func TestTestGenerator_ProcessEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "HOME=/home/glens", "GOFLAGS=-mod=mod", "OPENAI_API_KEY=sk-secret", "API_KEYS=k1"}
+	target := &environment.Environment{BaseURL: "https://staging.example.com"}
+
+	tests := []struct {
+		name  string
+		env   *environment.Environment
+		scrub bool
+		want  []string
+	}{
+		{"inherited", nil, false, nil},
+		{"target environment", target, false, append(slices.Clone(environ), "GLENS_BASE_URL=https://staging.example.com")},
+		{"scrubbed", nil, true, []string{"PATH=/usr/bin", "HOME=/home/glens", "GOFLAGS=-mod=mod"}},
+		{"scrubbed target environment", target, true, []string{"PATH=/usr/bin", "HOME=/home/glens", "GOFLAGS=-mod=mod", "GLENS_BASE_URL=https://staging.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewTestGenerator("standard")
+			g.SetEnvironment(tt.env)
+			g.SetScrubEnv(tt.scrub)
+
+			assert.Equal(t, tt.want, g.processEnv(environ))
+		})
+	}
+}
+
+func TestTestGenerator_ExecuteTest_ScrubEnv(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test on generated modules")
+	}
+	t.Setenv("OPENAI_API_KEY", "sk-secret")
+	code := "package main\n\nimport (\n\t\"os\"\n\t\"testing\"\n)\n\n" +
+		"func TestEndpoint(t *testing.T) {\n\tif os.Getenv(\"OPENAI_API_KEY\") != \"\" {\n\t\tt.Fatal(\"provider key visible\")\n\t}\n}\n"
+
+	g := NewTestGenerator("standard")
+	g.SetScrubEnv(true)
+	result, err := g.ExecuteTest(context.Background(), code, &parser.Endpoint{Method: "GET", Path: "/users"})
+
+	require.NoError(t, err)
+	assert.True(t, result.Passed, result.Output)
+}
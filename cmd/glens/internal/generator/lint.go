@@ -0,0 +1,314 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	glensparser "glens/tools/glens/internal/parser"
+)
+
+// Severity ranks static analysis findings
+type Severity string
+
+// Finding severities, from least to most severe
+const (
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+	// SeverityNone as a fail-on threshold records findings without ever
+	// blocking execution
+	SeverityNone Severity = "none"
+)
+
+// Static analysis tools the quality gate can run
+const (
+	LintVet         = "vet"
+	LintStaticcheck = "staticcheck"
+	LintGosec       = "gosec"
+	// lintImports is the built-in check for dangerous imports; it always
+	// runs so the gate works without external tools installed
+	lintImports = "imports"
+)
+
+// DefaultLintTools are the external tools run when none are configured
+var DefaultLintTools = []string{LintVet, LintStaticcheck, LintGosec}
+
+// dangerousImports are packages generated API tests have no reason to use
+var dangerousImports = map[string]string{
+	"os/exec": "runs external commands",
+	"syscall": "makes raw system calls",
+	"unsafe":  "bypasses memory safety",
+	"plugin":  "loads arbitrary code",
+}
+
+// severityPenalty is subtracted from the quality score per finding
+var severityPenalty = map[Severity]float64{
+	SeverityLow:    2,
+	SeverityMedium: 5,
+	SeverityHigh:   20,
+}
+
+// ParseSeverity validates a severity; empty means SeverityHigh
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(strings.ToLower(s)); sev {
+	case "":
+		return SeverityHigh, nil
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityNone:
+		return sev, nil
+	default:
+		return "", fmt.Errorf("invalid severity %q: must be low, medium, high or none", s)
+	}
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether s is as severe as threshold; nothing reaches
+// SeverityNone
+func (s Severity) AtLeast(threshold Severity) bool {
+	return threshold != SeverityNone && s.rank() >= threshold.rank() && s.rank() > 0
+}
+
+// LintOptions configure the static analysis gate run before execution
+type LintOptions struct {
+	// Tools are the external tools to run (vet, staticcheck, gosec); tools
+	// not installed are skipped. Empty runs DefaultLintTools.
+	Tools []string
+	// FailOn blocks execution when a finding is at least this severe
+	FailOn Severity
+}
+
+// SetLint enables the static analysis gate; nil disables it
+func (g *TestGenerator) SetLint(opts *LintOptions) {
+	g.lint = opts
+}
+
+// LintEnabled reports whether generated tests are statically analysed
+func (g *TestGenerator) LintEnabled() bool {
+	return g.lint != nil
+}
+
+// Lint runs the static analysis gate over testCode and reports its findings
+// and whether they block execution
+func (g *TestGenerator) Lint(ctx context.Context, testCode string, endpoint *glensparser.Endpoint) (findings []Finding, blocked bool, err error) {
+	if g.lint == nil {
+		return nil, false, nil
+	}
+
+	findings = importFindings(testCode)
+
+	tools := g.lint.Tools
+	if len(tools) == 0 {
+		tools = DefaultLintTools
+	}
+	var available []string
+	for _, tool := range tools {
+		if _, err := exec.LookPath(lintCommand(tool)); err != nil {
+			log.Debug().Str("tool", tool).Msg("Static analysis tool not installed, skipping")
+			continue
+		}
+		available = append(available, tool)
+	}
+
+	if len(available) > 0 {
+		dir, fileName, err := g.writeTestModule(testCode, endpoint)
+		if err != nil {
+			return nil, false, err
+		}
+		defer removeTestModule(dir)
+
+		ctx, cancel := context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+		tidyModule(ctx, dir)
+
+		for _, tool := range available {
+			found, err := runLintTool(ctx, tool, dir, fileName)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to run %s: %w", tool, err)
+			}
+			findings = append(findings, found...)
+		}
+	}
+
+	for _, f := range findings {
+		if f.Severity.AtLeast(g.lint.FailOn) {
+			blocked = true
+		}
+	}
+	return findings, blocked, nil
+}
+
+// LintPenalty is the quality score deduction for findings
+func LintPenalty(findings []Finding) float64 {
+	penalty := 0.0
+	for _, f := range findings {
+		penalty += severityPenalty[f.Severity]
+	}
+	return penalty
+}
+
+// importFindings reports imports of dangerous packages
+func importFindings(testCode string) []Finding {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", testCode, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var findings []Finding
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if reason, ok := dangerousImports[path]; ok {
+			findings = append(findings, Finding{
+				Tool:     lintImports,
+				Rule:     "dangerous-import",
+				Severity: SeverityHigh,
+				Line:     fset.Position(spec.Pos()).Line,
+				Message:  fmt.Sprintf("imports %s, which %s", path, reason),
+			})
+		}
+	}
+	return findings
+}
+
+func lintCommand(tool string) string {
+	if tool == LintVet {
+		return "go"
+	}
+	return tool
+}
+
+// runLintTool runs one tool over the module in dir. Tools exit non-zero
+// when they report findings, so only unreadable output is an error.
+func runLintTool(ctx context.Context, tool, dir, fileName string) ([]Finding, error) {
+	var cmd *exec.Cmd
+	switch tool {
+	case LintVet:
+		cmd = exec.CommandContext(ctx, "go", "vet", "./...")
+	case LintStaticcheck:
+		cmd = exec.CommandContext(ctx, "staticcheck", "-f", "json", "-tests", "./...")
+	case LintGosec:
+		cmd = exec.CommandContext(ctx, "gosec", "-fmt", "json", "-quiet", "-tests", "./...")
+	default:
+		return nil, fmt.Errorf("unknown static analysis tool %q", tool)
+	}
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	switch tool {
+	case LintVet:
+		return parseVetOutput(string(output), fileName), nil
+	case LintStaticcheck:
+		return parseStaticcheckOutput(string(output))
+	default:
+		return parseGosecOutput(output)
+	}
+}
+
+// vetLine matches "./get_users_test.go:12:3: message" diagnostics
+var vetLine = regexp.MustCompile(`^(?:vet: )?\.?/?([^\s:]+\.go):(\d+)(?::\d+)?: (.+)$`)
+
+// parseVetOutput reads go vet diagnostics about fileName
+func parseVetOutput(output, fileName string) []Finding {
+	var findings []Finding
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := vetLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil || filepath.Base(m[1]) != fileName {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		findings = append(findings, Finding{Tool: LintVet, Rule: "vet", Severity: SeverityMedium, Line: line, Message: m[3]})
+	}
+	return findings
+}
+
+// parseStaticcheckOutput reads staticcheck's JSON lines
+func parseStaticcheckOutput(output string) ([]Finding, error) {
+	var findings []Finding
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var diag struct {
+			Code     string `json:"code"`
+			Severity string `json:"severity"`
+			Location struct {
+				Line int `json:"line"`
+			} `json:"location"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(line), &diag); err != nil {
+			return nil, fmt.Errorf("failed to parse staticcheck output: %w", err)
+		}
+		severity := SeverityLow
+		if diag.Severity == "error" {
+			severity = SeverityMedium
+		}
+		findings = append(findings, Finding{
+			Tool: LintStaticcheck, Rule: diag.Code, Severity: severity, Line: diag.Location.Line, Message: diag.Message,
+		})
+	}
+	return findings, nil
+}
+
+// parseGosecOutput reads gosec's JSON report
+func parseGosecOutput(output []byte) ([]Finding, error) {
+	start := strings.IndexByte(string(output), '{')
+	if start == -1 {
+		return nil, nil
+	}
+	var report struct {
+		Issues []struct {
+			Severity string `json:"severity"`
+			RuleID   string `json:"rule_id"`
+			Details  string `json:"details"`
+			Line     string `json:"line"`
+		} `json:"Issues"`
+	}
+	if err := json.Unmarshal(output[start:], &report); err != nil {
+		return nil, fmt.Errorf("failed to parse gosec output: %w", err)
+	}
+
+	findings := make([]Finding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		severity := Severity(strings.ToLower(issue.Severity))
+		if !slices.Contains([]Severity{SeverityLow, SeverityMedium, SeverityHigh}, severity) {
+			severity = SeverityMedium
+		}
+		// Multi-line issues report a range such as "12-14"
+		first, _, _ := strings.Cut(issue.Line, "-")
+		line, _ := strconv.Atoi(first)
+		findings = append(findings, Finding{
+			Tool: LintGosec, Rule: issue.RuleID, Severity: severity, Line: line, Message: issue.Details,
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	glensparser "glens/tools/glens/internal/parser"
+)
+
+const execTest = `package api_test
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestGetUsers(t *testing.T) {
+	_ = exec.Command("rm", "-rf", "/")
+}
+`
+
+func TestParseSeverity(t *testing.T) {
+	sev, err := ParseSeverity("")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityHigh, sev)
+
+	sev, err = ParseSeverity("Medium")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityMedium, sev)
+
+	_, err = ParseSeverity("critical")
+	assert.Error(t, err)
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	assert.True(t, SeverityHigh.AtLeast(SeverityMedium))
+	assert.True(t, SeverityMedium.AtLeast(SeverityMedium))
+	assert.False(t, SeverityLow.AtLeast(SeverityMedium))
+	assert.False(t, SeverityHigh.AtLeast(SeverityNone), "nothing reaches none")
+}
+
+func TestTestGenerator_Lint(t *testing.T) {
+	endpoint := &glensparser.Endpoint{Method: "GET", Path: "/users"}
+	g := NewTestGenerator("testify")
+
+	findings, blocked, err := g.Lint(context.Background(), execTest, endpoint)
+	require.NoError(t, err)
+	assert.Empty(t, findings, "disabled gate reports nothing")
+	assert.False(t, blocked)
+
+	// Tools that are not installed are skipped; the import check still runs
+	g.SetLint(&LintOptions{Tools: []string{"not-installed"}, FailOn: SeverityHigh})
+	findings, blocked, err = g.Lint(context.Background(), execTest, endpoint)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, Finding{
+		Tool:     lintImports,
+		Rule:     "dangerous-import",
+		Severity: SeverityHigh,
+		Line:     4,
+		Message:  "imports os/exec, which runs external commands",
+	}, findings[0])
+	assert.True(t, blocked)
+
+	g.SetLint(&LintOptions{Tools: []string{"not-installed"}, FailOn: SeverityNone})
+	_, blocked, err = g.Lint(context.Background(), execTest, endpoint)
+	require.NoError(t, err)
+	assert.False(t, blocked, "fail-on none only records findings")
+}
+
+func TestLintPenalty(t *testing.T) {
+	assert.Zero(t, LintPenalty(nil))
+	assert.Equal(t, 27.0, LintPenalty([]Finding{
+		{Severity: SeverityLow}, {Severity: SeverityMedium}, {Severity: SeverityHigh},
+	}))
+}
+
+func TestParseVetOutput(t *testing.T) {
+	output := "# example.com/api\n" +
+		"vet: ./get_users_test.go:12:3: unreachable code\n" +
+		"./helpers_test.go:4:1: other file\n"
+
+	findings := parseVetOutput(output, "get_users_test.go")
+	assert.Equal(t, []Finding{
+		{Tool: LintVet, Rule: "vet", Severity: SeverityMedium, Line: 12, Message: "unreachable code"},
+	}, findings)
+}
+
+func TestParseStaticcheckOutput(t *testing.T) {
+	output := `{"code":"SA5011","severity":"error","location":{"file":"x_test.go","line":9,"column":2},"message":"possible nil pointer dereference"}
+{"code":"ST1003","severity":"warning","location":{"file":"x_test.go","line":3,"column":6},"message":"should not use underscores"}
+`
+	findings, err := parseStaticcheckOutput(output)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Tool: LintStaticcheck, Rule: "SA5011", Severity: SeverityMedium, Line: 9, Message: "possible nil pointer dereference"},
+		{Tool: LintStaticcheck, Rule: "ST1003", Severity: SeverityLow, Line: 3, Message: "should not use underscores"},
+	}, findings)
+
+	_, err = parseStaticcheckOutput("{not json")
+	assert.Error(t, err)
+}
+
+func TestParseGosecOutput(t *testing.T) {
+	output := []byte(`[gosec] 2024/01/01 scanning
+{"Issues":[{"severity":"HIGH","rule_id":"G204","details":"Subprocess launched with variable","line":"14-16"}]}`)
+
+	findings, err := parseGosecOutput(output)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Tool: LintGosec, Rule: "G204", Severity: SeverityHigh, Line: 14, Message: "Subprocess launched with variable"},
+	}, findings)
+
+	findings, err = parseGosecOutput(nil)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
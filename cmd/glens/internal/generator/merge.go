@@ -0,0 +1,293 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TestSource is generated test code attributed to the model that wrote it
+type TestSource struct {
+	Model string
+	Code  string
+}
+
+// MergedTest is a suite combining the unique test functions of several
+// sources
+type MergedTest struct {
+	Code string
+	// Contributions lists the test functions taken from each model
+	Contributions map[string][]string
+	// Scenarios are the cases kept by DedupeTests, with the models whose
+	// equivalent cases were dropped; MergeTests leaves them empty
+	Scenarios []Scenario
+}
+
+// majorVersion matches the /v2 suffix of module paths
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// MergeTests combines sources into one test file. Test functions are kept
+// once per distinct body; a name clash with a different body keeps both,
+// suffixing the later one with its model. Helpers and other declarations
+// are taken from the first source declaring them, so sources should be
+// ordered best first. Imports no kept declaration uses are dropped.
+func MergeTests(sources []TestSource) (*MergedTest, error) {
+	merged := &MergedTest{Contributions: make(map[string][]string)}
+	var (
+		pkgName  string
+		imports  = make(map[string]*ast.ImportSpec)
+		declared = make(map[string]bool)
+		bodies   = make(map[string]bool)
+		decls    []string
+	)
+
+	for _, source := range sources {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", source.Code, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse test code of %s: %w", source.Model, err)
+		}
+		if pkgName == "" {
+			pkgName = file.Name.Name
+		}
+		for _, spec := range file.Imports {
+			key := importKey(spec)
+			if _, ok := imports[key]; !ok {
+				imports[key] = spec
+			}
+		}
+
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+
+			fn, isFunc := decl.(*ast.FuncDecl)
+			if isFunc && isTestFunc(fn) {
+				body, err := printNode(fset, fn.Body)
+				if err != nil {
+					return nil, err
+				}
+				if bodies[body] {
+					continue
+				}
+				bodies[body] = true
+				if declared[fn.Name.Name] {
+					fn.Name.Name += "_" + identSuffix(source.Model)
+				}
+				declared[fn.Name.Name] = true
+				merged.Contributions[source.Model] = append(merged.Contributions[source.Model], fn.Name.Name)
+			} else {
+				names := declNames(decl)
+				if anyDeclared(declared, names) {
+					continue
+				}
+				for _, name := range names {
+					declared[name] = true
+				}
+			}
+
+			code, err := printNode(fset, &printer.CommentedNode{Node: decl, Comments: file.Comments})
+			if err != nil {
+				return nil, err
+			}
+			decls = append(decls, code)
+		}
+	}
+	if pkgName == "" {
+		return nil, fmt.Errorf("no test code to merge")
+	}
+
+	code, err := assembleFile(pkgName, imports, strings.Join(decls, "\n\n"))
+	if err != nil {
+		return nil, err
+	}
+	merged.Code = code
+	return merged, nil
+}
+
+// assembleFile formats a file of package pkgName holding the declarations
+// in body and those of imports they use
+func assembleFile(pkgName string, imports map[string]*ast.ImportSpec, body string) (string, error) {
+	used, err := usedImports(imports, fmt.Sprintf("package %s\n\n%s\n", pkgName, body))
+	if err != nil {
+		return "", err
+	}
+	var src strings.Builder
+	fmt.Fprintf(&src, "package %s\n\n", pkgName)
+	if len(used) > 0 {
+		src.WriteString("import (\n")
+		for _, spec := range used {
+			src.WriteString("\t" + spec + "\n")
+		}
+		src.WriteString(")\n\n")
+	}
+	src.WriteString(body)
+	src.WriteString("\n")
+
+	formatted, err := format.Source([]byte(src.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format test code: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// TestFunctions lists the test functions declared in code; code that does
+// not parse has none
+func TestFunctions(code string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && isTestFunc(fn) {
+			names = append(names, fn.Name.Name)
+		}
+	}
+	return names
+}
+
+func isTestFunc(fn *ast.FuncDecl) bool {
+	return fn.Recv == nil && strings.HasPrefix(fn.Name.Name, "Test") && fn.Name.Name != "TestMain"
+}
+
+// declNames lists the top-level names a declaration introduces
+func declNames(decl ast.Decl) []string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return []string{receiverType(d.Recv.List[0].Type) + "." + d.Name.Name}
+		}
+		return []string{d.Name.Name}
+	case *ast.GenDecl:
+		var names []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					if name.Name != "_" {
+						names = append(names, name.Name)
+					}
+				}
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// receiverType names the receiver type of a method
+func receiverType(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(e.X)
+	case *ast.IndexExpr:
+		return receiverType(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+func anyDeclared(declared map[string]bool, names []string) bool {
+	for _, name := range names {
+		if declared[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func printNode(fset *token.FileSet, node any) (string, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return "", fmt.Errorf("failed to print test code: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// identSuffix turns a model name such as "ollama:mistral" into an
+// identifier suffix
+func identSuffix(model string) string {
+	var b strings.Builder
+	for _, r := range model {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func importKey(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name + " " + spec.Path.Value
+	}
+	return spec.Path.Value
+}
+
+// usedImports returns the import lines the declarations in src refer to,
+// sorted by path. Blank and dot imports are always kept.
+func usedImports(imports map[string]*ast.ImportSpec, src string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse merged test: %w", err)
+	}
+	qualifiers := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				qualifiers[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	var specs []*ast.ImportSpec
+	for _, spec := range imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." || qualifiers[name] {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Path.Value < specs[j].Path.Value
+	})
+	used := make([]string, len(specs))
+	for i, spec := range specs {
+		used[i] = importKey(spec)
+	}
+	return used, nil
+}
+
+// importName guesses the package name of an import path: its last element
+// without a major version, "go-" prefix or ".vN" suffix
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersion.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return strings.ReplaceAll(name, "-", "")
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gptTest = `package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const baseURL = "http://localhost:8080"
+
+// TestGetUsers checks the happy path
+func TestGetUsers(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGetUsersNotFound(t *testing.T) {
+	resp, _ := http.Get(baseURL + "/users/missing")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+`
+
+const claudeTest = `package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const baseURL = "http://127.0.0.1:9999"
+
+func TestGetUsers(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users")
+	require.NoError(t, err)
+	var users []map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&users))
+}
+
+func TestGetUsersNotFound(t *testing.T) {
+	resp, _ := http.Get(baseURL + "/users/missing")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+`
+
+func TestMergeTests(t *testing.T) {
+	merged, err := MergeTests([]TestSource{
+		{Model: "gpt4", Code: gptTest},
+		{Model: "ollama:mistral", Code: claudeTest},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"gpt4":           {"TestGetUsers", "TestGetUsersNotFound"},
+		"ollama:mistral": {"TestGetUsers_ollama_mistral"},
+	}, merged.Contributions, "identical bodies are kept once, clashing names are suffixed")
+
+	assert.Contains(t, merged.Code, "// TestGetUsers checks the happy path")
+	assert.Contains(t, merged.Code, `const baseURL = "http://localhost:8080"`, "first declaration wins")
+	assert.NotContains(t, merged.Code, "127.0.0.1")
+	assert.Contains(t, merged.Code, `"encoding/json"`)
+	assert.Contains(t, merged.Code, `"github.com/stretchr/testify/require"`)
+	assert.Equal(t, []string{"TestGetUsers", "TestGetUsersNotFound", "TestGetUsers_ollama_mistral"}, TestFunctions(merged.Code))
+}
+
+func TestMergeTests_DropsUnusedImports(t *testing.T) {
+	merged, err := MergeTests([]TestSource{
+		{Model: "gpt4", Code: gptTest},
+		{Model: "claude", Code: gptTest},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{"gpt4": {"TestGetUsers", "TestGetUsersNotFound"}}, merged.Contributions)
+	assert.NotContains(t, merged.Code, "require")
+}
+
+func TestMergeTests_Errors(t *testing.T) {
+	_, err := MergeTests(nil)
+	assert.Error(t, err)
+
+	_, err = MergeTests([]TestSource{{Model: "gpt4", Code: "not go"}})
+	assert.Error(t, err)
+}
+
+func TestImportName(t *testing.T) {
+	assert.Equal(t, "assert", importName("github.com/stretchr/testify/assert"))
+	assert.Equal(t, "resty", importName("github.com/go-resty/resty/v2"))
+	assert.Equal(t, "yaml", importName("gopkg.in/yaml.v3"))
+}
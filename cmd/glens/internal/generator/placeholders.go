@@ -0,0 +1,498 @@
+package generator
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/environment"
+	glensparser "glens/tools/glens/internal/parser"
+)
+
+// Placeholder variables that Parameterize declares in test code, reading
+// the target from the process environment as the suite helpers do
+const (
+	// BaseURLVar holds the base URL of the API under test: GLENS_BASE_URL,
+	// falling back to the host the model wrote
+	BaseURLVar = "glensBaseURL"
+	// TokenVar holds the bearer token or API key of the API under test:
+	// GLENS_TOKEN
+	TokenVar = "glensToken"
+)
+
+var (
+	// loopbackPattern matches the scheme and loopback host models default to
+	loopbackPattern = regexp.MustCompile(`^https?://(localhost|127\.0\.0\.1|0\.0\.0\.0|\[::1\])(:\d+)?`)
+	// requiredEnvPattern matches the GLENS_* variables test code reads
+	requiredEnvPattern = regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\("(GLENS_[A-Z0-9_]+)"\)`)
+	// stubUndefinedPattern matches type errors caused by the empty stand-ins
+	// of imported packages rather than by the test code
+	stubUndefinedPattern = regexp.MustCompile(`undefined: \w+\.\w+`)
+)
+
+// invalidCredentialWords mark credentials a test sends on purpose to check
+// that they are rejected; they are kept
+var invalidCredentialWords = []string{
+	"invalid", "wrong", "expired", "bad", "fake", "malformed", "bogus",
+	"revoked", "incorrect", "unauthorized", "forged", "tampered",
+}
+
+// Parameterize rewrites the hardcoded hosts and credentials of testCode to
+// the placeholder convention, so that a test runs against any environment
+// without edits and never carries a secret:
+//
+//   - URLs on a loopback host, DefaultBaseURL, the base URL of env or one of
+//     servers become BaseURLVar plus the rest of the URL
+//   - credentials set in auth headers (Authorization, X-API-Key, tokens)
+//     and the token of env become TokenVar, keeping a "Bearer " scheme
+//
+// Empty credentials and those marked as invalid, which tests send to get a
+// 401, are kept. The variables are declared when first needed. Code that
+// does not parse, or that the rewrite would break, is returned unchanged.
+func Parameterize(testCode string, env *environment.Environment, servers []glensparser.Server) string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", testCode, parser.ParseComments)
+	if err != nil {
+		return testCode
+	}
+
+	p := &parameterizer{fset: fset, file: file, code: testCode, bases: hostBases(env, servers)}
+	if env != nil {
+		p.token = env.Auth.Token
+		p.authHeader = env.AuthHeader()
+	}
+	p.collect()
+	if len(p.edits) == 0 {
+		return testCode
+	}
+
+	rewritten, err := p.apply()
+	if err != nil || typeErrors(rewritten) > typeErrors(testCode) {
+		log.Debug().Err(err).Msg("Keeping hardcoded hosts and credentials of test")
+		return testCode
+	}
+	return rewritten
+}
+
+// RequiredEnv returns the GLENS_* environment variables testCode reads,
+// sorted and without duplicates
+func RequiredEnv(testCode string) []string {
+	var names []string
+	for _, match := range requiredEnvPattern.FindAllStringSubmatch(testCode, -1) {
+		names = append(names, match[1])
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// hostBases returns the URL prefixes rewritten to BaseURLVar, longest first
+func hostBases(env *environment.Environment, servers []glensparser.Server) []string {
+	bases := []string{environment.DefaultBaseURL}
+	if env != nil && env.BaseURL != "" {
+		bases = append(bases, env.BaseURL)
+	}
+	for _, server := range servers {
+		resolved, err := server.ResolveURL(nil)
+		if err != nil || !strings.Contains(resolved, "://") {
+			continue
+		}
+		bases = append(bases, strings.TrimSuffix(resolved, "/"))
+	}
+	slices.SortFunc(bases, func(a, b string) int { return len(b) - len(a) })
+	return slices.Compact(bases)
+}
+
+// edit replaces the source between pos and end
+type edit struct {
+	pos, end token.Pos
+	text     string
+}
+
+// parameterizer collects the edits of one test file
+type parameterizer struct {
+	fset       *token.FileSet
+	file       *ast.File
+	code       string
+	bases      []string
+	token      string
+	authHeader string
+
+	edits []edit
+	// fallback is the base URL of the first rewritten host
+	fallback string
+	// usesToken is set when a credential was rewritten
+	usesToken bool
+	// consts are the const declarations holding rewritten literals, which
+	// become var declarations
+	consts map[*ast.GenDecl]bool
+	// done marks literals already rewritten
+	done map[*ast.BasicLit]bool
+	// imports are the imports the added declarations use
+	imports []string
+}
+
+// collect finds the hosts and credentials to rewrite
+func (p *parameterizer) collect() {
+	p.consts = make(map[*ast.GenDecl]bool)
+	p.done = make(map[*ast.BasicLit]bool)
+	declared := p.declared()
+
+	ast.Inspect(p.file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec, *ast.Field:
+			return false
+		case *ast.ValueSpec:
+			// The placeholders' own declarations hold the fallback
+			if slices.ContainsFunc(n.Names, func(name *ast.Ident) bool { return name.Name == BaseURLVar || name.Name == TokenVar }) {
+				return false
+			}
+		case *ast.CallExpr:
+			if isHeaderSet(n) && len(n.Args) == 2 {
+				p.credential(n.Args[0], n.Args[1], true)
+			}
+		case *ast.CompositeLit:
+			// Only http.Header literals name headers by their generic
+			// words; in other maps "token" may well be a payload field
+			header := isHTTPHeader(n.Type)
+			for _, elt := range n.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				value := kv.Value
+				if list, ok := value.(*ast.CompositeLit); ok && len(list.Elts) == 1 {
+					value = list.Elts[0] // http.Header{"Authorization": {"Bearer x"}}
+				}
+				p.credential(kv.Key, value, header)
+			}
+		case *ast.BasicLit:
+			p.literal(n)
+		}
+		return true
+	})
+
+	// Enumerations cannot become var declarations; such files keep their
+	// hosts and credentials
+	for decl := range p.consts {
+		if usesIota(decl) {
+			p.edits = nil
+			return
+		}
+		p.edits = append(p.edits, edit{pos: decl.TokPos, end: decl.TokPos + token.Pos(len("const")), text: "var"})
+	}
+
+	if p.fallback != "" && !declared[BaseURLVar] {
+		p.declare("// " + BaseURLVar + " is the base URL of the API under test, from " + environment.EnvBaseURL + "\n" +
+			"var " + BaseURLVar + " = cmp.Or(os.Getenv(" + strconv.Quote(environment.EnvBaseURL) + "), " + strconv.Quote(p.fallback) + ")")
+		p.imports = append(p.imports, "cmp", "os")
+	}
+	if p.usesToken && !declared[TokenVar] {
+		p.declare("// " + TokenVar + " is the bearer token or API key of the API under test, from " + environment.EnvToken + "\n" +
+			"var " + TokenVar + " = os.Getenv(" + strconv.Quote(environment.EnvToken) + ")")
+		p.imports = append(p.imports, "os")
+	}
+}
+
+// declared returns the top-level names of the file
+func (p *parameterizer) declared() map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range p.file.Decls {
+		for _, ident := range topLevelIdents(decl) {
+			names[ident.Name] = true
+		}
+	}
+	return names
+}
+
+// literal rewrites a string literal holding a hardcoded host or the token
+// of the environment
+func (p *parameterizer) literal(lit *ast.BasicLit) {
+	if lit.Kind != token.STRING || p.done[lit] {
+		return
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	if p.token != "" && (value == p.token || strings.HasSuffix(value, " "+p.token)) {
+		p.credentialLiteral(lit, value)
+		return
+	}
+
+	base := p.base(value)
+	if base == "" {
+		return
+	}
+	if p.fallback == "" {
+		p.fallback = base
+	}
+	text := BaseURLVar
+	if rest := value[len(base):]; rest != "" {
+		text += " + " + strconv.Quote(rest)
+	}
+	p.replace(lit, text)
+}
+
+// base returns the hardcoded base URL value starts with, if any
+func (p *parameterizer) base(value string) string {
+	for _, base := range p.bases {
+		if isURLPrefix(value, base) {
+			return base
+		}
+	}
+	if base := loopbackPattern.FindString(value); base != "" && isURLPrefix(value, base) {
+		return base
+	}
+	return ""
+}
+
+// isURLPrefix reports whether base is value or the start of its path or query
+func isURLPrefix(value, base string) bool {
+	rest, ok := strings.CutPrefix(value, base)
+	return ok && (rest == "" || rest[0] == '/' || rest[0] == '?')
+}
+
+// credential rewrites the value of a header when name is an auth header;
+// generic words such as "token" only count when generic is set
+func (p *parameterizer) credential(name, value ast.Expr, generic bool) {
+	nameLit, ok := name.(*ast.BasicLit)
+	if !ok || nameLit.Kind != token.STRING {
+		return
+	}
+	header, err := strconv.Unquote(nameLit.Value)
+	if err != nil || !p.isAuthHeader(header, generic) {
+		return
+	}
+	for _, lit := range p.stringLiterals(value) {
+		if v, err := strconv.Unquote(lit.Value); err == nil {
+			p.credentialLiteral(lit, v)
+		}
+	}
+}
+
+// credentialLiteral rewrites lit, a credential with value, to TokenVar
+func (p *parameterizer) credentialLiteral(lit *ast.BasicLit, value string) {
+	if p.done[lit] || strings.TrimSpace(value) == "" {
+		return
+	}
+	lower := strings.ToLower(value)
+	if slices.ContainsFunc(invalidCredentialWords, func(word string) bool { return strings.Contains(lower, word) }) {
+		return
+	}
+	text := TokenVar
+	if scheme, credential, ok := strings.Cut(value, " "); ok {
+		if strings.EqualFold(scheme, "Basic") || strings.TrimSpace(credential) == "" {
+			return
+		}
+		text = strconv.Quote(scheme+" ") + " + " + TokenVar
+	}
+	p.usesToken = true
+	p.replace(lit, text)
+}
+
+// isAuthHeader reports whether header carries credentials
+func (p *parameterizer) isAuthHeader(header string, generic bool) bool {
+	lower := strings.ToLower(header)
+	if lower == "authorization" || (p.authHeader != "" && strings.EqualFold(header, p.authHeader)) {
+		return true
+	}
+	words := []string{"api-key", "apikey", "api_key"}
+	if generic {
+		words = append(words, "token", "secret")
+	}
+	return slices.ContainsFunc(words, func(word string) bool { return strings.Contains(lower, word) })
+}
+
+// stringLiterals returns the string literals expr is built of: itself, the
+// operands of a concatenation, or the value of the variable or constant it
+// names
+func (p *parameterizer) stringLiterals(expr ast.Expr) []*ast.BasicLit {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return []*ast.BasicLit{e}
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return append(p.stringLiterals(e.X), p.stringLiterals(e.Y)...)
+		}
+	case *ast.ParenExpr:
+		return p.stringLiterals(e.X)
+	case *ast.Ident:
+		if e.Obj != nil {
+			if value := declaredValue(e); value != nil {
+				if lit, ok := value.(*ast.BasicLit); ok {
+					return []*ast.BasicLit{lit}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// declaredValue returns the expression ident was declared with, if any
+func declaredValue(ident *ast.Ident) ast.Expr {
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.ValueSpec:
+		if i := slices.IndexFunc(decl.Names, func(name *ast.Ident) bool { return name.Name == ident.Name }); i >= 0 && i < len(decl.Values) {
+			return decl.Values[i]
+		}
+	case *ast.AssignStmt:
+		if i := slices.IndexFunc(decl.Lhs, func(lhs ast.Expr) bool {
+			name, ok := lhs.(*ast.Ident)
+			return ok && name.Name == ident.Name
+		}); i >= 0 && len(decl.Lhs) == len(decl.Rhs) {
+			return decl.Rhs[i]
+		}
+	}
+	return nil
+}
+
+// replace records the rewrite of lit to text. A literal of a const
+// declaration turns the declaration into a var declaration.
+func (p *parameterizer) replace(lit *ast.BasicLit, text string) {
+	p.done[lit] = true
+	p.edits = append(p.edits, edit{pos: lit.Pos(), end: lit.End(), text: text})
+	if decl := p.constOf(lit); decl != nil {
+		p.consts[decl] = true
+	}
+}
+
+// constOf returns the const declaration holding lit, if any
+func (p *parameterizer) constOf(lit *ast.BasicLit) *ast.GenDecl {
+	var found *ast.GenDecl
+	ast.Inspect(p.file, func(n ast.Node) bool {
+		if n == nil || found != nil || n.End() < lit.Pos() || lit.End() < n.Pos() {
+			return false
+		}
+		if gen, ok := n.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+			found = gen
+		}
+		return true
+	})
+	return found
+}
+
+// declare adds a top-level declaration after the imports
+func (p *parameterizer) declare(decl string) {
+	pos := p.file.Name.End()
+	for _, d := range p.file.Decls {
+		if gen, ok := d.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			pos = gen.End()
+		}
+	}
+	p.edits = append(p.edits, edit{pos: pos, end: pos, text: "\n\n" + decl})
+}
+
+// apply applies the edits, adds the imports the placeholders use and
+// formats the result
+func (p *parameterizer) apply() (string, error) {
+	// Edits apply from the end; insertions at the same place keep their order
+	slices.Reverse(p.edits)
+	slices.SortStableFunc(p.edits, func(a, b edit) int { return int(b.pos - a.pos) })
+	code := p.code
+	base := p.fset.File(p.file.Pos()).Base()
+	for _, e := range p.edits {
+		start, end := int(e.pos)-base, int(e.end)-base
+		code = code[:start] + e.text + code[end:]
+	}
+
+	var missing []string
+	for _, path := range slices.Compact(p.imports) {
+		if !slices.ContainsFunc(p.file.Imports, func(spec *ast.ImportSpec) bool { return spec.Path.Value == strconv.Quote(path) }) {
+			missing = append(missing, strconv.Quote(path))
+		}
+	}
+	if len(missing) > 0 {
+		code = addImportLines(code, p.file, p.fset, missing)
+	}
+
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// addImportLines adds the quoted import paths to the first import block of
+// file, whose source is code, or as a new block after the package clause
+func addImportLines(code string, file *ast.File, fset *token.FileSet, paths []string) string {
+	lines := "\n\t" + strings.Join(paths, "\n\t")
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+			// Imports precede every edit, so their offsets still hold
+			offset := fset.Position(gen.Lparen).Offset + 1
+			return code[:offset] + lines + code[offset:]
+		}
+	}
+	offset := fset.Position(file.Name.End()).Offset
+	return code[:offset] + "\n\nimport (" + lines + "\n)" + code[offset:]
+}
+
+// isHeaderSet reports whether call may set a header or query parameter:
+// a Set or Add method, such as req.Header.Set
+func isHeaderSet(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && (sel.Sel.Name == "Set" || sel.Sel.Name == "Add")
+}
+
+// isHTTPHeader reports whether typ is http.Header
+func isHTTPHeader(typ ast.Expr) bool {
+	sel, ok := typ.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "http" && sel.Sel.Name == "Header"
+}
+
+// usesIota reports whether a const declaration enumerates with iota or
+// repeats its values implicitly
+func usesIota(decl *ast.GenDecl) bool {
+	for _, spec := range decl.Specs {
+		value, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if len(value.Values) == 0 {
+			return true
+		}
+		iota := false
+		for _, v := range value.Values {
+			ast.Inspect(v, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && ident.Name == "iota" {
+					iota = true
+				}
+				return !iota
+			})
+		}
+		if iota {
+			return true
+		}
+	}
+	return false
+}
+
+// typeErrors counts the type errors of code on its own, ignoring those of
+// the empty stand-ins of its imports
+func typeErrors(code string) int {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, 0)
+	if err != nil {
+		return 1 << 20
+	}
+	count := 0
+	conf := types.Config{Importer: stubImporter{}, Error: func(err error) {
+		if !stubUndefinedPattern.MatchString(err.Error()) {
+			count++
+		}
+	}}
+	_, _ = conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	return count
+}
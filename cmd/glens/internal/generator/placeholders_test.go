@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"glens/tools/glens/internal/environment"
+	glensparser "glens/tools/glens/internal/parser"
+)
+
+const hardcodedTest = `package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const apiKey = "sk-live-1234"
+
+func TestGetPet(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.petstore.io/v1/pets/1?verbose=1", nil)
+	req.Header.Set("Authorization", "Bearer abc.def.ghi")
+	req.Header.Set("X-API-Key", apiKey)
+	req.Header.Set("Idempotency-Key", "k-1")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	bad, _ := http.NewRequest("GET", "http://localhost:9090/pets/1", nil)
+	bad.Header = http.Header{"Authorization": {"Bearer invalid-token"}}
+	body := map[string]string{"token": "payload-value", "callback": "https://hooks.example.com/x"}
+	_, _ = bad, body
+}
+`
+
+func TestParameterize(t *testing.T) {
+	servers := []glensparser.Server{{URL: "https://api.petstore.io/{version}", Variables: map[string]glensparser.ServerVariable{"version": {Default: "v1"}}}}
+	code := Parameterize(hardcodedTest, nil, servers)
+
+	assert.Contains(t, code, "import (\n\t\"cmp\"\n\t\"net/http\"\n\t\"os\"\n\t\"testing\"\n")
+	assert.Contains(t, code, "// glensBaseURL is the base URL of the API under test, from GLENS_BASE_URL\n"+
+		`var glensBaseURL = cmp.Or(os.Getenv("GLENS_BASE_URL"), "https://api.petstore.io/v1")`)
+	assert.Contains(t, code, `var glensToken = os.Getenv("GLENS_TOKEN")`)
+	assert.Contains(t, code, `http.NewRequest("GET", glensBaseURL+"/pets/1?verbose=1", nil)`)
+	assert.Contains(t, code, `http.NewRequest("GET", glensBaseURL+"/pets/1", nil)`, "loopback hosts are rewritten")
+	assert.Contains(t, code, `req.Header.Set("Authorization", "Bearer "+glensToken)`)
+	assert.Contains(t, code, "var apiKey = glensToken", "credentials of constants are found through their uses")
+	assert.Contains(t, code, `"Idempotency-Key", "k-1"`)
+	assert.Contains(t, code, `{"Bearer invalid-token"}`, "invalid credentials are kept")
+	assert.Contains(t, code, `"token": "payload-value"`, "payload fields are kept")
+	assert.Contains(t, code, `"https://hooks.example.com/x"`, "other hosts are kept")
+	assert.Equal(t, []string{"GLENS_BASE_URL", "GLENS_TOKEN"}, RequiredEnv(code))
+
+	assert.Equal(t, code, Parameterize(code, nil, servers), "rewriting is idempotent")
+}
+
+func TestParameterize_Environment(t *testing.T) {
+	env := &environment.Environment{BaseURL: "https://staging.example.com", Auth: environment.Auth{Type: environment.AuthAPIKey, Header: "X-Tenant-Auth", Token: "s3cr3t-t0ken"}}
+	code := Parameterize(`package api_test
+
+import "testing"
+
+func TestPing(t *testing.T) {
+	const url = "https://staging.example.com"
+	headers := map[string]string{"X-Tenant-Auth": "s3cr3t-t0ken"}
+	t.Log(url, headers, "s3cr3t-t0ken")
+}
+`, env, nil)
+	assert.Contains(t, code, "import (\n\t\"cmp\"\n\t\"os\"\n)\n\nimport \"testing\"")
+	assert.Contains(t, code, "\tvar url = glensBaseURL\n")
+	assert.Contains(t, code, `{"X-Tenant-Auth": glensToken}`)
+	assert.Contains(t, code, "t.Log(url, headers, glensToken)", "the token of the environment is never kept")
+	assert.NotContains(t, code, "s3cr3t-t0ken")
+}
+
+func TestParameterize_KeepsCode(t *testing.T) {
+	enumerated := `package api_test
+
+const (
+	base = "http://localhost:8080"
+	other = iota
+)
+`
+	assert.Equal(t, enumerated, Parameterize(enumerated, nil, nil), "enumerations cannot become variables")
+	assert.Equal(t, "not go", Parameterize("not go", nil, nil))
+
+	clean := "package api_test\n\nfunc helper() string { return \"https://example.com\" }\n"
+	assert.Equal(t, clean, Parameterize(clean, nil, nil))
+}
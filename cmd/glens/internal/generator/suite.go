@@ -0,0 +1,542 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/environment"
+	glensparser "glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+)
+
+// Suite layout: tests live in <SuiteTestsDir>/<tag>/, one package per tag,
+// next to the go.mod, the helpers package and the index
+const (
+	SuiteTestsDir   = "tests"
+	SuiteHelpersDir = "helpers"
+	SuiteIndexFile  = "index.json"
+	// DefaultSuiteModule is the module path of a suite's generated go.mod
+	DefaultSuiteModule = "generatedtests"
+	// untaggedPackage holds the tests of endpoints without tags
+	untaggedPackage = "untagged"
+)
+
+// Keep markers enclose hand-written top-level declarations in a suite file
+// that survive regeneration; a kept declaration replaces a generated one
+// of the same name
+const (
+	KeepBegin = "// glens:keep-begin"
+	KeepEnd   = "// glens:keep-end"
+)
+
+// SuiteIndex lists the files of a suite written by WriteSuite
+type SuiteIndex struct {
+	Name        string       `json:"name"`
+	Module      string       `json:"module"`
+	Framework   string       `json:"framework"`
+	GeneratedAt time.Time    `json:"generated_at"`
+	TotalTests  int          `json:"total_tests"`
+	Files       []SuiteEntry `json:"files"`
+}
+
+// SuiteEntry describes one test file of a suite
+type SuiteEntry struct {
+	Path        string `json:"path"`
+	Endpoint    string `json:"endpoint"`
+	EndpointID  string `json:"endpoint_id"`
+	OperationID string `json:"operation_id,omitempty"`
+	// Fingerprint is the endpoint's parser.Endpoint.Fingerprint when the
+	// file was generated
+	Fingerprint string            `json:"fingerprint"`
+	Package     string            `json:"package"`
+	Tests       []string          `json:"tests"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// SuitePath is where the test model generated for endpoint lives in a
+// suite: tests/<tag>/<operationId>_<model>_test.go. Endpoints without an
+// operation ID are named by method and path.
+func SuitePath(endpoint *glensparser.Endpoint, model string) string {
+	name := identSuffix(endpoint.OperationID)
+	if name == "" {
+		name = strings.TrimSuffix((&TestGenerator{}).generateTestFileName(endpoint), "_test.go")
+	}
+	return path.Join(SuiteTestsDir, suitePackage(endpoint), name+"_"+identSuffix(model)+"_test.go")
+}
+
+// suitePackage names the package of an endpoint's tests after its first tag
+func suitePackage(endpoint *glensparser.Endpoint) string {
+	if len(endpoint.Tags) == 0 {
+		return untaggedPackage
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(endpoint.Tags[0]) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	switch {
+	case name == "":
+		return untaggedPackage
+	case name[0] >= '0' && name[0] <= '9':
+		return "tag_" + name
+	}
+	return name
+}
+
+// WriteSuite writes the files of suite, placed by their Path (see
+// SuitePath), into dir as a Go module that teams can commit and maintain.
+// Each file's package clause is set to its directory's package and
+// top-level names clashing with another file of the package are renamed.
+// Keep regions of existing files are carried over. The go.mod and the
+// helpers package are only written when missing so they can be edited;
+// test files, the index and the schemas of the glens/assertspec module
+// are rewritten on every run. Test code that does not parse is skipped.
+func WriteSuite(dir, module string, suite *TestSuite) (*SuiteIndex, error) {
+	if module == "" {
+		module = DefaultSuiteModule
+	}
+	index := &SuiteIndex{
+		Name:        suite.Name,
+		Module:      module,
+		Framework:   suite.Framework,
+		GeneratedAt: suite.GeneratedAt,
+	}
+
+	// Clashes are resolved in path order so reruns rename the same names
+	files := slices.Clone(suite.Files)
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	declared := make(map[string]map[string]bool) // package dir -> names
+	for i := range files {
+		file := &files[i]
+		pkgDir := path.Dir(file.Path)
+		if declared[pkgDir] == nil {
+			declared[pkgDir] = make(map[string]bool)
+		}
+
+		entry := SuiteEntry{
+			Path:        file.Path,
+			Endpoint:    file.Endpoint.Method + " " + file.Endpoint.Path,
+			EndpointID:  file.Endpoint.ID,
+			OperationID: file.Endpoint.OperationID,
+			Fingerprint: file.Endpoint.Fingerprint(),
+			Package:     path.Base(pkgDir),
+			Metadata:    file.Metadata,
+		}
+		if err := writeTestFile(dir, &entry, file.Content, declared[pkgDir]); err != nil {
+			log.Warn().
+				Err(err).
+				Str("file", file.Path).
+				Msg("Skipping generated test")
+			continue
+		}
+		index.TotalTests += len(entry.Tests)
+		index.Files = append(index.Files, entry)
+	}
+
+	if err := writeSuiteFile(dir, "go.mod", goModFile(module, suite.Framework), false); err != nil {
+		return nil, err
+	}
+	if err := writeSuiteFile(dir, path.Join(SuiteHelpersDir, "helpers.go"), helpersFile(suite.BaseURL), false); err != nil {
+		return nil, err
+	}
+	endpoints := make([]*glensparser.Endpoint, len(files))
+	for i := range files {
+		endpoints[i] = &files[i].Endpoint
+	}
+	if err := updateSuiteAssertspec(dir, ResponseSchemas(endpoints...)); err != nil {
+		return nil, err
+	}
+	if err := WriteSuiteIndex(dir, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// ReadSuiteIndex reads the index of the suite in dir
+func ReadSuiteIndex(dir string) (*SuiteIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SuiteIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite index: %w", err)
+	}
+	var index SuiteIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse suite index: %w", err)
+	}
+	return &index, nil
+}
+
+// WriteSuiteIndex writes index as the index of the suite in dir
+func WriteSuiteIndex(dir string, index *SuiteIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal suite index: %w", err)
+	}
+	return writeSuiteFile(dir, SuiteIndexFile, string(data)+"\n", true)
+}
+
+// RewriteSuiteFile replaces the file of entry in the suite in dir with
+// testCode generated for endpoint, keeping its keep regions, and updates
+// entry's fingerprint and tests. Names are checked for clashes against the
+// other files of the package.
+func RewriteSuiteFile(dir string, entry *SuiteEntry, endpoint *glensparser.Endpoint, testCode string) error {
+	pkgDir := filepath.Join(dir, filepath.FromSlash(path.Dir(entry.Path)))
+	others, err := filepath.Glob(filepath.Join(pkgDir, "*_test.go"))
+	if err != nil {
+		return fmt.Errorf("failed to list suite files: %w", err)
+	}
+	declared := make(map[string]bool)
+	for _, other := range others {
+		if filepath.Base(other) == path.Base(entry.Path) {
+			continue
+		}
+		content, err := os.ReadFile(other)
+		if err != nil {
+			return fmt.Errorf("failed to read suite file: %w", err)
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), "", content, 0)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			for _, ident := range topLevelIdents(decl) {
+				declared[ident.Name] = true
+			}
+		}
+	}
+
+	if err := writeTestFile(dir, entry, testCode, declared); err != nil {
+		return err
+	}
+	if err := updateSuiteAssertspec(dir, ResponseSchemas(endpoint)); err != nil {
+		return err
+	}
+	entry.Endpoint = endpoint.Method + " " + endpoint.Path
+	entry.EndpointID = endpoint.ID
+	entry.OperationID = endpoint.OperationID
+	entry.Fingerprint = endpoint.Fingerprint()
+	return nil
+}
+
+// writeTestFile writes testCode as the file of entry, carrying over the keep
+// regions of the current file, and records its test functions on entry
+func writeTestFile(dir string, entry *SuiteEntry, testCode string, declared map[string]bool) error {
+	current, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read suite file: %w", err)
+	}
+	suffix := strings.TrimSuffix(path.Base(entry.Path), "_test.go")
+	code, tests, err := suiteFile(testCode, string(current), entry.Package, suffix, declared)
+	if err != nil {
+		return err
+	}
+	if err := writeSuiteFile(dir, entry.Path, code, true); err != nil {
+		return err
+	}
+	entry.Tests = tests
+	return nil
+}
+
+// suiteFile builds a suite file of package pkgName from generated testCode
+// and the keep regions of current, the file's previous content. Generated
+// declarations named like kept ones are dropped; the kept regions follow
+// the generated code along with the imports they use.
+func suiteFile(testCode, current, pkgName, suffix string, declared map[string]bool) (code string, tests []string, err error) {
+	kept := strings.Join(keepRegions(current), "\n\n")
+	keptFile, err := parser.ParseFile(token.NewFileSet(), "", "package "+pkgName+"\n\n"+kept, 0)
+	if err != nil {
+		return "", nil, fmt.Errorf("keep regions do not parse: %w", err)
+	}
+	keptNames := make(map[string]bool)
+	for _, decl := range keptFile.Decls {
+		for _, ident := range topLevelIdents(decl) {
+			keptNames[ident.Name] = true
+			declared[ident.Name] = true
+		}
+	}
+
+	code, tests, err = packageTestFile(testCode, pkgName, suffix, declared, keptNames)
+	if err != nil || kept == "" {
+		return code, tests, err
+	}
+
+	imports := make(map[string]*ast.ImportSpec)
+	if currentFile, err := parser.ParseFile(token.NewFileSet(), "", current, parser.ImportsOnly); err == nil {
+		for _, spec := range currentFile.Imports {
+			imports[importKey(spec)] = spec
+		}
+	}
+	code, err = withImports(code+"\n"+kept+"\n", imports)
+	if err != nil {
+		return "", nil, err
+	}
+	return code, append(tests, TestFunctions("package "+pkgName+"\n\n"+kept)...), nil
+}
+
+// keepRegions returns the regions of src enclosed in keep markers, markers
+// included; an unterminated region runs to the end of src
+func keepRegions(src string) []string {
+	var (
+		regions []string
+		region  []string
+		inside  bool
+	)
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inside && trimmed == KeepBegin {
+			inside = true
+		}
+		if inside {
+			region = append(region, line)
+		}
+		if inside && trimmed == KeepEnd {
+			regions = append(regions, strings.Join(region, "\n"))
+			region, inside = nil, false
+		}
+	}
+	if inside {
+		regions = append(regions, strings.TrimRight(strings.Join(region, "\n"), "\n"))
+	}
+	return regions
+}
+
+// withImports adds the imports of extra that src needs to src and drops the
+// ones it does not
+func withImports(src string, extra map[string]*ast.ImportSpec) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse test code: %w", err)
+	}
+	imports := make(map[string]*ast.ImportSpec)
+	for _, spec := range file.Imports {
+		imports[importKey(spec)] = spec
+	}
+	for key, spec := range extra {
+		if _, ok := imports[key]; !ok {
+			imports[key] = spec
+		}
+	}
+
+	// The body starts after the package clause and import declarations
+	start := file.Name.End()
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			start = gen.End()
+		}
+	}
+	return assembleFile(file.Name.Name, imports, strings.TrimSpace(src[fset.Position(start).Offset:]))
+}
+
+// packageTestFile moves testCode into package pkgName, dropping top-level
+// declarations named in drop and renaming names already declared in the
+// package by suffixing them with suffix, and returns the formatted code and
+// its test functions
+func packageTestFile(testCode, pkgName, suffix string, declared, drop map[string]bool) (code string, tests []string, err error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", testCode, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+	file.Name.Name = pkgName
+	for _, decl := range slices.Clone(file.Decls) {
+		if !slices.ContainsFunc(topLevelIdents(decl), func(ident *ast.Ident) bool { return drop[ident.Name] }) {
+			continue
+		}
+		file.Decls = slices.DeleteFunc(file.Decls, func(d ast.Decl) bool { return d == decl })
+		file.Comments = slices.DeleteFunc(file.Comments, func(c *ast.CommentGroup) bool {
+			return c.Pos() >= declStart(decl) && c.End() <= decl.End()
+		})
+	}
+
+	// Type-check the file on its own, with empty stand-ins for its imports,
+	// to find every use of its top-level declarations
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object), Uses: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{Importer: stubImporter{}, Error: func(error) {}}
+	_, _ = conf.Check(pkgName, fset, []*ast.File{file}, info)
+
+	renames := make(map[types.Object]string)
+	for _, decl := range file.Decls {
+		for _, ident := range topLevelIdents(decl) {
+			name := ident.Name
+			if declared[name] {
+				name = freeName(name, suffix, declared)
+				if obj := info.Defs[ident]; obj != nil {
+					renames[obj] = name
+				}
+				ident.Name = name
+			}
+			declared[name] = true
+		}
+	}
+	for ident, obj := range info.Uses {
+		if name, ok := renames[obj]; ok {
+			ident.Name = name
+		}
+	}
+
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && isTestFunc(fn) {
+			tests = append(tests, fn.Name.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return "", nil, fmt.Errorf("failed to format test code: %w", err)
+	}
+	return buf.String(), tests, nil
+}
+
+// declStart is where decl begins, including its doc comment
+func declStart(decl ast.Decl) token.Pos {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			return d.Doc.Pos()
+		}
+	}
+	return decl.Pos()
+}
+
+// topLevelIdents returns the identifiers a declaration adds to the package
+// scope; methods and init functions add none
+func topLevelIdents(decl ast.Decl) []*ast.Ident {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil || d.Name.Name == "init" {
+			return nil
+		}
+		return []*ast.Ident{d.Name}
+	case *ast.GenDecl:
+		var idents []*ast.Ident
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.ValueSpec:
+				for _, name := range s.Names {
+					if name.Name != "_" {
+						idents = append(idents, name)
+					}
+				}
+			case *ast.TypeSpec:
+				idents = append(idents, s.Name)
+			}
+		}
+		return idents
+	}
+	return nil
+}
+
+// freeName suffixes name until it is not declared. A clashing TestMain
+// becomes an unexported function, as a package can only have one.
+func freeName(name, suffix string, declared map[string]bool) string {
+	if name == "TestMain" {
+		name = "testMain"
+	}
+	candidate := name + "_" + suffix
+	for n := 2; declared[candidate]; n++ {
+		candidate = fmt.Sprintf("%s_%s%d", name, suffix, n)
+	}
+	return candidate
+}
+
+// stubImporter stands in for the imports of generated tests, which need
+// not be installed, with empty packages; only the file's own declarations
+// have to resolve
+type stubImporter struct{}
+
+func (stubImporter) Import(importPath string) (*types.Package, error) {
+	pkg := types.NewPackage(importPath, importName(importPath))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// writeSuiteFile writes content to name under dir, secrets masked; existing
+// files are kept unless overwrite is set
+func writeSuiteFile(dir, name, content string, overwrite bool) error {
+	filePath := filepath.Join(dir, filepath.FromSlash(name))
+	if !overwrite {
+		if _, err := os.Stat(filePath); err == nil {
+			return nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to check suite file: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o750); err != nil {
+		return fmt.Errorf("failed to create suite directory: %w", err)
+	}
+	if err := os.WriteFile(filePath, []byte(redact.String(content)), 0o600); err != nil {
+		return fmt.Errorf("failed to write suite file: %w", err)
+	}
+	return nil
+}
+
+// helpersFile returns the helpers package of a suite, reading the
+// environment glens exports when it runs tests and defaulting to baseURL
+func helpersFile(baseURL string) string {
+	if baseURL == "" {
+		baseURL = environment.DefaultBaseURL
+	}
+	return fmt.Sprintf(`// Package helpers configures the generated tests of this suite from the
+// environment glens exports when it runs them.
+package helpers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultBaseURL is the API under test when %[2]s is not set.
+const DefaultBaseURL = %[1]q
+
+// BaseURL returns the base URL of the API under test.
+func BaseURL() string {
+	if url := os.Getenv(%[2]q); url != "" {
+		return url
+	}
+	return DefaultBaseURL
+}
+
+// Token returns the bearer token or API key of the API under test.
+func Token() string {
+	return os.Getenv(%[3]q)
+}
+
+// Client returns an HTTP client for the API under test, skipping TLS
+// verification when %[4]s is "true".
+func Client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if os.Getenv(%[4]q) == "true" {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // opted in for test environments
+	}
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+`, baseURL, environment.EnvBaseURL, environment.EnvToken, environment.EnvInsecureSkipVerify)
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	glensparser "glens/tools/glens/internal/parser"
+)
+
+func TestSuitePath(t *testing.T) {
+	tests := []struct {
+		endpoint glensparser.Endpoint
+		model    string
+		want     string
+	}{
+		{glensparser.Endpoint{Method: "GET", Path: "/users", OperationID: "listUsers", Tags: []string{"Users"}}, "gpt4", "tests/users/listUsers_gpt4_test.go"},
+		{glensparser.Endpoint{Method: "GET", Path: "/pets/{id}", Tags: []string{"Pet Store"}}, "ollama:mistral", "tests/pet_store/get_pets_id_ollama_mistral_test.go"},
+		{glensparser.Endpoint{Method: "DELETE", Path: "/v1", OperationID: "purge", Tags: []string{"2024"}}, "sonnet4", "tests/tag_2024/purge_sonnet4_test.go"},
+		{glensparser.Endpoint{Method: "GET", Path: "/", OperationID: "root"}, "mock", "tests/untagged/root_mock_test.go"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, SuitePath(&tt.endpoint, tt.model))
+	}
+}
+
+func TestWriteSuite(t *testing.T) {
+	endpoint := glensparser.Endpoint{Method: "GET", Path: "/users", OperationID: "listUsers", Tags: []string{"users"}}
+	suite := &TestSuite{
+		Name:      "Users API",
+		Framework: "testify",
+		BaseURL:   "https://eu.api.example.com",
+		Files: []TestFile{
+			{Path: SuitePath(&endpoint, "gpt4"), Endpoint: endpoint, Content: gptTest, Metadata: map[string]string{"ai_model": "gpt4"}},
+			{Path: SuitePath(&endpoint, "claude"), Endpoint: endpoint, Content: claudeTest},
+			{Path: SuitePath(&endpoint, "broken"), Endpoint: endpoint, Content: "not go"},
+		},
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/mine\n"), 0o600))
+
+	index, err := WriteSuite(dir, "", suite)
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultSuiteModule, index.Module)
+	assert.Equal(t, 4, index.TotalTests)
+	require.Len(t, index.Files, 2, "code that does not parse is skipped")
+	assert.Equal(t, "tests/users/listUsers_claude_test.go", index.Files[0].Path, "files are written in path order")
+	assert.Equal(t, []string{"TestGetUsers_listUsers_gpt4", "TestGetUsersNotFound_listUsers_gpt4"}, index.Files[1].Tests)
+
+	gpt, err := os.ReadFile(filepath.Join(dir, "tests", "users", "listUsers_gpt4_test.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(gpt), "package users\n")
+	assert.Contains(t, string(gpt), `const baseURL_listUsers_gpt4 = "http://localhost:8080"`)
+	assert.Contains(t, string(gpt), "http.Get(baseURL_listUsers_gpt4 + \"/users\")", "uses follow renamed declarations")
+	assert.Contains(t, string(gpt), "// TestGetUsers checks the happy path")
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/mine\n"+assertspecRequire, string(goMod), "an existing go.mod is kept, requiring glens/assertspec")
+	helpers, err := os.ReadFile(filepath.Join(dir, SuiteHelpersDir, "helpers.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(helpers), `const DefaultBaseURL = "https://eu.api.example.com"`)
+
+	data, err := os.ReadFile(filepath.Join(dir, SuiteIndexFile))
+	require.NoError(t, err)
+	var written SuiteIndex
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, "Users API", written.Name)
+	assert.Equal(t, map[string]string{"ai_model": "gpt4"}, written.Files[1].Metadata)
+}
+
+func TestWriteSuite_KeepRegions(t *testing.T) {
+	endpoint := glensparser.Endpoint{ID: "GET__users", Method: "GET", Path: "/users", OperationID: "listUsers", Tags: []string{"users"}}
+	suite := &TestSuite{Files: []TestFile{{Path: SuitePath(&endpoint, "gpt4"), Endpoint: endpoint, Content: gptTest}}}
+	dir := t.TempDir()
+	_, err := WriteSuite(dir, "", suite)
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "tests", "users", "listUsers_gpt4_test.go")
+	current, err := os.ReadFile(file)
+	require.NoError(t, err)
+	edited := string(current) + `
+// glens:keep-begin
+// TestGetUsersNotFound is tuned by hand
+func TestGetUsersNotFound(t *testing.T) {
+	var body map[string]any
+	_ = json.Unmarshal(nil, &body)
+}
+
+func TestAudit(t *testing.T) {}
+// glens:keep-end
+`
+	edited = strings.Replace(edited, "import (", "import (\n\t\"encoding/json\"", 1)
+	require.NoError(t, os.WriteFile(file, []byte(edited), 0o600))
+
+	index, err := ReadSuiteIndex(dir)
+	require.NoError(t, err)
+	entry := index.Files[0]
+	assert.Equal(t, "GET__users", entry.EndpointID)
+	assert.Equal(t, endpoint.Fingerprint(), entry.Fingerprint)
+
+	endpoint.Summary = "List users"
+	require.NoError(t, RewriteSuiteFile(dir, &entry, &endpoint, claudeTest))
+
+	rewritten, err := os.ReadFile(file)
+	require.NoError(t, err)
+	code := string(rewritten)
+	assert.Contains(t, code, "127.0.0.1", "generated code is replaced")
+	assert.Contains(t, code, "// TestGetUsersNotFound is tuned by hand")
+	assert.Equal(t, 1, strings.Count(code, "func TestGetUsersNotFound"), "kept declarations replace generated ones")
+	assert.NotContains(t, code, "assert.Equal", "imports only used by dropped code are removed")
+	assert.Contains(t, code, `"encoding/json"`)
+	assert.Equal(t, []string{"TestGetUsers", "TestGetUsersNotFound", "TestAudit"}, entry.Tests)
+	assert.Equal(t, endpoint.Fingerprint(), entry.Fingerprint)
+
+	// Keep regions survive a full rewrite too
+	_, err = WriteSuite(dir, "", suite)
+	require.NoError(t, err)
+	rewritten, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(rewritten), "func TestAudit(t *testing.T) {}")
+}
+
+func TestKeepRegions(t *testing.T) {
+	src := "a\n" + KeepBegin + "\nb\n" + KeepEnd + "\nc\n\t" + KeepBegin + "\nd\n"
+	assert.Equal(t, []string{KeepBegin + "\nb\n" + KeepEnd, "\t" + KeepBegin + "\nd"}, keepRegions(src))
+	assert.Empty(t, keepRegions("a\nb\n"))
+}
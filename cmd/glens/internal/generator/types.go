@@ -3,6 +3,7 @@ package generator
 import (
 	"time"
 
+	"glens/tools/glens/internal/environment"
 	"glens/tools/glens/internal/parser"
 )
 
@@ -10,6 +11,11 @@ import (
 type TestGenerator struct {
 	framework string
 	timeout   time.Duration
+	retries   int
+	env       *environment.Environment
+	scrubEnv  bool
+	lint      *LintOptions
+	servers   []parser.Server
 }
 
 // ExecutionResult contains the results of test execution
@@ -25,6 +31,21 @@ type ExecutionResult struct {
 	Errors       []TestError   `json:"errors,omitempty"`
 	Coverage     *Coverage     `json:"coverage,omitempty"`
 	Performance  *Performance  `json:"performance,omitempty"`
+	// Attempts is the number of times the test was run
+	Attempts int `json:"attempts"`
+	// Flaky is set when the test failed at least once and then passed on retry
+	Flaky bool `json:"flaky,omitempty"`
+	// FlakyErrors holds the errors of the first failed attempt of a flaky test
+	FlakyErrors []TestError `json:"flaky_errors,omitempty"`
+}
+
+// Finding is an issue static analysis reported in a generated test
+type Finding struct {
+	Tool     string   `json:"tool"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
 }
 
 // TestError represents a test execution error
@@ -73,6 +94,9 @@ type TestSuite struct {
 	TotalTests  int        `json:"total_tests"`
 	Framework   string     `json:"framework"`
 	GeneratedAt time.Time  `json:"generated_at"`
+	// BaseURL is the default base URL of the suite's helpers package;
+	// empty means environment.DefaultBaseURL
+	BaseURL string `json:"base_url,omitempty"`
 }
 
 // Framework represents supported test frameworks
@@ -86,6 +110,9 @@ const (
 	FrameworkGinkgo Framework = "ginkgo"
 	// FrameworkStandard represents the standard Go testing framework
 	FrameworkStandard Framework = "standard"
+	// FrameworkGRPC represents testify tests calling gRPC services with
+	// grpc-go clients or grpcurl
+	FrameworkGRPC Framework = "grpc"
 )
 
 // TestCategory represents different types of tests
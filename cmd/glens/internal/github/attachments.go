@@ -0,0 +1,109 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+)
+
+// Ways the failing tests of an issue are attached to it
+const (
+	// AttachNone leaves the failing tests out of issues
+	AttachNone = "none"
+	// AttachInline adds the test code and log to the issue body in
+	// collapsed <details> blocks
+	AttachInline = "inline"
+	// AttachGist uploads the test code and log of each model as a secret
+	// gist linked from the issue
+	AttachGist = "gist"
+)
+
+// maxInlineAttachment bounds the code and the log of an inline attachment,
+// as an issue body holds at most 65536 characters
+const maxInlineAttachment = 12000
+
+// Attachment is the failing test of a model and the log of its execution
+type Attachment struct {
+	Model string
+	// File is the name of the test file, e.g. gpt4_get_pets_test.go
+	File string
+	Code string
+	Log  string
+	// GistURL is the secret gist holding the test and its log, once
+	// uploaded
+	GistURL string
+}
+
+// NewAttachment returns the attachment of the failing test code of model
+// for endpoint
+func NewAttachment(endpoint *parser.Endpoint, model, code, log string) Attachment {
+	words := strings.FieldsFunc(strings.ToLower(model+" "+endpoint.Method+" "+endpoint.Path), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	return Attachment{Model: model, File: strings.Join(words, "_") + "_test.go", Code: code, Log: log}
+}
+
+// LogFile is the name of the attachment's log file
+func (a *Attachment) LogFile() string {
+	return strings.TrimSuffix(a.File, "_test.go") + ".log"
+}
+
+// InlineCode returns the test code, truncated to fit an issue body
+func (a *Attachment) InlineCode() string {
+	return truncateInline(a.Code)
+}
+
+// InlineLog returns the end of the log, truncated to fit an issue body
+func (a *Attachment) InlineLog() string {
+	if len(a.Log) <= maxInlineAttachment {
+		return a.Log
+	}
+	return "… truncated …\n" + a.Log[len(a.Log)-maxInlineAttachment:]
+}
+
+func truncateInline(s string) string {
+	if len(s) <= maxInlineAttachment {
+		return s
+	}
+	return s[:maxInlineAttachment] + "\n… truncated …"
+}
+
+// ParseAttachMode checks an attachment mode; empty is AttachNone
+func ParseAttachMode(mode string) (string, error) {
+	switch mode {
+	case "", AttachNone:
+		return AttachNone, nil
+	case AttachInline, AttachGist:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown attachment mode %q (use none, inline or gist)", mode)
+	}
+}
+
+// createGist uploads the test code and log of an attachment as a secret
+// gist and sets its GistURL
+func (c *Client) createGist(ctx context.Context, endpoint *parser.Endpoint, attachment *Attachment) error {
+	description := fmt.Sprintf("glens: failing %s test of %s %s", attachment.Model, endpoint.Method, endpoint.Path)
+	files := map[github.GistFilename]github.GistFile{
+		github.GistFilename(attachment.File): {Content: github.String(redact.String(attachment.Code))},
+	}
+	if attachment.Log != "" {
+		files[github.GistFilename(attachment.LogFile())] = github.GistFile{Content: github.String(redact.String(attachment.Log))}
+	}
+
+	gist, _, err := c.client.Gists.Create(ctx, &github.Gist{
+		Description: &description,
+		Public:      github.Bool(false),
+		Files:       files,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create gist: %w", err)
+	}
+	attachment.GistURL = gist.GetHTMLURL()
+	return nil
+}
@@ -10,13 +10,17 @@ import (
 	"golang.org/x/oauth2"
 
 	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
 )
 
 // Client wraps GitHub API operations
 type Client struct {
-	client *github.Client
-	owner  string
-	repo   string
+	client    *github.Client
+	owner     string
+	repo      string
+	templates *IssueTemplates
+	board     *Board
+	limits    *Limits
 }
 
 // NewClient creates a new GitHub client
@@ -30,9 +34,10 @@ func NewClient(token string) (*Client, error) {
 	)
 	tc := oauth2.NewClient(context.Background(), ts)
 
-	return &Client{
-		client: github.NewClient(tc),
-	}, nil
+	c := &Client{limits: DefaultLimits()}
+	tc.Transport = &rateLimitTransport{base: tc.Transport, limits: func() *Limits { return c.limits }}
+	c.client = github.NewClient(tc)
+	return c, nil
 }
 
 // SetRepository sets the target repository
@@ -53,32 +58,93 @@ func (c *Client) SetRepository(repository string) error {
 	return nil
 }
 
+// SetIssueTemplates sets how issues and subtasks are written; nil restores
+// DefaultIssueTemplates
+func (c *Client) SetIssueTemplates(templates *IssueTemplates) {
+	c.templates = templates
+}
+
+// issueTemplates returns the templates issues are written with
+func (c *Client) issueTemplates() *IssueTemplates {
+	if c.templates == nil {
+		return DefaultIssueTemplates
+	}
+	return c.templates
+}
+
+// SetLimits sets the pacing and issue cap of the client, shared with the
+// other clients of a run; nil restores DefaultLimits. Call it before the
+// client sends requests.
+func (c *Client) SetLimits(limits *Limits) {
+	if limits == nil {
+		limits = DefaultLimits()
+	}
+	c.limits = limits
+}
+
+// SetBoard sets the project board issues are placed on; nil places none
+func (c *Client) SetBoard(board *Board) {
+	c.board = board
+}
+
+// Failure is an endpoint whose tests failed, which an issue reports
+type Failure struct {
+	Endpoint *parser.Endpoint
+	// Models are the models whose tests failed
+	Models []string
+	// Results is the Markdown summary of the failed runs the issue
+	// templates receive
+	Results string
+	// Attachments are the failing tests of the models, attached as
+	// configured (see IssueConfig.Attachments); CreateEndpointIssue sets
+	// the GistURL of those it uploads
+	Attachments []Attachment
+}
+
 // CreateEndpointIssue creates a GitHub issue for an endpoint with AI model subtasks
-// This should only be called when tests have actually failed
-func (c *Client) CreateEndpointIssue(ctx context.Context, endpoint *parser.Endpoint, aiModels []string) (int, error) {
-	if c.owner == "" || c.repo == "" {
-		return 0, fmt.Errorf("repository not set, call SetRepository first")
+// This should only be called when tests have actually failed.
+func (c *Client) CreateEndpointIssue(ctx context.Context, failure *Failure) (int, error) {
+	issue, err := c.createEndpointIssue(ctx, failure)
+	if err != nil {
+		return 0, err
 	}
+	return issue.GetNumber(), nil
+}
 
-	title := fmt.Sprintf("❌ Test Failure: %s %s", endpoint.Method, endpoint.Path)
+func (c *Client) createEndpointIssue(ctx context.Context, failure *Failure) (*github.Issue, error) {
+	if c.owner == "" || c.repo == "" {
+		return nil, fmt.Errorf("repository not set, call SetRepository first")
+	}
+	if err := c.limits.takeIssue(); err != nil {
+		return nil, err
+	}
+	endpoint, aiModels := failure.Endpoint, failure.Models
 
-	body := c.generateIssueBody(endpoint, aiModels)
+	templates := c.issueTemplates()
+	c.attach(ctx, templates.Attach(), failure)
+	data := newIssueData(endpoint, aiModels, failure.Results)
+	if templates.Attach() != AttachNone {
+		data.Attachments = failure.Attachments
+	}
+	title, body, err := templates.Issue(data)
+	if err != nil {
+		return nil, err
+	}
+	// The hidden marker lets later runs find the issue of the endpoint
+	body = redact.String(body) + "\n\n" + endpointMarker(endpoint)
 
 	issue := &github.IssueRequest{
-		Title: &title,
-		Body:  &body,
-		Labels: &[]string{
-			"test-failure",
-			"integration-test",
-			"ai-generated",
-			"openapi",
-			strings.ToLower(endpoint.Method),
-		},
+		Title:  &title,
+		Body:   &body,
+		Labels: ptr(templates.Labels(data, "test-failure", strings.ToLower(endpoint.Method))),
+	}
+	if assignees := templates.Assignees(data); len(assignees) > 0 {
+		issue.Assignees = &assignees
 	}
 
 	createdIssue, _, err := c.client.Issues.Create(ctx, c.owner, c.repo, issue)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create issue: %w", err)
+		return nil, fmt.Errorf("failed to create issue: %w", err)
 	}
 
 	issueNumber := createdIssue.GetNumber()
@@ -90,7 +156,7 @@ func (c *Client) CreateEndpointIssue(ctx context.Context, endpoint *parser.Endpo
 
 	// Create subtasks for each AI model that failed
 	for _, aiModel := range aiModels {
-		if err := c.createSubtask(ctx, issueNumber, endpoint, aiModel); err != nil {
+		if err := c.createSubtask(ctx, issueNumber, data, aiModel); err != nil {
 			log.Error().
 				Err(err).
 				Str("ai_model", aiModel).
@@ -99,117 +165,27 @@ func (c *Client) CreateEndpointIssue(ctx context.Context, endpoint *parser.Endpo
 		}
 	}
 
-	return issueNumber, nil
-}
-
-// generateIssueBody creates the markdown body for the main issue
-func (c *Client) generateIssueBody(endpoint *parser.Endpoint, aiModels []string) string {
-	var body strings.Builder
-
-	body.WriteString("## ❌ Test Failure Report\n\n")
-	body.WriteString("This issue was created because integration tests failed for this endpoint.\n\n")
-	body.WriteString("### 🎯 Endpoint Details\n\n")
-	fmt.Fprintf(&body, "**Method:** `%s`\n", endpoint.Method)
-	fmt.Fprintf(&body, "**Path:** `%s`\n", endpoint.Path)
-
-	if endpoint.OperationID != "" {
-		fmt.Fprintf(&body, "**Operation ID:** `%s`\n", endpoint.OperationID)
-	}
-
-	if endpoint.Summary != "" {
-		fmt.Fprintf(&body, "**Summary:** %s\n", endpoint.Summary)
-	}
-
-	if endpoint.Description != "" {
-		fmt.Fprintf(&body, "\n**Description:**\n%s\n", endpoint.Description)
-	}
-
-	// Parameters section
-	if len(endpoint.Parameters) > 0 {
-		body.WriteString("\n### 📋 Parameters\n\n")
-		body.WriteString("| Name | Type | In | Required | Description |\n")
-		body.WriteString("|------|------|----|---------|--------------|\n")
-
-		for i := range endpoint.Parameters {
-			param := &endpoint.Parameters[i]
-			required := "No"
-			if param.Required {
-				required = "Yes"
-			}
-			fmt.Fprintf(&body, "| `%s` | `%s` | `%s` | %s | %s |\n",
-				param.Name, param.Schema.Type, param.In, required, param.Description)
-		}
-	}
-
-	// Request body section
-	if endpoint.RequestBody != nil {
-		body.WriteString("\n### 📤 Request Body\n\n")
-		if endpoint.RequestBody.Description != "" {
-			fmt.Fprintf(&body, "**Description:** %s\n\n", endpoint.RequestBody.Description)
-		}
-		body.WriteString("**Content Types:**\n")
-		for contentType := range endpoint.RequestBody.Content {
-			fmt.Fprintf(&body, "- `%s`\n", contentType)
-		}
-	}
-
-	// Responses section
-	if len(endpoint.Responses) > 0 {
-		body.WriteString("\n### 📥 Expected Responses\n\n")
-		body.WriteString("| Status Code | Description |\n")
-		body.WriteString("|-------------|-------------|\n")
-
-		for code, response := range endpoint.Responses {
-			fmt.Fprintf(&body, "| `%s` | %s |\n", code, response.Description)
-		}
-	}
-
-	// Failed AI Models section
-	body.WriteString("\n### 🤖 Failed Test Runs\n\n")
-	body.WriteString("The following AI models generated tests that failed:\n\n")
-
-	for _, model := range aiModels {
-		fmt.Fprintf(&body, "- ❌ **%s** - Tests failed (see subtask for details)\n", model)
-	}
-
-	body.WriteString("\n### 🔍 Investigation Checklist\n\n")
-	body.WriteString("- [ ] Review test failure details in comments below\n")
-	body.WriteString("- [ ] Verify OpenAPI specification is correct\n")
-	body.WriteString("- [ ] Check if implementation matches OpenAPI spec\n")
-	body.WriteString("- [ ] Verify test data and parameters are valid\n")
-	body.WriteString("- [ ] Check for authentication/authorization issues\n")
-	body.WriteString("- [ ] Review response formats and status codes\n")
-	body.WriteString("- [ ] Ensure endpoint is accessible and responding\n")
-
-	body.WriteString("\n### 🎯 Resolution Steps\n\n")
-	body.WriteString("1. **Analyze the failure** - Review test output and error messages\n")
-	body.WriteString("2. **Identify root cause** - Determine if it's a spec issue or implementation issue\n")
-	body.WriteString("3. **Fix the issue** - Update spec or implementation as needed\n")
-	body.WriteString("4. **Re-run tests** - Verify the fix resolves the failures\n")
-	body.WriteString("5. **Close issue** - Once all tests pass\n")
-
-	body.WriteString("\n---\n")
-	body.WriteString("*This issue was automatically generated by Glens after test failures*")
-
-	return body.String()
+	return createdIssue, nil
 }
 
 // createSubtask creates a subtask issue for a specific AI model
-func (c *Client) createSubtask(ctx context.Context, parentIssue int, endpoint *parser.Endpoint, aiModel string) error {
-	title := fmt.Sprintf("[%s] Generate tests for %s %s", aiModel, endpoint.Method, endpoint.Path)
-
-	body := c.generateSubtaskBody(parentIssue, endpoint, aiModel)
+func (c *Client) createSubtask(ctx context.Context, parentIssue int, data IssueData, aiModel string) error {
+	templates := c.issueTemplates()
+	data.Model = aiModel
+	data.ParentIssue = parentIssue
+	title, body, err := templates.Subtask(data)
+	if err != nil {
+		return err
+	}
+	body = redact.String(body)
 
 	issue := &github.IssueRequest{
-		Title: &title,
-		Body:  &body,
-		Labels: &[]string{
-			"integration-test",
-			"ai-generated",
-			"subtask",
-			strings.ToLower(aiModel),
-			strings.ToLower(endpoint.Method),
-		},
+		Title:  &title,
+		Body:   &body,
+		Labels: ptr(templates.Labels(data, "subtask", strings.ToLower(aiModel), strings.ToLower(data.Method))),
+	}
+	if assignees := templates.Assignees(data); len(assignees) > 0 {
+		issue.Assignees = &assignees
 	}
 
 	createdIssue, _, err := c.client.Issues.Create(ctx, c.owner, c.repo, issue)
@@ -240,105 +216,29 @@ func (c *Client) createSubtask(ctx context.Context, parentIssue int, endpoint *p
 	return nil
 }
 
-// generateSubtaskBody creates the markdown body for AI model subtasks
-func (c *Client) generateSubtaskBody(parentIssue int, endpoint *parser.Endpoint, aiModel string) string {
-	var body strings.Builder
-
-	fmt.Fprintf(&body, "## 🤖 %s Integration Test Generation\n\n", aiModel)
-	fmt.Fprintf(&body, "**Parent Issue:** #%d\n", parentIssue)
-	fmt.Fprintf(&body, "**Endpoint:** `%s %s`\n", endpoint.Method, endpoint.Path)
-	fmt.Fprintf(&body, "**AI Model:** %s\n\n", aiModel)
-
-	body.WriteString("### 🎯 Objective\n\n")
-	fmt.Fprintf(&body, "Generate comprehensive integration tests for the `%s %s` endpoint using the %s AI model.\n\n",
-		endpoint.Method, endpoint.Path, aiModel)
-
-	body.WriteString("### 📋 Tasks\n\n")
-	body.WriteString("- [ ] **Analyze Endpoint Specification**\n")
-	body.WriteString("  - Review parameters, request body, and response schemas\n")
-	body.WriteString("  - Identify security requirements\n")
-	body.WriteString("  - Understand business logic constraints\n\n")
-
-	body.WriteString("- [ ] **Generate Test Cases**\n")
-	body.WriteString("  - Happy path scenarios\n")
-	body.WriteString("  - Error handling cases\n")
-	body.WriteString("  - Boundary value testing\n")
-	body.WriteString("  - Security validation\n\n")
-
-	body.WriteString("- [ ] **Create Test Code**\n")
-	body.WriteString("  - Generate executable test code\n")
-	body.WriteString("  - Include proper assertions\n")
-	body.WriteString("  - Add test data generation\n")
-	body.WriteString("  - Implement cleanup procedures\n\n")
-
-	body.WriteString("- [ ] **Execute Tests**\n")
-	body.WriteString("  - Run generated test suite\n")
-	body.WriteString("  - Capture execution results\n")
-	body.WriteString("  - Document any failures\n")
-	body.WriteString("  - Generate performance metrics\n\n")
-
-	body.WriteString("### 🔍 Test Focus Areas\n\n")
-
-	if len(endpoint.Parameters) > 0 {
-		body.WriteString("**Parameters to Test:**\n")
-		for i := range endpoint.Parameters {
-			param := &endpoint.Parameters[i]
-			required := "optional"
-			if param.Required {
-				required = "required"
-			}
-			fmt.Fprintf(&body, "- `%s` (%s, %s): %s\n",
-				param.Name, param.In, required, param.Description)
-		}
-		body.WriteString("\n")
-	}
-
-	if endpoint.RequestBody != nil {
-		body.WriteString("**Request Body Testing:**\n")
-		body.WriteString("- Valid payload structures\n")
-		body.WriteString("- Invalid/malformed data\n")
-		body.WriteString("- Missing required fields\n")
-		body.WriteString("- Content-type validation\n\n")
+// attach uploads the attachments of failure as gists in the AttachGist
+// mode. An attachment that fails to upload is attached inline instead.
+func (c *Client) attach(ctx context.Context, mode string, failure *Failure) {
+	if mode != AttachGist {
+		return
 	}
-
-	if len(endpoint.Responses) > 0 {
-		body.WriteString("**Response Validation:**\n")
-		for code := range endpoint.Responses {
-			fmt.Fprintf(&body, "- HTTP %s response handling\n", code)
+	for i := range failure.Attachments {
+		if err := c.createGist(ctx, failure.Endpoint, &failure.Attachments[i]); err != nil {
+			log.Error().
+				Err(err).
+				Str("ai_model", failure.Attachments[i].Model).
+				Msg("Failed to upload failing test as gist")
 		}
-		body.WriteString("\n")
 	}
+}
 
-	body.WriteString("### 🛠 Technical Requirements\n\n")
-	body.WriteString("- **Framework:** Go with testify\n")
-	body.WriteString("- **HTTP Client:** Standard library or custom\n")
-	body.WriteString("- **Assertions:** Comprehensive validation\n")
-	body.WriteString("- **Documentation:** Clear test descriptions\n")
-	body.WriteString("- **Maintainability:** Readable and modular code\n\n")
-
-	body.WriteString("### 📊 Success Criteria\n\n")
-	body.WriteString("- [ ] All test cases execute without compilation errors\n")
-	body.WriteString("- [ ] Tests demonstrate endpoint functionality\n")
-	body.WriteString("- [ ] Error scenarios are properly handled\n")
-	body.WriteString("- [ ] Performance metrics are captured\n")
-	body.WriteString("- [ ] Test results are documented\n\n")
-
-	body.WriteString("### 📈 Deliverables\n\n")
-	body.WriteString("1. **Generated Test Code** - Complete test suite\n")
-	body.WriteString("2. **Execution Report** - Test run results\n")
-	body.WriteString("3. **Performance Metrics** - Response time analysis\n")
-	body.WriteString("4. **Issue Report** - Any discovered problems\n")
-	body.WriteString("5. **AI Prompt Details** - Prompt used for generation\n\n")
-
-	body.WriteString("---\n")
-	fmt.Fprintf(&body, "*Generated by Glens for %s*", aiModel)
-
-	return body.String()
+func ptr[T any](v T) *T {
+	return &v
 }
 
 // UpdateIssueWithResults updates an issue with test execution results
 func (c *Client) UpdateIssueWithResults(ctx context.Context, issueNumber int, results string) error {
-	comment := fmt.Sprintf("## 📊 Test Execution Results\n\n%s", results)
+	comment := redact.String(fmt.Sprintf("## 📊 Test Execution Results\n\n%s", results))
 
 	_, _, err := c.client.Issues.CreateComment(ctx, c.owner, c.repo, issueNumber, &github.IssueComment{
 		Body: &comment,
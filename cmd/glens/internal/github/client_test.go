@@ -79,7 +79,7 @@ func TestGitHubIntegration(t *testing.T) {
 
 		// Step 1: Create issue
 		t.Log("Creating test issue...")
-		issueNumber, err := client.CreateEndpointIssue(ctx, endpoint, aiModels)
+		issueNumber, err := client.CreateEndpointIssue(ctx, &Failure{Endpoint: endpoint, Models: aiModels})
 		require.NoError(t, err, "Failed to create issue")
 		assert.Greater(t, issueNumber, 0, "Issue number should be positive")
 		t.Logf("Created issue #%d", issueNumber)
@@ -0,0 +1,257 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+)
+
+// statusMarker identifies the status comment glens keeps up to date on
+// each issue it tracks
+const statusMarker = "<!-- glens:status -->"
+
+// markerPattern matches the hidden endpoint marker of an issue body
+var markerPattern = regexp.MustCompile(`<!-- glens:endpoint (.+?) -->`)
+
+// endpointMarker is the hidden line CreateEndpointIssue ends issue bodies
+// with, so later runs find the issue of an endpoint
+func endpointMarker(endpoint *parser.Endpoint) string {
+	return fmt.Sprintf("<!-- glens:endpoint %s -->", endpointKey(endpoint))
+}
+
+// endpointKey identifies an endpoint across runs: its ID, or its method
+// and path when it has none
+func endpointKey(endpoint *parser.Endpoint) string {
+	if endpoint.ID != "" {
+		return endpoint.ID
+	}
+	return endpoint.Method + " " + endpoint.Path
+}
+
+// TrackedIssue is the issue glens opened for an endpoint in an earlier run
+type TrackedIssue struct {
+	Number int
+	Open   bool
+	// NodeID is the issue's GraphQL ID, which project boards refer to
+	NodeID string
+}
+
+// IssueTracker maps endpoints to the issues glens opened for them, found by
+// the marker in their bodies. A nil IssueTracker tracks nothing, so every
+// failure opens a new issue as before.
+type IssueTracker struct {
+	mu     sync.Mutex
+	issues map[string]TrackedIssue
+}
+
+// Issue returns the issue tracked for endpoint
+func (t *IssueTracker) Issue(endpoint *parser.Endpoint) (TrackedIssue, bool) {
+	if t == nil {
+		return TrackedIssue{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	issue, ok := t.issues[endpointKey(endpoint)]
+	return issue, ok
+}
+
+func (t *IssueTracker) set(endpoint *parser.Endpoint, issue TrackedIssue) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.issues[endpointKey(endpoint)] = issue
+}
+
+// Len returns how many endpoints have a tracked issue
+func (t *IssueTracker) Len() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.issues)
+}
+
+// TrackIssues finds the test-failure issues glens opened in the repository,
+// open or closed, keeping the newest issue of each endpoint
+func (c *Client) TrackIssues(ctx context.Context) (*IssueTracker, error) {
+	issues, err := c.ListIssuesByLabel(ctx, []string{"test-failure"})
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := &IssueTracker{issues: map[string]TrackedIssue{}}
+	for _, issue := range issues {
+		if issue.IsPullRequest() {
+			continue
+		}
+		match := markerPattern.FindStringSubmatch(issue.GetBody())
+		if match == nil {
+			continue
+		}
+		if tracked, ok := tracker.issues[match[1]]; ok && tracked.Number > issue.GetNumber() {
+			continue
+		}
+		tracker.issues[match[1]] = TrackedIssue{Number: issue.GetNumber(), Open: issue.GetState() == "open", NodeID: issue.GetNodeID()}
+	}
+
+	log.Debug().
+		Int("tracked", len(tracker.issues)).
+		Msg("Tracked issues of earlier runs")
+
+	return tracker, nil
+}
+
+// Issue lifecycle actions of ReportFailure
+const (
+	// IssueOpened is a new issue
+	IssueOpened = "opened"
+	// IssueReopened is a closed issue whose endpoint fails again
+	IssueReopened = "reopened"
+	// IssueUpdated is an open issue whose endpoint still fails
+	IssueUpdated = "updated"
+)
+
+// ReportFailure opens an issue for failure, unless tracker has one for its
+// endpoint: a closed issue is then reopened and an open one left open, and
+// the status comment of either is updated with the results. The issue is
+// placed in the failing column of the board, if any. It returns the issue
+// number and the action taken.
+func (c *Client) ReportFailure(ctx context.Context, tracker *IssueTracker, failure *Failure) (int, string, error) {
+	tracked, ok := tracker.Issue(failure.Endpoint)
+	if !ok {
+		issue, err := c.createEndpointIssue(ctx, failure)
+		if err != nil {
+			return 0, "", err
+		}
+		number := issue.GetNumber()
+		tracker.set(failure.Endpoint, TrackedIssue{Number: number, Open: true, NodeID: issue.GetNodeID()})
+		if err := c.updateStatus(ctx, number, failingStatus(failure, "")); err != nil {
+			log.Error().Err(err).Int("issue_number", number).Msg("Failed to update issue status")
+		}
+		c.placeCard(ctx, failure.Endpoint, number, issue.GetNodeID(), false)
+		return number, IssueOpened, nil
+	}
+
+	action := IssueUpdated
+	if !tracked.Open {
+		if err := c.setState(ctx, tracked.Number, "open"); err != nil {
+			return 0, "", fmt.Errorf("failed to reopen issue: %w", err)
+		}
+		tracked.Open = true
+		tracker.set(failure.Endpoint, tracked)
+		action = IssueReopened
+	}
+	if err := c.updateStatus(ctx, tracked.Number, failingStatus(failure, failure.Results)); err != nil {
+		return 0, "", err
+	}
+	c.placeCard(ctx, failure.Endpoint, tracked.Number, tracked.NodeID, false)
+
+	log.Info().
+		Int("issue_number", tracked.Number).
+		Str("endpoint", fmt.Sprintf("%s %s", failure.Endpoint.Method, failure.Endpoint.Path)).
+		Str("action", action).
+		Msg("Existing GitHub issue " + action + " for test failure")
+
+	return tracked.Number, action, nil
+}
+
+// ResolveEndpoint closes the open issue tracked for an endpoint whose tests
+// now pass, updates its status comment and moves its card to the passing
+// column of the board, if any. It returns the closed issue's
+// number, or 0 when the endpoint had no open issue.
+func (c *Client) ResolveEndpoint(ctx context.Context, tracker *IssueTracker, endpoint *parser.Endpoint, models []string) (int, error) {
+	tracked, ok := tracker.Issue(endpoint)
+	if !ok || !tracked.Open {
+		return 0, nil
+	}
+
+	status := fmt.Sprintf("**State:** ✅ Passing — closed by glens\n**Passing models:** %s", strings.Join(models, ", "))
+	if err := c.updateStatus(ctx, tracked.Number, status); err != nil {
+		return 0, err
+	}
+	if err := c.setState(ctx, tracked.Number, "closed"); err != nil {
+		return 0, fmt.Errorf("failed to close issue: %w", err)
+	}
+	tracked.Open = false
+	tracker.set(endpoint, tracked)
+	c.placeCard(ctx, endpoint, tracked.Number, tracked.NodeID, true)
+
+	log.Info().
+		Int("issue_number", tracked.Number).
+		Str("endpoint", fmt.Sprintf("%s %s", endpoint.Method, endpoint.Path)).
+		Msg("GitHub issue closed - tests pass again")
+
+	return tracked.Number, nil
+}
+
+// placeCard places an endpoint's issue on the board in the column of its
+// status and risk. Board errors are logged and never fail the issue.
+func (c *Client) placeCard(ctx context.Context, endpoint *parser.Endpoint, number int, nodeID string, passing bool) {
+	if c.board == nil || nodeID == "" {
+		return
+	}
+	risk := newIssueData(endpoint, nil, "").Risk
+	if err := c.Place(ctx, c.board, nodeID, risk, passing); err != nil {
+		log.Error().Err(err).Int("issue_number", number).Msg("Failed to place issue on project board")
+	}
+}
+
+// failingStatus is the status of an issue whose endpoint fails, followed by
+// results when set
+func failingStatus(failure *Failure, results string) string {
+	status := fmt.Sprintf("**State:** ❌ Failing\n**Failed models:** %s", strings.Join(failure.Models, ", "))
+	if results != "" {
+		status += "\n\n" + results
+	}
+	return status
+}
+
+// setState opens or closes an issue
+func (c *Client) setState(ctx context.Context, issueNumber int, state string) error {
+	_, _, err := c.client.Issues.Edit(ctx, c.owner, c.repo, issueNumber, &github.IssueRequest{State: &state})
+	return err
+}
+
+// updateStatus writes status to the status comment of an issue, creating
+// the comment on the first update
+func (c *Client) updateStatus(ctx context.Context, issueNumber int, status string) error {
+	body := redact.String(fmt.Sprintf("%s\n## 🔄 Glens Status\n\n%s\n\n*Last run: %s*",
+		statusMarker, status, time.Now().UTC().Format(time.RFC3339)))
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.client.Issues.ListComments(ctx, c.owner, c.repo, issueNumber, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list issue comments: %w", err)
+		}
+		for _, comment := range comments {
+			if strings.HasPrefix(comment.GetBody(), statusMarker) {
+				if _, _, err := c.client.Issues.EditComment(ctx, c.owner, c.repo, comment.GetID(), &github.IssueComment{Body: &body}); err != nil {
+					return fmt.Errorf("failed to update status comment: %w", err)
+				}
+				return nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if _, _, err := c.client.Issues.CreateComment(ctx, c.owner, c.repo, issueNumber, &github.IssueComment{Body: &body}); err != nil {
+		return fmt.Errorf("failed to create status comment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func TestLifecycle(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var created []github.IssueRequest
+	edits := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/acme/api/issues":
+			assert.Equal(t, "test-failure", r.URL.Query().Get("labels"))
+			assert.Equal(t, "all", r.URL.Query().Get("state"))
+			_, _ = w.Write([]byte(`[
+				{"number": 3, "state": "closed", "body": "old\n\n<!-- glens:endpoint GET__pets -->"},
+				{"number": 5, "state": "closed", "body": "newer\n\n<!-- glens:endpoint GET__pets -->"},
+				{"number": 6, "state": "open", "body": "<!-- glens:endpoint DELETE__pets_{id} -->"},
+				{"number": 8, "state": "open", "body": "<!-- glens:endpoint POST__pets -->", "pull_request": {}},
+				{"number": 9, "state": "open", "body": "created by hand"}
+			]`))
+		case "POST /repos/acme/api/issues":
+			var issue github.IssueRequest
+			assert.NoError(t, json.Unmarshal(body, &issue))
+			created = append(created, issue)
+			_, _ = w.Write([]byte(`{"number": 10}`))
+		case "GET /repos/acme/api/issues/6/comments":
+			_, _ = w.Write([]byte(`[{"id": 60, "body": "🤖 gpt4 Subtask Created"}, {"id": 61, "body": "<!-- glens:status -->\nold status"}]`))
+		case "PATCH /repos/acme/api/issues/comments/61", "PATCH /repos/acme/api/issues/5", "PATCH /repos/acme/api/issues/6":
+			edits[r.URL.Path] = string(body)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			edits[r.URL.Path] = string(body)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(t, server.URL)
+	tracker, err := client.TrackIssues(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, tracker.Len(), "pull requests and issues without a marker are not tracked")
+
+	listPets := &parser.Endpoint{ID: "GET__pets", Method: "GET", Path: "/pets"}
+	issue, ok := tracker.Issue(listPets)
+	require.True(t, ok)
+	assert.Equal(t, TrackedIssue{Number: 5}, issue, "the newest issue of an endpoint is tracked")
+
+	// A closed issue whose endpoint fails again is reopened, not duplicated
+	number, action, err := client.ReportFailure(ctx, tracker, &Failure{Endpoint: listPets, Models: []string{"gpt4"}, Results: "### ❌ gpt4"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, number)
+	assert.Equal(t, IssueReopened, action)
+	assert.JSONEq(t, `{"state": "open"}`, edits["/repos/acme/api/issues/5"])
+	assert.Contains(t, edits["/repos/acme/api/issues/5/comments"], "**State:** ❌ Failing\\n**Failed models:** gpt4\\n\\n### ❌ gpt4")
+	assert.Empty(t, created)
+
+	// An endpoint without an issue gets a new one, with its marker
+	createPet := &parser.Endpoint{ID: "POST__pets", Method: "POST", Path: "/pets"}
+	number, action, err = client.ReportFailure(ctx, tracker, &Failure{Endpoint: createPet, Models: []string{"gpt4"}})
+	require.NoError(t, err)
+	assert.Equal(t, 10, number)
+	assert.Equal(t, IssueOpened, action)
+	require.Len(t, created, 2, "the issue and its subtask")
+	assert.True(t, strings.HasSuffix(created[0].GetBody(), "\n\n<!-- glens:endpoint POST__pets -->"))
+	issue, _ = tracker.Issue(createPet)
+	assert.Equal(t, TrackedIssue{Number: 10, Open: true}, issue)
+
+	// The open issue of an endpoint that passes is closed, and its status
+	// comment edited in place
+	deletePet := &parser.Endpoint{ID: "DELETE__pets_{id}", Method: "DELETE", Path: "/pets/{id}"}
+	number, err = client.ResolveEndpoint(ctx, tracker, deletePet, []string{"gpt4", "ollama"})
+	require.NoError(t, err)
+	assert.Equal(t, 6, number)
+	assert.JSONEq(t, `{"state": "closed"}`, edits["/repos/acme/api/issues/6"])
+	assert.Contains(t, edits["/repos/acme/api/issues/comments/61"], "✅ Passing — closed by glens\\n**Passing models:** gpt4, ollama")
+	assert.NotContains(t, requests, "POST /repos/acme/api/issues/6/comments")
+
+	// Endpoints without an open issue are left alone
+	number, err = client.ResolveEndpoint(ctx, tracker, deletePet, []string{"gpt4"})
+	require.NoError(t, err)
+	assert.Zero(t, number)
+	number, err = client.ResolveEndpoint(ctx, nil, listPets, []string{"gpt4"})
+	require.NoError(t, err)
+	assert.Zero(t, number)
+}
@@ -0,0 +1,258 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/safety"
+)
+
+// Default board columns: options of the project's status field
+const (
+	DefaultStatusField   = "Status"
+	DefaultFailingColumn = "Failing"
+	DefaultPassingColumn = "Passing"
+)
+
+// ProjectConfig places issues on a GitHub Projects (v2) board, read from
+// the github.project config section. The token needs the project scope.
+type ProjectConfig struct {
+	// Owner is the user or organization owning the project (default the
+	// repository owner) and Number the project number from its URL
+	Owner  string `mapstructure:"owner"`
+	Number int    `mapstructure:"number"`
+	// StatusField is the single-select field whose options are the columns
+	StatusField string `mapstructure:"status_field"`
+	// Failing and Passing are the columns of failing and passing endpoints
+	Failing string `mapstructure:"failing"`
+	Passing string `mapstructure:"passing"`
+	// RiskColumns replace Failing for the failing endpoints of a risk
+	// level, e.g. high: "Critical"
+	RiskColumns map[string]string `mapstructure:"risk_columns"`
+	// RiskField, when set, is a single-select field set to the endpoint's
+	// risk level (safe, medium or high)
+	RiskField string `mapstructure:"risk_field"`
+}
+
+// Enabled reports whether a project is configured
+func (cfg *ProjectConfig) Enabled() bool {
+	return cfg.Number > 0
+}
+
+// Validate checks the project number and the risk levels of RiskColumns
+func (cfg *ProjectConfig) Validate() error {
+	if cfg.Number < 0 {
+		return fmt.Errorf("github.project.number: %d is not a project number", cfg.Number)
+	}
+	for level := range cfg.RiskColumns {
+		if _, err := safety.ParseRisk(level); err != nil {
+			return fmt.Errorf("github.project.risk_columns: %w", err)
+		}
+	}
+	return nil
+}
+
+// Board is a GitHub project that issues are placed on, in the column of
+// their endpoint's status and risk
+type Board struct {
+	cfg       ProjectConfig
+	projectID string
+	status    selectField
+	risk      *selectField
+}
+
+// selectField is a single-select field of a project and its options by
+// lowercased name
+type selectField struct {
+	id      string
+	options map[string]string
+}
+
+// option returns the ID of the option named name
+func (f *selectField) option(name string) (string, bool) {
+	id, ok := f.options[strings.ToLower(name)]
+	return id, ok
+}
+
+const projectQuery = `query($owner: String!, $number: Int!) {
+  repositoryOwner(login: $owner) {
+    ... on ProjectV2Owner {
+      projectV2(number: $number) {
+        id
+        fields(first: 50) {
+          nodes { ... on ProjectV2SingleSelectField { id name options { id name } } }
+        }
+      }
+    }
+  }
+}`
+
+// OpenBoard looks up the project of cfg and checks that its fields have
+// the configured columns
+func (c *Client) OpenBoard(ctx context.Context, cfg ProjectConfig) (*Board, error) {
+	if cfg.Owner == "" {
+		cfg.Owner = c.owner
+	}
+	if cfg.StatusField == "" {
+		cfg.StatusField = DefaultStatusField
+	}
+	if cfg.Failing == "" {
+		cfg.Failing = DefaultFailingColumn
+	}
+	if cfg.Passing == "" {
+		cfg.Passing = DefaultPassingColumn
+	}
+
+	var data struct {
+		RepositoryOwner *struct {
+			ProjectV2 *struct {
+				ID     string `json:"id"`
+				Fields struct {
+					Nodes []struct {
+						ID      string `json:"id"`
+						Name    string `json:"name"`
+						Options []struct {
+							ID   string `json:"id"`
+							Name string `json:"name"`
+						} `json:"options"`
+					} `json:"nodes"`
+				} `json:"fields"`
+			} `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	if err := c.graphQL(ctx, projectQuery, map[string]any{"owner": cfg.Owner, "number": cfg.Number}, &data); err != nil {
+		return nil, fmt.Errorf("failed to look up project %s/%d: %w", cfg.Owner, cfg.Number, err)
+	}
+	if data.RepositoryOwner == nil || data.RepositoryOwner.ProjectV2 == nil {
+		return nil, fmt.Errorf("project %s/%d not found", cfg.Owner, cfg.Number)
+	}
+
+	project := data.RepositoryOwner.ProjectV2
+	fields := map[string]selectField{}
+	for _, node := range project.Fields.Nodes {
+		if node.ID == "" {
+			continue
+		}
+		field := selectField{id: node.ID, options: map[string]string{}}
+		for _, option := range node.Options {
+			field.options[strings.ToLower(option.Name)] = option.ID
+		}
+		fields[strings.ToLower(node.Name)] = field
+	}
+
+	board := &Board{cfg: cfg, projectID: project.ID}
+	var ok bool
+	if board.status, ok = fields[strings.ToLower(cfg.StatusField)]; !ok {
+		return nil, fmt.Errorf("project %s/%d has no single-select field %q", cfg.Owner, cfg.Number, cfg.StatusField)
+	}
+	columns := []string{cfg.Failing, cfg.Passing}
+	for _, column := range cfg.RiskColumns {
+		columns = append(columns, column)
+	}
+	for _, column := range columns {
+		if _, ok := board.status.option(column); !ok {
+			return nil, fmt.Errorf("field %q of project %s/%d has no option %q", cfg.StatusField, cfg.Owner, cfg.Number, column)
+		}
+	}
+	if cfg.RiskField != "" {
+		field, ok := fields[strings.ToLower(cfg.RiskField)]
+		if !ok {
+			return nil, fmt.Errorf("project %s/%d has no single-select field %q", cfg.Owner, cfg.Number, cfg.RiskField)
+		}
+		board.risk = &field
+	}
+
+	log.Info().
+		Str("owner", cfg.Owner).
+		Int("project", cfg.Number).
+		Msg("GitHub project board configured")
+
+	return board, nil
+}
+
+// Column returns the column of an endpoint of risk that passes or fails
+func (b *Board) Column(risk string, passing bool) string {
+	if passing {
+		return b.cfg.Passing
+	}
+	for level, column := range b.cfg.RiskColumns {
+		if strings.EqualFold(level, risk) {
+			return column
+		}
+	}
+	return b.cfg.Failing
+}
+
+const addItemMutation = `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}`
+
+const setOptionMutation = `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) {
+    projectV2Item { id }
+  }
+}`
+
+// Place adds the issue with node ID issueID to the board, or finds its
+// card, and moves the card to the column of risk and passing
+func (c *Client) Place(ctx context.Context, board *Board, issueID, risk string, passing bool) error {
+	var added struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	if err := c.graphQL(ctx, addItemMutation, map[string]any{"project": board.projectID, "content": issueID}, &added); err != nil {
+		return fmt.Errorf("failed to add issue to project: %w", err)
+	}
+	item := added.AddProjectV2ItemByID.Item.ID
+
+	column := board.Column(risk, passing)
+	option, _ := board.status.option(column)
+	if err := c.setOption(ctx, board, item, board.status.id, option); err != nil {
+		return fmt.Errorf("failed to move card to %q: %w", column, err)
+	}
+	if board.risk != nil {
+		if option, ok := board.risk.option(risk); ok {
+			if err := c.setOption(ctx, board, item, board.risk.id, option); err != nil {
+				return fmt.Errorf("failed to set card risk: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) setOption(ctx context.Context, board *Board, item, field, option string) error {
+	vars := map[string]any{"project": board.projectID, "item": item, "field": field, "option": option}
+	return c.graphQL(ctx, setOptionMutation, vars, nil)
+}
+
+// graphQL runs a query of the GitHub GraphQL API, decoding its data into
+// out when set
+func (c *Client) graphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	req, err := c.client.NewRequest("POST", "graphql", map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", resp.Errors[0].Message)
+	}
+	if out == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
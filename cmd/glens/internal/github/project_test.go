@@ -0,0 +1,91 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectResponse is the project acme/3, with a Status field of Failing,
+// Critical and Passing and a Risk field
+const projectResponse = `{"data": {"repositoryOwner": {"projectV2": {"id": "PVT_1", "fields": {"nodes": [
+	{},
+	{"id": "F_status", "name": "Status", "options": [{"id": "O_fail", "name": "Failing"}, {"id": "O_crit", "name": "Critical"}, {"id": "O_pass", "name": "Passing"}]},
+	{"id": "F_risk", "name": "Risk", "options": [{"id": "O_high", "name": "High"}, {"id": "O_safe", "name": "Safe"}]}
+]}}}}}`
+
+func TestBoard(t *testing.T) {
+	var mu sync.Mutex
+	var mutations []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "/graphql", r.URL.Path)
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch {
+		case strings.Contains(req.Query, "repositoryOwner"):
+			if req.Variables["number"] != float64(3) {
+				_, _ = w.Write([]byte(`{"data": {"repositoryOwner": {"projectV2": null}}}`))
+				return
+			}
+			assert.Equal(t, "acme", req.Variables["owner"], "the repository owner owns the project by default")
+			_, _ = w.Write([]byte(projectResponse))
+		case strings.Contains(req.Query, "addProjectV2ItemById"):
+			mutations = append(mutations, req.Variables)
+			_, _ = w.Write([]byte(`{"data": {"addProjectV2ItemById": {"item": {"id": "PVTI_9"}}}}`))
+		default:
+			mutations = append(mutations, req.Variables)
+			_, _ = w.Write([]byte(`{"data": {}}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(t, server.URL)
+
+	_, err := client.OpenBoard(ctx, ProjectConfig{Number: 4})
+	assert.ErrorContains(t, err, "project acme/4 not found")
+	_, err = client.OpenBoard(ctx, ProjectConfig{Number: 3, Passing: "Done"})
+	assert.ErrorContains(t, err, `field "Status" of project acme/3 has no option "Done"`)
+	_, err = client.OpenBoard(ctx, ProjectConfig{Number: 3, RiskField: "Severity"})
+	assert.ErrorContains(t, err, `no single-select field "Severity"`)
+
+	board, err := client.OpenBoard(ctx, ProjectConfig{Number: 3, RiskColumns: map[string]string{"high": "critical"}, RiskField: "risk"})
+	require.NoError(t, err)
+	assert.Equal(t, "critical", board.Column("HIGH", false))
+	assert.Equal(t, "Failing", board.Column("medium", false))
+	assert.Equal(t, "Passing", board.Column("high", true))
+
+	require.NoError(t, client.Place(ctx, board, "I_7", "high", false))
+	require.Len(t, mutations, 3)
+	assert.Equal(t, map[string]any{"project": "PVT_1", "content": "I_7"}, mutations[0])
+	assert.Equal(t, map[string]any{"project": "PVT_1", "item": "PVTI_9", "field": "F_status", "option": "O_crit"}, mutations[1])
+	assert.Equal(t, "O_high", mutations[2]["option"])
+
+	mutations = nil
+	require.NoError(t, client.Place(ctx, board, "I_7", "medium", true))
+	require.Len(t, mutations, 2, "risks without an option are not set")
+	assert.Equal(t, "O_pass", mutations[1]["option"])
+}
+
+func TestProjectConfig_Validate(t *testing.T) {
+	cfg := ProjectConfig{}
+	assert.False(t, cfg.Enabled())
+	assert.NoError(t, cfg.Validate())
+
+	cfg = ProjectConfig{Number: 3, RiskColumns: map[string]string{"critical": "Fire"}}
+	assert.True(t, cfg.Enabled())
+	assert.ErrorContains(t, cfg.Validate(), "github.project.risk_columns")
+	assert.ErrorContains(t, (&ProjectConfig{Number: -1}).Validate(), "github.project.number")
+}
@@ -0,0 +1,200 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Defaults of Limits, following GitHub's guidance for content-creating
+// requests: at least a second apart, and a minute's wait after hitting a
+// secondary rate limit
+const (
+	DefaultPacing  = time.Second
+	DefaultMaxWait = 15 * time.Minute
+	// secondaryBackoff is the first wait after a secondary rate limit
+	// without Retry-After, doubled on each retry
+	secondaryBackoff = time.Minute
+	// maxRateLimitRetries bounds the retries of a rate-limited request
+	maxRateLimitRetries = 5
+)
+
+// ErrIssueLimit is returned when opening an issue would exceed
+// Limits.MaxIssues
+var ErrIssueLimit = errors.New("issue limit of the run reached")
+
+// Limits paces the requests of the clients sharing it, so that large runs
+// stay clear of GitHub's abuse detection, and caps the issues they open.
+// Share one Limits between the clients of a run.
+type Limits struct {
+	// Pacing is the least time between two content-creating (POST, PATCH,
+	// PUT, DELETE) requests
+	Pacing time.Duration
+	// MaxWait bounds the wait for a rate limit to lift; a request limited
+	// for longer fails
+	MaxWait time.Duration
+	// MaxIssues caps the failure issues opened, 0 for no cap. Subtasks,
+	// and issues reopened or updated, do not count.
+	MaxIssues int
+
+	mu sync.Mutex
+	// nextWrite is when the next content-creating request may be sent and
+	// blockedUntil when a rate limit lifts, for every request
+	nextWrite    time.Time
+	blockedUntil time.Time
+	opened       int
+	now          func() time.Time
+	sleep        func(ctx context.Context, d time.Duration) error
+}
+
+// NewLimits returns limits pacing content-creating requests pacing apart
+// and capping the run at maxIssues issues (0 for no cap)
+func NewLimits(pacing, maxWait time.Duration, maxIssues int) *Limits {
+	return &Limits{Pacing: pacing, MaxWait: maxWait, MaxIssues: maxIssues, now: time.Now, sleep: sleepContext}
+}
+
+// DefaultLimits returns the limits of a client without SetLimits
+func DefaultLimits() *Limits {
+	return NewLimits(DefaultPacing, DefaultMaxWait, 0)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Opened returns how many issues were opened under the limits
+func (l *Limits) Opened() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.opened
+}
+
+// takeIssue counts an issue about to be opened, or returns ErrIssueLimit
+func (l *Limits) takeIssue() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.MaxIssues > 0 && l.opened >= l.MaxIssues {
+		return ErrIssueLimit
+	}
+	l.opened++
+	return nil
+}
+
+// wait blocks until a request may be sent: after any rate limit lifts and,
+// for content-creating requests, Pacing after the previous one
+func (l *Limits) wait(ctx context.Context, write bool) error {
+	l.mu.Lock()
+	now := l.now()
+	at := l.blockedUntil
+	if write {
+		if l.nextWrite.After(at) {
+			at = l.nextWrite
+		}
+		// Reserve the slot, so concurrent writers queue up
+		slot := at
+		if slot.Before(now) {
+			slot = now
+		}
+		l.nextWrite = slot.Add(l.Pacing)
+	}
+	l.mu.Unlock()
+	return l.sleep(ctx, at.Sub(now))
+}
+
+// block holds every request of the clients sharing l for d
+func (l *Limits) block(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// rateLimitTransport paces the requests of a client by its limits and
+// retries those GitHub rejects for a primary or secondary rate limit
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	limits func() *Limits
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limits := t.limits()
+	write := req.Method != http.MethodGet && req.Method != http.MethodHead
+	for attempt := 0; ; attempt++ {
+		if err := limits.wait(req.Context(), write); err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, limited := rateLimitWait(resp, attempt, limits.now())
+		if !limited || attempt >= maxRateLimitRetries || wait > limits.MaxWait || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		log.Warn().
+			Str("method", req.Method).
+			Str("path", req.URL.Path).
+			Dur("retry_after", wait).
+			Int("attempt", attempt+1).
+			Msg("GitHub rate limit hit - waiting before retrying")
+		limits.block(wait)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// rateLimitWait reports whether resp rejects a request for a rate limit,
+// and how long to wait before retrying it: its Retry-After, the reset of an
+// exhausted primary limit, or an exponential backoff for secondary limits
+func rateLimitWait(resp *http.Response, attempt int, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0).Sub(now) + time.Second, true
+		}
+	}
+
+	// Secondary limits are told apart from permission errors by their message
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if resp.StatusCode != http.StatusTooManyRequests && (err != nil || !strings.Contains(strings.ToLower(string(body)), "secondary rate limit")) {
+		return 0, false
+	}
+	return secondaryBackoff << attempt, true
+}
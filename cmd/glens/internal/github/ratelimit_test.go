@@ -0,0 +1,116 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock makes limits sleep on a clock that only moves when they sleep,
+// recording each wait
+func fakeClock(limits *Limits) *[]time.Duration {
+	now := time.Unix(1700000000, 0)
+	var waits []time.Duration
+	limits.now = func() time.Time { return now }
+	limits.sleep = func(_ context.Context, d time.Duration) error {
+		if d > 0 {
+			waits = append(waits, d)
+			now = now.Add(d)
+		}
+		return nil
+	}
+	return &waits
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[r.URL.Path]++
+		switch r.URL.Path {
+		case "/repos/acme/api/issues/1/comments":
+			if calls[r.URL.Path] == 1 {
+				w.Header().Set("Retry-After", "30")
+				http.Error(w, `{"message": "You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+				return
+			}
+		case "/repos/acme/api/issues/2/comments":
+			if calls[r.URL.Path] <= 2 {
+				http.Error(w, `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes"}`, http.StatusForbidden)
+				return
+			}
+		case "/repos/acme/api/issues/3/comments":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Unix(1700000000, 0).Add(time.Hour).Unix(), 10))
+			http.Error(w, `{"message": "API rate limit exceeded"}`, http.StatusForbidden)
+			return
+		case "/repos/acme/api/issues/4/comments":
+			http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+			return
+		case "/repos/acme/api/issues":
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(t, server.URL)
+	limits := NewLimits(time.Second, 10*time.Minute, 0)
+	waits := fakeClock(limits)
+	client.SetLimits(limits)
+
+	require.NoError(t, client.UpdateIssueWithResults(ctx, 1, "results"))
+	assert.Equal(t, 2, calls["/repos/acme/api/issues/1/comments"])
+	assert.Equal(t, []time.Duration{30 * time.Second}, *waits, "Retry-After is honoured")
+
+	*waits = nil
+	require.NoError(t, client.UpdateIssueWithResults(ctx, 2, "results"))
+	assert.Equal(t, 3, calls["/repos/acme/api/issues/2/comments"])
+	assert.Equal(t, []time.Duration{time.Second, time.Minute, 2 * time.Minute}, *waits, "paced after the last write, secondary limits back off exponentially")
+
+	*waits = nil
+	assert.Error(t, client.UpdateIssueWithResults(ctx, 3, "results"), "limits lifting after MaxWait fail")
+	assert.Equal(t, 1, calls["/repos/acme/api/issues/3/comments"])
+	assert.Error(t, client.UpdateIssueWithResults(ctx, 4, "results"))
+	assert.Equal(t, 1, calls["/repos/acme/api/issues/4/comments"], "permission errors are not retried")
+
+	// Writes are paced a second apart, reads are not
+	require.NoError(t, client.UpdateIssueWithResults(ctx, 5, "results"))
+	*waits = nil
+	require.NoError(t, client.UpdateIssueWithResults(ctx, 5, "results"))
+	require.NoError(t, client.UpdateIssueWithResults(ctx, 5, "results"))
+	_, err := client.ListIssuesByLabel(ctx, []string{"test-failure"})
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, *waits)
+}
+
+func TestLimits_MaxIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"number": 1}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	limits := NewLimits(0, DefaultMaxWait, 1)
+	client.SetLimits(limits)
+
+	failure := &Failure{Endpoint: templateEndpoint()}
+	_, err := client.CreateEndpointIssue(context.Background(), failure)
+	require.NoError(t, err)
+	_, err = client.CreateEndpointIssue(context.Background(), failure)
+	assert.ErrorIs(t, err, ErrIssueLimit)
+	_, _, err = client.ReportFailure(context.Background(), nil, failure)
+	assert.ErrorIs(t, err, ErrIssueLimit)
+	assert.Equal(t, 1, limits.Opened())
+}
@@ -0,0 +1,274 @@
+package github
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/parser"
+)
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// Default title formats of issues and subtasks
+const (
+	DefaultIssueTitle   = "❌ Test Failure: {{.Method}} {{.Path}}"
+	DefaultSubtaskTitle = "[{{.Model}}] Generate tests for {{.Method}} {{.Path}}"
+)
+
+// DefaultIssueLabels are the labels of every issue and subtask when
+// IssueConfig.Labels is empty
+var DefaultIssueLabels = []string{"integration-test", "ai-generated", "openapi"}
+
+// IssueConfig is how issues are written, read from the github config
+// section. Titles and bodies are Go text/templates executed with IssueData.
+type IssueConfig struct {
+	// TitleFormat and SubtaskTitleFormat are the templates of the titles
+	// of issues and subtasks (default DefaultIssueTitle and
+	// DefaultSubtaskTitle)
+	TitleFormat        string `mapstructure:"issue_title"`
+	SubtaskTitleFormat string `mapstructure:"subtask_title"`
+	// BodyTemplate and SubtaskBodyTemplate are files of templates replacing
+	// the built-in issue and subtask bodies
+	BodyTemplate        string `mapstructure:"issue_template"`
+	SubtaskBodyTemplate string `mapstructure:"subtask_template"`
+	// Labels replace DefaultIssueLabels. Issues are also labelled
+	// test-failure, subtasks subtask and their model, and both the
+	// endpoint's method.
+	Labels    []string `mapstructure:"issue_labels"`
+	Assignees []string `mapstructure:"issue_assignees"`
+	// Rules add labels and assignees to the issues of matching endpoints
+	Rules []IssueRule `mapstructure:"issue_rules"`
+	// Attachments is how the failing tests are attached to issues:
+	// AttachNone (default), AttachInline or AttachGist
+	Attachments string `mapstructure:"attachments"`
+}
+
+// IssueRule adds labels and assignees to the issues and subtasks of the
+// endpoints with one of its tags, categories or risks. An endpoint must
+// match every non-empty list of the rule.
+type IssueRule struct {
+	Tags []string `mapstructure:"tags"`
+	// Categories are safety categories: read, write, mutate or destroy
+	Categories []string `mapstructure:"categories"`
+	// Risks are risk levels: safe, medium or high
+	Risks     []string `mapstructure:"risks"`
+	Labels    []string `mapstructure:"labels"`
+	Assignees []string `mapstructure:"assignees"`
+}
+
+// IssueData is the data issue templates are executed with. The endpoint's
+// fields are promoted, so templates use {{.Method}}, {{.Path}},
+// {{.OperationID}}, {{.Tags}}, {{.Parameters}} and the like directly.
+type IssueData struct {
+	*parser.Endpoint
+	// Models are the models whose tests failed
+	Models []string
+	// Model is the model of a subtask; empty for issues
+	Model string
+	// ParentIssue is the issue number of a subtask's issue; 0 for issues
+	ParentIssue int
+	// Results is the Markdown summary of the failed test runs, also
+	// commented on the issue
+	Results string
+	// Attachments are the failing tests of the models; they have a GistURL
+	// when they were uploaded as gists, and are empty unless configured
+	Attachments []Attachment
+	// Category is the endpoint's safety category: read, write, mutate or destroy
+	Category string
+	// Risk is the endpoint's risk level: safe, medium or high
+	Risk string
+}
+
+// IssueTemplates renders the titles and bodies of issues and subtasks and
+// picks their labels, assignees and attachments
+type IssueTemplates struct {
+	title, subtaskTitle, body, subtaskBody *template.Template
+	labels, assignees                      []string
+	rules                                  []IssueRule
+	attach                                 string
+}
+
+// templateFuncs are the functions issue templates may call besides the
+// text/template built-ins
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// DefaultIssueTemplates writes issues as glens always has
+var DefaultIssueTemplates = must(NewIssueTemplates(IssueConfig{}))
+
+func must(t *IssueTemplates, err error) *IssueTemplates {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// NewIssueTemplates parses the templates of cfg
+func NewIssueTemplates(cfg IssueConfig) (*IssueTemplates, error) {
+	t := &IssueTemplates{labels: cfg.Labels, assignees: cfg.Assignees, rules: cfg.Rules}
+	if len(t.labels) == 0 {
+		t.labels = DefaultIssueLabels
+	}
+	var err error
+	if t.attach, err = ParseAttachMode(cfg.Attachments); err != nil {
+		return nil, fmt.Errorf("github.attachments: %w", err)
+	}
+	for i, rule := range cfg.Rules {
+		for _, risk := range rule.Risks {
+			if _, err := safety.ParseRisk(risk); err != nil {
+				return nil, fmt.Errorf("github.issue_rules[%d]: %w", i, err)
+			}
+		}
+	}
+
+	if t.title, err = parseTitle("issue_title", cfg.TitleFormat, DefaultIssueTitle); err != nil {
+		return nil, err
+	}
+	if t.subtaskTitle, err = parseTitle("subtask_title", cfg.SubtaskTitleFormat, DefaultSubtaskTitle); err != nil {
+		return nil, err
+	}
+	if t.body, err = parseBody("issue_template", cfg.BodyTemplate, "issue.md.tmpl"); err != nil {
+		return nil, err
+	}
+	if t.subtaskBody, err = parseBody("subtask_template", cfg.SubtaskBodyTemplate, "subtask.md.tmpl"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseTitle parses the title template format, or fallback when it is empty
+func parseTitle(key, format, fallback string) (*template.Template, error) {
+	if format == "" {
+		format = fallback
+	}
+	tmpl, err := template.New(key).Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("github.%s: %w", key, err)
+	}
+	return tmpl, nil
+}
+
+// parseBody parses the body template in file, or the built-in template
+// named builtin when file is empty
+func parseBody(key, file, builtin string) (*template.Template, error) {
+	if file == "" {
+		return template.New(builtin).Funcs(templateFuncs).ParseFS(embeddedTemplates, "templates/"+builtin)
+	}
+	data, err := os.ReadFile(file) //nolint:gosec // the template path is configured by the user
+	if err != nil {
+		return nil, fmt.Errorf("github.%s: %w", key, err)
+	}
+	tmpl, err := template.New(key).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("github.%s: %w", key, err)
+	}
+	return tmpl, nil
+}
+
+// Attach returns how the failing tests are attached to issues
+func (t *IssueTemplates) Attach() string {
+	return t.attach
+}
+
+// newIssueData returns the data of the templates of an endpoint's issue
+func newIssueData(endpoint *parser.Endpoint, models []string, results string) IssueData {
+	category := safety.Categorise(endpoint.Method, endpoint.Path, endpoint.XSafe).WithRisk(endpoint.DeclaredRisk())
+	return IssueData{
+		Endpoint: endpoint,
+		Models:   models,
+		Results:  results,
+		Category: string(category.Category),
+		Risk:     string(category.Risk),
+	}
+}
+
+// Issue renders the title and body of an issue
+func (t *IssueTemplates) Issue(data IssueData) (title, body string, err error) {
+	return render(t.title, t.body, data)
+}
+
+// Subtask renders the title and body of a subtask
+func (t *IssueTemplates) Subtask(data IssueData) (title, body string, err error) {
+	return render(t.subtaskTitle, t.subtaskBody, data)
+}
+
+func render(titleTemplate, bodyTemplate *template.Template, data IssueData) (title, body string, err error) {
+	var buf bytes.Buffer
+	if err := titleTemplate.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed to render issue title: %w", err)
+	}
+	// Titles are one line
+	title = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := bodyTemplate.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("failed to render issue body: %w", err)
+	}
+	return title, strings.TrimSpace(buf.String()), nil
+}
+
+// Labels returns the labels of the issue (or subtask, with kind
+// "subtask" and its model among extra) of data's endpoint: the configured
+// labels, kind, extra, and those of the matching rules
+func (t *IssueTemplates) Labels(data IssueData, kind string, extra ...string) []string {
+	labels := slices.Clone(t.labels)
+	labels = append(labels, kind)
+	labels = append(labels, extra...)
+	for i := range t.rules {
+		if t.rules[i].matches(data) {
+			labels = append(labels, t.rules[i].Labels...)
+		}
+	}
+	return uniqueFold(labels)
+}
+
+// Assignees returns the assignees of the issues of data's endpoint: the
+// configured ones and those of the matching rules
+func (t *IssueTemplates) Assignees(data IssueData) []string {
+	assignees := slices.Clone(t.assignees)
+	for i := range t.rules {
+		if t.rules[i].matches(data) {
+			assignees = append(assignees, t.rules[i].Assignees...)
+		}
+	}
+	return uniqueFold(assignees)
+}
+
+// matches reports whether the rule applies to data's endpoint
+func (r *IssueRule) matches(data IssueData) bool {
+	if len(r.Tags) > 0 && !slices.ContainsFunc(data.Tags, func(tag string) bool {
+		return slices.ContainsFunc(r.Tags, func(want string) bool { return strings.EqualFold(want, tag) })
+	}) {
+		return false
+	}
+	if len(r.Categories) > 0 && !slices.ContainsFunc(r.Categories, func(c string) bool { return strings.EqualFold(c, data.Category) }) {
+		return false
+	}
+	if len(r.Risks) > 0 && !slices.ContainsFunc(r.Risks, func(risk string) bool { return strings.EqualFold(risk, data.Risk) }) {
+		return false
+	}
+	return true
+}
+
+// uniqueFold drops empty and repeated values, keeping the first of each
+// in order and comparing case-insensitively as GitHub does
+func uniqueFold(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value != "" && !slices.ContainsFunc(out, func(v string) bool { return strings.EqualFold(v, value) }) {
+			out = append(out, value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func templateEndpoint() *parser.Endpoint {
+	return &parser.Endpoint{
+		Method:      "DELETE",
+		Path:        "/pets/{id}",
+		OperationID: "deletePet",
+		Tags:        []string{"Pets"},
+		Parameters: []parser.Parameter{
+			{Name: "id", In: "path", Required: true, Description: "Pet ID", Schema: parser.Schema{Type: "string"}},
+		},
+		Responses: map[string]parser.Response{
+			"404": {Description: "Not found"},
+			"204": {Description: "Deleted"},
+		},
+	}
+}
+
+func TestDefaultIssueTemplates(t *testing.T) {
+	data := newIssueData(templateEndpoint(), []string{"gpt4", "ollama"}, "")
+
+	title, body, err := DefaultIssueTemplates.Issue(data)
+	require.NoError(t, err)
+	assert.Equal(t, "❌ Test Failure: DELETE /pets/{id}", title)
+	assert.Contains(t, body, "**Operation ID:** `deletePet`\n")
+	assert.Contains(t, body, "| `id` | `string` | `path` | Yes | Pet ID |\n")
+	assert.Contains(t, body, "| `204` | Deleted |\n| `404` | Not found |\n", "responses are sorted")
+	assert.Contains(t, body, "- ❌ **gpt4** - Tests failed (see subtask for details)\n- ❌ **ollama**")
+	assert.NotContains(t, body, "Request Body")
+	assert.True(t, len(body) > 0 && body[len(body)-1] == '*', "body ends with the footer")
+
+	data.Model, data.ParentIssue = "gpt4", 12
+	title, body, err = DefaultIssueTemplates.Subtask(data)
+	require.NoError(t, err)
+	assert.Equal(t, "[gpt4] Generate tests for DELETE /pets/{id}", title)
+	assert.Contains(t, body, "**Parent Issue:** #12\n")
+	assert.Contains(t, body, "- `id` (path, required): Pet ID\n\n**Response Validation:**\n- HTTP 204 response handling\n- HTTP 404 response handling\n\n### 🛠")
+
+	assert.Equal(t, []string{"integration-test", "ai-generated", "openapi", "test-failure", "delete"},
+		DefaultIssueTemplates.Labels(data, "test-failure", "delete"))
+	assert.Empty(t, DefaultIssueTemplates.Assignees(data))
+}
+
+func TestNewIssueTemplates_Custom(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "issue.md")
+	require.NoError(t, os.WriteFile(bodyFile, []byte("{{.OperationID}} ({{.Risk}}) failed for {{join .Models \", \"}}\n\n{{.Results}}\n"), 0o600))
+
+	templates, err := NewIssueTemplates(IssueConfig{
+		TitleFormat:  "[{{upper .Category}}] {{.Method}}\n{{.Path}}",
+		BodyTemplate: bodyFile,
+		Labels:       []string{"api", "bug"},
+		Assignees:    []string{"oncall"},
+		Rules: []IssueRule{
+			{Tags: []string{"pets"}, Labels: []string{"team:pets"}, Assignees: []string{"alice"}},
+			{Risks: []string{"high"}, Categories: []string{"destroy"}, Labels: []string{"P1", "BUG"}},
+			{Tags: []string{"pets"}, Risks: []string{"safe"}, Labels: []string{"never"}},
+		},
+	})
+	require.NoError(t, err)
+
+	data := newIssueData(templateEndpoint(), []string{"gpt4", "ollama"}, "### ❌ gpt4")
+	title, body, err := templates.Issue(data)
+	require.NoError(t, err)
+	assert.Equal(t, "[DESTROY] DELETE /pets/{id}", title)
+	assert.Equal(t, "deletePet (high) failed for gpt4, ollama\n\n### ❌ gpt4", body)
+	assert.Equal(t, []string{"api", "bug", "test-failure", "delete", "team:pets", "P1"}, templates.Labels(data, "test-failure", "delete"))
+	assert.Equal(t, []string{"oncall", "alice"}, templates.Assignees(data))
+
+	_, body, err = templates.Subtask(data)
+	require.NoError(t, err)
+	assert.Contains(t, body, "Integration Test Generation", "subtasks keep the built-in body")
+}
+
+func TestNewIssueTemplates_Invalid(t *testing.T) {
+	_, err := NewIssueTemplates(IssueConfig{TitleFormat: "{{.Method"})
+	assert.ErrorContains(t, err, "github.issue_title")
+
+	_, err = NewIssueTemplates(IssueConfig{SubtaskBodyTemplate: filepath.Join(t.TempDir(), "missing.md")})
+	assert.ErrorContains(t, err, "github.subtask_template")
+
+	_, err = NewIssueTemplates(IssueConfig{Rules: []IssueRule{{Risks: []string{"critical"}}}})
+	assert.ErrorContains(t, err, "github.issue_rules[0]")
+
+	templates, err := NewIssueTemplates(IssueConfig{TitleFormat: "{{.Unknown}}"})
+	require.NoError(t, err)
+	_, _, err = templates.Issue(newIssueData(templateEndpoint(), nil, ""))
+	assert.ErrorContains(t, err, "failed to render issue title")
+}
@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// caFile writes the certificate of server to a PEM bundle
+func caFile(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+	return file
+}
+
+func get(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	client, err := Client(time.Second, TLS{})
+	require.NoError(t, err)
+	assert.Error(t, get(client, server.URL), "the test CA is not trusted by default")
+
+	client, err = Client(time.Second, TLS{CAFile: caFile(t, server)})
+	require.NoError(t, err)
+	assert.NoError(t, get(client, server.URL))
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.NotNil(t, transport.Proxy, "proxy environment variables are honored")
+
+	client, err = Client(time.Second, TLS{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	assert.NoError(t, get(client, server.URL))
+
+	_, err = Client(time.Second, TLS{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "failed to read CA bundle")
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+	_, err = Client(time.Second, TLS{CAFile: empty})
+	assert.ErrorContains(t, err, "holds no PEM certificates")
+}
+
+// unrelatedCA writes a PEM bundle of a self-signed CA that signed nothing
+func unrelatedCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Corporate Proxy CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "corporate.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return file
+}
+
+func TestConfigure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	t.Cleanup(func() { require.NoError(t, Configure(TLS{})) })
+
+	require.NoError(t, Configure(TLS{CAFile: caFile(t, server)}))
+	assert.NoError(t, get(&http.Client{Timeout: time.Second}, server.URL), "clients without a transport trust the CA")
+
+	// A provider's CA bundle is trusted in addition to the default one
+	client, err := Client(time.Second, TLS{CAFile: unrelatedCA(t)})
+	require.NoError(t, err)
+	assert.NoError(t, get(client, server.URL))
+
+	require.NoError(t, Configure(TLS{}))
+	assert.Error(t, get(&http.Client{Timeout: time.Second}, server.URL))
+	client, err = Client(time.Second, TLS{CAFile: unrelatedCA(t)})
+	require.NoError(t, err)
+	assert.Error(t, get(client, server.URL))
+}
@@ -0,0 +1,80 @@
+// Package jobs runs analyses asynchronously and keeps their status and
+// reports in a pluggable store (in-memory, Redis or a storage.Store).
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+// Job status constants
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Errors returned by stores and queues
+var (
+	// ErrNotFound is returned for unknown job IDs or jobs without a report
+	ErrNotFound = errors.New("job not found")
+	// ErrQueueFull is returned when no more jobs can be accepted
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrStopped is returned for jobs submitted after the queue stopped
+	ErrStopped = errors.New("job queue stopped")
+	// ErrAbandoned fails jobs whose process stopped before finishing them
+	ErrAbandoned = errors.New("the server running the job stopped")
+)
+
+// Progress describes how far a running analysis has come
+type Progress struct {
+	EndpointsTotal     int    `json:"endpoints_total"`
+	EndpointsProcessed int    `json:"endpoints_processed"`
+	CurrentEndpoint    string `json:"current_endpoint,omitempty"`
+	CurrentModel       string `json:"current_model,omitempty"`
+}
+
+// Job is one asynchronous analysis run
+type Job struct {
+	ID      string `json:"id"`
+	Status  Status `json:"status"`
+	SpecURL string `json:"spec_url"`
+	// SpecName is the file name of an uploaded spec, stored at SpecURL
+	// until the job has run
+	SpecName          string     `json:"spec_name,omitempty"`
+	Models            []string   `json:"models,omitempty"`
+	ApprovedEndpoints []string   `json:"approved_endpoints,omitempty"`
+	SkippedEndpoints  []string   `json:"skipped_endpoints,omitempty"`
+	Progress          Progress   `json:"progress"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+	// UpdatedAt is refreshed while the job is queued or running, so jobs
+	// left unfinished by a stopped process can be told apart
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Finished reports whether the job reached a terminal state
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Store persists jobs and their reports
+type Store interface {
+	// Save creates or replaces a job
+	Save(ctx context.Context, job *Job) error
+	// Get returns a copy of the job or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+	// SaveReport stores the finished JSON report of a job
+	SaveReport(ctx context.Context, id string, report []byte) error
+	// Report returns the JSON report of a job or ErrNotFound
+	Report(ctx context.Context, id string) ([]byte, error)
+	// List returns all stored jobs
+	List(ctx context.Context) ([]*Job, error)
+}
@@ -0,0 +1,346 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/storage"
+)
+
+func TestMemoryStore_SaveGetReport(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0)
+
+	_, err := s.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	job := &Job{ID: "j1", Status: StatusQueued, SpecURL: "spec.json"}
+	require.NoError(t, s.Save(ctx, job))
+	job.Status = StatusRunning // stored copy must not change
+
+	got, err := s.Get(ctx, "j1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, got.Status)
+
+	_, err = s.Report(ctx, "j1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, s.SaveReport(ctx, "j1", []byte(`{}`)))
+	report, err := s.Report(ctx, "j1")
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(report))
+}
+
+func TestMemoryStore_EvictsExpiredJobs(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(time.Minute)
+
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, s.Save(ctx, &Job{ID: "old", Status: StatusSucceeded, FinishedAt: &old}))
+	require.NoError(t, s.Save(ctx, &Job{ID: "new", Status: StatusQueued}))
+
+	_, err := s.Get(ctx, "old")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Get(ctx, "new")
+	assert.NoError(t, err)
+}
+
+func TestObjectStore_SaveGetReportEvict(t *testing.T) {
+	ctx := context.Background()
+	objects := storage.NewFS(t.TempDir())
+	s := NewObjectStore(objects, time.Minute)
+
+	_, err := s.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Saves look for expired jobs once per interval
+	s.lastEvict = time.Now()
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, s.Save(ctx, &Job{ID: "old", Status: StatusSucceeded, FinishedAt: &old}))
+	require.NoError(t, s.SaveReport(ctx, "old", []byte(`{}`)))
+	_, err = s.Report(ctx, "old")
+	require.NoError(t, err)
+	s.lastEvict = time.Time{}
+	require.NoError(t, s.Save(ctx, &Job{ID: "j1", Status: StatusRunning, Progress: Progress{CurrentModel: "gpt4"}}))
+
+	job, err := s.Get(ctx, "j1")
+	require.NoError(t, err)
+	assert.Equal(t, "gpt4", job.Progress.CurrentModel)
+	_, err = s.Report(ctx, "j1")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// A second server on the same storage sees the job, the old one is gone
+	restarted := NewObjectStore(objects, time.Minute)
+	_, err = restarted.Get(ctx, "j1")
+	assert.NoError(t, err)
+	_, err = restarted.Get(ctx, "old")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = restarted.Report(ctx, "old")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// waitFinished polls the store until the job reaches a terminal state.
+func waitFinished(t *testing.T, s Store, id string) *Job {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		job, err := s.Get(context.Background(), id)
+		return err == nil && job.Finished()
+	}, 2*time.Second, 5*time.Millisecond)
+	job, _ := s.Get(context.Background(), id)
+	return job
+}
+
+func TestQueue_RunsJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		runErr     error
+		wantStatus Status
+		wantReport bool
+	}{
+		{"success", nil, StatusSucceeded, true},
+		{"failure", errors.New("spec unreachable"), StatusFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			store := NewMemoryStore(0)
+			q := NewQueue(store, func(_ context.Context, _ *Job, progress func(Progress)) ([]byte, error) {
+				progress(Progress{EndpointsTotal: 2, EndpointsProcessed: 2})
+				return []byte(`{"ok":true}`), tt.runErr
+			}, 1, 4)
+			q.Start(ctx)
+
+			require.NoError(t, q.Submit(ctx, &Job{ID: "j1", SpecURL: "spec.json"}))
+			job := waitFinished(t, store, "j1")
+
+			assert.Equal(t, tt.wantStatus, job.Status)
+			assert.Equal(t, 2, job.Progress.EndpointsProcessed)
+			assert.NotNil(t, job.StartedAt)
+			_, err := store.Report(ctx, "j1")
+			assert.Equal(t, tt.wantReport, err == nil)
+		})
+	}
+}
+
+func TestQueue_RejectsWhenFull(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	q := NewQueue(store, nil, 1, 1) // not started: nothing drains the queue
+
+	require.NoError(t, q.Submit(ctx, &Job{ID: "j1"}))
+	assert.ErrorIs(t, q.Submit(ctx, &Job{ID: "j2"}), ErrQueueFull)
+
+	job, err := store.Get(ctx, "j2")
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, job.Status)
+}
+
+func TestQueue_StopFailsPendingJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := NewMemoryStore(0)
+	started := make(chan struct{}, 1)
+	q := NewQueue(store, func(ctx context.Context, _ *Job, _ func(Progress)) ([]byte, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 1, 4)
+	q.Start(ctx)
+
+	require.NoError(t, q.Submit(ctx, &Job{ID: "running"}))
+	<-started
+	require.NoError(t, q.Submit(ctx, &Job{ID: "pending"}))
+	cancel()
+	q.Wait()
+
+	for _, id := range []string{"running", "pending"} {
+		job, err := store.Get(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, job.Status, id)
+		assert.NotEmpty(t, job.Error, id)
+	}
+
+	assert.ErrorIs(t, q.Submit(context.Background(), &Job{ID: "late"}), ErrStopped)
+	job, err := store.Get(context.Background(), "late")
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, job.Status)
+}
+
+func TestQueue_FailsJobsAbandonedByStoppedProcesses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now().UTC()
+	finished := now.Add(-time.Hour)
+	store := NewMemoryStore(0)
+	for _, job := range []*Job{
+		{ID: "stale-queued", Status: StatusQueued, UpdatedAt: now.Add(-time.Hour)},
+		{ID: "stale-running", Status: StatusRunning, UpdatedAt: now.Add(-time.Hour)},
+		{ID: "from-older-version", Status: StatusRunning},
+		{ID: "alive", Status: StatusRunning, UpdatedAt: now},
+		{ID: "done", Status: StatusSucceeded, UpdatedAt: finished, FinishedAt: &finished},
+	} {
+		require.NoError(t, store.Save(ctx, job))
+	}
+
+	q := NewQueue(store, func(ctx context.Context, _ *Job, _ func(Progress)) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 1, 4)
+	q.Start(ctx)
+
+	tests := []struct {
+		id         string
+		wantStatus Status
+	}{
+		{"stale-queued", StatusFailed},
+		{"stale-running", StatusFailed},
+		{"from-older-version", StatusFailed},
+		{"alive", StatusRunning},
+		{"done", StatusSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			job, err := store.Get(ctx, tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, job.Status)
+			if tt.wantStatus == StatusFailed {
+				assert.Equal(t, ErrAbandoned.Error(), job.Error)
+			}
+		})
+	}
+}
+
+func TestQueue_HeartbeatKeepsOwnJobsAlive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewMemoryStore(0)
+	require.NoError(t, store.Save(ctx, &Job{ID: "other", Status: StatusRunning, UpdatedAt: time.Now().UTC()}))
+	q := NewQueue(store, func(ctx context.Context, _ *Job, _ func(Progress)) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 1, 4)
+	q.heartbeat = 10 * time.Millisecond
+	q.Start(ctx)
+	require.NoError(t, q.Submit(ctx, &Job{ID: "own"}))
+
+	// The job of the other process goes stale, the running one of this
+	// queue is saved on every heartbeat
+	job := waitFinished(t, store, "other")
+	assert.Equal(t, ErrAbandoned.Error(), job.Error)
+	time.Sleep(50 * time.Millisecond)
+	job, err := store.Get(ctx, "own")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, job.Status)
+}
+
+func TestStores_List(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisStore, err := NewRedisStore("redis://"+server.Addr(), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = redisStore.Close() })
+
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{"memory", NewMemoryStore(0)},
+		{"object", NewObjectStore(storage.NewFS(t.TempDir()), 0)},
+		{"redis", redisStore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			all, err := tt.store.List(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, all)
+
+			require.NoError(t, tt.store.Save(ctx, &Job{ID: "j1", Status: StatusSucceeded}))
+			require.NoError(t, tt.store.SaveReport(ctx, "j1", []byte(`{}`)))
+			require.NoError(t, tt.store.Save(ctx, &Job{ID: "j2", Status: StatusQueued}))
+
+			all, err = tt.store.List(ctx)
+			require.NoError(t, err)
+			var ids []string
+			for _, job := range all {
+				ids = append(ids, job.ID)
+			}
+			assert.ElementsMatch(t, []string{"j1", "j2"}, ids)
+		})
+	}
+}
+
+func TestRedisStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	server.RequireAuth("secret")
+	s, err := NewRedisStore("redis://:secret@"+server.Addr()+"/0", time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	_, err = s.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Save(ctx, &Job{ID: "j1", Status: StatusRunning, Progress: Progress{CurrentModel: "gpt4"}}))
+	job, err := s.Get(ctx, "j1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, job.Status)
+	assert.Equal(t, "gpt4", job.Progress.CurrentModel)
+
+	require.NoError(t, s.SaveReport(ctx, "j1", []byte("{\"multi\":\"line\r\nvalue\"}")))
+	report, err := s.Report(ctx, "j1")
+	require.NoError(t, err)
+	assert.Equal(t, "{\"multi\":\"line\r\nvalue\"}", string(report))
+
+	server.FastForward(2 * time.Hour)
+	_, err = s.Get(ctx, "j1")
+	assert.ErrorIs(t, err, ErrNotFound, "jobs expire after the TTL")
+}
+
+func TestRedisStore_ConcurrentAccessAndReconnect(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	s, err := NewRedisStore("redis://"+server.Addr(), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("j%d", i)
+			assert.NoError(t, s.Save(ctx, &Job{ID: id, Status: StatusQueued}))
+			job, err := s.Get(ctx, id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, id, job.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Pooled connections dropped by a Redis restart are re-dialled
+	addr := server.Addr()
+	server.Close()
+	require.NoError(t, server.StartAddr(addr))
+	require.NoError(t, s.Save(ctx, &Job{ID: "after", Status: StatusQueued}))
+	_, err = s.Get(ctx, "after")
+	assert.NoError(t, err)
+}
+
+func TestNewRedisStore_InvalidURL(t *testing.T) {
+	for _, raw := range []string{"localhost:6379", "http://localhost", "redis://localhost/abc"} {
+		_, err := NewRedisStore(raw, 0)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps jobs in process memory. Finished jobs are evicted after
+// the configured TTL.
+type MemoryStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	jobs    map[string]*Job
+	reports map[string][]byte
+}
+
+// NewMemoryStore creates an in-memory store; a non-positive ttl keeps jobs forever
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:     ttl,
+		jobs:    make(map[string]*Job),
+		reports: make(map[string][]byte),
+	}
+}
+
+// Save creates or replaces a job
+func (s *MemoryStore) Save(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpired()
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil
+}
+
+// Get returns a copy of the job or ErrNotFound
+func (s *MemoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	stored := *job
+	return &stored, nil
+}
+
+// SaveReport stores the finished JSON report of a job
+func (s *MemoryStore) SaveReport(_ context.Context, id string, report []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports[id] = report
+	return nil
+}
+
+// Report returns the JSON report of a job or ErrNotFound
+func (s *MemoryStore) Report(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, ok := s.reports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return report, nil
+}
+
+// List returns copies of all stored jobs
+func (s *MemoryStore) List(_ context.Context) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		stored := *job
+		all = append(all, &stored)
+	}
+	return all, nil
+}
+
+// evictExpired drops finished jobs older than the TTL; callers hold s.mu
+func (s *MemoryStore) evictExpired() {
+	if s.ttl <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-s.ttl)
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			delete(s.reports, id)
+		}
+	}
+}
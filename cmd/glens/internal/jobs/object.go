@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"glens/tools/glens/internal/storage"
+)
+
+const (
+	// objectKeyPrefix holds a directory of objects per job
+	objectKeyPrefix = "jobs/"
+	jobObject       = "/job.json"
+	reportObject    = "/report.json"
+	// objectEvictInterval is how often saves look for expired jobs, each
+	// look reading every job of the store
+	objectEvictInterval = 10 * time.Minute
+)
+
+// ObjectStore keeps jobs and reports as objects of a storage.Store: files,
+// a SQLite database or object storage, so they survive restarts of the
+// server. Finished jobs are deleted after the configured TTL.
+type ObjectStore struct {
+	store storage.Store
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	lastEvict time.Time
+}
+
+// NewObjectStore creates a store of jobs in store; a non-positive ttl keeps
+// jobs forever
+func NewObjectStore(store storage.Store, ttl time.Duration) *ObjectStore {
+	return &ObjectStore{store: store, ttl: ttl}
+}
+
+// Save creates or replaces a job
+func (s *ObjectStore) Save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode job: %w", err)
+	}
+	if err := s.store.Put(ctx, objectKeyPrefix+job.ID+jobObject, "application/json", data); err != nil {
+		return err
+	}
+	s.evictExpired(ctx)
+	return nil
+}
+
+// Get returns the job or ErrNotFound
+func (s *ObjectStore) Get(ctx context.Context, id string) (*Job, error) {
+	data, err := s.get(ctx, objectKeyPrefix+id+jobObject)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("decode job: %w", err)
+	}
+	return &job, nil
+}
+
+// SaveReport stores the finished JSON report of a job
+func (s *ObjectStore) SaveReport(ctx context.Context, id string, report []byte) error {
+	return s.store.Put(ctx, objectKeyPrefix+id+reportObject, "application/json", report)
+}
+
+// Report returns the JSON report of a job or ErrNotFound
+func (s *ObjectStore) Report(ctx context.Context, id string) ([]byte, error) {
+	return s.get(ctx, objectKeyPrefix+id+reportObject)
+}
+
+// List returns all stored jobs, skipping objects that vanish or cannot be
+// decoded while listing
+func (s *ObjectStore) List(ctx context.Context) ([]*Job, error) {
+	keys, err := s.store.List(ctx, objectKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var all []*Job
+	for _, key := range keys {
+		id, ok := strings.CutSuffix(strings.TrimPrefix(key, objectKeyPrefix), jobObject)
+		if !ok {
+			continue
+		}
+		if job, err := s.Get(ctx, id); err == nil {
+			all = append(all, job)
+		}
+	}
+	return all, nil
+}
+
+// get reads an object, turning missing objects into ErrNotFound
+func (s *ObjectStore) get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// evictExpired deletes finished jobs older than the TTL, at most once per
+// objectEvictInterval; failures are left to the next look
+func (s *ObjectStore) evictExpired(ctx context.Context) {
+	if s.ttl <= 0 {
+		return
+	}
+	s.mu.Lock()
+	if time.Since(s.lastEvict) < objectEvictInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastEvict = time.Now()
+	s.mu.Unlock()
+
+	all, err := s.List(ctx)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-s.ttl)
+	for _, job := range all {
+		if job.FinishedAt == nil || !job.FinishedAt.Before(cutoff) {
+			continue
+		}
+		_ = s.store.Delete(ctx, objectKeyPrefix+job.ID+reportObject)
+		_ = s.store.Delete(ctx, objectKeyPrefix+job.ID+jobObject)
+	}
+}
@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Runner executes the analysis of a job and returns its JSON report.
+// progress may be called any number of times while the job runs.
+type Runner func(ctx context.Context, job *Job, progress func(Progress)) ([]byte, error)
+
+// HeartbeatInterval is how often a queue saves its queued and running jobs
+// again. Unfinished jobs nobody saved for three intervals were left behind
+// by a process that stopped, and are failed.
+const HeartbeatInterval = time.Minute
+
+// Queue hands submitted jobs to a fixed pool of workers
+type Queue struct {
+	store     Store
+	runner    Runner
+	workers   int
+	pending   chan *Job
+	wg        sync.WaitGroup
+	heartbeat time.Duration
+
+	// mu guards the jobs of this process while they change and are saved
+	mu      sync.Mutex
+	active  map[string]*Job
+	stopped bool
+}
+
+// NewQueue creates a queue with the given number of workers and capacity
+// for pending jobs
+func NewQueue(store Store, runner Runner, workers, capacity int) *Queue {
+	return &Queue{
+		store:     store,
+		runner:    runner,
+		workers:   max(workers, 1),
+		pending:   make(chan *Job, max(capacity, 1)),
+		heartbeat: HeartbeatInterval,
+		active:    make(map[string]*Job),
+	}
+}
+
+// Start fails the jobs a previous process left unfinished and launches the
+// workers. When ctx is cancelled the workers stop, running jobs see the
+// cancellation and pending jobs are failed.
+func (q *Queue) Start(ctx context.Context) {
+	q.reconcile(ctx)
+
+	for range q.workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-q.pending:
+					q.run(ctx, job)
+				}
+			}
+		}()
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				q.stop(ctx)
+				return
+			case <-ticker.C:
+				q.touch(ctx)
+				q.reconcile(ctx)
+			}
+		}
+	}()
+}
+
+// Wait blocks until all workers have stopped
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// Submit stores a new job as queued and schedules it
+func (q *Queue) Submit(ctx context.Context, job *Job) error {
+	job.Status = StatusQueued
+	job.CreatedAt = time.Now().UTC()
+	job.UpdatedAt = job.CreatedAt
+	if err := q.store.Save(ctx, job); err != nil {
+		return fmt.Errorf("save job: %w", err)
+	}
+
+	q.mu.Lock()
+	err := ErrStopped
+	if !q.stopped {
+		select {
+		case q.pending <- job:
+			q.active[job.ID] = job
+			q.mu.Unlock()
+			return nil
+		default:
+			err = ErrQueueFull
+		}
+	}
+	q.mu.Unlock()
+
+	q.finish(ctx, job, fmt.Errorf("rejected: %w", err))
+	return err
+}
+
+// run executes one job and records its outcome
+func (q *Queue) run(ctx context.Context, job *Job) {
+	q.update(ctx, job, func() {
+		started := time.Now().UTC()
+		job.Status = StatusRunning
+		job.StartedAt = &started
+	})
+
+	report, err := q.runner(ctx, job, func(p Progress) {
+		q.update(ctx, job, func() { job.Progress = p })
+	})
+	if err == nil {
+		if saveErr := q.store.SaveReport(ctx, job.ID, report); saveErr != nil {
+			err = fmt.Errorf("save report: %w", saveErr)
+		}
+	}
+
+	q.finish(ctx, job, err)
+}
+
+// finish marks a job as succeeded or failed
+func (q *Queue) finish(ctx context.Context, job *Job, err error) {
+	// Record the outcome even when shutdown cancelled the run
+	q.update(context.WithoutCancel(ctx), job, func() {
+		finished := time.Now().UTC()
+		job.FinishedAt = &finished
+		job.Status = StatusSucceeded
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		}
+		delete(q.active, job.ID)
+	})
+
+	log.Info().
+		Str("job_id", job.ID).
+		Str("status", string(job.Status)).
+		Str("error", job.Error).
+		Msg("job finished")
+}
+
+// stop refuses further jobs and fails the pending ones, which no worker
+// will run any more
+func (q *Queue) stop(ctx context.Context) {
+	q.mu.Lock()
+	q.stopped = true
+	q.mu.Unlock()
+
+	for {
+		select {
+		case job := <-q.pending:
+			q.finish(ctx, job, fmt.Errorf("not run: %w", ErrStopped))
+		default:
+			return
+		}
+	}
+}
+
+// touch saves the queued and running jobs of this process, showing other
+// processes sharing the store that they are still looked after
+func (q *Queue) touch(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.active {
+		q.save(ctx, job)
+	}
+}
+
+// reconcile fails unfinished jobs of other processes that have not been
+// saved for three heartbeats: the process queueing or running them stopped
+func (q *Queue) reconcile(ctx context.Context) {
+	all, err := q.store.List(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to list jobs left unfinished")
+		return
+	}
+	cutoff := time.Now().Add(-3 * q.heartbeat)
+	for _, job := range all {
+		if job.Finished() || job.UpdatedAt.After(cutoff) {
+			continue
+		}
+		q.mu.Lock()
+		_, ours := q.active[job.ID]
+		q.mu.Unlock()
+		if !ours {
+			q.finish(ctx, job, ErrAbandoned)
+		}
+	}
+}
+
+// update changes a job and saves it while holding q.mu, so heartbeats never
+// save a job halfway through a change
+func (q *Queue) update(ctx context.Context, job *Job, change func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	change()
+	q.save(ctx, job)
+}
+
+// save persists job state, logging failures: a status update must not abort
+// the analysis itself. Callers hold q.mu.
+func (q *Queue) save(ctx context.Context, job *Job) {
+	job.UpdatedAt = time.Now().UTC()
+	if err := q.store.Save(ctx, job); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("failed to save job state")
+	}
+}
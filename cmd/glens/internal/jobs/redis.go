@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces all keys written by the store
+const redisKeyPrefix = "glens:job:"
+
+// RedisStore keeps jobs in Redis so every replica of the API can answer
+// status and report requests. The client pools connections, so concurrent
+// job updates and status requests do not wait on each other, and re-dials
+// after network errors.
+type RedisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a store from a redis://[:password@]host:port[/db]
+// URL, or a rediss:// one for TLS. Keys expire after ttl; a non-positive
+// ttl keeps them forever.
+func NewRedisStore(rawURL string, ttl time.Duration) (*RedisStore, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL %q: expected redis://[:password@]host:port[/db]: %w", rawURL, err)
+	}
+	return &RedisStore{client: redis.NewClient(opts), ttl: ttl}, nil
+}
+
+// Close closes the connections to Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// Save creates or replaces a job
+func (s *RedisStore) Save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode job: %w", err)
+	}
+	return s.set(ctx, redisKeyPrefix+job.ID, data)
+}
+
+// Get returns the job or ErrNotFound
+func (s *RedisStore) Get(ctx context.Context, id string) (*Job, error) {
+	data, err := s.get(ctx, redisKeyPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("decode job: %w", err)
+	}
+	return &job, nil
+}
+
+// SaveReport stores the finished JSON report of a job
+func (s *RedisStore) SaveReport(ctx context.Context, id string, report []byte) error {
+	return s.set(ctx, redisKeyPrefix+id+":report", report)
+}
+
+// Report returns the JSON report of a job or ErrNotFound
+func (s *RedisStore) Report(ctx context.Context, id string) ([]byte, error) {
+	return s.get(ctx, redisKeyPrefix+id+":report")
+}
+
+// List returns all stored jobs, skipping jobs that expire while listing
+func (s *RedisStore) List(ctx context.Context) ([]*Job, error) {
+	var all []*Job
+	iter := s.client.Scan(ctx, 0, redisKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		id := strings.TrimPrefix(iter.Val(), redisKeyPrefix)
+		if strings.HasSuffix(id, ":report") {
+			continue
+		}
+		job, err := s.Get(ctx, id)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		all = append(all, job)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("redis scan: %w", err)
+	}
+	return all, nil
+}
+
+func (s *RedisStore) set(ctx context.Context, key string, value []byte) error {
+	ttl := s.ttl
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := s.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("redis get %s: %w", key, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisStore_Integration runs the store against the Redis at REDIS_URL:
+//
+//	REDIS_URL=redis://localhost:6379/15 go test -tags integration ./internal/jobs
+func TestRedisStore_Integration(t *testing.T) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL is not set")
+	}
+	ctx := context.Background()
+	s, err := NewRedisStore(url, time.Minute)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	prefix := fmt.Sprintf("it-%d-", time.Now().UnixNano())
+	_, err = s.Get(ctx, prefix+"missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("%s%d", prefix, i)
+			assert.NoError(t, s.Save(ctx, &Job{ID: id, Status: StatusRunning}))
+			assert.NoError(t, s.SaveReport(ctx, id, []byte(`{"id":"`+id+`"}`)))
+			job, err := s.Get(ctx, id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, id, job.ID)
+			}
+			report, err := s.Report(ctx, id)
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, `{"id":"`+id+`"}`, string(report))
+			}
+		}()
+	}
+	wg.Wait()
+}
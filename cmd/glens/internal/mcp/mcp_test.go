@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/reporter"
+)
+
+const sampleSpec = "../../../../test_specs/sample_api.json"
+
+// decoded is a response with the result kept raw for per-test decoding.
+type decoded struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func call(t *testing.T, s *Server, msg string) decoded {
+	t.Helper()
+	out := s.Handle(context.Background(), []byte(msg))
+	require.NotNil(t, out)
+	var resp decoded
+	require.NoError(t, json.Unmarshal(out, &resp))
+	return resp
+}
+
+// callTool invokes a tool and returns its text content and error flag.
+func callTool(t *testing.T, s *Server, name, args string) (string, bool) {
+	t.Helper()
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`)
+	require.Nil(t, resp.Error)
+	var result toolResult
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	require.Len(t, result.Content, 1)
+	return result.Content[0].Text, result.IsError
+}
+
+func echoServer() *Server {
+	return New(Info{Name: "glens", Version: "test"}, Tool{
+		Name:        "echo",
+		Description: "Echo the message",
+		InputSchema: schema(map[string]any{"message": map[string]any{"type": "string"}}, "message"),
+		Handler: func(_ context.Context, args json.RawMessage) (any, error) {
+			var in struct{ Message string }
+			_ = json.Unmarshal(args, &in)
+			if in.Message == "" {
+				return nil, errors.New("message is required")
+			}
+			return in.Message, nil
+		},
+	})
+}
+
+func TestHandle_Protocol(t *testing.T) {
+	s := echoServer()
+
+	resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+	require.Nil(t, resp.Error)
+	assert.JSONEq(t, `1`, string(resp.ID))
+	assert.Contains(t, string(resp.Result), `"protocolVersion":"2024-11-05"`)
+	assert.Contains(t, string(resp.Result), `"name":"glens"`)
+
+	resp = call(t, s, `{"jsonrpc":"2.0","id":"a","method":"tools/list"}`)
+	require.Nil(t, resp.Error)
+	assert.JSONEq(t, `"a"`, string(resp.ID))
+	assert.Contains(t, string(resp.Result), `"inputSchema"`)
+
+	assert.Nil(t, s.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+}
+
+func TestHandle_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		wantCode int
+	}{
+		{"malformed json", `{`, codeParseError},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"method":"ping"}`, codeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"nope"}`, codeMethodNotFound},
+		{"unknown tool", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, codeInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(t, echoServer(), tt.msg)
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, tt.wantCode, resp.Error.Code)
+		})
+	}
+}
+
+func TestToolCall_ErrorIsReportedInResult(t *testing.T) {
+	s := echoServer()
+
+	text, isError := callTool(t, s, "echo", `{"message":"hi"}`)
+	assert.False(t, isError)
+	assert.Equal(t, "hi", text)
+
+	text, isError = callTool(t, s, "echo", `{}`)
+	assert.True(t, isError)
+	assert.Equal(t, "message is required", text)
+}
+
+func TestServeStdio(t *testing.T) {
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}` + "\n")
+	var out bytes.Buffer
+
+	require.NoError(t, echoServer().ServeStdio(context.Background(), in, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2, "notifications get no reply")
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":{}}`, lines[0])
+	assert.Contains(t, lines[1], `"echo"`)
+}
+
+const sseToken = "s3cret"
+
+// sseRequest builds a request to the SSE transport carrying sseToken
+func sseRequest(t *testing.T, ctx context.Context, method, url, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+sseToken)
+	return req
+}
+
+func TestSSEHandler(t *testing.T) {
+	ts := httptest.NewServer(echoServer().SSEHandler(SSEOptions{Token: sseToken}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := http.DefaultClient.Do(sseRequest(t, ctx, http.MethodGet, ts.URL+"/sse", ""))
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(stream.Body)
+	endpoint := readEvent(t, events, "endpoint")
+	require.True(t, strings.HasPrefix(endpoint, "/message?sessionId="))
+
+	post, err := http.DefaultClient.Do(sseRequest(t, ctx, http.MethodPost, ts.URL+endpoint,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"echo","arguments":{"message":"over sse"}}}`))
+	require.NoError(t, err)
+	_ = post.Body.Close()
+	assert.Equal(t, http.StatusAccepted, post.StatusCode)
+
+	msg := readEvent(t, events, "message")
+	assert.Contains(t, msg, `"id":7`)
+	assert.Contains(t, msg, "over sse")
+
+	unknown, err := http.DefaultClient.Do(sseRequest(t, ctx, http.MethodPost, ts.URL+"/message?sessionId=nope", `{}`))
+	require.NoError(t, err)
+	_ = unknown.Body.Close()
+	assert.Equal(t, http.StatusNotFound, unknown.StatusCode)
+}
+
+func TestSSEHandler_RejectsUntrustedRequests(t *testing.T) {
+	handler := echoServer().SSEHandler(SSEOptions{Token: sseToken, AllowedHosts: []string{"mcp.internal"}})
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		token  string
+		want   int
+	}{
+		{"no token", "127.0.0.1:8090", "", "", http.StatusUnauthorized},
+		{"wrong token", "localhost:8090", "", "guess", http.StatusUnauthorized},
+		{"rebound host", "attacker.example:8090", "", sseToken, http.StatusForbidden},
+		{"foreign origin", "127.0.0.1:8090", "https://attacker.example", sseToken, http.StatusForbidden},
+		{"null origin", "127.0.0.1:8090", "null", sseToken, http.StatusForbidden},
+		{"loopback origin", "[::1]:8090", "http://localhost:3000", sseToken, http.StatusNotFound},
+		{"allowed host", "mcp.internal:8090", "http://MCP.internal", sseToken, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/message?sessionId=nope", strings.NewReader(`{}`))
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+	req.Host = "127.0.0.1"
+	req.Header.Set("Authorization", "Bearer ")
+	echoServer().SSEHandler(SSEOptions{}).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "a handler without a token rejects everything")
+}
+
+// readEvent reads SSE lines until an event of the given type and returns
+// its data.
+func readEvent(t *testing.T, r *bufio.Reader, event string) string {
+	t.Helper()
+	current := ""
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: ") && current == event:
+			return strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func glensServer(t *testing.T) *Server {
+	t.Helper()
+	manager, err := ai.NewManager([]string{"mock"}, ai.Config{})
+	require.NoError(t, err)
+	return New(Info{Name: "glens"}, Tools(Backend{
+		AI:     manager,
+		Models: []string{"mock"},
+		Report: func(_ context.Context, req ReportRequest) (*reporter.Report, error) {
+			return &reporter.Report{Metadata: map[string]interface{}{"spec": req.SpecURL, "endpoints": req.Endpoints}}, nil
+		},
+	})...)
+}
+
+func TestTools_ParseSpecAndListEndpoints(t *testing.T) {
+	s := glensServer(t)
+
+	text, isError := callTool(t, s, "parse_spec", `{"spec_url":"`+sampleSpec+`"}`)
+	require.False(t, isError, text)
+	assert.Contains(t, text, `"endpoint_count": 3`)
+
+	text, isError = callTool(t, s, "list_endpoints", `{"spec_url":"`+sampleSpec+`"}`)
+	require.False(t, isError, text)
+	var listed struct{ Endpoints []endpointSummary }
+	require.NoError(t, json.Unmarshal([]byte(text), &listed))
+	require.Len(t, listed.Endpoints, 3)
+	for _, e := range listed.Endpoints {
+		assert.NotEmpty(t, e.RiskLevel, e.Path)
+	}
+
+	_, isError = callTool(t, s, "parse_spec", `{}`)
+	assert.True(t, isError)
+}
+
+func TestTools_GenerateTest(t *testing.T) {
+	s := glensServer(t)
+
+	text, isError := callTool(t, s, "generate_test", `{"spec_url":"`+sampleSpec+`","endpoint":"getUser"}`)
+	require.False(t, isError, text)
+	assert.Contains(t, text, `"model": "mock"`)
+	assert.Contains(t, text, "func Test")
+
+	text, isError = callTool(t, s, "generate_test", `{"spec_url":"`+sampleSpec+`","endpoint":"nope"}`)
+	assert.True(t, isError)
+	assert.Contains(t, text, "not found")
+
+	text, isError = callTool(t, s, "generate_test", `{"spec_url":"`+sampleSpec+`","endpoint":"getUser","model":"gpt4"}`)
+	assert.True(t, isError)
+	assert.Contains(t, text, "not served")
+}
+
+func TestTools_CreateReport(t *testing.T) {
+	s := glensServer(t)
+
+	text, isError := callTool(t, s, "create_report", `{"spec_url":"`+sampleSpec+`","endpoints":["getUser"],"format":"json"}`)
+	require.False(t, isError, text)
+	assert.Contains(t, text, `"getUser"`)
+
+	_, isError = callTool(t, s, "create_report", `{"spec_url":"`+sampleSpec+`","models":["gpt4"]}`)
+	assert.True(t, isError)
+}
@@ -0,0 +1,202 @@
+// Package mcp implements a Model Context Protocol server so AI agents
+// (Claude Desktop, IDE assistants) can drive glens through tool calls. It
+// speaks JSON-RPC 2.0 over stdio or the HTTP+SSE transport.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ProtocolVersion is the MCP revision this server implements.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a callable the server exposes through tools/list and tools/call.
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema is the JSON Schema of the tool arguments
+	InputSchema map[string]any
+	// Handler runs the tool; its result is returned to the agent as JSON
+	// text, strings are returned verbatim
+	Handler func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// Info identifies the server to clients during initialize.
+type Info struct {
+	Name    string
+	Version string
+}
+
+// Server dispatches MCP requests to tools. Tool calls run one at a time:
+// the AI clients behind them are not safe for concurrent use.
+type Server struct {
+	info  Info
+	tools map[string]Tool
+	calls sync.Mutex
+}
+
+// New creates a server exposing the given tools.
+func New(info Info, tools ...Tool) *Server {
+	s := &Server{info: info, tools: make(map[string]Tool, len(tools))}
+	for _, t := range tools {
+		s.tools[t.Name] = t
+	}
+	return s
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// toolContent is one content block of a tools/call result.
+type toolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type toolResult struct {
+	Content []toolContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// Handle processes one JSON-RPC message and returns the encoded response,
+// or nil for notifications, which get no reply.
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: codeParseError, Message: fmt.Sprintf("parse error: %v", err)}})
+	}
+	if len(req.ID) == 0 {
+		log.Debug().Str("method", req.Method).Msg("MCP notification")
+		return nil
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return encode(failure(req.ID, codeInvalidRequest, "invalid request"))
+	}
+
+	return encode(s.dispatch(ctx, req))
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) response {
+	switch req.Method {
+	case "initialize":
+		return success(req.ID, map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.info.Name, "version": s.info.Version},
+		})
+	case "ping":
+		return success(req.ID, map[string]any{})
+	case "tools/list":
+		return success(req.ID, map[string]any{"tools": s.listTools()})
+	case "tools/call":
+		return s.callTool(ctx, req)
+	default:
+		return failure(req.ID, codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method))
+	}
+}
+
+func (s *Server) listTools() []map[string]any {
+	names := make([]string, 0, len(s.tools))
+	for name := range s.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tools := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		t := s.tools[name]
+		tools = append(tools, map[string]any{
+			"name":        t.Name,
+			"description": t.Description,
+			"inputSchema": t.InputSchema,
+		})
+	}
+	return tools
+}
+
+// callTool runs a tool. Tool failures are reported in the result with
+// isError so the agent can read them; only protocol errors are JSON-RPC
+// errors.
+func (s *Server) callTool(ctx context.Context, req request) response {
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return failure(req.ID, codeInvalidParams, fmt.Sprintf("invalid params: %v", err))
+	}
+	tool, ok := s.tools[params.Name]
+	if !ok {
+		return failure(req.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name))
+	}
+	if len(params.Arguments) == 0 {
+		params.Arguments = json.RawMessage("{}")
+	}
+
+	s.calls.Lock()
+	defer s.calls.Unlock()
+
+	log.Info().Str("tool", tool.Name).Msg("MCP tool call")
+	out, err := tool.Handler(ctx, params.Arguments)
+	if err != nil {
+		log.Warn().Err(err).Str("tool", tool.Name).Msg("MCP tool call failed")
+		return success(req.ID, toolResult{Content: []toolContent{{Type: "text", Text: err.Error()}}, IsError: true})
+	}
+
+	text, ok := out.(string)
+	if !ok {
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return success(req.ID, toolResult{Content: []toolContent{{Type: "text", Text: fmt.Sprintf("encode result: %v", err)}}, IsError: true})
+		}
+		text = string(data)
+	}
+	return success(req.ID, toolResult{Content: []toolContent{{Type: "text", Text: text}}})
+}
+
+func success(id json.RawMessage, result any) response {
+	return response{JSONRPC: "2.0", ID: id, Result: result}
+}
+
+func failure(id json.RawMessage, code int, message string) response {
+	return response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func encode(resp response) []byte {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(failure(resp.ID, codeInvalidRequest, fmt.Sprintf("encode response: %v", err)))
+	}
+	return data
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sseHandler implements the MCP HTTP+SSE transport: GET /sse opens an
+// event stream whose first "endpoint" event names the URL to POST messages
+// to; responses arrive on the stream as "message" events.
+type sseHandler struct {
+	server *Server
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session is one open event stream.
+type session struct {
+	out  chan []byte
+	done chan struct{}
+}
+
+// SSEOptions secure the HTTP+SSE transport, whose tools generate and run
+// code on the host.
+type SSEOptions struct {
+	// Token must be sent as "Authorization: Bearer <token>" on every
+	// request; without one every request is rejected
+	Token string
+	// AllowedHosts are host names, besides loopback ones, that requests may
+	// address in Host and come from in Origin, for servers reachable beyond
+	// loopback
+	AllowedHosts []string
+}
+
+// SSEHandler returns an http.Handler serving GET /sse and POST /message.
+// Requests addressed to, or sent by pages of, hosts other than loopback
+// and opts.AllowedHosts are rejected, which defeats DNS rebinding, as are
+// those without opts.Token.
+func (s *Server) SSEHandler(opts SSEOptions) http.Handler {
+	h := &sseHandler{server: s, sessions: make(map[string]*session)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", h.stream)
+	mux.HandleFunc("POST /message", h.message)
+	return guard(opts, mux)
+}
+
+// guard checks the Host, Origin and bearer token of requests before next.
+func guard(opts SSEOptions, next http.Handler) http.Handler {
+	allowed := func(host string) bool {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return true
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && ip.IsLoopback() {
+			return true
+		}
+		for _, h := range opts.AllowedHosts {
+			if strings.EqualFold(host, h) {
+				return true
+			}
+		}
+		return false
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !allowed(host) {
+			http.Error(w, "host not allowed", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !allowed(u.Hostname()) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || opts.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *sseHandler) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sess := &session{out: make(chan []byte, 16), done: make(chan struct{})}
+	h.mu.Lock()
+	h.sessions[id] = sess
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, id)
+		h.mu.Unlock()
+		close(sess.done)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: endpoint\ndata: /message?sessionId=%s\n\n", id)
+	flusher.Flush()
+
+	log.Info().Str("session_id", id).Msg("MCP SSE session opened")
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Info().Str("session_id", id).Msg("MCP SSE session closed")
+			return
+		case msg := <-sess.out:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+		}
+	}
+}
+
+// message accepts a JSON-RPC message for a session and answers 202; the
+// response is delivered on the session's event stream.
+func (h *sseHandler) message(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("sessionId")
+	h.mu.Lock()
+	sess, ok := h.sessions[id]
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	// Handle outside the request: tool calls can outlive the POST
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		resp := h.server.Handle(ctx, body)
+		if resp == nil {
+			return
+		}
+		select {
+		case sess.out <- resp:
+		case <-sess.done:
+		}
+	}()
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
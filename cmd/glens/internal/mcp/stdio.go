@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+)
+
+// maxMessageSize bounds a single newline-delimited message on stdio.
+const maxMessageSize = 16 << 20
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is closed or ctx is cancelled. Logs must not go to
+// w: it is the protocol channel.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				if err := scanner.Err(); err != nil {
+					return fmt.Errorf("read stdin: %w", err)
+				}
+				return nil
+			}
+			if len(line) == 0 {
+				continue
+			}
+			resp := s.Handle(ctx, line)
+			if resp == nil {
+				continue
+			}
+			if _, err := w.Write(append(resp, '\n')); err != nil {
+				return fmt.Errorf("write stdout: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+// ReportRequest are the create_report arguments passed to Backend.Report.
+type ReportRequest struct {
+	SpecURL   string   `json:"spec_url"`
+	Models    []string `json:"models,omitempty"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	RunTests  bool     `json:"run_tests,omitempty"`
+	Format    string   `json:"format,omitempty"`
+}
+
+// Backend connects the glens tools to the pipeline.
+type Backend struct {
+	// AI generates tests; Models are the models it serves, the first being
+	// the default
+	AI     *ai.Manager
+	Models []string
+	// Generator executes generated tests
+	Generator *generator.TestGenerator
+	// Report runs a full analysis
+	Report func(ctx context.Context, req ReportRequest) (*reporter.Report, error)
+}
+
+// specArgs are the arguments every tool takes.
+type specArgs struct {
+	SpecURL string `json:"spec_url"`
+}
+
+// endpointArgs select one endpoint of a spec.
+type endpointArgs struct {
+	specArgs
+	Endpoint string `json:"endpoint"`
+}
+
+// endpointSummary is one list_endpoints entry.
+type endpointSummary struct {
+	ID          string   `json:"id"`
+	OperationID string   `json:"operation_id,omitempty"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Summary     string   `json:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	RiskLevel   string   `json:"risk_level"`
+}
+
+// Tools returns the glens tools backed by b.
+func Tools(b Backend) []Tool {
+	return []Tool{
+		{
+			Name:        "parse_spec",
+			Description: "Parse an OpenAPI spec (URL or file path) and summarise its API info, servers and endpoint count.",
+			InputSchema: schema(specProperty(), "spec_url"),
+			Handler:     b.parseSpec,
+		},
+		{
+			Name:        "list_endpoints",
+			Description: "List the endpoints of an OpenAPI spec with their operation IDs and risk level (low, medium, high, critical).",
+			InputSchema: schema(withProps(specProperty(), map[string]any{
+				"tag": map[string]any{"type": "string", "description": "Only endpoints with this tag"},
+			}), "spec_url"),
+			Handler: b.listEndpoints,
+		},
+		{
+			Name:        "generate_test",
+			Description: "Generate a Go integration test for one endpoint with an AI model.",
+			InputSchema: schema(withProps(endpointProperties(), map[string]any{
+				"model": map[string]any{"type": "string", "enum": b.Models, "description": "AI model (default: first served model)"},
+			}), "spec_url", "endpoint"),
+			Handler: b.generateTest,
+		},
+		{
+			Name:        "run_test",
+			Description: "Run Go integration test code for an endpoint against the configured target environment and return the results.",
+			InputSchema: schema(withProps(endpointProperties(), map[string]any{
+				"test_code": map[string]any{"type": "string", "description": "Go test source, e.g. from generate_test"},
+			}), "spec_url", "endpoint", "test_code"),
+			Handler: b.runTest,
+		},
+		{
+			Name:        "create_report",
+			Description: "Analyze a spec end to end (generate and optionally run tests per endpoint and model) and return the report.",
+			InputSchema: schema(withProps(specProperty(), map[string]any{
+				"models":    map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": b.Models}},
+				"endpoints": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Endpoints to include (operation ID, endpoint ID or \"METHOD /path\"); default all"},
+				"run_tests": map[string]any{"type": "boolean", "description": "Execute generated tests"},
+				"format":    map[string]any{"type": "string", "enum": []string{"markdown", "json"}, "description": "Report format (default markdown)"},
+			}), "spec_url"),
+			Handler: b.createReport,
+		},
+	}
+}
+
+func (b Backend) parseSpec(_ context.Context, raw json.RawMessage) (any, error) {
+	var args specArgs
+	spec, err := decodeSpec(raw, &args, &args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"title":          spec.Info.Title,
+		"version":        spec.Info.Version,
+		"description":    spec.Info.Description,
+		"openapi":        spec.Version,
+		"servers":        spec.Servers,
+		"endpoint_count": len(spec.Endpoints),
+	}, nil
+}
+
+func (b Backend) listEndpoints(_ context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		specArgs
+		Tag string `json:"tag"`
+	}
+	spec, err := decodeSpec(raw, &args, &args.specArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]endpointSummary, 0, len(spec.Endpoints))
+	for i := range spec.Endpoints {
+		e := &spec.Endpoints[i]
+		if args.Tag != "" && !slices.Contains(e.Tags, args.Tag) {
+			continue
+		}
+		endpoints = append(endpoints, endpointSummary{
+			ID:          e.ID,
+			OperationID: e.OperationID,
+			Method:      e.Method,
+			Path:        e.Path,
+			Summary:     e.Summary,
+			Tags:        e.Tags,
+			RiskLevel:   string(safety.Categorise(e.Method, e.Path, e.XSafe).WithRisk(e.DeclaredRisk()).Risk),
+		})
+	}
+	return map[string]any{"endpoints": endpoints}, nil
+}
+
+func (b Backend) generateTest(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		endpointArgs
+		Model string `json:"model"`
+	}
+	endpoint, err := decodeEndpoint(raw, &args, &args.endpointArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	model := args.Model
+	if model == "" && len(b.Models) > 0 {
+		model = b.Models[0]
+	}
+	if !slices.Contains(b.Models, model) {
+		return nil, fmt.Errorf("model %q is not served (available: %v)", model, b.Models)
+	}
+
+	code, _, err := b.AI.GenerateTest(ctx, model, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("generate test: %w", err)
+	}
+	return map[string]any{
+		"model":     model,
+		"endpoint":  endpoint.Method + " " + endpoint.Path,
+		"test_code": code,
+	}, nil
+}
+
+func (b Backend) runTest(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		endpointArgs
+		TestCode string `json:"test_code"`
+	}
+	endpoint, err := decodeEndpoint(raw, &args, &args.endpointArgs)
+	if err != nil {
+		return nil, err
+	}
+	if args.TestCode == "" {
+		return nil, errors.New("test_code is required")
+	}
+
+	result, err := b.Generator.ExecuteTest(ctx, args.TestCode, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("run test: %w", err)
+	}
+	return result, nil
+}
+
+func (b Backend) createReport(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args ReportRequest
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.SpecURL == "" {
+		return nil, errors.New("spec_url is required")
+	}
+	for _, m := range args.Models {
+		if !slices.Contains(b.Models, m) {
+			return nil, fmt.Errorf("model %q is not served (available: %v)", m, b.Models)
+		}
+	}
+
+	report, err := b.Report(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	format := reporter.FormatMarkdown
+	if args.Format == "json" {
+		format = reporter.FormatJSON
+	}
+	return reporter.Render(report, format)
+}
+
+// decodeSpec decodes raw into args and parses the spec named by spec.
+func decodeSpec(raw json.RawMessage, args any, spec *specArgs) (*parser.OpenAPISpec, error) {
+	if err := json.Unmarshal(raw, args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if spec.SpecURL == "" {
+		return nil, errors.New("spec_url is required")
+	}
+	parsed, err := parser.ParseOpenAPISpec(spec.SpecURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	return parsed, nil
+}
+
+// decodeEndpoint decodes raw into args and finds the endpoint it names.
+func decodeEndpoint(raw json.RawMessage, args any, sel *endpointArgs) (*parser.Endpoint, error) {
+	spec, err := decodeSpec(raw, args, &sel.specArgs)
+	if err != nil {
+		return nil, err
+	}
+	if sel.Endpoint == "" {
+		return nil, errors.New("endpoint is required")
+	}
+	endpoint, ok := spec.FindEndpoint(sel.Endpoint)
+	if !ok {
+		return nil, fmt.Errorf("endpoint %q not found; use list_endpoints to see operation IDs", sel.Endpoint)
+	}
+	return endpoint, nil
+}
+
+func schema(properties map[string]any, required ...string) map[string]any {
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+func specProperty() map[string]any {
+	return map[string]any{
+		"spec_url": map[string]any{"type": "string", "description": "OpenAPI spec URL or file path"},
+	}
+}
+
+func endpointProperties() map[string]any {
+	return withProps(specProperty(), map[string]any{
+		"endpoint": map[string]any{"type": "string", "description": "Operation ID, endpoint ID or \"METHOD /path\""},
+	})
+}
+
+func withProps(base, extra map[string]any) map[string]any {
+	for k, v := range extra {
+		base[k] = v
+	}
+	return base
+}
@@ -0,0 +1,239 @@
+package mockserver
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// maxDepth bounds schema nesting when generating example values
+const maxDepth = 8
+
+// maxExpansions is how often a recursive schema is generated with all its
+// properties; deeper occurrences get their required properties and no
+// array items, so the value ends while staying valid
+const maxExpansions = 1
+
+// generator builds example values from schemas. The parser cuts recursive
+// references, leaving a schema with only its $ref; schemas holds the
+// complete schema of each reference to generate those from.
+type generator struct {
+	schemas map[string]*parser.Schema
+}
+
+// newGenerator indexes the referenced schemas of the endpoints' responses
+func newGenerator(endpoints []*parser.Endpoint) *generator {
+	g := &generator{schemas: make(map[string]*parser.Schema)}
+	for _, endpoint := range endpoints {
+		for _, response := range endpoint.Responses {
+			for _, mt := range response.Content {
+				g.index(&mt.Schema)
+			}
+			for _, header := range response.Headers {
+				g.index(&header.Schema)
+			}
+		}
+	}
+	return g
+}
+
+// index records schema and its subschemas by reference
+func (g *generator) index(schema *parser.Schema) {
+	if schema.Ref != "" {
+		if _, seen := g.schemas[schema.Ref]; seen || isCut(schema) {
+			return
+		}
+		g.schemas[schema.Ref] = schema
+	}
+	for name := range schema.Properties {
+		prop := schema.Properties[name]
+		g.index(&prop)
+	}
+	if schema.Items != nil {
+		g.index(schema.Items)
+	}
+	for _, parts := range [][]parser.Schema{schema.PrefixItems, schema.OneOf, schema.AnyOf, schema.AllOf} {
+		for i := range parts {
+			g.index(&parts[i])
+		}
+	}
+}
+
+// isCut reports whether schema is a recursive reference the parser left
+// unresolved
+func isCut(schema *parser.Schema) bool {
+	return schema.Ref != "" && schema.Type == "" && len(schema.Types) == 0 && len(schema.Properties) == 0 &&
+		schema.Items == nil && len(schema.OneOf) == 0 && len(schema.AnyOf) == 0 && len(schema.AllOf) == 0
+}
+
+// exampleFor returns the response body for a media type. A named example is
+// used when requested, then the media type example, then the first named
+// example, and finally a value generated from the schema.
+func (g *generator) exampleFor(mt parser.MediaType, exampleName string) interface{} {
+	if exampleName != "" {
+		if example, ok := mt.Examples[exampleName]; ok {
+			return example.Value
+		}
+	}
+	if mt.Example != nil {
+		return mt.Example
+	}
+	if len(mt.Examples) > 0 {
+		names := make([]string, 0, len(mt.Examples))
+		for name := range mt.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return mt.Examples[names[0]].Value
+	}
+	return g.generate(&mt.Schema)
+}
+
+// headerValue returns the value of a documented response header: its
+// example, the created resource under the requested path for Location, or
+// a scalar generated from its schema; empty when none fits
+func (g *generator) headerValue(name string, header parser.Header, requestPath string) string {
+	if header.Example != nil {
+		return fmt.Sprint(header.Example)
+	}
+	if strings.EqualFold(name, "Location") {
+		return strings.TrimSuffix(requestPath, "/") + "/1"
+	}
+	switch value := g.generate(&header.Schema).(type) {
+	case string, int, int64, float64, bool:
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// generate builds a deterministic value that satisfies the schema
+func (g *generator) generate(schema *parser.Schema) interface{} {
+	return g.value(schema, 0, 0)
+}
+
+// value generates schema at depth, nested in expansions recursive
+// references
+func (g *generator) value(schema *parser.Schema, depth, expansions int) interface{} {
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Const != nil {
+		return schema.Const
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	if depth > maxDepth {
+		return nil
+	}
+	if target, ok := g.schemas[schema.Ref]; ok && isCut(schema) {
+		return g.value(target, depth+1, expansions+1)
+	}
+
+	// The first variant of a oneOf or anyOf is as valid as any other;
+	// object variants are merged into the base object below
+	if variants := schema.Variants(); len(variants) > 0 && schema.Type == "" && len(schema.Properties) == 0 && schema.Discriminator == nil {
+		return g.value(&variants[0].Schema, depth+1, expansions)
+	}
+
+	switch schema.Type {
+	case "null":
+		return nil
+	case "string":
+		return generateString(schema)
+	case "integer":
+		if schema.Minimum != nil {
+			return int64(*schema.Minimum)
+		}
+		if schema.ExclusiveMinimum != nil {
+			return int64(*schema.ExclusiveMinimum) + 1
+		}
+		return 1
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		if schema.ExclusiveMinimum != nil {
+			return *schema.ExclusiveMinimum + 1
+		}
+		return 1.5
+	case "boolean":
+		return true
+	case "array":
+		values := make([]interface{}, 0, len(schema.PrefixItems)+1)
+		for i := range schema.PrefixItems {
+			values = append(values, g.value(&schema.PrefixItems[i], depth+1, expansions))
+		}
+		if len(values) == 0 && schema.Items != nil && expansions <= maxExpansions {
+			values = append(values, g.value(schema.Items, depth+1, expansions))
+		}
+		return values
+	default:
+		return g.object(schema, depth, expansions)
+	}
+}
+
+// object builds an object with a value for every declared property,
+// including those of allOf subschemas and of the first polymorphic variant,
+// whose discriminator value is set. Objects nested in too many recursive
+// references get their required properties only.
+func (g *generator) object(schema *parser.Schema, depth, expansions int) map[string]interface{} {
+	obj := make(map[string]interface{}, len(schema.Properties))
+	merge := func(part *parser.Schema) {
+		if values, ok := g.value(part, depth+1, expansions).(map[string]interface{}); ok {
+			for name, value := range values {
+				obj[name] = value
+			}
+		}
+	}
+	for i := range schema.AllOf {
+		merge(&schema.AllOf[i])
+	}
+	required := schema.RequiredProperties()
+	for name := range schema.Properties {
+		if expansions > maxExpansions && !slices.Contains(required, name) {
+			continue
+		}
+		prop := schema.Properties[name]
+		obj[name] = g.value(&prop, depth+1, expansions)
+	}
+	if variants := schema.Variants(); len(variants) > 0 {
+		merge(&variants[0].Schema)
+		if schema.Discriminator != nil && variants[0].Value != "" {
+			obj[schema.Discriminator.PropertyName] = variants[0].Value
+		}
+	}
+	return obj
+}
+
+// generateString returns a string matching common formats and length limits
+func generateString(schema *parser.Schema) string {
+	var s string
+	switch schema.Format {
+	case "date-time":
+		s = "2024-01-01T00:00:00Z"
+	case "date":
+		s = "2024-01-01"
+	case "email":
+		s = "user@example.com"
+	case "uuid":
+		s = "00000000-0000-4000-8000-000000000000"
+	case "uri", "url":
+		s = "https://example.com"
+	case "ipv4":
+		s = "192.0.2.1"
+	default:
+		s = "string"
+	}
+
+	if schema.MaxLength != nil && len(s) > *schema.MaxLength {
+		s = s[:*schema.MaxLength]
+	}
+	for schema.MinLength != nil && len(s) < *schema.MinLength {
+		s += "x"
+	}
+	return s
+}
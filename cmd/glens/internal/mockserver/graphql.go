@@ -0,0 +1,90 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// maxGraphQLBody bounds the size of GraphQL request bodies read
+const maxGraphQLBody = 1 << 20
+
+// graphQLRequest is the JSON body of a GraphQL request
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphQLOperation returns the query or mutation, posted to the path of
+// matched, whose field the request's document selects. Invalid bodies,
+// unknown fields and missing required variables are answered with GraphQL
+// errors and a nil endpoint.
+func (s *Server) graphQLOperation(w http.ResponseWriter, r *http.Request, matched *parser.Endpoint) *parser.Endpoint {
+	var request graphQLRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxGraphQLBody)).Decode(&request); err != nil || request.Query == "" {
+		writeGraphQLError(w, http.StatusBadRequest, "request body must be JSON with a query")
+		return nil
+	}
+
+	field := rootField(request.Query)
+	var endpoint *parser.Endpoint
+	for _, rt := range s.routes {
+		if rt.endpoint.GraphQL != nil && rt.endpoint.Path == matched.Path && rt.endpoint.GraphQL.Field == field {
+			endpoint = rt.endpoint
+			break
+		}
+	}
+	if endpoint == nil {
+		writeGraphQLError(w, http.StatusOK, fmt.Sprintf("Cannot query field %q on the root type", field))
+		return nil
+	}
+
+	for _, param := range endpoint.Parameters {
+		if _, ok := request.Variables[param.Name]; param.Required && !ok {
+			writeGraphQLError(w, http.StatusOK, fmt.Sprintf("Variable %q of required type was not provided", "$"+param.Name))
+			return nil
+		}
+	}
+	return endpoint
+}
+
+// rootField returns the first root field a document selects, e.g. pet for
+// "query Pet($id: ID!) { p: pet(id: $id) { name } }"
+func rootField(document string) string {
+	_, selection, ok := strings.Cut(document, "{")
+	if !ok {
+		return ""
+	}
+	name, rest := leadingName(selection)
+	if alias, aliased := strings.CutPrefix(strings.TrimSpace(rest), ":"); aliased {
+		name, _ = leadingName(alias)
+	}
+	return name
+}
+
+// leadingName splits the GraphQL name at the start of s, after whitespace,
+// from the rest of s
+func leadingName(s string) (name, rest string) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// writeGraphQLError writes a GraphQL response reporting one error
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
@@ -0,0 +1,284 @@
+// Package mockserver serves example responses for every endpoint of an
+// OpenAPI specification so generated tests can run without a real backend.
+// Operations are served under the base path of the spec's first server,
+// such as /v1, and at the root, where tests generated against the default
+// base URL send their requests.
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// Server is an http.Handler answering requests from the spec's responses
+type Server struct {
+	routes   []route
+	basePath string
+	examples *generator
+}
+
+// route is an endpoint with its path template split into segments
+type route struct {
+	endpoint *parser.Endpoint
+	segments []string
+}
+
+// New creates a mock server for all operations of the specification
+func New(spec *parser.OpenAPISpec) *Server {
+	s := &Server{basePath: basePath(spec.Servers)}
+	endpoints := make([]*parser.Endpoint, 0, len(spec.Endpoints))
+	for i := range spec.Endpoints {
+		endpoint := &spec.Endpoints[i]
+		if endpoint.Incoming() {
+			// Webhooks and callbacks are sent by the API, not served
+			continue
+		}
+		s.routes = append(s.routes, route{
+			endpoint: endpoint,
+			segments: splitPath(endpoint.Path),
+		})
+		endpoints = append(endpoints, endpoint)
+	}
+	s.examples = newGenerator(endpoints)
+
+	// Literal paths such as /users/me must win over /users/{id}
+	sort.SliceStable(s.routes, func(i, j int) bool {
+		return countParams(s.routes[i].segments) < countParams(s.routes[j].segments)
+	})
+	return s
+}
+
+// ServeHTTP answers with the response selected by the Prefer header
+// ("code=404", "example=name") or the lowest documented success status,
+// sending the response's documented headers
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint, methodAllowed := s.match(r.Method, s.trimBasePath(r.URL.Path))
+	if endpoint == nil {
+		status := http.StatusNotFound
+		if methodAllowed {
+			status = http.StatusMethodNotAllowed
+		}
+		writeError(w, status, fmt.Sprintf("no operation for %s %s", r.Method, r.URL.Path))
+		return
+	}
+	if endpoint.GraphQL != nil {
+		// GraphQL operations share a path; the document selects one
+		if endpoint = s.graphQLOperation(w, r, endpoint); endpoint == nil {
+			return
+		}
+	}
+
+	prefer := parsePrefer(r.Header.Get("Prefer"))
+	code, response, ok := selectResponse(endpoint, prefer["code"])
+	if !ok {
+		writeError(w, http.StatusNotImplemented,
+			fmt.Sprintf("%s %s does not document response %q", endpoint.Method, endpoint.Path, prefer["code"]))
+		return
+	}
+
+	log.Debug().
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Int("status", code).
+		Msg("Mock response")
+
+	for name, header := range response.Headers {
+		if value := s.examples.headerValue(name, header, r.URL.Path); value != "" {
+			w.Header().Set(name, value)
+		}
+	}
+
+	contentType, mt, hasBody := selectMediaType(response, r.Header.Get("Accept"))
+	if !hasBody {
+		w.WriteHeader(code)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	body := s.examples.exampleFor(mt, prefer["example"])
+	if s, isString := body.(string); isString && !strings.Contains(contentType, "json") {
+		_, _ = w.Write([]byte(s))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// match finds the endpoint for a request. When the path matches but the
+// method does not, methodAllowed is true so the caller can answer 405.
+func (s *Server) match(method, path string) (endpoint *parser.Endpoint, methodAllowed bool) {
+	segments := splitPath(path)
+	for _, rt := range s.routes {
+		if !matchSegments(rt.segments, segments) {
+			continue
+		}
+		if strings.EqualFold(rt.endpoint.Method, method) {
+			return rt.endpoint, false
+		}
+		methodAllowed = true
+	}
+	return nil, methodAllowed
+}
+
+// trimBasePath removes the server's base path from a request path
+func (s *Server) trimBasePath(path string) string {
+	if rest, ok := strings.CutPrefix(path, s.basePath); ok && s.basePath != "" && (rest == "" || strings.HasPrefix(rest, "/")) {
+		return rest
+	}
+	return path
+}
+
+// basePath returns the path of the first server's URL, without its
+// trailing slash; empty when the server is at the root or its URL cannot be
+// resolved
+func basePath(servers []parser.Server) string {
+	if len(servers) == 0 {
+		return ""
+	}
+	resolved, err := servers[0].ResolveURL(nil)
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(resolved)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// splitPath splits a URL path into non-empty segments
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+// countParams counts the templated segments of a path
+func countParams(segments []string) int {
+	n := 0
+	for _, part := range segments {
+		if strings.HasPrefix(part, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+// matchSegments reports whether request segments fit a path template where
+// "{name}" segments match any value
+func matchSegments(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, part := range template {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			continue
+		}
+		if part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePrefer parses "key=value" preferences of a Prefer header
+func parsePrefer(header string) map[string]string {
+	prefs := make(map[string]string)
+	for _, part := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found {
+			prefs[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prefs
+}
+
+// selectResponse picks the requested status code, or the lowest documented
+// 2xx code, falling back to "default" and then the lowest documented code
+func selectResponse(endpoint *parser.Endpoint, preferred string) (int, parser.Response, bool) {
+	if preferred != "" {
+		response, ok := endpoint.Responses[preferred]
+		if !ok {
+			return 0, parser.Response{}, false
+		}
+		code, err := strconv.Atoi(preferred)
+		if err != nil {
+			return 0, parser.Response{}, false
+		}
+		return code, response, true
+	}
+
+	codes := make([]string, 0, len(endpoint.Responses))
+	for code := range endpoint.Responses {
+		if code != "default" {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return statusCode(code), endpoint.Responses[code], true
+		}
+	}
+	if response, ok := endpoint.Responses["default"]; ok {
+		return http.StatusOK, response, true
+	}
+	if len(codes) > 0 {
+		return statusCode(codes[0]), endpoint.Responses[codes[0]], true
+	}
+	return http.StatusOK, parser.Response{}, true
+}
+
+// statusCode converts a documented code such as "200" or "2XX" to a status
+func statusCode(code string) int {
+	if n, err := strconv.Atoi(code); err == nil {
+		return n
+	}
+	if n, err := strconv.Atoi(strings.NewReplacer("X", "0", "x", "0").Replace(code)); err == nil {
+		return n
+	}
+	return http.StatusOK
+}
+
+// selectMediaType prefers a media type named in Accept, then JSON, then the
+// first documented type
+func selectMediaType(response parser.Response, accept string) (string, parser.MediaType, bool) {
+	if len(response.Content) == 0 {
+		return "", parser.MediaType{}, false
+	}
+
+	types := make([]string, 0, len(response.Content))
+	for contentType := range response.Content {
+		if accept != "" && strings.Contains(accept, contentType) {
+			return contentType, response.Content[contentType], true
+		}
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+
+	for _, contentType := range types {
+		if strings.Contains(contentType, "json") {
+			return contentType, response.Content[contentType], true
+		}
+	}
+	return types[0], response.Content[types[0]], true
+}
+
+// writeError writes a JSON error body in the shape of RFC 9457 problem details
+func writeError(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"title":  http.StatusText(status),
+		"status": status,
+		"detail": detail,
+	})
+}
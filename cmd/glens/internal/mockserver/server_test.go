@@ -0,0 +1,321 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+func testSpec() *parser.OpenAPISpec {
+	maxLen := 3
+	return &parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		{
+			Method: "GET",
+			Path:   "/users/{id}",
+			Responses: map[string]parser.Response{
+				"200": {Content: map[string]parser.MediaType{
+					"application/json": {Schema: parser.Schema{
+						Type: "object",
+						Properties: map[string]parser.Schema{
+							"id":    {Type: "integer"},
+							"email": {Type: "string", Format: "email"},
+							"role":  {Type: "string", Enum: []interface{}{"admin", "user"}},
+							"code":  {Type: "string", MaxLength: &maxLen},
+							"tags":  {Type: "array", Items: &parser.Schema{Type: "string"}},
+						},
+					}},
+				}},
+				"404": {Content: map[string]parser.MediaType{
+					"application/json": {
+						Example: map[string]interface{}{"error": "not found"},
+						Examples: map[string]parser.Example{
+							"gone": {Value: map[string]interface{}{"error": "gone"}},
+						},
+					},
+				}},
+			},
+		},
+		{
+			Method:    "GET",
+			Path:      "/users/me",
+			Responses: map[string]parser.Response{"200": {Content: map[string]parser.MediaType{"application/json": {Example: map[string]interface{}{"id": "me"}}}}},
+		},
+		{
+			Method:    "DELETE",
+			Path:      "/users/{id}",
+			Responses: map[string]parser.Response{"204": {Description: "Deleted"}},
+		},
+	}}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	srv := New(testSpec())
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		prefer     string
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name: "schema generated success", method: "GET", path: "/users/42", wantStatus: http.StatusOK,
+			wantBody: map[string]interface{}{
+				"id": float64(1), "email": "user@example.com", "role": "admin", "code": "str", "tags": []interface{}{"string"},
+			},
+		},
+		{name: "literal path wins", method: "GET", path: "/users/me", wantStatus: http.StatusOK, wantBody: map[string]interface{}{"id": "me"}},
+		{name: "prefer code", method: "GET", path: "/users/42", prefer: "code=404", wantStatus: http.StatusNotFound, wantBody: map[string]interface{}{"error": "not found"}},
+		{name: "prefer named example", method: "GET", path: "/users/42", prefer: "code=404, example=gone", wantStatus: http.StatusNotFound, wantBody: map[string]interface{}{"error": "gone"}},
+		{name: "undocumented code", method: "GET", path: "/users/42", prefer: "code=418", wantStatus: http.StatusNotImplemented},
+		{name: "no content", method: "DELETE", path: "/users/42", wantStatus: http.StatusNoContent},
+		{name: "method not allowed", method: "PUT", path: "/users/42", wantStatus: http.StatusMethodNotAllowed},
+		{name: "unknown path", method: "GET", path: "/orders", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, http.NoBody)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			rec := httptest.NewRecorder()
+
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != nil {
+				var body map[string]interface{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantBody, body)
+			}
+		})
+	}
+}
+
+func TestServer_ServeHTTP_Headers(t *testing.T) {
+	srv := New(&parser.OpenAPISpec{Endpoints: []parser.Endpoint{{
+		Method: "POST",
+		Path:   "/users",
+		Responses: map[string]parser.Response{"201": {Headers: map[string]parser.Header{
+			"Location":              {Schema: parser.Schema{Type: "string", Format: "uri"}},
+			"ETag":                  {Example: `"v1"`},
+			"X-RateLimit-Remaining": {Schema: parser.Schema{Type: "integer"}},
+			"X-Untyped":             {},
+		}}},
+	}}})
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("POST", "/users", http.NoBody))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/users/1", rec.Header().Get("Location"))
+	assert.Equal(t, `"v1"`, rec.Header().Get("ETag"))
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.NotContains(t, rec.Header(), "X-Untyped")
+}
+
+func TestServer_ServeHTTP_GraphQL(t *testing.T) {
+	operation := func(field string, result parser.Schema, params ...parser.Parameter) parser.Endpoint {
+		return parser.Endpoint{
+			Method:     "POST",
+			Path:       parser.GraphQLPath,
+			GraphQL:    &parser.GraphQLOperation{Type: parser.KindQuery, Field: field},
+			Parameters: params,
+			Responses: map[string]parser.Response{"200": {Content: map[string]parser.MediaType{
+				"application/json": {Schema: parser.Schema{Type: "object", Properties: map[string]parser.Schema{
+					"data": {Type: "object", Properties: map[string]parser.Schema{field: result}},
+				}}},
+			}}},
+		}
+	}
+	srv := New(&parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		operation("version", parser.Schema{Type: "string", Example: "1.2"}),
+		operation("pet", parser.Schema{Type: "object", Properties: map[string]parser.Schema{"id": {Type: "integer"}}},
+			parser.Parameter{Name: "id", In: "argument", Required: true}),
+	}})
+
+	post := func(body string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest("POST", "/graphql", strings.NewReader(body)))
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+		return rec.Code, decoded
+	}
+
+	status, body := post(`{"query": "query P($id: ID!) { p: pet(id: $id) { id } }", "variables": {"id": "1"}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"pet": map[string]interface{}{"id": float64(1)}}}, body,
+		"the document's root field selects the operation")
+
+	_, body = post(`{"query": "{ version }"}`)
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"version": "1.2"}}, body)
+
+	status, body = post(`{"query": "query P($id: ID!) { pet(id: $id) { id } }"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []interface{}{map[string]interface{}{"message": `Variable "$id" of required type was not provided`}}, body["errors"])
+
+	_, body = post(`{"query": "{ owner { id } }"}`)
+	assert.Equal(t, []interface{}{map[string]interface{}{"message": `Cannot query field "owner" on the root type`}}, body["errors"])
+
+	status, _ = post(`not json`)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestGenerateValue_JSONSchemaDialect(t *testing.T) {
+	zero := 0.0
+	schema := parser.Schema{
+		Type: "object",
+		Properties: map[string]parser.Schema{
+			"id":    {Type: "integer", ExclusiveMinimum: &zero},
+			"kind":  {Const: "cat"},
+			"note":  {Type: "null", Nullable: true},
+			"point": {Type: "array", PrefixItems: []parser.Schema{{Type: "number"}, {Type: "string", Format: "date"}}},
+			"pet":   {OneOf: []parser.Schema{{Type: "boolean"}, {Type: "string"}}},
+		},
+		AllOf: []parser.Schema{{Properties: map[string]parser.Schema{"age": {Type: "integer"}}}},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"id":    int64(1),
+		"kind":  "cat",
+		"note":  nil,
+		"point": []interface{}{1.5, "2024-01-01"},
+		"pet":   true,
+		"age":   1,
+	}, (&generator{}).generate(&schema))
+}
+
+func TestGenerateValue_Discriminator(t *testing.T) {
+	schema := parser.Schema{
+		Type:       "object",
+		Properties: map[string]parser.Schema{"petType": {Type: "string"}},
+		OneOf: []parser.Schema{
+			{Ref: "#/components/schemas/Cat", Properties: map[string]parser.Schema{"hunts": {Type: "boolean"}}},
+			{Ref: "#/components/schemas/Dog", Properties: map[string]parser.Schema{"bark": {Type: "string"}}},
+		},
+		Discriminator: &parser.Discriminator{PropertyName: "petType", Mapping: map[string]string{"cat": "#/components/schemas/Cat"}},
+	}
+
+	assert.Equal(t, map[string]interface{}{"petType": "cat", "hunts": true}, (&generator{}).generate(&schema))
+}
+
+// treeSpec serves a recursive schema under the base path /v1
+const treeSpec = `openapi: 3.0.3
+info: {title: Trees, version: "1"}
+servers:
+  - url: https://{region}.example.com/{version}/
+    variables:
+      region: {default: eu}
+      version: {default: v1}
+paths:
+  /trees:
+    get:
+      operationId: listTrees
+      responses:
+        "200":
+          description: Trees
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: "#/components/schemas/Node"}
+  /trees/{id}:
+    get:
+      operationId: getTree
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: A tree
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Node"}
+components:
+  schemas:
+    Node:
+      type: object
+      required: [name, children, owner]
+      properties:
+        name: {type: string}
+        note: {type: string}
+        children:
+          type: array
+          items: {$ref: "#/components/schemas/Node"}
+        parent: {$ref: "#/components/schemas/Node"}
+        owner:
+          type: object
+          required: [id]
+          properties:
+            id: {type: integer}
+            manager: {$ref: "#/components/schemas/Node"}
+`
+
+func TestServer_ServeHTTP_BasePath(t *testing.T) {
+	spec, err := parser.ParseSpecData("trees.yaml", []byte(treeSpec))
+	require.NoError(t, err)
+	srv := New(spec)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/v1/trees", http.StatusOK},
+		{"/v1/trees/7", http.StatusOK},
+		{"/trees/7", http.StatusOK},
+		{"/v1", http.StatusNotFound},
+		{"/v1trees", http.StatusNotFound},
+		{"/v1/invalid/endpoint", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			srv.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, http.NoBody))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestGenerateValue_RecursiveRef(t *testing.T) {
+	spec, err := parser.ParseSpecData("trees.yaml", []byte(treeSpec))
+	require.NoError(t, err)
+	srv := New(spec)
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/trees/7", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	// The parser resolves Node once and cuts its references to itself,
+	// which are expanded once in full and then with required properties
+	required := map[string]interface{}{"name": "string", "children": []interface{}{}, "owner": map[string]interface{}{"id": float64(1)}}
+	expanded := map[string]interface{}{
+		"name":     "string",
+		"note":     "string",
+		"children": []interface{}{required},
+		"parent":   required,
+		"owner":    map[string]interface{}{"id": float64(1), "manager": required},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"name":     "string",
+		"note":     "string",
+		"children": []interface{}{expanded},
+		"parent":   expanded,
+		"owner":    map[string]interface{}{"id": float64(1), "manager": expanded},
+	}, body)
+}
+
+func TestParsePrefer(t *testing.T) {
+	got := parsePrefer(`code=404; example="gone", dynamic=true`)
+	assert.Equal(t, map[string]string{"code": "404", "example": "gone", "dynamic": "true"}, got)
+}
@@ -0,0 +1,50 @@
+package mockserver
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/parser"
+)
+
+// TestWorkflow runs the tests the mock model generates against the mock
+// server, as "glens mock serve" followed by "glens analyze
+// --ai-models=mock" does, at the default base URL and under the server's
+// base path
+func TestWorkflow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles and runs generated tests")
+	}
+	spec, err := parser.ParseSpecData("trees.yaml", []byte(treeSpec))
+	require.NoError(t, err)
+	srv := httptest.NewServer(New(spec))
+	defer srv.Close()
+	aiManager, err := ai.NewManager([]string{"mock"}, ai.Config{})
+	require.NoError(t, err)
+
+	for _, baseURL := range []string{srv.URL, srv.URL + "/v1"} {
+		t.Run(baseURL, func(t *testing.T) {
+			report, err := analysis.Run(context.Background(), spec, aiManager, analysis.Options{
+				Models:    []string{"mock"},
+				Framework: "testify",
+				RunTests:  true,
+				Env:       &environment.Environment{BaseURL: baseURL},
+			})
+
+			require.NoError(t, err)
+			require.Len(t, report.EndpointResults, len(spec.Endpoints))
+			for _, result := range report.EndpointResults {
+				test := result.Tests["mock"]
+				require.NotNil(t, test.ExecutionResult, "%s: %s", result.Endpoint.Path, test.ExecutionError)
+				assert.True(t, test.ExecutionResult.Passed, "%s:\n%s", result.Endpoint.Path, test.ExecutionResult.Output)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"glens/tools/glens/internal/reporter"
+)
+
+// TLS modes of an SMTP connection
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS (port 587)
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS from the start (port 465)
+	TLSImplicit = "tls"
+	// TLSNone sends in plaintext, e.g. to a local relay
+	TLSNone = "none"
+)
+
+// emailTimeout bounds the delivery of an email
+const emailTimeout = 30 * time.Second
+
+// Email delivers the run summary by SMTP, for stakeholders who don't
+// watch CI
+type Email struct {
+	Host string `mapstructure:"host"`
+	// Port defaults to 465 with TLSImplicit and 587 otherwise
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// To are the recipients
+	To []string `mapstructure:"to"`
+	// TLS is starttls (default), tls or none
+	TLS                string `mapstructure:"tls"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+	// AttachReport attaches the full report to the email
+	AttachReport bool    `mapstructure:"attach_report"`
+	On           Trigger `mapstructure:"on"` // empty means OnCompleted
+}
+
+// Validate checks the server, addresses, TLS mode and trigger
+func (e *Email) Validate() error {
+	if e.Host == "" {
+		return errors.New("notifications.email.host is required")
+	}
+	if e.Port < 0 || e.Port > 65535 {
+		return fmt.Errorf("notifications.email.port: %d is not a port", e.Port)
+	}
+	if _, err := mail.ParseAddress(e.From); err != nil {
+		return fmt.Errorf("notifications.email.from: %q is not an email address", e.From)
+	}
+	if len(e.To) == 0 {
+		return errors.New("notifications.email.to needs at least one recipient")
+	}
+	for _, to := range e.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("notifications.email.to: %q is not an email address", to)
+		}
+	}
+	switch e.TLS {
+	case "", TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return fmt.Errorf("notifications.email.tls: %q must be %s, %s or %s", e.TLS, TLSStartTLS, TLSImplicit, TLSNone)
+	}
+	switch e.On {
+	case "", OnCompleted, OnBelowThreshold:
+	default:
+		return fmt.Errorf("notifications.email.on: %q must be %s or %s", e.On, OnCompleted, OnBelowThreshold)
+	}
+	return nil
+}
+
+// address is the host:port of the SMTP server
+func (e *Email) address() string {
+	port := e.Port
+	if port == 0 {
+		port = 587
+		if e.TLS == TLSImplicit {
+			port = 465
+		}
+	}
+	return net.JoinHostPort(e.Host, strconv.Itoa(port))
+}
+
+// emailPage is the HTML body of the email: the run summary, its failures
+// and a link to the report
+var emailPage = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif; color: #1e293b;">
+<h2>{{.Headline}}</h2>
+<table style="border-collapse: collapse;">
+<tr><td style="padding: 4px 12px;">Health score</td><td style="padding: 4px 12px;"><strong>{{printf "%.1f%%" .HealthScore}}</strong>{{if .HealthThreshold}} (threshold {{printf "%.0f%%" .HealthThreshold}}){{end}}</td></tr>
+<tr><td style="padding: 4px 12px;">Tests</td><td style="padding: 4px 12px;">{{.PassedTests}}/{{.TotalTests}} passed, {{.FailedTests}} failed</td></tr>
+<tr><td style="padding: 4px 12px;">Endpoints</td><td style="padding: 4px 12px;">{{.EndpointsProcessed}}/{{.TotalEndpoints}}</td></tr>
+<tr><td style="padding: 4px 12px;">Issues created</td><td style="padding: 4px 12px;">{{.IssuesCreated}}</td></tr>
+</table>
+{{if .Failures}}<h3>Failures</h3>
+<ul>
+{{range .Failures}}<li><code>{{.Method}} {{.Path}}</code> ({{.Model}}){{if .Issue}} — issue #{{.Issue}}{{end}}</li>
+{{end}}</ul>
+{{if .More}}<p>… and {{.More}} more</p>{{end}}{{end}}
+{{if .Link}}<p><a href="{{.ReportURL}}">View report</a></p>{{else if .ReportURL}}<p>Report: <code>{{.ReportURL}}</code></p>{{end}}
+<p style="color: #64748b; font-size: 12px;">Sent by glens</p>
+</body>
+</html>
+`))
+
+// emailHTML renders the HTML body of s
+func emailHTML(s *Summary) (string, error) {
+	var body strings.Builder
+	err := emailPage.Execute(&body, struct {
+		*Summary
+		Headline string
+		More     int
+		Link     bool
+	}{s, headline(s), max(s.FailedTests-len(s.Failures), 0), isLink(s.ReportURL)})
+	if err != nil {
+		return "", fmt.Errorf("failed to render email: %w", err)
+	}
+	return body.String(), nil
+}
+
+// emailText is the plain-text alternative of the HTML body
+func emailText(s *Summary) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\n%s\n", headline(s), counts(s))
+	if lines := failureLines(s); len(lines) > 0 {
+		fmt.Fprintf(&text, "\nFailures\n%s\n", strings.ReplaceAll(strings.Join(lines, "\n"), "`", ""))
+	}
+	if s.ReportURL != "" {
+		fmt.Fprintf(&text, "\nReport: %s\n", s.ReportURL)
+	}
+	return text.String()
+}
+
+// attachment is a file attached to an email
+type attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// reportAttachment is the report written to reportFile, or the report
+// rendered as HTML when it was not written
+func reportAttachment(report *reporter.Report, reportFile string) (attachment, error) {
+	if reportFile != "" {
+		data, err := os.ReadFile(reportFile) //nolint:gosec // the report glens just wrote
+		if err == nil {
+			contentType := mime.TypeByExtension(filepath.Ext(reportFile))
+			if contentType == "" {
+				contentType = "text/markdown; charset=utf-8"
+			}
+			return attachment{Name: filepath.Base(reportFile), ContentType: contentType, Data: data}, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return attachment{}, fmt.Errorf("failed to read report: %w", err)
+		}
+	}
+	rendered, err := reporter.Render(report, reporter.FormatHTML)
+	if err != nil {
+		return attachment{}, err
+	}
+	return attachment{Name: "report.html", ContentType: "text/html; charset=utf-8", Data: []byte(rendered)}, nil
+}
+
+// buildEmail composes the MIME message of s from e, with the HTML summary,
+// its plain-text alternative and any attachments
+func buildEmail(e *Email, s *Summary, attachments []attachment) ([]byte, error) {
+	htmlBody, err := emailHTML(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	mixed := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(headline(s))))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+
+	var alternative bytes.Buffer
+	alt := multipart.NewWriter(&alternative)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", emailText(s)},
+		{"text/html; charset=utf-8", htmlBody},
+	} {
+		w, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(w, []byte(part.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", alt.Boundary())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(alternative.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// writeBase64 writes data base64-encoded in lines of 76 characters
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := fmt.Fprintf(w, "%s\r\n", encoded)
+	return err
+}
+
+// sendEmail delivers msg through the SMTP server of e
+func sendEmail(ctx context.Context, e *Email, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+
+	tlsConfig := &tls.Config{
+		ServerName:         e.Host,
+		InsecureSkipVerify: e.InsecureSkipVerify, //nolint:gosec // opt-in for self-signed relays
+		MinVersion:         tls.VersionTLS12,
+	}
+	var conn net.Conn
+	var err error
+	if e.TLS == TLSImplicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", e.address())
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", e.address())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", e.address(), err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer client.Close() //nolint:errcheck
+
+	if e.TLS == "" || e.TLS == TLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, to := range e.To {
+		rcpt, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %w", rcpt.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// sendReportEmail emails the summary of report, attaching the report
+// written to reportFile when configured
+func sendReportEmail(ctx context.Context, e *Email, s *Summary, report *reporter.Report, reportFile string) error {
+	var attachments []attachment
+	if e.AttachReport {
+		a, err := reportAttachment(report, reportFile)
+		if err != nil {
+			return err
+		}
+		attachments = append(attachments, a)
+	}
+	msg, err := buildEmail(e, s, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to compose email: %w", err)
+	}
+	return sendEmail(ctx, e, msg)
+}
@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP accepts one plaintext SMTP session and returns the recipients
+// and message it received
+func fakeSMTP(t *testing.T) (port int, received <-chan [2]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	out := make(chan [2]string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 fake ESMTP")
+		var rcpts []string
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				rcpts = append(rcpts, strings.TrimSpace(line[len("RCPT TO:"):]))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				out <- [2]string{strings.Join(rcpts, ","), data.String()}
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, out
+}
+
+func TestEmail_Validate(t *testing.T) {
+	valid := Email{Host: "smtp.example.com", From: "Glens <glens@example.com>", To: []string{"qa@example.com"}}
+	tests := []struct {
+		name    string
+		modify  func(*Email)
+		wantErr string
+	}{
+		{"valid", func(*Email) {}, ""},
+		{"host", func(e *Email) { e.Host = "" }, "host is required"},
+		{"from", func(e *Email) { e.From = "glens" }, "from"},
+		{"to", func(e *Email) { e.To = nil }, "at least one recipient"},
+		{"recipient", func(e *Email) { e.To = []string{"qa@"} }, `"qa@" is not an email address`},
+		{"tls", func(e *Email) { e.TLS = "ssl" }, `tls: "ssl"`},
+		{"trigger", func(e *Email) { e.On = "weekly" }, `on: "weekly"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := valid
+			tt.modify(&email)
+			err := email.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSend_Email(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "report.md")
+	require.NoError(t, os.WriteFile(reportFile, []byte("# Full report\n"), 0o600))
+	port, received := fakeSMTP(t)
+
+	cfg := &Config{Email: &Email{
+		Host:         "127.0.0.1",
+		Port:         port,
+		TLS:          TLSNone,
+		From:         "Glens <glens@example.com>",
+		To:           []string{"qa@example.com", "Lead <lead@example.com>"},
+		AttachReport: true,
+	}}
+	require.NoError(t, Send(context.Background(), cfg, testReport(), reportFile))
+
+	got := <-received
+	assert.Equal(t, "<qa@example.com>,<lead@example.com>", got[0])
+
+	msg, err := mail.ReadMessage(strings.NewReader(got[1]))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Contains(t, subject, "Petstore 1.0.0: health score")
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+
+	body, err := parts.NextPart()
+	require.NoError(t, err)
+	_, altParams, err := mime.ParseMediaType(body.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	alternatives := multipart.NewReader(body, altParams["boundary"])
+	var bodies []string
+	for {
+		part, err := alternatives.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+"\n"+decodeBase64(t, content))
+	}
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[0], "3/4 tests passed · 1 failed")
+	assert.Contains(t, bodies[1], "<code>POST /pets</code> (gpt4) — issue #12")
+	assert.Contains(t, bodies[1], "<code>"+reportFile+"</code>")
+
+	attached, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "report.md", attached.FileName())
+	content, err := io.ReadAll(attached)
+	require.NoError(t, err)
+	assert.Equal(t, "# Full report\n", decodeBase64(t, content))
+}
+
+func TestSend_EmailBelowThresholdOnly(t *testing.T) {
+	cfg := &Config{HealthThreshold: 10, Email: &Email{
+		Host: "127.0.0.1", Port: 1, TLS: TLSNone, From: "glens@example.com", To: []string{"qa@example.com"}, On: OnBelowThreshold,
+	}}
+	// A healthy run sends nothing, so the unreachable server is not dialed
+	assert.NoError(t, Send(context.Background(), cfg, testReport(), ""))
+
+	cfg.HealthThreshold = 100
+	err := Send(context.Background(), cfg, testReport(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notification email: failed to connect to 127.0.0.1:1")
+}
+
+func decodeBase64(t *testing.T, content []byte) string {
+	t.Helper()
+	decoded, err := base64.StdEncoding.DecodeString(string(content))
+	require.NoError(t, err)
+	return string(decoded)
+}
@@ -0,0 +1,261 @@
+// Package notify announces finished analysis runs: it posts the run's
+// health score, failures and a link to the report to Slack, Microsoft Teams
+// or generic JSON webhooks, and emails an HTML summary with the report
+// attached, on every run or only when the health score drops below a
+// threshold.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/tools/glens/internal/reporter"
+)
+
+// Kind is the payload format of a webhook
+type Kind string
+
+const (
+	// KindSlack posts a Slack incoming-webhook message
+	KindSlack Kind = "slack"
+	// KindTeams posts a Microsoft Teams connector card
+	KindTeams Kind = "teams"
+	// KindGeneric posts the Summary as JSON
+	KindGeneric Kind = "generic"
+)
+
+// Trigger is when a webhook is notified
+type Trigger string
+
+const (
+	// OnCompleted notifies after every run
+	OnCompleted Trigger = "completed"
+	// OnBelowThreshold notifies when the health score is below the
+	// configured threshold
+	OnBelowThreshold Trigger = "below_threshold"
+)
+
+// Events of a Summary
+const (
+	EventRunCompleted         = "run_completed"
+	EventHealthBelowThreshold = "health_below_threshold"
+)
+
+// maxFailures bounds the failures listed in a notification
+const maxFailures = 10
+
+// requestTimeout bounds each webhook call
+const requestTimeout = 15 * time.Second
+
+// Webhook is a notification target
+type Webhook struct {
+	// Name identifies the webhook in logs and errors; defaults to its kind
+	Name string  `mapstructure:"name"`
+	Kind Kind    `mapstructure:"kind"`
+	URL  string  `mapstructure:"url"`
+	On   Trigger `mapstructure:"on"` // empty means OnCompleted
+}
+
+// Config is the notifications section of the config
+type Config struct {
+	Webhooks []Webhook `mapstructure:"webhooks"`
+	// Email, when set, emails the run summary
+	Email *Email `mapstructure:"email"`
+	// HealthThreshold is the health score, in percent, under which
+	// OnBelowThreshold webhooks and email are notified
+	HealthThreshold float64 `mapstructure:"health_threshold"`
+	// ReportURL links the report in notifications, e.g. the CI artifact;
+	// empty links the report file
+	ReportURL string `mapstructure:"report_url"`
+}
+
+// IsZero reports whether no webhook or email is configured
+func (c *Config) IsZero() bool {
+	return len(c.Webhooks) == 0 && c.Email == nil
+}
+
+// Validate checks the kinds, triggers and URLs of the webhooks, the email
+// settings and the threshold
+func (c *Config) Validate() error {
+	if c.HealthThreshold < 0 || c.HealthThreshold > 100 {
+		return fmt.Errorf("notifications.health_threshold: %v must be between 0 and 100", c.HealthThreshold)
+	}
+	for i := range c.Webhooks {
+		w := &c.Webhooks[i]
+		switch w.Kind {
+		case KindSlack, KindTeams, KindGeneric:
+		default:
+			return fmt.Errorf("notifications.webhooks[%d]: kind %q must be %s, %s or %s", i, w.Kind, KindSlack, KindTeams, KindGeneric)
+		}
+		switch w.On {
+		case "", OnCompleted, OnBelowThreshold:
+		default:
+			return fmt.Errorf("notifications.webhooks[%d]: on %q must be %s or %s", i, w.On, OnCompleted, OnBelowThreshold)
+		}
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notifications.webhooks[%d]: url is not an http(s) URL", i)
+		}
+	}
+	if c.Email != nil {
+		return c.Email.Validate()
+	}
+	return nil
+}
+
+// Failure is a failing test listed in a notification
+type Failure struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Model  string `json:"model"`
+	// Issue is the number of the issue opened for the endpoint, if any
+	Issue int `json:"issue,omitempty"`
+}
+
+// Summary is what a notification tells about a run; generic webhooks
+// receive it as JSON
+type Summary struct {
+	Event              string    `json:"event"`
+	API                string    `json:"api"`
+	Version            string    `json:"version,omitempty"`
+	HealthScore        float64   `json:"health_score"`
+	HealthThreshold    float64   `json:"health_threshold,omitempty"`
+	BelowThreshold     bool      `json:"below_threshold"`
+	TotalEndpoints     int       `json:"total_endpoints"`
+	EndpointsProcessed int       `json:"endpoints_processed"`
+	TotalTests         int       `json:"total_tests"`
+	PassedTests        int       `json:"passed_tests"`
+	FailedTests        int       `json:"failed_tests"`
+	IssuesCreated      int       `json:"issues_created"`
+	Failures           []Failure `json:"failures,omitempty"` // the first failures, at most 10
+	ReportURL          string    `json:"report_url,omitempty"`
+	GeneratedAt        time.Time `json:"generated_at"`
+}
+
+// NewSummary summarizes report, comparing its health score to threshold
+// (0 disables the comparison)
+func NewSummary(report *reporter.Report, threshold float64, reportURL string) Summary {
+	s := Summary{
+		Event:              EventRunCompleted,
+		API:                report.Specification.Info.Title,
+		Version:            report.Specification.Info.Version,
+		HealthScore:        report.Summary.OverallHealthScore,
+		HealthThreshold:    threshold,
+		BelowThreshold:     threshold > 0 && report.Summary.OverallHealthScore < threshold,
+		TotalEndpoints:     report.Summary.TotalEndpoints,
+		EndpointsProcessed: report.Summary.EndpointsProcessed,
+		TotalTests:         report.Summary.TotalTests,
+		PassedTests:        report.Summary.PassedTests,
+		FailedTests:        report.Summary.FailedTests,
+		IssuesCreated:      report.Summary.TotalIssuesCreated,
+		ReportURL:          reportURL,
+		GeneratedAt:        report.GeneratedAt,
+	}
+	if s.BelowThreshold {
+		s.Event = EventHealthBelowThreshold
+	}
+
+	for i := range report.EndpointResults {
+		result := &report.EndpointResults[i]
+		models := make([]string, 0, len(result.Tests))
+		for model := range result.Tests {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			exec := result.Tests[model].ExecutionResult
+			if exec == nil || !exec.Failed || len(s.Failures) == maxFailures {
+				continue
+			}
+			s.Failures = append(s.Failures, Failure{
+				Method: result.Endpoint.Method,
+				Path:   result.Endpoint.Path,
+				Model:  model,
+				Issue:  result.IssueNumber,
+			})
+		}
+	}
+	return s
+}
+
+// Send notifies the webhooks and email of cfg whose trigger matches the run
+// of report, linking cfg.ReportURL or else the report written to
+// reportFile. Every target is tried; the errors of those that failed are
+// joined.
+func Send(ctx context.Context, cfg *Config, report *reporter.Report, reportFile string) error {
+	reportURL := cfg.ReportURL
+	if reportURL == "" && reportFile != "" {
+		reportURL = reportFile
+		if abs, err := filepath.Abs(reportFile); err == nil {
+			reportURL = abs
+		}
+	}
+	summary := NewSummary(report, cfg.HealthThreshold, reportURL)
+
+	var errs []error
+	for i := range cfg.Webhooks {
+		webhook := &cfg.Webhooks[i]
+		if webhook.On == OnBelowThreshold && !summary.BelowThreshold {
+			continue
+		}
+		name := webhook.Name
+		if name == "" {
+			name = string(webhook.Kind)
+		}
+		if err := post(ctx, webhook.URL, payload(webhook.Kind, &summary)); err != nil {
+			errs = append(errs, fmt.Errorf("notification %s: %w", name, err))
+			continue
+		}
+		log.Info().Str("webhook", name).Str("event", summary.Event).Msg("Run notification sent")
+	}
+	if email := cfg.Email; email != nil && (email.On != OnBelowThreshold || summary.BelowThreshold) {
+		if err := sendReportEmail(ctx, email, &summary, report, reportFile); err != nil {
+			errs = append(errs, fmt.Errorf("notification email: %w", err))
+		} else {
+			log.Info().Strs("to", email.To).Str("event", summary.Event).Msg("Run notification emailed")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends body as JSON to target
+func post(ctx context.Context, target string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error quotes the URL, which holds the webhook's credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+func testReport() *reporter.Report {
+	spec := &parser.OpenAPISpec{
+		Info: parser.Info{Title: "Petstore", Version: "1.0.0"},
+		Endpoints: []parser.Endpoint{
+			{ID: "GET__pets", Method: "GET", Path: "/pets"},
+			{ID: "POST__pets", Method: "POST", Path: "/pets"},
+		},
+	}
+	passed := reporter.TestResult{ExecutionResult: &generator.ExecutionResult{Passed: true}}
+	failed := reporter.TestResult{ExecutionResult: &generator.ExecutionResult{Failed: true}}
+	return reporter.GenerateReport(spec, []reporter.EndpointResult{
+		{Endpoint: spec.Endpoints[0], Tests: map[string]reporter.TestResult{"gpt4": passed, "mock": passed}},
+		{Endpoint: spec.Endpoints[1], Tests: map[string]reporter.TestResult{"gpt4": failed, "mock": passed}, IssueNumber: 12},
+	})
+}
+
+func TestNewSummary(t *testing.T) {
+	report := testReport()
+
+	s := NewSummary(report, 0, "reports/report.md")
+	assert.Equal(t, EventRunCompleted, s.Event)
+	assert.Equal(t, "Petstore", s.API)
+	assert.False(t, s.BelowThreshold)
+	assert.Equal(t, 4, s.TotalTests)
+	assert.Equal(t, 1, s.FailedTests)
+	assert.Equal(t, []Failure{{Method: "POST", Path: "/pets", Model: "gpt4", Issue: 12}}, s.Failures)
+
+	s = NewSummary(report, 100, "")
+	assert.True(t, s.BelowThreshold)
+	assert.Equal(t, EventHealthBelowThreshold, s.Event)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Webhook{Kind: KindSlack, URL: "https://hooks.slack.com/services/T/B/x"}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"valid", Config{Webhooks: []Webhook{valid}, HealthThreshold: 70}, ""},
+		{"kind", Config{Webhooks: []Webhook{{Kind: "discord", URL: valid.URL}}}, `kind "discord"`},
+		{"trigger", Config{Webhooks: []Webhook{{Kind: KindTeams, URL: valid.URL, On: "always"}}}, `on "always"`},
+		{"url", Config{Webhooks: []Webhook{{Kind: KindGeneric, URL: "hooks.example.com"}}}, "not an http(s) URL"},
+		{"threshold", Config{Webhooks: []Webhook{valid}, HealthThreshold: 120}, "between 0 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]map[string]any)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "nope", http.StatusInternalServerError)
+			return
+		}
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		received[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	cfg := &Config{
+		HealthThreshold: 50,
+		ReportURL:       "https://ci.example.com/artifacts/report.html",
+		Webhooks: []Webhook{
+			{Kind: KindSlack, URL: srv.URL + "/slack"},
+			{Kind: KindTeams, URL: srv.URL + "/teams"},
+			{Kind: KindGeneric, URL: srv.URL + "/generic"},
+			{Kind: KindGeneric, URL: srv.URL + "/below", On: OnBelowThreshold},
+		},
+	}
+	require.NoError(t, Send(context.Background(), cfg, testReport(), "reports/report.md"))
+	require.Len(t, received, 3, "the below_threshold webhook is skipped for a healthy run")
+
+	slack, _ := received["/slack"]["text"].(string)
+	assert.Contains(t, slack, "Petstore 1.0.0: health score")
+	assert.Contains(t, slack, "3/4 tests passed · 1 failed")
+	assert.Contains(t, slack, "`POST /pets` (gpt4) — issue #12")
+	assert.Contains(t, slack, "<https://ci.example.com/artifacts/report.html|View report>")
+
+	assert.Equal(t, "MessageCard", received["/teams"]["@type"])
+	assert.Equal(t, colorFailed, received["/teams"]["themeColor"])
+	assert.NotEmpty(t, received["/teams"]["potentialAction"])
+
+	assert.Equal(t, EventRunCompleted, received["/generic"]["event"])
+	assert.Equal(t, "https://ci.example.com/artifacts/report.html", received["/generic"]["report_url"])
+
+	cfg.HealthThreshold = 100
+	cfg.Webhooks = []Webhook{
+		{Name: "ops", Kind: KindGeneric, URL: srv.URL + "/broken?token=s3cret"},
+		{Kind: KindGeneric, URL: srv.URL + "/below", On: OnBelowThreshold},
+	}
+	err := Send(context.Background(), cfg, testReport(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notification ops: webhook returned 500")
+	assert.NotContains(t, err.Error(), "s3cret")
+	assert.Equal(t, EventHealthBelowThreshold, received["/below"]["event"], "the other webhooks are still notified")
+}
+
+func TestPost_HidesURL(t *testing.T) {
+	err := post(context.Background(), "http://127.0.0.1:1/hook?token=s3cret", map[string]string{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+	assert.NotContains(t, err.Error(), "127.0.0.1:1/hook")
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Teams card colors
+const (
+	colorPassed = "2EB67D"
+	colorFailed = "E01E5A"
+	colorBelow  = "ECB22E"
+)
+
+// payload is the body posted to a webhook of kind
+func payload(kind Kind, s *Summary) any {
+	switch kind {
+	case KindSlack:
+		return slackPayload(s)
+	case KindTeams:
+		return teamsPayload(s)
+	default:
+		return s
+	}
+}
+
+// headline is the one-line outcome of the run
+func headline(s *Summary) string {
+	icon := "✅"
+	switch {
+	case s.BelowThreshold:
+		icon = "⚠️"
+	case s.FailedTests > 0:
+		icon = "❌"
+	}
+	name := strings.TrimSpace(s.API + " " + s.Version)
+	if s.BelowThreshold {
+		return fmt.Sprintf("%s %s: health score %.1f%% is below the %.0f%% threshold", icon, name, s.HealthScore, s.HealthThreshold)
+	}
+	return fmt.Sprintf("%s %s: health score %.1f%%", icon, name, s.HealthScore)
+}
+
+// counts is the test and issue counts of the run
+func counts(s *Summary) string {
+	return fmt.Sprintf("%d/%d tests passed · %d failed · %d/%d endpoints · %d issue(s) created",
+		s.PassedTests, s.TotalTests, s.FailedTests, s.EndpointsProcessed, s.TotalEndpoints, s.IssuesCreated)
+}
+
+// failureLines lists the failures, one per line
+func failureLines(s *Summary) []string {
+	lines := make([]string, 0, len(s.Failures)+1)
+	for _, f := range s.Failures {
+		line := fmt.Sprintf("• `%s %s` (%s)", f.Method, f.Path, f.Model)
+		if f.Issue > 0 {
+			line += fmt.Sprintf(" — issue #%d", f.Issue)
+		}
+		lines = append(lines, line)
+	}
+	if more := s.FailedTests - len(s.Failures); more > 0 && len(s.Failures) > 0 {
+		lines = append(lines, fmt.Sprintf("… and %d more", more))
+	}
+	return lines
+}
+
+// isLink reports whether the report URL can be opened from a chat message
+func isLink(reportURL string) bool {
+	return strings.HasPrefix(reportURL, "https://") || strings.HasPrefix(reportURL, "http://")
+}
+
+// slackPayload is a Slack incoming-webhook message in mrkdwn
+func slackPayload(s *Summary) map[string]any {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*%s*\n%s", headline(s), counts(s))
+	if lines := failureLines(s); len(lines) > 0 {
+		fmt.Fprintf(&text, "\n\n*Failures*\n%s", strings.Join(lines, "\n"))
+	}
+	switch {
+	case isLink(s.ReportURL):
+		fmt.Fprintf(&text, "\n\n<%s|View report>", s.ReportURL)
+	case s.ReportURL != "":
+		fmt.Fprintf(&text, "\n\nReport: `%s`", s.ReportURL)
+	}
+	return map[string]any{"text": text.String()}
+}
+
+// teamsPayload is a Microsoft Teams connector message card
+func teamsPayload(s *Summary) map[string]any {
+	color := colorPassed
+	switch {
+	case s.BelowThreshold:
+		color = colorBelow
+	case s.FailedTests > 0:
+		color = colorFailed
+	}
+	facts := []map[string]string{
+		{"name": "Health score", "value": fmt.Sprintf("%.1f%%", s.HealthScore)},
+		{"name": "Tests", "value": fmt.Sprintf("%d/%d passed, %d failed", s.PassedTests, s.TotalTests, s.FailedTests)},
+		{"name": "Endpoints", "value": fmt.Sprintf("%d/%d", s.EndpointsProcessed, s.TotalEndpoints)},
+		{"name": "Issues created", "value": fmt.Sprintf("%d", s.IssuesCreated)},
+	}
+	if s.ReportURL != "" && !isLink(s.ReportURL) {
+		facts = append(facts, map[string]string{"name": "Report", "value": s.ReportURL})
+	}
+	section := map[string]any{"facts": facts}
+	if lines := failureLines(s); len(lines) > 0 {
+		section["text"] = "**Failures**\n\n" + strings.Join(lines, "\n\n")
+	}
+
+	card := map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    headline(s),
+		"title":      headline(s),
+		"themeColor": color,
+		"sections":   []any{section},
+	}
+	if isLink(s.ReportURL) {
+		card["potentialAction"] = []any{map[string]any{
+			"@type":   "OpenUri",
+			"name":    "View report",
+			"targets": []any{map[string]string{"os": "default", "uri": s.ReportURL}},
+		}}
+	}
+	return card
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// ChangedEndpoints compares two versions of a spec. It returns the endpoints
+// of next that are new or differ from prev, in next's order, and the sorted
+// IDs of prev's endpoints that next no longer has.
+func ChangedEndpoints(prev, next *OpenAPISpec) (changed []Endpoint, removed []string) {
+	previous := make(map[string]*Endpoint, len(prev.Endpoints))
+	for i := range prev.Endpoints {
+		previous[prev.Endpoints[i].ID] = &prev.Endpoints[i]
+	}
+
+	for i := range next.Endpoints {
+		e := &next.Endpoints[i]
+		old, ok := previous[e.ID]
+		if !ok || !reflect.DeepEqual(old, e) {
+			changed = append(changed, *e)
+		}
+		delete(previous, e.ID)
+	}
+
+	for id := range previous {
+		removed = append(removed, id)
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// Fingerprint is a digest of everything the spec says about an endpoint, so
+// a stored fingerprint tells whether the endpoint changed since
+func (e *Endpoint) Fingerprint() string {
+	// Endpoints only hold JSON values, so marshaling cannot fail
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
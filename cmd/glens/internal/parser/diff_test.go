@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedEndpoints(t *testing.T) {
+	prev := &OpenAPISpec{Endpoints: []Endpoint{
+		{ID: "GET__users", Method: "GET", Path: "/users", Summary: "List users"},
+		{ID: "POST__users", Method: "POST", Path: "/users"},
+		{ID: "DELETE__users_{id}", Method: "DELETE", Path: "/users/{id}"},
+	}}
+	next := &OpenAPISpec{Endpoints: []Endpoint{
+		{ID: "GET__users", Method: "GET", Path: "/users", Summary: "List all users"},
+		{ID: "POST__users", Method: "POST", Path: "/users"},
+		{ID: "GET__users_{id}", Method: "GET", Path: "/users/{id}"},
+	}}
+
+	changed, removed := ChangedEndpoints(prev, next)
+	assert.Equal(t, []string{"GET /users", "GET /users/{id}"}, paths(changed))
+	assert.Equal(t, []string{"DELETE__users_{id}"}, removed)
+
+	changed, removed = ChangedEndpoints(next, next)
+	assert.Empty(t, changed)
+	assert.Empty(t, removed)
+}
+
+func TestEndpoint_Fingerprint(t *testing.T) {
+	endpoint := Endpoint{ID: "GET__users", Method: "GET", Path: "/users", Responses: map[string]Response{
+		"200": {Description: "OK"},
+		"404": {Description: "Not found"},
+	}}
+	same := endpoint
+	assert.Equal(t, endpoint.Fingerprint(), same.Fingerprint())
+
+	endpoint.Summary = "List users"
+	assert.NotEqual(t, same.Fingerprint(), endpoint.Fingerprint())
+}
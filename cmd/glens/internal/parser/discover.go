@@ -0,0 +1,294 @@
+package parser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// discoverTimeout bounds each probe of Discover unless spec_fetch sets a
+// shorter timeout
+const discoverTimeout = 15 * time.Second
+
+// SpecLocations are the paths, relative to a base URL, where frameworks
+// commonly serve their OpenAPI document
+var SpecLocations = []string{
+	"openapi.json",
+	"openapi.yaml",
+	"openapi.yml",
+	"swagger.json",
+	"swagger.yaml",
+	"v3/api-docs",
+	"v2/api-docs",
+	"api-docs",
+	"api-docs.json",
+	"api/openapi.json",
+	"docs/openapi.json",
+	".well-known/openapi",
+	".well-known/openapi.json",
+	".well-known/openapi.yaml",
+}
+
+// IndexLocations are the paths of service index pages listing specs: the
+// RFC 9727 API catalog, APIs.json, springdoc's swagger-config and
+// springfox's swagger-resources
+var IndexLocations = []string{
+	".well-known/api-catalog",
+	"apis.json",
+	"v3/api-docs/swagger-config",
+	"swagger-resources",
+}
+
+// DiscoveredSpec is an OpenAPI document found by Discover
+type DiscoveredSpec struct {
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	Version   string `json:"version,omitempty"`
+	OpenAPI   string `json:"openapi"`
+	Endpoints int    `json:"endpoints"`
+	// Via is the index page that listed the spec; empty for probed
+	// locations
+	Via string `json:"via,omitempty"`
+}
+
+// Discover probes the common spec locations and service index pages of a
+// base URL and returns the OpenAPI documents found, sorted by URL. Probes
+// run concurrently with the spec_fetch settings; documents served at
+// several locations are listed once.
+func Discover(baseURL string) ([]DiscoveredSpec, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme: %s", base.Scheme)
+	}
+	cfg := currentFetchConfig()
+	if cfg.Timeout <= 0 || cfg.Timeout > discoverTimeout {
+		cfg.Timeout = discoverTimeout
+	}
+
+	d := &discovery{cfg: cfg, seen: map[string]bool{}}
+	roots := []*url.URL{withTrailingSlash(base)}
+	if strings.Trim(base.Path, "/") != "" {
+		// The base URL may be a spec itself, or a service below the root
+		d.probe(base.String(), "")
+		roots = append(roots, base.ResolveReference(&url.URL{Path: "/"}))
+	}
+	var wg sync.WaitGroup
+	for _, root := range roots {
+		for _, location := range SpecLocations {
+			wg.Add(1)
+			go func(u string) {
+				defer wg.Done()
+				d.probe(u, "")
+			}(root.ResolveReference(&url.URL{Path: location}).String())
+		}
+		for _, location := range IndexLocations {
+			wg.Add(1)
+			go func(u string) {
+				defer wg.Done()
+				d.index(u)
+			}(root.ResolveReference(&url.URL{Path: location}).String())
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(d.found, func(i, j int) bool { return d.found[i].URL < d.found[j].URL })
+	var specs []DiscoveredSpec
+	contents := map[[32]byte]bool{}
+	for _, found := range d.found {
+		if !contents[found.sum] {
+			contents[found.sum] = true
+			specs = append(specs, found.DiscoveredSpec)
+		}
+	}
+	return specs, nil
+}
+
+// discovery collects the specs found by concurrent probes
+type discovery struct {
+	cfg   FetchConfig
+	mu    sync.Mutex
+	seen  map[string]bool
+	found []foundSpec
+}
+
+// foundSpec is a discovered spec with the checksum of its content
+type foundSpec struct {
+	DiscoveredSpec
+	sum [32]byte
+}
+
+// claim reports whether u is probed for the first time
+func (d *discovery) claim(u string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen[u] {
+		return false
+	}
+	d.seen[u] = true
+	return true
+}
+
+// probe fetches u and records it when it is an OpenAPI document
+func (d *discovery) probe(u, via string) {
+	if !d.claim(u) {
+		return
+	}
+	data, err := fetch(context.Background(), u, d.cfg)
+	if err != nil {
+		log.Debug().Err(err).Str("url", u).Msg("No spec at location")
+		return
+	}
+	found, ok := describeSpec(u, data)
+	if !ok {
+		return
+	}
+	found.Via = via
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.found = append(d.found, foundSpec{DiscoveredSpec: found, sum: sha256.Sum256(data)})
+}
+
+// index fetches the index page u and probes the specs it lists
+func (d *discovery) index(u string) {
+	if !d.claim(u) {
+		return
+	}
+	data, err := fetch(context.Background(), u, d.cfg)
+	if err != nil {
+		log.Debug().Err(err).Str("url", u).Msg("No index at location")
+		return
+	}
+	page, err := url.Parse(u)
+	if err != nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, ref := range indexLinks(data) {
+		target, err := page.Parse(ref)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+			continue
+		}
+		wg.Add(1)
+		go func(target string) {
+			defer wg.Done()
+			d.probe(target, u)
+		}(target.String())
+	}
+	wg.Wait()
+}
+
+// describeSpec summarizes data when it is an OpenAPI or Swagger document
+func describeSpec(u string, data []byte) (DiscoveredSpec, bool) {
+	var head struct {
+		OpenAPI string `yaml:"openapi"`
+		Swagger string `yaml:"swagger"`
+		Info    struct {
+			Title   string `yaml:"title"`
+			Version string `yaml:"version"`
+		} `yaml:"info"`
+	}
+	// YAML is a superset of JSON, so one decoder reads both
+	if err := yaml.Unmarshal(data, &head); err != nil {
+		return DiscoveredSpec{}, false
+	}
+	version := head.OpenAPI
+	if version == "" {
+		version = head.Swagger
+	}
+	if version == "" {
+		return DiscoveredSpec{}, false
+	}
+	found := DiscoveredSpec{URL: u, Title: head.Info.Title, Version: head.Info.Version, OpenAPI: version}
+	if spec, err := parseOpenAPI(u, data); err == nil {
+		found.Endpoints = len(spec.Endpoints)
+	}
+	return found, true
+}
+
+// indexLinks returns the spec URLs an index page lists: the service-desc
+// links of an API catalog linkset, the OpenAPI and Swagger properties of
+// APIs.json, and the urls of a swagger-config or swagger-resources list
+func indexLinks(data []byte) []string {
+	var links []string
+
+	var catalog struct {
+		Linkset []struct {
+			ServiceDesc []struct {
+				Href string `json:"href"`
+			} `json:"service-desc"`
+		} `json:"linkset"`
+		APIs []struct {
+			Properties []struct {
+				Type string `json:"type"`
+				URL  string `json:"url"`
+			} `json:"properties"`
+		} `json:"apis"`
+		URL  string `json:"url"`
+		URLs []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+	}
+	if err := json.Unmarshal(data, &catalog); err == nil {
+		for _, entry := range catalog.Linkset {
+			for _, desc := range entry.ServiceDesc {
+				links = append(links, desc.Href)
+			}
+		}
+		for _, api := range catalog.APIs {
+			for _, property := range api.Properties {
+				switch strings.ToLower(property.Type) {
+				case "openapi", "swagger", "x-openapi":
+					links = append(links, property.URL)
+				}
+			}
+		}
+		links = append(links, catalog.URL)
+		for _, u := range catalog.URLs {
+			links = append(links, u.URL)
+		}
+	}
+
+	var resources []struct {
+		URL      string `json:"url"`
+		Location string `json:"location"`
+	}
+	if err := json.Unmarshal(data, &resources); err == nil {
+		for _, r := range resources {
+			links = append(links, r.URL, r.Location)
+		}
+	}
+
+	nonEmpty := links[:0]
+	for _, link := range links {
+		if link != "" {
+			nonEmpty = append(nonEmpty, link)
+		}
+	}
+	return nonEmpty
+}
+
+// withTrailingSlash returns u with a path ending in /, so relative
+// locations resolve below it
+func withTrailingSlash(u *url.URL) *url.URL {
+	copied := *u
+	if !strings.HasSuffix(copied.Path, "/") {
+		copied.Path += "/"
+		copied.RawPath = ""
+	}
+	copied.RawQuery = ""
+	copied.Fragment = ""
+	return &copied
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const discoverSpec = `{"openapi":"3.0.3","info":{"title":"Orders","version":"2.1"},
+"paths":{"/orders":{"get":{"responses":{"200":{"description":"ok"}}}}}}`
+
+func TestDiscover(t *testing.T) {
+	previous := currentFetchConfig()
+	SetFetchConfig(FetchConfig{DisableCache: true})
+	t.Cleanup(func() { SetFetchConfig(previous) })
+
+	mux := http.NewServeMux()
+	// The same document at two locations is listed once
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(discoverSpec))
+	})
+	mux.HandleFunc("/v3/api-docs", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(discoverSpec))
+	})
+	mux.HandleFunc("/.well-known/api-catalog", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"linkset":[{"anchor":"/billing","service-desc":[{"href":"/billing/spec.yaml"}]}]}`))
+	})
+	mux.HandleFunc("/billing/spec.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("swagger: \"2.0\"\ninfo: {title: Billing, version: \"1\"}\npaths: {}\n"))
+	})
+	// Pages that are not specs are ignored
+	mux.HandleFunc("/swagger.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>swagger ui</html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	specs, err := Discover(server.URL)
+	require.NoError(t, err)
+	require.Len(t, specs, 2)
+	assert.Equal(t, DiscoveredSpec{
+		URL: server.URL + "/billing/spec.yaml", Title: "Billing", Version: "1", OpenAPI: "2.0",
+		Via: server.URL + "/.well-known/api-catalog",
+	}, specs[0])
+	assert.Equal(t, DiscoveredSpec{
+		URL: server.URL + "/openapi.json", Title: "Orders", Version: "2.1", OpenAPI: "3.0.3", Endpoints: 1,
+	}, specs[1])
+
+	// A base URL below the root is probed itself, then under the root
+	specs, err = Discover(server.URL + "/billing/spec.yaml")
+	require.NoError(t, err)
+	assert.Len(t, specs, 2)
+
+	_, err = Discover("ftp://example.com")
+	assert.ErrorContains(t, err, "unsupported URL scheme")
+}
+
+func TestIndexLinks(t *testing.T) {
+	assert.Equal(t, []string{"/a.json", "/v3/api-docs/orders"},
+		indexLinks([]byte(`{"configUrl":"/v3/api-docs/swagger-config","url":"/a.json","urls":[{"name":"orders","url":"/v3/api-docs/orders"}]}`)))
+	assert.Equal(t, []string{"https://example.com/openapi.yaml"},
+		indexLinks([]byte(`{"apis":[{"properties":[{"type":"Swagger","url":"https://example.com/openapi.yaml"},{"type":"x-docs","url":"/docs"}]}]}`)))
+	assert.Equal(t, []string{"/v2/api-docs?group=default"},
+		indexLinks([]byte(`[{"name":"default","location":"/v2/api-docs?group=default","swaggerVersion":"2.0"}]`)))
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Vendor extensions with which spec authors guide test generation, set on
+// an operation or on its path item:
+//
+//	x-glens-skip: flaky upstream  # true or the reason; excluded from analysis
+//	x-glens-auth: none            # public: tests send no credentials
+//	x-glens-test-data:            # values tests should use
+//	  petId: 42
+//	x-glens-priority: high        # high, normal (default) or low
+//	x-glens-risk: high            # overrides the inferred safety risk
+const (
+	ExtSkip     = "x-glens-skip"
+	ExtAuth     = "x-glens-auth"
+	ExtTestData = "x-glens-test-data"
+	ExtPriority = "x-glens-priority"
+	ExtRisk     = "x-glens-risk"
+)
+
+// Priorities of x-glens-priority, from first to last analysed
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// priorityRank orders priorities; unknown ones rank as normal
+var priorityRank = map[string]int{PriorityHigh: 2, PriorityNormal: 1, PriorityLow: 0}
+
+// extractExtensions returns the x- fields of an operation or path item
+func extractExtensions(raw map[string]interface{}) map[string]interface{} {
+	var extensions map[string]interface{}
+	for key, value := range raw {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if extensions == nil {
+			extensions = make(map[string]interface{})
+		}
+		extensions[key] = value
+	}
+	return extensions
+}
+
+// extensionString returns the lowercase string value of an extension
+func (e *Endpoint) extensionString(name string) string {
+	value, _ := e.Extensions[name].(string)
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// Skipped reports whether x-glens-skip excludes the endpoint from analysis
+// and why
+func (e *Endpoint) Skipped() (reason string, skipped bool) {
+	switch value := e.Extensions[ExtSkip].(type) {
+	case bool:
+		return ExtSkip, value
+	case string:
+		return value, value != ""
+	}
+	return "", false
+}
+
+// Auth is the x-glens-auth mode of the endpoint, e.g. "none" for public
+// endpoints tests call without credentials; empty when not set
+func (e *Endpoint) Auth() string {
+	return e.extensionString(ExtAuth)
+}
+
+// Public reports whether x-glens-auth: none marks the endpoint public
+func (e *Endpoint) Public() bool {
+	return e.Auth() == "none"
+}
+
+// TestData is the x-glens-test-data of the endpoint as indented JSON, or
+// the text itself when it is a string; empty when not set
+func (e *Endpoint) TestData() string {
+	value, ok := e.Extensions[ExtTestData]
+	if !ok || value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok {
+		return strings.TrimSpace(text)
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// Priority is the x-glens-priority of the endpoint: high, normal or low
+func (e *Endpoint) Priority() string {
+	if priority := e.extensionString(ExtPriority); priority != "" {
+		if _, ok := priorityRank[priority]; ok {
+			return priority
+		}
+	}
+	return PriorityNormal
+}
+
+// PriorityAtLeast reports whether the endpoint's priority is min or higher;
+// an empty min matches every endpoint
+func (e *Endpoint) PriorityAtLeast(min string) bool {
+	if min == "" {
+		return true
+	}
+	return priorityRank[e.Priority()] >= priorityRank[strings.ToLower(min)]
+}
+
+// ComparePriority orders endpoints from high to low priority, for use with
+// slices.SortStableFunc
+func ComparePriority(a, b Endpoint) int {
+	return priorityRank[b.Priority()] - priorityRank[a.Priority()]
+}
+
+// DeclaredRisk is the safety risk x-glens-risk declares for the endpoint,
+// overriding the one inferred from its method and path; empty when not set
+func (e *Endpoint) DeclaredRisk() string {
+	return e.extensionString(ExtRisk)
+}
+
+// HasHints reports whether extensions guide the endpoint's prompt
+func (e *Endpoint) HasHints() bool {
+	return e.Public() || e.TestData() != "" || e.Priority() != PriorityNormal
+}
+
+// ValidPriority reports whether name is a priority of x-glens-priority
+func ValidPriority(name string) bool {
+	_, ok := priorityRank[strings.ToLower(name)]
+	return ok
+}
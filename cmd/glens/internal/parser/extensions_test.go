@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const extensionsSpec = `
+openapi: 3.0.3
+info:
+  title: Shop
+  version: 1.0.0
+paths:
+  /health:
+    x-glens-priority: low
+    get:
+      operationId: health
+      x-glens-auth: None
+      responses:
+        '200':
+          description: OK
+  /orders:
+    x-glens-priority: low
+    post:
+      operationId: createOrder
+      x-glens-priority: high
+      x-glens-risk: high
+      x-glens-test-data:
+        sku: ABC-1
+        quantity: 2
+      responses:
+        '201':
+          description: Created
+  /legacy:
+    get:
+      operationId: legacy
+      x-glens-skip: upstream is flaky
+      responses:
+        '200':
+          description: OK
+  /export:
+    get:
+      operationId: export
+      x-glens-skip: true
+      x-glens-test-data: Use the export of 2024-01-01
+      responses:
+        '200':
+          description: OK
+`
+
+func TestParseOpenAPISpec_Extensions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(extensionsSpec), 0o600))
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	byID := map[string]*Endpoint{}
+	for i := range spec.Endpoints {
+		byID[spec.Endpoints[i].OperationID] = &spec.Endpoints[i]
+	}
+
+	health := byID["health"]
+	assert.Equal(t, map[string]interface{}{"x-glens-auth": "None", "x-glens-priority": "low"}, health.Extensions,
+		"path item extensions are inherited")
+	assert.True(t, health.Public())
+	assert.Equal(t, PriorityLow, health.Priority())
+	assert.True(t, health.HasHints())
+
+	order := byID["createOrder"]
+	assert.Equal(t, PriorityHigh, order.Priority(), "operation extensions override the path item's")
+	assert.Equal(t, "high", order.DeclaredRisk())
+	assert.Equal(t, "{\n  \"quantity\": 2,\n  \"sku\": \"ABC-1\"\n}", order.TestData())
+	assert.False(t, order.Public())
+
+	reason, skipped := byID["legacy"].Skipped()
+	assert.True(t, skipped)
+	assert.Equal(t, "upstream is flaky", reason)
+	reason, skipped = byID["export"].Skipped()
+	assert.True(t, skipped)
+	assert.Equal(t, ExtSkip, reason)
+	assert.Equal(t, "Use the export of 2024-01-01", byID["export"].TestData())
+	_, skipped = order.Skipped()
+	assert.False(t, skipped)
+
+	sorted := slices.Clone(spec.Endpoints)
+	slices.SortStableFunc(sorted, ComparePriority)
+	assert.Equal(t, "createOrder", sorted[0].OperationID)
+	assert.Equal(t, "health", sorted[len(sorted)-1].OperationID)
+
+	filter, err := NewFilter(Selection{MinPriority: "normal"})
+	require.NoError(t, err)
+	assert.Len(t, filter.Apply(spec), 3, "only the low-priority health check is dropped")
+	_, err = NewFilter(Selection{MinPriority: "urgent"})
+	assert.EqualError(t, err, `invalid priority "urgent": must be high, normal or low`)
+}
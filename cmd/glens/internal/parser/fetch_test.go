@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fetchSpec = `openapi: 3.0.0
+info: {title: Protected, version: "1"}
+paths: {}
+`
+
+func TestFetch_HeadersAndCache(t *testing.T) {
+	var requests, full int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer spec-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(fetchSpec))
+	}))
+	defer server.Close()
+
+	_, err := fetch(context.Background(), server.URL, FetchConfig{DisableCache: true})
+	assert.ErrorContains(t, err, "HTTP 401")
+
+	cfg := FetchConfig{Headers: map[string]string{"authorization": "Bearer spec-token"}, CacheDir: t.TempDir()}
+	for range 2 {
+		data, err := fetch(context.Background(), server.URL, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, fetchSpec, string(data))
+	}
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 1, full, "the unchanged spec is served from the cache")
+	assert.Equal(t, []string{"Bearer spec-token"}, cfg.Secrets())
+}
+
+func TestFetch_Redirects(t *testing.T) {
+	var forwarded string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte(fetchSpec))
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			http.Redirect(w, r, other.URL+"/spec.yaml", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := FetchConfig{Headers: map[string]string{"X-Api-Key": "k"}, DisableCache: true}
+	data, err := fetch(context.Background(), server.URL+"/spec.yaml", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, fetchSpec, string(data))
+	assert.Empty(t, forwarded, "headers are not forwarded to other hosts")
+
+	_, err = fetch(context.Background(), server.URL+"/loop", FetchConfig{MaxRedirects: 3, DisableCache: true})
+	assert.ErrorContains(t, err, "stopped after 4 redirects")
+	_, err = fetch(context.Background(), server.URL+"/spec.yaml", FetchConfig{MaxRedirects: -1, DisableCache: true})
+	assert.ErrorContains(t, err, "stopped after 1 redirects")
+}
+
+func TestFetch_GzipAndSizeLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(fetchSpec + "# " + strings.Repeat("a", 2<<20) + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoded.yaml":
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(compressed.Bytes())
+		case "/spec.yaml.gz":
+			_, _ = w.Write(compressed.Bytes())
+		default:
+			_, _ = w.Write(bytes.Repeat([]byte("a"), 2<<20))
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/encoded.yaml", "/spec.yaml.gz"} {
+		data, err := fetch(context.Background(), server.URL+path, FetchConfig{DisableCache: true})
+		require.NoError(t, err, path)
+		assert.True(t, strings.HasPrefix(string(data), fetchSpec), path)
+	}
+
+	_, err = fetch(context.Background(), server.URL+"/large.yaml", FetchConfig{MaxSizeMB: 1, DisableCache: true})
+	assert.ErrorContains(t, err, "exceeds the 1 MB size limit")
+	// The limit applies to the decompressed spec too
+	_, err = fetch(context.Background(), server.URL+"/encoded.yaml", FetchConfig{MaxSizeMB: 1, DisableCache: true})
+	assert.ErrorContains(t, err, "exceeds the 1 MB size limit")
+}
+
+func TestFetch_ResolveHeader(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(fetchSpec))
+	}))
+	defer server.Close()
+	resolve := func(_ context.Context, value string) (string, error) {
+		if value == "vault://secret/spec#token" {
+			return "Bearer spec-token", nil
+		}
+		return "", errors.New("secret not found")
+	}
+
+	cfg := FetchConfig{Headers: map[string]string{"Authorization": "vault://secret/spec#token"}, DisableCache: true, ResolveHeader: resolve}
+	_, err := fetch(context.Background(), server.URL, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer spec-token", received)
+
+	cfg.Headers["Authorization"] = "vault://secret/missing"
+	_, err = fetch(context.Background(), server.URL, cfg)
+	assert.ErrorContains(t, err, "secret not found")
+}
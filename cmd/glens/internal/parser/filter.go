@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RegexpPrefix marks a Selection.Path as a regular expression instead of a glob
+const RegexpPrefix = "re:"
+
+// Selection describes which endpoints to include. Empty fields select every
+// endpoint; a non-empty list matches when any of its entries does.
+type Selection struct {
+	// Tags match endpoints carrying any of these tags
+	Tags []string
+	// Methods match case-insensitively, e.g. "get", "POST"
+	Methods []string
+	// Path matches the endpoint path with a glob ("*" within one segment,
+	// "**" across segments, "?" one character), or with a regular
+	// expression when prefixed with "re:", e.g. "re:^/v[12]/users"
+	Path string
+	// Exclude drops endpoints by operation ID, endpoint ID or "METHOD /path"
+	Exclude []string
+	// MinPriority drops endpoints whose x-glens-priority is lower, e.g.
+	// "high" keeps only high-priority endpoints
+	MinPriority string
+}
+
+// IsZero reports whether s selects every endpoint
+func (s Selection) IsZero() bool {
+	return len(s.Tags) == 0 && len(s.Methods) == 0 && s.Path == "" &&
+		len(s.Exclude) == 0 && s.MinPriority == ""
+}
+
+// String describes the set criteria, e.g. "methods=GET path=/admin/**"
+func (s Selection) String() string {
+	var parts []string
+	if len(s.Tags) > 0 {
+		parts = append(parts, "tags="+strings.Join(s.Tags, ","))
+	}
+	if len(s.Methods) > 0 {
+		parts = append(parts, "methods="+strings.Join(s.Methods, ","))
+	}
+	if s.Path != "" {
+		parts = append(parts, "path="+s.Path)
+	}
+	if len(s.Exclude) > 0 {
+		parts = append(parts, "exclude="+strings.Join(s.Exclude, ","))
+	}
+	if s.MinPriority != "" {
+		parts = append(parts, "min_priority="+s.MinPriority)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Filter matches endpoints against a compiled Selection
+type Filter struct {
+	Selection
+	path *regexp.Regexp
+}
+
+// NewFilter validates the path pattern of s and returns its filter
+func NewFilter(s Selection) (*Filter, error) {
+	f := &Filter{Selection: s}
+	if s.MinPriority != "" && !ValidPriority(s.MinPriority) {
+		return nil, fmt.Errorf("invalid priority %q: must be %s, %s or %s", s.MinPriority, PriorityHigh, PriorityNormal, PriorityLow)
+	}
+	if s.Path == "" {
+		return f, nil
+	}
+
+	var err error
+	if expr, ok := strings.CutPrefix(s.Path, RegexpPrefix); ok {
+		f.path, err = regexp.Compile(expr)
+	} else {
+		f.path, err = globRegexp(s.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern %q: %w", s.Path, err)
+	}
+	return f, nil
+}
+
+// Match reports whether e passes every set criterion
+func (f *Filter) Match(e *Endpoint) bool {
+	if len(f.Tags) > 0 && !slices.ContainsFunc(f.Tags, func(tag string) bool {
+		return slices.Contains(e.Tags, tag)
+	}) {
+		return false
+	}
+	if len(f.Methods) > 0 && !slices.ContainsFunc(f.Methods, func(method string) bool {
+		return strings.EqualFold(method, e.Method)
+	}) {
+		return false
+	}
+	if f.path != nil && !f.path.MatchString(e.Path) {
+		return false
+	}
+	if !e.PriorityAtLeast(f.MinPriority) {
+		return false
+	}
+	return !slices.ContainsFunc(f.Exclude, e.Matches)
+}
+
+// Apply returns the endpoints of s that match f, in spec order
+func (f *Filter) Apply(s *OpenAPISpec) []Endpoint {
+	return f.Select(s.Endpoints)
+}
+
+// Select returns the endpoints that match f, in their original order
+func (f *Filter) Select(endpoints []Endpoint) []Endpoint {
+	matched := make([]Endpoint, 0, len(endpoints))
+	for i := range endpoints {
+		if f.Match(&endpoints[i]) {
+			matched = append(matched, endpoints[i])
+		}
+	}
+	return matched
+}
+
+// globRegexp translates a path glob into an anchored regular expression
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func filterSpec() *OpenAPISpec {
+	return &OpenAPISpec{Endpoints: []Endpoint{
+		{Method: "GET", Path: "/users", Tags: []string{"users"}},
+		{Method: "POST", Path: "/users", Tags: []string{"users"}},
+		{Method: "GET", Path: "/users/{id}", Tags: []string{"users"}},
+		{Method: "DELETE", Path: "/users/{id}/sessions/{sid}", Tags: []string{"users", "auth"}},
+		{Method: "GET", Path: "/health", OperationID: "health", Deprecated: true},
+	}}
+}
+
+func paths(endpoints []Endpoint) []string {
+	out := make([]string, len(endpoints))
+	for i, e := range endpoints {
+		out[i] = e.Method + " " + e.Path
+	}
+	return out
+}
+
+func TestFilter_Apply(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection Selection
+		want      []string
+	}{
+		{"no criteria", Selection{}, []string{"GET /users", "POST /users", "GET /users/{id}", "DELETE /users/{id}/sessions/{sid}", "GET /health"}},
+		{"tag", Selection{Tags: []string{"auth"}}, []string{"DELETE /users/{id}/sessions/{sid}"}},
+		{"any tag", Selection{Tags: []string{"auth", "missing"}}, []string{"DELETE /users/{id}/sessions/{sid}"}},
+		{"method case-insensitive", Selection{Methods: []string{"get"}}, []string{"GET /users", "GET /users/{id}", "GET /health"}},
+		{"single segment glob", Selection{Path: "/users/*"}, []string{"GET /users/{id}"}},
+		{"multi segment glob", Selection{Path: "/users/**"}, []string{"GET /users/{id}", "DELETE /users/{id}/sessions/{sid}"}},
+		{"question mark", Selection{Path: "/healt?"}, []string{"GET /health"}},
+		{"regexp", Selection{Path: "re:^/users/\\{id\\}(/|$)"}, []string{"GET /users/{id}", "DELETE /users/{id}/sessions/{sid}"}},
+		{"exclude", Selection{Methods: []string{"GET"}, Exclude: []string{"health", "get /users"}}, []string{"GET /users/{id}"}},
+		{"combined", Selection{Tags: []string{"users"}, Methods: []string{"GET", "POST"}, Path: "/users"}, []string{"GET /users", "POST /users"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.selection)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, paths(f.Apply(filterSpec())))
+		})
+	}
+}
+
+func TestNewFilter_InvalidRegexp(t *testing.T) {
+	_, err := NewFilter(Selection{Path: "re:("})
+	assert.ErrorContains(t, err, `invalid path pattern "re:("`)
+}
+
+func TestSelection_String(t *testing.T) {
+	assert.True(t, Selection{}.IsZero())
+	s := Selection{Methods: []string{"GET"}, Path: "/admin/*", Exclude: []string{"ping"}}
+	assert.False(t, s.IsZero())
+	assert.Equal(t, "methods=GET path=/admin/* exclude=ping", s.String())
+}
+
+func TestFilter_GlobEscapesRegexp(t *testing.T) {
+	f, err := NewFilter(Selection{Path: "/v1.0/(items)"})
+	require.NoError(t, err)
+	assert.True(t, f.Match(&Endpoint{Path: "/v1.0/(items)"}))
+	assert.False(t, f.Match(&Endpoint{Path: "/v1x0/(items)"}))
+}
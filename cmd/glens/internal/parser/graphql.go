@@ -0,0 +1,545 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// GraphQLPath is the path GraphQL operations are posted to
+const GraphQLPath = "/graphql"
+
+// GraphQLOperation is the field of a GraphQL schema's Query or Mutation
+// type an endpoint calls
+type GraphQLOperation struct {
+	// Type is the root operation type: query or mutation
+	Type string `json:"type"`
+	// Field is the name of the root field, e.g. user
+	Field string `json:"field"`
+	// Arguments are the field's arguments, in order
+	Arguments []GraphQLArgument `json:"arguments,omitempty"`
+	// ReturnType is the field's GraphQL type, e.g. [User!]!
+	ReturnType string `json:"return_type"`
+	// Document is a document calling the field with its arguments as
+	// variables and selecting the scalar fields of the result
+	Document string `json:"document"`
+}
+
+// GraphQLArgument is an argument of a GraphQL field
+type GraphQLArgument struct {
+	Name string `json:"name"`
+	// Type is the argument's GraphQL type, e.g. ID!
+	Type string `json:"type"`
+}
+
+// gqlSchema is a GraphQL schema read from SDL or an introspection result
+type gqlSchema struct {
+	description string
+	// query and mutation name the root operation types
+	query, mutation string
+	types           map[string]*gqlType
+}
+
+// gqlType is a named type of a GraphQL schema. Its kind is that of the
+// introspection system: OBJECT, INTERFACE, UNION, ENUM, INPUT_OBJECT or
+// SCALAR.
+type gqlType struct {
+	kind        string
+	name        string
+	description string
+	// fields are the fields of objects and interfaces and the input fields
+	// of input objects
+	fields     []gqlField
+	enumValues []string
+}
+
+// gqlField is a field, argument or input field of a GraphQL type
+type gqlField struct {
+	name        string
+	description string
+	args        []gqlField
+	typ         gqlTypeRef
+	hasDefault  bool
+	deprecated  bool
+	// deprecationReason is the reason of @deprecated, if given
+	deprecationReason string
+}
+
+// gqlTypeRef is a reference to a named type, or a list of an element type,
+// that may be non-null
+type gqlTypeRef struct {
+	name    string
+	ofType  *gqlTypeRef
+	nonNull bool
+}
+
+// String returns the reference in GraphQL notation, e.g. [User!]!
+func (t gqlTypeRef) String() string {
+	s := t.name
+	if t.ofType != nil {
+		s = "[" + t.ofType.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// named returns the name of the type a reference ends in, e.g. User for
+// [User!]!
+func (t gqlTypeRef) named() string {
+	for t.ofType != nil {
+		t = *t.ofType
+	}
+	return t.name
+}
+
+// Kinds of GraphQL types, as named by the introspection system
+const (
+	gqlObject      = "OBJECT"
+	gqlInterface   = "INTERFACE"
+	gqlUnion       = "UNION"
+	gqlEnum        = "ENUM"
+	gqlInputObject = "INPUT_OBJECT"
+	gqlScalar      = "SCALAR"
+)
+
+// gqlScalars maps the built-in GraphQL scalars to JSON schema types
+var gqlScalars = map[string]string{
+	"Int":     "integer",
+	"Float":   "number",
+	"String":  "string",
+	"ID":      "string",
+	"Boolean": "boolean",
+}
+
+// maxGraphQLDepth bounds how deep input and output types are expanded into
+// schemas, as GraphQL types commonly refer to each other
+const maxGraphQLDepth = 3
+
+// maxSelectionDepth bounds how deep generated documents select the fields
+// of nested objects
+const maxSelectionDepth = 2
+
+var sdlStartPattern = regexp.MustCompile(`^(?:\s*#[^\n]*\n)*\s*(?:"""|(?:schema|type|extend|scalar|interface|input|enum|union|directive)\b)`)
+
+// isGraphQLSDL determines if the content is a GraphQL schema in the schema
+// definition language, based on file extension or content
+func isGraphQLSDL(source string, data []byte) bool {
+	lower := strings.ToLower(source)
+	for _, ext := range []string{".graphql", ".graphqls", ".gql"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return sdlStartPattern.Match(data)
+}
+
+// isGraphQLIntrospection determines if JSON content is the result of a
+// GraphQL introspection query
+func isGraphQLIntrospection(data []byte) bool {
+	content := bytes.TrimSpace(data)
+	return len(content) > 0 && content[0] == '{' && bytes.Contains(content, []byte(`"__schema"`))
+}
+
+// parseGraphQLSDL converts a GraphQL schema in SDL to a specification
+func parseGraphQLSDL(data []byte) (*OpenAPISpec, error) {
+	schema, err := parseSDL(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL schema: %w", err)
+	}
+	return graphQLSpec(schema)
+}
+
+// introspectionResult is the result of an introspection query, with or
+// without the data envelope of a GraphQL response
+type introspectionResult struct {
+	Schema *introspectionSchema `json:"__schema"`
+	Data   struct {
+		Schema *introspectionSchema `json:"__schema"`
+	} `json:"data"`
+}
+
+type introspectionSchema struct {
+	Description string `json:"description"`
+	QueryType   *struct {
+		Name string `json:"name"`
+	} `json:"queryType"`
+	MutationType *struct {
+		Name string `json:"name"`
+	} `json:"mutationType"`
+	Types []introspectionType `json:"types"`
+}
+
+type introspectionType struct {
+	Kind        string               `json:"kind"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Fields      []introspectionField `json:"fields"`
+	InputFields []introspectionValue `json:"inputFields"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+type introspectionField struct {
+	Name              string               `json:"name"`
+	Description       string               `json:"description"`
+	Args              []introspectionValue `json:"args"`
+	Type              introspectionTypeRef `json:"type"`
+	IsDeprecated      bool                 `json:"isDeprecated"`
+	DeprecationReason string               `json:"deprecationReason"`
+}
+
+type introspectionValue struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	Type         introspectionTypeRef `json:"type"`
+	DefaultValue *string              `json:"defaultValue"`
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   string                `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+// ref converts an introspection type reference, whose NON_NULL and LIST
+// wrappers nest through ofType
+func (r introspectionTypeRef) ref() gqlTypeRef {
+	switch {
+	case r.Kind == "NON_NULL" && r.OfType != nil:
+		inner := r.OfType.ref()
+		inner.nonNull = true
+		return inner
+	case r.Kind == "LIST" && r.OfType != nil:
+		inner := r.OfType.ref()
+		return gqlTypeRef{ofType: &inner}
+	}
+	return gqlTypeRef{name: r.Name}
+}
+
+// value converts an argument or input field
+func (v introspectionValue) value() gqlField {
+	return gqlField{
+		name:        v.Name,
+		description: v.Description,
+		typ:         v.Type.ref(),
+		hasDefault:  v.DefaultValue != nil,
+	}
+}
+
+// parseGraphQLIntrospection converts the JSON result of an introspection
+// query to a specification
+func parseGraphQLIntrospection(data []byte) (*OpenAPISpec, error) {
+	var result introspectionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL introspection result: %w", err)
+	}
+	raw := result.Schema
+	if raw == nil {
+		raw = result.Data.Schema
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("failed to parse GraphQL introspection result: no __schema")
+	}
+
+	schema := &gqlSchema{description: raw.Description, types: make(map[string]*gqlType)}
+	if raw.QueryType != nil {
+		schema.query = raw.QueryType.Name
+	}
+	if raw.MutationType != nil {
+		schema.mutation = raw.MutationType.Name
+	}
+	for _, rt := range raw.Types {
+		t := &gqlType{kind: rt.Kind, name: rt.Name, description: rt.Description}
+		for _, f := range rt.Fields {
+			field := gqlField{
+				name:              f.Name,
+				description:       f.Description,
+				typ:               f.Type.ref(),
+				deprecated:        f.IsDeprecated,
+				deprecationReason: f.DeprecationReason,
+			}
+			for _, arg := range f.Args {
+				field.args = append(field.args, arg.value())
+			}
+			t.fields = append(t.fields, field)
+		}
+		for _, f := range rt.InputFields {
+			t.fields = append(t.fields, f.value())
+		}
+		for _, v := range rt.EnumValues {
+			t.enumValues = append(t.enumValues, v.Name)
+		}
+		schema.types[t.name] = t
+	}
+	return graphQLSpec(schema)
+}
+
+// graphQLSpec maps the fields of a schema's Query and Mutation types to
+// endpoints posting to /graphql, with the field's arguments as parameters
+// and its return type as the schema of the response's data; GraphQL errors
+// are documented as a 400 response. Subscriptions
+// are not mapped, as they are not served over plain HTTP requests.
+func graphQLSpec(schema *gqlSchema) (*OpenAPISpec, error) {
+	spec := &OpenAPISpec{
+		Info:      Info{Title: "GraphQL API", Description: schema.description},
+		Version:   "GraphQL",
+		Endpoints: []Endpoint{},
+	}
+	roots := 0
+	for _, root := range []struct{ kind, name string }{{KindQuery, schema.query}, {KindMutation, schema.mutation}} {
+		t, ok := schema.types[root.name]
+		if !ok {
+			continue
+		}
+		roots++
+		for _, field := range t.fields {
+			spec.Endpoints = append(spec.Endpoints, schema.endpoint(root.kind, field))
+		}
+	}
+	if roots == 0 {
+		return nil, fmt.Errorf("GraphQL schema has no %s or %s type", schema.query, schema.mutation)
+	}
+	return spec, nil
+}
+
+// endpoint maps a root field of the kind operation type to an endpoint
+func (s *gqlSchema) endpoint(kind string, field gqlField) Endpoint {
+	operation := &GraphQLOperation{Type: kind, Field: field.name, ReturnType: field.typ.String()}
+	e := Endpoint{
+		ID:          strings.ToUpper(kind) + "_" + field.name,
+		Kind:        kind,
+		Method:      http.MethodPost,
+		Path:        GraphQLPath,
+		OperationID: field.name,
+		Summary:     strings.TrimSpace(strings.SplitN(field.description, "\n", 2)[0]),
+		Description: field.description,
+		Tags:        []string{kind},
+		Deprecated:  field.deprecated,
+		// Queries are read-only by the GraphQL specification
+		XSafe:   kind == KindQuery,
+		GraphQL: operation,
+	}
+	if field.deprecationReason != "" {
+		e.Description = strings.TrimSpace(e.Description + "\n\nDeprecated: " + field.deprecationReason)
+	}
+	if kind == KindMutation && operationNoun(field.name, deleteVerbs) != "" {
+		e.Extensions = map[string]interface{}{ExtRisk: "high"}
+	}
+
+	variables := Schema{Type: "object", Properties: make(map[string]Schema)}
+	for _, arg := range field.args {
+		schema := s.schemaOf(arg.typ, 0)
+		schema.Description = arg.description
+		required := arg.typ.nonNull && !arg.hasDefault
+		e.Parameters = append(e.Parameters, Parameter{
+			Name:        arg.name,
+			In:          "argument",
+			Description: arg.description,
+			Required:    required,
+			Schema:      schema,
+		})
+		variables.Properties[arg.name] = schema
+		if required {
+			variables.Required = append(variables.Required, arg.name)
+		}
+		operation.Arguments = append(operation.Arguments, GraphQLArgument{Name: arg.name, Type: arg.typ.String()})
+	}
+	operation.Document = s.document(kind, field)
+
+	body := Schema{
+		Type:     "object",
+		Required: []string{"query"},
+		Properties: map[string]Schema{
+			"query":     {Type: "string", Description: "GraphQL document", Example: operation.Document},
+			"variables": variables,
+		},
+	}
+	if len(variables.Required) > 0 {
+		body.Required = append(body.Required, "variables")
+	}
+	e.RequestBody = &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: body}},
+	}
+
+	e.Responses = map[string]Response{
+		"200": {
+			Description: "The field's result",
+			Content: map[string]MediaType{"application/json": {Schema: Schema{
+				Type:     "object",
+				Required: []string{"data"},
+				Properties: map[string]Schema{"data": {
+					Type:       "object",
+					Properties: map[string]Schema{field.name: s.schemaOf(field.typ, 0)},
+					Required:   []string{field.name},
+				}},
+			}}},
+		},
+		"400": {
+			Description: "Invalid document or variables; many servers answer 200 with the errors instead",
+			Content: map[string]MediaType{"application/json": {Schema: Schema{
+				Type:     "object",
+				Required: []string{"errors"},
+				Properties: map[string]Schema{"errors": {
+					Type: "array",
+					Items: &Schema{
+						Type:       "object",
+						Properties: map[string]Schema{"message": {Type: "string"}},
+						Required:   []string{"message"},
+					},
+				}},
+			}}},
+		},
+	}
+	return e
+}
+
+// schemaOf converts a type reference to a JSON schema. Nullable types are
+// marked nullable; objects are expanded up to maxGraphQLDepth.
+func (s *gqlSchema) schemaOf(ref gqlTypeRef, depth int) Schema {
+	var schema Schema
+	switch {
+	case ref.ofType != nil:
+		items := s.schemaOf(*ref.ofType, depth)
+		schema = Schema{Type: "array", Items: &items}
+	case gqlScalars[ref.name] != "":
+		schema = Schema{Type: gqlScalars[ref.name]}
+	default:
+		schema = s.namedSchema(ref.name, depth)
+	}
+	schema.Nullable = !ref.nonNull
+	return schema
+}
+
+// namedSchema converts a named type that is not a built-in scalar
+func (s *gqlSchema) namedSchema(name string, depth int) Schema {
+	t, ok := s.types[name]
+	if !ok || t.kind == gqlScalar {
+		// Custom scalars such as DateTime are serialised as strings
+		return Schema{Type: "string", Format: name, Description: typeDescription(t)}
+	}
+	schema := Schema{Description: t.description}
+	switch t.kind {
+	case gqlEnum:
+		schema.Type = "string"
+		for _, value := range t.enumValues {
+			schema.Enum = append(schema.Enum, value)
+		}
+	case gqlObject, gqlInterface, gqlInputObject:
+		schema.Type = "object"
+		if depth >= maxGraphQLDepth {
+			break
+		}
+		schema.Properties = make(map[string]Schema, len(t.fields))
+		for _, f := range t.fields {
+			property := s.schemaOf(f.typ, depth+1)
+			if f.description != "" {
+				property.Description = f.description
+			}
+			schema.Properties[f.name] = property
+			if f.typ.nonNull && (t.kind != gqlInputObject || !f.hasDefault) {
+				schema.Required = append(schema.Required, f.name)
+			}
+		}
+	default:
+		// Unions are objects of one of their member types
+		schema.Type = "object"
+	}
+	return schema
+}
+
+// typeDescription returns the description of t, which may be nil
+func typeDescription(t *gqlType) string {
+	if t == nil {
+		return ""
+	}
+	return t.description
+}
+
+// document returns a document calling field with each argument passed as a
+// variable of the same name, e.g.
+//
+//	query User($id: ID!) {
+//	  user(id: $id) {
+//	    id
+//	    name
+//	  }
+//	}
+func (s *gqlSchema) document(kind string, field gqlField) string {
+	var sb strings.Builder
+	sb.WriteString(kind + " " + strings.ToUpper(field.name[:1]) + field.name[1:])
+	if len(field.args) > 0 {
+		variables := make([]string, len(field.args))
+		arguments := make([]string, len(field.args))
+		for i, arg := range field.args {
+			variables[i] = "$" + arg.name + ": " + arg.typ.String()
+			arguments[i] = arg.name + ": $" + arg.name
+		}
+		sb.WriteString("(" + strings.Join(variables, ", ") + ")")
+		sb.WriteString(" {\n  " + field.name + "(" + strings.Join(arguments, ", ") + ")")
+	} else {
+		sb.WriteString(" {\n  " + field.name)
+	}
+	s.writeSelection(&sb, field.typ.named(), "  ", 0)
+	sb.WriteString("\n}")
+	return sb.String()
+}
+
+// writeSelection writes the selection set of an object, interface or union
+// type: its scalar and enum fields, and those of its object fields up to
+// maxSelectionDepth. Fields with required arguments are left out. Types
+// without such fields select __typename; scalars and enums have no
+// selection set.
+func (s *gqlSchema) writeSelection(sb *strings.Builder, name, indent string, depth int) {
+	t, ok := s.types[name]
+	if !ok || t.kind == gqlScalar || t.kind == gqlEnum {
+		return
+	}
+
+	var lines []string
+	if t.kind == gqlObject || t.kind == gqlInterface {
+		for _, f := range t.fields {
+			if f.deprecated || hasRequiredArgs(f.args) {
+				continue
+			}
+			fieldType, ok := s.types[f.typ.named()]
+			if !ok || fieldType.kind == gqlScalar || fieldType.kind == gqlEnum {
+				lines = append(lines, f.name)
+				continue
+			}
+			if depth+1 >= maxSelectionDepth {
+				continue
+			}
+			var nested strings.Builder
+			nested.WriteString(f.name)
+			s.writeSelection(&nested, f.typ.named(), indent+"  ", depth+1)
+			lines = append(lines, nested.String())
+		}
+	}
+	if len(lines) == 0 {
+		lines = []string{"__typename"}
+	}
+
+	sb.WriteString(" {")
+	for _, line := range lines {
+		sb.WriteString("\n" + indent + "  " + line)
+	}
+	sb.WriteString("\n" + indent + "}")
+}
+
+// hasRequiredArgs reports whether any of args must be given, which
+// a selection without arguments cannot satisfy
+func hasRequiredArgs(args []gqlField) bool {
+	for _, arg := range args {
+		if arg.typ.nonNull && !arg.hasDefault {
+			return true
+		}
+	}
+	return false
+}
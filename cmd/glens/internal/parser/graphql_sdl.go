@@ -0,0 +1,474 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sdlToken is a lexical token of the GraphQL schema definition language
+type sdlToken struct {
+	kind  int
+	value string
+	line  int
+}
+
+// Kinds of SDL tokens
+const (
+	tokEOF = iota
+	tokName
+	tokString
+	tokNumber
+	tokPunct
+)
+
+// lexSDL splits a schema into tokens, dropping whitespace, commas and
+// comments. Strings are unescaped and block strings dedented.
+func lexSDL(source string) ([]sdlToken, error) {
+	source = strings.TrimPrefix(source, "\ufeff")
+	var tokens []sdlToken
+	line := 1
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], `"""`):
+			end := blockStringEnd(source, i+3)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated block string", line)
+			}
+			raw := source[i+3 : end]
+			tokens = append(tokens, sdlToken{tokString, blockStringValue(strings.ReplaceAll(raw, `\"""`, `"""`)), line})
+			line += strings.Count(raw, "\n")
+			i = end + 3
+		case c == '"':
+			j := i + 1
+			for j < len(source) && source[j] != '"' && source[j] != '\n' {
+				if source[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(source) || source[j] != '"' {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			value, err := strconv.Unquote(source[i : j+1])
+			if err != nil {
+				value = source[i+1 : j]
+			}
+			tokens = append(tokens, sdlToken{tokString, value, line})
+			i = j + 1
+		case strings.HasPrefix(source[i:], "..."):
+			tokens = append(tokens, sdlToken{tokPunct, "...", line})
+			i += 3
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			tokens = append(tokens, sdlToken{tokPunct, string(c), line})
+			i++
+		case isNameStart(c):
+			j := i + 1
+			for j < len(source) && (isNameStart(source[j]) || isDigit(source[j])) {
+				j++
+			}
+			tokens = append(tokens, sdlToken{tokName, source[i:j], line})
+			i = j
+		case c == '-' || isDigit(c):
+			j := i + 1
+			for j < len(source) && (isDigit(source[j]) || strings.IndexByte(".eE+-", source[j]) >= 0) {
+				j++
+			}
+			tokens = append(tokens, sdlToken{tokNumber, source[i:j], line})
+			i = j
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+	}
+	return append(tokens, sdlToken{kind: tokEOF, line: line}), nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// blockStringEnd returns the index of the """ closing a block string whose
+// content starts at start, or -1 when it is not closed
+func blockStringEnd(source string, start int) int {
+	for j := start; j+3 <= len(source); j++ {
+		if source[j] == '\\' && strings.HasPrefix(source[j+1:], `"""`) {
+			j += 3
+			continue
+		}
+		if strings.HasPrefix(source[j:], `"""`) {
+			return j
+		}
+	}
+	return -1
+}
+
+// blockStringValue removes the common indentation of a block string's
+// lines after the first, and its leading and trailing blank lines
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// sdlParser parses the type system definitions of a schema. The first
+// error stops parsing: every method returns zero values once err is set.
+type sdlParser struct {
+	tokens []sdlToken
+	pos    int
+	err    error
+	schema *gqlSchema
+	// extensions are the types of extend definitions, merged into the
+	// types they extend once all definitions are read
+	extensions []*gqlType
+}
+
+// parseSDL parses a GraphQL schema in the schema definition language.
+// Without a schema definition the root operation types are Query and
+// Mutation.
+func parseSDL(source string) (*gqlSchema, error) {
+	tokens, err := lexSDL(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &sdlParser{
+		tokens: tokens,
+		schema: &gqlSchema{query: "Query", mutation: "Mutation", types: make(map[string]*gqlType)},
+	}
+	for p.err == nil && p.peek().kind != tokEOF {
+		p.definition()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	for _, ext := range p.extensions {
+		t, ok := p.schema.types[ext.name]
+		if !ok {
+			p.schema.types[ext.name] = ext
+			continue
+		}
+		t.fields = append(t.fields, ext.fields...)
+		t.enumValues = append(t.enumValues, ext.enumValues...)
+	}
+	return p.schema, nil
+}
+
+// definition parses a schema, type, extension or directive definition
+func (p *sdlParser) definition() {
+	description := p.description()
+	keyword := p.peek()
+	switch p.name() {
+	case "schema":
+		p.schema.query, p.schema.mutation = "", ""
+		p.schema.description = description
+		p.schemaDefinition()
+	case "extend":
+		if p.skipKeyword("schema") {
+			p.schemaDefinition()
+			return
+		}
+		if ext := p.typeDefinition(p.name(), ""); ext != nil {
+			p.extensions = append(p.extensions, ext)
+		}
+	case "directive":
+		p.directiveDefinition()
+	default:
+		t := p.typeDefinition(keyword.value, description)
+		if t == nil {
+			return
+		}
+		if _, ok := p.schema.types[t.name]; ok {
+			p.fail(fmt.Errorf("line %d: type %s is defined twice", keyword.line, t.name))
+			return
+		}
+		p.schema.types[t.name] = t
+	}
+}
+
+// schemaDefinition parses the root operation types of a schema definition
+func (p *sdlParser) schemaDefinition() {
+	p.directives()
+	p.expect("{")
+	for p.err == nil && !p.skip("}") {
+		operation := p.name()
+		p.expect(":")
+		name := p.name()
+		switch operation {
+		case "query":
+			p.schema.query = name
+		case "mutation":
+			p.schema.mutation = name
+		}
+	}
+}
+
+// typeDefinition parses the definition of a named type after its keyword,
+// which is the last token read
+func (p *sdlParser) typeDefinition(keyword, description string) *gqlType {
+	kinds := map[string]string{
+		"type":      gqlObject,
+		"interface": gqlInterface,
+		"union":     gqlUnion,
+		"enum":      gqlEnum,
+		"input":     gqlInputObject,
+		"scalar":    gqlScalar,
+	}
+	kind, ok := kinds[keyword]
+	if !ok {
+		p.unexpected(p.tokens[p.pos-1])
+		return nil
+	}
+	t := &gqlType{kind: kind, name: p.name(), description: description}
+
+	switch kind {
+	case gqlObject, gqlInterface, gqlInputObject:
+		if kind != gqlInputObject && p.skipKeyword("implements") {
+			p.skip("&")
+			p.name()
+			for p.skip("&") {
+				p.name()
+			}
+		}
+		p.directives()
+		if p.skip("{") {
+			for p.err == nil && !p.skip("}") {
+				t.fields = append(t.fields, p.field())
+			}
+		}
+	case gqlEnum:
+		p.directives()
+		if p.skip("{") {
+			for p.err == nil && !p.skip("}") {
+				p.description()
+				t.enumValues = append(t.enumValues, p.name())
+				p.directives()
+			}
+		}
+	case gqlUnion:
+		p.directives()
+		if p.skip("=") {
+			p.skip("|")
+			p.name()
+			for p.skip("|") {
+				p.name()
+			}
+		}
+	case gqlScalar:
+		p.directives()
+	}
+	return t
+}
+
+// field parses a field with its arguments, or an argument or input field
+// with its default value
+func (p *sdlParser) field() gqlField {
+	f := gqlField{description: p.description(), name: p.name()}
+	if p.skip("(") {
+		for p.err == nil && !p.skip(")") {
+			f.args = append(f.args, p.field())
+		}
+	}
+	p.expect(":")
+	f.typ = p.typeRef()
+	if p.skip("=") {
+		p.value()
+		f.hasDefault = true
+	}
+	f.deprecated, f.deprecationReason = p.directives()
+	return f
+}
+
+// typeRef parses a type reference such as [User!]!
+func (p *sdlParser) typeRef() gqlTypeRef {
+	var ref gqlTypeRef
+	if p.skip("[") {
+		inner := p.typeRef()
+		p.expect("]")
+		ref.ofType = &inner
+	} else {
+		ref.name = p.name()
+	}
+	ref.nonNull = p.skip("!")
+	return ref
+}
+
+// directives parses the directives applied to a definition and reports
+// whether one is @deprecated, with its reason
+func (p *sdlParser) directives() (deprecated bool, reason string) {
+	for p.err == nil && p.skip("@") {
+		name := p.name()
+		args := make(map[string]string)
+		if p.skip("(") {
+			for p.err == nil && !p.skip(")") {
+				arg := p.name()
+				p.expect(":")
+				args[arg] = p.value()
+			}
+		}
+		if name == "deprecated" {
+			deprecated, reason = true, args["reason"]
+		}
+	}
+	return deprecated, reason
+}
+
+// directiveDefinition parses a directive definition after its keyword
+func (p *sdlParser) directiveDefinition() {
+	p.expect("@")
+	p.name()
+	if p.skip("(") {
+		for p.err == nil && !p.skip(")") {
+			p.field()
+		}
+	}
+	p.skipKeyword("repeatable")
+	if !p.skipKeyword("on") {
+		p.unexpected(p.peek())
+		return
+	}
+	p.skip("|")
+	p.name()
+	for p.skip("|") {
+		p.name()
+	}
+}
+
+// value parses a constant or variable value and returns it as text; lists
+// and objects are skipped
+func (p *sdlParser) value() string {
+	tok := p.next()
+	if tok.kind != tokPunct {
+		return tok.value
+	}
+	switch tok.value {
+	case "$":
+		return "$" + p.name()
+	case "[":
+		for p.err == nil && !p.skip("]") {
+			p.value()
+		}
+		return "[]"
+	case "{":
+		for p.err == nil && !p.skip("}") {
+			p.name()
+			p.expect(":")
+			p.value()
+		}
+		return "{}"
+	}
+	p.unexpected(tok)
+	return ""
+}
+
+// description parses the optional string describing a definition
+func (p *sdlParser) description() string {
+	if p.peek().kind != tokString {
+		return ""
+	}
+	return p.next().value
+}
+
+// peek returns the next token, or an EOF token after an error
+func (p *sdlParser) peek() sdlToken {
+	if p.err != nil {
+		return sdlToken{kind: tokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+// next consumes the next token
+func (p *sdlParser) next() sdlToken {
+	tok := p.peek()
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// name consumes a name
+func (p *sdlParser) name() string {
+	tok := p.next()
+	if tok.kind != tokName {
+		p.unexpected(tok)
+		return ""
+	}
+	return tok.value
+}
+
+// skip consumes the next token if it is the punctuator punct
+func (p *sdlParser) skip(punct string) bool {
+	if tok := p.peek(); tok.kind == tokPunct && tok.value == punct {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipKeyword consumes the next token if it is the name keyword
+func (p *sdlParser) skipKeyword(keyword string) bool {
+	if tok := p.peek(); tok.kind == tokName && tok.value == keyword {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the punctuator punct
+func (p *sdlParser) expect(punct string) {
+	if !p.skip(punct) {
+		tok := p.peek()
+		if p.err == nil {
+			p.fail(fmt.Errorf("line %d: expected %q, found %s", tok.line, punct, describeToken(tok)))
+		}
+	}
+}
+
+// unexpected records an error for a token that does not fit the grammar
+func (p *sdlParser) unexpected(tok sdlToken) {
+	p.fail(fmt.Errorf("line %d: unexpected %s", tok.line, describeToken(tok)))
+}
+
+// fail records the first error
+func (p *sdlParser) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// describeToken returns a token as error messages quote it
+func describeToken(tok sdlToken) string {
+	if tok.kind == tokEOF {
+		return "end of schema"
+	}
+	return strconv.Quote(tok.value)
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const graphQLSchema = `
+# Pet store
+"""
+Pet store API
+"""
+schema { query: RootQuery, mutation: RootMutation }
+
+enum Status { AVAILABLE SOLD }
+
+type Pet implements Node & Named @key(fields: "id") {
+  id: ID!
+  "The pet's name"
+  name: String!
+  status: Status
+  owner: Owner
+  born: Date
+  photos(size: Int!): [String!]
+  legacy: String @deprecated(reason: "Use name")
+}
+
+type Owner { id: ID! }
+
+scalar Date
+
+input NewPet {
+  name: String!
+  status: Status = AVAILABLE
+}
+
+type RootQuery {
+  """
+  Find pets
+    by status
+  """
+  pets(status: Status, first: Int = 10): [Pet!]!
+  pet(id: ID!): Pet @deprecated
+}
+
+type RootMutation {
+  addPet(input: NewPet!): Pet!
+}
+
+extend type RootMutation {
+  removePet(id: ID!): Boolean!
+}
+
+type Subscription { petAdded: Pet! }
+
+directive @key(fields: String!) repeatable on OBJECT | INTERFACE
+`
+
+// parseGraphQL parses content written to a file called name
+func parseGraphQL(t *testing.T, name, content string) (*OpenAPISpec, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return ParseOpenAPISpec(path)
+}
+
+func TestParseGraphQLSDL(t *testing.T) {
+	spec, err := parseGraphQL(t, "schema.graphql", graphQLSchema)
+	require.NoError(t, err)
+	assert.Equal(t, "GraphQL", spec.Version)
+	assert.Equal(t, "Pet store API", spec.Info.Description)
+
+	ids := make([]string, len(spec.Endpoints))
+	for i, e := range spec.Endpoints {
+		ids[i] = e.ID
+	}
+	assert.Equal(t, []string{"QUERY_pets", "QUERY_pet", "MUTATION_addPet", "MUTATION_removePet"}, ids,
+		"subscriptions are not mapped; extensions add fields")
+
+	pets := spec.Endpoints[0]
+	assert.Equal(t, KindQuery, pets.Kind)
+	assert.Equal(t, "POST", pets.Method)
+	assert.Equal(t, GraphQLPath, pets.Path)
+	assert.Equal(t, "pets", pets.OperationID)
+	assert.Equal(t, "Find pets", pets.Summary)
+	assert.Equal(t, "Find pets\n  by status", pets.Description, "block strings are dedented")
+	assert.True(t, pets.XSafe, "queries have no side effects")
+	require.Len(t, pets.Parameters, 2)
+	assert.Equal(t, Parameter{
+		Name:   "status",
+		In:     "argument",
+		Schema: Schema{Type: "string", Nullable: true, Enum: []interface{}{"AVAILABLE", "SOLD"}},
+	}, pets.Parameters[0])
+	assert.False(t, pets.Parameters[1].Required, "arguments with defaults are optional")
+	assert.Equal(t, "[Pet!]!", pets.GraphQL.ReturnType)
+	assert.Equal(t, `query Pets($status: Status, $first: Int) {
+  pets(status: $status, first: $first) {
+    id
+    name
+    status
+    owner {
+      id
+    }
+    born
+  }
+}`, pets.GraphQL.Document, "deprecated fields and fields with required arguments are not selected")
+
+	data := pets.Responses["200"].Content["application/json"].Schema.Properties["data"]
+	result := data.Properties["pets"]
+	assert.Equal(t, "array", result.Type)
+	assert.False(t, result.Nullable)
+	pet := *result.Items
+	assert.Equal(t, []string{"id", "name"}, pet.Required)
+	assert.Equal(t, "The pet's name", pet.Properties["name"].Description)
+	assert.Equal(t, Schema{Type: "string", Format: "Date", Nullable: true}, pet.Properties["born"])
+	assert.Contains(t, pets.Responses, "400")
+
+	assert.True(t, spec.Endpoints[1].Deprecated)
+
+	addPet := spec.Endpoints[2]
+	assert.Equal(t, KindMutation, addPet.Kind)
+	assert.False(t, addPet.XSafe)
+	assert.Equal(t, []GraphQLArgument{{Name: "input", Type: "NewPet!"}}, addPet.GraphQL.Arguments)
+	input := addPet.Parameters[0]
+	assert.True(t, input.Required)
+	assert.Equal(t, []string{"name"}, input.Schema.Required, "input fields with defaults are optional")
+	body := addPet.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, []string{"query", "variables"}, body.Required)
+	assert.Equal(t, addPet.GraphQL.Document, body.Properties["query"].Example)
+	assert.Empty(t, addPet.DeclaredRisk())
+
+	assert.Equal(t, "high", spec.Endpoints[3].DeclaredRisk(), "removing mutations are high risk")
+}
+
+func TestParseGraphQLSDL_Detection(t *testing.T) {
+	spec, err := parseGraphQL(t, "schema.txt", "type Query { ping: String }")
+	require.NoError(t, err, "SDL is detected from its content")
+	require.Len(t, spec.Endpoints, 1)
+	assert.Equal(t, "query Ping {\n  ping\n}", spec.Endpoints[0].GraphQL.Document)
+}
+
+func TestParseGraphQLSDL_Errors(t *testing.T) {
+	_, err := parseGraphQL(t, "schema.graphql", "type Query {\n  pet(id: ID!: Pet\n}")
+	assert.EqualError(t, err, `failed to parse GraphQL schema: line 2: unexpected ":"`)
+
+	_, err = parseGraphQL(t, "schema.graphql", "type Query { a: String }\ntype Query { b: String }")
+	assert.EqualError(t, err, "failed to parse GraphQL schema: line 2: type Query is defined twice")
+
+	_, err = parseGraphQL(t, "schema.graphql", `type Query { a: String @deprecated(reason: "x) }`)
+	assert.EqualError(t, err, "failed to parse GraphQL schema: line 1: unterminated string")
+
+	_, err = parseGraphQL(t, "schema.graphql", "type Pet { id: ID }")
+	assert.EqualError(t, err, "GraphQL schema has no Query or Mutation type")
+}
+
+func TestParseGraphQLIntrospection(t *testing.T) {
+	spec, err := parseGraphQL(t, "schema.json", `{"data": {"__schema": {
+		"queryType": {"name": "Query"},
+		"mutationType": null,
+		"types": [
+			{"kind": "OBJECT", "name": "Query", "fields": [{
+				"name": "users",
+				"description": "List users",
+				"args": [{"name": "ids", "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}}, "defaultValue": null}],
+				"type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "User"}},
+				"isDeprecated": false
+			}]},
+			{"kind": "OBJECT", "name": "User", "fields": [
+				{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+				{"name": "age", "args": [], "type": {"kind": "SCALAR", "name": "Int"}}
+			]},
+			{"kind": "SCALAR", "name": "ID"},
+			{"kind": "SCALAR", "name": "Int"}
+		]
+	}}}`)
+	require.NoError(t, err)
+	require.Len(t, spec.Endpoints, 1)
+
+	users := spec.Endpoints[0]
+	assert.Equal(t, "QUERY_users", users.ID)
+	assert.Equal(t, "List users", users.Summary)
+	assert.Equal(t, []GraphQLArgument{{Name: "ids", Type: "[ID!]!"}}, users.GraphQL.Arguments)
+	assert.Equal(t, "[User]", users.GraphQL.ReturnType)
+	assert.Equal(t, "query Users($ids: [ID!]!) {\n  users(ids: $ids) {\n    id\n    age\n  }\n}", users.GraphQL.Document)
+	assert.Equal(t, Schema{Type: "array", Items: &Schema{Type: "string"}}, users.Parameters[0].Schema)
+}
@@ -0,0 +1,372 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GRPCMethod is the method of a gRPC service an endpoint calls
+type GRPCMethod struct {
+	// Service is the service's full name, e.g. petstore.v1.PetService
+	Service string `json:"service"`
+	// Method is the method's name, e.g. GetPet
+	Method string `json:"method"`
+	// InputType and OutputType are the full names of the request and
+	// response messages, e.g. petstore.v1.GetPetRequest
+	InputType  string `json:"input_type"`
+	OutputType string `json:"output_type"`
+	// ClientStreaming and ServerStreaming are set for methods taking or
+	// returning a stream of messages
+	ClientStreaming bool `json:"client_streaming,omitempty"`
+	ServerStreaming bool `json:"server_streaming,omitempty"`
+	// ProtoFile and Protoset are the absolute paths of the .proto file or
+	// file descriptor set the method was read from; both are empty for
+	// schemas fetched from a URL, whose server must support reflection
+	ProtoFile string `json:"proto_file,omitempty"`
+	Protoset  string `json:"protoset,omitempty"`
+}
+
+// Symbol returns the method as gRPC tools name it, e.g.
+// petstore.v1.PetService/GetPet
+func (m *GRPCMethod) Symbol() string {
+	return m.Service + "/" + m.Method
+}
+
+// GrpcurlArgs returns the grpcurl flags reading the method's schema from
+// its .proto file or descriptor set; none when grpcurl must use reflection
+func (m *GRPCMethod) GrpcurlArgs() []string {
+	switch {
+	case m.Protoset != "":
+		return []string{"-protoset", m.Protoset}
+	case m.ProtoFile != "":
+		return []string{"-import-path", filepath.Dir(m.ProtoFile), "-proto", filepath.Base(m.ProtoFile)}
+	}
+	return nil
+}
+
+// protoSchema is the messages, enums and services of protobuf files, read
+// from .proto source or a file descriptor set. Types are keyed by their
+// full name without a leading dot, e.g. petstore.v1.Pet.
+type protoSchema struct {
+	messages map[string]*protoMessage
+	enums    map[string][]string
+	services []protoService
+}
+
+// protoMessage is a message type; map fields refer to an entry message
+// with key and value fields, as protoc generates them
+type protoMessage struct {
+	description string
+	fields      []protoField
+	mapEntry    bool
+}
+
+// protoField is a field of a message. Its type is a scalar type such as
+// int64, or the full name of a message or enum.
+type protoField struct {
+	name        string
+	jsonName    string
+	description string
+	typ         string
+	repeated    bool
+	// required is the proto2 label; proto3 fields are all optional
+	required   bool
+	deprecated bool
+}
+
+// protoService is a service with its methods
+type protoService struct {
+	name        string
+	description string
+	methods     []protoMethod
+}
+
+// protoMethod is an RPC method of a service
+type protoMethod struct {
+	name            string
+	description     string
+	input, output   string
+	clientStreaming bool
+	serverStreaming bool
+	deprecated      bool
+	// noSideEffects is set by the NO_SIDE_EFFECTS idempotency level
+	noSideEffects bool
+}
+
+func newProtoSchema() *protoSchema {
+	return &protoSchema{messages: make(map[string]*protoMessage), enums: make(map[string][]string)}
+}
+
+// protoScalars maps protobuf scalar types to JSON schemas of their
+// canonical JSON mapping, in which 64-bit integers are strings
+var protoScalars = map[string]Schema{
+	"double":   {Type: "number", Format: "double"},
+	"float":    {Type: "number", Format: "float"},
+	"int32":    {Type: "integer", Format: "int32"},
+	"sint32":   {Type: "integer", Format: "int32"},
+	"sfixed32": {Type: "integer", Format: "int32"},
+	"uint32":   {Type: "integer", Format: "uint32"},
+	"fixed32":  {Type: "integer", Format: "uint32"},
+	"int64":    {Type: "string", Format: "int64"},
+	"sint64":   {Type: "string", Format: "int64"},
+	"sfixed64": {Type: "string", Format: "int64"},
+	"uint64":   {Type: "string", Format: "uint64"},
+	"fixed64":  {Type: "string", Format: "uint64"},
+	"bool":     {Type: "boolean"},
+	"string":   {Type: "string"},
+	"bytes":    {Type: "string", Format: "byte"},
+}
+
+// protoWellKnown maps the well-known types with a special JSON mapping
+var protoWellKnown = map[string]Schema{
+	"google.protobuf.Timestamp":   {Type: "string", Format: "date-time"},
+	"google.protobuf.Duration":    {Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?s$`},
+	"google.protobuf.FieldMask":   {Type: "string"},
+	"google.protobuf.Empty":       {Type: "object"},
+	"google.protobuf.Struct":      {Type: "object"},
+	"google.protobuf.Value":       {},
+	"google.protobuf.ListValue":   {Type: "array", Items: &Schema{}},
+	"google.protobuf.Any":         {Type: "object", Required: []string{"@type"}},
+	"google.protobuf.StringValue": {Type: "string", Nullable: true},
+	"google.protobuf.BytesValue":  {Type: "string", Format: "byte", Nullable: true},
+	"google.protobuf.BoolValue":   {Type: "boolean", Nullable: true},
+	"google.protobuf.Int32Value":  {Type: "integer", Format: "int32", Nullable: true},
+	"google.protobuf.UInt32Value": {Type: "integer", Format: "uint32", Nullable: true},
+	"google.protobuf.Int64Value":  {Type: "string", Format: "int64", Nullable: true},
+	"google.protobuf.UInt64Value": {Type: "string", Format: "uint64", Nullable: true},
+	"google.protobuf.FloatValue":  {Type: "number", Format: "float", Nullable: true},
+	"google.protobuf.DoubleValue": {Type: "number", Format: "double", Nullable: true},
+}
+
+// maxProtoDepth bounds how deep messages are expanded into schemas, as
+// messages may refer to themselves
+const maxProtoDepth = 3
+
+var protoStartPattern = regexp.MustCompile(`^(?:\s*(?://[^\n]*|/\*(?s:.*?)\*/))*\s*(?:syntax|edition)\s*=\s*["']`)
+
+// isProto determines if the content is a protobuf schema (.proto), based on
+// file extension or content
+func isProto(source string, data []byte) bool {
+	return strings.HasSuffix(strings.ToLower(source), ".proto") || protoStartPattern.Match(data)
+}
+
+// isProtoDescriptorSet determines if source is a file descriptor set, as
+// written by protoc --descriptor_set_out or buf build, from its extension;
+// the binary content has no reliable signature
+func isProtoDescriptorSet(source string) bool {
+	lower := strings.ToLower(source)
+	for _, ext := range []string{".protoset", ".pb", ".binpb", ".desc"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProto converts a .proto file to a specification. Imported files are
+// not read: their messages are unknown objects, except well-known types.
+func parseProto(source string, data []byte) (*OpenAPISpec, error) {
+	schema, err := parseProtoSource(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto file: %w", err)
+	}
+	return grpcSpec(schema, &GRPCMethod{ProtoFile: localPath(source)})
+}
+
+// parseProtoDescriptorSet converts a file descriptor set to a
+// specification. Sets built with --include_imports resolve every type.
+func parseProtoDescriptorSet(source string, data []byte) (*OpenAPISpec, error) {
+	schema, err := decodeFileDescriptorSet(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file descriptor set: %w", err)
+	}
+	return grpcSpec(schema, &GRPCMethod{Protoset: localPath(source)})
+}
+
+// localPath returns the absolute path of a source file, or "" for URLs
+func localPath(source string) string {
+	if isURL(source) {
+		return ""
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		return abs
+	}
+	return source
+}
+
+// grpcSpec maps the methods of a schema's services to endpoints posting to
+// /<service>/<method>, with the input message as the request body and the
+// output message as the 200 response, both in their JSON mapping; failed
+// calls are documented as the default response, a google.rpc.Status. The
+// locations of from are copied to each method.
+func grpcSpec(schema *protoSchema, from *GRPCMethod) (*OpenAPISpec, error) {
+	if len(schema.services) == 0 {
+		return nil, fmt.Errorf("protobuf schema defines no services")
+	}
+	spec := &OpenAPISpec{
+		Info:      Info{Title: "gRPC API"},
+		Version:   "gRPC",
+		Endpoints: []Endpoint{},
+	}
+	if len(schema.services) == 1 {
+		spec.Info.Title = schema.services[0].name
+		spec.Info.Description = schema.services[0].description
+	}
+	for _, service := range schema.services {
+		for _, method := range service.methods {
+			spec.Endpoints = append(spec.Endpoints, schema.endpoint(service, method, from))
+		}
+	}
+	return spec, nil
+}
+
+// endpoint maps a method of service to an endpoint
+func (s *protoSchema) endpoint(service protoService, method protoMethod, from *GRPCMethod) Endpoint {
+	short := service.name[strings.LastIndex(service.name, ".")+1:]
+	operation := &GRPCMethod{
+		Service:         service.name,
+		Method:          method.name,
+		InputType:       method.input,
+		OutputType:      method.output,
+		ClientStreaming: method.clientStreaming,
+		ServerStreaming: method.serverStreaming,
+		ProtoFile:       from.ProtoFile,
+		Protoset:        from.Protoset,
+	}
+	e := Endpoint{
+		ID:          "RPC_" + short + "_" + method.name,
+		Kind:        KindRPC,
+		Method:      http.MethodPost,
+		Path:        "/" + operation.Symbol(),
+		OperationID: method.name,
+		Summary:     strings.TrimSpace(strings.SplitN(method.description, "\n", 2)[0]),
+		Description: method.description,
+		Tags:        []string{short},
+		Deprecated:  method.deprecated,
+		// Methods declared free of side effects, or named like reads, are safe
+		XSafe: method.noSideEffects || operationNoun(method.name, readVerbs) != "" ||
+			operationNoun(method.name, []string{"list", "search", "count"}) != "",
+		GRPC: operation,
+	}
+	if operationNoun(method.name, deleteVerbs) != "" {
+		e.Extensions = map[string]interface{}{ExtRisk: "high"}
+	}
+
+	input := s.messageSchema(method.input, 0)
+	input.Description = "The " + method.input + " message"
+	if method.clientStreaming {
+		input.Description += "; the method takes a stream of them"
+	}
+	e.RequestBody = &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: input}},
+	}
+
+	output := s.messageSchema(method.output, 0)
+	output.Description = "The " + method.output + " message"
+	if method.serverStreaming {
+		output.Description += "; the method returns a stream of them"
+	}
+	e.Responses = map[string]Response{
+		"200": {
+			Description: "The method's response",
+			Content:     map[string]MediaType{"application/json": {Schema: output}},
+		},
+		"default": {
+			Description: "The gRPC status of a failed call",
+			Content: map[string]MediaType{"application/json": {Schema: Schema{
+				Type:     "object",
+				Required: []string{"code", "message"},
+				Properties: map[string]Schema{
+					"code":    {Type: "integer", Description: "gRPC status code, e.g. 3 for INVALID_ARGUMENT"},
+					"message": {Type: "string"},
+					"details": {Type: "array", Items: &Schema{Type: "object"}},
+				},
+			}}},
+		},
+	}
+	return e
+}
+
+// typeSchema converts a scalar, enum or message type to a JSON schema
+func (s *protoSchema) typeSchema(typ string, depth int) Schema {
+	if schema, ok := protoScalars[typ]; ok {
+		return schema
+	}
+	if values, ok := s.enums[typ]; ok {
+		schema := Schema{Type: "string"}
+		for _, value := range values {
+			schema.Enum = append(schema.Enum, value)
+		}
+		return schema
+	}
+	return s.messageSchema(typ, depth)
+}
+
+// messageSchema converts a message to an object schema with the fields as
+// properties named by their JSON names. Messages defined in files that were
+// not read are objects without properties.
+func (s *protoSchema) messageSchema(name string, depth int) Schema {
+	if schema, ok := protoWellKnown[name]; ok {
+		return schema
+	}
+	message, ok := s.messages[name]
+	if !ok {
+		return Schema{Type: "object", Description: "The " + name + " message"}
+	}
+	schema := Schema{Type: "object", Description: message.description}
+	if depth >= maxProtoDepth {
+		return schema
+	}
+	schema.Properties = make(map[string]Schema, len(message.fields))
+	for _, f := range message.fields {
+		property := s.fieldSchema(f, depth)
+		if f.description != "" {
+			property.Description = f.description
+		}
+		schema.Properties[f.jsonName] = property
+		if f.required {
+			schema.Required = append(schema.Required, f.jsonName)
+		}
+	}
+	return schema
+}
+
+// fieldSchema converts a field: repeated fields are arrays, and map fields
+// objects described by their key and value types
+func (s *protoSchema) fieldSchema(f protoField, depth int) Schema {
+	if entry, ok := s.messages[f.typ]; ok && entry.mapEntry && f.repeated && len(entry.fields) == 2 {
+		return Schema{
+			Type:        "object",
+			Description: fmt.Sprintf("map<%s, %s>", entry.fields[0].typ, entry.fields[1].typ),
+		}
+	}
+	schema := s.typeSchema(f.typ, depth+1)
+	if f.repeated {
+		return Schema{Type: "array", Items: &schema}
+	}
+	return schema
+}
+
+// protoJSONName returns the JSON name protoc derives from a field name,
+// e.g. petId for pet_id
+func protoJSONName(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && r >= 'a' && r <= 'z':
+			sb.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			sb.WriteRune(r)
+			upper = false
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// wireField is a field of a message in the protobuf wire format: a varint,
+// or the bytes of a length-delimited field
+type wireField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+// Protobuf wire types
+const (
+	wireVarint = 0
+	wireI64    = 1
+	wireLen    = 2
+	wireI32    = 5
+)
+
+// decodeWire splits an encoded message into its fields. Fixed-size fields
+// are skipped, as descriptors have none that glens reads.
+func decodeWire(data []byte) ([]wireField, error) {
+	var fields []wireField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("malformed field key")
+		}
+		data = data[n:]
+		f := wireField{num: int(key >> 3)}
+		switch key & 7 {
+		case wireVarint:
+			if f.varint, n = binary.Uvarint(data); n <= 0 {
+				return nil, fmt.Errorf("malformed varint of field %d", f.num)
+			}
+			data = data[n:]
+		case wireLen:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return nil, fmt.Errorf("truncated field %d", f.num)
+			}
+			f.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireI64, wireI32:
+			size := 8
+			if key&7 == wireI32 {
+				size = 4
+			}
+			if len(data) < size {
+				return nil, fmt.Errorf("truncated field %d", f.num)
+			}
+			data = data[size:]
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d of field %d", key&7, f.num)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// descriptorDecoder reads the descriptors of a google.protobuf.FileDescriptorSet
+// into a schema. The first error stops decoding.
+type descriptorDecoder struct {
+	schema *protoSchema
+	err    error
+}
+
+// decodeFileDescriptorSet decodes the files of a descriptor set
+func decodeFileDescriptorSet(data []byte) (*protoSchema, error) {
+	d := &descriptorDecoder{schema: newProtoSchema()}
+	for _, f := range d.fields(data) {
+		if f.num == 1 {
+			d.file(f.bytes)
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.schema, nil
+}
+
+// fields decodes a message, recording errors
+func (d *descriptorDecoder) fields(data []byte) []wireField {
+	if d.err != nil {
+		return nil
+	}
+	fields, err := decodeWire(data)
+	if err != nil {
+		d.err = err
+	}
+	return fields
+}
+
+// file decodes a FileDescriptorProto
+func (d *descriptorDecoder) file(data []byte) {
+	fields := d.fields(data)
+	pkg := wireString(fields, 2)
+	for _, f := range fields {
+		switch f.num {
+		case 4:
+			d.message(f.bytes, pkg)
+		case 5:
+			d.enum(f.bytes, pkg)
+		case 6:
+			d.service(f.bytes, pkg)
+		}
+	}
+}
+
+// message decodes a DescriptorProto with its nested types
+func (d *descriptorDecoder) message(data []byte, scope string) {
+	fields := d.fields(data)
+	name := qualify(scope, wireString(fields, 1))
+	message := &protoMessage{}
+	for _, f := range fields {
+		switch f.num {
+		case 2:
+			message.fields = append(message.fields, d.field(f.bytes))
+		case 3:
+			d.message(f.bytes, name)
+		case 4:
+			d.enum(f.bytes, name)
+		case 7:
+			// MessageOptions.map_entry
+			message.mapEntry = wireBool(d.fields(f.bytes), 7)
+		}
+	}
+	d.schema.messages[name] = message
+}
+
+// field decodes a FieldDescriptorProto
+func (d *descriptorDecoder) field(data []byte) protoField {
+	fields := d.fields(data)
+	f := protoField{
+		name:     wireString(fields, 1),
+		jsonName: wireString(fields, 10),
+		typ:      strings.TrimPrefix(wireString(fields, 6), "."),
+	}
+	if f.jsonName == "" {
+		f.jsonName = protoJSONName(f.name)
+	}
+	for _, wf := range fields {
+		switch wf.num {
+		case 4:
+			f.repeated = wf.varint == 3
+			f.required = wf.varint == 2
+		case 5:
+			if f.typ == "" && int(wf.varint) < len(descriptorTypes) {
+				f.typ = descriptorTypes[wf.varint]
+			}
+		case 8:
+			// FieldOptions.deprecated
+			f.deprecated = wireBool(d.fields(wf.bytes), 3)
+		}
+	}
+	return f
+}
+
+// descriptorTypes names the scalar types of FieldDescriptorProto.Type by
+// number; messages, groups and enums are named by type_name instead
+var descriptorTypes = []string{
+	1: "double", 2: "float", 3: "int64", 4: "uint64", 5: "int32", 6: "fixed64",
+	7: "fixed32", 8: "bool", 9: "string", 12: "bytes", 13: "uint32",
+	15: "sfixed32", 16: "sfixed64", 17: "sint32", 18: "sint64",
+}
+
+// enum decodes an EnumDescriptorProto
+func (d *descriptorDecoder) enum(data []byte, scope string) {
+	fields := d.fields(data)
+	var values []string
+	for _, f := range fields {
+		if f.num == 2 {
+			values = append(values, wireString(d.fields(f.bytes), 1))
+		}
+	}
+	d.schema.enums[qualify(scope, wireString(fields, 1))] = values
+}
+
+// service decodes a ServiceDescriptorProto
+func (d *descriptorDecoder) service(data []byte, pkg string) {
+	fields := d.fields(data)
+	service := protoService{name: qualify(pkg, wireString(fields, 1))}
+	for _, f := range fields {
+		if f.num != 2 {
+			continue
+		}
+		method := d.fields(f.bytes)
+		m := protoMethod{
+			name:            wireString(method, 1),
+			input:           strings.TrimPrefix(wireString(method, 2), "."),
+			output:          strings.TrimPrefix(wireString(method, 3), "."),
+			clientStreaming: wireBool(method, 5),
+			serverStreaming: wireBool(method, 6),
+		}
+		for _, mf := range method {
+			if mf.num == 4 {
+				// MethodOptions.deprecated and idempotency_level
+				options := d.fields(mf.bytes)
+				m.deprecated = wireBool(options, 33)
+				m.noSideEffects = wireUint(options, 34) == 1
+			}
+		}
+		service.methods = append(service.methods, m)
+	}
+	d.schema.services = append(d.schema.services, service)
+}
+
+// wireString returns the last string field num of fields, as protobuf
+// merges repeated occurrences of singular fields
+func wireString(fields []wireField, num int) string {
+	value := ""
+	for _, f := range fields {
+		if f.num == num {
+			value = string(f.bytes)
+		}
+	}
+	return value
+}
+
+// wireUint returns the last varint field num of fields
+func wireUint(fields []wireField, num int) uint64 {
+	var value uint64
+	for _, f := range fields {
+		if f.num == num {
+			value = f.varint
+		}
+	}
+	return value
+}
+
+// wireBool returns the last boolean field num of fields
+func wireBool(fields []wireField, num int) bool {
+	return wireUint(fields, num) != 0
+}
@@ -0,0 +1,574 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// protoToken is a lexical token of a .proto file. Comment is the text of
+// the comments on the lines right above the token, which document it.
+type protoToken struct {
+	kind    int
+	value   string
+	line    int
+	comment string
+}
+
+// lexProto splits a .proto file into tokens, dropping whitespace and
+// comments. Names keep their dots, e.g. google.protobuf.Timestamp.
+func lexProto(source string) ([]protoToken, error) {
+	source = strings.TrimPrefix(source, "\ufeff")
+	var tokens []protoToken
+	var comment []string
+	line := 1
+	// lastLine is the line of the last token; comments on it trail the
+	// token rather than documenting the next one
+	lastLine := 0
+	blank := 0
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			if blank++; blank > 1 {
+				// A blank line detaches comments from what follows
+				comment = nil
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			if line != lastLine {
+				comment = append(comment, strings.TrimSpace(strings.TrimPrefix(source[i:i+end], "//")))
+				blank = 0
+			}
+			i += end
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			text := source[i+2 : i+2+end]
+			if line != lastLine {
+				for _, l := range strings.Split(text, "\n") {
+					comment = append(comment, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "*")))
+				}
+				blank = 0
+			}
+			line += strings.Count(text, "\n")
+			i += end + 4
+		default:
+			tok, n, err := lexProtoToken(source[i:], line)
+			if err != nil {
+				return nil, err
+			}
+			tok.comment = strings.TrimSpace(strings.Join(comment, "\n"))
+			tokens = append(tokens, tok)
+			comment, blank, lastLine = nil, 0, line
+			i += n
+		}
+	}
+	return append(tokens, protoToken{kind: tokEOF, line: line}), nil
+}
+
+// lexProtoToken reads the name, number, string or punctuator at the start
+// of s and returns it with its length
+func lexProtoToken(s string, line int) (protoToken, int, error) {
+	c := s[0]
+	switch {
+	case c == '"' || c == '\'':
+		j := 1
+		for j < len(s) && s[j] != c && s[j] != '\n' {
+			if s[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(s) || s[j] != c {
+			return protoToken{}, 0, fmt.Errorf("line %d: unterminated string", line)
+		}
+		raw := s[1:j]
+		value, err := strconv.Unquote(`"` + strings.ReplaceAll(raw, `"`, `\"`) + `"`)
+		if err != nil {
+			value = raw
+		}
+		return protoToken{kind: tokString, value: value, line: line}, j + 1, nil
+	case isNameStart(c) || (c == '.' && len(s) > 1 && isNameStart(s[1])):
+		j := 1
+		for j < len(s) && (isNameStart(s[j]) || isDigit(s[j]) || s[j] == '.') {
+			j++
+		}
+		return protoToken{kind: tokName, value: s[:j], line: line}, j, nil
+	case isDigit(c) || ((c == '-' || c == '+' || c == '.') && len(s) > 1 && (isDigit(s[1]) || isNameStart(s[1]))):
+		// Numbers, including signed ones, hex literals and -inf
+		j := 1
+		for j < len(s) && (isNameStart(s[j]) || isDigit(s[j]) || s[j] == '.' ||
+			((s[j] == '-' || s[j] == '+') && (s[j-1] == 'e' || s[j-1] == 'E'))) {
+			j++
+		}
+		return protoToken{kind: tokNumber, value: s[:j], line: line}, j, nil
+	case strings.IndexByte(";{}[]()<>=,:", c) >= 0:
+		return protoToken{kind: tokPunct, value: string(c), line: line}, 1, nil
+	}
+	return protoToken{}, 0, fmt.Errorf("line %d: unexpected character %q", line, c)
+}
+
+// protoParser parses the definitions of a .proto file. The first error
+// stops parsing: every method returns zero values once err is set.
+type protoParser struct {
+	tokens []protoToken
+	pos    int
+	err    error
+	pkg    string
+	schema *protoSchema
+	// refs are the type names of fields and methods with the scope they
+	// appear in, resolved to full names once all definitions are read
+	refs []protoRef
+}
+
+// protoRef is a type name to resolve in the scope of a package or message
+type protoRef struct {
+	name  *string
+	scope string
+}
+
+// parseProtoSource parses the messages, enums and services of a .proto
+// file, in the proto2, proto3 or editions syntax
+func parseProtoSource(source string) (*protoSchema, error) {
+	tokens, err := lexProto(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &protoParser{tokens: tokens, schema: newProtoSchema()}
+	for p.err == nil && p.peek().kind != tokEOF {
+		p.definition()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	for _, ref := range p.refs {
+		*ref.name = p.resolve(*ref.name, ref.scope)
+	}
+	return p.schema, nil
+}
+
+// definition parses a top-level statement
+func (p *protoParser) definition() {
+	if p.skip(";") {
+		return
+	}
+	keyword := p.name()
+	switch keyword {
+	case "syntax", "edition":
+		p.expect("=")
+		p.next()
+		p.expect(";")
+	case "package":
+		p.pkg = p.name()
+		p.expect(";")
+	case "import":
+		if !p.skipKeyword("public") {
+			p.skipKeyword("weak")
+		}
+		p.next()
+		p.expect(";")
+	case "option":
+		p.option()
+		p.expect(";")
+	case "message":
+		p.message(p.pkg)
+	case "enum":
+		p.enum(p.pkg)
+	case "service":
+		p.service()
+	case "extend":
+		p.name()
+		p.skipBlock()
+	default:
+		p.unexpected(p.tokens[p.pos-1])
+	}
+}
+
+// message parses a message definition after its keyword, with its nested
+// messages and enums, in the scope of a package or enclosing message
+func (p *protoParser) message(scope string) {
+	comment := p.tokens[p.pos-1].comment
+	line := p.peek().line
+	name := qualify(scope, p.name())
+	message := &protoMessage{description: comment}
+	p.expect("{")
+	for p.err == nil && !p.skip("}") {
+		p.messageElement(name, message)
+	}
+	if _, ok := p.schema.messages[name]; ok && p.err == nil {
+		p.fail(fmt.Errorf("line %d: message %s is defined twice", line, name))
+	}
+	p.schema.messages[name] = message
+	for i := range message.fields {
+		p.refs = append(p.refs, protoRef{&message.fields[i].typ, name})
+	}
+}
+
+// messageElement parses a field, nested definition or option of the
+// message called name
+func (p *protoParser) messageElement(name string, message *protoMessage) {
+	switch tok := p.peek(); {
+	case p.skip(";"):
+	case tok.kind != tokName:
+		p.unexpected(p.next())
+	case (tok.value == "message" || tok.value == "enum" || tok.value == "oneof") && p.peekAt(2).value == "{":
+		p.next()
+		switch tok.value {
+		case "message":
+			p.message(name)
+		case "enum":
+			p.enum(name)
+		default:
+			// The fields of a oneof are fields of the message
+			p.name()
+			p.expect("{")
+			for p.err == nil && !p.skip("}") {
+				if p.skipKeyword("option") {
+					p.option()
+					p.expect(";")
+					continue
+				}
+				message.fields = append(message.fields, p.field(name))
+			}
+		}
+	case tok.value == "extend" && p.peekAt(2).value == "{":
+		p.next()
+		p.name()
+		p.skipBlock()
+	case p.skipKeyword("option"):
+		p.option()
+		p.expect(";")
+	case tok.value == "reserved" || tok.value == "extensions":
+		p.skipStatement()
+	default:
+		message.fields = append(message.fields, p.field(name))
+	}
+}
+
+// field parses a field of the message called name; map fields define their
+// entry message in its scope, e.g. TagsEntry for map<string, string> tags
+func (p *protoParser) field(name string) protoField {
+	f := protoField{description: p.peek().comment}
+	switch {
+	case p.skipKeyword("repeated"):
+		f.repeated = true
+	case p.skipKeyword("required"):
+		f.required = true
+	default:
+		p.skipKeyword("optional")
+	}
+
+	if tok := p.peek(); tok.value == "map" && p.peekAt(1).value == "<" {
+		p.next()
+		p.expect("<")
+		key := p.name()
+		p.expect(",")
+		value := p.name()
+		p.expect(">")
+		f.name = p.name()
+		camel := protoJSONName(f.name)
+		if camel == "" {
+			return f
+		}
+		entry := qualify(name, strings.ToUpper(camel[:1])+camel[1:]+"Entry")
+		p.schema.messages[entry] = &protoMessage{mapEntry: true, fields: []protoField{
+			{name: "key", jsonName: "key", typ: key},
+			{name: "value", jsonName: "value", typ: value},
+		}}
+		p.refs = append(p.refs, protoRef{&p.schema.messages[entry].fields[1].typ, name})
+		f.typ, f.repeated = entry, true
+	} else {
+		f.typ = p.name()
+		f.name = p.name()
+	}
+	f.jsonName = protoJSONName(f.name)
+
+	p.expect("=")
+	p.next()
+	if f.typ == "group" {
+		// proto2 groups define a nested message of the field's name
+		p.skipBlock()
+		f.typ, f.jsonName = qualify(name, f.name), strings.ToLower(f.name)
+		return f
+	}
+	if p.skip("[") {
+		for p.err == nil && !p.skip("]") {
+			option, value := p.option()
+			switch option {
+			case "deprecated":
+				f.deprecated = value == "true"
+			case "json_name":
+				f.jsonName = value
+			}
+			p.skip(",")
+		}
+	}
+	p.expect(";")
+	return f
+}
+
+// enum parses an enum definition after its keyword
+func (p *protoParser) enum(scope string) {
+	name := qualify(scope, p.name())
+	var values []string
+	p.expect("{")
+	for p.err == nil && !p.skip("}") {
+		switch {
+		case p.skip(";"):
+		case p.skipKeyword("option"):
+			p.option()
+			p.expect(";")
+		case p.skipKeyword("reserved"):
+			p.skipStatement()
+		default:
+			values = append(values, p.name())
+			p.expect("=")
+			p.next()
+			if p.skip("[") {
+				for p.err == nil && !p.skip("]") {
+					p.option()
+					p.skip(",")
+				}
+			}
+			p.expect(";")
+		}
+	}
+	p.schema.enums[name] = values
+}
+
+// service parses a service definition after its keyword
+func (p *protoParser) service() {
+	comment := p.tokens[p.pos-1].comment
+	service := protoService{description: comment, name: qualify(p.pkg, p.name())}
+	p.expect("{")
+	for p.err == nil && !p.skip("}") {
+		switch {
+		case p.skip(";"):
+		case p.skipKeyword("option"):
+			p.option()
+			p.expect(";")
+		case p.peek().value == "rpc":
+			service.methods = append(service.methods, p.method())
+		default:
+			p.unexpected(p.next())
+		}
+	}
+	p.schema.services = append(p.schema.services, service)
+	last := &p.schema.services[len(p.schema.services)-1]
+	for i := range last.methods {
+		p.refs = append(p.refs, protoRef{&last.methods[i].input, p.pkg}, protoRef{&last.methods[i].output, p.pkg})
+	}
+}
+
+// method parses an rpc definition with its options
+func (p *protoParser) method() protoMethod {
+	m := protoMethod{description: p.next().comment, name: p.name()}
+	m.clientStreaming, m.input = p.methodType()
+	p.skipKeyword("returns")
+	m.serverStreaming, m.output = p.methodType()
+	if !p.skip("{") {
+		p.expect(";")
+		return m
+	}
+	for p.err == nil && !p.skip("}") {
+		if p.skip(";") {
+			continue
+		}
+		if !p.skipKeyword("option") {
+			p.unexpected(p.next())
+			break
+		}
+		switch option, value := p.option(); option {
+		case "deprecated":
+			m.deprecated = value == "true"
+		case "idempotency_level":
+			m.noSideEffects = value == "NO_SIDE_EFFECTS"
+		}
+		p.expect(";")
+	}
+	return m
+}
+
+// methodType parses the parenthesised request or response type of a
+// method, e.g. (stream Pet)
+func (p *protoParser) methodType() (stream bool, name string) {
+	p.expect("(")
+	name = p.name()
+	if name == "stream" && p.peek().kind == tokName {
+		stream, name = true, p.name()
+	}
+	p.expect(")")
+	return stream, name
+}
+
+// option parses the name and value of an option, e.g. deprecated = true or
+// (google.api.http) = { get: "/v1/pets" }. Custom option names keep their
+// parentheses; aggregate values are skipped and returned as "{}".
+func (p *protoParser) option() (name, value string) {
+	for p.err == nil && !p.skip("=") {
+		tok := p.next()
+		if tok.kind == tokEOF {
+			p.unexpected(tok)
+			return "", ""
+		}
+		name += tok.value
+	}
+	if p.peek().value == "{" {
+		p.skipBlock()
+		return name, "{}"
+	}
+	value = p.next().value
+	for p.peek().kind == tokString {
+		// Adjacent strings are concatenated
+		value += p.next().value
+	}
+	return name, value
+}
+
+// skipStatement skips tokens up to and including the next ";"
+func (p *protoParser) skipStatement() {
+	for p.err == nil && !p.skip(";") {
+		if tok := p.next(); tok.kind == tokEOF {
+			p.unexpected(tok)
+		}
+	}
+}
+
+// skipBlock skips a block in braces, including nested blocks
+func (p *protoParser) skipBlock() {
+	p.expect("{")
+	for depth := 1; p.err == nil && depth > 0; {
+		switch tok := p.next(); {
+		case tok.kind == tokEOF:
+			p.unexpected(tok)
+		case tok.value == "{" && tok.kind == tokPunct:
+			depth++
+		case tok.value == "}" && tok.kind == tokPunct:
+			depth--
+		}
+	}
+}
+
+// resolve returns the full name of a type referred to by name in scope:
+// fully qualified names start with a dot, others are looked up from the
+// innermost scope outwards. Scalars and types that are not defined, such
+// as imported ones, are returned as written.
+func (p *protoParser) resolve(name, scope string) string {
+	if full, ok := strings.CutPrefix(name, "."); ok {
+		return full
+	}
+	if _, ok := protoScalars[name]; ok {
+		return name
+	}
+	for {
+		candidate := qualify(scope, name)
+		if _, ok := p.schema.messages[candidate]; ok {
+			return candidate
+		}
+		if _, ok := p.schema.enums[candidate]; ok {
+			return candidate
+		}
+		if scope == "" {
+			return name
+		}
+		scope = scope[:max(strings.LastIndex(scope, "."), 0)]
+	}
+}
+
+// qualify prefixes name with a package or message scope
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// peek returns the next token, or an EOF token after an error
+func (p *protoParser) peek() protoToken {
+	return p.peekAt(0)
+}
+
+// peekAt returns the token n positions ahead
+func (p *protoParser) peekAt(n int) protoToken {
+	if p.err != nil || p.pos+n >= len(p.tokens) {
+		return protoToken{kind: tokEOF}
+	}
+	return p.tokens[p.pos+n]
+}
+
+// next consumes the next token
+func (p *protoParser) next() protoToken {
+	tok := p.peek()
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// name consumes a name
+func (p *protoParser) name() string {
+	tok := p.next()
+	if tok.kind != tokName {
+		p.unexpected(tok)
+		return ""
+	}
+	return tok.value
+}
+
+// skip consumes the next token if it is the punctuator punct
+func (p *protoParser) skip(punct string) bool {
+	if tok := p.peek(); tok.kind == tokPunct && tok.value == punct {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipKeyword consumes the next token if it is the name keyword
+func (p *protoParser) skipKeyword(keyword string) bool {
+	if tok := p.peek(); tok.kind == tokName && tok.value == keyword {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the punctuator punct
+func (p *protoParser) expect(punct string) {
+	if !p.skip(punct) {
+		tok := p.peek()
+		if p.err == nil {
+			p.fail(fmt.Errorf("line %d: expected %q, found %s", tok.line, punct, describeProtoToken(tok)))
+		}
+	}
+}
+
+// unexpected records an error for a token that does not fit the grammar
+func (p *protoParser) unexpected(tok protoToken) {
+	p.fail(fmt.Errorf("line %d: unexpected %s", tok.line, describeProtoToken(tok)))
+}
+
+// fail records the first error
+func (p *protoParser) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// describeProtoToken returns a token as error messages quote it
+func describeProtoToken(tok protoToken) string {
+	if tok.kind == tokEOF {
+		return "end of file"
+	}
+	return strconv.Quote(tok.value)
+}
@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const protoSchemaSource = `// Pet store
+syntax = "proto3";
+
+package petstore.v1;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/petstore/v1;petstorev1";
+
+// PetService manages pets
+service PetService {
+  // Get a pet
+  // by its ID
+  rpc GetPet(GetPetRequest) returns (Pet) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc WatchPets(GetPetRequest) returns (stream Pet);
+  rpc DeletePet(GetPetRequest) returns (.petstore.v1.Pet) {
+    option deprecated = true;
+    option (google.api.http) = { delete: "/v1/pets/{id}" };
+  }
+}
+
+// A pet
+message Pet {
+  int64 id = 1;
+  string display_name = 2 [json_name = "name", deprecated = true]; // not a description
+  Status status = 3;
+  repeated string photo_urls = 4;
+  map<string, Owner> owners = 5;
+  google.protobuf.Timestamp born = 6;
+  Pet parent = 7;
+
+  // Who looks after a pet
+  message Owner {
+    oneof contact {
+      string email = 1;
+    }
+  }
+  reserved 8 to 10;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_SOLD = 1 [deprecated = true];
+}
+
+message GetPetRequest { int64 id = 1; }
+`
+
+// writeSpec writes content to a file called name and parses it
+func writeSpec(t *testing.T, name string, content []byte) (*OpenAPISpec, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return ParseOpenAPISpec(path)
+}
+
+func TestParseProto(t *testing.T) {
+	spec, err := writeSpec(t, "petstore.proto", []byte(protoSchemaSource))
+	require.NoError(t, err)
+	assert.Equal(t, "gRPC", spec.Version)
+	assert.Equal(t, "petstore.v1.PetService", spec.Info.Title)
+	assert.Equal(t, "PetService manages pets", spec.Info.Description)
+	require.Len(t, spec.Endpoints, 3)
+
+	get := spec.Endpoints[0]
+	assert.Equal(t, "RPC_PetService_GetPet", get.ID)
+	assert.Equal(t, KindRPC, get.Kind)
+	assert.Equal(t, "POST", get.Method)
+	assert.Equal(t, "/petstore.v1.PetService/GetPet", get.Path)
+	assert.Equal(t, "Get a pet", get.Summary)
+	assert.Equal(t, "Get a pet\nby its ID", get.Description)
+	assert.Equal(t, []string{"PetService"}, get.Tags)
+	assert.True(t, get.XSafe)
+	assert.Equal(t, "petstore.v1.PetService/GetPet", get.GRPC.Symbol())
+	assert.Equal(t, "petstore.v1.GetPetRequest", get.GRPC.InputType)
+	assert.Equal(t, "petstore.v1.Pet", get.GRPC.OutputType)
+	assert.True(t, filepath.IsAbs(get.GRPC.ProtoFile))
+	assert.Equal(t, []string{"-import-path", filepath.Dir(get.GRPC.ProtoFile), "-proto", "petstore.proto"}, get.GRPC.GrpcurlArgs())
+
+	input := get.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, map[string]Schema{"id": {Type: "string", Format: "int64"}}, input.Properties,
+		"64-bit integers are strings in JSON")
+
+	pet := get.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, "The petstore.v1.Pet message", pet.Description)
+	assert.Len(t, pet.Properties, 7)
+	assert.Contains(t, pet.Properties, "name", "json_name renames fields")
+	assert.Equal(t, Schema{Type: "string", Format: "date-time"}, pet.Properties["born"])
+	assert.Equal(t, Schema{Type: "string", Enum: []interface{}{"STATUS_UNSPECIFIED", "STATUS_SOLD"}}, pet.Properties["status"])
+	assert.Equal(t, Schema{Type: "array", Items: &Schema{Type: "string"}}, pet.Properties["photoUrls"])
+	assert.Equal(t, Schema{Type: "object", Description: "map<string, petstore.v1.Pet.Owner>"}, pet.Properties["owners"])
+	assert.Equal(t, "A pet", pet.Properties["parent"].Description)
+	assert.Contains(t, get.Responses, "default")
+
+	watch := spec.Endpoints[1]
+	assert.True(t, watch.GRPC.ServerStreaming)
+	assert.False(t, watch.GRPC.ClientStreaming)
+	assert.Contains(t, watch.Responses["200"].Content["application/json"].Schema.Description, "returns a stream")
+
+	remove := spec.Endpoints[2]
+	assert.True(t, remove.Deprecated)
+	assert.Equal(t, "petstore.v1.Pet", remove.GRPC.OutputType, "fully qualified names drop their dot")
+	assert.Equal(t, "high", remove.DeclaredRisk())
+}
+
+func TestParseProto_Detection(t *testing.T) {
+	spec, err := writeSpec(t, "schema.txt", []byte("/* ping */\nsyntax = \"proto3\";\nservice Ping { rpc Ping(Empty) returns (Empty); }\nmessage Empty {}"))
+	require.NoError(t, err, "proto files are detected from their content")
+	require.Len(t, spec.Endpoints, 1)
+	assert.Equal(t, "/Ping/Ping", spec.Endpoints[0].Path)
+}
+
+func TestParseProto_Errors(t *testing.T) {
+	_, err := writeSpec(t, "a.proto", []byte("syntax = \"proto3\";\nmessage Pet {\n  int64 id = 1\n}"))
+	assert.EqualError(t, err, `failed to parse proto file: line 4: expected ";", found "}"`)
+
+	_, err = writeSpec(t, "a.proto", []byte("message Pet {}\nmessage Pet {}"))
+	assert.EqualError(t, err, "failed to parse proto file: line 2: message Pet is defined twice")
+
+	_, err = writeSpec(t, "a.proto", []byte(`option java_package = "x`))
+	assert.EqualError(t, err, "failed to parse proto file: line 1: unterminated string")
+
+	_, err = writeSpec(t, "a.proto", []byte("syntax = \"proto3\";\nmessage Pet {}"))
+	assert.EqualError(t, err, "protobuf schema defines no services")
+}
+
+// protoBytes encodes a length-delimited field
+func protoBytes(num int, value []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(num<<3|wireLen))
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// protoVarint encodes a varint field
+func protoVarint(num int, value uint64) []byte {
+	return binary.AppendUvarint(binary.AppendUvarint(nil, uint64(num<<3|wireVarint)), value)
+}
+
+// protoMessageBytes concatenates encoded fields
+func protoMessageBytes(fields ...[]byte) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = append(b, f...)
+	}
+	return b
+}
+
+func TestParseProtoDescriptorSet(t *testing.T) {
+	str := func(num int, s string) []byte { return protoBytes(num, []byte(s)) }
+	file := protoMessageBytes(
+		str(1, "pets.proto"),
+		str(2, "pets"),
+		// message Pet { int64 id = 1; repeated Kind kinds = 2 [deprecated = true]; }
+		protoBytes(4, protoMessageBytes(
+			str(1, "Pet"),
+			protoBytes(2, protoMessageBytes(str(1, "id"), protoVarint(3, 1), protoVarint(4, 1), protoVarint(5, 3), str(10, "id"))),
+			protoBytes(2, protoMessageBytes(str(1, "kinds"), protoVarint(3, 2), protoVarint(4, 3), protoVarint(5, 14),
+				str(6, ".pets.Kind"), protoBytes(8, protoVarint(3, 1)))),
+			// fixed-size fields are skipped
+			[]byte{0x5d, 1, 2, 3, 4},
+		)),
+		// enum Kind { CAT = 0; DOG = 1; }
+		protoBytes(5, protoMessageBytes(str(1, "Kind"),
+			protoBytes(2, str(1, "CAT")), protoBytes(2, protoMessageBytes(str(1, "DOG"), protoVarint(2, 1))))),
+		// service Pets { rpc ListPets(Pet) returns (stream Pet) { option idempotency_level = NO_SIDE_EFFECTS; } }
+		protoBytes(6, protoMessageBytes(str(1, "Pets"),
+			protoBytes(2, protoMessageBytes(str(1, "ListPets"), str(2, ".pets.Pet"), str(3, ".pets.Pet"),
+				protoBytes(4, protoVarint(34, 1)), protoVarint(6, 1))))),
+	)
+
+	spec, err := writeSpec(t, "pets.protoset", protoBytes(1, file))
+	require.NoError(t, err)
+	require.Len(t, spec.Endpoints, 1)
+
+	list := spec.Endpoints[0]
+	assert.Equal(t, "/pets.Pets/ListPets", list.Path)
+	assert.True(t, list.XSafe)
+	assert.True(t, list.GRPC.ServerStreaming)
+	assert.True(t, filepath.IsAbs(list.GRPC.Protoset))
+	assert.Equal(t, []string{"-protoset", list.GRPC.Protoset}, list.GRPC.GrpcurlArgs())
+	pet := list.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, Schema{Type: "string", Format: "int64"}, pet.Properties["id"])
+	assert.Equal(t, Schema{Type: "array", Items: &Schema{Type: "string", Enum: []interface{}{"CAT", "DOG"}}}, pet.Properties["kinds"])
+
+	_, err = writeSpec(t, "broken.pb", []byte{0x0a, 0x05, 0x01})
+	assert.EqualError(t, err, "failed to parse file descriptor set: truncated field 1")
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Pagination styles
+const (
+	// PaginationPage pages by page number and page size
+	PaginationPage = "page"
+	// PaginationOffset pages by the number of items to skip and a limit
+	PaginationOffset = "offset"
+	// PaginationCursor pages by an opaque cursor returned with each page
+	PaginationCursor = "cursor"
+)
+
+// Pagination describes how a list endpoint pages its results. It is
+// detected from conventional query parameter names and can be declared or
+// corrected with the x-pagination extension of the operation:
+//
+//	x-pagination:
+//	  style: cursor        # page, offset or cursor
+//	  cursor: after        # query parameter names
+//	  size: first
+//	  nextCursor: meta.end # response field holding the next cursor
+//	  items: nodes         # response field holding the page's items
+//
+// x-pagination: false turns detection off.
+type Pagination struct {
+	Style       string `json:"style"`
+	PageParam   string `json:"page_param,omitempty"`
+	SizeParam   string `json:"size_param,omitempty"`
+	OffsetParam string `json:"offset_param,omitempty"`
+	CursorParam string `json:"cursor_param,omitempty"`
+	// NextCursor is the dotted path of the response field holding the
+	// cursor of the next page, e.g. "meta.next_cursor"
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Items is the response field holding the page's items; empty when the
+	// response is the array itself or its list field is unknown
+	Items string `json:"items,omitempty"`
+	// FirstPage is the number of the first page, the minimum of the page
+	// parameter or 1
+	FirstPage int `json:"first_page,omitempty"`
+	// MaxSize is the maximum of the size parameter, 0 when unbounded
+	MaxSize int `json:"max_size,omitempty"`
+}
+
+// Conventional names of pagination query parameters and response fields
+var (
+	pageParamNames   = []string{"page", "page_number", "pageNumber", "page[number]"}
+	sizeParamNames   = []string{"limit", "per_page", "perPage", "page_size", "pageSize", "size", "page[size]", "max_results", "maxResults", "count"}
+	offsetParamNames = []string{"offset", "skip", "page[offset]"}
+	cursorParamNames = []string{"cursor", "page_token", "pageToken", "next_token", "nextToken", "after", "starting_after", "continuation_token", "page[cursor]"}
+	nextCursorNames  = []string{"next_cursor", "nextCursor", "next_page_token", "nextPageToken", "next_token", "nextToken", "endCursor", "cursor"}
+	cursorContainers = []string{"meta", "pagination", "page_info", "pageInfo", "paging"}
+	itemsFieldNames  = []string{"items", "data", "results", "records", "content", "values", "entries", "nodes"}
+)
+
+// detectPagination sets the endpoint's pagination from the operation's
+// x-pagination extension and its query parameters
+func detectPagination(endpoint *Endpoint, operation map[string]interface{}) {
+	declared := map[string]interface{}{}
+	switch ext := operation["x-pagination"].(type) {
+	case bool:
+		if !ext {
+			return
+		}
+	case map[string]interface{}:
+		declared = ext
+	}
+	stringField := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := declared[key].(string); ok && value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	p := Pagination{
+		Style:       stringField("style", "type"),
+		PageParam:   orQueryParam(endpoint, stringField("page", "pageParam"), pageParamNames),
+		SizeParam:   orQueryParam(endpoint, stringField("size", "limit", "sizeParam", "limitParam"), sizeParamNames),
+		OffsetParam: orQueryParam(endpoint, stringField("offset", "offsetParam"), offsetParamNames),
+		CursorParam: orQueryParam(endpoint, stringField("cursor", "cursorParam"), cursorParamNames),
+		NextCursor:  stringField("nextCursor", "next_cursor"),
+		Items:       stringField("items"),
+	}
+	if p.Style == "" {
+		switch {
+		case p.CursorParam != "":
+			p.Style = PaginationCursor
+		case p.PageParam != "":
+			p.Style = PaginationPage
+		case p.OffsetParam != "":
+			p.Style = PaginationOffset
+		default:
+			// A size parameter alone limits a list without paging it
+			return
+		}
+	}
+
+	if schema, ok := endpoint.successSchema(); ok {
+		if p.Items == "" {
+			p.Items = itemsField(schema)
+		}
+		if p.NextCursor == "" && p.Style == PaginationCursor {
+			p.NextCursor = nextCursorField(schema)
+		}
+	}
+	if p.Style == PaginationPage {
+		p.FirstPage = 1
+		if page, ok := endpoint.queryParam(p.PageParam); ok && page.Schema.Minimum != nil {
+			p.FirstPage = int(*page.Schema.Minimum)
+		}
+	}
+	if size, ok := endpoint.queryParam(p.SizeParam); ok && size.Schema.Maximum != nil {
+		p.MaxSize = int(*size.Schema.Maximum)
+	}
+	endpoint.Pagination = &p
+}
+
+// orQueryParam returns name when it is set, otherwise the first of the
+// conventional names the endpoint has as a query parameter
+func orQueryParam(endpoint *Endpoint, name string, conventional []string) string {
+	if name != "" {
+		return name
+	}
+	for _, candidate := range conventional {
+		if _, ok := endpoint.queryParam(candidate); ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// queryParam returns the query parameter called name
+func (e *Endpoint) queryParam(name string) (Parameter, bool) {
+	if name == "" {
+		return Parameter{}, false
+	}
+	i := slices.IndexFunc(e.Parameters, func(p Parameter) bool {
+		return p.In == "query" && p.Name == name
+	})
+	if i < 0 {
+		return Parameter{}, false
+	}
+	return e.Parameters[i], true
+}
+
+// successSchema returns the JSON schema of the endpoint's first documented
+// 2xx response
+func (e *Endpoint) successSchema() (Schema, bool) {
+	codes := make([]string, 0, len(e.Responses))
+	for code := range e.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		for contentType, media := range e.Responses[code].Content {
+			if strings.Contains(contentType, "json") {
+				return media.Schema, true
+			}
+		}
+	}
+	return Schema{}, false
+}
+
+// itemsField returns the array property of a list response holding its
+// items; empty when the response is an array or has no such property
+func itemsField(schema Schema) string {
+	if schema.Type == "array" {
+		return ""
+	}
+	for _, name := range itemsFieldNames {
+		if schema.Properties[name].Type == "array" {
+			return name
+		}
+	}
+	var arrays []string
+	for name, property := range schema.Properties {
+		if property.Type == "array" {
+			arrays = append(arrays, name)
+		}
+	}
+	if len(arrays) == 1 {
+		return arrays[0]
+	}
+	return ""
+}
+
+// nextCursorField returns the dotted path of the response field holding
+// the next page's cursor, looked up at the top level and in containers such
+// as meta and pagination
+func nextCursorField(schema Schema) string {
+	for _, name := range nextCursorNames {
+		if _, ok := schema.Properties[name]; ok {
+			return name
+		}
+	}
+	for _, container := range cursorContainers {
+		properties := schema.Properties[container].Properties
+		for _, name := range nextCursorNames {
+			if _, ok := properties[name]; ok {
+				return container + "." + name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const paginationSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    parameters:
+      - name: per_page
+        in: query
+        schema:
+          type: integer
+          maximum: 100
+    get:
+      operationId: listPets
+      parameters:
+        - name: page
+          in: query
+          schema:
+            type: integer
+            minimum: 0
+      responses:
+        '200':
+          description: Pets
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  total:
+                    type: integer
+                  results:
+                    type: array
+                    items:
+                      type: object
+  /events:
+    get:
+      operationId: listEvents
+      parameters:
+        - name: cursor
+          in: query
+        - name: limit
+          in: query
+      responses:
+        '200':
+          description: Events
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data:
+                    type: array
+                  meta:
+                    type: object
+                    properties:
+                      next_cursor:
+                        type: string
+  /orders:
+    get:
+      operationId: listOrders
+      x-pagination:
+        style: cursor
+        cursor: after
+        size: first
+        nextCursor: pageInfo.end
+        items: nodes
+      parameters:
+        - name: after
+          in: query
+        - name: first
+          in: query
+      responses:
+        '200':
+          description: Orders
+  /logs:
+    get:
+      operationId: listLogs
+      x-pagination: false
+      parameters:
+        - name: offset
+          in: query
+      responses:
+        '200':
+          description: Logs
+  /users:
+    get:
+      operationId: listUsers
+      parameters:
+        - name: limit
+          in: query
+      responses:
+        '200':
+          description: Users
+`
+
+func TestParseOpenAPISpec_Pagination(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(paginationSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	byID := map[string]*Pagination{}
+	for _, e := range spec.Endpoints {
+		byID[e.OperationID] = e.Pagination
+	}
+
+	assert.Equal(t, &Pagination{
+		Style: PaginationPage, PageParam: "page", SizeParam: "per_page",
+		Items: "results", FirstPage: 0, MaxSize: 100,
+	}, byID["listPets"], "path-level parameters are inherited before detection")
+	assert.Equal(t, &Pagination{
+		Style: PaginationCursor, CursorParam: "cursor", SizeParam: "limit",
+		NextCursor: "meta.next_cursor", Items: "data",
+	}, byID["listEvents"])
+	assert.Equal(t, &Pagination{
+		Style: PaginationCursor, CursorParam: "after", SizeParam: "first",
+		NextCursor: "pageInfo.end", Items: "nodes",
+	}, byID["listOrders"], "x-pagination declares non-conventional names")
+	assert.Nil(t, byID["listLogs"], "x-pagination: false turns detection off")
+	assert.Nil(t, byID["listUsers"], "a limit alone does not page")
+}
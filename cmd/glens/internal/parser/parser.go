@@ -1,12 +1,12 @@
 package parser
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,15 +14,26 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// ParseOpenAPISpec parses an OpenAPI specification from a URL or file path
+// ParseOpenAPISpec parses an OpenAPI specification from a URL or file path.
+// GraphQL schemas, in SDL (.graphql, .graphqls, .gql) or as the JSON result
+// of an introspection query, are parsed too: each field of the Query and
+// Mutation types becomes an endpoint posting to GraphQLPath. So are gRPC
+// services, from .proto files or file descriptor sets (.protoset, .pb,
+// .binpb, .desc): each method becomes an endpoint posting to its path.
 func ParseOpenAPISpec(source string) (*OpenAPISpec, error) {
+	return ParseOpenAPISpecContext(context.Background(), source)
+}
+
+// ParseOpenAPISpecContext is ParseOpenAPISpec with a context that cancels
+// fetching the specification from a URL
+func ParseOpenAPISpecContext(ctx context.Context, source string) (*OpenAPISpec, error) {
 	log.Debug().Str("source", source).Msg("Parsing OpenAPI specification")
 
 	var data []byte
 	var err error
 
 	if isURL(source) {
-		data, err = fetchFromURL(source)
+		data, err = fetchFromURL(ctx, source)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch from URL: %w", err)
 		}
@@ -32,25 +43,42 @@ func ParseOpenAPISpec(source string) (*OpenAPISpec, error) {
 			return nil, fmt.Errorf("failed to read file: %w", err)
 		}
 	}
+	return ParseSpecData(source, data)
+}
 
-	// Determine format based on content or extension
-	var rawSpec map[string]interface{}
-	if isYAML(source, data) {
-		if err := yaml.Unmarshal(data, &rawSpec); err != nil {
-			return nil, fmt.Errorf("failed to parse YAML: %w", err)
-		}
-	} else {
-		if err := json.Unmarshal(data, &rawSpec); err != nil {
-			return nil, fmt.Errorf("failed to parse JSON: %w", err)
-		}
+// ParseSpecData parses a specification already read from source, which
+// only names it: its extension picks the format as for ParseOpenAPISpec
+func ParseSpecData(source string, data []byte) (*OpenAPISpec, error) {
+	// GraphQL schemas, in SDL or as introspection results, map their
+	// queries and mutations to endpoints, and protobuf schemas the methods
+	// of their services
+	var spec *OpenAPISpec
+	var err error
+	switch {
+	case isProtoDescriptorSet(source):
+		spec, err = parseProtoDescriptorSet(source, data)
+	case isProto(source, data):
+		spec, err = parseProto(source, data)
+	case isGraphQLSDL(source, data):
+		spec, err = parseGraphQLSDL(data)
+	case !isYAML(source, data) && isGraphQLIntrospection(data):
+		spec, err = parseGraphQLIntrospection(data)
+	default:
+		spec, err = parseOpenAPI(source, data)
 	}
-
-	spec, err := convertToSpec(rawSpec)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert to internal format: %w", err)
+		return nil, err
 	}
 
 	spec.ParsedAt = time.Now()
+	spec.Source = source
+	for _, warning := range spec.Warnings {
+		log.Debug().
+			Str("location", spec.Location(warning.Position)).
+			Str("path", warning.Path).
+			Str("code", warning.Code).
+			Msg(warning.Message)
+	}
 
 	log.Info().
 		Int("endpoints_count", len(spec.Endpoints)).
@@ -61,40 +89,60 @@ func ParseOpenAPISpec(source string) (*OpenAPISpec, error) {
 	return spec, nil
 }
 
-// isURL checks if the source is a URL
-func isURL(source string) bool {
-	u, err := url.Parse(source)
-	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
-}
-
-// fetchFromURL fetches content from a URL
-func fetchFromURL(urlStr string) ([]byte, error) {
-	// Validate URL to mitigate G107 security warning
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+// parseOpenAPI parses an OpenAPI document in YAML or JSON. Large documents
+// are parsed in the low-memory mode (see ParseConfig).
+func parseOpenAPI(source string, data []byte) (*OpenAPISpec, error) {
+	if currentParseConfig().lowMemory(len(data)) {
+		log.Debug().Int("size", len(data)).Msg("Parsing OpenAPI specification in low-memory mode")
+		return parseLowMemory(source, data)
 	}
 
-	// Only allow HTTP and HTTPS schemes
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
+	// Determine format based on content or extension
+	var rawSpec map[string]interface{}
+	var document *yaml.Node
+	var err error
+	if isYAML(source, data) {
+		if document, rawSpec, err = parseYAML(data); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &rawSpec); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		// JSON is YAML: its node tree gives the positions
+		document, _ = yamlDocument(data)
 	}
 
-	resp, err := http.Get(parsedURL.String())
+	spec, err := convertToSpec(rawSpec, nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to convert to internal format: %w", err)
 	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Debug().Err(closeErr).Msg("failed to close response body")
-		}
-	}()
+	locateOperations(spec, rawSpec, document)
+	return spec, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+// parseYAML parses a YAML document into its node tree and its generic
+// value, with anchors and merge keys applied (see yamlConverter)
+func parseYAML(data []byte) (*yaml.Node, map[string]interface{}, error) {
+	document, err := yamlDocument(data)
+	if err != nil || document == nil {
+		return nil, nil, err
 	}
+	value, err := newYAMLConverter().value(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	rawSpec, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil, errNotMapping
+	}
+	return document, rawSpec, nil
+}
 
-	return io.ReadAll(resp.Body)
+// isURL checks if the source is a URL
+func isURL(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
 }
 
 // isYAML determines if the content is YAML based on file extension or content
@@ -111,11 +159,17 @@ func isYAML(source string, data []byte) bool {
 		strings.HasPrefix(content, "swagger:")
 }
 
-// convertToSpec converts the raw specification to our internal format
-func convertToSpec(rawSpec map[string]interface{}) (*OpenAPISpec, error) {
+// convertToSpec converts the raw specification to our internal format.
+// The path items of the paths and webhooks of a document parsed in the
+// low-memory mode, which rawSpec lacks, are converted one at a time.
+func convertToSpec(rawSpec map[string]interface{}, sections *streamedSections) (*OpenAPISpec, error) {
 	spec := &OpenAPISpec{
 		Endpoints: []Endpoint{},
 	}
+	resolver := newRefResolver(rawSpec)
+	if resolved, _, _ := resolver.resolve(rawSpec, nil); resolved != nil {
+		rawSpec = resolved.(map[string]interface{})
+	}
 
 	// Extract version
 	if openapi, ok := rawSpec["openapi"].(string); ok {
@@ -142,6 +196,38 @@ func convertToSpec(rawSpec map[string]interface{}) (*OpenAPISpec, error) {
 		}
 		spec.Endpoints = endpoints
 	}
+	if sections != nil {
+		endpoints, err := extractStreamed(resolver, sections.paths, extractEndpoints)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract endpoints: %w", err)
+		}
+		spec.Endpoints = append(spec.Endpoints, endpoints...)
+	}
+
+	// The root security requirements apply to the path operations that
+	// declare none of their own
+	if securityRaw, ok := rawSpec["security"].([]interface{}); ok {
+		security := extractSecurity(securityRaw)
+		for i := range spec.Endpoints {
+			if spec.Endpoints[i].Kind == "" && spec.Endpoints[i].Security == nil {
+				spec.Endpoints[i].Security = security
+			}
+		}
+	}
+
+	// Extract webhooks (OpenAPI 3.1)
+	if webhooksRaw, ok := rawSpec["webhooks"].(map[string]interface{}); ok {
+		spec.Endpoints = append(spec.Endpoints, extractWebhooks(webhooksRaw)...)
+	}
+	if sections != nil {
+		webhooks, err := extractStreamed(resolver, sections.webhooks, func(webhooksRaw map[string]interface{}) ([]Endpoint, error) {
+			return extractWebhooks(webhooksRaw), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract webhooks: %w", err)
+		}
+		spec.Endpoints = append(spec.Endpoints, webhooks...)
+	}
 
 	return spec, nil
 }
@@ -190,6 +276,14 @@ func extractServers(serversRaw []interface{}) []Server {
 			if description, ok := serverMap["description"].(string); ok {
 				server.Description = description
 			}
+			if variablesRaw, ok := serverMap["variables"].(map[string]interface{}); ok {
+				server.Variables = make(map[string]ServerVariable, len(variablesRaw))
+				for name, variableRaw := range variablesRaw {
+					if variable, ok := variableRaw.(map[string]interface{}); ok {
+						server.Variables[name] = extractServerVariable(variable)
+					}
+				}
+			}
 			servers = append(servers, server)
 		}
 	}
@@ -197,67 +291,234 @@ func extractServers(serversRaw []interface{}) []Server {
 	return servers
 }
 
-// extractEndpoints extracts endpoints from paths
+// extractServerVariable extracts the default, allowed values and
+// description of a server URL variable
+func extractServerVariable(variableRaw map[string]interface{}) ServerVariable {
+	var variable ServerVariable
+	if value, ok := variableRaw["default"]; ok && value != nil {
+		variable.Default = fmt.Sprint(value)
+	}
+	if enumRaw, ok := variableRaw["enum"].([]interface{}); ok {
+		for _, value := range enumRaw {
+			variable.Enum = append(variable.Enum, fmt.Sprint(value))
+		}
+	}
+	if description, ok := variableRaw["description"].(string); ok {
+		variable.Description = description
+	}
+	return variable
+}
+
+// extractEndpoints extracts endpoints from paths, followed by the
+// callbacks of their operations
 func extractEndpoints(pathsRaw map[string]interface{}) ([]Endpoint, error) {
-	var endpoints []Endpoint
+	var endpoints, callbacks []Endpoint
 
 	for path, pathItemRaw := range pathsRaw {
-		if pathItem, ok := pathItemRaw.(map[string]interface{}); ok {
-			for method, operationRaw := range pathItem {
-				if method == "parameters" || method == "servers" {
-					continue // Skip path-level parameters and servers
-				}
+		pathItem, ok := pathItemRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for method, operation := range operations(pathItem) {
+			endpoint := extractOperation(method, path, operation)
+			inheritPathItem(&endpoint, pathItem)
+			detectPagination(&endpoint, operation)
+			endpoint.ID = fmt.Sprintf("%s_%s", endpoint.Method, strings.ReplaceAll(path, "/", "_"))
+			endpoints = append(endpoints, endpoint)
+
+			if callbacksRaw, ok := operation["callbacks"].(map[string]interface{}); ok {
+				callbacks = append(callbacks, extractCallbacks(&endpoint, callbacksRaw)...)
+			}
+		}
+	}
 
-				if operation, ok := operationRaw.(map[string]interface{}); ok {
-					endpoint := Endpoint{
-						ID:        fmt.Sprintf("%s_%s", strings.ToUpper(method), strings.ReplaceAll(path, "/", "_")),
-						Method:    strings.ToUpper(method),
-						Path:      path,
-						Responses: make(map[string]Response),
-					}
+	return append(endpoints, callbacks...), nil
+}
 
-					// Extract operation details
-					if operationID, ok := operation["operationId"].(string); ok {
-						endpoint.OperationID = operationID
-					}
-					if summary, ok := operation["summary"].(string); ok {
-						endpoint.Summary = summary
-					}
-					if description, ok := operation["description"].(string); ok {
-						endpoint.Description = description
-					}
+// extractCallbacks extracts the operations of the callbacks of trigger.
+// Callbacks map names to path items keyed by the callback URL expression.
+func extractCallbacks(trigger *Endpoint, callbacksRaw map[string]interface{}) []Endpoint {
+	var endpoints []Endpoint
+	for name, callbackRaw := range callbacksRaw {
+		callback, ok := callbackRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for expression, pathItemRaw := range callback {
+			pathItem, ok := pathItemRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for method, operation := range operations(pathItem) {
+				endpoint := extractOperation(method, expression, operation)
+				inheritPathItem(&endpoint, pathItem)
+				endpoint.ID = fmt.Sprintf("%s_CALLBACK_%s_%s", trigger.ID, name, endpoint.Method)
+				endpoint.Kind = KindCallback
+				endpoint.Trigger = trigger.Method + " " + trigger.Path
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
 
-					// Extract tags
-					if tagsRaw, ok := operation["tags"].([]interface{}); ok {
-						for _, tagRaw := range tagsRaw {
-							if tag, ok := tagRaw.(string); ok {
-								endpoint.Tags = append(endpoint.Tags, tag)
-							}
-						}
-					}
+// extractWebhooks extracts the operations of the OpenAPI 3.1 webhooks
+// section, which maps webhook names to path items
+func extractWebhooks(webhooksRaw map[string]interface{}) []Endpoint {
+	var endpoints []Endpoint
+	for name, pathItemRaw := range webhooksRaw {
+		pathItem, ok := pathItemRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for method, operation := range operations(pathItem) {
+			endpoint := extractOperation(method, name, operation)
+			inheritPathItem(&endpoint, pathItem)
+			endpoint.ID = fmt.Sprintf("WEBHOOK_%s_%s", endpoint.Method, name)
+			endpoint.Kind = KindWebhook
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
 
-					// Extract parameters
-					if parametersRaw, ok := operation["parameters"].([]interface{}); ok {
-						endpoint.Parameters = extractParameters(parametersRaw)
-					}
+// operations returns the operations of a path item by method, skipping
+// path-level fields such as parameters and servers, vendor extensions and
+// unknown fields (see checkPathItem)
+func operations(pathItem map[string]interface{}) map[string]map[string]interface{} {
+	ops := make(map[string]map[string]interface{})
+	for method, operationRaw := range pathItem {
+		if !slices.Contains(httpMethods, strings.ToLower(method)) {
+			continue
+		}
+		if operation, ok := operationRaw.(map[string]interface{}); ok {
+			ops[method] = operation
+		}
+	}
+	return ops
+}
 
-					// Extract request body
-					if requestBodyRaw, ok := operation["requestBody"].(map[string]interface{}); ok {
-						endpoint.RequestBody = extractRequestBody(requestBodyRaw)
-					}
+// inheritPathItem applies the path item's parameters, servers and vendor
+// extensions to one of its operations. An operation parameter overrides the
+// path parameter of the same name and location; operation servers and
+// extensions override those of the path.
+func inheritPathItem(endpoint *Endpoint, pathItem map[string]interface{}) {
+	if parametersRaw, ok := pathItem["parameters"].([]interface{}); ok {
+		var inherited []Parameter
+		for _, param := range extractParameters(parametersRaw) {
+			overridden := slices.ContainsFunc(endpoint.Parameters, func(p Parameter) bool {
+				return p.Name == param.Name && p.In == param.In
+			})
+			if !overridden {
+				inherited = append(inherited, param)
+			}
+		}
+		endpoint.Parameters = append(inherited, endpoint.Parameters...)
+	}
 
-					// Extract responses
-					if responsesRaw, ok := operation["responses"].(map[string]interface{}); ok {
-						endpoint.Responses = extractResponses(responsesRaw)
-					}
+	if len(endpoint.Servers) == 0 {
+		if serversRaw, ok := pathItem["servers"].([]interface{}); ok {
+			endpoint.Servers = extractServers(serversRaw)
+		}
+	}
 
-					endpoints = append(endpoints, endpoint)
-				}
+	for key, value := range extractExtensions(pathItem) {
+		if _, overridden := endpoint.Extensions[key]; !overridden {
+			if endpoint.Extensions == nil {
+				endpoint.Extensions = make(map[string]interface{})
+			}
+			endpoint.Extensions[key] = value
+		}
+	}
+}
+
+// extractOperation extracts the endpoint of an operation; its ID is left
+// to the caller
+func extractOperation(method, path string, operation map[string]interface{}) Endpoint {
+	endpoint := Endpoint{
+		Method:    strings.ToUpper(method),
+		Path:      path,
+		Responses: make(map[string]Response),
+	}
+
+	// Extract operation details
+	if operationID, ok := operation["operationId"].(string); ok {
+		endpoint.OperationID = operationID
+	}
+	if summary, ok := operation["summary"].(string); ok {
+		endpoint.Summary = summary
+	}
+	if description, ok := operation["description"].(string); ok {
+		endpoint.Description = description
+	}
+	if deprecated, ok := operation["deprecated"].(bool); ok {
+		endpoint.Deprecated = deprecated
+	}
+	if xSafe, ok := operation["x-safe"].(bool); ok {
+		endpoint.XSafe = xSafe
+	}
+	endpoint.Extensions = extractExtensions(operation)
+
+	// Extract tags
+	if tagsRaw, ok := operation["tags"].([]interface{}); ok {
+		for _, tagRaw := range tagsRaw {
+			if tag, ok := tagRaw.(string); ok {
+				endpoint.Tags = append(endpoint.Tags, tag)
 			}
 		}
 	}
 
-	return endpoints, nil
+	// Extract parameters
+	if parametersRaw, ok := operation["parameters"].([]interface{}); ok {
+		endpoint.Parameters = extractParameters(parametersRaw)
+	}
+
+	if serversRaw, ok := operation["servers"].([]interface{}); ok {
+		endpoint.Servers = extractServers(serversRaw)
+	}
+
+	// Extract request body
+	if requestBodyRaw, ok := operation["requestBody"].(map[string]interface{}); ok {
+		endpoint.RequestBody = extractRequestBody(requestBodyRaw)
+	}
+
+	// Extract responses
+	if responsesRaw, ok := operation["responses"].(map[string]interface{}); ok {
+		endpoint.Responses = extractResponses(responsesRaw)
+	}
+
+	if securityRaw, ok := operation["security"].([]interface{}); ok {
+		endpoint.Security = extractSecurity(securityRaw)
+	}
+
+	return endpoint
+}
+
+// extractSecurity extracts security requirements, each mapping scheme names
+// to scopes. An empty list, which declares an operation public, is returned
+// as an empty non-nil slice so it differs from no declaration.
+func extractSecurity(securityRaw []interface{}) []SecurityRequirement {
+	security := make([]SecurityRequirement, 0, len(securityRaw))
+	for _, requirementRaw := range securityRaw {
+		requirementData, ok := requirementRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		requirement := make(SecurityRequirement, len(requirementData))
+		for scheme, scopesRaw := range requirementData {
+			scopes := []string{}
+			if scopesList, ok := scopesRaw.([]interface{}); ok {
+				for _, scope := range scopesList {
+					if s, ok := scope.(string); ok {
+						scopes = append(scopes, s)
+					}
+				}
+			}
+			requirement[scheme] = scopes
+		}
+		security = append(security, requirement)
+	}
+	return security
 }
 
 // extractParameters extracts parameters from operation
@@ -330,6 +591,9 @@ func extractResponses(responsesRaw map[string]interface{}) map[string]Response {
 			if contentRaw, ok := response["content"].(map[string]interface{}); ok {
 				resp.Content = extractContent(contentRaw)
 			}
+			if headersRaw, ok := response["headers"].(map[string]interface{}); ok {
+				resp.Headers = extractHeaders(headersRaw)
+			}
 
 			responses[code] = resp
 		}
@@ -338,6 +602,34 @@ func extractResponses(responsesRaw map[string]interface{}) map[string]Response {
 	return responses
 }
 
+// extractHeaders extracts the headers of a response
+func extractHeaders(headersRaw map[string]interface{}) map[string]Header {
+	headers := make(map[string]Header)
+
+	for name, headerRaw := range headersRaw {
+		headerData, ok := headerRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		header := Header{}
+		if description, ok := headerData["description"].(string); ok {
+			header.Description = description
+		}
+		if required, ok := headerData["required"].(bool); ok {
+			header.Required = required
+		}
+		if schemaRaw, ok := headerData["schema"].(map[string]interface{}); ok {
+			header.Schema = extractSchema(schemaRaw)
+		}
+		if example := headerData["example"]; example != nil {
+			header.Example = example
+		}
+		headers[name] = header
+	}
+
+	return headers
+}
+
 // extractContent extracts media type content
 func extractContent(contentRaw map[string]interface{}) map[string]MediaType {
 	content := make(map[string]MediaType)
@@ -352,6 +644,9 @@ func extractContent(contentRaw map[string]interface{}) map[string]MediaType {
 			if example := mediaTypeData["example"]; example != nil {
 				mt.Example = example
 			}
+			if examplesRaw, ok := mediaTypeData["examples"].(map[string]interface{}); ok {
+				mt.Examples = extractExamples(examplesRaw)
+			}
 
 			content[mediaType] = mt
 		}
@@ -364,9 +659,7 @@ func extractContent(contentRaw map[string]interface{}) map[string]MediaType {
 func extractSchema(schemaRaw map[string]interface{}) Schema {
 	schema := Schema{}
 
-	if schemaType, ok := schemaRaw["type"].(string); ok {
-		schema.Type = schemaType
-	}
+	extractSchemaType(&schema, schemaRaw)
 	if format, ok := schemaRaw["format"].(string); ok {
 		schema.Format = format
 	}
@@ -387,6 +680,21 @@ func extractSchema(schemaRaw map[string]interface{}) Schema {
 		}
 	}
 
+	// Extract array item schema; 3.1 tuples list positional items in
+	// prefixItems
+	if itemsRaw, ok := schemaRaw["items"].(map[string]interface{}); ok {
+		items := extractSchema(itemsRaw)
+		schema.Items = &items
+	}
+	schema.PrefixItems = extractSchemas(schemaRaw["prefixItems"])
+
+	schema.OneOf = extractSchemas(schemaRaw["oneOf"])
+	schema.AnyOf = extractSchemas(schemaRaw["anyOf"])
+	schema.AllOf = extractSchemas(schemaRaw["allOf"])
+	if discriminatorRaw, ok := schemaRaw["discriminator"].(map[string]interface{}); ok {
+		schema.Discriminator = extractDiscriminator(discriminatorRaw)
+	}
+
 	// Extract required fields
 	if requiredRaw, ok := schemaRaw["required"].([]interface{}); ok {
 		for _, reqRaw := range requiredRaw {
@@ -396,5 +704,151 @@ func extractSchema(schemaRaw map[string]interface{}) Schema {
 		}
 	}
 
+	extractSchemaConstraints(&schema, schemaRaw)
+
 	return schema
 }
+
+// extractSchemaType extracts the type, which 3.1 allows to be an array
+// such as ["string", "null"] in place of 3.0's nullable keyword
+func extractSchemaType(schema *Schema, schemaRaw map[string]interface{}) {
+	schema.Nullable, _ = schemaRaw["nullable"].(bool)
+
+	switch typeRaw := schemaRaw["type"].(type) {
+	case string:
+		schema.Type = typeRaw
+	case []interface{}:
+		for _, t := range typeRaw {
+			name, ok := t.(string)
+			if !ok {
+				continue
+			}
+			if name == "null" {
+				schema.Nullable = true
+				continue
+			}
+			schema.Types = append(schema.Types, name)
+		}
+		switch len(schema.Types) {
+		case 0:
+			if schema.Nullable {
+				schema.Type = "null"
+			}
+		case 1:
+			schema.Type = schema.Types[0]
+			schema.Types = nil
+		default:
+			schema.Type = schema.Types[0]
+		}
+	}
+}
+
+// extractDiscriminator extracts the discriminator of a polymorphic schema
+func extractDiscriminator(discriminatorRaw map[string]interface{}) *Discriminator {
+	discriminator := &Discriminator{}
+	discriminator.PropertyName, _ = discriminatorRaw["propertyName"].(string)
+	if mappingRaw, ok := discriminatorRaw["mapping"].(map[string]interface{}); ok {
+		discriminator.Mapping = make(map[string]string, len(mappingRaw))
+		for value, refRaw := range mappingRaw {
+			if ref, ok := refRaw.(string); ok {
+				discriminator.Mapping[value] = ref
+			}
+		}
+	}
+	return discriminator
+}
+
+// extractSchemas extracts a list of schemas such as oneOf or prefixItems
+func extractSchemas(schemasRaw interface{}) []Schema {
+	list, ok := schemasRaw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var schemas []Schema
+	for _, raw := range list {
+		if schemaRaw, ok := raw.(map[string]interface{}); ok {
+			schemas = append(schemas, extractSchema(schemaRaw))
+		}
+	}
+	return schemas
+}
+
+// extractSchemaConstraints extracts enum, const, example, and validation
+// keywords
+func extractSchemaConstraints(schema *Schema, schemaRaw map[string]interface{}) {
+	if enumRaw, ok := schemaRaw["enum"].([]interface{}); ok {
+		schema.Enum = enumRaw
+	}
+	if constValue, ok := schemaRaw["const"]; ok {
+		schema.Const = constValue
+	}
+	if example := schemaRaw["example"]; example != nil {
+		schema.Example = example
+	} else if examples, ok := schemaRaw["examples"].([]interface{}); ok && len(examples) > 0 {
+		// 3.1 schemas list examples, 3.0 schemas have a single example
+		schema.Example = examples[0]
+	}
+	if pattern, ok := schemaRaw["pattern"].(string); ok {
+		schema.Pattern = pattern
+	}
+	if minimum, ok := toFloat(schemaRaw["minimum"]); ok {
+		schema.Minimum = &minimum
+	}
+	if maximum, ok := toFloat(schemaRaw["maximum"]); ok {
+		schema.Maximum = &maximum
+	}
+	// 3.1 exclusive bounds are numbers, 3.0 ones are flags on minimum and
+	// maximum
+	if bound, ok := toFloat(schemaRaw["exclusiveMinimum"]); ok {
+		schema.ExclusiveMinimum = &bound
+	} else if exclusive, _ := schemaRaw["exclusiveMinimum"].(bool); exclusive && schema.Minimum != nil {
+		schema.ExclusiveMinimum, schema.Minimum = schema.Minimum, nil
+	}
+	if bound, ok := toFloat(schemaRaw["exclusiveMaximum"]); ok {
+		schema.ExclusiveMaximum = &bound
+	} else if exclusive, _ := schemaRaw["exclusiveMaximum"].(bool); exclusive && schema.Maximum != nil {
+		schema.ExclusiveMaximum, schema.Maximum = schema.Maximum, nil
+	}
+	if minLength, ok := toFloat(schemaRaw["minLength"]); ok {
+		n := int(minLength)
+		schema.MinLength = &n
+	}
+	if maxLength, ok := toFloat(schemaRaw["maxLength"]); ok {
+		n := int(maxLength)
+		schema.MaxLength = &n
+	}
+}
+
+// extractExamples extracts named examples of a media type
+func extractExamples(examplesRaw map[string]interface{}) map[string]Example {
+	examples := make(map[string]Example)
+
+	for name, exampleRaw := range examplesRaw {
+		if exampleData, ok := exampleRaw.(map[string]interface{}); ok {
+			example := Example{Value: exampleData["value"]}
+			if summary, ok := exampleData["summary"].(string); ok {
+				example.Summary = summary
+			}
+			if description, ok := exampleData["description"].(string); ok {
+				example.Description = description
+			}
+			examples[name] = example
+		}
+	}
+
+	return examples
+}
+
+// toFloat converts JSON (float64) and YAML (int) numbers to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,495 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookSpec = `
+openapi: 3.1.0
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /subscriptions:
+    parameters:
+      - name: tenant
+        in: header
+    post:
+      operationId: subscribe
+      callbacks:
+        petEvent:
+          '{$request.body#/callbackUrl}':
+            post:
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      required: [id]
+              responses:
+                '200':
+                  description: Received
+webhooks:
+  newPet:
+    post:
+      operationId: newPetWebhook
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [id, name]
+      responses:
+        '200':
+          description: Received
+`
+
+func TestParseOpenAPISpec_WebhooksAndCallbacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(webhookSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	require.Len(t, spec.Endpoints, 3)
+
+	subscribe := spec.Endpoints[0]
+	assert.Equal(t, "POST__subscriptions", subscribe.ID)
+	assert.Empty(t, subscribe.Kind)
+	assert.False(t, subscribe.Incoming())
+
+	callback := spec.Endpoints[1]
+	assert.Equal(t, "POST__subscriptions_CALLBACK_petEvent_POST", callback.ID)
+	assert.Equal(t, KindCallback, callback.Kind)
+	assert.Equal(t, "POST /subscriptions", callback.Trigger)
+	assert.Equal(t, "{$request.body#/callbackUrl}", callback.Path)
+	assert.True(t, callback.Incoming())
+
+	webhook := spec.Endpoints[2]
+	assert.Equal(t, "WEBHOOK_POST_newPet", webhook.ID)
+	assert.Equal(t, KindWebhook, webhook.Kind)
+	assert.Equal(t, "newPet", webhook.Path)
+	assert.Equal(t, "newPetWebhook", webhook.OperationID)
+	assert.Equal(t, []string{"id", "name"}, webhook.RequestBody.Content["application/json"].Schema.Required)
+}
+
+func TestParseOpenAPISpec_Positions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(webhookSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, spec.Source)
+	assert.Equal(t, &Position{Line: 11, Column: 5}, spec.Endpoints[0].Position)
+	assert.Equal(t, &Position{Line: 16, Column: 13}, spec.Endpoints[1].Position, "callbacks")
+	assert.Equal(t, &Position{Line: 28, Column: 5}, spec.Endpoints[2].Position, "webhooks")
+
+	jsonPath := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+  "openapi": "3.0.3",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "paths": {
+    "/pets": {"$ref": "#/components/pathItems/pets"},
+    "/pets/{id}": {
+      "get": {"responses": {"200": {"description": "OK"}}}
+    }
+  },
+  "components": {"pathItems": {"pets": {"get": {"responses": {"200": {"description": "OK"}}}}}}
+}`), 0o600))
+	spec, err = ParseOpenAPISpec(jsonPath)
+	require.NoError(t, err)
+	for _, e := range spec.Endpoints {
+		switch e.Path {
+		case "/pets":
+			assert.Equal(t, &Position{Line: 5, Column: 5}, e.Position, "referenced path items point at their path")
+		case "/pets/{id}":
+			assert.Equal(t, &Position{Line: 7, Column: 7}, e.Position)
+		}
+	}
+}
+
+func TestParseOpenAPISpec_Warnings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`openapi: 3.1.0
+info: {title: Pets, version: 1.0.0}
+paths:
+  pets:
+    x-owner: {team: pets}
+    gett:
+      responses: {'200': {description: OK}}
+    get:
+      parameters:
+        - {name: limit, in: query}
+        - {name: page}
+        - $ref: '#/components/parameters/Body'
+        - just a string
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pett'
+              example: {type: sting, $ref: nowhere}
+        '404': Not found
+components:
+  parameters:
+    Body: {name: pet, in: body}
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: [strin, "null"]}
+        type: {type: string, enum: [dog, cat]}
+        tags: tag list
+        extra: true
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+`), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	require.Len(t, spec.Endpoints, 1, "unknown fields and extensions are no operations")
+	assert.Equal(t, "GET", spec.Endpoints[0].Method)
+
+	type warning struct{ code, path, message string }
+	var got []warning
+	for _, w := range spec.Warnings {
+		got = append(got, warning{w.Code, w.Path, w.Message})
+	}
+	assert.Equal(t, []warning{
+		{WarningInvalidPath, "/paths/pets", `path "pets" does not start with /`},
+		{WarningUnknownField, "/paths/pets/gett", `unknown field "gett" of pets is skipped (HTTP methods are get, put, post, delete, options, head, patch, trace, query)`},
+		{WarningMalformedParameter, "/paths/pets/get/parameters/1", "parameter 1 of GET pets has no name or no in"},
+		{WarningMalformedParameter, "/paths/pets/get/parameters/2", `parameter "pet" of GET pets is in "body" (query, header, path, cookie)`},
+		{WarningMalformedParameter, "/paths/pets/get/parameters/3", "parameter 3 of GET pets is not an object and is skipped"},
+		{WarningUnresolvedRef, "/paths/pets/get/responses/200/content/application~1json/schema/items/$ref", "unresolved reference #/components/schemas/Pett"},
+		{WarningMalformedResponse, "/paths/pets/get/responses/404", "response 404 of GET pets is not an object and is skipped"},
+		{WarningInvalidSchemaType, "/components/schemas/Pet/properties/name/type", `invalid schema type "strin" (array, boolean, integer, null, number, object, string)`},
+		{WarningMalformedSchema, "/components/schemas/Pet/properties/tags", "schema /components/schemas/Pet/properties/tags is not an object and is skipped"},
+	}, got, "examples, security schemes and boolean schemas are fine")
+	assert.Equal(t, &Position{Line: 21, Column: 25}, spec.Warnings[5].Position)
+	assert.Equal(t, path+":21:25", spec.Location(spec.Warnings[5].Position))
+}
+
+func TestExtractSchema_JSONSchemaDialect(t *testing.T) {
+	raw := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id"},
+		"properties": map[string]interface{}{
+			"id":       map[string]interface{}{"type": "integer", "exclusiveMinimum": 0},
+			"nickname": map[string]interface{}{"type": []interface{}{"string", "null"}, "maxLength": 20},
+			"legacy":   map[string]interface{}{"type": "integer", "minimum": 1, "exclusiveMinimum": true, "nullable": true},
+			"value":    map[string]interface{}{"type": []interface{}{"string", "integer"}},
+			"kind":     map[string]interface{}{"const": "cat", "examples": []interface{}{"cat"}},
+			"point": map[string]interface{}{
+				"type":        "array",
+				"prefixItems": []interface{}{map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "number"}},
+			},
+			"pet": map[string]interface{}{"oneOf": []interface{}{
+				map[string]interface{}{"$ref": "#/components/schemas/Cat"},
+				map[string]interface{}{"$ref": "#/components/schemas/Dog"},
+			}},
+			"owner": map[string]interface{}{"allOf": []interface{}{
+				map[string]interface{}{"type": "object", "required": []interface{}{"name"}},
+				map[string]interface{}{"properties": map[string]interface{}{"age": map[string]interface{}{"type": "integer"}}},
+			}},
+		},
+	}
+
+	schema := extractSchema(raw)
+	props := schema.Properties
+
+	require.NotNil(t, props["id"].ExclusiveMinimum)
+	assert.Equal(t, 0.0, *props["id"].ExclusiveMinimum)
+	assert.Equal(t, "integer; exclusive minimum 0", props["id"].Describe()+"; "+props["id"].Constraints())
+
+	assert.Equal(t, "string", props["nickname"].Type)
+	assert.True(t, props["nickname"].Nullable)
+	assert.Nil(t, props["nickname"].Types)
+	assert.Equal(t, "string or null", props["nickname"].Describe())
+
+	assert.Nil(t, props["legacy"].Minimum, "3.0 exclusiveMinimum turns minimum exclusive")
+	assert.Equal(t, 1.0, *props["legacy"].ExclusiveMinimum)
+	assert.Equal(t, "integer or null", props["legacy"].Describe())
+
+	assert.Equal(t, []string{"string", "integer"}, props["value"].Types)
+	assert.Equal(t, "string | integer", props["value"].Describe())
+
+	assert.Equal(t, "cat", props["kind"].Const)
+	assert.Equal(t, "cat", props["kind"].Example)
+	assert.Equal(t, "const cat", props["kind"].Describe())
+
+	assert.Len(t, props["point"].PrefixItems, 2)
+	assert.Equal(t, "tuple [number, number]", props["point"].Describe())
+
+	assert.Len(t, props["pet"].OneOf, 2)
+	assert.Equal(t, "oneOf(Cat, Dog)", props["pet"].Describe())
+
+	require.Len(t, props["owner"].AllOf, 2)
+	assert.Equal(t, []string{"name"}, props["owner"].AllOf[0].Required)
+	assert.Equal(t, "allOf(object, any)", props["owner"].Describe())
+}
+
+const polymorphicSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '201':
+          description: Created
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [petType]
+      properties:
+        petType:
+          type: string
+        owner:
+          $ref: '#/components/schemas/Person'
+      oneOf:
+        - $ref: '#/components/schemas/Cat'
+        - $ref: '#/components/schemas/Dog'
+      discriminator:
+        propertyName: petType
+        mapping:
+          dog: '#/components/schemas/Dog'
+    Cat:
+      allOf:
+        - type: object
+          required: [hunts]
+          properties:
+            hunts:
+              type: boolean
+    Dog:
+      type: object
+      required: [bark]
+      properties:
+        bark:
+          type: string
+    Person:
+      type: object
+      properties:
+        friend:
+          $ref: '#/components/schemas/Person'
+`
+
+func TestParseOpenAPISpec_Polymorphism(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(polymorphicSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	pet := spec.Endpoints[0].RequestBody.Content["application/json"].Schema
+
+	assert.Equal(t, "#/components/schemas/Pet", pet.Ref)
+	assert.Equal(t, []string{"petType"}, pet.Required, "references are resolved")
+	assert.Equal(t, "Person", pet.Properties["owner"].Properties["friend"].Describe())
+	assert.Empty(t, pet.Properties["owner"].Properties["friend"].Properties, "recursive references stay references")
+
+	require.NotNil(t, pet.Discriminator)
+	assert.Equal(t, "petType", pet.Discriminator.PropertyName)
+	assert.Equal(t, map[string]string{"dog": "#/components/schemas/Dog"}, pet.Discriminator.Mapping)
+
+	variants := pet.Variants()
+	require.Len(t, variants, 2)
+	assert.Equal(t, "Cat", variants[0].Name)
+	assert.Equal(t, "Cat", variants[0].Value, "defaults to the schema name")
+	assert.Equal(t, []string{"hunts"}, variants[0].Schema.RequiredProperties())
+	assert.Equal(t, "Dog", variants[1].Name)
+	assert.Equal(t, "dog", variants[1].Value)
+	assert.Equal(t, []string{"bark"}, variants[1].Schema.RequiredProperties())
+}
+
+func TestSchema_Variants_FromMapping(t *testing.T) {
+	base := Schema{Discriminator: &Discriminator{PropertyName: "kind", Mapping: map[string]string{
+		"card": "#/components/schemas/Card",
+		"bank": "#/components/schemas/BankTransfer",
+	}}}
+	assert.Equal(t, []Variant{
+		{Name: "BankTransfer", Value: "bank"},
+		{Name: "Card", Value: "card"},
+	}, base.Variants())
+
+	anyOf := Schema{AnyOf: []Schema{
+		{Type: "object", Properties: map[string]Schema{"kind": {Const: "card"}}},
+		{Type: "string"},
+	}, Discriminator: &Discriminator{PropertyName: "kind"}}
+	variants := anyOf.Variants()
+	assert.Equal(t, "variant 1", variants[0].Name)
+	assert.Equal(t, "card", variants[0].Value)
+	assert.Empty(t, Schema{Type: "object"}.Variants())
+}
+
+const inheritanceSpec = `
+openapi: 3.0.3
+info:
+  title: Files
+  version: 1.0.0
+servers:
+  - url: https://api.example.com
+paths:
+  /files/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+      - $ref: '#/components/parameters/Tenant'
+    servers:
+      - url: https://{region}.files.example.com
+        variables:
+          region:
+            default: eu
+    get:
+      parameters:
+        - name: X-Tenant
+          in: header
+          required: true
+          description: Overrides the path parameter
+        - name: fields
+          in: query
+      responses:
+        '200':
+          description: OK
+    put:
+      servers:
+        - url: https://upload.example.com
+      responses:
+        '204':
+          description: Stored
+components:
+  parameters:
+    Tenant:
+      name: X-Tenant
+      in: header
+      description: Shared tenant header
+`
+
+func TestParseOpenAPISpec_PathItemInheritance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(inheritanceSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}}, spec.Servers)
+
+	byMethod := map[string]Endpoint{}
+	for _, e := range spec.Endpoints {
+		byMethod[e.Method] = e
+	}
+
+	get := byMethod["GET"]
+	require.Len(t, get.Parameters, 3)
+	assert.Equal(t, "id", get.Parameters[0].Name, "path-level parameters come first")
+	assert.True(t, get.Parameters[0].Required)
+	assert.Equal(t, "Overrides the path parameter", get.Parameters[1].Description)
+	assert.Equal(t, "fields", get.Parameters[2].Name)
+	assert.Equal(t, []Server{{URL: "https://{region}.files.example.com", Variables: map[string]ServerVariable{"region": {Default: "eu"}}}}, get.Servers)
+
+	put := byMethod["PUT"]
+	require.Len(t, put.Parameters, 2)
+	assert.Equal(t, "Shared tenant header", put.Parameters[1].Description)
+	assert.Equal(t, []Server{{URL: "https://upload.example.com"}}, put.Servers, "operation servers override path servers")
+}
+
+const anchorSpec = `openapi: 3.0.3
+info: {title: Pets, version: 1.0.0}
+x-errors: &errors
+  400: {description: Bad request}
+  500: {description: Server error}
+paths:
+  /pets: &pets
+    get:
+      responses:
+        <<: *errors
+        200: {description: OK}
+        500: {description: Overridden}
+  /cats: *pets
+  /dogs:
+    <<: *pets
+    post:
+      responses: *errors
+`
+
+func TestParseOpenAPISpec_AnchorsAndMergeKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(anchorSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	assert.Empty(t, spec.Warnings, "aliases and merge keys are no unknown fields")
+
+	byOperation := map[string]Endpoint{}
+	for _, e := range spec.Endpoints {
+		byOperation[e.Method+" "+e.Path] = e
+	}
+	require.Len(t, byOperation, 4)
+	for _, operation := range []string{"GET /pets", "GET /cats", "GET /dogs"} {
+		get := byOperation[operation]
+		assert.Len(t, get.Responses, 3, "%s: unquoted status codes and merged responses", operation)
+		assert.Equal(t, "Overridden", get.Responses["500"].Description, "own keys override merged ones")
+		assert.Equal(t, &Position{Line: 8, Column: 5}, get.Position, "%s: operations of aliased path items are at their anchor", operation)
+	}
+	assert.Equal(t, "Bad request", byOperation["POST /dogs"].Responses["400"].Description)
+
+	require.NoError(t, os.WriteFile(path, []byte("openapi: 3.0.3\npaths: &paths\n  /loop: *paths\n"), 0o600))
+	_, err = ParseOpenAPISpec(path)
+	assert.ErrorContains(t, err, `anchor "paths" value contains itself`)
+}
+
+func TestParseOpenAPISpec_LowMemory(t *testing.T) {
+	sources := []string{"../server/openapi.yaml"}
+	jsonSpecs, err := filepath.Glob("../../../../test_specs/*.json")
+	require.NoError(t, err)
+	sources = append(sources, jsonSpecs...)
+	anchors := filepath.Join(t.TempDir(), "anchors.yaml")
+	require.NoError(t, os.WriteFile(anchors, []byte(anchorSpec), 0o600))
+	webhooks := filepath.Join(t.TempDir(), "webhooks.yaml")
+	require.NoError(t, os.WriteFile(webhooks, []byte(webhookSpec), 0o600))
+	sources = append(sources, anchors, webhooks)
+
+	for _, source := range sources {
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			SetParseConfig(ParseConfig{})
+			want, err := ParseOpenAPISpec(source)
+			require.NoError(t, err)
+			SetParseConfig(ParseConfig{LowMemory: true})
+			defer SetParseConfig(ParseConfig{})
+			got, err := ParseOpenAPISpec(source)
+			require.NoError(t, err)
+
+			assert.Empty(t, got.Warnings)
+			assert.Equal(t, want.Info, got.Info)
+			assert.Equal(t, want.Servers, got.Servers)
+			for i := range want.Endpoints {
+				want.Endpoints[i].Position = nil
+			}
+			assert.ElementsMatch(t, want.Endpoints, got.Endpoints)
+		})
+	}
+}
+
+func TestParseConfig_LowMemory(t *testing.T) {
+	assert.False(t, ParseConfig{}.lowMemory(DefaultLowMemoryAboveMB<<20))
+	assert.True(t, ParseConfig{}.lowMemory(DefaultLowMemoryAboveMB<<20+1))
+	assert.True(t, ParseConfig{LowMemoryAboveMB: 1}.lowMemory(2<<20))
+	assert.False(t, ParseConfig{LowMemoryAboveMB: -1}.lowMemory(1<<30))
+	assert.True(t, ParseConfig{LowMemory: true}.lowMemory(1))
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a 1-based line and column of a spec file
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Location returns position in the spec file as source:line:column, or ""
+// for a nil position
+func (s *OpenAPISpec) Location(position *Position) string {
+	if position == nil {
+		return ""
+	}
+	if s.Source == "" {
+		return fmt.Sprintf("line %d", position.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", s.Source, position.Line, position.Column)
+}
+
+// locateOperations sets the Position of the endpoints of spec to where
+// their operations are declared in document, the YAML or JSON document spec
+// was parsed from as rawSpec: the method key of the operation, or the path
+// key when the path item is a $ref. Operations of anchored path items are
+// at their anchor. It also adds the Warnings of the document (see
+// checkDocument).
+func locateOperations(spec *OpenAPISpec, rawSpec map[string]interface{}, document *yaml.Node) {
+	if document == nil {
+		return
+	}
+	positions := make(map[string]*Position)
+	eachPair(mappingValue(document, "paths"), func(path, pathItem *yaml.Node) {
+		locatePathItem(positions, "", "", path, pathItem)
+		eachPair(pathItem, func(method, operation *yaml.Node) {
+			trigger := strings.ToUpper(method.Value) + " " + path.Value
+			eachPair(mappingValue(operation, "callbacks"), func(_, callback *yaml.Node) {
+				eachPair(callback, func(expression, callbackItem *yaml.Node) {
+					locatePathItem(positions, KindCallback, trigger, expression, callbackItem)
+				})
+			})
+		})
+	})
+	eachPair(mappingValue(document, "webhooks"), func(name, pathItem *yaml.Node) {
+		locatePathItem(positions, KindWebhook, "", name, pathItem)
+	})
+
+	checkDocument(spec, rawSpec, document)
+
+	for i := range spec.Endpoints {
+		e := &spec.Endpoints[i]
+		if position, ok := positions[operationKey(e.Kind, e.Trigger, e.Method, e.Path)]; ok {
+			e.Position = position
+		} else if position, ok := positions[operationKey(e.Kind, e.Trigger, "", e.Path)]; ok {
+			e.Position = position
+		}
+	}
+}
+
+// locatePathItem records the positions of the path key and the operations
+// of a path item
+func locatePathItem(positions map[string]*Position, kind, trigger string, path, pathItem *yaml.Node) {
+	positions[operationKey(kind, trigger, "", path.Value)] = &Position{Line: path.Line, Column: path.Column}
+	eachPair(pathItem, func(method, _ *yaml.Node) {
+		positions[operationKey(kind, trigger, method.Value, path.Value)] = &Position{Line: method.Line, Column: method.Column}
+	})
+}
+
+func operationKey(kind, trigger, method, path string) string {
+	return kind + "\x00" + trigger + "\x00" + strings.ToUpper(method) + " " + path
+}
+
+// mappingValue returns the value of key in a mapping node, if any
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	var value *yaml.Node
+	eachPair(node, func(k, v *yaml.Node) {
+		if value == nil && k.Value == key {
+			value = v
+		}
+	})
+	return value
+}
+
+// eachPair calls fn with the keys and values of a mapping node, following
+// aliases. The pairs "<<" merge keys merge in follow the mapping's own,
+// without those whose key the mapping or an earlier merged mapping has.
+func eachPair(node *yaml.Node, fn func(key, value *yaml.Node)) {
+	eachMergedPair(node, fn, nil)
+}
+
+// eachMergedPair is eachPair for a node merged into the mappings of
+// merging, which it does not merge again so merge cycles end
+func eachMergedPair(node *yaml.Node, fn func(key, value *yaml.Node), merging []*yaml.Node) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode || slices.Contains(merging, node) {
+		return
+	}
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isMergeKey(node.Content[i]) {
+			merges = append(merges, resolveAlias(node.Content[i+1]))
+			continue
+		}
+		fn(node.Content[i], resolveAlias(node.Content[i+1]))
+	}
+	if len(merges) == 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		seen[node.Content[i].Value] = true
+	}
+	for _, merge := range merges {
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+		for _, source := range sources {
+			eachMergedPair(source, func(key, value *yaml.Node) {
+				if !seen[key.Value] {
+					seen[key.Value] = true
+					fn(key, value)
+				}
+			}, append(merging, node))
+		}
+	}
+}
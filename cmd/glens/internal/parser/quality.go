@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Spec quality checks, each passed or failed by every operation
+const (
+	// CheckDocumented requires a summary or description
+	CheckDocumented = "documented"
+	// CheckExamples requires an example of the request or response of
+	// operations that have parameters, a body or response content
+	CheckExamples = "examples"
+	// CheckResponseSchemas requires a success response, with a schema for
+	// the content of every success response
+	CheckResponseSchemas = "response_schemas"
+	// CheckOperationID requires an operationId no other operation uses
+	CheckOperationID = "unique_operation_id"
+	// CheckSecurity requires security requirements, from the operation or
+	// the root, or x-glens-auth
+	CheckSecurity = "security"
+)
+
+// qualityChecks are the checks in report order, with their descriptions
+var qualityChecks = []struct{ name, description string }{
+	{CheckDocumented, "Summary or description"},
+	{CheckExamples, "Request or response examples"},
+	{CheckResponseSchemas, "Success response schemas"},
+	{CheckOperationID, "Unique operationId"},
+	{CheckSecurity, "Security declared"},
+}
+
+// SpecQuality scores how well a specification documents its operations.
+// Generated tests are only as good as the spec they are generated from, so
+// its gaps point at the operations whose tests need the most help.
+type SpecQuality struct {
+	// Score is the average of the checks' percentages, from 0 to 100
+	Score float64 `json:"score"`
+	// Operations is the number of operations assessed
+	Operations int            `json:"operations"`
+	Checks     []QualityCheck `json:"checks"`
+	// Gaps lists the operations failing a check, in spec order
+	Gaps []QualityGap `json:"gaps,omitempty"`
+}
+
+// QualityCheck is how many operations pass a check
+type QualityCheck struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Passed      int     `json:"passed"`
+	Percentage  float64 `json:"percentage"`
+}
+
+// QualityGap is what an operation is missing, one suggestion per failed
+// check
+type QualityGap struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	OperationID string   `json:"operation_id,omitempty"`
+	Checks      []string `json:"checks"`
+	Suggestions []string `json:"suggestions"`
+	// Position is where the operation is declared in the spec file
+	Position *Position `json:"position,omitempty"`
+}
+
+// AssessQuality scores the OpenAPI operations of spec: path operations,
+// webhooks and callbacks. It returns nil for specs without any, such as
+// GraphQL and gRPC schemas, whose endpoints glens derives itself.
+func AssessQuality(spec *OpenAPISpec) *SpecQuality {
+	var operations []*Endpoint
+	ids := make(map[string][]*Endpoint)
+	for i := range spec.Endpoints {
+		e := &spec.Endpoints[i]
+		if e.Kind != "" && !e.Incoming() {
+			continue
+		}
+		operations = append(operations, e)
+		if e.OperationID != "" {
+			ids[e.OperationID] = append(ids[e.OperationID], e)
+		}
+	}
+	if len(operations) == 0 {
+		return nil
+	}
+
+	quality := &SpecQuality{Operations: len(operations)}
+	passed := make(map[string]int, len(qualityChecks))
+	for _, e := range operations {
+		gap := QualityGap{Method: e.Method, Path: e.Path, OperationID: e.OperationID, Position: e.Position}
+		fail := func(check, suggestion string) {
+			gap.Checks = append(gap.Checks, check)
+			gap.Suggestions = append(gap.Suggestions, suggestion)
+		}
+
+		if e.Summary != "" || e.Description != "" {
+			passed[CheckDocumented]++
+		} else {
+			fail(CheckDocumented, "add a summary or description")
+		}
+
+		if !needsExamples(e) || hasExamples(e) {
+			passed[CheckExamples]++
+		} else {
+			fail(CheckExamples, "add an example of the request body, parameters or a response")
+		}
+
+		if missing := missingResponseSchema(e); missing == "" {
+			passed[CheckResponseSchemas]++
+		} else {
+			fail(CheckResponseSchemas, missing)
+		}
+
+		switch others := ids[e.OperationID]; {
+		case e.OperationID == "":
+			fail(CheckOperationID, "add an operationId")
+		case len(others) > 1:
+			var also []string
+			for _, other := range others {
+				if other != e {
+					also = append(also, other.Method+" "+other.Path)
+				}
+			}
+			fail(CheckOperationID, fmt.Sprintf("rename operationId %s, also used by %s", e.OperationID, strings.Join(also, ", ")))
+		default:
+			passed[CheckOperationID]++
+		}
+
+		if e.Security != nil || e.Auth() != "" {
+			passed[CheckSecurity]++
+		} else {
+			fail(CheckSecurity, "declare security requirements, or security: [] for public operations")
+		}
+
+		if len(gap.Checks) > 0 {
+			quality.Gaps = append(quality.Gaps, gap)
+		}
+	}
+
+	total := 0.0
+	for _, check := range qualityChecks {
+		percentage := float64(passed[check.name]) / float64(len(operations)) * 100
+		quality.Checks = append(quality.Checks, QualityCheck{
+			Name:        check.name,
+			Description: check.description,
+			Passed:      passed[check.name],
+			Percentage:  percentage,
+		})
+		total += percentage
+	}
+	quality.Score = total / float64(len(qualityChecks))
+	return quality
+}
+
+// needsExamples reports whether an operation sends or receives values an
+// example could illustrate
+func needsExamples(e *Endpoint) bool {
+	if len(e.Parameters) > 0 || (e.RequestBody != nil && len(e.RequestBody.Content) > 0) {
+		return true
+	}
+	for _, response := range e.Responses {
+		if len(response.Content) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExamples reports whether a parameter, the request body or a response
+// of an operation has an example, in its media type or its schema
+func hasExamples(e *Endpoint) bool {
+	for i := range e.Parameters {
+		if e.Parameters[i].Example != nil || e.Parameters[i].Schema.Example != nil {
+			return true
+		}
+	}
+	if e.RequestBody != nil && contentHasExample(e.RequestBody.Content) {
+		return true
+	}
+	for _, response := range e.Responses {
+		if contentHasExample(response.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentHasExample reports whether a media type has an example
+func contentHasExample(content map[string]MediaType) bool {
+	for _, media := range content {
+		if media.Example != nil || len(media.Examples) > 0 || media.Schema.Example != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// missingResponseSchema returns what an operation's success responses lack:
+// a 2xx response at all, or a schema for the content of one of them; empty
+// when nothing is missing. A 2xx response without content needs no schema.
+func missingResponseSchema(e *Endpoint) string {
+	found := false
+	for _, code := range slices.Sorted(maps.Keys(e.Responses)) {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		found = true
+		content := e.Responses[code].Content
+		for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+			if media := content[mediaType]; media.Schema.Describe() == "" && len(media.Schema.Properties) == 0 && len(media.Schema.AllOf) == 0 {
+				return fmt.Sprintf("define a schema for the %s %s response", code, mediaType)
+			}
+		}
+	}
+	if !found {
+		return "document a success (2xx) response"
+	}
+	return ""
+}
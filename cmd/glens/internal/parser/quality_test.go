@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const qualitySpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+security:
+  - bearer: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - name: limit
+          in: query
+          example: 10
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+    post:
+      operationId: listPets
+      security: []
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json: {}
+  /health:
+    get:
+      description: Liveness probe
+      security: []
+      responses:
+        '204':
+          description: Healthy
+  /pets/{id}:
+    delete:
+      operationId: deletePet
+      summary: Delete a pet
+      x-glens-auth: none
+      responses:
+        '404':
+          description: Not found
+`
+
+func TestAssessQuality(t *testing.T) {
+	spec, err := writeSpec(t, "spec.yaml", []byte(qualitySpec))
+	require.NoError(t, err)
+
+	list, _ := spec.FindEndpoint("GET /pets")
+	assert.Equal(t, []SecurityRequirement{{"bearer": {}}}, list.Security, "root security applies")
+	create, _ := spec.FindEndpoint("POST /pets")
+	assert.NotNil(t, create.Security, "security: [] declares a public operation")
+	assert.Empty(t, create.Security)
+
+	quality := AssessQuality(spec)
+	require.NotNil(t, quality)
+	assert.Equal(t, 4, quality.Operations)
+	passed := make(map[string]int)
+	for _, check := range quality.Checks {
+		passed[check.Name] = check.Passed
+	}
+	assert.Equal(t, map[string]int{
+		CheckDocumented:      3,
+		CheckExamples:        3,
+		CheckResponseSchemas: 2,
+		CheckOperationID:     1,
+		CheckSecurity:        4,
+	}, passed)
+	// (75 + 75 + 50 + 25 + 100) / 5
+	assert.InDelta(t, 65.0, quality.Score, 1e-9)
+
+	gaps := make(map[string][]string)
+	for _, gap := range quality.Gaps {
+		gaps[gap.Method+" "+gap.Path] = gap.Suggestions
+	}
+	assert.Len(t, gaps, 4)
+	assert.Equal(t, []string{"rename operationId listPets, also used by POST /pets"}, gaps["GET /pets"])
+	assert.Equal(t, []string{
+		"add a summary or description",
+		"add an example of the request body, parameters or a response",
+		"define a schema for the 201 application/json response",
+		"rename operationId listPets, also used by GET /pets",
+	}, gaps["POST /pets"])
+	assert.Equal(t, []string{"add an operationId"}, gaps["GET /health"], "operations without values need no examples")
+	assert.Equal(t, []string{"document a success (2xx) response"}, gaps["DELETE /pets/{id}"])
+}
+
+func TestAssessQuality_DerivedEndpoints(t *testing.T) {
+	spec := &OpenAPISpec{Endpoints: []Endpoint{
+		{ID: "QUERY_pets", Kind: KindQuery, Method: "POST", Path: GraphQLPath},
+		{ID: "SCENARIO_adopt", Kind: KindScenario},
+	}}
+	assert.Nil(t, AssessQuality(spec), "GraphQL operations and scenarios are not spec operations")
+}
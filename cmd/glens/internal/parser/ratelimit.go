@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// RateLimit is the rate limiting an endpoint documents in its responses
+type RateLimit struct {
+	// Headers are the rate-limit headers of its 2xx responses, such as
+	// X-RateLimit-Remaining
+	Headers []string `json:"headers,omitempty"`
+	// Throttled is set when a 429 Too Many Requests response is documented
+	Throttled bool `json:"throttled,omitempty"`
+	// ThrottledHeaders are the rate-limit headers of the 429 response, such
+	// as Retry-After
+	ThrottledHeaders []string `json:"throttled_headers,omitempty"`
+}
+
+// IsRateLimitHeader reports whether name is a rate-limit header:
+// Retry-After, X-RateLimit-*, X-Rate-Limit-* or the IETF RateLimit and
+// RateLimit-* headers
+func IsRateLimitHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "retry-after" ||
+		strings.HasPrefix(name, "x-ratelimit") ||
+		strings.HasPrefix(name, "x-rate-limit") ||
+		strings.HasPrefix(name, "ratelimit")
+}
+
+// RateLimit returns the endpoint's documented rate limiting, nil when its
+// responses declare neither rate-limit headers nor a 429 response
+func (e *Endpoint) RateLimit() *RateLimit {
+	var limit RateLimit
+	for code, response := range e.Responses {
+		switch {
+		case code == "429":
+			limit.Throttled = true
+			limit.ThrottledHeaders = rateLimitHeaders(response)
+		case strings.HasPrefix(code, "2"):
+			for _, name := range rateLimitHeaders(response) {
+				if !containsFold(limit.Headers, name) {
+					limit.Headers = append(limit.Headers, name)
+				}
+			}
+		}
+	}
+	if len(limit.Headers) == 0 && !limit.Throttled {
+		return nil
+	}
+	sort.Strings(limit.Headers)
+	return &limit
+}
+
+// rateLimitHeaders returns the names of the rate-limit headers of
+// response, sorted
+func rateLimitHeaders(response Response) []string {
+	var names []string
+	for _, name := range response.HeaderNames() {
+		if IsRateLimitHeader(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// containsFold reports whether names contains name, ignoring case
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rateLimitSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+components:
+  headers:
+    Remaining:
+      description: Requests left in the window
+      required: true
+      schema:
+        type: integer
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: Pets
+          headers:
+            X-RateLimit-Limit:
+              schema:
+                type: integer
+            X-RateLimit-Remaining:
+              $ref: '#/components/headers/Remaining'
+            ETag:
+              schema:
+                type: string
+        '429':
+          description: Too many requests
+          headers:
+            Retry-After:
+              schema:
+                type: integer
+  /health:
+    get:
+      responses:
+        '200':
+          description: OK
+`
+
+func TestParseOpenAPISpec_ResponseHeaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(rateLimitSpec), 0o600))
+
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	pets, ok := spec.FindEndpoint("GET /pets")
+	require.True(t, ok)
+
+	ok200 := pets.Responses["200"]
+	assert.Equal(t, []string{"ETag", "X-RateLimit-Limit", "X-RateLimit-Remaining"}, ok200.HeaderNames())
+	assert.Equal(t, Header{
+		Description: "Requests left in the window", Required: true, Schema: Schema{Type: "integer"},
+	}, ok200.Headers["X-RateLimit-Remaining"], "header refs are resolved")
+
+	assert.Equal(t, []string{"ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"}, pets.ResponseHeaders())
+	assert.Equal(t, &RateLimit{
+		Headers:          []string{"X-RateLimit-Limit", "X-RateLimit-Remaining"},
+		Throttled:        true,
+		ThrottledHeaders: []string{"Retry-After"},
+	}, pets.RateLimit())
+
+	health, ok := spec.FindEndpoint("GET /health")
+	require.True(t, ok)
+	assert.Nil(t, health.RateLimit())
+}
+
+func TestIsRateLimitHeader(t *testing.T) {
+	for _, name := range []string{"Retry-After", "X-RateLimit-Reset", "x-rate-limit-limit", "RateLimit", "RateLimit-Policy"} {
+		assert.True(t, IsRateLimitHeader(name), name)
+	}
+	for _, name := range []string{"ETag", "X-Request-ID", "Link"} {
+		assert.False(t, IsRateLimitHeader(name), name)
+	}
+}
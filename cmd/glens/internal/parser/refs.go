@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxRefDepth bounds how deeply nested references are inlined
+const maxRefDepth = 32
+
+// refResolver replaces the local references of the nodes of one document,
+// such as #/components/schemas/Pet, by their resolved targets. A target
+// keeps its "$ref" so the schema's name is not lost. Recursive references
+// and references nested deeper than maxRefDepth are left as they are.
+// Resolved nodes share the parts without references with the original,
+// and each target is resolved once and shared by all the references to it,
+// unless its resolution stopped at a recursive reference and so depends on
+// where it is referenced: heavily referenced specs resolve in memory
+// proportional to their size rather than to the number of references.
+// Resolved nodes must not be modified.
+type refResolver struct {
+	root     map[string]interface{}
+	resolved map[string]map[string]interface{}
+}
+
+func newRefResolver(root map[string]interface{}) *refResolver {
+	return &refResolver{root: root, resolved: make(map[string]map[string]interface{})}
+}
+
+// resolve returns node with its references resolved, whether that changed
+// node, and whether no reference was left unresolved for being recursive
+// or too deep
+func (r *refResolver) resolve(node interface{}, stack []string) (value interface{}, changed, complete bool) {
+	complete = true
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			if len(stack) >= maxRefDepth || slices.Contains(stack, ref) {
+				complete = false
+			} else if target, targetComplete, ok := r.target(ref, stack); ok {
+				if len(v) == 1 {
+					return target, true, targetComplete
+				}
+				resolved := maps.Clone(target)
+				for key, value := range v {
+					if key != "$ref" {
+						value, _, valueComplete := r.resolve(value, stack)
+						resolved[key] = value
+						targetComplete = targetComplete && valueComplete
+					}
+				}
+				return resolved, true, targetComplete
+			}
+		}
+		var out map[string]interface{}
+		for key, value := range v {
+			resolved, valueChanged, valueComplete := r.resolve(value, stack)
+			complete = complete && valueComplete
+			if valueChanged {
+				if out == nil {
+					out = maps.Clone(v)
+				}
+				out[key] = resolved
+			}
+		}
+		if out == nil {
+			return v, false, complete
+		}
+		return out, true, complete
+	case []interface{}:
+		var out []interface{}
+		for i, value := range v {
+			resolved, valueChanged, valueComplete := r.resolve(value, stack)
+			complete = complete && valueComplete
+			if valueChanged {
+				if out == nil {
+					out = slices.Clone(v)
+				}
+				out[i] = resolved
+			}
+		}
+		if out == nil {
+			return v, false, complete
+		}
+		return out, true, complete
+	default:
+		return node, false, true
+	}
+}
+
+// target returns the resolved target of ref, referenced with stack, and
+// whether it is complete; ok is false when ref does not point to an object
+func (r *refResolver) target(ref string, stack []string) (target map[string]interface{}, complete, ok bool) {
+	if target, ok := r.resolved[ref]; ok {
+		return target, true, true
+	}
+	raw, ok := lookupPointer(r.root, ref).(map[string]interface{})
+	if !ok {
+		return nil, true, false
+	}
+	value, _, complete := r.resolve(raw, append(stack, ref))
+	target = maps.Clone(value.(map[string]interface{}))
+	target["$ref"] = ref
+	if complete {
+		r.resolved[ref] = target
+	}
+	return target, complete, true
+}
+
+// lookupPointer returns the value a local reference (#/a/b) points to, or
+// nil when it is not local or does not exist
+func lookupPointer(root map[string]interface{}, ref string) interface{} {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var node interface{} = root
+	for _, token := range strings.Split(pointer, "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := node.(type) {
+		case map[string]interface{}:
+			node = v[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			node = v[i]
+		default:
+			return nil
+		}
+	}
+	return node
+}
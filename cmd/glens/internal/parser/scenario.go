@@ -0,0 +1,309 @@
+package parser
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioStep is one request of a scenario
+type ScenarioStep struct {
+	// Name identifies the step in the generated test, e.g. "create"
+	Name string `json:"name"`
+	// Endpoint is the operation the step calls; its path parameters take
+	// the values saved by earlier steps under the same name
+	Endpoint Endpoint `json:"endpoint"`
+	// Save maps variable names to the dotted response fields whose values
+	// later steps use, e.g. petId: id
+	Save map[string]string `json:"save,omitempty"`
+	// Expect is the status code the step must answer with
+	Expect int `json:"expect"`
+}
+
+// Verbs that start the operation IDs of a resource's lifecycle, e.g.
+// createPet, getPetById, updatePet and deletePet
+var (
+	createVerbs = []string{"create", "add", "new", "post"}
+	readVerbs   = []string{"get", "read", "fetch", "retrieve", "show", "find"}
+	updateVerbs = []string{"update", "replace", "patch", "edit", "modify"}
+	deleteVerbs = []string{"delete", "remove", "destroy"}
+)
+
+// methodRisk orders methods from read-only to destructive, so a scenario
+// is categorised by its riskiest step
+var methodRisk = []string{"GET", "HEAD", "OPTIONS", "POST", "PATCH", "PUT", "DELETE"}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// newScenario returns the endpoint testing steps in order. Its Path is the
+// scenario's name and its Method that of the riskiest step.
+func newScenario(name, summary, description string, steps []ScenarioStep) Endpoint {
+	scenario := Endpoint{
+		ID:          "SCENARIO_" + slug(name),
+		Kind:        KindScenario,
+		Path:        name,
+		Summary:     summary,
+		Description: description,
+		Responses:   make(map[string]Response),
+		Steps:       steps,
+	}
+	for _, step := range steps {
+		if slices.Index(methodRisk, step.Endpoint.Method) > slices.Index(methodRisk, scenario.Method) {
+			scenario.Method = step.Endpoint.Method
+		}
+		for _, tag := range step.Endpoint.Tags {
+			if !slices.Contains(scenario.Tags, tag) {
+				scenario.Tags = append(scenario.Tags, tag)
+			}
+		}
+	}
+	return scenario
+}
+
+// InferScenarios infers a create, read, update and delete scenario for
+// each resource whose collection path has a POST operation. The other
+// steps are the GET, PUT or PATCH and DELETE operations of its item path,
+// the collection path followed by a path parameter, or else the operations
+// whose IDs name the same resource as the create operation (createPet,
+// getPetById, deletePet). Resources without a GET or DELETE are skipped.
+func InferScenarios(spec *OpenAPISpec) []Endpoint {
+	var scenarios []Endpoint
+	for i := range spec.Endpoints {
+		create := &spec.Endpoints[i]
+		if create.Kind != "" || create.Method != "POST" {
+			continue
+		}
+		item := itemOperations(spec, create)
+		if item["GET"] == nil && item["DELETE"] == nil {
+			continue
+		}
+
+		var steps []ScenarioStep
+		addStep := func(name string, endpoint *Endpoint, expect int) {
+			steps = append(steps, ScenarioStep{Name: name, Endpoint: *endpoint, Expect: expect})
+		}
+		addStep("create", create, successCode(create, 201))
+		if get := item["GET"]; get != nil {
+			addStep("read", get, successCode(get, 200))
+		}
+		if update := cmp.Or(item["PUT"], item["PATCH"]); update != nil {
+			addStep("update", update, successCode(update, 200))
+		}
+		if remove := item["DELETE"]; remove != nil {
+			addStep("delete", remove, successCode(remove, 204))
+			if get := item["GET"]; get != nil {
+				addStep("read_deleted", get, 404)
+			}
+		}
+
+		// The created resource's ID fills the item path parameters
+		schema, _ := create.successSchema()
+		steps[0].Save = make(map[string]string)
+		for _, step := range steps[1:] {
+			if param := lastPathParam(step.Endpoint.Path); param != "" {
+				steps[0].Save[param] = idField(schema, param)
+			}
+		}
+
+		names := make([]string, len(steps))
+		for i, step := range steps {
+			names[i] = step.Name
+		}
+		scenarios = append(scenarios, newScenario(
+			slug(create.Path)+"_lifecycle",
+			fmt.Sprintf("Lifecycle of a resource of %s: %s", create.Path, strings.Join(names, ", ")),
+			"", steps))
+	}
+	sort.Slice(scenarios, func(i, j int) bool { return scenarios[i].Path < scenarios[j].Path })
+	return scenarios
+}
+
+// itemOperations returns the operations of the resources created by
+// create, by method: those of the item path below its collection path, or
+// else those whose operation IDs name the same resource
+func itemOperations(spec *OpenAPISpec, create *Endpoint) map[string]*Endpoint {
+	byPath := make(map[string]*Endpoint)
+	byID := make(map[string]*Endpoint)
+	noun := operationNoun(create.OperationID, createVerbs)
+	for i := range spec.Endpoints {
+		e := &spec.Endpoints[i]
+		if e.Kind != "" || lastPathParam(e.Path) == "" {
+			continue
+		}
+		if parent, ok := itemParent(e.Path); ok && parent == create.Path {
+			if _, seen := byPath[e.Method]; !seen {
+				byPath[e.Method] = e
+			}
+			continue
+		}
+		if noun == "" {
+			continue
+		}
+		verbs := map[string][]string{"GET": readVerbs, "PUT": updateVerbs, "PATCH": updateVerbs, "DELETE": deleteVerbs}[e.Method]
+		if n := operationNoun(e.OperationID, verbs); n != "" && strings.HasPrefix(strings.ToLower(n), strings.ToLower(noun)) {
+			if _, seen := byID[e.Method]; !seen {
+				byID[e.Method] = e
+			}
+		}
+	}
+	if len(byPath) > 0 {
+		return byPath
+	}
+	return byID
+}
+
+// operationNoun returns what an operation ID such as createPet or
+// create_pet names after one of verbs, or "" when it starts with none
+func operationNoun(operationID string, verbs []string) string {
+	for _, verb := range verbs {
+		if len(operationID) <= len(verb) || !strings.EqualFold(operationID[:len(verb)], verb) {
+			continue
+		}
+		rest := operationID[len(verb):]
+		if rest[0] == '_' || rest[0] == '-' {
+			return strings.TrimLeft(rest, "_-")
+		}
+		if rest[0] >= 'A' && rest[0] <= 'Z' {
+			return rest
+		}
+	}
+	return ""
+}
+
+// itemParent returns the parent of a path whose last segment is a path
+// parameter, e.g. /pets for /pets/{petId}
+func itemParent(path string) (string, bool) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "", false
+	}
+	segment := path[i+1:]
+	if len(segment) < 3 || segment[0] != '{' || strings.IndexByte(segment, '}') != len(segment)-1 {
+		return "", false
+	}
+	return path[:i], true
+}
+
+// lastPathParam returns the name of the last path parameter of path
+func lastPathParam(path string) string {
+	matches := pathParamPattern.FindAllStringSubmatch(path, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// idField returns the field of a created resource holding the value of
+// the path parameter param: a property of that name, or else "id"
+func idField(schema Schema, param string) string {
+	if _, ok := schema.Properties[param]; ok {
+		return param
+	}
+	return "id"
+}
+
+// successCode returns the endpoint's lowest documented 2xx status code, or
+// fallback when it documents none
+func successCode(e *Endpoint, fallback int) int {
+	lowest := 0
+	for code := range e.Responses {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 200 || status > 299 {
+			continue
+		}
+		if lowest == 0 || status < lowest {
+			lowest = status
+		}
+	}
+	if lowest == 0 {
+		return fallback
+	}
+	return lowest
+}
+
+// slug turns a name or path into an identifier of lowercase letters,
+// digits and underscores, e.g. "/users/{id}/pets" into "users_id_pets"
+func slug(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	return strings.Join(words, "_")
+}
+
+// scenarioFile is the YAML format of explicit scenarios:
+//
+//	scenarios:
+//	  - name: adopt_pet
+//	    description: A pet is listed, adopted and removed
+//	    steps:
+//	      - operation: createPet      # operation ID, endpoint ID or "METHOD /path"
+//	        save: {petId: id}         # variable: dotted response field
+//	      - operation: POST /pets/{petId}/adopt
+//	        expect: 202               # default: the first documented 2xx
+//	      - name: cleanup
+//	        operation: deletePet
+type scenarioFile struct {
+	Scenarios []struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Steps       []struct {
+			Name      string            `yaml:"name"`
+			Operation string            `yaml:"operation"`
+			Save      map[string]string `yaml:"save"`
+			Expect    int               `yaml:"expect"`
+		} `yaml:"steps"`
+	} `yaml:"scenarios"`
+}
+
+// LoadScenarios reads the scenarios of a YAML file whose steps name
+// operations of spec
+func LoadScenarios(path string, spec *OpenAPISpec) ([]Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenarios file: %w", err)
+	}
+	var file scenarioFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse scenarios file %s: %w", path, err)
+	}
+
+	scenarios := make([]Endpoint, 0, len(file.Scenarios))
+	seen := make(map[string]bool)
+	for i, s := range file.Scenarios {
+		if s.Name == "" {
+			return nil, fmt.Errorf("scenario %d in %s has no name", i+1, path)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("duplicate scenario %q in %s", s.Name, path)
+		}
+		seen[s.Name] = true
+		if len(s.Steps) == 0 {
+			return nil, fmt.Errorf("scenario %q has no steps", s.Name)
+		}
+
+		steps := make([]ScenarioStep, 0, len(s.Steps))
+		for j, st := range s.Steps {
+			endpoint, ok := spec.FindEndpoint(st.Operation)
+			if !ok {
+				return nil, fmt.Errorf("scenario %q step %d: operation %q not found in the spec", s.Name, j+1, st.Operation)
+			}
+			step := ScenarioStep{Name: st.Name, Endpoint: *endpoint, Save: st.Save, Expect: st.Expect}
+			if step.Name == "" {
+				step.Name = cmp.Or(endpoint.OperationID, fmt.Sprintf("step_%d", j+1))
+			}
+			if step.Expect == 0 {
+				step.Expect = successCode(endpoint, 200)
+			}
+			steps = append(steps, step)
+		}
+		scenarios = append(scenarios, newScenario(s.Name, "", s.Description, steps))
+	}
+	return scenarios, nil
+}
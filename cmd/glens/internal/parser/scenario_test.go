@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scenarioSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      tags: [pets]
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  petId:
+                    type: string
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      tags: [pets]
+      responses:
+        '200':
+          description: Pet
+    patch:
+      operationId: updatePet
+      responses:
+        '200':
+          description: Updated
+    delete:
+      operationId: deletePet
+      tags: [admin]
+      responses:
+        '204':
+          description: Deleted
+  /orders:
+    post:
+      operationId: addOrder
+      responses:
+        '200':
+          description: Placed
+  /order-details/{orderId}:
+    get:
+      operationId: getOrderById
+      responses:
+        '200':
+          description: Order
+  /search:
+    post:
+      operationId: search
+      responses:
+        '200':
+          description: Results
+`
+
+// parseScenarioSpec parses scenarioSpec
+func parseScenarioSpec(t *testing.T) *OpenAPISpec {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(scenarioSpec), 0o600))
+	spec, err := ParseOpenAPISpec(path)
+	require.NoError(t, err)
+	return spec
+}
+
+// stepSummaries returns the name, operation, expected status and saved
+// variables of each step
+func stepSummaries(steps []ScenarioStep) [][]any {
+	var summaries [][]any
+	for _, step := range steps {
+		summaries = append(summaries, []any{step.Name, step.Endpoint.OperationID, step.Expect, step.Save})
+	}
+	return summaries
+}
+
+func TestInferScenarios(t *testing.T) {
+	scenarios := InferScenarios(parseScenarioSpec(t))
+	require.Len(t, scenarios, 2, "POST /search has no item operations")
+
+	orders := scenarios[0]
+	assert.Equal(t, "SCENARIO_orders_lifecycle", orders.ID)
+	assert.Equal(t, "orders_lifecycle", orders.Path)
+	assert.Equal(t, KindScenario, orders.Kind)
+	assert.Equal(t, "POST", orders.Method)
+	assert.Equal(t, [][]any{
+		{"create", "addOrder", 200, map[string]string{"orderId": "id"}},
+		{"read", "getOrderById", 200, map[string]string(nil)},
+	}, stepSummaries(orders.Steps), "operation IDs name the resource when paths do not")
+
+	pets := scenarios[1]
+	assert.Equal(t, "DELETE", pets.Method, "a scenario is as risky as its riskiest step")
+	assert.Equal(t, []string{"pets", "admin"}, pets.Tags)
+	assert.Equal(t, "Lifecycle of a resource of /pets: create, read, update, delete, read_deleted", pets.Summary)
+	assert.Equal(t, [][]any{
+		{"create", "createPet", 201, map[string]string{"petId": "petId"}},
+		{"read", "getPet", 200, map[string]string(nil)},
+		{"update", "updatePet", 200, map[string]string(nil)},
+		{"delete", "deletePet", 204, map[string]string(nil)},
+		{"read_deleted", "getPet", 404, map[string]string(nil)},
+	}, stepSummaries(pets.Steps))
+}
+
+func TestLoadScenarios(t *testing.T) {
+	spec := parseScenarioSpec(t)
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "scenarios.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	scenarios, err := LoadScenarios(write(`
+scenarios:
+  - name: Rename pet
+    description: A pet is created and renamed
+    steps:
+      - operation: createPet
+        save: {petId: petId}
+      - name: rename
+        operation: PATCH /pets/{petId}
+        expect: 202
+`), spec)
+	require.NoError(t, err)
+	require.Len(t, scenarios, 1)
+	assert.Equal(t, "SCENARIO_rename_pet", scenarios[0].ID)
+	assert.Equal(t, "Rename pet", scenarios[0].Path)
+	assert.Equal(t, "A pet is created and renamed", scenarios[0].Description)
+	assert.Equal(t, "PATCH", scenarios[0].Method)
+	assert.Equal(t, [][]any{
+		{"createPet", "createPet", 201, map[string]string{"petId": "petId"}},
+		{"rename", "updatePet", 202, map[string]string(nil)},
+	}, stepSummaries(scenarios[0].Steps))
+
+	_, err = LoadScenarios(write("scenarios:\n  - name: broken\n    steps:\n      - operation: adoptPet\n"), spec)
+	assert.EqualError(t, err, `scenario "broken" step 1: operation "adoptPet" not found in the spec`)
+	_, err = LoadScenarios(write("scenarios:\n  - name: empty\n"), spec)
+	assert.EqualError(t, err, `scenario "empty" has no steps`)
+	_, err = LoadScenarios(filepath.Join(t.TempDir(), "missing.yaml"), spec)
+	assert.ErrorContains(t, err, "failed to read scenarios file")
+}
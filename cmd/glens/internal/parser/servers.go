@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// serverVariablePattern matches the {name} placeholders of a server URL
+var serverVariablePattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// ResolveURL substitutes the server's variables into its URL. A variable
+// takes its value from values, falling back to its default; values outside
+// a variable's enum are rejected.
+func (s Server) ResolveURL(values map[string]string) (string, error) {
+	var errs []string
+	resolved := serverVariablePattern.ReplaceAllStringFunc(s.URL, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		variable, declared := s.Variables[name]
+		value, ok := values[name]
+		if !ok {
+			value = variable.Default
+		}
+		switch {
+		case !declared && !ok:
+			errs = append(errs, fmt.Sprintf("variable %q is not declared", name))
+		case value == "":
+			errs = append(errs, fmt.Sprintf("variable %q has no default; set a value", name))
+		case len(variable.Enum) > 0 && !slices.Contains(variable.Enum, value):
+			errs = append(errs, fmt.Sprintf("variable %q must be one of %s, not %q", name, strings.Join(variable.Enum, ", "), value))
+		}
+		return value
+	})
+	if len(errs) > 0 {
+		return "", fmt.Errorf("cannot resolve server %s: %s", s.URL, strings.Join(errs, "; "))
+	}
+	return resolved, nil
+}
+
+// Server returns the server selected by ref: its index in the servers
+// list, or its description or URL (case-insensitive)
+func (spec *OpenAPISpec) Server(ref string) (*Server, error) {
+	if len(spec.Servers) == 0 {
+		return nil, fmt.Errorf("the specification declares no servers")
+	}
+	if index, err := strconv.Atoi(ref); err == nil {
+		if index < 0 || index >= len(spec.Servers) {
+			return nil, fmt.Errorf("server index %d out of range: the specification declares %d servers", index, len(spec.Servers))
+		}
+		return &spec.Servers[index], nil
+	}
+
+	available := make([]string, len(spec.Servers))
+	for i, server := range spec.Servers {
+		if strings.EqualFold(server.Description, ref) || strings.EqualFold(server.URL, ref) {
+			return &spec.Servers[i], nil
+		}
+		available[i] = fmt.Sprintf("%d: %s", i, server.URL)
+		if server.Description != "" {
+			available[i] += " (" + server.Description + ")"
+		}
+	}
+	return nil, fmt.Errorf("server %q not found. Available servers: %s", ref, strings.Join(available, ", "))
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ResolveURL(t *testing.T) {
+	server := Server{
+		URL: "https://{region}.api.example.com/{version}",
+		Variables: map[string]ServerVariable{
+			"region":  {Default: "eu", Enum: []string{"eu", "us"}},
+			"version": {Default: "v1"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    string
+		wantErr string
+	}{
+		{"defaults", nil, "https://eu.api.example.com/v1", ""},
+		{"override", map[string]string{"region": "us", "version": "v2"}, "https://us.api.example.com/v2", ""},
+		{"outside enum", map[string]string{"region": "ap"}, "", `variable "region" must be one of eu, us, not "ap"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.ResolveURL(tt.values)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Server{URL: "https://{tenant}.example.com"}.ResolveURL(nil)
+	assert.ErrorContains(t, err, `variable "tenant" is not declared`)
+	_, err = Server{URL: "https://{tenant}.example.com", Variables: map[string]ServerVariable{"tenant": {}}}.ResolveURL(nil)
+	assert.ErrorContains(t, err, `variable "tenant" has no default`)
+}
+
+func TestOpenAPISpec_Server(t *testing.T) {
+	spec := &OpenAPISpec{Servers: []Server{
+		{URL: "https://api.example.com", Description: "Production"},
+		{URL: "https://staging.example.com", Description: "Staging"},
+	}}
+
+	server, err := spec.Server("1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://staging.example.com", server.URL)
+
+	server, err = spec.Server("production")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com", server.URL)
+
+	_, err = spec.Server("2")
+	assert.EqualError(t, err, "server index 2 out of range: the specification declares 2 servers")
+	_, err = spec.Server("dev")
+	assert.EqualError(t, err, `server "dev" not found. Available servers: 0: https://api.example.com (Production), 1: https://staging.example.com (Staging)`)
+	_, err = (&OpenAPISpec{}).Server("0")
+	assert.EqualError(t, err, "the specification declares no servers")
+}
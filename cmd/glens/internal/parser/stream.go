@@ -0,0 +1,275 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLowMemoryAboveMB is the spec size above which OpenAPI specs are
+// parsed in the low-memory mode by default
+const DefaultLowMemoryAboveMB = 20
+
+// ParseConfig is the spec_parse section of the config: how OpenAPI specs
+// are parsed.
+// The low-memory mode converts the paths and webhooks of a spec one path
+// item at a time instead of as a whole document tree, reading JSON specs
+// with a streaming decoder. Specs parsed in this mode have no positions or
+// warnings, and references into paths or webhooks are left unresolved.
+type ParseConfig struct {
+	// LowMemory parses every OpenAPI spec in the low-memory mode
+	LowMemory bool `mapstructure:"low_memory"`
+	// LowMemoryAboveMB parses specs larger than this in the low-memory
+	// mode (default 20); negative only does with LowMemory
+	LowMemoryAboveMB int64 `mapstructure:"low_memory_above_mb"`
+}
+
+// lowMemory reports whether a spec of size bytes is parsed in the
+// low-memory mode
+func (c ParseConfig) lowMemory(size int) bool {
+	switch {
+	case c.LowMemory:
+		return true
+	case c.LowMemoryAboveMB < 0:
+		return false
+	case c.LowMemoryAboveMB == 0:
+		return int64(size) > DefaultLowMemoryAboveMB<<20
+	default:
+		return int64(size) > c.LowMemoryAboveMB<<20
+	}
+}
+
+var (
+	parseMu     sync.RWMutex
+	parseConfig ParseConfig
+)
+
+// SetParseConfig makes cfg the process-wide settings of spec parsing
+func SetParseConfig(cfg ParseConfig) {
+	parseMu.Lock()
+	defer parseMu.Unlock()
+	parseConfig = cfg
+}
+
+// currentParseConfig returns the process-wide settings of spec parsing
+func currentParseConfig() ParseConfig {
+	parseMu.RLock()
+	defer parseMu.RUnlock()
+	return parseConfig
+}
+
+// errNotMapping rejects documents that are not a mapping of sections
+var errNotMapping = errors.New("document is not a mapping")
+
+// streamedSections are the paths and webhooks of a document parsed in the
+// low-memory mode
+type streamedSections struct {
+	paths, webhooks []streamedPathItem
+}
+
+// streamedPathItem is a path item converted on demand
+type streamedPathItem struct {
+	name  string
+	value func() (interface{}, error)
+}
+
+// parseLowMemory parses an OpenAPI document in the low-memory mode
+func parseLowMemory(source string, data []byte) (*OpenAPISpec, error) {
+	var rawSpec map[string]interface{}
+	var sections *streamedSections
+	var err error
+	if isYAML(source, data) {
+		if rawSpec, sections, err = streamYAML(data); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	} else {
+		if rawSpec, sections, err = streamJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+	}
+
+	spec, err := convertToSpec(rawSpec, sections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert to internal format: %w", err)
+	}
+	return spec, nil
+}
+
+// streamYAML returns the sections of a YAML document but its paths and
+// webhooks, whose path items are converted on demand. The YAML library
+// holds the node tree of the whole document, but not its conversion.
+func streamYAML(data []byte) (map[string]interface{}, *streamedSections, error) {
+	document, err := yamlDocument(data)
+	if err != nil || document == nil {
+		return nil, nil, err
+	}
+	if resolveAlias(document).Kind != yaml.MappingNode {
+		return nil, nil, errNotMapping
+	}
+
+	converter := newYAMLConverter()
+	rawSpec := make(map[string]interface{})
+	sections := &streamedSections{}
+	eachPair(document, func(key, value *yaml.Node) {
+		if err != nil {
+			return
+		}
+		if key.Value != "paths" && key.Value != "webhooks" {
+			rawSpec[key.Value], err = converter.value(value)
+			return
+		}
+		var items []streamedPathItem
+		eachPair(value, func(name, pathItem *yaml.Node) {
+			items = append(items, streamedPathItem{name: name.Value, value: func() (interface{}, error) {
+				return converter.value(pathItem)
+			}})
+		})
+		if key.Value == "paths" {
+			sections.paths = items
+		} else {
+			sections.webhooks = items
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rawSpec, sections, nil
+}
+
+// streamJSON reads the sections of a JSON document but its paths and
+// webhooks with a streaming decoder. The path items of paths and webhooks
+// are only scanned, and decoded from data on demand.
+func streamJSON(data []byte) (map[string]interface{}, *streamedSections, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	} else if token != json.Delim('{') {
+		return nil, nil, errNotMapping
+	}
+
+	rawSpec := make(map[string]interface{})
+	sections := &streamedSections{}
+	for decoder.More() {
+		key, err := jsonKey(decoder)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key {
+		case "paths", "webhooks":
+			items, err := streamJSONMembers(decoder, data)
+			if err != nil {
+				return nil, nil, err
+			}
+			if key == "paths" {
+				sections.paths = items
+			} else {
+				sections.webhooks = items
+			}
+		default:
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				return nil, nil, err
+			}
+			rawSpec[key] = value
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	return rawSpec, sections, nil
+}
+
+// streamJSONMembers scans the members of the object decoder is at and
+// returns them as path items decoded from data on demand. Values that are
+// not objects have no members.
+func streamJSONMembers(decoder *json.Decoder, data []byte) ([]streamedPathItem, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, skipJSONValue(decoder, token)
+	}
+
+	var items []streamedPathItem
+	for decoder.More() {
+		name, err := jsonKey(decoder)
+		if err != nil {
+			return nil, err
+		}
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if err := skipJSONValue(decoder, token); err != nil {
+			return nil, err
+		}
+		// The member's value, after the colon the key token leaves
+		raw := bytes.TrimLeft(data[start:decoder.InputOffset()], " \t\r\n:")
+		items = append(items, streamedPathItem{name: name, value: func() (interface{}, error) {
+			var value interface{}
+			err := json.Unmarshal(raw, &value)
+			return value, err
+		}})
+	}
+	_, err = decoder.Token()
+	return items, err
+}
+
+// jsonKey reads the key of an object member
+func jsonKey(decoder *json.Decoder) (string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("offset %d: expected an object key", decoder.InputOffset())
+	}
+	return key, nil
+}
+
+// skipJSONValue reads the rest of the value starting with token
+func skipJSONValue(decoder *json.Decoder, token json.Token) error {
+	depth := 0
+	for {
+		if delim, ok := token.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if token, err = decoder.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+// extractStreamed extracts the endpoints of streamed path items with
+// extract, converting and resolving one path item at a time
+func extractStreamed(resolver *refResolver, items []streamedPathItem, extract func(map[string]interface{}) ([]Endpoint, error)) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	for _, item := range items {
+		value, err := item.value()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", item.name, err)
+		}
+		value, _, _ = resolver.resolve(value, nil)
+		extracted, err := extract(map[string]interface{}{item.name: value})
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, extracted...)
+	}
+	return endpoints, nil
+}
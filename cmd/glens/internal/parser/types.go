@@ -1,6 +1,11 @@
 package parser
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -11,6 +16,11 @@ type OpenAPISpec struct {
 	Endpoints []Endpoint `json:"endpoints"`
 	Version   string     `json:"version"`
 	ParsedAt  time.Time  `json:"parsed_at"`
+	// Source is the file path or URL the spec was read from
+	Source string `json:"source,omitempty"`
+	// Warnings are the problems of the spec parsing tolerated, in document
+	// order
+	Warnings []SpecWarning `json:"warnings,omitempty"`
 }
 
 // Info contains API metadata
@@ -30,30 +40,85 @@ type Contact struct {
 
 // Server represents an API server
 type Server struct {
-	URL         string            `json:"url"`
-	Description string            `json:"description,omitempty"`
-	Variables   map[string]string `json:"variables,omitempty"`
+	URL         string                    `json:"url"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"`
 }
 
+// ServerVariable is a {name} placeholder of a server URL
+type ServerVariable struct {
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Endpoint kinds. Path operations, which clients send to the API, have no
+// kind; webhooks and callbacks are requests the API sends to its clients,
+// scenarios are workflows across several path operations, queries and
+// mutations are the operations of a GraphQL schema, and RPCs the methods of
+// gRPC services.
+const (
+	// KindWebhook is an operation of the OpenAPI 3.1 webhooks section; its
+	// Path is the webhook's name
+	KindWebhook = "webhook"
+	// KindCallback is an operation of a callback of a path operation; its
+	// Path is the runtime expression of the callback URL
+	KindCallback = "callback"
+	// KindScenario is a sequence of path operations tested together; its
+	// Path is the scenario's name and Steps its requests
+	KindScenario = "scenario"
+	// KindQuery is a field of a GraphQL schema's Query type, posted to
+	// GraphQLPath; GraphQL describes it
+	KindQuery = "query"
+	// KindMutation is a field of a GraphQL schema's Mutation type
+	KindMutation = "mutation"
+	// KindRPC is a method of a gRPC service; its Path is
+	// /<package>.<Service>/<Method> and GRPC describes it
+	KindRPC = "rpc"
+)
+
 // Endpoint represents a single API endpoint
 type Endpoint struct {
-	ID          string                `json:"id"`
-	Method      string                `json:"method"`
-	Path        string                `json:"path"`
-	OperationID string                `json:"operation_id,omitempty"`
-	Summary     string                `json:"summary,omitempty"`
-	Description string                `json:"description,omitempty"`
-	Tags        []string              `json:"tags,omitempty"`
-	Parameters  []Parameter           `json:"parameters,omitempty"`
-	RequestBody *RequestBody          `json:"request_body,omitempty"`
-	Responses   map[string]Response   `json:"responses,omitempty"`
-	Security    []SecurityRequirement `json:"security,omitempty"`
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind,omitempty"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	OperationID string   `json:"operation_id,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	XSafe       bool     `json:"x_safe,omitempty"` // x-safe: operation has no side effects
+	// Extensions are the operation's x- fields, including those of its
+	// path item; see ExtSkip and the other x-glens extensions
+	Extensions  map[string]interface{} `json:"extensions,omitempty"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"request_body,omitempty"`
+	Responses   map[string]Response    `json:"responses,omitempty"`
+	Security    []SecurityRequirement  `json:"security,omitempty"`
+	// Servers overrides the specification's servers for this operation,
+	// from the operation or its path item; empty means OpenAPISpec.Servers
+	Servers []Server `json:"servers,omitempty"`
+	// Pagination is set for list operations that page their results
+	Pagination *Pagination `json:"pagination,omitempty"`
+	// Trigger is the "METHOD /path" of the operation whose request
+	// registers a callback
+	Trigger string `json:"trigger,omitempty"`
+	// Steps are the requests of a scenario, in order
+	Steps []ScenarioStep `json:"steps,omitempty"`
+	// GraphQL is the field a GraphQL query or mutation calls
+	GraphQL *GraphQLOperation `json:"graphql,omitempty"`
+	// GRPC is the method a gRPC endpoint calls
+	GRPC *GRPCMethod `json:"grpc,omitempty"`
+	// Position is where the operation is declared in the spec file; nil
+	// for endpoints glens derives itself
+	Position *Position `json:"position,omitempty"`
 }
 
 // Parameter represents an endpoint parameter
 type Parameter struct {
 	Name        string      `json:"name"`
-	In          string      `json:"in"` // query, header, path, cookie
+	In          string      `json:"in"` // query, header, path, cookie; argument for GraphQL
 	Description string      `json:"description,omitempty"`
 	Required    bool        `json:"required"`
 	Schema      Schema      `json:"schema"`
@@ -81,27 +146,229 @@ type MediaType struct {
 	Examples map[string]Example `json:"examples,omitempty"`
 }
 
-// Schema represents a JSON schema
+// Schema represents a JSON schema, in the OpenAPI 3.0 or the 3.1 (JSON
+// Schema 2020-12) dialect. Type is the first non-null type; a type array
+// with several non-null types keeps all of them in Types, and "null" in the
+// array or the 3.0 nullable keyword sets Nullable.
 type Schema struct {
-	Type        string            `json:"type,omitempty"`
-	Format      string            `json:"format,omitempty"`
-	Description string            `json:"description,omitempty"`
-	Properties  map[string]Schema `json:"properties,omitempty"`
-	Items       *Schema           `json:"items,omitempty"`
-	Required    []string          `json:"required,omitempty"`
-	Enum        []interface{}     `json:"enum,omitempty"`
-	Example     interface{}       `json:"example,omitempty"`
-	Minimum     *float64          `json:"minimum,omitempty"`
-	Maximum     *float64          `json:"maximum,omitempty"`
-	MinLength   *int              `json:"min_length,omitempty"`
-	MaxLength   *int              `json:"max_length,omitempty"`
-	Pattern     string            `json:"pattern,omitempty"`
-	Ref         string            `json:"$ref,omitempty"`
+	Type             string            `json:"type,omitempty"`
+	Types            []string          `json:"types,omitempty"`
+	Nullable         bool              `json:"nullable,omitempty"`
+	Format           string            `json:"format,omitempty"`
+	Description      string            `json:"description,omitempty"`
+	Properties       map[string]Schema `json:"properties,omitempty"`
+	Items            *Schema           `json:"items,omitempty"`
+	PrefixItems      []Schema          `json:"prefix_items,omitempty"`
+	Required         []string          `json:"required,omitempty"`
+	Enum             []interface{}     `json:"enum,omitempty"`
+	Const            interface{}       `json:"const,omitempty"`
+	Example          interface{}       `json:"example,omitempty"`
+	Minimum          *float64          `json:"minimum,omitempty"`
+	Maximum          *float64          `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64          `json:"exclusive_minimum,omitempty"`
+	ExclusiveMaximum *float64          `json:"exclusive_maximum,omitempty"`
+	MinLength        *int              `json:"min_length,omitempty"`
+	MaxLength        *int              `json:"max_length,omitempty"`
+	Pattern          string            `json:"pattern,omitempty"`
+	OneOf            []Schema          `json:"one_of,omitempty"`
+	AnyOf            []Schema          `json:"any_of,omitempty"`
+	AllOf            []Schema          `json:"all_of,omitempty"`
+	Discriminator    *Discriminator    `json:"discriminator,omitempty"`
+	Ref              string            `json:"$ref,omitempty"`
+}
+
+// Discriminator names the property that selects the variant of a
+// polymorphic schema, and maps its values to the variants' references
+type Discriminator struct {
+	PropertyName string            `json:"property_name"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+}
+
+// Variant is one alternative of a polymorphic schema
+type Variant struct {
+	// Name is the variant's schema name, e.g. Cat for #/components/schemas/Cat
+	Name string
+	// Value is the discriminator value selecting the variant, if any
+	Value string
+	// Schema is the variant's schema; empty for variants only known from
+	// the discriminator mapping
+	Schema Schema
+}
+
+// Variants returns the alternatives of a oneOf or anyOf schema, or those
+// listed by the discriminator mapping of a base schema that its subtypes
+// extend with allOf. The discriminator value of a variant is looked up in
+// the mapping, then in a const or single enum of the discriminator
+// property, and defaults to the schema name.
+func (s Schema) Variants() []Variant {
+	alternatives := s.OneOf
+	if len(alternatives) == 0 {
+		alternatives = s.AnyOf
+	}
+
+	var variants []Variant
+	for i := range alternatives {
+		alt := alternatives[i]
+		variant := Variant{Name: refName(alt.Ref), Schema: alt}
+		if variant.Name == "" {
+			variant.Name = fmt.Sprintf("variant %d", i+1)
+		}
+		if s.Discriminator != nil {
+			variant.Value = s.Discriminator.valueOf(alt)
+		}
+		variants = append(variants, variant)
+	}
+	if len(variants) > 0 || s.Discriminator == nil {
+		return variants
+	}
+
+	values := make([]string, 0, len(s.Discriminator.Mapping))
+	for value := range s.Discriminator.Mapping {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		variants = append(variants, Variant{Name: refName(s.Discriminator.Mapping[value]), Value: value})
+	}
+	return variants
+}
+
+// RequiredProperties returns the required properties of the schema and of
+// the allOf subschemas it extends
+func (s Schema) RequiredProperties() []string {
+	required := append([]string(nil), s.Required...)
+	for _, part := range s.AllOf {
+		for _, name := range part.RequiredProperties() {
+			if !slices.Contains(required, name) {
+				required = append(required, name)
+			}
+		}
+	}
+	return required
+}
+
+// valueOf returns the discriminator value that selects variant
+func (d *Discriminator) valueOf(variant Schema) string {
+	for value, ref := range d.Mapping {
+		if ref == variant.Ref || (variant.Ref != "" && ref == refName(variant.Ref)) {
+			return value
+		}
+	}
+	property := variant.Properties[d.PropertyName]
+	for _, part := range variant.AllOf {
+		if p, ok := part.Properties[d.PropertyName]; ok {
+			property = p
+		}
+	}
+	if property.Const != nil {
+		return fmt.Sprint(property.Const)
+	}
+	if len(property.Enum) == 1 {
+		return fmt.Sprint(property.Enum[0])
+	}
+	return refName(variant.Ref)
+}
+
+// refName returns the last segment of a reference, the schema name
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// Describe summarises the schema's type for prompts, e.g. "string",
+// "integer | string", "string or null", "array of string", "const 'cat'"
+// or "oneOf(object, string)"
+func (s Schema) Describe() string {
+	var desc string
+	switch {
+	case s.Const != nil:
+		return fmt.Sprintf("const %v", s.Const)
+	case s.Ref != "":
+		desc = refName(s.Ref)
+	case len(s.Types) > 1:
+		desc = strings.Join(s.Types, " | ")
+	case len(s.OneOf) > 0:
+		desc = describeComposition("oneOf", s.OneOf)
+	case len(s.AnyOf) > 0:
+		desc = describeComposition("anyOf", s.AnyOf)
+	case s.Type == "array" && len(s.PrefixItems) > 0:
+		items := make([]string, len(s.PrefixItems))
+		for i := range s.PrefixItems {
+			items[i] = s.PrefixItems[i].Describe()
+		}
+		desc = "tuple [" + strings.Join(items, ", ") + "]"
+	case s.Type == "array" && s.Items != nil:
+		desc = "array of " + s.Items.Describe()
+	case s.Type != "":
+		desc = s.Type
+	case len(s.AllOf) > 0:
+		desc = describeComposition("allOf", s.AllOf)
+	}
+	if s.Nullable && desc != "null" {
+		if desc == "" {
+			return "null"
+		}
+		desc += " or null"
+	}
+	return desc
+}
+
+// Constraints summarises the schema's validation keywords for prompts, e.g.
+// "minimum 1, exclusive maximum 100, max length 20, enum [a b]"
+func (s Schema) Constraints() string {
+	var parts []string
+	if len(s.Enum) > 0 {
+		parts = append(parts, fmt.Sprintf("enum %v", s.Enum))
+	}
+	bounds := []struct {
+		name  string
+		value *float64
+	}{
+		{"minimum", s.Minimum},
+		{"exclusive minimum", s.ExclusiveMinimum},
+		{"maximum", s.Maximum},
+		{"exclusive maximum", s.ExclusiveMaximum},
+	}
+	for _, bound := range bounds {
+		if bound.value != nil {
+			parts = append(parts, fmt.Sprintf("%s %g", bound.name, *bound.value))
+		}
+	}
+	if s.MinLength != nil {
+		parts = append(parts, fmt.Sprintf("min length %d", *s.MinLength))
+	}
+	if s.MaxLength != nil {
+		parts = append(parts, fmt.Sprintf("max length %d", *s.MaxLength))
+	}
+	if s.Pattern != "" {
+		parts = append(parts, "pattern "+s.Pattern)
+	}
+	if s.Format != "" {
+		parts = append(parts, "format "+s.Format)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeComposition describes the subschemas of a composition keyword
+func describeComposition(keyword string, schemas []Schema) string {
+	parts := make([]string, len(schemas))
+	for i := range schemas {
+		parts[i] = orAny(schemas[i].Describe())
+	}
+	return keyword + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// orAny names a schema without type constraints
+func orAny(desc string) string {
+	if desc == "" {
+		return "any"
+	}
+	return desc
 }
 
 // Header represents a response header
 type Header struct {
 	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
 	Schema      Schema      `json:"schema,omitempty"`
 	Example     interface{} `json:"example,omitempty"`
 }
@@ -115,3 +382,74 @@ type Example struct {
 
 // SecurityRequirement represents security requirements
 type SecurityRequirement map[string][]string
+
+// HeaderNames returns the names of the response's headers, sorted
+func (r Response) HeaderNames() []string {
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResponseHeaders returns the names of the headers documented in any of the
+// endpoint's responses, sorted and without duplicates
+func (e *Endpoint) ResponseHeaders() []string {
+	var names []string
+	for _, response := range e.Responses {
+		names = append(names, response.HeaderNames()...)
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// JSONResponseSchemas returns the schemas of the endpoint's JSON responses
+// by status code, which tests can validate response bodies against. It is
+// empty for webhooks, callbacks, scenarios and GraphQL and gRPC operations,
+// whose responses are not those of a request to their path.
+func (e *Endpoint) JSONResponseSchemas() map[string]*Schema {
+	if e.Incoming() || e.Kind == KindScenario || e.GraphQL != nil || e.GRPC != nil {
+		return nil
+	}
+	schemas := make(map[string]*Schema)
+	for code, response := range e.Responses {
+		for _, contentType := range slices.Sorted(maps.Keys(response.Content)) {
+			if media := response.Content[contentType]; strings.Contains(contentType, "json") && !media.Schema.isEmpty() {
+				schemas[code] = &media.Schema
+				break
+			}
+		}
+	}
+	return schemas
+}
+
+// isEmpty reports whether s constrains nothing, as for recursive references
+// cut while resolving
+func (s *Schema) isEmpty() bool {
+	return s.Type == "" && len(s.Types) == 0 && len(s.Properties) == 0 && s.Items == nil &&
+		len(s.OneOf) == 0 && len(s.AnyOf) == 0 && len(s.AllOf) == 0
+}
+
+// Incoming reports whether the API sends the endpoint's requests, so its
+// test receives them instead of calling the API
+func (e *Endpoint) Incoming() bool {
+	return e.Kind == KindWebhook || e.Kind == KindCallback
+}
+
+// Matches reports whether ref names the endpoint by operation ID, endpoint
+// ID, or "METHOD /path" (method case-insensitive)
+func (e *Endpoint) Matches(ref string) bool {
+	return ref == e.OperationID || ref == e.ID ||
+		strings.EqualFold(ref, e.Method+" "+e.Path)
+}
+
+// FindEndpoint returns the first endpoint matching ref
+func (s *OpenAPISpec) FindEndpoint(ref string) (*Endpoint, bool) {
+	for i := range s.Endpoints {
+		if s.Endpoints[i].Matches(ref) {
+			return &s.Endpoints[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,276 @@
+package parser
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Codes of spec warnings
+const (
+	// WarningUnresolvedRef marks a local $ref pointing nowhere
+	WarningUnresolvedRef = "unresolved_ref"
+	// WarningInvalidSchemaType marks a schema type that is not a JSON
+	// Schema type, such as "strin"
+	WarningInvalidSchemaType = "invalid_schema_type"
+	// WarningInvalidPath marks a path that does not start with a slash
+	WarningInvalidPath = "invalid_path"
+	// WarningSkippedPathItem marks a path item, operation or callback that
+	// is not an object and is skipped
+	WarningSkippedPathItem = "skipped_path_item"
+	// WarningUnknownField marks a field of a path item that is neither an
+	// HTTP method nor a path item field, such as a misspelled method
+	WarningUnknownField = "unknown_field"
+	// WarningMalformedParameter marks a parameter that is not an object,
+	// lacks a name or a location, or has an unknown location
+	WarningMalformedParameter = "malformed_parameter"
+	// WarningMalformedResponse marks a response or media type that is not
+	// an object and is skipped
+	WarningMalformedResponse = "malformed_response"
+	// WarningMalformedSchema marks a schema, such as a property, that is
+	// not an object and is skipped
+	WarningMalformedSchema = "malformed_schema"
+)
+
+// SpecWarning is a problem of the spec that parsing tolerates but that
+// leaves its operations or schemas incomplete
+type SpecWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Path is the JSON pointer of the offending node, e.g.
+	// /paths/~1pets/get/parameters/0
+	Path     string    `json:"path"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// Fields of the spec the checks know
+var (
+	// httpMethods are the path item fields holding operations
+	httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace", "query"}
+	// pathItemFields are the other fields of path items
+	pathItemFields = []string{"$ref", "summary", "description", "servers", "parameters"}
+	// parameterLocations are the values of a parameter's in field
+	parameterLocations = []string{"query", "header", "path", "cookie"}
+	// schemaTypes are the types a schema may declare
+	schemaTypes = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+	// schemaKeys hold a schema in schemas and media types
+	schemaKeys = []string{"schema", "items", "additionalProperties", "not", "contains", "propertyNames"}
+	// schemaListKeys hold lists of schemas in schemas
+	schemaListKeys = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	// dataKeys hold example values rather than spec objects
+	dataKeys = []string{"example", "value", "default", "const", "enum"}
+)
+
+// checkDocument adds to spec a warning for every part of document, parsed
+// as rawSpec, that the extract functions skip or cannot make sense of, in
+// document order
+func checkDocument(spec *OpenAPISpec, rawSpec map[string]interface{}, document *yaml.Node) {
+	eachPair(mappingValue(document, "paths"), func(path, pathItem *yaml.Node) {
+		pointer := jsonPointer("/paths", path.Value)
+		if !strings.HasPrefix(path.Value, "/") {
+			spec.warn(WarningInvalidPath, pointer, path, "path %q does not start with /", path.Value)
+		}
+		checkPathItem(spec, rawSpec, pointer, path.Value, pathItem)
+	})
+	eachPair(mappingValue(document, "webhooks"), func(name, pathItem *yaml.Node) {
+		checkPathItem(spec, rawSpec, jsonPointer("/webhooks", name.Value), "webhook "+name.Value, pathItem)
+	})
+	checkNodes(spec, rawSpec, "", document, false)
+
+	slices.SortStableFunc(spec.Warnings, func(a, b SpecWarning) int {
+		return cmp.Or(cmp.Compare(a.Position.Line, b.Position.Line), cmp.Compare(a.Position.Column, b.Position.Column))
+	})
+}
+
+// checkPathItem warns about the fields and operations of the path item of
+// name that are skipped
+func checkPathItem(spec *OpenAPISpec, rawSpec map[string]interface{}, pointer, name string, pathItem *yaml.Node) {
+	if pathItem.Kind != yaml.MappingNode {
+		spec.warn(WarningSkippedPathItem, pointer, pathItem, "path item of %s is not an object and is skipped", name)
+		return
+	}
+	eachPair(pathItem, func(key, value *yaml.Node) {
+		fieldPointer := jsonPointer(pointer, key.Value)
+		switch {
+		case slices.Contains(httpMethods, strings.ToLower(key.Value)):
+			checkOperation(spec, rawSpec, fieldPointer, strings.ToUpper(key.Value)+" "+name, value)
+		case key.Value == "parameters":
+			checkParameters(spec, rawSpec, fieldPointer, name, value)
+		case slices.Contains(pathItemFields, key.Value) || strings.HasPrefix(key.Value, "x-"):
+		default:
+			spec.warn(WarningUnknownField, fieldPointer, key, "unknown field %q of %s is skipped (HTTP methods are %s)",
+				key.Value, name, strings.Join(httpMethods, ", "))
+		}
+	})
+}
+
+// checkOperation warns about the parameters, responses and callbacks of an
+// operation that are skipped
+func checkOperation(spec *OpenAPISpec, rawSpec map[string]interface{}, pointer, name string, operation *yaml.Node) {
+	if operation.Kind != yaml.MappingNode {
+		spec.warn(WarningSkippedPathItem, pointer, operation, "operation %s is not an object and is skipped", name)
+		return
+	}
+	checkParameters(spec, rawSpec, jsonPointer(pointer, "parameters"), name, mappingValue(operation, "parameters"))
+	if requestBody := mappingValue(operation, "requestBody"); requestBody != nil {
+		checkContent(spec, jsonPointer(jsonPointer(pointer, "requestBody"), "content"), name, mappingValue(requestBody, "content"))
+	}
+	responsesPointer := jsonPointer(pointer, "responses")
+	eachPair(mappingValue(operation, "responses"), func(code, response *yaml.Node) {
+		responsePointer := jsonPointer(responsesPointer, code.Value)
+		if response.Kind != yaml.MappingNode {
+			spec.warn(WarningMalformedResponse, responsePointer, response, "response %s of %s is not an object and is skipped", code.Value, name)
+			return
+		}
+		checkContent(spec, jsonPointer(responsePointer, "content"), name, mappingValue(response, "content"))
+	})
+	callbacksPointer := jsonPointer(pointer, "callbacks")
+	eachPair(mappingValue(operation, "callbacks"), func(callbackName, callback *yaml.Node) {
+		callbackPointer := jsonPointer(callbacksPointer, callbackName.Value)
+		if callback.Kind != yaml.MappingNode {
+			spec.warn(WarningSkippedPathItem, callbackPointer, callback, "callback %s of %s is not an object and is skipped", callbackName.Value, name)
+			return
+		}
+		eachPair(callback, func(expression, pathItem *yaml.Node) {
+			checkPathItem(spec, rawSpec, jsonPointer(callbackPointer, expression.Value), "callback "+callbackName.Value, pathItem)
+		})
+	})
+}
+
+// checkParameters warns about the parameters of a parameters list that are
+// skipped or cannot be sent: those that are not objects, lack a name or a
+// location, or have an unknown location
+func checkParameters(spec *OpenAPISpec, rawSpec map[string]interface{}, pointer, name string, parameters *yaml.Node) {
+	if parameters == nil || parameters.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, parameterNode := range parameters.Content {
+		parameterNode = resolveAlias(parameterNode)
+		parameterPointer := fmt.Sprintf("%s/%d", pointer, i)
+		parameter := make(map[string]interface{})
+		switch {
+		case parameterNode.Kind != yaml.MappingNode:
+			spec.warn(WarningMalformedParameter, parameterPointer, parameterNode, "parameter %d of %s is not an object and is skipped", i, name)
+			continue
+		case mappingValue(parameterNode, "$ref") != nil:
+			target, ok := lookupPointer(rawSpec, mappingValue(parameterNode, "$ref").Value).(map[string]interface{})
+			if !ok {
+				continue // reported as an unresolved reference
+			}
+			parameter = target
+		default:
+			if err := parameterNode.Decode(&parameter); err != nil {
+				continue
+			}
+		}
+		paramName, _ := parameter["name"].(string)
+		in, _ := parameter["in"].(string)
+		switch {
+		case paramName == "" || in == "":
+			spec.warn(WarningMalformedParameter, parameterPointer, parameterNode, "parameter %d of %s has no name or no in", i, name)
+		case !slices.Contains(parameterLocations, in):
+			spec.warn(WarningMalformedParameter, parameterPointer, parameterNode, "parameter %q of %s is in %q (%s)",
+				paramName, name, in, strings.Join(parameterLocations, ", "))
+		}
+	}
+}
+
+// checkContent warns about the media types of a content map that are not
+// objects
+func checkContent(spec *OpenAPISpec, pointer, name string, content *yaml.Node) {
+	eachPair(content, func(mediaType, value *yaml.Node) {
+		if value.Kind != yaml.MappingNode {
+			spec.warn(WarningMalformedResponse, jsonPointer(pointer, mediaType.Value), value,
+				"media type %s of %s is not an object and is skipped", mediaType.Value, name)
+		}
+	})
+}
+
+// checkNodes warns about the unresolved references of node and, within
+// schemas, about invalid types and subschemas that are not objects; node
+// is a schema when schema is set.
+// Aliases and merge keys are not followed: the nodes they refer to are
+// checked where they are anchored, once.
+func checkNodes(spec *OpenAPISpec, rawSpec map[string]interface{}, pointer string, node *yaml.Node, schema bool) {
+	if node.Kind == yaml.AliasNode {
+		return
+	}
+	if schema && node.Kind != yaml.MappingNode && node.Tag != "!!bool" {
+		spec.warn(WarningMalformedSchema, pointer, node, "schema %s is not an object and is skipped", pointer)
+		return
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			checkNodes(spec, rawSpec, fmt.Sprintf("%s/%d", pointer, i), item, false)
+		}
+	case yaml.MappingNode:
+		eachOwnPair(node, func(key, value *yaml.Node) {
+			valuePointer := jsonPointer(pointer, key.Value)
+			switch {
+			case slices.Contains(dataKeys, key.Value) || strings.HasPrefix(key.Value, "x-"):
+			case key.Value == "$ref":
+				if strings.HasPrefix(value.Value, "#/") && lookupPointer(rawSpec, value.Value) == nil {
+					spec.warn(WarningUnresolvedRef, valuePointer, value, "unresolved reference %s", value.Value)
+				}
+			case schema && key.Value == "type":
+				checkSchemaType(spec, valuePointer, value)
+			case schema && (key.Value == "properties" || key.Value == "patternProperties") || key.Value == "schemas":
+				eachOwnPair(value, func(property, propertySchema *yaml.Node) {
+					checkNodes(spec, rawSpec, jsonPointer(valuePointer, property.Value), propertySchema, true)
+				})
+			case schema && slices.Contains(schemaListKeys, key.Value) && value.Kind == yaml.SequenceNode:
+				for i, item := range value.Content {
+					checkNodes(spec, rawSpec, fmt.Sprintf("%s/%d", valuePointer, i), item, true)
+				}
+			default:
+				checkNodes(spec, rawSpec, valuePointer, value, slices.Contains(schemaKeys, key.Value))
+			}
+		})
+	}
+}
+
+// eachOwnPair calls fn with the keys and values of a mapping node itself,
+// without merge keys
+func eachOwnPair(node *yaml.Node, fn func(key, value *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !isMergeKey(node.Content[i]) {
+			fn(node.Content[i], node.Content[i+1])
+		}
+	}
+}
+
+// checkSchemaType warns about the types of a schema's type node that are
+// not JSON Schema types
+func checkSchemaType(spec *OpenAPISpec, pointer string, node *yaml.Node) {
+	types := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		types = node.Content
+	}
+	for _, t := range types {
+		if t.Kind == yaml.ScalarNode && !slices.Contains(schemaTypes, t.Value) {
+			spec.warn(WarningInvalidSchemaType, pointer, t, "invalid schema type %q (%s)", t.Value, strings.Join(schemaTypes, ", "))
+		}
+	}
+}
+
+// warn adds a warning about the node at pointer to spec
+func (s *OpenAPISpec) warn(code, pointer string, node *yaml.Node, format string, args ...interface{}) {
+	s.Warnings = append(s.Warnings, SpecWarning{
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Path:     pointer,
+		Position: &Position{Line: node.Line, Column: node.Column},
+	})
+}
+
+// jsonPointer appends token to the JSON pointer parent, escaped
+func jsonPointer(parent, token string) string {
+	return parent + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
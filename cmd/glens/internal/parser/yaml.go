@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument parses data as a single YAML document and returns its root
+// node, nil for an empty document
+func yamlDocument(data []byte) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	return root.Content[0], nil
+}
+
+// yamlConverter converts YAML nodes to the generic values the extract
+// functions read. Unlike yaml.Unmarshal into interface{} it
+//   - keys every mapping by strings, so responses keyed by unquoted status
+//     codes (200:) are not decoded as map[interface{}]interface{} the
+//     extract functions skip
+//   - converts an anchored node once and shares the value with all its
+//     aliases, so heavy anchor use does not multiply memory
+//   - applies "<<" merge keys: the mapping's own keys override merged ones,
+//     and earlier merged mappings override later ones
+//
+// The values it returns must not be modified.
+type yamlConverter struct {
+	anchors map[*yaml.Node]interface{}
+	// converting holds the anchored nodes being converted, to reject an
+	// anchor whose value contains itself
+	converting map[*yaml.Node]bool
+}
+
+func newYAMLConverter() *yamlConverter {
+	return &yamlConverter{anchors: make(map[*yaml.Node]interface{}), converting: make(map[*yaml.Node]bool)}
+}
+
+// value returns the generic value of node
+func (c *yamlConverter) value(node *yaml.Node) (interface{}, error) {
+	if node.Kind == yaml.AliasNode {
+		return c.value(node.Alias)
+	}
+	if node.Anchor != "" {
+		if value, ok := c.anchors[node]; ok {
+			return value, nil
+		}
+		if c.converting[node] {
+			return nil, fmt.Errorf("line %d: anchor %q value contains itself", node.Line, node.Anchor)
+		}
+		c.converting[node] = true
+		defer delete(c.converting, node)
+	}
+
+	var value interface{}
+	var err error
+	switch node.Kind {
+	case yaml.MappingNode:
+		value, err = c.mapping(node)
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			if items[i], err = c.value(item); err != nil {
+				return nil, err
+			}
+		}
+		value = items
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!str" {
+			value = node.Value
+		} else if err = node.Decode(&value); err != nil {
+			return nil, err
+		}
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			value, err = c.value(node.Content[0])
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if node.Anchor != "" {
+		c.anchors[node] = value
+	}
+	return value, nil
+}
+
+// mapping returns the generic value of a mapping node
+func (c *yamlConverter) mapping(node *yaml.Node) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(node.Content)/2)
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isMergeKey(node.Content[i]) {
+			merges = append(merges, resolveAlias(node.Content[i+1]))
+		}
+	}
+	for i := len(merges) - 1; i >= 0; i-- {
+		sources := []*yaml.Node{merges[i]}
+		if merges[i].Kind == yaml.SequenceNode {
+			sources = merges[i].Content
+		}
+		for j := len(sources) - 1; j >= 0; j-- {
+			if resolveAlias(sources[j]).Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: map merge requires a map or a sequence of maps as the value", sources[j].Line)
+			}
+			merged, err := c.value(sources[j])
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range merged.(map[string]interface{}) {
+				out[key] = value
+			}
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isMergeKey(node.Content[i]) {
+			continue
+		}
+		value, err := c.value(node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		out[resolveAlias(node.Content[i]).Value] = value
+	}
+	return out, nil
+}
+
+// resolveAlias returns the node an alias node refers to, other nodes as
+// they are
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// isMergeKey reports whether node is the "<<" key merging mappings into
+// the mapping holding it
+func isMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Value == "<<" && node.ShortTag() == "!!merge"
+}
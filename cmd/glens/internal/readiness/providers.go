@@ -0,0 +1,97 @@
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Provider describes how to preflight an AI provider: its API key variable
+// and an authenticated request listing its models.
+type Provider struct {
+	Name string
+	// KeyEnv holds the API key; BaseURLEnv optionally overrides BaseURL.
+	KeyEnv     string
+	BaseURLEnv string
+	BaseURL    string
+	// Authorize adds the API key to a request.
+	Authorize func(req *http.Request, key string)
+}
+
+// Providers are the AI providers whose models the API serves.
+var Providers = []Provider{
+	{
+		Name:       "openai",
+		KeyEnv:     "OPENAI_API_KEY",
+		BaseURLEnv: "OPENAI_BASE_URL",
+		BaseURL:    "https://api.openai.com/v1",
+		Authorize: func(req *http.Request, key string) {
+			req.Header.Set("Authorization", "Bearer "+key)
+		},
+	},
+	{
+		Name:       "anthropic",
+		KeyEnv:     "ANTHROPIC_API_KEY",
+		BaseURLEnv: "ANTHROPIC_BASE_URL",
+		BaseURL:    "https://api.anthropic.com/v1",
+		Authorize: func(req *http.Request, key string) {
+			req.Header.Set("x-api-key", key)
+			req.Header.Set("anthropic-version", "2023-06-01")
+		},
+	},
+}
+
+// ProviderChecks returns a preflight check per provider. A provider with an
+// API key must answer GET <base>/models with it; one without a key is
+// reported as not configured, which fails readiness only when the provider
+// is listed in required.
+func ProviderChecks(getenv func(string) string, client *http.Client, required []string) []Check {
+	requiredSet := make(map[string]bool, len(required))
+	for _, name := range required {
+		requiredSet[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	checks := make([]Check, 0, len(Providers))
+	for _, p := range Providers {
+		key := getenv(p.KeyEnv)
+		base := p.BaseURL
+		if p.BaseURLEnv != "" && getenv(p.BaseURLEnv) != "" {
+			base = getenv(p.BaseURLEnv)
+		}
+		checks = append(checks, Check{
+			Name:     "provider:" + p.Name,
+			Optional: key == "" && !requiredSet[p.Name],
+			Run:      providerPreflight(p, client, key, base),
+		})
+	}
+	return checks
+}
+
+// providerPreflight lists the models of a provider to verify its API key
+// and reachability.
+func providerPreflight(p Provider, client *http.Client, key, base string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if key == "" {
+			return fmt.Errorf("%s not set: %w", p.KeyEnv, ErrNotConfigured)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+"/models", nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		p.Authorize(req, key)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("%s unreachable: %w", p.Name, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("%s rejected %s (HTTP %d)", p.Name, p.KeyEnv, resp.StatusCode)
+		case resp.StatusCode >= 300:
+			return fmt.Errorf("%s returned HTTP %d", p.Name, resp.StatusCode)
+		}
+		return nil
+	}
+}
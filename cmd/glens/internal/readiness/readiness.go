@@ -0,0 +1,190 @@
+// Package readiness tracks whether the API server should receive traffic.
+// Checks, such as the preflight of the AI providers, run in the background
+// and their last results back GET /readyz; a draining server reports not
+// ready so load balancers stop routing to it before it shuts down.
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is how often checks are re-run.
+const DefaultInterval = time.Minute
+
+// DefaultCheckTimeout bounds a single check run.
+const DefaultCheckTimeout = 5 * time.Second
+
+// Check is a named dependency probe.
+type Check struct {
+	Name string
+	// Optional checks are reported but never make the server unready.
+	Optional bool
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of the last run of a check.
+type Result struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Optional  bool      `json:"optional,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Check statuses and server states reported by GET /readyz.
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusPending = "pending"
+	// StatusNotConfigured marks checks failing with ErrNotConfigured.
+	StatusNotConfigured = "not_configured"
+	StatusReady         = "ready"
+	StatusNotReady      = "not_ready"
+	StatusDraining      = "draining"
+)
+
+// ErrNotConfigured is returned by checks of dependencies that are not set
+// up, such as a provider without an API key.
+var ErrNotConfigured = errors.New("not configured")
+
+// Checker runs checks and reports readiness.
+type Checker struct {
+	checks   []Check
+	interval time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	results  map[string]Result
+	draining bool
+}
+
+// New creates a checker re-running checks every interval (default
+// DefaultInterval). Checks stay pending until Start runs them.
+func New(checks []Check, interval time.Duration) *Checker {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	c := &Checker{
+		checks:   checks,
+		interval: interval,
+		timeout:  DefaultCheckTimeout,
+		now:      time.Now,
+		results:  make(map[string]Result, len(checks)),
+	}
+	for _, check := range checks {
+		c.results[check.Name] = Result{Status: StatusPending, Optional: check.Optional}
+	}
+	return c
+}
+
+// Start runs the checks in the background, now and then every interval
+// until ctx is done.
+func (c *Checker) Start(ctx context.Context) {
+	go func() {
+		c.RunChecks(ctx)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.RunChecks(ctx)
+			}
+		}
+	}()
+}
+
+// RunChecks runs every check concurrently and records the results.
+func (c *Checker) RunChecks(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			result := Result{Status: StatusOK, Optional: check.Optional}
+			if err := check.Run(checkCtx); err != nil {
+				result.Status = StatusFailed
+				if errors.Is(err, ErrNotConfigured) {
+					result.Status = StatusNotConfigured
+				}
+				result.Error = err.Error()
+			}
+			result.CheckedAt = c.now().UTC()
+			c.mu.Lock()
+			c.results[check.Name] = result
+			c.mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+}
+
+// Drain marks the server as shutting down; it stays unready from then on.
+func (c *Checker) Drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = true
+}
+
+// Status returns the server state and the result of each check.
+func (c *Checker) Status() (string, map[string]Result) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	results := make(map[string]Result, len(c.results))
+	status := StatusReady
+	for name, result := range c.results {
+		results[name] = result
+		if !result.Optional && result.Status != StatusOK {
+			status = StatusNotReady
+		}
+	}
+	if c.draining {
+		status = StatusDraining
+	}
+	return status, results
+}
+
+// statusResponse is the JSON body returned by GET /readyz.
+type statusResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+	Failed []string          `json:"failed,omitempty"`
+}
+
+// Handler serves GET /readyz: 200 when every required check passed, 503
+// while checks are pending or failing and once the server is draining.
+func (c *Checker) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		status, results := c.Status()
+		resp := statusResponse{Status: status, Checks: results}
+		for name, result := range results {
+			if result.Status == StatusFailed {
+				resp.Failed = append(resp.Failed, name)
+			}
+		}
+		sort.Strings(resp.Failed)
+
+		code := http.StatusOK
+		if status != StatusReady {
+			code = http.StatusServiceUnavailable
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, "failed to encode JSON response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_, _ = w.Write(data)
+		_, _ = w.Write([]byte("\n"))
+	}
+}
@@ -0,0 +1,85 @@
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readyz(t *testing.T, c *Checker) (int, statusResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.Handler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp statusResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	return rec.Code, resp
+}
+
+func TestChecker_Handler(t *testing.T) {
+	var dbErr error
+	c := New([]Check{
+		{Name: "db", Run: func(context.Context) error { return dbErr }},
+		{Name: "cache", Optional: true, Run: func(context.Context) error { return errors.New("cache down") }},
+	}, 0)
+
+	code, resp := readyz(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "checks are pending until they run")
+	assert.Equal(t, StatusNotReady, resp.Status)
+	assert.Equal(t, StatusPending, resp.Checks["db"].Status)
+
+	c.RunChecks(context.Background())
+	code, resp = readyz(t, c)
+	assert.Equal(t, http.StatusOK, code, "optional checks do not affect readiness")
+	assert.Equal(t, StatusReady, resp.Status)
+	assert.Equal(t, []string{"cache"}, resp.Failed)
+	assert.Equal(t, "cache down", resp.Checks["cache"].Error)
+
+	dbErr = errors.New("db down")
+	c.RunChecks(context.Background())
+	code, resp = readyz(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, []string{"cache", "db"}, resp.Failed)
+
+	dbErr = nil
+	c.RunChecks(context.Background())
+	c.Drain()
+	code, resp = readyz(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDraining, resp.Status)
+}
+
+func TestProviderChecks(t *testing.T) {
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		gotKey = r.Header.Get("Authorization")
+		if gotKey != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	env := map[string]string{"OPENAI_API_KEY": "good", "OPENAI_BASE_URL": server.URL + "/v1"}
+	c := New(ProviderChecks(func(k string) string { return env[k] }, server.Client(), nil), 0)
+	c.RunChecks(context.Background())
+	status, results := c.Status()
+	assert.Equal(t, StatusReady, status)
+	assert.Equal(t, "Bearer good", gotKey)
+	assert.Equal(t, StatusOK, results["provider:openai"].Status)
+	assert.Equal(t, StatusNotConfigured, results["provider:anthropic"].Status)
+	assert.True(t, results["provider:anthropic"].Optional)
+
+	env["OPENAI_API_KEY"] = "revoked"
+	c = New(ProviderChecks(func(k string) string { return env[k] }, server.Client(), []string{"anthropic"}), 0)
+	c.RunChecks(context.Background())
+	status, results = c.Status()
+	assert.Equal(t, StatusNotReady, status)
+	assert.Equal(t, "openai rejected OPENAI_API_KEY (HTTP 401)", results["provider:openai"].Error)
+	assert.False(t, results["provider:anthropic"].Optional, "required providers need a key")
+}
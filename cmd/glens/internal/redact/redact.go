@@ -0,0 +1,232 @@
+// Package redact scrubs secrets from what glens writes to disk or posts to
+// GitHub: reports (with their prompts and generated code), generated test
+// suites, logs, run events and issue bodies. It masks well-known credential
+// formats (bearer tokens, GitHub, OpenAI, AWS and Google keys, private keys),
+// configured patterns and the values of secret environment variables.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Mask replaces each secret
+const Mask = "********"
+
+// minValueLength is the shortest secret value masked; shorter values would
+// mask ordinary words
+const minValueLength = 6
+
+// builtinPatterns match well-known credential formats. A pattern with a
+// capture group masks the group only.
+var builtinPatterns = []string{
+	`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9\-._~+/]{8,}=*)`,
+	`\bgh[pousr]_[A-Za-z0-9]{36,}`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}`,
+	`\bsk-[A-Za-z0-9_\-]{20,}`,
+	`\bAKIA[0-9A-Z]{16}\b`,
+	`\bAIza[0-9A-Za-z_\-]{35}`,
+	`\bxox[abprs]-[A-Za-z0-9\-]{10,}`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// secretEnvSuffixes are environment variable name suffixes whose values
+// are secrets, e.g. GITHUB_TOKEN, OPENAI_API_KEY, AWS_SECRET_ACCESS_KEY
+var secretEnvSuffixes = []string{"TOKEN", "SECRET", "PASSWORD", "API_KEY", "APIKEY", "ACCESS_KEY", "PRIVATE_KEY", "CREDENTIALS"}
+
+// Config is the redaction section of the config
+type Config struct {
+	// Patterns are regular expressions masked in addition to the built-in
+	// ones; a pattern with a capture group masks the group only
+	Patterns []string `mapstructure:"patterns"`
+	// EnvVars names environment variables whose values are secrets, in
+	// addition to those named like *_TOKEN, *_SECRET, *_PASSWORD or *_API_KEY
+	EnvVars []string `mapstructure:"env_vars"`
+}
+
+// Validate checks that the patterns compile
+func (c *Config) Validate() error {
+	for i, pattern := range c.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("redaction.patterns[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Redactor masks secret values and patterns in text. It is safe for
+// concurrent use; a nil Redactor masks nothing.
+type Redactor struct {
+	patterns []*regexp.Regexp
+
+	mu     sync.RWMutex
+	values []string // longest first, so a value containing another is masked whole
+}
+
+// New returns a Redactor of the built-in patterns, the patterns of cfg and
+// the secret variables of environ ("NAME=value" pairs, as os.Environ)
+func New(cfg Config, environ []string) (*Redactor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Redactor{}
+	for _, pattern := range append(append([]string{}, builtinPatterns...), cfg.Patterns...) {
+		r.patterns = append(r.patterns, regexp.MustCompile(pattern))
+	}
+
+	named := make(map[string]bool, len(cfg.EnvVars))
+	for _, name := range cfg.EnvVars {
+		named[name] = true
+	}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && (named[name] || isSecretEnv(name)) {
+			r.AddValues(value)
+		}
+	}
+	return r, nil
+}
+
+func isSecretEnv(name string) bool {
+	name = strings.ToUpper(name)
+	for _, suffix := range secretEnvSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddValues masks values from now on, e.g. the credentials of the target
+// environment. Values shorter than 6 characters are ignored.
+func (r *Redactor) AddValues(values ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) < minValueLength {
+			continue
+		}
+		// Also mask the value as JSON and HTML reports escape it
+		for _, form := range []string{value, jsonEscape(value), html.EscapeString(value)} {
+			if !containsString(r.values, form) {
+				r.values = append(r.values, form)
+			}
+		}
+	}
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonEscape(s string) string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return strings.Trim(string(data), `"`)
+}
+
+// String returns s with every secret masked
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.RLock()
+	for _, value := range r.values {
+		s = strings.ReplaceAll(s, value, Mask)
+	}
+	r.mu.RUnlock()
+
+	for _, pattern := range r.patterns {
+		s = maskPattern(pattern, s)
+	}
+	return s
+}
+
+// maskPattern masks the matches of pattern in s, or their first capture
+// group when the pattern has one
+func maskPattern(pattern *regexp.Regexp, s string) string {
+	matches := pattern.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Writer returns a writer masking the secrets of each write to w. Each
+// write must be whole, as log and event lines are.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &writer{w: w, redact: r.String}
+}
+
+type writer struct {
+	w      io.Writer
+	redact func(string) string
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// std is the process-wide Redactor, masking the built-in patterns until
+// SetDefault configures it
+var std atomic.Pointer[Redactor]
+
+func init() {
+	r, _ := New(Config{}, nil)
+	std.Store(r)
+}
+
+// SetDefault replaces the process-wide Redactor
+func SetDefault(r *Redactor) {
+	std.Store(r)
+}
+
+// Default returns the process-wide Redactor
+func Default() *Redactor {
+	return std.Load()
+}
+
+// String masks secrets in s with the process-wide Redactor
+func String(s string) string {
+	return Default().String(s)
+}
+
+// Writer returns a writer masking the secrets of each write to w with the
+// process-wide Redactor at the time of the write
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w, redact: String}
+}
@@ -0,0 +1,297 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+// CoverageStatus is how far an endpoint's test by one model got: generated,
+// compiled, executed, passed
+type CoverageStatus string
+
+const (
+	// CoverageMissing means the model produced no test: the endpoint was not
+	// analyzed, or generation failed
+	CoverageMissing CoverageStatus = "missing"
+	// CoverageGenerated means the test was generated but not executed, so
+	// whether it compiles is unknown
+	CoverageGenerated CoverageStatus = "generated"
+	// CoverageCompileFailed means the test was executed and did not compile
+	CoverageCompileFailed CoverageStatus = "compile_failed"
+	// CoverageSkipped means the test compiled and skipped itself
+	CoverageSkipped CoverageStatus = "skipped"
+	// CoverageFailed means the test compiled, ran and failed
+	CoverageFailed CoverageStatus = "failed"
+	// CoveragePassed means the test compiled, ran and passed, possibly on retry
+	CoveragePassed CoverageStatus = "passed"
+)
+
+// Generated reports whether the test exists
+func (s CoverageStatus) Generated() bool { return s != CoverageMissing && s != "" }
+
+// Compiled reports whether the test is known to compile
+func (s CoverageStatus) Compiled() bool {
+	return s == CoverageSkipped || s == CoverageFailed || s == CoveragePassed
+}
+
+// Executed reports whether the test ran
+func (s CoverageStatus) Executed() bool { return s.Compiled() }
+
+// Passed reports whether the test passed
+func (s CoverageStatus) Passed() bool { return s == CoveragePassed }
+
+// icon is the status's cell in Markdown and HTML matrices
+func (s CoverageStatus) icon() string {
+	switch s {
+	case CoveragePassed:
+		return "✅"
+	case CoverageFailed:
+		return "❌"
+	case CoverageCompileFailed:
+		return "🔨"
+	case CoverageSkipped:
+		return "⏭️"
+	case CoverageGenerated:
+		return "📝"
+	default:
+		return "⬜"
+	}
+}
+
+// coverageLegend explains the icons of a coverage matrix
+const coverageLegend = "✅ passed · ❌ failed · 🔨 does not compile · ⏭️ skipped · 📝 generated, not executed · ⬜ missing"
+
+// Coverage maps every endpoint of a spec against every model of a run,
+// making untested endpoints and failing models visible at a glance.
+type Coverage struct {
+	Models    []string      `json:"models"`
+	Endpoints []CoverageRow `json:"endpoints"`
+	// ByMethod and ByTag total the cells per HTTP method and per tag; an
+	// endpoint with several tags counts towards each
+	ByMethod []CoverageTotals `json:"by_method"`
+	ByTag    []CoverageTotals `json:"by_tag"`
+	Total    CoverageTotals   `json:"total"`
+}
+
+// CoverageRow is an endpoint's status per model
+type CoverageRow struct {
+	ID     string                    `json:"id"`
+	Method string                    `json:"method"`
+	Path   string                    `json:"path"`
+	Tags   []string                  `json:"tags,omitempty"`
+	Status map[string]CoverageStatus `json:"status"` // key: AI model name
+}
+
+// CoverageTotals counts the endpoint × model cells of a group that reached
+// each stage
+type CoverageTotals struct {
+	Name      string `json:"name"`
+	Endpoints int    `json:"endpoints"`
+	Tests     int    `json:"tests"`
+	Generated int    `json:"generated"`
+	Compiled  int    `json:"compiled"`
+	Executed  int    `json:"executed"`
+	Passed    int    `json:"passed"`
+}
+
+// untagged names the ByTag group of endpoints without tags
+const untagged = "(untagged)"
+
+// BuildCoverage maps the endpoints of spec, followed by any results for
+// endpoints it does not list such as inferred scenarios, against models.
+func BuildCoverage(spec *parser.OpenAPISpec, results []EndpointResult, models []string) *Coverage {
+	coverage := &Coverage{Models: models, Total: CoverageTotals{Name: "Total"}}
+
+	byID := make(map[string]*EndpointResult, len(results))
+	for i := range results {
+		byID[results[i].Endpoint.ID] = &results[i]
+	}
+	endpoints := make([]parser.Endpoint, 0, len(spec.Endpoints)+len(results))
+	listed := make(map[string]bool, len(spec.Endpoints))
+	for _, endpoint := range spec.Endpoints {
+		endpoints = append(endpoints, endpoint)
+		listed[endpoint.ID] = true
+	}
+	for _, result := range results {
+		if !listed[result.Endpoint.ID] {
+			endpoints = append(endpoints, result.Endpoint)
+		}
+	}
+
+	methods := make(map[string]*CoverageTotals)
+	tags := make(map[string]*CoverageTotals)
+	group := func(groups map[string]*CoverageTotals, name string) *CoverageTotals {
+		if groups[name] == nil {
+			groups[name] = &CoverageTotals{Name: name}
+		}
+		return groups[name]
+	}
+
+	for _, endpoint := range endpoints {
+		row := CoverageRow{
+			ID:     endpoint.ID,
+			Method: endpoint.Method,
+			Path:   endpoint.Path,
+			Tags:   endpoint.Tags,
+			Status: make(map[string]CoverageStatus, len(models)),
+		}
+		for _, model := range models {
+			row.Status[model] = CoverageMissing
+			if result := byID[endpoint.ID]; result != nil {
+				if test, ok := result.Tests[model]; ok {
+					row.Status[model] = testCoverageStatus(&test)
+				}
+			}
+		}
+		coverage.Endpoints = append(coverage.Endpoints, row)
+
+		totals := []*CoverageTotals{&coverage.Total, group(methods, endpoint.Method)}
+		for _, tag := range endpointTags(&endpoint) {
+			totals = append(totals, group(tags, tag))
+		}
+		for _, t := range totals {
+			t.add(row, models)
+		}
+	}
+
+	coverage.ByMethod = sortedTotals(methods)
+	coverage.ByTag = sortedTotals(tags)
+	return coverage
+}
+
+// testCoverageStatus is how far a generated test got
+func testCoverageStatus(test *TestResult) CoverageStatus {
+	exec := test.ExecutionResult
+	switch {
+	case exec == nil:
+		return CoverageGenerated
+	case hasCompilationError(exec.Errors):
+		return CoverageCompileFailed
+	case exec.Passed:
+		return CoveragePassed
+	case exec.Skipped:
+		return CoverageSkipped
+	default:
+		return CoverageFailed
+	}
+}
+
+// hasCompilationError reports whether a test failed to compile, which the
+// generator records as an error of the "compilation" test
+func hasCompilationError(errs []generator.TestError) bool {
+	for _, e := range errs {
+		if e.TestName == "compilation" {
+			return true
+		}
+	}
+	return false
+}
+
+// add counts an endpoint's cells
+func (t *CoverageTotals) add(row CoverageRow, models []string) {
+	t.Endpoints++
+	for _, model := range models {
+		status := row.Status[model]
+		t.Tests++
+		if status.Generated() {
+			t.Generated++
+		}
+		if status.Compiled() {
+			t.Compiled++
+		}
+		if status.Executed() {
+			t.Executed++
+		}
+		if status.Passed() {
+			t.Passed++
+		}
+	}
+}
+
+// sortedTotals returns groups sorted by name
+func sortedTotals(groups map[string]*CoverageTotals) []CoverageTotals {
+	totals := make([]CoverageTotals, 0, len(groups))
+	for _, t := range groups {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Name < totals[j].Name })
+	return totals
+}
+
+// WriteCoverageCSV writes coverage as CSV, one row per endpoint and model,
+// with the stages the test reached as true/false columns.
+func WriteCoverageCSV(w io.Writer, coverage *Coverage) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"endpoint_id", "method", "path", "tags", "model", "status", "generated", "compiled", "executed", "passed"})
+	for _, row := range coverage.Endpoints {
+		for _, model := range coverage.Models {
+			status := row.Status[model]
+			_ = out.Write([]string{
+				row.ID, row.Method, row.Path, strings.Join(row.Tags, ";"), model, string(status),
+				strconv.FormatBool(status.Generated()), strconv.FormatBool(status.Compiled()),
+				strconv.FormatBool(status.Executed()), strconv.FormatBool(status.Passed()),
+			})
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write coverage CSV: %w", err)
+	}
+	return nil
+}
+
+// RenderCoverageMarkdown returns the coverage section of a Markdown report.
+func RenderCoverageMarkdown(coverage *Coverage) string {
+	var md strings.Builder
+	writeCoverage(&md, coverage)
+	return md.String()
+}
+
+// writeCoverage writes the endpoint × model matrix and the totals by method
+// and by tag
+func writeCoverage(md *strings.Builder, coverage *Coverage) {
+	fmt.Fprintf(md, "%s\n\n", coverageLegend)
+	fmt.Fprintf(md, "| Endpoint |")
+	for _, model := range coverage.Models {
+		fmt.Fprintf(md, " %s |", model)
+	}
+	fmt.Fprintf(md, "\n|----------|%s\n", strings.Repeat("---|", len(coverage.Models)))
+	for _, row := range coverage.Endpoints {
+		fmt.Fprintf(md, "| `%s %s` |", row.Method, row.Path)
+		for _, model := range coverage.Models {
+			fmt.Fprintf(md, " %s |", row.Status[model].icon())
+		}
+		fmt.Fprintf(md, "\n")
+	}
+	fmt.Fprintf(md, "\n")
+
+	for _, section := range []struct {
+		title, column string
+		totals        []CoverageTotals
+	}{
+		{"By Method", "Method", coverage.ByMethod},
+		{"By Tag", "Tag", coverage.ByTag},
+	} {
+		fmt.Fprintf(md, "### %s\n\n", section.title)
+		fmt.Fprintf(md, "| %s | Endpoints | Generated | Compiled | Executed | Passed |\n", section.column)
+		fmt.Fprintf(md, "|%s|-----------|-----------|----------|----------|--------|\n", strings.Repeat("-", len(section.column)+2))
+		for _, t := range section.totals {
+			writeCoverageTotals(md, t.Name, t)
+		}
+		writeCoverageTotals(md, "**Total**", coverage.Total)
+		fmt.Fprintf(md, "\n")
+	}
+}
+
+// writeCoverageTotals writes a row of totals named name
+func writeCoverageTotals(md *strings.Builder, name string, t CoverageTotals) {
+	fmt.Fprintf(md, "| %s | %d | %d/%d | %d/%d | %d/%d | %d/%d |\n", name, t.Endpoints,
+		t.Generated, t.Tests, t.Compiled, t.Tests, t.Executed, t.Tests, t.Passed, t.Tests)
+}
@@ -0,0 +1,165 @@
+package reporter
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+// DiagnosticSeverity is the severity of a Diagnostic, as defined by the
+// Language Server Protocol
+type DiagnosticSeverity int
+
+// Diagnostic severities glens reports: failing tests are errors, spec
+// warnings and quality gaps warnings
+const (
+	SeverityError   DiagnosticSeverity = 1
+	SeverityWarning DiagnosticSeverity = 2
+)
+
+// diagnosticSource names glens as the producer of diagnostics in editors
+const diagnosticSource = "glens"
+
+// Diagnostic codes of findings that are not spec quality checks, whose
+// codes are the check names (parser.CheckDocumented, ...)
+const (
+	// CodeTestFailed marks an operation whose generated test failed
+	CodeTestFailed = "test_failed"
+	// CodeTestError marks an operation whose generated test could not be
+	// executed, e.g. because it did not compile
+	CodeTestError = "test_error"
+)
+
+// FileDiagnostics are the diagnostics of one file, shaped like the params
+// of the Language Server Protocol textDocument/publishDiagnostics
+// notification so editor extensions can publish them as they are
+type FileDiagnostics struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Diagnostic is a finding keyed to a range of the spec file
+type Diagnostic struct {
+	Range    DiagnosticRange    `json:"range"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Code     string             `json:"code"`
+	Source   string             `json:"source"`
+	Message  string             `json:"message"`
+}
+
+// DiagnosticRange is a range of a file; the end is exclusive
+type DiagnosticRange struct {
+	Start DiagnosticPosition `json:"start"`
+	End   DiagnosticPosition `json:"end"`
+}
+
+// DiagnosticPosition is a 0-based line and character of a file
+type DiagnosticPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Diagnostics returns the failing tests, spec warnings and spec quality
+// gaps of report as diagnostics of the spec file, at the operations or
+// nodes they concern.
+// Findings of operations without a position in the spec, such as GraphQL
+// fields, point at the start of the file.
+func Diagnostics(report *Report) []FileDiagnostics {
+	spec := &report.Specification
+	diagnostics := []Diagnostic{}
+	for i := range report.EndpointResults {
+		result := &report.EndpointResults[i]
+		operation := result.Endpoint.Method + " " + result.Endpoint.Path
+		for _, model := range slices.Sorted(maps.Keys(result.Tests)) {
+			test := result.Tests[model]
+			switch execution := test.ExecutionResult; {
+			case execution != nil && execution.Failed && !execution.Passed:
+				diagnostics = append(diagnostics, Diagnostic{
+					Range:    diagnosticRange(result.Endpoint.Position),
+					Severity: SeverityError,
+					Code:     CodeTestFailed,
+					Source:   diagnosticSource,
+					Message:  fmt.Sprintf("%s: test generated by %s failed%s", operation, model, firstTestError(execution.Errors)),
+				})
+			case test.ExecutionError != "":
+				diagnostics = append(diagnostics, Diagnostic{
+					Range:    diagnosticRange(result.Endpoint.Position),
+					Severity: SeverityError,
+					Code:     CodeTestError,
+					Source:   diagnosticSource,
+					Message:  fmt.Sprintf("%s: test generated by %s could not run: %s", operation, model, firstLine(test.ExecutionError)),
+				})
+			}
+		}
+	}
+
+	for _, warning := range spec.Warnings {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    diagnosticRange(warning.Position),
+			Severity: SeverityWarning,
+			Code:     warning.Code,
+			Source:   diagnosticSource,
+			Message:  warning.Message,
+		})
+	}
+
+	if report.SpecQuality != nil {
+		for _, gap := range report.SpecQuality.Gaps {
+			for i, check := range gap.Checks {
+				diagnostics = append(diagnostics, Diagnostic{
+					Range:    diagnosticRange(gap.Position),
+					Severity: SeverityWarning,
+					Code:     check,
+					Source:   diagnosticSource,
+					Message:  fmt.Sprintf("%s %s: %s", gap.Method, gap.Path, gap.Suggestions[i]),
+				})
+			}
+		}
+	}
+
+	return []FileDiagnostics{{URI: specURI(spec.Source), Diagnostics: diagnostics}}
+}
+
+// diagnosticRange returns the empty range at position, which editors
+// widen to the word there
+func diagnosticRange(position *parser.Position) DiagnosticRange {
+	var start DiagnosticPosition
+	if position != nil {
+		start = DiagnosticPosition{Line: position.Line - 1, Character: position.Column - 1}
+	}
+	return DiagnosticRange{Start: start, End: start}
+}
+
+// specURI returns the URI of the spec read from source: URLs as they are,
+// file paths as absolute file URIs
+func specURI(source string) string {
+	if source == "" || strings.Contains(source, "://") {
+		return source
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(source)}).String()
+}
+
+// firstTestError describes the first of errors, if any
+func firstTestError(errors []generator.TestError) string {
+	if len(errors) == 0 {
+		return ""
+	}
+	if errors[0].TestName == "" {
+		return ": " + firstLine(errors[0].Message)
+	}
+	return fmt.Sprintf(": %s: %s", errors[0].TestName, firstLine(errors[0].Message))
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
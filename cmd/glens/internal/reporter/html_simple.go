@@ -45,12 +45,94 @@ func generateHTMLReportSimple(report *Report) (string, error) {
 	fmt.Fprintf(&htmlBuilder, "<tr><td>Total Tests</td><td>%d</td></tr>\n", report.Summary.TotalTests)
 	fmt.Fprintf(&htmlBuilder, "<tr><td>Tests Passed</td><td>%d</td></tr>\n", report.Summary.PassedTests)
 	fmt.Fprintf(&htmlBuilder, "<tr><td>Tests Failed</td><td>%d</td></tr>\n", report.Summary.FailedTests)
+	fmt.Fprintf(&htmlBuilder, "<tr><td>Tests Flaky</td><td>%d</td></tr>\n", report.Summary.FlakyTests)
 	fmt.Fprintf(&htmlBuilder, "<tr><td>Overall Health Score</td><td>%.1f%%</td></tr>\n", report.Summary.OverallHealthScore)
 	htmlBuilder.WriteString("</table>\n")
 
+	if len(report.Tags) > 0 {
+		htmlBuilder.WriteString("<h2>🏷️ By Tag</h2>\n")
+		writeHTMLTagSummaries(&htmlBuilder, report.Tags)
+	}
+
+	if coverage := report.Coverage; coverage != nil && len(coverage.Models) > 0 {
+		htmlBuilder.WriteString("<h2>🗺️ Coverage Map</h2>\n")
+		fmt.Fprintf(&htmlBuilder, "<p>%s</p>\n", coverageLegend)
+		htmlBuilder.WriteString("<table>\n<tr><th>Endpoint</th>")
+		for _, model := range coverage.Models {
+			fmt.Fprintf(&htmlBuilder, "<th>%s</th>", html.EscapeString(model))
+		}
+		htmlBuilder.WriteString("</tr>\n")
+		for _, row := range coverage.Endpoints {
+			fmt.Fprintf(&htmlBuilder, "<tr><td><code>%s %s</code></td>", html.EscapeString(row.Method), html.EscapeString(row.Path))
+			for _, model := range coverage.Models {
+				status := row.Status[model]
+				fmt.Fprintf(&htmlBuilder, "<td title=\"%s\">%s</td>", status, status.icon())
+			}
+			htmlBuilder.WriteString("</tr>\n")
+		}
+		htmlBuilder.WriteString("</table>\n")
+		for _, section := range []struct {
+			column string
+			totals []CoverageTotals
+		}{
+			{"Method", coverage.ByMethod},
+			{"Tag", coverage.ByTag},
+		} {
+			htmlBuilder.WriteString("<table>\n")
+			fmt.Fprintf(&htmlBuilder, "<tr><th>%s</th><th>Endpoints</th><th>Generated</th><th>Compiled</th><th>Executed</th><th>Passed</th></tr>\n", section.column)
+			for _, t := range section.totals {
+				writeHTMLCoverageTotals(&htmlBuilder, html.EscapeString(t.Name), t)
+			}
+			writeHTMLCoverageTotals(&htmlBuilder, "<strong>Total</strong>", coverage.Total)
+			htmlBuilder.WriteString("</table>\n")
+		}
+	}
+
+	if len(report.DeprecatedOperations) > 0 {
+		htmlBuilder.WriteString("<h2>⚠️ Deprecated Operations</h2>\n")
+		htmlBuilder.WriteString("<table>\n")
+		htmlBuilder.WriteString("<tr><th>Operation</th><th>Operation ID</th><th>Tested</th></tr>\n")
+		for _, op := range report.DeprecatedOperations {
+			tested := "No"
+			if op.Tested {
+				tested = "Yes"
+			}
+			fmt.Fprintf(&htmlBuilder, "<tr><td><code>%s %s</code></td><td>%s</td><td>%s</td></tr>\n",
+				html.EscapeString(op.Method), html.EscapeString(op.Path), html.EscapeString(op.OperationID), tested)
+		}
+		htmlBuilder.WriteString("</table>\n")
+	}
+
+	if quality := report.SpecQuality; quality != nil {
+		htmlBuilder.WriteString("<h2>📝 Spec Quality</h2>\n")
+		fmt.Fprintf(&htmlBuilder, "<p><strong>Score:</strong> %.1f/100 across %d operation(s)</p>\n", quality.Score, quality.Operations)
+		htmlBuilder.WriteString("<table>\n")
+		htmlBuilder.WriteString("<tr><th>Check</th><th>Operations</th><th>Coverage</th></tr>\n")
+		for _, check := range quality.Checks {
+			fmt.Fprintf(&htmlBuilder, "<tr><td>%s</td><td>%d/%d</td><td>%.1f%%</td></tr>\n",
+				html.EscapeString(check.Description), check.Passed, quality.Operations, check.Percentage)
+		}
+		htmlBuilder.WriteString("</table>\n")
+		if len(quality.Gaps) > 0 {
+			htmlBuilder.WriteString("<ul>\n")
+			for _, gap := range quality.Gaps {
+				fmt.Fprintf(&htmlBuilder, "<li><code>%s %s</code>: %s</li>\n", html.EscapeString(gap.Method),
+					html.EscapeString(gap.Path), html.EscapeString(strings.Join(gap.Suggestions, "; ")))
+			}
+			htmlBuilder.WriteString("</ul>\n")
+		}
+	}
+
 	// Footer
 	htmlBuilder.WriteString("<p><em>This report was automatically generated by Glens</em></p>")
 	htmlBuilder.WriteString("</body></html>")
 
 	return htmlBuilder.String(), nil
 }
+
+// writeHTMLCoverageTotals writes a row of coverage totals named name, which
+// is HTML
+func writeHTMLCoverageTotals(htmlBuilder *strings.Builder, name string, t CoverageTotals) {
+	fmt.Fprintf(htmlBuilder, "<tr><td>%s</td><td>%d</td><td>%d/%d</td><td>%d/%d</td><td>%d/%d</td><td>%d/%d</td></tr>\n",
+		name, t.Endpoints, t.Generated, t.Tests, t.Compiled, t.Tests, t.Executed, t.Tests, t.Passed, t.Tests)
+}
@@ -2,6 +2,7 @@ package reporter
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -60,7 +61,22 @@ func generateMarkdownReport(report *Report) (string, error) {
 
 	// Detailed Endpoint Results
 	fmt.Fprintf(&md, "## 🎯 Endpoint Test Results\n\n")
-	writeEndpointResults(&md, report.EndpointResults)
+	writeEndpointResults(&md, &report.Specification, report.EndpointResults, report.Tags)
+
+	if report.Coverage != nil && len(report.Coverage.Models) > 0 {
+		fmt.Fprintf(&md, "## 🗺️ Coverage Map\n\n")
+		writeCoverage(&md, report.Coverage)
+	}
+
+	if len(report.DeprecatedOperations) > 0 {
+		fmt.Fprintf(&md, "## ⚠️ Deprecated Operations\n\n")
+		writeDeprecatedOperations(&md, report.DeprecatedOperations)
+	}
+
+	if report.SpecQuality != nil {
+		fmt.Fprintf(&md, "## 📝 Spec Quality\n\n")
+		writeSpecQuality(&md, &report.Specification, report.SpecQuality)
+	}
 
 	// Recommendations
 	if len(report.ModelComparison.Recommendations) > 0 {
@@ -85,21 +101,14 @@ func writeExecutiveSummary(md *strings.Builder, summary *Summary) {
 	fmt.Fprintf(md, "| **Tests Passed** | %d ✅ |\n", summary.PassedTests)
 	fmt.Fprintf(md, "| **Tests Failed** | %d ❌ |\n", summary.FailedTests)
 	fmt.Fprintf(md, "| **Tests Skipped** | %d ⏭️ |\n", summary.SkippedTests)
+	fmt.Fprintf(md, "| **Tests Flaky** | %d ⚠️ |\n", summary.FlakyTests)
 	fmt.Fprintf(md, "| **GitHub Issues Created** | %d |\n", summary.TotalIssuesCreated)
 	fmt.Fprintf(md, "| **AI Models Used** | %s |\n", strings.Join(summary.AIModelsUsed, ", "))
+	fmt.Fprintf(md, "| **Average Test Coverage** | %.1f%% |\n", summary.AverageCoverage)
 	fmt.Fprintf(md, "| **Overall Health Score** | %.1f%% |\n", summary.OverallHealthScore)
 
-	// Health Score Badge
-	healthEmoji := "🟢"
-	if summary.OverallHealthScore < 70 {
-		healthEmoji = "🟡"
-	}
-	if summary.OverallHealthScore < 50 {
-		healthEmoji = "🔴"
-	}
-
 	fmt.Fprintf(md, "\n### Overall Health Status\n\n")
-	fmt.Fprintf(md, "%s **%.1f%%** - ", healthEmoji, summary.OverallHealthScore)
+	fmt.Fprintf(md, "%s **%.1f%%** - ", healthBadge(summary.OverallHealthScore), summary.OverallHealthScore)
 
 	switch {
 	case summary.OverallHealthScore >= 80:
@@ -179,17 +188,18 @@ func writeModelComparison(md *strings.Builder, comparison *ModelComparison) {
 
 	// Overall comparison table
 	fmt.Fprintf(md, "### Model Performance Overview\n\n")
-	fmt.Fprintf(md, "| Model | Tests Generated | Success Rate | Avg Quality | Avg Coverage | Avg Execution Time |\n")
-	fmt.Fprintf(md, "|-------|----------------|--------------|-------------|--------------|-------------------|\n")
+	fmt.Fprintf(md, "| Model | Tests Generated | Success Rate | Avg Quality | Avg Coverage | Weak Tests | Avg Execution Time |\n")
+	fmt.Fprintf(md, "|-------|----------------|--------------|-------------|--------------|------------|-------------------|\n")
 
 	for i := range comparison.Models {
 		model := &comparison.Models[i]
-		fmt.Fprintf(md, "| **%s** | %d | %.1f%% | %.1f | %.1f%% | %s |\n",
+		fmt.Fprintf(md, "| **%s** | %d | %.1f%% | %.1f | %.1f%% | %.1f%% | %s |\n",
 			model.ModelName,
 			model.TestsGenerated,
 			model.SuccessRate*100,
 			model.AvgQualityScore,
 			model.AvgCoverageScore,
+			model.WeakTestRate*100,
 			model.AvgExecutionTime)
 	}
 
@@ -251,9 +261,11 @@ func writeModelComparison(md *strings.Builder, comparison *ModelComparison) {
 		fmt.Fprintf(md, "- Tests Generated: %d\n", model.TestsGenerated)
 		fmt.Fprintf(md, "- Tests Passed: %d\n", model.TestsPassed)
 		fmt.Fprintf(md, "- Tests Failed: %d\n", model.TestsFailed)
+		fmt.Fprintf(md, "- Tests Flaky: %d\n", model.TestsFlaky)
 		fmt.Fprintf(md, "- Success Rate: %.1f%%\n", model.SuccessRate*100)
 		fmt.Fprintf(md, "- Average Quality Score: %.1f\n", model.AvgQualityScore)
 		fmt.Fprintf(md, "- Average Coverage: %.1f%%\n", model.AvgCoverageScore)
+		fmt.Fprintf(md, "- Weak Test Rate: %.1f%%\n", model.WeakTestRate*100)
 		fmt.Fprintf(md, "- Average Execution Time: %s\n", model.AvgExecutionTime)
 		fmt.Fprintf(md, "- Total Tokens Used: %d\n", model.TotalTokensUsed)
 
@@ -261,14 +273,93 @@ func writeModelComparison(md *strings.Builder, comparison *ModelComparison) {
 	}
 }
 
-// writeEndpointResults writes the detailed endpoint results
-func writeEndpointResults(md *strings.Builder, results []EndpointResult) {
+// writeEndpointResults writes the detailed endpoint results, summarized per
+// tag and grouped by each endpoint's first tag when the spec has tags
+func writeEndpointResults(md *strings.Builder, spec *parser.OpenAPISpec, results []EndpointResult, tags []TagSummary) {
 	if len(results) == 0 {
 		fmt.Fprintf(md, "No endpoint results available.\n\n")
 		return
 	}
 
+	if len(tags) > 0 {
+		fmt.Fprintf(md, "### By Tag\n\n")
+		writeTagSummaries(md, tags)
+	}
+
 	fmt.Fprintf(md, "### Summary\n\n")
+	if len(tags) == 0 {
+		writeEndpointSummaryTable(md, results)
+	} else {
+		groups := make(map[string][]EndpointResult)
+		for i := range results {
+			tag := endpointTags(&results[i].Endpoint)[0]
+			groups[tag] = append(groups[tag], results[i])
+		}
+		for _, tag := range tags {
+			if len(groups[tag.Tag]) == 0 {
+				continue
+			}
+			fmt.Fprintf(md, "#### 🏷️ %s\n\n", tag.Tag)
+			fmt.Fprintf(md, "%s Health %.1f%% · %d/%d tests passed · %d issue(s)\n\n",
+				healthBadge(tag.HealthScore), tag.HealthScore, tag.PassedTests, tag.TotalTests, tag.IssuesCreated)
+			writeEndpointSummaryTable(md, groups[tag.Tag])
+			fmt.Fprintf(md, "\n")
+		}
+	}
+
+	// Detailed results for each endpoint
+	fmt.Fprintf(md, "\n### Detailed Results\n\n")
+	for i := range results {
+		result := &results[i]
+		fmt.Fprintf(md, "#### %d. %s %s%s%s\n\n", i+1, result.Endpoint.Method, result.Endpoint.Path,
+			graphQLMark(&result.Endpoint), deprecatedMark(&result.Endpoint))
+
+		if result.Endpoint.Summary != "" {
+			fmt.Fprintf(md, "**Summary:** %s\n\n", result.Endpoint.Summary)
+		}
+
+		if location := spec.Location(result.Endpoint.Position); location != "" {
+			fmt.Fprintf(md, "**Location:** `%s`\n\n", location)
+		}
+
+		if result.RiskLevel != "" {
+			fmt.Fprintf(md, "**Category:** %s (%s risk)\n\n", result.Category, result.RiskLevel)
+		}
+
+		if result.Route != "" {
+			fmt.Fprintf(md, "**Models:** %s (routed by %s)\n\n", strings.Join(result.Models, ", "), result.Route)
+		}
+
+		if result.SkipReason != "" {
+			fmt.Fprintf(md, "> ⏭️ Skipped: %s\n\n", result.SkipReason)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(md, "> ⚠️ %s\n\n", warning)
+		}
+
+		if result.IssueNumber > 0 {
+			fmt.Fprintf(md, "**GitHub Issue:** #%d\n\n", result.IssueNumber)
+		}
+
+		fmt.Fprintf(md, "**Test Results by Model:**\n\n")
+		for modelName := range result.Tests {
+			test := result.Tests[modelName]
+			fmt.Fprintf(md, "##### Model: %s\n\n", modelName)
+			writeTestResult(md, &test)
+			fmt.Fprintf(md, "\n")
+		}
+
+		if result.Ensemble != nil {
+			writeEnsemble(md, result.Ensemble)
+		}
+
+		fmt.Fprintf(md, "---\n\n")
+	}
+}
+
+// writeEndpointSummaryTable writes a summary row per endpoint result
+func writeEndpointSummaryTable(md *strings.Builder, results []EndpointResult) {
 	fmt.Fprintf(md, "| Endpoint | Status | Issue | Tests | Passed | Failed | Overall Score |\n")
 	fmt.Fprintf(md, "|----------|--------|-------|-------|--------|--------|--------------|\n")
 
@@ -294,9 +385,11 @@ func writeEndpointResults(md *strings.Builder, results []EndpointResult) {
 			}
 		}
 
-		fmt.Fprintf(md, "| `%s %s` | %s %s | %s | %d | %d | %d | %.1f |\n",
+		fmt.Fprintf(md, "| `%s %s`%s%s | %s %s | %s | %d | %d | %d | %.1f |\n",
 			result.Endpoint.Method,
 			result.Endpoint.Path,
+			graphQLMark(&result.Endpoint),
+			deprecatedMark(&result.Endpoint),
 			statusEmoji,
 			result.Status,
 			issueLink,
@@ -305,62 +398,172 @@ func writeEndpointResults(md *strings.Builder, results []EndpointResult) {
 			failedCount,
 			result.OverallScore)
 	}
+}
 
-	// Detailed results for each endpoint
-	fmt.Fprintf(md, "\n### Detailed Results\n\n")
-	for i := range results {
-		result := &results[i]
-		fmt.Fprintf(md, "#### %d. %s %s\n\n", i+1, result.Endpoint.Method, result.Endpoint.Path)
+// deprecatedMark flags deprecated endpoints in headings and tables
+func deprecatedMark(endpoint *parser.Endpoint) string {
+	if endpoint.Deprecated {
+		return " (deprecated)"
+	}
+	return ""
+}
 
-		if result.Endpoint.Summary != "" {
-			fmt.Fprintf(md, "**Summary:** %s\n\n", result.Endpoint.Summary)
+// graphQLMark names the query or mutation of GraphQL endpoints, which all
+// share one path, in headings and tables
+func graphQLMark(endpoint *parser.Endpoint) string {
+	if endpoint.GraphQL == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%s %s)", endpoint.GraphQL.Type, endpoint.GraphQL.Field)
+}
+
+// writeDeprecatedOperations lists the deprecated operations still present
+// in the spec
+func writeDeprecatedOperations(md *strings.Builder, operations []DeprecatedOperation) {
+	fmt.Fprintf(md, "The specification still contains %d deprecated operation(s). ", len(operations))
+	fmt.Fprintf(md, "They are not tested unless --include-deprecated is set.\n\n")
+	fmt.Fprintf(md, "| Operation | Operation ID | Tested |\n")
+	fmt.Fprintf(md, "|-----------|--------------|--------|\n")
+	for _, op := range operations {
+		operationID, tested := "-", "No"
+		if op.OperationID != "" {
+			operationID = op.OperationID
+		}
+		if op.Tested {
+			tested = "Yes"
 		}
+		fmt.Fprintf(md, "| `%s %s` | %s | %s |\n", op.Method, op.Path, operationID, tested)
+	}
+	fmt.Fprintf(md, "\n")
+}
 
-		if result.IssueNumber > 0 {
-			fmt.Fprintf(md, "**GitHub Issue:** #%d\n\n", result.IssueNumber)
+// writeSpecQuality writes the spec's quality score, how many operations
+// pass each check and what each failing operation should add
+func writeSpecQuality(md *strings.Builder, spec *parser.OpenAPISpec, quality *parser.SpecQuality) {
+	fmt.Fprintf(md, "**Score:** %.1f/100 across %d operation(s). ", quality.Score, quality.Operations)
+	fmt.Fprintf(md, "Generated tests can only check what the spec documents.\n\n")
+	fmt.Fprintf(md, "| Check | Operations | Coverage |\n")
+	fmt.Fprintf(md, "|-------|------------|----------|\n")
+	for _, check := range quality.Checks {
+		fmt.Fprintf(md, "| %s | %d/%d | %.1f%% |\n", check.Description, check.Passed, quality.Operations, check.Percentage)
+	}
+	fmt.Fprintf(md, "\n")
+
+	if len(quality.Gaps) == 0 {
+		return
+	}
+	fmt.Fprintf(md, "### Gaps\n\n")
+	for _, gap := range quality.Gaps {
+		operationID := ""
+		if gap.OperationID != "" {
+			operationID = " (" + gap.OperationID + ")"
 		}
+		location := ""
+		if gap.Position != nil {
+			location = " at `" + spec.Location(gap.Position) + "`"
+		}
+		fmt.Fprintf(md, "- `%s %s`%s%s: %s\n", gap.Method, gap.Path, operationID, location, strings.Join(gap.Suggestions, "; "))
+	}
+	fmt.Fprintf(md, "\n")
+}
 
-		fmt.Fprintf(md, "**Test Results by Model:**\n\n")
-		for modelName := range result.Tests {
-			test := result.Tests[modelName]
-			fmt.Fprintf(md, "##### Model: %s\n\n", modelName)
+// writeEnsemble writes the test an ensemble of models produced for an
+// endpoint
+func writeEnsemble(md *strings.Builder, ensemble *EnsembleResult) {
+	fmt.Fprintf(md, "##### Ensemble (%s)\n\n", ensemble.Mode)
+	if ensemble.Selected != "" {
+		fmt.Fprintf(md, "- **Selected Model:** %s\n", ensemble.Selected)
+	}
+	models := make([]string, 0, len(ensemble.Contributions))
+	for model := range ensemble.Contributions {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	fmt.Fprintf(md, "- **Contributions:**\n")
+	for _, model := range models {
+		fmt.Fprintf(md, "  - %s: %s\n", model, orNone(ensemble.Contributions[model]))
+	}
+	writeTestResult(md, &ensemble.Test)
+	fmt.Fprintf(md, "\n")
+}
 
-			if test.ExecutionResult != nil {
-				status := "✅ Passed"
-				if test.ExecutionResult.Failed {
-					status = "❌ Failed"
-				} else if test.ExecutionResult.Skipped {
-					status = "⏭️ Skipped"
-				}
+// writeTestResult writes the outcome and metrics of one generated test
+func writeTestResult(md *strings.Builder, test *TestResult) {
+	if test.ExecutionResult != nil {
+		status := "✅ Passed"
+		switch {
+		case test.ExecutionResult.Failed:
+			status = "❌ Failed"
+		case test.ExecutionResult.Flaky:
+			status = fmt.Sprintf("⚠️ Flaky (passed on attempt %d)", test.ExecutionResult.Attempts)
+		case test.ExecutionResult.Skipped:
+			status = "⏭️ Skipped"
+		}
 
-				fmt.Fprintf(md, "- **Status:** %s\n", status)
-				fmt.Fprintf(md, "- **Duration:** %s\n", test.ExecutionResult.Duration)
-				fmt.Fprintf(md, "- **Test Count:** %d\n", test.ExecutionResult.TestCount)
-
-				if len(test.ExecutionResult.Errors) > 0 {
-					fmt.Fprintf(md, "- **Errors:**\n")
-					for _, err := range test.ExecutionResult.Errors {
-						if err.Message != "" {
-							fmt.Fprintf(md, "  - %s: %s\n", err.TestName, err.Message)
-						} else {
-							fmt.Fprintf(md, "  - %s\n", err.TestName)
-						}
-					}
-				}
-			} else if test.ExecutionError != "" {
-				fmt.Fprintf(md, "- **Status:** ❌ Execution Error\n")
-				fmt.Fprintf(md, "- **Error:** %s\n", test.ExecutionError)
-			}
+		fmt.Fprintf(md, "- **Status:** %s\n", status)
+		fmt.Fprintf(md, "- **Duration:** %s\n", test.ExecutionResult.Duration)
+		fmt.Fprintf(md, "- **Test Count:** %d\n", test.ExecutionResult.TestCount)
+		if test.ExecutionResult.Attempts > 1 {
+			fmt.Fprintf(md, "- **Attempts:** %d\n", test.ExecutionResult.Attempts)
+		}
 
-			fmt.Fprintf(md, "- **Quality Score:** %.1f\n", test.QualityScore)
-			fmt.Fprintf(md, "- **Framework:** %s\n", test.Framework)
-			fmt.Fprintf(md, "- **Generated At:** %s\n", test.GeneratedAt.Format(time.RFC3339))
+		if len(test.ExecutionResult.FlakyErrors) > 0 {
+			fmt.Fprintf(md, "- **Failures Before Retry:**\n")
+			for _, err := range test.ExecutionResult.FlakyErrors {
+				fmt.Fprintf(md, "  - %s\n", err.TestName)
+			}
+		}
 
-			fmt.Fprintf(md, "\n")
+		if len(test.ExecutionResult.Errors) > 0 {
+			fmt.Fprintf(md, "- **Errors:**\n")
+			for _, err := range test.ExecutionResult.Errors {
+				if err.Message != "" {
+					fmt.Fprintf(md, "  - %s: %s\n", err.TestName, err.Message)
+				} else {
+					fmt.Fprintf(md, "  - %s\n", err.TestName)
+				}
+			}
 		}
+	} else if test.ExecutionError != "" {
+		fmt.Fprintf(md, "- **Status:** ❌ Execution Error\n")
+		fmt.Fprintf(md, "- **Error:** %s\n", test.ExecutionError)
+	}
 
-		fmt.Fprintf(md, "---\n\n")
+	fmt.Fprintf(md, "- **Quality Score:** %.1f\n", test.QualityScore)
+	coverage := test.Metrics.TestCoverage
+	fmt.Fprintf(md, "- **Coverage:** %.1f%% (status codes %s, %d/%d parameters)\n",
+		coverage.CoveragePercentage, orNone(coverage.StatusCodesCovered), coverage.ParametersCovered, coverage.ParametersTotal)
+	if weak := test.Metrics.CodeQuality.WeakTests; len(weak) > 0 {
+		fmt.Fprintf(md, "- **Weak Tests:** ⚠️ %s assert no response status, body or header\n", strings.Join(weak, ", "))
+	}
+	if findings := test.Metrics.CodeQuality.StaticFindings; len(findings) > 0 {
+		fmt.Fprintf(md, "- **Static Analysis:** %d finding(s)\n", len(findings))
+		for _, f := range findings {
+			fmt.Fprintf(md, "  - %s %s (%s) line %d: %s\n", f.Tool, f.Rule, f.Severity, f.Line, f.Message)
+		}
 	}
+	fmt.Fprintf(md, "- **Framework:** %s\n", test.Framework)
+	if sampling := samplingSummary(test.Metadata); sampling != "" {
+		fmt.Fprintf(md, "- **Sampling:** %s\n", sampling)
+	}
+	if generatedBy := test.Metadata["generated_by"]; generatedBy != "" {
+		fmt.Fprintf(md, "- **Generated By:** %s (fallback from %s: %s)\n",
+			generatedBy, test.Metadata["fallback_from"], test.Metadata["fallback_reason"])
+	}
+	if attempts, ok := test.Metadata["repair_attempts"]; ok {
+		outcome := "still failing"
+		if test.Metadata["repaired"] == "true" {
+			outcome = "repaired"
+		}
+		fmt.Fprintf(md, "- **Repair Attempts:** %s (%s)\n", attempts, outcome)
+	}
+	if test.ArtifactsDir != "" {
+		fmt.Fprintf(md, "- **Artifacts:** `%s`\n", test.ArtifactsDir)
+	}
+	if test.GistURL != "" {
+		fmt.Fprintf(md, "- **Failing Test:** [gist](%s)\n", test.GistURL)
+	}
+	fmt.Fprintf(md, "- **Generated At:** %s\n", test.GeneratedAt.Format(time.RFC3339))
 }
 
 // writeRecommendations writes the recommendations section
@@ -403,9 +606,41 @@ func writeAppendices(md *strings.Builder, report *Report) {
 	fmt.Fprintf(md, "\n### B. Test Execution Environment\n\n")
 	fmt.Fprintf(md, "- **Test Framework:** Go with testify\n")
 	fmt.Fprintf(md, "- **Execution Mode:** Sequential\n")
-	fmt.Fprintf(md, "- **Timeout:** 2 minutes per test\n")
+	if timeout, ok := report.Metadata["test_timeout"]; ok {
+		fmt.Fprintf(md, "- **Timeout:** %v per test attempt\n", timeout)
+	} else {
+		fmt.Fprintf(md, "- **Timeout:** 2 minutes per test\n")
+	}
+	if retries, ok := report.Metadata["test_retries"]; ok {
+		fmt.Fprintf(md, "- **Retries:** %v (tests passing on retry are reported as flaky)\n", retries)
+	}
 	fmt.Fprintf(md, "- **Report Generated:** %s\n\n", report.GeneratedAt.Format(time.RFC3339))
 
+	// The optional appendices are lettered from C on
+	letter := 'C'
+	if len(report.RequiredEnv) > 0 {
+		fmt.Fprintf(md, "### %c. Required Environment Variables\n\n", letter)
+		letter++
+		fmt.Fprintf(md, "The generated tests read their target and credentials from these variables:\n\n")
+		fmt.Fprintf(md, "| Variable | Tests | Description |\n")
+		fmt.Fprintf(md, "|----------|-------|-------------|\n")
+		for _, variable := range report.RequiredEnv {
+			fmt.Fprintf(md, "| `%s` | %d | %s |\n", variable.Name, variable.Tests, variable.Description)
+		}
+		fmt.Fprintf(md, "\n")
+	}
+
+	if spec := &report.Specification; len(spec.Warnings) > 0 {
+		fmt.Fprintf(md, "### %c. Spec Warnings\n\n", letter)
+		fmt.Fprintf(md, "Parts of the spec glens could not understand were skipped; tests of the operations using them may be incomplete.\n\n")
+		fmt.Fprintf(md, "| Location | Path | Problem |\n")
+		fmt.Fprintf(md, "|----------|------|---------|\n")
+		for _, warning := range spec.Warnings {
+			fmt.Fprintf(md, "| `%s` | `%s` | %s |\n", spec.Location(warning.Position), warning.Path, strings.ReplaceAll(warning.Message, "|", `\|`))
+		}
+		fmt.Fprintf(md, "\n")
+	}
+
 	fmt.Fprintf(md, "---\n\n")
 	fmt.Fprintf(md, "This report was automatically generated by Glens\n")
 }
@@ -425,3 +660,22 @@ func getStatusEmoji(status EndpointStatus) string {
 		return "⏸️"
 	}
 }
+
+// samplingSummary lists the generation parameters recorded in metadata
+func samplingSummary(metadata map[string]string) string {
+	var params []string
+	for _, key := range []string{"temperature", "top_p", "seed", "max_output_tokens"} {
+		if value, ok := metadata[key]; ok {
+			params = append(params, key+"="+value)
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+// orNone joins values, or returns "none" when there are none
+func orNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,200 @@
+package reporter
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"glens/tools/glens/internal/redact"
+	"glens/tools/glens/internal/storage"
+)
+
+// Portfolio aggregates the reports of a multi-spec run, ranking the
+// services by health score so teams see which need attention first
+type Portfolio struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Services are ranked by health score, highest first; services whose
+	// analysis failed come last
+	Services []PortfolioService `json:"services"`
+	// AverageHealthScore averages the health scores of the analyzed services
+	AverageHealthScore float64 `json:"average_health_score"`
+	EndpointsProcessed int     `json:"endpoints_processed"`
+	TotalTests         int     `json:"total_tests"`
+	PassedTests        int     `json:"passed_tests"`
+	FailedTests        int     `json:"failed_tests"`
+	IssuesCreated      int     `json:"issues_created"`
+}
+
+// PortfolioService is the outcome of one spec of a multi-spec run
+type PortfolioService struct {
+	// Rank is the service's position by health score, 0 when its analysis
+	// failed
+	Rank int    `json:"rank,omitempty"`
+	Name string `json:"name"`
+	Spec string `json:"spec"`
+	// Report is the path of the service's own report, relative to the
+	// portfolio report
+	Report             string  `json:"report,omitempty"`
+	HealthScore        float64 `json:"health_score"`
+	SpecQualityScore   float64 `json:"spec_quality_score,omitempty"`
+	EndpointsProcessed int     `json:"endpoints_processed"`
+	TotalTests         int     `json:"total_tests"`
+	PassedTests        int     `json:"passed_tests"`
+	FailedTests        int     `json:"failed_tests"`
+	FlakyTests         int     `json:"flaky_tests"`
+	IssuesCreated      int     `json:"issues_created"`
+	// Error is why the service could not be analyzed
+	Error string `json:"error,omitempty"`
+}
+
+// NewPortfolioService summarizes the report of the service name, analyzed
+// from spec and written to reportPath
+func NewPortfolioService(name, spec, reportPath string, report *Report) PortfolioService {
+	service := PortfolioService{
+		Name:               name,
+		Spec:               spec,
+		Report:             reportPath,
+		HealthScore:        report.Summary.OverallHealthScore,
+		EndpointsProcessed: report.Summary.EndpointsProcessed,
+		TotalTests:         report.Summary.TotalTests,
+		PassedTests:        report.Summary.PassedTests,
+		FailedTests:        report.Summary.FailedTests,
+		FlakyTests:         report.Summary.FlakyTests,
+	}
+	if report.SpecQuality != nil {
+		service.SpecQualityScore = report.SpecQuality.Score
+	}
+	for i := range report.EndpointResults {
+		if report.EndpointResults[i].IssueNumber > 0 {
+			service.IssuesCreated++
+		}
+	}
+	return service
+}
+
+// BuildPortfolio ranks services by health score and totals their results
+func BuildPortfolio(services []PortfolioService) *Portfolio {
+	portfolio := &Portfolio{GeneratedAt: time.Now(), Services: slices.Clone(services)}
+	slices.SortStableFunc(portfolio.Services, func(a, b PortfolioService) int {
+		if (a.Error == "") != (b.Error == "") {
+			if a.Error == "" {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(cmp.Compare(b.HealthScore, a.HealthScore), strings.Compare(a.Name, b.Name))
+	})
+
+	analyzed := 0
+	for i := range portfolio.Services {
+		service := &portfolio.Services[i]
+		if service.Error != "" {
+			continue
+		}
+		analyzed++
+		service.Rank = analyzed
+		portfolio.AverageHealthScore += service.HealthScore
+		portfolio.EndpointsProcessed += service.EndpointsProcessed
+		portfolio.TotalTests += service.TotalTests
+		portfolio.PassedTests += service.PassedTests
+		portfolio.FailedTests += service.FailedTests
+		portfolio.IssuesCreated += service.IssuesCreated
+	}
+	if analyzed > 0 {
+		portfolio.AverageHealthScore /= float64(analyzed)
+	}
+	return portfolio
+}
+
+// WritePortfolio writes the portfolio as Markdown (.md) or JSON
+func WritePortfolio(portfolio *Portfolio, filePath string) error {
+	store, key := storage.UserPath(storage.NewFS(""), filePath)
+	return StorePortfolio(context.Background(), store, key, portfolio)
+}
+
+// StorePortfolio writes the portfolio to the object key of store as
+// Markdown (.md) or JSON
+func StorePortfolio(ctx context.Context, store storage.Store, key string, portfolio *Portfolio) error {
+	format := FormatJSON
+	var content []byte
+	if strings.HasSuffix(strings.ToLower(key), ".md") {
+		format = FormatMarkdown
+		content = []byte(generatePortfolioMarkdown(portfolio))
+	} else {
+		data, err := json.MarshalIndent(portfolio, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal portfolio to JSON: %w", err)
+		}
+		content = data
+	}
+	if err := store.Put(ctx, key, format.contentType(), []byte(redact.String(string(content)))); err != nil {
+		return fmt.Errorf("failed to write portfolio report: %w", err)
+	}
+	return nil
+}
+
+// generatePortfolioMarkdown renders the portfolio ranking
+func generatePortfolioMarkdown(portfolio *Portfolio) string {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# 📊 Glens Portfolio Report\n\n")
+	fmt.Fprintf(&md, "**Generated:** %s\n\n", portfolio.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&md, "## Summary\n\n")
+	fmt.Fprintf(&md, "- **Services:** %d\n", len(portfolio.Services))
+	fmt.Fprintf(&md, "- **Average Health Score:** %.1f%%\n", portfolio.AverageHealthScore)
+	fmt.Fprintf(&md, "- **Endpoints Processed:** %d\n", portfolio.EndpointsProcessed)
+	fmt.Fprintf(&md, "- **Tests:** %d (%d passed, %d failed)\n", portfolio.TotalTests, portfolio.PassedTests, portfolio.FailedTests)
+	fmt.Fprintf(&md, "- **Issues Created:** %d\n\n", portfolio.IssuesCreated)
+
+	fmt.Fprintf(&md, "## Services by Health Score\n\n")
+	fmt.Fprintf(&md, "| Rank | Service | Health Score | Spec Quality | Endpoints | Passed | Failed | Flaky | Issues | Report |\n")
+	fmt.Fprintf(&md, "|------|---------|--------------|--------------|-----------|--------|--------|-------|--------|--------|\n")
+	for _, service := range portfolio.Services {
+		if service.Error != "" {
+			continue
+		}
+		quality := "-"
+		if service.SpecQualityScore > 0 {
+			quality = fmt.Sprintf("%.1f", service.SpecQualityScore)
+		}
+		link := "-"
+		if service.Report != "" {
+			link = fmt.Sprintf("[report](%s)", service.Report)
+		}
+		fmt.Fprintf(&md, "| %d | %s | %s %.1f | %s | %d | %d | %d | %d | %d | %s |\n",
+			service.Rank, service.Name, healthBadge(service.HealthScore), service.HealthScore, quality,
+			service.EndpointsProcessed, service.PassedTests, service.FailedTests, service.FlakyTests,
+			service.IssuesCreated, link)
+	}
+
+	var failed []PortfolioService
+	for _, service := range portfolio.Services {
+		if service.Error != "" {
+			failed = append(failed, service)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&md, "\n## ❌ Failed Analyses\n\n")
+		for _, service := range failed {
+			fmt.Fprintf(&md, "- **%s** (`%s`): %s\n", service.Name, service.Spec, service.Error)
+		}
+	}
+
+	return md.String()
+}
+
+// healthBadge marks a health score as good, fair or poor
+func healthBadge(score float64) string {
+	switch {
+	case score >= 70:
+		return "🟢"
+	case score >= 50:
+		return "🟡"
+	default:
+		return "🔴"
+	}
+}
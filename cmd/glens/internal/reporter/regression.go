@@ -0,0 +1,49 @@
+package reporter
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Regression is a test that passed in a baseline report and no longer does
+type Regression struct {
+	// Endpoint is "METHOD /path"
+	Endpoint string         `json:"endpoint"`
+	AIModel  string         `json:"ai_model"`
+	Now      CoverageStatus `json:"now"`
+}
+
+// Regressions returns the tests of current, by endpoint and model, that
+// passed in baseline but failed or no longer compile. Tests that were not
+// executed, were skipped or are missing from either report are not
+// regressions. They are sorted by endpoint and model.
+func Regressions(baseline, current *Report) []Regression {
+	passed := make(map[[2]string]bool)
+	for _, result := range baseline.EndpointResults {
+		for model, test := range result.Tests {
+			if testCoverageStatus(&test).Passed() {
+				passed[[2]string{endpointKey(&result), model}] = true
+			}
+		}
+	}
+
+	var regressions []Regression
+	for _, result := range current.EndpointResults {
+		endpoint := endpointKey(&result)
+		for model, test := range result.Tests {
+			status := testCoverageStatus(&test)
+			if passed[[2]string{endpoint, model}] && (status == CoverageFailed || status == CoverageCompileFailed) {
+				regressions = append(regressions, Regression{Endpoint: endpoint, AIModel: model, Now: status})
+			}
+		}
+	}
+	slices.SortFunc(regressions, func(a, b Regression) int {
+		return cmp.Or(cmp.Compare(a.Endpoint, b.Endpoint), cmp.Compare(a.AIModel, b.AIModel))
+	})
+	return regressions
+}
+
+// endpointKey identifies the endpoint of result across reports
+func endpointKey(result *EndpointResult) string {
+	return result.Endpoint.Method + " " + result.Endpoint.Path
+}
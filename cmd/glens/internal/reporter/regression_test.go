@@ -0,0 +1,43 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
+)
+
+// executedReport returns a report with a GET /pets test per model, passed
+// or failed as given; a nil status leaves the test unexecuted
+func executedReport(passed map[string]*bool) *Report {
+	tests := make(map[string]TestResult, len(passed))
+	for model, ok := range passed {
+		test := TestResult{AIModel: model}
+		if ok != nil {
+			test.ExecutionResult = &generator.ExecutionResult{Passed: *ok}
+		}
+		tests[model] = test
+	}
+	return &Report{EndpointResults: []EndpointResult{{
+		Endpoint: parser.Endpoint{Method: "GET", Path: "/pets"},
+		Tests:    tests,
+	}}}
+}
+
+func TestRegressions(t *testing.T) {
+	pass, fail := true, false
+	baseline := executedReport(map[string]*bool{"gpt4": &pass, "sonnet4": &pass, "mock": &fail, "ollama": &pass})
+	current := executedReport(map[string]*bool{"gpt4": &fail, "sonnet4": &pass, "mock": &fail, "ollama": nil})
+	current.EndpointResults[0].Tests["sonnet4"] = TestResult{ExecutionResult: &generator.ExecutionResult{
+		Errors: []generator.TestError{{TestName: "compilation"}},
+	}}
+
+	assert.Equal(t, []Regression{
+		{Endpoint: "GET /pets", AIModel: "gpt4", Now: CoverageFailed},
+		{Endpoint: "GET /pets", AIModel: "sonnet4", Now: CoverageCompileFailed},
+	}, Regressions(baseline, current), "tests failing before or not executed now are not regressions")
+
+	assert.Empty(t, Regressions(current, baseline))
+}
@@ -1,20 +1,35 @@
 package reporter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/generator"
 	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/redact"
+	"glens/tools/glens/internal/storage"
 )
 
-// GenerateReport creates a comprehensive report from specification and results
+// GenerateReport creates a comprehensive report from specification and
+// results, scored with the default weights
 func GenerateReport(spec *parser.OpenAPISpec, endpointResults []EndpointResult) *Report {
+	return GenerateReportWithScoring(spec, endpointResults, DefaultScoring())
+}
+
+// GenerateReportWithScoring creates a comprehensive report from
+// specification and results, scoring health and ranking models with the
+// weights of scoring; its webhook is not called (see ScoreWithWebhook).
+func GenerateReportWithScoring(spec *parser.OpenAPISpec, endpointResults []EndpointResult, scoring Scoring) *Report {
 	log.Info().
 		Int("endpoints", len(endpointResults)).
 		Msg("Generating comprehensive report")
@@ -29,10 +44,16 @@ func GenerateReport(spec *parser.OpenAPISpec, endpointResults []EndpointResult)
 	}
 
 	// Generate summary
-	report.Summary = generateSummary(spec, endpointResults)
+	report.Summary = generateSummary(spec, endpointResults, scoring.Health)
 
 	// Generate model comparison
-	report.ModelComparison = generateModelComparison(endpointResults)
+	report.ModelComparison = generateModelComparison(endpointResults, scoring.Model)
+
+	report.DeprecatedOperations = deprecatedOperations(spec, endpointResults)
+	report.SpecQuality = parser.AssessQuality(spec)
+	report.Tags = tagSummaries(spec, endpointResults, scoring.Health)
+	report.Coverage = BuildCoverage(spec, endpointResults, slices.Sorted(slices.Values(report.Summary.AIModelsUsed)))
+	report.RequiredEnv = requiredEnv(endpointResults)
 
 	// Calculate overall execution time
 	report.ExecutionTime = time.Since(startTime)
@@ -42,6 +63,7 @@ func GenerateReport(spec *parser.OpenAPISpec, endpointResults []EndpointResult)
 	report.Metadata["generator"] = "glens"
 	report.Metadata["total_endpoints"] = len(spec.Endpoints)
 	report.Metadata["processed_endpoints"] = len(endpointResults)
+	report.Metadata["scoring"] = scoring
 
 	log.Info().
 		Dur("generation_time", report.ExecutionTime).
@@ -51,8 +73,55 @@ func GenerateReport(spec *parser.OpenAPISpec, endpointResults []EndpointResult)
 	return report
 }
 
+// deprecatedOperations lists the deprecated operations of spec in spec
+// order, noting which of them have results
+func deprecatedOperations(spec *parser.OpenAPISpec, results []EndpointResult) []DeprecatedOperation {
+	tested := make(map[string]bool, len(results))
+	for i := range results {
+		tested[results[i].Endpoint.ID] = true
+	}
+
+	var deprecated []DeprecatedOperation
+	for i := range spec.Endpoints {
+		endpoint := &spec.Endpoints[i]
+		if !endpoint.Deprecated {
+			continue
+		}
+		deprecated = append(deprecated, DeprecatedOperation{
+			Method:      endpoint.Method,
+			Path:        endpoint.Path,
+			OperationID: endpoint.OperationID,
+			Tested:      tested[endpoint.ID],
+		})
+	}
+	return deprecated
+}
+
+// requiredEnv lists the environment variables the tests of results read,
+// sorted by name
+func requiredEnv(results []EndpointResult) []RequiredVariable {
+	tests := make(map[string]int)
+	for i := range results {
+		for _, test := range results[i].Tests {
+			for _, name := range generator.RequiredEnv(test.TestCode) {
+				tests[name]++
+			}
+		}
+	}
+
+	var variables []RequiredVariable
+	for _, name := range slices.Sorted(maps.Keys(tests)) {
+		variables = append(variables, RequiredVariable{
+			Name:        name,
+			Description: environment.Description(name),
+			Tests:       tests[name],
+		})
+	}
+	return variables
+}
+
 // generateSummary creates the summary section of the report
-func generateSummary(spec *parser.OpenAPISpec, results []EndpointResult) Summary {
+func generateSummary(spec *parser.OpenAPISpec, results []EndpointResult, weights HealthWeights) Summary {
 	summary := Summary{
 		TotalEndpoints:     len(spec.Endpoints),
 		EndpointsProcessed: len(results),
@@ -64,7 +133,9 @@ func generateSummary(spec *parser.OpenAPISpec, results []EndpointResult) Summary
 	passedTests := 0
 	failedTests := 0
 	skippedTests := 0
+	flakyTests := 0
 	issuesCreated := 0
+	totalCoverage := 0.0
 	modelsMap := make(map[string]bool)
 	frameworksMap := make(map[string]bool)
 
@@ -83,6 +154,7 @@ func generateSummary(spec *parser.OpenAPISpec, results []EndpointResult) Summary
 			frameworksMap[testResult.Framework] = true
 
 			totalTests++
+			totalCoverage += testResult.Metrics.TestCoverage.CoveragePercentage
 
 			if testResult.ExecutionResult != nil {
 				if testResult.ExecutionResult.Passed {
@@ -93,6 +165,9 @@ func generateSummary(spec *parser.OpenAPISpec, results []EndpointResult) Summary
 				if testResult.ExecutionResult.Skipped {
 					skippedTests++
 				}
+				if testResult.ExecutionResult.Flaky {
+					flakyTests++
+				}
 
 				executionTimes = append(executionTimes, testResult.ExecutionResult.Duration)
 			}
@@ -115,13 +190,17 @@ func generateSummary(spec *parser.OpenAPISpec, results []EndpointResult) Summary
 	summary.PassedTests = passedTests
 	summary.FailedTests = failedTests
 	summary.SkippedTests = skippedTests
+	summary.FlakyTests = flakyTests
 	summary.TotalIssuesCreated = issuesCreated
+	if totalTests > 0 {
+		summary.AverageCoverage = totalCoverage / float64(totalTests)
+	}
 
 	// Calculate execution summary
 	summary.ExecutionSummary = calculateExecutionSummary(executionTimes, generationTimes, passedTests, totalTests)
 
 	// Calculate overall health score
-	summary.OverallHealthScore = calculateOverallHealthScore(&summary)
+	summary.OverallHealthScore = weights.score(&summary)
 
 	return summary
 }
@@ -166,26 +245,8 @@ func calculateExecutionSummary(executionTimes, generationTimes []time.Duration,
 	return summary
 }
 
-// calculateOverallHealthScore calculates a composite health score
-func calculateOverallHealthScore(summary *Summary) float64 {
-	if summary.TotalTests == 0 {
-		return 0.0
-	}
-
-	// Calculate success rate
-	successRate := float64(summary.PassedTests) / float64(summary.TotalTests)
-
-	// Calculate coverage (endpoints processed vs total)
-	coverageRate := float64(summary.EndpointsProcessed) / float64(summary.TotalEndpoints)
-
-	// Weighted score (70% success rate, 30% coverage)
-	healthScore := (successRate * 0.7) + (coverageRate * 0.3)
-
-	return healthScore * 100 // Return as percentage
-}
-
 // generateModelComparison creates the model comparison section
-func generateModelComparison(results []EndpointResult) ModelComparison {
+func generateModelComparison(results []EndpointResult, weights ModelWeights) ModelComparison {
 	comparison := ModelComparison{
 		Models: make([]ModelResult, 0),
 		ComparisonMatrix: ComparisonMatrix{
@@ -201,6 +262,9 @@ func generateModelComparison(results []EndpointResult) ModelComparison {
 
 	// Aggregate results by model
 	modelStats := make(map[string]*ModelResult)
+	securityScores := make(map[string]float64)
+	testFunctions := make(map[string]int)
+	weakTests := make(map[string]int)
 
 	for i := range results {
 		result := &results[i]
@@ -223,13 +287,19 @@ func generateModelComparison(results []EndpointResult) ModelComparison {
 				} else {
 					stats.TestsFailed++
 				}
+				if testResult.ExecutionResult.Flaky {
+					stats.TestsFlaky++
+				}
 
 				stats.AvgExecutionTime += testResult.ExecutionResult.Duration
 			}
 
 			stats.AvgQualityScore += testResult.QualityScore
 			stats.AvgCoverageScore += testResult.Metrics.TestCoverage.CoveragePercentage
+			securityScores[modelName] += testResult.Metrics.SecurityCoverage.SecurityScore
 			stats.TotalTokensUsed += testResult.Metrics.Performance.TokensUsed
+			testFunctions[modelName] += testResult.Metrics.CodeQuality.TestFunctionCount
+			weakTests[modelName] += len(testResult.Metrics.CodeQuality.WeakTests)
 		}
 	}
 
@@ -238,9 +308,13 @@ func generateModelComparison(results []EndpointResult) ModelComparison {
 		if stats.TestsGenerated > 0 {
 			stats.AvgQualityScore /= float64(stats.TestsGenerated)
 			stats.AvgCoverageScore /= float64(stats.TestsGenerated)
+			securityScores[modelName] /= float64(stats.TestsGenerated)
 			stats.AvgExecutionTime /= time.Duration(stats.TestsGenerated)
 			stats.SuccessRate = float64(stats.TestsPassed) / float64(stats.TestsGenerated)
 		}
+		if testFunctions[modelName] > 0 {
+			stats.WeakTestRate = float64(weakTests[modelName]) / float64(testFunctions[modelName])
+		}
 
 		// Identify strengths and weaknesses
 		stats.Strengths, stats.Weaknesses = identifyModelCharacteristics(stats)
@@ -252,16 +326,17 @@ func generateModelComparison(results []EndpointResult) ModelComparison {
 		comparison.ComparisonMatrix.CoverageComparison[modelName] = stats.AvgCoverageScore
 		comparison.ComparisonMatrix.PerformanceComparison[modelName] = float64(stats.AvgExecutionTime.Milliseconds())
 		comparison.ComparisonMatrix.ReliabilityComparison[modelName] = stats.SuccessRate
+		comparison.ComparisonMatrix.SecurityComparison[modelName] = securityScores[modelName]
 	}
 
 	// Generate rankings
 	comparison.Rankings = generateRankings(comparison.Models)
 
 	// Determine best performer
-	comparison.BestPerformer = determineBestPerformer(comparison.Models)
+	comparison.BestPerformer = determineBestPerformer(comparison.Models, weights)
 
 	// Generate recommendations
-	comparison.Recommendations = generateRecommendations(comparison.Models)
+	comparison.Recommendations = generateRecommendations(comparison.Models, comparison.BestPerformer)
 
 	return comparison
 }
@@ -285,6 +360,11 @@ func identifyModelCharacteristics(model *ModelResult) (strengths, weaknesses []s
 		weaknesses = append(weaknesses, "Limited test coverage")
 	}
 
+	// Assertion depth
+	if model.WeakTestRate > 0.25 {
+		weaknesses = append(weaknesses, fmt.Sprintf("Weak assertions (%.0f%% of tests check no status, body or header)", model.WeakTestRate*100))
+	}
+
 	// Performance assessment
 	if model.AvgExecutionTime < 5*time.Second {
 		strengths = append(strengths, "Fast test execution")
@@ -385,17 +465,17 @@ func generateRankings(models []ModelResult) []ModelRanking {
 }
 
 // determineBestPerformer identifies the overall best performing model
-func determineBestPerformer(models []ModelResult) string {
+func determineBestPerformer(models []ModelResult, weights ModelWeights) string {
 	if len(models) == 0 {
 		return ""
 	}
 
 	bestModel := models[0]
-	bestScore := calculateCompositeScore(&bestModel)
+	bestScore := weights.score(&bestModel)
 
 	for i := 1; i < len(models); i++ {
 		model := &models[i]
-		score := calculateCompositeScore(model)
+		score := weights.score(model)
 		if score > bestScore {
 			bestScore = score
 			bestModel = *model
@@ -405,56 +485,20 @@ func determineBestPerformer(models []ModelResult) string {
 	return bestModel.ModelName
 }
 
-// calculateCompositeScore calculates a weighted composite score for ranking
-func calculateCompositeScore(model *ModelResult) float64 {
-	// Weighted scoring: 30% quality, 25% coverage, 25% reliability, 20% performance
-	qualityWeight := 0.30
-	coverageWeight := 0.25
-	reliabilityWeight := 0.25
-	performanceWeight := 0.20
-
-	// Normalize performance score (lower execution time is better)
-	performanceScore := 100.0
-	if model.AvgExecutionTime > 0 {
-		// Convert to seconds and invert (max 100 for under 1 second)
-		seconds := model.AvgExecutionTime.Seconds()
-		performanceScore = 100.0 / (1.0 + seconds)
-	}
-
-	compositeScore := (model.AvgQualityScore * qualityWeight) +
-		(model.AvgCoverageScore * coverageWeight) +
-		(model.SuccessRate * 100 * reliabilityWeight) +
-		(performanceScore * performanceWeight)
-
-	return compositeScore
-}
-
-// generateRecommendations creates actionable recommendations
-func generateRecommendations(models []ModelResult) []Recommendation {
+// generateRecommendations creates actionable recommendations, best being
+// the best performing model
+func generateRecommendations(models []ModelResult, best string) []Recommendation {
 	recommendations := make([]Recommendation, 0)
 
 	// Analyze overall performance
 	if len(models) > 1 {
-		// Find best performer
-		best := models[0]
-		bestScore := calculateCompositeScore(&best)
-
-		for i := 1; i < len(models); i++ {
-			model := &models[i]
-			score := calculateCompositeScore(model)
-			if score > bestScore {
-				bestScore = score
-				best = *model
-			}
-		}
-
 		recommendations = append(recommendations, Recommendation{
 			Category:    "Model Selection",
 			Title:       "Primary Model Recommendation",
-			Description: fmt.Sprintf("Use %s as the primary model for test generation based on overall performance", best.ModelName),
+			Description: fmt.Sprintf("Use %s as the primary model for test generation based on overall performance", best),
 			Priority:    "high",
 			ActionItems: []string{
-				fmt.Sprintf("Configure %s as the default model", best.ModelName),
+				fmt.Sprintf("Configure %s as the default model", best),
 				"Monitor performance metrics regularly",
 				"Consider cost implications of model choice",
 			},
@@ -503,20 +547,102 @@ func generateRecommendations(models []ModelResult) []Recommendation {
 	return recommendations
 }
 
-// WriteReport writes the report to a file in the specified format
+// WriteReport writes the report to a file in the format of its extension:
+// Markdown (.md), HTML (.html) or JSON
 func WriteReport(report *Report, filePath string) error {
+	return WriteReportFormat(report, filePath, FormatOf(filePath))
+}
+
+// FormatOf returns the report format of a file extension: Markdown (.md),
+// HTML (.html) or JSON
+func FormatOf(filePath string) ReportFormat {
+	switch {
+	case strings.HasSuffix(strings.ToLower(filePath), ".md"):
+		return FormatMarkdown
+	case strings.HasSuffix(strings.ToLower(filePath), ".html"):
+		return FormatHTML
+	default:
+		return FormatJSON
+	}
+}
+
+// ParseFormat returns the report format named name (markdown, json, html
+// or lsp-diagnostics)
+func ParseFormat(name string) (ReportFormat, error) {
+	switch format := ReportFormat(strings.ToLower(name)); format {
+	case FormatMarkdown, FormatJSON, FormatHTML, FormatLSPDiagnostics:
+		return format, nil
+	case "md":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q (markdown, json, html or lsp-diagnostics)", name)
+	}
+}
+
+// WriteReportFormat writes the report to a file in format
+func WriteReportFormat(report *Report, filePath string, format ReportFormat) error {
+	store, key := storage.UserPath(storage.NewFS(""), filePath)
+	return StoreReport(context.Background(), store, key, report, format)
+}
+
+// StoreReport writes the report to the object key of store in format
+func StoreReport(ctx context.Context, store storage.Store, key string, report *Report, format ReportFormat) error {
 	log.Info().
-		Str("file_path", filePath).
+		Str("file_path", store.Location(key)).
 		Msg("Writing report to file")
 
-	// Determine format from file extension
-	format := FormatJSON
-	if strings.HasSuffix(strings.ToLower(filePath), ".md") {
-		format = FormatMarkdown
-	} else if strings.HasSuffix(strings.ToLower(filePath), ".html") {
-		format = FormatHTML
+	content, err := Render(report, format)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Put(ctx, key, format.contentType(), []byte(content)); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+
+	log.Info().
+		Str("file_path", store.Location(key)).
+		Str("format", string(format)).
+		Int("size_bytes", len(content)).
+		Msg("Report written successfully")
+
+	return nil
+}
+
+// ReadReport reads a report written as JSON
+func ReadReport(filePath string) (*Report, error) {
+	store, key := storage.UserPath(storage.NewFS(""), filePath)
+	return LoadReport(context.Background(), store, key)
+}
+
+// LoadReport reads the report written as JSON to the object key of store
+func LoadReport(ctx context.Context, store storage.Store, key string) (*Report, error) {
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s (JSON reports only): %w", store.Location(key), err)
+	}
+	return &report, nil
+}
+
+// contentType is the media type of reports in the format
+func (f ReportFormat) contentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "application/json"
 	}
+}
 
+// Render returns the report in the given format, secrets masked; unknown
+// formats render JSON
+func Render(report *Report, format ReportFormat) (string, error) {
 	var content string
 	var err error
 
@@ -525,30 +651,25 @@ func WriteReport(report *Report, filePath string) error {
 		content, err = generateMarkdownReport(report)
 	case FormatHTML:
 		content, err = generateHTMLReport(report)
+	case FormatLSPDiagnostics:
+		jsonData, jsonErr := json.MarshalIndent(Diagnostics(report), "", "  ")
+		if jsonErr != nil {
+			return "", fmt.Errorf("failed to marshal diagnostics to JSON: %w", jsonErr)
+		}
+		content = string(jsonData)
 	default:
-		// JSON format
 		jsonData, jsonErr := json.MarshalIndent(report, "", "  ")
 		if jsonErr != nil {
-			return fmt.Errorf("failed to marshal report to JSON: %w", jsonErr)
+			return "", fmt.Errorf("failed to marshal report to JSON: %w", jsonErr)
 		}
 		content = string(jsonData)
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to generate report content: %w", err)
-	}
-
-	if err := os.WriteFile(filePath, []byte(content), 0o600); err != nil {
-		return fmt.Errorf("failed to write report file: %w", err)
+		return "", fmt.Errorf("failed to generate report content: %w", err)
 	}
-
-	log.Info().
-		Str("file_path", filePath).
-		Str("format", string(format)).
-		Int("size_bytes", len(content)).
-		Msg("Report written successfully")
-
-	return nil
+	// Prompts, generated code and errors may quote credentials
+	return redact.String(content), nil
 }
 
 // EnsureReportDirectory ensures the directory for the report file exists
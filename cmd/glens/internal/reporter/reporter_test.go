@@ -1,8 +1,19 @@
 package reporter
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/generator"
+	"glens/tools/glens/internal/parser"
 )
 
 func TestCalculateExecutionSummary_SuccessRate(t *testing.T) {
@@ -30,3 +41,552 @@ func TestCalculateExecutionSummary_SuccessRate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSummary_CountsFlakyTests(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{{Method: "GET", Path: "/users"}}}
+	results := []EndpointResult{{
+		Tests: map[string]TestResult{
+			"gpt4":   {ExecutionResult: &generator.ExecutionResult{Passed: true, Flaky: true, Attempts: 2}},
+			"ollama": {ExecutionResult: &generator.ExecutionResult{Passed: true, Attempts: 1}},
+		},
+	}}
+
+	summary := generateSummary(spec, results, DefaultScoring().Health)
+	if summary.FlakyTests != 1 {
+		t.Errorf("FlakyTests = %d, want 1", summary.FlakyTests)
+	}
+	if summary.PassedTests != 2 {
+		t.Errorf("PassedTests = %d, want 2", summary.PassedTests)
+	}
+}
+
+func TestGenerateSummary_HealthScoreUsesTestCoverage(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{{Method: "GET", Path: "/users"}}}
+	results := []EndpointResult{{
+		Tests: map[string]TestResult{
+			"gpt4": {
+				ExecutionResult: &generator.ExecutionResult{Passed: true},
+				Metrics:         TestMetrics{TestCoverage: TestCoverage{CoveragePercentage: 80}},
+			},
+			"ollama": {
+				ExecutionResult: &generator.ExecutionResult{Failed: true},
+				Metrics:         TestMetrics{TestCoverage: TestCoverage{CoveragePercentage: 40}},
+			},
+		},
+	}}
+
+	summary := generateSummary(spec, results, DefaultScoring().Health)
+	if summary.AverageCoverage != 60 {
+		t.Errorf("AverageCoverage = %v, want 60", summary.AverageCoverage)
+	}
+	// 50% * 1/2 passed + 20% * 1/1 endpoints + 30% * 60% coverage
+	if want := 63.0; math.Abs(summary.OverallHealthScore-want) > 1e-9 {
+		t.Errorf("OverallHealthScore = %v, want %v", summary.OverallHealthScore, want)
+	}
+}
+
+func TestGenerateModelComparison_SecurityComparison(t *testing.T) {
+	results := []EndpointResult{
+		{Tests: map[string]TestResult{"gpt4": {Metrics: TestMetrics{SecurityCoverage: SecurityCoverage{SecurityScore: 60}}}}},
+		{Tests: map[string]TestResult{"gpt4": {Metrics: TestMetrics{SecurityCoverage: SecurityCoverage{SecurityScore: 20}}}}},
+	}
+
+	comparison := generateModelComparison(results, DefaultScoring().Model)
+	if got := comparison.ComparisonMatrix.SecurityComparison["gpt4"]; got != 40 {
+		t.Errorf("SecurityComparison[gpt4] = %v, want 40", got)
+	}
+}
+
+func TestGenerateModelComparison_WeakTestRate(t *testing.T) {
+	weak := func(functions int, weakTests ...string) TestResult {
+		return TestResult{Metrics: TestMetrics{CodeQuality: CodeQuality{TestFunctionCount: functions, WeakTests: weakTests}}}
+	}
+	results := []EndpointResult{
+		{Tests: map[string]TestResult{"gpt4": weak(3, "TestA"), "mistral": weak(1, "TestB")}},
+		{Tests: map[string]TestResult{"gpt4": weak(1), "mistral": weak(1, "TestC")}},
+	}
+
+	comparison := generateModelComparison(results, DefaultScoring().Model)
+	for _, model := range comparison.Models {
+		var want float64
+		var weakness bool
+		switch model.ModelName {
+		case "gpt4":
+			want = 0.25
+		case "mistral":
+			want, weakness = 1, true
+		}
+		if model.WeakTestRate != want {
+			t.Errorf("WeakTestRate[%s] = %v, want %v", model.ModelName, model.WeakTestRate, want)
+		}
+		flagged := strings.Contains(strings.Join(model.Weaknesses, "\n"), "Weak assertions")
+		if flagged != weakness {
+			t.Errorf("Weaknesses[%s] = %v, want weak assertions flagged: %v", model.ModelName, model.Weaknesses, weakness)
+		}
+	}
+}
+
+func TestGenerateReport_DeprecatedOperations(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		{ID: "GET__users", Method: "GET", Path: "/users"},
+		{ID: "GET__v1_users", Method: "GET", Path: "/v1/users", OperationID: "listUsersV1", Deprecated: true},
+		{ID: "DELETE__v1_users", Method: "DELETE", Path: "/v1/users", Deprecated: true},
+	}}
+	results := []EndpointResult{
+		{Endpoint: spec.Endpoints[0]},
+		{Endpoint: spec.Endpoints[1]},
+	}
+
+	report := GenerateReport(spec, results)
+	want := []DeprecatedOperation{
+		{Method: "GET", Path: "/v1/users", OperationID: "listUsersV1", Tested: true},
+		{Method: "DELETE", Path: "/v1/users"},
+	}
+	if len(report.DeprecatedOperations) != len(want) {
+		t.Fatalf("DeprecatedOperations = %+v, want %+v", report.DeprecatedOperations, want)
+	}
+	for i := range want {
+		if report.DeprecatedOperations[i] != want[i] {
+			t.Errorf("DeprecatedOperations[%d] = %+v, want %+v", i, report.DeprecatedOperations[i], want[i])
+		}
+	}
+
+	md, err := generateMarkdownReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"## ⚠️ Deprecated Operations",
+		"| `GET /v1/users` | listUsersV1 | Yes |",
+		"| `DELETE /v1/users` | - | No |",
+		"#### 2. GET /v1/users (deprecated)",
+	} {
+		if !strings.Contains(md, line) {
+			t.Errorf("markdown report is missing %q", line)
+		}
+	}
+}
+
+func TestGenerateReport_RequiredEnv(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{{ID: "GET__users", Method: "GET", Path: "/users"}}}
+	results := []EndpointResult{{Endpoint: spec.Endpoints[0], Tests: map[string]TestResult{
+		"gpt4":   {TestCode: `var base = cmp.Or(os.Getenv("GLENS_BASE_URL"), "x"); var token = os.Getenv("GLENS_TOKEN")`},
+		"sonnet": {TestCode: `var base = os.Getenv("GLENS_BASE_URL")`},
+		"mock":   {TestCode: `var base = "http://localhost:8080"`},
+	}}}
+
+	report := GenerateReport(spec, results)
+	want := []RequiredVariable{
+		{Name: "GLENS_BASE_URL", Description: environment.Descriptions["GLENS_BASE_URL"], Tests: 2},
+		{Name: "GLENS_TOKEN", Description: "Bearer token or API key of the API under test", Tests: 1},
+	}
+	if len(report.RequiredEnv) != len(want) {
+		t.Fatalf("RequiredEnv = %+v, want %+v", report.RequiredEnv, want)
+	}
+	for i := range want {
+		if report.RequiredEnv[i] != want[i] {
+			t.Errorf("RequiredEnv[%d] = %+v, want %+v", i, report.RequiredEnv[i], want[i])
+		}
+	}
+
+	md, err := generateMarkdownReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"### C. Required Environment Variables",
+		"| `GLENS_TOKEN` | 1 | Bearer token or API key of the API under test |",
+	} {
+		if !strings.Contains(md, line) {
+			t.Errorf("markdown report is missing %q", line)
+		}
+	}
+}
+
+func TestRender_LSPDiagnostics(t *testing.T) {
+	spec := &parser.OpenAPISpec{Source: "/specs/pets.yaml", Endpoints: []parser.Endpoint{
+		{ID: "GET__pets", Method: "GET", Path: "/pets", OperationID: "listPets", Summary: "List pets",
+			Security: []parser.SecurityRequirement{{"bearer": nil}}, Position: &parser.Position{Line: 8, Column: 5},
+			Responses: map[string]parser.Response{"200": {Description: "OK", Content: map[string]parser.MediaType{
+				"application/json": {Schema: parser.Schema{Type: "array"}},
+			}}}},
+		{ID: "query_pet", Method: "POST", Path: "/graphql", OperationID: "pet", Summary: "A pet", Kind: parser.KindQuery},
+	}, Warnings: []parser.SpecWarning{
+		{Code: parser.WarningUnresolvedRef, Message: "unresolved reference #/components/schemas/Pett",
+			Path: "/paths/~1pets/get/responses/200/content/application~1json/schema/items/$ref", Position: &parser.Position{Line: 14, Column: 25}},
+	}}
+	failed := &generator.ExecutionResult{Failed: true, Errors: []generator.TestError{{TestName: "TestListPets", Message: "expected 200\ngot 500"}}}
+	results := []EndpointResult{
+		{Endpoint: spec.Endpoints[0], Tests: map[string]TestResult{
+			"gpt4":   {ExecutionResult: failed},
+			"sonnet": {ExecutionResult: &generator.ExecutionResult{Passed: true}},
+		}},
+		{Endpoint: spec.Endpoints[1], Tests: map[string]TestResult{"gpt4": {ExecutionError: "compilation failed\nundefined: client"}}},
+	}
+
+	report := GenerateReport(spec, results)
+	content, err := Render(report, FormatLSPDiagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []FileDiagnostics
+	if err := json.Unmarshal([]byte(content), &files); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].URI != "file:///specs/pets.yaml" {
+		t.Fatalf("files = %+v, want the diagnostics of file:///specs/pets.yaml", files)
+	}
+	at := DiagnosticRange{Start: DiagnosticPosition{Line: 7, Character: 4}, End: DiagnosticPosition{Line: 7, Character: 4}}
+	want := []Diagnostic{
+		{Range: at, Severity: SeverityError, Code: CodeTestFailed, Source: "glens",
+			Message: "GET /pets: test generated by gpt4 failed: TestListPets: expected 200"},
+		{Severity: SeverityError, Code: CodeTestError, Source: "glens",
+			Message: "POST /graphql: test generated by gpt4 could not run: compilation failed"},
+		{Range: DiagnosticRange{Start: DiagnosticPosition{Line: 13, Character: 24}, End: DiagnosticPosition{Line: 13, Character: 24}},
+			Severity: SeverityWarning, Code: parser.WarningUnresolvedRef, Source: "glens", Message: "unresolved reference #/components/schemas/Pett"},
+		{Range: at, Severity: SeverityWarning, Code: parser.CheckExamples, Source: "glens",
+			Message: "GET /pets: add an example of the request body, parameters or a response"},
+	}
+	if len(files[0].Diagnostics) != len(want) {
+		t.Fatalf("Diagnostics = %+v, want %+v", files[0].Diagnostics, want)
+	}
+	for i := range want {
+		if files[0].Diagnostics[i] != want[i] {
+			t.Errorf("Diagnostics[%d] = %+v, want %+v", i, files[0].Diagnostics[i], want[i])
+		}
+	}
+
+	md, err := generateMarkdownReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"**Location:** `/specs/pets.yaml:8:5`",
+		"- `GET /pets` (listPets) at `/specs/pets.yaml:8:5`: add an example",
+		"### C. Spec Warnings",
+		"| `/specs/pets.yaml:14:25` | `/paths/~1pets/get/responses/200/content/application~1json/schema/items/$ref` | unresolved reference #/components/schemas/Pett |",
+	} {
+		if !strings.Contains(md, line) {
+			t.Errorf("markdown report is missing %q", line)
+		}
+	}
+}
+
+func TestGenerateReport_SpecQuality(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		{ID: "GET__users", Method: "GET", Path: "/users", OperationID: "listUsers", Summary: "List users",
+			Security: []parser.SecurityRequirement{}, Responses: map[string]parser.Response{"200": {Description: "Users"}}},
+		{ID: "DELETE__users", Method: "DELETE", Path: "/users"},
+	}}
+
+	report := GenerateReport(spec, nil)
+	if report.SpecQuality == nil || report.SpecQuality.Operations != 2 {
+		t.Fatalf("SpecQuality = %+v, want 2 operations", report.SpecQuality)
+	}
+
+	md, err := generateMarkdownReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"## 📝 Spec Quality",
+		"**Score:** 60.0/100 across 2 operation(s).",
+		"| Unique operationId | 1/2 | 50.0% |",
+		"- `DELETE /users`: add a summary or description; document a success (2xx) response; add an operationId; declare security requirements",
+	} {
+		if !strings.Contains(md, line) {
+			t.Errorf("markdown report is missing %q", line)
+		}
+	}
+
+	report = GenerateReport(&parser.OpenAPISpec{Endpoints: []parser.Endpoint{{Kind: parser.KindQuery}}}, nil)
+	md, err = generateMarkdownReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(md, "Spec Quality") {
+		t.Error("GraphQL schemas have no spec quality section")
+	}
+}
+
+func TestGenerateReport_Tags(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		{ID: "GET__pets", Method: "GET", Path: "/pets", Tags: []string{"pets"}},
+		{ID: "POST__pets", Method: "POST", Path: "/pets", Tags: []string{"pets", "admin"}},
+		{ID: "GET__health", Method: "GET", Path: "/health"},
+	}}
+	passed := TestResult{ExecutionResult: &generator.ExecutionResult{Passed: true}}
+	failed := TestResult{ExecutionResult: &generator.ExecutionResult{Failed: true}}
+	results := []EndpointResult{
+		{Endpoint: spec.Endpoints[0], Tests: map[string]TestResult{"gpt4": passed, "mock": failed}},
+		{Endpoint: spec.Endpoints[1], Tests: map[string]TestResult{"gpt4": failed}, IssueNumber: 7},
+	}
+
+	report := GenerateReport(spec, results)
+	want := []TagSummary{
+		{Tag: "admin", TotalEndpoints: 1, EndpointsProcessed: 1, TotalTests: 1, FailedTests: 1, IssuesCreated: 1, HealthScore: 20},
+		{Tag: "pets", TotalEndpoints: 2, EndpointsProcessed: 2, TotalTests: 3, PassedTests: 1, FailedTests: 2, IssuesCreated: 1,
+			PassRate: 100.0 / 3, HealthScore: 100.0/3*0.5 + 20},
+		{Tag: "(untagged)", TotalEndpoints: 1},
+	}
+	if len(report.Tags) != len(want) {
+		t.Fatalf("Tags = %+v, want %+v", report.Tags, want)
+	}
+	for i := range want {
+		got := report.Tags[i]
+		if math.Abs(got.HealthScore-want[i].HealthScore) > 1e-9 || math.Abs(got.PassRate-want[i].PassRate) > 1e-9 {
+			t.Errorf("Tags[%d] scores = %.2f/%.2f, want %.2f/%.2f", i, got.PassRate, got.HealthScore, want[i].PassRate, want[i].HealthScore)
+		}
+		got.HealthScore, got.PassRate = want[i].HealthScore, want[i].PassRate
+		if got != want[i] {
+			t.Errorf("Tags[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	md, err := generateMarkdownReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"### By Tag",
+		"| pets | 2/2 | 3 | 1 | 2 | 33.3% | 🔴 36.7% | 1 |",
+		"#### 🏷️ pets",
+		"🔴 Health 36.7% · 1/3 tests passed · 1 issue(s)",
+	} {
+		if !strings.Contains(md, line) {
+			t.Errorf("markdown report is missing %q", line)
+		}
+	}
+	if strings.Contains(md, "#### 🏷️ admin") {
+		t.Error("endpoints are listed under their first tag only")
+	}
+
+	if tags := GenerateReport(&parser.OpenAPISpec{Endpoints: []parser.Endpoint{{ID: "GET__health"}}}, nil).Tags; tags != nil {
+		t.Errorf("Tags = %+v, want nil for a spec without tags", tags)
+	}
+}
+
+func TestBuildCoverage(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		{ID: "GET__users", Method: "GET", Path: "/users", Tags: []string{"users"}},
+		{ID: "POST__users", Method: "POST", Path: "/users", Tags: []string{"users", "admin"}},
+		{ID: "GET__health", Method: "GET", Path: "/health"},
+	}}
+	compileError := []generator.TestError{{TestName: "compilation", Message: "undefined: foo"}}
+	results := []EndpointResult{
+		{Endpoint: spec.Endpoints[0], Tests: map[string]TestResult{
+			"gpt4": {ExecutionResult: &generator.ExecutionResult{Passed: true}},
+			"mock": {ExecutionResult: &generator.ExecutionResult{Failed: true}},
+		}},
+		{Endpoint: spec.Endpoints[1], Tests: map[string]TestResult{
+			"gpt4": {ExecutionResult: &generator.ExecutionResult{Failed: true, Errors: compileError}},
+			"mock": {TestCode: "package main"},
+		}},
+		{Endpoint: parser.Endpoint{ID: "scenario_users", Kind: parser.KindScenario, Method: "SCENARIO", Path: "users lifecycle"},
+			Tests: map[string]TestResult{"gpt4": {ExecutionResult: &generator.ExecutionResult{Skipped: true}}}},
+	}
+
+	coverage := BuildCoverage(spec, results, []string{"gpt4", "mock"})
+	want := [][2]CoverageStatus{
+		{CoveragePassed, CoverageFailed},
+		{CoverageCompileFailed, CoverageGenerated},
+		{CoverageMissing, CoverageMissing},
+		{CoverageSkipped, CoverageMissing},
+	}
+	if len(coverage.Endpoints) != len(want) {
+		t.Fatalf("Endpoints = %+v, want %d rows", coverage.Endpoints, len(want))
+	}
+	for i, row := range coverage.Endpoints {
+		if got := [2]CoverageStatus{row.Status["gpt4"], row.Status["mock"]}; got != want[i] {
+			t.Errorf("%s %s = %v, want %v", row.Method, row.Path, got, want[i])
+		}
+	}
+
+	wantTotal := CoverageTotals{Name: "Total", Endpoints: 4, Tests: 8, Generated: 5, Compiled: 3, Executed: 3, Passed: 1}
+	if coverage.Total != wantTotal {
+		t.Errorf("Total = %+v, want %+v", coverage.Total, wantTotal)
+	}
+	wantTags := []CoverageTotals{
+		{Name: "(untagged)", Endpoints: 2, Tests: 4, Generated: 1, Compiled: 1, Executed: 1},
+		{Name: "admin", Endpoints: 1, Tests: 2, Generated: 2},
+		{Name: "users", Endpoints: 2, Tests: 4, Generated: 4, Compiled: 2, Executed: 2, Passed: 1},
+	}
+	if fmt.Sprint(coverage.ByTag) != fmt.Sprint(wantTags) {
+		t.Errorf("ByTag = %+v, want %+v", coverage.ByTag, wantTags)
+	}
+	if len(coverage.ByMethod) != 3 || coverage.ByMethod[0].Name != "GET" || coverage.ByMethod[0].Passed != 1 {
+		t.Errorf("ByMethod = %+v", coverage.ByMethod)
+	}
+
+	md := RenderCoverageMarkdown(coverage)
+	for _, line := range []string{
+		"| Endpoint | gpt4 | mock |",
+		"| `POST /users` | 🔨 | 📝 |",
+		"| `GET /health` | ⬜ | ⬜ |",
+		"| **Total** | 4 | 5/8 | 3/8 | 3/8 | 1/8 |",
+	} {
+		if !strings.Contains(md, line) {
+			t.Errorf("coverage markdown is missing %q", line)
+		}
+	}
+
+	var csv strings.Builder
+	if err := WriteCoverageCSV(&csv, coverage); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 9 {
+		t.Fatalf("CSV has %d lines, want a header and 8 rows:\n%s", len(lines), csv.String())
+	}
+	if lines[3] != "POST__users,POST,/users,users;admin,gpt4,compile_failed,true,false,false,false" {
+		t.Errorf("CSV row = %q", lines[3])
+	}
+}
+
+func TestBuildPortfolio(t *testing.T) {
+	report := &Report{
+		Summary:         Summary{OverallHealthScore: 82.5, EndpointsProcessed: 4, TotalTests: 4, PassedTests: 3, FailedTests: 1},
+		EndpointResults: []EndpointResult{{IssueNumber: 12}, {}},
+		SpecQuality:     &parser.SpecQuality{Score: 90},
+	}
+	users := NewPortfolioService("users", "users.yaml", "users/report.md", report)
+	if users.IssuesCreated != 1 || users.SpecQualityScore != 90 {
+		t.Errorf("NewPortfolioService = %+v, want 1 issue and spec quality 90", users)
+	}
+
+	portfolio := BuildPortfolio([]PortfolioService{
+		{Name: "broken", Spec: "broken.yaml", Error: "failed to parse OpenAPI spec"},
+		{Name: "payments", HealthScore: 40, EndpointsProcessed: 2, TotalTests: 2, PassedTests: 1, FailedTests: 1},
+		users,
+	})
+
+	var order []string
+	for _, service := range portfolio.Services {
+		order = append(order, fmt.Sprintf("%d:%s", service.Rank, service.Name))
+	}
+	if got := strings.Join(order, " "); got != "1:users 2:payments 0:broken" {
+		t.Errorf("ranking = %q, want healthiest first and failures last", got)
+	}
+	if math.Abs(portfolio.AverageHealthScore-61.25) > 1e-9 {
+		t.Errorf("AverageHealthScore = %v, want 61.25", portfolio.AverageHealthScore)
+	}
+	if portfolio.EndpointsProcessed != 6 || portfolio.FailedTests != 2 || portfolio.IssuesCreated != 1 {
+		t.Errorf("totals = %+v", portfolio)
+	}
+
+	md := generatePortfolioMarkdown(portfolio)
+	for _, want := range []string{
+		"| 1 | users | 🟢 82.5 | 90.0 | 4 | 3 | 1 | 0 | 1 | [report](users/report.md) |",
+		"| 2 | payments | 🔴 40.0 | - | 2 | 1 | 1 | 0 | 0 | - |",
+		"- **broken** (`broken.yaml`): failed to parse OpenAPI spec",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("portfolio markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestScoring_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Scoring)
+		wantErr string
+	}{
+		{"defaults", func(*Scoring) {}, ""},
+		{"custom", func(s *Scoring) { s.Health = HealthWeights{SuccessRate: 0.7, TestCoverage: 0.3} }, ""},
+		{"rounding", func(s *Scoring) { s.Health = HealthWeights{SuccessRate: 0.1, EndpointCoverage: 0.2, TestCoverage: 0.7} }, ""},
+		{"health sum", func(s *Scoring) { s.Health.SuccessRate = 0.6 }, "scoring.health: weights sum to"},
+		{"model sum", func(s *Scoring) { s.Model.Performance = 0 }, "scoring.model: weights sum to"},
+		{"negative", func(s *Scoring) { s.Health = HealthWeights{SuccessRate: 1.5, TestCoverage: -0.5} }, "must not be negative"},
+		{"webhook", func(s *Scoring) { s.Webhook = "ftp://scores" }, "not an http(s) URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoring := DefaultScoring()
+			tt.modify(&scoring)
+			err := scoring.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateReportWithScoring(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{{ID: "GET__a"}, {ID: "GET__b"}}}
+	results := []EndpointResult{{
+		Endpoint: spec.Endpoints[0],
+		Tests: map[string]TestResult{
+			"fast": {QualityScore: 20, ExecutionResult: &generator.ExecutionResult{Passed: true, Duration: time.Millisecond}},
+			"good": {QualityScore: 100, ExecutionResult: &generator.ExecutionResult{Passed: true, Duration: 9 * time.Second}},
+		},
+	}}
+
+	scoring := Scoring{
+		Health: HealthWeights{SuccessRate: 1},
+		Model:  ModelWeights{Performance: 1},
+	}
+	report := GenerateReportWithScoring(spec, results, scoring)
+	if report.Summary.OverallHealthScore != 100 {
+		t.Errorf("OverallHealthScore = %v, want 100 when only success rate counts", report.Summary.OverallHealthScore)
+	}
+	if report.ModelComparison.BestPerformer != "fast" {
+		t.Errorf("BestPerformer = %q, want fast when only performance counts", report.ModelComparison.BestPerformer)
+	}
+	if report.Metadata["scoring"] != scoring {
+		t.Errorf("Metadata[scoring] = %v, want %v", report.Metadata["scoring"], scoring)
+	}
+
+	if best := GenerateReport(spec, results).ModelComparison.BestPerformer; best != "good" {
+		t.Errorf("default BestPerformer = %q, want good", best)
+	}
+}
+
+func TestScoreWithWebhook(t *testing.T) {
+	spec := &parser.OpenAPISpec{Endpoints: []parser.Endpoint{{ID: "GET__a", Tags: []string{"users"}}, {ID: "GET__b", Tags: []string{"admin"}}}}
+	passed := TestResult{ExecutionResult: &generator.ExecutionResult{Passed: true}}
+	results := []EndpointResult{{Endpoint: spec.Endpoints[0], Tests: map[string]TestResult{"gpt4": passed, "mock": passed}}}
+
+	var received ScoringRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"health_score": 42, "model_scores": {"mock": 90, "gpt4": 10}, "tag_scores": {"users": 7}}`))
+	}))
+	defer srv.Close()
+
+	report := GenerateReport(spec, results)
+	if err := ScoreWithWebhook(context.Background(), report, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if received.Summary.TotalTests != 2 || len(received.Models) != 2 || len(received.Tags) != 2 {
+		t.Errorf("webhook received %+v", received)
+	}
+	if report.Summary.OverallHealthScore != 42 {
+		t.Errorf("OverallHealthScore = %v, want 42", report.Summary.OverallHealthScore)
+	}
+	if report.ModelComparison.BestPerformer != "mock" {
+		t.Errorf("BestPerformer = %q, want mock", report.ModelComparison.BestPerformer)
+	}
+	if !strings.Contains(report.ModelComparison.Recommendations[0].Description, "Use mock") {
+		t.Errorf("Recommendations[0] = %+v, want mock recommended", report.ModelComparison.Recommendations[0])
+	}
+	for _, tag := range report.Tags {
+		if tag.Tag == "users" && tag.HealthScore != 7 {
+			t.Errorf("users HealthScore = %v, want 7", tag.HealthScore)
+		}
+	}
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"health_score": 420}`))
+	}))
+	defer bad.Close()
+	if err := ScoreWithWebhook(context.Background(), report, bad.URL); err == nil || report.Summary.OverallHealthScore != 42 {
+		t.Errorf("ScoreWithWebhook() = %v with health %v, want an error and the report unchanged", err, report.Summary.OverallHealthScore)
+	}
+}
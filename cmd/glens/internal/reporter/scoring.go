@@ -0,0 +1,252 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// weightTolerance absorbs rounding in weights like 0.1 + 0.2 + 0.7
+const weightTolerance = 1e-6
+
+// scoringWebhookTimeout bounds a scoring webhook call
+const scoringWebhookTimeout = 30 * time.Second
+
+// maxScoringResponse bounds the body read from a scoring webhook
+const maxScoringResponse = 1 << 20
+
+// HealthWeights weigh the parts of the overall health score of a report
+// and of its tags; they sum to 1
+type HealthWeights struct {
+	// SuccessRate weighs the share of tests that passed
+	SuccessRate float64 `json:"success_rate"`
+	// EndpointCoverage weighs the share of the spec's endpoints processed
+	EndpointCoverage float64 `json:"endpoint_coverage"`
+	// TestCoverage weighs how well the tests cover their endpoints' status
+	// codes and parameters
+	TestCoverage float64 `json:"test_coverage"`
+}
+
+// ModelWeights weigh the parts of the composite score that picks the best
+// performing model; they sum to 1
+type ModelWeights struct {
+	Quality     float64 `json:"quality"`
+	Coverage    float64 `json:"coverage"`
+	Reliability float64 `json:"reliability"`
+	// Performance weighs execution speed: 100 for instant tests, 50 for
+	// tests taking a second
+	Performance float64 `json:"performance"`
+}
+
+// Scoring configures how reports score health and rank models
+type Scoring struct {
+	Health HealthWeights `json:"health"`
+	Model  ModelWeights  `json:"model"`
+	// Webhook, when set, is the URL of a service replacing the weighted
+	// scores with an organization's own KPI formulas (see ScoreWithWebhook)
+	Webhook string `json:"-"`
+}
+
+// DefaultScoring returns the weights reports use unless configured
+func DefaultScoring() Scoring {
+	return Scoring{
+		Health: HealthWeights{SuccessRate: 0.5, EndpointCoverage: 0.2, TestCoverage: 0.3},
+		Model:  ModelWeights{Quality: 0.30, Coverage: 0.25, Reliability: 0.25, Performance: 0.20},
+	}
+}
+
+// String describes the weights, e.g. in the metadata of a Markdown report
+func (s Scoring) String() string {
+	return fmt.Sprintf("health: %g success rate, %g endpoint coverage, %g test coverage; models: %g quality, %g coverage, %g reliability, %g performance",
+		s.Health.SuccessRate, s.Health.EndpointCoverage, s.Health.TestCoverage,
+		s.Model.Quality, s.Model.Coverage, s.Model.Reliability, s.Model.Performance)
+}
+
+// Validate checks that each group of weights is non-negative and sums to 1,
+// and that the webhook is an HTTP(S) URL
+func (s *Scoring) Validate() error {
+	if err := validateWeights("scoring.health", map[string]float64{
+		"success_rate":      s.Health.SuccessRate,
+		"endpoint_coverage": s.Health.EndpointCoverage,
+		"test_coverage":     s.Health.TestCoverage,
+	}); err != nil {
+		return err
+	}
+	if err := validateWeights("scoring.model", map[string]float64{
+		"quality":     s.Model.Quality,
+		"coverage":    s.Model.Coverage,
+		"reliability": s.Model.Reliability,
+		"performance": s.Model.Performance,
+	}); err != nil {
+		return err
+	}
+	if s.Webhook != "" {
+		u, err := url.Parse(s.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("scoring.webhook: %q is not an http(s) URL", s.Webhook)
+		}
+	}
+	return nil
+}
+
+// validateWeights checks the weights of the group named section
+func validateWeights(section string, weights map[string]float64) error {
+	sum := 0.0
+	for name, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("%s.%s: %v must not be negative", section, name, weight)
+		}
+		sum += weight
+	}
+	if math.Abs(sum-1) > weightTolerance {
+		return fmt.Errorf("%s: weights sum to %v, want 1", section, sum)
+	}
+	return nil
+}
+
+// score is the health score of summary as a percentage
+func (w HealthWeights) score(summary *Summary) float64 {
+	if summary.TotalTests == 0 {
+		return 0.0
+	}
+
+	successRate := float64(summary.PassedTests) / float64(summary.TotalTests)
+	coverageRate := float64(summary.EndpointsProcessed) / float64(summary.TotalEndpoints)
+
+	healthScore := (successRate * w.SuccessRate) + (coverageRate * w.EndpointCoverage) +
+		(summary.AverageCoverage / 100 * w.TestCoverage)
+
+	return healthScore * 100 // Return as percentage
+}
+
+// score is the composite score ranking model
+func (w ModelWeights) score(model *ModelResult) float64 {
+	// Normalize performance score (lower execution time is better)
+	performanceScore := 100.0
+	if model.AvgExecutionTime > 0 {
+		// Convert to seconds and invert (max 100 for under 1 second)
+		seconds := model.AvgExecutionTime.Seconds()
+		performanceScore = 100.0 / (1.0 + seconds)
+	}
+
+	return (model.AvgQualityScore * w.Quality) +
+		(model.AvgCoverageScore * w.Coverage) +
+		(model.SuccessRate * 100 * w.Reliability) +
+		(performanceScore * w.Performance)
+}
+
+// ScoringRequest is what a scoring webhook receives
+type ScoringRequest struct {
+	Summary Summary       `json:"summary"`
+	Models  []ModelResult `json:"models"`
+	Tags    []TagSummary  `json:"tags,omitempty"`
+}
+
+// ScoringResponse is what a scoring webhook answers. Scores are
+// percentages; any it leaves out keep their weighted value.
+type ScoringResponse struct {
+	HealthScore *float64 `json:"health_score,omitempty"`
+	// ModelScores rank the models: the highest becomes the best performer
+	ModelScores map[string]float64 `json:"model_scores,omitempty"`
+	TagScores   map[string]float64 `json:"tag_scores,omitempty"`
+}
+
+// ScoreWithWebhook POSTs the summary, models and tags of report as a
+// ScoringRequest to webhook and applies the scores of its ScoringResponse,
+// for organizations with KPI formulas of their own. The report is left
+// unchanged on error.
+func ScoreWithWebhook(ctx context.Context, report *Report, webhook string) error {
+	body, err := json.Marshal(ScoringRequest{
+		Summary: report.Summary,
+		Models:  report.ModelComparison.Models,
+		Tags:    report.Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode scoring request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scoringWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create scoring request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("scoring webhook failed: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("scoring webhook returned %s", resp.Status)
+	}
+	var scores ScoringResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxScoringResponse)).Decode(&scores); err != nil {
+		return fmt.Errorf("failed to decode scoring webhook response: %w", err)
+	}
+	if err := scores.validate(); err != nil {
+		return fmt.Errorf("scoring webhook: %w", err)
+	}
+
+	scores.apply(report)
+	return nil
+}
+
+// validate checks that every score is a percentage
+func (r *ScoringResponse) validate() error {
+	check := func(name string, score float64) error {
+		if math.IsNaN(score) || score < 0 || score > 100 {
+			return fmt.Errorf("score of %s is %v, want 0 to 100", name, score)
+		}
+		return nil
+	}
+	if r.HealthScore != nil {
+		if err := check("health", *r.HealthScore); err != nil {
+			return err
+		}
+	}
+	for model, score := range r.ModelScores {
+		if err := check("model "+model, score); err != nil {
+			return err
+		}
+	}
+	for tag, score := range r.TagScores {
+		if err := check("tag "+tag, score); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply replaces the scores of report with those of the response, picking
+// the best performer and its recommendation again when models were scored
+func (r *ScoringResponse) apply(report *Report) {
+	if r.HealthScore != nil {
+		report.Summary.OverallHealthScore = *r.HealthScore
+	}
+	for i := range report.Tags {
+		if score, ok := r.TagScores[report.Tags[i].Tag]; ok {
+			report.Tags[i].HealthScore = score
+		}
+	}
+
+	comparison := &report.ModelComparison
+	best, bestScore := "", -1.0
+	for i := range comparison.Models {
+		name := comparison.Models[i].ModelName
+		if score, ok := r.ModelScores[name]; ok && score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+	if best != "" {
+		comparison.BestPerformer = best
+		comparison.Recommendations = generateRecommendations(comparison.Models, best)
+	}
+}
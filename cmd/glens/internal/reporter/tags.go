@@ -0,0 +1,120 @@
+package reporter
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// TagSummary summarizes the results of the endpoints with an OpenAPI tag,
+// the granularity at which teams usually own APIs
+type TagSummary struct {
+	Tag                string  `json:"tag"`
+	TotalEndpoints     int     `json:"total_endpoints"`
+	EndpointsProcessed int     `json:"endpoints_processed"`
+	TotalTests         int     `json:"total_tests"`
+	PassedTests        int     `json:"passed_tests"`
+	FailedTests        int     `json:"failed_tests"`
+	FlakyTests         int     `json:"flaky_tests"`
+	IssuesCreated      int     `json:"issues_created"`
+	PassRate           float64 `json:"pass_rate"` // percentage of tests that passed
+	HealthScore        float64 `json:"health_score"`
+}
+
+// endpointTags returns the tags of an endpoint, or untagged
+func endpointTags(endpoint *parser.Endpoint) []string {
+	if len(endpoint.Tags) == 0 {
+		return []string{untagged}
+	}
+	return endpoint.Tags
+}
+
+// tagSummaries summarizes the results of each tag of spec, scoring health
+// like the whole report; an endpoint with several tags counts towards each.
+// Tags are sorted by name, untagged endpoints last. It is nil when the spec
+// has no tags.
+func tagSummaries(spec *parser.OpenAPISpec, results []EndpointResult, weights HealthWeights) []TagSummary {
+	specs := make(map[string]*parser.OpenAPISpec)
+	tagResults := make(map[string][]EndpointResult)
+	for i := range spec.Endpoints {
+		for _, tag := range endpointTags(&spec.Endpoints[i]) {
+			if specs[tag] == nil {
+				specs[tag] = &parser.OpenAPISpec{}
+			}
+			specs[tag].Endpoints = append(specs[tag].Endpoints, spec.Endpoints[i])
+		}
+	}
+	for i := range results {
+		for _, tag := range endpointTags(&results[i].Endpoint) {
+			tagResults[tag] = append(tagResults[tag], results[i])
+			if specs[tag] == nil {
+				specs[tag] = &parser.OpenAPISpec{}
+			}
+		}
+	}
+	if len(specs) == 0 || (len(specs) == 1 && specs[untagged] != nil) {
+		return nil
+	}
+
+	tags := make([]string, 0, len(specs))
+	for tag := range specs {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if (tags[i] == untagged) != (tags[j] == untagged) {
+			return tags[j] == untagged
+		}
+		return tags[i] < tags[j]
+	})
+
+	summaries := make([]TagSummary, 0, len(tags))
+	for _, tag := range tags {
+		tagSpec := specs[tag]
+		// Results without a spec endpoint, such as scenarios, count as
+		// endpoints of their tag
+		if len(tagSpec.Endpoints) < len(tagResults[tag]) {
+			tagSpec = &parser.OpenAPISpec{Endpoints: make([]parser.Endpoint, len(tagResults[tag]))}
+		}
+		summary := generateSummary(tagSpec, tagResults[tag], weights)
+		summaries = append(summaries, TagSummary{
+			Tag:                tag,
+			TotalEndpoints:     summary.TotalEndpoints,
+			EndpointsProcessed: summary.EndpointsProcessed,
+			TotalTests:         summary.TotalTests,
+			PassedTests:        summary.PassedTests,
+			FailedTests:        summary.FailedTests,
+			FlakyTests:         summary.FlakyTests,
+			IssuesCreated:      summary.TotalIssuesCreated,
+			PassRate:           summary.ExecutionSummary.SuccessRate * 100,
+			HealthScore:        summary.OverallHealthScore,
+		})
+	}
+	return summaries
+}
+
+// writeTagSummaries writes the per-tag summary table
+func writeTagSummaries(md *strings.Builder, tags []TagSummary) {
+	fmt.Fprintf(md, "| Tag | Endpoints | Tests | Passed | Failed | Pass Rate | Health | Issues |\n")
+	fmt.Fprintf(md, "|-----|-----------|-------|--------|--------|-----------|--------|--------|\n")
+	for _, tag := range tags {
+		fmt.Fprintf(md, "| %s | %d/%d | %d | %d | %d | %.1f%% | %s %.1f%% | %d |\n", tag.Tag,
+			tag.EndpointsProcessed, tag.TotalEndpoints, tag.TotalTests, tag.PassedTests, tag.FailedTests,
+			tag.PassRate, healthBadge(tag.HealthScore), tag.HealthScore, tag.IssuesCreated)
+	}
+	fmt.Fprintf(md, "\n")
+}
+
+// writeHTMLTagSummaries writes the per-tag summary table as HTML
+func writeHTMLTagSummaries(htmlBuilder *strings.Builder, tags []TagSummary) {
+	htmlBuilder.WriteString("<table>\n")
+	htmlBuilder.WriteString("<tr><th>Tag</th><th>Endpoints</th><th>Tests</th><th>Passed</th><th>Failed</th><th>Pass Rate</th><th>Health</th><th>Issues</th></tr>\n")
+	for _, tag := range tags {
+		fmt.Fprintf(htmlBuilder, "<tr><td>%s</td><td>%d/%d</td><td>%d</td><td>%d</td><td>%d</td><td>%.1f%%</td><td>%s %.1f%%</td><td>%d</td></tr>\n",
+			html.EscapeString(tag.Tag), tag.EndpointsProcessed, tag.TotalEndpoints, tag.TotalTests, tag.PassedTests,
+			tag.FailedTests, tag.PassRate, healthBadge(tag.HealthScore), tag.HealthScore, tag.IssuesCreated)
+	}
+	htmlBuilder.WriteString("</table>\n")
+}
@@ -3,6 +3,8 @@ package reporter
 import (
 	"time"
 
+	"glens/pkg/safety"
+
 	"glens/tools/glens/internal/generator"
 	"glens/tools/glens/internal/parser"
 )
@@ -16,6 +18,38 @@ type Report struct {
 	GeneratedAt     time.Time              `json:"generated_at"`
 	ExecutionTime   time.Duration          `json:"execution_time"`
 	Metadata        map[string]interface{} `json:"metadata"`
+	// DeprecatedOperations lists the operations the spec marks deprecated,
+	// whether or not they were tested
+	DeprecatedOperations []DeprecatedOperation `json:"deprecated_operations,omitempty"`
+	// SpecQuality scores how well the spec documents its operations; nil
+	// for GraphQL and gRPC schemas
+	SpecQuality *parser.SpecQuality `json:"spec_quality,omitempty"`
+	// Coverage maps every endpoint of the spec against every model
+	Coverage *Coverage `json:"coverage,omitempty"`
+	// Tags summarizes the results per OpenAPI tag; nil when the spec has
+	// no tags
+	Tags []TagSummary `json:"tags,omitempty"`
+	// RequiredEnv lists the GLENS_* environment variables the generated
+	// tests read, which must be set to run them
+	RequiredEnv []RequiredVariable `json:"required_env,omitempty"`
+}
+
+// RequiredVariable is an environment variable generated tests read
+type RequiredVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Tests counts the generated tests reading it
+	Tests int `json:"tests"`
+}
+
+// DeprecatedOperation is an operation marked deprecated in the spec
+type DeprecatedOperation struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operation_id,omitempty"`
+	// Tested is set when the run generated tests for the operation
+	// (--include-deprecated)
+	Tested bool `json:"tested"`
 }
 
 // Summary contains high-level statistics
@@ -26,10 +60,12 @@ type Summary struct {
 	PassedTests        int              `json:"passed_tests"`
 	FailedTests        int              `json:"failed_tests"`
 	SkippedTests       int              `json:"skipped_tests"`
+	FlakyTests         int              `json:"flaky_tests"`
 	TotalIssuesCreated int              `json:"total_issues_created"`
 	AIModelsUsed       []string         `json:"ai_models_used"`
 	Frameworks         []string         `json:"frameworks"`
 	ExecutionSummary   ExecutionSummary `json:"execution_summary"`
+	AverageCoverage    float64          `json:"average_coverage"`
 	OverallHealthScore float64          `json:"overall_health_score"`
 }
 
@@ -51,7 +87,32 @@ type EndpointResult struct {
 	Tests        map[string]TestResult `json:"tests"` // key: AI model name
 	OverallScore float64               `json:"overall_score"`
 	Status       EndpointStatus        `json:"status"`
-	ProcessedAt  time.Time             `json:"processed_at"`
+	// SkipReason explains a StatusSkipped endpoint, e.g. a timeout
+	SkipReason  string    `json:"skip_reason,omitempty"`
+	ProcessedAt time.Time `json:"processed_at"`
+	// Category and RiskLevel classify the endpoint's side effects; Warnings
+	// flag risky endpoints and explain tests that were not executed
+	Category  safety.Category `json:"category,omitempty"`
+	RiskLevel safety.Risk     `json:"risk_level,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+	// Ensemble combines the tests of all models when ensemble mode is on
+	Ensemble *EnsembleResult `json:"ensemble,omitempty"`
+	// Models are the models routing rules assigned to the endpoint and
+	// Route the rule that picked them (e.g. rules[0], or default); both are
+	// empty without routing
+	Models []string `json:"models,omitempty"`
+	Route  string   `json:"route,omitempty"`
+}
+
+// EnsembleResult is the test an ensemble of models produced for an endpoint
+type EnsembleResult struct {
+	// Mode is "best" (pick one model's test) or "merge" (combine them)
+	Mode string `json:"mode"`
+	// Selected is the model whose test was picked in best mode
+	Selected string `json:"selected,omitempty"`
+	// Contributions lists the test functions each model contributed
+	Contributions map[string][]string `json:"contributions"`
+	Test          TestResult          `json:"test"`
 }
 
 // TestResult contains results for a specific AI model's test
@@ -65,6 +126,14 @@ type TestResult struct {
 	GeneratedAt     time.Time                  `json:"generated_at"`
 	Metrics         TestMetrics                `json:"metrics"`
 	QualityScore    float64                    `json:"quality_score"`
+	// Metadata describes the generation, e.g. temperature, seed and tokens
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// ArtifactsDir holds the prompt, raw response, code and logs of the
+	// test's generation when the run saved them (--artifacts-dir)
+	ArtifactsDir string `json:"artifacts_dir,omitempty"`
+	// GistURL is the secret gist holding the failing test and its log,
+	// when its failure issue attached them as gists
+	GistURL string `json:"gist_url,omitempty"`
 }
 
 // TestMetrics contains detailed test metrics
@@ -84,6 +153,14 @@ type CodeQuality struct {
 	ComplexityScore   float64  `json:"complexity_score"`
 	ReadabilityScore  float64  `json:"readability_score"`
 	CategoriesCovered []string `json:"categories_covered"`
+	// StaticFindings are the issues the static analysis gate reported
+	StaticFindings []generator.Finding `json:"static_findings,omitempty"`
+	// AssertionStrength is how deeply the test functions assert the
+	// response (status, body, headers), from 0 to 100
+	AssertionStrength float64 `json:"assertion_strength"`
+	// WeakTests are the test functions asserting neither the status, the
+	// body nor the headers of the response, e.g. only err == nil
+	WeakTests []string `json:"weak_tests,omitempty"`
 }
 
 // TestCoverage measures how well the test covers the endpoint
@@ -133,6 +210,7 @@ type ModelResult struct {
 	TestsGenerated   int           `json:"tests_generated"`
 	TestsPassed      int           `json:"tests_passed"`
 	TestsFailed      int           `json:"tests_failed"`
+	TestsFlaky       int           `json:"tests_flaky"`
 	AvgQualityScore  float64       `json:"avg_quality_score"`
 	AvgCoverageScore float64       `json:"avg_coverage_score"`
 	AvgExecutionTime time.Duration `json:"avg_execution_time"`
@@ -140,6 +218,9 @@ type ModelResult struct {
 	SuccessRate      float64       `json:"success_rate"`
 	Strengths        []string      `json:"strengths"`
 	Weaknesses       []string      `json:"weaknesses"`
+	// WeakTestRate is the share of the model's test functions that assert
+	// nothing about the response
+	WeakTestRate float64 `json:"weak_test_rate"`
 }
 
 // ComparisonMatrix provides side-by-side comparison data
@@ -204,4 +285,7 @@ const (
 	FormatHTML ReportFormat = "html"
 	// FormatPDF generates reports in PDF format
 	FormatPDF ReportFormat = "pdf"
+	// FormatLSPDiagnostics generates the failing tests and spec quality
+	// gaps as Language Server Protocol diagnostics of the spec file
+	FormatLSPDiagnostics ReportFormat = "lsp-diagnostics"
 )
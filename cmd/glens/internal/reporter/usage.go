@@ -0,0 +1,153 @@
+package reporter
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// Price is the cost of a model's tokens
+type Price struct {
+	Model string `mapstructure:"model" json:"model"`
+	// USDPerMillionTokens prices input and output tokens alike; reports
+	// record their sum only
+	USDPerMillionTokens float64 `mapstructure:"usd_per_million_tokens" json:"usd_per_million_tokens"`
+}
+
+// Usage sums the AI token consumption of past reports and its cost, for
+// charging AI spend back to the teams owning the specs. Total.Runs counts
+// the reports with tests.
+type Usage struct {
+	ByModel []UsageRow `json:"by_model"`
+	BySpec  []UsageRow `json:"by_spec"`
+	ByMonth []UsageRow `json:"by_month"`
+	Total   UsageRow   `json:"total"`
+	// Unpriced lists the models without a price, whose tokens cost nothing
+	// in the totals
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// UsageRow is the consumption of one model, spec or month
+type UsageRow struct {
+	Name    string  `json:"name"`
+	Runs    int     `json:"runs"`
+	Tests   int     `json:"tests"`
+	Tokens  int     `json:"tokens"`
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// UsageMonthFormat is the layout of the months of Usage.ByMonth
+const UsageMonthFormat = "2006-01"
+
+// BuildUsage sums the tokens of every test of reports by model, by spec
+// title and by month of the run. Tests from a fallback model count for the
+// model that generated them. Reports written before token usage was
+// recorded count their tests with 0 tokens.
+func BuildUsage(reports []*Report, prices []Price) *Usage {
+	perMillion := make(map[string]float64, len(prices))
+	for _, p := range prices {
+		perMillion[p.Model] = p.USDPerMillionTokens
+	}
+	byModel := make(map[string]*UsageRow)
+	bySpec := make(map[string]*UsageRow)
+	byMonth := make(map[string]*UsageRow)
+	unpriced := make(map[string]bool)
+
+	usage := &Usage{Total: UsageRow{Name: "total"}}
+	for _, report := range reports {
+		spec := report.Specification.Info.Title
+		if spec == "" {
+			spec = "untitled"
+		}
+		month := report.GeneratedAt.UTC().Format(UsageMonthFormat)
+		counted := make(map[*UsageRow]bool)
+		add := func(groups map[string]*UsageRow, name string, tokens int, cost float64) {
+			row, ok := groups[name]
+			if !ok {
+				row = &UsageRow{Name: name}
+				groups[name] = row
+			}
+			if !counted[row] {
+				counted[row] = true
+				row.Runs++
+			}
+			row.Tests++
+			row.Tokens += tokens
+			row.CostUSD += cost
+		}
+
+		tests := 0
+		for _, result := range report.EndpointResults {
+			for _, test := range result.Tests {
+				model := test.AIModel
+				if generatedBy := test.Metadata["generated_by"]; generatedBy != "" {
+					model = generatedBy
+				}
+				tokens := test.Metrics.Performance.TokensUsed
+				price, ok := perMillion[model]
+				if !ok && tokens > 0 {
+					unpriced[model] = true
+				}
+				cost := float64(tokens) * price / 1e6
+				add(byModel, model, tokens, cost)
+				add(bySpec, spec, tokens, cost)
+				add(byMonth, month, tokens, cost)
+				usage.Total.Tests++
+				usage.Total.Tokens += tokens
+				usage.Total.CostUSD += cost
+				tests++
+			}
+		}
+		if tests > 0 {
+			usage.Total.Runs++
+		}
+	}
+
+	usage.ByModel = usageRows(byModel)
+	usage.BySpec = usageRows(bySpec)
+	usage.ByMonth = usageRows(byMonth)
+	for model := range unpriced {
+		usage.Unpriced = append(usage.Unpriced, model)
+	}
+	slices.Sort(usage.Unpriced)
+	return usage
+}
+
+// usageRows returns the rows of groups sorted by name
+func usageRows(groups map[string]*UsageRow) []UsageRow {
+	rows := make([]UsageRow, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b UsageRow) int { return cmp.Compare(a.Name, b.Name) })
+	return rows
+}
+
+// Groups returns the groupings of u by name (model, spec, month)
+func (u *Usage) Groups() map[string][]UsageRow {
+	return map[string][]UsageRow{"model": u.ByModel, "spec": u.BySpec, "month": u.ByMonth}
+}
+
+// WriteUsageCSV writes the rows of the named groups of usage as CSV, one
+// row per model, spec or month, for spreadsheets and billing systems.
+func WriteUsageCSV(w io.Writer, usage *Usage, groups []string) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"group", "name", "runs", "tests", "tokens", "cost_usd"})
+	all := usage.Groups()
+	for _, group := range groups {
+		for _, row := range all[group] {
+			_ = out.Write([]string{
+				group, row.Name, strconv.Itoa(row.Runs), strconv.Itoa(row.Tests),
+				strconv.Itoa(row.Tokens), strconv.FormatFloat(row.CostUSD, 'f', 4, 64),
+			})
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write usage CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package reporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// usageReport returns a report of spec from at with a test per model using
+// the given tokens
+func usageReport(spec string, at time.Time, tokens map[string]int) *Report {
+	tests := make(map[string]TestResult, len(tokens))
+	for model, used := range tokens {
+		test := TestResult{AIModel: model}
+		test.Metrics.Performance.TokensUsed = used
+		tests[model] = test
+	}
+	return &Report{
+		Specification:   parser.OpenAPISpec{Info: parser.Info{Title: spec}},
+		GeneratedAt:     at,
+		EndpointResults: []EndpointResult{{Tests: tests}},
+	}
+}
+
+func TestBuildUsage(t *testing.T) {
+	jan := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC)
+	fallback := usageReport("Orders", feb, map[string]int{"gpt-4o": 1000})
+	test := fallback.EndpointResults[0].Tests["gpt-4o"]
+	test.Metadata = map[string]string{"generated_by": "gpt-4o-mini"}
+	fallback.EndpointResults[0].Tests["gpt-4o"] = test
+
+	usage := BuildUsage([]*Report{
+		usageReport("Payments", jan, map[string]int{"gpt-4o": 200000, "ollama": 50000}),
+		usageReport("Payments", feb, map[string]int{"gpt-4o": 100000}),
+		fallback,
+		{GeneratedAt: feb}, // a portfolio or empty run
+	}, []Price{{Model: "gpt-4o", USDPerMillionTokens: 5}, {Model: "gpt-4o-mini", USDPerMillionTokens: 0.5}})
+
+	assert.Equal(t, UsageRow{Name: "total", Runs: 3, Tests: 4, Tokens: 351000, CostUSD: 1.5005}, roundCost(usage.Total))
+	require.Len(t, usage.ByModel, 3)
+	assert.Equal(t, UsageRow{Name: "gpt-4o", Runs: 2, Tests: 2, Tokens: 300000, CostUSD: 1.5}, roundCost(usage.ByModel[0]))
+	assert.Equal(t, UsageRow{Name: "gpt-4o-mini", Runs: 1, Tests: 1, Tokens: 1000, CostUSD: 0.0005}, roundCost(usage.ByModel[1]))
+	assert.Equal(t, []string{"ollama"}, usage.Unpriced)
+	assert.Equal(t, []UsageRow{
+		{Name: "Orders", Runs: 1, Tests: 1, Tokens: 1000, CostUSD: 0.0005},
+		{Name: "Payments", Runs: 2, Tests: 3, Tokens: 350000, CostUSD: 1.5},
+	}, []UsageRow{roundCost(usage.BySpec[0]), roundCost(usage.BySpec[1])})
+	assert.Equal(t, []string{"2026-01", "2026-02"}, []string{usage.ByMonth[0].Name, usage.ByMonth[1].Name})
+	assert.Equal(t, 2, usage.ByMonth[1].Runs)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteUsageCSV(&buf, usage, []string{"month"}))
+	assert.Equal(t, "group,name,runs,tests,tokens,cost_usd\n"+
+		"month,2026-01,1,2,250000,1.0000\n"+
+		"month,2026-02,2,2,101000,0.5005\n", buf.String())
+}
+
+// roundCost rounds the cost of row to hundredths of a cent
+func roundCost(row UsageRow) UsageRow {
+	row.CostUSD = float64(int64(row.CostUSD*1e4+0.5)) / 1e4
+	return row
+}
@@ -0,0 +1,191 @@
+// Package scaffold renders the starter files glens init writes into a
+// project: the config, the .gitignore of the .glens directory, and CI
+// pipelines running glens analyze when the spec changes.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Paths of the scaffolded files, relative to the project directory
+const (
+	ConfigPath         = ".glens/config.yaml"
+	GitignorePath      = ".glens/.gitignore"
+	GitHubWorkflowPath = ".github/workflows/glens.yml"
+	GitLabCIPath       = ".gitlab-ci.yml"
+)
+
+// CI systems a pipeline can be scaffolded for
+const (
+	CIGitHub = "github"
+	CIGitLab = "gitlab"
+)
+
+// CISystems are the supported CI systems
+var CISystems = []string{CIGitHub, CIGitLab}
+
+// Options are the answers a scaffold is rendered from
+type Options struct {
+	// Spec is the path or URL of the OpenAPI spec the pipelines analyze
+	Spec   string
+	Models []string
+	// Environment names the environment the tests run against at BaseURL;
+	// empty leaves environments out
+	Environment string
+	BaseURL     string
+	// Repository is the owner/repo that receives issues; empty disables
+	// issue creation
+	Repository string
+	// CI lists the CI systems to write pipelines for
+	CI []string
+	// Version is the glens release the pipelines install, e.g. 1.4.0
+	Version string
+}
+
+// File is a rendered file
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// releaseTagPrefix prefixes the release tags of the glens module
+const releaseTagPrefix = "cmd/glens/"
+
+var (
+	environmentName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	repositoryName  = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+	releaseVersion  = regexp.MustCompile(`^v\d+\.\d+\.\d+`)
+)
+
+// Validate reports answers that would render a broken scaffold
+func (o Options) Validate() error {
+	var errs []error
+	if len(o.Models) == 0 {
+		errs = append(errs, errors.New("at least one AI model is required"))
+	}
+	if o.Environment != "" && !environmentName.MatchString(o.Environment) {
+		errs = append(errs, fmt.Errorf("environment name %q may only contain letters, digits, - and _", o.Environment))
+	}
+	if o.Environment != "" && o.BaseURL == "" {
+		errs = append(errs, fmt.Errorf("environment %s needs a base URL", o.Environment))
+	}
+	if o.Repository != "" && !repositoryName.MatchString(o.Repository) {
+		errs = append(errs, fmt.Errorf("repository %q is not owner/repo", o.Repository))
+	}
+	for _, ci := range o.CI {
+		if !slices.Contains(CISystems, ci) {
+			errs = append(errs, fmt.Errorf("unsupported CI %q (supported: %s)", ci, strings.Join(CISystems, ", ")))
+		}
+	}
+	if len(o.CI) > 0 && o.Spec == "" {
+		errs = append(errs, errors.New("CI pipelines need the spec to analyze"))
+	}
+	return errors.Join(errs...)
+}
+
+// templateData is what templates render from
+type templateData struct {
+	Options
+	// SpecIsFile is set when Spec is a path in the project, so pipelines
+	// run when it changes
+	SpecIsFile bool
+	// Released is set when Version names a glens release
+	Released bool
+}
+
+var funcs = template.FuncMap{
+	"quote": strconv.Quote,
+	"list": func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+}
+
+// Render returns the files of the scaffold described by opts
+func Render(opts Options) ([]File, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// Release tags of the glens module are prefixed with its directory;
+	// release builds embed the version without them and without the v, and
+	// development builds get a placeholder to replace
+	version := "v" + strings.TrimPrefix(strings.TrimPrefix(opts.Version, releaseTagPrefix), "v")
+	data := templateData{
+		Options:    opts,
+		SpecIsFile: opts.Spec != "" && !strings.Contains(opts.Spec, "://"),
+		Released:   releaseVersion.MatchString(version),
+	}
+	data.Version = releaseTagPrefix + version
+	if !data.Released {
+		data.Version = releaseTagPrefix + "v0.0.0"
+	}
+
+	files := map[string]string{
+		ConfigPath:    "config.yaml.tmpl",
+		GitignorePath: "gitignore.tmpl",
+	}
+	if slices.Contains(opts.CI, CIGitHub) {
+		files[GitHubWorkflowPath] = "github-workflow.yml.tmpl"
+	}
+	if slices.Contains(opts.CI, CIGitLab) {
+		files[GitLabCIPath] = "gitlab-ci.yml.tmpl"
+	}
+
+	rendered := make([]File, 0, len(files))
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		name := files[path]
+		tmpl, err := template.New(name).Funcs(funcs).ParseFS(templates, "templates/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", path, err)
+		}
+		rendered = append(rendered, File{Path: path, Content: buf.Bytes()})
+	}
+	return rendered, nil
+}
+
+// Write writes files under dir. Unless force is set it writes nothing when
+// any of them exists, returning the existing paths in the error.
+func Write(dir string, files []File, force bool) error {
+	if !force {
+		var existing []string
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(dir, f.Path)); err == nil {
+				existing = append(existing, f.Path)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("%s already exist; use --force to overwrite", strings.Join(existing, ", "))
+		}
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(path, f.Content, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRender(t *testing.T) {
+	files, err := Render(Options{
+		Spec:        "api/openapi.yaml",
+		Models:      []string{"gpt4", "sonnet4"},
+		Environment: "staging",
+		BaseURL:     "https://staging.example.com",
+		Repository:  "acme/pets",
+		CI:          []string{CIGitLab, CIGitHub},
+		Version:     "1.4.0",
+	})
+	require.NoError(t, err)
+
+	rendered := make(map[string]string, len(files))
+	for _, f := range files {
+		if f.Path != GitignorePath {
+			var doc map[string]any
+			require.NoError(t, yaml.Unmarshal(f.Content, &doc), "%s is valid YAML", f.Path)
+		}
+		rendered[f.Path] = string(f.Content)
+	}
+	assert.Len(t, rendered, 4)
+
+	config := rendered[ConfigPath]
+	assert.Contains(t, config, `ai_models: ["gpt4", "sonnet4"]`)
+	assert.Contains(t, config, `environment: "staging"`)
+	assert.Contains(t, config, `base_url: "https://staging.example.com"`)
+	assert.Contains(t, config, `repository: "acme/pets"`)
+	assert.Contains(t, config, "create_issues: true")
+
+	workflow := rendered[GitHubWorkflowPath]
+	assert.Contains(t, workflow, `- "api/openapi.yaml"`, "runs when the spec changes")
+	assert.Contains(t, workflow, `GLENS_VERSION: "cmd/glens/v1.4.0"`)
+	assert.Contains(t, workflow, "${{ secrets.OPENAI_API_KEY }}")
+	assert.NotContains(t, workflow, "schedule")
+	assert.Contains(t, rendered[GitLabCIPath], `glens analyze "api/openapi.yaml" --config .glens/config.yaml`)
+	assert.Contains(t, rendered[GitignorePath], "reports/")
+}
+
+func TestRender_Defaults(t *testing.T) {
+	files, err := Render(Options{
+		Spec:    "https://api.example.com/openapi.json",
+		Models:  []string{"gpt4"},
+		CI:      []string{CIGitHub},
+		Version: "dev",
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	rendered := make(map[string]string, len(files))
+	for _, f := range files {
+		rendered[f.Path] = string(f.Content)
+	}
+
+	config := rendered[ConfigPath]
+	assert.NotContains(t, config, "environments:")
+	assert.Contains(t, config, "create_issues: false")
+
+	workflow := rendered[GitHubWorkflowPath]
+	assert.Contains(t, workflow, "schedule:", "a remote spec is analyzed on a schedule")
+	assert.Contains(t, workflow, `"cmd/glens/v0.0.0" # set to a glens release tag`)
+}
+
+func TestOptions_Validate(t *testing.T) {
+	assert.NoError(t, Options{Models: []string{"gpt4"}}.Validate())
+
+	err := Options{
+		Environment: "stag ing",
+		Repository:  "pets",
+		CI:          []string{"jenkins"},
+	}.Validate()
+	assert.ErrorContains(t, err, "at least one AI model is required")
+	assert.ErrorContains(t, err, `environment name "stag ing"`)
+	assert.ErrorContains(t, err, "environment stag ing needs a base URL")
+	assert.ErrorContains(t, err, `repository "pets" is not owner/repo`)
+	assert.ErrorContains(t, err, `unsupported CI "jenkins"`)
+	assert.ErrorContains(t, err, "CI pipelines need the spec to analyze")
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	files := []File{{Path: ConfigPath, Content: []byte("run: {}\n")}, {Path: GitignorePath, Content: []byte("cache/\n")}}
+	require.NoError(t, Write(dir, files, false))
+
+	content, err := os.ReadFile(filepath.Join(dir, ConfigPath))
+	require.NoError(t, err)
+	assert.Equal(t, "run: {}\n", string(content))
+
+	files[0].Content = []byte("run: {ai_models: [gpt4]}\n")
+	err = Write(dir, files, false)
+	assert.ErrorContains(t, err, ".glens/config.yaml, .glens/.gitignore already exist")
+	content, err = os.ReadFile(filepath.Join(dir, ConfigPath))
+	require.NoError(t, err)
+	assert.Equal(t, "run: {}\n", string(content), "nothing is overwritten")
+
+	require.NoError(t, Write(dir, files, true))
+	content, err = os.ReadFile(filepath.Join(dir, ConfigPath))
+	require.NoError(t, err)
+	assert.Equal(t, "run: {ai_models: [gpt4]}\n", string(content))
+}
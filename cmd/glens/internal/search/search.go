@@ -0,0 +1,202 @@
+// Package search finds the operations of a spec that best match a natural
+// language query, by the similarity of the embeddings of the query and of
+// each operation's summary. Embeddings are cached on disk per spec.
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/parser"
+)
+
+// batchSize bounds the texts of one embeddings request
+const batchSize = 64
+
+// Focus keeps the matches scoring at least focusRatio of the best match
+// and at least minFocusScore
+const (
+	focusRatio    = 0.5
+	minFocusScore = 0.2
+)
+
+// Index holds the embedding of every operation of a spec
+type Index struct {
+	// SpecHash identifies the spec's endpoints and Embedder the provider
+	// and model of the vectors
+	SpecHash string  `json:"spec_hash"`
+	Embedder string  `json:"embedder"`
+	Entries  []Entry `json:"entries"`
+}
+
+// Entry is the embedding of the endpoint with ID
+type Entry struct {
+	ID     string    `json:"id"`
+	Vector []float64 `json:"vector"`
+}
+
+// Match is an endpoint matching a query, with its cosine similarity
+type Match struct {
+	Endpoint *parser.Endpoint
+	Score    float64
+}
+
+// Document is the text embedded for an endpoint: its method and path,
+// operation ID, summary, description, tags and parameter names
+func Document(e *parser.Endpoint) string {
+	parts := []string{e.Method + " " + e.Path}
+	for _, part := range []string{e.OperationID, e.Summary, e.Description} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(e.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(e.Tags, ", "))
+	}
+	var params []string
+	for _, p := range e.Parameters {
+		params = append(params, p.Name)
+	}
+	if len(params) > 0 {
+		parts = append(parts, "Parameters: "+strings.Join(params, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// SpecHash is a digest of the documents of the spec's endpoints, so the
+// cache is reused until an operation's summary changes
+func SpecHash(spec *parser.OpenAPISpec) string {
+	h := sha256.New()
+	for i := range spec.Endpoints {
+		e := &spec.Endpoints[i]
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00", e.ID, Document(e))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Build returns the index of spec, read from cacheDir when an earlier
+// build cached it and embedded and cached otherwise. An empty cacheDir
+// disables the cache. cached reports whether the index was read.
+func Build(ctx context.Context, embedder ai.Embedder, spec *parser.OpenAPISpec, cacheDir string) (index *Index, cached bool, err error) {
+	hash := SpecHash(spec)
+	file := ""
+	if cacheDir != "" {
+		file = filepath.Join(cacheDir, cacheName(hash, embedder.Name()))
+		if index, err := readIndex(file); err == nil && index.SpecHash == hash && index.Embedder == embedder.Name() {
+			return index, true, nil
+		}
+	}
+
+	index = &Index{SpecHash: hash, Embedder: embedder.Name()}
+	for start := 0; start < len(spec.Endpoints); start += batchSize {
+		end := min(start+batchSize, len(spec.Endpoints))
+		texts := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			texts = append(texts, Document(&spec.Endpoints[i]))
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to embed endpoints: %w", err)
+		}
+		for i, vector := range vectors {
+			index.Entries = append(index.Entries, Entry{ID: spec.Endpoints[start+i].ID, Vector: vector})
+		}
+	}
+
+	if file != "" {
+		if err := writeIndex(file, index); err != nil {
+			return nil, false, fmt.Errorf("failed to cache embeddings: %w", err)
+		}
+	}
+	return index, false, nil
+}
+
+// Search returns the limit endpoints of spec most similar to query, best
+// first; limit 0 returns them all
+func (ix *Index) Search(ctx context.Context, embedder ai.Embedder, spec *parser.OpenAPISpec, query string, limit int) ([]Match, error) {
+	vectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	endpoints := make(map[string]*parser.Endpoint, len(spec.Endpoints))
+	for i := range spec.Endpoints {
+		endpoints[spec.Endpoints[i].ID] = &spec.Endpoints[i]
+	}
+	var matches []Match
+	for _, entry := range ix.Entries {
+		if endpoint, ok := endpoints[entry.ID]; ok {
+			matches = append(matches, Match{Endpoint: endpoint, Score: ai.Cosine(vectors[0], entry.Vector)})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Focus returns the endpoints of spec a natural language focus such as
+// "payment endpoints" describes: those scoring close to the best match,
+// best first. It is empty when nothing resembles focus.
+func (ix *Index) Focus(ctx context.Context, embedder ai.Embedder, spec *parser.OpenAPISpec, focus string) ([]Match, error) {
+	matches, err := ix.Search(ctx, embedder, spec, focus, 0)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	cutoff := max(matches[0].Score*focusRatio, minFocusScore)
+	var focused []Match
+	for _, m := range matches {
+		if m.Score < cutoff {
+			break
+		}
+		focused = append(focused, m)
+	}
+	return focused, nil
+}
+
+// DefaultCacheDir is where embeddings are cached unless configured: the
+// user cache directory, or "" (no cache) when there is none
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "glens", "embeddings")
+}
+
+// cacheName is the file of the index of a spec hash and embedder
+func cacheName(hash, embedder string) string {
+	return hash[:32] + "-" + strings.NewReplacer("/", "_", ":", "_").Replace(embedder) + ".json"
+}
+
+func readIndex(file string) (*Index, error) {
+	data, err := os.ReadFile(file) // #nosec G304 -- the name is a hash
+	if err != nil {
+		return nil, err
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+func writeIndex(file string, index *Index) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return err
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o600)
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/parser"
+)
+
+// countingEmbedder counts the texts it embeds
+type countingEmbedder struct {
+	ai.Embedder
+	texts int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e.texts += len(texts)
+	return e.Embedder.Embed(ctx, texts)
+}
+
+func testSpec() *parser.OpenAPISpec {
+	return &parser.OpenAPISpec{Endpoints: []parser.Endpoint{
+		{ID: "listPets", Method: "GET", Path: "/pets", OperationID: "listPets", Summary: "List all pets", Tags: []string{"pets"}},
+		{ID: "uploadAvatar", Method: "PUT", Path: "/users/{id}/avatar", OperationID: "uploadUserAvatar", Summary: "Upload a profile picture",
+			Parameters: []parser.Parameter{{Name: "id"}}},
+		{ID: "cancelOrder", Method: "POST", Path: "/orders/{id}/cancel", OperationID: "cancelOrder", Summary: "Cancel an order", Tags: []string{"store"}},
+		{ID: "deleteUser", Method: "DELETE", Path: "/users/{id}", OperationID: "deleteUser", Summary: "Delete a user"},
+	}}
+}
+
+func TestSearch(t *testing.T) {
+	embedder, err := ai.NewEmbedder(ai.EmbeddingConfig{}, ai.Config{})
+	require.NoError(t, err)
+	spec := testSpec()
+	ctx := context.Background()
+
+	index, cached, err := Build(ctx, embedder, spec, "")
+	require.NoError(t, err)
+	assert.False(t, cached)
+
+	for query, want := range map[string]string{
+		"upload the user's profile picture": "uploadAvatar",
+		"cancelling orders":                 "cancelOrder",
+		"which pets are there":              "listPets",
+		"remove a user":                     "deleteUser",
+	} {
+		matches, err := index.Search(ctx, embedder, spec, query, 2)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, want, matches[0].Endpoint.ID, query)
+		assert.GreaterOrEqual(t, matches[0].Score, matches[1].Score)
+	}
+}
+
+func TestBuild_Cache(t *testing.T) {
+	base, err := ai.NewEmbedder(ai.EmbeddingConfig{}, ai.Config{})
+	require.NoError(t, err)
+	embedder := &countingEmbedder{Embedder: base}
+	spec := testSpec()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	_, cached, err := Build(ctx, embedder, spec, dir)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, 4, embedder.texts)
+
+	index, cached, err := Build(ctx, embedder, spec, dir)
+	require.NoError(t, err)
+	assert.True(t, cached, "an unchanged spec reads the cache")
+	assert.Equal(t, 4, embedder.texts)
+	assert.Len(t, index.Entries, 4)
+
+	spec.Endpoints[0].Summary = "List the pets of the store"
+	_, cached, err = Build(ctx, embedder, spec, dir)
+	require.NoError(t, err)
+	assert.False(t, cached, "a changed summary is embedded again")
+	assert.Equal(t, 8, embedder.texts)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"embedder":"local/hashing-512"`)
+}
+
+func TestFocus(t *testing.T) {
+	embedder, err := ai.NewEmbedder(ai.EmbeddingConfig{}, ai.Config{})
+	require.NoError(t, err)
+	spec := testSpec()
+	spec.Endpoints = append(spec.Endpoints,
+		parser.Endpoint{ID: "createPayment", Method: "POST", Path: "/payments", OperationID: "createPayment", Summary: "Charge a card", Tags: []string{"payments"}},
+		parser.Endpoint{ID: "refundPayment", Method: "POST", Path: "/payments/{id}/refund", OperationID: "refundPayment", Summary: "Refund a payment", Tags: []string{"payments"}},
+	)
+	ctx := context.Background()
+	index, _, err := Build(ctx, embedder, spec, "")
+	require.NoError(t, err)
+
+	focused, err := index.Focus(ctx, embedder, spec, "payment endpoints")
+	require.NoError(t, err)
+	var ids []string
+	for _, m := range focused {
+		ids = append(ids, m.Endpoint.ID)
+	}
+	assert.ElementsMatch(t, []string{"createPayment", "refundPayment"}, ids)
+
+	focused, err = index.Focus(ctx, embedder, spec, "kubernetes")
+	require.NoError(t, err)
+	assert.Empty(t, focused)
+}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGCPEndpoint = "https://secretmanager.googleapis.com"
+	gcpMetadataToken   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCP fetches secrets from Google Cloud Secret Manager over its REST API.
+// Paths name a secret, projects/<project>/secrets/<secret>, optionally
+// followed by /versions/<version>; the latest version is used by default.
+type GCP struct {
+	// Endpoint is the API base URL (default https://secretmanager.googleapis.com)
+	Endpoint string
+	// Token returns the OAuth2 access token sent with each request; nil sends
+	// no Authorization header, as emulators expect
+	Token func(ctx context.Context) (string, error)
+	// HTTPClient defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+}
+
+// GCPFromEnv configures the GCP backend from the environment.
+// SECRET_MANAGER_EMULATOR_HOST (e.g. localhost:8088) selects an emulator
+// without authentication. Otherwise the access token is read from
+// GOOGLE_OAUTH_ACCESS_TOKEN or, on GCP, from the metadata server.
+func GCPFromEnv() *GCP {
+	if host := os.Getenv("SECRET_MANAGER_EMULATOR_HOST"); host != "" {
+		return &GCP{Endpoint: "http://" + host}
+	}
+	g := &GCP{Endpoint: defaultGCPEndpoint}
+	g.Token = func(ctx context.Context) (string, error) {
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			return token, nil
+		}
+		return g.metadataToken(ctx)
+	}
+	return g
+}
+
+// Fetch accesses a version of the secret named by path
+func (g *GCP) Fetch(ctx context.Context, path string) (string, error) {
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "secrets":
+		path += "/versions/latest"
+	case len(parts) == 6 && parts[0] == "projects" && parts[2] == "secrets" && parts[4] == "versions":
+	default:
+		return "", fmt.Errorf("gcp secret path must be projects/<project>/secrets/<secret>[/versions/<version>], got %q", path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(g.endpoint(), "/")+"/v1/"+path+":access", http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if g.Token != nil {
+		token, err := g.Token(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get GCP access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	var body struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doJSON(g.client(), req, &body); err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(body.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
+
+// metadataToken fetches an access token for the default service account
+// from the GCE/Cloud Run metadata server
+func (g *GCP) metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataToken, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(g.client(), req, &token); err != nil {
+		return "", fmt.Errorf("set GOOGLE_OAUTH_ACCESS_TOKEN outside GCP: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("metadata server returned no access token")
+	}
+	return token.AccessToken, nil
+}
+
+func (g *GCP) endpoint() string {
+	if g.Endpoint == "" {
+		return defaultGCPEndpoint
+	}
+	return g.Endpoint
+}
+
+func (g *GCP) client() *http.Client {
+	if g.HTTPClient == nil {
+		return defaultHTTPClient
+	}
+	return g.HTTPClient
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// doJSON sends req and decodes a 200 JSON response into out
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
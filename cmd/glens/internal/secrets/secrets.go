@@ -0,0 +1,152 @@
+// Package secrets resolves secret references in configuration values, so
+// credentials such as API keys can live in a secret store instead of
+// plaintext config or CI environment variables.
+//
+// A reference names a backend and a backend-specific path:
+//
+//	secretref://gcp/projects/my-project/secrets/openai-key
+//	secretref://gcp/projects/my-project/secrets/openai-key/versions/3
+//	secretref://vault/secret/glens/openai#api_key
+//	vault://secret/glens/openai#api_key
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RefScheme prefixes references of the form secretref://<backend>/<path>
+const RefScheme = "secretref://"
+
+// Backend fetches secrets from one secret store
+type Backend interface {
+	// Fetch returns the secret named by path, the part of the reference
+	// after the backend name
+	Fetch(ctx context.Context, path string) (string, error)
+}
+
+// Resolver replaces secret references with the secrets they name using
+// registered backends. Resolved secrets are cached for the Resolver's
+// lifetime. A Resolver is safe for concurrent use.
+type Resolver struct {
+	mu       sync.Mutex
+	backends map[string]Backend
+	cache    map[string]string
+}
+
+// NewResolver returns a Resolver with no backends
+func NewResolver() *Resolver {
+	return &Resolver{
+		backends: make(map[string]Backend),
+		cache:    make(map[string]string),
+	}
+}
+
+// FromEnv returns a Resolver with the gcp and vault backends configured
+// from their standard environment variables
+func FromEnv() *Resolver {
+	r := NewResolver()
+	r.Register("gcp", GCPFromEnv())
+	r.Register("vault", VaultFromEnv())
+	return r
+}
+
+// Register makes backend available under name, replacing any backend
+// already registered under it
+func (r *Resolver) Register(name string, backend Backend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[name] = backend
+}
+
+// IsRef reports whether value is a secret reference
+func IsRef(value string) bool {
+	_, _, ok := parseRef(value)
+	return ok
+}
+
+// parseRef splits a reference into backend name and path.
+// <backend>://<path> is shorthand for secretref://<backend>/<path>.
+func parseRef(value string) (backend, path string, ok bool) {
+	if rest, found := strings.CutPrefix(value, RefScheme); found {
+		backend, path, ok = strings.Cut(rest, "/")
+		return backend, path, ok && backend != "" && path != ""
+	}
+	if rest, found := strings.CutPrefix(value, "vault://"); found && rest != "" {
+		return "vault", rest, true
+	}
+	return "", "", false
+}
+
+// Resolve returns the secret named by value, or value unchanged when it is
+// not a secret reference
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	name, path, ok := parseRef(value)
+	if !ok {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	backend, registered := r.backends[name]
+	cached, hit := r.cache[value]
+	r.mu.Unlock()
+
+	if hit {
+		return cached, nil
+	}
+	if !registered {
+		return "", fmt.Errorf("unknown secret backend '%s' in %s", name, value)
+	}
+
+	secret, err := backend.Fetch(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", value, err)
+	}
+
+	r.mu.Lock()
+	r.cache[value] = secret
+	r.mu.Unlock()
+	return secret, nil
+}
+
+// ResolveAll returns a deep copy of settings with every secret reference
+// in string values replaced by its secret
+func (r *Resolver) ResolveAll(ctx context.Context, settings map[string]any) (map[string]any, error) {
+	resolved, err := r.resolveValue(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	out, _ := resolved.(map[string]any)
+	return out, nil
+}
+
+func (r *Resolver) resolveValue(ctx context.Context, value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := r.resolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := r.resolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	case string:
+		return r.Resolve(ctx, v)
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,148 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGCPServer serves Secret Manager's access endpoint like test/mock-secrets
+func newGCPServer(t *testing.T, secrets map[string]string, calls *int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		value, ok := secrets[r.URL.Path]
+		if !ok {
+			http.Error(w, "secret not found", http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(value))},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newVaultServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/glens/openai" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"api_key":"sk-vault","value":"default"}}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolver_GCP(t *testing.T) {
+	calls := 0
+	srv := newGCPServer(t, map[string]string{
+		"/v1/projects/p/secrets/openai-key/versions/latest:access": "sk-latest",
+		"/v1/projects/p/secrets/openai-key/versions/2:access":      "sk-v2",
+	}, &calls)
+
+	r := NewResolver()
+	r.Register("gcp", &GCP{Endpoint: srv.URL, Token: func(context.Context) (string, error) { return "t", nil }})
+	ctx := context.Background()
+
+	got, err := r.Resolve(ctx, "secretref://gcp/projects/p/secrets/openai-key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-latest", got)
+
+	got, err = r.Resolve(ctx, "secretref://gcp/projects/p/secrets/openai-key/versions/2")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-v2", got)
+
+	// Resolved secrets are cached
+	_, err = r.Resolve(ctx, "secretref://gcp/projects/p/secrets/openai-key")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	_, err = r.Resolve(ctx, "secretref://gcp/projects/p/secrets/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	_, err = r.Resolve(ctx, "secretref://gcp/openai-key")
+	assert.ErrorContains(t, err, "projects/<project>/secrets/<secret>")
+}
+
+func TestResolver_Vault(t *testing.T) {
+	srv := newVaultServer(t, "root")
+	r := NewResolver()
+	r.Register("vault", &Vault{Address: srv.URL, Token: "root"})
+	ctx := context.Background()
+
+	got, err := r.Resolve(ctx, "vault://secret/glens/openai#api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-vault", got)
+
+	got, err = r.Resolve(ctx, "secretref://vault/secret/glens/openai")
+	require.NoError(t, err)
+	assert.Equal(t, "default", got)
+
+	_, err = r.Resolve(ctx, "vault://secret/glens/openai#missing")
+	assert.ErrorContains(t, err, `no string field "missing"`)
+
+	r.Register("vault", &Vault{Address: srv.URL, Token: "wrong"})
+	_, err = r.Resolve(ctx, "vault://secret/glens/other")
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestResolver_PlainValuesAndUnknownBackend(t *testing.T) {
+	r := NewResolver()
+	ctx := context.Background()
+
+	got, err := r.Resolve(ctx, "sk-plaintext")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-plaintext", got)
+
+	_, err = r.Resolve(ctx, "secretref://aws/prod/openai")
+	assert.ErrorContains(t, err, "unknown secret backend 'aws'")
+
+	assert.True(t, IsRef("vault://secret/x"))
+	assert.False(t, IsRef("secretref://gcp"))
+	assert.False(t, IsRef("https://api.openai.com"))
+}
+
+func TestResolver_ResolveAll(t *testing.T) {
+	srv := newVaultServer(t, "root")
+	r := NewResolver()
+	r.Register("vault", &Vault{Address: srv.URL, Token: "root"})
+
+	settings := map[string]any{
+		"ai_models": map[string]any{
+			"openai": map[string]any{"api_key": "vault://secret/glens/openai#api_key", "max_tokens": 4000},
+		},
+		"labels": []any{"vault://secret/glens/openai"},
+	}
+
+	got, err := r.ResolveAll(context.Background(), settings)
+	require.NoError(t, err)
+
+	openai := got["ai_models"].(map[string]any)["openai"].(map[string]any)
+	assert.Equal(t, "sk-vault", openai["api_key"])
+	assert.Equal(t, 4000, openai["max_tokens"])
+	assert.Equal(t, []any{"default"}, got["labels"])
+	assert.Equal(t, "vault://secret/glens/openai#api_key",
+		settings["ai_models"].(map[string]any)["openai"].(map[string]any)["api_key"], "input is not modified")
+}
+
+func TestGCPFromEnv_Emulator(t *testing.T) {
+	t.Setenv("SECRET_MANAGER_EMULATOR_HOST", "localhost:8088")
+	g := GCPFromEnv()
+	assert.Equal(t, "http://localhost:8088", g.Endpoint)
+	assert.Nil(t, g.Token)
+}
@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultVaultField is read when a Vault reference names no field
+const defaultVaultField = "value"
+
+// Vault fetches secrets from a HashiCorp Vault KV version 2 engine. Paths
+// are <mount>/<secret>#<field>, e.g. secret/glens/openai#api_key; the field
+// defaults to "value".
+type Vault struct {
+	// Address is the Vault server URL, e.g. https://vault.example.com:8200
+	Address string
+	// Token authenticates requests
+	Token string
+	// Namespace selects a Vault Enterprise namespace
+	Namespace string
+	// HTTPClient defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+}
+
+// VaultFromEnv configures the Vault backend from VAULT_ADDR, VAULT_TOKEN
+// and VAULT_NAMESPACE
+func VaultFromEnv() *Vault {
+	return &Vault{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+}
+
+// Fetch reads one field of the KV v2 secret named by path
+func (v *Vault) Fetch(ctx context.Context, path string) (string, error) {
+	if v.Address == "" {
+		return "", errors.New("vault address not configured (set VAULT_ADDR)")
+	}
+
+	path, field, _ := strings.Cut(path, "#")
+	if field == "" {
+		field = defaultVaultField
+	}
+	mount, secret, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || secret == "" {
+		return "", fmt.Errorf("vault secret path must be <mount>/<secret>[#field], got %q", path)
+	}
+
+	url := strings.TrimSuffix(v.Address, "/") + "/v1/" + mount + "/data/" + secret
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if v.Token != "" {
+		req.Header.Set("X-Vault-Token", v.Token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	client := v.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := doJSON(client, req, &body); err != nil {
+		return "", err
+	}
+
+	value, ok := body.Data.Data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string field %q", path, field)
+	}
+	return value, nil
+}
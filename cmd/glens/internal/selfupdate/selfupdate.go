@@ -0,0 +1,373 @@
+// Package selfupdate finds glens releases on GitHub and replaces the running
+// binary with the release asset of its platform, after verifying it against
+// the release's checksums.
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Release channels
+const (
+	ChannelStable     = "stable"
+	ChannelPrerelease = "prerelease"
+)
+
+// Defaults of Updater
+const (
+	DefaultAPIURL     = "https://api.github.com"
+	DefaultRepository = "aydabd/glens"
+	// TagPrefix prefixes the release tags of the glens module, which shares
+	// its repository with other released modules
+	TagPrefix = "cmd/glens/v"
+	// ChecksumsAsset lists the SHA-256 of every asset of a release
+	ChecksumsAsset = "checksums.txt"
+	// SignatureAsset is the detached GPG signature of ChecksumsAsset
+	SignatureAsset = ChecksumsAsset + ".asc"
+	// MaxDownloadSize bounds every download, well above the size of a
+	// glens binary
+	MaxDownloadSize = 256 << 20
+)
+
+// ErrNoRelease is returned when no release matches the channel or version
+var ErrNoRelease = errors.New("no matching glens release found")
+
+// Release is a glens release
+type Release struct {
+	Tag string
+	// Version is the tag without TagPrefix, e.g. 1.4.0 or 1.5.0-rc.1
+	Version    string
+	Prerelease bool
+	URL        string
+	// Assets maps asset names to their download URLs
+	Assets map[string]string
+}
+
+// Updater finds and installs glens releases
+type Updater struct {
+	// APIURL defaults to DefaultAPIURL
+	APIURL string
+	// Repository defaults to DefaultRepository
+	Repository string
+	// Token is sent to the GitHub API when set, raising its rate limit
+	Token string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+	// MaxSize bounds every download in bytes, defaulting to MaxDownloadSize
+	MaxSize int64
+}
+
+// AssetName returns the name of the release binary for goos and goarch
+func AssetName(goos, goarch string) string {
+	name := "glens-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// ValidChannel reports whether channel is a release channel
+func ValidChannel(channel string) bool {
+	return channel == ChannelStable || channel == ChannelPrerelease
+}
+
+// Latest returns the newest release of channel; the prerelease channel
+// includes stable releases. A non-empty version selects that release
+// instead.
+func (u *Updater) Latest(ctx context.Context, channel, version string) (*Release, error) {
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		HTMLURL    string `json:"html_url"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+		Assets     []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/releases?per_page=100", u.apiURL(), u.repository())
+	body, err := u.get(ctx, url, "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+
+	version = strings.TrimPrefix(version, "v")
+	var latest *Release
+	for _, r := range releases {
+		if r.Draft || !strings.HasPrefix(r.TagName, TagPrefix) {
+			continue
+		}
+		release := &Release{
+			Tag:        r.TagName,
+			Version:    strings.TrimPrefix(r.TagName, TagPrefix),
+			Prerelease: r.Prerelease,
+			URL:        r.HTMLURL,
+			Assets:     make(map[string]string, len(r.Assets)),
+		}
+		for _, a := range r.Assets {
+			release.Assets[a.Name] = a.URL
+		}
+		if version != "" {
+			if release.Version == version {
+				return release, nil
+			}
+			continue
+		}
+		if release.Prerelease && channel != ChannelPrerelease {
+			continue
+		}
+		if latest == nil || Compare(release.Version, latest.Version) > 0 {
+			latest = release
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoRelease
+	}
+	return latest, nil
+}
+
+// Download returns the binary asset of release, verified against the
+// release's checksums. The checksums come from the same release as the
+// binary, so they only prove its integrity: with verifySignature they must
+// also carry a valid GPG signature from a key in the user's keyring, which
+// proves where it comes from.
+func (u *Updater) Download(ctx context.Context, release *Release, asset string, verifySignature bool) ([]byte, error) {
+	binaryURL, ok := release.Assets[asset]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no binary for this platform (%s)", release.Tag, asset)
+	}
+	checksumsURL, ok := release.Assets[ChecksumsAsset]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s to verify the binary with", release.Tag, ChecksumsAsset)
+	}
+	checksums, err := u.get(ctx, checksumsURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", ChecksumsAsset, err)
+	}
+	if verifySignature {
+		signatureURL, ok := release.Assets[SignatureAsset]
+		if !ok {
+			return nil, fmt.Errorf("release %s is not signed (no %s)", release.Tag, SignatureAsset)
+		}
+		signature, err := u.get(ctx, signatureURL, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", SignatureAsset, err)
+		}
+		if err := verifyGPG(ctx, checksums, signature); err != nil {
+			return nil, err
+		}
+	}
+	want, err := checksumOf(checksums, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := u.get(ctx, binaryURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", asset, got, want)
+	}
+	return binary, nil
+}
+
+// Replace atomically replaces the executable at path with binary, keeping
+// its permissions. The new binary is written next to it and renamed over
+// it; Windows, which cannot overwrite a running executable, first moves the
+// old one aside to path.old.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("failed to move the running binary aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// Compare compares two versions like 1.4.0 and 1.5.0-rc.1 by semantic
+// versioning precedence, returning -1, 0 or +1. A prerelease precedes its
+// release.
+func Compare(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	coreA, preA, _ := strings.Cut(strings.SplitN(a, "+", 2)[0], "-")
+	coreB, preB, _ := strings.Cut(strings.SplitN(b, "+", 2)[0], "-")
+	if c := compareDotted(coreA, coreB, false); c != 0 {
+		return c
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	return compareDotted(preA, preB, true)
+}
+
+// compareDotted compares dot-separated identifiers, numerically when both
+// are numbers; with prerelease, numeric identifiers precede others
+func compareDotted(a, b string, prerelease bool) int {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		if i >= len(partsA) {
+			return -1
+		}
+		if i >= len(partsB) {
+			return 1
+		}
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case prerelease && errA == nil:
+			return -1
+		case prerelease && errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(partsA[i], partsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// checksumOf returns the SHA-256 of asset from sha256sum output
+func checksumOf(checksums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", ChecksumsAsset, asset)
+}
+
+// verifyGPG checks the detached signature of checksums with gpg
+func verifyGPG(ctx context.Context, checksums, signature []byte) error {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		return errors.New("verifying the signature requires gpg on the PATH")
+	}
+	dir, err := os.MkdirTemp("", "glens-update-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+	data, sig := filepath.Join(dir, ChecksumsAsset), filepath.Join(dir, SignatureAsset)
+	if err := os.WriteFile(data, checksums, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(sig, signature, 0o600); err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, gpg, "--batch", "--verify", sig, data).CombinedOutput() // #nosec G204 -- fixed arguments
+	if err != nil {
+		return fmt.Errorf("signature verification failed (is the release key imported?): %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// get downloads url
+func (u *Updater) get(ctx context.Context, url, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if u.Token != "" && strings.HasPrefix(url, u.apiURL()) {
+		req.Header.Set("Authorization", "Bearer "+u.Token)
+	}
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	limit := u.MaxSize
+	if limit <= 0 {
+		limit = MaxDownloadSize
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", url, limit)
+	}
+	return body, nil
+}
+
+func (u *Updater) apiURL() string {
+	if u.APIURL != "" {
+		return strings.TrimSuffix(u.APIURL, "/")
+	}
+	return DefaultAPIURL
+}
+
+func (u *Updater) repository() string {
+	if u.Repository != "" {
+		return u.Repository
+	}
+	return DefaultRepository
+}
@@ -0,0 +1,148 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseServer serves the releases API and the assets of releases
+func releaseServer(t *testing.T, binary []byte) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256(binary)
+	asset := AssetName("linux", "amd64")
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/aydabd/glens/releases":
+			assetsOf := func(tag string) []map[string]string {
+				return []map[string]string{
+					{"name": asset, "browser_download_url": server.URL + "/download/" + tag + "/" + asset},
+					{"name": ChecksumsAsset, "browser_download_url": server.URL + "/download/" + tag + "/" + ChecksumsAsset},
+				}
+			}
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"tag_name": "cmd/api/v9.0.0", "assets": assetsOf("api")},
+				{"tag_name": "cmd/glens/v1.10.0-rc.1", "prerelease": true, "assets": assetsOf("rc")},
+				{"tag_name": "cmd/glens/v1.9.0", "assets": assetsOf("v1.9.0")},
+				{"tag_name": "cmd/glens/v1.11.0", "draft": true, "assets": assetsOf("draft")},
+				{"tag_name": "cmd/glens/v1.2.0", "assets": assetsOf("v1.2.0")},
+			})
+		case "/download/v1.9.0/" + asset:
+			_, _ = w.Write(binary)
+		case "/download/v1.9.0/" + ChecksumsAsset:
+			_, _ = fmt.Fprintf(w, "%s  %s\n%s  glens-darwin-arm64\n", hex.EncodeToString(sum[:]), asset, hex.EncodeToString(sum[:]))
+		case "/download/v1.2.0/" + asset:
+			_, _ = w.Write([]byte("tampered"))
+		case "/download/v1.2.0/" + ChecksumsAsset:
+			_, _ = fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(sum[:]), asset)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpdater_Latest(t *testing.T) {
+	server := releaseServer(t, []byte("binary"))
+	u := &Updater{APIURL: server.URL}
+	ctx := context.Background()
+
+	release, err := u.Latest(ctx, ChannelStable, "")
+	require.NoError(t, err)
+	assert.Equal(t, "cmd/glens/v1.9.0", release.Tag)
+	assert.Equal(t, "1.9.0", release.Version)
+
+	release, err = u.Latest(ctx, ChannelPrerelease, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.0-rc.1", release.Version)
+	assert.True(t, release.Prerelease)
+
+	release, err = u.Latest(ctx, ChannelStable, "v1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "cmd/glens/v1.2.0", release.Tag)
+
+	_, err = u.Latest(ctx, ChannelStable, "9.0.0")
+	assert.ErrorIs(t, err, ErrNoRelease, "tags of other modules are ignored")
+}
+
+func TestUpdater_Download(t *testing.T) {
+	server := releaseServer(t, []byte("binary"))
+	u := &Updater{APIURL: server.URL}
+	ctx := context.Background()
+	asset := AssetName("linux", "amd64")
+
+	release, err := u.Latest(ctx, ChannelStable, "1.9.0")
+	require.NoError(t, err)
+	binary, err := u.Download(ctx, release, asset, false)
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(binary))
+
+	_, err = u.Download(ctx, release, AssetName("windows", "arm64"), false)
+	assert.ErrorContains(t, err, "has no binary for this platform (glens-windows-arm64.exe)")
+	_, err = u.Download(ctx, release, asset, true)
+	assert.ErrorContains(t, err, "is not signed")
+
+	release, err = u.Latest(ctx, ChannelStable, "1.2.0")
+	require.NoError(t, err)
+	_, err = u.Download(ctx, release, asset, false)
+	assert.ErrorContains(t, err, "checksum mismatch for glens-linux-amd64")
+}
+
+func TestUpdater_DownloadSizeLimit(t *testing.T) {
+	server := releaseServer(t, []byte("binary"))
+	u := &Updater{APIURL: server.URL}
+	ctx := context.Background()
+
+	release, err := u.Latest(ctx, ChannelStable, "1.9.0")
+	require.NoError(t, err)
+	u.MaxSize = int64(len("binary")) - 1
+	_, err = u.Download(ctx, release, AssetName("linux", "amd64"), false)
+	assert.ErrorContains(t, err, "response exceeds 5 bytes")
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glens")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o700))
+
+	require.NoError(t, Replace(path, []byte("new")))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"v1.2.3", "1.2.4", -1},
+		{"1.3.0-rc.1", "1.3.0", -1},
+		{"1.3.0-rc.2", "1.3.0-rc.10", -1},
+		{"1.3.0-beta", "1.3.0-alpha", 1},
+		{"1.3.0-rc.1", "1.3.0-rc", 1},
+		{"2.0.0", "1.99.99", 1},
+	} {
+		assert.Equal(t, tc.want, Compare(tc.a, tc.b), "%s vs %s", tc.a, tc.b)
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/apiauth"
+
+	"glens/tools/glens/internal/jobs"
+)
+
+// analyzeResponse is returned when an analysis job is queued. RunID equals
+// JobID and is kept for clients of the original contract.
+type analyzeResponse struct {
+	RunID     string `json:"run_id"`
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+// analyze handles POST /api/v1/analyze requests by queueing a job. The
+// spec is named by spec_url or uploaded, see readSpecRequest; uploads are
+// kept until the job has run.
+func (s *Server) analyze(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeRequest
+	upload, err := s.uploads.readSpecRequest(w, r, &req)
+	if err != nil {
+		writeUploadProblem(w, r, err)
+		return
+	}
+
+	if upload == nil {
+		if req.SpecURL == "" {
+			apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+				"Validation Error", "spec_url is required")
+			return
+		}
+		if err := checkSpecURL(req.SpecURL); err != nil {
+			apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+				"Validation Error", err.Error())
+			return
+		}
+	}
+
+	if unknown := s.unknownModel(req.Models); unknown != "" {
+		apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+			"Validation Error", fmt.Sprintf("model %q is not served; see GET /api/v1/models", unknown))
+		return
+	}
+
+	specName := ""
+	if upload != nil {
+		if req.SpecURL, err = s.uploads.save(upload.Name, upload.Data); err != nil {
+			apiauth.WriteProblem(w, r, http.StatusInternalServerError, ProblemTypeInternal,
+				"Internal Server Error", err.Error())
+			return
+		}
+		specName = upload.Name
+	}
+
+	resp, err := s.submit(r.Context(), req, specName)
+	if err != nil {
+		s.uploads.release(req.SpecURL)
+	}
+	switch {
+	case errors.Is(err, jobs.ErrQueueFull):
+		w.Header().Set("Retry-After", "30")
+		apiauth.WriteProblem(w, r, http.StatusServiceUnavailable, ProblemTypeOverloaded,
+			"Service Unavailable", "analysis queue is full, retry later")
+		return
+	case errors.Is(err, jobs.ErrStopped):
+		apiauth.WriteProblem(w, r, http.StatusServiceUnavailable, ProblemTypeOverloaded,
+			"Service Unavailable", "server is shutting down, retry later")
+		return
+	case err != nil:
+		apiauth.WriteProblem(w, r, http.StatusInternalServerError, ProblemTypeInternal,
+			"Internal Server Error", err.Error())
+		return
+	}
+
+	w.Header().Set("Location", resp.StatusURL)
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// submit queues an analysis job for the request; specName names a spec
+// uploaded to req.SpecURL.
+func (s *Server) submit(ctx context.Context, req AnalyzeRequest, specName string) (analyzeResponse, error) {
+	id, err := generateRunID()
+	if err != nil {
+		return analyzeResponse{}, fmt.Errorf("generate run id: %w", err)
+	}
+
+	job := &jobs.Job{
+		ID:                id,
+		SpecURL:           req.SpecURL,
+		SpecName:          specName,
+		Models:            req.Models,
+		ApprovedEndpoints: req.ApprovedEndpoints,
+		SkippedEndpoints:  req.SkippedEndpoints,
+	}
+	if err := s.queue.Submit(ctx, job); err != nil {
+		return analyzeResponse{}, err
+	}
+
+	log.Info().Str("job_id", id).Str("spec_url", req.SpecURL).Str("spec_name", specName).Msg("analysis job queued")
+	return analyzeResponse{
+		RunID:     id,
+		JobID:     id,
+		Status:    string(jobs.StatusQueued),
+		StatusURL: "/api/v1/jobs/" + id,
+	}, nil
+}
+
+// unknownModel returns the first requested model that is not served.
+func (s *Server) unknownModel(requested []string) string {
+	for _, name := range requested {
+		if !slices.ContainsFunc(s.cfg.Models, func(m Model) bool { return m.ID == name }) {
+			return name
+		}
+	}
+	return ""
+}
+
+func generateRunID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/apiauth"
+
+	"glens/tools/glens/internal/jobs"
+)
+
+const (
+	// jobEventInterval is how often event streams poll a job for changes
+	jobEventInterval = 500 * time.Millisecond
+	// jobKeepAlive is the longest an event stream stays silent, so proxies
+	// keep it open
+	jobKeepAlive = 15 * time.Second
+)
+
+// getJob handles GET /api/v1/jobs/{id} requests with status and progress.
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.lookupJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// getJobReport handles GET /api/v1/jobs/{id}/report requests by streaming
+// the JSON report of a succeeded job.
+func (s *Server) getJobReport(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.lookupJob(w, r)
+	if !ok {
+		return
+	}
+
+	if job.Status != jobs.StatusSucceeded {
+		detail := fmt.Sprintf("job is %s; the report is available once it succeeds", job.Status)
+		if job.Error != "" {
+			detail = fmt.Sprintf("job failed: %s", job.Error)
+		}
+		apiauth.WriteProblem(w, r, http.StatusConflict, ProblemTypeConflict, "Report Not Ready", detail)
+		return
+	}
+
+	report, err := s.store.Report(r.Context(), job.ID)
+	if err != nil {
+		apiauth.WriteProblem(w, r, http.StatusInternalServerError, ProblemTypeInternal,
+			"Internal Server Error", fmt.Sprintf("load report: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(report)
+}
+
+// jobEvents handles GET /api/v1/jobs/{id}/events by streaming the job as
+// Server-Sent Events: a "job" event with the job each time its status or
+// progress changes, ending with the event of the finished job or when the
+// server shuts down. The store is polled, so streams follow the jobs of
+// every server sharing it.
+func (s *Server) jobEvents(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.lookupJob(w, r)
+	if !ok {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(s.eventInterval)
+	defer ticker.Stop()
+	var sent []byte
+	lastWrite := time.Now()
+	for {
+		var event string
+		data, err := json.Marshal(job)
+		switch {
+		case err != nil:
+			log.Error().Err(err).Str("job_id", job.ID).Msg("failed to encode job event")
+		case !bytes.Equal(data, sent):
+			event = fmt.Sprintf("event: job\ndata: %s\n\n", data)
+			sent = data
+		case time.Since(lastWrite) >= jobKeepAlive:
+			event = ": keep-alive\n\n"
+		}
+		if event != "" {
+			_, _ = io.WriteString(w, event)
+			if err := rc.Flush(); err != nil {
+				log.Warn().Err(err).Str("job_id", job.ID).Msg("job event stream cannot be flushed")
+				return
+			}
+			lastWrite = time.Now()
+		}
+		if job.Finished() {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		next, err := s.store.Get(r.Context(), job.ID)
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			return
+		case err != nil:
+			log.Warn().Err(err).Str("job_id", job.ID).Msg("failed to poll job for events")
+		default:
+			job = next
+		}
+	}
+}
+
+// jobIDPattern matches the IDs generateRunID gives jobs; other IDs never
+// reach the store, whose keys they are part of
+var jobIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// lookupJob loads the job named in the path, writing a problem response
+// when it cannot be found.
+func (s *Server) lookupJob(w http.ResponseWriter, r *http.Request) (*jobs.Job, bool) {
+	id := r.PathValue("id")
+	var job *jobs.Job
+	err := jobs.ErrNotFound
+	if jobIDPattern.MatchString(id) {
+		job, err = s.store.Get(r.Context(), id)
+	}
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		apiauth.WriteProblem(w, r, http.StatusNotFound, ProblemTypeNotFound,
+			"Not Found", fmt.Sprintf("job %q does not exist", id))
+		return nil, false
+	case err != nil:
+		apiauth.WriteProblem(w, r, http.StatusInternalServerError, ProblemTypeInternal,
+			"Internal Server Error", fmt.Sprintf("load job: %v", err))
+		return nil, false
+	}
+	return job, true
+}
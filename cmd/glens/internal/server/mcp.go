@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// jsonRPCRequest represents a JSON-RPC 2.0 request.
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// jsonRPCResponse represents a JSON-RPC 2.0 response.
+type jsonRPCResponse struct {
+	JSONRPC string    `json:"jsonrpc"`
+	ID      any       `json:"id"`
+	Result  any       `json:"result,omitempty"`
+	Error   *rpcError `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// toolCallParams are the params of a tools/call request.
+type toolCallParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// mcp handles POST /api/v1/mcp JSON-RPC 2.0 requests.
+// Note: JSON-RPC 2.0 defines its own error format (not RFC 9457)
+// because JSON-RPC clients expect {jsonrpc, id, error} responses.
+func (s *Server) mcp(w http.ResponseWriter, r *http.Request) {
+	var req jsonRPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, jsonRPCResponse{
+			JSONRPC: "2.0",
+			ID:      nil,
+			Error:   &rpcError{Code: -32700, Message: fmt.Sprintf("parse error: %v", err)},
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.routeRPC(r.Context(), req))
+}
+
+func (s *Server) routeRPC(ctx context.Context, req jsonRPCRequest) jsonRPCResponse {
+	switch req.Method {
+	case "tools/list":
+		tools := []map[string]string{
+			{"name": "analyze", "description": "Run OpenAPI spec analysis"},
+			{"name": "models", "description": "List supported AI models"},
+		}
+		return jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: tools}
+	case "tools/call":
+		return s.handleToolsCall(ctx, req)
+	default:
+		return rpcFailure(req.ID, -32601, "method not found")
+	}
+}
+
+// handleToolsCall runs the analyze or models tool.
+func (s *Server) handleToolsCall(ctx context.Context, req jsonRPCRequest) jsonRPCResponse {
+	var params toolCallParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return rpcFailure(req.ID, -32602, fmt.Sprintf("invalid params: %v", err))
+	}
+
+	switch params.Name {
+	case "models":
+		return jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{"models": s.cfg.Models}}
+	case "analyze":
+		var args AnalyzeRequest
+		if err := json.Unmarshal(params.Arguments, &args); err != nil || args.SpecURL == "" {
+			return rpcFailure(req.ID, -32602, "invalid params: arguments.spec_url is required")
+		}
+		if err := checkSpecURL(args.SpecURL); err != nil {
+			return rpcFailure(req.ID, -32602, "invalid params: "+err.Error())
+		}
+		if unknown := s.unknownModel(args.Models); unknown != "" {
+			return rpcFailure(req.ID, -32602, fmt.Sprintf("invalid params: model %q is not served", unknown))
+		}
+		resp, err := s.submit(ctx, args, "")
+		if err != nil {
+			return rpcFailure(req.ID, -32603, err.Error())
+		}
+		return jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resp}
+	default:
+		return rpcFailure(req.ID, -32602, fmt.Sprintf("unknown tool %q", params.Name))
+	}
+}
+
+func rpcFailure(id any, code int, message string) jsonRPCResponse {
+	return jsonRPCResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
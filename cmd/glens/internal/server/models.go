@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"glens/pkg/modelcatalog"
+)
+
+const (
+	// healthTTL is how long the health of the models is reused before
+	// their providers are checked again
+	healthTTL = time.Minute
+	// healthTimeout bounds the checks of one models request
+	healthTimeout = 10 * time.Second
+)
+
+// Model health statuses; models the server cannot check are unchecked
+const (
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+	HealthUnchecked = "unchecked"
+)
+
+// modelHealth is the result of a model's last health check.
+type modelHealth struct {
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// modelEntry is a model listed by GET /api/v1/models.
+type modelEntry struct {
+	Model
+	// Price is omitted for models without a price
+	Price  *modelcatalog.Price `json:"price,omitempty"`
+	Health modelHealth         `json:"health"`
+}
+
+// healthCache keeps the health of the models for healthTTL, so listing
+// models does not call every provider on every request.
+type healthCache struct {
+	check func(ctx context.Context) map[string]error
+
+	mu        sync.Mutex
+	results   map[string]error
+	checkedAt time.Time
+}
+
+// get returns the health of the models and when it was checked, checking
+// them again once the last results are older than healthTTL. Concurrent
+// requests wait for one check.
+func (c *healthCache) get(ctx context.Context) (map[string]error, time.Time) {
+	if c.check == nil {
+		return nil, time.Time{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.results == nil || time.Since(c.checkedAt) > healthTTL {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), healthTimeout)
+		defer cancel()
+		c.results = c.check(ctx)
+		c.checkedAt = time.Now().UTC()
+	}
+	return c.results, c.checkedAt
+}
+
+// models handles GET /api/v1/models requests, describing each served model
+// with its price and the health of its provider.
+func (s *Server) models(w http.ResponseWriter, r *http.Request) {
+	results, checkedAt := s.modelHealth.get(r.Context())
+	models := make([]modelEntry, len(s.cfg.Models))
+	for i, m := range s.cfg.Models {
+		entry := modelEntry{Model: m, Health: modelHealth{Status: HealthUnchecked}}
+		if price, ok := s.cfg.Prices[m.ID]; ok {
+			entry.Price = &price
+		}
+		if err, checked := results[m.ID]; checked {
+			entry.Health = modelHealth{Status: HealthHealthy, CheckedAt: &checkedAt}
+			if err != nil {
+				entry.Health.Status = HealthUnhealthy
+				entry.Health.Error = err.Error()
+			}
+		}
+		models[i] = entry
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"models": models,
+	})
+}
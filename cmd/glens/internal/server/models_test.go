@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/pkg/modelcatalog"
+)
+
+func TestModels_PricesAndHealth(t *testing.T) {
+	checks := 0
+	srv := New(Config{
+		Models: []Model{
+			{ID: "gpt-4o", Name: "OpenAI GPT-4", Provider: "openai", ContextWindow: 128000, MaxOutputTokens: 4000},
+			{ID: "mistral-local", Name: "ollama:mistral", Provider: "ollama", Local: true},
+			{ID: "mock", Name: "mock", Provider: "mock", Local: true},
+		},
+		Prices: map[string]modelcatalog.Price{"gpt-4o": {Input: 2.5, Output: 10}},
+		Health: func(context.Context) map[string]error {
+			checks++
+			return map[string]error{"gpt-4o": nil, "mistral-local": errors.New("provider not reachable")}
+		},
+	})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	rec := do(srv, http.MethodGet, "/api/v1/models", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Models []modelEntry `json:"models"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Len(t, resp.Models, 3)
+
+	gpt := resp.Models[0]
+	assert.Equal(t, 128000, gpt.ContextWindow)
+	assert.Equal(t, &modelcatalog.Price{Input: 2.5, Output: 10}, gpt.Price)
+	assert.Equal(t, HealthHealthy, gpt.Health.Status)
+	assert.NotNil(t, gpt.Health.CheckedAt)
+
+	local := resp.Models[1]
+	assert.Nil(t, local.Price)
+	assert.Equal(t, HealthUnhealthy, local.Health.Status)
+	assert.Equal(t, "provider not reachable", local.Health.Error)
+
+	assert.Equal(t, modelHealth{Status: HealthUnchecked}, resp.Models[2].Health)
+
+	do(srv, http.MethodGet, "/api/v1/models", "")
+	assert.Equal(t, 1, checks, "health is reused within its TTL")
+}
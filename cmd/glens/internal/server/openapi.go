@@ -0,0 +1,36 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec describes the routes of the server; it is served at
+// /api/v1/openapi.json.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// openAPI returns a handler serving the OpenAPI document spec, written in
+// YAML, as JSON. The document is converted once, so an invalid one fails
+// here rather than on the first request.
+func openAPI(spec []byte) (http.HandlerFunc, error) {
+	var doc any
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("convert OpenAPI document to JSON: %w", err)
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	}, nil
+}
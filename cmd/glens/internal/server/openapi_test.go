@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"glens/pkg/apiclient"
+)
+
+func TestOpenAPI_ServesYAMLAsJSON(t *testing.T) {
+	h, err := openAPI([]byte(`openapi: 3.1.0
+info:
+  title: Glens API
+paths:
+  /healthz:
+    get:
+      responses:
+        "200":
+          description: ok
+`))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var doc map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/healthz")
+}
+
+func TestOpenAPI_InvalidDocument(t *testing.T) {
+	_, err := openAPI([]byte("paths: [unclosed"))
+	assert.Error(t, err)
+}
+
+// routeRecorder records the patterns of registered routes
+type routeRecorder []string
+
+func (r *routeRecorder) Handle(pattern string, _ http.Handler) {
+	*r = append(*r, pattern)
+}
+
+func (r *routeRecorder) HandleFunc(pattern string, _ func(http.ResponseWriter, *http.Request)) {
+	*r = append(*r, pattern)
+}
+
+func TestOpenAPI_DescribesTheRoutes(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]any `yaml:"paths"`
+	}
+	require.NoError(t, yaml.Unmarshal(openAPISpec, &doc))
+	var documented []string
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	srv, _ := newTestServer(t)
+	var routes routeRecorder
+	srv.registerRoutes(&routes)
+
+	assert.ElementsMatch(t, routes, documented)
+}
+
+// TestOpenAPI_ServedToTheClient fetches the document through the typed
+// client, without an API key as clients discover the API before using one.
+func TestOpenAPI_ServedToTheClient(t *testing.T) {
+	srv := New(Config{Version: "test", APIKeys: []string{"secret"}})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+	httpServer := httptest.NewServer(srv)
+	t.Cleanup(httpServer.Close)
+	client, err := apiclient.New(httpServer.URL)
+	require.NoError(t, err)
+
+	raw, err := client.OpenAPI(context.Background())
+
+	require.NoError(t, err)
+	var served struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &served))
+	assert.Equal(t, "3.1.0", served.OpenAPI)
+	assert.Contains(t, served.Paths, "/api/v1/jobs/{id}/events")
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"glens/pkg/apiauth"
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// TokenEstimate is the expected token use of a model generating the tests
+// of some endpoints.
+type TokenEstimate struct {
+	Model        string `json:"model"`
+	Endpoints    int    `json:"endpoints"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+}
+
+// Estimator estimates the tokens each model of a run of models uses to
+// generate the tests of endpoints.
+type Estimator func(endpoints []parser.Endpoint, models []string) ([]TokenEstimate, error)
+
+// previewRequest is the JSON body for the analyze preview endpoint; the
+// lists select the endpoints and models as for an analysis.
+type previewRequest struct {
+	SpecURL           string   `json:"spec_url"`
+	Models            []string `json:"models"`
+	ApprovedEndpoints []string `json:"approved_endpoints"`
+	SkippedEndpoints  []string `json:"skipped_endpoints"`
+}
+
+// endpointCategory represents an endpoint with its risk level and whether
+// the analysis would test it.
+type endpointCategory struct {
+	ID          string `json:"id"`
+	OperationID string `json:"operation_id,omitempty"`
+	Path        string `json:"path"`
+	Method      string `json:"method"`
+	Category    string `json:"category"`
+	RiskLevel   string `json:"risk_level"`
+	Selected    bool   `json:"selected"`
+}
+
+// previewSummary counts the selected endpoints by category and risk level.
+type previewSummary struct {
+	Total      int            `json:"total"`
+	Selected   int            `json:"selected"`
+	ByCategory map[string]int `json:"by_category"`
+	ByRisk     map[string]int `json:"by_risk"`
+}
+
+// modelEstimate is the expected token use and cost of one model.
+type modelEstimate struct {
+	TokenEstimate
+	CostUSD float64 `json:"cost_usd"`
+	Priced  bool    `json:"priced"`
+}
+
+// previewEstimate is the expected token use and cost of the analysis.
+// Tokens are approximated from the rendered prompts and a typical test;
+// unpriced models cost nothing in the totals.
+type previewEstimate struct {
+	Models       []modelEstimate `json:"models"`
+	InputTokens  int             `json:"input_tokens"`
+	OutputTokens int             `json:"output_tokens"`
+	CostUSD      float64         `json:"cost_usd"`
+	Unpriced     []string        `json:"unpriced,omitempty"`
+}
+
+// previewResponse is returned by the analyze preview endpoint.
+type previewResponse struct {
+	// SpecURL is empty for uploaded specs
+	SpecURL   string             `json:"spec_url"`
+	Endpoints []endpointCategory `json:"endpoints"`
+	Summary   previewSummary     `json:"summary"`
+	// Estimate is omitted when the server cannot estimate token use
+	Estimate *previewEstimate `json:"estimate,omitempty"`
+}
+
+// analyzePreview handles POST /api/v1/analyze/preview requests by parsing the
+// spec, named by spec_url or uploaded, categorising every endpoint by risk,
+// marking those the analysis would test and estimating its token use and
+// cost per model, so clients can show what a run costs and touches before
+// starting it.
+func (s *Server) analyzePreview(w http.ResponseWriter, r *http.Request) {
+	var req previewRequest
+	upload, err := s.uploads.readSpecRequest(w, r, &req)
+	if err != nil {
+		writeUploadProblem(w, r, err)
+		return
+	}
+
+	source := req.SpecURL
+	if upload != nil {
+		if source, err = s.uploads.save(upload.Name, upload.Data); err != nil {
+			apiauth.WriteProblem(w, r, http.StatusInternalServerError, ProblemTypeInternal,
+				"Internal Server Error", err.Error())
+			return
+		}
+		defer s.uploads.release(source)
+		req.SpecURL = ""
+	} else if req.SpecURL == "" {
+		apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+			"Validation Error", "spec_url is required")
+		return
+	} else if err := checkSpecURL(req.SpecURL); err != nil {
+		apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+			"Validation Error", err.Error())
+		return
+	}
+
+	if unknown := s.unknownModel(req.Models); unknown != "" {
+		apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+			"Validation Error", fmt.Sprintf("model %q is not served; see GET /api/v1/models", unknown))
+		return
+	}
+
+	spec, err := parser.ParseOpenAPISpecContext(r.Context(), source)
+	if err != nil {
+		apiauth.WriteProblem(w, r, http.StatusUnprocessableEntity, ProblemTypeValidation,
+			"Validation Error", fmt.Sprintf("parse spec: %v", err))
+		return
+	}
+
+	resp := previewResponse{
+		SpecURL:   req.SpecURL,
+		Endpoints: make([]endpointCategory, 0, len(spec.Endpoints)),
+		Summary: previewSummary{
+			Total:      len(spec.Endpoints),
+			ByCategory: map[string]int{},
+			ByRisk:     map[string]int{},
+		},
+	}
+	var selected []parser.Endpoint
+	for i := range spec.Endpoints {
+		endpoint := &spec.Endpoints[i]
+		ec := safety.Categorise(endpoint.Method, endpoint.Path, endpoint.XSafe).WithRisk(endpoint.DeclaredRisk())
+		category := endpointCategory{
+			ID:          endpoint.ID,
+			OperationID: endpoint.OperationID,
+			Path:        ec.Path,
+			Method:      ec.Method,
+			Category:    string(ec.Category),
+			RiskLevel:   string(ec.Risk),
+			Selected:    previewSelects(endpoint, req.ApprovedEndpoints, req.SkippedEndpoints),
+		}
+		resp.Endpoints = append(resp.Endpoints, category)
+		if category.Selected {
+			selected = append(selected, *endpoint)
+			resp.Summary.Selected++
+			resp.Summary.ByCategory[category.Category]++
+			resp.Summary.ByRisk[category.RiskLevel]++
+		}
+	}
+
+	if s.cfg.Estimate != nil {
+		models := req.Models
+		if len(models) == 0 {
+			models = s.cfg.DefaultModels
+		}
+		estimates, err := s.cfg.Estimate(selected, models)
+		if err != nil {
+			apiauth.WriteProblem(w, r, http.StatusUnprocessableEntity, ProblemTypeValidation,
+				"Validation Error", fmt.Sprintf("estimate token usage: %v", err))
+			return
+		}
+		resp.Estimate = s.price(estimates)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// previewSelects reports whether an analysis approving and skipping the
+// given endpoints tests endpoint. Without approvals, endpoints marked
+// x-glens-skip or deprecated are skipped, as by default in runs.
+func previewSelects(endpoint *parser.Endpoint, approved, skipped []string) bool {
+	matches := func(refs []string) bool {
+		return slices.ContainsFunc(refs, endpoint.Matches)
+	}
+	if matches(skipped) {
+		return false
+	}
+	if len(approved) > 0 {
+		return matches(approved)
+	}
+	_, marked := endpoint.Skipped()
+	return !marked && !endpoint.Deprecated
+}
+
+// price totals estimates and costs them at the configured prices.
+func (s *Server) price(estimates []TokenEstimate) *previewEstimate {
+	estimate := &previewEstimate{Models: make([]modelEstimate, 0, len(estimates))}
+	for _, e := range estimates {
+		price, priced := s.cfg.Prices[e.Model]
+		if !priced && e.InputTokens+e.OutputTokens > 0 {
+			estimate.Unpriced = append(estimate.Unpriced, e.Model)
+		}
+		cost := price.Cost(e.InputTokens, e.OutputTokens)
+		estimate.Models = append(estimate.Models, modelEstimate{TokenEstimate: e, CostUSD: cost, Priced: priced})
+		estimate.InputTokens += e.InputTokens
+		estimate.OutputTokens += e.OutputTokens
+		estimate.CostUSD += cost
+	}
+	return estimate
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/pkg/modelcatalog"
+
+	"glens/tools/glens/internal/parser"
+)
+
+// newEstimatingServer returns a server whose estimates charge every model
+// 1000 input and 500 output tokens per endpoint
+func newEstimatingServer(t *testing.T) *Server {
+	t.Helper()
+	srv := New(Config{
+		Models:        []Model{{ID: "mock", Name: "mock", Provider: "mock"}, {ID: "gpt-4o", Name: "gpt-4o", Provider: "openai"}},
+		DefaultModels: []string{"gpt-4o"},
+		Prices:        map[string]modelcatalog.Price{"gpt-4o": {Input: 2.5, Output: 10}},
+		Estimate: func(endpoints []parser.Endpoint, models []string) ([]TokenEstimate, error) {
+			if len(models) > 0 && models[0] == "mock" && len(endpoints) == 1 {
+				return nil, errors.New("template failed")
+			}
+			estimates := make([]TokenEstimate, len(models))
+			for i, model := range models {
+				estimates[i] = TokenEstimate{Model: model, Endpoints: len(endpoints), InputTokens: 1000 * len(endpoints), OutputTokens: 500 * len(endpoints)}
+			}
+			return estimates, nil
+		},
+	})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+	return srv
+}
+
+func TestAnalyzePreview_SummaryAndEstimate(t *testing.T) {
+	srv := newEstimatingServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+serveSpec(t, sampleSpec)+`","models":["gpt-4o","mock"],"skipped_endpoints":["GET /users"]}`)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp previewResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	for _, e := range resp.Endpoints {
+		assert.Equal(t, e.OperationID != "listUsers", e.Selected, e.OperationID)
+	}
+	assert.Equal(t, previewSummary{
+		Total:      3,
+		Selected:   2,
+		ByCategory: map[string]int{"read": 1, "write": 1},
+		ByRisk:     map[string]int{"safe": 1, "medium": 1},
+	}, resp.Summary)
+
+	require.NotNil(t, resp.Estimate)
+	require.Len(t, resp.Estimate.Models, 2)
+	assert.Equal(t, modelEstimate{
+		TokenEstimate: TokenEstimate{Model: "gpt-4o", Endpoints: 2, InputTokens: 2000, OutputTokens: 1000},
+		CostUSD:       0.015,
+		Priced:        true,
+	}, resp.Estimate.Models[0])
+	assert.False(t, resp.Estimate.Models[1].Priced)
+	assert.Equal(t, 4000, resp.Estimate.InputTokens)
+	assert.Equal(t, 2000, resp.Estimate.OutputTokens)
+	assert.InDelta(t, 0.015, resp.Estimate.CostUSD, 1e-9)
+	assert.Equal(t, []string{"mock"}, resp.Estimate.Unpriced)
+}
+
+func TestAnalyzePreview_DefaultModelsAndApprovals(t *testing.T) {
+	srv := newEstimatingServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+serveSpec(t, sampleSpec)+`","approved_endpoints":["createPost"]}`)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp previewResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, 1, resp.Summary.Selected)
+	assert.Equal(t, map[string]int{"write": 1}, resp.Summary.ByCategory)
+	require.Len(t, resp.Estimate.Models, 1)
+	assert.Equal(t, "gpt-4o", resp.Estimate.Models[0].Model)
+	assert.Empty(t, resp.Estimate.Unpriced)
+}
+
+func TestAnalyzePreview_InvalidEstimates(t *testing.T) {
+	srv := newEstimatingServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+serveSpec(t, sampleSpec)+`","models":["gpt4"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `model \"gpt4\" is not served`)
+
+	rec = do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+serveSpec(t, sampleSpec)+`","models":["mock"],"approved_endpoints":["createPost"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "estimate token usage: template failed")
+}
@@ -0,0 +1,11 @@
+package server
+
+// Problem type URI constants of the errors the handlers answer with
+// apiauth.WriteProblem.
+const (
+	ProblemTypeValidation = "https://glens.dev/errors/validation"
+	ProblemTypeInternal   = "https://glens.dev/errors/internal"
+	ProblemTypeNotFound   = "https://glens.dev/errors/not-found"
+	ProblemTypeConflict   = "https://glens.dev/errors/conflict"
+	ProblemTypeOverloaded = "https://glens.dev/errors/overloaded"
+)
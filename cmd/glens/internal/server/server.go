@@ -0,0 +1,237 @@
+// Package server exposes the glens pipeline over HTTP, as glens serve and
+// the standalone API (cmd/api), which runs glens serve. The routes are
+// described by openapi.yaml, served at /api/v1/openapi.json.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"glens/pkg/apiauth"
+	"glens/pkg/metrics"
+	"glens/pkg/modelcatalog"
+
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/readiness"
+	"glens/tools/glens/internal/telemetry"
+)
+
+// AnalyzeRequest is the JSON body for the analyze endpoint.
+type AnalyzeRequest struct {
+	SpecURL           string   `json:"spec_url"`
+	Models            []string `json:"models"`
+	ApprovedEndpoints []string `json:"approved_endpoints"`
+	SkippedEndpoints  []string `json:"skipped_endpoints"`
+}
+
+// Model describes an AI model available to analysis runs.
+type Model struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	// ContextWindow is the tokens the model reads, 0 when unknown;
+	// MaxOutputTokens those an analysis lets it write
+	ContextWindow     int  `json:"context_window,omitempty"`
+	MaxOutputTokens   int  `json:"max_output_tokens,omitempty"`
+	SupportsStreaming bool `json:"supports_streaming"`
+	// Local models keep prompts on the server's machine
+	Local bool `json:"local"`
+	// Deprecated says why the model catalog deprecates the model
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+// Config wires the server to the analysis pipeline.
+type Config struct {
+	Version string
+	Models  []Model
+	// Runner executes queued analysis jobs
+	Runner jobs.Runner
+	// Store keeps job status and reports; defaults to an in-memory store
+	Store jobs.Store
+	// Workers is the number of concurrent analyses (default 1)
+	Workers int
+	// QueueSize is the number of jobs that may wait for a worker (default 100)
+	QueueSize int
+	// Cluster, when set, serves the /api/v1/cluster/ routes through which
+	// remote workers lease the endpoints of distributed jobs; they need an
+	// API key like every other route
+	Cluster http.Handler
+	// UploadDir keeps uploaded specs until their analysis finishes
+	// (default the system temporary directory)
+	UploadDir string
+	// MaxSpecSize is the largest request body, and so spec upload, in
+	// bytes (default DefaultMaxSpecSize)
+	MaxSpecSize int64
+	// DefaultModels are the models of analyses that request none
+	DefaultModels []string
+	// Estimate, when set, estimates the token use previews report
+	Estimate Estimator
+	// Prices are the prices of models, costing the estimates of previews
+	// and listed with the models
+	Prices map[string]modelcatalog.Price
+	// Health, when set, health-checks the models listed by GET
+	// /api/v1/models, returning the error of each model it can check
+	Health func(ctx context.Context) map[string]error
+	// Readiness, when set, backs GET /readyz; without it the server is
+	// ready while it runs
+	Readiness *readiness.Checker
+	// APIKeys, when set, are required of every request but those of
+	// openPaths; without keys every request is allowed
+	APIKeys []string
+	// RateLimit limits the requests of each API key, or client address
+	// without keys
+	RateLimit apiauth.RateLimitConfig
+}
+
+// openPaths stay reachable without an API key: probes and scrapers, and
+// clients discovering the API
+var openPaths = []string{"/healthz", "/livez", "/readyz", "/metrics", "/api/v1/openapi.json"}
+
+// Server serves the glens REST API.
+type Server struct {
+	cfg         Config
+	handler     http.Handler
+	store       jobs.Store
+	queue       *jobs.Queue
+	uploads     *uploads
+	modelHealth *healthCache
+	openAPI     http.HandlerFunc
+	// eventInterval is how often job event streams poll the store
+	eventInterval time.Duration
+
+	// ctx is cancelled by Shutdown, stopping the queue workers and ending
+	// job event streams
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a server with all routes registered and starts its job workers.
+func New(cfg Config) *Server {
+	if cfg.Store == nil {
+		cfg.Store = jobs.NewMemoryStore(0)
+	}
+	if cfg.Readiness == nil {
+		cfg.Readiness = readiness.New(nil, 0)
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+
+	openAPI, err := openAPI(openAPISpec)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		cfg:           cfg,
+		store:         cfg.Store,
+		uploads:       newUploads(cfg.UploadDir, cfg.MaxSpecSize),
+		modelHealth:   &healthCache{check: cfg.Health},
+		openAPI:       openAPI,
+		eventInterval: jobEventInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	runner := cfg.Runner
+	if runner != nil {
+		// An uploaded spec is deleted once its job has run
+		runner = func(ctx context.Context, job *jobs.Job, progress func(jobs.Progress)) ([]byte, error) {
+			defer s.uploads.release(job.SpecURL)
+			return cfg.Runner(ctx, job, progress)
+		}
+	}
+	s.queue = jobs.NewQueue(cfg.Store, runner, cfg.Workers, cfg.QueueSize)
+	s.queue.Start(ctx)
+
+	mux := http.NewServeMux()
+	s.registerRoutes(mux)
+	instrumented := metrics.Instrument(telemetry.HTTPRequestDuration, mux)
+	secured := apiauth.APIKeyAuth(cfg.APIKeys, openPaths...)(
+		apiauth.RateLimit(apiauth.NewLimiter(cfg.RateLimit), openPaths...)(instrumented))
+	s.handler = Recovery(Logging(CORS(secured)))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Shutdown cancels running jobs and waits for the workers to return or for
+// ctx to expire.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.queue.Wait()
+		s.uploads.releaseAll()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// router is the part of http.ServeMux routes are registered with
+type router interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// registerRoutes registers the routes of openapi.yaml, and the cluster
+// routes when the server coordinates one.
+func (s *Server) registerRoutes(mux router) {
+	mux.HandleFunc("GET /healthz", s.health)
+	mux.HandleFunc("GET /livez", s.health)
+	mux.HandleFunc("GET /readyz", s.cfg.Readiness.Handler())
+	mux.HandleFunc("GET /api/v1/openapi.json", s.openAPI)
+	mux.HandleFunc("POST /api/v1/analyze", s.analyze)
+	mux.HandleFunc("POST /api/v1/analyze/preview", s.analyzePreview)
+	mux.HandleFunc("GET /api/v1/jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /api/v1/jobs/{id}/report", s.getJobReport)
+	mux.HandleFunc("GET /api/v1/jobs/{id}/events", s.jobEvents)
+	mux.HandleFunc("GET /api/v1/models", s.models)
+	mux.HandleFunc("POST /api/v1/mcp", s.mcp)
+	mux.Handle("GET /metrics", telemetry.Registry.Handler())
+	if s.cfg.Cluster != nil {
+		mux.Handle("/api/v1/cluster/", s.cfg.Cluster)
+	}
+}
+
+// healthResponse is the JSON body returned by the health endpoint.
+type healthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// health reports service health.
+func (s *Server) health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{
+		Status:  "ok",
+		Version: s.cfg.Version,
+	})
+}
+
+// writeJSON marshals v to JSON and writes it to w with the given status code.
+// It encodes to a buffer first so that encoding failures are caught before
+// headers are sent, avoiding a mixed/corrupted response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "failed to encode JSON response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+	_, _ = w.Write([]byte("\n"))
+}
@@ -0,0 +1,463 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/pkg/apiauth"
+
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/readiness"
+	"glens/tools/glens/internal/storage"
+)
+
+const (
+	sampleSpec = "../../../../test_specs/sample_api.json"
+	// failingSpec is a spec URL whose jobs fail
+	failingSpec = "https://example.com/fail.json"
+)
+
+// serveSpec serves the file at path over HTTP, as spec URLs must be http(s)
+// ones, and returns its URL
+func serveSpec(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return serveContent(t, data)
+}
+
+// serveContent serves data over HTTP and returns its URL
+func serveContent(t *testing.T, data []byte) string {
+	t.Helper()
+	spec := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(spec.Close)
+	return spec.URL + "/openapi.json"
+}
+
+// newTestServer returns a server whose jobs are reported on the channel and
+// succeed with a fixed report.
+func newTestServer(t *testing.T) (*Server, chan *jobs.Job) {
+	t.Helper()
+	runs := make(chan *jobs.Job, 1)
+	srv := New(Config{
+		Version: "test",
+		Models:  []Model{{ID: "mock", Name: "mock", Provider: "mock"}},
+		Runner: func(_ context.Context, job *jobs.Job, progress func(jobs.Progress)) ([]byte, error) {
+			progress(jobs.Progress{EndpointsTotal: 1, EndpointsProcessed: 1, CurrentModel: "mock"})
+			runs <- job
+			if job.SpecURL == failingSpec {
+				return nil, errors.New("spec unreachable")
+			}
+			return []byte(`{"summary":{"total_endpoints":1}}`), nil
+		},
+	})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+	return srv, runs
+}
+
+// waitFinished polls the job endpoint until the job is finished.
+func waitFinished(t *testing.T, srv http.Handler, id string) jobs.Job {
+	t.Helper()
+	var job jobs.Job
+	require.Eventually(t, func() bool {
+		rec := do(srv, http.MethodGet, "/api/v1/jobs/"+id, "")
+		if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&job) != nil {
+			return false
+		}
+		return job.Finished()
+	}, time.Second, 10*time.Millisecond)
+	return job
+}
+
+func do(srv http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHealth(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := do(srv, http.MethodGet, "/healthz", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok","version":"test"}`, rec.Body.String())
+}
+
+func TestProbes(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := do(srv, http.MethodGet, "/livez", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok","version":"test"}`, rec.Body.String())
+
+	rec = do(srv, http.MethodGet, "/readyz", "")
+	assert.Equal(t, http.StatusOK, rec.Code, "a server without readiness checks is ready")
+	assert.Contains(t, rec.Body.String(), `"status":"ready"`)
+}
+
+func TestProbes_ReadinessDrains(t *testing.T) {
+	ready := readiness.New(nil, 0)
+	srv := New(Config{Version: "test", Readiness: ready})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	assert.Equal(t, http.StatusOK, do(srv, http.MethodGet, "/readyz", "").Code)
+	ready.Drain()
+	rec := do(srv, http.MethodGet, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"draining"`)
+}
+
+func TestAnalyze_ValidRequest_QueuesJob(t *testing.T) {
+	srv, runs := newTestServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze", `{"spec_url":"https://example.com/api.json","models":["mock"]}`)
+
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var resp analyzeResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "queued", resp.Status)
+	assert.Len(t, resp.JobID, 32)
+	assert.Equal(t, resp.JobID, resp.RunID)
+	assert.Equal(t, "/api/v1/jobs/"+resp.JobID, rec.Header().Get("Location"))
+
+	select {
+	case req := <-runs:
+		assert.Equal(t, "https://example.com/api.json", req.SpecURL)
+	case <-time.After(time.Second):
+		t.Fatal("analysis job was not started")
+	}
+}
+
+func TestJobs_StatusAndReport(t *testing.T) {
+	srv, runs := newTestServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze", `{"spec_url":"https://example.com/api.json"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var resp analyzeResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	<-runs
+
+	job := waitFinished(t, srv, resp.JobID)
+	assert.Equal(t, jobs.StatusSucceeded, job.Status)
+	assert.Equal(t, 1, job.Progress.EndpointsProcessed)
+	assert.Equal(t, "mock", job.Progress.CurrentModel)
+
+	rec = do(srv, http.MethodGet, "/api/v1/jobs/"+resp.JobID+"/report", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"summary":{"total_endpoints":1}}`, rec.Body.String())
+}
+
+func TestJobs_FailedJob_ReportConflict(t *testing.T) {
+	srv, runs := newTestServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze", `{"spec_url":"`+failingSpec+`"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var resp analyzeResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	<-runs
+
+	job := waitFinished(t, srv, resp.JobID)
+	assert.Equal(t, jobs.StatusFailed, job.Status)
+	assert.Equal(t, "spec unreachable", job.Error)
+
+	rec = do(srv, http.MethodGet, "/api/v1/jobs/"+resp.JobID+"/report", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "spec unreachable")
+}
+
+func TestJobs_EventsStreamUntilFinished(t *testing.T) {
+	srv, runs := newTestServer(t)
+	srv.eventInterval = 5 * time.Millisecond
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze", `{"spec_url":"https://example.com/api.json"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var resp analyzeResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+	stream, err := http.Get(httpSrv.URL + resp.StatusURL + "/events")
+	require.NoError(t, err)
+	defer func() { _ = stream.Body.Close() }()
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+	<-runs
+
+	// The stream ends with the event of the finished job
+	body, err := io.ReadAll(stream.Body)
+	require.NoError(t, err)
+	var last jobs.Job
+	for _, line := range strings.Split(string(body), "\n") {
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			require.NoError(t, json.Unmarshal([]byte(data), &last))
+		}
+	}
+	assert.Contains(t, string(body), "event: job\n")
+	assert.Equal(t, jobs.StatusSucceeded, last.Status)
+	assert.Equal(t, 1, last.Progress.EndpointsProcessed)
+}
+
+func TestShutdown_EndsEventStreamsAndCancelsJobs(t *testing.T) {
+	started := make(chan struct{})
+	srv := New(Config{
+		Runner: func(ctx context.Context, _ *jobs.Job, _ func(jobs.Progress)) ([]byte, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	srv.eventInterval = 5 * time.Millisecond
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(httpSrv.Close)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze", `{"spec_url":"https://example.com/api.json"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var resp analyzeResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	stream, err := http.Get(httpSrv.URL + resp.StatusURL + "/events")
+	require.NoError(t, err)
+	defer func() { _ = stream.Body.Close() }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, srv.Shutdown(ctx), "the running job is cancelled")
+	_, err = io.ReadAll(stream.Body)
+	require.NoError(t, err, "the stream ends")
+	require.NoError(t, httpSrv.Config.Shutdown(ctx), "no stream holds the HTTP server")
+
+	job, err := srv.store.Get(context.Background(), resp.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, jobs.StatusFailed, job.Status)
+}
+
+func TestJobs_UnknownJob_Returns404(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, path := range []string{"/api/v1/jobs/missing", "/api/v1/jobs/missing/report", "/api/v1/jobs/missing/events"} {
+		rec := do(srv, http.MethodGet, path, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"), path)
+	}
+}
+
+func TestJobs_IDsOtherThanJobIDs_Return404(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "secret"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret", "job.json"), []byte(`{"id":"secret","status":"succeeded"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret", "report.json"), []byte(`{"secret":true}`), 0o600))
+	srv := New(Config{Store: jobs.NewObjectStore(storage.NewFS(root), 0)})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	for _, path := range []string{"/api/v1/jobs/..%2Fsecret", "/api/v1/jobs/..%2Fsecret/report", "/api/v1/jobs/..%2F..%2Fetc"} {
+		rec := do(srv, http.MethodGet, path, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		assert.NotContains(t, rec.Body.String(), `"secret":true`, path)
+	}
+}
+
+func TestAnalyze_InvalidRequests_Return400(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantDetail string
+	}{
+		{"malformed JSON", `{invalid`, "invalid request body"},
+		{"missing spec_url", `{}`, "spec_url is required"},
+		{"unknown model", `{"spec_url":"https://example.com/openapi.json","models":["gpt4"]}`, `model "gpt4" is not served`},
+		{"file spec_url", `{"spec_url":"/etc/passwd"}`, "spec_url must be an http or https URL"},
+		{"file scheme", `{"spec_url":"file:///etc/passwd"}`, "spec_url must be an http or https URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+
+			rec := do(srv, http.MethodPost, "/api/v1/analyze", tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			var resp apiauth.ProblemDetail
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, ProblemTypeValidation, resp.Type)
+			assert.Contains(t, resp.Detail, tt.wantDetail)
+			assert.Equal(t, "/api/v1/analyze", resp.Instance)
+		})
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"analyze invalid JSON", "/api/v1/analyze", `{bad`},
+		{"analyze missing spec_url", "/api/v1/analyze", `{"models":["mock"]}`},
+		{"preview invalid JSON", "/api/v1/analyze/preview", `{bad`},
+		{"preview missing spec_url", "/api/v1/analyze/preview", `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+
+			rec := do(srv, http.MethodPost, tt.path, tt.body)
+
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			var p apiauth.ProblemDetail
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+			assert.True(t, strings.HasPrefix(p.Type, "https://"), "type must be an https URI")
+			assert.NotEmpty(t, p.Title)
+			assert.NotEmpty(t, p.Detail)
+			assert.Equal(t, rec.Code, p.Status, "status must match the HTTP status code")
+			assert.Equal(t, tt.path, p.Instance, "instance must be the request path")
+		})
+	}
+}
+
+func TestAnalyzePreview_CategorisesParsedSpec(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+serveSpec(t, sampleSpec)+`"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp previewResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Contains(t, resp.Endpoints, endpointCategory{ID: "GET__users", OperationID: "listUsers", Path: "/users", Method: "GET", Category: "read", RiskLevel: "safe", Selected: true})
+	assert.Contains(t, resp.Endpoints, endpointCategory{ID: "POST__posts", OperationID: "createPost", Path: "/posts", Method: "POST", Category: "write", RiskLevel: "medium", Selected: true})
+	assert.Equal(t, len(resp.Endpoints), resp.Summary.Total)
+	assert.Nil(t, resp.Estimate, "the server has no estimator")
+}
+
+func TestAnalyzePreview_UnparseableSpec_Returns422(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+serveContent(t, []byte("not a spec"))+`"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestAnalyzePreview_LocalSpec_Returns400(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := do(srv, http.MethodPost, "/api/v1/analyze/preview", `{"spec_url":"`+sampleSpec+`"}`)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "upload local specs instead")
+}
+
+func TestModels(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := do(srv, http.MethodGet, "/api/v1/models", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"models":[{"id":"mock","name":"mock","provider":"mock","supports_streaming":false,"local":false,"health":{"status":"unchecked"}}]}`, rec.Body.String())
+}
+
+func TestMetrics_RecordsRequestDurations(t *testing.T) {
+	srv, _ := newTestServer(t)
+	do(srv, http.MethodGet, "/healthz", "")
+
+	rec := do(srv, http.MethodGet, "/metrics", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), `glens_http_request_duration_seconds_count{method="GET",route="GET /healthz",status="200"}`)
+}
+
+func TestMCP(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantError int
+	}{
+		{"tools/list", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, 0},
+		{"models tool", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"models"}}`, 0},
+		{"analyze tool", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"analyze","arguments":{"spec_url":"https://example.com/openapi.json"}}}`, 0},
+		{"analyze local spec", `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"analyze","arguments":{"spec_url":"/etc/passwd"}}}`, -32602},
+		{"analyze without spec", `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"analyze","arguments":{}}}`, -32602},
+		{"unknown tool", `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nope"}}`, -32602},
+		{"unknown method", `{"jsonrpc":"2.0","id":6,"method":"nope"}`, -32601},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTestServer(t)
+
+			rec := do(srv, http.MethodPost, "/api/v1/mcp", tt.body)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			var resp jsonRPCResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			if tt.wantError == 0 {
+				assert.Nil(t, resp.Error)
+				assert.NotNil(t, resp.Result)
+			} else {
+				require.NotNil(t, resp.Error)
+				assert.Equal(t, tt.wantError, resp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	srv := New(Config{Version: "test", APIKeys: []string{"secret"}})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	for _, path := range []string{"/healthz", "/livez", "/readyz", "/metrics", "/api/v1/openapi.json"} {
+		assert.Equal(t, http.StatusOK, do(srv, http.MethodGet, path, "").Code, "%s needs no key", path)
+	}
+
+	rec := do(srv, http.MethodGet, "/api/v1/models", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/models", nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIKeys_ClusterRoutes(t *testing.T) {
+	cluster := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := New(Config{Version: "test", APIKeys: []string{"secret"}, Cluster: cluster})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	rec := do(srv, http.MethodPost, "/api/v1/cluster/lease", `{"worker_id":"w"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "workers need an API key")
+
+	// The worker token travels as the bearer token, the API key beside it
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/cluster/lease", strings.NewReader(`{"worker_id":"w"}`))
+	req.Header.Set("Authorization", "Bearer worker-token")
+	req.Header.Set("X-API-Key", "secret")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"glens/pkg/apiauth"
+)
+
+// DefaultMaxSpecSize is the largest spec upload accepted unless configured
+const DefaultMaxSpecSize = 10 << 20
+
+// specFormField is the multipart form field holding an uploaded spec
+const specFormField = "spec"
+
+// specExtensions are the file extensions the parser tells formats apart by;
+// uploads keep theirs, others are named by their content
+var specExtensions = map[string]bool{
+	".json": true, ".yaml": true, ".yml": true,
+	".graphql": true, ".graphqls": true, ".gql": true,
+	".proto": true, ".protoset": true, ".pb": true,
+}
+
+// listFields are the request fields a form or query may set
+var listFields = []string{"models", "approved_endpoints", "skipped_endpoints"}
+
+// errSpecTooLarge is returned for uploads over the size limit
+var errSpecTooLarge = errors.New("spec exceeds the upload size limit")
+
+// uploads keeps uploaded specs in temporary files until the analysis that
+// reads them finishes
+type uploads struct {
+	dir     string
+	maxSize int64
+
+	mu    sync.Mutex
+	files map[string]bool
+}
+
+func newUploads(dir string, maxSize int64) *uploads {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSpecSize
+	}
+	return &uploads{dir: dir, maxSize: maxSize, files: make(map[string]bool)}
+}
+
+// save writes data to a new temporary file named like name and returns
+// its path
+func (u *uploads) save(name string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if !specExtensions[ext] {
+		ext = ".yaml"
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			ext = ".json"
+		}
+	}
+	if u.dir != "" {
+		if err := os.MkdirAll(u.dir, 0o750); err != nil {
+			return "", fmt.Errorf("create upload directory: %w", err)
+		}
+	}
+	f, err := os.CreateTemp(u.dir, "glens-spec-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("store uploaded spec: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("store uploaded spec: %w", err)
+	}
+
+	u.mu.Lock()
+	u.files[f.Name()] = true
+	u.mu.Unlock()
+	return f.Name(), nil
+}
+
+// release deletes the uploaded spec at path; paths that are not uploads
+// are left alone
+func (u *uploads) release(path string) {
+	u.mu.Lock()
+	owned := u.files[path]
+	delete(u.files, path)
+	u.mu.Unlock()
+	if !owned {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("path", path).Msg("failed to remove uploaded spec")
+	}
+}
+
+// releaseAll deletes the uploads of jobs that never ran
+func (u *uploads) releaseAll() {
+	u.mu.Lock()
+	paths := make([]string, 0, len(u.files))
+	for path := range u.files {
+		paths = append(paths, path)
+	}
+	u.mu.Unlock()
+	for _, path := range paths {
+		u.release(path)
+	}
+}
+
+// checkSpecURL rejects spec URLs other than http and https ones: the
+// parser also reads local files, which clients must upload instead of
+// naming files of the server
+func checkSpecURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("spec_url must be an http or https URL; upload local specs instead")
+	}
+	return nil
+}
+
+// specUpload is a spec sent in a request body
+type specUpload struct {
+	Name string
+	Data []byte
+}
+
+// readSpecRequest decodes the body of an analyze or preview request into
+// req. A JSON body is the request itself, naming the spec by spec_url. A
+// multipart form carries the spec file in the spec field and the request's
+// lists as repeated or comma-separated fields; any other content type is
+// the spec itself, with the lists as query parameters, unless it is a JSON
+// request with a spec_url. Bodies over the
+// upload limit fail with errSpecTooLarge.
+func (u *uploads) readSpecRequest(w http.ResponseWriter, r *http.Request, req any) (*specUpload, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, u.maxSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch {
+	case mediaType == "" || mediaType == "application/json":
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, tooLarge(fmt.Errorf("invalid request body: %w", err))
+		}
+		return nil, nil
+	case mediaType == "multipart/form-data":
+		if err := r.ParseMultipartForm(u.maxSize); err != nil {
+			return nil, tooLarge(fmt.Errorf("invalid multipart body: %w", err))
+		}
+		defer func() { _ = r.MultipartForm.RemoveAll() }()
+		file, header, err := r.FormFile(specFormField)
+		if err != nil {
+			return nil, fmt.Errorf("multipart body has no %q file", specFormField)
+		}
+		defer func() { _ = file.Close() }()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("read uploaded spec: %w", err)
+		}
+		if err := decodeLists(r.MultipartForm.Value, req); err != nil {
+			return nil, err
+		}
+		return &specUpload{Name: header.Filename, Data: data}, nil
+	default:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, tooLarge(fmt.Errorf("read spec body: %w", err))
+		}
+		// Clients such as curl -d label JSON requests as form data
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) == nil && fields["spec_url"] != nil {
+			return nil, json.Unmarshal(data, req)
+		}
+		if err := decodeLists(r.URL.Query(), req); err != nil {
+			return nil, err
+		}
+		return &specUpload{Name: specNameOf(mediaType), Data: data}, nil
+	}
+}
+
+// decodeLists sets the list fields of req from form values, each given
+// repeatedly or comma-separated, e.g. models=mock,gpt-4o
+func decodeLists(values map[string][]string, req any) error {
+	lists := map[string][]string{}
+	for _, key := range listFields {
+		for _, entry := range values[key] {
+			for _, item := range strings.Split(entry, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					lists[key] = append(lists[key], item)
+				}
+			}
+		}
+	}
+	data, err := json.Marshal(lists)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("invalid request fields: %w", err)
+	}
+	return nil
+}
+
+// specNameOf names a spec sent as a body of mediaType
+func specNameOf(mediaType string) string {
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		return "spec.json"
+	case strings.Contains(mediaType, "yaml"):
+		return "spec.yaml"
+	case mediaType == "application/graphql":
+		return "schema.graphql"
+	}
+	return ""
+}
+
+// tooLarge turns the error of a body cut at the upload limit into
+// errSpecTooLarge
+func tooLarge(err error) error {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return fmt.Errorf("%w of %d bytes", errSpecTooLarge, maxBytes.Limit)
+	}
+	return err
+}
+
+// writeUploadProblem answers a request whose body readSpecRequest rejected
+func writeUploadProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errSpecTooLarge) {
+		apiauth.WriteProblem(w, r, http.StatusRequestEntityTooLarge, ProblemTypeValidation,
+			"Payload Too Large", err.Error())
+		return
+	}
+	apiauth.WriteProblem(w, r, http.StatusBadRequest, ProblemTypeValidation,
+		"Validation Error", err.Error())
+}
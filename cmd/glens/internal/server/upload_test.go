@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/internal/jobs"
+)
+
+// uploadedRun is a job run with the content of its spec at run time
+type uploadedRun struct {
+	job  *jobs.Job
+	spec string
+}
+
+// newUploadServer returns a server keeping uploads in dir whose jobs report
+// the spec they read
+func newUploadServer(t *testing.T, dir string, maxSize int64) (*Server, chan uploadedRun) {
+	t.Helper()
+	runs := make(chan uploadedRun, 1)
+	srv := New(Config{
+		Models:      []Model{{ID: "mock", Name: "mock", Provider: "mock"}},
+		UploadDir:   dir,
+		MaxSpecSize: maxSize,
+		Runner: func(_ context.Context, job *jobs.Job, _ func(jobs.Progress)) ([]byte, error) {
+			data, err := os.ReadFile(job.SpecURL)
+			runs <- uploadedRun{job: job, spec: string(data)}
+			return []byte(`{}`), err
+		},
+	})
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+	return srv, runs
+}
+
+// multipartBody returns a form with the spec file and fields
+func multipartBody(t *testing.T, filename, spec string, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if filename != "" {
+		part, err := form.CreateFormFile(specFormField, filename)
+		require.NoError(t, err)
+		_, _ = part.Write([]byte(spec))
+	}
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	require.NoError(t, form.Close())
+	return &body, form.FormDataContentType()
+}
+
+func post(srv http.Handler, path, contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAnalyze_MultipartUpload_QueuesJob(t *testing.T) {
+	dir := t.TempDir()
+	srv, runs := newUploadServer(t, dir, 0)
+
+	body, contentType := multipartBody(t, "petstore.yaml", "openapi: 3.0.0\n", map[string]string{
+		"models":            "mock",
+		"skipped_endpoints": "deletePet,GET /pets",
+	})
+	rec := post(srv, "/api/v1/analyze", contentType, body)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var resp analyzeResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+	run := <-runs
+	assert.Equal(t, "openapi: 3.0.0\n", run.spec)
+	assert.Equal(t, "petstore.yaml", run.job.SpecName)
+	assert.True(t, strings.HasSuffix(run.job.SpecURL, ".yaml"), "the parser tells YAML apart by extension")
+	assert.Equal(t, []string{"mock"}, run.job.Models)
+	assert.Equal(t, []string{"deletePet", "GET /pets"}, run.job.SkippedEndpoints)
+
+	job := waitFinished(t, srv, resp.JobID)
+	assert.Equal(t, jobs.StatusSucceeded, job.Status)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "the upload is deleted once its job has run")
+}
+
+func TestAnalyze_SpecBody_QueuesJob(t *testing.T) {
+	srv, runs := newUploadServer(t, t.TempDir(), 0)
+
+	rec := post(srv, "/api/v1/analyze?models=mock", "application/octet-stream", bytes.NewBufferString(`{"openapi": "3.0.0"}`))
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	run := <-runs
+	assert.JSONEq(t, `{"openapi": "3.0.0"}`, run.spec)
+	assert.True(t, strings.HasSuffix(run.job.SpecURL, ".json"))
+	assert.Equal(t, []string{"mock"}, run.job.Models)
+
+	// A JSON request labelled as form data (curl -d) still names its spec
+	rec = post(srv, "/api/v1/analyze", "application/x-www-form-urlencoded", bytes.NewBufferString(`{"spec_url":"https://example.com/api.json"}`))
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.Equal(t, "https://example.com/api.json", (<-runs).job.SpecURL)
+}
+
+func TestAnalyze_InvalidUploads(t *testing.T) {
+	srv, _ := newUploadServer(t, t.TempDir(), 512)
+
+	rec := post(srv, "/api/v1/analyze", "application/yaml", bytes.NewBufferString(strings.Repeat("x", 600)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), "upload size limit of 512 bytes")
+
+	body, contentType := multipartBody(t, "", "", map[string]string{"models": "mock"})
+	rec = post(srv, "/api/v1/analyze", contentType, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `multipart body has no \"spec\" file`)
+
+	rec = post(srv, "/api/v1/analyze?models=gpt4", "application/yaml", bytes.NewBufferString("openapi: 3.0.0"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `model \"gpt4\" is not served`)
+}
+
+func TestAnalyzePreview_Upload(t *testing.T) {
+	dir := t.TempDir()
+	srv, _ := newUploadServer(t, dir, 0)
+	spec, err := os.ReadFile(sampleSpec)
+	require.NoError(t, err)
+
+	body, contentType := multipartBody(t, "sample_api.json", string(spec), nil)
+	rec := post(srv, "/api/v1/analyze/preview", contentType, body)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp previewResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Empty(t, resp.SpecURL)
+	assert.Equal(t, "/users", resp.Endpoints[0].Path)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
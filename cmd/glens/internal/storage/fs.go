@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tempPattern names the files Put writes before renaming them into place
+const tempPattern = ".glens-*.tmp"
+
+// FS keeps objects as files under Root, the default store. Keys are
+// slash-separated paths relative to Root, the working directory when empty,
+// and never reach outside it; files the user names elsewhere are reached
+// through UserPath.
+type FS struct {
+	Root string
+}
+
+// NewFS returns the filesystem store rooted at root
+func NewFS(root string) *FS {
+	return &FS{Root: root}
+}
+
+// UserPath returns the store and key of a file or directory the user
+// named: store and the slash-separated path, or for the unrooted filesystem
+// store a store of the directory of a path outside the working directory,
+// as keys never leave their store's root
+func UserPath(store Store, name string) (Store, string) {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if fsys, ok := store.(*FS); ok && fsys.Root == "" && !filepath.IsLocal(name) {
+		return NewFS(filepath.Dir(name)), filepath.Base(name)
+	}
+	return store, filepath.ToSlash(name)
+}
+
+// path is the file of key, which must be local to Root
+func (f *FS) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		root := f.Root
+		if root == "" {
+			root = "the working directory"
+		}
+		return "", fmt.Errorf("key %q is outside %s", key, root)
+	}
+	return filepath.Join(f.Root, name), nil
+}
+
+// Location implements Store
+func (f *FS) Location(key string) string {
+	name, err := f.path(key)
+	if err != nil {
+		return key
+	}
+	return name
+}
+
+// Put implements Store, writing a temporary file renamed into place so
+// readers never see a partial object
+func (f *FS) Put(_ context.Context, key, _ string, data []byte) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, tempPattern)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (f *FS) Get(_ context.Context, key string) ([]byte, error) {
+	name, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name) // #nosec G304 -- keys are chosen by the user or glens
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return data, err
+}
+
+// List implements Store by walking the directory of prefix: prefix itself
+// when it ends in a slash, else its parent
+func (f *FS) List(_ context.Context, prefix string) ([]string, error) {
+	dir, want := path.Dir(prefix), ""
+	if prefix != "" {
+		want = path.Clean(prefix)
+	}
+	if strings.HasSuffix(prefix, "/") {
+		dir = want
+	}
+	root, err := f.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if matched, _ := filepath.Match(tempPattern, d.Name()); matched {
+			return nil
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		if key := path.Join(dir, filepath.ToSlash(rel)); strings.HasPrefix(key, want) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Delete implements Store
+func (f *FS) Delete(_ context.Context, key string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
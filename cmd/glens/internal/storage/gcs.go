@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	gcpMetadataToken   = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCS keeps objects in a Google Cloud Storage bucket over its JSON API
+type GCS struct {
+	Bucket string
+	// Endpoint is the API base URL (default https://storage.googleapis.com)
+	Endpoint string
+	// Token returns the OAuth2 access token sent with each request; nil sends
+	// no Authorization header, as emulators expect
+	Token func(ctx context.Context) (string, error)
+	// HTTPClient defaults to a client with a 5 minute timeout
+	HTTPClient *http.Client
+}
+
+// GCSFromEnv configures bucket from the environment. STORAGE_EMULATOR_HOST
+// (e.g. localhost:4443) selects an emulator such as fake-gcs-server
+// without authentication. Otherwise the access token is read from
+// GOOGLE_OAUTH_ACCESS_TOKEN or, on GCP, from the metadata server.
+func GCSFromEnv(bucket string) *GCS {
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint := host
+		if u, err := url.Parse(host); err != nil || u.Scheme == "" || u.Host == "" {
+			endpoint = "http://" + host
+		}
+		return &GCS{Bucket: bucket, Endpoint: endpoint}
+	}
+	g := &GCS{Bucket: bucket, Endpoint: defaultGCSEndpoint}
+	g.Token = func(ctx context.Context) (string, error) {
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			return token, nil
+		}
+		return g.metadataToken(ctx)
+	}
+	return g
+}
+
+// Location implements Store
+func (g *GCS) Location(key string) string {
+	return "gs://" + g.Bucket + "/" + key
+}
+
+// endpoint is the API base URL
+func (g *GCS) endpoint() string {
+	if g.Endpoint == "" {
+		return defaultGCSEndpoint
+	}
+	return g.Endpoint
+}
+
+// objectURL is the JSON API URL of the object key
+func (g *GCS) objectURL(key string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", g.endpoint(), url.PathEscape(g.Bucket), url.PathEscape(key))
+}
+
+// Put implements Store with a simple media upload
+func (g *GCS) Put(ctx context.Context, key, contentType string, data []byte) error {
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		g.endpoint(), url.PathEscape(g.Bucket), url.QueryEscape(key))
+	resp, err := g.do(ctx, http.MethodPost, target, contentType, data)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return check(resp)
+}
+
+// Get implements Store
+func (g *GCS) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := g.do(ctx, http.MethodGet, g.objectURL(key)+"?alt=media", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", g.Location(key), ErrNotFound)
+	}
+	if err := check(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// List implements Store, following the pages of the object listing
+func (g *GCS) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"prefix": {prefix}, "fields": {"items(name),nextPageToken"}}
+		if token != "" {
+			query.Set("pageToken", token)
+		}
+		target := fmt.Sprintf("%s/storage/v1/b/%s/o?%s", g.endpoint(), url.PathEscape(g.Bucket), query.Encode())
+		resp, err := g.do(ctx, http.MethodGet, target, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = check(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+		}
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", g.Location(prefix), err)
+		}
+		for _, item := range page.Items {
+			keys = append(keys, item.Name)
+		}
+		if page.NextPageToken == "" {
+			return keys, nil
+		}
+		token = page.NextPageToken
+	}
+}
+
+// Delete implements Store
+func (g *GCS) Delete(ctx context.Context, key string) error {
+	resp, err := g.do(ctx, http.MethodDelete, g.objectURL(key), "", nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return check(resp)
+}
+
+// do sends a request with body to target, authorized with the access token
+func (g *GCS) do(ctx context.Context, method, target, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if g.Token != nil {
+		token, err := g.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get GCP access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := g.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// metadataToken fetches an access token for the default service account
+// from the GCE/Cloud Run metadata server
+func (g *GCS) metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataToken, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("set GOOGLE_OAUTH_ACCESS_TOKEN outside GCP: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := check(resp); err != nil {
+		return "", fmt.Errorf("metadata server: %w", err)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode metadata token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("metadata server returned no access token")
+	}
+	return token.AccessToken, nil
+}
+
+func (g *GCS) client() *http.Client {
+	if g.HTTPClient == nil {
+		return defaultHTTPClient
+	}
+	return g.HTTPClient
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	// Pure Go driver, as glens is built without cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the table of objects
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS objects (
+	key          TEXT PRIMARY KEY,
+	content_type TEXT NOT NULL,
+	data         BLOB NOT NULL,
+	updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// SQLite keeps objects in a table of a SQLite database file: one durable
+// file for a single server, safe to share with the CLI on the same machine
+type SQLite struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenSQLite opens the database at path, creating it and its directory
+// when missing
+func OpenSQLite(path string) (*SQLite, error) {
+	if path == "" {
+		return nil, errors.New("sqlite storage needs a database file, e.g. sqlite://.glens/glens.db")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create directory of %s: %w", path, err)
+	}
+	// Writers of other processes are waited for rather than failed
+	dsn := (&url.URL{Scheme: "file", Opaque: path, RawQuery: "_pragma=busy_timeout(5000)"}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// One connection serializes the writes of this process
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", path, err)
+	}
+	return &SQLite{db: db, path: path}, nil
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Location implements Store
+func (s *SQLite) Location(key string) string {
+	return "sqlite://" + s.path + "#" + key
+}
+
+// Put implements Store
+func (s *SQLite) Put(ctx context.Context, key, contentType string, data []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO objects (key, content_type, data, updated_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE SET content_type = excluded.content_type, data = excluded.data, updated_at = excluded.updated_at`,
+		key, contentType, data)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.Location(key), err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (s *SQLite) Get(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM objects WHERE key = ?`, key).Scan(&data)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("%s: %w", s.Location(key), ErrNotFound)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", s.Location(key), err)
+	}
+	return data, nil
+}
+
+// List implements Store
+func (s *SQLite) List(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key FROM objects WHERE substr(key, 1, ?) = ? ORDER BY key`,
+		utf8.RuneCountInString(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", s.Location(prefix), err)
+	}
+	defer func() { _ = rows.Close() }()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", s.Location(prefix), err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Delete implements Store
+func (s *SQLite) Delete(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM objects WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", s.Location(key), err)
+	}
+	return nil
+}
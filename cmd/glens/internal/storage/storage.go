@@ -0,0 +1,194 @@
+// Package storage keeps reports, analysis jobs and the artifacts of runs
+// (NDJSON events, generated tests) in a Store: the filesystem by default, a
+// SQLite database, or object storage, Amazon S3 or S3-compatible services
+// and Google Cloud Storage, so deployments choose how durable they are.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultHTTPClient bounds each upload
+var defaultHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+// ErrNotFound is returned by Get for keys without an object
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps objects under slash-separated keys
+type Store interface {
+	// Put writes data to the object key, replacing any earlier object
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get returns the object key, or an error wrapping ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the sorted keys starting with prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the object key; missing objects are not an error
+	Delete(ctx context.Context, key string) error
+	// Location is the URL of the object key, e.g. s3://bucket/key
+	Location(key string) string
+}
+
+// Artifact is a local file and the key it is uploaded under, relative to
+// the run's prefix
+type Artifact struct {
+	Key  string
+	Path string
+}
+
+// Open returns the store of target, s3://bucket[/prefix] or
+// gs://bucket[/prefix], configured from the environment, and the prefix
+// of target
+func Open(target string) (Store, string, error) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	if !ok {
+		return nil, "", fmt.Errorf("upload target %q must be s3://bucket/prefix or gs://bucket/prefix", target)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, "", fmt.Errorf("upload target %q has no bucket", target)
+	}
+	prefix = strings.Trim(prefix, "/")
+
+	switch scheme {
+	case "s3":
+		store, err := S3FromEnv(bucket)
+		if err != nil {
+			return nil, "", err
+		}
+		return store, prefix, nil
+	case "gs", "gcs":
+		return GCSFromEnv(bucket), prefix, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported upload target %q: use s3:// or gs://", target)
+	}
+}
+
+// New returns the store of target, where reports and jobs are kept: a
+// directory (file:// optional; empty is the working directory),
+// sqlite://path/to/glens.db, s3://bucket[/prefix] or gs://bucket[/prefix].
+// Keys of object storage are placed under the prefix.
+func New(target string) (Store, error) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	switch {
+	case !ok:
+		return NewFS(target), nil
+	case scheme == "file":
+		return NewFS(rest), nil
+	case scheme == "sqlite":
+		return OpenSQLite(rest)
+	case scheme != "s3" && scheme != "gs" && scheme != "gcs":
+		return nil, fmt.Errorf("unsupported storage %q: use a directory, sqlite://, s3:// or gs://", target)
+	}
+	store, prefix, err := Open(target)
+	if err != nil || prefix == "" {
+		return store, err
+	}
+	return &prefixed{Store: store, prefix: prefix + "/"}, nil
+}
+
+// prefixed places the keys of a store under a prefix
+type prefixed struct {
+	Store
+	prefix string
+}
+
+func (p *prefixed) Put(ctx context.Context, key, contentType string, data []byte) error {
+	return p.Store.Put(ctx, p.prefix+key, contentType, data)
+}
+
+func (p *prefixed) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.Store.Get(ctx, p.prefix+key)
+}
+
+func (p *prefixed) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := p.Store.List(ctx, p.prefix+prefix)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.prefix)
+	}
+	return keys, err
+}
+
+func (p *prefixed) Delete(ctx context.Context, key string) error {
+	return p.Store.Delete(ctx, p.prefix+key)
+}
+
+func (p *prefixed) Location(key string) string {
+	return p.Store.Location(p.prefix + key)
+}
+
+// Dir lists the files under root as artifacts keyed keyPrefix/<relative
+// path>. A missing root lists nothing.
+func Dir(root, keyPrefix string) ([]Artifact, error) {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var artifacts []Artifact
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, Artifact{Key: path.Join(keyPrefix, filepath.ToSlash(rel)), Path: p})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	return artifacts, nil
+}
+
+// Upload writes artifacts to store under prefix/runID and returns the
+// location of the run's artifacts. It stops at the first failure.
+func Upload(ctx context.Context, store Store, prefix, runID string, artifacts []Artifact) (string, error) {
+	base := path.Join(prefix, runID)
+	for _, artifact := range artifacts {
+		data, err := os.ReadFile(artifact.Path) // #nosec G304 -- artifacts this run wrote
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", artifact.Path, err)
+		}
+		key := path.Join(base, artifact.Key)
+		if err := store.Put(ctx, key, contentType(artifact.Path), data); err != nil {
+			return "", fmt.Errorf("failed to upload %s to %s: %w", artifact.Path, store.Location(key), err)
+		}
+	}
+	return store.Location(base + "/"), nil
+}
+
+// contentType guesses the media type of a file from its extension
+func contentType(file string) string {
+	switch ext := filepath.Ext(file); ext {
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	case ".ndjson":
+		return "application/x-ndjson"
+	case ".go", ".mod", ".sum":
+		return "text/plain; charset=utf-8"
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+		return "application/octet-stream"
+	}
+}
+
+// check turns a non-2xx response into an error quoting its body
+func check(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
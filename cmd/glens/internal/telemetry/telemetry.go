@@ -0,0 +1,38 @@
+// Package telemetry defines the Prometheus metrics glens records during
+// analysis runs and while serving the API.
+package telemetry
+
+import (
+	"glens/pkg/metrics"
+)
+
+// Registry holds every glens metric; serve it with Registry.Handler().
+var Registry = metrics.NewRegistry()
+
+// AI generation calls can take minutes, so latency buckets go well beyond
+// the HTTP defaults.
+var generationBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+var (
+	// AIGenerationDuration observes test generation latency per provider,
+	// model and outcome (success, error).
+	AIGenerationDuration = Registry.NewHistogram("glens_ai_generation_duration_seconds",
+		"Duration of AI test generation calls.", generationBuckets, "provider", "model", "outcome")
+
+	// AITokens counts tokens reported by AI providers.
+	AITokens = Registry.NewCounter("glens_ai_tokens_total",
+		"Tokens used by AI test generation.", "provider", "model")
+
+	// TestResults counts executed generated tests by model and result
+	// (passed, failed, flaky, error).
+	TestResults = Registry.NewCounter("glens_tests_total",
+		"Generated tests executed, by result.", "model", "result")
+
+	// IssueCreationErrors counts failures to create or update issues.
+	IssueCreationErrors = Registry.NewCounter("glens_issue_creation_errors_total",
+		"Errors creating or updating issues for failed tests.")
+
+	// HTTPRequestDuration observes API request durations.
+	HTTPRequestDuration = Registry.NewHistogram("glens_http_request_duration_seconds",
+		"Duration of HTTP requests by method, route and status.", nil, "method", "route", "status")
+)
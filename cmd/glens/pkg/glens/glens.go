@@ -0,0 +1,258 @@
+// Package glens is the programmatic API for embedding glens in other Go
+// programs. It parses OpenAPI specs and runs the analysis pipeline — AI
+// test generation, optional test execution, report building — with every
+// setting passed explicitly instead of read from CLI configuration.
+//
+//	analyzer, err := glens.NewAnalyzer(glens.Options{
+//		Spec:   "https://api.example.com/openapi.json",
+//		Models: []string{"gpt4"},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	report, err := analyzer.Run(ctx)
+package glens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"glens/pkg/safety"
+
+	"glens/tools/glens/internal/ai"
+	"glens/tools/glens/internal/analysis"
+	"glens/tools/glens/internal/environment"
+	"glens/tools/glens/internal/jobs"
+	"glens/tools/glens/internal/parser"
+	"glens/tools/glens/internal/reporter"
+)
+
+type (
+	// Spec is a parsed OpenAPI specification.
+	Spec = parser.OpenAPISpec
+	// Endpoint is one operation of a Spec.
+	Endpoint = parser.Endpoint
+	// Parameter is a parameter of an Endpoint.
+	Parameter = parser.Parameter
+	// Schema is a JSON schema of a parameter, request or response; local
+	// $refs are resolved in place and keep their Ref.
+	Schema = parser.Schema
+	// Report is the result of an analysis run.
+	Report = reporter.Report
+	// ReportFormat selects how RenderReport formats a Report.
+	ReportFormat = reporter.ReportFormat
+	// Environment is the target tests run against: base URL, headers, auth.
+	Environment = environment.Environment
+	// Auth is the credential injected into requests of generated tests.
+	Auth = environment.Auth
+	// AuthType selects how Auth.Token is sent.
+	AuthType = environment.AuthType
+	// Progress reports how far a run has got.
+	Progress = jobs.Progress
+	// AIConfig holds the settings of every AI provider.
+	AIConfig = ai.Config
+	// OpenAIConfig configures OpenAI and OpenAI-compatible (Mistral) models.
+	OpenAIConfig = ai.OpenAIConfig
+	// AnthropicConfig configures Anthropic models.
+	AnthropicConfig = ai.AnthropicConfig
+	// GoogleConfig configures Google Gemini models.
+	GoogleConfig = ai.GoogleConfig
+	// OllamaConfig configures a local or self-hosted Ollama server.
+	OllamaConfig = ai.OllamaConfig
+	// Risk classifies the side effects of an endpoint.
+	Risk = safety.Risk
+)
+
+// Report formats accepted by RenderReport.
+const (
+	FormatMarkdown = reporter.FormatMarkdown
+	FormatJSON     = reporter.FormatJSON
+	FormatHTML     = reporter.FormatHTML
+	// FormatLSPDiagnostics renders failing tests and spec quality gaps as
+	// Language Server Protocol diagnostics of the spec file.
+	FormatLSPDiagnostics = reporter.FormatLSPDiagnostics
+)
+
+// Risk levels for Options.AllowRisk.
+const (
+	RiskSafe   = safety.RiskSafe
+	RiskMedium = safety.RiskMedium
+	RiskHigh   = safety.RiskHigh
+)
+
+// Auth types for Environment.Auth.
+const (
+	AuthNone   = environment.AuthNone
+	AuthBearer = environment.AuthBearer
+	AuthAPIKey = environment.AuthAPIKey
+)
+
+// Options configure an Analyzer.
+type Options struct {
+	// Spec is the URL or file path of the OpenAPI specification
+	Spec string
+	// Models are the AI models that generate a test per endpoint, e.g.
+	// "gpt4", "sonnet4", "flash-pro", "ollama:codellama", "mock"
+	Models []string
+	// AI configures the model providers: API keys, base URLs, timeouts.
+	// Nil reads API keys from OPENAI_API_KEY, ANTHROPIC_API_KEY,
+	// GOOGLE_API_KEY and MISTRAL_API_KEY.
+	AI *AIConfig
+	// PromptDir holds prompt templates overriding the built-in prompts:
+	// <model>[.<category>].tmpl or <provider>[.<category>].tmpl, e.g.
+	// "gpt4.tmpl" or "anthropic.destroy.tmpl"
+	PromptDir string
+	// ExamplesDir holds exemplar Go tests in the team's house style; the
+	// MaxExamples (default 2) most relevant to each endpoint by method and
+	// tags are added to its prompt. A "// glens: methods=POST tags=users"
+	// first line declares what an example covers.
+	ExamplesDir string
+	MaxExamples int
+	// Framework of generated tests: "testify" (default) or "ginkgo"
+	Framework string
+	// Endpoints restricts the run to endpoints named by operation ID,
+	// endpoint ID or "METHOD /path"; empty means all endpoints
+	Endpoints []string
+	// SkipEndpoints excludes endpoints, named the same way as Endpoints
+	SkipEndpoints []string
+	// Tags, Methods and Path narrow the endpoints further: any listed tag,
+	// any listed HTTP method, and a path glob ("*" within a segment, "**"
+	// across segments) or "re:<regexp>"
+	Tags    []string
+	Methods []string
+	Path    string
+	// MinPriority keeps only endpoints whose x-glens-priority is at least
+	// "high", "normal" or "low"; endpoints marked x-glens-skip are skipped
+	// unless named in Endpoints
+	MinPriority string
+	// IncludeDeprecated also tests operations marked deprecated in the
+	// spec, which are skipped by default
+	IncludeDeprecated bool
+	// Scenarios adds a multi-step test of each resource lifecycle inferred
+	// from the spec's paths and operation IDs (create, read, update,
+	// delete); ScenariosFile adds the scenarios of a YAML file
+	Scenarios     bool
+	ScenariosFile string
+	// RunTests executes generated tests against Environment
+	RunTests bool
+	// AllowRisk is the highest endpoint risk whose tests are executed:
+	// RiskSafe (default) runs read-only endpoints only, RiskMedium adds
+	// writes and updates, RiskHigh adds deletes
+	AllowRisk Risk
+	// TestTimeout bounds each test run attempt (default 2m)
+	TestTimeout time.Duration
+	// TestRetries re-runs failing tests; a pass on retry is reported flaky
+	TestRetries int
+	// EndpointTimeout bounds the generation and execution of each
+	// endpoint's tests and TotalTimeout the whole run; endpoints cut short
+	// are reported as skipped. 0 disables either.
+	EndpointTimeout time.Duration
+	TotalTimeout    time.Duration
+	// Environment is the target tests run against (default
+	// http://localhost:8080)
+	Environment *Environment
+	// Progress, when set, is called as endpoints and models are processed
+	Progress func(Progress)
+}
+
+// Analyzer runs the analysis pipeline with fixed Options.
+type Analyzer struct {
+	opts Options
+	ai   *ai.Manager
+}
+
+// ParseSpec parses an OpenAPI 3.x specification from a URL or file path.
+func ParseSpec(source string) (*Spec, error) {
+	return parser.ParseOpenAPISpec(source)
+}
+
+// RenderReport formats a report as Markdown, JSON or HTML.
+func RenderReport(report *Report, format ReportFormat) (string, error) {
+	return reporter.Render(report, format)
+}
+
+// NewAnalyzer validates opts and creates the AI clients for its models.
+func NewAnalyzer(opts Options) (*Analyzer, error) {
+	if opts.Spec == "" {
+		return nil, errors.New("glens: Options.Spec is required")
+	}
+	if len(opts.Models) == 0 {
+		return nil, errors.New("glens: Options.Models needs at least one model")
+	}
+	if opts.Framework == "" {
+		opts.Framework = "testify"
+	}
+	if _, err := safety.ParseRisk(string(opts.AllowRisk)); err != nil {
+		return nil, fmt.Errorf("glens: invalid AllowRisk: %w", err)
+	}
+	if opts.Environment != nil {
+		env := *opts.Environment
+		if err := env.Resolve(); err != nil {
+			return nil, fmt.Errorf("glens: invalid environment: %w", err)
+		}
+		opts.Environment = &env
+	}
+
+	aiConfig := ai.ConfigFromEnv()
+	if opts.AI != nil {
+		aiConfig = *opts.AI
+	}
+	manager, err := ai.NewManager(opts.Models, aiConfig)
+	if err != nil {
+		return nil, fmt.Errorf("glens: failed to initialize AI clients: %w", err)
+	}
+	manager.SetEnvironment(opts.Environment)
+	prompts, err := ai.NewPrompts(opts.PromptDir)
+	if err != nil {
+		return nil, fmt.Errorf("glens: failed to load prompt templates: %w", err)
+	}
+	if opts.ExamplesDir != "" {
+		examples, err := ai.LoadExamples(opts.ExamplesDir)
+		if err != nil {
+			return nil, fmt.Errorf("glens: %w", err)
+		}
+		if opts.MaxExamples <= 0 {
+			opts.MaxExamples = ai.DefaultMaxExamples
+		}
+		prompts.SetExamples(examples, opts.MaxExamples)
+	}
+	manager.SetPrompts(prompts)
+
+	return &Analyzer{opts: opts, ai: manager}, nil
+}
+
+// Run parses the spec and analyzes every selected endpoint. It returns
+// early with an error when ctx is cancelled. An Analyzer runs one analysis
+// at a time; its AI clients are not safe for concurrent use.
+func (a *Analyzer) Run(ctx context.Context) (*Report, error) {
+	spec, err := ParseSpec(a.opts.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("glens: failed to parse OpenAPI spec: %w", err)
+	}
+
+	return analysis.Run(ctx, spec, a.ai, analysis.Options{
+		Models:    a.opts.Models,
+		Framework: a.opts.Framework,
+		Approved:  a.opts.Endpoints,
+		Skipped:   a.opts.SkipEndpoints,
+		Selection: parser.Selection{
+			Tags:        a.opts.Tags,
+			Methods:     a.opts.Methods,
+			Path:        a.opts.Path,
+			MinPriority: a.opts.MinPriority,
+		},
+		IncludeDeprecated: a.opts.IncludeDeprecated,
+		InferScenarios:    a.opts.Scenarios,
+		ScenariosFile:     a.opts.ScenariosFile,
+		RunTests:          a.opts.RunTests,
+		AllowRisk:         a.opts.AllowRisk,
+		TestTimeout:       a.opts.TestTimeout,
+		TestRetries:       a.opts.TestRetries,
+		EndpointTimeout:   a.opts.EndpointTimeout,
+		TotalTimeout:      a.opts.TotalTimeout,
+		Env:               a.opts.Environment,
+		Progress:          a.opts.Progress,
+	})
+}
@@ -0,0 +1,203 @@
+package glens_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"glens/tools/glens/pkg/glens"
+)
+
+const sampleSpec = "../../../../test_specs/sample_api.json"
+
+func TestParseSpec(t *testing.T) {
+	spec, err := glens.ParseSpec(sampleSpec)
+	require.NoError(t, err)
+
+	assert.Len(t, spec.Endpoints, 3)
+	_, ok := spec.FindEndpoint("getUser")
+	assert.True(t, ok)
+}
+
+func TestNewAnalyzer_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    glens.Options
+		wantErr string
+	}{
+		{"missing spec", glens.Options{Models: []string{"mock"}}, "Spec is required"},
+		{"missing models", glens.Options{Spec: sampleSpec}, "at least one model"},
+		{"unknown model", glens.Options{Spec: sampleSpec, Models: []string{"nope"}}, "AI clients"},
+		{"bad allow risk", glens.Options{Spec: sampleSpec, Models: []string{"mock"}, AllowRisk: "critical"}, "invalid AllowRisk"},
+		{"missing prompt dir", glens.Options{Spec: sampleSpec, Models: []string{"mock"}, PromptDir: "does-not-exist"}, "prompt templates"},
+		{"missing examples dir", glens.Options{Spec: sampleSpec, Models: []string{"mock"}, ExamplesDir: "does-not-exist"}, "failed to load examples"},
+		{"bad environment", glens.Options{Spec: sampleSpec, Models: []string{"mock"}, Environment: &glens.Environment{BaseURL: "not a url"}}, "invalid environment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := glens.NewAnalyzer(tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestAnalyzer_Run(t *testing.T) {
+	var last glens.Progress
+	analyzer, err := glens.NewAnalyzer(glens.Options{
+		Spec:          sampleSpec,
+		Models:        []string{"mock"},
+		SkipEndpoints: []string{"POST /posts"},
+		Progress:      func(p glens.Progress) { last = p },
+	})
+	require.NoError(t, err)
+
+	report, err := analyzer.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.EndpointResults, 2)
+	for _, result := range report.EndpointResults {
+		assert.Contains(t, result.Tests, "mock")
+		assert.NotEmpty(t, result.Tests["mock"].TestCode)
+	}
+	// Endpoint order follows the spec's paths map, so only the counts are fixed
+	assert.Equal(t, 2, last.EndpointsTotal)
+	assert.Equal(t, 2, last.EndpointsProcessed)
+	assert.Contains(t, []string{"GET /users", "GET /users/{id}"}, last.CurrentEndpoint)
+
+	md, err := glens.RenderReport(report, glens.FormatMarkdown)
+	require.NoError(t, err)
+	assert.Contains(t, md, "#")
+}
+
+func TestAnalyzer_Run_Selection(t *testing.T) {
+	analyzer, err := glens.NewAnalyzer(glens.Options{
+		Spec:    sampleSpec,
+		Models:  []string{"mock"},
+		Methods: []string{"get"},
+		Path:    "/users/*",
+	})
+	require.NoError(t, err)
+
+	report, err := analyzer.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.EndpointResults, 1)
+	assert.Equal(t, "/users/{id}", report.EndpointResults[0].Endpoint.Path)
+	assert.Equal(t, "methods=get path=/users/*", report.Metadata["selection"])
+
+	analyzer, err = glens.NewAnalyzer(glens.Options{Spec: sampleSpec, Models: []string{"mock"}, Tags: []string{"nope"}})
+	require.NoError(t, err)
+	_, err = analyzer.Run(context.Background())
+	assert.ErrorContains(t, err, "no endpoints match the selection (tags=nope)")
+}
+
+func TestAnalyzer_Run_RiskGating(t *testing.T) {
+	analyzer, err := glens.NewAnalyzer(glens.Options{
+		Spec:      sampleSpec,
+		Models:    []string{"mock"},
+		Endpoints: []string{"POST /posts"},
+		RunTests:  true,
+	})
+	require.NoError(t, err)
+
+	report, err := analyzer.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.EndpointResults, 1)
+	result := report.EndpointResults[0]
+	assert.Equal(t, glens.RiskMedium, result.RiskLevel)
+	assert.EqualValues(t, "write", result.Category)
+	assert.Nil(t, result.Tests["mock"].ExecutionResult, "medium risk tests are not executed by default")
+	assert.Contains(t, result.Warnings, "tests not executed: medium risk exceeds allowed risk safe")
+	assert.Equal(t, "safe", report.Metadata["allow_risk"])
+
+	md, err := glens.RenderReport(report, glens.FormatMarkdown)
+	require.NoError(t, err)
+	assert.Contains(t, md, "**Category:** write (medium risk)")
+	assert.Contains(t, md, "⚠️ POST /posts is write (medium risk)")
+}
+
+func TestAnalyzer_Run_Cancelled(t *testing.T) {
+	analyzer, err := glens.NewAnalyzer(glens.Options{Spec: sampleSpec, Models: []string{"mock"}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = analyzer.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAnalyzer_Run_Timeouts(t *testing.T) {
+	analyzer, err := glens.NewAnalyzer(glens.Options{
+		Spec:            sampleSpec,
+		Models:          []string{"mock"},
+		Endpoints:       []string{"getUser"},
+		EndpointTimeout: time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	report, err := analyzer.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.EndpointResults, 1)
+	assert.EqualValues(t, "skipped", report.EndpointResults[0].Status)
+	assert.Equal(t, "endpoint timeout of 1ns exceeded", report.EndpointResults[0].SkipReason)
+	assert.Equal(t, "1ns", report.Metadata["endpoint_timeout"])
+
+	analyzer, err = glens.NewAnalyzer(glens.Options{Spec: sampleSpec, Models: []string{"mock"}, TotalTimeout: time.Nanosecond})
+	require.NoError(t, err)
+
+	report, err = analyzer.Run(context.Background())
+	require.NoError(t, err, "the total timeout skips endpoints instead of failing the run")
+	require.NotEmpty(t, report.EndpointResults)
+	for _, result := range report.EndpointResults {
+		assert.EqualValues(t, "skipped", result.Status)
+		assert.Equal(t, "total timeout of 1ns reached before the endpoint started", result.SkipReason)
+		assert.Empty(t, result.Tests)
+	}
+
+	md, err := glens.RenderReport(report, glens.FormatMarkdown)
+	require.NoError(t, err)
+	assert.Contains(t, md, "> ⏭️ Skipped: total timeout of 1ns reached before the endpoint started")
+}
+
+func TestAnalyzer_Run_Deprecated(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(spec, []byte(`{
+		"openapi": "3.0.3",
+		"info": {"title": "Users", "version": "2"},
+		"paths": {
+			"/users": {"get": {"operationId": "listUsers", "responses": {"200": {"description": "OK"}}}},
+			"/v1/users": {"get": {"operationId": "listUsersV1", "deprecated": true, "responses": {"200": {"description": "OK"}}}}
+		}
+	}`), 0o600))
+
+	analyzer, err := glens.NewAnalyzer(glens.Options{Spec: spec, Models: []string{"mock"}})
+	require.NoError(t, err)
+	report, err := analyzer.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, report.EndpointResults, 1)
+	assert.Equal(t, "/users", report.EndpointResults[0].Endpoint.Path)
+	require.Len(t, report.DeprecatedOperations, 1)
+	assert.False(t, report.DeprecatedOperations[0].Tested)
+
+	analyzer, err = glens.NewAnalyzer(glens.Options{Spec: spec, Models: []string{"mock"}, IncludeDeprecated: true})
+	require.NoError(t, err)
+	report, err = analyzer.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.EndpointResults, 2)
+	assert.True(t, report.DeprecatedOperations[0].Tested)
+
+	analyzer, err = glens.NewAnalyzer(glens.Options{Spec: spec, Models: []string{"mock"}, Endpoints: []string{"listUsersV1"}})
+	require.NoError(t, err)
+	report, err = analyzer.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.EndpointResults, 1, "endpoints named explicitly are tested even when deprecated")
+}
@@ -1,15 +1,14 @@
-// Package analyze loads and analyses OpenAPI specs for the accuracy tool.
+// Package analyze parses OpenAPI specs with the glens parser and compares
+// what it extracts against expected fixtures for the accuracy tool.
 package analyze
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"glens/tools/glens/pkg/glens"
 )
 
 // Result holds the outcome of analysing a single spec.
@@ -20,23 +19,21 @@ type Result struct {
 	Endpoints int
 	Elapsed   time.Duration
 	Err       error
+	// Fixture is the expected-fixture file the spec was compared against,
+	// empty when it has none
+	Fixture string
+	// Comparison is the extraction accuracy against Fixture
+	Comparison *Comparison
 }
 
-// minimalSpec holds only the fields needed for accuracy metrics.
-type minimalSpec struct {
-	Info struct {
-		Title   string `json:"title"`
-		Version string `json:"version"`
-	} `json:"info"`
-	Paths map[string]map[string]interface{} `json:"paths"`
-}
-
-// Specs analyses each spec and returns a Result per spec.
-func Specs(paths []string) []Result {
+// Specs parses each spec and returns a Result per spec. A spec is compared
+// against its fixture, <name>.yaml in fixturesDir or, when fixturesDir is
+// empty, <name>.expected.yaml next to the spec.
+func Specs(paths []string, fixturesDir string) []Result {
 	results := make([]Result, 0, len(paths))
 	for _, p := range paths {
 		start := time.Now()
-		spec, err := loadSpec(p)
+		spec, err := glens.ParseSpec(p)
 		elapsed := time.Since(start)
 
 		r := Result{
@@ -47,49 +44,52 @@ func Specs(paths []string) []Result {
 		}
 		if err == nil {
 			r.Title = spec.Info.Title
-			r.Endpoints = countEndpoints(spec)
+			r.Endpoints = len(operations(spec))
+			r.Fixture, r.Comparison, r.Err = compareFixture(spec, fixturePath(p, r.Name, fixturesDir))
 		}
 		results = append(results, r)
 	}
 	return results
 }
 
-func loadSpec(source string) (*minimalSpec, error) {
-	data, err := fetch(source)
-	if err != nil {
-		return nil, err
+// fixturePath returns the fixture of the spec at path named name, or ""
+// when it has none
+func fixturePath(path, name, fixturesDir string) string {
+	candidate := filepath.Join(fixturesDir, name+".yaml")
+	if fixturesDir == "" {
+		if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+			return ""
+		}
+		candidate = filepath.Join(filepath.Dir(path), name+".expected.yaml")
 	}
-	var spec minimalSpec
-	if err := json.Unmarshal(data, &spec); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	if _, err := os.Stat(candidate); err != nil {
+		return ""
 	}
-	return &spec, nil
+	return candidate
 }
 
-func fetch(source string) ([]byte, error) {
-	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Get(source) //nolint:gosec
-		if err != nil {
-			return nil, fmt.Errorf("HTTP request failed: %w", err)
-		}
-		defer resp.Body.Close() //nolint:errcheck
-
-		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-			bodySnippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-			return nil, fmt.Errorf("HTTP %d %s: %s", resp.StatusCode, resp.Status, strings.TrimSpace(string(bodySnippet)))
-		}
-		return io.ReadAll(resp.Body)
+// compareFixture compares spec against the fixture at path, if any
+func compareFixture(spec *glens.Spec, path string) (string, *Comparison, error) {
+	if path == "" {
+		return "", nil, nil
 	}
-	return os.ReadFile(source) //nolint:gosec
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return path, nil, err
+	}
+	return path, Compare(spec, fixture), nil
 }
 
-func countEndpoints(spec *minimalSpec) int {
-	count := 0
-	for _, methods := range spec.Paths {
-		count += len(methods)
+// operations returns the path operations of spec, leaving out the
+// webhooks, callbacks and scenarios glens derives from it
+func operations(spec *glens.Spec) []*glens.Endpoint {
+	var ops []*glens.Endpoint
+	for i := range spec.Endpoints {
+		if spec.Endpoints[i].Kind == "" {
+			ops = append(ops, &spec.Endpoints[i])
+		}
 	}
-	return count
+	return ops
 }
 
 // specName derives a short display name from the file path, cross-platform.
@@ -1,8 +1,10 @@
 package analyze_test
 
 import (
+	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"testing"
 
 	"glens/tools/accuracy/internal/analyze"
@@ -25,7 +27,7 @@ func sampleSpecPath(t *testing.T) string {
 func TestSpecs_sampleAPI(t *testing.T) {
 	specPath := sampleSpecPath(t)
 
-	results := analyze.Specs([]string{specPath})
+	results := analyze.Specs([]string{specPath}, "")
 
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
@@ -50,7 +52,7 @@ func TestSpecs_sampleAPI(t *testing.T) {
 }
 
 func TestSpecs_missingFile(t *testing.T) {
-	results := analyze.Specs([]string{"/nonexistent/path/spec.json"})
+	results := analyze.Specs([]string{"/nonexistent/path/spec.json"}, "")
 
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
@@ -64,7 +66,7 @@ func TestSpecs_multipleSpecs(t *testing.T) {
 	specPath := sampleSpecPath(t)
 
 	// Run the same spec twice to verify multi-spec handling
-	results := analyze.Specs([]string{specPath, specPath})
+	results := analyze.Specs([]string{specPath, specPath}, "")
 
 	if len(results) != 2 {
 		t.Fatalf("expected 2 results, got %d", len(results))
@@ -78,3 +80,117 @@ func TestSpecs_multipleSpecs(t *testing.T) {
 		}
 	}
 }
+
+func TestSpecs_fixture(t *testing.T) {
+	results := analyze.Specs([]string{sampleSpecPath(t)}, "")
+
+	r := results[0]
+	if r.Err != nil {
+		t.Fatalf("unexpected error: %v", r.Err)
+	}
+	if filepath.Base(r.Fixture) != "sample_api.expected.yaml" {
+		t.Fatalf("fixture = %q, want sample_api.expected.yaml", r.Fixture)
+	}
+	c := r.Comparison
+	if c == nil {
+		t.Fatal("expected a comparison against the fixture")
+	}
+	if !c.TitleMatches || c.ExpectedEndpoints != 3 {
+		t.Errorf("title matches = %v, expected endpoints = %d", c.TitleMatches, c.ExpectedEndpoints)
+	}
+	if c.Overall.Precision != 1 || c.Overall.Recall != 1 {
+		t.Errorf("overall = %+v, want precision and recall 1; missing %v, unexpected %v", c.Overall, c.Missing, c.Unexpected)
+	}
+}
+
+func TestSpecs_fixturesDir(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "refs.json")
+	writeFile(t, spec, `{
+  "openapi": "3.0.3",
+  "info": {"title": "Refs API", "version": "1.0.0"},
+  "paths": {
+    "/pets/{petId}": {
+      "get": {
+        "parameters": [
+          {"name": "petId", "in": "path", "required": true, "schema": {"type": "string"}},
+          {"name": "fields", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "A pet",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {"type": "object", "properties": {"owner": {"$ref": "#/components/schemas/Owner"}}},
+      "Owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+    }
+  }
+}`)
+	fixtures := filepath.Join(dir, "fixtures")
+	writeFile(t, filepath.Join(fixtures, "refs.yaml"), `
+title: Refs API
+endpoints: 1
+operations:
+  - method: GET
+    path: /pets/{petId}
+    parameters:
+      - {in: path, name: petId}
+    refs:
+      - "#/components/schemas/Pet"
+      - "#/components/schemas/Owner"
+  - method: DELETE
+    path: /pets/{petId}
+`)
+
+	r := analyze.Specs([]string{spec}, fixtures)[0]
+	if r.Err != nil {
+		t.Fatalf("unexpected error: %v", r.Err)
+	}
+	c := r.Comparison
+	if c == nil {
+		t.Fatal("expected a comparison against the fixture")
+	}
+	if c.Refs.Matched != 2 || c.Refs.Recall != 1 {
+		t.Errorf("refs = %+v, want both refs resolved", c.Refs)
+	}
+	if c.Operations.Matched != 1 || c.Operations.Recall != 0.5 || c.Operations.Precision != 1 {
+		t.Errorf("operations = %+v, want 1 of 2 expected found", c.Operations)
+	}
+	if c.Parameters.Precision != 0.5 || c.Parameters.Recall != 1 {
+		t.Errorf("parameters = %+v, want the query parameter unexpected", c.Parameters)
+	}
+	wantMissing := []string{"operation DELETE /pets/{petId}"}
+	if !slices.Equal(c.Missing, wantMissing) {
+		t.Errorf("missing = %v, want %v", c.Missing, wantMissing)
+	}
+	wantUnexpected := []string{"parameter GET /pets/{petId} query.fields"}
+	if !slices.Equal(c.Unexpected, wantUnexpected) {
+		t.Errorf("unexpected = %v, want %v", c.Unexpected, wantUnexpected)
+	}
+}
+
+func TestSpecs_invalidFixture(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "sample_api.yaml"), "operations: {not: a list}\n")
+
+	r := analyze.Specs([]string{sampleSpecPath(t)}, dir)[0]
+	if r.Err == nil {
+		t.Error("expected error for an invalid fixture, got nil")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,238 @@
+package analyze
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"glens/tools/glens/pkg/glens"
+)
+
+// Fixture is what the parser is expected to extract from a spec. Only the
+// operations it lists are checked for parameters and schema refs, so a
+// fixture may describe a large spec partially.
+//
+//	title: Sample API
+//	endpoints: 3
+//	operations:
+//	  - method: GET
+//	    path: /users/{id}
+//	    parameters:
+//	      - {in: path, name: id}
+//	    refs:
+//	      - "#/components/schemas/User"
+type Fixture struct {
+	Title string `yaml:"title"`
+	// Endpoints is the expected number of path operations
+	Endpoints  int                `yaml:"endpoints"`
+	Operations []FixtureOperation `yaml:"operations"`
+}
+
+// FixtureOperation is an operation the parser must extract, with its
+// parameters and the schema refs of its parameters, request and responses,
+// each of which must be resolved
+type FixtureOperation struct {
+	Method     string             `yaml:"method"`
+	Path       string             `yaml:"path"`
+	Parameters []FixtureParameter `yaml:"parameters"`
+	Refs       []string           `yaml:"refs"`
+}
+
+// FixtureParameter identifies a parameter by location and name
+type FixtureParameter struct {
+	In   string `yaml:"in"`
+	Name string `yaml:"name"`
+}
+
+// LoadFixture reads a fixture YAML file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// Metric counts the facts of one kind the parser was expected to extract
+// and did extract.
+type Metric struct {
+	Expected  int     `json:"expected"`
+	Extracted int     `json:"extracted"`
+	Matched   int     `json:"matched"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+// Comparison is the extraction accuracy of a spec against its fixture.
+type Comparison struct {
+	// TitleMatches is false when the fixture's title differs from the spec's
+	TitleMatches      bool `json:"title_matches"`
+	ExpectedEndpoints int  `json:"expected_endpoints"`
+	// Operations, Parameters and Refs score each kind of fact; Overall
+	// scores them together
+	Operations Metric `json:"operations"`
+	Parameters Metric `json:"parameters"`
+	Refs       Metric `json:"refs"`
+	Overall    Metric `json:"overall"`
+	// Missing lists expected facts the parser did not extract, Unexpected
+	// extracted facts the fixture does not expect
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+}
+
+// Compare scores what the parser extracted from spec against fixture.
+func Compare(spec *glens.Spec, fixture *Fixture) *Comparison {
+	c := &Comparison{
+		TitleMatches:      fixture.Title == "" || fixture.Title == spec.Info.Title,
+		ExpectedEndpoints: fixture.Endpoints,
+	}
+
+	extracted := make(map[string]*glens.Endpoint)
+	var extractedOps []string
+	for _, op := range operations(spec) {
+		key := opKey(op.Method, op.Path)
+		extracted[key] = op
+		extractedOps = append(extractedOps, "operation "+key)
+	}
+
+	var expectedOps, expectedParams, extractedParams, expectedRefs, extractedRefs []string
+	for _, want := range fixture.Operations {
+		key := opKey(want.Method, want.Path)
+		expectedOps = append(expectedOps, "operation "+key)
+		for _, p := range want.Parameters {
+			expectedParams = append(expectedParams, fmt.Sprintf("parameter %s %s.%s", key, strings.ToLower(p.In), p.Name))
+		}
+		for _, ref := range want.Refs {
+			expectedRefs = append(expectedRefs, fmt.Sprintf("ref %s %s", key, ref))
+		}
+
+		op, ok := extracted[key]
+		if !ok {
+			continue
+		}
+		for _, p := range op.Parameters {
+			extractedParams = append(extractedParams, fmt.Sprintf("parameter %s %s.%s", key, strings.ToLower(p.In), p.Name))
+		}
+		for _, ref := range resolvedRefs(op) {
+			extractedRefs = append(extractedRefs, fmt.Sprintf("ref %s %s", key, ref))
+		}
+	}
+
+	c.Operations = c.score(expectedOps, extractedOps)
+	c.Parameters = c.score(expectedParams, extractedParams)
+	c.Refs = c.score(expectedRefs, extractedRefs)
+	c.Overall = newMetric(
+		c.Operations.Expected+c.Parameters.Expected+c.Refs.Expected,
+		c.Operations.Extracted+c.Parameters.Extracted+c.Refs.Extracted,
+		c.Operations.Matched+c.Parameters.Matched+c.Refs.Matched,
+	)
+	return c
+}
+
+// score matches expected against extracted facts, recording the misses
+func (c *Comparison) score(expected, extracted []string) Metric {
+	expected = unique(expected)
+	extracted = unique(extracted)
+	matched := 0
+	for _, fact := range expected {
+		if slices.Contains(extracted, fact) {
+			matched++
+		} else {
+			c.Missing = append(c.Missing, fact)
+		}
+	}
+	for _, fact := range extracted {
+		if !slices.Contains(expected, fact) {
+			c.Unexpected = append(c.Unexpected, fact)
+		}
+	}
+	return newMetric(len(expected), len(extracted), matched)
+}
+
+// newMetric computes precision and recall; with nothing to find or nothing
+// found they are 1, as nothing was missed or wrongly extracted
+func newMetric(expected, extracted, matched int) Metric {
+	m := Metric{Expected: expected, Extracted: extracted, Matched: matched, Precision: 1, Recall: 1}
+	if extracted > 0 {
+		m.Precision = float64(matched) / float64(extracted)
+	}
+	if expected > 0 {
+		m.Recall = float64(matched) / float64(expected)
+	}
+	return m
+}
+
+// resolvedRefs returns the local refs of an operation's parameter, request
+// and response schemas whose target was inlined by the parser
+func resolvedRefs(op *glens.Endpoint) []string {
+	var refs []string
+	var walk func(s *glens.Schema)
+	walk = func(s *glens.Schema) {
+		if s.Ref != "" && resolved(s) {
+			refs = append(refs, s.Ref)
+		}
+		for _, name := range sortedKeys(s.Properties) {
+			prop := s.Properties[name]
+			walk(&prop)
+		}
+		if s.Items != nil {
+			walk(s.Items)
+		}
+		for _, group := range [][]glens.Schema{s.PrefixItems, s.OneOf, s.AnyOf, s.AllOf} {
+			for i := range group {
+				walk(&group[i])
+			}
+		}
+	}
+
+	for i := range op.Parameters {
+		walk(&op.Parameters[i].Schema)
+	}
+	if op.RequestBody != nil {
+		for _, mediaType := range sortedKeys(op.RequestBody.Content) {
+			schema := op.RequestBody.Content[mediaType].Schema
+			walk(&schema)
+		}
+	}
+	for _, code := range sortedKeys(op.Responses) {
+		content := op.Responses[code].Content
+		for _, mediaType := range sortedKeys(content) {
+			schema := content[mediaType].Schema
+			walk(&schema)
+		}
+	}
+	return unique(refs)
+}
+
+// resolved reports whether a schema with a $ref carries its target's
+// content rather than the bare reference
+func resolved(s *glens.Schema) bool {
+	return s.Type != "" || len(s.Properties) > 0 || s.Items != nil || len(s.Enum) > 0 ||
+		len(s.OneOf) > 0 || len(s.AnyOf) > 0 || len(s.AllOf) > 0
+}
+
+func opKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func unique(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
@@ -1,7 +1,8 @@
-// Package report builds the accuracy markdown report.
+// Package report builds the accuracy report, as markdown or CI-friendly JSON.
 package report
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,89 @@ import (
 	"glens/tools/accuracy/internal/analyze"
 )
 
+// Thresholds are the lowest acceptable extraction precision and recall of
+// every spec compared against a fixture, from 0 to 1; 0 disables a check.
+type Thresholds struct {
+	MinPrecision float64 `json:"min_precision"`
+	MinRecall    float64 `json:"min_recall"`
+}
+
+// Regressions returns why results fall short: specs that failed to parse,
+// specs with another endpoint count than their fixture expects, and specs
+// whose overall precision or recall is below the thresholds.
+func Regressions(results []analyze.Result, thresholds Thresholds) []string {
+	var regressions []string
+	for _, r := range results {
+		if r.Err != nil {
+			regressions = append(regressions, fmt.Sprintf("%s: %v", r.Name, r.Err))
+			continue
+		}
+		c := r.Comparison
+		if c == nil {
+			continue
+		}
+		if c.ExpectedEndpoints > 0 && c.ExpectedEndpoints != r.Endpoints {
+			regressions = append(regressions, fmt.Sprintf("%s: %d endpoints, expected %d", r.Name, r.Endpoints, c.ExpectedEndpoints))
+		}
+		if c.Overall.Precision < thresholds.MinPrecision {
+			regressions = append(regressions, fmt.Sprintf("%s: precision %.3f below %.3f", r.Name, c.Overall.Precision, thresholds.MinPrecision))
+		}
+		if c.Overall.Recall < thresholds.MinRecall {
+			regressions = append(regressions, fmt.Sprintf("%s: recall %.3f below %.3f", r.Name, c.Overall.Recall, thresholds.MinRecall))
+		}
+	}
+	return regressions
+}
+
+// jsonReport is the JSON form of the report
+type jsonReport struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Thresholds  Thresholds   `json:"thresholds"`
+	Passed      bool         `json:"passed"`
+	Regressions []string     `json:"regressions,omitempty"`
+	Specs       []jsonResult `json:"specs"`
+}
+
+// jsonResult is the JSON form of one spec's result
+type jsonResult struct {
+	Name       string              `json:"name"`
+	Spec       string              `json:"spec"`
+	Title      string              `json:"title,omitempty"`
+	Endpoints  int                 `json:"endpoints"`
+	DurationMS int64               `json:"duration_ms"`
+	Error      string              `json:"error,omitempty"`
+	Fixture    string              `json:"fixture,omitempty"`
+	Comparison *analyze.Comparison `json:"comparison,omitempty"`
+}
+
+// JSON builds the report as JSON, with the regressions against thresholds.
+func JSON(results []analyze.Result, thresholds Thresholds) ([]byte, error) {
+	regressions := Regressions(results, thresholds)
+	out := jsonReport{
+		GeneratedAt: time.Now().UTC(),
+		Thresholds:  thresholds,
+		Passed:      len(regressions) == 0,
+		Regressions: regressions,
+		Specs:       make([]jsonResult, 0, len(results)),
+	}
+	for _, r := range results {
+		spec := jsonResult{
+			Name:       r.Name,
+			Spec:       r.SpecPath,
+			Title:      r.Title,
+			Endpoints:  r.Endpoints,
+			DurationMS: r.Elapsed.Milliseconds(),
+			Fixture:    r.Fixture,
+			Comparison: r.Comparison,
+		}
+		if r.Err != nil {
+			spec.Error = r.Err.Error()
+		}
+		out.Specs = append(out.Specs, spec)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
 // Build generates a markdown accuracy report from the given results.
 func Build(results []analyze.Result) string {
 	var sb strings.Builder
@@ -20,11 +104,19 @@ func Build(results []analyze.Result) string {
 	total := len(results)
 	passed := 0
 	totalEndpoints := 0
+	compared := 0
+	var matched, expected, extracted int
 	for _, r := range results {
 		if r.Err == nil {
 			passed++
 			totalEndpoints += r.Endpoints
 		}
+		if c := r.Comparison; c != nil {
+			compared++
+			matched += c.Overall.Matched
+			expected += c.Overall.Expected
+			extracted += c.Overall.Extracted
+		}
 	}
 
 	sb.WriteString("## Summary\n\n")
@@ -37,6 +129,15 @@ func Build(results []analyze.Result) string {
 	if total > 0 {
 		sb.WriteString(fmt.Sprintf("| Success Rate | %d%% |\n", passed*100/total))
 	}
+	if compared > 0 {
+		sb.WriteString(fmt.Sprintf("| Specs With Fixtures | %d |\n", compared))
+		if extracted > 0 {
+			sb.WriteString(fmt.Sprintf("| Precision | %.1f%% |\n", float64(matched)*100/float64(extracted)))
+		}
+		if expected > 0 {
+			sb.WriteString(fmt.Sprintf("| Recall | %.1f%% |\n", float64(matched)*100/float64(expected)))
+		}
+	}
 	sb.WriteString("\n")
 
 	sb.WriteString("## Results\n\n")
@@ -53,8 +154,56 @@ func Build(results []analyze.Result) string {
 				sb.WriteString(fmt.Sprintf("**Title:** %s\n\n", r.Title))
 			}
 			sb.WriteString(fmt.Sprintf("**Endpoints Found:** %d\n\n", r.Endpoints))
+			if r.Comparison != nil {
+				writeComparison(&sb, r)
+			}
 		}
 		sb.WriteString("---\n\n")
 	}
 	return sb.String()
 }
+
+// writeComparison writes a spec's extraction accuracy against its fixture
+func writeComparison(sb *strings.Builder, r analyze.Result) {
+	c := r.Comparison
+	sb.WriteString(fmt.Sprintf("**Fixture:** `%s`\n\n", r.Fixture))
+	if c.ExpectedEndpoints > 0 && c.ExpectedEndpoints != r.Endpoints {
+		sb.WriteString(fmt.Sprintf("**Endpoint Count:** ⚠️ %d, expected %d\n\n", r.Endpoints, c.ExpectedEndpoints))
+	}
+	if !c.TitleMatches {
+		sb.WriteString("**Title:** ⚠️ differs from the fixture\n\n")
+	}
+
+	sb.WriteString("| Facts | Expected | Extracted | Matched | Precision | Recall |\n")
+	sb.WriteString("|-------|----------|-----------|---------|-----------|--------|\n")
+	for _, row := range []struct {
+		name   string
+		metric analyze.Metric
+	}{
+		{"Operations", c.Operations},
+		{"Parameters", c.Parameters},
+		{"Schema refs", c.Refs},
+		{"**Overall**", c.Overall},
+	} {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %.1f%% | %.1f%% |\n", row.name,
+			row.metric.Expected, row.metric.Extracted, row.metric.Matched, row.metric.Precision*100, row.metric.Recall*100))
+	}
+	sb.WriteString("\n")
+
+	for _, list := range []struct {
+		title string
+		facts []string
+	}{
+		{"Missing", c.Missing},
+		{"Unexpected", c.Unexpected},
+	} {
+		if len(list.facts) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("**%s:**\n\n", list.title))
+		for _, fact := range list.facts {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", fact))
+		}
+		sb.WriteString("\n")
+	}
+}
@@ -15,19 +15,26 @@ import (
 var version = "0.1.0"
 
 func main() {
-	var outputFile string
+	var outputFile, format, fixturesDir string
+	var thresholds report.Thresholds
 	var showVersion bool
 
-	flag.StringVar(&outputFile, "output", "", "write markdown report to file (default: stdout)")
+	flag.StringVar(&outputFile, "output", "", "write report to file (default: stdout)")
+	flag.StringVar(&format, "format", "markdown", "report format: markdown or json")
+	flag.StringVar(&fixturesDir, "fixtures", "", "directory of expected fixtures named <spec>.yaml (default: <spec>.expected.yaml next to each spec)")
+	flag.Float64Var(&thresholds.MinPrecision, "min-precision", 0, "fail when a spec's extraction precision is below this (0-1)")
+	flag.Float64Var(&thresholds.MinRecall, "min-recall", 0, "fail when a spec's extraction recall is below this (0-1)")
 	flag.BoolVar(&showVersion, "version", false, "print version and exit")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: accuracy [flags] <spec> [spec...]\n\n")
-		fmt.Fprintf(os.Stderr, "Evaluates OpenAPI spec parsing accuracy and generates a report.\n\n")
+		fmt.Fprintf(os.Stderr, "Parses OpenAPI specs with the glens parser, compares them against\n")
+		fmt.Fprintf(os.Stderr, "expected fixtures and generates an accuracy report.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  accuracy test_specs/sample_api.json\n")
 		fmt.Fprintf(os.Stderr, "  accuracy --output report.md spec1.json spec2.json\n")
+		fmt.Fprintf(os.Stderr, "  accuracy --format json --min-precision 0.95 --min-recall 0.95 test_specs/*.json\n")
 	}
 	flag.Parse()
 
@@ -42,22 +49,38 @@ func main() {
 		os.Exit(1)
 	}
 
-	results := analyze.Specs(specs)
-	output := report.Build(results)
+	results := analyze.Specs(specs, fixturesDir)
+
+	var output string
+	switch format {
+	case "markdown":
+		output = report.Build(results)
+	case "json":
+		data, err := report.JSON(results, thresholds)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building report: %v\n", err)
+			os.Exit(1)
+		}
+		output = string(data) + "\n"
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q: use markdown or json\n", format)
+		os.Exit(1)
+	}
 
 	if outputFile != "" {
 		if err := os.WriteFile(outputFile, []byte(output), 0o600); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Report written to %s\n", outputFile)
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", outputFile)
 	} else {
 		fmt.Print(output)
 	}
 
-	for _, r := range results {
-		if r.Err != nil {
-			os.Exit(1)
+	if regressions := report.Regressions(results, thresholds); len(regressions) > 0 {
+		for _, regression := range regressions {
+			fmt.Fprintf(os.Stderr, "FAIL %s\n", regression)
 		}
+		os.Exit(1)
 	}
 }
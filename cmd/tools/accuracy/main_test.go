@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -32,3 +33,71 @@ func TestReport_withResults(t *testing.T) {
 		t.Error("report missing failure marker")
 	}
 }
+
+func comparedResults() []analyze.Result {
+	return []analyze.Result{{
+		Name:      "sample_api",
+		SpecPath:  "sample_api.json",
+		Endpoints: 2,
+		Fixture:   "sample_api.expected.yaml",
+		Comparison: &analyze.Comparison{
+			TitleMatches:      true,
+			ExpectedEndpoints: 3,
+			Operations:        analyze.Metric{Expected: 3, Extracted: 2, Matched: 2, Precision: 1, Recall: 2.0 / 3},
+			Parameters:        analyze.Metric{Expected: 1, Extracted: 1, Matched: 1, Precision: 1, Recall: 1},
+			Refs:              analyze.Metric{Precision: 1, Recall: 1},
+			Overall:           analyze.Metric{Expected: 4, Extracted: 3, Matched: 3, Precision: 1, Recall: 0.75},
+			Missing:           []string{"operation POST /posts"},
+		},
+	}}
+}
+
+func TestReport_comparison(t *testing.T) {
+	out := report.Build(comparedResults())
+	for _, want := range []string{"| Recall | 75.0% |", "2, expected 3", "| Operations | 3 | 2 | 2 | 100.0% | 66.7% |", "- `operation POST /posts`"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	results := comparedResults()
+
+	got := report.Regressions(results, report.Thresholds{MinPrecision: 0.9, MinRecall: 0.9})
+	want := []string{"sample_api: 2 endpoints, expected 3", "sample_api: recall 0.750 below 0.900"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("regressions = %q, want %q", got, want)
+	}
+
+	results[0].Endpoints = 3
+	if got := report.Regressions(results, report.Thresholds{MinPrecision: 0.9, MinRecall: 0.7}); len(got) != 0 {
+		t.Errorf("regressions = %q, want none", got)
+	}
+}
+
+func TestReport_JSON(t *testing.T) {
+	data, err := report.JSON(comparedResults(), report.Thresholds{MinRecall: 0.9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out struct {
+		Passed      bool     `json:"passed"`
+		Regressions []string `json:"regressions"`
+		Specs       []struct {
+			Name       string `json:"name"`
+			Comparison struct {
+				Overall analyze.Metric `json:"overall"`
+			} `json:"comparison"`
+		} `json:"specs"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if out.Passed || len(out.Regressions) != 2 {
+		t.Errorf("passed = %v, regressions = %q, want failed with 2", out.Passed, out.Regressions)
+	}
+	if len(out.Specs) != 1 || out.Specs[0].Name != "sample_api" || out.Specs[0].Comparison.Overall.Recall != 0.75 {
+		t.Errorf("specs = %+v", out.Specs)
+	}
+}
@@ -1,3 +1,43 @@
 module glens/tools/demo
 
 go 1.25
+
+require (
+	glens/pkg/apiauth v0.0.0 // indirect
+	glens/pkg/logging v0.0.0
+	glens/tools/glens v0.0.0
+	golang.org/x/term v0.35.0
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/rs/zerolog v1.35.1 // indirect
+	glens/pkg/metrics v0.0.0 // indirect
+	glens/pkg/modelcatalog v0.0.0 // indirect
+	glens/pkg/safety v0.0.0 // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/oauth2 v0.36.0 // indirect
+	golang.org/x/sys v0.36.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	modernc.org/libc v1.66.10 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+	modernc.org/sqlite v1.40.1 // indirect
+)
+
+replace glens/tools/glens => ../../glens
+
+replace glens/pkg/logging => ../../../pkg/logging
+
+replace glens/pkg/metrics => ../../../pkg/metrics
+
+replace glens/pkg/modelcatalog => ../../../pkg/modelcatalog
+
+replace glens/pkg/safety => ../../../pkg/safety
+
+replace glens/pkg/apiauth => ../../../pkg/apiauth
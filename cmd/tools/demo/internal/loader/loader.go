@@ -2,59 +2,17 @@
 package loader
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"strings"
-	"time"
-)
 
-// Spec holds the minimum OpenAPI data needed for a demo.
-type Spec struct {
-	Info struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Version     string `json:"version"`
-	} `json:"info"`
-	Servers []struct {
-		URL string `json:"url"`
-	} `json:"servers"`
-	Paths map[string]map[string]struct {
-		Summary string   `json:"summary"`
-		Tags    []string `json:"tags"`
-	} `json:"paths"`
-}
+	"glens/tools/glens/pkg/glens"
+)
 
-// Load fetches an OpenAPI JSON spec from a file path or HTTP URL.
-func Load(source string) (*Spec, error) {
-	data, err := fetch(source)
+// Load parses an OpenAPI spec from a file path or HTTP URL with the glens
+// parser, the same one glens analyze uses.
+func Load(source string) (*glens.Spec, error) {
+	spec, err := glens.ParseSpec(source)
 	if err != nil {
-		return nil, err
-	}
-
-	var spec Spec
-	if err := json.Unmarshal(data, &spec); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
-	}
-	return &spec, nil
-}
-
-func fetch(source string) ([]byte, error) {
-	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Get(source) //nolint:gosec
-		if err != nil {
-			return nil, fmt.Errorf("HTTP request failed: %w", err)
-		}
-		defer resp.Body.Close() //nolint:errcheck
-
-		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-			bodySnippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-			return nil, fmt.Errorf("HTTP %d %s: %s", resp.StatusCode, resp.Status, strings.TrimSpace(string(bodySnippet)))
-		}
-		return io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
 	}
-	return os.ReadFile(source) //nolint:gosec
+	return spec, nil
 }
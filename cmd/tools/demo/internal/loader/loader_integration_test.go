@@ -41,8 +41,8 @@ func TestLoad_sampleAPI(t *testing.T) {
 	} else if spec.Servers[0].URL != "https://api.example.com/v1" {
 		t.Errorf("server URL = %q, want %q", spec.Servers[0].URL, "https://api.example.com/v1")
 	}
-	if len(spec.Paths) != 3 {
-		t.Errorf("paths count = %d, want 3", len(spec.Paths))
+	if len(spec.Endpoints) != 3 {
+		t.Errorf("endpoints count = %d, want 3", len(spec.Endpoints))
 	}
 }
 
@@ -61,19 +61,19 @@ func TestLoad_expectedPaths(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	wantPaths := map[string]string{
-		"/users":      "get",
-		"/users/{id}": "get",
-		"/posts":      "post",
+	want := map[string]bool{
+		"GET /users":      true,
+		"GET /users/{id}": true,
+		"POST /posts":     true,
 	}
-	for path, method := range wantPaths {
-		methods, ok := spec.Paths[path]
-		if !ok {
-			t.Errorf("missing expected path %q", path)
-			continue
-		}
-		if _, hasMethod := methods[method]; !hasMethod {
-			t.Errorf("path %q missing expected method %q", path, method)
+	for _, ep := range spec.Endpoints {
+		key := ep.Method + " " + ep.Path
+		if !want[key] {
+			t.Errorf("unexpected endpoint %q", key)
 		}
+		delete(want, key)
+	}
+	for key := range want {
+		t.Errorf("missing expected endpoint %q", key)
 	}
 }
@@ -0,0 +1,34 @@
+// Package pipeline runs the glens analysis pipeline offline for the demo tool.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"glens/tools/glens/pkg/glens"
+)
+
+// DefaultModels are the offline models the demo compares; they need no API
+// key or network access.
+var DefaultModels = []string{"enhanced-mock", "mock"}
+
+// Run generates a test per endpoint of the spec at source with each model,
+// without executing the tests, and returns the resulting report.
+func Run(ctx context.Context, source string, models []string) (*glens.Report, error) {
+	if len(models) == 0 {
+		models = DefaultModels
+	}
+	analyzer, err := glens.NewAnalyzer(glens.Options{
+		Spec:   source,
+		Models: slices.Clone(models),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the pipeline: %w", err)
+	}
+	report, err := analyzer.Run(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline failed: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,145 @@
+// Package preview writes and opens the HTML preview of a demo walkthrough.
+package preview
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"glens/tools/glens/pkg/glens"
+)
+
+// page is the preview: the spec, the model comparison and every test the
+// pipeline generated, one collapsible block per endpoint and model
+var page = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Glens demo — {{.Spec.Info.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 40px; line-height: 1.5; color: #1e293b; }
+table { border-collapse: collapse; margin: 16px 0; }
+th, td { border: 1px solid #cbd5e1; padding: 8px 12px; text-align: left; }
+th { background: #f1f5f9; }
+code, pre { font-family: ui-monospace, monospace; }
+pre { background: #0f172a; color: #e2e8f0; padding: 16px; overflow-x: auto; border-radius: 6px; }
+details { margin: 8px 0; }
+summary { cursor: pointer; }
+.best { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>🚀 Glens demo — {{.Spec.Info.Title}} {{.Spec.Info.Version}}</h1>
+{{with .Spec.Info.Description}}<p>{{.}}</p>{{end}}
+<h2>Endpoints ({{len .Spec.Endpoints}})</h2>
+<table>
+<tr><th>Method</th><th>Path</th><th>Summary</th></tr>
+{{range .Spec.Endpoints}}<tr><td>{{.Method}}</td><td><code>{{.Path}}</code></td><td>{{.Summary}}</td></tr>
+{{end}}</table>
+<h2>Model Comparison</h2>
+<table>
+<tr><th>Model</th><th>Tests</th><th>Quality</th><th>Coverage</th><th>Security</th></tr>
+{{range .Models}}<tr{{if .Best}} class="best"{{end}}><td>{{.Name}}{{if .Best}} ★{{end}}</td><td>{{.Tests}}</td><td>{{printf "%.1f" .Quality}}</td><td>{{printf "%.1f%%" .Coverage}}</td><td>{{printf "%.1f" .Security}}</td></tr>
+{{end}}</table>
+<h2>Generated Tests</h2>
+{{range .Tests}}<details{{if .Open}} open{{end}}>
+<summary><code>{{.Method}} {{.Path}}</code> — {{.Model}} (quality {{printf "%.1f" .Quality}})</summary>
+<pre><code>{{.Code}}</code></pre>
+</details>
+{{end}}<p><em>Generated offline by glens-demo</em></p>
+</body>
+</html>
+`))
+
+// model is a row of the model comparison
+type model struct {
+	Name                        string
+	Tests                       int
+	Quality, Coverage, Security float64
+	Best                        bool
+}
+
+// test is a generated test
+type test struct {
+	Method, Path, Model, Code string
+	Quality                   float64
+	Open                      bool
+}
+
+// DefaultPath is where the preview is written when no path is given.
+func DefaultPath() string {
+	return filepath.Join(os.TempDir(), "glens-demo.html")
+}
+
+// Write renders the walkthrough of report as an HTML page at path.
+func Write(report *glens.Report, path string) error {
+	comparison := report.ModelComparison
+	data := struct {
+		Spec   *glens.Spec
+		Models []model
+		Tests  []test
+	}{Spec: &report.Specification}
+	for _, m := range comparison.Models {
+		data.Models = append(data.Models, model{
+			Name:     m.ModelName,
+			Tests:    m.TestsGenerated,
+			Quality:  m.AvgQualityScore,
+			Coverage: m.AvgCoverageScore,
+			Security: comparison.ComparisonMatrix.SecurityComparison[m.ModelName],
+			Best:     m.ModelName == comparison.BestPerformer,
+		})
+	}
+	for _, result := range report.EndpointResults {
+		models := make([]string, 0, len(result.Tests))
+		for name := range result.Tests {
+			models = append(models, name)
+		}
+		sort.Strings(models)
+		for _, name := range models {
+			data.Tests = append(data.Tests, test{
+				Method:  result.Endpoint.Method,
+				Path:    result.Endpoint.Path,
+				Model:   name,
+				Code:    result.Tests[name].TestCode,
+				Quality: result.Tests[name].QualityScore,
+				Open:    len(data.Tests) == 0,
+			})
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create preview directory: %w", err)
+	}
+	f, err := os.Create(path) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	if err := page.Execute(f, data); err != nil {
+		return fmt.Errorf("failed to render preview: %w", err)
+	}
+	return f.Close()
+}
+
+// Open opens path with the platform's default browser, without waiting
+// for it to exit.
+func Open(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open preview: %w", err)
+	}
+	return nil
+}
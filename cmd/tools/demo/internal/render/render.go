@@ -3,11 +3,15 @@ package render
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
-	"glens/tools/demo/internal/loader"
+	"glens/tools/glens/pkg/glens"
 )
 
+// maxSampleLines bounds how much of the sample test is printed
+const maxSampleLines = 40
+
 // Banner prints the glens demo banner.
 func Banner() {
 	fmt.Println(`
@@ -20,16 +24,12 @@ func Banner() {
 }
 
 // SpecInfo prints API metadata from a parsed spec.
-func SpecInfo(spec *loader.Spec) {
+func SpecInfo(spec *glens.Spec) {
 	fmt.Println("─── API Information ──────────────────────────────────────────")
 	fmt.Printf("  Title:   %s\n", spec.Info.Title)
 	fmt.Printf("  Version: %s\n", spec.Info.Version)
 	if spec.Info.Description != "" {
-		desc := spec.Info.Description
-		if len(desc) > 80 {
-			desc = desc[:77] + "..."
-		}
-		fmt.Printf("  Desc:    %s\n", desc)
+		fmt.Printf("  Desc:    %s\n", truncate(spec.Info.Description, 80))
 	}
 	if len(spec.Servers) > 0 {
 		fmt.Printf("  Server:  %s\n", spec.Servers[0].URL)
@@ -37,59 +37,93 @@ func SpecInfo(spec *loader.Spec) {
 	fmt.Println()
 }
 
-// Endpoints prints all endpoints found in the spec.
-func Endpoints(spec *loader.Spec) {
-	count := len(spec.Paths)
-	fmt.Printf("─── Endpoints (%d paths) ─────────────────────────────────────\n", count)
-	i := 1
-	for path, methods := range spec.Paths {
-		for method, op := range methods {
-			tags := ""
-			if len(op.Tags) > 0 {
-				tags = fmt.Sprintf(" [%s]", strings.Join(op.Tags, ", "))
-			}
-			summary := op.Summary
-			if len(summary) > 40 {
-				summary = summary[:37] + "..."
-			}
-			fmt.Printf("  %2d. %-6s %-35s %s%s\n", i, strings.ToUpper(method), path, summary, tags)
-			i++
+// Endpoints prints all endpoints the parser extracted from the spec.
+func Endpoints(spec *glens.Spec) {
+	fmt.Printf("─── Endpoints (%d) ───────────────────────────────────────────\n", len(spec.Endpoints))
+	for i, ep := range spec.Endpoints {
+		tags := ""
+		if len(ep.Tags) > 0 {
+			tags = fmt.Sprintf(" [%s]", strings.Join(ep.Tags, ", "))
+		}
+		if ep.Kind != "" {
+			tags += fmt.Sprintf(" (%s)", ep.Kind)
 		}
+		fmt.Printf("  %2d. %-6s %-35s %s%s\n", i+1, ep.Method, ep.Path, truncate(ep.Summary, 40), tags)
 	}
 	fmt.Println()
 }
 
-// ModelComparison prints a table of available AI models.
-func ModelComparison() {
-	fmt.Println("─── Available AI Models ──────────────────────────────────────")
+// ModelComparison prints how the models of a report compare on the tests
+// they generated.
+func ModelComparison(report *glens.Report) {
+	comparison := report.ModelComparison
+	fmt.Println("─── Model Comparison ─────────────────────────────────────────")
 	fmt.Println()
-	fmt.Println("  Provider    Model                    Cost/1M tokens  Speed")
-	fmt.Println("  ─────────── ──────────────────────── ──────────────  ─────")
-	fmt.Println("  OpenAI      gpt-4o                   $5.00           Fast")
-	fmt.Println("  OpenAI      gpt-4o-mini              $0.15           Fast")
-	fmt.Println("  Anthropic   claude-3.5-sonnet         $3.00           Fast")
-	fmt.Println("  Google      gemini-2.0-flash          $0.00 (free)    Very Fast")
-	fmt.Println("  Google      gemini-2.0-pro            $1.25           Fast")
-	fmt.Println("  Local       enhanced-mock             $0.00 (free)    Very Fast")
-	fmt.Println("  Local       ollama:*                  $0.00 (free)    Depends")
+	if len(comparison.Models) == 0 {
+		fmt.Println("  No tests were generated.")
+		fmt.Println()
+		return
+	}
+	fmt.Println("  Model                    Tests  Quality  Coverage  Security")
+	fmt.Println("  ──────────────────────── ─────  ───────  ────────  ────────")
+	for _, m := range comparison.Models {
+		name := m.ModelName
+		if name == comparison.BestPerformer {
+			name += " ★"
+		}
+		fmt.Printf("  %-24s %5d  %7.1f  %7.1f%%  %8.1f\n", name, m.TestsGenerated,
+			m.AvgQualityScore, m.AvgCoverageScore, comparison.ComparisonMatrix.SecurityComparison[m.ModelName])
+	}
 	fmt.Println()
+	if comparison.BestPerformer != "" {
+		fmt.Printf("  ★ Best performer: %s\n\n", comparison.BestPerformer)
+	}
 }
 
-// SampleTest prints a sample generated test snippet.
-func SampleTest() {
+// SampleTest prints a test the pipeline generated, preferring the best
+// performer's test of the first endpoint.
+func SampleTest(report *glens.Report) {
 	fmt.Println("─── Sample Generated Test ────────────────────────────────────")
-	fmt.Print(`
-  func TestGETEndpoint(t *testing.T) {
-      client := &http.Client{Timeout: 10 * time.Second}
-      resp, err := client.Get(baseURL + "/endpoint")
-      require.NoError(t, err)
-      defer resp.Body.Close()
-      assert.Equal(t, http.StatusOK, resp.StatusCode)
-  }
-`)
+	fmt.Println()
+	for _, result := range report.EndpointResults {
+		models := make([]string, 0, len(result.Tests))
+		for model := range result.Tests {
+			models = append(models, model)
+		}
+		if len(models) == 0 {
+			continue
+		}
+		sort.Strings(models)
+		model := models[0]
+		if _, ok := result.Tests[report.ModelComparison.BestPerformer]; ok {
+			model = report.ModelComparison.BestPerformer
+		}
+		test := result.Tests[model]
+		fmt.Printf("  %s %s — generated by %s (quality %.1f)\n\n", result.Endpoint.Method, result.Endpoint.Path, model, test.QualityScore)
+		lines := strings.Split(strings.TrimRight(test.TestCode, "\n"), "\n")
+		for i, line := range lines {
+			if i == maxSampleLines {
+				fmt.Printf("  ... (%d more lines)\n", len(lines)-maxSampleLines)
+				break
+			}
+			if line == "" {
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Println()
+		return
+	}
+	fmt.Println("  No tests were generated.")
+	fmt.Println()
+}
+
+// QuickStart prints how to run glens on a spec.
+func QuickStart() {
 	fmt.Println("─── Quick Start ──────────────────────────────────────────────")
 	fmt.Println()
-	fmt.Println("  # Offline demo (no API key needed):")
+	fmt.Println("  # Offline (no API key needed):")
 	fmt.Println("  glens analyze <spec> --ai-models=enhanced-mock")
 	fmt.Println()
 	fmt.Println("  # With OpenAI:")
@@ -97,3 +131,10 @@ func SampleTest() {
 	fmt.Println("  glens analyze <spec> --ai-models=gpt-4o")
 	fmt.Println()
 }
+
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width-3] + "..."
+	}
+	return s
+}
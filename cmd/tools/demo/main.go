@@ -1,32 +1,62 @@
-// Command demo demonstrates glens OpenAPI parsing capabilities.
+// Command demo walks through glens on an OpenAPI spec offline: it parses the
+// spec, generates tests with the offline models and compares them.
 // It is a cross-platform replacement for scripts/demo_modern.sh.
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
+	"golang.org/x/term"
+
+	"glens/pkg/logging"
 	"glens/tools/demo/internal/loader"
+	"glens/tools/demo/internal/pipeline"
+	"glens/tools/demo/internal/preview"
 	"glens/tools/demo/internal/render"
 )
 
 // version is set at build time via -ldflags="-X main.version=<tag>".
 var version = "0.1.0"
 
+// demoOptions configure a walkthrough
+type demoOptions struct {
+	spec   string
+	models []string
+	// html is where the HTML preview is written, empty for none
+	html string
+	// open opens the preview in the browser
+	open bool
+	// pause waits for Enter from in between steps
+	pause bool
+	in    io.Reader
+}
+
 func main() {
-	var specPath string
-	var showVersion bool
+	var specPath, models string
+	var opts demoOptions
+	var noPause, showVersion bool
 
 	flag.StringVar(&specPath, "spec", "", "path to OpenAPI spec file or URL")
+	flag.StringVar(&models, "models", strings.Join(pipeline.DefaultModels, ","), "comma-separated models to compare")
+	flag.StringVar(&opts.html, "html", "", "write an HTML preview of the generated report to this file")
+	flag.BoolVar(&opts.open, "open", false, "write the HTML preview and open it in the browser")
+	flag.BoolVar(&noPause, "no-pause", false, "do not wait for Enter between steps")
 	flag.BoolVar(&showVersion, "version", false, "print version and exit")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: demo [flags] [spec-path]\n\n")
-		fmt.Fprintf(os.Stderr, "Demonstrates glens OpenAPI parsing capabilities.\n\n")
+		fmt.Fprintf(os.Stderr, "Walks through glens offline: parses the spec, generates a test per\n")
+		fmt.Fprintf(os.Stderr, "endpoint with the offline models and compares them.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  demo --spec test_specs/sample_api.json\n")
+		fmt.Fprintf(os.Stderr, "  demo --open test_specs/petstore_crud.json\n")
 		fmt.Fprintf(os.Stderr, "  demo https://petstore3.swagger.io/api/v3/openapi.json\n")
 	}
 	flag.Parse()
@@ -45,25 +75,84 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := runDemo(specPath); err != nil {
+	// Keep the pipeline's progress logs out of the walkthrough
+	logging.Setup(logging.Config{Level: logging.LevelWarn, Format: logging.FormatConsole})
+
+	opts.spec = specPath
+	opts.models = splitList(models)
+	opts.pause = !noPause && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+	opts.in = os.Stdin
+	if opts.open && opts.html == "" {
+		opts.html = preview.DefaultPath()
+	}
+
+	if err := runDemo(context.Background(), opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runDemo(specPath string) error {
+func runDemo(ctx context.Context, opts demoOptions) error {
 	render.Banner()
-	fmt.Printf("Parsing OpenAPI spec: %s\n\n", specPath)
+	fmt.Printf("Parsing OpenAPI spec: %s\n\n", opts.spec)
 
-	spec, err := loader.Load(specPath)
+	spec, err := loader.Load(opts.spec)
 	if err != nil {
 		return fmt.Errorf("failed to load spec: %w", err)
 	}
 
 	render.SpecInfo(spec)
 	render.Endpoints(spec)
-	render.ModelComparison()
-	render.SampleTest()
+	opts.wait()
+
+	models := opts.models
+	if len(models) == 0 {
+		models = pipeline.DefaultModels
+	}
+	fmt.Printf("Generating tests with %s...\n\n", strings.Join(models, ", "))
+	report, err := pipeline.Run(ctx, opts.spec, models)
+	if err != nil {
+		return err
+	}
 
+	render.ModelComparison(report)
+	opts.wait()
+	render.SampleTest(report)
+	opts.wait()
+	render.QuickStart()
+
+	if opts.html != "" {
+		if err := preview.Write(report, opts.html); err != nil {
+			return err
+		}
+		fmt.Printf("HTML preview written to %s\n", opts.html)
+		if opts.open {
+			if err := preview.Open(opts.html); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
+
+// wait pauses the walkthrough until Enter is pressed
+func (o demoOptions) wait() {
+	if !o.pause {
+		return
+	}
+	fmt.Print("  Press Enter to continue...")
+	_, _ = bufio.NewReader(o.in).ReadString('\n')
+	fmt.Println()
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
@@ -1,26 +1,91 @@
 package main
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"glens/tools/demo/internal/pipeline"
 	"glens/tools/demo/internal/render"
 )
 
+// sampleSpecPath returns the absolute path to test_specs/sample_api.json
+func sampleSpecPath(t *testing.T) string {
+	t.Helper()
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("runtime.Caller failed")
+	}
+	// file is at: cmd/tools/demo/main_test.go; repo root is 3 directories up
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "test_specs", "sample_api.json")
+}
+
 func TestRunDemo_missingFile(t *testing.T) {
-	err := runDemo("/nonexistent/spec.json")
+	err := runDemo(context.Background(), demoOptions{spec: "/nonexistent/spec.json"})
 	if err == nil {
 		t.Error("expected error for missing file, got nil")
 	}
 }
 
-func TestRenderBanner(_ *testing.T) {
-	render.Banner() // must not panic
+func TestRunDemo_preview(t *testing.T) {
+	html := filepath.Join(t.TempDir(), "preview", "demo.html")
+	err := runDemo(context.Background(), demoOptions{spec: sampleSpecPath(t), html: html})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(html) //nolint:gosec
+	if err != nil {
+		t.Fatalf("preview not written: %v", err)
+	}
+	page := string(data)
+	for _, want := range []string{"Sample API", "enhanced-mock ★", "GET /users/{id}", "func TestGETUsers"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("preview missing %q", want)
+		}
+	}
 }
 
-func TestRenderModelComparison(_ *testing.T) {
-	render.ModelComparison() // must not panic
+func TestPipeline_sampleAPI(t *testing.T) {
+	report, err := pipeline.Run(context.Background(), sampleSpecPath(t), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.EndpointResults) != 3 {
+		t.Fatalf("endpoint results = %d, want 3", len(report.EndpointResults))
+	}
+	for _, result := range report.EndpointResults {
+		for _, model := range pipeline.DefaultModels {
+			if !strings.Contains(result.Tests[model].TestCode, "func Test") {
+				t.Errorf("%s %s: %s generated no test", result.Endpoint.Method, result.Endpoint.Path, model)
+			}
+		}
+	}
+	if len(report.ModelComparison.Models) != len(pipeline.DefaultModels) {
+		t.Errorf("compared models = %d, want %d", len(report.ModelComparison.Models), len(pipeline.DefaultModels))
+	}
+}
+
+func TestPipeline_unknownModel(t *testing.T) {
+	if _, err := pipeline.Run(context.Background(), sampleSpecPath(t), []string{"no-such-model"}); err == nil {
+		t.Error("expected error for unknown model, got nil")
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" enhanced-mock, ,mock ")
+	if strings.Join(got, "|") != "enhanced-mock|mock" {
+		t.Errorf("splitList = %q", got)
+	}
+}
+
+func TestRenderBanner(_ *testing.T) {
+	render.Banner() // must not panic
 }
 
-func TestRenderSampleTest(_ *testing.T) {
-	render.SampleTest() // must not panic
+func TestRenderQuickStart(_ *testing.T) {
+	render.QuickStart() // must not panic
 }
@@ -17,6 +17,11 @@ resource "google_cloud_run_v2_service" "api" {
         value = var.log_level
       }
 
+      env {
+        name  = "AI_MODELS"
+        value = var.ai_models
+      }
+
       ports {
         container_port = 8080
       }
@@ -13,6 +13,12 @@ variable "log_level" {
   type        = string
 }
 
+variable "ai_models" {
+  description = "Comma-separated AI models served to analysis runs"
+  type        = string
+  default     = "gpt4"
+}
+
 variable "min_instances" {
   description = "Minimum number of Cloud Run instances"
   type        = number
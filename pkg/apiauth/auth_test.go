@@ -0,0 +1,98 @@
+package apiauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		keys       []string
+		method     string
+		path       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"no keys configured allows all", nil, http.MethodGet, "/api/v1/models", nil, http.StatusOK},
+		{"missing key", []string{"secret"}, http.MethodGet, "/api/v1/models", nil, http.StatusUnauthorized},
+		{"wrong key", []string{"secret"}, http.MethodGet, "/api/v1/models", map[string]string{"X-API-Key": "nope"}, http.StatusUnauthorized},
+		{"valid X-API-Key", []string{"other", "secret"}, http.MethodGet, "/api/v1/models", map[string]string{"X-API-Key": "secret"}, http.StatusOK},
+		{"valid bearer token", []string{"secret"}, http.MethodGet, "/api/v1/models", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"basic auth is not a key", []string{"secret"}, http.MethodGet, "/api/v1/models", map[string]string{"Authorization": "Basic secret"}, http.StatusUnauthorized},
+		{"exempt path", []string{"secret"}, http.MethodGet, "/healthz", nil, http.StatusOK},
+		{"preflight", []string{"secret"}, http.MethodOptions, "/api/v1/models", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			APIKeyAuth(tt.keys, "/healthz")(inner).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+				var p ProblemDetail
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+				assert.Equal(t, ProblemTypeUnauthorized, p.Type)
+				assert.Equal(t, http.StatusUnauthorized, p.Status)
+				assert.Equal(t, tt.path, p.Instance)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_SetsKeyID(t *testing.T) {
+	var ids []string
+	inner := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ids = append(ids, KeyID(r.Context()))
+	})
+	auth := APIKeyAuth([]string{"first", "second"})(inner)
+
+	for _, key := range []string{"first", "second", "first"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/models", nil)
+		req.Header.Set(APIKeyHeader, key)
+		auth.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, ids, 3)
+	assert.NotEmpty(t, ids[0])
+	assert.NotEqual(t, ids[0], ids[1], "keys get distinct ids")
+	assert.Equal(t, ids[0], ids[2], "ids are stable")
+	assert.NotContains(t, ids[0], "first", "ids do not leak the key")
+}
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, ParseKeys(" a, ,b ,"))
+	assert.Nil(t, ParseKeys(""))
+}
+
+func TestLoadKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(path, []byte("# team keys\nalpha\n\n  beta  \n"), 0o600))
+
+	keys, err := LoadKeysFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "beta"}, keys)
+
+	_, err = LoadKeysFile(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package apiauth
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem type URI constants for rejected requests.
+const (
+	ProblemTypeUnauthorized = "https://glens.dev/errors/unauthorized"
+	ProblemTypeRateLimited  = "https://glens.dev/errors/rate-limited"
+	ProblemTypeQuota        = "https://glens.dev/errors/quota-exceeded"
+)
+
+// ProblemDetail is an RFC 9457 Problem Details response, the format of the
+// errors of the glens APIs.
+type ProblemDetail struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+// WriteProblem writes an RFC 9457 Problem Details JSON response. The
+// handlers of the glens APIs answer every error with it, as the middleware
+// does refusals.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, problemType, title, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+
+	p := ProblemDetail{
+		Type:     problemType,
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	}
+
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		http.Error(w, "failed to encode problem response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,169 @@
+package apiauth
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultQuotaWindow is the period request quotas are counted over.
+const DefaultQuotaWindow = 24 * time.Hour
+
+// maxIdleClients bounds the limiter state before idle clients are pruned.
+const maxIdleClients = 10000
+
+// RateLimitConfig configures per-client rate limiting and quotas. Clients
+// are identified by API key, or by remote address when auth is disabled.
+type RateLimitConfig struct {
+	// RequestsPerSecond refills each client's token bucket; 0 disables
+	// rate limiting
+	RequestsPerSecond float64
+	// Burst is the bucket size (default: RequestsPerSecond rounded up)
+	Burst int
+	// Quota caps the requests per QuotaWindow; 0 disables quotas
+	Quota       int
+	QuotaWindow time.Duration
+}
+
+// Limiter tracks a token bucket and a quota counter per client.
+type Limiter struct {
+	cfg     RateLimitConfig
+	now     func() time.Time
+	mu      sync.Mutex
+	clients map[string]*clientState
+}
+
+type clientState struct {
+	tokens      float64
+	lastRefill  time.Time
+	used        int
+	windowStart time.Time
+}
+
+// decision is the outcome of one limiter check.
+type decision struct {
+	allowed    bool
+	problem    string
+	retryAfter time.Duration
+	remaining  int
+}
+
+// NewLimiter creates a limiter, filling in default burst and quota window.
+func NewLimiter(cfg RateLimitConfig) *Limiter {
+	if cfg.Burst <= 0 {
+		cfg.Burst = int(math.Ceil(cfg.RequestsPerSecond))
+	}
+	if cfg.QuotaWindow <= 0 {
+		cfg.QuotaWindow = DefaultQuotaWindow
+	}
+	return &Limiter{cfg: cfg, now: time.Now, clients: make(map[string]*clientState)}
+}
+
+// Enabled reports whether the limiter enforces a rate limit or quota.
+func (l *Limiter) Enabled() bool {
+	return l.cfg.RequestsPerSecond > 0 || l.cfg.Quota > 0
+}
+
+// allow consumes one request for the client if both its bucket and quota
+// permit it.
+func (l *Limiter) allow(client string) decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	st, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= maxIdleClients {
+			l.prune(now)
+		}
+		st = &clientState{tokens: float64(l.cfg.Burst), lastRefill: now, windowStart: now}
+		l.clients[client] = st
+	}
+
+	if l.cfg.Quota > 0 {
+		if now.Sub(st.windowStart) >= l.cfg.QuotaWindow {
+			st.used, st.windowStart = 0, now
+		}
+		if st.used >= l.cfg.Quota {
+			return decision{problem: ProblemTypeQuota, retryAfter: st.windowStart.Add(l.cfg.QuotaWindow).Sub(now)}
+		}
+	}
+
+	if l.cfg.RequestsPerSecond > 0 {
+		elapsed := now.Sub(st.lastRefill).Seconds()
+		st.tokens = math.Min(float64(l.cfg.Burst), st.tokens+elapsed*l.cfg.RequestsPerSecond)
+		st.lastRefill = now
+		if st.tokens < 1 {
+			wait := (1 - st.tokens) / l.cfg.RequestsPerSecond
+			return decision{problem: ProblemTypeRateLimited, retryAfter: time.Duration(wait * float64(time.Second))}
+		}
+		st.tokens--
+	}
+
+	st.used++
+	return decision{allowed: true, remaining: l.cfg.Quota - st.used}
+}
+
+// prune drops clients whose bucket has refilled and whose quota window has
+// expired; they are indistinguishable from new clients.
+func (l *Limiter) prune(now time.Time) {
+	for id, st := range l.clients {
+		refilled := l.cfg.RequestsPerSecond <= 0 ||
+			st.tokens+now.Sub(st.lastRefill).Seconds()*l.cfg.RequestsPerSecond >= float64(l.cfg.Burst)
+		expired := l.cfg.Quota <= 0 || now.Sub(st.windowStart) >= l.cfg.QuotaWindow
+		if refilled && expired {
+			delete(l.clients, id)
+		}
+	}
+}
+
+// RateLimit rejects requests over the client's rate limit or quota with 429
+// and a Retry-After header. It must run after APIKeyAuth so clients are
+// limited per key. A disabled limiter passes every request through.
+func RateLimit(l *Limiter, exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !l.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || isExempt(r.URL.Path, exempt) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			d := l.allow(clientID(r))
+			if l.cfg.Quota > 0 {
+				w.Header().Set("X-Quota-Limit", strconv.Itoa(l.cfg.Quota))
+				w.Header().Set("X-Quota-Remaining", strconv.Itoa(max(d.remaining, 0)))
+			}
+			if !d.allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.retryAfter.Seconds()))))
+				if d.problem == ProblemTypeQuota {
+					WriteProblem(w, r, http.StatusTooManyRequests, ProblemTypeQuota,
+						"Quota Exceeded", "the request quota for this API key is used up")
+				} else {
+					WriteProblem(w, r, http.StatusTooManyRequests, ProblemTypeRateLimited,
+						"Too Many Requests", "rate limit exceeded, retry after the Retry-After delay")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientID identifies the caller by API key, falling back to remote address.
+func clientID(r *http.Request) string {
+	if id := KeyID(r.Context()); id != "" {
+		return "key:" + id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
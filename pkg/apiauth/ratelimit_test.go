@@ -0,0 +1,105 @@
+package apiauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a controllable time source for the limiter.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestLimiter(cfg RateLimitConfig) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLimiter(cfg)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	l, clock := newTestLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 2})
+
+	assert.True(t, l.allow("a").allowed)
+	assert.True(t, l.allow("a").allowed)
+	d := l.allow("a")
+	assert.False(t, d.allowed, "burst exhausted")
+	assert.Equal(t, ProblemTypeRateLimited, d.problem)
+	assert.Equal(t, time.Second, d.retryAfter)
+
+	assert.True(t, l.allow("b").allowed, "buckets are per client")
+
+	clock.t = clock.t.Add(time.Second)
+	assert.True(t, l.allow("a").allowed, "bucket refills")
+	assert.False(t, l.allow("a").allowed)
+}
+
+func TestLimiter_Quota(t *testing.T) {
+	l, clock := newTestLimiter(RateLimitConfig{Quota: 2, QuotaWindow: time.Hour})
+
+	assert.Equal(t, 1, l.allow("a").remaining)
+	assert.Equal(t, 0, l.allow("a").remaining)
+	d := l.allow("a")
+	assert.False(t, d.allowed)
+	assert.Equal(t, ProblemTypeQuota, d.problem)
+	assert.Equal(t, time.Hour, d.retryAfter)
+
+	clock.t = clock.t.Add(time.Hour)
+	assert.True(t, l.allow("a").allowed, "quota resets with the window")
+}
+
+func TestLimiter_PrunesIdleClients(t *testing.T) {
+	l, clock := newTestLimiter(RateLimitConfig{RequestsPerSecond: 10})
+	l.allow("idle")
+	clock.t = clock.t.Add(time.Hour)
+
+	l.prune(clock.t)
+
+	assert.Empty(t, l.clients)
+}
+
+func TestRateLimit_Middleware(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	l, _ := newTestLimiter(RateLimitConfig{RequestsPerSecond: 1, Quota: 10})
+	h := APIKeyAuth([]string{"secret"})(RateLimit(l, "/healthz")(inner))
+
+	send := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(APIKeyHeader, "secret")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("/api/v1/models")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("X-Quota-Limit"))
+	assert.Equal(t, "9", rec.Header().Get("X-Quota-Remaining"))
+
+	rec = send("/api/v1/models")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), ProblemTypeRateLimited)
+
+	assert.Equal(t, http.StatusOK, send("/healthz").Code, "exempt paths are not limited")
+}
+
+func TestRateLimit_Disabled_PassesThrough(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := RateLimit(NewLimiter(RateLimitConfig{}))(inner)
+
+	for range 100 {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/models", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,276 @@
+// Package apiclient is a typed Go client of the glens REST API: the
+// operations of cmd/glens/internal/server/openapi.yaml, served by glens serve
+// and by the standalone API, which runs it. Its types follow the
+// schemas of the document and its methods the operations, named after their
+// operationId; change them together with the document.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// specFormField is the multipart form field of an uploaded spec
+const specFormField = "spec"
+
+// Client calls the API of a glens server
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with key, sent as a bearer token
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient sends requests with hc instead of http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// New returns a client of the server at baseURL, e.g.
+// https://glens.example.com; a path prefix of a proxied server is kept.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q: want e.g. https://glens.example.com", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	c := &Client{baseURL: u, httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Error is a failed request, described by the server's RFC 9457 problem
+// when it sent one
+type Error struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+	// RetryAfter is the wait the server asks for, e.g. when its analysis
+	// queue is full or a rate limit is hit
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("glens API: %d %s", e.Status, e.Title)
+	}
+	return fmt.Sprintf("glens API: %d %s: %s", e.Status, e.Title, e.Detail)
+}
+
+// StatusCode returns the HTTP status of a failed request, or 0 when err is
+// not an *Error
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Status
+	}
+	return 0
+}
+
+// Health checks the server is up (healthCheck)
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/healthz", nil, "", &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// Models lists the models the server analyses with (listModels)
+func (c *Client) Models(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Models []Model `json:"models"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/models", nil, "", &resp); err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+// Analyze starts the analysis of the spec at req.SpecURL (startAnalysis)
+func (c *Client) Analyze(ctx context.Context, req AnalyzeRequest) (*AnalyzeResponse, error) {
+	var resp AnalyzeResponse
+	if err := c.doJSON(ctx, "/api/v1/analyze", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AnalyzeUpload starts the analysis of a spec uploaded from spec, for specs
+// the server cannot reach by URL; name is the spec's file name, whose
+// extension tells the server its format (startAnalysis)
+func (c *Client) AnalyzeUpload(ctx context.Context, name string, spec io.Reader, req AnalyzeRequest) (*AnalyzeResponse, error) {
+	var resp AnalyzeResponse
+	if err := c.doUpload(ctx, "/api/v1/analyze", name, spec, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Preview lists the endpoints an analysis would test and estimates its
+// token use and cost (analyzePreview)
+func (c *Client) Preview(ctx context.Context, req AnalyzeRequest) (*Preview, error) {
+	var resp Preview
+	if err := c.doJSON(ctx, "/api/v1/analyze/preview", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PreviewUpload previews the analysis of an uploaded spec, see
+// AnalyzeUpload (analyzePreview)
+func (c *Client) PreviewUpload(ctx context.Context, name string, spec io.Reader, req AnalyzeRequest) (*Preview, error) {
+	var resp Preview
+	if err := c.doUpload(ctx, "/api/v1/analyze/preview", name, spec, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Job returns the status and progress of an analysis job (getJob)
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(id), nil, "", &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Report returns the report of a succeeded job; jobs that have not
+// succeeded fail with status 409 (getJobReport)
+func (c *Client) Report(ctx context.Context, id string) (Report, error) {
+	var report Report
+	if err := c.do(ctx, http.MethodGet, "/api/v1/jobs/"+url.PathEscape(id)+"/report", nil, "", &report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// OpenAPI returns the OpenAPI document of the server (getOpenAPI)
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/api/v1/openapi.json", nil, "", &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// doJSON posts body as JSON to path and decodes the response into out
+func (c *Client) doJSON(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, path, bytes.NewReader(data), "application/json", out)
+}
+
+// doUpload posts the spec and the lists of req as a multipart form to path
+// and decodes the response into out
+func (c *Client) doUpload(ctx context.Context, path, name string, spec io.Reader, req AnalyzeRequest, out any) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"models", req.Models},
+		{"approved_endpoints", req.ApprovedEndpoints},
+		{"skipped_endpoints", req.SkippedEndpoints},
+	}
+	for _, field := range fields {
+		for _, value := range field.values {
+			if err := form.WriteField(field.name, value); err != nil {
+				return fmt.Errorf("encode request: %w", err)
+			}
+		}
+	}
+	part, err := form.CreateFormFile(specFormField, name)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	if _, err := io.Copy(part, spec); err != nil {
+		return fmt.Errorf("read spec %s: %w", name, err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, path, &body, form.FormDataContentType(), out)
+}
+
+// do sends a request to path and decodes the JSON response into out;
+// responses other than 2xx are returned as an *Error
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, contentType string, out any) error {
+	resp, err := c.send(ctx, method, path, body, contentType, "application/json")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send sends a request to path accepting accept; the body of a 2xx
+// response is left to the caller, others are returned as an *Error
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader, contentType, accept string) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
+
+// responseError reads the problem of a failed response
+func responseError(resp *http.Response) error {
+	apiErr := &Error{}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Title == "" {
+		apiErr = &Error{Title: http.StatusText(resp.StatusCode), Detail: strings.TrimSpace(string(data))}
+	}
+	apiErr.Status = resp.StatusCode
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
@@ -0,0 +1,148 @@
+package apiclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"glens/pkg/apiclient"
+)
+
+func newServer(t *testing.T, handler http.HandlerFunc) *apiclient.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := apiclient.New(srv.URL+"/", apiclient.WithAPIKey("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNew_RejectsInvalidURLs(t *testing.T) {
+	for _, u := range []string{"", "glens.example.com", "ftp://glens.example.com", "http://"} {
+		if _, err := apiclient.New(u); err == nil {
+			t.Errorf("New(%q) succeeded", u)
+		}
+	}
+}
+
+func TestAnalyze_SendsJSONWithAPIKey(t *testing.T) {
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/analyze" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req apiclient.AnalyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.SpecURL != "https://example.com/openapi.json" || !slices.Equal(req.Models, []string{"gpt-4o"}) {
+			t.Errorf("request = %+v", req)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"run_id":"j1","job_id":"j1","status":"queued","status_url":"/api/v1/jobs/j1"}`)
+	})
+
+	resp, err := c.Analyze(context.Background(), apiclient.AnalyzeRequest{
+		SpecURL: "https://example.com/openapi.json",
+		Models:  []string{"gpt-4o"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.JobID != "j1" || resp.Status != "queued" || resp.StatusURL != "/api/v1/jobs/j1" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestAnalyzeUpload_SendsMultipartForm(t *testing.T) {
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.MultipartForm.Value["skipped_endpoints"]; !slices.Equal(got, []string{"GET /a", "GET /b"}) {
+			t.Errorf("skipped_endpoints = %v", got)
+		}
+		file, header, err := r.FormFile("spec")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "api.yaml" || string(data) != "openapi: 3.0.0" {
+			t.Errorf("spec = %s %q", header.Filename, data)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"run_id":"j2","status":"queued"}`)
+	})
+
+	resp, err := c.AnalyzeUpload(context.Background(), "api.yaml", strings.NewReader("openapi: 3.0.0"),
+		apiclient.AnalyzeRequest{SkippedEndpoints: []string{"GET /a", "GET /b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RunID != "j2" {
+		t.Errorf("run_id = %q", resp.RunID)
+	}
+}
+
+func TestJob_DecodesProgress(t *testing.T) {
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/jobs/j1" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"id":"j1","status":"running","spec_url":"s","created_at":"2026-01-02T03:04:05Z",
+			"progress":{"endpoints_total":4,"endpoints_processed":1,"current_endpoint":"GET /users"}}`)
+	})
+
+	job, err := c.Job(context.Background(), "j1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != apiclient.JobRunning || job.Finished() {
+		t.Errorf("status = %s", job.Status)
+	}
+	if job.Progress.EndpointsTotal != 4 || job.Progress.CurrentEndpoint != "GET /users" {
+		t.Errorf("progress = %+v", job.Progress)
+	}
+}
+
+func TestErrors_ReadProblemAndRetryAfter(t *testing.T) {
+	c := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/analyze":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"type":"https://glens.dev/errors/overloaded","title":"Service Unavailable","status":503,"detail":"analysis queue is full, retry later"}`)
+		default:
+			http.Error(w, "gateway down", http.StatusBadGateway)
+		}
+	})
+
+	_, err := c.Analyze(context.Background(), apiclient.AnalyzeRequest{SpecURL: "s"})
+	var apiErr *apiclient.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v", err)
+	}
+	if apiErr.Status != http.StatusServiceUnavailable || apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("error = %+v", apiErr)
+	}
+	if got := err.Error(); got != "glens API: 503 Service Unavailable: analysis queue is full, retry later" {
+		t.Errorf("message = %q", got)
+	}
+
+	_, err = c.Models(context.Background())
+	if apiclient.StatusCode(err) != http.StatusBadGateway || !strings.Contains(err.Error(), "gateway down") {
+		t.Errorf("error = %v", err)
+	}
+}
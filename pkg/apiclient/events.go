@@ -0,0 +1,92 @@
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// reconnectDelay is the wait before a dropped event stream is reopened
+var reconnectDelay = time.Second
+
+// maxEventSize is the largest event a stream may send
+const maxEventSize = 1 << 20
+
+// WatchJob follows a job through its event stream, calling onChange, when
+// set, with the job each time its status or progress changes, and returns
+// the finished job; its Status tells whether it succeeded. Streams dropped
+// before the job finishes are reopened. ctx bounds the watch, not the job,
+// which keeps running on the server (streamJobEvents).
+func (c *Client) WatchJob(ctx context.Context, id string, onChange func(*Job)) (*Job, error) {
+	for {
+		job, err := c.streamJob(ctx, id, onChange)
+		switch {
+		case job != nil && job.Finished():
+			return job, nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case StatusCode(err) != 0:
+			// The server refused the stream, e.g. for an unknown job
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// streamJob reads the event stream of a job until it ends and returns the
+// job of its last event
+func (c *Client) streamJob(ctx context.Context, id string, onChange func(*Job)) (*Job, error) {
+	path := "/api/v1/jobs/" + url.PathEscape(id) + "/events"
+	resp, err := c.send(ctx, http.MethodGet, path, nil, "", "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var last *Job
+	var event string
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			// Lines starting with a colon are comments, e.g. keep-alives
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		// A blank line dispatches the event
+		if event == "job" && len(data) > 0 {
+			var job Job
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &job); err != nil {
+				return last, fmt.Errorf("GET %s: decode event: %w", path, err)
+			}
+			last = &job
+			if onChange != nil {
+				onChange(&job)
+			}
+		}
+		event, data = "", nil
+	}
+	if err := scanner.Err(); err != nil {
+		return last, fmt.Errorf("GET %s: %w", path, err)
+	}
+	return last, nil
+}
@@ -0,0 +1,64 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWatchJob_ReopensDroppedStreams(t *testing.T) {
+	reconnectDelay = time.Millisecond
+	connections := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/jobs/j1/events" || r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("request = %s %s", r.URL.Path, r.Header.Get("Accept"))
+		}
+		connections++
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprintf(w, "event: job\ndata: {\"id\":\"j1\",\"status\":\"running\",\"progress\":{\"endpoints_total\":2,\"endpoints_processed\":%d}}\n\n", connections-1)
+		if connections > 1 {
+			fmt.Fprint(w, "event: job\ndata: {\"id\":\"j1\",\"status\":\"succeeded\",\n")
+			fmt.Fprint(w, "data: \"progress\":{\"endpoints_total\":2,\"endpoints_processed\":2}}\n\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var processed []int
+	job, err := c.WatchJob(context.Background(), "j1", func(j *Job) {
+		processed = append(processed, j.Progress.EndpointsProcessed)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobSucceeded || connections != 2 {
+		t.Errorf("status = %s after %d connections", job.Status, connections)
+	}
+	if fmt.Sprint(processed) != "[0 1 2]" {
+		t.Errorf("progress = %v", processed)
+	}
+}
+
+func TestWatchJob_UnknownJob(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"title":"Not Found","status":404,"detail":"job \"j1\" does not exist"}`)
+	}))
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.WatchJob(context.Background(), "j1", nil); StatusCode(err) != http.StatusNotFound {
+		t.Errorf("error = %v", err)
+	}
+}
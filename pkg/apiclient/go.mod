@@ -0,0 +1,3 @@
+module glens/pkg/apiclient
+
+go 1.25
@@ -0,0 +1,158 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Health is the response of healthCheck
+type Health struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// AnalyzeRequest names the spec of an analysis, or of its preview, and
+// selects its models and endpoints. SpecURL is ignored by uploads.
+type AnalyzeRequest struct {
+	SpecURL           string   `json:"spec_url,omitempty"`
+	Models            []string `json:"models,omitempty"`
+	ApprovedEndpoints []string `json:"approved_endpoints,omitempty"`
+	SkippedEndpoints  []string `json:"skipped_endpoints,omitempty"`
+}
+
+// AnalyzeResponse is the response of startAnalysis: the queued job, whose
+// ID RunID repeats for clients of the original contract.
+type AnalyzeResponse struct {
+	RunID     string `json:"run_id"`
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+// JobStatus is the state of an analysis job
+type JobStatus string
+
+// Job states
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is an analysis queued by startAnalysis
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+	// SpecURL is a server path for uploaded specs, named by SpecName
+	SpecURL           string      `json:"spec_url"`
+	SpecName          string      `json:"spec_name,omitempty"`
+	Models            []string    `json:"models,omitempty"`
+	ApprovedEndpoints []string    `json:"approved_endpoints,omitempty"`
+	SkippedEndpoints  []string    `json:"skipped_endpoints,omitempty"`
+	Progress          JobProgress `json:"progress"`
+	// Error says why a failed job failed
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the job has succeeded or failed
+func (j *Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// JobProgress counts the endpoints a job has processed
+type JobProgress struct {
+	EndpointsTotal     int    `json:"endpoints_total"`
+	EndpointsProcessed int    `json:"endpoints_processed"`
+	CurrentEndpoint    string `json:"current_endpoint,omitempty"`
+	CurrentModel       string `json:"current_model,omitempty"`
+}
+
+// Model is an AI model the server analyses with
+type Model struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	// ContextWindow is 0 when unknown
+	ContextWindow     int  `json:"context_window,omitempty"`
+	MaxOutputTokens   int  `json:"max_output_tokens,omitempty"`
+	SupportsStreaming bool `json:"supports_streaming"`
+	Local             bool `json:"local"`
+	// Deprecated says why the model should no longer be used
+	Deprecated string `json:"deprecated,omitempty"`
+	// Price and Health are nil when the server does not report them
+	Price  *ModelPrice  `json:"price,omitempty"`
+	Health *ModelHealth `json:"health,omitempty"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// ModelHealth is the last health check of a model's provider
+type ModelHealth struct {
+	// Status is healthy, unhealthy or unchecked
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Preview is the response of analyzePreview
+type Preview struct {
+	// SpecURL is empty for uploaded specs
+	SpecURL   string             `json:"spec_url"`
+	Endpoints []EndpointCategory `json:"endpoints"`
+	Summary   PreviewSummary     `json:"summary"`
+	// Estimate is nil when the server cannot estimate token use
+	Estimate *PreviewEstimate `json:"estimate,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// EndpointCategory is an endpoint with its risk and whether the analysis
+// would test it
+type EndpointCategory struct {
+	ID          string `json:"id"`
+	OperationID string `json:"operation_id,omitempty"`
+	Path        string `json:"path"`
+	Method      string `json:"method"`
+	Category    string `json:"category"`
+	RiskLevel   string `json:"risk_level"`
+	Selected    bool   `json:"selected"`
+}
+
+// PreviewSummary counts the selected endpoints by category and risk level
+type PreviewSummary struct {
+	Total      int            `json:"total"`
+	Selected   int            `json:"selected"`
+	ByCategory map[string]int `json:"by_category"`
+	ByRisk     map[string]int `json:"by_risk"`
+}
+
+// PreviewEstimate is the expected token use and cost of an analysis
+type PreviewEstimate struct {
+	Models       []ModelEstimate `json:"models"`
+	InputTokens  int             `json:"input_tokens"`
+	OutputTokens int             `json:"output_tokens"`
+	CostUSD      float64         `json:"cost_usd"`
+	// Unpriced lists the models CostUSD leaves out
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// ModelEstimate is the expected token use and cost of one model
+type ModelEstimate struct {
+	Model        string  `json:"model"`
+	Endpoints    int     `json:"endpoints"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	Priced       bool    `json:"priced"`
+}
+
+// Report is the JSON report of an analysis job, as written by glens
+// analyze --format json
+type Report = json.RawMessage
@@ -0,0 +1,3 @@
+module glens/pkg/metrics
+
+go 1.25
@@ -0,0 +1,149 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"glens/pkg/metrics"
+)
+
+func render(t *testing.T, r *metrics.Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	return buf.String()
+}
+
+func TestCounter(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounter("jobs_total", "Jobs by result.", "result")
+
+	c.Inc("passed")
+	c.Add(2, "passed")
+	c.Inc("failed")
+	c.Add(-5, "failed")
+
+	if got := c.Value("passed"); got != 3 {
+		t.Errorf("Value(passed) = %v, want 3", got)
+	}
+	want := `# HELP jobs_total Jobs by result.
+# TYPE jobs_total counter
+jobs_total{result="failed"} 1
+jobs_total{result="passed"} 3
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounter_NoLabels(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("errors_total", "Errors.").Inc()
+
+	if got := render(t, r); !strings.Contains(got, "\nerrors_total 1\n") {
+		t.Errorf("WriteText = %s", got)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := r.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "op")
+
+	h.Observe(0.05, "get")
+	h.Observe(0.5, "get")
+	h.Observe(3, "get")
+
+	if got := h.Count("get"); got != 3 {
+		t.Errorf("Count = %d, want 3", got)
+	}
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.1"} 1
+latency_seconds_bucket{op="get",le="1"} 2
+latency_seconds_bucket{op="get",le="+Inf"} 3
+latency_seconds_sum{op="get"} 3.55
+latency_seconds_count{op="get"} 3
+`
+	if got := render(t, r); got != want {
+		t.Errorf("WriteText =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("x_total", "X.", "path").Inc(`a"b\c`)
+
+	if got := render(t, r); !strings.Contains(got, `x_total{path="a\"b\\c"} 1`) {
+		t.Errorf("WriteText = %s", got)
+	}
+}
+
+func TestLabelValuesKeepNonASCII(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("x_total", "X.", "path").Inc("/café\tmenu\nü")
+
+	if got := render(t, r); !strings.Contains(got, "x_total{path=\"/café\tmenu\\nü\"} 1") {
+		t.Errorf("WriteText = %s", got)
+	}
+}
+
+func TestWrongLabelCountPanics(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := r.NewCounter("x_total", "X.", "a", "b")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for wrong label count")
+		}
+	}()
+	c.Inc("only-one")
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("x_total", "X.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for duplicate name")
+		}
+	}()
+	r.NewHistogram("x_total", "X.", nil)
+}
+
+func TestHandlerAndInstrument(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := r.NewHistogram("http_request_duration_seconds", "Durations.", nil, "method", "route", "status")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.Handle("GET /metrics", r.Handler())
+	srv := metrics.Instrument(h, mux)
+
+	for _, path := range []string{"/items/1", "/items/2", "/nowhere"} {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := h.Count("GET", "GET /items/{id}", "418"); got != 2 {
+		t.Errorf("route count = %d, want 2", got)
+	}
+	if got := h.Count("GET", "unmatched", "404"); got != 1 {
+		t.Errorf("unmatched count = %d, want 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `route="GET /items/{id}"`) {
+		t.Errorf("body missing route series:\n%s", rec.Body.String())
+	}
+}
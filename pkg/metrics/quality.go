@@ -0,0 +1,563 @@
+package metrics
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Endpoint describes the API operation a generated test targets.
+type Endpoint struct {
+	Method string
+	Path   string
+	// StatusCodes are the documented response codes, e.g. "200", "4XX" or
+	// "default".
+	StatusCodes []string
+	// Parameters are the names of the documented parameters.
+	Parameters []string
+}
+
+// Quality is the static analysis of a Go test file against its endpoint.
+// Scores range from 0 to 100.
+type Quality struct {
+	LinesOfCode   int
+	CommentLines  int
+	TestFunctions int
+	Subtests      int
+	Assertions    int
+	// Complexity is the mean cyclomatic complexity of the test functions.
+	Complexity  float64
+	Readability float64
+	// Categories are the kinds of cases the test names and asserted status
+	// codes suggest: happy-path, error-handling, boundary, security and
+	// performance.
+	Categories []string
+
+	// Methods are the HTTP methods the test sends.
+	Methods []string
+	// StatusCodes are the status codes the test compares against, sorted.
+	StatusCodes []string
+	// DocumentedCovered counts the endpoint's documented status codes the
+	// test asserts.
+	DocumentedCovered int
+	ParametersCovered int
+	ParametersTotal   int
+	// EdgeCases counts the test cases named as error, boundary or security
+	// cases.
+	EdgeCases int
+	Coverage  float64
+
+	Security Security
+	// Strength is how deeply the test functions assert the response.
+	Strength Strength
+	// Score combines code quality (40%), coverage (40%) and security (20%).
+	Score float64
+}
+
+// Security records which kinds of security cases a test exercises.
+type Security struct {
+	Authentication  bool
+	Authorization   bool
+	InputValidation bool
+	SQLInjection    bool
+	XSS             bool
+	// Score is 20 per kind exercised.
+	Score float64
+}
+
+// Patterns classifying test case names and string literals.
+var (
+	categoryPatterns = []struct {
+		name    string
+		pattern *regexp.Regexp
+	}{
+		{"happy-path", regexp.MustCompile(`(?i)success|\bvalid|happy|\bok\b|created`)},
+		{"error-handling", regexp.MustCompile(`(?i)error|invalid|fail|not.?found|missing|\bbad|reject|conflict`)},
+		{"boundary", regexp.MustCompile(`(?i)boundary|edge|limit|empty|\bmax|\bmin|large|\blong|zero|negative|overflow`)},
+		{"security", regexp.MustCompile(`(?i)auth|token|forbidden|permission|injection|xss|security`)},
+		{"performance", regexp.MustCompile(`(?i)perf|latency|timeout|duration|concurren|\bload\b`)},
+	}
+	authPattern         = regexp.MustCompile(`(?i)unauthori[sz]ed|unauthenticated|no.?auth|without.?auth|invalid.?token|missing.?token|expired.?token`)
+	authzPattern        = regexp.MustCompile(`(?i)forbidden|permission|not.?allowed|access.?denied|other.?user|\brole`)
+	validationPattern   = regexp.MustCompile(`(?i)invalid|validation|malformed|bad.?request|required`)
+	sqlInjectionPattern = regexp.MustCompile(`(?i)' ?or ?'?1'? ?= ?'?1|drop table|union select|sql.?injection|; ?--`)
+	xssPattern          = regexp.MustCompile(`(?i)<script|javascript:|onerror=|xss`)
+)
+
+// Calls counted as assertions: testify and suite helpers, glens/assertspec
+// schema validation, testing.T failures and Gomega matchers.
+var (
+	assertionPackages = []string{"assert", "require", "suite", schemaPackage}
+	failureMethods    = []string{"Error", "Errorf", "Fatal", "Fatalf", "Fail", "FailNow"}
+	gomegaMatchers    = []string{"Expect", "Eventually", "Consistently", "Ω"}
+)
+
+// statusConstants maps net/http status constant names to their codes.
+var statusConstants = statusConstantCodes()
+
+// Readability limits: longer functions and lines lose points.
+const (
+	maxReadableFuncLines = 60
+	maxLineLength        = 120
+)
+
+// AnalyzeTest parses a Go test file and measures its quality and how well it
+// covers endpoint. It fails when code is not valid Go.
+func AnalyzeTest(code string, endpoint Endpoint) (*Quality, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated_test.go", code, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse test code: %w", err)
+	}
+
+	a := &analysis{
+		fset:     fset,
+		methods:  make(map[string]bool),
+		statuses: make(map[string]bool),
+	}
+	a.countLines(code, file)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			a.function(fn)
+		}
+	}
+	ast.Inspect(file, a.visit)
+
+	q := &Quality{
+		LinesOfCode:     a.lines,
+		CommentLines:    a.commentLines,
+		TestFunctions:   a.testFunctions,
+		Subtests:        a.subtests,
+		Assertions:      a.assertions,
+		Methods:         sortedKeys(a.methods),
+		StatusCodes:     sortedKeys(a.statuses),
+		ParametersTotal: len(endpoint.Parameters),
+	}
+	if a.testFunctions > 0 {
+		q.Complexity = float64(a.complexity) / float64(a.testFunctions)
+	}
+	q.Readability = a.readability()
+	q.Categories, q.EdgeCases = a.categorise()
+	q.Security = a.security()
+	q.Strength = a.responseChecks
+	q.Strength.finish(a.depth, a.testFunctions)
+
+	q.DocumentedCovered, q.Coverage = coverage(q, endpoint, a)
+	q.Score = score(q)
+	return q, nil
+}
+
+// analysis accumulates what one walk over a test file finds.
+type analysis struct {
+	fset *token.FileSet
+
+	lines, commentLines, longLines int
+	testFunctions, subtests        int
+	assertions                     int
+	complexity                     int
+	funcLines                      []int
+	caseNames                      []string
+	literals                       []string
+	identifiers                    map[string]bool
+	methods, statuses              map[string]bool
+	responseChecks                 Strength
+	// depth sums the strength scores of the test functions
+	depth float64
+}
+
+// countLines counts code lines, comment lines and overlong lines.
+func (a *analysis) countLines(code string, file *ast.File) {
+	commentOnly := make(map[int]bool)
+	for _, group := range file.Comments {
+		start, end := a.fset.Position(group.Pos()).Line, a.fset.Position(group.End()).Line
+		a.commentLines += end - start + 1
+		for line := start; line <= end; line++ {
+			commentOnly[line] = true
+		}
+	}
+	for i, line := range strings.Split(code, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || (commentOnly[i+1] && (strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*"))) {
+			continue
+		}
+		a.lines++
+		if len(line) > maxLineLength {
+			a.longLines++
+		}
+	}
+}
+
+// function records a test function's name, length and cyclomatic complexity.
+func (a *analysis) function(fn *ast.FuncDecl) {
+	if !isTestFunction(fn) {
+		return
+	}
+	a.testFunctions++
+	a.caseNames = append(a.caseNames, fn.Name.Name)
+	a.funcLines = append(a.funcLines, a.fset.Position(fn.End()).Line-a.fset.Position(fn.Pos()).Line+1)
+	a.strength(fn)
+
+	a.complexity++
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			a.complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				a.complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				a.complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				a.complexity++
+			}
+		}
+		return true
+	})
+}
+
+// isTestFunction reports whether fn is func TestXxx(t *testing.T).
+func isTestFunction(fn *ast.FuncDecl) bool {
+	if fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Test") || fn.Type.Params.NumFields() != 1 {
+		return false
+	}
+	star, ok := fn.Type.Params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "T"
+}
+
+// visit collects assertions, subtests, HTTP methods, status codes, case
+// names and string literals.
+func (a *analysis) visit(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		a.call(n)
+	case *ast.BinaryExpr:
+		if n.Op == token.EQL || n.Op == token.NEQ {
+			a.statusOperand(n.X)
+			a.statusOperand(n.Y)
+		}
+	case *ast.KeyValueExpr:
+		if key, ok := n.Key.(*ast.Ident); ok {
+			name := strings.ToLower(key.Name)
+			switch {
+			case strings.Contains(name, "status") || strings.Contains(name, "code"):
+				a.statusOperand(n.Value)
+			case name == "name" || name == "desc" || name == "description" || name == "scenario":
+				if s, ok := stringLiteral(n.Value); ok {
+					a.caseNames = append(a.caseNames, s)
+				}
+			}
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := n.X.(*ast.Ident); ok && pkg.Name == "http" {
+			if method, ok := strings.CutPrefix(n.Sel.Name, "Method"); ok {
+				a.methods[strings.ToUpper(method)] = true
+			}
+			if code, ok := statusConstants[n.Sel.Name]; ok {
+				a.statuses[code] = true
+			}
+		}
+	case *ast.BasicLit:
+		if s, ok := stringLiteral(n); ok {
+			a.literals = append(a.literals, s)
+			if isHTTPMethod(s) {
+				a.methods[s] = true
+			}
+		}
+	case *ast.Ident:
+		if a.identifiers == nil {
+			a.identifiers = make(map[string]bool)
+		}
+		a.identifiers[strings.ToLower(n.Name)] = true
+	}
+	return true
+}
+
+// call counts assertions and records subtests and asserted status codes.
+func (a *analysis) call(call *ast.CallExpr) {
+	var receiver, name string
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			receiver = ident.Name
+		}
+	case *ast.Ident:
+		name = fun.Name
+	}
+
+	switch {
+	case name == "Run" && len(call.Args) == 2:
+		a.subtests++
+		if s, ok := stringLiteral(call.Args[0]); ok {
+			a.caseNames = append(a.caseNames, s)
+		}
+		return
+	case slices.Contains(assertionPackages, receiver),
+		// err.Error() is not an assertion; t.Error("...") is
+		slices.Contains(failureMethods, name) && receiver != "" && (len(call.Args) > 0 || strings.HasPrefix(name, "Fail")),
+		slices.Contains(gomegaMatchers, name):
+		a.assertions++
+	default:
+		return
+	}
+	for _, arg := range call.Args {
+		a.statusOperand(arg)
+	}
+}
+
+// statusOperand records expr when it is a status code literal or constant.
+func (a *analysis) statusOperand(expr ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT {
+			return
+		}
+		if code, err := strconv.Atoi(e.Value); err == nil && code >= 100 && code <= 599 {
+			a.statuses[e.Value] = true
+		}
+	case *ast.SelectorExpr:
+		if code, ok := statusConstants[e.Sel.Name]; ok {
+			a.statuses[code] = true
+		}
+	}
+}
+
+// readability scores comment density, function length, line length and
+// named cases, 25 points each.
+func (a *analysis) readability() float64 {
+	if a.lines == 0 {
+		return 0
+	}
+	score := 25 * math.Min(1, float64(a.commentLines)/(0.1*float64(a.lines)))
+	if len(a.funcLines) > 0 {
+		total := 0
+		for _, n := range a.funcLines {
+			total += n
+		}
+		mean := float64(total) / float64(len(a.funcLines))
+		score += 25 * math.Min(1, float64(maxReadableFuncLines)/mean)
+	}
+	score += 25 * (1 - float64(a.longLines)/float64(a.lines))
+	if a.subtests > 0 || a.testFunctions > 1 {
+		score += 25
+	}
+	return round(score)
+}
+
+// categorise derives the case categories from case names and asserted
+// status codes, and counts the negative cases.
+func (a *analysis) categorise() (categories []string, edgeCases int) {
+	found := make(map[string]bool)
+	for _, name := range a.caseNames {
+		edge := false
+		for _, c := range categoryPatterns {
+			if c.pattern.MatchString(splitWords(name)) {
+				found[c.name] = true
+				edge = edge || (c.name != "happy-path" && c.name != "performance")
+			}
+		}
+		if edge {
+			edgeCases++
+		}
+	}
+	for code := range a.statuses {
+		switch {
+		case strings.HasPrefix(code, "2"):
+			found["happy-path"] = true
+		case code == "401" || code == "403":
+			found["security"] = true
+			found["error-handling"] = true
+		case code >= "400":
+			found["error-handling"] = true
+		}
+	}
+
+	for _, c := range categoryPatterns {
+		if found[c.name] {
+			categories = append(categories, c.name)
+		}
+	}
+	return categories, edgeCases
+}
+
+// security detects the kinds of security cases from status codes, case
+// names and payloads.
+func (a *analysis) security() Security {
+	names := make([]string, len(a.caseNames))
+	for i, name := range a.caseNames {
+		names[i] = splitWords(name)
+	}
+	caseText := strings.Join(names, "\n")
+	literalText := strings.Join(a.literals, "\n")
+
+	s := Security{
+		Authentication:  a.statuses["401"] || authPattern.MatchString(caseText),
+		Authorization:   a.statuses["403"] || authzPattern.MatchString(caseText),
+		InputValidation: a.statuses["400"] || a.statuses["422"] || validationPattern.MatchString(caseText),
+		SQLInjection:    sqlInjectionPattern.MatchString(caseText) || sqlInjectionPattern.MatchString(literalText),
+		XSS:             xssPattern.MatchString(caseText) || xssPattern.MatchString(literalText),
+	}
+	for _, covered := range []bool{s.Authentication, s.Authorization, s.InputValidation, s.SQLInjection, s.XSS} {
+		if covered {
+			s.Score += 20
+		}
+	}
+	return s
+}
+
+// coverage weighs documented status codes (50%), parameters (30%) and the
+// endpoint's method (20%).
+func coverage(q *Quality, endpoint Endpoint, a *analysis) (documented int, percentage float64) {
+	codes := 0
+	for _, code := range endpoint.StatusCodes {
+		if strings.EqualFold(code, "default") {
+			continue
+		}
+		codes++
+		if a.coversStatus(code) {
+			documented++
+		}
+	}
+	statusScore := 1.0
+	if codes > 0 {
+		statusScore = float64(documented) / float64(codes)
+	}
+
+	for _, param := range endpoint.Parameters {
+		if a.mentions(param) {
+			q.ParametersCovered++
+		}
+	}
+	paramScore := 1.0
+	if q.ParametersTotal > 0 {
+		paramScore = float64(q.ParametersCovered) / float64(q.ParametersTotal)
+	}
+
+	methodScore := 0.0
+	if endpoint.Method == "" || a.methods[strings.ToUpper(endpoint.Method)] {
+		methodScore = 1
+	}
+	return documented, round(100 * (0.5*statusScore + 0.3*paramScore + 0.2*methodScore))
+}
+
+// coversStatus reports whether a documented code, possibly a range such as
+// "4XX", was asserted.
+func (a *analysis) coversStatus(code string) bool {
+	if len(code) == 3 && strings.EqualFold(code[1:], "xx") {
+		for asserted := range a.statuses {
+			if asserted[0] == code[0] {
+				return true
+			}
+		}
+		return false
+	}
+	return a.statuses[code]
+}
+
+// mentions reports whether a parameter name appears in the test as an
+// identifier or inside a string literal.
+func (a *analysis) mentions(param string) bool {
+	lower := strings.ToLower(param)
+	if a.identifiers[lower] || a.identifiers[strings.NewReplacer("-", "", "_", "").Replace(lower)] {
+		return true
+	}
+	word := regexp.MustCompile(`\b` + regexp.QuoteMeta(lower) + `\b`)
+	for _, s := range a.literals {
+		if word.MatchString(strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// score combines the code quality, coverage and security scores.
+func score(q *Quality) float64 {
+	if q.TestFunctions == 0 {
+		return 0
+	}
+	// Three assertions per case is considered thorough, as deep as they
+	// check the response
+	cases := q.TestFunctions + q.Subtests
+	assertionScore := 50*math.Min(1, float64(q.Assertions)/float64(3*cases)) + 0.5*q.Strength.Score
+	code := 0.5*assertionScore + 0.5*q.Readability
+	return round(0.4*code + 0.4*q.Coverage + 0.2*q.Security.Score)
+}
+
+// statusConstantCodes derives the net/http status constant names, such as
+// "StatusNotFound", from the status texts.
+func statusConstantCodes() map[string]string {
+	codes := map[string]string{
+		"StatusTeapot":               "418",
+		"StatusNonAuthoritativeInfo": "203",
+	}
+	for code := 100; code <= 599; code++ {
+		text := http.StatusText(code)
+		if text == "" {
+			continue
+		}
+		name := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, strings.ReplaceAll(text, "-", " "))
+		codes["Status"+name] = strconv.Itoa(code)
+	}
+	return codes
+}
+
+// splitWords turns identifiers such as "TestGetUser_NotFound" into words so
+// case names match the category patterns.
+func splitWords(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-':
+			b.WriteRune(' ')
+			continue
+		case i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]):
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+func isHTTPMethod(s string) bool {
+	switch s {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
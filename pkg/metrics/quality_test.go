@@ -0,0 +1,212 @@
+package metrics_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"glens/pkg/metrics"
+)
+
+const userTest = `package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUser covers GET /users/{id}
+func TestGetUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{name: "valid user", id: "1", status: http.StatusOK},
+		{name: "not found", id: "999", status: 404},
+		{name: "sql injection", id: "1' OR '1'='1", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, baseURL+"/users/"+tt.id, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestGetUser_Unauthorized(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users/1")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != 401 {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+}
+`
+
+func TestAnalyzeTest(t *testing.T) {
+	q, err := metrics.AnalyzeTest(userTest, metrics.Endpoint{
+		Method:      "GET",
+		Path:        "/users/{id}",
+		StatusCodes: []string{"200", "404", "500", "default"},
+		Parameters:  []string{"id", "fields"},
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTest: %v", err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"TestFunctions", q.TestFunctions, 2},
+		{"Subtests", q.Subtests, 1},
+		{"Assertions", q.Assertions, 5},
+		{"CommentLines", q.CommentLines, 1},
+		{"Methods", q.Methods, []string{"GET"}},
+		{"StatusCodes", q.StatusCodes, []string{"200", "400", "401", "404"}},
+		{"DocumentedCovered", q.DocumentedCovered, 2},
+		{"ParametersCovered", q.ParametersCovered, 1},
+		{"Categories", q.Categories, []string{"happy-path", "error-handling", "security"}},
+		{"EdgeCases", q.EdgeCases, 3},
+		{"Security", q.Security, metrics.Security{
+			Authentication: true, InputValidation: true, SQLInjection: true, Score: 60,
+		}},
+		// 50% * 2/3 status codes + 30% * 1/2 parameters + 20% method
+		{"Coverage", q.Coverage, 68.3},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if q.Complexity < 2 || q.Complexity > 3 {
+		t.Errorf("Complexity = %v, want between 2 and 3", q.Complexity)
+	}
+	if q.Score <= 0 || q.Score > 100 {
+		t.Errorf("Score = %v, want within (0, 100]", q.Score)
+	}
+}
+
+func TestAnalyzeTest_StatusRanges(t *testing.T) {
+	q, err := metrics.AnalyzeTest(userTest, metrics.Endpoint{StatusCodes: []string{"2XX", "5XX"}})
+	if err != nil {
+		t.Fatalf("AnalyzeTest: %v", err)
+	}
+	if q.DocumentedCovered != 1 {
+		t.Errorf("DocumentedCovered = %d, want 1 (2XX only)", q.DocumentedCovered)
+	}
+}
+
+func TestAnalyzeTest_NoTests(t *testing.T) {
+	q, err := metrics.AnalyzeTest("package api\n\nfunc helper() {}\n", metrics.Endpoint{Method: "GET"})
+	if err != nil {
+		t.Fatalf("AnalyzeTest: %v", err)
+	}
+	if q.Score != 0 {
+		t.Errorf("Score = %v, want 0 without test functions", q.Score)
+	}
+}
+
+func TestAnalyzeTest_InvalidCode(t *testing.T) {
+	_, err := metrics.AnalyzeTest("Here are your tests:\nfunc TestX(", metrics.Endpoint{})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse test code") {
+		t.Errorf("err = %v, want parse error", err)
+	}
+}
+
+const strengthTest = `package api_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"glens/assertspec"
+)
+
+func TestGetUserReachable(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users/1")
+	require.NoError(t, err)
+	assert.NotNil(t, resp)
+}
+
+func TestGetUserDecodes(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users/1")
+	require.NoError(t, err)
+	var user map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+}
+
+func TestGetUser(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users/1")
+	require.NoError(t, err)
+	code := resp.StatusCode
+	assert.Equal(t, http.StatusOK, code)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var user struct{ Name string }
+	require.NoError(t, json.Unmarshal(body, &user))
+	if user.Name == "" {
+		t.Error("name is empty")
+	}
+}
+
+func TestGetUserHeaders(t *testing.T) {
+	resp, _ := http.Get(baseURL + "/users/1")
+	switch resp.StatusCode {
+	case http.StatusOK:
+	default:
+		t.Fatal("unexpected status")
+	}
+	assert.Contains(t, resp.Header.Get("Content-Type"), "json")
+	assert.Equal(t, 1, len(resp.Cookies()))
+}
+
+func TestGetUserSchema(t *testing.T) {
+	resp, err := http.Get(baseURL + "/users/1")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assertspec.Body(t, "GET /users/{id}", resp.StatusCode, body)
+}
+`
+
+func TestAnalyzeTest_Strength(t *testing.T) {
+	q, err := metrics.AnalyzeTest(strengthTest, metrics.Endpoint{Method: "GET"})
+	if err != nil {
+		t.Fatalf("AnalyzeTest: %v", err)
+	}
+	want := metrics.Strength{
+		StatusAsserted: 2,
+		// validating the body against the spec asserts it, not its status
+		BodyAsserted:   2,
+		HeaderAsserted: 1,
+		// decoding the body asserts nothing about it
+		WeakTests: []string{"TestGetUserReachable", "TestGetUserDecodes"},
+		// (0 + 0 + 100 + 100 + 50) / 5
+		Score: 50,
+	}
+	if !reflect.DeepEqual(q.Strength, want) {
+		t.Errorf("Strength = %+v, want %+v", q.Strength, want)
+	}
+
+	weak, err := metrics.AnalyzeTest(strings.Split(strengthTest, "func TestGetUserDecodes")[0], metrics.Endpoint{Method: "GET"})
+	if err != nil {
+		t.Fatalf("AnalyzeTest: %v", err)
+	}
+	if weak.Score >= q.Score {
+		t.Errorf("Score of weak test = %v, want below %v", weak.Score, q.Score)
+	}
+}
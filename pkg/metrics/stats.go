@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"math"
+	"slices"
+)
+
+// z95 is the standard normal quantile of a two-sided 95% interval.
+const z95 = 1.959964
+
+// t95 holds Student's t quantiles of a two-sided 95% interval for 1 to 30
+// degrees of freedom; larger samples use z95.
+var t95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// Stats summarises a sample of measurements, such as latencies or token
+// counts.
+type Stats struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	P50    float64 `json:"p50"`
+	P95    float64 `json:"p95"`
+	// CILow and CIHigh bound the 95% confidence interval of the mean, using
+	// Student's t distribution. With a single value both equal the mean.
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
+
+// Summarize computes the statistics of values. An empty sample returns the
+// zero Stats.
+func Summarize(values []float64) Stats {
+	n := len(values)
+	if n == 0 {
+		return Stats{}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(n)
+
+	s := Stats{
+		N:      n,
+		Mean:   mean,
+		Min:    sorted[0],
+		Max:    sorted[n-1],
+		P50:    percentile(sorted, 0.50),
+		P95:    percentile(sorted, 0.95),
+		CILow:  mean,
+		CIHigh: mean,
+	}
+	if n < 2 {
+		return s
+	}
+
+	squares := 0.0
+	for _, v := range sorted {
+		squares += (v - mean) * (v - mean)
+	}
+	s.StdDev = math.Sqrt(squares / float64(n-1))
+
+	critical := z95
+	if n-1 <= len(t95) {
+		critical = t95[n-2]
+	}
+	margin := critical * s.StdDev / math.Sqrt(float64(n))
+	s.CILow = mean - margin
+	s.CIHigh = mean + margin
+	return s
+}
+
+// percentile interpolates the p-th quantile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// Rate is a proportion of successful trials, such as a pass rate.
+type Rate struct {
+	Successes int     `json:"successes"`
+	Total     int     `json:"total"`
+	Value     float64 `json:"value"`
+	// CILow and CIHigh bound the 95% Wilson score interval, which stays
+	// within [0, 1] and behaves well for small samples and rates near 0 or 1.
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
+
+// Proportion computes the rate of successes out of total trials. Zero
+// trials return the zero Rate.
+func Proportion(successes, total int) Rate {
+	if total <= 0 {
+		return Rate{}
+	}
+	n := float64(total)
+	p := float64(successes) / n
+	z2 := z95 * z95
+
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z95 / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return Rate{
+		Successes: successes,
+		Total:     total,
+		Value:     p,
+		CILow:     math.Max(0, center-margin),
+		CIHigh:    math.Min(1, center+margin),
+	}
+}
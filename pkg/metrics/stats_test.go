@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"math"
+	"testing"
+
+	"glens/pkg/metrics"
+)
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-3
+}
+
+func TestSummarize(t *testing.T) {
+	s := metrics.Summarize([]float64{4, 1, 3, 2, 5})
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Mean", s.Mean, 3},
+		{"Min", s.Min, 1},
+		{"Max", s.Max, 5},
+		{"P50", s.P50, 3},
+		{"P95", s.P95, 4.8},
+		{"StdDev", s.StdDev, 1.5811},
+		// 3 ± 2.776 * 1.5811 / sqrt(5)
+		{"CILow", s.CILow, 1.0369},
+		{"CIHigh", s.CIHigh, 4.9631},
+	}
+	for _, c := range checks {
+		if !near(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if s.N != 5 {
+		t.Errorf("N = %d, want 5", s.N)
+	}
+}
+
+func TestSummarize_SmallSamples(t *testing.T) {
+	if s := metrics.Summarize(nil); s != (metrics.Stats{}) {
+		t.Errorf("Summarize(nil) = %+v, want zero", s)
+	}
+	s := metrics.Summarize([]float64{2})
+	if s.Mean != 2 || s.CILow != 2 || s.CIHigh != 2 || s.StdDev != 0 {
+		t.Errorf("Summarize([2]) = %+v, want a degenerate interval at 2", s)
+	}
+}
+
+func TestProportion(t *testing.T) {
+	r := metrics.Proportion(8, 10)
+	if r.Value != 0.8 || r.Successes != 8 || r.Total != 10 {
+		t.Errorf("Proportion(8, 10) = %+v", r)
+	}
+	if !near(r.CILow, 0.4902) || !near(r.CIHigh, 0.9433) {
+		t.Errorf("Wilson interval = [%v, %v], want [0.4902, 0.9433]", r.CILow, r.CIHigh)
+	}
+
+	all := metrics.Proportion(5, 5)
+	if all.CIHigh != 1 || all.CILow >= 1 || all.CILow <= 0.5 {
+		t.Errorf("Proportion(5, 5) interval = [%v, %v]", all.CILow, all.CIHigh)
+	}
+	if r := metrics.Proportion(0, 0); r != (metrics.Rate{}) {
+		t.Errorf("Proportion(0, 0) = %+v, want zero", r)
+	}
+}
@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+)
+
+// Strength is how deeply the test functions check the responses they get.
+// Tests that only assert that the request did not fail pass against any
+// backend that answers at all.
+type Strength struct {
+	// StatusAsserted, BodyAsserted and HeaderAsserted count the test
+	// functions asserting the response status code, body and headers.
+	StatusAsserted int
+	BodyAsserted   int
+	HeaderAsserted int
+	// WeakTests are the test functions asserting none of them.
+	WeakTests []string
+	// Score is the mean depth of the test functions: 100 for asserting two
+	// or more of status, body and headers, 50 for one and 0 for none.
+	Score float64
+}
+
+// responsePart is a part of an HTTP response a test can assert.
+type responsePart int
+
+const (
+	partStatus responsePart = 1 << iota
+	partBody
+	partHeader
+)
+
+// Selectors reading a part of a response, and calls reading the body that
+// only fail when the request did, so that asserting them checks nothing.
+var (
+	partSelectors = map[string]responsePart{
+		"StatusCode": partStatus,
+		"Code":       partStatus,
+		"Status":     partStatus,
+		"Body":       partBody,
+		"Header":     partHeader,
+		"Cookies":    partHeader,
+	}
+	bodyReaders = []string{"ReadAll", "NewDecoder", "Decode", "Unmarshal", "Close"}
+	// Gomega matchers of net/http responses.
+	partMatchers = map[string]responsePart{
+		"HaveHTTPStatus":          partStatus,
+		"HaveHTTPBody":            partBody,
+		"HaveHTTPHeaderWithValue": partHeader,
+	}
+)
+
+// strength records which parts of the response fn asserts.
+func (a *analysis) strength(fn *ast.FuncDecl) {
+	asserted := assertedParts(fn.Body)
+	kinds := 0
+	for part, count := range map[responsePart]*int{
+		partStatus: &a.responseChecks.StatusAsserted,
+		partBody:   &a.responseChecks.BodyAsserted,
+		partHeader: &a.responseChecks.HeaderAsserted,
+	} {
+		if asserted&part != 0 {
+			*count++
+			kinds++
+		}
+	}
+	if kinds == 0 {
+		a.responseChecks.WeakTests = append(a.responseChecks.WeakTests, fn.Name.Name)
+	}
+	a.depth += 50 * float64(min(kinds, 2))
+}
+
+// finish completes the strength score from the depth of testFunctions.
+func (s *Strength) finish(depth float64, testFunctions int) {
+	if testFunctions > 0 {
+		s.Score = round(depth / float64(testFunctions))
+	}
+}
+
+// assertedParts walks body in order, tracking the variables that hold a
+// part of the response (resp.StatusCode, io.ReadAll(resp.Body), a value
+// decoded from it, ...), and returns the parts read by the arguments of
+// assertions and the operands of comparisons and switches.
+func assertedParts(body *ast.BlockStmt) responsePart {
+	vars := make(map[string]responsePart)
+	var asserted responsePart
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			var part responsePart
+			for _, rhs := range n.Rhs {
+				part |= partsOf(rhs, vars)
+			}
+			assign(vars, n.Lhs, part)
+		case *ast.ValueSpec:
+			var part responsePart
+			for _, value := range n.Values {
+				part |= partsOf(value, vars)
+			}
+			for _, name := range n.Names {
+				assign(vars, []ast.Expr{name}, part)
+			}
+		case *ast.RangeStmt:
+			assign(vars, []ast.Expr{n.Key, n.Value}, partsOf(n.X, vars))
+		case *ast.CallExpr:
+			name := callName(n)
+			if name == "Decode" || name == "Unmarshal" {
+				// json.Unmarshal(body, &v), json.NewDecoder(resp.Body).Decode(&v)
+				for _, arg := range n.Args {
+					if ref, ok := arg.(*ast.UnaryExpr); ok && ref.Op == token.AND {
+						assign(vars, []ast.Expr{ref.X}, partBody)
+					}
+				}
+			}
+			switch {
+			case isSchemaValidation(n):
+				// assertspec.Body(t, op, resp.StatusCode, body) picks the
+				// schema by status but only validates the body
+				asserted |= partBody
+			case isAssertion(n):
+				for _, arg := range n.Args {
+					asserted |= checkedParts(arg, vars)
+				}
+			}
+			asserted |= partMatchers[name]
+		case *ast.BinaryExpr:
+			switch n.Op {
+			case token.EQL, token.NEQ, token.LSS, token.GTR, token.LEQ, token.GEQ:
+				asserted |= checkedParts(n.X, vars) | checkedParts(n.Y, vars)
+			}
+		case *ast.SwitchStmt:
+			if n.Tag != nil {
+				asserted |= checkedParts(n.Tag, vars)
+			}
+		}
+		return true
+	})
+	return asserted
+}
+
+// assign records that the variables among lhs hold part; err never does.
+func assign(vars map[string]responsePart, lhs []ast.Expr, part responsePart) {
+	if part == 0 {
+		return
+	}
+	for _, expr := range lhs {
+		if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" && ident.Name != "err" {
+			vars[ident.Name] |= part
+		}
+	}
+}
+
+// partsOf returns the parts of the response expr reads.
+func partsOf(expr ast.Expr, vars map[string]responsePart) responsePart {
+	return readParts(expr, vars, false)
+}
+
+// checkedParts returns the parts of the response an assertion of expr
+// checks: those expr reads, except through calls that merely read the
+// body, such as require.NoError(t, json.NewDecoder(resp.Body).Decode(&v)).
+func checkedParts(expr ast.Expr, vars map[string]responsePart) responsePart {
+	return readParts(expr, vars, true)
+}
+
+func readParts(expr ast.Expr, vars map[string]responsePart, checked bool) responsePart {
+	var parts responsePart
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if checked && slices.Contains(bodyReaders, callName(n)) {
+				return false
+			}
+		case *ast.SelectorExpr:
+			if pkg, ok := n.X.(*ast.Ident); ok && pkg.Name == "http" {
+				return false
+			}
+			parts |= partSelectors[n.Sel.Name]
+		case *ast.Ident:
+			parts |= vars[n.Name]
+		}
+		return true
+	})
+	return parts
+}
+
+// isAssertion reports whether call asserts its arguments: testify and suite
+// helpers and Gomega matchers; t.Error and t.Fatal only report failures
+// found by the comparison around them.
+func isAssertion(call *ast.CallExpr) bool {
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		if ident, ok := sel.X.(*ast.Ident); ok && slices.Contains(assertionPackages, ident.Name) {
+			return true
+		}
+	}
+	return slices.Contains(gomegaMatchers, callName(call))
+}
+
+// schemaPackage is the package glens writes next to generated tests to
+// validate response bodies against the spec.
+const schemaPackage = "assertspec"
+
+// isSchemaValidation reports whether call validates a response body with
+// glens/assertspec.
+func isSchemaValidation(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == schemaPackage
+}
+
+func callName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	case *ast.Ident:
+		return fun.Name
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+// Package modelcatalog is the single list of the AI models glens knows by
+// name: their aliases, the provider and provider model behind each, context
+// windows, list prices and deprecations. The catalog is embedded YAML
+// (models.yaml), so the CLI, the API servers and cost calculations agree on
+// every model without calling a provider.
+package modelcatalog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed models.yaml
+var modelsYAML []byte
+
+// Providers are the providers a model may name
+var Providers = []string{"openai", "anthropic", "google", "mistral", "ollama", "mock"}
+
+// Price is the list price of a model in USD per million tokens
+type Price struct {
+	Input  float64 `yaml:"input" json:"input"`
+	Output float64 `yaml:"output" json:"output"`
+}
+
+// Cost returns the price in USD of input and output tokens
+func (p Price) Cost(input, output int) float64 {
+	return (float64(input)*p.Input + float64(output)*p.Output) / 1e6
+}
+
+// Blended returns the price per million tokens not split into input and
+// output, as counted by reports: the mean of both prices
+func (p Price) Blended() float64 {
+	return (p.Input + p.Output) / 2
+}
+
+// Model is a model glens creates by name
+type Model struct {
+	// ID is the name of the model in --ai-models; Aliases also name it
+	ID      string   `yaml:"id" json:"id"`
+	Aliases []string `yaml:"aliases" json:"aliases,omitempty"`
+	// Provider serves the model; Model is the provider's name of it
+	Provider string `yaml:"provider" json:"provider"`
+	Model    string `yaml:"model" json:"model,omitempty"`
+	// Configured models take ai_models.<provider>.model, or the model of
+	// their Ollama Server entry, Model being its default
+	Configured bool   `yaml:"configured" json:"configured,omitempty"`
+	Server     string `yaml:"server" json:"server,omitempty"`
+
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	// ContextWindow is the tokens the model reads, 0 when unknown
+	ContextWindow int `yaml:"context_window" json:"context_window,omitempty"`
+	// Price is nil for models without a list price
+	Price *Price `yaml:"price" json:"price,omitempty"`
+	// Deprecated says why the model should no longer be used, and instead
+	// what; empty for current models
+	Deprecated string `yaml:"deprecated" json:"deprecated,omitempty"`
+}
+
+// Local reports whether the model runs on this machine or on a self-hosted
+// Ollama server rather than at a cloud provider
+func (m Model) Local() bool {
+	return m.Provider == "ollama" || m.Provider == "mock"
+}
+
+// Catalog is a list of models indexed by name
+type Catalog struct {
+	models         []Model
+	names          map[string]int
+	contextWindows map[string]int
+}
+
+// catalogFile is the format of models.yaml
+type catalogFile struct {
+	Models         []Model        `yaml:"models"`
+	ContextWindows map[string]int `yaml:"context_windows"`
+}
+
+// Parse reads a catalog in the format of models.yaml. Every model needs an
+// ID and a known provider, and no two models share a name.
+func Parse(data []byte) (*Catalog, error) {
+	var file catalogFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid model catalog: %w", err)
+	}
+	c := &Catalog{
+		models:         file.Models,
+		names:          make(map[string]int),
+		contextWindows: make(map[string]int),
+	}
+	var errs []error
+	for i, m := range file.Models {
+		if m.ID == "" {
+			errs = append(errs, fmt.Errorf("models[%d]: id is required", i))
+			continue
+		}
+		if !slices.Contains(Providers, m.Provider) {
+			errs = append(errs, fmt.Errorf("model %s: unknown provider %q (use one of %s)", m.ID, m.Provider, strings.Join(Providers, ", ")))
+		}
+		for _, name := range append([]string{m.ID}, m.Aliases...) {
+			if j, ok := c.names[name]; ok {
+				errs = append(errs, fmt.Errorf("model %s: name %q is taken by %s", m.ID, name, file.Models[j].ID))
+				continue
+			}
+			c.names[name] = i
+		}
+		if m.Model != "" && m.ContextWindow > 0 {
+			c.contextWindows[baseModel(m.Model)] = m.ContextWindow
+		}
+	}
+	for model, window := range file.ContextWindows {
+		c.contextWindows[baseModel(model)] = window
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Default returns the catalog embedded in glens
+var Default = sync.OnceValue(func() *Catalog {
+	c, err := Parse(modelsYAML)
+	if err != nil {
+		panic(err)
+	}
+	return c
+})
+
+// Models lists the models in catalog order
+func (c *Catalog) Models() []Model {
+	models := make([]Model, len(c.models))
+	copy(models, c.models)
+	return models
+}
+
+// Lookup returns the model named name by its ID or an alias
+func (c *Catalog) Lookup(name string) (Model, bool) {
+	i, ok := c.names[name]
+	if !ok {
+		return Model{}, false
+	}
+	return c.models[i], true
+}
+
+// Names lists the IDs of the models that are not deprecated, for completion
+func (c *Catalog) Names() []string {
+	var names []string
+	for _, m := range c.models {
+		if m.Deprecated == "" {
+			names = append(names, m.ID)
+		}
+	}
+	return names
+}
+
+// Price returns the list price of the model named name
+func (c *Catalog) Price(name string) (Price, bool) {
+	m, ok := c.Lookup(name)
+	if !ok || m.Price == nil {
+		return Price{}, false
+	}
+	return *m.Price, true
+}
+
+// ContextWindow returns the context window in tokens of a provider model,
+// e.g. gpt-4o or codellama:7b-instruct, or 0 when it is not known. Ollama
+// tags and the ollama: prefix are ignored.
+func (c *Catalog) ContextWindow(model string) int {
+	return c.contextWindows[baseModel(model)]
+}
+
+// baseModel strips the ollama: prefix and the tag of an Ollama model
+func baseModel(model string) string {
+	model = strings.TrimPrefix(model, "ollama:")
+	if base, _, ok := strings.Cut(model, ":"); ok {
+		return base
+	}
+	return model
+}
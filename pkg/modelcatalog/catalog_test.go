@@ -0,0 +1,83 @@
+package modelcatalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	require.NotEmpty(t, c.Models())
+
+	m, ok := c.Lookup("gpt4o")
+	require.True(t, ok, "aliases name models")
+	assert.Equal(t, "gpt-4o", m.ID)
+	assert.Equal(t, "openai", m.Provider)
+	assert.False(t, m.Local())
+	assert.Equal(t, 128000, m.ContextWindow)
+
+	m, ok = c.Lookup("mistral-local")
+	require.True(t, ok)
+	assert.True(t, m.Local())
+
+	_, ok = c.Lookup("gpt-5-turbo-ultra")
+	assert.False(t, ok)
+
+	for _, m := range c.Models() {
+		assert.NotEmpty(t, m.Name, m.ID)
+		assert.True(t, m.Model != "" || m.Configured, "%s names no provider model", m.ID)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	c := Default()
+	m, ok := c.Lookup("claude-3-5-sonnet")
+	require.True(t, ok)
+	assert.Contains(t, m.Deprecated, "claude-sonnet-4")
+	assert.NotContains(t, c.Names(), "claude-3.5-sonnet", "deprecated models are not offered")
+	assert.Contains(t, c.Names(), "claude-sonnet-4")
+}
+
+func TestPrice(t *testing.T) {
+	c := Default()
+	price, ok := c.Price("gpt-4o")
+	require.True(t, ok)
+	assert.InDelta(t, 0.0125, price.Cost(1000, 1000), 1e-9)
+	assert.InDelta(t, 6.25, price.Blended(), 1e-9)
+
+	price, ok = c.Price("mistral-local")
+	require.True(t, ok, "local models are free")
+	assert.Zero(t, price.Cost(1e6, 1e6))
+
+	_, ok = c.Price("gemini-2.0-pro")
+	assert.False(t, ok, "models without a list price are unpriced")
+}
+
+func TestContextWindow(t *testing.T) {
+	c := Default()
+	assert.Equal(t, 128000, c.ContextWindow("gpt-4o"))
+	assert.Equal(t, 16384, c.ContextWindow("codellama:7b-instruct"), "Ollama tags are ignored")
+	assert.Equal(t, 131072, c.ContextWindow("ollama:llama3.1:70b"))
+	assert.Zero(t, c.ContextWindow("unknown"))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte(`
+models:
+  - id: a
+    provider: openai
+    aliases: [b]
+  - id: b
+    provider: cohere
+  - provider: mock
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `model b: unknown provider "cohere"`)
+	assert.Contains(t, err.Error(), `model b: name "b" is taken by a`)
+	assert.Contains(t, err.Error(), "models[2]: id is required")
+
+	_, err = Parse([]byte("models: {"))
+	assert.ErrorContains(t, err, "invalid model catalog")
+}
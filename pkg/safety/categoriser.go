@@ -1,6 +1,13 @@
+// Package safety classifies API endpoints by what calling them does: reads,
+// writes, mutations and deletions, and the risk of running generated tests
+// against them. The glens CLI, its servers and the standalone API share it,
+// so every one of them agrees on which endpoints are safe to test.
 package safety
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // Risk represents the risk level of an endpoint.
 type Risk string
@@ -12,6 +19,27 @@ const (
 	RiskHigh   Risk = "high"
 )
 
+// riskRank orders risk levels from least to most dangerous.
+var riskRank = map[Risk]int{RiskSafe: 0, RiskMedium: 1, RiskHigh: 2}
+
+// ParseRisk parses a risk level name. An empty name is RiskSafe.
+func ParseRisk(s string) (Risk, error) {
+	if s == "" {
+		return RiskSafe, nil
+	}
+	r := Risk(strings.ToLower(s))
+	if _, ok := riskRank[r]; !ok {
+		return "", fmt.Errorf("unknown risk level %q (use safe, medium or high)", s)
+	}
+	return r, nil
+}
+
+// Allows reports whether an endpoint of the given risk is within limit r.
+// An empty limit allows safe endpoints only.
+func (r Risk) Allows(risk Risk) bool {
+	return riskRank[risk] <= riskRank[r]
+}
+
 // Category represents the operational category of an endpoint.
 type Category string
 
@@ -82,6 +110,21 @@ func Categorise(method, path string, xSafe bool) EndpointCategory {
 	return ec
 }
 
+// WithRisk overrides the inferred risk with one the spec declares (e.g.
+// with x-glens-risk); an endpoint declared safe is read-only. An empty or
+// unknown declared risk keeps the inferred one.
+func (c EndpointCategory) WithRisk(declared string) EndpointCategory {
+	risk := Risk(strings.ToLower(declared))
+	if _, ok := riskRank[risk]; !ok {
+		return c
+	}
+	c.Risk = risk
+	if risk == RiskSafe {
+		c.Category = CategoryRead
+	}
+	return c
+}
+
 // CategoriseAll categorises a batch of endpoints.
 func CategoriseAll(endpoints []EndpointInput) []EndpointCategory {
 	results := make([]EndpointCategory, len(endpoints))
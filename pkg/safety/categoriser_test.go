@@ -114,3 +114,30 @@ func TestWarnings_empty(t *testing.T) {
 
 	assert.Empty(t, warnings)
 }
+
+func TestParseRisk(t *testing.T) {
+	for in, want := range map[string]Risk{"": RiskSafe, "safe": RiskSafe, "Medium": RiskMedium, "high": RiskHigh} {
+		got, err := ParseRisk(in)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseRisk("critical")
+	assert.ErrorContains(t, err, `unknown risk level "critical"`)
+}
+
+func TestRisk_Allows(t *testing.T) {
+	assert.True(t, Risk("").Allows(RiskSafe))
+	assert.False(t, Risk("").Allows(RiskMedium))
+	assert.True(t, RiskMedium.Allows(RiskMedium))
+	assert.False(t, RiskMedium.Allows(RiskHigh))
+	assert.True(t, RiskHigh.Allows(RiskHigh))
+}
+
+func TestEndpointCategory_WithRisk(t *testing.T) {
+	post := Categorise("POST", "/reports", false)
+	assert.Equal(t, post, post.WithRisk(""), "no declared risk keeps the inferred one")
+	assert.Equal(t, post, post.WithRisk("critical"), "unknown risks are ignored")
+	assert.Equal(t, EndpointCategory{Path: "/reports", Method: "POST", Category: CategoryWrite, Risk: RiskHigh}, post.WithRisk("High"))
+	assert.Equal(t, EndpointCategory{Path: "/reports", Method: "POST", Category: CategoryRead, Risk: RiskSafe}, post.WithRisk("safe"))
+}
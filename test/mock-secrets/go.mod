@@ -1,3 +1,5 @@
 module glens/test/mock-secrets
 
 go 1.25
+
+require golang.org/x/crypto v0.43.0
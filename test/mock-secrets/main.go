@@ -3,13 +3,19 @@ package main
 import (
 	"encoding/base64"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 )
 
+// stateKeyEnv holds the passphrase encrypting the state file, kept out of
+// the command line.
+const stateKeyEnv = "MOCK_SECRETS_STATE_KEY"
+
 // secretVersion holds a single version's payload.
 type secretVersion struct {
 	Data []byte
@@ -26,6 +32,8 @@ type secret struct {
 type store struct {
 	mu      sync.RWMutex
 	secrets map[string]*secret
+	// persist, when set, saves the store after every change.
+	persist *persistence
 }
 
 func newStore() *store {
@@ -33,18 +41,61 @@ func newStore() *store {
 }
 
 func main() {
+	port := flag.Int("port", 8088, "port to listen on")
+	stateFile := flag.String("state-file", "", "persist secrets across restarts in this file, encrypted with the passphrase in $"+stateKeyEnv)
+	seedFile := flag.String("seed", "", "JSON file of secrets to preload, mapping secret names to a value or a list of versions")
+	flag.Parse()
+
 	s := newStore()
+	if *stateFile != "" {
+		p, err := newPersistence(*stateFile, os.Getenv(stateKeyEnv))
+		if err != nil {
+			log.Fatalf("state file: %v (set $%s)", err, stateKeyEnv)
+		}
+		if err := p.load(s); err != nil {
+			log.Fatalf("state file: %v", err)
+		}
+		s.persist = p
+		log.Printf("restored %d secret(s) from %s", len(s.secrets), *stateFile)
+	}
+	if *seedFile != "" {
+		added, err := seed(s, *seedFile)
+		if err != nil {
+			log.Fatalf("seed: %v", err)
+		}
+		if s.persist != nil && added > 0 {
+			if err := s.persist.save(s); err != nil {
+				log.Fatalf("state file: %v", err)
+			}
+		}
+		log.Printf("seeded %d secret(s) from %s", added, *seedFile)
+	}
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /healthz", handleHealthz)
 	mux.HandleFunc("/v1/", s.handleV1)
 
-	log.Println("mock-secrets listening on :8088")
-	if err := http.ListenAndServe(":8088", mux); err != nil {
+	addr := fmt.Sprintf(":%d", *port)
+	log.Printf("mock-secrets listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
 
+// save persists the store, if persistence is enabled. The caller holds
+// s.mu.
+func (s *store) save() error {
+	if s.persist == nil {
+		return nil
+	}
+	if err := s.persist.save(s); err != nil {
+		log.Printf("failed to persist secrets: %v", err)
+		return err
+	}
+	return nil
+}
+
 func handleHealthz(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, "ok")
@@ -102,6 +153,11 @@ func (s *store) createSecret(w http.ResponseWriter, r *http.Request, path string
 	}
 
 	s.secrets[name] = &secret{Name: name}
+	if err := s.save(); err != nil {
+		delete(s.secrets, name)
+		http.Error(w, "failed to persist secret", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -148,6 +204,11 @@ func (s *store) addVersion(w http.ResponseWriter, r *http.Request, path string)
 
 	sec.Versions = append(sec.Versions, secretVersion{Data: data})
 	version := len(sec.Versions)
+	if err := s.save(); err != nil {
+		sec.Versions = sec.Versions[:version-1]
+		http.Error(w, "failed to persist secret version", http.StatusInternalServerError)
+		return
+	}
 	versionName := fmt.Sprintf("%s/versions/%d", name, version)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// secretName matches full secret names: projects/{project}/secrets/{secret}.
+var secretName = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+$`)
+
+// stateFormat is the version of the encrypted state file layout.
+const stateFormat = 2
+
+// scrypt parameters deriving the state key from the passphrase, the
+// interactive-login cost recommended by the scrypt package.
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	saltSize      = 16
+	stateKeyBytes = 32
+)
+
+// encryptedState is the on-disk state file: the JSON of persistedState,
+// sealed with AES-256-GCM under a key derived by scrypt from the
+// passphrase and Salt.
+type encryptedState struct {
+	Format     int    `json:"format"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// persistedState is the plaintext of the state file.
+type persistedState struct {
+	Secrets []persistedSecret `json:"secrets"`
+}
+
+// persistedSecret is a secret and the payloads of its versions, oldest first.
+type persistedSecret struct {
+	Name     string   `json:"name"`
+	Versions [][]byte `json:"versions"`
+}
+
+// persistence writes the store to an encrypted state file.
+type persistence struct {
+	path       string
+	passphrase string
+	// salt and aead are those of the state file, set by load or the first
+	// save so the key is derived once.
+	salt []byte
+	aead cipher.AEAD
+}
+
+// newPersistence persists to path, encrypting with a key derived from
+// passphrase.
+func newPersistence(path, passphrase string) (*persistence, error) {
+	if passphrase == "" {
+		return nil, errors.New("a passphrase is required to encrypt the state file")
+	}
+	return &persistence{path: path, passphrase: passphrase}, nil
+}
+
+// useSalt derives the key of salt from the passphrase.
+func (p *persistence) useSalt(salt []byte) error {
+	key, err := scrypt.Key([]byte(p.passphrase), salt, scryptN, scryptR, scryptP, stateKeyBytes)
+	if err != nil {
+		return fmt.Errorf("failed to derive state key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+	p.salt, p.aead = salt, aead
+	return nil
+}
+
+// load restores the secrets of the state file into s. A missing file is
+// an empty state.
+func (p *persistence) load(s *store) error {
+	raw, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var sealed encryptedState
+	if err := json.Unmarshal(raw, &sealed); err != nil {
+		return fmt.Errorf("invalid state file %s: %w", p.path, err)
+	}
+	if sealed.Format != stateFormat {
+		return fmt.Errorf("state file %s has unsupported format %d", p.path, sealed.Format)
+	}
+	if len(sealed.Salt) < saltSize {
+		return fmt.Errorf("state file %s has an invalid salt", p.path)
+	}
+	if err := p.useSalt(sealed.Salt); err != nil {
+		return err
+	}
+	if len(sealed.Nonce) != p.aead.NonceSize() {
+		return fmt.Errorf("state file %s has an invalid nonce", p.path)
+	}
+	plaintext, err := p.aead.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt state file %s: wrong passphrase or corrupted file", p.path)
+	}
+
+	var state persistedState
+	if err := json.Unmarshal(plaintext, &state); err != nil {
+		return fmt.Errorf("invalid state in %s: %w", p.path, err)
+	}
+	for _, persisted := range state.Secrets {
+		sec := &secret{Name: persisted.Name}
+		for _, data := range persisted.Versions {
+			sec.Versions = append(sec.Versions, secretVersion{Data: data})
+		}
+		s.secrets[persisted.Name] = sec
+	}
+	return nil
+}
+
+// save writes the secrets of s to the state file, replacing it atomically.
+// The caller holds s.mu.
+func (p *persistence) save(s *store) error {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var state persistedState
+	for _, name := range names {
+		persisted := persistedSecret{Name: name, Versions: [][]byte{}}
+		for _, version := range s.secrets[name].Versions {
+			persisted.Versions = append(persisted.Versions, version.Data)
+		}
+		state.Secrets = append(state.Secrets, persisted)
+	}
+	plaintext, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	if p.aead == nil {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		if err := p.useSalt(salt); err != nil {
+			return err
+		}
+	}
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	raw, err := json.Marshal(encryptedState{
+		Format:     stateFormat,
+		Salt:       p.salt,
+		Nonce:      nonce,
+		Ciphertext: p.aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode state file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), ".mock-secrets-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// seed preloads the secrets of a JSON seed file into s. The file maps full
+// secret names to a value or a list of values, one per version:
+//
+//	{
+//	  "projects/dev/secrets/openai-api-key": "sk-test",
+//	  "projects/dev/secrets/db-password": ["old", "current"]
+//	}
+//
+// Secrets that already exist, e.g. restored from the state file, are kept
+// as they are. It returns how many secrets were added.
+func seed(s *store, path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return 0, fmt.Errorf("invalid seed file %s: %w", path, err)
+	}
+
+	added := 0
+	for name, value := range entries {
+		if !secretName.MatchString(name) {
+			return 0, fmt.Errorf("seed file %s: %q is not a secret name like projects/{project}/secrets/{secret}", path, name)
+		}
+		var versions []string
+		if err := json.Unmarshal(value, &versions); err != nil {
+			var single string
+			if err := json.Unmarshal(value, &single); err != nil {
+				return 0, fmt.Errorf("seed file %s: %s must be a string or a list of strings", path, name)
+			}
+			versions = []string{single}
+		}
+		if _, exists := s.secrets[name]; exists {
+			continue
+		}
+		sec := &secret{Name: name}
+		for _, v := range versions {
+			sec.Versions = append(sec.Versions, secretVersion{Data: []byte(v)})
+		}
+		s.secrets[name] = sec
+		added++
+	}
+	return added, nil
+}
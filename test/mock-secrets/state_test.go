@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestPersistence(t *testing.T, path, passphrase string) *persistence {
+	t.Helper()
+	p, err := newPersistence(path, passphrase)
+	if err != nil {
+		t.Fatalf("newPersistence: %v", err)
+	}
+	return p
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistenceRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := newStore()
+	s.secrets["projects/p/secrets/a"] = &secret{
+		Name:     "projects/p/secrets/a",
+		Versions: []secretVersion{{Data: []byte("old")}, {Data: []byte("current")}},
+	}
+	s.secrets["projects/p/secrets/empty"] = &secret{Name: "projects/p/secrets/empty"}
+	if err := newTestPersistence(t, path, "passphrase").save(s); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("current")) {
+		t.Error("state file holds a plaintext payload")
+	}
+
+	restored := newStore()
+	p := newTestPersistence(t, path, "passphrase")
+	if err := p.load(restored); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(restored.secrets) != 2 {
+		t.Fatalf("restored %d secrets, want 2", len(restored.secrets))
+	}
+	versions := restored.secrets["projects/p/secrets/a"].Versions
+	if len(versions) != 2 || string(versions[0].Data) != "old" || string(versions[1].Data) != "current" {
+		t.Errorf("versions = %+v", versions)
+	}
+
+	// Saving again keeps the salt of the loaded file
+	var before encryptedState
+	if err := json.Unmarshal(raw, &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.save(restored); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if !bytes.Equal(p.salt, before.Salt) {
+		t.Error("save after load changed the salt")
+	}
+}
+
+func TestPersistenceSaltIsRandom(t *testing.T) {
+	dir := t.TempDir()
+	salts := make([][]byte, 2)
+	for i := range salts {
+		p := newTestPersistence(t, filepath.Join(dir, "state.json"+string(rune('a'+i))), "passphrase")
+		if err := p.save(newStore()); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		if len(p.salt) != saltSize {
+			t.Fatalf("salt has %d bytes, want %d", len(p.salt), saltSize)
+		}
+		salts[i] = p.salt
+	}
+	if bytes.Equal(salts[0], salts[1]) {
+		t.Error("two state files share a salt")
+	}
+}
+
+func TestPersistenceWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := newStore()
+	s.secrets["projects/p/secrets/a"] = &secret{Name: "projects/p/secrets/a", Versions: []secretVersion{{Data: []byte("x")}}}
+	if err := newTestPersistence(t, path, "right").save(s); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	err := newTestPersistence(t, path, "wrong").load(newStore())
+	if err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("load = %v, want a wrong passphrase error", err)
+	}
+}
+
+func TestPersistenceMissingFileAndPassphrase(t *testing.T) {
+	if _, err := newPersistence("state.json", ""); err == nil {
+		t.Error("newPersistence accepted an empty passphrase")
+	}
+
+	s := newStore()
+	if err := newTestPersistence(t, filepath.Join(t.TempDir(), "missing.json"), "p").load(s); err != nil {
+		t.Errorf("load of a missing file = %v", err)
+	}
+	if len(s.secrets) != 0 {
+		t.Errorf("restored %d secrets from a missing file", len(s.secrets))
+	}
+}
+
+func TestSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.json")
+	writeFile(t, path, `{
+		"projects/dev/secrets/key": "sk-test",
+		"projects/dev/secrets/db": ["old", "current"],
+		"projects/dev/secrets/kept": "seeded"
+	}`)
+	s := newStore()
+	s.secrets["projects/dev/secrets/kept"] = &secret{Name: "projects/dev/secrets/kept", Versions: []secretVersion{{Data: []byte("restored")}}}
+
+	added, err := seed(s, path)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("added = %d, want 2", added)
+	}
+	if v := s.secrets["projects/dev/secrets/key"].Versions; len(v) != 1 || string(v[0].Data) != "sk-test" {
+		t.Errorf("key versions = %+v", v)
+	}
+	if v := s.secrets["projects/dev/secrets/db"].Versions; len(v) != 2 || string(v[1].Data) != "current" {
+		t.Errorf("db versions = %+v", v)
+	}
+	if v := s.secrets["projects/dev/secrets/kept"].Versions; string(v[0].Data) != "restored" {
+		t.Errorf("seed replaced an existing secret: %+v", v)
+	}
+}
+
+func TestSeedRejectsInvalidEntries(t *testing.T) {
+	for name, content := range map[string]string{
+		"bad name":  `{"openai-api-key": "sk"}`,
+		"bad value": `{"projects/p/secrets/a": 42}`,
+		"bad json":  `[`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "seed.json")
+			writeFile(t, path, content)
+			if _, err := seed(newStore(), path); err == nil {
+				t.Error("seed accepted an invalid file")
+			}
+		})
+	}
+}